@@ -0,0 +1,380 @@
+// Package anomaly keeps rolling statistics of payments sent to every destination account and
+// flags payments deviating from them before they are submitted
+package anomaly
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// PolicyLog logs flagged payments and sends them
+	PolicyLog = "log"
+	// PolicyApprove sends flagged payments only when they are approved by an operator
+	PolicyApprove = "approve"
+	// PolicyBlock rejects flagged payments
+	PolicyBlock = "block"
+)
+
+const (
+	// FlagAmountZScore flags an amount more than max_z_score standard deviations above the mean
+	FlagAmountZScore = "amount_z_score"
+	// FlagAmountPercentile flags an amount above max_percentile of previous amounts
+	FlagAmountPercentile = "amount_percentile"
+	// FlagNewAsset flags the first payment of an asset to a destination with previous payments
+	FlagNewAsset = "new_asset"
+	// FlagUnusualHour flags a payment at an hour of day with no previous payments to the
+	// destination
+	FlagUnusualHour = "unusual_hour"
+)
+
+// DefaultMinPayments is the number of previous payments needed to flag a payment when
+// Settings.MinPayments is 0
+const DefaultMinPayments = 20
+
+// bucketsPerOctave is the number of amount histogram buckets between an amount and its double,
+// percentiles are estimated within 19%
+const bucketsPerOctave = 4
+
+// Settings are thresholds of a Detector
+type Settings struct {
+	// MinPayments is the number of previous payments to a destination (in the asset for amount
+	// flags) needed to flag a payment
+	MinPayments int64
+	// MaxZScore flags amounts more than MaxZScore standard deviations above the mean, 0
+	// disables the flag
+	MaxZScore float64
+	// MaxPercentile flags amounts above the percentile (ex. 99) of previous amounts, 0 disables
+	// the flag
+	MaxPercentile float64
+	// Policy is the action taken for flagged payments
+	Policy string
+}
+
+// Validate returns an error when settings are invalid
+func (s Settings) Validate() error {
+	switch s.Policy {
+	case PolicyLog, PolicyApprove, PolicyBlock:
+	default:
+		return errors.New("policy must be one of log, approve, block")
+	}
+	if s.MinPayments < 0 {
+		return errors.New("min_payments cannot be negative")
+	}
+	if s.MaxZScore < 0 {
+		return errors.New("max_z_score cannot be negative")
+	}
+	if s.MaxPercentile < 0 || s.MaxPercentile >= 100 {
+		return errors.New("max_percentile must be between 0 and 100")
+	}
+	return nil
+}
+
+// Payment is a payment to a destination account
+type Payment struct {
+	Destination string
+	// AssetCode is `XLM` for XLM
+	AssetCode   string
+	AssetIssuer string
+	Amount      xdr.Int64
+	Time        time.Time
+	// Generation is the generation of the destination (see generation.Tracker), statistics of a
+	// recreated account start empty. 0 is generation 1.
+	Generation int64
+}
+
+// Report contains flags of a payment and statistics of previous payments that triggered them
+type Report struct {
+	Flags  []string `json:"flags"`
+	Policy string   `json:"policy"`
+	// Approved is true when an operator approved the payment (`approve` policy)
+	Approved bool     `json:"approved,omitempty"`
+	Stats    Snapshot `json:"stats"`
+}
+
+// Snapshot contains statistics of payments sent to a destination before a payment
+type Snapshot struct {
+	Amount string `json:"amount"`
+	// Payments is the number of payments to the destination in all assets
+	Payments int64 `json:"payments"`
+	// Assets are assets sent to the destination (`code:issuer`, `XLM`)
+	Assets []string `json:"assets"`
+	// AssetPayments is the number of payments to the destination in the asset of the payment
+	AssetPayments int64    `json:"asset_payments"`
+	MeanAmount    string   `json:"mean_amount,omitempty"`
+	StdDevAmount  string   `json:"stddev_amount,omitempty"`
+	ZScore        *float64 `json:"z_score,omitempty"`
+	// PercentileAmount is an estimate of max_percentile of previous amounts
+	PercentileAmount string `json:"percentile_amount,omitempty"`
+	// HourPayments is the number of payments to the destination at the UTC hour of the payment
+	HourPayments int64 `json:"hour_payments"`
+}
+
+// Detector flags payments deviating from statistics of payments previously sent to their
+// destinations
+type Detector struct {
+	settings      Settings
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	// mutex serializes updates of statistics, concurrent updates of a destination would be lost
+	mutex sync.Mutex
+}
+
+// NewDetector creates a Detector of validated settings
+func NewDetector(settings Settings, repository db.RepositoryInterface, entityManager db.EntityManagerInterface) *Detector {
+	if settings.MinPayments == 0 {
+		settings.MinPayments = DefaultMinPayments
+	}
+	return &Detector{
+		settings:      settings,
+		repository:    repository,
+		entityManager: entityManager,
+	}
+}
+
+// Enabled returns false for nil and zero Detectors, they do not check payments
+func (d *Detector) Enabled() bool {
+	return d != nil && d.settings.Policy != ""
+}
+
+// Policy returns the action taken for flagged payments
+func (d *Detector) Policy() string {
+	return d.settings.Policy
+}
+
+// Check returns a report of a payment deviating from statistics of its destination, nil when
+// the payment is not flagged. Only statistics of the destination are loaded.
+func (d *Detector) Check(payment Payment) (*Report, error) {
+	stats, err := d.destinationStats(payment)
+	if err != nil {
+		return nil, err
+	}
+
+	hour := payment.Time.UTC().Hour()
+	snapshot := Snapshot{Amount: amount.String(payment.Amount), Assets: []string{}}
+	var assetStats *entities.CounterpartyStats
+	for _, s := range stats {
+		snapshot.Payments += s.Count
+		snapshot.Assets = append(snapshot.Assets, assetName(s.AssetCode, s.AssetIssuer))
+		hours, err := decodeHours(s.HourHistogram)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.HourPayments += hours[hour]
+		if s.AssetCode == payment.AssetCode && s.AssetIssuer == payment.AssetIssuer {
+			assetStats = s
+		}
+	}
+
+	var flags []string
+	if snapshot.Payments >= d.settings.MinPayments {
+		if assetStats == nil {
+			flags = append(flags, FlagNewAsset)
+		}
+		if snapshot.HourPayments == 0 {
+			flags = append(flags, FlagUnusualHour)
+		}
+	}
+
+	if assetStats != nil {
+		snapshot.AssetPayments = assetStats.Count
+		snapshot.MeanAmount = formatStroops(assetStats.MeanAmount)
+		if assetStats.Count > 1 {
+			stdDev := math.Sqrt(assetStats.AmountM2 / float64(assetStats.Count-1))
+			snapshot.StdDevAmount = formatStroops(stdDev)
+			if stdDev > 0 {
+				zScore := (float64(payment.Amount) - assetStats.MeanAmount) / stdDev
+				snapshot.ZScore = &zScore
+			}
+		}
+
+		amounts, err := decodeAmounts(assetStats.AmountHistogram)
+		if err != nil {
+			return nil, err
+		}
+		percentileBucket, ok := percentile(amounts, assetStats.Count, d.settings.MaxPercentile)
+		if d.settings.MaxPercentile > 0 && ok {
+			snapshot.PercentileAmount = formatStroops(bucketUpperBound(percentileBucket))
+		}
+
+		if assetStats.Count >= d.settings.MinPayments {
+			if d.settings.MaxZScore > 0 && snapshot.ZScore != nil && *snapshot.ZScore > d.settings.MaxZScore {
+				flags = append(flags, FlagAmountZScore)
+			}
+			if d.settings.MaxPercentile > 0 && ok && Bucket(payment.Amount) > percentileBucket {
+				flags = append(flags, FlagAmountPercentile)
+			}
+		}
+	}
+
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	return &Report{Flags: flags, Policy: d.settings.Policy, Stats: snapshot}, nil
+}
+
+// Record adds a successful payment to statistics of its destination and asset
+func (d *Detector) Record(payment Payment) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	stats, err := d.destinationStats(payment)
+	if err != nil {
+		return err
+	}
+
+	var assetStats *entities.CounterpartyStats
+	for _, s := range stats {
+		if s.AssetCode == payment.AssetCode && s.AssetIssuer == payment.AssetIssuer {
+			assetStats = s
+		}
+	}
+	if assetStats == nil {
+		assetStats = &entities.CounterpartyStats{
+			Destination: payment.Destination,
+			Generation:  payment.generation(),
+			AssetCode:   payment.AssetCode,
+			AssetIssuer: payment.AssetIssuer,
+		}
+	}
+
+	amounts, err := decodeAmounts(assetStats.AmountHistogram)
+	if err != nil {
+		return err
+	}
+	hours, err := decodeHours(assetStats.HourHistogram)
+	if err != nil {
+		return err
+	}
+
+	// Welford's algorithm
+	value := float64(payment.Amount)
+	assetStats.Count++
+	delta := value - assetStats.MeanAmount
+	assetStats.MeanAmount += delta / float64(assetStats.Count)
+	assetStats.AmountM2 += delta * (value - assetStats.MeanAmount)
+
+	amounts[strconv.Itoa(Bucket(payment.Amount))]++
+	hours[payment.Time.UTC().Hour()]++
+
+	histogram, err := json.Marshal(amounts)
+	if err != nil {
+		return err
+	}
+	assetStats.AmountHistogram = string(histogram)
+	histogram, err = json.Marshal(hours)
+	if err != nil {
+		return err
+	}
+	assetStats.HourHistogram = string(histogram)
+	assetStats.UpdatedAt = utc.New(payment.Time)
+
+	return d.entityManager.Persist(assetStats)
+}
+
+// destinationStats returns statistics of the destination generation of a payment
+func (d *Detector) destinationStats(payment Payment) ([]*entities.CounterpartyStats, error) {
+	stats, err := d.repository.GetCounterpartyStats(payment.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	generationStats := []*entities.CounterpartyStats{}
+	for _, s := range stats {
+		if s.Generation == payment.generation() {
+			generationStats = append(generationStats, s)
+		}
+	}
+	return generationStats, nil
+}
+
+func (p Payment) generation() int64 {
+	if p.Generation == 0 {
+		return 1
+	}
+	return p.Generation
+}
+
+// Bucket returns the amount histogram bucket of an amount in stroops
+func Bucket(value xdr.Int64) int {
+	if value < 1 {
+		return 0
+	}
+	return int(math.Floor(bucketsPerOctave * math.Log2(float64(value))))
+}
+
+// bucketUpperBound returns the smallest amount in stroops above a bucket
+func bucketUpperBound(bucket int) float64 {
+	return math.Pow(2, float64(bucket+1)/bucketsPerOctave)
+}
+
+// percentile returns the bucket of the p-th percentile of count amounts, false when there are
+// no amounts
+func percentile(amounts map[string]int64, count int64, p float64) (int, bool) {
+	buckets := make([]int, 0, len(amounts))
+	for key := range amounts {
+		bucket, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		buckets = append(buckets, bucket)
+	}
+	if len(buckets) == 0 {
+		return 0, false
+	}
+	sort.Ints(buckets)
+
+	rank := int64(math.Ceil(p / 100 * float64(count)))
+	var cumulative int64
+	for _, bucket := range buckets {
+		cumulative += amounts[strconv.Itoa(bucket)]
+		if cumulative >= rank {
+			return bucket, true
+		}
+	}
+	return buckets[len(buckets)-1], true
+}
+
+func decodeAmounts(histogram string) (map[string]int64, error) {
+	amounts := map[string]int64{}
+	if histogram == "" {
+		return amounts, nil
+	}
+	err := json.Unmarshal([]byte(histogram), &amounts)
+	return amounts, err
+}
+
+func decodeHours(histogram string) ([]int64, error) {
+	hours := make([]int64, 24)
+	if histogram == "" {
+		return hours, nil
+	}
+	err := json.Unmarshal([]byte(histogram), &hours)
+	if err == nil && len(hours) != 24 {
+		err = errors.New("hour histogram must have 24 hours")
+	}
+	return hours, err
+}
+
+// formatStroops returns an amount in stroops rounded down to a stroop
+func formatStroops(value float64) string {
+	return amount.String(xdr.Int64(math.Floor(value)))
+}
+
+func assetName(code, issuer string) string {
+	if issuer == "" {
+		return code
+	}
+	return code + ":" + issuer
+}
@@ -0,0 +1,129 @@
+package anomaly
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-anomaly")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+	repository := db.NewRepository(driver)
+
+	detector := NewDetector(Settings{MinPayments: 20, MaxZScore: 4, MaxPercentile: 99, Policy: PolicyApprove}, repository, db.NewEntityManager(driver))
+	destination := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	morning := time.Date(2018, 3, 1, 10, 15, 0, 0, time.UTC)
+	payment := func(code string, units int64, at time.Time) Payment {
+		return Payment{Destination: destination, AssetCode: code, AssetIssuer: issuer, Amount: xdr.Int64(units * 1e7), Time: at}
+	}
+
+	t.Run("payments are not flagged without enough statistics", func(t *testing.T) {
+		report, err := detector.Check(payment("USD", 1000000, morning))
+		require.NoError(t, err)
+		assert.Nil(t, report)
+	})
+
+	// 90, 95, 100, 105, 110 USD every morning
+	for i := 0; i < 50; i++ {
+		require.NoError(t, detector.Record(payment("USD", int64(90+5*(i%5)), morning.AddDate(0, 0, i))))
+	}
+
+	t.Run("statistics are updated incrementally", func(t *testing.T) {
+		stats, err := repository.GetCounterpartyStats(destination)
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		assert.Equal(t, int64(50), stats[0].Count)
+		assert.InDelta(t, 100*1e7, stats[0].MeanAmount, 1)
+	})
+
+	t.Run("usual payment is not flagged", func(t *testing.T) {
+		report, err := detector.Check(payment("USD", 105, morning))
+		require.NoError(t, err)
+		assert.Nil(t, report)
+	})
+
+	t.Run("large amount is flagged", func(t *testing.T) {
+		report, err := detector.Check(payment("USD", 10000, morning))
+		require.NoError(t, err)
+		require.NotNil(t, report)
+		assert.Equal(t, []string{FlagAmountZScore, FlagAmountPercentile}, report.Flags)
+		assert.Equal(t, PolicyApprove, report.Policy)
+		assert.Equal(t, int64(50), report.Stats.Payments)
+		assert.Equal(t, int64(50), report.Stats.AssetPayments)
+		assert.Equal(t, "100.0000000", report.Stats.MeanAmount)
+		assert.Equal(t, []string{"USD:" + issuer}, report.Stats.Assets)
+		require.NotNil(t, report.Stats.ZScore)
+		assert.True(t, *report.Stats.ZScore > 1000)
+	})
+
+	t.Run("new asset is flagged", func(t *testing.T) {
+		report, err := detector.Check(payment("EUR", 100, morning))
+		require.NoError(t, err)
+		require.NotNil(t, report)
+		assert.Equal(t, []string{FlagNewAsset}, report.Flags)
+	})
+
+	t.Run("unusual hour is flagged", func(t *testing.T) {
+		report, err := detector.Check(payment("USD", 100, morning.Add(-7*time.Hour)))
+		require.NoError(t, err)
+		require.NotNil(t, report)
+		assert.Equal(t, []string{FlagUnusualHour}, report.Flags)
+		assert.Equal(t, int64(0), report.Stats.HourPayments)
+	})
+
+	t.Run("other assets have separate statistics", func(t *testing.T) {
+		require.NoError(t, detector.Record(payment("EUR", 100, morning)))
+		stats, err := repository.GetCounterpartyStats(destination)
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		assert.Equal(t, int64(50), stats[0].Count)
+		assert.Equal(t, int64(1), stats[1].Count)
+	})
+
+	t.Run("recreated destination has separate statistics", func(t *testing.T) {
+		recreated := payment("USD", 10000, morning)
+		recreated.Generation = 2
+		report, err := detector.Check(recreated)
+		require.NoError(t, err)
+		assert.Nil(t, report)
+
+		require.NoError(t, detector.Record(recreated))
+		stats, err := repository.GetCounterpartyStats(destination)
+		require.NoError(t, err)
+		require.Len(t, stats, 3)
+		assert.Equal(t, int64(2), stats[2].Generation)
+		assert.Equal(t, int64(1), stats[2].Count)
+		assert.Equal(t, int64(50), stats[0].Count)
+	})
+}
+
+func TestSettingsValidate(t *testing.T) {
+	assert.NoError(t, Settings{Policy: PolicyLog}.Validate())
+	assert.EqualError(t, Settings{}.Validate(), "policy must be one of log, approve, block")
+	assert.EqualError(t, Settings{Policy: PolicyBlock, MaxZScore: -1}.Validate(), "max_z_score cannot be negative")
+	assert.EqualError(t, Settings{Policy: PolicyBlock, MaxPercentile: 100}.Validate(), "max_percentile must be between 0 and 100")
+}
+
+func TestBucket(t *testing.T) {
+	assert.Equal(t, 0, Bucket(0))
+	assert.Equal(t, 0, Bucket(1))
+	assert.Equal(t, 4, Bucket(2))
+	assert.Equal(t, 40, Bucket(1024))
+	assert.True(t, bucketUpperBound(Bucket(1000)) > 1000)
+}
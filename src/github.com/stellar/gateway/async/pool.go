@@ -0,0 +1,98 @@
+// Package async bounds submissions of payments sent with `async=true`. They are answered before
+// their transactions are submitted, so the pool limits how many transactions are submitted at the
+// same time and submits transactions of the same source account one at a time: a transaction is
+// not submitted while an earlier one with the same sequence number can still be included.
+package async
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultWorkers is the number of transactions submitted at the same time when it's not configured
+const DefaultWorkers = 16
+
+// Pool is a pool of submission workers
+type Pool struct {
+	workers chan struct{}
+
+	mutex   sync.Mutex
+	sources map[string]*source
+}
+
+// source serializes submissions of a source account, it's dropped when no submission uses it
+type source struct {
+	lock chan struct{}
+	refs int
+}
+
+// NewPool creates a pool submitting at most workers transactions at the same time
+// (DefaultWorkers when 0)
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	return &Pool{workers: make(chan struct{}, workers), sources: map[string]*source{}}
+}
+
+// Size returns the number of workers
+func (p *Pool) Size() int {
+	return cap(p.workers)
+}
+
+// Busy returns the number of transactions being submitted
+func (p *Pool) Busy() int {
+	return len(p.workers)
+}
+
+// Acquire waits until no other transaction of account is submitted and a worker is free. release
+// must be called once the submission is finished, ctx.Err() is returned when ctx is done before.
+// The account is locked first so payments of a busy account don't hold workers.
+func (p *Pool) Acquire(ctx context.Context, account string) (release func(), err error) {
+	s := p.ref(account)
+	select {
+	case s.lock <- struct{}{}:
+	case <-ctx.Done():
+		p.unref(account)
+		return nil, ctx.Err()
+	}
+
+	select {
+	case p.workers <- struct{}{}:
+	case <-ctx.Done():
+		<-s.lock
+		p.unref(account)
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-p.workers
+			<-s.lock
+			p.unref(account)
+		})
+	}, nil
+}
+
+func (p *Pool) ref(account string) *source {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	s, ok := p.sources[account]
+	if !ok {
+		s = &source{lock: make(chan struct{}, 1)}
+		p.sources[account] = s
+	}
+	s.refs++
+	return s
+}
+
+func (p *Pool) unref(account string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	s := p.sources[account]
+	s.refs--
+	if s.refs == 0 {
+		delete(p.sources, account)
+	}
+}
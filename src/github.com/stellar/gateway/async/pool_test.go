@@ -0,0 +1,72 @@
+package async
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolAcquire(t *testing.T) {
+	pool := NewPool(2)
+	assert.Equal(t, 2, pool.Size())
+
+	first, err := pool.Acquire(context.Background(), "GA")
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Busy())
+
+	// Another account gets the second worker
+	other, err := pool.Acquire(context.Background(), "GB")
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.Busy())
+	other()
+	other()
+	assert.Equal(t, 1, pool.Busy())
+
+	// The same account waits for the first submission
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Acquire(ctx, "GA")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	acquired := make(chan func())
+	go func() {
+		release, err := pool.Acquire(context.Background(), "GA")
+		assert.NoError(t, err)
+		acquired <- release
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("account acquired twice")
+	case <-time.After(10 * time.Millisecond):
+	}
+	first()
+	second := <-acquired
+	assert.Equal(t, 1, pool.Busy())
+	second()
+
+	assert.Equal(t, 0, pool.Busy())
+	assert.Empty(t, pool.sources)
+}
+
+func TestPoolWorkers(t *testing.T) {
+	pool := NewPool(1)
+	release, err := pool.Acquire(context.Background(), "GA")
+	require.NoError(t, err)
+
+	// A free account waits for a worker, its lock is released when it gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Acquire(ctx, "GB")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	release()
+
+	release, err = pool.Acquire(context.Background(), "GB")
+	require.NoError(t, err)
+	release()
+	assert.Empty(t, pool.sources)
+
+	assert.Equal(t, DefaultWorkers, NewPool(0).Size())
+}
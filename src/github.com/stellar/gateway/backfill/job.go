@@ -0,0 +1,281 @@
+// Package backfill ingests historical payments received before the bridge server was started (or
+// before the listener cursor) through the payment listener pipeline
+package backfill
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/support/errors"
+)
+
+const (
+	// DefaultPageLimit is a number of payments loaded from Horizon in a single request
+	DefaultPageLimit = 200
+	// DefaultMinInterval is a minimum time between Horizon requests. It matches the default
+	// (3600 requests per hour) rate limit of Horizon.
+	DefaultMinInterval = time.Second
+	// rateLimitedWait is used when Horizon responds with 429 without Retry-After
+	rateLimitedWait = 10 * time.Second
+)
+
+// PaymentProcessor processes backfilled payments, implemented by listener.PaymentListener
+type PaymentProcessor interface {
+	BackfillPayment(payment horizon.PaymentResponse) error
+}
+
+// Progress is a state of a backfill job returned by /admin/backfill endpoint
+type Progress struct {
+	AccountID  string `json:"account_id"`
+	FromLedger uint32 `json:"from_ledger"`
+	Running    bool   `json:"running"`
+	// Processed is a number of payments processed by this run of the job
+	Processed     int64     `json:"processed"`
+	Cursor        string    `json:"cursor"`
+	CurrentLedger uint32    `json:"current_ledger"`
+	TargetLedger  uint32    `json:"target_ledger"`
+	StartedAt     utc.Time  `json:"started_at"`
+	FinishedAt    *utc.Time `json:"finished_at,omitempty"`
+	// ETA is estimated from the pace of ledgers processed so far
+	ETA   *utc.Time `json:"eta,omitempty"`
+	Error string    `json:"error,omitempty"`
+}
+
+// Job pages through Horizon payments of an account starting at a given ledger. The paging token of
+// the last processed payment is saved in BackfillCursor table after every page so a job started
+// again continues where the previous one stopped.
+type Job struct {
+	AccountID  string
+	FromLedger uint32
+	PageLimit  int
+	// MinInterval is a minimum time between Horizon requests. Interval is increased when Horizon
+	// rate limit headers show fewer remaining requests.
+	MinInterval time.Duration
+
+	horizon       horizon.HorizonInterface
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	processor     PaymentProcessor
+	log           *logrus.Entry
+	now           func() time.Time
+	sleep         func(time.Duration)
+
+	mutex    sync.RWMutex
+	progress Progress
+}
+
+// NewJob creates a new Job
+func NewJob(
+	accountID string,
+	fromLedger uint32,
+	horizon horizon.HorizonInterface,
+	repository db.RepositoryInterface,
+	entityManager db.EntityManagerInterface,
+	processor PaymentProcessor,
+) *Job {
+	return &Job{
+		AccountID:     accountID,
+		FromLedger:    fromLedger,
+		PageLimit:     DefaultPageLimit,
+		MinInterval:   DefaultMinInterval,
+		horizon:       horizon,
+		repository:    repository,
+		entityManager: entityManager,
+		processor:     processor,
+		log: logrus.WithFields(logrus.Fields{
+			"service":             "Backfill",
+			logging.CategoryField: logging.CategoryListener,
+			"account_id":          accountID,
+		}),
+		now:   time.Now,
+		sleep: time.Sleep,
+		progress: Progress{
+			AccountID:  accountID,
+			FromLedger: fromLedger,
+		},
+	}
+}
+
+// Progress returns the current state of the job, it's safe to call while Run is running
+func (j *Job) Progress() Progress {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.progress
+}
+
+// Run processes payments until there are no more payments in Horizon or an error occurs
+func (j *Job) Run() (err error) {
+	j.updateProgress(func(p *Progress) {
+		p.Running = true
+		p.StartedAt = utc.New(j.now())
+	})
+	defer func() {
+		finishedAt := utc.New(j.now())
+		j.updateProgress(func(p *Progress) {
+			p.Running = false
+			p.FinishedAt = &finishedAt
+			p.ETA = nil
+			if err != nil {
+				p.Error = err.Error()
+			}
+		})
+	}()
+
+	savedCursor, err := j.repository.GetBackfillCursor(j.AccountID)
+	if err != nil {
+		return errors.Wrap(err, "Error loading backfill cursor")
+	}
+
+	cursor := horizon.PagingTokenFromLedger(j.FromLedger)
+	if savedCursor != nil {
+		savedLedger, err := horizon.LedgerFromPagingToken(savedCursor.PagingToken)
+		if err != nil {
+			return errors.Wrap(err, "Invalid backfill cursor")
+		}
+		// Backfill of earlier ledgers starts over, the listener skips already processed payments
+		if savedLedger >= j.FromLedger {
+			cursor = savedCursor.PagingToken
+		}
+	}
+
+	targetLedger, err := j.horizon.LoadLatestLedger()
+	if err != nil {
+		return errors.Wrap(err, "Error loading latest ledger")
+	}
+
+	startLedger, _ := horizon.LedgerFromPagingToken(cursor)
+	startedAt := j.now()
+	j.updateProgress(func(p *Progress) {
+		p.Cursor = cursor
+		p.CurrentLedger = startLedger
+		p.TargetLedger = targetLedger
+	})
+
+	j.log.WithFields(logrus.Fields{"cursor": cursor, "target_ledger": targetLedger}).Info("Started backfill")
+
+	interval := j.MinInterval
+	var lastRequest time.Time
+	for {
+		if wait := interval - j.now().Sub(lastRequest); !lastRequest.IsZero() && wait > 0 {
+			j.sleep(wait)
+		}
+		lastRequest = j.now()
+
+		page, err := j.horizon.LoadPayments(j.AccountID, cursor, j.PageLimit)
+		if rateLimited, ok := err.(*horizon.RateLimitedError); ok {
+			wait := rateLimited.RetryAfter
+			if wait <= 0 {
+				wait = rateLimitedWait
+			}
+			j.log.WithFields(logrus.Fields{"wait": wait}).Warn("Horizon rate limit exceeded, waiting")
+			j.sleep(wait)
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "Error loading payments")
+		}
+
+		records := page.Embedded.Records
+		for _, payment := range records {
+			err = j.processor.BackfillPayment(payment)
+			if err != nil {
+				j.saveCursor(cursor)
+				return errors.Wrap(err, "Error processing payment "+payment.ID)
+			}
+			cursor = payment.PagingToken
+		}
+
+		if len(records) > 0 {
+			err = j.saveCursor(cursor)
+			if err != nil {
+				return errors.Wrap(err, "Error saving backfill cursor")
+			}
+		}
+
+		currentLedger, _ := horizon.LedgerFromPagingToken(cursor)
+		now := j.now()
+		j.updateProgress(func(p *Progress) {
+			p.Processed += int64(len(records))
+			p.Cursor = cursor
+			p.CurrentLedger = currentLedger
+			if currentLedger > p.TargetLedger {
+				p.TargetLedger = currentLedger
+			}
+			p.ETA = estimate(startLedger, currentLedger, p.TargetLedger, startedAt, now)
+		})
+
+		progress := j.Progress()
+		j.log.WithFields(logrus.Fields{
+			"processed":      progress.Processed,
+			"current_ledger": progress.CurrentLedger,
+			"target_ledger":  progress.TargetLedger,
+		}).Info("Backfilled a page of payments")
+
+		if len(records) < j.PageLimit {
+			j.log.Info("Backfill finished")
+			return nil
+		}
+
+		interval = j.intervalFor(page.RateLimit)
+	}
+}
+
+// intervalFor spreads remaining requests over the rate limit window
+func (j *Job) intervalFor(rateLimit *horizon.RateLimit) time.Duration {
+	if rateLimit == nil {
+		return j.MinInterval
+	}
+
+	if rateLimit.Remaining <= 0 {
+		return rateLimit.Reset
+	}
+
+	interval := rateLimit.Reset / time.Duration(rateLimit.Remaining)
+	if interval < j.MinInterval {
+		return j.MinInterval
+	}
+	return interval
+}
+
+func (j *Job) saveCursor(pagingToken string) error {
+	cursor, err := j.repository.GetBackfillCursor(j.AccountID)
+	if err != nil {
+		return err
+	}
+
+	if cursor == nil {
+		cursor = &entities.BackfillCursor{AccountID: j.AccountID}
+	}
+	cursor.PagingToken = pagingToken
+	cursor.UpdatedAt = utc.New(j.now())
+	return j.entityManager.Persist(cursor)
+}
+
+func (j *Job) updateProgress(update func(p *Progress)) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	update(&j.progress)
+}
+
+// estimate returns nil until at least one ledger was processed
+func estimate(startLedger, currentLedger, targetLedger uint32, startedAt, now time.Time) *utc.Time {
+	if currentLedger <= startLedger {
+		return nil
+	}
+
+	processed := float64(currentLedger - startLedger)
+	remaining := float64(0)
+	if targetLedger > currentLedger {
+		remaining = float64(targetLedger - currentLedger)
+	}
+
+	elapsed := now.Sub(startedAt)
+	eta := utc.New(now.Add(time.Duration(float64(elapsed) * remaining / processed)))
+	return &eta
+}
@@ -0,0 +1,178 @@
+package backfill
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testAccount = "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"
+
+type fakeProcessor struct {
+	processed []string
+	failOn    string
+}
+
+func (p *fakeProcessor) BackfillPayment(payment horizon.PaymentResponse) error {
+	if payment.ID == p.failOn {
+		return errors.New("DB error")
+	}
+	p.processed = append(p.processed, payment.ID)
+	return nil
+}
+
+// page returns a page with payments in given ledgers
+func page(ledgers ...uint32) horizon.PaymentsPage {
+	var result horizon.PaymentsPage
+	for i, ledger := range ledgers {
+		token := strconv.FormatInt(int64(ledger)<<32|int64(i+1)<<12, 10)
+		result.Embedded.Records = append(result.Embedded.Records, horizon.PaymentResponse{ID: token, PagingToken: token})
+	}
+	return result
+}
+
+func TestJob(t *testing.T) {
+	Convey("Job", t, func() {
+		mockHorizon := new(mocks.MockHorizon)
+		mockRepository := new(mocks.MockRepository)
+		mockEntityManager := new(mocks.MockEntityManager)
+		processor := &fakeProcessor{}
+
+		job := NewJob(testAccount, 100, mockHorizon, mockRepository, mockEntityManager, processor)
+		job.PageLimit = 2
+
+		now := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+		var slept []time.Duration
+		job.now = func() time.Time { return now }
+		job.sleep = func(d time.Duration) {
+			slept = append(slept, d)
+			now = now.Add(d)
+		}
+
+		mockHorizon.On("LoadLatestLedger").Return(uint32(110), nil)
+
+		var savedTokens []string
+		mockEntityManager.On("Persist", mock.AnythingOfType("*entities.BackfillCursor")).
+			Run(func(args mock.Arguments) {
+				cursor := args.Get(0).(*entities.BackfillCursor)
+				assert.Equal(t, testAccount, cursor.AccountID)
+				savedTokens = append(savedTokens, cursor.PagingToken)
+			}).Return(nil)
+
+		Convey("When there is no saved cursor", func() {
+			mockRepository.On("GetBackfillCursor", testAccount).Return(nil, nil)
+
+			first := page(101, 102)
+			second := page(105)
+			mockHorizon.On("LoadPayments", testAccount, horizon.PagingTokenFromLedger(100), 2).Return(first, nil).Once()
+			mockHorizon.On("LoadPayments", testAccount, first.Embedded.Records[1].PagingToken, 2).Return(second, nil).Once()
+
+			Convey("it pages from the ledger and saves the cursor after every page", func() {
+				err := job.Run()
+				assert.NoError(t, err)
+				assert.Equal(t, []string{first.Embedded.Records[0].ID, first.Embedded.Records[1].ID, second.Embedded.Records[0].ID}, processor.processed)
+				assert.Equal(t, []string{first.Embedded.Records[1].PagingToken, second.Embedded.Records[0].PagingToken}, savedTokens)
+				assert.Equal(t, []time.Duration{DefaultMinInterval}, slept)
+
+				progress := job.Progress()
+				assert.False(t, progress.Running)
+				assert.Equal(t, int64(3), progress.Processed)
+				assert.Equal(t, uint32(105), progress.CurrentLedger)
+				assert.Equal(t, uint32(110), progress.TargetLedger)
+				assert.NotNil(t, progress.FinishedAt)
+				assert.Empty(t, progress.Error)
+				mockHorizon.AssertExpectations(t)
+			})
+		})
+
+		Convey("When cursor is saved after the ledger", func() {
+			saved := page(103).Embedded.Records[0].PagingToken
+			mockRepository.On("GetBackfillCursor", testAccount).
+				Return(&entities.BackfillCursor{AccountID: testAccount, PagingToken: saved}, nil)
+			mockHorizon.On("LoadPayments", testAccount, saved, 2).Return(horizon.PaymentsPage{}, nil).Once()
+
+			Convey("it resumes from the saved cursor", func() {
+				err := job.Run()
+				assert.NoError(t, err)
+				assert.Empty(t, processor.processed)
+				assert.Empty(t, savedTokens)
+				assert.Equal(t, uint32(103), job.Progress().CurrentLedger)
+				mockHorizon.AssertExpectations(t)
+			})
+		})
+
+		Convey("When cursor is saved before the ledger", func() {
+			saved := page(50).Embedded.Records[0].PagingToken
+			mockRepository.On("GetBackfillCursor", testAccount).
+				Return(&entities.BackfillCursor{AccountID: testAccount, PagingToken: saved}, nil)
+			mockHorizon.On("LoadPayments", testAccount, horizon.PagingTokenFromLedger(100), 2).Return(horizon.PaymentsPage{}, nil).Once()
+
+			Convey("it starts from the ledger", func() {
+				err := job.Run()
+				assert.NoError(t, err)
+				mockHorizon.AssertExpectations(t)
+			})
+		})
+
+		Convey("When Horizon rate limit is exceeded", func() {
+			mockRepository.On("GetBackfillCursor", testAccount).Return(nil, nil)
+			mockHorizon.On("LoadPayments", testAccount, horizon.PagingTokenFromLedger(100), 2).
+				Return(horizon.PaymentsPage{}, &horizon.RateLimitedError{RetryAfter: 30 * time.Second}).Once()
+			mockHorizon.On("LoadPayments", testAccount, horizon.PagingTokenFromLedger(100), 2).
+				Return(page(101), nil).Once()
+
+			Convey("it waits and retries", func() {
+				err := job.Run()
+				assert.NoError(t, err)
+				assert.Equal(t, []time.Duration{30 * time.Second}, slept)
+				assert.Len(t, processor.processed, 1)
+				mockHorizon.AssertExpectations(t)
+			})
+		})
+
+		Convey("When processing a payment fails", func() {
+			mockRepository.On("GetBackfillCursor", testAccount).Return(nil, nil)
+			records := page(101, 102)
+			processor.failOn = records.Embedded.Records[1].ID
+			mockHorizon.On("LoadPayments", testAccount, horizon.PagingTokenFromLedger(100), 2).Return(records, nil).Once()
+
+			Convey("it saves the cursor of the last processed payment", func() {
+				err := job.Run()
+				assert.Error(t, err)
+				assert.Equal(t, []string{records.Embedded.Records[0].PagingToken}, savedTokens)
+
+				progress := job.Progress()
+				assert.False(t, progress.Running)
+				assert.Contains(t, progress.Error, "DB error")
+			})
+		})
+	})
+}
+
+func TestIntervalFor(t *testing.T) {
+	job := NewJob(testAccount, 1, nil, nil, nil, nil)
+
+	assert.Equal(t, DefaultMinInterval, job.intervalFor(nil))
+	assert.Equal(t, time.Minute, job.intervalFor(&horizon.RateLimit{Limit: 3600, Remaining: 0, Reset: time.Minute}))
+	assert.Equal(t, 6*time.Second, job.intervalFor(&horizon.RateLimit{Limit: 3600, Remaining: 10, Reset: time.Minute}))
+	assert.Equal(t, DefaultMinInterval, job.intervalFor(&horizon.RateLimit{Limit: 3600, Remaining: 3000, Reset: time.Minute}))
+}
+
+func TestEstimate(t *testing.T) {
+	startedAt := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, estimate(100, 100, 200, startedAt, startedAt.Add(time.Minute)))
+
+	eta := estimate(100, 150, 200, startedAt, startedAt.Add(time.Minute))
+	if assert.NotNil(t, eta) {
+		assert.Equal(t, startedAt.Add(2*time.Minute), eta.Time())
+	}
+}
@@ -0,0 +1,95 @@
+package backfill
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/leader"
+	"github.com/stellar/gateway/utc"
+)
+
+var (
+	// ErrRunning is returned by Manager.Start when a job is already running
+	ErrRunning = errors.New("Backfill is already running")
+	// ErrUnavailable is returned by Manager.Start when there is no DB or payment listener
+	ErrUnavailable = errors.New("Backfill not available: payment listener is not running")
+	// ErrStandby is returned by Manager.Start on a standby replica, backfills run on the leader
+	ErrStandby = errors.New("Backfill not available: this replica is not the leader")
+)
+
+// Manager runs a single backfill job at a time in the background for /admin/backfill endpoint
+type Manager struct {
+	horizon       horizon.HorizonInterface
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	processor     PaymentProcessor
+	// Elector rejects starting jobs on standby replicas, jobs can be started on any replica when nil
+	Elector *leader.Elector
+
+	mutex sync.Mutex
+	job   *Job
+	// newJob allows replacing jobs in tests
+	newJob func(accountID string, fromLedger uint32) *Job
+}
+
+// NewManager creates a new Manager
+func NewManager(
+	horizon horizon.HorizonInterface,
+	repository db.RepositoryInterface,
+	entityManager db.EntityManagerInterface,
+	processor PaymentProcessor,
+) *Manager {
+	m := &Manager{
+		horizon:       horizon,
+		repository:    repository,
+		entityManager: entityManager,
+		processor:     processor,
+	}
+	m.newJob = func(accountID string, fromLedger uint32) *Job {
+		return NewJob(accountID, fromLedger, m.horizon, m.repository, m.entityManager, m.processor)
+	}
+	return m
+}
+
+// Start starts a new job in the background and returns its initial progress
+func (m *Manager) Start(accountID string, fromLedger uint32) (Progress, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.processor == nil || m.repository == nil {
+		return Progress{}, ErrUnavailable
+	}
+
+	if m.Elector != nil && !m.Elector.IsLeader() {
+		return Progress{}, ErrStandby
+	}
+
+	if m.job != nil && m.job.Progress().Running {
+		return m.job.Progress(), ErrRunning
+	}
+
+	job := m.newJob(accountID, fromLedger)
+	// Mark as running before returning so a concurrent Start is rejected
+	job.updateProgress(func(p *Progress) {
+		p.Running = true
+		p.StartedAt = utc.New(job.now())
+	})
+	m.job = job
+
+	go job.Run()
+	return job.Progress(), nil
+}
+
+// Progress returns progress of the running (or last) job, nil if no job was started
+func (m *Manager) Progress() *Progress {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.job == nil {
+		return nil
+	}
+	progress := m.job.Progress()
+	return &progress
+}
@@ -0,0 +1,262 @@
+// Package breaker implements circuit breakers failing requests to unavailable dependencies fast
+package breaker
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/utc"
+)
+
+// States of a breaker
+const (
+	// StateClosed breakers pass all requests
+	StateClosed = "closed"
+	// StateOpen breakers fail all requests with OpenError
+	StateOpen = "open"
+	// StateHalfOpen breakers pass a single probe request, other requests fail with OpenError
+	StateHalfOpen = "half_open"
+)
+
+// Settings control when breakers open
+type Settings struct {
+	// FailureRate (0 to 1) of requests in a window that opens a breaker
+	FailureRate float64
+	// MinRequests is a number of requests in a window required before the failure rate is checked
+	MinRequests int
+	// Window is a duration of windows failures are counted in
+	Window time.Duration
+	// OpenTimeout is a time an open breaker waits before sending a probe request
+	OpenTimeout time.Duration
+}
+
+// OpenError is returned instead of calling a dependency when its breaker is open
+type OpenError struct {
+	Dependency string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s is unavailable (circuit breaker open), retry after %s", e.Dependency, e.RetryAfter)
+}
+
+// Status is returned by /admin/circuit-breakers endpoint
+type Status struct {
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Requests int    `json:"requests"`
+	Failures int    `json:"failures"`
+	// OpenedAt is set when the breaker is open or half open
+	OpenedAt *utc.Time `json:"opened_at,omitempty"`
+}
+
+// Breaker counts failed requests to a dependency in fixed windows and opens when their rate
+// reaches Settings.FailureRate. After Settings.OpenTimeout a single probe request is sent, its
+// success closes the breaker.
+type Breaker struct {
+	name     string
+	settings Settings
+	now      func() time.Time
+	log      *logrus.Entry
+
+	mutex       sync.Mutex
+	state       string
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+}
+
+// Do calls fn unless the breaker is open. isFailure decides which errors returned by fn are
+// counted as failures, other errors (ex. not found responses) mean the dependency is available.
+func (b *Breaker) Do(fn func() error, isFailure func(error) bool) error {
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	b.done(probe, err != nil && isFailure(err))
+	return err
+}
+
+// allow returns true when the request is a probe of a half open breaker
+func (b *Breaker) allow() (bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case StateOpen:
+		retryAt := b.openedAt.Add(b.settings.OpenTimeout)
+		if now.Before(retryAt) {
+			return false, &OpenError{Dependency: b.name, RetryAfter: retryAt.Sub(now)}
+		}
+		b.state = StateHalfOpen
+		b.log.Info("Sending probe request")
+		return true, nil
+	case StateHalfOpen:
+		// Waiting for the result of the probe
+		return false, &OpenError{Dependency: b.name, RetryAfter: b.settings.OpenTimeout}
+	default:
+		if now.Sub(b.windowStart) >= b.settings.Window {
+			b.windowStart = now
+			b.requests = 0
+			b.failures = 0
+		}
+	}
+	return false, nil
+}
+
+func (b *Breaker) done(probe, failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if probe {
+		if b.state != StateHalfOpen {
+			// Breaker was reset during the probe
+			return
+		}
+		if failed {
+			b.open()
+		} else {
+			b.close()
+			b.log.Info("Probe request succeeded, breaker closed")
+		}
+		return
+	}
+
+	if b.state != StateClosed {
+		// Request was sent before the breaker opened, only the probe decides now
+		return
+	}
+
+	b.requests++
+	if failed {
+		b.failures++
+	}
+
+	minRequests := b.settings.MinRequests
+	if minRequests < 1 {
+		minRequests = 1
+	}
+	if b.failures > 0 && b.requests >= minRequests && float64(b.failures)/float64(b.requests) >= b.settings.FailureRate {
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.log.WithFields(logrus.Fields{"requests": b.requests, "failures": b.failures}).Warn("Breaker opened")
+	b.state = StateOpen
+	b.openedAt = b.now()
+}
+
+func (b *Breaker) close() {
+	b.state = StateClosed
+	b.windowStart = b.now()
+	b.requests = 0
+	b.failures = 0
+}
+
+// Reset closes the breaker
+func (b *Breaker) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.close()
+}
+
+// Status returns the current state of the breaker
+func (b *Breaker) Status() Status {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	status := Status{
+		Name:     b.name,
+		State:    b.state,
+		Requests: b.requests,
+		Failures: b.failures,
+	}
+	if b.state != StateClosed {
+		openedAt := utc.New(b.openedAt)
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// Set contains breakers of dependencies, created on first use with the same settings
+type Set struct {
+	settings Settings
+	now      func() time.Time
+
+	mutex    sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewSet creates a new Set
+func NewSet(settings Settings, now func() time.Time) *Set {
+	return &Set{
+		settings: settings,
+		now:      now,
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// Get returns a breaker of a dependency
+func (s *Set) Get(name string) *Breaker {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b, ok := s.breakers[name]
+	if !ok {
+		b = &Breaker{
+			name:     name,
+			settings: s.settings,
+			now:      s.now,
+			log:      logrus.WithFields(logrus.Fields{"service": "CircuitBreaker", "dependency": name}),
+		}
+		b.close()
+		s.breakers[name] = b
+	}
+	return b
+}
+
+// Status returns states of all breakers ordered by name
+func (s *Set) Status() []Status {
+	s.mutex.Lock()
+	breakers := make([]*Breaker, 0, len(s.breakers))
+	for _, b := range s.breakers {
+		breakers = append(breakers, b)
+	}
+	s.mutex.Unlock()
+
+	statuses := make([]Status, 0, len(breakers))
+	for _, b := range breakers {
+		statuses = append(statuses, b.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Reset closes a breaker of a dependency, all breakers when name is empty. It returns false when
+// there is no breaker with the name.
+func (s *Set) Reset(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if name == "" {
+		for _, b := range s.breakers {
+			b.Reset()
+		}
+		return true
+	}
+
+	b, ok := s.breakers[name]
+	if !ok {
+		return false
+	}
+	b.Reset()
+	return true
+}
@@ -0,0 +1,114 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errUnavailable = errors.New("connection refused")
+	errNotFound    = errors.New("not found")
+)
+
+func isFailure(err error) bool {
+	return err == errUnavailable
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	set := NewSet(Settings{
+		FailureRate: 0.5,
+		MinRequests: 4,
+		Window:      time.Minute,
+		OpenTimeout: 30 * time.Second,
+	}, func() time.Time { return now })
+	b := set.Get("horizon.accounts")
+
+	call := func(err error) error {
+		return b.Do(func() error { return err }, isFailure)
+	}
+
+	// Not found errors do not count
+	assert.Equal(t, errNotFound, call(errNotFound))
+	assert.Equal(t, errUnavailable, call(errUnavailable))
+	assert.NoError(t, call(nil))
+	assert.Equal(t, StateClosed, b.Status().State)
+
+	// 2 of 4 requests failed
+	assert.Equal(t, errUnavailable, call(errUnavailable))
+	require.Equal(t, StateOpen, b.Status().State)
+
+	err := call(nil)
+	openErr, ok := err.(*OpenError)
+	require.True(t, ok, "expected OpenError, got %v", err)
+	assert.Equal(t, "horizon.accounts", openErr.Dependency)
+	assert.Equal(t, 30*time.Second, openErr.RetryAfter)
+
+	// Failed probe opens the breaker again
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, errUnavailable, call(errUnavailable))
+	assert.Equal(t, StateOpen, b.Status().State)
+	assert.IsType(t, &OpenError{}, call(nil))
+
+	// Successful probe closes it
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, call(nil))
+	assert.Equal(t, Status{Name: "horizon.accounts", State: StateClosed}, b.Status())
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	now := time.Now()
+	set := NewSet(Settings{FailureRate: 1, Window: time.Minute, OpenTimeout: time.Second}, func() time.Time { return now })
+	b := set.Get("horizon.transactions")
+
+	assert.Equal(t, errUnavailable, b.Do(func() error { return errUnavailable }, isFailure))
+	require.Equal(t, StateOpen, b.Status().State)
+
+	now = now.Add(time.Second)
+	err := b.Do(func() error {
+		// Only the probe is sent
+		assert.Equal(t, StateHalfOpen, b.Status().State)
+		assert.IsType(t, &OpenError{}, b.Do(func() error { return nil }, isFailure))
+		return nil
+	}, isFailure)
+	assert.NoError(t, err)
+	assert.Equal(t, StateClosed, b.Status().State)
+}
+
+func TestBreakerWindow(t *testing.T) {
+	now := time.Now()
+	set := NewSet(Settings{FailureRate: 0.5, MinRequests: 2, Window: time.Minute, OpenTimeout: time.Second}, func() time.Time { return now })
+	b := set.Get("federation:stellar.org")
+
+	b.Do(func() error { return errUnavailable }, isFailure)
+	// Previous window is not counted
+	now = now.Add(time.Minute)
+	b.Do(func() error { return nil }, isFailure)
+	b.Do(func() error { return nil }, isFailure)
+	assert.Equal(t, Status{Name: "federation:stellar.org", State: StateClosed, Requests: 2}, b.Status())
+}
+
+func TestSetReset(t *testing.T) {
+	set := NewSet(Settings{FailureRate: 1, Window: time.Minute, OpenTimeout: time.Minute}, time.Now)
+	for _, name := range []string{"horizon.transactions", "horizon.accounts"} {
+		set.Get(name).Do(func() error { return errUnavailable }, isFailure)
+	}
+
+	statuses := set.Status()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, "horizon.accounts", statuses[0].Name)
+	assert.Equal(t, StateOpen, statuses[0].State)
+	assert.NotNil(t, statuses[0].OpenedAt)
+
+	assert.False(t, set.Reset("horizon.ledgers"))
+	assert.True(t, set.Reset("horizon.accounts"))
+	assert.Equal(t, StateClosed, set.Get("horizon.accounts").Status().State)
+	assert.Equal(t, StateOpen, set.Get("horizon.transactions").Status().State)
+
+	assert.True(t, set.Reset(""))
+	assert.Equal(t, StateClosed, set.Get("horizon.transactions").Status().State)
+}
@@ -1,10 +1,13 @@
 package bridge
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -13,16 +16,42 @@ import (
 
 	"github.com/elazarl/go-bindata-assetfs"
 	"github.com/facebookgo/inject"
+	"github.com/stellar/gateway/anomaly"
+	"github.com/stellar/gateway/async"
+	"github.com/stellar/gateway/backfill"
+	"github.com/stellar/gateway/breaker"
 	"github.com/stellar/gateway/bridge/config"
 	"github.com/stellar/gateway/bridge/gui"
 	"github.com/stellar/gateway/bridge/handlers"
+	"github.com/stellar/gateway/channels"
+	"github.com/stellar/gateway/confirmation"
+	"github.com/stellar/gateway/conversion"
+	"github.com/stellar/gateway/counterparty"
 	"github.com/stellar/gateway/db"
 	"github.com/stellar/gateway/db/drivers/mysql"
 	"github.com/stellar/gateway/db/drivers/postgres"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/errormap"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/external"
+	"github.com/stellar/gateway/generation"
+	"github.com/stellar/gateway/handoff"
 	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/jws"
+	"github.com/stellar/gateway/leader"
 	"github.com/stellar/gateway/listener"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/queue"
+	"github.com/stellar/gateway/ratelimit"
+	"github.com/stellar/gateway/reconciliation"
+	"github.com/stellar/gateway/retry"
 	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/stats"
 	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/warmup"
+	"github.com/stellar/gateway/webhook"
 	"github.com/stellar/go/clients/federation"
 	"github.com/stellar/go/clients/stellartoml"
 	"github.com/zenazn/goji/graceful"
@@ -33,67 +62,202 @@ import (
 // App is the application object
 type App struct {
 	config         config.Config
+	options        Options
 	requestHandler handlers.RequestHandler
+	signer         *jws.Signer
+	trustedProxies server.TrustedProxies
+	components     []Component
+	database       bool
+	// networks are Apps of networks by name in an App of a server with `networks` config, their
+	// /status reports all networks
+	networks      map[string]*App
+	networkStatus http.HandlerFunc
 }
 
-// NewApp constructs an new App instance from the provided config.
-func NewApp(config config.Config, migrateFlag bool, versionFlag bool, version string) (app *App, err error) {
-	var g inject.Graph
+// Options customize an App embedded in another Go service. The zero Options are used by the
+// standalone server.
+type Options struct {
+	// ConfigFile is the path config was loaded from, it's read again by /admin/reload
+	ConfigFile string
+	// PathPrefix is a path the handler is mounted under in an external mux (ex. `/bridge`), it's
+	// stripped from request paths
+	PathPrefix string
+	// Middleware wraps the endpoints (ex. custom auth and tracing), the first one is the outermost.
+	// Request IDs and client IPs are already attached to requests, API keys are checked after.
+	Middleware []func(http.Handler) http.Handler
+	// Logger replaces output, formatter, level and hooks of the standard logrus logger the bridge
+	// logs with
+	Logger *log.Logger
+	// Driver is an initialized DB driver managed by the caller, database params of the config
+	// are not used when it's set. Migrations are not applied by the App.
+	Driver db.Driver
+	// Horizon is a Horizon client created by horizon.New and shared with the caller, its failure
+	// log and retries are not configured by the App. A client of the horizon param is created
+	// when it's nil.
+	Horizon *horizon.Horizon
+	// EventSink receives events of the journal after they are written (ex. to publish them to a
+	// message queue), it's used only with `events` config
+	EventSink events.Sink
+	// Version is the version of the bridge, leader handoffs are made only between replicas of
+	// different versions
+	Version string
+	// Security enables strict security mode: NewApp refuses configs failing checks that are not
+	// waived by the policy and /admin/reload refuses to load them
+	Security *config.SecurityPolicy
+
+	// logSampler is shared by Apps of all networks
+	logSampler *logging.Sampler
+}
 
-	var driver db.Driver
-	switch config.Database.Type {
-	case "mysql":
-		driver = &mysql.Driver{}
-	case "postgres":
-		driver = &postgres.Driver{}
-	case "":
-		// Allow to start gateway server with a single endpoint: /payment
-		break
-	default:
-		return nil, fmt.Errorf("%s database has no driver", config.Database.Type)
+// Component is a background component of the App (ex. the payment listener). Components are
+// started by App.Start and cannot be started again after they are stopped.
+type Component interface {
+	Name() string
+	Start() error
+	Stop()
+}
+
+// component implements Component with functions
+type component struct {
+	name  string
+	start func() error
+	stop  func()
+}
+
+func (c component) Name() string { return c.name }
+func (c component) Start() error { return c.start() }
+func (c component) Stop()        { c.stop() }
+
+// NewApp constructs an new App instance from the provided config. Background components are not
+// running until Start is called. With `networks` config an App of every network is constructed,
+// Options.Driver and Options.Horizon are used by the default network.
+func NewApp(config config.Config, options Options) (*App, error) {
+	if options.Security != nil {
+		waived, err := options.Security.Check(&config)
+		if err != nil {
+			return nil, err
+		}
+		for _, violation := range waived {
+			log.WithFields(log.Fields{"waiver": violation.Check, "violation": violation.Message}).Warn("Strict security check waived")
+		}
 	}
 
-	var entityManager db.EntityManagerInterface
-	var repository db.Repository
+	if len(config.Networks) > 0 {
+		return newMultiNetworkApp(config, options)
+	}
+	return newNetworkApp(config, options)
+}
 
-	if driver != nil {
-		err = driver.Init(config.Database.URL)
+// newNetworkApp constructs an App serving a single network
+func newNetworkApp(config config.Config, options Options) (app *App, err error) {
+	var g inject.Graph
+	var components []Component
+
+	driver := options.Driver
+	if driver == nil {
+		driver, err = newDriver(config.Database.Type)
 		if err != nil {
-			err = fmt.Errorf("Cannot connect to a DB: %s", err)
 			return
 		}
+		if driver != nil {
+			err = driver.Init(config.Database.URL)
+			if err != nil {
+				err = fmt.Errorf("Cannot connect to a DB: %s", err)
+				return
+			}
+		}
+	}
+
+	var entityManager db.EntityManager
+	var repository db.Repository
+	var volumeAggregator stats.VolumeAggregatorInterface
+	// Disabled journal does not record events
+	journal := &events.Journal{}
 
+	if driver != nil {
 		entityManager = db.NewEntityManager(driver)
 		repository = db.NewRepository(driver)
-	}
 
-	if migrateFlag {
-		if driver == nil {
-			log.Fatal("No database driver.")
-			return
+		// Started first so it's stopped after components recording events
+		if config.Events.Enabled {
+			journal = events.NewJournal(entityManager, config.Events.BufferSize, time.Now)
+			journal.Sink = options.EventSink
+			components = append(components, component{"event_journal", func() error {
+				journal.Run()
+				return nil
+			}, journal.Stop})
 		}
 
-		var migrationsApplied int
-		migrationsApplied, err = driver.MigrateUp("gateway")
+		aggregator := stats.NewVolumeAggregator(repository, entityManager)
+		components = append(components, component{"volume_aggregator", func() error {
+			aggregator.Run()
+			return nil
+		}, aggregator.Stop})
+		volumeAggregator = aggregator
+	}
+
+	logger := log.StandardLogger()
+	if options.Logger != nil {
+		logger.Out = options.Logger.Out
+		logger.Hooks = options.Logger.Hooks
+		logger.Level = options.Logger.Level
+		logger.Formatter = options.Logger.Formatter
+	}
+
+	logSampler := options.logSampler
+	if logSampler == nil {
+		logSampler, err = logging.NewSampler(config.LogSampling)
 		if err != nil {
 			return
 		}
+		log.SetFormatter(&logging.Formatter{Formatter: logger.Formatter, Sampler: logSampler})
+	}
 
-		log.Info("Applied migrations: ", migrationsApplied)
-		os.Exit(0)
-		return
+	retries := retry.NewSet(config.RetrySettings(), time.Sleep)
+
+	h := options.Horizon
+	if h == nil {
+		created := horizon.New(config.Horizon)
+		created.Failures = horizon.NewFailureLog(config.HorizonFailures.Size, time.Now)
+		created.Timeout = time.Duration(config.HorizonTimeoutSeconds) * time.Second
+		created.HandlerRetry = retries.Get(retry.Callbacks, horizon.DefaultHandlerRetry)
+		h = &created
 	}
 
-	if versionFlag {
-		fmt.Printf("Bridge Server Version: %s \n", version)
-		os.Exit(0)
-		return
+	breakers := breaker.NewSet(breaker.Settings{
+		FailureRate: config.CircuitBreakers.FailureRate,
+		MinRequests: config.CircuitBreakers.MinRequests,
+		Window:      time.Duration(config.CircuitBreakers.WindowSeconds) * time.Second,
+		OpenTimeout: time.Duration(config.CircuitBreakers.OpenTimeoutSeconds) * time.Second,
+	}, time.Now)
+
+	// Request handlers and the submitter fail fast when Horizon is failing. The listener and
+	// backfills use Horizon directly, they retry on their own.
+	var requestHorizon horizon.HorizonInterface = h
+	if config.CircuitBreakers.FailureRate != 0 {
+		requestHorizon = horizon.NewBreakerHorizon(h, breakers)
+	}
+	// All submissions of the network share its budget, the listener and backfills don't submit
+	submissionRate := ratelimit.NewLimiter(ratelimit.Settings{Rate: config.SubmissionRate.Rate, Burst: config.SubmissionRate.Burst})
+	if submissionRate.Enabled() {
+		requestHorizon = horizon.NewLimitedHorizon(requestHorizon, submissionRate)
 	}
 
-	h := horizon.New(config.Horizon)
+	// Disabled tracker counts every account in generation 1
+	generations := &generation.Tracker{}
+	if driver != nil {
+		generations = generation.NewTracker(repository, entityManager, time.Now)
+		generations.Events = journal
+	}
 
 	log.Print("Creating and initializing TransactionSubmitter")
-	ts := submitter.NewTransactionSubmitter(&h, entityManager, config.NetworkPassphrase, time.Now)
+	ts := submitter.NewTransactionSubmitter(requestHorizon, entityManager, config.NetworkPassphrase, time.Now)
+	ts.Volumes = volumeAggregator
+	ts.Retry = retries.Get(retry.Submitter, submitter.DefaultRetry)
+	ts.Events = journal
+	ts.Generations = generations
+	// Sequence numbers of recreated accounts start again
+	generations.Recreated = append(generations.Recreated, ts.InvalidateAccount)
 	if err != nil {
 		return
 	}
@@ -121,25 +285,98 @@ func NewApp(config config.Config, migrateFlag bool, versionFlag bool, version st
 
 	log.Print("TransactionSubmitter created")
 
-	log.Print("Creating and starting PaymentListener")
+	// Only the payment listener (with payment request expiry) and backfills run on a single
+	// replica, HTTP requests are handled by all of them
+	var leaseStore leader.Store
+	replica := config.LeaderElection.Replica
+	if config.LeaderElection.Enabled {
+		leaseStore = driver
+	}
+	if replica == "" {
+		replica, err = defaultReplica()
+		if err != nil {
+			return
+		}
+	}
+	elector := leader.NewElector(leaseStore, "payment_listener", replica, time.Duration(config.LeaderElection.TTLSeconds)*time.Second, time.Now)
+	elector.Version = options.Version
+	elector.HandoffTimeout = time.Duration(config.LeaderElection.HandoffTimeoutSeconds) * time.Second
+	elector.Events = journal
+	// Conversions of the previous leader used the same accounts
+	elector.Hooks.Acquired = append(elector.Hooks.Acquired, ts.InvalidateAccounts)
+	components = append(components, component{"leader_elector", func() error {
+		elector.Run()
+		return nil
+	}, elector.Stop})
+
+	// Callbacks are sent only to allowed hosts, with TLS options of a destination
+	webhooks, err := webhook.NewClient(config.Callbacks.WebhookSettings())
+	if err != nil {
+		return
+	}
+
+	log.Print("Creating PaymentListener")
+
+	// Issuers of received assets are looked up only when a payment is received, the zero
+	// resolver is disabled
+	issuerInfo := &external.IssuerInfoResolver{}
+	if !config.IssuerInfo.Disabled {
+		ttl := external.DefaultIssuerInfoTTL
+		if config.IssuerInfo.CacheHours != 0 {
+			ttl = time.Duration(config.IssuerInfo.CacheHours) * time.Hour
+		}
+		issuerInfo = external.NewIssuerInfoResolver(h, &http.Client{Timeout: 10 * time.Second}, ttl, time.Now)
+	}
 
 	var paymentListener listener.PaymentListener
+	// Backfills are not available without the listener
+	backfills := &backfill.Manager{}
 
 	if config.Accounts.ReceivingAccountID == "" {
 		log.Warning("No accounts.receiving_account_id param. Skipping...")
 	} else if config.Callbacks.Receive == "" {
 		log.Warning("No callbacks.receive param. Skipping...")
 	} else {
-		paymentListener, err = listener.NewPaymentListener(&config, entityManager, &h, repository, time.Now)
+		paymentListener, err = listener.NewPaymentListener(&config, entityManager, h, repository, volumeAggregator, webhooks, time.Now)
 		if err != nil {
 			return
 		}
-		err = paymentListener.Listen()
-		if err != nil {
-			return
+		paymentListener.Elector = elector
+		paymentListener.IssuerInfo = issuerInfo
+		paymentListener.Events = journal
+		paymentListener.Generations = generations
+		if config.AutoConversion.Enabled() {
+			var settings conversion.Settings
+			settings, err = config.AutoConversion.ConversionSettings()
+			if err != nil {
+				return
+			}
+			err = ts.InitAccount(settings.Seed)
+			if err != nil {
+				return
+			}
+			// Conversions of received payments wait for submissions of requests
+			paymentListener.Converter = conversion.NewConverter(settings, h, ts.WithSubmissionPriority(ratelimit.Low), repository, entityManager, time.Now)
 		}
+		components = append(components, component{"payment_listener", paymentListener.Listen, paymentListener.Stop})
+		elector.Hooks.Drain = append(elector.Hooks.Drain, paymentListener.Drain)
 
 		log.Print("PaymentListener created")
+		backfills = backfill.NewManager(h, repository, entityManager, &paymentListener)
+		backfills.Elector = elector
+	}
+
+	if config.Reconciliation.Enabled {
+		reconciler := reconciliation.NewReconciler(config.ReconciledAccounts(), h, repository, entityManager, time.Now)
+		reconciler.Hour = config.Reconciliation.Hour
+		reconciler.Callback = config.Callbacks.Reconciliation
+		reconciler.MACKey = config.MACKey
+		reconciler.Webhooks = webhooks
+		reconciler.Elector = elector
+		components = append(components, component{"reconciler", func() error {
+			reconciler.Run()
+			return nil
+		}, reconciler.Stop})
 	}
 
 	if len(config.APIKey) > 0 && len(config.APIKey) < 15 {
@@ -147,8 +384,84 @@ func NewApp(config config.Config, migrateFlag bool, versionFlag bool, version st
 		return
 	}
 
+	if len(config.OperatorAPIKey) > 0 && len(config.OperatorAPIKey) < 15 {
+		err = errors.New("operator-api-key have to be at least 15 chars long")
+		return
+	}
+
+	// Disabled detector does not check payments
+	anomalies := &anomaly.Detector{}
+	if config.AnomalyDetection.Enabled() {
+		anomalies = anomaly.NewDetector(config.AnomalyDetection.DetectorSettings(), repository, entityManager)
+	}
+
+	// Disabled pool has no channels, payments use their source accounts
+	channelPool := &channels.Pool{}
+	if len(config.Channels.Seeds) > 0 {
+		channelPool, err = channels.NewPool(requestHorizon, config.Channels.Seeds, config.Channels.LeaseWait())
+		if err != nil {
+			return
+		}
+		log.WithField("channels", channelPool.Size()).Print("Channel accounts configured")
+	}
+	elector.Hooks.Acquired = append(elector.Hooks.Acquired, channelPool.Invalidate)
+
+	// Unknown bridge codes are rejected at start
+	errorMapper, err := errormap.NewMapper(config.ErrorMapping.Codes, config.ErrorMapping.Webhooks)
+	if err != nil {
+		return
+	}
+
+	// Invalid list files are rejected at start
+	counterparties, err := counterparty.NewLists(config.Counterparties.CounterpartySettings(), time.Now)
+	if err != nil {
+		return
+	}
+
+	var signer *jws.Signer
+	if config.ResponseSigning.SigningSeed != "" {
+		signer, err = jws.NewSigner(config.ResponseSigning.SigningSeed, config.ResponseSigning.KeyID, config.ResponseSigning.PreviousKeys)
+		if err != nil {
+			return
+		}
+	}
+
 	requestHandler := handlers.RequestHandler{}
 
+	// Disabled queue does not send payments, `queue=true` is rejected without a database
+	paymentQueue := &queue.Worker{}
+	if driver != nil {
+		paymentQueue = queue.NewWorker(func(payment *entities.QueuedPayment) queue.Result {
+			return requestHandler.SendQueuedPayment(payment)
+		}, repository, entityManager, time.Now)
+		if config.Queue.IntervalSeconds != 0 {
+			paymentQueue.Interval = time.Duration(config.Queue.IntervalSeconds) * time.Second
+		}
+		paymentQueue.MaxAttempts = config.Queue.MaxAttempts
+		paymentQueue.Callback = config.Callbacks.Queue
+		paymentQueue.MACKey = config.MACKey
+		paymentQueue.Webhooks = webhooks
+		paymentQueue.Elector = elector
+		components = append(components, component{"payment_queue", func() error {
+			paymentQueue.Run()
+			return nil
+		}, paymentQueue.Stop})
+	}
+
+	// Disabled worker does not deliver callbacks, `callback` is rejected without a database
+	paymentCallbacks := &confirmation.Worker{}
+	if driver != nil {
+		paymentCallbacks = confirmation.NewWorker(h, repository, entityManager, time.Now)
+		paymentCallbacks.Retry = retries.Get(retry.PaymentCallbacks, confirmation.DefaultRetry)
+		paymentCallbacks.MACKey = config.MACKey
+		paymentCallbacks.Webhooks = webhooks
+		paymentCallbacks.Elector = elector
+		components = append(components, component{"payment_callbacks", func() error {
+			paymentCallbacks.Run()
+			return nil
+		}, paymentCallbacks.Stop})
+	}
+
 	httpClientWithTimeout := http.Client{
 		Timeout: 10 * time.Second,
 	}
@@ -157,68 +470,322 @@ func NewApp(config config.Config, migrateFlag bool, versionFlag bool, version st
 		HTTP: &httpClientWithTimeout,
 	}
 
-	federationClient := federation.Client{
+	var federationClient external.FederationClientInterface = &federation.Client{
 		HTTP:        &httpClientWithTimeout,
 		StellarTOML: &stellartomlClient,
 	}
+	if config.CircuitBreakers.FailureRate != 0 {
+		federationClient = external.NewFederationBreaker(federationClient, breakers)
+	}
+	federationClient = external.NewFederationRetry(federationClient, retries.Get(retry.Resolver, external.DefaultResolverRetry))
+
+	// Warm-up runs after accounts are loaded, requests are handled while it's running
+	warmer := warmup.NewWarmer(nil, nil, nil, warmup.Settings{}, time.Now)
+	if config.WarmStart.Enabled {
+		ttl := external.DefaultFederationCacheTTL
+		if config.WarmStart.FederationCacheSeconds != 0 {
+			ttl = time.Duration(config.WarmStart.FederationCacheSeconds) * time.Second
+		}
+		federationCache := external.NewFederationCache(federationClient, ttl, time.Now)
+		federationClient = federationCache
+
+		settings := warmup.Settings{
+			FederationAddresses: config.WarmStart.FederationAddresses,
+			HorizonConnections:  config.WarmStart.HorizonConnections,
+		}
+		if settings.HorizonConnections == 0 {
+			settings.HorizonConnections = 2
+		}
+		for _, seed := range []string{config.Accounts.BaseSeed, config.Accounts.AuthorizingSeed, config.Accounts.RecoverySeed} {
+			if seed != "" {
+				settings.Seeds = append(settings.Seeds, seed)
+			}
+		}
+		for _, seed := range config.Accounts.Sources {
+			settings.Seeds = append(settings.Seeds, seed)
+		}
+		warmer = warmup.NewWarmer(federationCache, &ts, h, settings, time.Now)
+	}
+	federationMetrics := external.NewFederationMetrics(federationClient, config.ResolverMetrics.MaxDomains, time.Now)
 
 	err = g.Provide(
 		&inject.Object{Value: &requestHandler},
 		&inject.Object{Value: &config},
 		&inject.Object{Value: &stellartomlClient},
-		&inject.Object{Value: &federationClient},
-		&inject.Object{Value: &h},
+		&inject.Object{Value: federationMetrics},
+		&inject.Object{Value: requestHorizon},
 		&inject.Object{Value: &repository},
+		&inject.Object{Value: &entityManager},
 		&inject.Object{Value: driver},
 		&inject.Object{Value: &ts},
 		&inject.Object{Value: &paymentListener},
 		&inject.Object{Value: &httpClientWithTimeout},
+		&inject.Object{Value: logSampler},
+		&inject.Object{Value: backfills},
+		&inject.Object{Value: breakers},
+		&inject.Object{Value: elector},
+		&inject.Object{Value: webhooks},
+		&inject.Object{Value: h.Failures},
+		&inject.Object{Value: h.RateLimits},
+		&inject.Object{Value: retries},
+		&inject.Object{Value: warmer},
+		&inject.Object{Value: errorMapper},
+		&inject.Object{Value: counterparties},
+		&inject.Object{Value: inflight.NewRegistry(inflight.DefaultSize, time.Now)},
+		&inject.Object{Value: handoff.NewStore(handoff.DefaultSize)},
+		&inject.Object{Value: async.NewPool(config.Async.Workers)},
+		&inject.Object{Value: issuerInfo},
+		&inject.Object{Value: anomalies},
+		&inject.Object{Value: channelPool},
+		&inject.Object{Value: journal},
+		&inject.Object{Value: generations},
+		&inject.Object{Value: submissionRate},
+		&inject.Object{Value: paymentQueue},
+		&inject.Object{Value: paymentCallbacks},
 	)
 
 	if err != nil {
-		log.Fatal("Injector: ", err)
+		err = fmt.Errorf("Injector: %s", err)
+		return
 	}
 
-	if err := g.Populate(); err != nil {
-		log.Fatal("Injector: ", err)
+	err = g.Populate()
+	if err != nil {
+		err = fmt.Errorf("Injector: %s", err)
+		return
+	}
+	requestHandler.ConfigFile = options.ConfigFile
+	requestHandler.Signer = signer
+	requestHandler.Security = options.Security
+	// Warm-up runs once, requests are handled while it's running so it's not interrupted by Stop
+	components = append(components, component{"warmer", func() error {
+		warmer.Run()
+		return nil
+	}, func() {}})
+
+	// Validated by config.Validate
+	trustedProxies, err := server.ParseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return
 	}
 
 	app = &App{
 		config:         config,
+		options:        options,
 		requestHandler: requestHandler,
+		signer:         signer,
+		trustedProxies: trustedProxies,
+		components:     components,
+		database:       driver != nil,
 	}
 	return
 }
 
+// Components returns background components of the App in the order they are started
+func (a *App) Components() []Component {
+	return a.components
+}
+
+// Start starts background components, components started before an error are stopped
+func (a *App) Start() error {
+	for i, c := range a.components {
+		err := c.Start()
+		if err != nil {
+			for j := i - 1; j >= 0; j-- {
+				a.components[j].Stop()
+			}
+			return fmt.Errorf("Cannot start %s: %s", c.Name(), err)
+		}
+		log.WithField("component", c.Name()).Info("Started")
+	}
+	return nil
+}
+
+// Stop stops background components in the reverse order, the handler keeps serving requests
+func (a *App) Stop() {
+	for i := len(a.components) - 1; i >= 0; i-- {
+		a.components[i].Stop()
+	}
+}
+
+// Migrate applies DB migrations of databases of all networks of the config
+func Migrate(config config.Config) (int, error) {
+	applied := 0
+	for _, name := range config.NetworkNames() {
+		networkConfig, _ := config.NetworkConfig(name)
+		driver, err := openDB(networkConfig)
+		if err != nil {
+			return applied, err
+		}
+		n, err := driver.MigrateUp("gateway")
+		applied += n
+		if err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+// newDriver returns a DB driver of a given type, nil if no DB is configured
+func newDriver(databaseType string) (db.Driver, error) {
+	switch databaseType {
+	case "mysql":
+		return &mysql.Driver{}, nil
+	case "postgres":
+		return &postgres.Driver{}, nil
+	case "sqlite":
+		return &sqlite.Driver{}, nil
+	case "":
+		// Allow to start gateway server with a single endpoint: /payment
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%s database has no driver", databaseType)
+	}
+}
+
+// defaultReplica returns a name of this replica in the leader lease: a hostname with a random
+// suffix so restarted replicas and containers with equal hostnames are distinguished
+func defaultReplica() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 4)
+	_, err = rand.Read(suffix)
+	if err != nil {
+		return "", err
+	}
+	return hostname + "-" + hex.EncodeToString(suffix), nil
+}
+
 // Serve starts the server
 func (a *App) Serve() {
-	portString := fmt.Sprintf(":%d", *a.config.Port)
-	flag.Set("bind", portString)
+	network, address := a.config.ListenNetwork()
+	flag.Set("bind", address)
 
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if a.config.ProxyProtocol {
+		listener = server.NewProxyProtocolListener(listener, a.trustedProxies)
+	}
+	log.WithFields(log.Fields{"address": listener.Addr().String(), "proxy_protocol": a.config.ProxyProtocol}).Info("Listening")
+
+	err = graceful.Serve(listener, a.Handler())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Handler returns a handler of endpoints enabled by the config, it serves requests under
+// Options.PathPrefix
+func (a *App) Handler() http.Handler {
+	var handler http.Handler
+	if a.networks != nil {
+		handler = a.networkRoutes()
+	} else {
+		handler = a.routes()
+	}
+	if a.options.PathPrefix == "" {
+		return handler
+	}
+	return http.StripPrefix(a.options.PathPrefix, handler)
+}
+
+// routes returns a mux with endpoints enabled by the config
+func (a *App) routes() *web.Mux {
 	bridge := web.New()
 
 	bridge.Abandon(middleware.Logger)
+	bridge.Use(server.RequestIDMiddleware())
+	bridge.Use(server.ClientIPMiddleware(a.trustedProxies))
+	for _, m := range a.options.Middleware {
+		bridge.Use(m)
+	}
 	bridge.Use(server.StripTrailingSlashMiddleware())
 	bridge.Use(server.HeadersMiddleware())
-	if a.config.APIKey != "" {
-		bridge.Use(server.APIKeyMiddleware(a.config.APIKey))
+	if a.signer != nil {
+		// Signs bodies after error codes are mapped
+		bridge.Use(a.signer.Middleware)
+	}
+	bridge.Use(a.requestHandler.ErrorMapper.Middleware)
+	bridge.Use(handlers.PaymentJSONMiddleware)
+	if a.config.APIKey != "" || a.config.OperatorAPIKey != "" {
+		bridge.Use(server.APIKeyMiddleware(a.config.APIKey, a.config.OperatorAPIKey))
+	}
+	if a.options.Security != nil && !a.options.Security.Waived(config.SecurityAdminSeparation) {
+		bridge.Use(server.OperatorOnlyMiddleware("/admin"))
 	}
 
 	if a.config.Accounts.AuthorizingSeed != "" {
 		bridge.Post("/authorize", a.requestHandler.Authorize)
+		bridge.Post("/authorize/batch", a.requestHandler.AuthorizeBatch)
 	} else {
-		log.Warning("accounts.authorizing_seed not provided. /authorize endpoint will not be available.")
+		log.Warning("accounts.authorizing_seed not provided. /authorize endpoints will not be available.")
+	}
+
+	if a.config.Accounts.RecoverySeed != "" {
+		bridge.Post("/preauth", a.requestHandler.Preauth)
+		bridge.Post("/preauth/submit", a.requestHandler.PreauthSubmit)
 	}
 
 	bridge.Post("/create-keypair", a.requestHandler.CreateKeypair)
 	bridge.Post("/builder", a.requestHandler.Builder)
 	bridge.Post("/payment", a.requestHandler.Payment)
 	bridge.Get("/payment", a.requestHandler.Payment)
+	bridge.Get("/payment/:id", a.requestHandler.PaymentResult)
+	bridge.Post("/simulate", a.requestHandler.Simulate)
 	bridge.Post("/reprocess", a.requestHandler.Reprocess)
+	bridge.Get("/errors", a.requestHandler.Errors)
+	if a.networkStatus != nil {
+		bridge.Get("/status", a.networkStatus)
+	} else {
+		bridge.Get("/status", a.requestHandler.Status)
+	}
+	if a.signer != nil {
+		bridge.Get(jws.KeysPath, a.signer.KeysHandler)
+	}
+
+	if a.config.Accounts.ReceivingAccountID != "" && a.database {
+		bridge.Post("/payment_requests", a.requestHandler.PaymentRequests)
+		bridge.Get("/payment_requests/:id", a.requestHandler.PaymentRequest)
+	} else {
+		log.Warning("accounts.receiving_account_id or database not provided. /payment_requests endpoints will not be available.")
+	}
+
+	if a.signer != nil && a.config.Accounts.ReceivingAccountID != "" && a.database {
+		bridge.Get("/verify/payment/:id", a.requestHandler.VerifyPayment)
+	} else {
+		log.Warning("response_signing, accounts.receiving_account_id or database not provided. /verify/payment endpoint will not be available.")
+	}
 
 	bridge.Get("/admin/received-payments", a.requestHandler.AdminReceivedPayments)
 	bridge.Get("/admin/received-payments/:id", a.requestHandler.AdminReceivedPayment)
+	bridge.Get("/admin/disputes", a.requestHandler.AdminDisputes)
 	bridge.Get("/admin/sent-transactions", a.requestHandler.AdminSentTransactions)
+	bridge.Post("/admin/transactions/:id/rebuild", a.requestHandler.AdminRebuildTransaction)
+	bridge.Get("/admin/export/envelopes", a.requestHandler.AdminExportEnvelopes)
+	bridge.Get("/admin/stats/volumes", a.requestHandler.AdminStatsVolumes)
+	bridge.Get("/admin/reconciliations/:date", a.requestHandler.AdminReconciliation)
+	bridge.Get("/admin/log-sampling", a.requestHandler.AdminLogSampling)
+	bridge.Post("/admin/log-sampling", a.requestHandler.AdminLogSampling)
+	bridge.Get("/admin/backfill", a.requestHandler.AdminBackfill)
+	bridge.Post("/admin/backfill", a.requestHandler.AdminBackfill)
+	bridge.Post("/admin/reload", a.requestHandler.AdminReload)
+	bridge.Get("/admin/circuit-breakers", a.requestHandler.AdminCircuitBreakers)
+	bridge.Post("/admin/circuit-breakers", a.requestHandler.AdminCircuitBreakers)
+	bridge.Get("/admin/debug/horizon_failures", a.requestHandler.AdminHorizonFailures)
+	bridge.Get("/admin/retry-policies", a.requestHandler.AdminRetryPolicies)
+	bridge.Get("/admin/submission-rate", a.requestHandler.AdminSubmissionRate)
+	bridge.Get("/admin/resolver/domains", a.requestHandler.AdminResolverDomains)
+	bridge.Get("/admin/inflight", a.requestHandler.AdminInflight)
+	bridge.Post("/admin/counterparties/reload", a.requestHandler.AdminReloadCounterparties)
+	bridge.Post("/admin/accounts/:id/reregister", a.requestHandler.AdminReregisterAccount)
+	if a.config.Events.Enabled && a.database {
+		bridge.Get("/admin/events", a.requestHandler.AdminEvents)
+	}
 
 	if a.config.Develop {
 		// Create a proxy server to localhost:3000 where GUI development server lives.
@@ -236,13 +803,9 @@ func (a *App) Serve() {
 				AssetInfo: gui.AssetInfo,
 			})
 		bridge.Get("/admin", func(w http.ResponseWriter, r *http.Request) {
-			http.Redirect(w, r, "/admin/", http.StatusPermanentRedirect)
+			http.Redirect(w, r, a.options.PathPrefix+"/admin/", http.StatusPermanentRedirect)
 		})
 		bridge.Get("/admin/*", http.StripPrefix("/admin/", fileServerHandler))
 	}
-
-	err := graceful.ListenAndServe(portString, bridge)
-	if err != nil {
-		log.Fatal(err)
-	}
+	return bridge
 }
@@ -0,0 +1,65 @@
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stellar/gateway/backfill"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/listener"
+	"github.com/stellar/gateway/stats"
+	"github.com/stellar/gateway/webhook"
+)
+
+// RunBackfill processes historical payments of accountID starting at fromLedger through the payment
+// listener and returns when all payments were processed. It can be run next to a running server.
+func RunBackfill(config config.Config, accountID string, fromLedger uint32) error {
+	if config.Callbacks.Receive == "" {
+		return errors.New("No callbacks.receive param.")
+	}
+
+	driver, err := openDB(config)
+	if err != nil {
+		return err
+	}
+
+	entityManager := db.NewEntityManager(driver)
+	repository := db.NewRepository(driver)
+
+	h := horizon.New(config.Horizon)
+
+	webhooks, err := webhook.NewClient(config.Callbacks.WebhookSettings())
+	if err != nil {
+		return err
+	}
+
+	days := touchedDays{}
+	paymentListener, err := listener.NewPaymentListener(&config, entityManager, &h, repository, days, webhooks, time.Now)
+	if err != nil {
+		return err
+	}
+
+	job := backfill.NewJob(accountID, fromLedger, &h, repository, entityManager, &paymentListener)
+	jobErr := job.Run()
+
+	// Volumes are recomputed before exiting, even if the job failed in the middle
+	aggregator := stats.NewVolumeAggregator(repository, entityManager)
+	for date := range days {
+		err = aggregator.Recompute(date)
+		if err != nil {
+			return fmt.Errorf("Error recomputing daily volumes of %s: %s", date, err)
+		}
+	}
+
+	return jobErr
+}
+
+// touchedDays collects days of backfilled payments
+type touchedDays map[string]bool
+
+func (d touchedDays) Touch(t time.Time) {
+	d[stats.Date(t)] = true
+}
@@ -0,0 +1,324 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/listener"
+	"github.com/stellar/gateway/pagination"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/reconciliation"
+	"github.com/stellar/gateway/stats"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stellar/go/keypair"
+)
+
+// ErrNotConfirmed is returned by commands changing data or sending callbacks without `--yes`
+var ErrNotConfirmed = errors.New("Refusing to continue without --yes")
+
+// Command runs `bridge` subcommands operating directly on the database and Horizon, without
+// the HTTP API. Reports are written to Output as JSON.
+type Command struct {
+	Config config.Config
+	Output io.Writer
+	// Confirmed is set by `--yes`, commands changing data fail with ErrNotConfirmed without it
+	Confirmed bool
+
+	now func() time.Time
+}
+
+// NewCommand creates a new Command
+func NewCommand(config config.Config, output io.Writer, confirmed bool) *Command {
+	return &Command{
+		Config:    config,
+		Output:    output,
+		Confirmed: confirmed,
+		now:       time.Now,
+	}
+}
+
+// openDB connects to the database of the config
+func openDB(config config.Config) (db.Driver, error) {
+	driver, err := newDriver(config.Database.Type)
+	if err != nil {
+		return nil, err
+	}
+	if driver == nil {
+		return nil, errors.New("No database driver.")
+	}
+
+	err = driver.Init(config.Database.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot connect to a DB: %s", err)
+	}
+	return driver, nil
+}
+
+func (c *Command) write(report interface{}) error {
+	encoder := json.NewEncoder(c.Output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// TxStatus implements `bridge tx status <hash>` reporting a transaction sent by the server
+func (c *Command) TxStatus(hash string) error {
+	if !protocols.IsValidTransactionHash(hash) {
+		return errors.New("Invalid transaction hash")
+	}
+
+	driver, err := openDB(c.Config)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := db.NewRepository(driver).GetSentTransactionByHash(hash)
+	if err != nil {
+		return err
+	}
+	if transaction == nil {
+		return fmt.Errorf("Transaction %s was not sent by this server", hash)
+	}
+	return c.write(transaction)
+}
+
+// ListenerCursorReport is written by `bridge listener cursor`
+type ListenerCursorReport struct {
+	// Cursor is the paging token the listener starts from, null when it starts from `now`
+	Cursor *string `json:"cursor"`
+	// Set is the last cursor set by `--set`, it's used until a newer payment is processed
+	Set *entities.ListenerCursor `json:"set"`
+}
+
+// ListenerCursor implements `bridge listener cursor [--set <paging-token>]`. A new cursor is used
+// when the listener starts (or a standby replica takes over), running listeners are not affected.
+func (c *Command) ListenerCursor(set string) error {
+	driver, err := openDB(c.Config)
+	if err != nil {
+		return err
+	}
+	repository := db.NewRepository(driver)
+
+	if set != "" {
+		if _, err = strconv.ParseUint(set, 10, 64); err != nil && set != "now" {
+			return errors.New("Cursor must be a paging token or `now`")
+		}
+		if !c.Confirmed {
+			return ErrNotConfirmed
+		}
+
+		lastPaymentID, err := repository.GetLastReceivedPaymentID()
+		if err != nil {
+			return err
+		}
+
+		listenerCursor := &entities.ListenerCursor{
+			PagingToken:    set,
+			AfterPaymentID: lastPaymentID,
+			SetAt:          utc.New(c.now()),
+		}
+		err = db.NewEntityManager(driver).Persist(listenerCursor)
+		if err != nil {
+			return err
+		}
+	}
+
+	report := ListenerCursorReport{}
+	report.Cursor, err = repository.GetLastCursorValue()
+	if err != nil {
+		return err
+	}
+	report.Set, err = repository.GetListenerCursor()
+	if err != nil {
+		return err
+	}
+	return c.write(report)
+}
+
+// Reprocess implements `bridge reprocess <operation-id>`. Like /reprocess it sends the receive
+// callback again, successful payments require force.
+func (c *Command) Reprocess(operationID string, force bool) error {
+	if operationID == "" {
+		return protocols.NewMissingParameter("operation_id")
+	}
+	if c.Config.Callbacks.Receive == "" {
+		return errors.New("No callbacks.receive param.")
+	}
+	if !c.Confirmed {
+		return ErrNotConfirmed
+	}
+
+	driver, err := openDB(c.Config)
+	if err != nil {
+		return err
+	}
+	entityManager := db.NewEntityManager(driver)
+	repository := db.NewRepository(driver)
+	h := horizon.New(c.Config.Horizon)
+
+	webhooks, err := webhook.NewClient(c.Config.Callbacks.WebhookSettings())
+	if err != nil {
+		return err
+	}
+
+	days := touchedDays{}
+	paymentListener, err := listener.NewPaymentListener(&c.Config, entityManager, &h, repository, days, webhooks, c.now)
+	if err != nil {
+		return err
+	}
+
+	response := bridge.ReprocessResponse{Status: "ok"}
+	operation, err := h.LoadOperation(operationID)
+	if err == nil {
+		err = paymentListener.ReprocessPayment(operation, force)
+	}
+	if err != nil {
+		response = bridge.ReprocessResponse{Status: "error", Message: err.Error()}
+	}
+
+	aggregator := stats.NewVolumeAggregator(repository, entityManager)
+	for date := range days {
+		err = aggregator.Recompute(date)
+		if err != nil {
+			return fmt.Errorf("Error recomputing daily volumes of %s: %s", date, err)
+		}
+	}
+
+	err = c.write(response)
+	if err != nil {
+		return err
+	}
+	if response.Status != "ok" {
+		return errors.New(response.Message)
+	}
+	return nil
+}
+
+// AccountReport is written by `bridge accounts list` for every configured account
+type AccountReport struct {
+	// Name is a config key of the account, ex. `base_seed`
+	Name      string            `json:"name"`
+	AccountID string            `json:"account_id"`
+	Sequence  string            `json:"sequence,omitempty"`
+	Balances  []horizon.Balance `json:"balances,omitempty"`
+	// Error is set when the account can't be loaded from Horizon
+	Error string `json:"error,omitempty"`
+}
+
+// AccountsList implements `bridge accounts list` reporting configured accounts with their
+// sequence numbers and balances. Seeds are reported as public keys.
+func (c *Command) AccountsList() error {
+	accounts := c.Config.Accounts
+	configured := []struct{ name, value string }{
+		{"authorizing_seed", accounts.AuthorizingSeed},
+		{"base_seed", accounts.BaseSeed},
+		{"issuing_account_id", accounts.IssuingAccountID},
+		{"receiving_account_id", accounts.ReceivingAccountID},
+		{"recovery_seed", accounts.RecoverySeed},
+	}
+
+	h := horizon.New(c.Config.Horizon)
+	reports := []AccountReport{}
+	for _, account := range configured {
+		if account.value == "" {
+			continue
+		}
+
+		// Config validation makes sure keys can be parsed
+		kp, err := keypair.Parse(account.value)
+		if err != nil {
+			return err
+		}
+
+		report := AccountReport{Name: account.name, AccountID: kp.Address()}
+		response, err := h.LoadAccount(report.AccountID)
+		if err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Sequence = response.SequenceNumber
+			report.Balances = response.Balances
+		}
+		reports = append(reports, report)
+	}
+	return c.write(reports)
+}
+
+// LimitsShow implements `bridge limits show` reporting limits enforced by the server with the
+// config file. Zero values are not enforced.
+func (c *Command) LimitsShow() error {
+	report := map[string]interface{}{
+		"path_payments": map[string]interface{}{
+			"slippage_check_threshold": c.Config.PathPayments.SlippageCheckThreshold,
+			"max_slippage":             c.Config.PathPayments.MaxSlippage,
+		},
+		"circuit_breakers": map[string]interface{}{
+			"failure_rate":         c.Config.CircuitBreakers.FailureRate,
+			"min_requests":         c.Config.CircuitBreakers.MinRequests,
+			"window_seconds":       c.Config.CircuitBreakers.WindowSeconds,
+			"open_timeout_seconds": c.Config.CircuitBreakers.OpenTimeoutSeconds,
+		},
+		"callback_timeout_seconds": int(webhook.Timeout / time.Second),
+		"admin_max_page_size":      pagination.MaxLimit,
+	}
+	if c.Config.LeaderElection.Enabled {
+		report["leader_lease_ttl_seconds"] = c.Config.LeaderElection.TTLSeconds
+	}
+	return c.write(report)
+}
+
+// Reconcile implements `bridge reconcile --date <YYYY-MM-DD> [--format json|csv]` writing a
+// reconciliation report of the day. With `--yes` the report replaces the stored report of the
+// day (returned by /admin/reconciliations/{date}) and callbacks.reconciliation is called when it
+// has mismatches.
+func (c *Command) Reconcile(date, format string) error {
+	if date == "" {
+		return protocols.NewMissingParameter("date")
+	}
+	if format != "json" && format != "csv" {
+		return errors.New("Format must be json or csv")
+	}
+
+	driver, err := openDB(c.Config)
+	if err != nil {
+		return err
+	}
+	h := horizon.New(c.Config.Horizon)
+
+	webhooks, err := webhook.NewClient(c.Config.Callbacks.WebhookSettings())
+	if err != nil {
+		return err
+	}
+
+	reconciler := reconciliation.NewReconciler(c.Config.ReconciledAccounts(), &h, db.NewRepository(driver), db.NewEntityManager(driver), c.now)
+	reconciler.Callback = c.Config.Callbacks.Reconciliation
+	reconciler.MACKey = c.Config.MACKey
+	reconciler.Webhooks = webhooks
+
+	report, err := reconciler.Reconcile(date)
+	if err != nil {
+		return err
+	}
+
+	if format == "csv" {
+		err = report.WriteCSV(c.Output)
+	} else {
+		err = c.write(report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.Confirmed {
+		return reconciler.Store(report)
+	}
+	return nil
+}
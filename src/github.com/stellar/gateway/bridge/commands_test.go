@@ -0,0 +1,223 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/loadtest"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCommand returns a command using a migrated SQLite database in a temporary directory
+func newTestCommand(t *testing.T, confirmed bool) (*Command, db.EntityManager, *bytes.Buffer) {
+	dir, err := ioutil.TempDir("", "bridge-commands")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	var c config.Config
+	c.Database.Type = "sqlite"
+	c.Database.URL = filepath.Join(dir, "bridge.db")
+	c.NetworkPassphrase = "Test SDF Network ; September 2015"
+
+	driver, err := openDB(c)
+	require.NoError(t, err)
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	command := NewCommand(c, output, confirmed)
+	command.now = func() time.Time { return time.Date(2017, 3, 1, 9, 30, 15, 0, time.UTC) }
+	return command, db.NewEntityManager(driver), output
+}
+
+func TestCommandTxStatus(t *testing.T) {
+	command, entityManager, output := newTestCommand(t, false)
+
+	hash := "c492d87c4642815dfb3c7dcce01af4effd162b031064098a0d786b6e0a00fd74"
+	ledger := uint64(123)
+	require.NoError(t, entityManager.Persist(&entities.SentTransaction{
+		TransactionID: hash,
+		Status:        entities.SentTransactionStatusSuccess,
+		Source:        "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW",
+		SubmittedAt:   utc.New(command.now()),
+		Ledger:        &ledger,
+		EnvelopeXdr:   "AAAA",
+	}))
+
+	require.NoError(t, command.TxStatus(hash))
+	var transaction entities.SentTransaction
+	require.NoError(t, json.Unmarshal(output.Bytes(), &transaction))
+	assert.Equal(t, entities.SentTransactionStatusSuccess, transaction.Status)
+	assert.Equal(t, ledger, *transaction.Ledger)
+	assert.Equal(t, "2017-03-01T09:30:15Z", transaction.SubmittedAt.String())
+
+	assert.Error(t, command.TxStatus("a00fd74"))
+	assert.Error(t, command.TxStatus("0000000000000000000000000000000000000000000000000000000000000000"))
+}
+
+func TestCommandListenerCursor(t *testing.T) {
+	command, entityManager, output := newTestCommand(t, false)
+
+	cursor := func() ListenerCursorReport {
+		output.Reset()
+		require.NoError(t, command.ListenerCursor(""))
+		var report ListenerCursorReport
+		require.NoError(t, json.Unmarshal(output.Bytes(), &report))
+		return report
+	}
+	receive := func(operationID, pagingToken string) {
+		require.NoError(t, entityManager.Persist(&entities.ReceivedPayment{
+			OperationID: operationID,
+			PagingToken: pagingToken,
+			ProcessedAt: utc.New(command.now()),
+			Status:      "Success",
+		}))
+	}
+
+	assert.Nil(t, cursor().Cursor)
+
+	receive("100", "100")
+	assert.Equal(t, "100", *cursor().Cursor)
+
+	assert.Equal(t, ErrNotConfirmed, command.ListenerCursor("50"))
+	assert.Error(t, command.ListenerCursor("yesterday"))
+
+	command.Confirmed = true
+	require.NoError(t, command.ListenerCursor("50"))
+	report := cursor()
+	assert.Equal(t, "50", *report.Cursor)
+	assert.Equal(t, "50", report.Set.PagingToken)
+
+	// Payments processed after the set cursor move it again
+	receive("51", "51")
+	report = cursor()
+	assert.Equal(t, "51", *report.Cursor)
+	assert.Equal(t, "50", report.Set.PagingToken)
+}
+
+func TestCommandReprocess(t *testing.T) {
+	command, entityManager, output := newTestCommand(t, false)
+
+	var callbacks []string
+	receiveCallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callbacks = append(callbacks, r.PostFormValue("id"))
+	}))
+	defer receiveCallback.Close()
+
+	var horizonURL string
+	horizon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/operations/12884905985":
+			fmt.Fprintf(w, `{
+  "id": "12884905985",
+  "type": "payment",
+  "paging_token": "12884905985",
+  "_links": {"transaction": {"href": "%s/transactions/c492d87c"}},
+  "from": "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW",
+  "to": "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS",
+  "asset_type": "native",
+  "amount": "20.0000000"
+}`, horizonURL)
+		case "/transactions/c492d87c":
+			fmt.Fprint(w, `{"memo_type": "text", "memo": "alice"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer horizon.Close()
+	horizonURL = horizon.URL
+
+	command.Config.Horizon = horizon.URL
+	command.Config.Callbacks.Receive = receiveCallback.URL
+	command.Config.Accounts.ReceivingAccountID = "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+
+	payment := &entities.ReceivedPayment{
+		OperationID: "12884905985",
+		PagingToken: "12884905985",
+		ProcessedAt: utc.New(command.now()),
+		Status:      "Error response from receive callback",
+	}
+	require.NoError(t, entityManager.Persist(payment))
+
+	assert.Equal(t, ErrNotConfirmed, command.Reprocess("12884905985", false))
+	assert.Empty(t, callbacks)
+
+	command.Confirmed = true
+	require.NoError(t, command.Reprocess("12884905985", false))
+	assert.Equal(t, []string{"12884905985"}, callbacks)
+	assert.Contains(t, output.String(), `"status": "ok"`)
+
+	// Successful payments require force
+	output.Reset()
+	assert.Error(t, command.Reprocess("12884905985", false))
+	assert.Contains(t, output.String(), `"status": "error"`)
+	require.NoError(t, command.Reprocess("12884905985", true))
+	assert.Len(t, callbacks, 2)
+
+	assert.Error(t, command.Reprocess("", false))
+}
+
+func TestCommandLimitsShow(t *testing.T) {
+	command, _, output := newTestCommand(t, false)
+	command.Config.PathPayments.MaxSlippage = "0.01"
+
+	require.NoError(t, command.LimitsShow())
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &report))
+	assert.Equal(t, "0.01", report["path_payments"].(map[string]interface{})["max_slippage"])
+	assert.Equal(t, float64(200), report["admin_max_page_size"])
+}
+
+func TestCommandLoadtest(t *testing.T) {
+	var c config.Config
+	c.NetworkPassphrase = "Test SDF Network ; September 2015"
+	c.Accounts.BaseSeed = "SDZT3EJZ7FZRYNTLOZ7VH6G5UYBFO2IO3Q5PGONMILPCZU3AL7QNZHTE"
+	c.Assets = []config.Asset{{Code: "USD", Issuer: "GBIHSMPXC2KJ3NJVHEYTG3KCHYEUQRT45X6AWYWXMAXZOAX4F5LFZYYQ"}}
+
+	plan := loadtest.Plan{
+		RPS:             20,
+		DurationSeconds: 1,
+		Seed:            1,
+		Destinations:    []string{"GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW"},
+		Mix: []loadtest.Payment{
+			{Name: "usd", Weight: 3, Params: map[string]string{"amount": "10", "asset_code": "USD", "asset_issuer": "GBIHSMPXC2KJ3NJVHEYTG3KCHYEUQRT45X6AWYWXMAXZOAX4F5LFZYYQ"}},
+			{Name: "create_account", Weight: 1, Params: map[string]string{"amount": "20"}, NewDestination: true},
+		},
+		Horizon: loadtest.HorizonSettings{Failures: map[string]float64{"op_underfunded": 0.5}},
+	}
+	require.NoError(t, plan.Validate())
+
+	output := &bytes.Buffer{}
+	require.NoError(t, NewCommand(c, output, false).Loadtest(plan, ""))
+
+	var report loadtest.Report
+	require.NoError(t, json.Unmarshal(output.Bytes(), &report))
+	assert.Equal(t, "in-process", report.Target)
+	assert.Equal(t, int64(20), report.Requests)
+	assert.Equal(t, 20, report.Latency.Count)
+	responses := int64(0)
+	for _, count := range report.Codes {
+		responses += count
+	}
+	assert.Equal(t, int64(20), responses)
+	// Stages are reported by the inflight registry of the server. Concurrent payments of the
+	// source fail with tx_bad_seq and are submitted again, every submission is counted.
+	submitting := report.ByStage[inflight.StageSubmitting].Count
+	assert.True(t, submitting >= 20, "submitting count: %d", submitting)
+	require.NotNil(t, report.Inflight)
+	assert.Equal(t, inflight.DefaultSize, report.Inflight.Size)
+}
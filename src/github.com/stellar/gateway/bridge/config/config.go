@@ -2,9 +2,27 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"github.com/stellar/gateway/anomaly"
+	"github.com/stellar/gateway/conversion"
+	"github.com/stellar/gateway/counterparty"
+	"github.com/stellar/gateway/errormap"
+	"github.com/stellar/gateway/jws"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stellar/go/amount"
 	"github.com/stellar/go/keypair"
+	"math/big"
+	"net"
 	"net/url"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config contains config params of the bridge server
@@ -15,15 +33,132 @@ type Config struct {
 	LogFormat         string `mapstructure:"log_format"`
 	MACKey            string `mapstructure:"mac_key"`
 	APIKey            string `mapstructure:"api_key"`
+	OperatorAPIKey    string `mapstructure:"operator_api_key"`
 	NetworkPassphrase string `mapstructure:"network_passphrase"`
+	DisableAutoTrust  bool   `mapstructure:"disable_auto_trust"`
 	Develop           bool
 	Assets            []Asset
-	Database          struct {
-		Type string
-		URL  string
-	}
+	Database          Database
 	Accounts
 	Callbacks
+	PathPayments `mapstructure:"path_payments"`
+	// LogSampling contains initial sample rates (0 to 1) of log categories, ex. `horizon = 0.1`
+	LogSampling map[string]float64 `mapstructure:"log_sampling"`
+	// ForbidAccountCreation fails XLM payments to non-existent destinations like
+	// `forbid_account_creation` param of all requests
+	ForbidAccountCreation bool `mapstructure:"forbid_account_creation"`
+	// AllowUnsignedPayURIs allows /payment `uri` params without origin_domain signature
+	AllowUnsignedPayURIs bool `mapstructure:"allow_unsigned_pay_uris"`
+	// HorizonTimeoutSeconds limits every account load and transaction submission, 30 when 0
+	HorizonTimeoutSeconds int `mapstructure:"horizon_timeout_seconds"`
+	// CircuitBreakers are disabled when failure_rate is not set
+	CircuitBreakers `mapstructure:"circuit_breakers"`
+	// SubmissionRate limits transaction submissions to Horizon, disabled when rate is not set
+	SubmissionRate `mapstructure:"submission_rate"`
+	// LeaderElection runs the payment listener on a single replica sharing the DB
+	LeaderElection `mapstructure:"leader_election"`
+	// HorizonFailures configures capture of failed Horizon exchanges
+	HorizonFailures `mapstructure:"horizon_failures"`
+	// ResolverMetrics configures metrics of federation lookups per destination domain
+	ResolverMetrics `mapstructure:"resolver_metrics"`
+	// Retry contains retry policies of components (`submitter`, `callbacks`, `resolver`), not
+	// configured values use defaults of the component
+	Retry map[string]RetryPolicy
+	// WarmStart prepares caches and Horizon connections in the background after start
+	WarmStart `mapstructure:"warm_start"`
+	// ErrorMapping rewrites error codes of responses for downstream systems
+	ErrorMapping `mapstructure:"error_mapping"`
+	// Counterparties are allow and deny lists of destination domains loaded from files
+	Counterparties
+	// DepositRequirements are rules of payments to destination domains, ex. exchanges accepting
+	// deposits of registered sources only
+	DepositRequirements []DepositRequirement `mapstructure:"deposit_requirements"`
+	// ResponseSigning signs response bodies for clients sending `Accept-Signature: jws`
+	ResponseSigning `mapstructure:"response_signing"`
+	// IssuerInfo adds names and domains of anchors issuing assets to received payments
+	IssuerInfo `mapstructure:"issuer_info"`
+	// BindAddress is an IP address (ex. `::1`) the server listens on, all IPv4 and IPv6 addresses
+	// when empty
+	BindAddress string `mapstructure:"bind_address"`
+	// IPv6Only listens on IPv6 addresses only, a dual-stack socket is used otherwise
+	IPv6Only bool `mapstructure:"ipv6_only"`
+	// ProxyProtocol reads client addresses from PROXY protocol headers of connections from
+	// trusted_proxies
+	ProxyProtocol bool `mapstructure:"proxy_protocol"`
+	// TrustedProxies are CIDRs or IP addresses of load balancers trusted to report client
+	// addresses in PROXY protocol and X-Forwarded-For headers
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// AutoConversion converts received payments of accepted assets into a target asset
+	AutoConversion `mapstructure:"auto_conversion"`
+	// AnomalyDetection flags payments deviating from statistics of payments sent to their
+	// destinations
+	AnomalyDetection `mapstructure:"anomaly_detection"`
+	// Reconciliation compares bridge records of each day with Horizon history
+	Reconciliation `mapstructure:"reconciliation"`
+	// Events journals state transitions of transactions and payments for audit systems
+	Events Events
+	// InternalTransfers configures payments to accounts of the `accounts` group
+	InternalTransfers InternalTransfers `mapstructure:"internal_transfers"`
+	// Queue configures sending of payments sent with `queue=true`
+	Queue Queue
+	// Async configures submission of payments sent with `async=true`
+	Async Async
+	// TransactionBuilder is the backend encoding /payment transactions (`build` or `xdr`), `build`
+	// when empty
+	TransactionBuilder string `mapstructure:"transaction_builder"`
+	// BaseFee is the fee per operation in stroops of /payment transactions sent without `fee`
+	// param, 100 when 0
+	BaseFee uint32 `mapstructure:"base_fee"`
+	// BaseReserve is the base reserve of the network in stroops, accounts created by /payment
+	// need a balance of 2 base reserves. txspec.BaseReserve when 0.
+	BaseReserve uint32 `mapstructure:"base_reserve"`
+	// Channels are channel accounts used as sources of /payment transactions so payments of the
+	// same account are submitted concurrently
+	Channels Channels
+	// DefaultNetwork is the name of the network of top-level horizon, network_passphrase,
+	// database and accounts params, `default` when empty
+	DefaultNetwork string `mapstructure:"default_network"`
+	// Networks are other Stellar networks served by the same server, requests select them with
+	// `network` param or the API key of a network
+	Networks map[string]Network
+
+	// network is the name of the network of a config returned by NetworkConfig
+	network string
+	// networkAccounts are accounts of all networks by name, set by NetworkConfig
+	networkAccounts map[string]Accounts
+	// secretReferences are keys of secrets read from environment variables by Load
+	secretReferences map[string]bool
+}
+
+// Database contains values of `database` config group
+type Database struct {
+	Type string
+	URL  string
+}
+
+// validate checks the database type, `parseTime=true` param is added to mysql URLs
+func (d *Database) validate() error {
+	dbURL, err := url.Parse(d.URL)
+	if err != nil {
+		return errors.New("Cannot parse database.url param")
+	}
+
+	switch d.Type {
+	case "mysql":
+		// Add `parseTime=true` param to mysql url
+		query := dbURL.Query()
+		query.Set("parseTime", "true")
+		dbURL.RawQuery = query.Encode()
+		d.URL = dbURL.String()
+	case "postgres", "sqlite":
+		break
+	case "":
+		// Allow to start gateway server with a single endpoint: /payment
+		break
+	default:
+		return errors.New("Invalid database.type param")
+	}
+	return nil
 }
 
 // Asset represents credit asset
@@ -38,12 +173,520 @@ type Accounts struct {
 	BaseSeed           string `mapstructure:"base_seed"`
 	IssuingAccountID   string `mapstructure:"issuing_account_id"`
 	ReceivingAccountID string `mapstructure:"receiving_account_id"`
+	// RecoverySeed is a seed of a dedicated account used only as a source of pre-authorized recovery transactions
+	RecoverySeed string `mapstructure:"recovery_seed"`
+	// AllowedSigners are additional public keys allowed to sign transactions, used by `bridge verify-signatures`
+	AllowedSigners []string `mapstructure:"allowed_signers"`
+	// Sources are seeds of accounts by name, /payment `source` param can be a name instead of a seed
+	Sources map[string]string
+	// NamedSourcesOnly rejects /payment requests with a seed in `source` param
+	NamedSourcesOnly bool `mapstructure:"named_sources_only"`
+}
+
+// baseSeedAlias references `base_seed` in stored payment requests
+const baseSeedAlias = "base_seed"
+
+// accountKeys are config keys of the group, they are aliases of their accounts
+var accountKeys = map[string]bool{
+	baseSeedAlias:          true,
+	"authorizing_seed":     true,
+	"recovery_seed":        true,
+	"issuing_account_id":   true,
+	"receiving_account_id": true,
+}
+
+// validSourceName matches names of `sources`, they cannot be mistaken for seeds or public keys
+var validSourceName = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// IsSourceName returns true when value has the format of a name of `accounts.sources`
+func IsSourceName(value string) bool {
+	return validSourceName.MatchString(value)
+}
+
+// SeedAlias returns the config key of a configured seed (or its name in `sources`), so it can be
+// stored and logged instead of the secret. It returns an empty string for other seeds.
+func (a Accounts) SeedAlias(seed string) string {
+	if seed == "" {
+		return ""
+	}
+	if seed == a.BaseSeed {
+		return baseSeedAlias
+	}
+	for name, sourceSeed := range a.Sources {
+		if seed == sourceSeed {
+			return name
+		}
+	}
+	return ""
+}
+
+// AliasSeed returns the seed referenced by an alias returned by SeedAlias, an empty string when
+// the seed is no longer configured
+func (a Accounts) AliasSeed(alias string) string {
+	if alias == baseSeedAlias {
+		return a.BaseSeed
+	}
+	return a.Sources[alias]
+}
+
+// AliasAccount returns the account ID of a config key of the group (ex. `receiving_account_id`
+// or `base_seed`) or of a name of `sources`, an empty string when alias is not a key of a
+// configured account
+func (a Accounts) AliasAccount(alias string) string {
+	accounts := map[string]string{
+		"issuing_account_id":   a.IssuingAccountID,
+		"receiving_account_id": a.ReceivingAccountID,
+	}
+	seeds := map[string]string{
+		baseSeedAlias:      a.BaseSeed,
+		"authorizing_seed": a.AuthorizingSeed,
+		"recovery_seed":    a.RecoverySeed,
+	}
+	for name, seed := range a.Sources {
+		seeds[name] = seed
+	}
+	if seed, ok := seeds[alias]; ok {
+		if kp, err := keypair.Parse(seed); err == nil {
+			return kp.Address()
+		}
+		return ""
+	}
+	return accounts[alias]
+}
+
+// validate checks seeds and account IDs of accounts
+func (a Accounts) validate() error {
+	if a.AuthorizingSeed != "" {
+		_, err := keypair.Parse(a.AuthorizingSeed)
+		if err != nil {
+			return errors.New("accounts.authorizing_seed is invalid")
+		}
+	}
+
+	if a.BaseSeed != "" {
+		_, err := keypair.Parse(a.BaseSeed)
+		if err != nil {
+			return errors.New("accounts.base_seed is invalid")
+		}
+	}
+
+	if a.IssuingAccountID != "" {
+		_, err := keypair.Parse(a.IssuingAccountID)
+		if err != nil {
+			return errors.New("accounts.issuing_account_id is invalid")
+		}
+	}
+
+	if a.ReceivingAccountID != "" {
+		_, err := keypair.Parse(a.ReceivingAccountID)
+		if err != nil {
+			return errors.New("accounts.receiving_account_id is invalid")
+		}
+	}
+
+	if a.RecoverySeed != "" {
+		_, err := keypair.Parse(a.RecoverySeed)
+		if err != nil {
+			return errors.New("accounts.recovery_seed is invalid")
+		}
+	}
+
+	for name, seed := range a.Sources {
+		if !IsSourceName(name) {
+			return errors.New("accounts.sources: names must be at most 32 lowercase letters, digits, `_` or `-`, got " + name)
+		}
+		if accountKeys[name] {
+			return errors.New("accounts.sources: " + name + " is a key of accounts and cannot be a name")
+		}
+		kp, err := keypair.Parse(seed)
+		if _, ok := kp.(*keypair.Full); err != nil || !ok {
+			return errors.New("accounts.sources." + name + " is invalid")
+		}
+	}
+
+	for _, signer := range a.AllowedSigners {
+		_, err := keypair.Parse(signer)
+		if err != nil {
+			return errors.New("accounts.allowed_signers contains invalid key " + signer)
+		}
+	}
+	return nil
+}
+
+// Channels contains values of `channels` config group
+type Channels struct {
+	// Seeds are seeds of channel accounts, channels are not used when empty
+	Seeds []string
+	// LeaseWaitSeconds is how long a payment waits for a free channel before failing,
+	// channels.DefaultLeaseWait when 0
+	LeaseWaitSeconds float64 `mapstructure:"lease_wait_seconds"`
+}
+
+// LeaseWait returns lease_wait_seconds as a duration
+func (c Channels) LeaseWait() time.Duration {
+	return time.Duration(c.LeaseWaitSeconds * float64(time.Second))
+}
+
+// validate checks channel seeds, channels must be distinct accounts other than accounts of the
+// bridge
+func (c Channels) validate(accounts Accounts) error {
+	if c.LeaseWaitSeconds < 0 {
+		return errors.New("channels.lease_wait_seconds param cannot be negative")
+	}
+
+	addresses := map[string]bool{}
+	for _, seed := range c.Seeds {
+		kp, err := keypair.Parse(seed)
+		if err != nil || kp.Address() == seed {
+			return errors.New("channels.seeds contains invalid seed")
+		}
+		if addresses[kp.Address()] {
+			return errors.New("channels.seeds contains duplicate seed of " + kp.Address())
+		}
+		if accounts.HasAccount(kp.Address()) {
+			return errors.New("channels.seeds cannot contain accounts of accounts group: " + kp.Address())
+		}
+		addresses[kp.Address()] = true
+	}
+	return nil
 }
 
 // Callbacks contains values of `callbacks` config group
 type Callbacks struct {
 	Receive string
 	Error   string
+	// PaymentRequest is called when a payment request is fulfilled or expires
+	PaymentRequest string `mapstructure:"payment_request"`
+	// Admin is called on events of monitored accounts, ex. when the receiving account is merged
+	Admin string
+	// Reconciliation is called when a reconciliation report has mismatches
+	Reconciliation string
+	// Queue is called when a payment sent with `queue=true` is settled
+	Queue string
+	// AllowedHosts are host patterns (ex. `*.example.com`) callback URLs must match, any host when empty
+	AllowedHosts []string `mapstructure:"allowed_hosts"`
+	// TLS contains TLS options by callback name (`receive`, `error`, `payment_request`, `admin`,
+	// `reconciliation`, `queue`)
+	TLS map[string]webhook.TLSOptions
+}
+
+// WebhookSettings returns settings of a callbacks client
+func (c Callbacks) WebhookSettings() webhook.Settings {
+	return webhook.Settings{
+		Destinations: map[string]string{
+			"receive":         c.Receive,
+			"error":           c.Error,
+			"payment_request": c.PaymentRequest,
+			"admin":           c.Admin,
+			"reconciliation":  c.Reconciliation,
+			"queue":           c.Queue,
+		},
+		AllowedHosts: c.AllowedHosts,
+		TLS:          c.TLS,
+	}
+}
+
+// PathPayments contains values of `path_payments` config group
+type PathPayments struct {
+	// SlippageCheckThreshold is a destination amount above which order books are checked before sending
+	SlippageCheckThreshold string `mapstructure:"slippage_check_threshold"`
+	// MaxSlippage is the maximum allowed estimated slippage, ex. 0.01 for 1%
+	MaxSlippage string `mapstructure:"max_slippage"`
+}
+
+// CircuitBreakers contains values of `circuit_breakers` config group
+type CircuitBreakers struct {
+	// FailureRate (0 to 1) of Horizon or federation requests in a window that opens a breaker
+	FailureRate float64 `mapstructure:"failure_rate"`
+	// MinRequests is a number of requests in a window required before the failure rate is checked
+	MinRequests        int `mapstructure:"min_requests"`
+	WindowSeconds      int `mapstructure:"window_seconds"`
+	OpenTimeoutSeconds int `mapstructure:"open_timeout_seconds"`
+}
+
+// SubmissionRate contains values of `submission_rate` config group
+type SubmissionRate struct {
+	// Rate is a number of transactions per second submitted to Horizon by all components
+	Rate float64
+	// Burst is a number of transactions submitted at once after a quiet period, 1 when 0
+	Burst int
+}
+
+// validate checks rate and burst of the group, prefix is prepended to errors
+func (s *SubmissionRate) validate(prefix string) error {
+	if s.Rate < 0 {
+		return errors.New(prefix + "submission_rate.rate param must not be negative")
+	}
+	if s.Burst < 0 {
+		return errors.New(prefix + "submission_rate.burst param must not be negative")
+	}
+	return nil
+}
+
+// LeaderElection contains values of `leader_election` config group
+type LeaderElection struct {
+	Enabled    bool
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// Replica identifies this replica in the lease, a hostname with a random suffix when empty
+	Replica string
+	// HandoffTimeoutSeconds is how long a replica of a new version waits for the leader to hand
+	// the lease off before taking it over, handoffs are disabled when 0
+	HandoffTimeoutSeconds int `mapstructure:"handoff_timeout_seconds"`
+}
+
+// HorizonFailures contains values of `horizon_failures` config group
+type HorizonFailures struct {
+	// Size is a number of failed exchanges kept per Horizon endpoint, 20 when 0
+	Size int
+	// AttachID adds `horizon_failure_id` to error responses caused by a captured failure
+	AttachID bool `mapstructure:"attach_id"`
+}
+
+// ResolverMetrics contains values of `resolver_metrics` config group
+type ResolverMetrics struct {
+	// MaxDomains is a number of domains with their own metrics, lookups of other domains are
+	// counted together. 100 when 0.
+	MaxDomains int `mapstructure:"max_domains"`
+}
+
+// RetryPolicy contains values of `retry.<component>` config group
+type RetryPolicy struct {
+	// MaxAttempts is a number of attempts including the first one
+	MaxAttempts        int     `mapstructure:"max_attempts"`
+	BaseBackoffSeconds float64 `mapstructure:"base_backoff_seconds"`
+	MaxBackoffSeconds  float64 `mapstructure:"max_backoff_seconds"`
+	// Jitter (0 to 1) is a randomized fraction of backoffs
+	Jitter float64
+	// MaxRateLimitWaitSeconds is a total wait for resets of rate limits of a call
+	MaxRateLimitWaitSeconds float64 `mapstructure:"max_rate_limit_wait_seconds"`
+}
+
+// WarmStart contains values of `warm_start` config group
+type WarmStart struct {
+	Enabled bool
+	// FederationAddresses are frequent recipients resolved at start and cached, reloaded by
+	// /admin/reload
+	FederationAddresses []string `mapstructure:"federation_addresses"`
+	// FederationCacheSeconds is a time federation responses are cached, 300 when 0
+	FederationCacheSeconds int `mapstructure:"federation_cache_seconds"`
+	// HorizonConnections is a number of keep-alive connections opened to Horizon, 2 when 0
+	HorizonConnections int `mapstructure:"horizon_connections"`
+}
+
+// ErrorMapping contains values of `error_mapping` config group
+type ErrorMapping struct {
+	// Codes are mappings by bridge error code, codes not listed are returned unchanged
+	Codes map[string]errormap.Mapping
+	// Webhooks applies the mappings to errors in webhook payloads
+	Webhooks bool
+}
+
+// Counterparties contains values of `counterparties` config group
+type Counterparties struct {
+	// AllowFile is a CSV or JSON lines file of domains payments can be sent to, any domain when empty
+	AllowFile string `mapstructure:"allow_file"`
+	// DenyFile is a CSV or JSON lines file of domains payments cannot be sent to
+	DenyFile string `mapstructure:"deny_file"`
+}
+
+// CounterpartySettings returns settings of counterparty lists
+func (c Counterparties) CounterpartySettings() counterparty.Settings {
+	return counterparty.Settings{AllowFile: c.AllowFile, DenyFile: c.DenyFile}
+}
+
+// ResponseSigning contains values of `response_signing` config group
+type ResponseSigning struct {
+	// SigningSeed signs responses, signing is disabled when empty
+	SigningSeed string `mapstructure:"signing_seed"`
+	// KeyID identifies the signing key in signatures and the key set
+	KeyID string `mapstructure:"key_id"`
+	// PreviousKeys are public keys of rotated signing keys by their key IDs, still served to
+	// verify responses signed before the rotation
+	PreviousKeys map[string]string `mapstructure:"previous_keys"`
+}
+
+// IssuerInfo contains values of `issuer_info` config group
+type IssuerInfo struct {
+	// Disabled stops lookups of issuer home domains and stellar.toml files
+	Disabled bool
+	// CacheHours is a time stellar.toml files of issuers are cached, 72 when 0
+	CacheHours int `mapstructure:"cache_hours"`
+	// WaitSeconds is a time receive callbacks wait for an issuer that is not cached, 1 when 0
+	WaitSeconds float64 `mapstructure:"wait_seconds"`
+}
+
+// AutoConversion contains values of `auto_conversion` config group
+type AutoConversion struct {
+	// Seed is a seed of accounts.receiving_account_id, it signs path payments of conversions
+	Seed string
+	// Target is the asset received payments are converted into, one of `assets`
+	Target Asset
+	// MaxSlippage is the maximum estimated slippage of a conversion, ex. 0.01 for 1%
+	MaxSlippage string `mapstructure:"max_slippage"`
+	// Assets are accepted assets converted into Target, conversion is disabled when empty
+	Assets []ConvertedAsset
+}
+
+// ConvertedAsset contains values of `auto_conversion.assets` config group
+type ConvertedAsset struct {
+	Code   string
+	Issuer string
+	// MinAmount is the smallest received amount that is converted, smaller payments are not
+	// processed
+	MinAmount string `mapstructure:"min_amount"`
+}
+
+// Enabled returns true when assets are converted
+func (a AutoConversion) Enabled() bool {
+	return len(a.Assets) > 0
+}
+
+// ConversionSettings returns settings of a converter, XLM is configured with `XLM` code
+func (a AutoConversion) ConversionSettings() (settings conversion.Settings, err error) {
+	settings.Seed = a.Seed
+	settings.Target = conversionAsset(a.Target.Code, a.Target.Issuer)
+
+	for _, asset := range a.Assets {
+		converted := conversion.Asset{Asset: conversionAsset(asset.Code, asset.Issuer)}
+		if asset.MinAmount != "" {
+			converted.MinAmount, err = amount.Parse(asset.MinAmount)
+			if err != nil {
+				err = fmt.Errorf("cannot parse min_amount of %s", asset.Code)
+				return
+			}
+		}
+		settings.Assets = append(settings.Assets, converted)
+	}
+
+	var ok bool
+	settings.MaxSlippage, ok = new(big.Rat).SetString(a.MaxSlippage)
+	if !ok {
+		err = errors.New("max_slippage is invalid")
+		return
+	}
+
+	err = settings.Validate()
+	return
+}
+
+// AnomalyDetection contains values of `anomaly_detection` config group
+type AnomalyDetection struct {
+	// Policy is `log`, `approve` or `block`, detection is disabled when empty
+	Policy string
+	// MinPayments is the number of previous payments to a destination needed to flag a payment,
+	// anomaly.DefaultMinPayments when 0
+	MinPayments int64 `mapstructure:"min_payments"`
+	// MaxZScore flags amounts more than max_z_score standard deviations above the mean
+	MaxZScore float64 `mapstructure:"max_z_score"`
+	// MaxPercentile flags amounts above the percentile of previous amounts, ex. 99
+	MaxPercentile float64 `mapstructure:"max_percentile"`
+}
+
+// Enabled returns true when payments are checked
+func (a AnomalyDetection) Enabled() bool {
+	return a.Policy != ""
+}
+
+// DetectorSettings returns settings of an anomaly detector
+func (a AnomalyDetection) DetectorSettings() anomaly.Settings {
+	return anomaly.Settings{
+		MinPayments:   a.MinPayments,
+		MaxZScore:     a.MaxZScore,
+		MaxPercentile: a.MaxPercentile,
+		Policy:        a.Policy,
+	}
+}
+
+// Reconciliation contains values of `reconciliation` config group
+type Reconciliation struct {
+	// Enabled reconciles the previous day every day at Hour (UTC)
+	Enabled bool
+	Hour    int
+	// Accounts are compared in addition to accounts of the bridge and source accounts of sent
+	// payments
+	Accounts []string
+}
+
+// Events contains values of `events` config group
+type Events struct {
+	// Enabled writes events to the DB, they are read by /admin/events
+	Enabled bool
+	// BufferSize is the number of events waiting to be written, events.DefaultBufferSize when 0
+	BufferSize int `mapstructure:"buffer_size"`
+}
+
+// InternalTransfers contains values of `internal_transfers` config group
+type InternalTransfers struct {
+	// SkipChecks sends internal transfers without deposit requirements and anomaly detection
+	SkipChecks bool `mapstructure:"skip_checks"`
+	// OperatorOnly rejects internal transfers of requests without the operator role
+	OperatorOnly bool `mapstructure:"operator_only"`
+}
+
+// Queue contains values of `queue` config group
+type Queue struct {
+	// IntervalSeconds is the time between attempts to send queued payments while Horizon is
+	// unreachable, 10 when 0
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// MaxAttempts fails a queued payment after a number of attempts, payments are retried until
+	// they are sent when 0
+	MaxAttempts int `mapstructure:"max_attempts"`
+}
+
+// Async contains values of `async` config group
+type Async struct {
+	// Workers is the number of async payment transactions submitted at the same time,
+	// async.DefaultWorkers when 0
+	Workers int
+}
+
+// ReconciledAccounts returns configured accounts whose payments are reconciled
+func (c *Config) ReconciledAccounts() []string {
+	accounts := []string{}
+	for _, seed := range []string{c.Accounts.BaseSeed, c.Accounts.AuthorizingSeed} {
+		if kp, err := keypair.Parse(seed); err == nil {
+			accounts = append(accounts, kp.Address())
+		}
+	}
+	if c.Accounts.ReceivingAccountID != "" {
+		accounts = append(accounts, c.Accounts.ReceivingAccountID)
+	}
+	return append(accounts, c.Reconciliation.Accounts...)
+}
+
+func conversionAsset(code, issuer string) protocols.Asset {
+	if code == "XLM" && issuer == "" {
+		return protocols.Asset{}
+	}
+	return protocols.Asset{Code: code, Issuer: issuer}
+}
+
+// ListenNetwork returns the network and address the server listens on
+func (c *Config) ListenNetwork() (network, address string) {
+	network = "tcp"
+	if c.IPv6Only {
+		network = "tcp6"
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(c.BindAddress, "["), "]")
+	if host == "" && c.IPv6Only {
+		host = "::"
+	}
+	return network, net.JoinHostPort(host, strconv.Itoa(*c.Port))
+}
+
+// RetrySettings returns settings of configured retry policies by component
+func (c *Config) RetrySettings() map[string]retry.Settings {
+	settings := make(map[string]retry.Settings, len(c.Retry))
+	for name, policy := range c.Retry {
+		settings[name] = retry.Settings{
+			MaxAttempts:      policy.MaxAttempts,
+			BaseBackoff:      time.Duration(policy.BaseBackoffSeconds * float64(time.Second)),
+			MaxBackoff:       time.Duration(policy.MaxBackoffSeconds * float64(time.Second)),
+			Jitter:           policy.Jitter,
+			MaxRateLimitWait: time.Duration(policy.MaxRateLimitWaitSeconds * float64(time.Second)),
+		}
+	}
+	return settings
 }
 
 // Validate validates config and returns error if any of config values is incorrect
@@ -53,6 +696,29 @@ func (c *Config) Validate() (err error) {
 		return
 	}
 
+	if c.BindAddress != "" {
+		ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(c.BindAddress, "["), "]"))
+		if ip == nil {
+			err = errors.New("bind_address param must be an IP address")
+			return
+		}
+		if c.IPv6Only && ip.To4() != nil {
+			err = errors.New("bind_address param must be an IPv6 address when ipv6_only is set")
+			return
+		}
+	}
+
+	_, err = server.ParseTrustedProxies(c.TrustedProxies)
+	if err != nil {
+		err = errors.New("trusted_proxies param is invalid: " + err.Error())
+		return
+	}
+
+	if c.ProxyProtocol && len(c.TrustedProxies) == 0 {
+		err = errors.New("trusted_proxies param is required when proxy_protocol is set")
+		return
+	}
+
 	if c.Horizon == "" {
 		err = errors.New("horizon param is required")
 		return
@@ -64,6 +730,11 @@ func (c *Config) Validate() (err error) {
 		return
 	}
 
+	if c.HorizonTimeoutSeconds < 0 {
+		err = errors.New("horizon_timeout_seconds param cannot be negative")
+		return
+	}
+
 	if c.NetworkPassphrase == "" {
 		err = errors.New("network_passphrase param is required")
 		return
@@ -96,74 +767,262 @@ func (c *Config) Validate() (err error) {
 		}
 	}
 
-	var dbURL *url.URL
-	dbURL, err = url.Parse(c.Database.URL)
+	err = c.Database.validate()
 	if err != nil {
-		err = errors.New("Cannot parse database.url param")
 		return
 	}
 
-	switch c.Database.Type {
-	case "mysql":
-		// Add `parseTime=true` param to mysql url
-		query := dbURL.Query()
-		query.Set("parseTime", "true")
-		dbURL.RawQuery = query.Encode()
-		c.Database.URL = dbURL.String()
-	case "postgres":
-		break
-	case "":
-		// Allow to start gateway server with a single endpoint: /payment
-		break
-	default:
-		err = errors.New("Invalid database.type param")
+	err = c.Accounts.validate()
+	if err != nil {
 		return
 	}
 
-	if c.Accounts.AuthorizingSeed != "" {
-		_, err = keypair.Parse(c.Accounts.AuthorizingSeed)
+	if c.PathPayments.SlippageCheckThreshold != "" {
+		_, err = amount.Parse(c.PathPayments.SlippageCheckThreshold)
 		if err != nil {
-			err = errors.New("accounts.authorizing_seed is invalid")
+			err = errors.New("Cannot parse path_payments.slippage_check_threshold param")
+			return
+		}
+
+		maxSlippage, ok := new(big.Rat).SetString(c.PathPayments.MaxSlippage)
+		if !ok || maxSlippage.Sign() < 0 {
+			err = errors.New("path_payments.max_slippage param is invalid")
 			return
 		}
 	}
 
-	if c.Accounts.BaseSeed != "" {
-		_, err = keypair.Parse(c.Accounts.BaseSeed)
-		if err != nil {
-			err = errors.New("accounts.base_seed is invalid")
+	if c.CircuitBreakers.FailureRate != 0 {
+		if c.CircuitBreakers.FailureRate < 0 || c.CircuitBreakers.FailureRate > 1 {
+			err = errors.New("circuit_breakers.failure_rate param must be between 0 and 1")
+			return
+		}
+
+		if c.CircuitBreakers.MinRequests < 0 {
+			err = errors.New("circuit_breakers.min_requests param is invalid")
+			return
+		}
+
+		if c.CircuitBreakers.WindowSeconds <= 0 || c.CircuitBreakers.OpenTimeoutSeconds <= 0 {
+			err = errors.New("circuit_breakers.window_seconds and circuit_breakers.open_timeout_seconds params are required")
 			return
 		}
 	}
 
-	if c.Accounts.IssuingAccountID != "" {
-		_, err = keypair.Parse(c.Accounts.IssuingAccountID)
-		if err != nil {
-			err = errors.New("accounts.issuing_account_id is invalid")
+	err = c.SubmissionRate.validate("")
+	if err != nil {
+		return
+	}
+
+	if c.LeaderElection.Enabled {
+		if c.Database.Type == "" {
+			err = errors.New("leader_election requires a database")
+			return
+		}
+
+		if c.LeaderElection.TTLSeconds < 10 {
+			err = errors.New("leader_election.ttl_seconds param must be at least 10")
+			return
+		}
+
+		if c.LeaderElection.HandoffTimeoutSeconds < 0 {
+			err = errors.New("leader_election.handoff_timeout_seconds param cannot be negative")
 			return
 		}
 	}
 
-	if c.Accounts.ReceivingAccountID != "" {
-		_, err = keypair.Parse(c.Accounts.ReceivingAccountID)
-		if err != nil {
-			err = errors.New("accounts.receiving_account_id is invalid")
+	if c.HorizonFailures.Size < 0 {
+		err = errors.New("horizon_failures.size param cannot be negative")
+		return
+	}
+
+	err = c.Channels.validate(c.Accounts)
+	if err != nil {
+		return
+	}
+
+	if c.ResolverMetrics.MaxDomains < 0 {
+		err = errors.New("resolver_metrics.max_domains param cannot be negative")
+		return
+	}
+
+	for name, policy := range c.Retry {
+		known := false
+		for _, component := range retry.Components {
+			known = known || name == component
+		}
+		if !known {
+			err = fmt.Errorf("retry.%s is not a component with a retry policy", name)
+			return
+		}
+
+		if policy.MaxAttempts < 0 || policy.BaseBackoffSeconds < 0 || policy.MaxBackoffSeconds < 0 || policy.MaxRateLimitWaitSeconds < 0 {
+			err = fmt.Errorf("retry.%s params cannot be negative", name)
+			return
+		}
+
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			err = fmt.Errorf("retry.%s.jitter param must be between 0 and 1", name)
 			return
 		}
 	}
 
-	if c.Callbacks.Receive != "" {
-		_, err = url.Parse(c.Callbacks.Receive)
+	if c.WarmStart.FederationCacheSeconds < 0 || c.WarmStart.HorizonConnections < 0 {
+		err = errors.New("warm_start params cannot be negative")
+		return
+	}
+
+	for _, address := range c.WarmStart.FederationAddresses {
+		if !strings.Contains(address, "*") {
+			err = fmt.Errorf("warm_start.federation_addresses param contains invalid address: %s", address)
+			return
+		}
+	}
+
+	if c.IssuerInfo.CacheHours < 0 || c.IssuerInfo.WaitSeconds < 0 {
+		err = errors.New("issuer_info params cannot be negative")
+		return
+	}
+
+	for name, path := range map[string]string{"allow_file": c.Counterparties.AllowFile, "deny_file": c.Counterparties.DenyFile} {
+		if path == "" {
+			continue
+		}
+		if _, formatErr := counterparty.FormatOf(path); formatErr != nil {
+			err = fmt.Errorf("counterparties.%s param is invalid: %s", name, formatErr)
+			return
+		}
+	}
+
+	err = c.validateDepositRequirements()
+	if err != nil {
+		return
+	}
+
+	if c.ResponseSigning.SigningSeed != "" {
+		_, signerErr := jws.NewSigner(c.ResponseSigning.SigningSeed, c.ResponseSigning.KeyID, c.ResponseSigning.PreviousKeys)
+		if signerErr != nil {
+			err = errors.New("response_signing is invalid: " + signerErr.Error())
+			return
+		}
+	}
+
+	if c.AutoConversion.Enabled() {
+		_, settingsErr := c.AutoConversion.ConversionSettings()
+		if settingsErr != nil {
+			err = errors.New("auto_conversion is invalid: " + settingsErr.Error())
+			return
+		}
+
+		if c.Database.Type == "" {
+			err = errors.New("auto_conversion requires a database")
+			return
+		}
+
+		if c.Accounts.ReceivingAccountID == "" || keypair.MustParse(c.AutoConversion.Seed).Address() != c.Accounts.ReceivingAccountID {
+			err = errors.New("auto_conversion.seed must be a seed of accounts.receiving_account_id")
+			return
+		}
+
+		allowed := false
+		for _, asset := range c.Assets {
+			allowed = allowed || asset == c.AutoConversion.Target
+		}
+		if !allowed {
+			err = errors.New("auto_conversion.target must be one of assets")
+			return
+		}
+	}
+
+	if c.AnomalyDetection.Enabled() {
+		if settingsErr := c.AnomalyDetection.DetectorSettings().Validate(); settingsErr != nil {
+			err = errors.New("anomaly_detection is invalid: " + settingsErr.Error())
+			return
+		}
+
+		if c.Database.Type == "" {
+			err = errors.New("anomaly_detection requires a database")
+			return
+		}
+	}
+
+	if c.Reconciliation.Hour < 0 || c.Reconciliation.Hour > 23 {
+		err = errors.New("reconciliation.hour must be between 0 and 23")
+		return
+	}
+
+	for _, account := range c.Reconciliation.Accounts {
+		_, err = keypair.Parse(account)
 		if err != nil {
-			err = errors.New("Cannot parse callbacks.receive param")
+			err = errors.New("reconciliation.accounts contains invalid account " + account)
 			return
 		}
 	}
 
-	if c.Callbacks.Error != "" {
-		_, err = url.Parse(c.Callbacks.Error)
+	if c.Reconciliation.Enabled && c.Database.Type == "" {
+		err = errors.New("reconciliation requires a database")
+		return
+	}
+
+	if c.Events.BufferSize < 0 {
+		err = errors.New("events.buffer_size must be positive")
+		return
+	}
+
+	if c.Events.Enabled && c.Database.Type == "" {
+		err = errors.New("events requires a database")
+		return
+	}
+
+	if c.Queue.IntervalSeconds < 0 || c.Queue.MaxAttempts < 0 {
+		err = errors.New("queue.interval_seconds and queue.max_attempts must not be negative")
+		return
+	}
+
+	if c.Async.Workers < 0 {
+		err = errors.New("async.workers must not be negative")
+		return
+	}
+
+	if c.InternalTransfers.OperatorOnly && c.OperatorAPIKey == "" {
+		err = errors.New("internal_transfers.operator_only requires operator_api_key")
+		return
+	}
+
+	if txspec.Backend(c.TransactionBuilder) == nil {
+		err = errors.New("transaction_builder must be build or xdr")
+		return
+	}
+
+	if c.BaseFee != 0 && c.BaseFee < txspec.BaseFee {
+		err = fmt.Errorf("base_fee must be at least %d", txspec.BaseFee)
+		return
+	}
+
+	err = c.validateNetworks()
+	if err != nil {
+		return
+	}
+
+	_, err = logging.NewSampler(c.LogSampling)
+	if err != nil {
+		err = errors.New("log_sampling is invalid: " + err.Error())
+		return
+	}
+
+	for name, callback := range c.Callbacks.WebhookSettings().Destinations {
+		if callback == "" {
+			continue
+		}
+
+		var callbackURL *url.URL
+		callbackURL, err = url.Parse(callback)
 		if err != nil {
-			err = errors.New("Cannot parse callbacks.error param")
+			err = errors.New("Cannot parse callbacks." + name + " param")
+			return
+		}
+
+		if !webhook.HostAllowed(c.Callbacks.AllowedHosts, callbackURL) {
+			err = errors.New("callbacks." + name + " host is not in callbacks.allowed_hosts")
 			return
 		}
 	}
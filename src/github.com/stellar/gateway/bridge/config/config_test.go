@@ -0,0 +1,488 @@
+package config
+
+import (
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigListenNetwork(t *testing.T) {
+	port := 8006
+	tests := []struct {
+		bindAddress string
+		ipv6Only    bool
+		network     string
+		address     string
+		err         string
+	}{
+		{"", false, "tcp", ":8006", ""},
+		{"", true, "tcp6", "[::]:8006", ""},
+		{"::", false, "tcp", "[::]:8006", ""},
+		{"[2001:db8::1]", true, "tcp6", "[2001:db8::1]:8006", ""},
+		{"127.0.0.1", false, "tcp", "127.0.0.1:8006", ""},
+		{"127.0.0.1", true, "", "", "bind_address param must be an IPv6 address when ipv6_only is set"},
+		{"bridge.example.com", false, "", "", "bind_address param must be an IP address"},
+	}
+
+	for _, test := range tests {
+		c := Config{Port: &port, BindAddress: test.bindAddress, IPv6Only: test.ipv6Only}
+		// Other params are missing, Validate fails with horizon param error after bind_address is checked
+		err := c.Validate()
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.bindAddress)
+			continue
+		}
+		assert.EqualError(t, err, "horizon param is required", test.bindAddress)
+
+		network, address := c.ListenNetwork()
+		assert.Equal(t, test.network, network)
+		assert.Equal(t, test.address, address)
+		_, _, err = net.SplitHostPort(address)
+		assert.NoError(t, err)
+	}
+}
+
+func TestConfigTrustedProxies(t *testing.T) {
+	port := 8006
+	c := Config{Port: &port, TrustedProxies: []string{"10.0.0.0/8", "10.0.0.300"}}
+	assert.EqualError(t, c.Validate(), "trusted_proxies param is invalid: invalid IP address: 10.0.0.300")
+
+	c = Config{Port: &port, ProxyProtocol: true}
+	assert.EqualError(t, c.Validate(), "trusted_proxies param is required when proxy_protocol is set")
+
+	c = Config{Port: &port, ProxyProtocol: true, TrustedProxies: []string{"fd00::/8"}}
+	assert.EqualError(t, c.Validate(), "horizon param is required")
+}
+
+func TestConfigAutoConversion(t *testing.T) {
+	receiving, err := keypair.Random()
+	require.NoError(t, err)
+	usd := Asset{Code: "USD", Issuer: "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"}
+
+	port := 8006
+	valid := func() Config {
+		c := Config{
+			Port:              &port,
+			Horizon:           "https://horizon-testnet.stellar.org",
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Assets:            []Asset{usd},
+			Accounts:          Accounts{ReceivingAccountID: receiving.Address()},
+			AutoConversion: AutoConversion{
+				Seed:        receiving.Seed(),
+				Target:      usd,
+				MaxSlippage: "0.02",
+				Assets:      []ConvertedAsset{{Code: "XLM", MinAmount: "10"}},
+			},
+		}
+		c.Database.Type = "sqlite"
+		return c
+	}
+
+	c := valid()
+	require.NoError(t, c.Validate())
+	settings, err := c.AutoConversion.ConversionSettings()
+	require.NoError(t, err)
+	assert.Equal(t, protocols.Asset{}, settings.Assets[0].Asset)
+	assert.Equal(t, xdr.Int64(10*1e7), settings.Assets[0].MinAmount)
+	assert.Equal(t, big.NewRat(2, 100), settings.MaxSlippage)
+
+	c = valid()
+	c.AutoConversion.Assets[0].MinAmount = "ten"
+	assert.EqualError(t, c.Validate(), "auto_conversion is invalid: cannot parse min_amount of XLM")
+
+	c = valid()
+	c.AutoConversion.MaxSlippage = ""
+	assert.EqualError(t, c.Validate(), "auto_conversion is invalid: max_slippage is invalid")
+
+	c = valid()
+	c.Database.Type = ""
+	assert.EqualError(t, c.Validate(), "auto_conversion requires a database")
+
+	c = valid()
+	c.Accounts.ReceivingAccountID = "GBL27BKG2JSDU6KQ5YJKCDWTVIU24VTG4PLB63SF4K2DBZS5XZMWRPVU"
+	assert.EqualError(t, c.Validate(), "auto_conversion.seed must be a seed of accounts.receiving_account_id")
+
+	c = valid()
+	c.Assets = nil
+	assert.EqualError(t, c.Validate(), "auto_conversion.target must be one of assets")
+
+	// Disabled without assets
+	c = valid()
+	c.AutoConversion = AutoConversion{Seed: "invalid"}
+	assert.NoError(t, c.Validate())
+}
+
+func TestConfigAnomalyDetection(t *testing.T) {
+	port := 8006
+	valid := func() Config {
+		c := Config{
+			Port:              &port,
+			Horizon:           "https://horizon-testnet.stellar.org",
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			AnomalyDetection:  AnomalyDetection{Policy: "approve", MaxZScore: 4, MaxPercentile: 99},
+		}
+		c.Database.Type = "sqlite"
+		return c
+	}
+
+	c := valid()
+	require.NoError(t, c.Validate())
+
+	c = valid()
+	c.AnomalyDetection.Policy = "ignore"
+	assert.EqualError(t, c.Validate(), "anomaly_detection is invalid: policy must be one of log, approve, block")
+
+	c = valid()
+	c.AnomalyDetection.MaxPercentile = 100
+	assert.EqualError(t, c.Validate(), "anomaly_detection is invalid: max_percentile must be between 0 and 100")
+
+	c = valid()
+	c.Database.Type = ""
+	assert.EqualError(t, c.Validate(), "anomaly_detection requires a database")
+
+	// Disabled without policy
+	c = valid()
+	c.AnomalyDetection = AnomalyDetection{MaxZScore: -1}
+	assert.NoError(t, c.Validate())
+}
+
+func TestConfigReconciliation(t *testing.T) {
+	port := 8006
+	valid := func() Config {
+		c := Config{
+			Port:              &port,
+			Horizon:           "https://horizon-testnet.stellar.org",
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Reconciliation: Reconciliation{
+				Enabled:  true,
+				Hour:     2,
+				Accounts: []string{"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"},
+			},
+		}
+		c.Accounts.BaseSeed = "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"
+		c.Accounts.ReceivingAccountID = "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+		c.Database.Type = "sqlite"
+		return c
+	}
+
+	c := valid()
+	require.NoError(t, c.Validate())
+	assert.Equal(t, []string{
+		"GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ",
+		"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS",
+		"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+	}, c.ReconciledAccounts())
+
+	c = valid()
+	c.Reconciliation.Hour = 24
+	assert.EqualError(t, c.Validate(), "reconciliation.hour must be between 0 and 23")
+
+	c = valid()
+	c.Reconciliation.Accounts = []string{"invalid"}
+	assert.EqualError(t, c.Validate(), "reconciliation.accounts contains invalid account invalid")
+
+	c = valid()
+	c.Database.Type = ""
+	assert.EqualError(t, c.Validate(), "reconciliation requires a database")
+
+	c = valid()
+	c.Callbacks.Reconciliation = "http://other.example.com/reconciliation"
+	c.Callbacks.AllowedHosts = []string{"*.stellar.org"}
+	assert.EqualError(t, c.Validate(), "callbacks.reconciliation host is not in callbacks.allowed_hosts")
+}
+
+func TestConfigEvents(t *testing.T) {
+	port := 8006
+	valid := func() Config {
+		c := Config{
+			Port:              &port,
+			Horizon:           "https://horizon-testnet.stellar.org",
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Events:            Events{Enabled: true, BufferSize: 100},
+		}
+		c.Database.Type = "sqlite"
+		return c
+	}
+
+	c := valid()
+	require.NoError(t, c.Validate())
+
+	c = valid()
+	c.Events.BufferSize = -1
+	assert.EqualError(t, c.Validate(), "events.buffer_size must be positive")
+
+	c = valid()
+	c.Database.Type = ""
+	assert.EqualError(t, c.Validate(), "events requires a database")
+}
+
+func TestConfigInternalTransfers(t *testing.T) {
+	port := 8006
+	c := Config{
+		Port:              &port,
+		Horizon:           "https://horizon-testnet.stellar.org",
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+		InternalTransfers: InternalTransfers{SkipChecks: true, OperatorOnly: true},
+	}
+	assert.EqualError(t, c.Validate(), "internal_transfers.operator_only requires operator_api_key")
+
+	c.OperatorAPIKey = "operator-api-key-1234"
+	require.NoError(t, c.Validate())
+}
+
+func TestAccountsAliasAccount(t *testing.T) {
+	accounts := Accounts{
+		BaseSeed:           "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK",
+		ReceivingAccountID: "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS",
+	}
+	assert.Equal(t, "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", accounts.AliasAccount("receiving_account_id"))
+	base := accounts.AliasAccount("base_seed")
+	assert.True(t, strings.HasPrefix(base, "G"))
+	assert.True(t, accounts.HasAccount(base))
+	assert.Equal(t, "", accounts.AliasAccount("issuing_account_id"), "not configured")
+	assert.Equal(t, "", accounts.AliasAccount("authorizing_seed"), "not configured")
+	assert.Equal(t, "", accounts.AliasAccount("alice*stellar.org"))
+}
+
+func TestAccountsSources(t *testing.T) {
+	refunds := "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H"
+	accounts := Accounts{
+		BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK",
+		Sources:  map[string]string{"refunds": refunds},
+	}
+	require.NoError(t, accounts.validate())
+	assert.Equal(t, "refunds", accounts.SeedAlias(refunds))
+	assert.Equal(t, refunds, accounts.AliasSeed("refunds"))
+	assert.Equal(t, "GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5", accounts.AliasAccount("refunds"))
+	assert.True(t, accounts.HasAccount("GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5"))
+	assert.Equal(t, "", accounts.AliasSeed("hotwallet"))
+
+	tests := []struct {
+		sources map[string]string
+		err     string
+	}{
+		{map[string]string{"Refunds": refunds}, "accounts.sources: names must be at most 32 lowercase letters, digits, `_` or `-`, got Refunds"},
+		{map[string]string{"base_seed": refunds}, "accounts.sources: base_seed is a key of accounts and cannot be a name"},
+		{map[string]string{"refunds": "GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5"}, "accounts.sources.refunds is invalid"},
+	}
+	for _, test := range tests {
+		accounts.Sources = test.sources
+		assert.EqualError(t, accounts.validate(), test.err)
+	}
+}
+
+func TestConfigTransactionBuilder(t *testing.T) {
+	port := 8006
+	c := Config{
+		Port:              &port,
+		Horizon:           "https://horizon-testnet.stellar.org",
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+	}
+	require.NoError(t, c.Validate())
+
+	c.TransactionBuilder = "xdr"
+	require.NoError(t, c.Validate())
+
+	c.TransactionBuilder = "txnbuild"
+	assert.EqualError(t, c.Validate(), "transaction_builder must be build or xdr")
+}
+
+func TestConfigBaseFee(t *testing.T) {
+	port := 8006
+	c := Config{
+		Port:              &port,
+		Horizon:           "https://horizon-testnet.stellar.org",
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+		BaseFee:           200,
+	}
+	require.NoError(t, c.Validate())
+
+	c.BaseFee = 99
+	assert.EqualError(t, c.Validate(), "base_fee must be at least 100")
+}
+
+func TestConfigChannels(t *testing.T) {
+	port := 8006
+	channelSeed := "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H"
+	c := Config{
+		Port:              &port,
+		Horizon:           "https://horizon-testnet.stellar.org",
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+		Accounts:          Accounts{BaseSeed: "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J"},
+		Channels:          Channels{Seeds: []string{channelSeed}, LeaseWaitSeconds: 0.5},
+	}
+	require.NoError(t, c.Validate())
+	assert.Equal(t, 500*time.Millisecond, c.Channels.LeaseWait())
+
+	c.Channels.Seeds = []string{channelSeed, channelSeed}
+	assert.EqualError(t, c.Validate(), "channels.seeds contains duplicate seed of GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5")
+
+	c.Channels.Seeds = []string{"GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5"}
+	assert.EqualError(t, c.Validate(), "channels.seeds contains invalid seed")
+
+	c.Channels.Seeds = []string{c.Accounts.BaseSeed}
+	assert.Contains(t, c.Validate().Error(), "channels.seeds cannot contain accounts of accounts group")
+
+	c.Channels.Seeds = []string{channelSeed}
+	c.Channels.LeaseWaitSeconds = -1
+	assert.EqualError(t, c.Validate(), "channels.lease_wait_seconds param cannot be negative")
+}
+
+func TestConfigNetworks(t *testing.T) {
+	port := 8006
+	pubnetSeed := "SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"
+	testnetIssuer := "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"
+	valid := func() Config {
+		c := Config{
+			Port:              &port,
+			Horizon:           "https://horizon.stellar.org",
+			NetworkPassphrase: "Public Global Stellar Network ; September 2015",
+			APIKey:            "pubnet-api-key-0123",
+			DefaultNetwork:    "pubnet",
+			Accounts:          Accounts{BaseSeed: pubnetSeed},
+			Database:          Database{Type: "postgres", URL: "postgres://localhost/bridge"},
+			SubmissionRate:    SubmissionRate{Rate: 2, Burst: 5},
+			Networks: map[string]Network{
+				"testnet": {
+					Horizon:           "https://horizon-testnet.stellar.org",
+					NetworkPassphrase: "Test SDF Network ; September 2015",
+					APIKey:            "testnet-api-key-0123",
+					Database:          Database{Type: "postgres", URL: "postgres://localhost/bridge?search_path=testnet"},
+					Accounts:          Accounts{IssuingAccountID: testnetIssuer},
+					SubmissionRate:    &SubmissionRate{Rate: 10},
+				},
+			},
+		}
+		return c
+	}
+
+	c := valid()
+	require.NoError(t, c.Validate())
+	assert.Equal(t, []string{"pubnet", "testnet"}, c.NetworkNames())
+	assert.Equal(t, "testnet", c.NetworkOfAPIKey("testnet-api-key-0123"))
+	assert.Equal(t, "", c.NetworkOfAPIKey("pubnet-api-key-0123"))
+
+	testnet, ok := c.NetworkConfig("testnet")
+	require.True(t, ok)
+	assert.Equal(t, "testnet", testnet.Network())
+	assert.Equal(t, "https://horizon-testnet.stellar.org", testnet.Horizon)
+	assert.Equal(t, "Test SDF Network ; September 2015", testnet.NetworkPassphrase)
+	assert.Equal(t, "testnet-api-key-0123", testnet.APIKey)
+	assert.Equal(t, "postgres://localhost/bridge?search_path=testnet", testnet.Database.URL)
+	assert.Equal(t, "", testnet.Accounts.BaseSeed)
+	assert.Equal(t, SubmissionRate{Rate: 10}, testnet.SubmissionRate)
+
+	pubnet, ok := c.NetworkConfig("pubnet")
+	require.True(t, ok)
+	assert.Equal(t, "pubnet", pubnet.Network())
+	assert.Equal(t, "pubnet-api-key-0123", pubnet.APIKey)
+	assert.Equal(t, SubmissionRate{Rate: 2, Burst: 5}, pubnet.SubmissionRate)
+	_, ok = c.NetworkConfig("futurenet")
+	assert.False(t, ok)
+
+	// Sources are checked against accounts of other networks
+	assert.Equal(t, "", pubnet.SourceNetwork(pubnetSeed))
+	assert.Equal(t, "testnet", pubnet.SourceNetwork(testnetIssuer))
+	assert.Equal(t, "pubnet", testnet.SourceNetwork(pubnetSeed))
+	assert.Equal(t, "", testnet.SourceNetwork(testnetIssuer))
+	assert.Equal(t, "", testnet.SourceNetwork("GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"))
+
+	tests := []struct {
+		modify func(c *Config)
+		err    string
+	}{
+		{func(c *Config) { c.DefaultNetwork = "Pubnet" }, "default_network must be at most 32 lowercase letters, digits, `_` or `-`"},
+		{func(c *Config) { c.Networks["pubnet"] = c.Networks["testnet"] }, "networks.pubnet: name of the default network cannot be used"},
+		{func(c *Config) {
+			network := c.Networks["testnet"]
+			network.NetworkPassphrase = ""
+			c.Networks["testnet"] = network
+		}, "networks.testnet: network_passphrase param is required"},
+		{func(c *Config) {
+			network := c.Networks["testnet"]
+			network.APIKey = c.APIKey
+			c.Networks["testnet"] = network
+		}, "networks.testnet: api_key is already used by another network or operator_api_key"},
+		{func(c *Config) {
+			network := c.Networks["testnet"]
+			network.Database = Database{}
+			c.Networks["testnet"] = network
+		}, "networks.testnet: database is required when database is configured"},
+		{func(c *Config) {
+			network := c.Networks["testnet"]
+			network.Database = c.Database
+			c.Networks["testnet"] = network
+		}, "networks.testnet: database is already used by pubnet network"},
+		{func(c *Config) {
+			network := c.Networks["testnet"]
+			network.Accounts.BaseSeed = "invalid"
+			c.Networks["testnet"] = network
+		}, "networks.testnet: accounts.base_seed is invalid"},
+		{func(c *Config) {
+			network := c.Networks["testnet"]
+			network.SubmissionRate = &SubmissionRate{Rate: -1}
+			c.Networks["testnet"] = network
+		}, "networks.testnet: submission_rate.rate param must not be negative"},
+		{func(c *Config) { c.SubmissionRate.Burst = -1 }, "submission_rate.burst param must not be negative"},
+	}
+	for _, test := range tests {
+		c := valid()
+		test.modify(&c)
+		assert.EqualError(t, c.Validate(), test.err)
+	}
+}
+
+func TestConfigDepositRequirements(t *testing.T) {
+	port := 8006
+	c := Config{
+		Port:              &port,
+		Horizon:           "https://horizon-testnet.stellar.org",
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+		Callbacks:         Callbacks{AllowedHosts: []string{"*.exchange.com"}},
+		DepositRequirements: []DepositRequirement{
+			{Domain: "exchange.com", MemoRequired: true},
+			{Domain: "eu.exchange.com", MemoType: "id", Sources: []string{"base_seed", "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"}, RegistrationURL: "https://api.exchange.com/deposits"},
+		},
+	}
+	require.NoError(t, c.Validate())
+
+	requirement, ok := c.DepositRequirement("pay.EU.exchange.com")
+	require.True(t, ok)
+	assert.Equal(t, "eu.exchange.com", requirement.Domain)
+	requirement, ok = c.DepositRequirement("exchange.com")
+	require.True(t, ok)
+	assert.Equal(t, "exchange.com", requirement.Domain)
+	_, ok = c.DepositRequirement("myexchange.com")
+	assert.False(t, ok)
+
+	sources := c.DepositRequirements[1]
+	assert.True(t, sources.AllowsSource("GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW", "base_seed"))
+	assert.True(t, sources.AllowsSource("GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", ""))
+	assert.False(t, sources.AllowsSource("GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW", ""))
+	assert.True(t, c.DepositRequirements[0].AllowsSource("GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW", ""))
+
+	c.DepositRequirements[1].RegistrationURL = "https://deposits.example.com"
+	assert.EqualError(t, c.Validate(), "deposit_requirements.eu.exchange.com.registration_url host is not in callbacks.allowed_hosts")
+
+	c.DepositRequirements[1].RegistrationURL = ""
+	c.DepositRequirements[1].Sources = []string{"authorizing_seed"}
+	assert.EqualError(t, c.Validate(), "deposit_requirements.eu.exchange.com.sources contains invalid account ID or alias authorizing_seed")
+
+	c.DepositRequirements[1].Sources = nil
+	c.DepositRequirements[1].MemoType = "tag"
+	assert.EqualError(t, c.Validate(), "deposit_requirements.eu.exchange.com.memo_type param must be id, text, hash or return")
+
+	c.DepositRequirements[1] = DepositRequirement{Domain: "Exchange.com"}
+	assert.EqualError(t, c.Validate(), "deposit_requirements.Exchange.com is configured more than once")
+
+	c.DepositRequirements[1] = DepositRequirement{}
+	assert.EqualError(t, c.Validate(), "deposit_requirements[1].domain param is required")
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/stellar/gateway/webhook"
+	"github.com/stellar/go/keypair"
+)
+
+// DepositMemoTypes are values of deposit_requirements memo_type param
+var DepositMemoTypes = []string{"id", "text", "hash", "return"}
+
+// DepositRequirement contains values of a `[[deposit_requirements]]` entry: rules payments to
+// destinations of a domain (ex. an exchange) must follow before they are submitted
+type DepositRequirement struct {
+	// Domain of federated destinations (`name*domain`) the rules apply to, including subdomains
+	Domain string `json:"domain"`
+	// MemoRequired rejects payments without a memo
+	MemoRequired bool `mapstructure:"memo_required" json:"memo_required"`
+	// MemoType is the memo type payments must use, any type when empty. A memo is required when
+	// it's set.
+	MemoType string `mapstructure:"memo_type" json:"memo_type,omitempty"`
+	// Sources are account IDs or seed aliases (`base_seed`) of sources registered with the
+	// domain, payments of other sources are rejected. Any source is allowed when empty.
+	Sources []string `json:"sources,omitempty"`
+	// RegistrationURL is called with the planned source and amount of every payment to the
+	// domain, the payment is sent only when the response approves it
+	RegistrationURL string `mapstructure:"registration_url" json:"-"`
+}
+
+// DepositRequirement returns requirements of payments to a destination domain. When entries of
+// a domain and its parent domain are configured, the most specific one is returned.
+func (c *Config) DepositRequirement(domain string) (DepositRequirement, bool) {
+	domain = strings.ToLower(domain)
+	var found DepositRequirement
+	ok := false
+	for _, requirement := range c.DepositRequirements {
+		entry := strings.ToLower(requirement.Domain)
+		if domain != entry && !strings.HasSuffix(domain, "."+entry) {
+			continue
+		}
+		if !ok || len(entry) > len(found.Domain) {
+			found, ok = requirement, true
+		}
+	}
+	return found, ok
+}
+
+// AllowsSource returns true when the source account or the alias of its seed is one of
+// registered sources
+func (r DepositRequirement) AllowsSource(accountID, alias string) bool {
+	if len(r.Sources) == 0 {
+		return true
+	}
+	for _, source := range r.Sources {
+		if source == accountID || (alias != "" && source == alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateDepositRequirements checks deposit_requirements entries, a domain can have a single
+// entry
+func (c *Config) validateDepositRequirements() error {
+	domains := map[string]bool{}
+	for i, requirement := range c.DepositRequirements {
+		if requirement.Domain == "" {
+			return fmt.Errorf("deposit_requirements[%d].domain param is required", i)
+		}
+		name := "deposit_requirements." + requirement.Domain
+		domain := strings.ToLower(requirement.Domain)
+		if domains[domain] {
+			return errors.New(name + " is configured more than once")
+		}
+		domains[domain] = true
+
+		if requirement.MemoType != "" {
+			known := false
+			for _, memoType := range DepositMemoTypes {
+				known = known || requirement.MemoType == memoType
+			}
+			if !known {
+				return errors.New(name + ".memo_type param must be id, text, hash or return")
+			}
+		}
+
+		for _, source := range requirement.Sources {
+			if source == baseSeedAlias {
+				continue
+			}
+			kp, err := keypair.Parse(source)
+			if err != nil || kp.Address() != source {
+				return errors.New(name + ".sources contains invalid account ID or alias " + source)
+			}
+		}
+
+		if requirement.RegistrationURL != "" {
+			registrationURL, err := url.Parse(requirement.RegistrationURL)
+			if err != nil || (registrationURL.Scheme != "http" && registrationURL.Scheme != "https") {
+				return errors.New("Cannot parse " + name + ".registration_url param")
+			}
+			if !webhook.HostAllowed(c.Callbacks.AllowedHosts, registrationURL) {
+				return errors.New(name + ".registration_url host is not in callbacks.allowed_hosts")
+			}
+		}
+	}
+	return nil
+}
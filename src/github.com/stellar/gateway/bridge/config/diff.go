@@ -0,0 +1,181 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Kinds of configuration changes
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// maskedValue replaces values of secret keys in changes
+const maskedValue = "******"
+
+// Change is a single changed key of the configuration
+type Change struct {
+	// Key is a path of the key, ex. `callbacks.receive` or `assets[0].code`
+	Key  string `json:"key"`
+	Kind string `json:"kind"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+	// RestartRequired is false for changes applied by /admin/reload
+	RestartRequired bool `json:"restart_required"`
+}
+
+// Load reads and validates a config file
+func Load(path string) (config Config, err error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	err = v.ReadInConfig()
+	if err != nil {
+		return
+	}
+
+	err = v.Unmarshal(&config)
+	if err != nil {
+		return
+	}
+
+	err = config.resolveSecretReferences()
+	if err != nil {
+		return
+	}
+
+	err = config.Validate()
+	return
+}
+
+// Diff returns changes between the running and a new config ordered by key. Values of secrets
+// (seeds, API keys, database URL) are masked.
+func Diff(running, loaded Config) []Change {
+	oldValues := make(map[string]string)
+	newValues := make(map[string]string)
+	flatten("", reflect.ValueOf(running), oldValues)
+	flatten("", reflect.ValueOf(loaded), newValues)
+
+	changes := []Change{}
+	for key, oldValue := range oldValues {
+		newValue, ok := newValues[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: key, Kind: ChangeRemoved, Old: oldValue})
+		case newValue != oldValue:
+			changes = append(changes, Change{Key: key, Kind: ChangeChanged, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newValues {
+		if _, ok := oldValues[key]; !ok {
+			changes = append(changes, Change{Key: key, Kind: ChangeAdded, New: newValue})
+		}
+	}
+
+	for i := range changes {
+		changes[i].RestartRequired = !IsHotApplicable(changes[i].Key)
+		if isSecret(changes[i].Key) {
+			if changes[i].Old != "" {
+				changes[i].Old = maskedValue
+			}
+			if changes[i].New != "" {
+				changes[i].New = maskedValue
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// DiffHash returns a hash identifying changes, /admin/reload applies changes only when `confirm`
+// param (if sent) is equal to it
+func DiffHash(changes []Change) string {
+	encoded, _ := json.Marshal(changes)
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:])
+}
+
+// IsHotApplicable returns true if a change of the key can be applied without restarting the server.
+// Only log sample rates, callback allowlist and TLS options, warm federation addresses and error
+// mappings are read after start.
+func IsHotApplicable(key string) bool {
+	return strings.HasPrefix(key, "log_sampling.") ||
+		strings.HasPrefix(key, "error_mapping.") ||
+		strings.HasPrefix(key, "callbacks.allowed_hosts[") ||
+		strings.HasPrefix(key, "callbacks.tls.") ||
+		strings.HasPrefix(key, "warm_start.federation_addresses[")
+}
+
+func isSecret(key string) bool {
+	switch key {
+	case "api_key", "operator_api_key", "mac_key", "database.url":
+		return true
+	}
+	// Params of `networks.<name>`
+	if strings.HasPrefix(key, "networks.") && (strings.HasSuffix(key, ".api_key") || strings.HasSuffix(key, ".database.url")) {
+		return true
+	}
+	// Seeds of `accounts.sources` by name
+	if strings.HasPrefix(key, "accounts.sources.") || strings.Contains(key, ".accounts.sources.") {
+		return true
+	}
+	return strings.HasSuffix(key, "_seed") || strings.HasPrefix(key, "channels.seeds[")
+}
+
+// fieldKey returns the config key of a struct field under prefix
+func fieldKey(prefix string, field reflect.StructField) string {
+	name := field.Tag.Get("mapstructure")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	if prefix != "" {
+		name = prefix + "." + name
+	}
+	return name
+}
+
+// flatten adds non-zero values of v to values using config keys
+func flatten(prefix string, v reflect.Value, values map[string]string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			flatten(prefix, v.Elem(), values)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			flatten(fieldKey(prefix, field), v.Field(i), values)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), v.Index(i), values)
+		}
+	case reflect.Map:
+		// Map values are set explicitly so zero values (ex. sample rate 0) are kept
+		for _, key := range v.MapKeys() {
+			name := fmt.Sprintf("%s.%v", prefix, key.Interface())
+			if v.MapIndex(key).Kind() == reflect.Struct {
+				flatten(name, v.MapIndex(key), values)
+				continue
+			}
+			values[name] = fmt.Sprintf("%v", v.MapIndex(key).Interface())
+		}
+	default:
+		if v.Interface() != reflect.Zero(v.Type()).Interface() {
+			values[prefix] = fmt.Sprintf("%v", v.Interface())
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+
+	"github.com/stellar/go/keypair"
+)
+
+// DefaultNetworkName is the name of the network of top-level params when default_network is
+// not set
+const DefaultNetworkName = "default"
+
+var validNetworkName = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// Network contains values of `networks.<name>` config group. Every network has its own Horizon,
+// accounts and database so sequence numbers, listeners and records of networks are isolated.
+type Network struct {
+	Horizon           string
+	NetworkPassphrase string `mapstructure:"network_passphrase"`
+	// APIKey is the client API key of the network, top-level api_key when empty. Requests made
+	// with it use the network when they don't send `network` param.
+	APIKey   string `mapstructure:"api_key"`
+	Database Database
+	Accounts
+	// Assets accepted by the payment listener, top-level assets when empty
+	Assets []Asset
+	// SubmissionRate of the Horizon of the network, top-level submission_rate when not set. Every
+	// network has its own budget.
+	SubmissionRate *SubmissionRate `mapstructure:"submission_rate"`
+}
+
+// Network returns the name of the network of the config
+func (c *Config) Network() string {
+	if c.network != "" {
+		return c.network
+	}
+	return c.DefaultNetworkName()
+}
+
+// DefaultNetworkName returns the name of the network of top-level params
+func (c *Config) DefaultNetworkName() string {
+	if c.DefaultNetwork != "" {
+		return c.DefaultNetwork
+	}
+	return DefaultNetworkName
+}
+
+// NetworkNames returns names of all configured networks, the default network first
+func (c *Config) NetworkNames() []string {
+	names := []string{}
+	for name := range c.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{c.DefaultNetworkName()}, names...)
+}
+
+// NetworkConfig returns the config of a network: a copy of c with horizon, network_passphrase,
+// database, accounts, assets, api_key and submission_rate of the network. Auto conversion,
+// channels and additional reconciled accounts are configured for accounts of the default
+// network, they are not used in other networks. It returns false when the network is not configured.
+func (c *Config) NetworkConfig(name string) (Config, bool) {
+	config := *c
+	config.network = name
+	config.networkAccounts = c.accountsByNetwork()
+	if name == c.DefaultNetworkName() {
+		return config, true
+	}
+
+	network, ok := c.Networks[name]
+	if !ok {
+		return Config{}, false
+	}
+
+	config.Horizon = network.Horizon
+	config.NetworkPassphrase = network.NetworkPassphrase
+	config.Database = network.Database
+	config.Accounts = network.Accounts
+	if network.APIKey != "" {
+		config.APIKey = network.APIKey
+	}
+	if len(network.Assets) > 0 {
+		config.Assets = network.Assets
+	}
+	if network.SubmissionRate != nil {
+		config.SubmissionRate = *network.SubmissionRate
+	}
+	config.AutoConversion = AutoConversion{}
+	config.Channels = Channels{}
+	config.Reconciliation.Accounts = nil
+	return config, true
+}
+
+// NetworkOfAPIKey returns the name of the network of a network api_key, an empty string for
+// other keys
+func (c *Config) NetworkOfAPIKey(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	for name, network := range c.Networks {
+		if network.APIKey == apiKey {
+			return name
+		}
+	}
+	return ""
+}
+
+// SourceNetwork returns the name of another network with an account of a source (a seed or an
+// account ID) in its accounts. It returns an empty string when the account is configured in the
+// network of the config or in no network.
+func (c *Config) SourceNetwork(source string) string {
+	kp, err := keypair.Parse(source)
+	if err != nil || len(c.Networks) == 0 {
+		return ""
+	}
+
+	address := kp.Address()
+	accounts := c.accountsByNetwork()
+	if accounts[c.Network()].HasAccount(address) {
+		return ""
+	}
+	for _, name := range c.NetworkNames() {
+		if accounts[name].HasAccount(address) {
+			return name
+		}
+	}
+	return ""
+}
+
+// accountsByNetwork returns accounts of every network, also in configs of networks other than
+// the default one
+func (c *Config) accountsByNetwork() map[string]Accounts {
+	if c.networkAccounts != nil {
+		return c.networkAccounts
+	}
+	accounts := map[string]Accounts{c.DefaultNetworkName(): c.Accounts}
+	for name, network := range c.Networks {
+		accounts[name] = network.Accounts
+	}
+	return accounts
+}
+
+// HasAccount returns true when address is an account of a configured seed (`sources` included) or
+// account ID
+func (a Accounts) HasAccount(address string) bool {
+	seeds := []string{a.BaseSeed, a.AuthorizingSeed, a.RecoverySeed}
+	for _, seed := range a.Sources {
+		seeds = append(seeds, seed)
+	}
+	for _, seed := range seeds {
+		if kp, err := keypair.Parse(seed); err == nil && kp.Address() == address {
+			return true
+		}
+	}
+	return address == a.IssuingAccountID || address == a.ReceivingAccountID
+}
+
+// validateNetworks checks `networks` params. Networks must not share databases, a network
+// database is required when the default network has one.
+func (c *Config) validateNetworks() error {
+	if !validNetworkName.MatchString(c.DefaultNetworkName()) {
+		return errors.New("default_network must be at most 32 lowercase letters, digits, `_` or `-`")
+	}
+
+	databases := map[string]string{}
+	if c.Database.Type != "" {
+		databases[c.Database.Type+" "+c.Database.URL] = c.DefaultNetworkName()
+	}
+	apiKeys := map[string]bool{c.APIKey: true, c.OperatorAPIKey: true}
+
+	for _, name := range c.NetworkNames()[1:] {
+		network := c.Networks[name]
+		prefix := "networks." + name + ": "
+
+		if !validNetworkName.MatchString(name) {
+			return errors.New(prefix + "network names must be at most 32 lowercase letters, digits, `_` or `-`")
+		}
+		if name == c.DefaultNetworkName() {
+			return errors.New(prefix + "name of the default network cannot be used")
+		}
+
+		if network.Horizon == "" {
+			return errors.New(prefix + "horizon param is required")
+		}
+		if _, err := url.Parse(network.Horizon); err != nil {
+			return errors.New(prefix + "Cannot parse horizon param")
+		}
+		if network.NetworkPassphrase == "" {
+			return errors.New(prefix + "network_passphrase param is required")
+		}
+
+		if network.APIKey != "" {
+			if len(network.APIKey) < 15 {
+				return errors.New(prefix + "api_key have to be at least 15 chars long")
+			}
+			if apiKeys[network.APIKey] {
+				return errors.New(prefix + "api_key is already used by another network or operator_api_key")
+			}
+			apiKeys[network.APIKey] = true
+		}
+
+		if c.Database.Type != "" && network.Database.Type == "" {
+			return errors.New(prefix + "database is required when database is configured")
+		}
+		if err := network.Database.validate(); err != nil {
+			return errors.New(prefix + err.Error())
+		}
+		if network.Database.Type != "" {
+			key := network.Database.Type + " " + network.Database.URL
+			if other, ok := databases[key]; ok {
+				return errors.New(prefix + "database is already used by " + other + " network")
+			}
+			databases[key] = name
+		}
+
+		if err := network.Accounts.validate(); err != nil {
+			return errors.New(prefix + err.Error())
+		}
+		if network.SubmissionRate != nil {
+			if err := network.SubmissionRate.validate(prefix); err != nil {
+				return err
+			}
+		}
+		for _, asset := range network.Assets {
+			if asset.Issuer == "" && asset.Code != "XLM" {
+				return errors.New(prefix + "assets: Issuer param is required for " + asset.Code)
+			}
+		}
+		c.Networks[name] = network
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Checks of strict security mode, their names are the waiver keys
+const (
+	// SecurityAuthentication requires api_key so clients must authenticate
+	SecurityAuthentication = "authentication"
+	// SecurityPlaintextHTTP requires a loopback bind_address, the server doesn't terminate TLS so
+	// it must be reached through a TLS proxy on the same host
+	SecurityPlaintextHTTP = "plaintext_http"
+	// SecurityRawSecrets requires secrets (seeds, API keys, database URLs) to be `env:NAME`
+	// references instead of values in the config file
+	SecurityRawSecrets = "raw_secrets"
+	// SecurityTLSSkipVerify forbids callbacks.tls.<name>.insecure_skip_verify
+	SecurityTLSSkipVerify = "tls_skip_verify"
+	// SecurityAdminSeparation requires operator_api_key different from api_key, /admin endpoints
+	// are served to operators only
+	SecurityAdminSeparation = "admin_separation"
+)
+
+// SecurityChecks are all checks of strict security mode in the order they are reported
+var SecurityChecks = []string{
+	SecurityAuthentication,
+	SecurityPlaintextHTTP,
+	SecurityRawSecrets,
+	SecurityTLSSkipVerify,
+	SecurityAdminSeparation,
+}
+
+// secretReferencePrefix starts values of secrets read from environment variables, ex.
+// `base_seed = "env:BRIDGE_BASE_SEED"`
+const secretReferencePrefix = "env:"
+
+// SecurityViolation is a failed check of strict security mode
+type SecurityViolation struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// InsecureConfigError is returned by SecurityPolicy.Check with all violations that are not waived
+type InsecureConfigError struct {
+	Violations []SecurityViolation
+}
+
+func (e *InsecureConfigError) Error() string {
+	lines := []string{fmt.Sprintf("strict security: %d check(s) failed", len(e.Violations))}
+	for _, violation := range e.Violations {
+		lines = append(lines, fmt.Sprintf("  %s: %s (waive with --security-waiver=%s)", violation.Check, violation.Message, violation.Check))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SecurityPolicy is strict security mode with waived checks
+type SecurityPolicy struct {
+	waivers map[string]bool
+}
+
+// NewSecurityPolicy creates a SecurityPolicy waiving checks of waivers, unknown checks are rejected
+// so a typo doesn't leave a check enabled unnoticed
+func NewSecurityPolicy(waivers []string) (*SecurityPolicy, error) {
+	policy := &SecurityPolicy{waivers: map[string]bool{}}
+	for _, waiver := range waivers {
+		known := false
+		for _, check := range SecurityChecks {
+			known = known || check == waiver
+		}
+		if !known {
+			return nil, fmt.Errorf("Unknown security waiver %s, checks are %s", waiver, strings.Join(SecurityChecks, ", "))
+		}
+		policy.waivers[waiver] = true
+	}
+	return policy, nil
+}
+
+// Waived returns true if check is waived, all checks are waived by a nil policy
+func (p *SecurityPolicy) Waived(check string) bool {
+	return p == nil || p.waivers[check]
+}
+
+// Check returns violations of c that are waived, and InsecureConfigError when other checks fail
+func (p *SecurityPolicy) Check(c *Config) (waived []SecurityViolation, err error) {
+	var violations []SecurityViolation
+	for _, violation := range c.SecurityViolations() {
+		if p.Waived(violation.Check) {
+			waived = append(waived, violation)
+		} else {
+			violations = append(violations, violation)
+		}
+	}
+	if len(violations) > 0 {
+		return waived, &InsecureConfigError{violations}
+	}
+	return waived, nil
+}
+
+// SecurityViolations returns checks of strict security mode failed by the config
+func (c *Config) SecurityViolations() []SecurityViolation {
+	var violations []SecurityViolation
+	add := func(check, format string, args ...interface{}) {
+		violations = append(violations, SecurityViolation{Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.APIKey == "" {
+		add(SecurityAuthentication, "api_key is not set, requests without a key are allowed")
+	}
+
+	if c.BindAddress == "" {
+		add(SecurityPlaintextHTTP, "bind_address is not set, HTTP is served without TLS on all addresses")
+	} else if ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(c.BindAddress, "["), "]")); ip == nil || !ip.IsLoopback() {
+		add(SecurityPlaintextHTTP, "bind_address %s is not a loopback address, HTTP is served without TLS", c.BindAddress)
+	}
+
+	if raw := c.rawSecretKeys(); len(raw) > 0 {
+		add(SecurityRawSecrets, "%s set in the config file, use %sNAME references", strings.Join(raw, ", "), secretReferencePrefix)
+	}
+
+	var insecure []string
+	for name, options := range c.Callbacks.TLS {
+		if options.InsecureSkipVerify {
+			insecure = append(insecure, "callbacks.tls."+name)
+		}
+	}
+	if len(insecure) > 0 {
+		sort.Strings(insecure)
+		add(SecurityTLSSkipVerify, "%s disable certificate verification", strings.Join(insecure, ", "))
+	}
+
+	switch {
+	case c.OperatorAPIKey == "":
+		add(SecurityAdminSeparation, "operator_api_key is not set, admin endpoints are served to clients")
+	case c.OperatorAPIKey == c.APIKey:
+		add(SecurityAdminSeparation, "operator_api_key is equal to api_key")
+	}
+
+	return violations
+}
+
+// rawSecretKeys returns sorted keys of secrets set in the config file, SQLite database paths are
+// not secrets
+func (c *Config) rawSecretKeys() []string {
+	values := map[string]string{}
+	flatten("", reflect.ValueOf(*c), values)
+
+	var raw []string
+	for key := range values {
+		if !isSecret(key) || c.secretReferences[key] {
+			continue
+		}
+		if strings.HasSuffix(key, "database.url") && values[strings.TrimSuffix(key, "url")+"type"] == "sqlite" {
+			continue
+		}
+		raw = append(raw, key)
+	}
+	sort.Strings(raw)
+	return raw
+}
+
+// resolveSecretReferences replaces `env:NAME` values of secrets with environment variables and
+// remembers their keys, other values are not changed
+func (c *Config) resolveSecretReferences() error {
+	c.secretReferences = map[string]bool{}
+	return resolveReferences("", reflect.ValueOf(c).Elem(), c.secretReferences)
+}
+
+// resolveReferences walks v like flatten, v must be settable
+func resolveReferences(prefix string, v reflect.Value, resolved map[string]bool) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return resolveReferences(prefix, v.Elem(), resolved)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			err := resolveReferences(fieldKey(prefix, field), v.Field(i), resolved)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			err := resolveReferences(fmt.Sprintf("%s[%d]", prefix, i), v.Index(i), resolved)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map values are not settable, they are replaced by resolved copies
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			err := resolveReferences(fmt.Sprintf("%s.%v", prefix, key.Interface()), value, resolved)
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+	case reflect.String:
+		if !isSecret(prefix) || !strings.HasPrefix(v.String(), secretReferencePrefix) {
+			return nil
+		}
+		name := strings.TrimPrefix(v.String(), secretReferencePrefix)
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return fmt.Errorf("%s: environment variable %s is not set", prefix, name)
+		}
+		v.SetString(value)
+		resolved[prefix] = true
+	}
+	return nil
+}
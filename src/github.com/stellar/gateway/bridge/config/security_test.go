@@ -0,0 +1,116 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/gateway/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityViolations(t *testing.T) {
+	checks := func(config Config) []string {
+		names := []string{}
+		for _, violation := range config.SecurityViolations() {
+			names = append(names, violation.Check)
+		}
+		return names
+	}
+
+	config := testConfig()
+	config.APIKey = ""
+	config.Callbacks.TLS = map[string]webhook.TLSOptions{"receive": {InsecureSkipVerify: true}, "error": {}}
+	assert.Equal(t, SecurityChecks, checks(config))
+
+	violations := config.SecurityViolations()
+	assert.Equal(t, "accounts.base_seed, database.url set in the config file, use env:NAME references", violations[2].Message)
+	assert.Equal(t, "callbacks.tls.receive disable certificate verification", violations[3].Message)
+
+	config = testConfig()
+	config.BindAddress = "::1"
+	config.OperatorAPIKey = "operator-key-1234567890"
+	config.secretReferences = map[string]bool{"api_key": true, "operator_api_key": true, "accounts.base_seed": true, "database.url": true}
+	assert.Equal(t, []string{}, checks(config))
+
+	config.BindAddress = "10.0.0.1"
+	config.OperatorAPIKey = config.APIKey
+	assert.Equal(t, []string{SecurityPlaintextHTTP, SecurityAdminSeparation}, checks(config))
+
+	// SQLite database URLs are paths
+	config = testConfig()
+	config.Database.Type = "sqlite"
+	config.Database.URL = "bridge.db"
+	raw := config.rawSecretKeys()
+	assert.Equal(t, []string{"accounts.base_seed", "api_key"}, raw)
+}
+
+func TestSecurityPolicy(t *testing.T) {
+	_, err := NewSecurityPolicy([]string{"raw_secret"})
+	assert.EqualError(t, err, "Unknown security waiver raw_secret, checks are authentication, plaintext_http, raw_secrets, tls_skip_verify, admin_separation")
+
+	policy, err := NewSecurityPolicy([]string{SecurityPlaintextHTTP, SecurityRawSecrets})
+	require.NoError(t, err)
+	assert.True(t, policy.Waived(SecurityRawSecrets))
+	assert.False(t, policy.Waived(SecurityAdminSeparation))
+
+	config := testConfig()
+	waived, err := policy.Check(&config)
+	assert.Len(t, waived, 2)
+	assert.EqualError(t, err, `strict security: 1 check(s) failed
+  admin_separation: operator_api_key is not set, admin endpoints are served to clients (waive with --security-waiver=admin_separation)`)
+
+	config.OperatorAPIKey = "operator-key-1234567890"
+	waived, err = policy.Check(&config)
+	assert.NoError(t, err)
+	assert.Equal(t, SecurityPlaintextHTTP, waived[0].Check)
+	assert.Equal(t, SecurityRawSecrets, waived[1].Check)
+}
+
+func TestLoadSecretReferences(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bridge.cfg")
+	err = ioutil.WriteFile(path, []byte(`port = 8001
+horizon = "https://horizon-testnet.stellar.org"
+network_passphrase = "Test SDF Network ; September 2015"
+api_key = "env:BRIDGE_TEST_API_KEY"
+
+[accounts]
+base_seed = "env:BRIDGE_TEST_BASE_SEED"
+
+[accounts.sources]
+refunds = "env:BRIDGE_TEST_REFUNDS_SEED"
+`), 0600)
+	require.NoError(t, err)
+
+	os.Setenv("BRIDGE_TEST_API_KEY", "api-key-1234567890")
+	os.Setenv("BRIDGE_TEST_BASE_SEED", "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J")
+	defer os.Unsetenv("BRIDGE_TEST_API_KEY")
+	defer os.Unsetenv("BRIDGE_TEST_BASE_SEED")
+
+	_, err = Load(path)
+	assert.EqualError(t, err, "accounts.sources.refunds: environment variable BRIDGE_TEST_REFUNDS_SEED is not set")
+
+	os.Setenv("BRIDGE_TEST_REFUNDS_SEED", "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H")
+	defer os.Unsetenv("BRIDGE_TEST_REFUNDS_SEED")
+
+	config, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "api-key-1234567890", config.APIKey)
+	assert.Equal(t, "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J", config.Accounts.BaseSeed)
+	assert.Equal(t, "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H", config.Accounts.Sources["refunds"])
+	assert.Empty(t, config.rawSecretKeys())
+
+	// Values of named sources are masked in diffs like other seeds
+	changed := config
+	changed.Accounts.Sources = map[string]string{"refunds": "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J"}
+	changes := Diff(config, changed)
+	require.Len(t, changes, 1)
+	assert.NotContains(t, changes[0].Old, "SDOTALIM")
+	assert.NotContains(t, changes[0].New, "SABLR5HO")
+}
@@ -0,0 +1,84 @@
+package bridge_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/bridge"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/go/network"
+)
+
+// The bridge is mounted under /bridge/ in a mux of another service which authenticates requests
+// with its own middleware and manages the database
+func Example_embedding() {
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer service-token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	dir, err := ioutil.TempDir("", "bridge-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	err = driver.Init(filepath.Join(dir, "bridge.db"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = driver.MigrateUp("gateway")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	c := config.Config{
+		Horizon:           "https://horizon-testnet.stellar.org",
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	}
+	app, err := bridge.NewApp(c, bridge.Options{
+		PathPrefix: "/bridge",
+		Middleware: []func(http.Handler) http.Handler{auth},
+		Logger:     logger,
+		Driver:     driver,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = app.Start()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer app.Stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/bridge/", app.Handler())
+
+	for _, token := range []string{"", "Bearer service-token"} {
+		request := httptest.NewRequest("POST", "/bridge/create-keypair", nil)
+		request.Header.Set("Authorization", token)
+		response := httptest.NewRecorder()
+		mux.ServeHTTP(response, request)
+		fmt.Println(response.Code)
+	}
+	// Output:
+	// 401
+	// 200
+}
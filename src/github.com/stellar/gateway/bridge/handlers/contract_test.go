@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/mysql"
+	"github.com/stellar/gateway/db/drivers/postgres"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/db/schema"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/pagination"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zenazn/goji/web"
+)
+
+// Contract tests check that the storage layer and admin API return the same data on every
+// supported database, both for new databases and for databases of the previous release migrated
+// by newer migrations. SQLite is always tested, PostgreSQL and MySQL are tested when
+// BRIDGE_TEST_POSTGRES_URL and BRIDGE_TEST_MYSQL_URL are set. All tables of these databases are
+// dropped, don't point them at a database you care about. Run
+// `go test ./bridge/handlers -run TestContract -update` to regenerate golden files in
+// testdata/contract after an intentional change.
+const (
+	contractGoldenDir = "testdata/contract"
+	contractSnapshot  = "../../db/schema/testdata/snapshot.json"
+)
+
+var contractURLEnv = map[string]string{
+	"postgres": "BRIDGE_TEST_POSTGRES_URL",
+	"mysql":    "BRIDGE_TEST_MYSQL_URL",
+}
+
+// contractTime is the time seeded records are created around
+var contractTime = time.Date(2018, 1, 2, 10, 0, 0, 0, time.UTC)
+
+const (
+	contractSource      = "GBSTRH4QOTWNSVA6E4HFERETX4ZLSR3CIUBLK7AXYII277PFJC4BBYOG"
+	contractDestination = "GAMVF7G4GJC4A7JMFJWLUAEIBFQD5RT3DCB5DC5TJDEKQBBACQ4JZVEE"
+	contractIssuer      = "GCPZJ3MJQ3GK4FSB3JXEOTCQFHHQXLOEKBCOU2UV6T5OTATHLNHXZK2I"
+)
+
+func TestContract(t *testing.T) {
+	snapshot, err := schema.LoadSnapshot(contractSnapshot)
+	require.NoError(t, err)
+
+	for _, databaseType := range schema.Types {
+		databaseType := databaseType
+		t.Run(databaseType, func(t *testing.T) {
+			url := ""
+			if env, ok := contractURLEnv[databaseType]; ok {
+				url = os.Getenv(env)
+				if url == "" {
+					t.Skipf("%s is not set", env)
+				}
+			}
+
+			dir, err := ioutil.TempDir("", "bridge-contract")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			// Gateway and compliance servers use separate databases, their migrations have the
+			// same IDs
+			for _, component := range schema.Components {
+				changed, err := snapshot.Changed(databaseType, component)
+				require.NoError(t, err)
+				require.Empty(t, changed, "%s migrations of %s release have been changed, add new migrations instead", component, snapshot.Release)
+
+				// From scratch
+				driver := openContractDatabase(t, databaseType, url, filepath.Join(dir, component+"-scratch.db"))
+				_, err = driver.MigrateUp(component)
+				require.NoError(t, err)
+				expected, err := schema.Describe(databaseType, driver.DB())
+				require.NoError(t, err)
+				testContract(t, databaseType, component, driver)
+				driver.DB().Close()
+
+				// From the schema of the previous release
+				driver = openContractDatabase(t, databaseType, url, filepath.Join(dir, component+"-snapshot.db"))
+				_, err = snapshot.Apply(databaseType, component, driver.DB())
+				require.NoError(t, err)
+				_, err = driver.MigrateUp(component)
+				require.NoError(t, err)
+				actual, err := schema.Describe(databaseType, driver.DB())
+				require.NoError(t, err)
+				assert.Equal(t, expected, actual, "%s schema migrated from %s release differs from a new schema", component, snapshot.Release)
+				testContract(t, databaseType, component, driver)
+				driver.DB().Close()
+			}
+		})
+	}
+}
+
+// openContractDatabase opens an empty database, SQLite databases are created in file and all
+// tables of other databases are dropped
+func openContractDatabase(t *testing.T, databaseType, url, file string) db.Driver {
+	var driver db.Driver
+	var tablesQuery, dropTable string
+	switch databaseType {
+	case "sqlite":
+		driver = &sqlite.Driver{}
+		url = file
+	case "postgres":
+		driver = &postgres.Driver{}
+		tablesQuery = "SELECT tablename FROM pg_tables WHERE schemaname = current_schema()"
+		dropTable = "DROP TABLE %s CASCADE"
+	case "mysql":
+		driver = &mysql.Driver{}
+		tablesQuery = "SHOW TABLES"
+		dropTable = "DROP TABLE %s"
+	}
+	require.NoError(t, driver.Init(url))
+
+	if tablesQuery != "" {
+		var tables []string
+		require.NoError(t, driver.DB().Select(&tables, tablesQuery))
+		for _, table := range tables {
+			_, err := driver.DB().Exec(fmt.Sprintf(dropTable, table))
+			require.NoError(t, err)
+		}
+	}
+	return driver
+}
+
+// TestRepositoryContractCoverage checks that contract tests call every method of Repository
+func TestRepositoryContractCoverage(t *testing.T) {
+	covered := map[string]bool{}
+	for _, calls := range repositoryContract() {
+		for name := range calls {
+			covered[name] = true
+		}
+	}
+
+	methods := reflect.TypeOf(db.Repository{})
+	for i := 0; i < methods.NumMethod(); i++ {
+		name := methods.Method(i).Name
+		assert.True(t, covered[name], "add a contract case of Repository.%s", name)
+	}
+}
+
+func testContract(t *testing.T, databaseType, component string, driver db.Driver) {
+	entityManager := db.NewEntityManager(driver)
+	for _, record := range contractRecords()[component] {
+		require.NoError(t, entityManager.Persist(record), "%s: %T", databaseType, record)
+	}
+	repository := db.NewRepository(driver)
+
+	results := map[string]interface{}{}
+	for name, call := range repositoryContract()[component] {
+		result, err := call(repository)
+		require.NoError(t, err, "%s: Repository.%s", databaseType, name)
+		results[name] = result
+	}
+	assertContractGolden(t, databaseType, component+"-repository", results)
+
+	if component != "gateway" {
+		return
+	}
+
+	requestHandler := RequestHandler{
+		Config:     &config.Config{},
+		Driver:     driver,
+		Repository: repository,
+		Horizon:    contractHorizon(),
+	}
+	responses := map[string]interface{}{}
+	for _, endpoint := range adminContract() {
+		responses[endpoint.name] = callContractEndpoint(t, databaseType, &requestHandler, endpoint)
+	}
+	assertContractGolden(t, databaseType, "admin", responses)
+}
+
+// contractRecords returns records of every entity seeded in databases of components, times are
+// fixed so responses are equal on every run
+func contractRecords() map[string][]entities.Entity {
+	at := func(hours int) utc.Time {
+		return utc.New(contractTime.Add(time.Duration(hours) * time.Hour))
+	}
+	atPtr := func(hours int) *utc.Time {
+		value := at(hours)
+		return &value
+	}
+	ledger := uint64(1000)
+	resultXdr := "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB/////gAAAAA="
+	payload := `{"source":"` + contractSource + `","destination":"` + contractDestination + `","amount":"10"}`
+	anomalies := `{"score":4.5,"reasons":["amount"]}`
+	rebuiltFrom := int64(2)
+	responseStatus := http.StatusOK
+	response := `{"hash":"` + contractHash(1) + `","ledger":1000}`
+	resultCodes := `{"transaction":"tx_failed","operations":["op_underfunded"]}`
+
+	gateway := []entities.Entity{
+		&entities.ReceivedPayment{OperationID: "4294967297", ProcessedAt: at(0), PagingToken: "4294967297", Status: "Success", AssetCode: "USD", AssetIssuer: contractIssuer, Amount: "10.0000000"},
+		&entities.ReceivedPayment{OperationID: "4294967298", ProcessedAt: at(1), PagingToken: "4294967298", Status: "Error", AssetCode: "XLM", Amount: "5.0000000", DisputedAt: atPtr(3), DisputedFields: "amount"},
+		&entities.ReceivedPayment{OperationID: "4294967200", ProcessedAt: at(2), PagingToken: "4294967200", Status: entities.ReceivedPaymentStatusImported, AssetCode: "USD", AssetIssuer: contractIssuer, Amount: "1.5000000", Backfill: true},
+		&entities.ListenerCursor{PagingToken: "4294967296", AfterPaymentID: 1, SetAt: at(0)},
+		&entities.BackfillCursor{AccountID: contractDestination, PagingToken: "4294967200", UpdatedAt: at(2)},
+		&entities.SentTransaction{TransactionID: contractHash(1), Status: entities.SentTransactionStatusSuccess, Source: contractSource, SubmittedAt: at(0), SucceededAt: atPtr(0), Ledger: &ledger, EnvelopeXdr: "AAAAAQ==", CorrelationID: "request-1", Payload: &payload, Anomalies: &anomalies},
+		&entities.SentTransaction{TransactionID: contractHash(2), Status: entities.SentTransactionStatusFailure, Source: contractSource, SubmittedAt: at(1), EnvelopeXdr: "AAAAAg==", ResultXdr: &resultXdr, CorrelationID: "request-2", Payload: &payload},
+		&entities.SentTransaction{TransactionID: contractHash(3), Status: entities.SentTransactionStatusSending, Source: contractSource, SubmittedAt: at(2), EnvelopeXdr: "AAAAAw==", CorrelationID: "request-3", RebuiltFrom: &rebuiltFrom, Internal: true},
+		&entities.DailyVolume{Date: "2018-01-02", AssetCode: "USD", AssetIssuer: contractIssuer, Direction: entities.DailyVolumeDirectionSent, Count: 2, Sum: 200000000, Fees: 200},
+		&entities.DailyVolume{Date: "2018-01-02", AssetCode: "USD", AssetIssuer: contractIssuer, Direction: entities.DailyVolumeDirectionReceived, Count: 1, Sum: 100000000},
+		&entities.DailyVolume{Date: "2018-01-03", AssetCode: "XLM", Direction: entities.DailyVolumeDirectionRefund, Count: 1, Sum: 50000000, Fees: 100},
+		&entities.DailyVolume{Date: "2018-01-02", AssetCode: "USD", AssetIssuer: contractIssuer, Direction: entities.DailyVolumeDirectionInternal, Count: 1, Sum: 300000000, Fees: 100},
+		&entities.RetiredAccount{AccountID: contractSource, MergedInto: contractDestination, OperationID: "4294967299", TransactionHash: contractHash(4), RetiredAt: at(3)},
+		&entities.Conversion{OperationID: "4294967297", Status: entities.ConversionStatusSuccess, SendAssetCode: "USD", SendAssetIssuer: contractIssuer, SendMax: "10.0000000", SendAmount: "9.9000000", DestinationAssetCode: "XLM", DestinationAmount: "30.0000000", EstimatedPrice: "3.0000000", TransactionHash: contractHash(5), CreatedAt: at(0), ConvertedAt: atPtr(0)},
+		&entities.PaymentRequest{RequestID: "request-open", Destination: contractDestination, Generation: 1, AssetCode: "USD", AssetIssuer: contractIssuer, Amount: "20.0000000", MemoType: "id", Memo: "1", Status: entities.PaymentRequestStatusOpen, CreatedAt: at(0), ExpiresAt: atPtr(1)},
+		&entities.PaymentRequest{RequestID: "request-fulfilled", Destination: contractDestination, Generation: 1, AssetCode: "USD", AssetIssuer: contractIssuer, Amount: "10.0000000", MemoType: "id", Memo: "1", Status: entities.PaymentRequestStatusFulfilled, CreatedAt: at(0), FulfilledAt: atPtr(0), OperationID: "4294967297"},
+		&entities.IdempotentPayment{PaymentID: "payment-1", RequestHash: "5d41402abc4b2a76b9719d911017c592", TransactionID: contractHash(1), ResponseStatus: &responseStatus, Response: &response, CreatedAt: at(0), CompletedAt: atPtr(0)},
+		&entities.CounterpartyStats{Destination: contractDestination, Generation: 1, AssetCode: "USD", AssetIssuer: contractIssuer, Count: 2, MeanAmount: 100000000, AmountM2: 0.5, AmountHistogram: `{"8":2}`, HourHistogram: "[0,0,0,0,0,0,0,0,0,0,2,0,0,0,0,0,0,0,0,0,0,0,0,0]", UpdatedAt: at(0)},
+		&entities.CounterpartyStats{Destination: contractDestination, Generation: 2, AssetCode: "USD", AssetIssuer: contractIssuer, Count: 1, MeanAmount: 50000000, AmountHistogram: `{"7":1}`, HourHistogram: "[0,0,0,0,0,0,0,0,0,0,0,0,1,0,0,0,0,0,0,0,0,0,0,0]", UpdatedAt: at(2)},
+		&entities.AccountGeneration{AccountID: contractDestination, Generation: 2, Sequence: 8589934593, RecreatedAt: atPtr(2), UpdatedAt: at(2)},
+		&entities.Event{Type: "transaction_submitting", Subject: contractHash(1), Version: 1, Payload: `{"source":"` + contractSource + `"}`, CreatedAt: at(0)},
+		&entities.Event{Type: "transaction_succeeded", Subject: contractHash(1), Version: 1, Payload: `{"source":"` + contractSource + `","ledger":1000}`, CreatedAt: at(0)},
+		&entities.Event{Type: "payment_received", Subject: "4294967297", Version: 1, Payload: `{"asset_code":"USD","amount":"10.0000000","backfill":false}`, CreatedAt: at(0)},
+		&entities.QueuedPayment{PaymentID: "queued-1", Status: entities.QueuedPaymentStatusSuccess, Payload: `{"version":1,"source_alias":"base_seed","params":{"amount":["10"]}}`, Role: "client", CorrelationID: "request-4", Attempts: 2, LastError: "dependency_unavailable", ResponseStatus: &responseStatus, Response: &response, QueuedAt: at(0), LastAttemptAt: atPtr(1), SettledAt: atPtr(1)},
+		&entities.QueuedPayment{PaymentID: "queued-2", Status: entities.QueuedPaymentStatusQueued, Payload: `{"version":1,"source_alias":"base_seed","params":{"amount":["20"]}}`, Role: "operator", QueuedAt: at(1)},
+		&entities.QueuedPayment{PaymentID: "queued-3", Status: entities.QueuedPaymentStatusQueued, Payload: `{"version":1,"source_alias":"base_seed","params":{"amount":["30"]}}`, Role: "client", QueuedAt: at(2)},
+		&entities.PaymentCallback{TransactionID: contractHash(1), PaymentID: "payment-1", URL: "https://example.com/confirmed", Status: entities.PaymentCallbackStatusDelivered, TransactionStatus: entities.PaymentCallbackSuccess, Ledger: &ledger, Attempts: 1, CreatedAt: at(0), NextAttemptAt: at(0), DeliveredAt: atPtr(0)},
+		&entities.PaymentCallback{TransactionID: contractHash(2), URL: "https://example.com/confirmed", Status: entities.PaymentCallbackStatusPending, TransactionStatus: entities.PaymentCallbackFailed, ResultCodes: &resultCodes, Attempts: 2, LastError: "Error response from payment callback: 500", CreatedAt: at(1), NextAttemptAt: at(2)},
+		&entities.PaymentCallback{TransactionID: contractHash(3), URL: "https://example.com/confirmed", Status: entities.PaymentCallbackStatusPending, CreatedAt: at(2), NextAttemptAt: at(2)},
+		&entities.Reconciliation{Date: "2018-01-02", Mismatches: 1, Report: `{"date":"2018-01-02","entries":[{"type":"missing_in_horizon","hash":"` + contractHash(2) + `"}]}`, CreatedAt: at(24)},
+	}
+	compliance := []entities.Entity{
+		&entities.AuthorizedTransaction{TransactionID: contractHash(6), Memo: "bWVtbw==", TransactionXdr: "AAAABg==", AuthorizedAt: at(0), Data: `{"sender":"alice*stellar.org"}`},
+		&entities.AllowedFi{Name: "Stellar", Domain: "stellar.org", PublicKey: contractIssuer, AllowedAt: at(0)},
+		&entities.AllowedUser{FiName: "Stellar", FiDomain: "stellar.org", FiPublicKey: contractIssuer, UserID: "alice", AllowedAt: at(0)},
+	}
+	return map[string][]entities.Entity{"gateway": gateway, "compliance": compliance}
+}
+
+func contractHash(n int) string {
+	return fmt.Sprintf("%064x", n)
+}
+
+// repositoryContract returns calls of every exported method of Repository by component and
+// method name
+func repositoryContract() map[string]map[string]func(r db.Repository) (interface{}, error) {
+	at := func(hours int) time.Time {
+		return contractTime.Add(time.Duration(hours) * time.Hour)
+	}
+
+	gateway := map[string]func(r db.Repository) (interface{}, error){
+		"GetLastCursorValue": func(r db.Repository) (interface{}, error) { return r.GetLastCursorValue() },
+		"GetListenerCursor":  func(r db.Repository) (interface{}, error) { return r.GetListenerCursor() },
+		"GetLastReceivedPaymentID": func(r db.Repository) (interface{}, error) {
+			return r.GetLastReceivedPaymentID()
+		},
+		"GetReceivedPaymentByOperationID": func(r db.Repository) (interface{}, error) {
+			return r.GetReceivedPaymentByOperationID(4294967298)
+		},
+		"GetReceivedPayments": func(r db.Repository) (interface{}, error) {
+			return r.GetReceivedPayments(1, 2)
+		},
+		"GetSentTransactions": func(r db.Repository) (interface{}, error) {
+			return r.GetSentTransactions(1, 2)
+		},
+		"GetReceivedPaymentsPage": func(r db.Repository) (interface{}, error) {
+			return r.GetReceivedPaymentsPage(pagination.Query{Cursor: &pagination.Cursor{ID: 3, Direction: pagination.DirectionNext}, Limit: 1})
+		},
+		"GetDisputedReceivedPaymentsPage": func(r db.Repository) (interface{}, error) {
+			return r.GetDisputedReceivedPaymentsPage(pagination.Query{Limit: 10})
+		},
+		"GetSentTransactionsPage": func(r db.Repository) (interface{}, error) {
+			return r.GetSentTransactionsPage(pagination.Query{Cursor: &pagination.Cursor{ID: 1, Direction: pagination.DirectionPrev}, Limit: 10})
+		},
+		"GetReceivedPaymentsProcessedBetween": func(r db.Repository) (interface{}, error) {
+			return r.GetReceivedPaymentsProcessedBetween(at(0), at(2))
+		},
+		"GetSentTransactionsSucceededBetween": func(r db.Repository) (interface{}, error) {
+			return r.GetSentTransactionsSucceededBetween(at(0), at(1))
+		},
+		"GetSentTransactionsSubmittedBetween": func(r db.Repository) (interface{}, error) {
+			return r.GetSentTransactionsSubmittedBetween(at(0), at(3), 1, 1)
+		},
+		"GetDailyVolumes": func(r db.Repository) (interface{}, error) {
+			return r.GetDailyVolumes("2018-01-01", "2018-01-02", "USD", contractIssuer)
+		},
+		"GetBackfillCursor": func(r db.Repository) (interface{}, error) {
+			return r.GetBackfillCursor(contractDestination)
+		},
+		"GetRetiredAccount": func(r db.Repository) (interface{}, error) {
+			return r.GetRetiredAccount(contractSource)
+		},
+		"GetAccountGeneration": func(r db.Repository) (interface{}, error) {
+			return r.GetAccountGeneration(contractDestination)
+		},
+		"GetConversionByOperationID": func(r db.Repository) (interface{}, error) {
+			return r.GetConversionByOperationID("4294967297")
+		},
+		"GetSentTransactionByHash": func(r db.Repository) (interface{}, error) {
+			return r.GetSentTransactionByHash(contractHash(2))
+		},
+		"GetSentTransactionsRebuiltFrom": func(r db.Repository) (interface{}, error) {
+			return r.GetSentTransactionsRebuiltFrom(2)
+		},
+		"GetPaymentRequestByRequestID": func(r db.Repository) (interface{}, error) {
+			return r.GetPaymentRequestByRequestID("request-fulfilled")
+		},
+		"GetPaymentRequestsByMemo": func(r db.Repository) (interface{}, error) {
+			return r.GetPaymentRequestsByMemo("id", "1")
+		},
+		"GetPaymentRequestByOperationID": func(r db.Repository) (interface{}, error) {
+			return r.GetPaymentRequestByOperationID("4294967297")
+		},
+		"GetExpiredPaymentRequests": func(r db.Repository) (interface{}, error) {
+			return r.GetExpiredPaymentRequests(at(1))
+		},
+		"CountReceivedPaymentsExcept": func(r db.Repository) (interface{}, error) {
+			return r.CountReceivedPaymentsExcept("Success")
+		},
+		"GetIdempotentPaymentByPaymentID": func(r db.Repository) (interface{}, error) {
+			return r.GetIdempotentPaymentByPaymentID("payment-1")
+		},
+		"GetQueuedPaymentByPaymentID": func(r db.Repository) (interface{}, error) {
+			return r.GetQueuedPaymentByPaymentID("queued-1")
+		},
+		"GetQueuedPayments": func(r db.Repository) (interface{}, error) {
+			return r.GetQueuedPayments(1)
+		},
+		"GetPaymentCallbacks": func(r db.Repository) (interface{}, error) {
+			return r.GetPaymentCallbacks(at(2), 1)
+		},
+		"GetCounterpartyStats": func(r db.Repository) (interface{}, error) {
+			return r.GetCounterpartyStats(contractDestination)
+		},
+		"GetEventsAfter": func(r db.Repository) (interface{}, error) {
+			return r.GetEventsAfter(1, 1)
+		},
+		"GetReconciliationByDate": func(r db.Repository) (interface{}, error) {
+			return r.GetReconciliationByDate("2018-01-02")
+		},
+	}
+	compliance := map[string]func(r db.Repository) (interface{}, error){
+		"GetAuthorizedTransactionByMemo": func(r db.Repository) (interface{}, error) {
+			return r.GetAuthorizedTransactionByMemo("bWVtbw==")
+		},
+		"GetAllowedFiByDomain": func(r db.Repository) (interface{}, error) {
+			return r.GetAllowedFiByDomain("stellar.org")
+		},
+		"GetAllowedUserByDomainAndUserID": func(r db.Repository) (interface{}, error) {
+			return r.GetAllowedUserByDomainAndUserID("stellar.org", "alice")
+		},
+	}
+	return map[string]map[string]func(r db.Repository) (interface{}, error){"gateway": gateway, "compliance": compliance}
+}
+
+// contractEndpoint is a request to an admin endpoint reading the database
+type contractEndpoint struct {
+	name    string
+	url     string
+	params  map[string]string
+	handler func(rh *RequestHandler, c web.C, w http.ResponseWriter, r *http.Request)
+}
+
+func adminContract() []contractEndpoint {
+	list := func(handler func(rh *RequestHandler, w http.ResponseWriter, r *http.Request)) func(rh *RequestHandler, c web.C, w http.ResponseWriter, r *http.Request) {
+		return func(rh *RequestHandler, c web.C, w http.ResponseWriter, r *http.Request) {
+			handler(rh, w, r)
+		}
+	}
+
+	return []contractEndpoint{
+		{name: "received-payments", url: "/admin/received-payments?limit=2", handler: list((*RequestHandler).AdminReceivedPayments)},
+		{name: "received-payments legacy page", url: "/admin/received-payments?page=1", handler: list((*RequestHandler).AdminReceivedPayments)},
+		{name: "received-payment", url: "/admin/received-payments/1", params: map[string]string{"id": "1"}, handler: (*RequestHandler).AdminReceivedPayment},
+		{name: "disputes", url: "/admin/disputes", handler: list((*RequestHandler).AdminDisputes)},
+		{name: "sent-transactions", url: "/admin/sent-transactions", handler: list((*RequestHandler).AdminSentTransactions)},
+		{name: "export envelopes", url: "/admin/export/envelopes?from=2018-01-02T10:00:00Z&to=2018-01-02T12:00:00Z", handler: list((*RequestHandler).AdminExportEnvelopes)},
+		{name: "stats volumes", url: "/admin/stats/volumes?from=2018-01-01&to=2018-01-03", handler: list((*RequestHandler).AdminStatsVolumes)},
+		{name: "events", url: "/admin/events?after=1&limit=5", handler: list((*RequestHandler).AdminEvents)},
+		{name: "reconciliation", url: "/admin/reconciliations/2018-01-02", params: map[string]string{"date": "2018-01-02"}, handler: (*RequestHandler).AdminReconciliation},
+	}
+}
+
+func contractHorizon() *mocks.MockHorizon {
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadOperation", "4294967297").Return(horizon.PaymentResponse{
+		ID:          "4294967297",
+		Type:        "payment",
+		PagingToken: "4294967297",
+		From:        contractSource,
+		To:          contractDestination,
+		AssetType:   "credit_alphanum4",
+		AssetCode:   "USD",
+		AssetIssuer: contractIssuer,
+		Amount:      "10.0000000",
+	}, nil)
+	mockHorizon.On("LoadMemo", mock.AnythingOfType("*horizon.PaymentResponse")).Return(nil)
+	return mockHorizon
+}
+
+// callContractEndpoint returns the JSON response of an endpoint, JSON lines responses are returned
+// as arrays
+func callContractEndpoint(t *testing.T, databaseType string, rh *RequestHandler, endpoint contractEndpoint) interface{} {
+	r := httptest.NewRequest("GET", endpoint.url, nil)
+	w := httptest.NewRecorder()
+	endpoint.handler(rh, web.C{URLParams: endpoint.params}, w, r)
+	require.Equal(t, http.StatusOK, w.Code, "%s: %s: %s", databaseType, endpoint.name, w.Body.String())
+
+	var lines []interface{}
+	for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		var value interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &value), "%s: %s", databaseType, endpoint.name)
+		lines = append(lines, value)
+	}
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	return lines
+}
+
+// assertContractGolden compares results with testdata/contract/<name>.golden.json, when -update
+// is set golden files are written by SQLite results
+func assertContractGolden(t *testing.T, databaseType, name string, results map[string]interface{}) {
+	file := filepath.Join(contractGoldenDir, name+".golden.json")
+	actual, err := json.MarshalIndent(results, "", "  ")
+	require.NoError(t, err)
+
+	if *updateGolden && databaseType == "sqlite" {
+		require.NoError(t, os.MkdirAll(contractGoldenDir, 0755))
+		require.NoError(t, ioutil.WriteFile(file, append(actual, '\n'), 0644))
+	}
+
+	expected, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actual), "%s: %s differs from %s", databaseType, name, file)
+}
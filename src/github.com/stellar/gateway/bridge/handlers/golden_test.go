@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Golden files pin envelopes built by every endpoint building transactions. Run
+// `go test ./bridge/handlers -run TestGoldenEnvelopes -update` to regenerate them after an
+// intentional change and review the diff of `.golden.json` files.
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+// goldenBackends are transaction_builder backends fixtures are built with. They must build the
+// same envelopes, golden files are updated with the first one.
+var goldenBackends = []string{txspec.BackendBuild, txspec.BackendXDR}
+
+const goldenSequence = "100"
+
+// goldenCase is a fixture in testdata/golden/<name>.json
+type goldenCase struct {
+	Endpoint string `json:"endpoint"`
+	// Form contains params of form requests
+	Form map[string]string `json:"form"`
+	// Body is sent as JSON when set
+	Body json.RawMessage `json:"body"`
+	// Accounts known to Horizon in addition to the config accounts (sequence 100, no trustlines)
+	Accounts map[string]horizon.AccountResponse `json:"accounts"`
+}
+
+// goldenHorizon is a Horizon with fixed accounts that records submitted envelopes
+type goldenHorizon struct {
+	mocks.MockHorizon
+	accounts  map[string]horizon.AccountResponse
+	submitted []string
+}
+
+func (h *goldenHorizon) LoadAccount(accountID string) (horizon.AccountResponse, error) {
+	account, ok := h.accounts[accountID]
+	if !ok {
+		return horizon.AccountResponse{}, errors.New("StatusCode indicates error")
+	}
+	return account, nil
+}
+
+func (h *goldenHorizon) LoadAccountCtx(ctx context.Context, accountID string) (horizon.AccountResponse, error) {
+	return h.LoadAccount(accountID)
+}
+
+func (h *goldenHorizon) SubmitTransactionCtx(ctx context.Context, txeBase64 string) (horizon.SubmitTransactionResponse, error) {
+	return h.SubmitTransaction(txeBase64)
+}
+
+func (h *goldenHorizon) SubmitTransaction(txeBase64 string) (horizon.SubmitTransactionResponse, error) {
+	h.submitted = append(h.submitted, txeBase64)
+	ledger := uint64(1000)
+	return horizon.SubmitTransactionResponse{Hash: "golden", Ledger: &ledger}, nil
+}
+
+func (h *goldenHorizon) WithCorrelationID(id string) horizon.HorizonInterface {
+	return h
+}
+
+func goldenConfig() *config.Config {
+	return &config.Config{
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+		Assets:            []config.Asset{{Code: "USD", Issuer: "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"}},
+		Accounts: config.Accounts{
+			// GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ
+			BaseSeed:         "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK",
+			AuthorizingSeed:  "SC37TBSIAYKIDQ6GTGLT2HSORLIHZQHBXVFI5P5K4Q5TSHRTRBK3UNWG",
+			IssuingAccountID: "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR",
+			// GC3P6OVKV3D6MCRIYFN6AUR6UA2A356N3GMPHFPGPLAWAPA7MI2QV7AR
+			RecoverySeed: "SBTTC5QPPOQRVE4HALMVOL4MZ5JGCB76JPYXISRXEXYMJ25OMDO3EJBI",
+		},
+	}
+}
+
+func TestGoldenEnvelopes(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/golden/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	for _, backend := range goldenBackends {
+		for _, fixture := range fixtures {
+			if strings.HasSuffix(fixture, ".golden.json") {
+				continue
+			}
+			testGoldenEnvelopes(t, fixture, backend)
+		}
+	}
+}
+
+func testGoldenEnvelopes(t *testing.T, fixture, backend string) {
+	name := strings.TrimSuffix(fixture, ".json")
+	t.Run(backend+"/"+filepath.Base(name), func(t *testing.T) {
+		envelopes := buildGoldenEnvelopes(t, fixture, backend)
+		require.NotEmpty(t, envelopes, "no envelopes built")
+
+		dumps := make([]interface{}, len(envelopes))
+		for i, envelope := range envelopes {
+			var txe xdr.TransactionEnvelope
+			require.NoError(t, xdr.SafeUnmarshalBase64(envelope, &txe))
+			dumps[i] = dumpXDR(reflect.ValueOf(txe))
+		}
+		dump, err := json.MarshalIndent(dumps, "", "  ")
+		require.NoError(t, err)
+
+		golden := strings.Join(envelopes, "\n") + "\n"
+		dump = append(dump, '\n')
+
+		if *updateGolden && backend == goldenBackends[0] {
+			require.NoError(t, ioutil.WriteFile(name+".golden", []byte(golden), 0644))
+			require.NoError(t, ioutil.WriteFile(name+".golden.json", dump, 0644))
+			return
+		}
+
+		expectedDump, err := ioutil.ReadFile(name + ".golden.json")
+		require.NoError(t, err, "missing golden file, run with -update")
+		assert.Equal(t, string(expectedDump), string(dump))
+
+		expected, err := ioutil.ReadFile(name + ".golden")
+		require.NoError(t, err, "missing golden file, run with -update")
+		assert.Equal(t, string(expected), golden)
+	})
+}
+
+// buildGoldenEnvelopes sends a fixture request and returns envelopes submitted to Horizon followed
+// by the envelope returned in the response (if any)
+func buildGoldenEnvelopes(t *testing.T, fixture, backend string) []string {
+	data, err := ioutil.ReadFile(fixture)
+	require.NoError(t, err)
+
+	var c goldenCase
+	require.NoError(t, json.Unmarshal(data, &c))
+
+	cfg := goldenConfig()
+	cfg.TransactionBuilder = backend
+	h := &goldenHorizon{accounts: make(map[string]horizon.AccountResponse)}
+	for _, seed := range []string{cfg.Accounts.BaseSeed, cfg.Accounts.AuthorizingSeed, cfg.Accounts.RecoverySeed} {
+		kp := keypair.MustParse(seed)
+		h.accounts[kp.Address()] = horizon.AccountResponse{AccountID: kp.Address(), SequenceNumber: goldenSequence}
+	}
+	for accountID, account := range c.Accounts {
+		account.AccountID = accountID
+		if account.SequenceNumber == "" {
+			account.SequenceNumber = goldenSequence
+		}
+		h.accounts[accountID] = account
+	}
+
+	entityManager := new(mocks.MockEntityManager)
+	entityManager.On("Persist", mock.Anything).Return(nil)
+
+	now := func() time.Time { return time.Unix(1500000000, 0) }
+	ts := submitter.NewTransactionSubmitter(h, entityManager, cfg.NetworkPassphrase, now)
+
+	requestHandler := RequestHandler{
+		Config:               cfg,
+		Horizon:              h,
+		TransactionSubmitter: &ts,
+		FederationResolver:   new(mocks.MockFederationResolver),
+		StellarTomlResolver:  new(mocks.MockStellartomlResolver),
+	}
+
+	handlers := map[string]http.HandlerFunc{
+		"/authorize":       requestHandler.Authorize,
+		"/authorize/batch": requestHandler.AuthorizeBatch,
+		"/builder":         requestHandler.Builder,
+		"/payment":         requestHandler.Payment,
+		"/preauth":         requestHandler.Preauth,
+	}
+	handler, ok := handlers[c.Endpoint]
+	require.True(t, ok, "unknown endpoint %s", c.Endpoint)
+
+	testServer := httptest.NewServer(handler)
+	defer testServer.Close()
+
+	var statusCode int
+	var response []byte
+	if c.Body != nil {
+		statusCode, response = net.JSONGetResponse(testServer, c.Body)
+	} else {
+		params := url.Values{}
+		for key, value := range c.Form {
+			params.Set(key, value)
+		}
+		statusCode, response = net.GetResponse(testServer, params)
+	}
+	require.Equal(t, 200, statusCode, string(response))
+
+	envelopes := h.submitted
+	var body struct {
+		TransactionEnvelope string `json:"transaction_envelope"`
+	}
+	json.Unmarshal(response, &body)
+	if body.TransactionEnvelope != "" {
+		envelopes = append(envelopes, body.TransactionEnvelope)
+	}
+	return envelopes
+}
+
+var accountIDType = reflect.TypeOf(xdr.AccountId{})
+
+// dumpXDR converts XDR value to a diff-friendly structure: unset union arms are omitted, account
+// IDs are strkey encoded, asset codes are strings and other bytes are hex encoded.
+func dumpXDR(v reflect.Value) interface{} {
+	if v.Type() == accountIDType {
+		accountID := v.Interface().(xdr.AccountId)
+		return accountID.Address()
+	}
+
+	if stringer, ok := v.Interface().(interface{ String() string }); ok && v.Kind() == reflect.Int32 {
+		return stringer.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpXDR(v.Elem())
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			value := v.Field(i)
+			if field.PkgPath != "" || (value.Kind() == reflect.Ptr && value.IsNil()) {
+				continue
+			}
+			if field.Name == "AssetCode" && value.Kind() == reflect.Array {
+				fields[field.Name] = strings.TrimRight(string(bytesOf(value)), "\x00")
+				continue
+			}
+			fields[field.Name] = dumpXDR(value)
+		}
+		return fields
+	case reflect.Array, reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return hex.EncodeToString(bytesOf(v))
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = dumpXDR(v.Index(i))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
+
+func bytesOf(v reflect.Value) []byte {
+	result := make([]byte, v.Len())
+	for i := range result {
+		result[i] = byte(v.Index(i).Uint())
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/test"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// testBaseSeed is the base seed of handlers created by newTestRequestHandler
+	testBaseSeed = "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"
+	// testDestination and testIssuer are the destination and the USD issuer of test payments
+	testDestination = "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	testIssuer      = "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+)
+
+// newTestRequestHandler returns a handler sending payments from testBaseSeed on the test network
+func newTestRequestHandler(h horizon.HorizonInterface) RequestHandler {
+	return RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Accounts:          config.Accounts{BaseSeed: testBaseSeed},
+		},
+		Horizon: h,
+	}
+}
+
+// usdPaymentParams returns params of a payment of amount USD to testDestination, params are added
+// to it
+func usdPaymentParams(amount string, params url.Values) url.Values {
+	values := url.Values{
+		"destination":  {testDestination},
+		"amount":       {amount},
+		"asset_code":   {"USD"},
+		"asset_issuer": {testIssuer},
+	}
+	for name, value := range params {
+		values[name] = value
+	}
+	return values
+}
+
+// accountTrusting returns an account with a sequence number and trustlines of assets with the
+// maximum limit
+func accountTrusting(sequence string, assets ...protocols.Asset) horizon.AccountResponse {
+	account := horizon.AccountResponse{SequenceNumber: sequence}
+	for _, asset := range assets {
+		account.Balances = append(account.Balances, horizon.Balance{
+			Balance:     "0.0000000",
+			Limit:       "922337203685.4775807",
+			AssetType:   "credit_alphanum4",
+			AssetCode:   asset.Code,
+			AssetIssuer: asset.Issuer,
+		})
+	}
+	return account
+}
+
+// newPaymentRequest returns a /payment request with params in a form body
+func newPaymentRequest(t *testing.T, params url.Values) *http.Request {
+	request, err := http.NewRequest(http.MethodPost, "/payment", strings.NewReader(params.Encode()))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+// servePayment serves request with requestHandler and returns the response
+func servePayment(requestHandler *RequestHandler, request *http.Request) (int, map[string]interface{}) {
+	response := httptest.NewRecorder()
+	requestHandler.Payment(response, request)
+	return response.Code, test.StringToJSONMap(response.Body.String())
+}
+
+// postPayment sends a /payment request with params to requestHandler and returns the response
+func postPayment(t *testing.T, requestHandler *RequestHandler, params url.Values) (int, map[string]interface{}) {
+	return servePayment(requestHandler, newPaymentRequest(t, params))
+}
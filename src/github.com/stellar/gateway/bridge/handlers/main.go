@@ -1,13 +1,43 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/anomaly"
+	"github.com/stellar/gateway/async"
+	"github.com/stellar/gateway/backfill"
+	"github.com/stellar/gateway/breaker"
 	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/channels"
+	"github.com/stellar/gateway/confirmation"
+	"github.com/stellar/gateway/counterparty"
 	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/errormap"
+	"github.com/stellar/gateway/events"
 	"github.com/stellar/gateway/external"
+	"github.com/stellar/gateway/generation"
+	"github.com/stellar/gateway/handoff"
 	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/jws"
+	"github.com/stellar/gateway/leader"
 	"github.com/stellar/gateway/listener"
+	"github.com/stellar/gateway/logging"
 	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/queue"
+	"github.com/stellar/gateway/ratelimit"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/gateway/server"
 	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/warmup"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stellar/go/keypair"
 )
 
 // RequestHandler implements bridge server request handlers
@@ -17,10 +47,191 @@ type RequestHandler struct {
 	Horizon              horizon.HorizonInterface                `inject:""`
 	Driver               db.Driver                               `inject:""`
 	Repository           db.RepositoryInterface                  `inject:""`
+	EntityManager        db.EntityManagerInterface               `inject:""`
 	StellarTomlResolver  external.StellarTomlClientInterface     `inject:""`
 	FederationResolver   external.FederationClientInterface      `inject:""`
+	FederationMetrics    *external.FederationMetrics             `inject:""`
 	TransactionSubmitter submitter.TransactionSubmitterInterface `inject:""`
 	PaymentListener      *listener.PaymentListener               `inject:""`
+	LogSampler           *logging.Sampler                        `inject:""`
+	Backfills            *backfill.Manager                       `inject:""`
+	Breakers             *breaker.Set                            `inject:""`
+	Elector              *leader.Elector                         `inject:""`
+	Webhooks             *webhook.Client                         `inject:""`
+	HorizonFailures      *horizon.FailureLog                     `inject:""`
+	HorizonRateLimits    *horizon.RateLimits                     `inject:""`
+	Retries              *retry.Set                              `inject:""`
+	Warmer               *warmup.Warmer                          `inject:""`
+	ErrorMapper          *errormap.Mapper                        `inject:""`
+	Inflight             *inflight.Registry                      `inject:""`
+	Counterparties       *counterparty.Lists                     `inject:""`
+	Handoffs             *handoff.Store                          `inject:""`
+	IssuerInfo           *external.IssuerInfoResolver            `inject:""`
+	Anomalies            *anomaly.Detector                       `inject:""`
+	Channels             *channels.Pool                          `inject:""`
+	Events               *events.Journal                         `inject:""`
+	Generations          *generation.Tracker                     `inject:""`
+	SubmissionRate       *ratelimit.Limiter                      `inject:""`
+	PaymentQueue         *queue.Worker                           `inject:""`
+	PaymentCallbacks     *confirmation.Worker                    `inject:""`
+	AsyncSubmissions     *async.Pool                             `inject:""`
+	// ConfigFile is reloaded by /admin/reload, reload is not available when empty
+	ConfigFile string
+	// Signer signs verdicts of /verify/payment, it's set when response_signing is configured
+	Signer *jws.Signer
+	// Security is the policy of strict security mode checking configs loaded by /admin/reload,
+	// nil when the mode is disabled
+	Security *config.SecurityPolicy
+	// correlationID is stored with payments sent by a copy returned by withCorrelationID
+	correlationID string
+	// rebuiltFrom is the ID of the failed transaction a copy used by rebuilds is sending again
+	rebuiltFrom *int64
+	// inflightPayment is the payment tracked by a copy returned by withInflight
+	inflightPayment *inflight.Payment
+	// idempotent is the payment with `id` param sent by a copy used by idempotentPayment
+	idempotent *idempotentSend
+	// internal is true in a copy returned by internalTransfer sending to an account of the config
+	internal bool
+	// ctx is the context of the request sent by a copy returned by withContext
+	ctx context.Context
+	// paymentCallback is the `callback` of the payment sent by a copy returned by withCallback
+	paymentCallback *entities.PaymentCallback
+	// async is the payment with `async=true` param sent by a copy used by asyncPayment
+	async *asyncSend
+}
+
+// requestLog returns a logger of handler logs of a request. Request ID attached by
+// server.RequestIDMiddleware makes all logs of the request sampled together.
+func requestLog(r *http.Request) *log.Entry {
+	fields := log.Fields{
+		logging.CategoryField: logging.CategoryHandler,
+		"client_ip":           server.ClientIP(r),
+	}
+	if id := server.RequestID(r); id != "" {
+		fields[logging.RequestIDField] = id
+	}
+	return log.WithFields(fields)
+}
+
+// PaymentJSONMiddleware converts JSON bodies of POST /payment requests to form params, so JSON
+// requests are authenticated, validated and processed like form requests. It must run before
+// middleware reading form params (server.APIKeyMiddleware).
+func PaymentJSONMiddleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/payment" && bridge.IsJSONRequest(r) {
+			err := bridge.ParsePaymentJSON(r)
+			if err != nil {
+				errorResponse := err.(*protocols.ErrorResponse)
+				requestLog(r).WithFields(errorResponse.LogData).Error(errorResponse.Error())
+				server.Write(w, errorResponse)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// correlatedSubmitter is implemented by submitters forwarding correlation IDs to Horizon
+type correlatedSubmitter interface {
+	WithCorrelationID(id string) submitter.TransactionSubmitterInterface
+}
+
+// withCorrelationID returns a copy of rh sending the correlation ID of r to Horizon and storing it
+// with sent transactions. An error response is written and false returned when the ID is invalid.
+func (rh *RequestHandler) withCorrelationID(w http.ResponseWriter, r *http.Request) (*RequestHandler, bool) {
+	id, err := server.CorrelationID(r)
+	if err != nil {
+		server.Write(w, protocols.NewInvalidParameterError(server.CorrelationIDParam, id, err.Error()))
+		return nil, false
+	}
+	if id == "" {
+		return rh, true
+	}
+
+	handler := *rh
+	handler.correlationID = id
+	handler.Horizon = rh.Horizon.WithCorrelationID(id)
+	if ts, ok := rh.TransactionSubmitter.(correlatedSubmitter); ok {
+		handler.TransactionSubmitter = ts.WithCorrelationID(id)
+	}
+	return &handler, true
+}
+
+// withInflight returns a copy of rh tracking a payment of r in the inflight registry. The
+// source is reported by its config key, or by its account ID when it's not in the config file.
+// Payment of the copy must be finished by inflightPayment.Done().
+func (rh *RequestHandler) withInflight(r *http.Request, request *bridge.PaymentRequest) *RequestHandler {
+	if rh.Inflight == nil {
+		return rh
+	}
+
+	source := rh.Config.Accounts.SeedAlias(request.Source)
+	if source == "" {
+		if sourceKeypair, err := keypair.Parse(request.Source); err == nil {
+			source = sourceKeypair.Address()
+		}
+	}
+
+	handler := *rh
+	handler.inflightPayment = rh.Inflight.Start(server.RequestID(r), inflight.KindSync, source)
+	return &handler
+}
+
+// withContext returns a copy of rh whose account loads are cancelled when the client of r
+// disconnects, submissions are not cancelled
+func (rh *RequestHandler) withContext(r *http.Request) *RequestHandler {
+	handler := *rh
+	handler.ctx = r.Context()
+	return &handler
+}
+
+// context returns the context of Horizon calls, payments sent in the background by copies
+// without a request context are not cancelled
+func (rh *RequestHandler) context() context.Context {
+	if rh.ctx == nil {
+		return context.Background()
+	}
+	return rh.ctx
+}
+
+// dependencyError returns DependencyUnavailableError when err was returned by an open circuit
+// breaker, RateLimitedError when Horizon rate limit was not reset in time, HorizonTimeoutError
+// when Horizon did not respond in time, PaymentChannelsExhausted when no channel account was
+// released in time, nil otherwise
+func dependencyError(err error) *protocols.ErrorResponse {
+	switch err := err.(type) {
+	case *breaker.OpenError:
+		return protocols.NewDependencyUnavailableError(err.Dependency)
+	case *horizon.RateLimitedError:
+		return protocols.NewRateLimitedError(err.RetryAfter)
+	case *horizon.TimeoutError:
+		return protocols.NewHorizonTimeoutError(err.Endpoint)
+	case *channels.ExhaustedError:
+		return bridge.NewPaymentChannelsExhaustedError(err.RetryAfter)
+	}
+	return nil
+}
+
+// isLookupAborted returns true when a failed account load does not mean the account is missing:
+// Horizon is not available or the request has been cancelled
+func isLookupAborted(err error) bool {
+	return dependencyError(err) != nil || errors.Is(err, context.Canceled)
+}
+
+// withHorizonFailureID returns a copy of errorResponse with `horizon_failure_id` data when
+// horizon_failures.attach_id is enabled and the error was caused by a captured Horizon failure
+func (rh *RequestHandler) withHorizonFailureID(errorResponse *protocols.ErrorResponse, failureID string) *protocols.ErrorResponse {
+	if !rh.Config.HorizonFailures.AttachID || failureID == "" {
+		return errorResponse
+	}
+
+	response := *errorResponse
+	response.Data = map[string]interface{}{"horizon_failure_id": failureID}
+	for key, value := range errorResponse.Data {
+		response.Data[key] = value
+	}
+	return &response
 }
 
 func (rh *RequestHandler) isAssetAllowed(code string, issuer string) bool {
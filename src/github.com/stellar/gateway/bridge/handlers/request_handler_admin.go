@@ -2,17 +2,34 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/backfill"
+	"github.com/stellar/gateway/bridge/config"
 	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/errormap"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/external"
 	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/listener"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/pagination"
 	"github.com/stellar/gateway/protocols"
 	callback "github.com/stellar/gateway/protocols/compliance"
+	"github.com/stellar/gateway/reconciliation"
 	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/signatures"
+	"github.com/stellar/gateway/stats"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/gateway/webhook"
 	"github.com/stellar/go/protocols/compliance"
 	"github.com/stellar/go/support/errors"
 	"github.com/zenazn/goji/web"
@@ -33,6 +50,7 @@ func (rh *RequestHandler) AdminReceivedPayment(c web.C, w http.ResponseWriter, r
 	}
 
 	payment := object.(*entities.ReceivedPayment)
+	rh.addIssuerInfo(payment)
 
 	paymentResponse, err := rh.Horizon.LoadOperation(payment.OperationID)
 	if err != nil {
@@ -115,42 +133,814 @@ func (rh *RequestHandler) getComplianceData(memo string) (*compliance.AuthData,
 
 // AdminReceivedPayments implements /admin/received-payments endpoint
 func (rh *RequestHandler) AdminReceivedPayments(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limit := 10
+	var payments []*entities.ReceivedPayment
+	var err error
 
-	payments, err := rh.Repository.GetReceivedPayments(page, limit)
+	legacy := isLegacyPageRequest(r)
+	query, errorResponse := pagination.QueryFromRequest(r)
+	if legacy {
+		// Deprecated: `page` param is kept for one release, use `cursor`
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		payments, err = rh.Repository.GetReceivedPayments(page, legacyPageLimit)
+	} else if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	} else {
+		payments, err = rh.Repository.GetReceivedPaymentsPage(query)
+	}
 	if err != nil {
 		log.WithFields(log.Fields{"err": err}).Error("Error loading ReceivedPayments")
 		server.Write(w, protocols.InternalServerError)
 		return
 	}
 
-	encoder := json.NewEncoder(w)
-	err = encoder.Encode(payments)
+	for _, payment := range payments {
+		rh.addIssuerInfo(payment)
+	}
+	rh.writeListPage(w, r, legacy, query, payments)
+}
+
+// AdminDisputes implements /admin/disputes endpoint returning received payments marked as
+// disputed by /verify/payment, newest first
+func (rh *RequestHandler) AdminDisputes(w http.ResponseWriter, r *http.Request) {
+	query, errorResponse := pagination.QueryFromRequest(r)
+	if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	}
+
+	payments, err := rh.Repository.GetDisputedReceivedPaymentsPage(query)
 	if err != nil {
-		log.WithFields(log.Fields{"err": err, "payments": payments}).Error("Error encoding ReceivedPayments")
+		log.WithFields(log.Fields{"err": err}).Error("Error loading disputed ReceivedPayments")
 		server.Write(w, protocols.InternalServerError)
 		return
 	}
+
+	for _, payment := range payments {
+		rh.addIssuerInfo(payment)
+	}
+	rh.writeListPage(w, r, false, query, payments)
 }
 
-// AdminReceivedPayments implements /admin/sent-transactions endpoint
+// addIssuerInfo sets issuer fields of a received payment from the cache, admin views don't wait
+// for issuers that are not cached yet
+func (rh *RequestHandler) addIssuerInfo(payment *entities.ReceivedPayment) {
+	if rh.IssuerInfo == nil || payment.AssetIssuer == "" {
+		return
+	}
+
+	info, ok := rh.IssuerInfo.Lookup(payment.AssetCode, payment.AssetIssuer, 0)
+	if ok {
+		payment.IssuerName = info.Name
+		payment.IssuerDomain = info.Domain
+		payment.AnchorAssetStatus = info.AnchorAssetStatus
+	}
+}
+
+// AdminSentTransactions implements /admin/sent-transactions endpoint
 func (rh *RequestHandler) AdminSentTransactions(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	limit := 10
+	var transactions []*entities.SentTransaction
+	var err error
 
-	transactions, err := rh.Repository.GetSentTransactions(page, limit)
+	legacy := isLegacyPageRequest(r)
+	query, errorResponse := pagination.QueryFromRequest(r)
+	if legacy {
+		// Deprecated: `page` param is kept for one release, use `cursor`
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		transactions, err = rh.Repository.GetSentTransactions(page, legacyPageLimit)
+	} else if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	} else {
+		transactions, err = rh.Repository.GetSentTransactionsPage(query)
+	}
 	if err != nil {
 		log.WithFields(log.Fields{"err": err}).Error("Error loading SentTransactions")
 		server.Write(w, protocols.InternalServerError)
 		return
 	}
 
+	rh.writeListPage(w, r, legacy, query, transactions)
+}
+
+// legacyPageLimit is a page size of deprecated `page` param
+const legacyPageLimit = 10
+
+// isLegacyPageRequest returns true when a list endpoint is called with deprecated `page` param
+// (without `cursor`). Such requests get a plain array of records, like before pagination envelope.
+func isLegacyPageRequest(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Get("page") != "" && query.Get("cursor") == ""
+}
+
+// writeListPage writes records of a list endpoint in pagination envelope or, for legacy
+// requests, as an array
+func (rh *RequestHandler) writeListPage(w http.ResponseWriter, r *http.Request, legacy bool, query pagination.Query, records interface{}) {
+	var response interface{} = records
+	if legacy {
+		w.Header().Set("Deprecation", "true")
+	} else {
+		page, err := pagination.NewPage(r.URL.Path, query, records)
+		if err != nil {
+			log.WithFields(log.Fields{"err": err, "path": r.URL.Path}).Error("Error building page")
+			server.Write(w, protocols.InternalServerError)
+			return
+		}
+		response = page
+	}
+
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(response)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "path": r.URL.Path}).Error("Error encoding page")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminEvents implements /admin/events endpoint. Events are listed in the order they were
+// written, starting after the sequence number of `after` param (from the first event when not set).
+func (rh *RequestHandler) AdminEvents(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	var after int64
+	if value := values.Get("after"); value != "" {
+		var err error
+		after, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || after < 0 {
+			server.Write(w, protocols.NewInvalidParameterError("after", value, "after must be a sequence number of an event."))
+			return
+		}
+	}
+
+	limit := pagination.DefaultLimit
+	if value := values.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > pagination.MaxLimit {
+			server.Write(w, protocols.NewInvalidParameterError("limit", value, fmt.Sprintf("limit must be between 1 and %d.", pagination.MaxLimit)))
+			return
+		}
+	}
+
+	stored, err := rh.Repository.GetEventsAfter(after, limit)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "after": after}).Error("Error loading events")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(events.NewPage(r.URL.Path, after, limit, stored))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding events")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminStatsVolumes implements /admin/stats/volumes endpoint. `from` and `to` are UTC dates
+// (YYYY-MM-DD, inclusive) and default to the last 30 days. `asset` can be an asset code or
+// `code:issuer`. Internal transfers are reported with `include_internal=true`.
+func (rh *RequestHandler) AdminStatsVolumes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	to := query.Get("to")
+	if to == "" {
+		to = stats.Date(time.Now())
+	}
+	toDate, err := time.Parse(stats.DateFormat, to)
+	if err != nil {
+		server.Write(w, protocols.NewInvalidParameterError("to", to, "to must be a date (YYYY-MM-DD)."))
+		return
+	}
+
+	from := query.Get("from")
+	if from == "" {
+		from = stats.Date(toDate.AddDate(0, 0, -29))
+	}
+	fromDate, err := time.Parse(stats.DateFormat, from)
+	if err != nil {
+		server.Write(w, protocols.NewInvalidParameterError("from", from, "from must be a date (YYYY-MM-DD)."))
+		return
+	}
+
+	if fromDate.After(toDate) {
+		server.Write(w, protocols.NewInvalidParameterError("from", from, "from must not be after to."))
+		return
+	}
+
+	var assetCode, assetIssuer string
+	if asset := query.Get("asset"); asset != "" {
+		parts := strings.SplitN(asset, ":", 2)
+		assetCode = parts[0]
+		if len(parts) == 2 {
+			assetIssuer = parts[1]
+		}
+	}
+
+	volumes, err := rh.Repository.GetDailyVolumes(from, to, assetCode, assetIssuer)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error loading DailyVolumes")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	if query.Get("include_internal") != "true" {
+		external := volumes[:0]
+		for _, volume := range volumes {
+			if volume.Direction != entities.DailyVolumeDirectionInternal {
+				external = append(external, volume)
+			}
+		}
+		volumes = external
+	}
+
+	report := stats.NewVolumeReport(from, to, volumes)
+
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(report)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding VolumeReport")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminLogSampling implements /admin/log-sampling endpoint. GET returns current sample rates. POST
+// changes rates of categories sent as params (ex. `horizon=0.1`) and restores full logging for
+// `burst_minutes` minutes when it's set. When operator_api_key is set only operator can change rates.
+func (rh *RequestHandler) AdminLogSampling(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if rh.Config.OperatorAPIKey != "" && server.RequestRole(r) != server.RoleOperator {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		// Validate all params before changing anything
+		rates := make(map[string]float64)
+		for _, category := range logging.Categories {
+			value := r.PostFormValue(category)
+			if value == "" {
+				continue
+			}
+
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate < 0 || rate > 1 {
+				server.Write(w, protocols.NewInvalidParameterError(category, value, "Sample rate must be a number between 0 and 1."))
+				return
+			}
+			rates[category] = rate
+		}
+
+		burst := r.PostFormValue("burst_minutes")
+		minutes, err := strconv.ParseUint(burst, 10, 32)
+		if burst != "" && err != nil {
+			server.Write(w, protocols.NewInvalidParameterError("burst_minutes", burst, "burst_minutes must be a number of minutes."))
+			return
+		}
+
+		for category, rate := range rates {
+			rh.LogSampler.SetRate(category, rate)
+		}
+		if burst != "" {
+			rh.LogSampler.Burst(time.Duration(minutes) * time.Minute)
+		}
+
+		log.WithFields(log.Fields{"status": rh.LogSampler.Status()}).Warn("Log sampling changed")
+	}
+
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(rh.LogSampler.Status())
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding log sampling status")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminCircuitBreakers implements /admin/circuit-breakers endpoint. GET returns states of Horizon and
+// federation breakers. POST closes a breaker given in `name` param or all breakers when it's empty.
+func (rh *RequestHandler) AdminCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if rh.Config.OperatorAPIKey != "" && server.RequestRole(r) != server.RoleOperator {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		name := r.PostFormValue("name")
+		if !rh.Breakers.Reset(name) {
+			server.Write(w, protocols.NewInvalidParameterError("name", name, "Circuit breaker not found."))
+			return
+		}
+
+		log.WithFields(log.Fields{"name": name}).Warn("Circuit breakers reset")
+	}
+
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(map[string]interface{}{
+		"enabled":  rh.Config.CircuitBreakers.FailureRate != 0,
+		"breakers": rh.Breakers.Status(),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding circuit breakers status")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminHorizonFailures implements /admin/debug/horizon_failures endpoint returning the last failed
+// Horizon exchanges, newest first. `endpoint` param returns failures of a single endpoint, `id`
+// param returns a single failure.
+func (rh *RequestHandler) AdminHorizonFailures(w http.ResponseWriter, r *http.Request) {
+	var failures []horizon.Failure
+	if id := r.URL.Query().Get("id"); id != "" {
+		failure, ok := rh.HorizonFailures.Get(id)
+		if !ok {
+			http.Error(w, "Failure not found", http.StatusNotFound)
+			return
+		}
+		failures = []horizon.Failure{failure}
+	} else {
+		failures = rh.HorizonFailures.List(r.URL.Query().Get("endpoint"))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(map[string]interface{}{"failures": failures})
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding Horizon failures")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminRetryPolicies implements /admin/retry-policies endpoint returning attempts histograms and
+// exhaustion counters of retry policies
+func (rh *RequestHandler) AdminRetryPolicies(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(map[string]interface{}{"policies": rh.Retries.Stats()})
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding retry policies")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminSubmissionRate implements /admin/submission-rate endpoint returning the state of the
+// submission rate limiter with numbers of waiting and delayed submissions by priority
+func (rh *RequestHandler) AdminSubmissionRate(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(rh.SubmissionRate.Status())
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding submission rate")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// resolverDomainsLimit is a default number of domains in lists of /admin/resolver/domains
+const resolverDomainsLimit = 10
+
+// AdminResolverDomains implements /admin/resolver/domains endpoint returning federation domains
+// with the slowest lookups (by average latency) and the highest error rates in the last hour,
+// `limit` domains in each list. `totals` contains metrics of every domain since start.
+func (rh *RequestHandler) AdminResolverDomains(w http.ResponseWriter, r *http.Request) {
+	limit := resolverDomainsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			server.Write(w, protocols.NewInvalidParameterError("limit", value, "Limit must be a positive integer."))
+			return
+		}
+	}
+
+	window := rh.FederationMetrics.Window()
+	slowest := append([]external.FederationDomainStats{}, window...)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].AverageLatency > slowest[j].AverageLatency })
+
+	mostErrors := []external.FederationDomainStats{}
+	for _, stats := range window {
+		if stats.ErrorRate > 0 {
+			mostErrors = append(mostErrors, stats)
+		}
+	}
+	sort.SliceStable(mostErrors, func(i, j int) bool {
+		if mostErrors[i].ErrorRate == mostErrors[j].ErrorRate {
+			return mostErrors[i].Lookups > mostErrors[j].Lookups
+		}
+		return mostErrors[i].ErrorRate > mostErrors[j].ErrorRate
+	})
+
+	if len(slowest) > limit {
+		slowest = slowest[:limit]
+	}
+	if len(mostErrors) > limit {
+		mostErrors = mostErrors[:limit]
+	}
+
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(map[string]interface{}{
+		"window_seconds": int(external.FederationMetricsWindow.Seconds()),
+		"max_domains":    rh.FederationMetrics.MaxDomains(),
+		"slowest":        slowest,
+		"most_errors":    mostErrors,
+		"totals":         rh.FederationMetrics.Totals(),
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding resolver domains")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminInflight implements /admin/inflight endpoint returning payments being processed with
+// their current stage and time spent in every stage
+func (rh *RequestHandler) AdminInflight(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(rh.Inflight.Snapshot())
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding inflight payments")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminReloadCounterparties implements /admin/counterparties/reload endpoint. It reads files of
+// counterparty lists again and returns versions of the running lists, the lists are kept when a
+// file is invalid. When operator_api_key is set only operator can reload lists.
+func (rh *RequestHandler) AdminReloadCounterparties(w http.ResponseWriter, r *http.Request) {
+	if rh.Config.OperatorAPIKey != "" && server.RequestRole(r) != server.RoleOperator {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	status, err := rh.Counterparties.Reload()
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error reloading counterparty lists")
+		http.Error(w, "Invalid counterparty list: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.WithFields(log.Fields{"status": status}).Warn("Counterparty lists reloaded")
+
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(status)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding counterparty lists")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminReregisterAccount implements /admin/accounts/{id}/reregister endpoint. It resumes the
+// listener of a merged receiving account after the account was created again.
+func (rh *RequestHandler) AdminReregisterAccount(c web.C, w http.ResponseWriter, r *http.Request) {
+	if rh.Config.OperatorAPIKey != "" && server.RequestRole(r) != server.RoleOperator {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	accountID := c.URLParams["id"]
+	if !protocols.IsValidAccountID(accountID) {
+		server.Write(w, protocols.NewInvalidParameterError("id", accountID, "Account ID must start with `G`."))
+		return
+	}
+
+	retired, err := rh.PaymentListener.Reregister(accountID)
+	switch {
+	case err == listener.ErrListenerStopped || err == listener.ErrAccountNotRetired:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		log.WithFields(log.Fields{"err": err, "account_id": accountID}).Error("Error reregistering account")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	encoder := json.NewEncoder(w)
+	err = encoder.Encode(retired)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding retired account")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminBackfill implements /admin/backfill endpoint. POST starts a backfill of historical payments
+// in the background, GET returns progress of the running (or last) backfill.
+func (rh *RequestHandler) AdminBackfill(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if rh.Config.OperatorAPIKey != "" && server.RequestRole(r) != server.RoleOperator {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		accountID := r.PostFormValue("account_id")
+		if accountID == "" {
+			accountID = rh.Config.Accounts.ReceivingAccountID
+		}
+		if !protocols.IsValidAccountID(accountID) {
+			server.Write(w, protocols.NewInvalidParameterError("account_id", accountID, "Account ID must start with `G`."))
+			return
+		}
+
+		value := r.PostFormValue("from_ledger")
+		fromLedger, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			server.Write(w, protocols.NewInvalidParameterError("from_ledger", value, "from_ledger must be a ledger sequence."))
+			return
+		}
+
+		_, err = rh.Backfills.Start(accountID, uint32(fromLedger))
+		switch err {
+		case nil:
+			log.WithFields(log.Fields{"account_id": accountID, "from_ledger": fromLedger}).Info("Backfill started")
+		case backfill.ErrRunning:
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	progress := rh.Backfills.Progress()
+	if progress == nil {
+		http.Error(w, "No backfill started", http.StatusNotFound)
+		return
+	}
+
 	encoder := json.NewEncoder(w)
-	err = encoder.Encode(transactions)
+	err := encoder.Encode(progress)
 	if err != nil {
-		log.WithFields(log.Fields{"err": err, "transactions": transactions}).Error("Error encoding SentTransactions")
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding backfill progress")
 		server.Write(w, protocols.InternalServerError)
 		return
 	}
 }
+
+// exportEnvelopesPageSize is a number of transactions loaded from the DB at once
+const exportEnvelopesPageSize = 100
+
+// AdminExportEnvelopes implements /admin/export/envelopes endpoint. It streams sent transactions with
+// `submitted_at` in [from, to) as JSON lines, `from` and `to` are RFC3339 timestamps. Use
+// `bridge verify-signatures` to check signatures of exported envelopes.
+func (rh *RequestHandler) AdminExportEnvelopes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	from := utc.Unix(0)
+	if value := query.Get("from"); value != "" {
+		var err error
+		from, err = utc.Parse(value)
+		if err != nil {
+			server.Write(w, protocols.NewInvalidParameterError("from", value, err.Error()))
+			return
+		}
+	}
+
+	to := utc.Now()
+	if value := query.Get("to"); value != "" {
+		var err error
+		to, err = utc.Parse(value)
+		if err != nil {
+			server.Write(w, protocols.NewInvalidParameterError("to", value, err.Error()))
+			return
+		}
+	}
+
+	if from.Unix() > to.Unix() {
+		server.Write(w, protocols.NewInvalidParameterError("from", query.Get("from"), "from must not be after to."))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	// Response status is sent with the first line so errors can only be logged and end the stream
+	var afterID int64
+	for {
+		transactions, err := rh.Repository.GetSentTransactionsSubmittedBetween(from.Time(), to.Time(), afterID, exportEnvelopesPageSize)
+		if err != nil {
+			log.WithFields(log.Fields{"err": err}).Error("Error loading SentTransactions")
+			if afterID == 0 {
+				server.Write(w, protocols.InternalServerError)
+			}
+			return
+		}
+
+		for _, transaction := range transactions {
+			err = encoder.Encode(signatures.NewExportedEnvelope(transaction))
+			if err != nil {
+				log.WithFields(log.Fields{"err": err}).Error("Error writing exported envelope")
+				return
+			}
+			afterID = *transaction.ID
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if len(transactions) < exportEnvelopesPageSize {
+			return
+		}
+	}
+}
+
+// reloadResponse is a response of /admin/reload
+type reloadResponse struct {
+	DryRun  bool            `json:"dry_run"`
+	Hash    string          `json:"hash"`
+	Changes []config.Change `json:"changes"`
+	// Applied contains keys of applied changes, other changes require restart
+	Applied []string `json:"applied"`
+}
+
+// AdminReload implements /admin/reload endpoint. It reads the config file again and returns its diff
+// against the running config. Unless `dry_run=true` is sent hot-applicable changes are applied. When
+// `confirm` is sent changes are applied only if it's equal to the diff hash, so the operator applies
+// exactly the reviewed diff. When operator_api_key is set only operator can reload config. In strict
+// security mode configs failing checks that are not waived are not applied.
+func (rh *RequestHandler) AdminReload(w http.ResponseWriter, r *http.Request) {
+	if rh.Config.OperatorAPIKey != "" && server.RequestRole(r) != server.RoleOperator {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if rh.ConfigFile == "" {
+		http.Error(w, "Config reload is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	loaded, err := config.Load(rh.ConfigFile)
+	if err != nil {
+		http.Error(w, "Invalid config: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes := config.Diff(*rh.Config, loaded)
+	response := reloadResponse{
+		DryRun:  r.PostFormValue("dry_run") == "true",
+		Hash:    config.DiffHash(changes),
+		Changes: changes,
+		Applied: []string{},
+	}
+
+	encoder := json.NewEncoder(w)
+
+	// Running configs passed the checks at start, a reload can't make them insecure
+	if rh.Security != nil && !response.DryRun {
+		_, err = rh.Security.Check(&loaded)
+		if err != nil {
+			log.WithFields(log.Fields{"err": err}).Error("Config reload refused by strict security mode")
+			http.Error(w, "Insecure config: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	confirm := r.PostFormValue("confirm")
+	if !response.DryRun && confirm != "" && confirm != response.Hash {
+		w.WriteHeader(http.StatusConflict)
+		encoder.Encode(response)
+		return
+	}
+
+	if !response.DryRun {
+		var errorMappingChanged bool
+		for _, change := range changes {
+			if !change.RestartRequired && strings.HasPrefix(change.Key, "error_mapping.") {
+				errorMappingChanged = true
+			}
+		}
+		// Validated before anything is applied, applied with the config below
+		if errorMappingChanged {
+			err = errormap.Validate(loaded.ErrorMapping.Codes)
+			if err != nil {
+				http.Error(w, "Invalid config: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Callback settings are applied first, they can still be rejected
+		var callbacksChanged bool
+		for _, change := range changes {
+			if !change.RestartRequired && strings.HasPrefix(change.Key, "callbacks.") {
+				callbacksChanged = true
+			}
+		}
+		if callbacksChanged {
+			// Callback URLs require a restart, the running ones must match the new allowlist
+			callbacks := rh.Config.Callbacks
+			callbacks.AllowedHosts = loaded.Callbacks.AllowedHosts
+			callbacks.TLS = loaded.Callbacks.TLS
+
+			settings := callbacks.WebhookSettings()
+			for name, callback := range settings.Destinations {
+				callbackURL, err := url.Parse(callback)
+				if callback != "" && (err != nil || !webhook.HostAllowed(settings.AllowedHosts, callbackURL)) {
+					http.Error(w, "Invalid config: running callbacks."+name+" host is not in callbacks.allowed_hosts", http.StatusBadRequest)
+					return
+				}
+			}
+
+			err = rh.Webhooks.Update(settings)
+			if err != nil {
+				http.Error(w, "Invalid config: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			rh.Config.Callbacks = callbacks
+		}
+
+		var warmAddressesChanged bool
+		for _, change := range changes {
+			if change.RestartRequired {
+				continue
+			}
+
+			if strings.HasPrefix(change.Key, "warm_start.federation_addresses[") {
+				warmAddressesChanged = true
+			}
+			if strings.HasPrefix(change.Key, "log_sampling.") {
+				category := strings.TrimPrefix(change.Key, "log_sampling.")
+				rate, ok := loaded.LogSampling[category]
+				if !ok {
+					// Categories not listed are logged fully
+					rate = 1
+				}
+				rh.LogSampler.SetRate(category, rate)
+			}
+			response.Applied = append(response.Applied, change.Key)
+		}
+		rh.Config.LogSampling = loaded.LogSampling
+
+		if warmAddressesChanged {
+			rh.Config.WarmStart.FederationAddresses = loaded.WarmStart.FederationAddresses
+			rh.Warmer.SetFederationAddresses(loaded.WarmStart.FederationAddresses)
+		}
+
+		if errorMappingChanged {
+			// Mappings have been validated above
+			rh.Config.ErrorMapping = loaded.ErrorMapping
+			rh.ErrorMapper.Update(loaded.ErrorMapping.Codes, loaded.ErrorMapping.Webhooks)
+		}
+
+		log.WithFields(log.Fields{"applied": response.Applied, "hash": response.Hash}).Warn("Config reloaded")
+	}
+
+	err = encoder.Encode(response)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding reload response")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// AdminReconciliation implements /admin/reconciliations/{date} endpoint returning the stored
+// reconciliation report of a UTC date (YYYY-MM-DD). `format=csv` returns report entries as CSV.
+func (rh *RequestHandler) AdminReconciliation(c web.C, w http.ResponseWriter, r *http.Request) {
+	date := c.URLParams["date"]
+	if _, err := time.Parse(stats.DateFormat, date); err != nil {
+		server.Write(w, protocols.NewInvalidParameterError("date", date, "date must be a date (YYYY-MM-DD)."))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		server.Write(w, protocols.NewInvalidParameterError("format", format, "format must be json or csv."))
+		return
+	}
+
+	stored, err := rh.Repository.GetReconciliationByDate(date)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error loading Reconciliation")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	if stored == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if format != "csv" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(stored.Report))
+		return
+	}
+
+	var report reconciliation.Report
+	err = json.Unmarshal([]byte(stored.Report), &report)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "date": date}).Error("Error decoding Reconciliation")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	err = report.WriteCSV(w)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "date": date}).Error("Error writing Reconciliation")
+	}
+}
@@ -1,17 +1,25 @@
 package handlers
 
 import (
+	"encoding/json"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 
+	"github.com/stellar/gateway/horizon"
 	"github.com/stellar/gateway/protocols"
 	"github.com/stellar/gateway/protocols/bridge"
 	"github.com/stellar/gateway/server"
 	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
 )
 
 // Authorize implements /authorize endpoint
 func (rh *RequestHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	rh, ok := rh.withCorrelationID(w, r)
+	if !ok {
+		return
+	}
+
 	request := &bridge.AuthorizeRequest{}
 	err := request.FromRequest(r)
 	if err != nil {
@@ -42,6 +50,10 @@ func (rh *RequestHandler) Authorize(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		log.WithFields(log.Fields{"err": err}).Error("Error submitting transaction")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
 		server.Write(w, protocols.InternalServerError)
 		return
 	}
@@ -55,3 +67,176 @@ func (rh *RequestHandler) Authorize(w http.ResponseWriter, r *http.Request) {
 
 	server.Write(w, &submitResponse)
 }
+
+// AuthorizeBatch implements /authorize/batch endpoint
+func (rh *RequestHandler) AuthorizeBatch(w http.ResponseWriter, r *http.Request) {
+	var request bridge.AuthorizeBatchRequest
+
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&request)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error decoding request")
+		server.Write(w, protocols.NewInvalidParameterError("", "", "Request body is not a valid JSON array"))
+		return
+	}
+
+	rh, ok := rh.withCorrelationID(w, r)
+	if !ok {
+		return
+	}
+
+	err = request.Validate(rh.Config.Assets, rh.Config.Accounts.IssuingAccountID)
+	if err != nil {
+		errorResponse := err.(*protocols.ErrorResponse)
+		log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, errorResponse)
+		return
+	}
+
+	authorizingKeypair, err := keypair.Parse(rh.Config.Accounts.AuthorizingSeed)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Invalid authorizing seed")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	response := &bridge.AuthorizeBatchResponse{
+		Results: make([]bridge.AuthorizeBatchEntryResult, len(request.Entries)),
+	}
+
+	// Entries of accounts without a trustline (or not existing) are skipped, entries of accounts
+	// that cannot be loaded fail, the rest is submitted
+	var pending []int
+	accounts := make(map[string]*horizon.AccountResponse)
+	accountErrors := make(map[string]*protocols.ErrorResponse)
+	for i, entry := range request.Entries {
+		response.Results[i].AuthorizeBatchEntry = entry
+
+		account, loaded := accounts[entry.Account]
+		if !loaded {
+			accountResponse, err := rh.Horizon.LoadAccount(entry.Account)
+			if errorResponse := dependencyError(err); errorResponse != nil {
+				// Entries cannot be checked, none is skipped
+				server.Write(w, errorResponse)
+				return
+			}
+			if statusErr, ok := err.(*horizon.StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+				log.WithFields(log.Fields{"account": entry.Account}).Warn("Trustor account does not exist")
+			} else if err != nil {
+				log.WithFields(log.Fields{"err": err, "account": entry.Account}).Error("Cannot load trustor account")
+				accountErrors[entry.Account] = protocols.NewDependencyUnavailableError("horizon")
+			} else {
+				account = &accountResponse
+			}
+			accounts[entry.Account] = account
+		}
+
+		if errorResponse := accountErrors[entry.Account]; errorResponse != nil {
+			response.Results[i].Status = bridge.AuthorizeBatchEntryStatusFailed
+			response.Results[i].Error = errorResponse
+			continue
+		}
+
+		if account == nil {
+			response.Results[i].Status = bridge.AuthorizeBatchEntryStatusSkipped
+			response.Results[i].Error = bridge.AllowTrustNoTrustline
+			continue
+		}
+
+		if _, ok := account.GetBalance(entry.AssetCode, rh.Config.Accounts.IssuingAccountID); !ok {
+			response.Results[i].Status = bridge.AuthorizeBatchEntryStatusSkipped
+			response.Results[i].Error = bridge.AllowTrustNoTrustline
+			continue
+		}
+
+		pending = append(pending, i)
+	}
+
+	for start := 0; start < len(pending); start += bridge.AuthorizeBatchMaxOperations {
+		end := start + bridge.AuthorizeBatchMaxOperations
+		if end > len(pending) {
+			end = len(pending)
+		}
+		rh.submitAuthorizeBatch(request.Entries, pending[start:end], authorizingKeypair.Address(), response.Results)
+	}
+
+	server.Write(w, response)
+}
+
+// submitAuthorizeBatch submits a single transaction with allow_trust operations for
+// entries at given indexes and writes their statuses to results.
+func (rh *RequestHandler) submitAuthorizeBatch(entries []bridge.AuthorizeBatchEntry, indexes []int, authorizingAccountID string, results []bridge.AuthorizeBatchEntryResult) {
+	fail := func(errorResponse *protocols.ErrorResponse) {
+		for _, i := range indexes {
+			results[i].Status = bridge.AuthorizeBatchEntryStatusFailed
+			results[i].Error = errorResponse
+		}
+	}
+
+	mutators := []b.TransactionMutator{
+		b.SourceAccount{rh.Config.Accounts.AuthorizingSeed},
+		b.Network{rh.Config.NetworkPassphrase},
+	}
+
+	for _, i := range indexes {
+		operationMutators := []interface{}{
+			b.Trustor{entries[i].Account},
+			b.Authorize{entries[i].ShouldAuthorize()},
+			b.AllowTrustAsset{entries[i].AssetCode},
+		}
+		// Authorizing account is a signer of the issuing account
+		if authorizingAccountID != rh.Config.Accounts.IssuingAccountID {
+			operationMutators = append(operationMutators, b.SourceAccount{rh.Config.Accounts.IssuingAccountID})
+		}
+		mutators = append(mutators, b.AllowTrust(operationMutators...))
+	}
+
+	tx := b.Transaction(mutators...)
+	if tx.Err != nil {
+		log.WithFields(log.Fields{"err": tx.Err}).Error("TransactionBuilder returned error")
+		fail(protocols.InternalServerError)
+		return
+	}
+
+	submitResponse, err := rh.TransactionSubmitter.SignAndSubmitRawTransaction(rh.Config.Accounts.AuthorizingSeed, tx.TX)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error submitting transaction")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			fail(errorResponse)
+			return
+		}
+		fail(protocols.InternalServerError)
+		return
+	}
+
+	for _, i := range indexes {
+		results[i].Hash = submitResponse.Hash
+		results[i].Ledger = submitResponse.Ledger
+	}
+
+	errorResponse := bridge.ErrorFromHorizonResponse(submitResponse)
+	if errorResponse == nil {
+		for _, i := range indexes {
+			results[i].Status = bridge.AuthorizeBatchEntryStatusSuccess
+		}
+		return
+	}
+
+	log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+
+	operationErrors := bridge.OperationErrorsFromHorizonResponse(submitResponse)
+	if len(operationErrors) != len(indexes) {
+		fail(errorResponse)
+		return
+	}
+
+	// Transactions are atomic so operations that succeeded have been rolled back
+	for j, i := range indexes {
+		results[i].Status = bridge.AuthorizeBatchEntryStatusFailed
+		if operationErrors[j] != nil {
+			results[i].Error = operationErrors[j]
+		} else {
+			results[i].Error = bridge.AllowTrustBatchRolledBack
+		}
+	}
+}
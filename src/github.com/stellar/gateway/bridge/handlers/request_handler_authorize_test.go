@@ -14,9 +14,13 @@ import (
 	"github.com/stellar/gateway/horizon"
 	"github.com/stellar/gateway/mocks"
 	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/protocols/bridge"
 	"github.com/stellar/gateway/test"
 	b "github.com/stellar/go/build"
+	"github.com/stellar/go/xdr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRequestHandlerAuthorize(t *testing.T) {
@@ -138,3 +142,169 @@ func TestRequestHandlerAuthorize(t *testing.T) {
 		})
 	})
 }
+
+func TestRequestHandlerAuthorizeBatch(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	mockTransactionSubmitter := new(mocks.MockTransactionSubmitter)
+
+	config := config.Config{
+		Assets: []config.Asset{
+			{Code: "USD", Issuer: "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"},
+		},
+		Accounts: config.Accounts{
+			IssuingAccountID: "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR",
+			// GBQXA3ABGQGTCLEVZIUTDRWWJOQD5LSAEDZAG7GMOGD2HBLWONGUVO4I
+			AuthorizingSeed: "SC37TBSIAYKIDQ6GTGLT2HSORLIHZQHBXVFI5P5K4Q5TSHRTRBK3UNWG",
+		},
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+	}
+
+	requestHandler := RequestHandler{Config: &config, Horizon: mockHorizon, TransactionSubmitter: mockTransactionSubmitter}
+	testServer := httptest.NewServer(http.HandlerFunc(requestHandler.AuthorizeBatch))
+	defer testServer.Close()
+
+	trusting := "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"
+	notTrusting := "GCQ6EXKRIJ3IADY5GKCT363DAPRDS46WPDMEJ4HKZSB7FLLYBHOWBBPQ"
+	notExisting := "GAR4C2JGKK4UGGS2XHMGYLS73GYI6C4EFNT5HRZKCD7PHL3HC7CCRVQQ"
+
+	Convey("Given authorize batch request", t, func() {
+		Convey("When body is not a JSON array", func() {
+			Convey("it should return error", func() {
+				statusCode, response := net.JSONGetResponse(testServer, map[string]interface{}{"account": trusting})
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "invalid_parameter",
+				  "message": "Invalid parameter."
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+			})
+		})
+
+		Convey("When asset code of one of the entries is invalid", func() {
+			Convey("it should return error", func() {
+				statusCode, response := net.JSONGetResponse(testServer, []map[string]interface{}{
+					{"account": trusting, "asset_code": "USD"},
+					{"account": trusting, "asset_code": "GBP"},
+				})
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "invalid_parameter",
+				  "message": "Invalid parameter.",
+				  "data": {
+				    "name": "[1].asset_code"
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+			})
+		})
+
+		Convey("When entries are valid", func() {
+			usdBalance := horizon.Balance{Balance: "0", AssetType: "credit_alphanum4", AssetCode: "USD", AssetIssuer: config.Accounts.IssuingAccountID}
+			mockHorizon.On("LoadAccount", trusting).Return(
+				horizon.AccountResponse{AccountID: trusting, Balances: []horizon.Balance{usdBalance}},
+				nil,
+			).Once()
+			mockHorizon.On("LoadAccount", notTrusting).Return(
+				horizon.AccountResponse{AccountID: notTrusting, Balances: []horizon.Balance{{Balance: "10", AssetType: "native"}}},
+				nil,
+			).Once()
+			mockHorizon.On("LoadAccount", notExisting).Return(
+				horizon.AccountResponse{},
+				&horizon.StatusError{StatusCode: http.StatusNotFound},
+			).Once()
+
+			entries := []map[string]interface{}{
+				{"account": trusting, "asset_code": "USD"},
+				{"account": notTrusting, "asset_code": "USD"},
+				{"account": notExisting, "asset_code": "USD"},
+				{"account": trusting, "asset_code": "USD", "authorize": false},
+			}
+
+			twoOperations := mock.MatchedBy(func(tx *xdr.Transaction) bool {
+				return len(tx.Operations) == 2 &&
+					tx.Operations[0].Body.AllowTrustOp.Authorize &&
+					!tx.Operations[1].Body.AllowTrustOp.Authorize &&
+					tx.Operations[0].SourceAccount.Address() == config.Accounts.IssuingAccountID
+			})
+
+			Convey("transaction succeeds", func() {
+				var ledger uint64
+				ledger = 100
+				mockTransactionSubmitter.On(
+					"SignAndSubmitRawTransaction",
+					config.Accounts.AuthorizingSeed,
+					twoOperations,
+				).Return(horizon.SubmitTransactionResponse{Hash: "abc", Ledger: &ledger}, nil).Once()
+
+				Convey("it should skip entries without trustline", func() {
+					statusCode, response := net.JSONGetResponse(testServer, entries)
+					assert.Equal(t, 200, statusCode)
+
+					var actual bridge.AuthorizeBatchResponse
+					json.Unmarshal(response, &actual)
+					assert.Len(t, actual.Results, 4)
+					assert.Equal(t, bridge.AuthorizeBatchEntryStatusSuccess, actual.Results[0].Status)
+					assert.Equal(t, "abc", actual.Results[0].Hash)
+					assert.Equal(t, bridge.AuthorizeBatchEntryStatusSkipped, actual.Results[1].Status)
+					assert.Equal(t, "allow_trust_no_trustline", actual.Results[1].Error.Code)
+					assert.Equal(t, bridge.AuthorizeBatchEntryStatusSkipped, actual.Results[2].Status)
+					assert.Equal(t, bridge.AuthorizeBatchEntryStatusSuccess, actual.Results[3].Status)
+					mockHorizon.AssertExpectations(t)
+					mockTransactionSubmitter.AssertExpectations(t)
+				})
+			})
+
+			Convey("trustor account cannot be loaded", func() {
+				unavailable := "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"
+				mockHorizon.On("LoadAccount", unavailable).Return(
+					horizon.AccountResponse{},
+					&horizon.StatusError{StatusCode: http.StatusInternalServerError},
+				).Once()
+				mockTransactionSubmitter.On(
+					"SignAndSubmitRawTransaction",
+					config.Accounts.AuthorizingSeed,
+					twoOperations,
+				).Return(horizon.SubmitTransactionResponse{Hash: "abc"}, nil).Once()
+
+				Convey("it should fail its entries instead of skipping them", func() {
+					statusCode, response := net.JSONGetResponse(testServer, append(entries, map[string]interface{}{"account": unavailable, "asset_code": "USD"}))
+					assert.Equal(t, 200, statusCode)
+
+					var actual bridge.AuthorizeBatchResponse
+					json.Unmarshal(response, &actual)
+					require.Len(t, actual.Results, 5)
+					assert.Equal(t, bridge.AuthorizeBatchEntryStatusSkipped, actual.Results[2].Status)
+					assert.Equal(t, bridge.AuthorizeBatchEntryStatusFailed, actual.Results[4].Status)
+					assert.Equal(t, "dependency_unavailable", actual.Results[4].Error.Code)
+					mockHorizon.AssertExpectations(t)
+				})
+			})
+
+			Convey("one of the operations fails", func() {
+				mockTransactionSubmitter.On(
+					"SignAndSubmitRawTransaction",
+					config.Accounts.AuthorizingSeed,
+					twoOperations,
+				).Return(horizon.SubmitTransactionResponse{
+					Extras: &horizon.SubmitTransactionResponseExtras{
+						// tx_failed: allow_trust_success, allow_trust_no_trust_line
+						ResultXdr: "AAAAAAAAAMj/////AAAAAgAAAAAAAAAHAAAAAAAAAAAAAAAH/////gAAAAA=",
+					},
+				}, nil).Once()
+
+				Convey("it should attribute errors to entries", func() {
+					statusCode, response := net.JSONGetResponse(testServer, entries)
+					assert.Equal(t, 200, statusCode)
+
+					var actual bridge.AuthorizeBatchResponse
+					json.Unmarshal(response, &actual)
+					assert.Equal(t, bridge.AuthorizeBatchEntryStatusFailed, actual.Results[0].Status)
+					assert.Equal(t, "allow_trust_batch_rolled_back", actual.Results[0].Error.Code)
+					assert.Equal(t, bridge.AuthorizeBatchEntryStatusFailed, actual.Results[3].Status)
+					assert.Equal(t, "allow_trust_no_trustline", actual.Results[3].Error.Code)
+					mockTransactionSubmitter.AssertExpectations(t)
+				})
+			})
+		})
+	})
+}
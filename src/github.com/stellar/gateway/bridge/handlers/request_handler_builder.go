@@ -10,6 +10,7 @@ import (
 	"github.com/stellar/gateway/protocols"
 	"github.com/stellar/gateway/protocols/bridge"
 	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/submitter"
 	b "github.com/stellar/go/build"
 )
 
@@ -26,6 +27,11 @@ func (rh *RequestHandler) Builder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rh, ok := rh.withCorrelationID(w, r)
+	if !ok {
+		return
+	}
+
 	err = request.Process()
 	if err != nil {
 		errorResponse := err.(*protocols.ErrorResponse)
@@ -46,6 +52,10 @@ func (rh *RequestHandler) Builder(w http.ResponseWriter, r *http.Request) {
 		accountResponse, err := rh.Horizon.LoadAccount(request.Source)
 		if err != nil {
 			log.WithFields(log.Fields{"err": err}).Error("Error when loading account")
+			if errorResponse := dependencyError(err); errorResponse != nil {
+				server.Write(w, errorResponse)
+				return
+			}
 			server.Write(w, protocols.InternalServerError)
 			return
 		}
@@ -83,10 +93,9 @@ func (rh *RequestHandler) Builder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txe := tx.Sign(request.Signers...)
-	txeB64, err := txe.Base64()
+	txeB64, err := submitter.SignEnvelope(tx.TX, rh.Config.NetworkPassphrase, request.Signers...)
 	if err != nil {
-		log.WithFields(log.Fields{"err": err, "request": request}).Error("Error encoding transaction envelope")
+		log.WithFields(log.Fields{"err": err, "request": request}).Error("Error signing transaction envelope")
 		server.Write(w, protocols.InternalServerError)
 		return
 	}
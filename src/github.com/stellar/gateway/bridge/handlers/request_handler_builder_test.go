@@ -100,6 +100,25 @@ func TestRequestHandlerBuilder(t *testing.T) {
 }`)
 				assert.Equal(t, expected, test.StringToJSONMap(responseString))
 			})
+
+			Convey("it should return the same XDR for starting_balance_stroops", func() {
+				body := data["operations"].([]interface{})[0].(map[string]interface{})["body"].(map[string]interface{})
+				delete(body, "starting_balance")
+				body["starting_balance_stroops"] = "500000000"
+
+				statusCode, response := net.JSONGetResponse(testServer, data)
+				assert.Equal(t, 200, statusCode)
+				assert.Equal(t, "AAAAAGySS3ZylffFaVZqZD6lNCUjCizHz7MLPwkN7Mxh4XN5AAAAZAAAAAAAAAB7AAAAAAAAAAAAAAABAAAAAAAAAAAAAAAAnEM7m3lksnFftHMGxdt6HTitUQSfvVvjk8JfduWfK+cAAAAAHc1lAAAAAAAAAAABn420/AAAAECXY+neSolhAeHUXf+UrOV6PjeJnvLM/HqjOlOEWD3hmu/z9aBksDu9zqa26jS14eMpZzq8sofnnvt248FUO+cP", test.StringToJSONMap(string(response))["transaction_envelope"])
+			})
+
+			Convey("it should return error when starting_balance is also sent", func() {
+				body := data["operations"].([]interface{})[0].(map[string]interface{})["body"].(map[string]interface{})
+				body["starting_balance_stroops"] = "500000000"
+
+				statusCode, response := net.JSONGetResponse(testServer, data)
+				assert.Equal(t, 400, statusCode)
+				assert.Equal(t, "starting_balance_stroops", test.StringToJSONMap(string(response))["data"].(map[string]interface{})["name"])
+			})
 		})
 
 		Convey("Payment", func() {
@@ -175,6 +194,18 @@ func TestRequestHandlerBuilder(t *testing.T) {
 }`)
 				assert.Equal(t, expected, test.StringToJSONMap(responseString))
 			})
+
+			Convey("it should return error when the path is longer than 5 assets", func() {
+				body := data["operations"].([]interface{})[0].(map[string]interface{})["body"].(map[string]interface{})
+				body["path"] = []interface{}{
+					map[string]interface{}{}, map[string]interface{}{}, map[string]interface{}{},
+					map[string]interface{}{}, map[string]interface{}{}, map[string]interface{}{},
+				}
+
+				statusCode, response := net.JSONGetResponse(testServer, data)
+				assert.Equal(t, 400, statusCode)
+				assert.Equal(t, "payment_invalid_path", test.StringToJSONMap(string(response))["code"])
+			})
 		})
 
 		Convey("ManageOffer", func() {
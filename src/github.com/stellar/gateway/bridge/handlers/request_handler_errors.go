@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/errorcodes"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/server"
+)
+
+// Errors implements /errors endpoint listing registered error codes with their HTTP statuses,
+// retriability and descriptions. Codes are bridge codes, before `error_mapping` is applied.
+func (rh *RequestHandler) Errors(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(map[string]interface{}{"errors": errorcodes.Entries()})
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding error codes")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
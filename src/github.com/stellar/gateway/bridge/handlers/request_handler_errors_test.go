@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stellar/gateway/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerErrors(t *testing.T) {
+	response := httptest.NewRecorder()
+	requestHandler := RequestHandler{}
+	requestHandler.Errors(response, httptest.NewRequest(http.MethodGet, "/errors", nil))
+	require.Equal(t, http.StatusOK, response.Code)
+
+	entries := test.StringToJSONMap(response.Body.String())["errors"].([]interface{})
+	found := false
+	for _, entry := range entries {
+		if entry.(map[string]interface{})["code"] == "channels_exhausted" {
+			found = true
+			assert.Equal(t, map[string]interface{}{
+				"code":        "channels_exhausted",
+				"status":      float64(http.StatusServiceUnavailable),
+				"retriable":   true,
+				"description": "All channel accounts are in use, please try again later.",
+			}, entry)
+		}
+	}
+	assert.True(t, found)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/test"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerAdminEvents(t *testing.T) {
+	var mockRepository *mocks.MockRepository
+	get := func(url string) (int, map[string]interface{}) {
+		response := httptest.NewRecorder()
+		requestHandler := RequestHandler{Repository: mockRepository}
+		requestHandler.AdminEvents(response, httptest.NewRequest(http.MethodGet, url, nil))
+		return response.Code, test.StringToJSONMap(response.Body.String())
+	}
+	id := func(id int64) *int64 { return &id }
+	createdAt := utc.New(time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	t.Run("events after a sequence number", func(t *testing.T) {
+		mockRepository = new(mocks.MockRepository)
+		mockRepository.On("GetEventsAfter", int64(40), 2).Return([]*entities.Event{
+			{ID: id(41), Type: "payment_received", Subject: "12884905985", Version: 1, Payload: `{"amount":"20"}`, CreatedAt: createdAt},
+			{ID: id(42), Type: "payment_processed", Subject: "12884905985", Version: 1, Payload: `{"status":"Success"}`, CreatedAt: createdAt},
+		}, nil).Once()
+
+		status, response := get("/admin/events?after=40&limit=2")
+		require.Equal(t, http.StatusOK, status, response)
+		assert.Equal(t, map[string]interface{}{"next": "/admin/events?after=42&limit=2"}, response["links"])
+		records := response["records"].([]interface{})
+		require.Len(t, records, 2)
+		assert.Equal(t, map[string]interface{}{
+			"sequence":   float64(41),
+			"type":       "payment_received",
+			"subject":    "12884905985",
+			"version":    float64(1),
+			"payload":    map[string]interface{}{"amount": "20"},
+			"created_at": "2017-03-01T12:00:00Z",
+		}, records[0])
+		mockRepository.AssertExpectations(t)
+	})
+
+	t.Run("no new events", func(t *testing.T) {
+		mockRepository = new(mocks.MockRepository)
+		mockRepository.On("GetEventsAfter", int64(0), 10).Return([]*entities.Event{}, nil).Once()
+
+		status, response := get("/admin/events")
+		require.Equal(t, http.StatusOK, status, response)
+		assert.Equal(t, []interface{}{}, response["records"])
+		assert.Equal(t, map[string]interface{}{"next": "/admin/events?after=0&limit=10"}, response["links"])
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		mockRepository = new(mocks.MockRepository)
+		for _, url := range []string{"/admin/events?after=-1", "/admin/events?after=last", "/admin/events?limit=0", "/admin/events?limit=201"} {
+			status, response := get(url)
+			assert.Equal(t, http.StatusBadRequest, status, url)
+			assert.Equal(t, "invalid_parameter", response["code"], url)
+		}
+		mockRepository.AssertNotCalled(t, "GetEventsAfter")
+	})
+
+	t.Run("DB error", func(t *testing.T) {
+		mockRepository = new(mocks.MockRepository)
+		mockRepository.On("GetEventsAfter", int64(0), 10).Return([]*entities.Event{}, errors.New("connection refused")).Once()
+
+		status, response := get("/admin/events")
+		assert.Equal(t, http.StatusInternalServerError, status)
+		assert.Equal(t, "internal_server_error", response["code"])
+	})
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerAdminInflight(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	requestHandler := RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Accounts: config.Accounts{
+				BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK",
+			},
+		},
+		Horizon:  mockHorizon,
+		Inflight: inflight.NewRegistry(4, time.Now),
+	}
+
+	submitting := make(chan struct{})
+	release := make(chan struct{})
+	ledger := uint64(1988727)
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(mock.Arguments) {
+		close(submitting)
+		<-release
+	}).Return(horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil).Once()
+
+	snapshot := func() inflight.Snapshot {
+		response := httptest.NewRecorder()
+		requestHandler.AdminInflight(response, httptest.NewRequest(http.MethodGet, "/admin/inflight", nil))
+		require.Equal(t, http.StatusOK, response.Code)
+		var snapshot inflight.Snapshot
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &snapshot))
+		return snapshot
+	}
+
+	params := url.Values{
+		"destination": {"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+		"amount":      {"20"},
+	}
+	request := httptest.NewRequest(http.MethodPost, "/payment", strings.NewReader(params.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set(server.RequestIDHeader, "req-42")
+	response := httptest.NewRecorder()
+	handler := server.RequestIDMiddleware()(http.HandlerFunc(requestHandler.Payment))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(response, request)
+	}()
+
+	select {
+	case <-submitting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Payment has not been submitted")
+	}
+
+	inflightPayments := snapshot()
+	require.Len(t, inflightPayments.Payments, 1)
+	payment := inflightPayments.Payments[0]
+	assert.Equal(t, "req-42", payment.RequestID)
+	assert.Equal(t, inflight.KindSync, payment.Kind)
+	assert.Equal(t, "base_seed", payment.Source)
+	assert.Equal(t, inflight.StageAwaitingConfirmation, payment.Stage)
+	stages := make([]string, len(payment.Stages))
+	for i, stage := range payment.Stages {
+		stages[i] = stage.Stage
+	}
+	assert.Equal(t, []string{
+		inflight.StageResolving,
+		inflight.StageLoadingAccount,
+		inflight.StageSubmitting,
+		inflight.StageAwaitingConfirmation,
+	}, stages)
+
+	close(release)
+	<-done
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, snapshot().Payments)
+}
@@ -4,21 +4,32 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/handoff"
 	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/market"
 	"github.com/stellar/gateway/protocols"
 	"github.com/stellar/gateway/protocols/bridge"
 	callback "github.com/stellar/gateway/protocols/compliance"
 	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/gateway/utc"
 	"github.com/stellar/go/address"
 	"github.com/stellar/go/amount"
-	b "github.com/stellar/go/build"
 	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/protocols/compliance"
 	"github.com/stellar/go/protocols/federation"
@@ -27,45 +38,210 @@ import (
 
 // Payment implements /payment endpoint
 func (rh *RequestHandler) Payment(w http.ResponseWriter, r *http.Request) {
+	rh, ok := rh.withCorrelationID(w, r)
+	if !ok {
+		return
+	}
+
+	logger := requestLog(r)
 	request := &bridge.PaymentRequest{}
 	err := request.FromRequest(r)
 	if err != nil {
-		log.Error(err.Error())
+		logger.Error(err.Error())
 		server.Write(w, protocols.InvalidParameterError)
 		return
 	}
 
+	var warnings []string
+	if request.URI != "" {
+		var errorResponse *protocols.ErrorResponse
+		warnings, errorResponse = rh.applyPayURI(request)
+		if errorResponse != nil {
+			logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+			server.Write(w, errorResponse)
+			return
+		}
+		if len(warnings) > 0 {
+			logger.WithFields(log.Fields{"warnings": warnings}).Warn("Request params conflict with uri")
+		}
+	}
+
+	rh.resolveDestinationAlias(request)
+
+	sourceName, errorResponse := rh.resolveSourceName(request)
+	if errorResponse != nil {
+		logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, errorResponse)
+		return
+	}
+	if sourceName != "" {
+		logger = logger.WithFields(log.Fields{"source": sourceName})
+	}
+
 	err = request.Validate()
 	if err != nil {
 		errorResponse := err.(*protocols.ErrorResponse)
-		log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, errorResponse)
+		return
+	}
+
+	if errorResponse := rh.resolveAssetIssuers(request, logger); errorResponse != nil {
+		logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
 		server.Write(w, errorResponse)
 		return
 	}
 
 	if request.Source == "" {
 		request.Source = rh.Config.Accounts.BaseSeed
+		if len(request.Signers) > 0 {
+			if err := request.ValidateSigners(); err != nil {
+				errorResponse := err.(*protocols.ErrorResponse)
+				logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+				server.Write(w, errorResponse)
+				return
+			}
+		}
 	}
 
-	sourceKeypair, _ := keypair.Parse(request.Source)
+	if sourceNetwork := rh.Config.SourceNetwork(request.Source); sourceNetwork != "" {
+		errorResponse := bridge.NewPaymentSourceOtherNetworkError(sourceNetwork, rh.Config.Network())
+		logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, errorResponse)
+		return
+	}
+
+	if errorResponse := rh.checkCounterparty(request.Destination, logger); errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	}
+
+	if request.Callback != "" {
+		if errorResponse := rh.callbackError(request); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+	}
+	if request.Async {
+		if errorResponse := rh.asyncError(request); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+	}
+
+	// Simulated payments are not stored, they are simulated like payments sent right away
+	if request.Queue && rh.EntityManager != nil {
+		rh.queuePayment(w, r, request, logger)
+		return
+	}
+
+	rh = rh.withInflight(r, request)
+	rh = rh.withCallback(request)
+
+	// Simulated payments are not stored, their response is written when they are finished
+	if request.Async && rh.EntityManager != nil {
+		rh.asyncPayment(w, r, request, warnings, logger)
+		return
+	}
+
+	var maxWait time.Duration
+	if request.MaxWait != "" {
+		// Validated by request.Validate
+		maxWait, _ = handoff.ParseWait(request.MaxWait)
+	}
+	if wait := handoff.Wait(maxWait, r.Header); wait > 0 && rh.Handoffs != nil {
+		rh.paymentWithHandoff(w, r, request, warnings, wait, logger)
+		return
+	}
+
+	// Handed off payments continue after the response, they are not cancelled with the request
+	rh = rh.withContext(r)
+	defer rh.inflightPayment.Done()
+	rh.processPayment(w, r, request, warnings, logger)
+}
+
+// processPayment sends a validated payment of r and writes the response, payments with `id`
+// param are sent once (see idempotentPayment)
+func (rh *RequestHandler) processPayment(
+	w http.ResponseWriter,
+	r *http.Request,
+	request *bridge.PaymentRequest,
+	warnings []string,
+	logger *log.Entry,
+) {
+	// Simulated payments are not stored
+	if request.ID != "" && rh.EntityManager != nil {
+		rh.idempotentPayment(w, r, request, warnings, logger)
+		return
+	}
+	rh.sendPayment(w, r, request, warnings, logger)
+}
+
+// sendPayment builds, signs and submits a payment transaction of a validated request, the
+// transaction of a public key source is returned unsigned
+func (rh *RequestHandler) sendPayment(
+	w http.ResponseWriter,
+	r *http.Request,
+	request *bridge.PaymentRequest,
+	warnings []string,
+	logger *log.Entry,
+) {
+	if request.Type == bridge.PaymentTypeMultiAsset {
+		rh.multiAssetPayment(w, request, logger)
+		return
+	}
+
+	if request.Type == bridge.PaymentTypeBatch {
+		rh.batchPayment(w, request, logger)
+		return
+	}
 
 	var submitResponse horizon.SubmitTransactionResponse
 	var submitError error
+	// Index of the payment operation in the transaction (change_trust can be prepended)
+	paymentOperationIndex := 0
+	// operation_type of errors of the payment operation
+	var operationType string
+
+	if request.AutoTrust && rh.Config.DisableAutoTrust {
+		server.Write(w, protocols.NewInvalidParameterError("auto_trust", "true", "Automatic trustline creation is disabled."))
+		return
+	}
 
 	// Will use compliance if compliance server is connected and:
 	// * User passed extra memo OR
 	// * User explicitly wants to use compliance protocol
-	if rh.Config.Compliance != "" &&
-		(request.ExtraMemo != "" || (request.ExtraMemo == "" && request.UseCompliance)) {
+	if rh.usesCompliance(request) {
 		// Compliance server part
+		callbackLog := logger.WithField(logging.CategoryField, logging.CategoryCallbacks)
+		if request.AutoTrust {
+			server.Write(w, protocols.NewInvalidParameterError("auto_trust", "true", "auto_trust cannot be used with compliance protocol."))
+			return
+		}
+
+		// Transactions of the compliance server have a hash memo of the attachment, the
+		// destination is resolved by the compliance server
+		errorResponse := rh.checkDeposit(request.Source, deposit{
+			Destination: request.Destination,
+			Amount:      request.Amount,
+			AssetCode:   request.AssetCode,
+			AssetIssuer: request.AssetIssuer,
+			Memo:        &txspec.Memo{Type: xdr.MemoTypeMemoHash},
+		}, logger)
+		if errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+
 		sendRequest := request.ToComplianceSendRequest()
 
+		rh.inflightPayment.SetStage(inflight.StageAwaitingApproval)
 		resp, err := rh.Client.PostForm(
 			rh.Config.Compliance+"/send",
 			sendRequest.ToValues(),
 		)
 		if err != nil {
-			log.WithFields(log.Fields{"err": err}).Error("Error sending request to compliance server")
+			callbackLog.WithFields(log.Fields{"err": err}).Error("Error sending request to compliance server")
 			server.Write(w, protocols.InternalServerError)
 			return
 		}
@@ -73,13 +249,13 @@ func (rh *RequestHandler) Payment(w http.ResponseWriter, r *http.Request) {
 		defer resp.Body.Close()
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			log.Error("Error reading compliance server response")
+			callbackLog.Error("Error reading compliance server response")
 			server.Write(w, protocols.InternalServerError)
 			return
 		}
 
 		if resp.StatusCode != 200 {
-			log.WithFields(log.Fields{
+			callbackLog.WithFields(log.Fields{
 				"status": resp.StatusCode,
 				"body":   string(body),
 			}).Error("Error response from compliance server")
@@ -90,21 +266,21 @@ func (rh *RequestHandler) Payment(w http.ResponseWriter, r *http.Request) {
 		var callbackSendResponse callback.SendResponse
 		err = json.Unmarshal(body, &callbackSendResponse)
 		if err != nil {
-			log.Error("Error unmarshalling from compliance server")
+			callbackLog.Error("Error unmarshalling from compliance server")
 			server.Write(w, protocols.InternalServerError)
 			return
 		}
 
 		if callbackSendResponse.AuthResponse.InfoStatus == compliance.AuthStatusPending ||
 			callbackSendResponse.AuthResponse.TxStatus == compliance.AuthStatusPending {
-			log.WithFields(log.Fields{"response": callbackSendResponse}).Info("Compliance response pending")
+			callbackLog.WithFields(log.Fields{"response": callbackSendResponse}).Info("Compliance response pending")
 			server.Write(w, bridge.NewPaymentPendingError(callbackSendResponse.AuthResponse.Pending))
 			return
 		}
 
 		if callbackSendResponse.AuthResponse.InfoStatus == compliance.AuthStatusDenied ||
 			callbackSendResponse.AuthResponse.TxStatus == compliance.AuthStatusDenied {
-			log.WithFields(log.Fields{"response": callbackSendResponse}).Info("Compliance response denied")
+			callbackLog.WithFields(log.Fields{"response": callbackSendResponse}).Info("Compliance response denied")
 			server.Write(w, bridge.PaymentDenied)
 			return
 		}
@@ -112,218 +288,281 @@ func (rh *RequestHandler) Payment(w http.ResponseWriter, r *http.Request) {
 		var tx xdr.Transaction
 		err = xdr.SafeUnmarshalBase64(callbackSendResponse.TransactionXdr, &tx)
 		if err != nil {
-			log.Error("Error unmarshalling transaction returned by compliance server")
+			callbackLog.Error("Error unmarshalling transaction returned by compliance server")
 			server.Write(w, protocols.InternalServerError)
 			return
 		}
 
+		if unsignedPayment(request) {
+			rh.writeUnsignedPayment(w, &tx, paymentOperationIndex, warnings, logger)
+			return
+		}
+
+		rh.inflightPayment.SetStage(inflight.StageSubmitting)
+		operationType = transactionOperationType(&tx, paymentOperationIndex)
 		submitResponse, submitError = rh.TransactionSubmitter.SignAndSubmitRawTransaction(request.Source, &tx)
 	} else {
 		// Payment without compliance server
-		destinationObject := &federation.NameResponse{}
-
-		_, _, err := address.Split(request.Destination)
-		if err != nil {
-			destinationObject.AccountID = request.Destination
-		} else {
-			destinationObject, err = rh.FederationResolver.LookupByAddress(request.Destination)
-			if err != nil {
-				log.WithFields(log.Fields{"destination": request.Destination, "err": err}).Print("Cannot resolve address")
-				server.Write(w, bridge.PaymentCannotResolveDestination)
-				return
-			}
+		rh.inflightPayment.SetStage(inflight.StageResolving)
+		destinationObject, errorResponse := rh.resolveDestination(request.Destination, logger)
+		if errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
 		}
 
-		if !protocols.IsValidAccountID(destinationObject.AccountID) {
-			log.WithFields(log.Fields{"AccountId": destinationObject.AccountID}).Print("Invalid AccountId in destination")
-			server.Write(w, protocols.NewInvalidParameterError("destination", request.Destination, "Destination public key must start with `G`."))
+		rh, errorResponse = rh.internalTransfer(r, destinationObject.AccountID, logger)
+		if errorResponse != nil {
+			server.Write(w, errorResponse)
 			return
 		}
 
-		var payWithMutator *b.PayWithPath
+		// The path is sent as path[n] params or `path` JSON param, both are validated by Validate
+		var path []protocols.Asset
+		if request.SendMax != "" {
+			path = request.Path
+		}
 
+		var foundPath *horizon.PathResponse
 		if request.SendMax != "" {
-			// Path payment
-			var sendAsset b.Asset
-			if request.SendAssetCode == "" && request.SendAssetIssuer == "" {
-				sendAsset = b.NativeAsset()
-			} else {
-				sendAsset = b.CreditAsset(request.SendAssetCode, request.SendAssetIssuer)
+			if request.SkipSlippageCheck && server.RequestRole(r) != server.RoleOperator {
+				server.Write(w, protocols.NewInvalidParameterError("skip_slippage_check", "true", "Only operator can skip slippage check."))
+				return
 			}
 
-			payWith := b.PayWith(sendAsset, request.SendMax)
-
-			for i := 0; ; i++ {
-				codeFieldName := fmt.Sprintf("path[%d][asset_code]", i)
-				issuerFieldName := fmt.Sprintf("path[%d][asset_issuer]", i)
-
-				// If the element does not exist in PostForm break the loop
-				if _, exists := r.PostForm[codeFieldName]; !exists {
-					break
+			if len(path) == 0 {
+				foundPath, errorResponse = rh.findPaymentPath(request, destinationObject.AccountID, logger)
+				if errorResponse != nil {
+					server.Write(w, errorResponse)
+					return
 				}
+				path = pathAssets(foundPath)
+			}
 
-				code := r.PostFormValue(codeFieldName)
-				issuer := r.PostFormValue(issuerFieldName)
-
-				if code == "" && issuer == "" {
-					payWith = payWith.Through(b.NativeAsset())
-				} else {
-					payWith = payWith.Through(b.CreditAsset(code, issuer))
+			if !request.SkipSlippageCheck {
+				errorResponse := rh.checkPathPaymentSlippage(request, path)
+				if errorResponse != nil {
+					logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+					server.Write(w, errorResponse)
+					return
 				}
 			}
-
-			payWithMutator = &payWith
 		}
 
-		var operationBuilder interface{}
-
-		if request.AssetCode != "" && request.AssetIssuer != "" {
-			mutators := []interface{}{
-				b.Destination{destinationObject.AccountID},
-				b.CreditAmount{request.AssetCode, request.AssetIssuer, request.Amount},
-			}
-
-			if payWithMutator != nil {
-				mutators = append(mutators, *payWithMutator)
-			}
-
-			operationBuilder = b.Payment(mutators...)
-		} else {
-			mutators := []interface{}{
-				b.Destination{destinationObject.AccountID},
-				b.NativeAmount{request.Amount},
-			}
-
-			if payWithMutator != nil {
-				mutators = append(mutators, *payWithMutator)
-			}
-
-			// Check if destination account exist
-			_, err = rh.Horizon.LoadAccount(destinationObject.AccountID)
-			if err != nil {
-				log.WithFields(log.Fields{"error": err}).Error("Error loading account")
-				operationBuilder = b.CreateAccount(mutators...)
-			} else {
-				operationBuilder = b.Payment(mutators...)
-			}
+		sweep := request.Amount == bridge.AmountAll
+		operation, err := rh.createPaymentOperation(request, destinationObject.AccountID, path)
+		operationType = paymentOperationType(request, operation)
+		if errorResponse, ok := err.(*protocols.ErrorResponse); ok {
+			logger.WithFields(log.Fields{"code": errorResponse.Code}).Print("Cannot create payment operation")
+			server.Write(w, withOperationType(errorResponse, operationType))
+			return
 		}
-
-		memoType := request.MemoType
-		memo := request.Memo
-
-		if destinationObject.MemoType != "" {
-			if request.MemoType != "" {
-				log.Print("Memo given in request but federation returned memo fields.")
-				server.Write(w, bridge.PaymentCannotUseMemo)
-				return
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err}).Error("Cannot check if destination exists")
+			errorResponse := dependencyError(err)
+			if errorResponse == nil {
+				errorResponse = protocols.InternalServerError
 			}
+			server.Write(w, withOperationType(errorResponse, operationType))
+			return
+		}
 
-			memoType = destinationObject.MemoType
-			memo = destinationObject.Memo.Value
+		memo, errorResponse := paymentMemo(request, destinationObject, logger)
+		if errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
 		}
+		warnings = rh.recreatedDestinationWarning(destinationObject.AccountID, warnings, logger)
 
-		var memoMutator interface{}
-		switch {
-		case memoType == "":
-			break
-		case memoType == "id":
-			id, err := strconv.ParseUint(memo, 10, 64)
-			if err != nil {
-				log.WithFields(log.Fields{"memo": memo}).Print("Cannot convert memo_id value to uint64")
-				server.Write(w, protocols.NewInvalidParameterError("memo", request.Memo, "Memo.id must be a number"))
+		var check *anomalyCheck
+		if !rh.skipsCounterpartyChecks() {
+			errorResponse = rh.checkDeposit(request.Source, deposit{
+				Destination: request.Destination,
+				AccountID:   destinationObject.AccountID,
+				Amount:      request.Amount,
+				AssetCode:   request.AssetCode,
+				AssetIssuer: request.AssetIssuer,
+				Memo:        memo,
+			}, logger)
+			if errorResponse != nil {
+				server.Write(w, errorResponse)
 				return
 			}
-			memoMutator = b.MemoID{id}
-		case memoType == "text":
-			memoMutator = &b.MemoText{memo}
-		case memoType == "hash":
-			memoBytes, err := hex.DecodeString(memo)
-			if err != nil || len(memoBytes) != 32 {
-				log.WithFields(log.Fields{"memo": memo}).Print("Cannot decode hash memo value")
-				server.Write(w, protocols.NewInvalidParameterError("memo", request.Memo, "Memo.hash must be 32 bytes and hex encoded."))
+
+			check, errorResponse = rh.checkAnomalies(r, request, destinationObject.AccountID, logger)
+			if errorResponse != nil {
+				server.Write(w, errorResponse)
 				return
 			}
-			var b32 [32]byte
-			copy(b32[:], memoBytes[0:32])
-			hash := xdr.Hash(b32)
-			memoMutator = &b.MemoHash{hash}
-		default:
-			log.Print("Not supported memo type: ", memoType)
-			server.Write(w, protocols.NewInvalidParameterError("memo", request.Memo, "Memo type not supported"))
-			return
 		}
 
-		accountResponse, err := rh.Horizon.LoadAccount(sourceKeypair.Address())
-		if err != nil {
-			log.WithFields(log.Fields{"error": err}).Error("Cannot load source account")
-			server.Write(w, bridge.PaymentSourceNotExist)
+		submitted, attempts := rh.retryBadSequence(func() *submittedPayment {
+			return rh.buildAndSubmitPayment(w, request, operation, memo, check, warnings, logger)
+		}, logger)
+		if submitted == nil {
 			return
 		}
+		rh.resolveCallback(submitted.response, submitted.err, logger)
+		submitResponse, submitError = submitted.response, submitted.err
+		submitResponse.Attempts = attempts
+		submitResponse.Path = foundPath
+		if sweep {
+			submitResponse.Amount = request.Amount
+		}
+		paymentOperationIndex = submitted.operationIndex
+	}
 
-		sequenceNumber, err := strconv.ParseUint(accountResponse.SequenceNumber, 10, 64)
-		if err != nil {
-			log.WithFields(log.Fields{"error": err}).Error("Cannot convert SequenceNumber")
-			server.Write(w, protocols.InternalServerError)
-			return
+	rh.writeSubmitResponse(w, submitResponse, submitError, paymentOperationIndex, operationType, warnings, logger)
+}
+
+// submittedPayment is a result of a submission of a payment transaction
+type submittedPayment struct {
+	response horizon.SubmitTransactionResponse
+	err      error
+	// operationIndex is the index of the payment operation in the transaction (change_trust can
+	// be prepended)
+	operationIndex int
+}
+
+// buildAndSubmitPayment loads the sequence number of the source, then builds, signs and submits a
+// transaction of a payment operation. It returns nil when a response has been written instead: the
+// transaction cannot be built or it's returned unsigned.
+func (rh *RequestHandler) buildAndSubmitPayment(
+	w http.ResponseWriter,
+	request *bridge.PaymentRequest,
+	operation txspec.OperationSpec,
+	memo *txspec.Memo,
+	check *anomalyCheck,
+	warnings []string,
+	logger *log.Entry,
+) *submittedPayment {
+	sourceKeypair, _ := keypair.Parse(request.Source)
+	paymentOperationIndex := 0
+
+	rh.inflightPayment.SetStage(inflight.StageLoadingAccount)
+	accountResponse, err := rh.Horizon.LoadAccountCtx(rh.context(), sourceKeypair.Address())
+	rh.observeAccount(sourceKeypair.Address(), accountResponse, err)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot load source account")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return nil
 		}
+		server.Write(w, rh.withHorizonFailureID(bridge.PaymentSourceNotExist, horizon.FailureID(err)))
+		return nil
+	}
+
+	sequenceNumber, err := strconv.ParseUint(accountResponse.SequenceNumber, 10, 64)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot convert SequenceNumber")
+		server.Write(w, protocols.InternalServerError)
+		return nil
+	}
+
+	spec := txspec.TxSpec{
+		Source:   sourceKeypair.Address(),
+		Sequence: sequenceNumber + 1,
+		Memo:     memo,
+	}
 
-		transactionMutators := []b.TransactionMutator{
-			b.SourceAccount{request.Source},
-			b.Sequence{sequenceNumber + 1},
-			b.Network{rh.Config.NetworkPassphrase},
-			operationBuilder.(b.TransactionMutator),
-		}
-
-		if memoMutator != nil {
-			transactionMutators = append(transactionMutators, memoMutator.(b.TransactionMutator))
-		}
-
-		tx := b.Transaction(transactionMutators...)
-
-		if tx.Err != nil {
-			log.WithFields(log.Fields{"err": tx.Err}).Print("Transaction builder error")
-			// TODO when build.OperationBuilder interface is ready check for
-			// create_account and payment errors separately
-			switch {
-			case tx.Err.Error() == "Asset code length is invalid":
-				server.Write(
-					w,
-					protocols.NewInvalidParameterError("asset_code", request.AssetCode, "Asset code length is invalid"),
-				)
-			case strings.Contains(tx.Err.Error(), "cannot parse amount"):
-				server.Write(
-					w,
-					protocols.NewInvalidParameterError("amount", request.Amount, "Cannot parse amount"),
-				)
-			default:
-				log.WithFields(log.Fields{"err": tx.Err}).Print("Transaction builder error")
-				server.Write(w, protocols.InternalServerError)
+	if request.AutoTrust {
+		code, issuer := sentAsset(request)
+		// Native asset and assets issued by the source do not need trustlines
+		if code != "" && issuer != sourceKeypair.Address() {
+			if _, ok := accountResponse.GetBalance(code, issuer); !ok {
+				logger.WithFields(log.Fields{"asset_code": code, "asset_issuer": issuer}).Info("Creating missing trustline of the source")
+				spec.Operations = append(spec.Operations, txspec.OperationSpec{
+					Type:  txspec.ChangeTrust,
+					Asset: txspec.Asset{Code: code, Issuer: issuer},
+				})
+				paymentOperationIndex = 1
 			}
-			return
 		}
+	}
 
-		txe := tx.Sign(request.Source)
-		txeB64, err := txe.Base64()
+	spec.Operations = append(spec.Operations, operation)
+	spec.Fee = rh.paymentFee(request, len(spec.Operations))
+	spec.TimeBounds = request.TimeBounds()
 
-		if err != nil {
-			log.WithFields(log.Fields{"error": err}).Error("Cannot encode transaction envelope")
+	lease, errorResponse := rh.leaseChannel(request, &spec, logger)
+	if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return nil
+	}
+	defer lease.Release()
+
+	tx, err := rh.transactionBuilder().Build(spec)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Print("Transaction builder error")
+		var operationErr *txspec.OperationError
+		switch {
+		case err == txspec.ErrInvalidFee:
+			server.Write(w, bridge.NewPaymentInvalidFeeError(request.Fee, spec.MinFee()))
+		case errors.As(err, &operationErr):
+			server.Write(w, withOperationType(operationBuildError(request, operationErr), paymentOperationType(request, operation)))
+		default:
 			server.Write(w, protocols.InternalServerError)
-			return
 		}
+		return nil
+	}
 
-		submitResponse, submitError = rh.Horizon.SubmitTransaction(txeB64)
+	if request.DryRun {
+		rh.writeDryRunPayment(w, tx, paymentSigners(request, lease), operation.Destination, paymentOperationIndex, warnings, logger)
+		return nil
 	}
 
-	if submitError != nil {
-		log.WithFields(log.Fields{"error": submitError}).Error("Error submitting transaction")
+	if unsignedPayment(request) {
+		rh.writeUnsignedPayment(w, tx, paymentOperationIndex, warnings, logger)
+		return nil
+	}
+
+	txeB64, err := submitter.SignEnvelope(tx, rh.Config.NetworkPassphrase, paymentSigners(request, lease)...)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot sign transaction envelope")
 		server.Write(w, protocols.InternalServerError)
+		return nil
+	}
+
+	submitResponse, submitError := rh.submitPayment(request, tx, txeB64, check, logger)
+	lease.Submitted(submitResponse.Ledger != nil)
+	submitResponse.TimeBounds = spec.TimeBounds
+	if submitError == nil && submitResponse.Ledger != nil {
+		rh.recordPayment(check, logger)
+	}
+	return &submittedPayment{response: submitResponse, err: submitError, operationIndex: paymentOperationIndex}
+}
+
+// usesCompliance returns true when the payment is sent using compliance protocol
+func (rh *RequestHandler) usesCompliance(request *bridge.PaymentRequest) bool {
+	return rh.Config.Compliance != "" &&
+		(request.ExtraMemo != "" || (request.ExtraMemo == "" && request.UseCompliance))
+}
+
+// writeSubmitResponse writes the response of a submitted payment transaction.
+// paymentOperationIndex is the index of the payment operation in the transaction.
+func (rh *RequestHandler) writeSubmitResponse(
+	w http.ResponseWriter,
+	submitResponse horizon.SubmitTransactionResponse,
+	submitError error,
+	paymentOperationIndex int,
+	operationType string,
+	warnings []string,
+	logger *log.Entry,
+) {
+	if submitError != nil {
+		logger.WithFields(log.Fields{"error": submitError}).Error("Error submitting transaction")
+		if errorResponse := dependencyError(submitError); errorResponse != nil {
+			server.Write(w, withOperationType(withHash(errorResponse, submitResponse.Hash), operationType))
+			return
+		}
+		server.Write(w, withOperationType(withHash(rh.withHorizonFailureID(protocols.InternalServerError, horizon.FailureID(submitError)), submitResponse.Hash), operationType))
 		return
 	}
 
 	errorResponse := bridge.ErrorFromHorizonResponse(submitResponse)
 	if errorResponse != nil {
-		log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
-		server.Write(w, errorResponse)
+		logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		errorResponse = withResultCodes(withAttempts(rh.withHorizonFailureID(errorResponse, submitResponse.FailureID), submitResponse.Attempts), submitResponse)
+		server.Write(w, withOperationType(withHash(errorResponse, submitResponse.Hash), operationType))
 		return
 	}
 
@@ -335,7 +574,7 @@ func (rh *RequestHandler) Payment(w http.ResponseWriter, r *http.Request) {
 		_, err := xdr.Unmarshal(b64r, &transactionResult)
 
 		if err == nil && transactionResult.Result.Code == xdr.TransactionResultCodeTxSuccess {
-			operationResult := (*transactionResult.Result.Results)[0]
+			operationResult := (*transactionResult.Result.Results)[paymentOperationIndex]
 			if operationResult.Tr.PathPaymentResult != nil {
 				sendAmount := operationResult.Tr.PathPaymentResult.SendAmount()
 				submitResponse.SendAmount = amount.String(sendAmount)
@@ -343,5 +582,507 @@ func (rh *RequestHandler) Payment(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	submitResponse.TrustlineCreated = paymentOperationIndex > 0
+	submitResponse.Warnings = warnings
+	submitResponse.ResultCodes = bridge.ResultCodesFromHorizonResponse(submitResponse)
 	server.Write(w, &submitResponse)
 }
+
+// withResultCodes returns a copy of errorResponse of a failed transaction with `result_codes`
+// decoded from its result XDR in data
+func withResultCodes(errorResponse *protocols.ErrorResponse, submitResponse horizon.SubmitTransactionResponse) *protocols.ErrorResponse {
+	codes := bridge.ResultCodesFromHorizonResponse(submitResponse)
+	if codes == nil {
+		return errorResponse
+	}
+
+	response := *errorResponse
+	response.Data = map[string]interface{}{"result_codes": codes}
+	for key, value := range errorResponse.Data {
+		response.Data[key] = value
+	}
+	return &response
+}
+
+// withOperationType returns a copy of errorResponse of a payment with `operation_type` in data:
+// payment, path_payment or create_account when the destination is created by the payment
+func withOperationType(errorResponse *protocols.ErrorResponse, operationType string) *protocols.ErrorResponse {
+	if operationType == "" {
+		return errorResponse
+	}
+
+	response := *errorResponse
+	response.Data = map[string]interface{}{"operation_type": operationType}
+	for key, value := range errorResponse.Data {
+		response.Data[key] = value
+	}
+	return &response
+}
+
+// paymentOperationType returns the operation_type of operation of request
+func paymentOperationType(request *bridge.PaymentRequest, operation txspec.OperationSpec) string {
+	switch {
+	case operation.Type == txspec.CreateAccount:
+		return "create_account"
+	case request.SendMax != "":
+		return "path_payment"
+	default:
+		return "payment"
+	}
+}
+
+// transactionOperationType returns the operation_type of the operation at index of tx (ex. built
+// by the compliance server), an empty string when it is not a payment
+func transactionOperationType(tx *xdr.Transaction, index int) string {
+	if index >= len(tx.Operations) {
+		return ""
+	}
+	switch tx.Operations[index].Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		return "create_account"
+	case xdr.OperationTypePathPayment:
+		return "path_payment"
+	case xdr.OperationTypePayment:
+		return "payment"
+	default:
+		return ""
+	}
+}
+
+// withHash returns a copy of errorResponse of a signed transaction with its `hash` in data, the
+// transaction may have been applied when its response was lost
+func withHash(errorResponse *protocols.ErrorResponse, hash string) *protocols.ErrorResponse {
+	if hash == "" {
+		return errorResponse
+	}
+
+	response := *errorResponse
+	response.Data = map[string]interface{}{"hash": hash}
+	for key, value := range errorResponse.Data {
+		response.Data[key] = value
+	}
+	return &response
+}
+
+// submitPayment submits a signed payment transaction. The transaction is stored with its request
+// (see bridge.PaymentPayload) so it can be rebuilt and with anomalies of a flagged payment,
+// simulated payments (nil EntityManager) are not stored.
+func (rh *RequestHandler) submitPayment(
+	request *bridge.PaymentRequest,
+	tx *xdr.Transaction,
+	txeB64 string,
+	check *anomalyCheck,
+	logger *log.Entry,
+) (horizon.SubmitTransactionResponse, error) {
+
+	rh.inflightPayment.SetStage(inflight.StageSubmitting)
+	transactionHash, err := submitter.TransactionHash(tx, rh.Config.NetworkPassphrase)
+	if err != nil {
+		return horizon.SubmitTransactionResponse{}, err
+	}
+	// Responses of failed or lost submissions don't have the hash, it's set in all responses so
+	// the transaction can be looked up later
+	hash := hex.EncodeToString(transactionHash[:])
+	rh.inflightPayment.SetHash(hash)
+
+	if rh.EntityManager == nil {
+		rh.inflightPayment.SetStage(inflight.StageAwaitingConfirmation)
+		response, err := rh.Horizon.SubmitTransactionCtx(rh.context(), txeB64)
+		if response.Hash == "" {
+			response.Hash = hash
+		}
+		return response, err
+	}
+
+	// Stored first, a concurrent request with the same id fails on the unique payment_id
+	if rh.idempotent != nil {
+		rh.idempotent.payment.TransactionID = hash
+		isNew := rh.idempotent.payment.IsNew()
+		err = rh.EntityManager.Persist(rh.idempotent.payment)
+		if err != nil {
+			rh.idempotent.conflict = isNew
+			return horizon.SubmitTransactionResponse{}, err
+		}
+	}
+	payload, err := json.Marshal(bridge.NewPaymentPayload(request, rh.Config.Accounts.SeedAlias(request.Source)))
+	if err != nil {
+		return horizon.SubmitTransactionResponse{}, err
+	}
+	sourceKeypair, _ := keypair.Parse(request.Source)
+	payloadString := string(payload)
+
+	sentTransaction := &entities.SentTransaction{
+		TransactionID: hash,
+		Status:        entities.SentTransactionStatusSending,
+		Source:        sourceKeypair.Address(),
+		SubmittedAt:   utc.Now(),
+		EnvelopeXdr:   txeB64,
+		CorrelationID: rh.correlationID,
+		Payload:       &payloadString,
+		RebuiltFrom:   rh.rebuiltFrom,
+		Anomalies:     check.storedReport(),
+		Internal:      rh.internal,
+	}
+	err = rh.EntityManager.Persist(sentTransaction)
+	if err != nil {
+		return horizon.SubmitTransactionResponse{}, err
+	}
+	events.RecordTransaction(rh.Events, sentTransaction)
+	err = rh.storeCallback(hash)
+	if err != nil {
+		return horizon.SubmitTransactionResponse{}, err
+	}
+	release, err := rh.acceptAsync(tx.SourceAccount.Address())
+	if err != nil {
+		return horizon.SubmitTransactionResponse{}, err
+	}
+	defer release()
+
+	// Horizon responds when the transaction is included in a ledger
+	rh.inflightPayment.SetStage(inflight.StageAwaitingConfirmation)
+	response, err := rh.Horizon.SubmitTransactionCtx(rh.context(), txeB64)
+	if response.Hash == "" {
+		response.Hash = hash
+	}
+	if err != nil {
+		return response, err
+	}
+	if rh.idempotent != nil {
+		rh.idempotent.resulted = true
+	}
+
+	if response.Ledger != nil {
+		sentTransaction.MarkSucceeded(*response.Ledger, response.ResultXdr)
+	} else {
+		result := "<empty>"
+		if response.Extras != nil {
+			result = response.Extras.ResultXdr
+		}
+		sentTransaction.MarkFailed(result)
+	}
+	// The transaction has been submitted so the response is returned anyway
+	if err := rh.EntityManager.Persist(sentTransaction); err != nil {
+		logger.WithFields(log.Fields{"err": err, "hash": sentTransaction.TransactionID}).Error("Error updating sent transaction")
+	}
+	events.RecordTransaction(rh.Events, sentTransaction)
+	return response, nil
+}
+
+// resolveDestination returns the account ID and federation memo of a destination address, a
+// destination that is not an address is returned as the account ID
+func (rh *RequestHandler) resolveDestination(destination string, logger *log.Entry) (*federation.NameResponse, *protocols.ErrorResponse) {
+	destinationObject := &federation.NameResponse{}
+
+	_, _, err := address.Split(destination)
+	if err != nil {
+		destinationObject.AccountID = destination
+	} else {
+		destinationObject, err = rh.FederationResolver.LookupByAddress(destination)
+		if err != nil {
+			logger.WithFields(log.Fields{"destination": destination, "err": err}).Print("Cannot resolve address")
+			if errorResponse := dependencyError(err); errorResponse != nil {
+				return nil, errorResponse
+			}
+			return nil, bridge.PaymentCannotResolveDestination
+		}
+	}
+
+	if !protocols.IsValidAccountID(destinationObject.AccountID) {
+		logger.WithFields(log.Fields{"AccountId": destinationObject.AccountID}).Print("Invalid AccountId in destination")
+		return nil, protocols.NewInvalidParameterError("destination", destination, "Destination public key must start with `G`.")
+	}
+	return destinationObject, nil
+}
+
+// checkCounterparty returns PaymentCounterpartyNotAllowed error when the domain of a federated
+// destination is not allowed by counterparty lists. Account ID destinations have no domain and
+// are not checked.
+func (rh *RequestHandler) checkCounterparty(destination string, logger *log.Entry) *protocols.ErrorResponse {
+	if rh.Counterparties == nil {
+		return nil
+	}
+
+	_, domain, err := address.Split(destination)
+	if err != nil || rh.Counterparties.Allowed(domain) {
+		return nil
+	}
+	logger.WithFields(log.Fields{"destination": destination}).Warn("Destination domain is not allowed by counterparty lists")
+	return bridge.PaymentCounterpartyNotAllowed
+}
+
+// paymentMemo returns the memo of the request or the memo returned by federation, nil when there
+// is none
+func paymentMemo(request *bridge.PaymentRequest, destinationObject *federation.NameResponse, logger *log.Entry) (*txspec.Memo, *protocols.ErrorResponse) {
+	memoType := request.MemoType
+	memo := request.Memo
+	invalidMemo := func(moreInfo string) *protocols.ErrorResponse {
+		return protocols.NewInvalidParameterError("memo", request.Memo, moreInfo)
+	}
+
+	if destinationObject.MemoType != "" {
+		if request.MemoType != "" {
+			logger.Print("Memo given in request but federation returned memo fields.")
+			return nil, bridge.PaymentCannotUseMemo
+		}
+
+		memoType = destinationObject.MemoType
+		memo = destinationObject.Memo.Value
+		// A broken federation server is not an error of the request
+		invalidMemo = func(moreInfo string) *protocols.ErrorResponse {
+			return bridge.NewPaymentInvalidFederationMemoError(request.Destination, memoType, memo, moreInfo)
+		}
+	}
+
+	switch {
+	case memoType == "":
+		return nil, nil
+	case memoType == "id":
+		id, err := strconv.ParseUint(memo, 10, 64)
+		if err != nil {
+			logger.WithFields(log.Fields{"memo": memo}).Print("Cannot convert memo_id value to uint64")
+			return nil, invalidMemo("Memo.id must be a number")
+		}
+		return &txspec.Memo{Type: xdr.MemoTypeMemoId, ID: id}, nil
+	case memoType == "text":
+		if length := len([]byte(memo)); length > txspec.MemoTextMaxLength {
+			moreInfo := fmt.Sprintf("Memo.text must be at most %d bytes long, it is %d bytes long.", txspec.MemoTextMaxLength, length)
+			logger.WithFields(log.Fields{"memo": memo}).Print("Text memo is too long")
+			if destinationObject.MemoType != "" {
+				return nil, invalidMemo(moreInfo)
+			}
+			return nil, bridge.NewPaymentInvalidMemoError(memo, moreInfo)
+		}
+		return &txspec.Memo{Type: xdr.MemoTypeMemoText, Text: memo}, nil
+	case memoType == "hash" || memoType == "return":
+		decoded, ok := protocols.DecodeHash(memo)
+		if !ok {
+			logger.WithFields(log.Fields{"memo": memo}).Print("Cannot decode " + memoType + " memo value")
+			return nil, invalidMemo("Memo." + memoType + " must be 32 bytes, hex or base64 encoded.")
+		}
+		hash := xdr.Hash(decoded)
+		if memoType == "return" {
+			// Return memos contain the hash of the refunded transaction
+			return &txspec.Memo{Type: xdr.MemoTypeMemoReturn, Hash: hash}, nil
+		}
+		return &txspec.Memo{Type: xdr.MemoTypeMemoHash, Hash: hash}, nil
+	default:
+		logger.Print("Not supported memo type: ", memoType)
+		return nil, invalidMemo("Memo type not supported")
+	}
+}
+
+// sentAsset returns code and issuer of the asset sent by the source (send asset of path payments).
+// Code is empty for native asset.
+func sentAsset(request *bridge.PaymentRequest) (code, issuer string) {
+	if request.SendMax != "" {
+		return request.SendAssetCode, request.SendAssetIssuer
+	}
+	return request.AssetCode, request.AssetIssuer
+}
+
+// createPaymentOperation builds payment operation (or path payment when request.SendMax is set)
+// to a given destination. When sending XLM to a non-existent account create_account operation is
+// returned instead, funding the account with `starting_balance` when it's set, unless account
+// creation is forbidden by the request or forbid_account_creation config. It returns
+// *breaker.OpenError when it cannot be checked if the destination exists and
+// *protocols.ErrorResponse when the destination cannot receive a credit asset (see
+// checkDestinationTrustline), doesn't exist and cannot be created, `starting_balance` cannot be
+// used or the created account would be below the minimum balance. `amount=all` is replaced with
+// the available balance of the source first (see sweepAmount).
+func (rh *RequestHandler) createPaymentOperation(
+	request *bridge.PaymentRequest,
+	destinationAccountID string,
+	path []protocols.Asset,
+) (txspec.OperationSpec, error) {
+	if errorResponse := validatePaymentAssetCodes(request, path); errorResponse != nil {
+		return txspec.OperationSpec{}, errorResponse
+	}
+
+	if request.Amount == bridge.AmountAll {
+		if err := rh.sweepAmount(request); err != nil {
+			return txspec.OperationSpec{}, err
+		}
+	}
+
+	operation := txspec.OperationSpec{
+		Type:        txspec.Payment,
+		Destination: destinationAccountID,
+		Amount:      request.Amount,
+	}
+
+	if request.SendMax != "" {
+		// Path payment
+		operation.SendAsset = txspec.Asset{Code: request.SendAssetCode, Issuer: request.SendAssetIssuer}
+		operation.SendMax = request.SendMax
+		for _, asset := range path {
+			operation.Path = append(operation.Path, txspec.Asset{Code: asset.Code, Issuer: asset.Issuer})
+		}
+	}
+
+	if request.AssetCode != "" && request.AssetIssuer != "" {
+		operation.Asset = txspec.Asset{Code: request.AssetCode, Issuer: request.AssetIssuer}
+		if request.SkipTrustCheck {
+			return operation, nil
+		}
+		return operation, rh.checkDestinationTrustline(destinationAccountID, request.AssetCode, request.AssetIssuer, request.Amount)
+	}
+
+	// Check if destination account exist
+	account, err := rh.Horizon.LoadAccountCtx(rh.context(), destinationAccountID)
+	if isLookupAborted(err) {
+		return txspec.OperationSpec{}, err
+	}
+	rh.observeAccount(destinationAccountID, account, err)
+	if err == nil {
+		if request.StartingBalance != "" {
+			return operation, bridge.PaymentDestinationExists
+		}
+		return operation, nil
+	}
+
+	log.WithFields(log.Fields{"error": err}).Error("Error loading account")
+	if request.ForbidAccountCreation || rh.Config.ForbidAccountCreation {
+		return operation, bridge.NewPaymentDestinationDoesNotExistError(destinationAccountID)
+	}
+	operation.Type = txspec.CreateAccount
+	minBalance := rh.minBalance()
+	if request.StartingBalance != "" {
+		startingBalance, _ := protocols.ParsePositiveAmount(request.StartingBalance)
+		if startingBalance < minBalance {
+			return operation, bridge.NewPaymentStartingBalanceBelowReserveError(request.StartingBalance, amount.String(minBalance))
+		}
+		operation.Amount = request.StartingBalance
+	} else if value, _ := protocols.ParsePositiveAmount(request.Amount); value < minBalance {
+		// create_account would fail with op_low_reserve
+		return operation, bridge.NewPaymentAmountBelowReserveError(request.Amount, amount.String(minBalance))
+	}
+	return operation, nil
+}
+
+// operationBuildError returns the error response of an operation of a payment transaction the
+// builder cannot encode. Params are validated before the transaction is built, this maps the
+// remaining builder errors to the param of the operation instead of a server error.
+func operationBuildError(request *bridge.PaymentRequest, operationErr *txspec.OperationError) *protocols.ErrorResponse {
+	if operationErr.Type == txspec.CreateAccount {
+		if operationErr.Err != txspec.ErrInvalidAmount {
+			return bridge.CreateAccountMalformed
+		}
+		if request.StartingBalance != "" {
+			return bridge.NewCreateAccountInvalidAmountError("starting_balance", request.StartingBalance)
+		}
+		return bridge.NewCreateAccountInvalidAmountError("amount", request.Amount)
+	}
+
+	switch operationErr.Err {
+	case txspec.ErrInvalidAmount:
+		if request.SendMax != "" && protocols.IsValidPositiveAmount(request.Amount) {
+			return bridge.NewPaymentInvalidAmountError("send_max", request.SendMax)
+		}
+		return bridge.NewPaymentInvalidAmountError("amount", request.Amount)
+	case txspec.ErrInvalidAssetCode:
+		return bridge.NewPaymentMalformedAssetCodeError("asset_code", request.AssetCode)
+	default:
+		return bridge.PaymentMalformed
+	}
+}
+
+// validatePaymentAssetCodes returns PaymentMalformedAssetCode error of the first invalid code of
+// assets of the payment operation, path is the sent or the found path of path payments
+func validatePaymentAssetCodes(request *bridge.PaymentRequest, path []protocols.Asset) *protocols.ErrorResponse {
+	if request.AssetCode != "" && !protocols.IsValidAssetCode(request.AssetCode) {
+		return bridge.NewPaymentMalformedAssetCodeError("asset_code", request.AssetCode)
+	}
+	if request.SendMax == "" {
+		return nil
+	}
+	if request.SendAssetCode != "" && !protocols.IsValidAssetCode(request.SendAssetCode) {
+		return bridge.NewPaymentMalformedAssetCodeError("send_asset_code", request.SendAssetCode)
+	}
+	for i, asset := range path {
+		if asset != (protocols.Asset{}) && !protocols.IsValidAssetCode(asset.Code) {
+			return bridge.NewPaymentMalformedAssetCodeError(fmt.Sprintf("path[%d][asset_code]", i), asset.Code)
+		}
+	}
+	return nil
+}
+
+// minBalance returns the minimum balance of a new account: 2 base reserves of base_reserve config
+func (rh *RequestHandler) minBalance() xdr.Int64 {
+	return 2 * rh.baseReserve()
+}
+
+// baseReserve returns base_reserve config or the base reserve of the public network when not set
+func (rh *RequestHandler) baseReserve() xdr.Int64 {
+	reserve := rh.Config.BaseReserve
+	if reserve == 0 {
+		reserve = txspec.BaseReserve
+	}
+	return xdr.Int64(reserve)
+}
+
+// paymentFee returns the fee of a transaction of request with a number of operations: `fee` param
+// or base_fee per operation, 0 (the minimum fee) when neither is set
+func (rh *RequestHandler) paymentFee(request *bridge.PaymentRequest, operations int) uint32 {
+	if request.Fee != "" {
+		fee, _ := strconv.ParseUint(request.Fee, 10, 32)
+		return uint32(fee)
+	}
+	return rh.Config.BaseFee * uint32(operations)
+}
+
+// transactionBuilder returns the builder of transaction_builder backend
+func (rh *RequestHandler) transactionBuilder() txspec.Builder {
+	return txspec.Backend(rh.Config.TransactionBuilder)
+}
+
+// checkPathPaymentSlippage estimates execution price of path payments above
+// path_payments.slippage_check_threshold using current order books and returns
+// PaymentExcessiveSlippage error when it exceeds path_payments.max_slippage.
+func (rh *RequestHandler) checkPathPaymentSlippage(request *bridge.PaymentRequest, path []protocols.Asset) *protocols.ErrorResponse {
+	if rh.Config.PathPayments.SlippageCheckThreshold == "" {
+		return nil
+	}
+
+	threshold, _ := amount.Parse(rh.Config.PathPayments.SlippageCheckThreshold)
+	destinationAmount, ok := protocols.ParsePositiveAmount(request.Amount)
+	if !ok || destinationAmount <= threshold {
+		// Invalid amount will be reported by transaction builder
+		return nil
+	}
+
+	assets := []protocols.Asset{{Code: request.SendAssetCode, Issuer: request.SendAssetIssuer}}
+	assets = append(assets, path...)
+	assets = append(assets, protocols.Asset{Code: request.AssetCode, Issuer: request.AssetIssuer})
+
+	books := make([]market.OrderBook, len(assets)-1)
+	for i := range books {
+		response, err := rh.Horizon.LoadOrderBook(assets[i+1].ToBaseAsset(), assets[i].ToBaseAsset())
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			return errorResponse
+		}
+		if err != nil {
+			return protocols.NewInternalServerError("Error loading order book", map[string]interface{}{"err": err})
+		}
+
+		books[i], err = market.NewOrderBook(response)
+		if err != nil {
+			return protocols.NewInternalServerError("Error parsing order book", map[string]interface{}{"err": err})
+		}
+	}
+
+	estimate, err := market.EstimatePath(books, big.NewRat(int64(destinationAmount), amount.One))
+	if err == market.ErrInsufficientLiquidity {
+		return bridge.PaymentTooFewOffers
+	} else if err != nil {
+		return protocols.NewInternalServerError("Error estimating path payment", map[string]interface{}{"err": err})
+	}
+
+	maxSlippage, _ := new(big.Rat).SetString(rh.Config.PathPayments.MaxSlippage)
+	if estimate.Slippage().Cmp(maxSlippage) <= 0 {
+		return nil
+	}
+
+	limitPrice := new(big.Rat).Add(big.NewRat(1, 1), maxSlippage)
+	limitPrice.Mul(limitPrice, estimate.BestPrice)
+	return bridge.NewPaymentExcessiveSlippageError(estimate.Price.FloatString(7), limitPrice.FloatString(7))
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/anomaly"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+)
+
+// anomalyCheck is a payment checked by anomaly detection, report is nil when it's not flagged
+type anomalyCheck struct {
+	payment anomaly.Payment
+	report  *anomaly.Report
+}
+
+// anomalyEvent is a payload of anomaly events, their subject is the request ID
+type anomalyEvent struct {
+	Destination string          `json:"destination"`
+	Report      *anomaly.Report `json:"report"`
+}
+
+// checkAnomalies checks a payment to a resolved destination against statistics of previous
+// payments to it, nil is returned when anomaly detection is disabled. A flagged payment is sent
+// (`log` policy and approved payments of `approve` policy) or an error response is returned.
+func (rh *RequestHandler) checkAnomalies(
+	r *http.Request,
+	request *bridge.PaymentRequest,
+	destination string,
+	logger *log.Entry,
+) (*anomalyCheck, *protocols.ErrorResponse) {
+	if request.ApproveAnomaly && server.RequestRole(r) != server.RoleOperator {
+		return nil, protocols.NewInvalidParameterError("approve_anomaly", "true", "Only operator can approve anomalies.")
+	}
+	if !rh.Anomalies.Enabled() {
+		return nil, nil
+	}
+
+	// Statistics of a recreated destination start empty
+	destinationGeneration, err := rh.Generations.Generation(destination)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error loading account generation")
+		return nil, protocols.InternalServerError
+	}
+
+	// Validated by request.Validate
+	value, _ := protocols.ParsePositiveAmount(request.Amount)
+	check := &anomalyCheck{payment: anomaly.Payment{
+		Destination: destination,
+		AssetCode:   request.AssetCode,
+		AssetIssuer: request.AssetIssuer,
+		Amount:      value,
+		Time:        time.Now(),
+		Generation:  destinationGeneration,
+	}}
+	if check.payment.AssetCode == "" {
+		check.payment.AssetCode = "XLM"
+	}
+
+	check.report, err = rh.Anomalies.Check(check.payment)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error checking payment anomalies")
+		return nil, protocols.InternalServerError
+	}
+	if check.report == nil {
+		return check, nil
+	}
+
+	fields := log.Fields{"destination": destination, "flags": check.report.Flags, "policy": check.report.Policy}
+	switch check.report.Policy {
+	case anomaly.PolicyBlock:
+		logger.WithFields(fields).Warn("Payment anomaly blocked")
+		rh.Events.Record(events.PaymentAnomalyBlocked, server.RequestID(r), anomalyEvent{destination, check.report})
+		return nil, bridge.NewPaymentAnomalyError(bridge.PaymentAnomalyBlocked, check.report)
+	case anomaly.PolicyApprove:
+		if !request.ApproveAnomaly {
+			logger.WithFields(fields).Warn("Payment anomaly requires approval")
+			return nil, bridge.NewPaymentAnomalyError(bridge.PaymentAnomalyApprovalRequired, check.report)
+		}
+		check.report.Approved = true
+		logger.WithFields(fields).Info("Payment anomaly approved by operator")
+		rh.Events.Record(events.PaymentAnomalyApproved, server.RequestID(r), anomalyEvent{destination, check.report})
+	default:
+		logger.WithFields(fields).Warn("Payment anomaly")
+	}
+	return check, nil
+}
+
+// storedReport returns the JSON report stored with the sent transaction, nil when the payment
+// was not flagged
+func (check *anomalyCheck) storedReport() *string {
+	if check == nil || check.report == nil {
+		return nil
+	}
+	report, err := json.Marshal(check.report)
+	if err != nil {
+		return nil
+	}
+	value := string(report)
+	return &value
+}
+
+// recordPayment adds a successful payment to statistics of its destination, errors are logged
+// because the payment has been sent. Internal transfers are not added.
+func (rh *RequestHandler) recordPayment(check *anomalyCheck, logger *log.Entry) {
+	if check == nil || rh.internal {
+		return
+	}
+	if err := rh.Anomalies.Record(check.payment); err != nil {
+		logger.WithFields(log.Fields{"err": err, "destination": check.payment.Destination}).Error("Error updating counterparty statistics")
+	}
+}
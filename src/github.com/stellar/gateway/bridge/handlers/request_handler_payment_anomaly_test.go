@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/anomaly"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentAnomaly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-anomaly")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+
+	mockHorizon := new(mocks.MockHorizon)
+	repository := db.NewRepository(driver)
+	entityManager := db.NewEntityManager(driver)
+	detector := func(policy string) *anomaly.Detector {
+		return anomaly.NewDetector(anomaly.Settings{MinPayments: 10, MaxZScore: 4, Policy: policy}, repository, entityManager)
+	}
+	requestHandler := newTestRequestHandler(mockHorizon)
+	requestHandler.Driver = driver
+	requestHandler.Repository = repository
+	requestHandler.EntityManager = entityManager
+
+	destination := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	for i := 0; i < 20; i++ {
+		require.NoError(t, detector(anomaly.PolicyLog).Record(anomaly.Payment{
+			Destination: destination,
+			AssetCode:   "USD",
+			AssetIssuer: issuer,
+			Amount:      xdr.Int64((18 + i%5) * 1e7),
+			Time:        time.Now(),
+		}))
+	}
+
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: issuer}), nil)
+	ledger := uint64(1988727)
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(
+		horizon.SubmitTransactionResponse{Hash: "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", Ledger: &ledger}, nil,
+	)
+
+	pay := func(amount string, params url.Values, role server.Role) (int, map[string]interface{}) {
+		params.Set("destination", destination)
+		params.Set("amount", amount)
+		params.Set("asset_code", "USD")
+		params.Set("asset_issuer", issuer)
+		request := newPaymentRequest(t, params)
+		if role != "" {
+			request = server.WithRole(request, role)
+		}
+		return servePayment(&requestHandler, request)
+	}
+	lastTransaction := func() *entities.SentTransaction {
+		transactions, err := repository.GetSentTransactions(1, 1)
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		return transactions[0]
+	}
+	paymentCount := func() int64 {
+		stats, err := repository.GetCounterpartyStats(destination)
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		return stats[0].Count
+	}
+
+	t.Run("flagged payment is blocked", func(t *testing.T) {
+		requestHandler.Anomalies = detector(anomaly.PolicyBlock)
+		statusCode, response := pay("5000", url.Values{}, "")
+		assert.Equal(t, http.StatusForbidden, statusCode)
+		assert.Equal(t, "payment_anomaly_blocked", response["code"])
+		report := response["data"].(map[string]interface{})["anomalies"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"amount_z_score"}, report["flags"])
+		assert.Equal(t, float64(20), report["stats"].(map[string]interface{})["asset_payments"])
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 0)
+	})
+
+	t.Run("flagged payment requires approval", func(t *testing.T) {
+		requestHandler.Anomalies = detector(anomaly.PolicyApprove)
+		statusCode, response := pay("5000", url.Values{}, "")
+		assert.Equal(t, http.StatusForbidden, statusCode)
+		assert.Equal(t, "payment_anomaly_approval_required", response["code"])
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 0)
+	})
+
+	t.Run("only operator can approve anomalies", func(t *testing.T) {
+		statusCode, response := pay("5000", url.Values{"approve_anomaly": {"true"}}, server.RoleClient)
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "invalid_parameter", response["code"])
+		assert.Equal(t, "approve_anomaly", response["data"].(map[string]interface{})["name"])
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 0)
+	})
+
+	t.Run("approved payment is sent and its report stored", func(t *testing.T) {
+		statusCode, _ := pay("5000", url.Values{"approve_anomaly": {"true"}}, server.RoleOperator)
+		assert.Equal(t, http.StatusOK, statusCode)
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 1)
+
+		transaction := lastTransaction()
+		require.NotNil(t, transaction.Anomalies)
+		var report anomaly.Report
+		require.NoError(t, json.Unmarshal([]byte(*transaction.Anomalies), &report))
+		assert.Equal(t, []string{anomaly.FlagAmountZScore}, report.Flags)
+		assert.True(t, report.Approved)
+		assert.Equal(t, int64(21), paymentCount())
+	})
+
+	t.Run("usual payment is sent", func(t *testing.T) {
+		statusCode, _ := pay("20", url.Values{}, "")
+		assert.Equal(t, http.StatusOK, statusCode)
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 2)
+		assert.Equal(t, int64(22), paymentCount())
+
+		transaction := lastTransaction()
+		assert.Nil(t, transaction.Anomalies)
+	})
+}
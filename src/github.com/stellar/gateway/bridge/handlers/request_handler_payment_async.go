@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+)
+
+// asyncPaymentPrefix prefixes IDs of async payments stored as idempotent payments
+const asyncPaymentPrefix = "async-"
+
+// asyncError returns an error when a validated payment with `async=true` param cannot be sent in
+// the background: its transaction is stored before the request is answered
+func (rh *RequestHandler) asyncError(request *bridge.PaymentRequest) *protocols.ErrorResponse {
+	var reason string
+	switch {
+	case rh.Config.Database.Type == "":
+		reason = "Async payments cannot be sent without a database."
+	case rh.Handoffs == nil || rh.AsyncSubmissions == nil:
+		reason = "Async payments are not enabled."
+	case unsignedPayment(request):
+		reason = "Unsigned payments are not submitted, they cannot be async."
+	}
+	if reason == "" {
+		return nil
+	}
+	return protocols.NewInvalidParameterError("async", "true", reason)
+}
+
+// asyncPayment validates, builds and signs a payment while its request waits and answers it with
+// 202 Accepted and an ID once the transaction is stored. The transaction is submitted in the
+// background by a worker of AsyncSubmissions, its response is returned by /payment/{id}. Requests
+// of payments failing before their transaction is stored get the response right away.
+//
+// The payment is sent once like a payment with `id` param, its response is stored with the
+// idempotent payment so it's returned after it's dropped from Handoffs.
+func (rh *RequestHandler) asyncPayment(
+	w http.ResponseWriter,
+	r *http.Request,
+	request *bridge.PaymentRequest,
+	warnings []string,
+	logger *log.Entry,
+) {
+	payment, err := rh.Handoffs.Start(rh.inflightPayment)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error starting payment")
+		rh.inflightPayment.Done()
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	request.ID = asyncPaymentPrefix + payment.ID
+	if rh.paymentCallback != nil {
+		rh.paymentCallback.PaymentID = payment.ID
+	}
+
+	handler := *rh
+	handler.async = &asyncSend{accepted: make(chan struct{})}
+	go handler.processInBackground(payment, r, request, warnings, logger)
+
+	select {
+	case <-payment.Done():
+	case <-handler.async.accepted:
+		// The payment can finish at the same time, HandOff returns false when it did
+		if payment.HandOff() {
+			rh.inflightPayment.SetKind(inflight.KindAsync)
+			logger.WithFields(log.Fields{"payment_id": payment.ID}).Info("Async payment accepted")
+			server.Write(w, handoffResponse(payment))
+			return
+		}
+	}
+	writeResult(w, payment.Result())
+}
+
+// acceptAsync answers the request of an async payment whose transaction has been stored and
+// waits for a worker submitting transactions of account, the source of the transaction. release
+// must be called once the transaction is submitted. It does nothing for other payments.
+func (rh *RequestHandler) acceptAsync(account string) (release func(), err error) {
+	if rh.async == nil {
+		return func() {}, nil
+	}
+	// A transaction rebuilt after tx_bad_seq is submitted by the same request
+	rh.async.once.Do(func() { close(rh.async.accepted) })
+	return rh.AsyncSubmissions.Acquire(rh.context(), account)
+}
+
+// asyncPaymentResult writes the stored response of an async payment that is not in Handoffs
+// anymore (ex. after a restart), false is returned when the payment is unknown. Payments without
+// a stored response are pending, their transaction can be looked up by its hash.
+func (rh *RequestHandler) asyncPaymentResult(w http.ResponseWriter, id string, logger *log.Entry) bool {
+	if rh.Config.Database.Type == "" {
+		return false
+	}
+	payment, err := rh.Repository.GetIdempotentPaymentByPaymentID(asyncPaymentPrefix + id)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error loading async payment")
+		server.Write(w, protocols.InternalServerError)
+		return true
+	}
+	if payment == nil {
+		return false
+	}
+
+	if !payment.IsCompleted() {
+		server.Write(w, bridge.PaymentHandoffResponse{
+			ID:     id,
+			Status: bridge.PaymentHandoffStatusPending,
+			Hash:   payment.TransactionID,
+		})
+		return true
+	}
+	writeResult(w, storedResult(payment))
+	return true
+}
+
+// asyncSend is a payment with `async=true` param sent in the background by asyncPayment
+type asyncSend struct {
+	once sync.Once
+	// accepted is closed when the transaction has been stored and the request can be answered
+	accepted chan struct{}
+}
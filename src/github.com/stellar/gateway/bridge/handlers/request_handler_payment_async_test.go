@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/async"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/handoff"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zenazn/goji/web"
+)
+
+func TestRequestHandlerPaymentAsync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-async")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+
+	mockHorizon := new(mocks.MockHorizon)
+	repository := db.NewRepository(driver)
+	requestHandler := RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Database:          config.Database{Type: "sqlite"},
+			Accounts:          config.Accounts{BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"},
+		},
+		Horizon:          mockHorizon,
+		Driver:           driver,
+		Repository:       repository,
+		EntityManager:    db.NewEntityManager(driver),
+		Inflight:         inflight.NewRegistry(inflight.DefaultSize, time.Now),
+		Handoffs:         handoff.NewStore(handoff.DefaultSize),
+		AsyncSubmissions: async.NewPool(2),
+	}
+
+	ledger := uint64(1988727)
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(
+		accountTrusting("100", protocols.Asset{Code: "USD", Issuer: "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"}), nil,
+	)
+	// Submissions wait until they are released by the test
+	submitting := make(chan string, 2)
+	release := make(chan struct{})
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		submitting <- args.String(0)
+		<-release
+	}).Return(horizon.SubmitTransactionResponse{Ledger: &ledger}, nil)
+
+	pay := func(params url.Values) (int, map[string]interface{}) {
+		return postPayment(t, &requestHandler, usdPaymentParams(params.Get("amount"), params))
+	}
+	result := func(handler *RequestHandler, id string) (int, map[string]interface{}) {
+		request := httptest.NewRequest(http.MethodGet, "/payment/"+id, nil)
+		response := httptest.NewRecorder()
+		handler.PaymentResult(web.C{URLParams: map[string]string{"id": id}}, response, request)
+		return response.Code, test.StringToJSONMap(response.Body.String())
+	}
+	// finished waits until the response of a payment is returned by /payment/{id}
+	finished := func(id string) (int, map[string]interface{}) {
+		for i := 0; i < 100; i++ {
+			if status, response := result(&requestHandler, id); status != http.StatusAccepted {
+				return status, response
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("payment not finished")
+		return 0, nil
+	}
+
+	t.Run("payment is answered before it's submitted", func(t *testing.T) {
+		status, response := pay(url.Values{"async": {"true"}, "amount": {"20"}})
+		require.Equal(t, http.StatusAccepted, status)
+		assert.Equal(t, "pending", response["status"])
+		id := response["id"].(string)
+		hash := response["hash"].(string)
+		assert.NotEmpty(t, hash)
+
+		transaction, err := repository.GetSentTransactionByHash(hash)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+		envelope := <-submitting
+		assert.Equal(t, transaction.EnvelopeXdr, envelope)
+
+		status, response = result(&requestHandler, id)
+		assert.Equal(t, http.StatusAccepted, status)
+		assert.Equal(t, "pending", response["status"])
+
+		release <- struct{}{}
+		status, response = finished(id)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, float64(ledger), response["ledger"])
+		assert.Equal(t, hash, response["hash"])
+
+		// The stored response is returned after the payment is dropped from the handoff store
+		restarted := requestHandler
+		restarted.Handoffs = handoff.NewStore(handoff.DefaultSize)
+		status, response = result(&restarted, id)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, float64(ledger), response["ledger"])
+	})
+
+	t.Run("transactions of a source are submitted one at a time", func(t *testing.T) {
+		status, first := pay(url.Values{"async": {"true"}, "amount": {"21"}})
+		require.Equal(t, http.StatusAccepted, status)
+		status, second := pay(url.Values{"async": {"true"}, "amount": {"22"}})
+		require.Equal(t, http.StatusAccepted, status)
+
+		<-submitting
+		select {
+		case <-submitting:
+			t.Fatal("second transaction submitted while the first one is pending")
+		case <-time.After(50 * time.Millisecond):
+		}
+		release <- struct{}{}
+		<-submitting
+		release <- struct{}{}
+
+		status, _ = finished(first["id"].(string))
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = finished(second["id"].(string))
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("invalid payments fail right away", func(t *testing.T) {
+		status, response := pay(url.Values{"async": {"true"}, "amount": {"-1"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.NotEqual(t, "pending", response["status"])
+
+		status, response = pay(url.Values{"async": {"true"}, "queue": {"true"}, "amount": {"20"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "async", response["data"].(map[string]interface{})["name"])
+
+		status, response = pay(url.Values{"async": {"true"}, "amount": {"20"}, "source": {"GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, response["more_info"], "Unsigned payments")
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 3)
+	})
+
+	t.Run("unknown payment is not found", func(t *testing.T) {
+		status, response := result(&requestHandler, "unknown")
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, "payment_not_found", response["code"])
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/go/xdr"
+)
+
+// DefaultBadSequenceRetry is a policy of rebuilt payments used when `retry.bad_seq` is not
+// configured. Another service using the source account is usually done after a short wait.
+var DefaultBadSequenceRetry = retry.Settings{MaxAttempts: 3, BaseBackoff: 100 * time.Millisecond, MaxBackoff: 500 * time.Millisecond, Jitter: 0.5}
+
+// errBadSequence is returned to the retry policy by payments failing with tx_bad_seq
+var errBadSequence = errors.New("transaction failed with tx_bad_seq")
+
+// retryBadSequence calls submit until its transaction doesn't fail with tx_bad_seq, using
+// `retry.bad_seq` policy. submit loads the sequence number of the source again so every attempt
+// is a new transaction. Other results and errors are not retried. It returns the last result of
+// submit and the number of attempts.
+func (rh *RequestHandler) retryBadSequence(submit func() *submittedPayment, logger *log.Entry) (*submittedPayment, int) {
+	var submitted *submittedPayment
+	attempts := 0
+	rh.badSequenceRetry().Do(func(attempt int) error {
+		attempts = attempt
+		submitted = submit()
+		if submitted == nil || submitted.err != nil {
+			return nil
+		}
+		if resultErr := submitted.response.TransactionResultError(); resultErr != nil && resultErr.Code == xdr.TransactionResultCodeTxBadSeq {
+			logger.WithFields(log.Fields{"attempt": attempt}).Warn("Payment failed with tx_bad_seq")
+			return errBadSequence
+		}
+		return nil
+	}, func(err error) bool {
+		return err == errBadSequence
+	})
+	return submitted, attempts
+}
+
+// badSequenceRetry returns `retry.bad_seq` policy of Retries, DefaultBadSequenceRetry when the
+// handler is created without Retries
+func (rh *RequestHandler) badSequenceRetry() *retry.Policy {
+	if rh.Retries == nil {
+		return retry.NewPolicy(retry.BadSequence, DefaultBadSequenceRetry, time.Sleep)
+	}
+	return rh.Retries.Get(retry.BadSequence, DefaultBadSequenceRetry)
+}
+
+// withAttempts adds `attempts` to data of an error of a failed payment that has been submitted
+// more than once
+func withAttempts(errorResponse *protocols.ErrorResponse, attempts int) *protocols.ErrorResponse {
+	if attempts <= 1 {
+		return errorResponse
+	}
+
+	response := *errorResponse
+	response.Data = map[string]interface{}{"attempts": attempts}
+	for key, value := range errorResponse.Data {
+		response.Data[key] = value
+	}
+	return &response
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentBadSequence(t *testing.T) {
+	badSeq := horizon.SubmitTransactionResponse{
+		Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: "AAAAAAAAAAD////7AAAAAA=="}, // tx_bad_seq
+	}
+	ledger := uint64(1988727)
+
+	var waits []time.Duration
+	var mockHorizon *mocks.MockHorizon
+	var submitted []string
+	pay := func() (int, map[string]interface{}) {
+		requestHandler := newTestRequestHandler(mockHorizon)
+		requestHandler.Retries = retry.NewSet(nil, func(d time.Duration) { waits = append(waits, d) })
+		return postPayment(t, &requestHandler, usdPaymentParams("20", nil))
+	}
+	reset := func() {
+		waits = nil
+		submitted = nil
+		mockHorizon = new(mocks.MockHorizon)
+		mockHorizon.On("LoadAccount", "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS").Return(
+			accountTrusting("1", protocols.Asset{Code: "USD", Issuer: "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"}), nil,
+		)
+	}
+	submit := func(response horizon.SubmitTransactionResponse) {
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+			submitted = append(submitted, args.String(0))
+		}).Return(response, nil).Once()
+	}
+	sequence := func(txeB64 string) xdr.SequenceNumber {
+		var envelope xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(txeB64, &envelope))
+		return envelope.Tx.SeqNum
+	}
+	// hash is the hash of an envelope on the test network, it's not computed by the bridge
+	hash := func(txeB64 string) string {
+		var envelope xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(txeB64, &envelope))
+		transactionHash, err := network.HashTransaction(&envelope.Tx, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		return hex.EncodeToString(transactionHash[:])
+	}
+
+	t.Run("rebuilt with a new sequence number", func(t *testing.T) {
+		reset()
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil).Once()
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "105"}, nil).Once()
+		submit(badSeq)
+		submit(horizon.SubmitTransactionResponse{Hash: "6a0049b4", Ledger: &ledger})
+
+		status, response := pay()
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, float64(2), response["attempts"])
+		require.Len(t, submitted, 2)
+		assert.Equal(t, xdr.SequenceNumber(101), sequence(submitted[0]))
+		assert.Equal(t, xdr.SequenceNumber(106), sequence(submitted[1]))
+		require.Len(t, waits, 1)
+		// 50% jitter of 100ms base backoff
+		assert.True(t, waits[0] >= 50*time.Millisecond && waits[0] <= 100*time.Millisecond, waits[0].String())
+		mockHorizon.AssertExpectations(t)
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		reset()
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil).Times(3)
+		for i := 0; i < 3; i++ {
+			submit(badSeq)
+		}
+
+		status, response := pay()
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "transaction_bad_seq", response["code"])
+		require.Len(t, submitted, 3)
+		// Failed submissions have the hash of the last envelope
+		assert.Equal(t, map[string]interface{}{
+			"attempts":       float64(3),
+			"hash":           hash(submitted[2]),
+			"operation_type": "payment",
+			"result_codes":   map[string]interface{}{"transaction": "tx_bad_seq"},
+		}, response["data"])
+		assert.Len(t, waits, 2)
+		mockHorizon.AssertExpectations(t)
+	})
+
+	t.Run("other results are not retried", func(t *testing.T) {
+		reset()
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil).Once()
+		submit(horizon.SubmitTransactionResponse{
+			Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB////+gAAAAA="},
+		})
+
+		status, response := pay()
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.NotEqual(t, "transaction_bad_seq", response["code"])
+		require.Len(t, submitted, 1)
+		assert.Equal(t, map[string]interface{}{
+			"hash":           hash(submitted[0]),
+			"operation_type": "payment",
+			"result_codes":   map[string]interface{}{"transaction": "tx_failed", "operations": []interface{}{"op_no_trust"}},
+		}, response["data"])
+		assert.Empty(t, waits)
+		mockHorizon.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/protocols/federation"
+	"github.com/stellar/go/xdr"
+)
+
+// batchPayment sends all payments of a `type=batch` payment in one transaction with an operation
+// per payment. Every destination is resolved before the transaction is built and nothing is
+// submitted when any of them fails, errors contain the index of the failed payment. Results are
+// reported per payment with result codes of their operations.
+func (rh *RequestHandler) batchPayment(w http.ResponseWriter, request *bridge.PaymentRequest, logger *log.Entry) {
+	sourceKeypair, _ := keypair.Parse(request.Source)
+
+	// The memo of the request applies to the whole transaction
+	memo, errorResponse := paymentMemo(request, &federation.NameResponse{}, logger)
+	if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	}
+
+	spec := txspec.TxSpec{Source: sourceKeypair.Address(), Memo: memo}
+	results := make([]bridge.BatchPaymentResult, len(request.Payments))
+
+	rh.inflightPayment.SetStage(inflight.StageResolving)
+	for i, payment := range request.Payments {
+		if errorResponse := rh.checkCounterparty(payment.Destination, logger); errorResponse != nil {
+			server.Write(w, bridge.NewBatchPaymentError(errorResponse, i))
+			return
+		}
+
+		destinationObject, errorResponse := rh.resolveDestination(payment.Destination, logger)
+		if errorResponse != nil {
+			server.Write(w, bridge.NewBatchPaymentError(errorResponse, i))
+			return
+		}
+		if destinationObject.MemoType != "" {
+			logger.WithFields(log.Fields{"destination": payment.Destination, "index": i}).Print("Federation returned memo fields of a batch destination")
+			server.Write(w, bridge.NewBatchPaymentError(bridge.PaymentBatchFederationMemo, i))
+			return
+		}
+
+		errorResponse = rh.checkDeposit(request.Source, deposit{
+			Destination: payment.Destination,
+			AccountID:   destinationObject.AccountID,
+			Amount:      payment.Amount,
+			AssetCode:   payment.Code,
+			AssetIssuer: payment.Issuer,
+			Memo:        memo,
+		}, logger)
+		if errorResponse != nil {
+			server.Write(w, bridge.NewBatchPaymentError(errorResponse, i))
+			return
+		}
+
+		operation, err := rh.createPaymentOperation(
+			&bridge.PaymentRequest{Amount: payment.Amount, AssetCode: payment.Code, AssetIssuer: payment.Issuer, SkipTrustCheck: request.SkipTrustCheck, ForbidAccountCreation: request.ForbidAccountCreation},
+			destinationObject.AccountID,
+			nil,
+		)
+		if errorResponse, ok := err.(*protocols.ErrorResponse); ok {
+			server.Write(w, bridge.NewBatchPaymentError(errorResponse, i))
+			return
+		}
+		if err != nil {
+			logger.WithFields(log.Fields{"error": err}).Error("Cannot check if destination exists")
+			server.Write(w, dependencyError(err))
+			return
+		}
+
+		spec.Operations = append(spec.Operations, operation)
+		results[i] = bridge.BatchPaymentResult{
+			BatchPayment: payment,
+			AccountID:    destinationObject.AccountID,
+			Operation:    string(operation.Type),
+		}
+	}
+
+	rh.inflightPayment.SetStage(inflight.StageLoadingAccount)
+	sourceAccount, err := rh.Horizon.LoadAccount(sourceKeypair.Address())
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot load source account")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+		server.Write(w, rh.withHorizonFailureID(bridge.PaymentSourceNotExist, horizon.FailureID(err)))
+		return
+	}
+
+	sequenceNumber, err := strconv.ParseUint(sourceAccount.SequenceNumber, 10, 64)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot convert SequenceNumber")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	spec.Sequence = sequenceNumber + 1
+
+	spec.Fee = rh.paymentFee(request, len(spec.Operations))
+	spec.TimeBounds = request.TimeBounds()
+
+	lease, errorResponse := rh.leaseChannel(request, &spec, logger)
+	if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	}
+	defer lease.Release()
+
+	tx, err := rh.transactionBuilder().Build(spec)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Transaction builder error")
+		if err == txspec.ErrInvalidFee {
+			server.Write(w, bridge.NewPaymentInvalidFeeError(request.Fee, spec.MinFee()))
+			return
+		}
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	if request.DryRun {
+		rh.writeDryRunPayment(w, tx, paymentSigners(request, lease), "", 0, nil, logger)
+		return
+	}
+
+	if unsignedPayment(request) {
+		rh.writeUnsignedPayment(w, tx, 0, nil, logger)
+		return
+	}
+
+	txeB64, err := submitter.SignEnvelope(tx, rh.Config.NetworkPassphrase, paymentSigners(request, lease)...)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot sign transaction envelope")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	submitResponse, err := rh.submitPayment(request, tx, txeB64, nil, logger)
+	lease.Submitted(submitResponse.Ledger != nil)
+	rh.resolveCallback(submitResponse, err, logger)
+	submitResponse.TimeBounds = spec.TimeBounds
+	rh.writeBatchSubmitResponse(w, results, submitResponse, err, logger)
+}
+
+// writeBatchSubmitResponse writes the response of a submitted batch payment transaction with a
+// result of every payment
+func (rh *RequestHandler) writeBatchSubmitResponse(
+	w http.ResponseWriter,
+	results []bridge.BatchPaymentResult,
+	submitResponse horizon.SubmitTransactionResponse,
+	err error,
+	logger *log.Entry,
+) {
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Error submitting transaction")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, withHash(errorResponse, submitResponse.Hash))
+			return
+		}
+		server.Write(w, withHash(rh.withHorizonFailureID(protocols.InternalServerError, horizon.FailureID(err)), submitResponse.Hash))
+		return
+	}
+
+	if codes := bridge.OperationResultCodes(submitResponse); len(codes) == len(results) {
+		for i := range results {
+			results[i].ResultCode = codes[i]
+		}
+	}
+
+	errorResponse := bridge.ErrorFromHorizonResponse(submitResponse)
+	if errorResponse != nil {
+		logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+
+		operationErrors := bridge.OperationErrorsFromHorizonResponse(submitResponse)
+		if len(operationErrors) != len(results) {
+			server.Write(w, withHash(withResultCodes(rh.withHorizonFailureID(errorResponse, submitResponse.FailureID), submitResponse), submitResponse.Hash))
+			return
+		}
+
+		// Transactions are atomic so payments that succeeded have not been applied either
+		for i := range results {
+			results[i].Error = operationErrors[i]
+			if results[i].Error == nil {
+				results[i].Error = bridge.PaymentBatchRolledBack
+			}
+		}
+		server.Write(w, withHash(withResultCodes(rh.withHorizonFailureID(bridge.NewPaymentBatchFailedError(results), submitResponse.FailureID), submitResponse), submitResponse.Hash))
+		return
+	}
+
+	server.Write(w, &bridge.BatchPaymentResponse{
+		Hash:        submitResponse.Hash,
+		Ledger:      submitResponse.Ledger,
+		Results:     results,
+		TimeBounds:  submitResponse.TimeBounds,
+		ResultCodes: bridge.ResultCodesFromHorizonResponse(submitResponse),
+	})
+}
+
+// batchResults returns results of payments of a batch sent in a transaction envelope, the
+// destination and the type of every operation are read from the envelope
+func batchResults(payments []bridge.BatchPayment, envelopeXdr string) []bridge.BatchPaymentResult {
+	results := make([]bridge.BatchPaymentResult, len(payments))
+	for i, payment := range payments {
+		results[i] = bridge.BatchPaymentResult{BatchPayment: payment}
+	}
+
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(envelopeXdr, &envelope); err != nil || len(envelope.Tx.Operations) != len(payments) {
+		return results
+	}
+	for i, operation := range envelope.Tx.Operations {
+		switch operation.Body.Type {
+		case xdr.OperationTypePayment:
+			results[i].AccountID = operation.Body.PaymentOp.Destination.Address()
+			results[i].Operation = string(txspec.Payment)
+		case xdr.OperationTypeCreateAccount:
+			results[i].AccountID = operation.Body.CreateAccountOp.Destination.Address()
+			results[i].Operation = string(txspec.CreateAccount)
+		}
+	}
+	return results
+}
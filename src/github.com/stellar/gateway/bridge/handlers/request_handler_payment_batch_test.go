@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/test"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func batchResultXdr(t *testing.T, code xdr.TransactionResultCode, results ...xdr.OperationResult) string {
+	resultXdr, err := xdr.MarshalBase64(xdr.TransactionResult{
+		FeeCharged: 200,
+		Result:     xdr.TransactionResultResult{Code: code, Results: &results},
+	})
+	require.NoError(t, err)
+	return resultXdr
+}
+
+func TestRequestHandlerPaymentBatch(t *testing.T) {
+	// GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW
+	seed := "SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"
+	source := "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW"
+	existing := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	missing := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+
+	params := url.Values{
+		"source":                    {seed},
+		"type":                      {bridge.PaymentTypeBatch},
+		"memo_type":                 {"id"},
+		"memo":                      {"123"},
+		"payments[0][destination]":  {existing},
+		"payments[0][amount]":       {"20"},
+		"payments[0][asset_code]":   {"USD"},
+		"payments[0][asset_issuer]": {source},
+		"payments[1][destination]":  {missing},
+		"payments[1][amount]":       {"5"},
+	}
+
+	paymentResult := func(code xdr.PaymentResultCode) xdr.OperationResult {
+		return xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+			Type:          xdr.OperationTypePayment,
+			PaymentResult: &xdr.PaymentResult{Code: code},
+		}}
+	}
+	createAccountResult := xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+		Type:                xdr.OperationTypeCreateAccount,
+		CreateAccountResult: &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountSuccess},
+	}}
+
+	newRequestHandler := func() (*RequestHandler, *mocks.MockHorizon) {
+		mockHorizon := new(mocks.MockHorizon)
+		mockHorizon.On("LoadAccount", source).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+		mockHorizon.On("LoadAccount", existing).Return(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: source}), nil)
+		mockHorizon.On("LoadAccount", missing).Return(horizon.AccountResponse{}, errors.New("Resource Missing"))
+		return &RequestHandler{
+			Config:  &config.Config{NetworkPassphrase: "Test SDF Network ; September 2015"},
+			Horizon: mockHorizon,
+		}, mockHorizon
+	}
+
+	send := func(requestHandler *RequestHandler, params url.Values) (*httptest.ResponseRecorder, map[string]interface{}) {
+		response := httptest.NewRecorder()
+		requestHandler.Payment(response, newPaymentRequest(t, params))
+		return response, test.StringToJSONMap(response.Body.String())
+	}
+
+	t.Run("success", func(t *testing.T) {
+		requestHandler, mockHorizon := newRequestHandler()
+
+		var ledger uint64 = 1988728
+		resultXdr := batchResultXdr(t, xdr.TransactionResultCodeTxSuccess, paymentResult(xdr.PaymentResultCodePaymentSuccess), createAccountResult)
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{
+			Hash:      "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a",
+			Ledger:    &ledger,
+			ResultXdr: &resultXdr,
+		}, nil).Once()
+
+		response, body := send(requestHandler, params)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		assert.Equal(t, "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", body["hash"])
+
+		results := body["results"].([]interface{})
+		require.Len(t, results, 2)
+		first := results[0].(map[string]interface{})
+		assert.Equal(t, existing, first["account_id"])
+		assert.Equal(t, "payment", first["operation"])
+		assert.Equal(t, "op_success", first["result_code"])
+		second := results[1].(map[string]interface{})
+		assert.Equal(t, missing, second["account_id"])
+		assert.Equal(t, "create_account", second["operation"])
+		assert.Equal(t, "op_success", second["result_code"])
+
+		envelopeXdr := mockHorizon.Calls[len(mockHorizon.Calls)-1].Arguments.String(0)
+		var envelope xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(envelopeXdr, &envelope))
+		assert.Equal(t, xdr.SequenceNumber(101), envelope.Tx.SeqNum)
+		assert.Equal(t, xdr.Uint32(200), envelope.Tx.Fee)
+		assert.Equal(t, xdr.Uint64(123), *envelope.Tx.Memo.Id)
+		require.Len(t, envelope.Tx.Operations, 2)
+		assert.Equal(t, xdr.OperationTypePayment, envelope.Tx.Operations[0].Body.Type)
+		assert.Equal(t, xdr.OperationTypeCreateAccount, envelope.Tx.Operations[1].Body.Type)
+	})
+
+	t.Run("failed operation rolls back the batch", func(t *testing.T) {
+		requestHandler, mockHorizon := newRequestHandler()
+
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{
+			Extras: &horizon.SubmitTransactionResponseExtras{
+				ResultXdr: batchResultXdr(t, xdr.TransactionResultCodeTxFailed, paymentResult(xdr.PaymentResultCodePaymentNoTrust), createAccountResult),
+			},
+		}, nil).Once()
+
+		response, body := send(requestHandler, params)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, bridge.PaymentBatchFailed.Code, body["code"])
+
+		results := body["data"].(map[string]interface{})["results"].([]interface{})
+		require.Len(t, results, 2)
+		first := results[0].(map[string]interface{})
+		assert.Equal(t, "op_no_trust", first["result_code"])
+		assert.Equal(t, bridge.PaymentNoTrust.Code, first["error"].(map[string]interface{})["code"])
+		second := results[1].(map[string]interface{})
+		assert.Equal(t, "op_success", second["result_code"])
+		assert.Equal(t, bridge.PaymentBatchRolledBack.Code, second["error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("invalid payment", func(t *testing.T) {
+		requestHandler, mockHorizon := newRequestHandler()
+
+		invalid := url.Values{}
+		for key, value := range params {
+			invalid[key] = value
+		}
+		invalid.Set("payments[1][amount]", "-5")
+
+		response, body := send(requestHandler, invalid)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "invalid_parameter", body["code"])
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "payments[1][amount]", data["name"])
+		assert.Equal(t, float64(1), data["index"])
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 0)
+	})
+
+	t.Run("destination without trustline", func(t *testing.T) {
+		mockHorizon := new(mocks.MockHorizon)
+		mockHorizon.On("LoadAccount", existing).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+		requestHandler := &RequestHandler{
+			Config:  &config.Config{NetworkPassphrase: "Test SDF Network ; September 2015"},
+			Horizon: mockHorizon,
+		}
+
+		response, body := send(requestHandler, params)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, bridge.PaymentNoTrust.Code, body["code"])
+		assert.Equal(t, float64(0), body["data"].(map[string]interface{})["index"])
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 0)
+	})
+
+	t.Run("fee lower than the minimum fee of the batch", func(t *testing.T) {
+		requestHandler, mockHorizon := newRequestHandler()
+
+		lowFee := url.Values{"fee": {"150"}}
+		for key, value := range params {
+			lowFee[key] = value
+		}
+
+		response, body := send(requestHandler, lowFee)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, bridge.PaymentInvalidFee.Code, body["code"])
+		assert.Equal(t, float64(200), body["data"].(map[string]interface{})["min_fee"])
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 0)
+	})
+
+	t.Run("single payment params", func(t *testing.T) {
+		requestHandler, _ := newRequestHandler()
+
+		invalid := url.Values{"destination": {existing}}
+		for key, value := range params {
+			invalid[key] = value
+		}
+
+		response, body := send(requestHandler, invalid)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "destination", body["data"].(map[string]interface{})["name"])
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/confirmation"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/gateway/webhook"
+)
+
+// callbackError returns an error when the `callback` of a validated payment cannot be delivered:
+// callbacks are stored in the database and only transactions submitted by the server have an
+// outcome
+func (rh *RequestHandler) callbackError(request *bridge.PaymentRequest) *protocols.ErrorResponse {
+	var reason string
+	// Validated by request.Validate
+	u, _ := url.Parse(request.Callback)
+	switch {
+	case rh.Config.Database.Type == "":
+		reason = "Callbacks cannot be sent without a database."
+	case unsignedPayment(request):
+		reason = "Unsigned payments are not submitted, they cannot have a callback."
+	case !webhook.HostAllowed(rh.Config.Callbacks.AllowedHosts, u):
+		reason = "Host of the callback is not allowed by callbacks.allowed_hosts."
+	}
+	if reason == "" {
+		return nil
+	}
+	return protocols.NewInvalidParameterError("callback", request.Callback, reason)
+}
+
+// withCallback returns a copy of rh storing the `callback` of a request with its transaction. A
+// transaction rebuilt by the same request (ex. after tx_bad_seq) replaces the stored one, so only
+// the outcome of the last transaction is posted. Simulated payments are not stored.
+func (rh *RequestHandler) withCallback(request *bridge.PaymentRequest) *RequestHandler {
+	if request.Callback == "" || rh.EntityManager == nil {
+		return rh
+	}
+
+	handler := *rh
+	handler.paymentCallback = &entities.PaymentCallback{
+		PaymentID: request.ID,
+		URL:       request.Callback,
+		Status:    entities.PaymentCallbackStatusPending,
+	}
+	return &handler
+}
+
+// storeCallback stores the callback of a payment with the hash of its transaction before it's
+// submitted. The outcome is looked up by the confirmation worker when it's not resolved by the
+// response of the submission, it waits until the submission has timed out.
+func (rh *RequestHandler) storeCallback(hash string) error {
+	if rh.paymentCallback == nil {
+		return nil
+	}
+
+	timeout := horizon.DefaultTimeout
+	if rh.Config.HorizonTimeoutSeconds != 0 {
+		timeout = time.Duration(rh.Config.HorizonTimeoutSeconds) * time.Second
+	}
+	now := time.Now()
+	rh.paymentCallback.TransactionID = hash
+	rh.paymentCallback.CreatedAt = utc.New(now)
+	rh.paymentCallback.NextAttemptAt = utc.New(now.Add(timeout))
+	return rh.EntityManager.Persist(rh.paymentCallback)
+}
+
+// resolveCallback stores the outcome of the last submitted transaction of a payment with a
+// callback and wakes the confirmation worker. Outcomes of failed submissions are unknown, the
+// worker looks them up in Horizon.
+func (rh *RequestHandler) resolveCallback(response horizon.SubmitTransactionResponse, submitError error, logger *log.Entry) {
+	if rh.paymentCallback == nil || rh.paymentCallback.IsNew() || submitError != nil {
+		return
+	}
+
+	status, ledger, resultCodes := confirmation.Outcome(response)
+	rh.paymentCallback.Resolve(status, ledger, resultCodes, utc.Now())
+	// The worker looks up the transaction when the outcome is not stored
+	if err := rh.EntityManager.Persist(rh.paymentCallback); err != nil {
+		logger.WithFields(log.Fields{"err": err, "hash": rh.paymentCallback.TransactionID}).Error("Error updating payment callback")
+		return
+	}
+	rh.PaymentCallbacks.Wake()
+}
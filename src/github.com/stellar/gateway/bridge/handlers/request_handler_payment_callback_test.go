@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/confirmation"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentCallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-callback")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+
+	var mockHorizon *mocks.MockHorizon
+	requestHandler := RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Database:          config.Database{Type: "sqlite"},
+			Accounts:          config.Accounts{BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"},
+			Callbacks:         config.Callbacks{AllowedHosts: []string{"*.example.com"}},
+		},
+		Driver:           driver,
+		Repository:       db.NewRepository(driver),
+		EntityManager:    db.NewEntityManager(driver),
+		PaymentCallbacks: &confirmation.Worker{},
+	}
+
+	account := accountTrusting("100", protocols.Asset{Code: "USD", Issuer: "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"})
+	pay := func(params url.Values, submitResponse horizon.SubmitTransactionResponse, submitError error) (int, map[string]interface{}) {
+		mockHorizon = new(mocks.MockHorizon)
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(account, nil)
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(submitResponse, submitError)
+		requestHandler.Horizon = mockHorizon
+
+		return postPayment(t, &requestHandler, usdPaymentParams("20", params))
+	}
+	stored := func(hash string) *entities.PaymentCallback {
+		found, err := driver.GetOne(&entities.PaymentCallback{}, "transaction_id = ?", hash)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		return found.(*entities.PaymentCallback)
+	}
+
+	t.Run("outcome of the submission is stored", func(t *testing.T) {
+		ledger := uint64(1988727)
+		status, response := pay(url.Values{
+			"id":       {"order-1"},
+			"callback": {"https://hooks.example.com/payments"},
+		}, horizon.SubmitTransactionResponse{Ledger: &ledger}, nil)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, float64(ledger), response["ledger"])
+
+		callback := stored(response["hash"].(string))
+		assert.Equal(t, "order-1", callback.PaymentID)
+		assert.Equal(t, "https://hooks.example.com/payments", callback.URL)
+		assert.Equal(t, entities.PaymentCallbackStatusPending, callback.Status)
+		assert.Equal(t, entities.PaymentCallbackSuccess, callback.TransactionStatus)
+		assert.Equal(t, &ledger, callback.Ledger)
+		assert.False(t, callback.NextAttemptAt.Time().After(time.Now()))
+	})
+
+	t.Run("lost submission is looked up later", func(t *testing.T) {
+		params := url.Values{"callback": {"https://hooks.example.com/payments"}, "memo_type": {"text"}, "memo": {"lost"}}
+		status, response := pay(params, horizon.SubmitTransactionResponse{}, errors.New("connection reset"))
+		require.Equal(t, http.StatusInternalServerError, status)
+
+		callback := stored(response["data"].(map[string]interface{})["hash"].(string))
+		assert.Equal(t, "", callback.TransactionStatus)
+		assert.True(t, callback.NextAttemptAt.Time().After(time.Now()))
+	})
+
+	t.Run("invalid callbacks are rejected", func(t *testing.T) {
+		for name, params := range map[string]url.Values{
+			"Callback must be an absolute http or https URL.":                   {"callback": {"/payments"}},
+			"Callback cannot be set in compliance payments.":                    {"callback": {"https://hooks.example.com/payments"}, "use_compliance": {"true"}},
+			"Host of the callback is not allowed by callbacks.allowed_hosts.":   {"callback": {"https://hooks.example.org/payments"}},
+			"Unsigned payments are not submitted, they cannot have a callback.": {"callback": {"https://hooks.example.com/payments"}, "source": {"GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"}},
+		} {
+			status, response := pay(params, horizon.SubmitTransactionResponse{}, nil)
+			assert.Equal(t, http.StatusBadRequest, status, name)
+			assert.Contains(t, response["more_info"], name)
+			mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+		}
+
+		requestHandler.Config.Database.Type = ""
+		defer func() { requestHandler.Config.Database.Type = "sqlite" }()
+		status, response := pay(url.Values{"callback": {"https://hooks.example.com/payments"}}, horizon.SubmitTransactionResponse{}, nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "Callbacks cannot be sent without a database.", response["more_info"])
+	})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/channels"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/txspec"
+)
+
+// leaseChannel leases a channel account when channels are configured and makes it the source of
+// the transaction of spec, paying the fee and using its sequence number. Operations keep the
+// source of the payment. Unsigned payments are not submitted by the server so they don't use
+// channels, nil lease is returned for them. The lease must be released by the caller.
+func (rh *RequestHandler) leaseChannel(request *bridge.PaymentRequest, spec *txspec.TxSpec, logger *log.Entry) (*channels.Lease, *protocols.ErrorResponse) {
+	if !rh.Channels.Enabled() || unsignedPayment(request) {
+		return nil, nil
+	}
+
+	lease, err := rh.Channels.Lease()
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot lease a channel")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			return nil, errorResponse
+		}
+		return nil, protocols.InternalServerError
+	}
+
+	for i := range spec.Operations {
+		if spec.Operations[i].Source == "" {
+			spec.Operations[i].Source = spec.Source
+		}
+	}
+	spec.Source = lease.Address()
+	spec.Sequence = lease.Sequence
+	logger.WithFields(log.Fields{"channel": lease.Address(), "sequence": lease.Sequence}).Info("Leased channel")
+	return lease, nil
+}
+
+// paymentSigners returns seeds signing the transaction of a payment: the source of the payment,
+// its `signers` and the channel of the lease
+func paymentSigners(request *bridge.PaymentRequest, lease *channels.Lease) []string {
+	var seeds []string
+	// Transactions of a public key source are signed only by signers
+	if !protocols.IsValidAccountID(request.Source) {
+		seeds = append(seeds, request.Source)
+	}
+	seeds = append(seeds, request.Signers...)
+	if lease != nil {
+		seeds = append(seeds, lease.Seed())
+	}
+	return seeds
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/go/address"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+// depositRegistrationDependency names the registration webhook in dependency_unavailable errors
+const depositRegistrationDependency = "deposit_registration"
+
+// deposit is a payment checked against deposit requirements of its destination domain
+type deposit struct {
+	// Destination is the destination param, requirements apply to federated addresses only
+	Destination string
+	AccountID   string
+	Amount      string
+	AssetCode   string
+	AssetIssuer string
+	// Memo of the transaction, nil when it has none
+	Memo *txspec.Memo
+}
+
+// depositRegistration is the response of a registration_url webhook
+type depositRegistration struct {
+	Approved bool `json:"approved"`
+}
+
+// depositRequirement returns deposit requirements of the domain of a federated destination
+func (rh *RequestHandler) depositRequirement(destination string) (config.DepositRequirement, bool) {
+	if len(rh.Config.DepositRequirements) == 0 {
+		return config.DepositRequirement{}, false
+	}
+	_, domain, err := address.Split(destination)
+	if err != nil {
+		return config.DepositRequirement{}, false
+	}
+	return rh.Config.DepositRequirement(domain)
+}
+
+// checkDeposit returns PaymentMemoPolicyViolation or PaymentSourceNotRegistered error when a
+// payment of source seed violates deposit requirements of the destination domain. Rules are
+// checked in order of configuration params, the registration webhook is called last so it's
+// called only for payments that can be sent.
+func (rh *RequestHandler) checkDeposit(source string, payment deposit, logger *log.Entry) *protocols.ErrorResponse {
+	requirement, ok := rh.depositRequirement(payment.Destination)
+	if !ok {
+		return nil
+	}
+	logger = logger.WithFields(log.Fields{"destination": payment.Destination, "domain": requirement.Domain})
+
+	memoType := depositMemoType(payment.Memo)
+	if (requirement.MemoRequired || requirement.MemoType != "") && memoType == "" {
+		logger.Warn("Payment without memo violates deposit requirements")
+		return bridge.NewPaymentDepositRequirementError(bridge.PaymentMemoPolicyViolation, requirement.Domain, "memo_required")
+	}
+	if requirement.MemoType != "" && memoType != requirement.MemoType {
+		logger.WithFields(log.Fields{"memo_type": memoType}).Warn("Memo type violates deposit requirements")
+		return bridge.NewPaymentDepositRequirementError(bridge.PaymentMemoPolicyViolation, requirement.Domain, "memo_type")
+	}
+
+	sourceKeypair, _ := keypair.Parse(source)
+	if !requirement.AllowsSource(sourceKeypair.Address(), rh.Config.Accounts.SeedAlias(source)) {
+		logger.WithFields(log.Fields{"source": sourceKeypair.Address()}).Warn("Source is not registered with destination domain")
+		return bridge.NewPaymentDepositRequirementError(bridge.PaymentSourceNotRegistered, requirement.Domain, "sources")
+	}
+
+	if requirement.RegistrationURL != "" {
+		return rh.registerDeposit(requirement, sourceKeypair.Address(), payment, logger)
+	}
+	return nil
+}
+
+// registerDeposit asks the registration webhook of requirement to approve a payment of source.
+// Anything other than a 200 response approving the payment rejects it, dependency_unavailable
+// is returned when the webhook cannot be reached.
+func (rh *RequestHandler) registerDeposit(requirement config.DepositRequirement, source string, payment deposit, logger *log.Entry) *protocols.ErrorResponse {
+	callbackLog := logger.WithField(logging.CategoryField, logging.CategoryCallbacks)
+	memoType := depositMemoType(payment.Memo)
+	body := url.Values{
+		"domain":       {requirement.Domain},
+		"destination":  {payment.Destination},
+		"account_id":   {payment.AccountID},
+		"source":       {source},
+		"amount":       {payment.Amount},
+		"asset_code":   {payment.AssetCode},
+		"asset_issuer": {payment.AssetIssuer},
+		"memo_type":    {memoType},
+		"memo":         {depositMemoValue(payment.Memo)},
+	}.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, requirement.RegistrationURL, strings.NewReader(body))
+	if err != nil {
+		callbackLog.WithFields(log.Fields{"err": err}).Error("Cannot create deposit registration request")
+		return protocols.InternalServerError
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if rh.Config.MACKey != "" {
+		rawkey, err := strkey.Decode(strkey.VersionByteSeed, rh.Config.MACKey)
+		if err != nil {
+			callbackLog.WithFields(log.Fields{"err": err}).Error("Invalid MAC key")
+			return protocols.InternalServerError
+		}
+		macer := hmac.New(sha256.New, rawkey)
+		macer.Write([]byte(body))
+		req.Header.Set("X_PAYLOAD_MAC", base64.StdEncoding.EncodeToString(macer.Sum(nil)))
+	}
+
+	resp, err := rh.Webhooks.Do(req)
+	if err != nil {
+		callbackLog.WithFields(log.Fields{"err": err}).Error("Error sending request to deposit registration webhook")
+		return protocols.NewDependencyUnavailableError(depositRegistrationDependency)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		callbackLog.WithFields(log.Fields{"err": err}).Error("Error reading deposit registration response")
+		return protocols.NewDependencyUnavailableError(depositRegistrationDependency)
+	}
+
+	var registration depositRegistration
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(responseBody, &registration) != nil || !registration.Approved {
+		callbackLog.WithFields(log.Fields{
+			"status": resp.StatusCode,
+			"body":   string(responseBody),
+		}).Warn("Deposit registration webhook did not approve payment")
+		return bridge.NewPaymentDepositRequirementError(bridge.PaymentSourceNotRegistered, requirement.Domain, "registration_url")
+	}
+	return nil
+}
+
+// depositMemoType returns the memo_type param value of memo, an empty string when there is no
+// memo
+func depositMemoType(memo *txspec.Memo) string {
+	if memo == nil {
+		return ""
+	}
+	switch memo.Type {
+	case xdr.MemoTypeMemoId:
+		return "id"
+	case xdr.MemoTypeMemoText:
+		return "text"
+	case xdr.MemoTypeMemoHash:
+		return "hash"
+	case xdr.MemoTypeMemoReturn:
+		return "return"
+	}
+	return ""
+}
+
+// depositMemoValue returns the memo param value of memo, hashes are hex encoded
+func depositMemoValue(memo *txspec.Memo) string {
+	switch depositMemoType(memo) {
+	case "id":
+		return strconv.FormatUint(memo.ID, 10)
+	case "text":
+		return memo.Text
+	case "hash", "return":
+		return hex.EncodeToString(memo.Hash[:])
+	}
+	return ""
+}
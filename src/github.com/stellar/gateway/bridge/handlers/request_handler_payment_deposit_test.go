@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentDepositRequirements(t *testing.T) {
+	var registrations []url.Values
+	approve := true
+	registrationServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		registrations = append(registrations, r.PostForm)
+		json.NewEncoder(w).Encode(depositRegistration{Approved: approve})
+	}))
+	defer registrationServer.Close()
+
+	webhooks, err := webhook.NewClient(webhook.Settings{})
+	require.NoError(t, err)
+	c := &config.Config{
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+		DepositRequirements: []config.DepositRequirement{
+			{Domain: "exchange.com", MemoType: "id", Sources: []string{"GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW"}, RegistrationURL: registrationServer.URL},
+			{Domain: "other.com", MemoRequired: true, Sources: []string{"base_seed"}},
+		},
+	}
+	// Mocks without expectations fail the test when called
+	requestHandler := RequestHandler{
+		Config:               c,
+		Client:               new(mocks.MockHTTPClient),
+		Horizon:              new(mocks.MockHorizon),
+		TransactionSubmitter: new(mocks.MockTransactionSubmitter),
+		FederationResolver:   new(mocks.MockFederationResolver),
+		Webhooks:             webhooks,
+	}
+
+	testServer := httptest.NewServer(http.HandlerFunc(requestHandler.Simulate))
+	defer testServer.Close()
+
+	state := `{
+  "accounts": {
+    "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW": {
+      "sequence": "100",
+      "balances": [{"asset_type": "native", "balance": "1000.0000000"}]
+    }
+  },
+  "federation": {
+    "bob*exchange.com": {"account_id": "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", "memo_type": "id", "memo": "123"},
+    "alice*exchange.com": {"account_id": "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+    "bob*pay.other.com": {"account_id": "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", "memo_type": "text", "memo": "bob"}
+  }
+}`
+
+	simulate := func(destination string, params url.Values) (simulationResponse, map[string]interface{}) {
+		params.Set("source", "SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42")
+		params.Set("destination", destination)
+		params.Set("amount", "20")
+		params.Set("state", state)
+		registrations = nil
+		statusCode, body := net.GetResponse(testServer, params)
+		require.Equal(t, http.StatusOK, statusCode, string(body))
+		var response simulationResponse
+		require.NoError(t, json.Unmarshal(body, &response), string(body))
+		var payment map[string]interface{}
+		require.NoError(t, json.Unmarshal(response.Response, &payment))
+		return response, payment
+	}
+	violation := func(domain, rule string) map[string]interface{} {
+		return map[string]interface{}{"domain": domain, "rule": rule}
+	}
+
+	t.Run("registered payment", func(t *testing.T) {
+		approve = true
+		response, _ := simulate("bob*exchange.com", url.Values{})
+		assert.Equal(t, http.StatusOK, response.Status, string(response.Response))
+		assert.NotEmpty(t, response.EnvelopeXdr)
+		require.NotNil(t, response.DepositRequirement)
+		assert.Equal(t, "exchange.com", response.DepositRequirement.Domain)
+		assert.Equal(t, "id", response.DepositRequirement.MemoType)
+		assert.True(t, response.DepositRequirement.Registration)
+		assert.Empty(t, response.DepositRequirement.RegistrationURL)
+
+		require.Len(t, registrations, 1)
+		assert.Equal(t, url.Values{
+			"domain":       {"exchange.com"},
+			"destination":  {"bob*exchange.com"},
+			"account_id":   {"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+			"source":       {"GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW"},
+			"amount":       {"20"},
+			"asset_code":   {""},
+			"asset_issuer": {""},
+			"memo_type":    {"id"},
+			"memo":         {"123"},
+		}, registrations[0])
+	})
+
+	t.Run("registration denied", func(t *testing.T) {
+		approve = false
+		response, payment := simulate("bob*exchange.com", url.Values{})
+		assert.Equal(t, http.StatusForbidden, response.Status)
+		assert.Equal(t, "payment_source_not_registered", payment["code"])
+		assert.Equal(t, violation("exchange.com", "registration_url"), payment["data"])
+		assert.Empty(t, response.EnvelopeXdr)
+	})
+
+	t.Run("memo missing", func(t *testing.T) {
+		response, payment := simulate("alice*exchange.com", url.Values{})
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		assert.Equal(t, "payment_memo_policy_violation", payment["code"])
+		assert.Equal(t, violation("exchange.com", "memo_required"), payment["data"])
+		assert.Empty(t, registrations)
+	})
+
+	t.Run("memo of another type", func(t *testing.T) {
+		response, payment := simulate("alice*exchange.com", url.Values{"memo_type": {"text"}, "memo": {"alice"}})
+		assert.Equal(t, http.StatusBadRequest, response.Status)
+		assert.Equal(t, violation("exchange.com", "memo_type"), payment["data"])
+	})
+
+	t.Run("source not registered", func(t *testing.T) {
+		response, payment := simulate("bob*pay.other.com", url.Values{})
+		assert.Equal(t, http.StatusForbidden, response.Status)
+		assert.Equal(t, "payment_source_not_registered", payment["code"])
+		assert.Equal(t, violation("other.com", "sources"), payment["data"])
+		require.NotNil(t, response.DepositRequirement)
+		assert.False(t, response.DepositRequirement.Registration)
+	})
+
+	t.Run("destinations without requirements", func(t *testing.T) {
+		response, _ := simulate("GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", url.Values{})
+		assert.Equal(t, http.StatusOK, response.Status)
+		assert.Nil(t, response.DepositRequirement)
+	})
+
+	t.Run("registration webhook unavailable", func(t *testing.T) {
+		c.DepositRequirements[0].RegistrationURL = "http://127.0.0.1:1/deposits"
+		defer func() { c.DepositRequirements[0].RegistrationURL = registrationServer.URL }()
+		response, payment := simulate("bob*exchange.com", url.Values{})
+		assert.Equal(t, http.StatusServiceUnavailable, response.Status)
+		assert.Equal(t, "dependency_unavailable", payment["code"])
+		assert.Equal(t, map[string]interface{}{"dependency": "deposit_registration"}, payment["data"])
+	})
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/go/xdr"
+)
+
+// writeDryRunPayment writes the envelope of a built payment transaction signed by seeds instead
+// of submitting it. Nothing is stored: a channel used by the transaction keeps its sequence
+// number. destination is the account ID the payment was resolved to.
+func (rh *RequestHandler) writeDryRunPayment(
+	w http.ResponseWriter,
+	tx *xdr.Transaction,
+	seeds []string,
+	destination string,
+	paymentOperationIndex int,
+	warnings []string,
+	logger *log.Entry,
+) {
+	hash, err := submitter.TransactionHash(tx, rh.Config.NetworkPassphrase)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot hash transaction")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	envelope, err := submitter.SignEnvelope(tx, rh.Config.NetworkPassphrase, seeds...)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot sign transaction envelope")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	response := &bridge.DryRunPaymentResponse{
+		Status:               bridge.PaymentStatusDryRun,
+		Hash:                 hex.EncodeToString(hash[:]),
+		EnvelopeXdr:          envelope,
+		Fee:                  uint32(tx.Fee),
+		Sequence:             strconv.FormatUint(uint64(tx.SeqNum), 10),
+		DestinationAccountID: destination,
+		TrustlineCreated:     paymentOperationIndex > 0,
+		Warnings:             warnings,
+	}
+	response.MemoType, response.Memo = transactionMemo(tx.Memo)
+	if tx.TimeBounds != nil {
+		response.TimeBounds = &txspec.TimeBounds{MinTime: uint64(tx.TimeBounds.MinTime), MaxTime: uint64(tx.TimeBounds.MaxTime)}
+	}
+	logger.WithFields(log.Fields{"hash": response.Hash}).Info("Returning transaction of dry run")
+	server.Write(w, response)
+}
+
+// transactionMemo returns the type and value of a memo like `memo_type` and `memo` params, both
+// are empty without memo
+func transactionMemo(memo xdr.Memo) (memoType, value string) {
+	switch memo.Type {
+	case xdr.MemoTypeMemoId:
+		return "id", strconv.FormatUint(uint64(*memo.Id), 10)
+	case xdr.MemoTypeMemoText:
+		return "text", *memo.Text
+	case xdr.MemoTypeMemoHash:
+		return "hash", base64.StdEncoding.EncodeToString(memo.Hash[:])
+	case xdr.MemoTypeMemoReturn:
+		return "return", base64.StdEncoding.EncodeToString(memo.RetHash[:])
+	default:
+		return "", ""
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/federation"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentDryRun(t *testing.T) {
+	baseSeed := testBaseSeed
+	destination := "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+
+	mockHorizon := new(mocks.MockHorizon)
+	mockFederationResolver := new(mocks.MockFederationResolver)
+	requestHandler := newTestRequestHandler(mockHorizon)
+	requestHandler.FederationResolver = mockFederationResolver
+	mockFederationResolver.On("LookupByAddress", "bob*stellar.org").Return(&federation.NameResponse{
+		AccountID: destination,
+		MemoType:  "text",
+		Memo:      federation.Memo{"125"},
+	}, nil)
+	mockHorizon.On("LoadAccount", destination).Return(accountTrusting("1", protocols.Asset{Code: "USD", Issuer: issuer}), nil)
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+
+	pay := func(params url.Values) (int, map[string]interface{}) {
+		params.Set("destination", "bob*stellar.org")
+		params.Set("amount", "20")
+		params.Set("asset_code", "USD")
+		params.Set("asset_issuer", issuer)
+		return postPayment(t, &requestHandler, params)
+	}
+
+	t.Run("signed transaction is returned without submitting it", func(t *testing.T) {
+		status, response := pay(url.Values{"dry_run": {"true"}, "fee": {"300"}})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, bridge.PaymentStatusDryRun, response["status"])
+		assert.Equal(t, destination, response["destination_account_id"])
+		assert.Equal(t, "text", response["memo_type"])
+		assert.Equal(t, "125", response["memo"])
+		assert.Equal(t, float64(300), response["fee"])
+		assert.Equal(t, "101", response["sequence"])
+		mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+
+		var envelope xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(response["envelope_xdr"].(string), &envelope))
+		hash, err := network.HashTransaction(&envelope.Tx, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		require.Len(t, envelope.Signatures, 1)
+		assert.NoError(t, keypair.MustParse(baseSeed).Verify(hash[:], envelope.Signatures[0].Signature))
+		assert.Equal(t, destination, envelope.Tx.Operations[0].Body.PaymentOp.Destination.Address())
+	})
+
+	t.Run("public key source is not signed", func(t *testing.T) {
+		status, response := pay(url.Values{"dry_run": {"true"}, "source": {"GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"}})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, bridge.PaymentStatusDryRun, response["status"])
+
+		var envelope xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(response["envelope_xdr"].(string), &envelope))
+		assert.Empty(t, envelope.Signatures)
+	})
+
+	t.Run("params storing the payment are rejected", func(t *testing.T) {
+		for name, value := range map[string]string{"queue": "true", "async": "true", "id": "order-1", "callback": "https://example.com/callback"} {
+			status, response := pay(url.Values{"dry_run": {"true"}, name: {value}})
+			assert.Equal(t, http.StatusBadRequest, status, name)
+			assert.Equal(t, "dry_run", response["data"].(map[string]interface{})["name"], name)
+		}
+		mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/horizon"
+)
+
+// observeAccount records the sequence number of an account loaded from Horizon in Generations, an
+// account not found by Horizon is recorded merged. Errors are logged, payments do not depend on
+// generations.
+func (rh *RequestHandler) observeAccount(accountID string, account horizon.AccountResponse, loadErr error) {
+	var err error
+	if statusErr, ok := loadErr.(*horizon.StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+		err = rh.Generations.Merged(accountID)
+	} else if loadErr == nil {
+		var sequence uint64
+		sequence, err = strconv.ParseUint(account.SequenceNumber, 10, 64)
+		if err == nil {
+			_, err = rh.Generations.Observe(accountID, sequence)
+		}
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"err": err, "account_id": accountID}).Error("Error observing account generation")
+	}
+}
+
+// recreatedDestinationWarning appends a warning to warnings of a payment to a destination that was
+// merged or recreated recently: it may be owned by someone else than its previous generation.
+func (rh *RequestHandler) recreatedDestinationWarning(destination string, warnings []string, logger *log.Entry) []string {
+	recent, err := rh.Generations.Recent(destination)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error loading account generation")
+		return warnings
+	}
+	if recent == nil {
+		return warnings
+	}
+
+	logger.WithFields(log.Fields{"destination": destination, "generation": recent.Generation}).Warn("Payment to a recreated account")
+	if recent.MergedAt != nil {
+		return append(warnings, "Destination account was merged, the payment creates it again")
+	}
+	return append(warnings, fmt.Sprintf("Destination account was merged and created again at %s", recent.RecreatedAt))
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/anomaly"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/generation"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// accountsHorizon returns accounts by ID with their sequence numbers, other accounts are not found
+type accountsHorizon struct {
+	*mocks.MockHorizon
+	sequences map[string]string
+}
+
+func (h *accountsHorizon) LoadAccount(accountID string) (horizon.AccountResponse, error) {
+	sequence, ok := h.sequences[accountID]
+	if !ok {
+		return horizon.AccountResponse{}, &horizon.StatusError{StatusCode: http.StatusNotFound, Body: []byte("Resource Missing")}
+	}
+	return horizon.AccountResponse{AccountID: accountID, SequenceNumber: sequence}, nil
+}
+
+func (h *accountsHorizon) LoadAccountCtx(ctx context.Context, accountID string) (horizon.AccountResponse, error) {
+	return h.LoadAccount(accountID)
+}
+
+func TestRequestHandlerPaymentRecreatedAccounts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-generation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+	repository := db.NewRepository(driver)
+	entityManager := db.NewEntityManager(driver)
+
+	source := "GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ"
+	destination := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	mockHorizon := &accountsHorizon{
+		MockHorizon: new(mocks.MockHorizon),
+		sequences:   map[string]string{source: "429496729600", destination: "429496729700"},
+	}
+	ledger := uint64(1988727)
+	var submitted xdr.TransactionEnvelope
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		require.NoError(t, xdr.SafeUnmarshalBase64(args.String(0), &submitted))
+	}).Return(horizon.SubmitTransactionResponse{Hash: "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", Ledger: &ledger}, nil)
+
+	generations := generation.NewTracker(repository, entityManager, time.Now)
+	var recreated []string
+	generations.Recreated = []func(string){func(accountID string) { recreated = append(recreated, accountID) }}
+	requestHandler := newTestRequestHandler(mockHorizon)
+	requestHandler.Driver = driver
+	requestHandler.Repository = repository
+	requestHandler.EntityManager = entityManager
+	requestHandler.Anomalies = anomaly.NewDetector(anomaly.Settings{Policy: anomaly.PolicyLog}, repository, entityManager)
+	requestHandler.Generations = generations
+
+	pay := func() map[string]interface{} {
+		params := url.Values{"destination": {destination}, "amount": {"20"}}
+		status, response := postPayment(t, &requestHandler, params)
+		require.Equal(t, http.StatusOK, status, response)
+		return response
+	}
+	// statsCounts returns numbers of payments to the destination by generation
+	statsCounts := func() map[int64]int64 {
+		stats, err := repository.GetCounterpartyStats(destination)
+		require.NoError(t, err)
+		counts := map[int64]int64{}
+		for _, s := range stats {
+			counts[s.Generation] += s.Count
+		}
+		return counts
+	}
+	storedGeneration := func(accountID string) int64 {
+		g, err := repository.GetAccountGeneration(accountID)
+		require.NoError(t, err)
+		require.NotNil(t, g)
+		return g.Generation
+	}
+
+	t.Run("payment to an account seen before", func(t *testing.T) {
+		response := pay()
+		assert.Nil(t, response["warnings"])
+		assert.Equal(t, map[int64]int64{1: 1}, statsCounts())
+	})
+
+	t.Run("payment to a merged destination", func(t *testing.T) {
+		delete(mockHorizon.sequences, destination)
+		response := pay()
+		assert.Equal(t, []interface{}{"Destination account was merged, the payment creates it again"}, response["warnings"])
+		assert.NotNil(t, submitted.Tx.Operations[0].Body.CreateAccountOp)
+		// The payment creating the account is counted in its next generation
+		assert.Equal(t, map[int64]int64{1: 1, 2: 1}, statsCounts())
+	})
+
+	t.Run("payment to a recreated destination", func(t *testing.T) {
+		mockHorizon.sequences[destination] = "858993459200"
+		response := pay()
+		require.Len(t, response["warnings"], 1)
+		assert.Contains(t, response["warnings"].([]interface{})[0], "Destination account was merged and created again at")
+		assert.Equal(t, int64(2), storedGeneration(destination))
+		assert.Equal(t, map[int64]int64{1: 1, 2: 2}, statsCounts())
+		assert.Equal(t, []string{destination}, recreated)
+	})
+
+	t.Run("payment from a recreated source", func(t *testing.T) {
+		// Merged without an observed merge and created again in an earlier ledger
+		mockHorizon.sequences[source] = "4294967296"
+		pay()
+		assert.Equal(t, int64(2), storedGeneration(source))
+		assert.Equal(t, xdr.SequenceNumber(4294967297), submitted.Tx.SeqNum)
+		assert.Equal(t, []string{destination, source}, recreated)
+	})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/handoff"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/ratelimit"
+	"github.com/stellar/gateway/server"
+	"github.com/zenazn/goji/web"
+)
+
+// paymentWithHandoff processes a payment in the background and writes its response when it's
+// finished within wait. Otherwise the payment is handed off: 202 Accepted is written with its ID
+// and the payment continues, its response is returned by /payment/{id} when it's finished.
+func (rh *RequestHandler) paymentWithHandoff(
+	w http.ResponseWriter,
+	r *http.Request,
+	request *bridge.PaymentRequest,
+	warnings []string,
+	wait time.Duration,
+	logger *log.Entry,
+) {
+	payment, err := rh.Handoffs.Start(rh.inflightPayment)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error starting payment")
+		rh.inflightPayment.Done()
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	go rh.processInBackground(payment, r, request, warnings, logger)
+
+	// A payment waiting for the submission rate limit past the deadline is handed off right away
+	handoffAfter := wait
+	if rh.SubmissionRate.Delay(ratelimit.High) > wait {
+		handoffAfter = 0
+	}
+	timer := time.NewTimer(handoffAfter)
+	defer timer.Stop()
+	select {
+	case <-payment.Done():
+	case <-timer.C:
+		// The payment can finish at the same time, HandOff returns false when it did
+		if payment.HandOff() {
+			rh.inflightPayment.SetKind(inflight.KindAsync)
+			logger.WithFields(log.Fields{"payment_id": payment.ID, "max_wait": wait.Seconds()}).Info("Payment handed off")
+			server.Write(w, handoffResponse(payment))
+			return
+		}
+	}
+	writeResult(w, payment.Result())
+}
+
+// processInBackground processes a payment which request can be answered before it's finished,
+// the response is stored in payment
+func (rh *RequestHandler) processInBackground(
+	payment *handoff.Payment,
+	r *http.Request,
+	request *bridge.PaymentRequest,
+	warnings []string,
+	logger *log.Entry,
+) {
+	response := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	defer func() {
+		// net/http recovers panics of handlers only, a panic here would stop the server
+		if recovered := recover(); recovered != nil {
+			logger.WithFields(log.Fields{"panic": recovered}).Error("Payment panicked")
+			response = &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+			server.Write(response, protocols.InternalServerError)
+		}
+		payment.Finish(handoff.Result{Status: response.status, Header: response.header, Body: response.body.Bytes()})
+		rh.inflightPayment.Done()
+	}()
+	rh.processPayment(response, r, request, warnings, logger)
+}
+
+// PaymentResult implements /payment/{id} endpoint returning the response of a handed off, queued
+// or async payment
+func (rh *RequestHandler) PaymentResult(c web.C, w http.ResponseWriter, r *http.Request) {
+	var payment *handoff.Payment
+	ok := false
+	if rh.Handoffs != nil {
+		payment, ok = rh.Handoffs.Get(c.URLParams["id"])
+	}
+	if !ok {
+		logger := requestLog(r)
+		if !rh.queuedPaymentResult(w, c.URLParams["id"], logger) && !rh.asyncPaymentResult(w, c.URLParams["id"], logger) {
+			server.Write(w, bridge.PaymentNotFound)
+		}
+		return
+	}
+
+	result := payment.Result()
+	if result == nil {
+		server.Write(w, handoffResponse(payment))
+		return
+	}
+	writeResult(w, result)
+}
+
+func handoffResponse(payment *handoff.Payment) bridge.PaymentHandoffResponse {
+	response := bridge.PaymentHandoffResponse{ID: payment.ID, Status: bridge.PaymentHandoffStatusPending}
+	if status, ok := payment.Tracked.Status(); ok {
+		response.Stage = status.Stage
+		response.Hash = status.Hash
+	}
+	return response
+}
+
+func writeResult(w http.ResponseWriter, result *handoff.Result) {
+	for name, values := range result.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(result.Status)
+	w.Write(result.Body)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/handoff"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zenazn/goji/web"
+)
+
+func TestRequestHandlerPaymentHandoff(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	requestHandler := RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Accounts: config.Accounts{
+				BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK",
+			},
+		},
+		Horizon:  mockHorizon,
+		Inflight: inflight.NewRegistry(4, time.Now),
+		Handoffs: handoff.NewStore(4),
+	}
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+
+	ledger := uint64(1988727)
+	// slowHorizon makes the next submission wait until release is closed
+	slowHorizon := func() (submitting, release chan struct{}) {
+		submitting = make(chan struct{})
+		release = make(chan struct{})
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(mock.Arguments) {
+			close(submitting)
+			<-release
+		}).Return(horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil).Once()
+		return
+	}
+
+	pay := func(maxWait string, header http.Header) *httptest.ResponseRecorder {
+		params := url.Values{
+			"destination": {"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+			"amount":      {"20"},
+		}
+		if maxWait != "" {
+			params.Set("max_wait", maxWait)
+		}
+		request := newPaymentRequest(t, params)
+		for name, values := range header {
+			request.Header[name] = values
+		}
+		response := httptest.NewRecorder()
+		requestHandler.Payment(response, request)
+		return response
+	}
+
+	result := func(id string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		c := web.C{URLParams: map[string]string{"id": id}}
+		requestHandler.PaymentResult(c, response, httptest.NewRequest(http.MethodGet, "/payment/"+id, nil))
+		return response
+	}
+
+	t.Run("handed off after max_wait", func(t *testing.T) {
+		_, release := slowHorizon()
+		response := pay("0.05", nil)
+		require.Equal(t, http.StatusAccepted, response.Code)
+
+		var accepted bridge.PaymentHandoffResponse
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &accepted))
+		assert.Len(t, accepted.ID, 32)
+		assert.Equal(t, bridge.PaymentHandoffStatusPending, accepted.Status)
+		assert.Equal(t, inflight.StageAwaitingConfirmation, accepted.Stage)
+		assert.Len(t, accepted.Hash, 64)
+
+		snapshot := requestHandler.Inflight.Snapshot()
+		require.Len(t, snapshot.Payments, 1)
+		assert.Equal(t, inflight.KindAsync, snapshot.Payments[0].Kind)
+		assert.Equal(t, accepted.Hash, snapshot.Payments[0].Hash)
+
+		pending := result(accepted.ID)
+		assert.Equal(t, http.StatusAccepted, pending.Code)
+		assert.Equal(t, response.Body.String(), pending.Body.String())
+
+		close(release)
+		payment, ok := requestHandler.Handoffs.Get(accepted.ID)
+		require.True(t, ok)
+		select {
+		case <-payment.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("Payment has not finished")
+		}
+
+		finished := result(accepted.ID)
+		assert.Equal(t, http.StatusOK, finished.Code)
+		assert.Contains(t, finished.Body.String(), `"hash": "6a3b"`)
+		// The inflight entry is released right after the result is stored
+		for i := 0; i < 1000 && len(requestHandler.Inflight.Snapshot().Payments) > 0; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		assert.Empty(t, requestHandler.Inflight.Snapshot().Payments)
+	})
+
+	t.Run("handed off before Request-Timeout", func(t *testing.T) {
+		_, release := slowHorizon()
+		defer close(release)
+		response := pay("30", http.Header{handoff.TimeoutHeader: {"0.6"}})
+		assert.Equal(t, http.StatusAccepted, response.Code)
+	})
+
+	t.Run("handed off when submission rate limit delays it past max_wait", func(t *testing.T) {
+		requestHandler.SubmissionRate = ratelimit.NewLimiter(ratelimit.Settings{Rate: 0.1})
+		defer func() { requestHandler.SubmissionRate = nil }()
+		// The next token is added in 10 seconds
+		requestHandler.SubmissionRate.Wait(ratelimit.Low)
+
+		_, release := slowHorizon()
+		defer close(release)
+		started := time.Now()
+		response := pay("5", nil)
+		assert.Equal(t, http.StatusAccepted, response.Code)
+		assert.True(t, time.Since(started) < time.Second)
+	})
+
+	t.Run("finished within max_wait", func(t *testing.T) {
+		submitting, release := slowHorizon()
+		go func() {
+			<-submitting
+			close(release)
+		}()
+		response := pay("5", nil)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"hash": "6a3b"`)
+	})
+
+	t.Run("invalid max_wait", func(t *testing.T) {
+		response := pay("61", nil)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Contains(t, response.Body.String(), `"name": "max_wait"`)
+	})
+
+	t.Run("unknown payment", func(t *testing.T) {
+		response := result("0123")
+		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Contains(t, response.Body.String(), "payment_not_found")
+	})
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/handoff"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/xdr"
+)
+
+// idempotentPayment sends a payment with `id` param at most once. The id is stored with the hash of
+// the transaction before it's submitted and completed with the response, a request repeated with
+// the same id returns the stored response. When the response is not stored (the server stopped or
+// the result was unknown) the stored transaction is loaded from Horizon or submitted again, a new
+// transaction is never built for the id.
+func (rh *RequestHandler) idempotentPayment(
+	w http.ResponseWriter,
+	r *http.Request,
+	request *bridge.PaymentRequest,
+	warnings []string,
+	logger *log.Entry,
+) {
+	logger = logger.WithField("payment_id", request.ID)
+	if rh.usesCompliance(request) {
+		server.Write(w, protocols.NewInvalidParameterError("id", request.ID, "id cannot be used with compliance protocol."))
+		return
+	}
+	if unsignedPayment(request) {
+		server.Write(w, protocols.NewInvalidParameterError("id", request.ID, "id cannot be used with unsigned payments of a public key source."))
+		return
+	}
+
+	hash := request.Hash()
+	payment, err := rh.Repository.GetIdempotentPaymentByPaymentID(request.ID)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error loading idempotent payment")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	if payment != nil && payment.RequestHash != hash {
+		logger.Warn("Payment id sent again with different params")
+		server.Write(w, bridge.PaymentDuplicateID)
+		return
+	}
+
+	if payment != nil && payment.IsCompleted() {
+		logger.WithFields(log.Fields{"hash": payment.TransactionID}).Info("Returning stored response of payment")
+		writeResult(w, storedResult(payment))
+		return
+	}
+
+	response := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	handler := *rh
+	if payment == nil {
+		handler.idempotent = &idempotentSend{payment: &entities.IdempotentPayment{
+			PaymentID:   request.ID,
+			RequestHash: hash,
+			CreatedAt:   utc.Now(),
+		}}
+		handler.sendPayment(response, r, request, warnings, logger)
+	} else {
+		logger.WithFields(log.Fields{"hash": payment.TransactionID}).Info("Resuming payment without stored response")
+		handler.idempotent = &idempotentSend{payment: payment}
+		handler.resumePayment(response, r, request, warnings, logger)
+	}
+
+	if handler.idempotent.conflict {
+		rh.writeConcurrentPayment(w, request, hash, response, logger)
+		return
+	}
+
+	// Responses without a transaction result are not stored, the payment is resumed when the
+	// request is repeated
+	if handler.idempotent.resulted {
+		payment = handler.idempotent.payment
+		payment.Complete(response.status, response.body.Bytes())
+		if err := rh.EntityManager.Persist(payment); err != nil {
+			logger.WithFields(log.Fields{"err": err}).Error("Error storing response of idempotent payment")
+		}
+	}
+
+	writeResult(w, &handoff.Result{Status: response.status, Header: response.header, Body: response.body.Bytes()})
+}
+
+// writeConcurrentPayment writes the response of a payment whose id was stored by a concurrent
+// request while it was sent. The stored response is returned when the other request has completed,
+// PaymentInProgress otherwise. response is the response of the failed store, returned when the
+// stored payment cannot be loaded.
+func (rh *RequestHandler) writeConcurrentPayment(
+	w http.ResponseWriter,
+	request *bridge.PaymentRequest,
+	hash string,
+	response *bufferedResponse,
+	logger *log.Entry,
+) {
+	payment, err := rh.Repository.GetIdempotentPaymentByPaymentID(request.ID)
+	switch {
+	case err != nil || payment == nil:
+		logger.WithFields(log.Fields{"err": err}).Error("Error loading idempotent payment stored concurrently")
+		writeResult(w, &handoff.Result{Status: response.status, Header: response.header, Body: response.body.Bytes()})
+	case payment.RequestHash != hash:
+		logger.Warn("Payment id sent concurrently with different params")
+		server.Write(w, bridge.PaymentDuplicateID)
+	case payment.IsCompleted():
+		logger.WithFields(log.Fields{"hash": payment.TransactionID}).Info("Returning stored response of concurrent payment")
+		writeResult(w, storedResult(payment))
+	default:
+		logger.WithFields(log.Fields{"hash": payment.TransactionID}).Info("Payment id is sent by a concurrent request")
+		server.Write(w, bridge.PaymentInProgress)
+	}
+}
+
+// resumePayment writes the response of a stored payment transaction which response was not
+// stored. The transaction is loaded from Horizon when it's in a ledger, otherwise its envelope is
+// submitted again: its sequence number makes sure it's applied at most once. The transaction is
+// stored after the payment, a payment without it was never submitted and it's sent again.
+func (rh *RequestHandler) resumePayment(
+	w http.ResponseWriter,
+	r *http.Request,
+	request *bridge.PaymentRequest,
+	warnings []string,
+	logger *log.Entry,
+) {
+	payment := rh.idempotent.payment
+	rh.inflightPayment.SetHash(payment.TransactionID)
+	sentTransaction, err := rh.Repository.GetSentTransactionByHash(payment.TransactionID)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err, "hash": payment.TransactionID}).Error("Cannot load sent transaction of idempotent payment")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	if sentTransaction == nil {
+		logger.WithFields(log.Fields{"hash": payment.TransactionID}).Warn("Transaction of idempotent payment was not stored, sending payment again")
+		rh.sendPayment(w, r, request, warnings, logger)
+		return
+	}
+
+	rh.inflightPayment.SetStage(inflight.StageAwaitingConfirmation)
+	var submitResponse horizon.SubmitTransactionResponse
+	transaction, err := rh.Horizon.LoadTransaction(payment.TransactionID)
+	if statusErr, ok := err.(*horizon.StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+		submitResponse, err = rh.Horizon.SubmitTransaction(sentTransaction.EnvelopeXdr)
+	} else if err == nil {
+		submitResponse = transaction.ToSubmitTransactionResponse()
+	}
+
+	rh.idempotent.resulted = err == nil
+	if err == nil && sentTransaction.Status == entities.SentTransactionStatusSending {
+		if submitResponse.Ledger != nil {
+			sentTransaction.MarkSucceeded(*submitResponse.Ledger, submitResponse.ResultXdr)
+		} else if submitResponse.Extras != nil {
+			sentTransaction.MarkFailed(submitResponse.Extras.ResultXdr)
+		}
+		if err := rh.EntityManager.Persist(sentTransaction); err != nil {
+			logger.WithFields(log.Fields{"err": err, "hash": sentTransaction.TransactionID}).Error("Error updating sent transaction")
+		}
+		events.RecordTransaction(rh.Events, sentTransaction)
+	}
+
+	// Time bounds are hashed so they are the bounds of the sent transaction
+	submitResponse.TimeBounds = request.TimeBounds()
+	if submitResponse.Hash == "" {
+		submitResponse.Hash = payment.TransactionID
+	}
+
+	if request.Type == bridge.PaymentTypeMultiAsset {
+		results := make([]bridge.PaymentAssetResult, len(request.Assets))
+		for i, asset := range request.Assets {
+			results[i] = bridge.PaymentAssetResult{PaymentAsset: asset, Status: bridge.PaymentAssetStatusNotSubmitted}
+		}
+		rh.writeMultiAssetSubmitResponse(w, results, submitResponse, err, logger)
+		return
+	}
+
+	if request.Type == bridge.PaymentTypeBatch {
+		rh.writeBatchSubmitResponse(w, batchResults(request.Payments, sentTransaction.EnvelopeXdr), submitResponse, err, logger)
+		return
+	}
+	operationIndex := paymentOperationIndex(sentTransaction.EnvelopeXdr)
+	rh.writeSubmitResponse(w, submitResponse, err, operationIndex, envelopeOperationType(sentTransaction.EnvelopeXdr, operationIndex), warnings, logger)
+}
+
+// paymentOperationIndex returns the index of the payment operation in a payment transaction
+// envelope, change_trust is prepended by auto_trust
+func paymentOperationIndex(envelopeXdr string) int {
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(envelopeXdr, &envelope); err != nil {
+		return 0
+	}
+	operations := envelope.Tx.Operations
+	if len(operations) > 1 && operations[0].Body.Type == xdr.OperationTypeChangeTrust {
+		return 1
+	}
+	return 0
+}
+
+// envelopeOperationType returns the operation_type of the operation at index of a sent transaction
+func envelopeOperationType(envelopeXdr string, index int) string {
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(envelopeXdr, &envelope); err != nil {
+		return ""
+	}
+	return transactionOperationType(&envelope.Tx, index)
+}
+
+// idempotentSend is a payment with `id` param sent or resumed by idempotentPayment
+type idempotentSend struct {
+	payment *entities.IdempotentPayment
+	// resulted is true when Horizon returned the result of the transaction
+	resulted bool
+	// conflict is true when the payment could not be stored because a concurrent request with the
+	// same id stored it first
+	conflict bool
+}
+
+// storedResult returns the stored response of a completed payment
+func storedResult(payment *entities.IdempotentPayment) *handoff.Result {
+	return &handoff.Result{Status: *payment.ResponseStatus, Body: []byte(*payment.Response)}
+}
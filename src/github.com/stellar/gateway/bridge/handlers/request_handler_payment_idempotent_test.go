@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentIdempotent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-idempotent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+
+	mockHorizon := new(mocks.MockHorizon)
+	repository := db.NewRepository(driver)
+	requestHandler := newTestRequestHandler(mockHorizon)
+	requestHandler.Driver = driver
+	requestHandler.Repository = repository
+	requestHandler.EntityManager = db.NewEntityManager(driver)
+
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"}), nil)
+	ledger := uint64(1988727)
+	lost := errors.New("connection reset by peer")
+	notFound := &horizon.StatusError{StatusCode: http.StatusNotFound}
+
+	pay := func(params url.Values) (int, map[string]interface{}) {
+		return postPayment(t, &requestHandler, params)
+	}
+	paymentParams := func(id string) url.Values {
+		return usdPaymentParams("20", url.Values{"id": {id}})
+	}
+	getPayment := func(id string) *entities.IdempotentPayment {
+		payment, err := repository.GetIdempotentPaymentByPaymentID(id)
+		require.NoError(t, err)
+		require.NotNil(t, payment)
+		return payment
+	}
+	getTransaction := func(hash string) *entities.SentTransaction {
+		transaction, err := repository.GetSentTransactionByHash(hash)
+		require.NoError(t, err)
+		require.NotNil(t, transaction)
+		return transaction
+	}
+
+	t.Run("payment applied before the response was lost is not sent again", func(t *testing.T) {
+		var envelope string
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{}, lost).
+			Run(func(args mock.Arguments) { envelope = args.String(0) }).Once()
+
+		statusCode, _ := pay(paymentParams("order-1"))
+		assert.Equal(t, http.StatusInternalServerError, statusCode)
+
+		payment := getPayment("order-1")
+		assert.False(t, payment.IsCompleted())
+		transaction := getTransaction(payment.TransactionID)
+		assert.Equal(t, envelope, transaction.EnvelopeXdr)
+		assert.Equal(t, entities.SentTransactionStatusSending, transaction.Status)
+
+		mockHorizon.On("LoadTransaction", payment.TransactionID).Return(horizon.TransactionResponse{
+			Hash:        payment.TransactionID,
+			Ledger:      ledger,
+			EnvelopeXdr: envelope,
+		}, nil).Once()
+
+		statusCode, response := pay(paymentParams("order-1"))
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, payment.TransactionID, response["hash"])
+		assert.Equal(t, float64(ledger), response["ledger"])
+		assert.True(t, getPayment("order-1").IsCompleted())
+		assert.Equal(t, entities.SentTransactionStatusSuccess, getTransaction(payment.TransactionID).Status)
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 1)
+	})
+
+	t.Run("completed payment returns the stored response", func(t *testing.T) {
+		statusCode, response := pay(paymentParams("order-1"))
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, getPayment("order-1").TransactionID, response["hash"])
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 1)
+		mockHorizon.AssertNumberOfCalls(t, "LoadTransaction", 1)
+	})
+
+	t.Run("id sent with different params is rejected", func(t *testing.T) {
+		params := paymentParams("order-1")
+		params.Set("amount", "21")
+		statusCode, response := pay(params)
+		assert.Equal(t, http.StatusConflict, statusCode)
+		assert.Equal(t, "payment_duplicate_id", response["code"])
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 1)
+	})
+
+	t.Run("payment not applied before the server stopped is submitted again", func(t *testing.T) {
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{}, lost).Once()
+		statusCode, _ := pay(paymentParams("order-2"))
+		assert.Equal(t, http.StatusInternalServerError, statusCode)
+
+		payment := getPayment("order-2")
+		envelope := getTransaction(payment.TransactionID).EnvelopeXdr
+		mockHorizon.On("LoadTransaction", payment.TransactionID).Return(horizon.TransactionResponse{}, notFound).Once()
+		mockHorizon.On("SubmitTransaction", envelope).Return(
+			horizon.SubmitTransactionResponse{Hash: payment.TransactionID, Ledger: &ledger}, nil,
+		).Once()
+
+		statusCode, response := pay(paymentParams("order-2"))
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, payment.TransactionID, response["hash"])
+		assert.True(t, getPayment("order-2").IsCompleted())
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 3)
+	})
+
+	t.Run("failed payment response is stored", func(t *testing.T) {
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{
+			Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB////+gAAAAA="},
+		}, nil).Once()
+
+		for i := 0; i < 2; i++ {
+			statusCode, response := pay(paymentParams("order-3"))
+			assert.Equal(t, http.StatusBadRequest, statusCode)
+			assert.Equal(t, "payment_no_trust", response["code"])
+		}
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 4)
+	})
+
+	t.Run("payment rejected before submission is not stored", func(t *testing.T) {
+		params := paymentParams("order-4")
+		params.Set("memo_type", "id")
+		params.Set("memo", "not-a-number")
+		statusCode, _ := pay(params)
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+
+		payment, err := repository.GetIdempotentPaymentByPaymentID("order-4")
+		require.NoError(t, err)
+		assert.Nil(t, payment)
+	})
+
+	t.Run("payment stored without its transaction is sent again", func(t *testing.T) {
+		// a different amount than other payments, transactions of equal payments have the same hash
+		params := paymentParams("order-5")
+		params.Set("amount", "21")
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{}, lost).Once()
+		statusCode, _ := pay(params)
+		assert.Equal(t, http.StatusInternalServerError, statusCode)
+		// The server stopped before the transaction was stored
+		require.NoError(t, driver.Delete(getTransaction(getPayment("order-5").TransactionID)))
+
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{Ledger: &ledger}, nil).Once()
+		statusCode, response := pay(params)
+		assert.Equal(t, http.StatusOK, statusCode)
+		payment := getPayment("order-5")
+		assert.True(t, payment.IsCompleted())
+		assert.Equal(t, payment.TransactionID, response["hash"])
+		getTransaction(payment.TransactionID)
+	})
+
+	t.Run("payment stored by a concurrent request is not sent", func(t *testing.T) {
+		// concurrently stores the payment while the request loads the source account
+		concurrently := func(id string, complete bool) *RequestHandler {
+			// the hash doesn't depend on the id
+			requestHash := getPayment("order-1").RequestHash
+			concurrentHorizon := new(mocks.MockHorizon)
+			concurrentHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"}), nil).Run(func(mock.Arguments) {
+				if payment, _ := repository.GetIdempotentPaymentByPaymentID(id); payment != nil {
+					return
+				}
+				payment := &entities.IdempotentPayment{PaymentID: id, RequestHash: requestHash, TransactionID: "stored", CreatedAt: utc.Now()}
+				if complete {
+					payment.Complete(http.StatusOK, []byte(`{"hash": "stored"}`))
+				}
+				require.NoError(t, requestHandler.EntityManager.Persist(payment))
+			})
+			handler := requestHandler
+			handler.Horizon = concurrentHorizon
+			return &handler
+		}
+		send := func(handler *RequestHandler, id string) (int, map[string]interface{}) {
+			return postPayment(t, handler, paymentParams(id))
+		}
+
+		statusCode, response := send(concurrently("order-6", false), "order-6")
+		assert.Equal(t, http.StatusConflict, statusCode)
+		assert.Equal(t, "payment_in_progress", response["code"])
+
+		statusCode, response = send(concurrently("order-7", true), "order-7")
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, "stored", response["hash"])
+	})
+
+	t.Run("too long id is invalid", func(t *testing.T) {
+		statusCode, response := pay(paymentParams(strings.Repeat("a", 65)))
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "invalid_parameter", response["code"])
+	})
+}
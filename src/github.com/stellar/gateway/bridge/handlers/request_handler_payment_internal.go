@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+)
+
+// resolveDestinationAlias replaces a config key of an account of the `accounts` group sent as
+// the destination (ex. `receiving_account_id`) with the account ID, so it's sent without
+// federation
+func (rh *RequestHandler) resolveDestinationAlias(request *bridge.PaymentRequest) {
+	if account := rh.Config.Accounts.AliasAccount(request.Destination); account != "" {
+		request.Destination = account
+	}
+}
+
+// internalTransfer returns a copy of the handler sending an internal transfer when the resolved
+// destination is an account of the `accounts` group, the same handler otherwise. Internal
+// transfers of other roles than the operator are rejected with `internal_transfers.operator_only`.
+func (rh *RequestHandler) internalTransfer(r *http.Request, accountID string, logger *log.Entry) (*RequestHandler, *protocols.ErrorResponse) {
+	if !rh.Config.Accounts.HasAccount(accountID) {
+		return rh, nil
+	}
+
+	if rh.Config.InternalTransfers.OperatorOnly && server.RequestRole(r) != server.RoleOperator {
+		return nil, protocols.NewInvalidParameterError("destination", accountID, "Only operator can send internal transfers.")
+	}
+
+	logger.WithFields(log.Fields{"destination": accountID}).Info("Sending internal transfer")
+	handler := *rh
+	handler.internal = true
+	return &handler, nil
+}
+
+// skipsCounterpartyChecks returns true when deposit requirements and anomaly detection are not
+// checked, for internal transfers with `internal_transfers.skip_checks`
+func (rh *RequestHandler) skipsCounterpartyChecks() bool {
+	return rh.internal && rh.Config.InternalTransfers.SkipChecks
+}
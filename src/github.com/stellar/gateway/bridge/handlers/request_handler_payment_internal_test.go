@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/anomaly"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/go/protocols/federation"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentInternalTransfer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-internal")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+	repository := db.NewRepository(driver)
+	entityManager := db.NewEntityManager(driver)
+
+	// Warm wallet of the bridge
+	receiving := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	detector := anomaly.NewDetector(anomaly.Settings{MinPayments: 10, MaxZScore: 4, Policy: anomaly.PolicyBlock}, repository, entityManager)
+	for i := 0; i < 20; i++ {
+		require.NoError(t, detector.Record(anomaly.Payment{
+			Destination: receiving,
+			AssetCode:   "USD",
+			AssetIssuer: issuer,
+			Amount:      xdr.Int64((18 + i%5) * 1e7),
+			Time:        time.Now(),
+		}))
+	}
+
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: issuer}), nil)
+	ledger := uint64(1988727)
+	var submitted xdr.TransactionEnvelope
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		require.NoError(t, xdr.SafeUnmarshalBase64(args.String(0), &submitted))
+	}).Return(horizon.SubmitTransactionResponse{Hash: "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", Ledger: &ledger}, nil)
+	mockFederationResolver := new(mocks.MockFederationResolver)
+	mockFederationResolver.On("LookupByAddress", "warm*bridge.example.com").Return(&federation.NameResponse{AccountID: receiving}, nil)
+
+	var requestHandler RequestHandler
+	reset := func(internal config.InternalTransfers) {
+		requestHandler = RequestHandler{
+			Config: &config.Config{
+				NetworkPassphrase: "Test SDF Network ; September 2015",
+				Accounts: config.Accounts{
+					BaseSeed:           "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK",
+					ReceivingAccountID: receiving,
+				},
+				OperatorAPIKey:    "operator-api-key-1234",
+				InternalTransfers: internal,
+			},
+			Horizon:            mockHorizon,
+			FederationResolver: mockFederationResolver,
+			Driver:             driver,
+			Repository:         repository,
+			EntityManager:      entityManager,
+			Anomalies:          detector,
+		}
+	}
+	pay := func(destination string, role server.Role) (int, map[string]interface{}) {
+		params := url.Values{
+			"destination":  {destination},
+			"amount":       {"5000"},
+			"asset_code":   {"USD"},
+			"asset_issuer": {issuer},
+		}
+		request := newPaymentRequest(t, params)
+		if role != "" {
+			request = server.WithRole(request, role)
+		}
+		return servePayment(&requestHandler, request)
+	}
+	lastTransaction := func() *entities.SentTransaction {
+		transactions, err := repository.GetSentTransactions(1, 1)
+		require.NoError(t, err)
+		require.Len(t, transactions, 1)
+		return transactions[0]
+	}
+	paymentCount := func() int64 {
+		stats, err := repository.GetCounterpartyStats(receiving)
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		return stats[0].Count
+	}
+
+	for _, destination := range []string{"receiving_account_id", receiving, "warm*bridge.example.com"} {
+		t.Run("checks are skipped for "+destination, func(t *testing.T) {
+			reset(config.InternalTransfers{SkipChecks: true})
+			statusCode, response := pay(destination, "")
+			require.Equal(t, http.StatusOK, statusCode, response)
+
+			assert.Equal(t, receiving, submitted.Tx.Operations[0].Body.PaymentOp.Destination.Address())
+			assert.True(t, lastTransaction().Internal)
+			assert.Nil(t, lastTransaction().Anomalies)
+			assert.Equal(t, int64(20), paymentCount(), "internal transfers are not added to counterparty statistics")
+		})
+	}
+
+	t.Run("internal transfers are checked without skip_checks", func(t *testing.T) {
+		reset(config.InternalTransfers{})
+		statusCode, response := pay("receiving_account_id", "")
+		assert.Equal(t, http.StatusForbidden, statusCode)
+		assert.Equal(t, "payment_anomaly_blocked", response["code"])
+	})
+
+	t.Run("internal transfers require operator", func(t *testing.T) {
+		reset(config.InternalTransfers{SkipChecks: true, OperatorOnly: true})
+		statusCode, response := pay("receiving_account_id", server.RoleClient)
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "invalid_parameter", response["code"])
+		assert.Equal(t, "destination", response["data"].(map[string]interface{})["name"])
+
+		statusCode, response = pay("receiving_account_id", server.RoleOperator)
+		assert.Equal(t, http.StatusOK, statusCode, response)
+		assert.True(t, lastTransaction().Internal)
+	})
+
+	t.Run("other destinations are not internal", func(t *testing.T) {
+		reset(config.InternalTransfers{SkipChecks: true, OperatorOnly: true})
+		requestHandler.Anomalies = &anomaly.Detector{}
+		statusCode, response := pay("GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET", server.RoleClient)
+		require.Equal(t, http.StatusOK, statusCode, response)
+		assert.False(t, lastTransaction().Internal)
+	})
+}
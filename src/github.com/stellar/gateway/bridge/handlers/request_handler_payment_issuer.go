@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/go/address"
+)
+
+// resolveAssetIssuers replaces asset_issuer and send_asset_issuer sent as federation addresses
+// (ex. `usd*bank.example.com`) with the account IDs returned by federation. Issuers sent as
+// account IDs are not changed.
+func (rh *RequestHandler) resolveAssetIssuers(request *bridge.PaymentRequest, logger *log.Entry) *protocols.ErrorResponse {
+	var errorResponse *protocols.ErrorResponse
+	request.AssetIssuer, errorResponse = rh.resolveAssetIssuer("asset_issuer", request.AssetIssuer, logger)
+	if errorResponse != nil {
+		return errorResponse
+	}
+	request.SendAssetIssuer, errorResponse = rh.resolveAssetIssuer("send_asset_issuer", request.SendAssetIssuer, logger)
+	return errorResponse
+}
+
+// resolveAssetIssuer returns the account ID of an issuer param, a federation response with a memo
+// is rejected because an asset is issued by an account, not by a memo of it
+func (rh *RequestHandler) resolveAssetIssuer(name, issuer string, logger *log.Entry) (string, *protocols.ErrorResponse) {
+	if _, _, err := address.Split(issuer); err != nil {
+		return issuer, nil
+	}
+
+	issuerObject, err := rh.FederationResolver.LookupByAddress(issuer)
+	if err != nil {
+		logger.WithFields(log.Fields{name: issuer, "err": err}).Print("Cannot resolve issuer address")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			return "", errorResponse
+		}
+		return "", bridge.NewPaymentInvalidIssuerError(name, issuer)
+	}
+
+	if issuerObject.MemoType != "" || issuerObject.Memo.String() != "" {
+		logger.WithFields(log.Fields{name: issuer, "memo_type": issuerObject.MemoType}).Print("Federation returned memo of issuer")
+		return "", bridge.NewPaymentInvalidIssuerError(name, issuer)
+	}
+
+	if !protocols.IsValidAccountID(issuerObject.AccountID) {
+		logger.WithFields(log.Fields{"AccountId": issuerObject.AccountID}).Print("Invalid AccountId of issuer")
+		return "", bridge.NewPaymentInvalidIssuerError(name, issuer)
+	}
+	return issuerObject.AccountID, nil
+}
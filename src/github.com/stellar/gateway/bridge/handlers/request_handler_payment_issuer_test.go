@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/breaker"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/go/protocols/federation"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerResolveAssetIssuer(t *testing.T) {
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	resolve := func(response *federation.NameResponse, err error) (string, *protocols.ErrorResponse) {
+		mockFederationResolver := new(mocks.MockFederationResolver)
+		mockFederationResolver.On("LookupByAddress", "usd*bank.example.com").Return(response, err)
+		requestHandler := RequestHandler{FederationResolver: mockFederationResolver}
+		return requestHandler.resolveAssetIssuer("asset_issuer", "usd*bank.example.com", log.NewEntry(log.StandardLogger()))
+	}
+
+	accountID, errorResponse := resolve(&federation.NameResponse{AccountID: issuer}, nil)
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, issuer, accountID)
+
+	invalid := bridge.NewPaymentInvalidIssuerError("asset_issuer", "usd*bank.example.com")
+	_, errorResponse = resolve(nil, errors.New("not found"))
+	assert.Equal(t, invalid, errorResponse)
+	_, errorResponse = resolve(&federation.NameResponse{AccountID: issuer, MemoType: "id", Memo: federation.Memo{Value: "1"}}, nil)
+	assert.Equal(t, invalid, errorResponse)
+	_, errorResponse = resolve(&federation.NameResponse{AccountID: "bank"}, nil)
+	assert.Equal(t, invalid, errorResponse)
+
+	_, errorResponse = resolve(nil, &breaker.OpenError{Dependency: "federation"})
+	assert.Equal(t, protocols.DependencyUnavailableError.Code, errorResponse.Code)
+
+	// Account IDs are not resolved
+	requestHandler := RequestHandler{FederationResolver: new(mocks.MockFederationResolver)}
+	accountID, errorResponse = requestHandler.resolveAssetIssuer("asset_issuer", issuer, log.NewEntry(log.StandardLogger()))
+	assert.Nil(t, errorResponse)
+	assert.Equal(t, issuer, accountID)
+}
+
+func TestRequestHandlerPaymentIssuerAddress(t *testing.T) {
+	destination := "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: issuer}), nil)
+	ledger := uint64(1988727)
+	var submitted xdr.TransactionEnvelope
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		require.NoError(t, xdr.SafeUnmarshalBase64(args.String(0), &submitted))
+	}).Return(horizon.SubmitTransactionResponse{Hash: "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", Ledger: &ledger}, nil)
+	mockFederationResolver := new(mocks.MockFederationResolver)
+	mockFederationResolver.On("LookupByAddress", "usd*bank.example.com").Return(&federation.NameResponse{AccountID: issuer}, nil)
+	mockFederationResolver.On("LookupByAddress", "deposit*bank.example.com").Return(&federation.NameResponse{AccountID: issuer, MemoType: "text", Memo: federation.Memo{Value: "usd"}}, nil)
+
+	requestHandler := newTestRequestHandler(mockHorizon)
+	requestHandler.FederationResolver = mockFederationResolver
+	pay := func(assetIssuer string) (int, map[string]interface{}) {
+		params := url.Values{
+			"destination":  {destination},
+			"amount":       {"20"},
+			"asset_code":   {"USD"},
+			"asset_issuer": {assetIssuer},
+		}
+		return postPayment(t, &requestHandler, params)
+	}
+
+	status, response := pay("usd*bank.example.com")
+	require.Equal(t, http.StatusOK, status, response)
+	asset := submitted.Tx.Operations[0].Body.PaymentOp.Asset
+	assert.Equal(t, issuer, asset.AlphaNum4.Issuer.Address())
+
+	status, response = pay("deposit*bank.example.com")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "payment_invalid_issuer", response["code"])
+	assert.Equal(t, "asset_issuer", response["data"].(map[string]interface{})["name"])
+	mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 1)
+
+	status, response = pay("bank")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalid_parameter", response["code"])
+}
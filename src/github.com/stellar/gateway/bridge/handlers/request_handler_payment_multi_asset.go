@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// multiAssetPayment sends all assets of a `type=multi_asset` payment to a single destination in
+// one transaction with a payment operation per asset. Every asset is checked against trustlines
+// and balances of the source and the destination before the transaction is submitted, nothing is
+// submitted when any asset cannot be sent. Results are reported per asset.
+func (rh *RequestHandler) multiAssetPayment(w http.ResponseWriter, request *bridge.PaymentRequest, logger *log.Entry) {
+	sourceKeypair, _ := keypair.Parse(request.Source)
+
+	rh.inflightPayment.SetStage(inflight.StageResolving)
+	destinationObject, errorResponse := rh.resolveDestination(request.Destination, logger)
+	if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	}
+
+	// Federation memo applies to the whole transaction
+	memo, errorResponse := paymentMemo(request, destinationObject, logger)
+	if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	}
+
+	for _, asset := range request.Assets {
+		errorResponse = rh.checkDeposit(request.Source, deposit{
+			Destination: request.Destination,
+			AccountID:   destinationObject.AccountID,
+			Amount:      asset.Amount,
+			AssetCode:   asset.Code,
+			AssetIssuer: asset.Issuer,
+			Memo:        memo,
+		}, logger)
+		if errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+	}
+
+	rh.inflightPayment.SetStage(inflight.StageLoadingAccount)
+	sourceAccount, err := rh.Horizon.LoadAccount(sourceKeypair.Address())
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot load source account")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+		server.Write(w, rh.withHorizonFailureID(bridge.PaymentSourceNotExist, horizon.FailureID(err)))
+		return
+	}
+
+	sequenceNumber, err := strconv.ParseUint(sourceAccount.SequenceNumber, 10, 64)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot convert SequenceNumber")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	// Accounts cannot be created by multi-asset payments, they need trustlines of sent assets
+	var destinationAccount *horizon.AccountResponse
+	accountResponse, err := rh.Horizon.LoadAccount(destinationObject.AccountID)
+	if statusErr, ok := err.(*horizon.StatusError); err != nil && (!ok || statusErr.StatusCode != http.StatusNotFound) {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot load destination account")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+		server.Write(w, rh.withHorizonFailureID(protocols.InternalServerError, horizon.FailureID(err)))
+		return
+	}
+	if err == nil {
+		destinationAccount = &accountResponse
+	}
+
+	results := make([]bridge.PaymentAssetResult, len(request.Assets))
+	invalid := false
+	for i, asset := range request.Assets {
+		results[i] = bridge.PaymentAssetResult{PaymentAsset: asset, Status: bridge.PaymentAssetStatusNotSubmitted}
+		results[i].Error = checkPaymentAsset(asset, sourceKeypair.Address(), sourceAccount, destinationObject.AccountID, destinationAccount)
+		if results[i].Error != nil {
+			results[i].Status = bridge.PaymentAssetStatusFailed
+			invalid = true
+		}
+	}
+	if invalid {
+		logger.WithFields(log.Fields{"results": results}).Info("Multi-asset payment cannot be sent")
+		server.Write(w, bridge.NewPaymentMultiAssetFailedError(results))
+		return
+	}
+
+	spec := txspec.TxSpec{
+		Source:   sourceKeypair.Address(),
+		Sequence: sequenceNumber + 1,
+		Memo:     memo,
+	}
+	for _, asset := range request.Assets {
+		spec.Operations = append(spec.Operations, txspec.OperationSpec{
+			Type:        txspec.Payment,
+			Destination: destinationObject.AccountID,
+			Asset:       txspec.Asset{Code: asset.Code, Issuer: asset.Issuer},
+			Amount:      asset.Amount,
+		})
+	}
+
+	spec.Fee = rh.paymentFee(request, len(spec.Operations))
+	spec.TimeBounds = request.TimeBounds()
+
+	lease, errorResponse := rh.leaseChannel(request, &spec, logger)
+	if errorResponse != nil {
+		server.Write(w, errorResponse)
+		return
+	}
+	defer lease.Release()
+
+	tx, err := rh.transactionBuilder().Build(spec)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Transaction builder error")
+		if err == txspec.ErrInvalidFee {
+			server.Write(w, bridge.NewPaymentInvalidFeeError(request.Fee, spec.MinFee()))
+			return
+		}
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	if request.DryRun {
+		rh.writeDryRunPayment(w, tx, paymentSigners(request, lease), destinationObject.AccountID, 0, nil, logger)
+		return
+	}
+
+	if unsignedPayment(request) {
+		rh.writeUnsignedPayment(w, tx, 0, nil, logger)
+		return
+	}
+
+	txeB64, err := submitter.SignEnvelope(tx, rh.Config.NetworkPassphrase, paymentSigners(request, lease)...)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot sign transaction envelope")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	submitResponse, err := rh.submitPayment(request, tx, txeB64, nil, logger)
+	lease.Submitted(submitResponse.Ledger != nil)
+	rh.resolveCallback(submitResponse, err, logger)
+	submitResponse.TimeBounds = spec.TimeBounds
+	rh.writeMultiAssetSubmitResponse(w, results, submitResponse, err, logger)
+}
+
+// writeMultiAssetSubmitResponse writes the response of a submitted multi-asset payment
+// transaction with a result of every asset
+func (rh *RequestHandler) writeMultiAssetSubmitResponse(
+	w http.ResponseWriter,
+	results []bridge.PaymentAssetResult,
+	submitResponse horizon.SubmitTransactionResponse,
+	err error,
+	logger *log.Entry,
+) {
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Error submitting transaction")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, withHash(errorResponse, submitResponse.Hash))
+			return
+		}
+		server.Write(w, withHash(rh.withHorizonFailureID(protocols.InternalServerError, horizon.FailureID(err)), submitResponse.Hash))
+		return
+	}
+
+	errorResponse := bridge.ErrorFromHorizonResponse(submitResponse)
+	if errorResponse != nil {
+		logger.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+
+		operationErrors := bridge.OperationErrorsFromHorizonResponse(submitResponse)
+		if len(operationErrors) != len(results) {
+			server.Write(w, withHash(withResultCodes(rh.withHorizonFailureID(errorResponse, submitResponse.FailureID), submitResponse), submitResponse.Hash))
+			return
+		}
+
+		// Transactions are atomic so assets that succeeded have not been sent either
+		for i := range results {
+			results[i].Status = bridge.PaymentAssetStatusFailed
+			results[i].Error = operationErrors[i]
+			if results[i].Error == nil {
+				results[i].Error = bridge.PaymentMultiAssetRolledBack
+			}
+		}
+		server.Write(w, withHash(withResultCodes(rh.withHorizonFailureID(bridge.NewPaymentMultiAssetFailedError(results), submitResponse.FailureID), submitResponse), submitResponse.Hash))
+		return
+	}
+
+	for i := range results {
+		results[i].Status = bridge.PaymentAssetStatusSuccess
+	}
+	server.Write(w, &bridge.MultiAssetPaymentResponse{
+		Hash:        submitResponse.Hash,
+		Ledger:      submitResponse.Ledger,
+		Results:     results,
+		TimeBounds:  submitResponse.TimeBounds,
+		ResultCodes: bridge.ResultCodesFromHorizonResponse(submitResponse),
+	})
+}
+
+// checkPaymentAsset returns an error when the source cannot send the asset or the destination
+// cannot receive it. destination is nil when the destination account does not exist.
+func checkPaymentAsset(
+	asset bridge.PaymentAsset,
+	sourceID string,
+	source horizon.AccountResponse,
+	destinationID string,
+	destination *horizon.AccountResponse,
+) *protocols.ErrorResponse {
+	if destination == nil {
+		return bridge.PaymentNoDestination
+	}
+
+	value, _ := protocols.ParsePositiveAmount(asset.Amount)
+	if asset.Code == "" {
+		if nativeBalance(source) < value {
+			return bridge.PaymentUnderfunded
+		}
+		return nil
+	}
+
+	// Issuers send and receive their assets without trustlines
+	if asset.Issuer != sourceID {
+		balance, ok := source.GetBalance(asset.Code, asset.Issuer)
+		if !ok {
+			return bridge.PaymentSrcNoTrust
+		}
+		if available, _ := amount.Parse(balance.Balance); available < value {
+			return bridge.PaymentUnderfunded
+		}
+	}
+	if asset.Issuer != destinationID {
+		if _, ok := destination.GetBalance(asset.Code, asset.Issuer); !ok {
+			return bridge.PaymentNoTrust
+		}
+	}
+	return nil
+}
+
+// nativeBalance returns XLM balance of an account in stroops, the reserve is checked by Horizon
+func nativeBalance(account horizon.AccountResponse) xdr.Int64 {
+	for _, balance := range account.Balances {
+		if balance.AssetType == "native" {
+			value, _ := amount.Parse(balance.Balance)
+			return value
+		}
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// findPaymentPath finds a path of a path payment sent without path params. Horizon finds paths
+// from assets of the source delivering the amount to the destination, the cheapest path from the
+// send asset is returned (the shorter one of paths with the same cost). PaymentNoPathFound error
+// is returned when there is no path or the cheapest one costs more than send_max.
+func (rh *RequestHandler) findPaymentPath(request *bridge.PaymentRequest, destinationAccountID string, logger *log.Entry) (*horizon.PathResponse, *protocols.ErrorResponse) {
+	sourceKeypair, _ := keypair.Parse(request.Source)
+	destinationAsset := protocols.Asset{Code: request.AssetCode, Issuer: request.AssetIssuer}
+	page, err := rh.Horizon.LoadPaths(sourceKeypair.Address(), destinationAccountID, destinationAsset.ToBaseAsset(), request.Amount)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot load paths")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			return nil, errorResponse
+		}
+		return nil, rh.withHorizonFailureID(protocols.InternalServerError, horizon.FailureID(err))
+	}
+
+	var cheapest *horizon.PathResponse
+	var cheapestAmount xdr.Int64
+	for i := range page.Embedded.Records {
+		record := &page.Embedded.Records[i]
+		if !pathFromAsset(record, request.SendAssetCode, request.SendAssetIssuer) {
+			continue
+		}
+		sourceAmount, err := amount.Parse(record.SourceAmount)
+		if err != nil {
+			logger.WithFields(log.Fields{"source_amount": record.SourceAmount}).Warn("Invalid source amount of path")
+			continue
+		}
+		if cheapest == nil || sourceAmount < cheapestAmount || (sourceAmount == cheapestAmount && len(record.Path) < len(cheapest.Path)) {
+			cheapest, cheapestAmount = record, sourceAmount
+		}
+	}
+
+	if cheapest == nil {
+		logger.WithFields(log.Fields{"paths": len(page.Embedded.Records)}).Warn("No path from send asset found")
+		return nil, bridge.PaymentNoPathFound
+	}
+	// Validated by request.Validate
+	sendMax, _ := protocols.ParsePositiveAmount(request.SendMax)
+	if cheapestAmount > sendMax {
+		logger.WithFields(log.Fields{"source_amount": cheapest.SourceAmount, "send_max": request.SendMax}).Warn("Cheapest path costs more than send_max")
+		return nil, bridge.NewPaymentNoPathFoundError(cheapest.SourceAmount)
+	}
+
+	logger.WithFields(log.Fields{"source_amount": cheapest.SourceAmount, "path": len(cheapest.Path)}).Info("Found path of path payment")
+	return cheapest, nil
+}
+
+// pathFromAsset returns true when a path starts with an asset, empty code and issuer is XLM
+func pathFromAsset(path *horizon.PathResponse, code, issuer string) bool {
+	if path.SourceAssetType == "native" {
+		return code == "" && issuer == ""
+	}
+	return path.SourceAssetCode == code && path.SourceAssetIssuer == issuer
+}
+
+// pathAssets returns intermediate assets of a path, XLM has empty code and issuer
+func pathAssets(path *horizon.PathResponse) []protocols.Asset {
+	assets := []protocols.Asset{}
+	for _, asset := range path.Path {
+		assets = append(assets, protocols.Asset{Code: asset.AssetCode, Issuer: asset.AssetIssuer})
+	}
+	return assets
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentPathFinding(t *testing.T) {
+	eurIssuer := "GAF3PBFQLH57KPECN4GRGHU5NUZ3XXKYYWLOTBIRJMBYHPUBWANIUCZU"
+	usdIssuer := "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"
+	destination := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	ledger := uint64(1988727)
+
+	var mockHorizon *mocks.MockHorizon
+	var submitted xdr.TransactionEnvelope
+	pay := func(sendMax string, records ...horizon.PathResponse) (int, map[string]interface{}) {
+		mockHorizon = new(mocks.MockHorizon)
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: usdIssuer}), nil)
+		var page horizon.PathsPage
+		page.Embedded.Records = records
+		mockHorizon.On(
+			"LoadPaths",
+			"GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW",
+			destination,
+			build.CreditAsset("USD", usdIssuer),
+			"20",
+		).Return(page, nil).Once()
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+			require.NoError(t, xdr.SafeUnmarshalBase64(args.String(0), &submitted))
+		}).Return(horizon.SubmitTransactionResponse{Hash: "6a0049b4", Ledger: &ledger}, nil)
+
+		requestHandler := RequestHandler{
+			Config: &config.Config{
+				NetworkPassphrase: "Test SDF Network ; September 2015",
+				Accounts:          config.Accounts{BaseSeed: "SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+			},
+			Horizon: mockHorizon,
+		}
+		params := url.Values{
+			"destination":       {destination},
+			"amount":            {"20"},
+			"asset_code":        {"USD"},
+			"asset_issuer":      {usdIssuer},
+			"send_max":          {sendMax},
+			"send_asset_code":   {"EUR"},
+			"send_asset_issuer": {eurIssuer},
+		}
+		return postPayment(t, &requestHandler, params)
+	}
+	path := func(sourceAmount string, native bool, assets ...horizon.PathAsset) horizon.PathResponse {
+		response := horizon.PathResponse{
+			SourceAssetType:        "credit_alphanum4",
+			SourceAssetCode:        "EUR",
+			SourceAssetIssuer:      eurIssuer,
+			SourceAmount:           sourceAmount,
+			DestinationAssetType:   "credit_alphanum4",
+			DestinationAssetCode:   "USD",
+			DestinationAssetIssuer: usdIssuer,
+			DestinationAmount:      "20.0000000",
+			Path:                   append([]horizon.PathAsset{}, assets...),
+		}
+		if native {
+			response.SourceAssetType, response.SourceAssetCode, response.SourceAssetIssuer = "native", "", ""
+		}
+		return response
+	}
+	xlm := horizon.PathAsset{AssetType: "native"}
+	btc := horizon.PathAsset{AssetType: "credit_alphanum4", AssetCode: "BTC", AssetIssuer: eurIssuer}
+
+	t.Run("cheapest path of the send asset", func(t *testing.T) {
+		status, response := pay("10",
+			path("9.8000000", false),
+			path("9.5000000", false, xlm, btc),
+			path("9.5000000", false, xlm),
+			path("4.0000000", true),
+		)
+		require.Equal(t, http.StatusOK, status, response)
+		assert.Equal(t, map[string]interface{}{
+			"source_asset_type":        "credit_alphanum4",
+			"source_asset_code":        "EUR",
+			"source_asset_issuer":      eurIssuer,
+			"source_amount":            "9.5000000",
+			"destination_asset_type":   "credit_alphanum4",
+			"destination_asset_code":   "USD",
+			"destination_asset_issuer": usdIssuer,
+			"destination_amount":       "20.0000000",
+			"path":                     []interface{}{map[string]interface{}{"asset_type": "native"}},
+		}, response["path"])
+
+		operation := submitted.Tx.Operations[0].Body.PathPaymentOp
+		require.NotNil(t, operation)
+		assert.Equal(t, xdr.Int64(100000000), operation.SendMax)
+		require.Len(t, operation.Path, 1)
+		assert.Equal(t, xdr.AssetTypeAssetTypeNative, operation.Path[0].Type)
+		mockHorizon.AssertExpectations(t)
+	})
+
+	t.Run("no path from the send asset", func(t *testing.T) {
+		status, response := pay("10", path("4.0000000", true))
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_no_path_found", response["code"])
+		assert.Nil(t, response["data"])
+		mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+
+	t.Run("cheapest path above send_max", func(t *testing.T) {
+		status, response := pay("9", path("9.8000000", false), path("9.5000000", false, xlm))
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_no_path_found", response["code"])
+		assert.Equal(t, map[string]interface{}{"source_amount": "9.5000000"}, response["data"])
+		mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+
+	t.Run("found path with a malformed asset code", func(t *testing.T) {
+		malformed := horizon.PathAsset{AssetType: "credit_alphanum4", AssetCode: "BT-C", AssetIssuer: eurIssuer}
+		status, response := pay("10", path("9.5000000", false, xlm, malformed))
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_malformed_asset_code", response["code"])
+		assert.Equal(t, map[string]interface{}{"name": "path[1][asset_code]", "operation_type": "path_payment"}, response["data"])
+		mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+}
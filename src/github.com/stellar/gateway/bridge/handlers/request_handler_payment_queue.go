@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/handoff"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/queue"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/keypair"
+)
+
+// queuedPaymentIDPrefix starts `id` params of queued payments, a payment sent again after the
+// server stopped during an attempt returns the response of the first attempt
+const queuedPaymentIDPrefix = "queue-"
+
+// queuePayment stores a validated payment with `queue=true` and writes 202 Accepted with its ID.
+// The payment is sent by the queue worker, its response is returned by /payment/{id}. Only
+// payments of configured sources can be queued: secrets of requests are never stored.
+func (rh *RequestHandler) queuePayment(w http.ResponseWriter, r *http.Request, request *bridge.PaymentRequest, logger *log.Entry) {
+	var reason string
+	sourceAlias := rh.Config.Accounts.SeedAlias(request.Source)
+	switch {
+	case rh.Config.Database.Type == "":
+		reason = "Payments cannot be queued without a database."
+	case unsignedPayment(request):
+		reason = "Unsigned payments of a public key source cannot be queued."
+	case sourceAlias == "":
+		reason = "Only payments of sources in the config file can be queued."
+	}
+	if reason != "" {
+		server.Write(w, protocols.NewInvalidParameterError("queue", "true", reason))
+		return
+	}
+
+	id, err := randomRequestID()
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error generating queued payment ID")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	payload, err := json.Marshal(bridge.NewPaymentPayload(request, sourceAlias))
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error encoding queued payment")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	payment := &entities.QueuedPayment{
+		PaymentID:     id,
+		Status:        entities.QueuedPaymentStatusQueued,
+		Payload:       string(payload),
+		Role:          string(server.RequestRole(r)),
+		CorrelationID: rh.correlationID,
+		QueuedAt:      utc.Now(),
+	}
+	err = rh.EntityManager.Persist(payment)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error saving queued payment")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	logger.WithFields(log.Fields{"payment_id": id}).Info("Payment queued")
+	rh.PaymentQueue.Wake()
+	server.Write(w, bridge.PaymentHandoffResponse{ID: id, Status: bridge.PaymentHandoffStatusQueued})
+}
+
+// SendQueuedPayment sends a queued payment, it implements queue.Sender. The payment is not sent
+// while Horizon is unreachable: the source account is loaded first and a 503 response is returned
+// when it cannot be loaded, so the payment stays queued.
+func (rh *RequestHandler) SendQueuedPayment(payment *entities.QueuedPayment) queue.Result {
+	logger := log.WithFields(log.Fields{"payment_id": payment.PaymentID, "correlation_id": payment.CorrelationID})
+	response := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+
+	var payload bridge.PaymentPayload
+	err := json.Unmarshal([]byte(payment.Payload), &payload)
+	if err != nil || payload.Version != bridge.PaymentPayloadVersion {
+		logger.WithFields(log.Fields{"err": err, "version": payload.Version}).Error("Cannot decode queued payment")
+		server.Write(response, bridge.RebuildUnsupportedVersion)
+		return queue.Result{Status: response.status, Body: response.body.Bytes()}
+	}
+	source := rh.Config.Accounts.AliasSeed(payload.SourceAlias)
+	if source == "" {
+		logger.WithFields(log.Fields{"source_alias": payload.SourceAlias}).Error("Source of queued payment is not configured")
+		server.Write(response, bridge.RebuildSourceNotConfigured)
+		return queue.Result{Status: response.status, Body: response.body.Bytes()}
+	}
+
+	// /payment reports an unreachable Horizon as a missing source account
+	sourceKeypair, _ := keypair.Parse(source)
+	_, err = rh.Horizon.LoadAccount(sourceKeypair.Address())
+	if statusErr, ok := err.(*horizon.StatusError); err != nil && (!ok || statusErr.StatusCode != http.StatusNotFound) {
+		errorResponse := dependencyError(err)
+		if errorResponse == nil {
+			errorResponse = protocols.NewDependencyUnavailableError("horizon")
+		}
+		logger.WithFields(log.Fields{"err": err}).Warn("Horizon is unreachable, payment stays queued")
+		server.Write(response, errorResponse)
+		return queue.Result{Status: response.status, Body: response.body.Bytes()}
+	}
+
+	values := payload.ToValues(source)
+	values.Set("id", queuedPaymentIDPrefix+payment.PaymentID)
+	r, err := http.NewRequest(http.MethodPost, "/payment", strings.NewReader(values.Encode()))
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error creating payment request")
+		server.Write(response, protocols.InternalServerError)
+		return queue.Result{Status: response.status, Body: response.body.Bytes()}
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if payment.CorrelationID != "" {
+		r.Header.Set(server.CorrelationIDHeader, payment.CorrelationID)
+	}
+	// Operator-only params (ex. skip_slippage_check) were checked with the role of the request
+	r = server.WithRole(r, server.Role(payment.Role))
+
+	logger.WithFields(log.Fields{"attempts": payment.Attempts}).Info("Sending queued payment")
+	rh.Payment(response, r)
+	return queue.Result{Status: response.status, Body: response.body.Bytes()}
+}
+
+// queuedPaymentResult writes the response of a queued payment, false when id is not a queued
+// payment
+func (rh *RequestHandler) queuedPaymentResult(w http.ResponseWriter, id string, logger *log.Entry) bool {
+	if rh.Config.Database.Type == "" {
+		return false
+	}
+	payment, err := rh.Repository.GetQueuedPaymentByPaymentID(id)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error loading queued payment")
+		server.Write(w, protocols.InternalServerError)
+		return true
+	}
+	if payment == nil {
+		return false
+	}
+
+	if payment.Status == entities.QueuedPaymentStatusQueued {
+		server.Write(w, bridge.PaymentHandoffResponse{
+			ID:        payment.PaymentID,
+			Status:    bridge.PaymentHandoffStatusQueued,
+			Attempts:  payment.Attempts,
+			LastError: payment.LastError,
+		})
+		return true
+	}
+	writeResult(w, &handoff.Result{Status: *payment.ResponseStatus, Body: []byte(*payment.Response)})
+	return true
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/queue"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/test"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zenazn/goji/web"
+)
+
+func TestRequestHandlerPaymentQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+
+	mockHorizon := new(mocks.MockHorizon)
+	repository := db.NewRepository(driver)
+	entityManager := db.NewEntityManager(driver)
+	requestHandler := RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Database:          config.Database{Type: "sqlite"},
+			Accounts:          config.Accounts{BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"},
+		},
+		Horizon:       mockHorizon,
+		Driver:        driver,
+		Repository:    repository,
+		EntityManager: entityManager,
+		PaymentQueue:  &queue.Worker{},
+	}
+
+	account := accountTrusting("100", protocols.Asset{Code: "USD", Issuer: "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"})
+	ledger := uint64(1988727)
+
+	pay := func(params url.Values, role server.Role) (int, map[string]interface{}) {
+		request := newPaymentRequest(t, params)
+		request.Header.Set(server.CorrelationIDHeader, "order-42")
+		return servePayment(&requestHandler, server.WithRole(request, role))
+	}
+	result := func(id string) (int, map[string]interface{}) {
+		request := httptest.NewRequest(http.MethodGet, "/payment/"+id, nil)
+		response := httptest.NewRecorder()
+		requestHandler.PaymentResult(web.C{URLParams: map[string]string{"id": id}}, response, request)
+		return response.Code, test.StringToJSONMap(response.Body.String())
+	}
+	paymentParams := func() url.Values {
+		return usdPaymentParams("20", url.Values{"queue": {"true"}})
+	}
+	getPayment := func(id string) *entities.QueuedPayment {
+		payment, err := repository.GetQueuedPaymentByPaymentID(id)
+		require.NoError(t, err)
+		require.NotNil(t, payment)
+		return payment
+	}
+
+	var id string
+	t.Run("payment is queued", func(t *testing.T) {
+		statusCode, response := pay(paymentParams(), server.RoleOperator)
+		require.Equal(t, http.StatusAccepted, statusCode)
+		assert.Equal(t, "queued", response["status"])
+		id = response["id"].(string)
+
+		payment := getPayment(id)
+		assert.Equal(t, entities.QueuedPaymentStatusQueued, payment.Status)
+		assert.Equal(t, "operator", payment.Role)
+		assert.Equal(t, "order-42", payment.CorrelationID)
+		assert.Contains(t, payment.Payload, `"source_alias":"base_seed"`)
+		assert.NotContains(t, payment.Payload, "SBKKWO3Z")
+		assert.NotContains(t, payment.Payload, "queue")
+		mockHorizon.AssertNotCalled(t, "LoadAccount", mock.Anything)
+
+		statusCode, response = result(id)
+		assert.Equal(t, http.StatusAccepted, statusCode)
+		assert.Equal(t, "queued", response["status"])
+	})
+
+	t.Run("payment stays queued while Horizon is unreachable", func(t *testing.T) {
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{}, errors.New("connection refused")).Once()
+		sent := requestHandler.SendQueuedPayment(getPayment(id))
+		assert.Equal(t, http.StatusServiceUnavailable, sent.Status)
+		assert.True(t, sent.Retryable())
+		assert.Contains(t, string(sent.Body), "dependency_unavailable")
+		mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+
+	t.Run("payment is sent once", func(t *testing.T) {
+		payment := getPayment(id)
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(account, nil)
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{Hash: "6a0049b4", Ledger: &ledger}, nil).Once()
+		sent := requestHandler.SendQueuedPayment(payment)
+		require.Equal(t, http.StatusOK, sent.Status)
+		assert.Contains(t, string(sent.Body), `"ledger": 1988727`)
+
+		// An attempt repeated after the server stopped returns the response of the first one
+		repeated := requestHandler.SendQueuedPayment(payment)
+		assert.Equal(t, sent, repeated)
+		mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 1)
+
+		idempotent, err := repository.GetIdempotentPaymentByPaymentID("queue-" + id)
+		require.NoError(t, err)
+		require.NotNil(t, idempotent)
+		transaction, err := repository.GetSentTransactionByHash(idempotent.TransactionID)
+		require.NoError(t, err)
+		assert.Equal(t, "order-42", transaction.CorrelationID)
+
+		payment.Attempts = 2
+		payment.Settle(sent.Status, sent.Body, utc.Now())
+		require.NoError(t, entityManager.Persist(payment))
+		statusCode, response := result(id)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, float64(ledger), response["ledger"])
+	})
+
+	t.Run("invalid queued payments are rejected", func(t *testing.T) {
+		params := paymentParams()
+		params.Set("id", "order-1")
+		statusCode, response := pay(params, server.RoleClient)
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "queue", response["data"].(map[string]interface{})["name"])
+
+		params = paymentParams()
+		params.Set("source", "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+		statusCode, response = pay(params, server.RoleClient)
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Contains(t, response["more_info"], "Unsigned payments")
+
+		params = paymentParams()
+		params.Set("source", "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H")
+		statusCode, response = pay(params, server.RoleClient)
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Contains(t, response["more_info"], "sources in the config file")
+	})
+
+	t.Run("unknown payment is not found", func(t *testing.T) {
+		statusCode, response := result("unknown")
+		assert.Equal(t, http.StatusNotFound, statusCode)
+		assert.Equal(t, "payment_not_found", response["code"])
+	})
+}
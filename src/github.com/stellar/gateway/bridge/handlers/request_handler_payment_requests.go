@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/utc"
+	"github.com/zenazn/goji/web"
+)
+
+// PaymentRequests implements POST /payment_requests endpoint. It creates a payment request to
+// the receiving account that is fulfilled by the listener when a matching payment arrives.
+func (rh *RequestHandler) PaymentRequests(w http.ResponseWriter, r *http.Request) {
+	request := &bridge.PaymentRequestCreateRequest{}
+	err := request.FromRequest(r)
+	if err != nil {
+		log.Error(err.Error())
+		server.Write(w, protocols.InvalidParameterError)
+		return
+	}
+
+	err = request.Validate()
+	if err != nil {
+		errorResponse := err.(*protocols.ErrorResponse)
+		log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, errorResponse)
+		return
+	}
+
+	if request.MemoType == "" {
+		// Random memo so the payment can be matched to the request
+		request.MemoType = "id"
+		request.Memo, err = randomMemoID()
+		if err != nil {
+			log.WithFields(log.Fields{"err": err}).Error("Error generating memo")
+			server.Write(w, protocols.InternalServerError)
+			return
+		}
+	} else {
+		existing, err := rh.Repository.GetPaymentRequestsByMemo(request.MemoType, request.Memo)
+		if err != nil {
+			log.WithFields(log.Fields{"err": err}).Error("Error loading payment requests")
+			server.Write(w, protocols.InternalServerError)
+			return
+		}
+
+		for _, paymentRequest := range existing {
+			if paymentRequest.Status == entities.PaymentRequestStatusOpen {
+				server.Write(w, protocols.NewInvalidParameterError("memo", request.Memo, "Memo is used by another open payment request."))
+				return
+			}
+		}
+	}
+
+	// Payments received by a recreated account do not fulfill requests of its previous generation
+	accountGeneration, err := rh.Generations.Generation(rh.Config.Accounts.ReceivingAccountID)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error loading account generation")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	requestID, err := randomRequestID()
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error generating payment request ID")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	paymentRequest := &entities.PaymentRequest{
+		RequestID:   requestID,
+		Destination: rh.Config.Accounts.ReceivingAccountID,
+		Generation:  accountGeneration,
+		AssetCode:   request.AssetCode,
+		AssetIssuer: request.AssetIssuer,
+		Amount:      request.Amount,
+		MemoType:    request.MemoType,
+		Memo:        request.Memo,
+		Status:      entities.PaymentRequestStatusOpen,
+		CreatedAt:   utc.Now(),
+	}
+	if request.ExpiresAt != "" {
+		expiresAt := utc.Unix(int64(request.ExpiresAtValue()))
+		paymentRequest.ExpiresAt = &expiresAt
+	}
+
+	err = rh.EntityManager.Persist(paymentRequest)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error saving payment request")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	log.WithFields(log.Fields{"id": requestID}).Info("Payment request created")
+	server.Write(w, bridge.NewPaymentRequestResponse(paymentRequest))
+}
+
+// PaymentRequest implements GET /payment_requests/{id} endpoint
+func (rh *RequestHandler) PaymentRequest(c web.C, w http.ResponseWriter, r *http.Request) {
+	paymentRequest, err := rh.Repository.GetPaymentRequestByRequestID(c.URLParams["id"])
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error loading payment request")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	if paymentRequest == nil {
+		server.Write(w, bridge.PaymentRequestNotFound)
+		return
+	}
+
+	server.Write(w, bridge.NewPaymentRequestResponse(paymentRequest))
+}
+
+func randomRequestID() (string, error) {
+	raw := make([]byte, 16)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+func randomMemoID() (string, error) {
+	raw := make([]byte, 8)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	// Some wallets parse memo IDs as signed integers
+	return strconv.FormatUint(binary.BigEndian.Uint64(raw)>>1, 10), nil
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/zenazn/goji/web"
+)
+
+func TestRequestHandlerPaymentRequests(t *testing.T) {
+	c := &config.Config{
+		Accounts: config.Accounts{
+			ReceivingAccountID: "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB",
+		},
+	}
+	mockRepository := new(mocks.MockRepository)
+	mockEntityManager := new(mocks.MockEntityManager)
+
+	requestHandler := RequestHandler{
+		Config:        c,
+		Repository:    mockRepository,
+		EntityManager: mockEntityManager,
+	}
+
+	mux := web.New()
+	mux.Post("/payment_requests", requestHandler.PaymentRequests)
+	mux.Get("/payment_requests/:id", requestHandler.PaymentRequest)
+
+	createServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = "/payment_requests"
+		mux.ServeHTTP(w, r)
+	}))
+	defer createServer.Close()
+
+	Convey("Given payment request", t, func() {
+		Convey("When memo type is hash", func() {
+			params := url.Values{
+				"amount":    {"20"},
+				"memo_type": {"hash"},
+				"memo":      {"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"},
+			}
+
+			Convey("it should return error", func() {
+				statusCode, response := net.GetResponse(createServer, params)
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "invalid_parameter",
+				  "message": "Invalid parameter.",
+				  "data": {
+				    "name": "memo_type"
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+			})
+		})
+
+		Convey("When memo is used by an open request", func() {
+			params := url.Values{
+				"amount":    {"20"},
+				"memo_type": {"text"},
+				"memo":      {"invoice 1"},
+			}
+
+			mockRepository.On("GetPaymentRequestsByMemo", "text", "invoice 1").Return(
+				[]*entities.PaymentRequest{{Status: entities.PaymentRequestStatusOpen}},
+				nil,
+			).Once()
+
+			Convey("it should return error", func() {
+				statusCode, response := net.GetResponse(createServer, params)
+				assert.Equal(t, 400, statusCode)
+				assert.Equal(t, "memo", test.StringToJSONMap(string(response))["data"].(map[string]interface{})["name"])
+			})
+		})
+
+		Convey("When memo is not sent", func() {
+			params := url.Values{
+				"amount":       {"20"},
+				"asset_code":   {"USD"},
+				"asset_issuer": {"GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"},
+				"expires_at":   {"2017-03-01T10:00:00+01:00"},
+			}
+
+			var saved *entities.PaymentRequest
+			mockEntityManager.On("Persist", mock.AnythingOfType("*entities.PaymentRequest")).Run(func(args mock.Arguments) {
+				saved = args.Get(0).(*entities.PaymentRequest)
+			}).Return(nil).Once()
+
+			Convey("it should generate memo ID and return pay URI", func() {
+				statusCode, response := net.GetResponse(createServer, params)
+				assert.Equal(t, 200, statusCode)
+
+				var body map[string]interface{}
+				assert.NoError(t, json.Unmarshal(response, &body))
+
+				assert.Equal(t, saved.RequestID, body["id"])
+				assert.Len(t, saved.RequestID, 32)
+				assert.Equal(t, "id", saved.MemoType)
+				assert.NotEmpty(t, saved.Memo)
+				assert.Equal(t, "open", body["status"])
+				assert.Equal(t, "2017-03-01T09:00:00Z", body["expires_at"])
+				assert.Equal(t, c.Accounts.ReceivingAccountID, body["destination"])
+
+				expectedURI := "web+stellar:pay?amount=20&asset_code=USD&asset_issuer=GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR" +
+					"&destination=GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB&memo=" + saved.Memo + "&memo_type=MEMO_ID"
+				assert.Equal(t, expectedURI, body["uri"])
+				assert.Equal(t, expectedURI, body["qr_payload"])
+				mockEntityManager.AssertExpectations(t)
+			})
+		})
+
+		Convey("When getting missing request", func() {
+			mockRepository.On("GetPaymentRequestByRequestID", "missing").Return(nil, nil).Once()
+
+			Convey("it should return not found", func() {
+				recorder := httptest.NewRecorder()
+				mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/payment_requests/missing", nil))
+				assert.Equal(t, 404, recorder.Code)
+				assert.Equal(t, "payment_request_not_found", test.StringToJSONMap(recorder.Body.String())["code"])
+			})
+		})
+
+		Convey("When getting fulfilled request", func() {
+			mockRepository.On("GetPaymentRequestByRequestID", "abc").Return(&entities.PaymentRequest{
+				RequestID:   "abc",
+				Destination: c.Accounts.ReceivingAccountID,
+				Amount:      "20",
+				MemoType:    "text",
+				Memo:        "invoice 1",
+				Status:      entities.PaymentRequestStatusFulfilled,
+				OperationID: "1",
+			}, nil).Once()
+
+			Convey("it should return its status", func() {
+				recorder := httptest.NewRecorder()
+				mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/payment_requests/abc", nil))
+				assert.Equal(t, 200, recorder.Code)
+
+				body := test.StringToJSONMap(recorder.Body.String())
+				assert.Equal(t, "fulfilled", body["status"])
+				assert.Equal(t, "1", body["operation_id"])
+				assert.Equal(t, "web+stellar:pay?amount=20&destination=GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB&memo=invoice%201&memo_type=MEMO_TEXT", body["uri"])
+			})
+		})
+	})
+}
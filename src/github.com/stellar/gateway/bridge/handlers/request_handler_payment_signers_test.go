@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentSigners(t *testing.T) {
+	baseSeed := testBaseSeed
+	first := "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J"
+	second := "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H"
+	ledger := uint64(1988727)
+
+	var mockHorizon *mocks.MockHorizon
+	var submitted []string
+	pay := func(params url.Values) (int, map[string]interface{}) {
+		mockHorizon = new(mocks.MockHorizon)
+		submitted = nil
+		mockHorizon.On("LoadAccount", "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS").Return(
+			accountTrusting("1", protocols.Asset{Code: "USD", Issuer: "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"}), nil,
+		)
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+			submitted = append(submitted, args.String(0))
+		}).Return(horizon.SubmitTransactionResponse{Ledger: &ledger}, nil)
+
+		requestHandler := newTestRequestHandler(mockHorizon)
+		return postPayment(t, &requestHandler, usdPaymentParams("20", params))
+	}
+	// signedBy returns addresses of seeds whose signatures of the submitted envelope are valid
+	signedBy := func(seeds ...string) []string {
+		require.Len(t, submitted, 1)
+		var envelope xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(submitted[0], &envelope))
+		hash, err := network.HashTransaction(&envelope.Tx, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+
+		signers := []string{}
+		for _, signature := range envelope.Signatures {
+			for _, seed := range seeds {
+				kp := keypair.MustParse(seed)
+				if kp.Verify(hash[:], signature.Signature) == nil {
+					signers = append(signers, kp.Address())
+				}
+			}
+		}
+		return signers
+	}
+	address := func(seed string) string {
+		return keypair.MustParse(seed).Address()
+	}
+
+	t.Run("signed by the source and signers", func(t *testing.T) {
+		status, _ := pay(url.Values{"signers[]": {first, second}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{address(baseSeed), address(first), address(second)}, signedBy(baseSeed, first, second))
+	})
+
+	t.Run("public key source signed by signers", func(t *testing.T) {
+		status, response := pay(url.Values{
+			"source":  {"GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"},
+			"signers": {first + "," + second},
+		})
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotNil(t, response["hash"])
+		assert.Equal(t, []string{address(first), address(second)}, signedBy(first, second))
+	})
+
+	t.Run("public key source without signers is not signed", func(t *testing.T) {
+		status, response := pay(url.Values{"source": {"GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"}})
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, response["envelope_xdr"])
+		assert.Empty(t, submitted)
+	})
+
+	t.Run("duplicate signers are rejected", func(t *testing.T) {
+		status, response := pay(url.Values{"signers[]": {first, first}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "invalid_parameter", response["code"])
+		assert.Contains(t, response["more_info"], "Signer 2 is a duplicate")
+
+		// The base seed is the source
+		status, response = pay(url.Values{"signers[]": {baseSeed}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, response["more_info"], "Signer 1 is a duplicate")
+		assert.Empty(t, submitted)
+	})
+
+	t.Run("invalid signers are rejected without echoing them", func(t *testing.T) {
+		status, response := pay(url.Values{"signers[]": {"GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB", first + "x"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "validation_failed", response["code"])
+		assert.NotContains(t, fmt.Sprint(response), first)
+		assert.Empty(t, submitted)
+	})
+}
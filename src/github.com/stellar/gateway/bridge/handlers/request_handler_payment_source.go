@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/go/keypair"
+)
+
+// resolveSourceName replaces a name of `accounts.sources` sent as the source with its seed and
+// returns the name, an empty string when the source is not a name. Unknown names are rejected
+// with PaymentInvalidSource. With `accounts.named_sources_only` sources other than names and
+// public keys are rejected without echoing them, they may be seeds, and so are `signers`.
+func (rh *RequestHandler) resolveSourceName(request *bridge.PaymentRequest) (string, *protocols.ErrorResponse) {
+	if rh.Config.Accounts.NamedSourcesOnly && len(request.Signers) > 0 {
+		return "", protocols.NewInvalidParameterError("signers", "", "Signers cannot be sent when only named sources are accepted.")
+	}
+
+	source := request.Source
+	if source == "" {
+		return "", nil
+	}
+
+	if config.IsSourceName(source) {
+		seed := rh.Config.Accounts.Sources[source]
+		if seed == "" {
+			return "", bridge.NewPaymentInvalidSourceError(source)
+		}
+		request.Source = seed
+		return source, nil
+	}
+
+	if rh.Config.Accounts.NamedSourcesOnly {
+		kp, err := keypair.Parse(source)
+		if _, isPublic := kp.(*keypair.FromAddress); err != nil || !isPublic {
+			return "", bridge.PaymentSourceSeedNotAllowed
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentNamedSource(t *testing.T) {
+	refunds := "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H"
+	other := "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J"
+
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+	ledger := uint64(1988727)
+	var submitted xdr.TransactionEnvelope
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		require.NoError(t, xdr.SafeUnmarshalBase64(args.String(0), &submitted))
+	}).Return(horizon.SubmitTransactionResponse{Hash: "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", Ledger: &ledger}, nil)
+
+	requestHandler := RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Accounts: config.Accounts{
+				BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK",
+				Sources:  map[string]string{"refunds": refunds},
+			},
+		},
+		Horizon: mockHorizon,
+	}
+	payAmount := func(source, amount string) (int, map[string]interface{}) {
+		params := url.Values{
+			"source":      {source},
+			"destination": {"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+			"amount":      {amount},
+		}
+		return postPayment(t, &requestHandler, params)
+	}
+	pay := func(source string) (int, map[string]interface{}) {
+		return payAmount(source, "20")
+	}
+
+	t.Run("named source", func(t *testing.T) {
+		status, response := pay("refunds")
+		require.Equal(t, http.StatusOK, status, response)
+		assert.Equal(t, keypair.MustParse(refunds).Address(), submitted.Tx.SourceAccount.Address())
+	})
+
+	t.Run("name is logged instead of the seed", func(t *testing.T) {
+		var logs bytes.Buffer
+		out := log.StandardLogger().Out
+		log.SetOutput(&logs)
+		defer log.SetOutput(out)
+
+		status, _ := payAmount("refunds", "-1")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, logs.String(), "source=refunds")
+		assert.NotContains(t, logs.String(), refunds)
+	})
+
+	t.Run("unknown name", func(t *testing.T) {
+		status, response := pay("hotwallet")
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_invalid_source", response["code"])
+		assert.Equal(t, map[string]interface{}{"source": "hotwallet"}, response["data"])
+	})
+
+	t.Run("seeds with named_sources_only", func(t *testing.T) {
+		status, _ := pay(other)
+		require.Equal(t, http.StatusOK, status)
+
+		requestHandler.Config.Accounts.NamedSourcesOnly = true
+		defer func() { requestHandler.Config.Accounts.NamedSourcesOnly = false }()
+		status, response := pay(other)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, "payment_source_seed_not_allowed", response["code"])
+		assert.Nil(t, response["data"])
+
+		status, _ = pay("refunds")
+		assert.Equal(t, http.StatusOK, status)
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentStartingBalance(t *testing.T) {
+	seed := testBaseSeed
+	missing := "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"
+	existing := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", keypair.MustParse(seed).Address()).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+	mockHorizon.On("LoadAccount", existing).Return(horizon.AccountResponse{SequenceNumber: "5"}, nil)
+	mockHorizon.On("LoadAccount", missing).Return(horizon.AccountResponse{}, errors.New("Resource Missing"))
+	ledger := uint64(1988727)
+	var submitted xdr.TransactionEnvelope
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		require.NoError(t, xdr.SafeUnmarshalBase64(args.String(0), &submitted))
+	}).Return(horizon.SubmitTransactionResponse{Hash: "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", Ledger: &ledger}, nil)
+
+	requestHandler := newTestRequestHandler(mockHorizon)
+	pay := func(params url.Values) (int, map[string]interface{}) {
+		return postPayment(t, &requestHandler, params)
+	}
+
+	t.Run("funds created destination", func(t *testing.T) {
+		status, response := pay(url.Values{"destination": {missing}, "amount": {"20"}, "starting_balance": {"25.5"}})
+		require.Equal(t, http.StatusOK, status, response)
+		operation := submitted.Tx.Operations[0].Body.CreateAccountOp
+		require.NotNil(t, operation)
+		assert.Equal(t, xdr.Int64(255000000), operation.StartingBalance)
+	})
+
+	t.Run("amount funds created destination without starting_balance", func(t *testing.T) {
+		status, response := pay(url.Values{"destination": {missing}, "amount": {"20"}})
+		require.Equal(t, http.StatusOK, status, response)
+		assert.Equal(t, xdr.Int64(200000000), submitted.Tx.Operations[0].Body.CreateAccountOp.StartingBalance)
+	})
+
+	t.Run("existing destination", func(t *testing.T) {
+		status, response := pay(url.Values{"destination": {existing}, "amount": {"20"}, "starting_balance": {"25"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_destination_exists", response["code"])
+	})
+
+	t.Run("below minimum balance", func(t *testing.T) {
+		status, response := pay(url.Values{"destination": {missing}, "amount": {"0.5"}, "starting_balance": {"0.9"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_starting_balance_below_reserve", response["code"])
+		assert.Equal(t, map[string]interface{}{"starting_balance": "0.9", "min_balance": "1.0000000", "operation_type": "create_account"}, response["data"])
+
+		requestHandler.Config.BaseReserve = 10000000
+		defer func() { requestHandler.Config.BaseReserve = 0 }()
+		_, response = pay(url.Values{"destination": {missing}, "amount": {"0.5"}, "starting_balance": {"1.5"}})
+		assert.Equal(t, "2.0000000", response["data"].(map[string]interface{})["min_balance"])
+	})
+
+	t.Run("amount below minimum balance", func(t *testing.T) {
+		status, response := pay(url.Values{"destination": {missing}, "amount": {"0.5"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_amount_below_reserve", response["code"])
+		assert.Equal(t, map[string]interface{}{"amount": "0.5", "min_balance": "1.0000000", "operation_type": "create_account"}, response["data"])
+
+		status, _ = pay(url.Values{"destination": {existing}, "amount": {"0.5"}})
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("credit asset", func(t *testing.T) {
+		status, response := pay(url.Values{"destination": {missing}, "amount": {"20"}, "asset_code": {"USD"}, "asset_issuer": {existing}, "starting_balance": {"25"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "invalid_parameter", response["code"])
+		assert.Equal(t, "starting_balance", response["data"].(map[string]interface{})["name"])
+	})
+
+	t.Run("forbidden account creation", func(t *testing.T) {
+		status, response := pay(url.Values{"destination": {missing}, "amount": {"20"}, "forbid_account_creation": {"true"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_destination_does_not_exist", response["code"])
+		assert.Equal(t, map[string]interface{}{"destination": missing, "operation_type": "payment"}, response["data"])
+
+		status, _ = pay(url.Values{"destination": {existing}, "amount": {"20"}, "forbid_account_creation": {"true"}})
+		assert.Equal(t, http.StatusOK, status)
+
+		status, response = pay(url.Values{"destination": {missing}, "amount": {"20"}, "starting_balance": {"25"}, "forbid_account_creation": {"true"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "starting_balance", response["data"].(map[string]interface{})["name"])
+	})
+
+	t.Run("forbid_account_creation config", func(t *testing.T) {
+		requestHandler.Config.ForbidAccountCreation = true
+		defer func() { requestHandler.Config.ForbidAccountCreation = false }()
+		status, response := pay(url.Values{"destination": {missing}, "amount": {"20"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_destination_does_not_exist", response["code"])
+	})
+
+	mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 4)
+
+	t.Run("create_account failure", func(t *testing.T) {
+		resultXdr, err := xdr.MarshalBase64(xdr.TransactionResult{
+			FeeCharged: 100,
+			Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxFailed, Results: &[]xdr.OperationResult{{
+				Code: xdr.OperationResultCodeOpInner,
+				Tr: &xdr.OperationResultTr{
+					Type:                xdr.OperationTypeCreateAccount,
+					CreateAccountResult: &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountUnderfunded},
+				},
+			}}},
+		})
+		require.NoError(t, err)
+		mockHorizon = new(mocks.MockHorizon)
+		mockHorizon.On("LoadAccount", keypair.MustParse(seed).Address()).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+		mockHorizon.On("LoadAccount", missing).Return(horizon.AccountResponse{}, errors.New("Resource Missing"))
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{
+			Hash:   "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a",
+			Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: resultXdr},
+		}, nil)
+		requestHandler.Horizon = mockHorizon
+
+		status, response := pay(url.Values{"destination": {missing}, "amount": {"20"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "create_account_underfunded", response["code"])
+		assert.Equal(t, "create_account", response["data"].(map[string]interface{})["operation_type"])
+	})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// sweepAmount replaces `amount=all` of request with the available XLM balance of the source: its
+// balance minus its minimum balance (2 base reserves and a base reserve per subentry) and the fee
+// of the transaction, which is paid by a channel when channels are enabled. Selling liabilities
+// of offers are not returned by Horizon, so sources with offers are refused.
+//
+// It returns *protocols.ErrorResponse when the source doesn't exist, has offers or nothing is
+// left to send, other errors are returned when the source cannot be loaded.
+func (rh *RequestHandler) sweepAmount(request *bridge.PaymentRequest) error {
+	sourceKeypair, _ := keypair.Parse(request.Source)
+	source := sourceKeypair.Address()
+	account, err := rh.Horizon.LoadAccountCtx(rh.context(), source)
+	rh.observeAccount(source, account, err)
+	if err != nil {
+		if isLookupAborted(err) {
+			return err
+		}
+		return rh.withHorizonFailureID(bridge.PaymentSourceNotExist, horizon.FailureID(err))
+	}
+
+	if offers := account.Offers(); offers > 0 {
+		return bridge.NewPaymentSweepOffersError(offers)
+	}
+
+	var balance xdr.Int64
+	for _, accountBalance := range account.Balances {
+		if accountBalance.AssetType == "native" {
+			balance, err = amount.Parse(accountBalance.Balance)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	minBalance := rh.minBalance() + xdr.Int64(account.SubentryCount)*rh.baseReserve()
+	var fee xdr.Int64
+	if !rh.Channels.Enabled() || unsignedPayment(request) {
+		fee = xdr.Int64(rh.paymentFee(request, 1))
+		if fee == 0 {
+			fee = txspec.BaseFee
+		}
+	}
+	available := balance - minBalance - fee
+	if available <= 0 {
+		return bridge.NewPaymentNothingToSweepError(amount.String(balance), amount.String(minBalance), amount.String(fee))
+	}
+	request.Amount = amount.String(available)
+	return nil
+}
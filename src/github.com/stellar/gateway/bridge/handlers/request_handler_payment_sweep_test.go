@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentSweep(t *testing.T) {
+	source := keypair.MustParse(testBaseSeed).Address()
+	destination := testDestination
+	issuer := testIssuer
+
+	// sourceAccount has an XLM balance and a USD trustline
+	sourceAccount := func(balance string, subentries int32) horizon.AccountResponse {
+		return horizon.AccountResponse{
+			AccountID:      source,
+			SequenceNumber: "100",
+			SubentryCount:  subentries,
+			Balances: []horizon.Balance{
+				{Balance: "10.0000000", AssetType: "credit_alphanum4", AssetCode: "USD", AssetIssuer: issuer},
+				{Balance: balance, AssetType: "native"},
+			},
+			Signers: []horizon.Signer{{Key: source, Weight: 1}},
+		}
+	}
+	pay := func(account horizon.AccountResponse, params url.Values) (*mocks.MockHorizon, int, map[string]interface{}) {
+		mockHorizon := new(mocks.MockHorizon)
+		requestHandler := newTestRequestHandler(mockHorizon)
+		mockHorizon.On("LoadAccount", source).Return(account, nil)
+		mockHorizon.On("LoadAccount", destination).Return(horizon.AccountResponse{AccountID: destination, SequenceNumber: "1"}, nil)
+		ledger := uint64(1988727)
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{Ledger: &ledger}, nil)
+
+		params.Set("destination", destination)
+		params.Set("amount", "all")
+		status, response := postPayment(t, &requestHandler, params)
+		return mockHorizon, status, response
+	}
+
+	t.Run("balance above minimum balance and fee is sent", func(t *testing.T) {
+		mockHorizon, status, response := pay(sourceAccount("100.0000000", 1), url.Values{})
+		require.Equal(t, http.StatusOK, status, response)
+		// 3 base reserves (one for the trustline) and the minimum fee are left
+		assert.Equal(t, "98.4999900", response["amount"])
+
+		envelope := mockHorizon.Calls[len(mockHorizon.Calls)-1].Arguments.String(0)
+		var tx xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(envelope, &tx))
+		assert.Equal(t, xdr.Int64(984999900), tx.Tx.Operations[0].Body.PaymentOp.Amount)
+		assert.Equal(t, xdr.Uint32(100), tx.Tx.Fee)
+	})
+
+	t.Run("fee param is left to pay the fee", func(t *testing.T) {
+		_, status, response := pay(sourceAccount("100.0000000", 1), url.Values{"fee": {"500"}})
+		require.Equal(t, http.StatusOK, status, response)
+		assert.Equal(t, "98.4999500", response["amount"])
+	})
+
+	t.Run("source with offers is refused", func(t *testing.T) {
+		mockHorizon, status, response := pay(sourceAccount("100.0000000", 3), url.Values{})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_sweep_offers", response["code"])
+		assert.Equal(t, float64(2), response["data"].(map[string]interface{})["offers"])
+		mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+
+	t.Run("nothing left to send", func(t *testing.T) {
+		mockHorizon, status, response := pay(sourceAccount("1.5000100", 1), url.Values{})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "payment_nothing_to_sweep", response["code"])
+		assert.Equal(t, map[string]interface{}{"balance": "1.5000100", "min_balance": "1.5000000", "fee": "0.0000100", "operation_type": "payment"}, response["data"])
+		mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+
+	t.Run("credit assets cannot be swept", func(t *testing.T) {
+		mockHorizon, status, response := pay(sourceAccount("100.0000000", 1), url.Values{"asset_code": {"USD"}, "asset_issuer": {issuer}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "amount", response["data"].(map[string]interface{})["name"])
+		mockHorizon.AssertNotCalled(t, "LoadAccount", source)
+	})
+}
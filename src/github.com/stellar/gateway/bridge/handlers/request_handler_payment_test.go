@@ -1,25 +1,38 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stellar/gateway/breaker"
 	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/channels"
+	"github.com/stellar/gateway/counterparty"
 	"github.com/stellar/gateway/horizon"
 	"github.com/stellar/gateway/mocks"
 	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/protocols/bridge"
 	callback "github.com/stellar/gateway/protocols/compliance"
 	"github.com/stellar/gateway/test"
+	"github.com/stellar/gateway/txspec"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/protocols/federation"
 	"github.com/stellar/go/xdr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRequestHandlerPayment(t *testing.T) {
@@ -72,6 +85,56 @@ func TestRequestHandlerPayment(t *testing.T) {
 			})
 		})
 
+		Convey("When correlation_id is invalid", func() {
+			params := url.Values{
+				"destination":    {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+				"amount":         {"20.0"},
+				"correlation_id": {"order\n42"},
+			}
+
+			Convey("it should return error", func() {
+				statusCode, response := net.GetResponse(testServer, params)
+				responseString := strings.TrimSpace(string(response))
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+  "code": "invalid_parameter",
+  "message": "Invalid parameter.",
+  "data": {
+    "name": "correlation_id"
+  }
+}`)
+				assert.Equal(t, expected, test.StringToJSONMap(responseString, "more_info"))
+			})
+		})
+
+		Convey("When several params are invalid", func() {
+			params := url.Values{
+				"source":      {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX43"},
+				"destination": {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+				"amount":      {"20.0"},
+				"memo":        {"order 42"},
+				"asset_code":  {"USD"},
+				"asset_isuer": {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+			}
+
+			Convey("it should return all errors", func() {
+				statusCode, response := net.GetResponse(testServer, params)
+				responseString := strings.TrimSpace(string(response))
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+  "code": "validation_failed",
+  "message": "Request has more than one invalid parameter, see errors.",
+  "errors": [
+    {"field": "asset_isuer", "code": "invalid_parameter", "message": "Unknown parameter."},
+    {"field": "source", "code": "invalid_parameter", "message": "Source must be a public key (starting with ` + "`G`" + `)."},
+    {"field": "memo_type", "code": "missing_parameter", "message": "Required parameter is missing."},
+    {"field": "asset_issuer", "code": "missing_parameter", "message": "Required parameter is missing."}
+  ]
+}`)
+				assert.Equal(t, expected, test.StringToJSONMap(responseString))
+			})
+		})
+
 		Convey("When destination is invalid", func() {
 			params := url.Values{
 				"source":      {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
@@ -79,14 +142,6 @@ func TestRequestHandlerPayment(t *testing.T) {
 				"amount":      {"20.0"},
 			}
 
-			mockFederationResolver.On(
-				"LookupByAddress",
-				"GD3YBOYIUVLU",
-			).Return(
-				&federation.NameResponse{AccountID: "GD3YBOYIUVLU"},
-				nil,
-			).Once()
-
 			Convey("it should return error", func() {
 				statusCode, response := net.GetResponse(testServer, params)
 				responseString := strings.TrimSpace(string(response))
@@ -146,6 +201,7 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 				assert.Equal(t, 200, statusCode)
 				expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a",
 					  "ledger": 1988728
 					}`)
@@ -233,6 +289,7 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a",
 					  "ledger": 1988728
 					}`)
@@ -296,6 +353,7 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "ad71fc31bfae25b0bd14add4cc5306661edf84cdd73f1353d2906363899167e1",
 					  "ledger": 1988728
 					}`)
@@ -304,6 +362,78 @@ func TestRequestHandlerPayment(t *testing.T) {
 			})
 		})
 
+		Convey("When text memo is longer than 28 bytes", func() {
+			params := url.Values{
+				"source":      {"SDWLS4G3XCNIYPKXJWWGGJT6UDY63WV6PEFTWP7JZMQB4RE7EUJQN5XM"},
+				"destination": {"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"},
+				"amount":      {"20"},
+				"memo_type":   {"text"},
+				// 15 characters but 30 bytes
+				"memo": {"ééééééééééééééé"},
+			}
+
+			mockHorizon.On(
+				"LoadAccount",
+				"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+			).Return(horizon.AccountResponse{}, nil).Once()
+
+			Convey("it should return error", func() {
+				statusCode, response := net.GetResponse(testServer, params)
+				responseString := strings.TrimSpace(string(response))
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "payment_invalid_memo",
+				  "message": "Memo is invalid.",
+				  "more_info": "Memo.text must be at most 28 bytes long, it is 30 bytes long.",
+				  "data": {
+				    "name": "memo"
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(responseString))
+			})
+		})
+
+		Convey("When federation returns a text memo longer than 28 bytes", func() {
+			params := url.Values{
+				"source":      {"SDWLS4G3XCNIYPKXJWWGGJT6UDY63WV6PEFTWP7JZMQB4RE7EUJQN5XM"},
+				"destination": {"bob*stellar.org"},
+				"amount":      {"20"},
+			}
+
+			mockFederationResolver.On(
+				"LookupByAddress",
+				"bob*stellar.org",
+			).Return(
+				&federation.NameResponse{
+					AccountID: "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+					MemoType:  "text",
+					Memo:      federation.Memo{"12345678901234567890123456789"},
+				},
+				nil,
+			).Once()
+
+			mockHorizon.On(
+				"LoadAccount",
+				"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+			).Return(horizon.AccountResponse{}, nil).Once()
+
+			Convey("it should return error", func() {
+				statusCode, response := net.GetResponse(testServer, params)
+				responseString := strings.TrimSpace(string(response))
+				assert.Equal(t, 502, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "invalid_federation_memo",
+				  "message": "Federation server of the destination returned an invalid memo.",
+				  "more_info": "Memo.text must be at most 28 bytes long, it is 29 bytes long.",
+				  "data": {
+				    "destination": "bob*stellar.org",
+				    "memo_type": "text"
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(responseString))
+			})
+		})
+
 		Convey("When asset_issuer is invalid", func() {
 			params := url.Values{
 				"source":       {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
@@ -376,13 +506,14 @@ func TestRequestHandlerPayment(t *testing.T) {
 				responseString := strings.TrimSpace(string(response))
 				assert.Equal(t, 400, statusCode)
 				expected := test.StringToJSONMap(`{
-  "code": "invalid_parameter",
-  "message": "Invalid parameter.",
+  "code": "payment_malformed_asset_code",
+  "message": "Asset code must be 1-12 alphanumeric characters.",
   "data": {
-    "name": "asset_code"
+    "name": "asset_code",
+    "operation_type": "payment"
   }
 }`)
-				assert.Equal(t, expected, test.StringToJSONMap(responseString, "more_info"))
+				assert.Equal(t, expected, test.StringToJSONMap(responseString))
 			})
 		})
 
@@ -426,13 +557,33 @@ func TestRequestHandlerPayment(t *testing.T) {
 				responseString := strings.TrimSpace(string(response))
 				assert.Equal(t, 400, statusCode)
 				expected := test.StringToJSONMap(`{
-  "code": "invalid_parameter",
-  "message": "Invalid parameter.",
+  "code": "payment_invalid_amount",
+  "message": "Amount must be a positive number with at most 7 decimal places, without exponent or group separators.",
   "data": {
     "name": "amount"
   }
 }`)
-				assert.Equal(t, expected, test.StringToJSONMap(responseString, "more_info"))
+				assert.Equal(t, expected, test.StringToJSONMap(responseString))
+			})
+
+			Convey("it should reject amounts and send_max the builder would misread", func() {
+				for _, invalid := range []string{"10,5", "1,000", "1e3", "-1", "0", "0.0", "1.12345678", "+1", " 1", ".5"} {
+					for _, name := range []string{"amount", "send_max"} {
+						params := url.Values{
+							"source":       {source},
+							"destination":  {destination},
+							"amount":       {"20"},
+							"asset_code":   {assetCode},
+							"asset_issuer": {assetIssuer},
+						}
+						params.Set(name, invalid)
+						statusCode, response := net.GetResponse(testServer, params)
+						assert.Equal(t, 400, statusCode, invalid)
+						payload := test.StringToJSONMap(string(response))
+						assert.Equal(t, "payment_invalid_amount", payload["code"], invalid)
+						assert.Equal(t, map[string]interface{}{"name": name}, payload["data"], invalid)
+					}
+				}
 			})
 		})
 
@@ -483,6 +634,7 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 				assert.Equal(t, 200, statusCode)
 				expected := test.StringToJSONMap(`{
+				  "attempts": 1,
 				  "hash": "ad71fc31bfae25b0bd14add4cc5306661edf84cdd73f1353d2906363899167e1",
 				  "ledger": 1988728
 				}`)
@@ -602,6 +754,7 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "f16040c1c6ee29eb4cc6f797651901750ff48a203985eea74f94353502f6629d",
 					  "ledger": 1988727
 					}`)
@@ -639,11 +792,85 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "b6802ab06786c923d7180236a84470c03b37ec71912bfe335d0cb57ebc534881",
 					  "ledger": 1988727
 					}`)
 					assert.Equal(t, expected, test.StringToJSONMap(responseString))
 				})
+
+				Convey("base64 memo hash is attached to the transaction", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+					).Return(
+						horizon.AccountResponse{
+							SequenceNumber: "100",
+						},
+						nil,
+					).Once()
+
+					var ledger uint64
+					ledger = 1988727
+					// The same envelope as for the hex memo
+					mockHorizon.On(
+						"SubmitTransaction",
+						"AAAAAIu7VxM5f9eQ3va0bpvKprxnSHB4zyEnY4D/VzT8Jio3AAAAZAAAAAAAAABlAAAAAAAAAAMCADrUIHRM3rjlJN62XzjLUJXTDQAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAQAAAADkhVuboDyZuBz9qkCLPGYF/jNmapt51Hcp74xNrumNVgAAAAFVU0QAAAAAAOSFW5ugPJm4HP2qQIs8ZgX+M2Zqm3nUdynvjE2u6Y1WAAAAAAvrwgAAAAAAAAAAAfwmKjcAAABAEV6Lzok4i4C1jJA3PVVARGx2+yfVw8odprnnnG0hqkUUwKnvVQcd59UJwbfzTG7oxR5DvxflV4aQ6RmZsIcmDQ==",
+					).Return(horizon.SubmitTransactionResponse{Hash: "b6802ab06786c923d7180236a84470c03b37ec71912bfe335d0cb57ebc534881", Ledger: &ledger}, nil).Once()
+
+					validParams.Add("memo_type", "hash")
+					validParams.Add("memo", "AgA61CB0TN645STetl84y1CV0w0AAAAAAAAAAAAAAAA=")
+					statusCode, _ := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+				})
+
+				Convey("memo return is attached to the transaction", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+					).Return(
+						horizon.AccountResponse{
+							SequenceNumber: "100",
+						},
+						nil,
+					).Once()
+
+					refunded := "c492d87c4642815dfb3c7dcce01af4effd162b031064098a0d786b6e0a00fd74"
+					var ledger uint64
+					ledger = 1988727
+					mockHorizon.On(
+						"SubmitTransaction",
+						mock.MatchedBy(func(envelopeXdr string) bool {
+							var envelope xdr.TransactionEnvelope
+							if xdr.SafeUnmarshalBase64(envelopeXdr, &envelope) != nil {
+								return false
+							}
+							memo := envelope.Tx.Memo
+							return memo.Type == xdr.MemoTypeMemoReturn && hex.EncodeToString(memo.RetHash[:]) == refunded
+						}),
+					).Return(horizon.SubmitTransactionResponse{Hash: refunded, Ledger: &ledger}, nil).Once()
+
+					validParams.Add("memo_type", "return")
+					validParams.Add("memo", refunded)
+					statusCode, _ := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+				})
+
+				Convey("memo_type=return is not a hash", func() {
+					validParams.Add("memo_type", "return")
+					validParams.Add("memo", "refund")
+					statusCode, response := net.GetResponse(testServer, validParams)
+					responseString := strings.TrimSpace(string(response))
+					assert.Equal(t, 400, statusCode)
+					expected := test.StringToJSONMap(`{
+  "code": "invalid_parameter",
+  "message": "Invalid parameter.",
+  "data": {
+    "name": "memo"
+  }
+}`)
+					assert.Equal(t, expected, test.StringToJSONMap(responseString, "more_info"))
+				})
 			})
 
 			Convey("source account does not exist", func() {
@@ -664,7 +891,54 @@ func TestRequestHandlerPayment(t *testing.T) {
 				})
 			})
 
+			Convey("horizon accounts breaker is open", func() {
+				mockHorizon.On(
+					"LoadAccount",
+					"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+				).Return(horizon.AccountResponse{}, &breaker.OpenError{Dependency: horizon.BreakerAccounts}).Once()
+
+				Convey("it should return error", func() {
+					statusCode, response := net.GetResponse(testServer, validParams)
+					responseString := strings.TrimSpace(string(response))
+					assert.Equal(t, 503, statusCode)
+					expected := test.StringToJSONMap(`{
+  "code": "dependency_unavailable",
+  "message": "Dependency is unavailable, please try again later.",
+  "data": {
+    "dependency": "horizon.accounts"
+  }
+}`)
+					assert.Equal(t, expected, test.StringToJSONMap(responseString))
+				})
+			})
+
+			Convey("horizon rate limit is not reset in time", func() {
+				mockHorizon.On(
+					"LoadAccount",
+					"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+				).Return(horizon.AccountResponse{}, &horizon.RateLimitedError{RetryAfter: 30 * time.Second}).Once()
+
+				Convey("it should return 429 with Retry-After", func() {
+					res, err := http.PostForm(testServer.URL, validParams)
+					require.NoError(t, err)
+					defer res.Body.Close()
+					response, _ := ioutil.ReadAll(res.Body)
+					assert.Equal(t, 429, res.StatusCode)
+					assert.Equal(t, "30", res.Header.Get("Retry-After"))
+					expected := test.StringToJSONMap(`{
+  "code": "rate_limited",
+  "message": "Horizon rate limit exceeded, please try again later.",
+  "data": {
+    "retry_after": 30
+  }
+}`)
+					assert.Equal(t, expected, test.StringToJSONMap(strings.TrimSpace(string(response))))
+				})
+			})
+
 			Convey("transaction failed in horizon", func() {
+				// tx_bad_seq is retried with DefaultBadSequenceRetry, the sequence number is
+				// loaded again for every attempt
 				mockHorizon.On(
 					"LoadAccount",
 					"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
@@ -673,7 +947,7 @@ func TestRequestHandlerPayment(t *testing.T) {
 						SequenceNumber: "100",
 					},
 					nil,
-				).Once()
+				).Times(DefaultBadSequenceRetry.MaxAttempts)
 
 				horizonResponse := horizon.SubmitTransactionResponse{
 					Ledger: nil,
@@ -686,21 +960,56 @@ func TestRequestHandlerPayment(t *testing.T) {
 				mockHorizon.On(
 					"SubmitTransaction",
 					mock.AnythingOfType("string"),
-				).Return(horizonResponse, nil).Once()
+				).Return(horizonResponse, nil).Times(DefaultBadSequenceRetry.MaxAttempts)
 
 				Convey("it should return error", func() {
 					statusCode, response := net.GetResponse(testServer, validParams)
 					responseString := strings.TrimSpace(string(response))
 
 					assert.Equal(t, 400, statusCode)
+					// Computed locally, it's the hash Horizon reports for the same envelope in
+					// "transaction success (credit)"
 					expected := test.StringToJSONMap(`{
   "code": "transaction_bad_seq",
-  "message": "Bad Sequence. Please, try again."
+  "message": "Bad Sequence. Please, try again.",
+  "remediation": "retry_with_new_sequence",
+  "data": {
+    "attempts": 3,
+    "hash": "4c8ddbc990381d5f7fe5142be0ac70fb282e7c54347734cdd7f19716fa18930b",
+    "operation_type": "payment",
+    "result_codes": {
+      "transaction": "tx_bad_seq"
+    }
+  }
 }`)
 					assert.Equal(t, expected, test.StringToJSONMap(responseString))
 				})
 			})
 
+			Convey("transaction response is lost", func() {
+				mockHorizon.On(
+					"LoadAccount",
+					"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+				).Return(
+					horizon.AccountResponse{
+						SequenceNumber: "100",
+					},
+					nil,
+				).Once()
+
+				mockHorizon.On(
+					"SubmitTransaction",
+					mock.AnythingOfType("string"),
+				).Return(horizon.SubmitTransactionResponse{}, errors.New("connection reset")).Once()
+
+				Convey("it should return error with the transaction hash", func() {
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 500, statusCode)
+					data := test.StringToJSONMap(strings.TrimSpace(string(response)))["data"]
+					assert.Equal(t, map[string]interface{}{"hash": "4c8ddbc990381d5f7fe5142be0ac70fb282e7c54347734cdd7f19716fa18930b", "operation_type": "payment"}, data)
+				})
+			})
+
 			Convey("transaction success (native)", func() {
 				validParams := url.Values{
 					// GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ
@@ -745,6 +1054,7 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a",
 					  "ledger": 1988727
 					}`)
@@ -782,77 +1092,485 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "4c8ddbc990381d5f7fe5142be0ac70fb282e7c54347734cdd7f19716fa18930b",
 					  "ledger": 1988727
 					}`)
 					assert.Equal(t, expected, test.StringToJSONMap(responseString))
 				})
 			})
-		})
-
-		Convey("When params are valid (path payment operation)", func() {
-			validParams := url.Values{
-				// GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ
-				"source":       {"SDWLS4G3XCNIYPKXJWWGGJT6UDY63WV6PEFTWP7JZMQB4RE7EUJQN5XM"},
-				"destination":  {"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"},
-				"amount":       {"20"},
-				"asset_code":   {"USD"},
-				"asset_issuer": {"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"},
-				"send_max":     {"100"},
-			}
-
-			// Source
-			mockHorizon.On(
-				"LoadAccount",
-				"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
-			).Return(
-				horizon.AccountResponse{
-					SequenceNumber: "100",
-				},
-				nil,
-			).Once()
-
-			// Destination
-			mockFederationResolver.On(
-				"LookupByAddress",
-				"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
-			).Return(
-				&federation.NameResponse{AccountID: "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"},
-				nil,
-			).Once()
 
-			mockHorizon.On(
-				"LoadAccount",
-				"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
-			).Return(
-				horizon.AccountResponse{
-					SequenceNumber: "100",
-				},
-				nil,
-			).Once()
+			Convey("auto_trust is set", func() {
+				validParams["auto_trust"] = []string{"true"}
 
-			Convey("transaction success (send native)", func() {
-				var ledger uint64
-				ledger = 1988727
-				horizonResponse := horizon.SubmitTransactionResponse{
-					Hash:   "88214f536658717d5a7d96e449d2fbd96277ce16f3d88dea023e5f20bd37325d",
-					Ledger: &ledger,
-					Extras: nil,
-				}
+				var ledger uint64 = 1988727
+				var submitted xdr.TransactionEnvelope
+				mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+					err := xdr.SafeUnmarshalBase64(args.String(0), &submitted)
+					assert.NoError(t, err)
+				}).Return(horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil).Once()
 
-				mockHorizon.On(
-					"SubmitTransaction",
-					"AAAAAIu7VxM5f9eQ3va0bpvKprxnSHB4zyEnY4D/VzT8Jio3AAAAZAAAAAAAAABlAAAAAAAAAAAAAAABAAAAAAAAAAIAAAAAAAAAADuaygAAAAAA5IVbm6A8mbgc/apAizxmBf4zZmqbedR3Ke+MTa7pjVYAAAABVVNEAAAAAADkhVuboDyZuBz9qkCLPGYF/jNmapt51Hcp74xNrumNVgAAAAAL68IAAAAAAAAAAAAAAAAB/CYqNwAAAECx9I76SOIjCL3pgwZdmFj9KWFzvNL82dt3+Laokh6Zm2v0o1UNq1mQsrqrv0mXM6uNcA96NbkfbogtXauHmwML",
-				).Return(horizonResponse, nil).Once()
+				Convey("it should prepend change_trust when source does not trust the asset", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+					).Return(
+						horizon.AccountResponse{
+							SequenceNumber: "100",
+							Balances:       []horizon.Balance{{Balance: "100", AssetType: "native"}},
+						},
+						nil,
+					).Once()
 
-				Convey("it should return success", func() {
 					statusCode, response := net.GetResponse(testServer, validParams)
-					responseString := strings.TrimSpace(string(response))
-
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
-					  "hash": "88214f536658717d5a7d96e449d2fbd96277ce16f3d88dea023e5f20bd37325d",
-					  "ledger": 1988727
+					  "attempts": 1,
+					  "hash": "6a3b",
+					  "ledger": 1988727,
+					  "trustline_created": true
+					}`)
+					assert.Equal(t, expected, test.StringToJSONMap(string(response)))
+
+					operations := submitted.Tx.Operations
+					if assert.Len(t, operations, 2) {
+						assert.Equal(t, xdr.OperationTypeChangeTrust, operations[0].Body.Type)
+						var code, issuer string
+						operations[0].Body.ChangeTrustOp.Line.Extract(new(xdr.AssetType), &code, &issuer)
+						assert.Equal(t, "USD", code)
+						assert.Equal(t, "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632", issuer)
+						assert.Equal(t, xdr.OperationTypePayment, operations[1].Body.Type)
+					}
+					assert.Equal(t, xdr.Uint32(200), submitted.Tx.Fee)
+				})
+
+				Convey("it should not change transaction when source trusts the asset", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+					).Return(
+						horizon.AccountResponse{
+							SequenceNumber: "100",
+							Balances: []horizon.Balance{{
+								Balance:     "100",
+								AssetType:   "credit_alphanum4",
+								AssetCode:   "USD",
+								AssetIssuer: "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+							}},
+						},
+						nil,
+					).Once()
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+					assert.NotContains(t, string(response), "trustline_created")
+					assert.Len(t, submitted.Tx.Operations, 1)
+					assert.Equal(t, xdr.Uint32(100), submitted.Tx.Fee)
+				})
+			})
+
+			Convey("auto_trust is disabled by config", func() {
+				c.DisableAutoTrust = true
+				Reset(func() {
+					c.DisableAutoTrust = false
+				})
+				validParams["auto_trust"] = []string{"true"}
+
+				statusCode, response := net.GetResponse(testServer, validParams)
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "invalid_parameter",
+				  "message": "Invalid parameter.",
+				  "data": {
+				    "name": "auto_trust"
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+			})
+
+			Convey("fee is set", func() {
+				loadSource := func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+					).Return(
+						horizon.AccountResponse{
+							SequenceNumber: "100",
+							Balances:       []horizon.Balance{{Balance: "100", AssetType: "native"}},
+						},
+						nil,
+					).Once()
+				}
+
+				var ledger uint64 = 1988727
+				var submitted xdr.TransactionEnvelope
+				submit := func() {
+					mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+						err := xdr.SafeUnmarshalBase64(args.String(0), &submitted)
+						assert.NoError(t, err)
+					}).Return(horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil).Once()
+				}
+
+				Convey("it should use the fee param", func() {
+					loadSource()
+					submit()
+					validParams["fee"] = []string{"300"}
+
+					statusCode, _ := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+					assert.Equal(t, xdr.Uint32(300), submitted.Tx.Fee)
+				})
+
+				Convey("it should use base_fee per operation when the param is not set", func() {
+					c.BaseFee = 150
+					Reset(func() {
+						c.BaseFee = 0
+					})
+					loadSource()
+					submit()
+					validParams["auto_trust"] = []string{"true"}
+
+					statusCode, _ := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+					assert.Len(t, submitted.Tx.Operations, 2)
+					assert.Equal(t, xdr.Uint32(300), submitted.Tx.Fee)
+				})
+
+				Convey("it should return error when the fee is not an integer", func() {
+					validParams["fee"] = []string{"100.5"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					expected := test.StringToJSONMap(`{
+					  "code": "invalid_fee",
+					  "message": "Fee must be an integer number of stroops, at least 100 per operation of the transaction.",
+					  "data": {
+					    "name": "fee",
+					    "min_fee": 100
+					  }
+					}`)
+					assert.Equal(t, expected, test.StringToJSONMap(string(response)))
+				})
+
+				Convey("it should return error when the fee is lower than the minimum fee of the transaction", func() {
+					loadSource()
+					validParams["fee"] = []string{"150"}
+					validParams["auto_trust"] = []string{"true"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					expected := test.StringToJSONMap(`{
+					  "code": "invalid_fee",
+					  "message": "Fee must be an integer number of stroops, at least 100 per operation of the transaction.",
+					  "data": {
+					    "name": "fee",
+					    "min_fee": 200
+					  }
+					}`)
+					assert.Equal(t, expected, test.StringToJSONMap(string(response)))
+				})
+
+				Convey("it should return error in compliance payments", func() {
+					validParams["fee"] = []string{"300"}
+					validParams["use_compliance"] = []string{"true"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					assert.Equal(t, "fee", test.StringToJSONMap(string(response))["data"].(map[string]interface{})["name"])
+				})
+			})
+
+			Convey("time bounds are set", func() {
+				Convey("it should set time bounds of the transaction and return them", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+					).Return(
+						horizon.AccountResponse{
+							SequenceNumber: "100",
+							Balances:       []horizon.Balance{{Balance: "100", AssetType: "native"}},
+						},
+						nil,
+					).Once()
+
+					var ledger uint64 = 1988727
+					var submitted xdr.TransactionEnvelope
+					mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+						err := xdr.SafeUnmarshalBase64(args.String(0), &submitted)
+						assert.NoError(t, err)
+					}).Return(horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil).Once()
+					validParams["min_time"] = []string{"1488360615"}
+					validParams["max_time"] = []string{"2100-01-01T00:00:00Z"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+					assert.Equal(t, &xdr.TimeBounds{MinTime: 1488360615, MaxTime: 4102444800}, submitted.Tx.TimeBounds)
+					assert.Equal(t, map[string]interface{}{"min_time": float64(1488360615), "max_time": float64(4102444800)}, test.StringToJSONMap(string(response))["time_bounds"])
+				})
+
+				Convey("it should return error when max_time is in the past", func() {
+					validParams["max_time"] = []string{"1488360615"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					body := test.StringToJSONMap(string(response))
+					assert.Equal(t, "invalid_time_bounds", body["code"])
+					assert.Equal(t, "max_time", body["data"].(map[string]interface{})["name"])
+				})
+
+				Convey("it should return error when max_time is before min_time", func() {
+					validParams["min_time"] = []string{"4102444801"}
+					validParams["max_time"] = []string{"4102444800"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					body := test.StringToJSONMap(string(response))
+					assert.Equal(t, "invalid_time_bounds", body["code"])
+					assert.Equal(t, "min_time", body["data"].(map[string]interface{})["name"])
+				})
+
+				Convey("it should return error when min_time is not a timestamp", func() {
+					validParams["min_time"] = []string{"tomorrow"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					body := test.StringToJSONMap(string(response))
+					assert.Equal(t, "invalid_parameter", body["code"])
+					assert.Equal(t, "min_time", body["data"].(map[string]interface{})["name"])
+				})
+
+				Convey("it should return error in compliance payments", func() {
+					validParams["max_time"] = []string{"4102444800"}
+					validParams["use_compliance"] = []string{"true"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					assert.Equal(t, "max_time", test.StringToJSONMap(string(response))["data"].(map[string]interface{})["name"])
+				})
+			})
+
+			Convey("amount_stroops is sent", func() {
+				delete(validParams, "amount")
+
+				Convey("it should send the amount in stroops", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+					).Return(
+						horizon.AccountResponse{
+							SequenceNumber: "100",
+							Balances:       []horizon.Balance{{Balance: "100", AssetType: "native"}},
+						},
+						nil,
+					).Once()
+
+					var ledger uint64 = 1988727
+					var submitted xdr.TransactionEnvelope
+					mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+						err := xdr.SafeUnmarshalBase64(args.String(0), &submitted)
+						assert.NoError(t, err)
+					}).Return(horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil).Once()
+					validParams["amount_stroops"] = []string{"1"}
+
+					statusCode, _ := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+					require.Len(t, submitted.Tx.Operations, 1)
+					assert.Equal(t, xdr.Int64(1), submitted.Tx.Operations[0].Body.MustPaymentOp().Amount)
+				})
+
+				Convey("it should return error when amount is also sent", func() {
+					validParams["amount"] = []string{"20"}
+					validParams["amount_stroops"] = []string{"200000000"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					expected := test.StringToJSONMap(`{
+					  "code": "invalid_parameter",
+					  "message": "Invalid parameter.",
+					  "data": {
+					    "name": "amount_stroops"
+					  }
+					}`)
+					assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+				})
+
+				Convey("it should return error when the amount in stroops is above int64 max", func() {
+					validParams["amount_stroops"] = []string{"9223372036854775808"}
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					assert.Equal(t, "amount_stroops", test.StringToJSONMap(string(response))["data"].(map[string]interface{})["name"])
+				})
+			})
+
+			Convey("channels are configured", func() {
+				// GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5
+				pool, err := channels.NewPool(mockHorizon, []string{"SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H"}, 10*time.Millisecond)
+				require.NoError(t, err)
+				requestHandler.Channels = pool
+				Reset(func() {
+					requestHandler.Channels = nil
+				})
+
+				mockHorizon.On(
+					"LoadAccount",
+					"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+				).Return(
+					horizon.AccountResponse{
+						SequenceNumber: "100",
+						Balances:       []horizon.Balance{{Balance: "100", AssetType: "native"}},
+					},
+					nil,
+				).Once()
+
+				Convey("it should send the payment with a channel as the transaction source", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5",
+					).Return(horizon.AccountResponse{SequenceNumber: "500"}, nil).Once()
+
+					var ledger uint64 = 1988727
+					var submitted xdr.TransactionEnvelope
+					mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+						err := xdr.SafeUnmarshalBase64(args.String(0), &submitted)
+						assert.NoError(t, err)
+					}).Return(horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil).Once()
+
+					statusCode, _ := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+					assert.Equal(t, "GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5", submitted.Tx.SourceAccount.Address())
+					assert.Equal(t, xdr.SequenceNumber(501), submitted.Tx.SeqNum)
+					require.Len(t, submitted.Tx.Operations, 1)
+					assert.Equal(t, "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ", submitted.Tx.Operations[0].SourceAccount.Address())
+					assert.Len(t, submitted.Signatures, 2)
+					assert.Equal(t, 1, pool.Free())
+				})
+
+				Convey("it should return error when all channels are leased", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5",
+					).Return(horizon.AccountResponse{SequenceNumber: "500"}, nil).Once()
+					lease, err := pool.Lease()
+					require.NoError(t, err)
+					defer lease.Release()
+
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 503, statusCode)
+					expected := test.StringToJSONMap(`{
+					  "code": "channels_exhausted",
+					  "message": "All channel accounts are in use, please try again later.",
+					  "data": {
+					    "retry_after": 1
+					  }
+					}`)
+					assert.Equal(t, expected, test.StringToJSONMap(string(response)))
+				})
+			})
+		})
+
+		Convey("When params are valid (path payment operation)", func() {
+			validParams := url.Values{
+				// GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ
+				"source":       {"SDWLS4G3XCNIYPKXJWWGGJT6UDY63WV6PEFTWP7JZMQB4RE7EUJQN5XM"},
+				"destination":  {"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"},
+				"amount":       {"20"},
+				"asset_code":   {"USD"},
+				"asset_issuer": {"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"},
+				"send_max":     {"100"},
+			}
+
+			// Source
+			mockHorizon.On(
+				"LoadAccount",
+				"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+			).Return(
+				horizon.AccountResponse{
+					SequenceNumber: "100",
+				},
+				nil,
+			).Once()
+
+			// Destination
+			mockFederationResolver.On(
+				"LookupByAddress",
+				"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+			).Return(
+				&federation.NameResponse{AccountID: "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"},
+				nil,
+			).Once()
+
+			mockHorizon.On(
+				"LoadAccount",
+				"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+			).Return(
+				horizon.AccountResponse{
+					SequenceNumber: "100",
+				},
+				nil,
+			).Once()
+
+			// Paths found for payments without path params
+			pathsTo := func(sourceAmount string, sendAsset b.Asset) {
+				path := horizon.PathResponse{
+					SourceAssetType:        "native",
+					SourceAmount:           sourceAmount,
+					DestinationAssetType:   "credit_alphanum4",
+					DestinationAssetCode:   "USD",
+					DestinationAssetIssuer: "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+					DestinationAmount:      "20.0000000",
+					Path:                   []horizon.PathAsset{},
+				}
+				if !sendAsset.Native {
+					path.SourceAssetType = "credit_alphanum4"
+					path.SourceAssetCode = sendAsset.Code
+					path.SourceAssetIssuer = sendAsset.Issuer
+				}
+				var page horizon.PathsPage
+				page.Embedded.Records = []horizon.PathResponse{path}
+				mockHorizon.On(
+					"LoadPaths",
+					"GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+					"GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+					b.CreditAsset("USD", "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"),
+					"20",
+				).Return(page, nil).Once()
+			}
+
+			Convey("transaction success (send native)", func() {
+				pathsTo("95.0000000", b.NativeAsset())
+
+				var ledger uint64
+				ledger = 1988727
+				horizonResponse := horizon.SubmitTransactionResponse{
+					Hash:   "88214f536658717d5a7d96e449d2fbd96277ce16f3d88dea023e5f20bd37325d",
+					Ledger: &ledger,
+					Extras: nil,
+				}
+
+				mockHorizon.On(
+					"SubmitTransaction",
+					"AAAAAIu7VxM5f9eQ3va0bpvKprxnSHB4zyEnY4D/VzT8Jio3AAAAZAAAAAAAAABlAAAAAAAAAAAAAAABAAAAAAAAAAIAAAAAAAAAADuaygAAAAAA5IVbm6A8mbgc/apAizxmBf4zZmqbedR3Ke+MTa7pjVYAAAABVVNEAAAAAADkhVuboDyZuBz9qkCLPGYF/jNmapt51Hcp74xNrumNVgAAAAAL68IAAAAAAAAAAAAAAAAB/CYqNwAAAECx9I76SOIjCL3pgwZdmFj9KWFzvNL82dt3+Laokh6Zm2v0o1UNq1mQsrqrv0mXM6uNcA96NbkfbogtXauHmwML",
+				).Return(horizonResponse, nil).Once()
+
+				Convey("it should return success", func() {
+					statusCode, response := net.GetResponse(testServer, validParams)
+					responseString := strings.TrimSpace(string(response))
+
+					assert.Equal(t, 200, statusCode)
+					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
+					  "hash": "88214f536658717d5a7d96e449d2fbd96277ce16f3d88dea023e5f20bd37325d",
+					  "ledger": 1988727,
+					  "path": {
+					    "source_asset_type": "native",
+					    "source_amount": "95.0000000",
+					    "destination_asset_type": "credit_alphanum4",
+					    "destination_asset_code": "USD",
+					    "destination_asset_issuer": "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+					    "destination_amount": "20.0000000",
+					    "path": []
+					  }
 					}`)
 					assert.Equal(t, expected, test.StringToJSONMap(responseString))
 				})
@@ -861,6 +1579,7 @@ func TestRequestHandlerPayment(t *testing.T) {
 			Convey("transaction success (send credit)", func() {
 				validParams["send_asset_code"] = []string{"USD"}
 				validParams["send_asset_issuer"] = []string{"GBDOSO3K4JTGSWJSIHXAOFIBMAABVM3YK3FI6VJPKIHHM56XAFIUCGD6"}
+				pathsTo("95.0000000", b.CreditAsset("USD", "GBDOSO3K4JTGSWJSIHXAOFIBMAABVM3YK3FI6VJPKIHHM56XAFIUCGD6"))
 
 				var ledger uint64
 				ledger = 1988727
@@ -881,8 +1600,20 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "8d143f846c2e0ce20364be737c2ebdbcd0da307b4952ec8e91ffcbbc6f51f5ce",
-					  "ledger": 1988727
+					  "ledger": 1988727,
+					  "path": {
+					    "source_asset_type": "credit_alphanum4",
+					    "source_asset_code": "USD",
+					    "source_asset_issuer": "GBDOSO3K4JTGSWJSIHXAOFIBMAABVM3YK3FI6VJPKIHHM56XAFIUCGD6",
+					    "source_amount": "95.0000000",
+					    "destination_asset_type": "credit_alphanum4",
+					    "destination_asset_code": "USD",
+					    "destination_asset_issuer": "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632",
+					    "destination_amount": "20.0000000",
+					    "path": []
+					  }
 					}`)
 					assert.Equal(t, expected, test.StringToJSONMap(responseString))
 				})
@@ -920,14 +1651,239 @@ func TestRequestHandlerPayment(t *testing.T) {
 
 					assert.Equal(t, 200, statusCode)
 					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
 					  "hash": "be2765c309ab6911fe3938de0053672ef541290333a59dfb750f07919e9d6fec",
 					  "ledger": 1988727,
 					  "send_amount": "50.6480800",
-					  "result_xdr": "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAACAAAAAAAAAAEAAAAAC8RjSvPMPWeQWzLq8JEM0BQNo0TfJQN/RwkCeJ+rT+YAAAAAAAAAAwAAAAFaQVIAAAAAAGDBYXf7bGrEkzodp+6aowtAynuEqzKzZRZKO2ftxMtDAAAAAa9EDYAAAAABVVNEAAAAAABstavC6cvn5h86pWOK5996Ape9k8mMM+Fgzqdp6J+9BwAAAAAeMEigAAAAAOj2P+n5SvD0Amrc4BYc6Zo8n6i6idQPeJdfwuvX+FVbAAAAAVpBUgAAAAAAYMFhd/tsasSTOh2n7pqjC0DKe4SrMrNlFko7Z+3Ey0MAAAABr0QNgAAAAAA="
+					  "result_xdr": "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAACAAAAAAAAAAEAAAAAC8RjSvPMPWeQWzLq8JEM0BQNo0TfJQN/RwkCeJ+rT+YAAAAAAAAAAwAAAAFaQVIAAAAAAGDBYXf7bGrEkzodp+6aowtAynuEqzKzZRZKO2ftxMtDAAAAAa9EDYAAAAABVVNEAAAAAABstavC6cvn5h86pWOK5996Ape9k8mMM+Fgzqdp6J+9BwAAAAAeMEigAAAAAOj2P+n5SvD0Amrc4BYc6Zo8n6i6idQPeJdfwuvX+FVbAAAAAVpBUgAAAAAAYMFhd/tsasSTOh2n7pqjC0DKe4SrMrNlFko7Z+3Ey0MAAAABr0QNgAAAAAA=",
+					  "result_codes": {
+					    "transaction": "tx_success",
+					    "operations": ["op_success"]
+					  }
 					}`)
 					assert.Equal(t, expected, test.StringToJSONMap(responseString))
 				})
 			})
+
+			Convey("transaction success (path as JSON)", func() {
+				validParams["send_asset_code"] = []string{"USD"}
+				validParams["send_asset_issuer"] = []string{"GBDOSO3K4JTGSWJSIHXAOFIBMAABVM3YK3FI6VJPKIHHM56XAFIUCGD6"}
+				validParams["path"] = []string{`[{}, {"asset_code": "EUR", "asset_issuer": "GAF3PBFQLH57KPECN4GRGHU5NUZ3XXKYYWLOTBIRJMBYHPUBWANIUCZU"}]`}
+
+				var ledger uint64
+				ledger = 1988727
+				// The same envelope as for path[n] params
+				mockHorizon.On(
+					"SubmitTransaction",
+					"AAAAAIu7VxM5f9eQ3va0bpvKprxnSHB4zyEnY4D/VzT8Jio3AAAAZAAAAAAAAABlAAAAAAAAAAAAAAABAAAAAAAAAAIAAAABVVNEAAAAAABG6Ttq4mZpWTJB7gcVAWAAGrN4VsqPVS9SDnZ31wFRQQAAAAA7msoAAAAAAOSFW5ugPJm4HP2qQIs8ZgX+M2Zqm3nUdynvjE2u6Y1WAAAAAVVTRAAAAAAA5IVbm6A8mbgc/apAizxmBf4zZmqbedR3Ke+MTa7pjVYAAAAAC+vCAAAAAAIAAAAAAAAAAUVVUgAAAAAAC7eEsFn79TyCbw0THp1tM7vdWMWW6YURSwODvoGwGooAAAAAAAAAAfwmKjcAAABAyO0YxnfaIdY51J9BaPyZYNxsBY2AhWCZpK6FRlaE+ZbdmznZ9cio2G7+fJgl3hWZUrQknQHElmzAZdgsqNnZAQ==",
+				).Return(horizon.SubmitTransactionResponse{Hash: "be2765c309ab6911fe3938de0053672ef541290333a59dfb750f07919e9d6fec", Ledger: &ledger}, nil).Once()
+
+				Convey("it should return success", func() {
+					statusCode, _ := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 200, statusCode)
+				})
+			})
+
+			Convey("When slippage check is enabled", func() {
+				c.PathPayments = config.PathPayments{SlippageCheckThreshold: "10", MaxSlippage: "0.01"}
+				Reset(func() {
+					c.PathPayments = config.PathPayments{}
+				})
+				pathsTo("100.0000000", b.NativeAsset())
+
+				mockHorizon.On(
+					"LoadOrderBook",
+					b.CreditAsset("USD", "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"),
+					b.NativeAsset(),
+				).Return(
+					horizon.OrderBookResponse{
+						Asks: []horizon.OrderBookLevel{
+							{Price: "5", Amount: "10"},
+							{Price: "6", Amount: "100"},
+						},
+					},
+					nil,
+				).Once()
+
+				Convey("it should return error when order book is too thin", func() {
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					expected := test.StringToJSONMap(`{
+					  "code": "payment_excessive_slippage",
+					  "message": "Estimated price of the path payment exceeds allowed slippage.",
+					  "data": {
+					    "estimated_price": "5.5000000",
+					    "limit_price": "5.0500000"
+					  }
+					}`)
+					assert.Equal(t, expected, test.StringToJSONMap(string(response)))
+				})
+			})
+
+			Convey("When skip_slippage_check is set by a client", func() {
+				validParams["skip_slippage_check"] = []string{"true"}
+
+				Convey("it should return error", func() {
+					statusCode, response := net.GetResponse(testServer, validParams)
+					assert.Equal(t, 400, statusCode)
+					expected := test.StringToJSONMap(`{
+					  "code": "invalid_parameter",
+					  "message": "Invalid parameter.",
+					  "data": {
+					    "name": "skip_slippage_check"
+					  }
+					}`)
+					assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+				})
+			})
+		})
+	})
+
+	Convey("Given multi-asset payment request", t, func() {
+		eurtIssuer := "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"
+		usdcIssuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+		destination := "GB3W7VQ2A2IOQIS4LUFUMRC2DWXONUDH24ROLE6RS4NGUNHVSXKCABOM"
+		params := url.Values{
+			"type":                    {"multi_asset"},
+			"destination":             {"partner*example.com"},
+			"assets[0][asset_code]":   {"EURT"},
+			"assets[0][asset_issuer]": {eurtIssuer},
+			"assets[0][amount]":       {"150"},
+			"assets[1][asset_code]":   {"USDC"},
+			"assets[1][asset_issuer]": {usdcIssuer},
+			"assets[1][amount]":       {"200.5"},
+		}
+		trustline := func(code, issuer, balance string) horizon.Balance {
+			return horizon.Balance{Balance: balance, AssetType: "credit_alphanum4", AssetCode: code, AssetIssuer: issuer}
+		}
+		source := horizon.AccountResponse{
+			SequenceNumber: "100",
+			Balances: []horizon.Balance{
+				{Balance: "10", AssetType: "native"},
+				trustline("EURT", eurtIssuer, "1000"),
+				trustline("USDC", usdcIssuer, "1000"),
+			},
+		}
+
+		Convey("When single-asset params are set", func() {
+			params.Set("amount", "20")
+
+			statusCode, response := net.GetResponse(testServer, params)
+			assert.Equal(t, 400, statusCode)
+			expected := test.StringToJSONMap(`{
+			  "code": "invalid_parameter",
+			  "message": "Invalid parameter.",
+			  "data": {
+			    "name": "amount"
+			  }
+			}`)
+			assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+		})
+
+		Convey("When the same asset is sent twice", func() {
+			params.Set("assets[1][asset_code]", "EURT")
+			params.Set("assets[1][asset_issuer]", eurtIssuer)
+
+			statusCode, response := net.GetResponse(testServer, params)
+			assert.Equal(t, 400, statusCode)
+			assert.Equal(t, "assets[1][asset_code]", test.StringToJSONMap(string(response))["data"].(map[string]interface{})["name"])
+		})
+
+		Convey("When params are valid", func() {
+			mockFederationResolver.On("LookupByAddress", "partner*example.com").Return(
+				&federation.NameResponse{AccountID: destination, MemoType: "id", Memo: federation.Memo{"42"}},
+				nil,
+			).Once()
+			mockHorizon.On("LoadAccount", "GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ").Return(source, nil).Once()
+
+			Convey("it should send all assets in one transaction", func() {
+				mockHorizon.On("LoadAccount", destination).Return(horizon.AccountResponse{
+					Balances: []horizon.Balance{trustline("EURT", eurtIssuer, "0"), trustline("USDC", usdcIssuer, "0")},
+				}, nil).Once()
+
+				var ledger uint64 = 1988727
+				var submitted xdr.TransactionEnvelope
+				mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+					assert.NoError(t, xdr.SafeUnmarshalBase64(args.String(0), &submitted))
+				}).Return(horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil).Once()
+
+				statusCode, response := net.GetResponse(testServer, params)
+				assert.Equal(t, 200, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "hash": "6a3b",
+				  "ledger": 1988727,
+				  "results": [
+				    {"asset_code": "EURT", "asset_issuer": "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632", "amount": "150", "status": "success"},
+				    {"asset_code": "USDC", "asset_issuer": "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ", "amount": "200.5", "status": "success"}
+				  ]
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response)))
+
+				operations := submitted.Tx.Operations
+				if assert.Len(t, operations, 2) {
+					assert.Equal(t, destination, operations[1].Body.PaymentOp.Destination.Address())
+					assert.Equal(t, xdr.Int64(2005000000), operations[1].Body.PaymentOp.Amount)
+					var code string
+					operations[0].Body.PaymentOp.Asset.Extract(new(xdr.AssetType), &code, new(string))
+					assert.Equal(t, "EURT", code)
+				}
+				assert.Equal(t, xdr.MemoTypeMemoId, submitted.Tx.Memo.Type)
+				assert.Equal(t, xdr.Uint64(42), *submitted.Tx.Memo.Id)
+			})
+
+			Convey("it should not submit when the destination cannot receive an asset", func() {
+				mockHorizon.On("LoadAccount", destination).Return(horizon.AccountResponse{
+					Balances: []horizon.Balance{trustline("EURT", eurtIssuer, "0")},
+				}, nil).Once()
+
+				statusCode, response := net.GetResponse(testServer, params)
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "multi_asset_payment_failed",
+				  "message": "At least one asset cannot be sent. No asset has been sent.",
+				  "data": {
+				    "results": [
+				      {"asset_code": "EURT", "asset_issuer": "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632", "amount": "150", "status": "not_submitted"},
+				      {
+				        "asset_code": "USDC", "asset_issuer": "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ", "amount": "200.5", "status": "failed",
+				        "error": {"code": "payment_no_trust", "message": "Destination missing a trust line for asset."}
+				      }
+				    ]
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response)))
+			})
+
+			Convey("it should report results of operations when the transaction fails", func() {
+				mockHorizon.On("LoadAccount", destination).Return(horizon.AccountResponse{
+					Balances: []horizon.Balance{trustline("EURT", eurtIssuer, "0"), trustline("USDC", usdcIssuer, "0")},
+				}, nil).Once()
+
+				paymentResult := func(code xdr.PaymentResultCode) xdr.OperationResult {
+					return xdr.OperationResult{Tr: &xdr.OperationResultTr{Type: xdr.OperationTypePayment, PaymentResult: &xdr.PaymentResult{Code: code}}}
+				}
+				resultXdr, err := xdr.MarshalBase64(xdr.TransactionResult{
+					FeeCharged: 200,
+					Result: xdr.TransactionResultResult{
+						Code:    xdr.TransactionResultCodeTxFailed,
+						Results: &[]xdr.OperationResult{paymentResult(xdr.PaymentResultCodePaymentSuccess), paymentResult(xdr.PaymentResultCodePaymentLineFull)},
+					},
+				})
+				require.NoError(t, err)
+				mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(
+					horizon.SubmitTransactionResponse{Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: resultXdr}},
+					nil,
+				).Once()
+
+				statusCode, response := net.GetResponse(testServer, params)
+				assert.Equal(t, 400, statusCode)
+				results := test.StringToJSONMap(string(response))["data"].(map[string]interface{})["results"].([]interface{})
+				if assert.Len(t, results, 2) {
+					assert.Equal(t, "multi_asset_payment_rolled_back", results[0].(map[string]interface{})["error"].(map[string]interface{})["code"])
+					assert.Equal(t, "payment_line_full", results[1].(map[string]interface{})["error"].(map[string]interface{})["code"])
+					assert.Equal(t, "failed", results[1].(map[string]interface{})["status"])
+				}
+			})
 		})
 	})
 
@@ -1154,10 +2110,241 @@ func TestRequestHandlerPayment(t *testing.T) {
 				assert.Equal(t, 500, statusCode)
 				expected := test.StringToJSONMap(`{
 					"code": "internal_server_error",
-					"message": "Internal Server Error, please try again."
+					"message": "Internal Server Error, please try again.",
+					"data": {
+						"operation_type": "payment"
+					}
 				}`)
 				assert.Equal(t, expected, test.StringToJSONMap(responseString))
 			})
 		})
+		Convey("When uri is set", func() {
+			// URI_REQUEST_SIGNING_KEY of example.com
+			signer, err := keypair.Parse("SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J")
+			So(err, ShouldBeNil)
+
+			signURI := func(uri string) string {
+				signature, err := signer.(*keypair.Full).Sign(bridge.PayURISignaturePayload(uri))
+				So(err, ShouldBeNil)
+				return uri + "&signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+			}
+
+			invalidURIParam := test.StringToJSONMap(`{
+  "code": "invalid_parameter",
+  "message": "Invalid parameter.",
+  "data": {
+    "name": "uri"
+  }
+}`)
+
+			Convey("it should return error when uri is unsigned", func() {
+				params := url.Values{
+					"source": {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+					"uri":    {"web+stellar:pay?destination=GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS&amount=20"},
+				}
+
+				statusCode, response := net.GetResponse(testServer, params)
+				assert.Equal(t, 400, statusCode)
+				assert.Equal(t, invalidURIParam, test.StringToJSONMap(strings.TrimSpace(string(response)), "more_info"))
+			})
+
+			Convey("it should return error when uri has callback", func() {
+				params := url.Values{
+					"source": {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+					"uri":    {"web+stellar:pay?destination=GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS&callback=url%3Ahttps%3A%2F%2Fexample.com"},
+				}
+
+				statusCode, response := net.GetResponse(testServer, params)
+				assert.Equal(t, 400, statusCode)
+				assert.Equal(t, invalidURIParam, test.StringToJSONMap(strings.TrimSpace(string(response)), "more_info"))
+			})
+
+			Convey("it should return error when uri is for other network", func() {
+				params := url.Values{
+					"source": {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+					"uri":    {"web+stellar:pay?destination=GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS&network_passphrase=Public+Global+Stellar+Network+%3B+September+2015"},
+				}
+
+				statusCode, response := net.GetResponse(testServer, params)
+				assert.Equal(t, 400, statusCode)
+				assert.Equal(t, invalidURIParam, test.StringToJSONMap(strings.TrimSpace(string(response)), "more_info"))
+			})
+
+			Convey("When uri is signed", func() {
+				mockHTTPClient.On(
+					"Get",
+					"https://example.com/.well-known/stellar.toml",
+				).Return(
+					net.BuildHTTPResponse(200, `URI_REQUEST_SIGNING_KEY="`+signer.Address()+`"`),
+					nil,
+				).Once()
+
+				Convey("it should return error when signature is invalid", func() {
+					uri := signURI("web+stellar:pay?destination=GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS&amount=20&origin_domain=example.com")
+					params := url.Values{
+						"source": {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+						"uri":    {strings.Replace(uri, "amount=20", "amount=200", 1)},
+					}
+
+					statusCode, response := net.GetResponse(testServer, params)
+					assert.Equal(t, 400, statusCode)
+					assert.Equal(t, invalidURIParam, test.StringToJSONMap(strings.TrimSpace(string(response)), "more_info"))
+				})
+
+				Convey("it should use request params and return warnings", func() {
+					params := url.Values{
+						// GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW
+						"source": {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+						"amount": {"20.0"},
+						"uri":    {signURI("web+stellar:pay?destination=GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS&amount=30&origin_domain=example.com")},
+					}
+
+					mockHorizon.On(
+						"LoadAccount",
+						"GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW",
+					).Return(
+						horizon.AccountResponse{
+							SequenceNumber: "100",
+						},
+						nil,
+					).Once()
+
+					mockHorizon.On(
+						"LoadAccount",
+						"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS",
+					).Return(horizon.AccountResponse{}, nil).Once()
+
+					var ledger uint64
+					ledger = 1988728
+					mockHorizon.On(
+						"SubmitTransaction",
+						"AAAAAFRj/hmos6yDrqGzKZytvMJ17Y8SpCCmgIOGZ7LP+5jIAAAAZAAAAAAAAABlAAAAAAAAAAAAAAABAAAAAAAAAAEAAAAAHinv2ogmGid/i3THKgjKzySx29sYKUaXnM6DVHizim4AAAAAAAAAAAvrwgAAAAAAAAAAAc/7mMgAAABAh6unGAOSOD3+9vbZXHwhDq4xdp/hl4MqZu0VVdLwldKPVy9MpXstDDxnNBBBzU48Hto+jH3qL73bbu+7zVXvCQ==",
+					).Return(horizon.SubmitTransactionResponse{
+						Hash:   "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a",
+						Ledger: &ledger,
+					}, nil).Once()
+
+					statusCode, response := net.GetResponse(testServer, params)
+					assert.Equal(t, 200, statusCode)
+					expected := test.StringToJSONMap(`{
+					  "attempts": 1,
+					  "hash": "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a",
+					  "ledger": 1988728,
+					  "warnings": ["amount from the request was used instead of the uri value 30"]
+					}`)
+					assert.Equal(t, expected, test.StringToJSONMap(strings.TrimSpace(string(response))))
+				})
+			})
+		})
+	})
+}
+
+func TestRequestHandlerPaymentCounterparties(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-counterparties")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	denyFile := filepath.Join(dir, "deny.csv")
+	require.NoError(t, ioutil.WriteFile(denyFile, []byte("domain\nblocked.example.com\n"), 0600))
+
+	lists, err := counterparty.NewLists(counterparty.Settings{DenyFile: denyFile}, time.Now)
+	require.NoError(t, err)
+	mockFederationResolver := new(mocks.MockFederationResolver)
+	requestHandler := RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Accounts:          config.Accounts{BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"},
+		},
+		FederationResolver: mockFederationResolver,
+		Counterparties:     lists,
+	}
+	testServer := httptest.NewServer(http.HandlerFunc(requestHandler.Payment))
+	defer testServer.Close()
+
+	statusCode, response := net.GetResponse(testServer, url.Values{
+		"destination": {"alice*pay.blocked.example.com"},
+		"amount":      {"20"},
 	})
+	assert.Equal(t, http.StatusForbidden, statusCode)
+	assert.Equal(t, "counterparty_not_allowed", test.StringToJSONMap(string(response))["code"])
+	mockFederationResolver.AssertNotCalled(t, "LookupByAddress", mock.Anything)
+}
+
+func TestOperationBuildError(t *testing.T) {
+	request := &bridge.PaymentRequest{Amount: "20", AssetCode: "USD", SendMax: "1.x"}
+	errorResponse := operationBuildError(request, &txspec.OperationError{Index: 1, Type: txspec.Payment, Err: txspec.ErrInvalidAmount})
+	assert.Equal(t, bridge.PaymentInvalidAmount.Code, errorResponse.Code)
+	assert.Equal(t, map[string]interface{}{"name": "send_max"}, errorResponse.Data)
+
+	errorResponse = operationBuildError(request, &txspec.OperationError{Type: txspec.Payment, Err: txspec.ErrInvalidAssetCode})
+	assert.Equal(t, bridge.PaymentMalformedAssetCode.Code, errorResponse.Code)
+
+	errorResponse = operationBuildError(request, &txspec.OperationError{Type: txspec.Payment, Err: errors.New("invalid destination")})
+	assert.Equal(t, bridge.PaymentMalformed, errorResponse)
+
+	// Destination created by the payment
+	request = &bridge.PaymentRequest{Amount: "20", StartingBalance: "2.x"}
+	errorResponse = operationBuildError(request, &txspec.OperationError{Type: txspec.CreateAccount, Err: txspec.ErrInvalidAmount})
+	assert.Equal(t, bridge.CreateAccountInvalidAmount.Code, errorResponse.Code)
+	assert.Equal(t, map[string]interface{}{"name": "starting_balance"}, errorResponse.Data)
+
+	errorResponse = operationBuildError(request, &txspec.OperationError{Type: txspec.CreateAccount, Err: errors.New("invalid destination")})
+	assert.Equal(t, bridge.CreateAccountMalformed, errorResponse)
+}
+
+func TestRequestHandlerPaymentJSON(t *testing.T) {
+	requestHandler := RequestHandler{
+		Config: &config.Config{
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Accounts:          config.Accounts{BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"},
+		},
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/payment", PaymentJSONMiddleware(http.HandlerFunc(requestHandler.Payment)))
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	post := func(body string) (int, map[string]interface{}) {
+		res, err := http.Post(testServer.URL+"/payment", "application/json; charset=utf-8", strings.NewReader(body))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		response, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, test.StringToJSONMap(string(response))
+	}
+
+	// Errors are equal to errors of the same form params
+	statusCode, response := post(`{
+  "source": "SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX43",
+  "destination": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET",
+  "amount": "20.0",
+  "memo": "order 42",
+  "asset_code": "USD",
+  "asset_isuer": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"
+}`)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, test.StringToJSONMap(`{
+  "code": "validation_failed",
+  "message": "Request has more than one invalid parameter, see errors.",
+  "errors": [
+    {"field": "asset_isuer", "code": "invalid_parameter", "message": "Unknown parameter."},
+    {"field": "source", "code": "invalid_parameter", "message": "Source must be a public key (starting with `+"`G`"+`)."},
+    {"field": "memo_type", "code": "missing_parameter", "message": "Required parameter is missing."},
+    {"field": "asset_issuer", "code": "missing_parameter", "message": "Required parameter is missing."}
+  ]
+}`), response)
+
+	statusCode, response = post(`{"destination": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"}`)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, "missing_parameter", response["code"])
+	assert.Equal(t, map[string]interface{}{"name": "amount"}, response["data"])
+
+	statusCode, response = post(`{"destination": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET", "amount": 20}`)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, "invalid_parameter", response["code"])
+	assert.Equal(t, map[string]interface{}{"name": "amount"}, response["data"])
+	assert.Equal(t, "Value must be a JSON string.", response["more_info"])
+
+	statusCode, response = post(`["destination"]`)
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	assert.Equal(t, "invalid_parameter", response["code"])
 }
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// contextHorizon records contexts of account loads and submissions
+type contextHorizon struct {
+	*mocks.MockHorizon
+	loads       []context.Context
+	submissions []context.Context
+}
+
+func (h *contextHorizon) LoadAccountCtx(ctx context.Context, accountID string) (horizon.AccountResponse, error) {
+	h.loads = append(h.loads, ctx)
+	return h.LoadAccount(accountID)
+}
+
+func (h *contextHorizon) SubmitTransactionCtx(ctx context.Context, txeBase64 string) (horizon.SubmitTransactionResponse, error) {
+	h.submissions = append(h.submissions, ctx)
+	return h.SubmitTransaction(txeBase64)
+}
+
+func TestRequestHandlerPaymentHorizonTimeout(t *testing.T) {
+	seed := testBaseSeed
+	destination := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+
+	var h *contextHorizon
+	pay := func(ctx context.Context) (int, map[string]interface{}) {
+		requestHandler := newTestRequestHandler(h)
+		params := url.Values{"destination": {destination}, "amount": {"20"}}
+		return servePayment(&requestHandler, newPaymentRequest(t, params).WithContext(ctx))
+	}
+	ctx := context.WithValue(context.Background(), contextKey("test"), "payment")
+
+	t.Run("account load times out", func(t *testing.T) {
+		h = &contextHorizon{MockHorizon: new(mocks.MockHorizon)}
+		h.On("LoadAccount", destination).Return(horizon.AccountResponse{SequenceNumber: "5"}, nil)
+		h.On("LoadAccount", keypair.MustParse(seed).Address()).Return(horizon.AccountResponse{}, &horizon.TimeoutError{Endpoint: "accounts", Timeout: 30 * time.Second})
+
+		status, response := pay(ctx)
+		assert.Equal(t, http.StatusGatewayTimeout, status)
+		assert.Equal(t, "horizon_timeout", response["code"])
+		assert.Equal(t, map[string]interface{}{"endpoint": "accounts"}, response["data"])
+		require.Len(t, h.loads, 2)
+		for _, loadCtx := range h.loads {
+			assert.Equal(t, "payment", loadCtx.Value(contextKey("test")))
+		}
+		h.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+
+	t.Run("disconnected client", func(t *testing.T) {
+		h = &contextHorizon{MockHorizon: new(mocks.MockHorizon)}
+		h.On("LoadAccount", destination).Return(horizon.AccountResponse{}, context.Canceled)
+
+		// The destination is not taken as missing, a create_account operation is not sent
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		status, _ := pay(cancelled)
+		assert.Equal(t, http.StatusInternalServerError, status)
+		require.Len(t, h.loads, 1)
+		assert.Equal(t, context.Canceled, h.loads[0].Err())
+		h.AssertNotCalled(t, "SubmitTransaction", mock.Anything)
+	})
+
+	t.Run("submission times out", func(t *testing.T) {
+		h = &contextHorizon{MockHorizon: new(mocks.MockHorizon)}
+		h.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "5"}, nil)
+		h.On("SubmitTransaction", mock.AnythingOfType("string")).Return(horizon.SubmitTransactionResponse{}, &horizon.TimeoutError{Endpoint: "submit_transaction", Timeout: 30 * time.Second})
+
+		status, response := pay(ctx)
+		assert.Equal(t, http.StatusGatewayTimeout, status)
+		assert.Equal(t, "horizon_timeout", response["code"])
+		assert.Contains(t, response["more_info"], "Outcome of the submission is unknown")
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "submit_transaction", data["endpoint"])
+		assert.NotEmpty(t, data["hash"])
+		require.Len(t, h.submissions, 1)
+		assert.Equal(t, "payment", h.submissions[0].Value(contextKey("test")))
+	})
+}
+
+type contextKey string
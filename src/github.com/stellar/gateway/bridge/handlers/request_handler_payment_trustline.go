@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/go/amount"
+)
+
+// checkDestinationTrustline loads the destination account and returns PaymentNoTrust error when it
+// does not trust a credit asset and PaymentLineFull error when the trustline limit does not leave
+// room for the amount, so these payments fail before they are built instead of after submission.
+// The issuer receives its own asset without a trustline. A destination that cannot be loaded
+// cannot receive a credit asset, PaymentNoDestination is returned then. Errors of a Horizon that
+// is not available and of a cancelled request are returned as they are.
+func (rh *RequestHandler) checkDestinationTrustline(destination, assetCode, assetIssuer, value string) error {
+	if destination == assetIssuer {
+		return nil
+	}
+
+	account, err := rh.Horizon.LoadAccountCtx(rh.context(), destination)
+	if isLookupAborted(err) {
+		return err
+	}
+	rh.observeAccount(destination, account, err)
+	if err != nil {
+		return bridge.PaymentNoDestination
+	}
+
+	balance, ok := account.GetBalance(assetCode, assetIssuer)
+	if !ok {
+		return bridge.NewPaymentNoTrustError(assetCode, assetIssuer)
+	}
+
+	limit, err := amount.Parse(balance.Limit)
+	if err != nil {
+		// Horizon omitted the limit, the submission reports a full line
+		return nil
+	}
+	current, err := amount.Parse(balance.Balance)
+	if err != nil {
+		return nil
+	}
+	sent, err := amount.Parse(value)
+	if err != nil {
+		return nil
+	}
+	if available := limit - current; sent > available {
+		return bridge.NewPaymentLineFullError(assetCode, assetIssuer, amount.String(available))
+	}
+	return nil
+}
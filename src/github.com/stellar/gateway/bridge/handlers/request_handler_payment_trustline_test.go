@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRequestHandlerCheckDestinationTrustline(t *testing.T) {
+	destination := "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	check := func(account horizon.AccountResponse, err error, value string) error {
+		mockHorizon := new(mocks.MockHorizon)
+		mockHorizon.On("LoadAccount", destination).Return(account, err)
+		requestHandler := RequestHandler{Horizon: mockHorizon}
+		return requestHandler.checkDestinationTrustline(destination, "USD", issuer, value)
+	}
+
+	assert.NoError(t, check(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: issuer}), nil, "100"))
+
+	err := check(accountTrusting("100", protocols.Asset{Code: "EUR", Issuer: issuer}), nil, "100")
+	if assert.IsType(t, &protocols.ErrorResponse{}, err) {
+		errorResponse := err.(*protocols.ErrorResponse)
+		assert.Equal(t, bridge.PaymentNoTrust.Code, errorResponse.Code)
+		assert.Equal(t, map[string]interface{}{"asset_code": "USD", "asset_issuer": issuer}, errorResponse.Data)
+	}
+
+	account := accountTrusting("100", protocols.Asset{Code: "USD", Issuer: issuer})
+	account.Balances[0].Balance = "950"
+	account.Balances[0].Limit = "1000"
+	assert.NoError(t, check(account, nil, "50"))
+	err = check(account, nil, "50.0000001")
+	if assert.IsType(t, &protocols.ErrorResponse{}, err) {
+		errorResponse := err.(*protocols.ErrorResponse)
+		assert.Equal(t, bridge.PaymentLineFull.Code, errorResponse.Code)
+		assert.Equal(t, "50.0000000", errorResponse.Data["available"])
+	}
+
+	assert.Equal(t, bridge.PaymentNoDestination, check(horizon.AccountResponse{}, &horizon.StatusError{StatusCode: http.StatusNotFound}, "100"))
+
+	limited := &horizon.RateLimitedError{}
+	assert.Equal(t, limited, check(horizon.AccountResponse{}, limited, "100"))
+
+	// Issuer does not need a trustline of its asset
+	requestHandler := RequestHandler{Horizon: new(mocks.MockHorizon)}
+	assert.NoError(t, requestHandler.checkDestinationTrustline(issuer, "USD", issuer, "100"))
+}
+
+func TestRequestHandlerPaymentTrustCheck(t *testing.T) {
+	destination := "GDSIKW43UA6JTOA47WVEBCZ4MYC74M3GNKNXTVDXFHXYYTNO5GGVN632"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	var mockHorizon *mocks.MockHorizon
+	pay := func(params url.Values) (int, map[string]interface{}) {
+		mockHorizon = new(mocks.MockHorizon)
+		mockHorizon.On("LoadAccount", destination).Return(horizon.AccountResponse{SequenceNumber: "1"}, nil)
+		mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+		ledger := uint64(1988727)
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(
+			horizon.SubmitTransactionResponse{Hash: "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", Ledger: &ledger}, nil,
+		)
+		requestHandler := newTestRequestHandler(mockHorizon)
+		return postPayment(t, &requestHandler, params)
+	}
+	params := url.Values{
+		"destination":  {destination},
+		"amount":       {"20"},
+		"asset_code":   {"USD"},
+		"asset_issuer": {issuer},
+	}
+
+	status, response := pay(params)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "payment_no_trust", response["code"])
+	mockHorizon.AssertNotCalled(t, "SubmitTransaction", mock.AnythingOfType("string"))
+
+	params.Set("skip_trust_check", "true")
+	status, response = pay(params)
+	assert.Equal(t, http.StatusOK, status, response)
+	mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 1)
+}
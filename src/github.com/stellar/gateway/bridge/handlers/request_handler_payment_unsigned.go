@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/go/xdr"
+)
+
+// unsignedPayment returns true when the source of a payment is a public key and no `signers` are
+// sent. Its transaction is returned unsigned instead of being submitted, ex. to be signed by a HSM.
+func unsignedPayment(request *bridge.PaymentRequest) bool {
+	return protocols.IsValidAccountID(request.Source) && len(request.Signers) == 0
+}
+
+// writeUnsignedPayment writes the envelope of a built payment transaction without signatures.
+// Unsigned transactions are not stored, they are not sent by the server.
+func (rh *RequestHandler) writeUnsignedPayment(
+	w http.ResponseWriter,
+	tx *xdr.Transaction,
+	paymentOperationIndex int,
+	warnings []string,
+	logger *log.Entry,
+) {
+	hash, err := submitter.TransactionHash(tx, rh.Config.NetworkPassphrase)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot hash transaction")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	envelope, err := submitter.SignEnvelope(tx, rh.Config.NetworkPassphrase)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Cannot encode transaction envelope")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	response := &bridge.UnsignedPaymentResponse{
+		Status:           bridge.PaymentStatusUnsigned,
+		Hash:             hex.EncodeToString(hash[:]),
+		EnvelopeXdr:      envelope,
+		TrustlineCreated: paymentOperationIndex > 0,
+		Warnings:         warnings,
+	}
+	if tx.TimeBounds != nil {
+		response.TimeBounds = &txspec.TimeBounds{MinTime: uint64(tx.TimeBounds.MinTime), MaxTime: uint64(tx.TimeBounds.MaxTime)}
+	}
+	logger.WithFields(log.Fields{"hash": response.Hash}).Info("Returning unsigned transaction of public key source")
+	server.Write(w, response)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPaymentUnsigned(t *testing.T) {
+	passphrase := "Test SDF Network ; September 2015"
+	source := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	mockHorizon := new(mocks.MockHorizon)
+	requestHandler := RequestHandler{
+		Config:  &config.Config{NetworkPassphrase: passphrase},
+		Horizon: mockHorizon,
+	}
+
+	mockHorizon.On("LoadAccount", source).Return(horizon.AccountResponse{SequenceNumber: "100"}, nil)
+	mockHorizon.On("LoadAccount", "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS").Return(accountTrusting("1", protocols.Asset{Code: "USD", Issuer: source}), nil)
+
+	params := url.Values{
+		"source":       {source},
+		"destination":  {"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+		"amount":       {"20"},
+		"asset_code":   {"USD"},
+		"asset_issuer": {source},
+		"memo_type":    {"id"},
+		"memo":         {"123"},
+	}
+	status, body := postPayment(t, &requestHandler, params)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, bridge.PaymentStatusUnsigned, body["status"])
+	mockHorizon.AssertNumberOfCalls(t, "SubmitTransaction", 0)
+
+	var envelope xdr.TransactionEnvelope
+	require.NoError(t, xdr.SafeUnmarshalBase64(body["envelope_xdr"].(string), &envelope))
+	assert.Empty(t, envelope.Signatures)
+	assert.Equal(t, source, envelope.Tx.SourceAccount.Address())
+	assert.Equal(t, xdr.SequenceNumber(101), envelope.Tx.SeqNum)
+	assert.Equal(t, xdr.Uint64(123), *envelope.Tx.Memo.Id)
+	require.Len(t, envelope.Tx.Operations, 1)
+	assert.Equal(t, xdr.OperationTypePayment, envelope.Tx.Operations[0].Body.Type)
+
+	hash, err := submitter.TransactionHash(&envelope.Tx, passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(hash[:]), body["hash"])
+}
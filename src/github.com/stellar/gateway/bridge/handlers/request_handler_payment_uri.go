@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/go/clients/stellartoml"
+)
+
+// applyPayURI merges a SEP-7 `uri` param into the /payment request. Signed URIs are verified with
+// the URI_REQUEST_SIGNING_KEY of origin_domain, unsigned URIs are accepted only when
+// allow_unsigned_pay_uris is set. It returns conflict warnings.
+func (rh *RequestHandler) applyPayURI(request *bridge.PaymentRequest) ([]string, *protocols.ErrorResponse) {
+	uri, err := bridge.ParsePayURI(request.URI)
+	if err != nil {
+		return nil, protocols.NewInvalidParameterError("uri", request.URI, err.Error())
+	}
+
+	if uri.Callback != "" {
+		return nil, protocols.NewInvalidParameterError("uri", request.URI, "URIs with callback are not supported, the bridge server submits transactions itself.")
+	}
+
+	if uri.NetworkPassphrase != "" && uri.NetworkPassphrase != rh.Config.NetworkPassphrase {
+		return nil, protocols.NewInvalidParameterError("uri", request.URI, "URI network_passphrase does not match the network of the bridge server.")
+	}
+
+	if uri.IsSigned() {
+		signingKey, err := rh.uriRequestSigningKey(uri.OriginDomain)
+		if err != nil {
+			return nil, protocols.NewInvalidParameterError("uri", request.URI, "Cannot load URI_REQUEST_SIGNING_KEY of origin_domain.", map[string]interface{}{"err": err})
+		}
+
+		err = uri.VerifySignature(signingKey)
+		if err != nil {
+			return nil, protocols.NewInvalidParameterError("uri", request.URI, err.Error())
+		}
+	} else if !rh.Config.AllowUnsignedPayURIs {
+		return nil, protocols.NewInvalidParameterError("uri", request.URI, "Unsigned URIs are not allowed.")
+	}
+
+	return request.MergePayURI(uri), nil
+}
+
+// uriRequestSigningKey loads URI_REQUEST_SIGNING_KEY from stellar.toml of a domain
+func (rh *RequestHandler) uriRequestSigningKey(domain string) (string, error) {
+	if domain == "" {
+		return "", errors.New("origin_domain is missing")
+	}
+
+	resp, err := rh.Client.Get("https://" + domain + stellartoml.WellKnownPath)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("stellar.toml request failed with status %d", resp.StatusCode)
+	}
+
+	var stellarToml struct {
+		URIRequestSigningKey string `toml:"URI_REQUEST_SIGNING_KEY"`
+	}
+	_, err = toml.DecodeReader(io.LimitReader(resp.Body, stellartoml.StellarTomlMaxSize), &stellarToml)
+	if err != nil {
+		return "", err
+	}
+
+	if stellarToml.URIRequestSigningKey == "" {
+		return "", errors.New("stellar.toml does not contain URI_REQUEST_SIGNING_KEY")
+	}
+	return stellarToml.URIRequestSigningKey, nil
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/txspec"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+// preauthSignerType is the type of pre-authorized transaction signers returned by Horizon
+const preauthSignerType = "preauth_tx"
+
+// Preauth implements /preauth endpoint. It builds a recovery payment transaction and adds its
+// hash as a pre-authorized transaction signer to both the payment source and the recovery account.
+//
+// A pre-authorized transaction is only valid with the exact sequence number it was built with. The
+// payment source (hot wallet) keeps sending transactions so its sequence number can't be reserved.
+// Instead the recovery transaction uses a dedicated account (`accounts.recovery_seed`) as the
+// transaction source and the hot wallet as the operation source. Signers are added to both accounts
+// by a single transaction of the recovery account consuming sequence number N+1, so the recovery
+// transaction is built with N+2. The recovery account must not be used for anything else, otherwise
+// the stored envelope becomes invalid. Preauth consumes the sequence number of an earlier recovery
+// envelope too, so it's refused while the recovery account has a pre-authorized transaction signer
+// unless `replace=true` is sent.
+func (rh *RequestHandler) Preauth(w http.ResponseWriter, r *http.Request) {
+	rh, ok := rh.withCorrelationID(w, r)
+	if !ok {
+		return
+	}
+
+	request := &bridge.PreauthRequest{}
+	err := request.FromRequest(r)
+	if err != nil {
+		log.Error(err.Error())
+		server.Write(w, protocols.InvalidParameterError)
+		return
+	}
+
+	err = request.Validate()
+	if err != nil {
+		errorResponse := err.(*protocols.ErrorResponse)
+		log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, errorResponse)
+		return
+	}
+
+	if request.Source == "" {
+		request.Source = rh.Config.Accounts.BaseSeed
+	}
+
+	sourceKeypair, err := keypair.Parse(request.Source)
+	if err != nil {
+		server.Write(w, protocols.NewMissingParameter("source"))
+		return
+	}
+
+	recoveryKeypair, _ := keypair.Parse(rh.Config.Accounts.RecoverySeed)
+	recoveryAccount, err := rh.Horizon.LoadAccount(recoveryKeypair.Address())
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Cannot load recovery account")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	if !request.Replace {
+		for _, accountSigner := range recoveryAccount.Signers {
+			if accountSigner.Type == preauthSignerType {
+				log.WithFields(log.Fields{"signer": accountSigner.Key}).Warn("Recovery envelope is outstanding")
+				server.Write(w, bridge.PreauthOutstanding)
+				return
+			}
+		}
+	}
+
+	sequenceNumber, err := strconv.ParseUint(recoveryAccount.SequenceNumber, 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Cannot convert SequenceNumber")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	// sequenceNumber+1 is used by set_options transaction below
+	sequenceNumber += 2
+
+	// Pre-authorized transactions are submitted later, trustlines are not checked now
+	paymentRequest := request.ToPaymentRequest()
+	paymentRequest.SkipTrustCheck = true
+	operation, err := rh.createPaymentOperation(paymentRequest, request.Destination, nil)
+	if errorResponse, ok := err.(*protocols.ErrorResponse); ok {
+		server.Write(w, errorResponse)
+		return
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Cannot check if destination exists")
+		server.Write(w, dependencyError(err))
+		return
+	}
+	operation.Source = sourceKeypair.Address()
+
+	tx, err := rh.transactionBuilder().Build(txspec.TxSpec{
+		Source:     recoveryKeypair.Address(),
+		Sequence:   sequenceNumber,
+		Operations: []txspec.OperationSpec{operation},
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Print("Transaction builder error")
+		server.Write(w, protocols.NewInvalidParameterError("", "", err.Error()))
+		return
+	}
+
+	if maxTime := request.MaxTimeValue(); maxTime != 0 {
+		tx.TimeBounds = &xdr.TimeBounds{MaxTime: xdr.Uint64(maxTime)}
+	}
+
+	hash, err := submitter.TransactionHash(tx, rh.Config.NetworkPassphrase)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error calculating transaction hash")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	signer, err := strkey.Encode(strkey.VersionByteHashTx, hash[:])
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding pre-authorized transaction signer")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	// Pre-authorized transactions are not signed
+	txeB64, err := submitter.SignEnvelope(tx, rh.Config.NetworkPassphrase)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Cannot encode transaction envelope")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	// A single transaction adds the signer to both accounts, the signer is never added to one of
+	// them only. Its source is the recovery account: it reserves the sequence number the recovery
+	// transaction was built with.
+	signersTx := b.Transaction(
+		b.SourceAccount{rh.Config.Accounts.RecoverySeed},
+		b.Sequence{sequenceNumber - 1},
+		b.Network{rh.Config.NetworkPassphrase},
+		b.SetOptions(b.AddSigner(signer, bridge.PreauthSignerWeight)),
+		b.SetOptions(b.SourceAccount{sourceKeypair.Address()}, b.AddSigner(signer, bridge.PreauthSignerWeight)),
+	)
+	if signersTx.Err != nil {
+		log.WithFields(log.Fields{"err": signersTx.Err}).Error("TransactionBuilder returned error")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	signersTxeB64, err := submitter.SignEnvelope(signersTx.TX, rh.Config.NetworkPassphrase, rh.Config.Accounts.RecoverySeed, request.Source)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error signing transaction envelope")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	submitResponse, err := rh.Horizon.SubmitTransaction(signersTxeB64)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error submitting transaction")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+		server.Write(w, rh.withHorizonFailureID(protocols.InternalServerError, horizon.FailureID(err)))
+		return
+	}
+
+	errorResponse := bridge.ErrorFromHorizonResponse(submitResponse)
+	if errorResponse != nil {
+		log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, rh.withHorizonFailureID(errorResponse, submitResponse.FailureID))
+		return
+	}
+
+	server.Write(w, &bridge.PreauthResponse{
+		Hash:                hex.EncodeToString(hash[:]),
+		Signer:              signer,
+		SequenceNumber:      strconv.FormatUint(sequenceNumber, 10),
+		TransactionEnvelope: txeB64,
+	})
+}
+
+// PreauthSubmit implements /preauth/submit endpoint
+func (rh *RequestHandler) PreauthSubmit(w http.ResponseWriter, r *http.Request) {
+	rh, ok := rh.withCorrelationID(w, r)
+	if !ok {
+		return
+	}
+
+	request := &bridge.PreauthSubmitRequest{}
+	err := request.FromRequest(r)
+	if err != nil {
+		log.Error(err.Error())
+		server.Write(w, protocols.InvalidParameterError)
+		return
+	}
+
+	err = request.Validate()
+	if err != nil {
+		errorResponse := err.(*protocols.ErrorResponse)
+		log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, errorResponse)
+		return
+	}
+
+	submitResponse, err := rh.Horizon.SubmitTransaction(request.TransactionEnvelope)
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error submitting transaction")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+		server.Write(w, rh.withHorizonFailureID(protocols.InternalServerError, horizon.FailureID(err)))
+		return
+	}
+
+	errorResponse := bridge.ErrorFromHorizonResponse(submitResponse)
+	if errorResponse != nil {
+		log.WithFields(errorResponse.LogData).Error(errorResponse.Error())
+		server.Write(w, rh.withHorizonFailureID(errorResponse, submitResponse.FailureID))
+		return
+	}
+
+	server.Write(w, &submitResponse)
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/test"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerPreauth(t *testing.T) {
+	c := &config.Config{
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+		Accounts: config.Accounts{
+			// GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ
+			BaseSeed: "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK",
+			// GC3P6OVKV3D6MCRIYFN6AUR6UA2A356N3GMPHFPGPLAWAPA7MI2QV7AR
+			RecoverySeed: "SBTTC5QPPOQRVE4HALMVOL4MZ5JGCB76JPYXISRXEXYMJ25OMDO3EJBI",
+		},
+	}
+	mockHorizon := new(mocks.MockHorizon)
+
+	requestHandler := RequestHandler{Config: c, Horizon: mockHorizon}
+
+	testServer := httptest.NewServer(http.HandlerFunc(requestHandler.Preauth))
+	defer testServer.Close()
+
+	Convey("Given preauth request", t, func() {
+		Convey("When destination is a federation address", func() {
+			params := url.Values{
+				"destination": {"bob*stellar.org"},
+				"amount":      {"20.0"},
+			}
+
+			Convey("it should return error", func() {
+				statusCode, response := net.GetResponse(testServer, params)
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "invalid_parameter",
+				  "message": "Invalid parameter.",
+				  "data": {
+				    "name": "destination"
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+			})
+		})
+
+		Convey("When params are valid", func() {
+			params := url.Values{
+				"destination":  {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+				"amount":       {"20.0"},
+				"asset_code":   {"USD"},
+				"asset_issuer": {"GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"},
+				"max_time":     {"2000000000"},
+			}
+
+			recoveryAccount := horizon.AccountResponse{SequenceNumber: "100"}
+			var ledger uint64
+			ledger = 100
+			var submitted []string
+			submit := func() {
+				mockHorizon.On(
+					"LoadAccount",
+					"GC3P6OVKV3D6MCRIYFN6AUR6UA2A356N3GMPHFPGPLAWAPA7MI2QV7AR",
+				).Return(recoveryAccount, nil).Once()
+				mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+					submitted = append(submitted, args.String(0))
+				}).Return(horizon.SubmitTransactionResponse{Ledger: &ledger}, nil).Once()
+			}
+
+			Convey("it should return unsigned recovery envelope", func() {
+				submit()
+				statusCode, response := net.GetResponse(testServer, params)
+				assert.Equal(t, 200, statusCode)
+
+				var actual bridge.PreauthResponse
+				json.Unmarshal(response, &actual)
+				assert.Equal(t, "102", actual.SequenceNumber)
+				assert.Equal(t, "T", actual.Signer[0:1])
+
+				var envelope xdr.TransactionEnvelope
+				err := xdr.SafeUnmarshalBase64(actual.TransactionEnvelope, &envelope)
+				assert.Nil(t, err)
+				assert.Len(t, envelope.Signatures, 0)
+				assert.Equal(t, "GC3P6OVKV3D6MCRIYFN6AUR6UA2A356N3GMPHFPGPLAWAPA7MI2QV7AR", envelope.Tx.SourceAccount.Address())
+				assert.Equal(t, xdr.SequenceNumber(102), envelope.Tx.SeqNum)
+				assert.Equal(t, xdr.Uint64(2000000000), envelope.Tx.TimeBounds.MaxTime)
+				assert.Equal(t, "GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ", envelope.Tx.Operations[0].SourceAccount.Address())
+
+				Convey("signers should be added by a single transaction", func() {
+					require.Len(t, submitted, 1)
+					var signers xdr.TransactionEnvelope
+					require.NoError(t, xdr.SafeUnmarshalBase64(submitted[0], &signers))
+					assert.Equal(t, "GC3P6OVKV3D6MCRIYFN6AUR6UA2A356N3GMPHFPGPLAWAPA7MI2QV7AR", signers.Tx.SourceAccount.Address())
+					assert.Equal(t, xdr.SequenceNumber(101), signers.Tx.SeqNum)
+					assert.Len(t, signers.Signatures, 2)
+					require.Len(t, signers.Tx.Operations, 2)
+					assert.Nil(t, signers.Tx.Operations[0].SourceAccount)
+					assert.Equal(t, "GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ", signers.Tx.Operations[1].SourceAccount.Address())
+					for _, operation := range signers.Tx.Operations {
+						assert.Equal(t, actual.Signer, operation.Body.SetOptionsOp.Signer.Key.Address())
+					}
+					mockHorizon.AssertExpectations(t)
+				})
+			})
+
+			Convey("When earlier recovery envelope is outstanding", func() {
+				recoveryAccount.Signers = []horizon.Signer{
+					{Key: "GC3P6OVKV3D6MCRIYFN6AUR6UA2A356N3GMPHFPGPLAWAPA7MI2QV7AR", Weight: 1, Type: "ed25519_public_key"},
+					{Key: "TDYNLZJVLYLGXNZNYN5RU5KKUSX7TSUOZYWXCMTC2KN4VMHS5G5J4QQY", Weight: 255, Type: "preauth_tx"},
+				}
+
+				Convey("it should return error", func() {
+					mockHorizon.On(
+						"LoadAccount",
+						"GC3P6OVKV3D6MCRIYFN6AUR6UA2A356N3GMPHFPGPLAWAPA7MI2QV7AR",
+					).Return(recoveryAccount, nil).Once()
+					statusCode, response := net.GetResponse(testServer, params)
+					assert.Equal(t, 409, statusCode)
+					assert.Equal(t, "preauth_outstanding", test.StringToJSONMap(string(response))["code"])
+					assert.Empty(t, submitted)
+				})
+
+				Convey("it should replace it with replace=true", func() {
+					submit()
+					params.Set("replace", "true")
+					statusCode, _ := net.GetResponse(testServer, params)
+					assert.Equal(t, 200, statusCode)
+					assert.Len(t, submitted, 1)
+				})
+			})
+		})
+	})
+}
+
+func TestRequestHandlerPreauthSubmit(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	requestHandler := RequestHandler{Config: &config.Config{}, Horizon: mockHorizon}
+
+	testServer := httptest.NewServer(http.HandlerFunc(requestHandler.PreauthSubmit))
+	defer testServer.Close()
+
+	Convey("Given preauth submit request", t, func() {
+		Convey("When envelope is signed", func() {
+			// Envelope with a single signature
+			txe := b.Transaction(
+				b.SourceAccount{"SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"},
+				b.Sequence{1},
+				b.TestNetwork,
+				b.Payment(
+					b.Destination{"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+					b.NativeAmount{"1"},
+				),
+			).Sign("SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK")
+			txeB64, _ := txe.Base64()
+
+			Convey("it should return error", func() {
+				statusCode, response := net.GetResponse(testServer, url.Values{"transaction_envelope": {txeB64}})
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "invalid_parameter",
+				  "message": "Invalid parameter.",
+				  "data": {
+				    "name": "transaction_envelope"
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response), "more_info"))
+			})
+
+			Convey("When envelope is not signed", func() {
+				unsigned, _ := xdr.MarshalBase64(xdr.TransactionEnvelope{Tx: txe.E.Tx})
+
+				var ledger uint64
+				ledger = 100
+				mockHorizon.On("SubmitTransaction", unsigned).Return(
+					horizon.SubmitTransactionResponse{Ledger: &ledger},
+					nil,
+				).Once()
+
+				Convey("it should submit it", func() {
+					statusCode, _ := net.GetResponse(testServer, url.Values{"transaction_envelope": {unsigned}})
+					assert.Equal(t, 200, statusCode)
+					mockHorizon.AssertExpectations(t)
+				})
+			})
+		})
+
+		Convey("When submission fails", func() {
+			unsigned, _ := xdr.MarshalBase64(xdr.TransactionEnvelope{Tx: *b.Transaction(
+				b.SourceAccount{"GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ"},
+				b.Sequence{2},
+				b.TestNetwork,
+				b.Payment(
+					b.Destination{"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+					b.NativeAmount{"1"},
+				),
+			).TX})
+
+			mockHorizon.On("SubmitTransaction", unsigned).Return(
+				horizon.SubmitTransactionResponse{
+					Extras:    &horizon.SubmitTransactionResponseExtras{ResultXdr: "AAAAAAAAAAD////7AAAAAA=="}, // tx_bad_seq
+					FailureID: "4f0caf4c5a415a4e",
+				},
+				nil,
+			).Once()
+
+			Convey("it should not return failure ID by default", func() {
+				statusCode, response := net.GetResponse(testServer, url.Values{"transaction_envelope": {unsigned}})
+				assert.Equal(t, 400, statusCode)
+				assert.NotContains(t, string(response), "horizon_failure_id")
+			})
+
+			Convey("it should return failure ID when horizon_failures.attach_id is enabled", func() {
+				requestHandler.Config.HorizonFailures.AttachID = true
+				defer func() { requestHandler.Config.HorizonFailures.AttachID = false }()
+
+				statusCode, response := net.GetResponse(testServer, url.Values{"transaction_envelope": {unsigned}})
+				assert.Equal(t, 400, statusCode)
+				expected := test.StringToJSONMap(`{
+				  "code": "transaction_bad_seq",
+				  "message": "Bad Sequence. Please, try again.",
+				  "remediation": "retry_with_new_sequence",
+				  "data": {
+				    "horizon_failure_id": "4f0caf4c5a415a4e"
+				  }
+				}`)
+				assert.Equal(t, expected, test.StringToJSONMap(string(response)))
+				// Shared error responses are not changed
+				assert.Nil(t, bridge.TransactionBadSequence.Data)
+			})
+		})
+	})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/zenazn/goji/web"
+)
+
+// AdminRebuildTransaction implements POST /admin/transactions/:id/rebuild endpoint. It sends the
+// stored request of a failed payment again, so the new transaction gets a current sequence
+// number and fee. The new transaction is linked to the failed one by `rebuilt_from` and the
+// response is the response of /payment.
+func (rh *RequestHandler) AdminRebuildTransaction(c web.C, w http.ResponseWriter, r *http.Request) {
+	logger := requestLog(r)
+
+	object, err := rh.Driver.GetOne(&entities.SentTransaction{}, "id = ?", c.URLParams["id"])
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error getting SentTransaction")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	if object == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	transaction := object.(*entities.SentTransaction)
+	logger = logger.WithFields(log.Fields{"id": *transaction.ID, "hash": transaction.TransactionID})
+
+	if transaction.Status != entities.SentTransactionStatusFailure {
+		server.Write(w, bridge.RebuildNotFailed)
+		return
+	}
+	if transaction.Payload == nil {
+		server.Write(w, bridge.RebuildNotAvailable)
+		return
+	}
+
+	// A rebuilt transaction that has not failed may be applied, sending the payment again would
+	// pay twice
+	rebuilt, err := rh.Repository.GetSentTransactionsRebuiltFrom(*transaction.ID)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error getting rebuilt transactions")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	for _, rebuiltTransaction := range rebuilt {
+		if rebuiltTransaction.Status != entities.SentTransactionStatusFailure {
+			server.Write(w, bridge.RebuildAlreadyRebuilt)
+			return
+		}
+	}
+
+	var payload bridge.PaymentPayload
+	err = json.Unmarshal([]byte(*transaction.Payload), &payload)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error decoding stored payment request")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	if payload.Version != bridge.PaymentPayloadVersion {
+		logger.WithFields(log.Fields{"version": payload.Version}).Error("Unsupported version of stored payment request")
+		server.Write(w, bridge.RebuildUnsupportedVersion)
+		return
+	}
+	if payload.SourceOmitted || payload.SignersOmitted {
+		server.Write(w, bridge.RebuildSecretOmitted)
+		return
+	}
+	source := rh.Config.Accounts.AliasSeed(payload.SourceAlias)
+	if source == "" {
+		logger.WithFields(log.Fields{"source_alias": payload.SourceAlias}).Error("Source of stored payment request is not configured")
+		server.Write(w, bridge.RebuildSourceNotConfigured)
+		return
+	}
+
+	// Context keeps the role of the admin request (skip_slippage_check)
+	paymentRequest, err := http.NewRequest(http.MethodPost, "/payment", strings.NewReader(payload.ToValues(source).Encode()))
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error creating payment request")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	paymentRequest = paymentRequest.WithContext(r.Context())
+	paymentRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if transaction.CorrelationID != "" {
+		paymentRequest.Header.Set(server.CorrelationIDHeader, transaction.CorrelationID)
+	}
+
+	logger.Info("Rebuilding failed payment")
+	handler := *rh
+	handler.rebuiltFrom = transaction.ID
+	handler.Payment(w, paymentRequest)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zenazn/goji/web"
+)
+
+func TestRequestHandlerAdminRebuildTransaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-rebuild")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+
+	baseSeed := testBaseSeed
+	rawSeed := "SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"
+	mockHorizon := new(mocks.MockHorizon)
+	requestHandler := newTestRequestHandler(mockHorizon)
+	requestHandler.Driver = driver
+	requestHandler.Repository = db.NewRepository(driver)
+	requestHandler.EntityManager = db.NewEntityManager(driver)
+
+	mockHorizon.On("LoadAccount", mock.AnythingOfType("string")).Return(accountTrusting("100", protocols.Asset{Code: "USD", Issuer: "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"}), nil)
+	ledger := uint64(1988727)
+	failed := horizon.SubmitTransactionResponse{
+		Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB////+gAAAAA="},
+	}
+
+	pay := func(params url.Values) int {
+		status, _ := postPayment(t, &requestHandler, params)
+		return status
+	}
+	rebuild := func(id string) (int, map[string]interface{}) {
+		request, err := http.NewRequest(http.MethodPost, "/admin/transactions/"+id+"/rebuild", nil)
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		requestHandler.AdminRebuildTransaction(web.C{URLParams: map[string]string{"id": id}}, response, request)
+		if response.Body.Len() == 0 {
+			return response.Code, nil
+		}
+		return response.Code, test.StringToJSONMap(response.Body.String())
+	}
+	getTransaction := func(id int64) *entities.SentTransaction {
+		object, err := driver.GetOne(&entities.SentTransaction{}, "id = ?", id)
+		require.NoError(t, err)
+		require.NotNil(t, object)
+		return object.(*entities.SentTransaction)
+	}
+
+	params := usdPaymentParams("20", url.Values{
+		"memo_type":      {"id"},
+		"memo":           {"42"},
+		"correlation_id": {"order-42"},
+	})
+	mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(failed, nil).Once()
+	assert.Equal(t, http.StatusBadRequest, pay(params))
+
+	t.Run("failed payment is stored with its request", func(t *testing.T) {
+		transaction := getTransaction(1)
+		assert.Equal(t, entities.SentTransactionStatusFailure, transaction.Status)
+		assert.Equal(t, "order-42", transaction.CorrelationID)
+		require.NotNil(t, transaction.Payload)
+		assert.NotContains(t, *transaction.Payload, baseSeed)
+
+		var payload bridge.PaymentPayload
+		require.NoError(t, json.Unmarshal([]byte(*transaction.Payload), &payload))
+		assert.Equal(t, bridge.PaymentPayloadVersion, payload.Version)
+		assert.Equal(t, "base_seed", payload.SourceAlias)
+		assert.Equal(t, "USD", payload.Params.Get("asset_code"))
+		assert.Equal(t, "42", payload.Params.Get("memo"))
+	})
+
+	t.Run("failed payment is rebuilt", func(t *testing.T) {
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(
+			horizon.SubmitTransactionResponse{Hash: "6a3b", Ledger: &ledger}, nil,
+		).Once()
+
+		statusCode, response := rebuild("1")
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, "6a3b", response["hash"])
+
+		transaction := getTransaction(2)
+		assert.Equal(t, entities.SentTransactionStatusSuccess, transaction.Status)
+		require.NotNil(t, transaction.RebuiltFrom)
+		assert.Equal(t, int64(1), *transaction.RebuiltFrom)
+		assert.Equal(t, "order-42", transaction.CorrelationID)
+		assert.Equal(t, *getTransaction(1).Payload, *transaction.Payload)
+	})
+
+	t.Run("payment is not rebuilt twice", func(t *testing.T) {
+		statusCode, response := rebuild("1")
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "rebuild_already_rebuilt", response["code"])
+	})
+
+	t.Run("successful transaction is not rebuilt", func(t *testing.T) {
+		statusCode, response := rebuild("2")
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "rebuild_not_failed", response["code"])
+	})
+
+	t.Run("payment with a raw secret is not rebuilt", func(t *testing.T) {
+		params.Set("source", rawSeed)
+		mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(failed, nil).Once()
+		assert.Equal(t, http.StatusBadRequest, pay(params))
+
+		transaction := getTransaction(3)
+		require.NotNil(t, transaction.Payload)
+		assert.NotContains(t, *transaction.Payload, rawSeed)
+
+		statusCode, response := rebuild("3")
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "rebuild_secret_omitted", response["code"])
+	})
+
+	t.Run("transaction without request is not rebuilt", func(t *testing.T) {
+		require.NoError(t, requestHandler.EntityManager.Persist(&entities.SentTransaction{
+			TransactionID: "c492d87c4642815dfb3c7dcce01af4effd162b031064098a0d786b6e0a00fd74",
+			Status:        entities.SentTransactionStatusFailure,
+			Source:        "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW",
+			EnvelopeXdr:   "AAAA",
+		}))
+
+		statusCode, response := rebuild("4")
+		assert.Equal(t, http.StatusBadRequest, statusCode)
+		assert.Equal(t, "rebuild_not_available", response["code"])
+
+		statusCode, _ = rebuild("5")
+		assert.Equal(t, http.StatusNotFound, statusCode)
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/external"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/go/protocols/federation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerAdminResolverDomains(t *testing.T) {
+	mockFederationResolver := new(mocks.MockFederationResolver)
+	mockFederationResolver.On("LookupByAddress", "alice*stellar.org").Return(&federation.NameResponse{AccountID: "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"}, nil)
+	mockFederationResolver.On("LookupByAddress", "alice*example.com").Return(&federation.NameResponse{}, errors.New("http get failed with (404) status code"))
+
+	metrics := external.NewFederationMetrics(mockFederationResolver, 0, time.Now)
+	requestHandler := RequestHandler{FederationMetrics: metrics}
+	for _, addy := range []string{"alice*stellar.org", "alice*stellar.org", "alice*example.com"} {
+		metrics.LookupByAddress(addy)
+	}
+
+	get := func(url string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		requestHandler.AdminResolverDomains(response, httptest.NewRequest(http.MethodGet, url, nil))
+		return response
+	}
+
+	response := get("/admin/resolver/domains?limit=1")
+	require.Equal(t, http.StatusOK, response.Code)
+	var body struct {
+		WindowSeconds int                              `json:"window_seconds"`
+		MaxDomains    int                              `json:"max_domains"`
+		Slowest       []external.FederationDomainStats `json:"slowest"`
+		MostErrors    []external.FederationDomainStats `json:"most_errors"`
+		Totals        []external.FederationDomainStats `json:"totals"`
+	}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &body))
+	assert.Equal(t, 3600, body.WindowSeconds)
+	assert.Equal(t, external.DefaultFederationMetricsDomains, body.MaxDomains)
+	assert.Len(t, body.Slowest, 1)
+	require.Len(t, body.MostErrors, 1)
+	assert.Equal(t, "example.com", body.MostErrors[0].Domain)
+	assert.Equal(t, map[string]int64{external.FederationErrorNotFound: 1}, body.MostErrors[0].Errors)
+	require.Len(t, body.Totals, 2)
+	assert.Equal(t, "stellar.org", body.Totals[1].Domain)
+	assert.Equal(t, int64(2), body.Totals[1].Lookups)
+
+	response = get("/admin/resolver/domains?limit=0")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
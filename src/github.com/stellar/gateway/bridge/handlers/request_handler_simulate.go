@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/simulation"
+)
+
+// simulationResponse is returned by /simulate
+type simulationResponse struct {
+	Simulation bool `json:"simulation"`
+	// Status and Response are what /payment would respond
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response"`
+	// EnvelopeXdr is the transaction that would be submitted, without signatures
+	EnvelopeXdr string             `json:"envelope_xdr,omitempty"`
+	Checks      []simulation.Check `json:"checks"`
+	// DepositRequirement contains rules of the destination domain, /payment checks them the same
+	// way
+	DepositRequirement *simulatedDepositRequirement `json:"deposit_requirement,omitempty"`
+}
+
+// simulatedDepositRequirement is a deposit requirement returned by /simulate, the registration
+// URL is not returned
+type simulatedDepositRequirement struct {
+	config.DepositRequirement
+	// Registration is true when payments must be approved by the registration webhook
+	Registration bool `json:"registration"`
+}
+
+// bufferedResponse keeps a response of a simulated request
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
+
+// Simulate implements /simulate endpoint. It runs /payment with account state of the `state` param
+// (or loaded from Horizon when empty) and returns what would be submitted instead of submitting.
+func (rh *RequestHandler) Simulate(w http.ResponseWriter, r *http.Request) {
+	logger := requestLog(r)
+
+	var state *simulation.State
+	if value := r.PostFormValue("state"); value != "" {
+		state = &simulation.State{}
+		err := json.Unmarshal([]byte(value), state)
+		if err != nil {
+			server.Write(w, protocols.NewInvalidParameterError("state", value, "state must be a JSON account state."))
+			return
+		}
+	}
+
+	request := &bridge.PaymentRequest{}
+	if request.FromRequest(r) == nil && rh.Config.Compliance != "" && (request.ExtraMemo != "" || request.UseCompliance) {
+		server.Write(w, protocols.NewInvalidParameterError("use_compliance", "true", "Payments using compliance protocol cannot be simulated."))
+		return
+	}
+
+	provider := simulation.NewProvider(state, rh.Horizon, rh.FederationResolver, rh.Config.NetworkPassphrase)
+	simulated := *rh
+	simulated.Horizon = provider
+	simulated.FederationResolver = provider
+	// Used only by compliance payments
+	simulated.TransactionSubmitter = nil
+	// Simulated payments are not stored
+	simulated.EntityManager = nil
+	// Simulated payments are not processed by the bridge
+	simulated.Inflight = nil
+	// Simulated payments are returned in the response
+	simulated.Handoffs = nil
+	// Simulated payments are not submitted so they don't lease channels
+	simulated.Channels = nil
+
+	response := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	simulated.Payment(response, r)
+
+	logger.WithFields(log.Fields{"status": response.status, "checks": len(provider.Checks)}).Info("Payment simulated")
+
+	result := simulationResponse{
+		Simulation:  true,
+		Status:      response.status,
+		Response:    json.RawMessage(response.body.Bytes()),
+		EnvelopeXdr: provider.EnvelopeXdr,
+		Checks:      provider.Checks,
+	}
+	if requirement, ok := rh.depositRequirement(request.Destination); ok {
+		result.DepositRequirement = &simulatedDepositRequirement{
+			DepositRequirement: requirement,
+			Registration:       requirement.RegistrationURL != "",
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(result)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error encoding simulation response")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/simulation"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHandlerSimulate(t *testing.T) {
+	c := &config.Config{
+		NetworkPassphrase: "Test SDF Network ; September 2015",
+		Compliance:        "http://compliance",
+	}
+	// Mocks without expectations fail the test when called
+	requestHandler := RequestHandler{
+		Config:               c,
+		Client:               new(mocks.MockHTTPClient),
+		Horizon:              new(mocks.MockHorizon),
+		TransactionSubmitter: new(mocks.MockTransactionSubmitter),
+		FederationResolver:   new(mocks.MockFederationResolver),
+	}
+
+	testServer := httptest.NewServer(http.HandlerFunc(requestHandler.Simulate))
+	defer testServer.Close()
+
+	state := `{
+  "accounts": {
+    "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW": {
+      "sequence": "100",
+      "balances": [{"asset_type": "native", "balance": "1000.0000000"}]
+    }
+  },
+  "federation": {
+    "bob*stellar.org": {"account_id": "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", "memo_type": "id", "memo": "123"}
+  }
+}`
+
+	simulate := func(params url.Values) (int, simulationResponse) {
+		statusCode, body := net.GetResponse(testServer, params)
+		var response simulationResponse
+		require.NoError(t, json.Unmarshal(body, &response), string(body))
+		return statusCode, response
+	}
+
+	Convey("Given simulate request", t, func() {
+		Convey("When destination and source are in the state", func() {
+			params := url.Values{
+				"source":      {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+				"destination": {"bob*stellar.org"},
+				"amount":      {"20.0"},
+				"state":       {state},
+			}
+
+			Convey("it should return unsigned transaction and checks", func() {
+				statusCode, response := simulate(params)
+				assert.Equal(t, 200, statusCode)
+				assert.True(t, response.Simulation)
+				assert.Equal(t, 200, response.Status)
+
+				var envelope xdr.TransactionEnvelope
+				require.NoError(t, xdr.SafeUnmarshalBase64(response.EnvelopeXdr, &envelope))
+				assert.Len(t, envelope.Signatures, 0)
+				assert.Equal(t, xdr.SequenceNumber(101), envelope.Tx.SeqNum)
+				assert.Equal(t, xdr.OperationTypeCreateAccount, envelope.Tx.Operations[0].Body.Type)
+				assert.Equal(t, xdr.Uint64(123), *envelope.Tx.Memo.Id)
+
+				assert.Equal(t, []simulation.Check{
+					{Name: "resolve_destination", Subject: "bob*stellar.org", Result: simulation.ResultOK},
+					{Name: "load_account", Subject: "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", Result: simulation.ResultNotFound},
+					{Name: "load_account", Subject: "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW", Result: simulation.ResultOK},
+					{Name: "build_transaction", Result: simulation.ResultOK},
+				}, response.Checks)
+			})
+		})
+
+		Convey("When source is not in the state", func() {
+			params := url.Values{
+				"source":      {"SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"},
+				"destination": {"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+				"amount":      {"20.0"},
+				"state":       {state},
+			}
+
+			Convey("it should return the payment error", func() {
+				statusCode, response := simulate(params)
+				assert.Equal(t, 200, statusCode)
+				assert.Equal(t, 400, response.Status)
+				assert.Contains(t, string(response.Response), `"code":"source_not_exist"`)
+				assert.Empty(t, response.EnvelopeXdr)
+			})
+		})
+
+		Convey("When destination can't be resolved", func() {
+			params := url.Values{
+				"source":      {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+				"destination": {"alice*stellar.org"},
+				"amount":      {"20.0"},
+				"state":       {state},
+			}
+
+			Convey("it should return the failed check", func() {
+				_, response := simulate(params)
+				assert.Contains(t, string(response.Response), `"code":"cannot_resolve_destination"`)
+				assert.Equal(t, []simulation.Check{
+					{Name: "resolve_destination", Subject: "alice*stellar.org", Result: simulation.ResultError, Detail: "Address not in simulation state"},
+				}, response.Checks)
+			})
+		})
+
+		Convey("When state is invalid", func() {
+			params := url.Values{
+				"destination": {"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+				"amount":      {"20.0"},
+				"state":       {"{"},
+			}
+
+			Convey("it should return error", func() {
+				statusCode, body := net.GetResponse(testServer, params)
+				assert.Equal(t, 400, statusCode)
+				assert.Contains(t, string(body), `"name": "state"`)
+			})
+		})
+
+		Convey("When payment uses compliance", func() {
+			params := url.Values{
+				"source":         {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+				"destination":    {"bob*stellar.org"},
+				"amount":         {"20.0"},
+				"use_compliance": {"true"},
+				"state":          {state},
+			}
+
+			Convey("it should return error", func() {
+				statusCode, body := net.GetResponse(testServer, params)
+				assert.Equal(t, 400, statusCode)
+				assert.Contains(t, string(body), `"name": "use_compliance"`)
+			})
+		})
+	})
+}
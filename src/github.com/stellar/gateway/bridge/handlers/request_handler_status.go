@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/server"
+)
+
+// Status implements /status endpoint returning the role of this replica. Every replica handles
+// requests, only the leader runs the payment listener. Callback destinations without TLS
+// certificate verification are listed so they don't go unnoticed. Warm-up progress is reported
+// in `warm_start`, a `warnings` entry is added while Horizon is rate limiting requests or
+// submissions wait for `submission_rate` limit. Entry counts and checksums of counterparty lists
+// identify the running versions of the files.
+func (rh *RequestHandler) Status(w http.ResponseWriter, r *http.Request) {
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(rh.StatusReport())
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding status")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+}
+
+// StatusReport returns the body of /status, servers with several networks report it for every
+// network
+func (rh *RequestHandler) StatusReport() map[string]interface{} {
+	rateLimit := rh.HorizonRateLimits.Status()
+	warnings := []string{}
+	if rateLimit.Limited {
+		warnings = append(warnings, "Horizon rate limit exceeded, payments can fail with rate_limited error")
+	}
+
+	submissionRate := rh.SubmissionRate.Status()
+	if submissionRate.Saturated {
+		warnings = append(warnings, "Submission rate limit reached, transactions are waiting to be submitted")
+	}
+
+	listenerStatus, err := rh.PaymentListener.Status()
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error loading listener status")
+		warnings = append(warnings, "Could not load payment listener status")
+	}
+
+	return map[string]interface{}{
+		"leader_election": rh.Elector.Status(),
+		"callbacks": map[string]interface{}{
+			"insecure_skip_verify": rh.Webhooks.InsecureDestinations(),
+		},
+		"warm_start":         rh.Warmer.Status(),
+		"horizon_rate_limit": rateLimit,
+		"submission_rate":    submissionRate,
+		"counterparties":     rh.Counterparties.Status(),
+		"listener":           listenerStatus,
+		"warnings":           warnings,
+	}
+}
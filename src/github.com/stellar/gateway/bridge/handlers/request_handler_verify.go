@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/amount"
+	"github.com/zenazn/goji/web"
+)
+
+// disputedEvent is a payload of PaymentDisputed events
+type disputedEvent struct {
+	Fields []string `json:"fields"`
+}
+
+// VerifyPayment implements /verify/payment/{operation_id} endpoint. It loads the operation and its
+// transaction from Horizon and compares them with the received payment and the payment request it
+// fulfilled. The verdict is signed with the response signing key, a payment with mismatched fields
+// is marked as disputed and listed by /admin/disputes.
+func (rh *RequestHandler) VerifyPayment(c web.C, w http.ResponseWriter, r *http.Request) {
+	logger := requestLog(r)
+	operationID := c.URLParams["id"]
+	id, err := strconv.ParseInt(operationID, 10, 64)
+	if err != nil {
+		server.Write(w, protocols.NewInvalidParameterError("operation_id", operationID, "Operation ID must be a number."))
+		return
+	}
+
+	payment, err := rh.Repository.GetReceivedPaymentByOperationID(id)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error loading received payment")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	if payment == nil {
+		server.Write(w, bridge.ReceivedPaymentNotFound)
+		return
+	}
+
+	paymentRequest, err := rh.Repository.GetPaymentRequestByOperationID(operationID)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error loading payment request")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	operation, err := rh.Horizon.LoadOperation(operationID)
+	if err == nil {
+		err = rh.Horizon.LoadMemo(&operation)
+	}
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err, "operation_id": operationID}).Error("Error loading operation from Horizon")
+		if errorResponse := dependencyError(err); errorResponse != nil {
+			server.Write(w, errorResponse)
+			return
+		}
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	verification := rh.verifyPayment(payment, paymentRequest, operation)
+	if mismatched := verification.Mismatched(); len(mismatched) > 0 && payment.DisputedAt == nil {
+		payment.MarkDisputed(mismatched, verification.VerifiedAt)
+		err = rh.EntityManager.Persist(payment)
+		if err != nil {
+			logger.WithFields(log.Fields{"err": err}).Error("Error saving disputed payment")
+			server.Write(w, protocols.InternalServerError)
+			return
+		}
+		logger.WithFields(log.Fields{"operation_id": operationID, "fields": payment.DisputedFields}).Warn("Received payment disputed")
+		rh.Events.Record(events.PaymentDisputed, operationID, disputedEvent{mismatched})
+	}
+	verification.Disputed = payment.DisputedAt != nil
+
+	verdict, err := json.Marshal(verification)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error encoding verification")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+	signature, err := rh.Signer.Sign(verdict)
+	if err != nil {
+		logger.WithFields(log.Fields{"err": err}).Error("Error signing verification")
+		server.Write(w, protocols.InternalServerError)
+		return
+	}
+
+	server.Write(w, &bridge.PaymentVerificationResponse{Verdict: verdict, Signature: signature})
+}
+
+// verifyPayment compares an operation loaded with its memo with the received payment and the
+// payment request it fulfilled (nil when it didn't fulfill one)
+func (rh *RequestHandler) verifyPayment(payment *entities.ReceivedPayment, paymentRequest *entities.PaymentRequest, operation horizon.PaymentResponse) *bridge.PaymentVerification {
+	assetCode, assetIssuer := operation.AssetCode, operation.AssetIssuer
+	if operation.AssetType == "native" {
+		assetCode, assetIssuer = "XLM", ""
+	}
+
+	verification := &bridge.PaymentVerification{
+		OperationID:     payment.OperationID,
+		TransactionHash: operation.TransactionHash,
+		VerifiedAt:      utc.Now(),
+	}
+	check := func(source, field, expected, actual string, matched bool) {
+		verification.Fields = append(verification.Fields, bridge.VerifiedField{
+			Field:    field,
+			Source:   source,
+			Expected: expected,
+			Actual:   actual,
+			Matched:  matched,
+		})
+	}
+
+	// Payments to other accounts are stored too, they are never credited
+	check(bridge.VerificationSourceReceivedPayment, "destination", rh.Config.Accounts.ReceivingAccountID, operation.To, operation.To == rh.Config.Accounts.ReceivingAccountID)
+	check(bridge.VerificationSourceReceivedPayment, "asset_code", payment.AssetCode, assetCode, assetCode == payment.AssetCode)
+	check(bridge.VerificationSourceReceivedPayment, "asset_issuer", payment.AssetIssuer, assetIssuer, assetIssuer == payment.AssetIssuer)
+	check(bridge.VerificationSourceReceivedPayment, "amount", payment.Amount, operation.Amount, equalAmounts(payment.Amount, operation.Amount))
+
+	if paymentRequest != nil {
+		verification.PaymentRequest = paymentRequest.RequestID
+		requestedCode := paymentRequest.AssetCode
+		if requestedCode == "" {
+			requestedCode = "XLM"
+		}
+		check(bridge.VerificationSourcePaymentRequest, "destination", paymentRequest.Destination, operation.To, operation.To == paymentRequest.Destination)
+		check(bridge.VerificationSourcePaymentRequest, "asset_code", requestedCode, assetCode, assetCode == requestedCode)
+		check(bridge.VerificationSourcePaymentRequest, "asset_issuer", paymentRequest.AssetIssuer, assetIssuer, assetIssuer == paymentRequest.AssetIssuer)
+		check(bridge.VerificationSourcePaymentRequest, "amount", paymentRequest.Amount, operation.Amount, equalAmounts(paymentRequest.Amount, operation.Amount))
+		check(bridge.VerificationSourcePaymentRequest, "memo_type", paymentRequest.MemoType, operation.Memo.Type, operation.Memo.Type == paymentRequest.MemoType)
+		check(bridge.VerificationSourcePaymentRequest, "memo", paymentRequest.Memo, operation.Memo.Value, operation.Memo.Value == paymentRequest.Memo)
+	}
+
+	verification.Verified = len(verification.Mismatched()) == 0
+	return verification
+}
+
+// equalAmounts returns true when amounts are equal, `10` equals `10.0000000`. Amounts of
+// operations other than payments are empty.
+func equalAmounts(expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	expectedValue, err := amount.Parse(expected)
+	if err != nil {
+		return false
+	}
+	actualValue, err := amount.Parse(actual)
+	return err == nil && expectedValue == actualValue
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/jws"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/zenazn/goji/web"
+)
+
+func TestRequestHandlerVerifyPayment(t *testing.T) {
+	receiving := "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"
+	issuer := "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"
+
+	signer, err := jws.NewSigner("SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK", "2026-10", nil)
+	require.NoError(t, err)
+	keys, err := signer.Keys().Addresses()
+	require.NoError(t, err)
+
+	type fixture struct {
+		repository    *mocks.MockRepository
+		entityManager *mocks.MockEntityManager
+		horizon       *mocks.MockHorizon
+		handler       *RequestHandler
+	}
+	setup := func(payment *entities.ReceivedPayment, paymentRequest *entities.PaymentRequest, memo string) fixture {
+		f := fixture{
+			repository:    new(mocks.MockRepository),
+			entityManager: new(mocks.MockEntityManager),
+			horizon:       new(mocks.MockHorizon),
+		}
+		f.repository.On("GetReceivedPaymentByOperationID", int64(4294967297)).Return(payment, nil)
+		f.repository.On("GetPaymentRequestByOperationID", "4294967297").Return(paymentRequest, nil)
+		f.horizon.On("LoadOperation", "4294967297").Return(horizon.PaymentResponse{
+			ID:              "4294967297",
+			Type:            "payment",
+			TransactionHash: "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a",
+			To:              receiving,
+			AssetType:       "credit_alphanum4",
+			AssetCode:       "USD",
+			AssetIssuer:     issuer,
+			Amount:          "20.0000000",
+		}, nil)
+		f.horizon.On("LoadMemo", mock.AnythingOfType("*horizon.PaymentResponse")).Run(func(args mock.Arguments) {
+			operation := args.Get(0).(*horizon.PaymentResponse)
+			operation.Memo.Type = "id"
+			operation.Memo.Value = memo
+		}).Return(nil)
+		f.handler = &RequestHandler{
+			Config:        &config.Config{Accounts: config.Accounts{ReceivingAccountID: receiving}},
+			Repository:    f.repository,
+			EntityManager: f.entityManager,
+			Horizon:       f.horizon,
+			Signer:        signer,
+		}
+		return f
+	}
+	verify := func(f fixture, id string) (int, bridge.PaymentVerification) {
+		r := httptest.NewRequest(http.MethodGet, "/verify/payment/"+id, nil)
+		w := httptest.NewRecorder()
+		f.handler.VerifyPayment(web.C{URLParams: map[string]string{"id": id}}, w, r)
+		if w.Code != http.StatusOK {
+			return w.Code, bridge.PaymentVerification{}
+		}
+
+		var response struct {
+			Verdict   json.RawMessage `json:"verdict"`
+			Signature string          `json:"signature"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		keyID, err := jws.Verify(response.Verdict, response.Signature, keys)
+		require.NoError(t, err)
+		assert.Equal(t, "2026-10", keyID)
+
+		var verification bridge.PaymentVerification
+		require.NoError(t, json.Unmarshal(response.Verdict, &verification))
+		return w.Code, verification
+	}
+	newPayment := func() *entities.ReceivedPayment {
+		return &entities.ReceivedPayment{OperationID: "4294967297", Status: "Success", AssetCode: "USD", AssetIssuer: issuer, Amount: "20.0000000"}
+	}
+	paymentRequest := &entities.PaymentRequest{RequestID: "5b2fc3e2", Destination: receiving, AssetCode: "USD", AssetIssuer: issuer, Amount: "20", MemoType: "id", Memo: "3978362090729488677", Status: entities.PaymentRequestStatusFulfilled, OperationID: "4294967297"}
+
+	t.Run("matching payment", func(t *testing.T) {
+		f := setup(newPayment(), paymentRequest, "3978362090729488677")
+		status, verification := verify(f, "4294967297")
+		require.Equal(t, http.StatusOK, status)
+		assert.True(t, verification.Verified)
+		assert.False(t, verification.Disputed)
+		assert.Equal(t, "5b2fc3e2", verification.PaymentRequest)
+		assert.Len(t, verification.Fields, 10)
+		f.entityManager.AssertNotCalled(t, "Persist", mock.Anything)
+	})
+
+	t.Run("mismatched memo is disputed", func(t *testing.T) {
+		payment := newPayment()
+		f := setup(payment, paymentRequest, "1")
+		f.entityManager.On("Persist", payment).Return(nil).Once()
+
+		status, verification := verify(f, "4294967297")
+		require.Equal(t, http.StatusOK, status)
+		assert.False(t, verification.Verified)
+		assert.True(t, verification.Disputed)
+		assert.Equal(t, []string{"memo"}, verification.Mismatched())
+		assert.Contains(t, verification.Fields, bridge.VerifiedField{Field: "memo", Source: bridge.VerificationSourcePaymentRequest, Expected: "3978362090729488677", Actual: "1"})
+		require.NotNil(t, payment.DisputedAt)
+		assert.Equal(t, "memo", payment.DisputedFields)
+
+		// Already disputed payments are not saved again
+		_, verification = verify(f, "4294967297")
+		assert.True(t, verification.Disputed)
+		f.entityManager.AssertExpectations(t)
+	})
+
+	t.Run("stored amount differs", func(t *testing.T) {
+		payment := newPayment()
+		payment.Amount = "200.0000000"
+		f := setup(payment, nil, "")
+		f.entityManager.On("Persist", payment).Return(nil).Once()
+
+		_, verification := verify(f, "4294967297")
+		assert.False(t, verification.Verified)
+		assert.Len(t, verification.Fields, 4)
+		assert.Equal(t, "amount", payment.DisputedFields)
+	})
+
+	t.Run("unknown payment", func(t *testing.T) {
+		f := setup(nil, nil, "")
+		r := httptest.NewRequest(http.MethodGet, "/verify/payment/4294967297", nil)
+		w := httptest.NewRecorder()
+		f.handler.VerifyPayment(web.C{URLParams: map[string]string{"id": "4294967297"}}, w, r)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "received_payment_not_found", test.StringToJSONMap(w.Body.String())["code"])
+
+		status, _ := verify(f, "payment")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -0,0 +1,238 @@
+package bridge
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingHorizon streams payments added by the test to the receiving account
+type streamingHorizon struct {
+	*httptest.Server
+	receiving string
+
+	mutex    sync.Mutex
+	payments []string
+	// added is closed and replaced when a payment is added
+	added chan struct{}
+	done  chan struct{}
+}
+
+func newStreamingHorizon(receiving string) *streamingHorizon {
+	h := &streamingHorizon{receiving: receiving, added: make(chan struct{}), done: make(chan struct{})}
+	h.Server = httptest.NewServer(http.HandlerFunc(h.serve))
+	return h
+}
+
+// add adds a payment with paging token n, payments are added in order of their tokens
+func (h *streamingHorizon) add(n int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.payments = append(h.payments, fmt.Sprintf(`{
+		"id": "%d", "type": "payment", "paging_token": "%d",
+		"_links": {"transaction": {"href": "%s/transactions/%d"}},
+		"from": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET", "to": "%s",
+		"asset_type": "native", "amount": "1.0000000"
+	}`, n, n, h.URL, n, h.receiving))
+	close(h.added)
+	h.added = make(chan struct{})
+}
+
+func (h *streamingHorizon) Close() {
+	close(h.done)
+	h.Server.Close()
+}
+
+func (h *streamingHorizon) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/accounts/"+h.receiving:
+		fmt.Fprintf(w, `{"id": "%s", "sequence": "1", "balances": [{"balance": "100.0000000", "asset_type": "native"}]}`, h.receiving)
+	case r.URL.Path == "/accounts/"+h.receiving+"/payments":
+		h.stream(w, r.URL.Query().Get("cursor"))
+	case strings.HasPrefix(r.URL.Path, "/transactions/"):
+		fmt.Fprint(w, `{"memo_type": "none"}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// stream writes payments after cursor until the server is closed or the client disconnects
+func (h *streamingHorizon) stream(w http.ResponseWriter, cursor string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	h.mutex.Lock()
+	next := len(h.payments)
+	h.mutex.Unlock()
+	if cursor != "now" {
+		// Paging tokens are 1, 2, ...
+		next, _ = strconv.Atoi(cursor)
+	}
+
+	for {
+		h.mutex.Lock()
+		for ; next < len(h.payments); next++ {
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", strings.Join(strings.Fields(h.payments[next]), " "))
+		}
+		added := h.added
+		h.mutex.Unlock()
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-added:
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// deliveries counts receive callbacks of payments
+type deliveries struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func (d *deliveries) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.counts[r.PostForm.Get("id")]++
+}
+
+func (d *deliveries) delivered() map[string]int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	counts := map[string]int{}
+	for id, count := range d.counts {
+		counts[id] = count
+	}
+	return counts
+}
+
+// TestAppLeaderHandoff runs an old and a new version of the bridge against one DB, like during a
+// deploy, and sends payments while the old version hands the listener off to the new one
+func TestAppLeaderHandoff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-handoff")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	receiving := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	horizonServer := newStreamingHorizon(receiving)
+	defer horizonServer.Close()
+	callbacks := &deliveries{counts: map[string]int{}}
+	callbackServer := httptest.NewServer(callbacks)
+	defer callbackServer.Close()
+
+	replicaConfig := func(replica string) config.Config {
+		return config.Config{
+			Horizon:           horizonServer.URL,
+			NetworkPassphrase: "Test SDF Network ; September 2015",
+			Assets:            []config.Asset{{Code: "XLM"}},
+			Accounts:          config.Accounts{ReceivingAccountID: receiving},
+			Callbacks:         config.Callbacks{Receive: callbackServer.URL},
+			Database:          config.Database{Type: "sqlite", URL: filepath.Join(dir, "bridge.db")},
+			Events:            config.Events{Enabled: true},
+			// Shorter than allowed by the config so the test runs a few seconds
+			LeaderElection: config.LeaderElection{Enabled: true, TTLSeconds: 2, Replica: replica, HandoffTimeoutSeconds: 60},
+		}
+	}
+	_, err = Migrate(replicaConfig("old"))
+	require.NoError(t, err)
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	// Both replicas write to the file, WAL keeps readers from blocking the writer. The mode is
+	// stored in the file, so it applies to connections of the Apps too.
+	_, err = driver.DB().Exec("PRAGMA journal_mode = WAL;")
+	require.NoError(t, err)
+
+	waitFor := func(what string, condition func() bool) {
+		deadline := time.Now().Add(20 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	deliveredAll := func(n int) func() bool {
+		return func() bool { return len(callbacks.delivered()) == n }
+	}
+
+	oldApp, err := NewApp(replicaConfig("old"), Options{Version: "0.0.11"})
+	require.NoError(t, err)
+	require.NoError(t, oldApp.Start())
+	defer oldApp.Stop()
+	waitFor("old version to lead", func() bool {
+		var holder string
+		driver.DB().Get(&holder, "SELECT holder FROM LeaderLease WHERE name = 'payment_listener';")
+		return holder == "old"
+	})
+	// The stream of the leader starts at `now`
+	time.Sleep(500 * time.Millisecond)
+	payments := 0
+	for ; payments < 5; payments++ {
+		horizonServer.add(payments + 1)
+	}
+	waitFor("payments received by the old version", deliveredAll(payments))
+
+	newApp, err := NewApp(replicaConfig("new"), Options{Version: "0.0.12"})
+	require.NoError(t, err)
+	require.NoError(t, newApp.Start())
+	defer newApp.Stop()
+
+	// Payments keep arriving during the handoff
+	stop := make(chan struct{})
+	sent := make(chan int)
+	go func() {
+		n := payments
+		defer func() { sent <- n }()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(50 * time.Millisecond):
+				n++
+				horizonServer.add(n)
+			}
+		}
+	}()
+	waitFor("handoff to the new version", func() bool {
+		handoff, _ := driver.GetHandoff("payment_listener")
+		return handoff != nil && handoff.Status == entities.LeaderHandoffStatusReleased
+	})
+	time.Sleep(time.Second)
+	close(stop)
+	payments = <-sent
+	for i := 0; i < 5; i++ {
+		payments++
+		horizonServer.add(payments)
+	}
+
+	waitFor("all payments delivered", deliveredAll(payments))
+	for id, count := range callbacks.delivered() {
+		assert.Equal(t, 1, count, "callbacks of payment %s", id)
+	}
+
+	handoff, err := driver.GetHandoff("payment_listener")
+	require.NoError(t, err)
+	assert.Equal(t, &entities.LeaderHandoff{Name: "payment_listener", Holder: "new", Version: "0.0.12", Status: entities.LeaderHandoffStatusReleased}, handoff)
+	var holder string
+	require.NoError(t, driver.DB().Get(&holder, "SELECT holder FROM LeaderLease WHERE name = 'payment_listener';"))
+	assert.Equal(t, "new", holder)
+	var recorded []string
+	require.NoError(t, driver.DB().Select(&recorded, "SELECT type FROM Event WHERE subject = 'payment_listener' ORDER BY id;"))
+	assert.Equal(t, []string{events.LeaderHandoffRequested, events.LeaderHandoffReleased}, recorded)
+}
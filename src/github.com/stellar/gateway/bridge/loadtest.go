@@ -0,0 +1,114 @@
+package bridge
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/loadtest"
+	"github.com/stellar/go/keypair"
+)
+
+// Loadtest implements `bridge loadtest` sending payments of a plan and writing the report. When
+// targetURL is empty the payments are sent to a server started in this process with the config,
+// a sandbox Horizon and (unless the plan sets use_config_database) a temporary SQLite database.
+// Stage latencies are reported only by the server started in this process.
+func (c *Command) Loadtest(plan loadtest.Plan, targetURL string) error {
+	if targetURL != "" {
+		runner := loadtest.NewRunner(plan, loadtest.NewHTTPTarget(targetURL, c.Config.APIKey), targetURL)
+		return c.write(runner.Run())
+	}
+
+	assets := []horizon.Balance{}
+	for _, asset := range c.Config.Assets {
+		if asset.Code == "XLM" && asset.Issuer == "" {
+			continue
+		}
+		assetType := "credit_alphanum4"
+		if len(asset.Code) > 4 {
+			assetType = "credit_alphanum12"
+		}
+		assets = append(assets, horizon.Balance{AssetType: assetType, AssetCode: asset.Code, AssetIssuer: asset.Issuer})
+	}
+
+	fakeHorizon := loadtest.NewFakeHorizon(plan.Horizon, c.Config.NetworkPassphrase, assets, plan.Seed)
+	for _, account := range loadtestAccounts(c.Config.Accounts, plan) {
+		fakeHorizon.AddAccount(account)
+	}
+	horizonServer := httptest.NewServer(fakeHorizon)
+	defer horizonServer.Close()
+
+	config := c.Config
+	config.Horizon = horizonServer.URL
+	// Payments received by the sandbox are not streamed and external services are not called
+	config.Callbacks.Receive = ""
+	config.Compliance = ""
+	config.LeaderElection.Enabled = false
+	config.WarmStart.Enabled = false
+	config.IssuerInfo.Disabled = true
+
+	if !plan.UseConfigDatabase {
+		dir, err := ioutil.TempDir("", "bridge-loadtest")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		config.Database.Type = "sqlite"
+		config.Database.URL = filepath.Join(dir, "bridge.db")
+		driver, err := openDB(config)
+		if err != nil {
+			return err
+		}
+		_, err = driver.MigrateUp("gateway")
+		if err != nil {
+			return err
+		}
+	}
+
+	app, err := NewApp(config, Options{})
+	if err != nil {
+		return err
+	}
+	err = app.Start()
+	if err != nil {
+		return err
+	}
+	defer app.Stop()
+
+	handler := app.Handler()
+	registry := app.requestHandler.Inflight
+	runner := loadtest.NewRunner(plan, &loadtest.HandlerTarget{Handler: handler, Registry: registry}, "in-process")
+	registry.OnDone = runner.ObservePayment
+	return c.write(runner.Run())
+}
+
+// loadtestAccounts returns accounts that exist in the sandbox Horizon: accounts of the config,
+// sources of the mix and destinations of the pool
+func loadtestAccounts(accounts config.Accounts, plan loadtest.Plan) []string {
+	keys := []string{
+		accounts.AuthorizingSeed,
+		accounts.BaseSeed,
+		accounts.IssuingAccountID,
+		accounts.ReceivingAccountID,
+		accounts.RecoverySeed,
+	}
+	for _, payment := range plan.Mix {
+		keys = append(keys, payment.Params["source"], payment.Params["destination"])
+	}
+	keys = append(keys, plan.Destinations...)
+
+	accountIDs := []string{}
+	for _, key := range keys {
+		// Federated destinations are not resolved by the sandbox
+		kp, err := keypair.Parse(key)
+		if err != nil {
+			continue
+		}
+		accountIDs = append(accountIDs, kp.Address())
+	}
+	return accountIDs
+}
@@ -0,0 +1,454 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/utc"
+)
+
+// ErrNotEmpty is returned by `bridge migrate-legacy` when the database contains data not imported
+// from a legacy server and `--force` is not set
+var ErrNotEmpty = errors.New("Database is not empty, refusing to import legacy data without --force")
+
+// legacyKeys maps keys of the config format used before 0.0.7 to current keys. Values of keys
+// mapped to an empty key are not config values anymore.
+var legacyKeys = map[string]string{
+	"authorizing_seed":     "accounts.authorizing_seed",
+	"base_seed":            "accounts.base_seed",
+	"issuing_account_id":   "accounts.issuing_account_id",
+	"receiving_account_id": "accounts.receiving_account_id",
+	"database_type":        "database.type",
+	"database_url":         "database.url",
+	"hooks.receive":        "callbacks.receive",
+	"hooks.error":          "callbacks.error",
+	// cursor_file is imported into the database
+	"cursor_file": "",
+}
+
+// LegacyMigration contains options of `bridge migrate-legacy`, every part is optional
+type LegacyMigration struct {
+	// LegacyConfig is a config file of a legacy server converted to ConfigFile
+	LegacyConfig string
+	// ConfigFile is the current config file, the database of it receives the imported data
+	ConfigFile string
+	// CursorFile contains the paging token the legacy listener stopped at, `cursor_file` of
+	// LegacyConfig by default
+	CursorFile string
+	// Operations is a CSV file with IDs of operations processed by the legacy server in the first
+	// column
+	Operations string
+	// Force imports data into a database that is not empty and overwrites a different ConfigFile
+	Force bool
+}
+
+// LegacyConfigChange is a key moved by the config conversion, To is empty for removed keys
+type LegacyConfigChange struct {
+	From string `json:"from"`
+	To   string `json:"to,omitempty"`
+}
+
+// LegacyCheck is a verification of the imported data
+type LegacyCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// LegacyMigrationReport is written by `bridge migrate-legacy`
+type LegacyMigrationReport struct {
+	// DryRun is true when nothing was changed because `--yes` was not set
+	DryRun bool `json:"dry_run"`
+	// ConfigChanges are keys moved when converting the legacy config
+	ConfigChanges []LegacyConfigChange `json:"config_changes"`
+	// ConfigWritten is false when the config file already contained the converted config
+	ConfigWritten bool `json:"config_written"`
+	// Cursor is the imported cursor
+	Cursor string `json:"cursor,omitempty"`
+	// CursorImported is false when the cursor was already imported
+	CursorImported bool `json:"cursor_imported"`
+	// OperationsRead is a number of operation IDs in the CSV file, OperationsImported of them
+	// were not in the database yet
+	OperationsRead     int           `json:"operations_read"`
+	OperationsImported int           `json:"operations_imported"`
+	Checks             []LegacyCheck `json:"checks"`
+}
+
+// MigrateLegacy implements `bridge migrate-legacy` importing the state of a legacy bridge server.
+// Running it again with the same files changes nothing: imported operations and the cursor are
+// skipped. Without `--yes` the config changes are reported and nothing is changed.
+func (c *Command) MigrateLegacy(migration LegacyMigration) error {
+	report := LegacyMigrationReport{
+		DryRun:        !c.Confirmed,
+		ConfigChanges: []LegacyConfigChange{},
+		Checks:        []LegacyCheck{},
+	}
+
+	var converted []byte
+	if migration.LegacyConfig != "" {
+		legacy, err := ioutil.ReadFile(migration.LegacyConfig)
+		if err != nil {
+			return err
+		}
+
+		var cursorFile string
+		converted, report.ConfigChanges, cursorFile, err = ConvertLegacyConfig(legacy)
+		if err != nil {
+			return fmt.Errorf("Error converting %s: %s", migration.LegacyConfig, err)
+		}
+		if migration.CursorFile == "" && cursorFile != "" {
+			if !filepath.IsAbs(cursorFile) {
+				cursorFile = filepath.Join(filepath.Dir(migration.LegacyConfig), cursorFile)
+			}
+			migration.CursorFile = cursorFile
+		}
+	}
+
+	if !c.Confirmed {
+		c.write(report)
+		return ErrNotConfirmed
+	}
+
+	if converted != nil {
+		var err error
+		report.ConfigWritten, err = writeConvertedConfig(migration.ConfigFile, converted, migration.Force)
+		if err != nil {
+			return err
+		}
+
+		c.Config, err = config.Load(migration.ConfigFile)
+		if err != nil {
+			return fmt.Errorf("Converted config is invalid: %s", err)
+		}
+	}
+
+	var cursor string
+	if migration.CursorFile != "" {
+		content, err := ioutil.ReadFile(migration.CursorFile)
+		if err != nil {
+			return err
+		}
+		cursor = strings.TrimSpace(string(content))
+		if _, err = strconv.ParseUint(cursor, 10, 64); err != nil && cursor != "now" {
+			return fmt.Errorf("%s must contain a paging token or `now`", migration.CursorFile)
+		}
+	}
+
+	var operationIDs []int64
+	if migration.Operations != "" {
+		file, err := os.Open(migration.Operations)
+		if err != nil {
+			return err
+		}
+		operationIDs, err = readOperationIDs(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("Error reading %s: %s", migration.Operations, err)
+		}
+	}
+
+	if cursor == "" && len(operationIDs) == 0 {
+		report.Checks = append(report.Checks, c.checkConfig())
+		return c.writeLegacyReport(report)
+	}
+
+	driver, err := openDB(c.Config)
+	if err != nil {
+		return err
+	}
+	// Legacy servers didn't have a database, the schema is created here
+	_, err = driver.MigrateUp("gateway")
+	if err != nil {
+		return err
+	}
+	repository := db.NewRepository(driver)
+	entityManager := db.NewEntityManager(driver)
+
+	if !migration.Force {
+		err = checkImportable(repository, cursor)
+		if err != nil {
+			return err
+		}
+	}
+
+	report.OperationsRead = len(operationIDs)
+	for _, operationID := range operationIDs {
+		existing, err := repository.GetReceivedPaymentByOperationID(operationID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		// Backfill payments don't move the live cursor, the imported cursor is used
+		err = entityManager.Persist(&entities.ReceivedPayment{
+			OperationID: strconv.FormatInt(operationID, 10),
+			ProcessedAt: utc.New(c.now()),
+			PagingToken: strconv.FormatInt(operationID, 10),
+			Status:      entities.ReceivedPaymentStatusImported,
+			Backfill:    true,
+		})
+		if err != nil {
+			return err
+		}
+		report.OperationsImported++
+	}
+
+	if cursor != "" {
+		report.Cursor = cursor
+		listenerCursor, err := repository.GetListenerCursor()
+		if err != nil {
+			return err
+		}
+		if listenerCursor == nil || listenerCursor.PagingToken != cursor {
+			lastPaymentID, err := repository.GetLastReceivedPaymentID()
+			if err != nil {
+				return err
+			}
+			err = entityManager.Persist(&entities.ListenerCursor{
+				PagingToken:    cursor,
+				AfterPaymentID: lastPaymentID,
+				SetAt:          utc.New(c.now()),
+			})
+			if err != nil {
+				return err
+			}
+			report.CursorImported = true
+		}
+	}
+
+	report.Checks = append(report.Checks, c.checkConfig())
+	report.Checks = append(report.Checks, checkImported(repository, cursor, operationIDs)...)
+	return c.writeLegacyReport(report)
+}
+
+// writeLegacyReport writes the report and returns an error when a check failed
+func (c *Command) writeLegacyReport(report LegacyMigrationReport) error {
+	err := c.write(report)
+	if err != nil {
+		return err
+	}
+	for _, check := range report.Checks {
+		if !check.OK {
+			return fmt.Errorf("Verification failed: %s", check.Name)
+		}
+	}
+	return nil
+}
+
+func (c *Command) checkConfig() LegacyCheck {
+	check := LegacyCheck{Name: "config", OK: true}
+	if err := c.Config.Validate(); err != nil {
+		check.OK = false
+		check.Detail = err.Error()
+	}
+	return check
+}
+
+// checkImportable returns ErrNotEmpty when the database contains sent transactions, payments
+// processed by this server or a listener cursor other than the imported one
+func checkImportable(repository db.Repository, cursor string) error {
+	transactions, err := repository.GetSentTransactions(1, 1)
+	if err != nil {
+		return err
+	}
+	if len(transactions) > 0 {
+		return ErrNotEmpty
+	}
+
+	payments, err := repository.CountReceivedPaymentsExcept(entities.ReceivedPaymentStatusImported)
+	if err != nil {
+		return err
+	}
+	if payments > 0 {
+		return ErrNotEmpty
+	}
+
+	listenerCursor, err := repository.GetListenerCursor()
+	if err != nil {
+		return err
+	}
+	if listenerCursor != nil && listenerCursor.PagingToken != cursor {
+		return ErrNotEmpty
+	}
+	return nil
+}
+
+// checkImported verifies the listener starts from the imported cursor and skips imported operations
+func checkImported(repository db.Repository, cursor string, operationIDs []int64) []LegacyCheck {
+	checks := []LegacyCheck{}
+
+	if cursor != "" {
+		check := LegacyCheck{Name: "cursor", OK: true}
+		value, err := repository.GetLastCursorValue()
+		switch {
+		case err != nil:
+			check.OK, check.Detail = false, err.Error()
+		case value == nil || *value != cursor:
+			// A payment processed after the import moved the cursor (only with --force)
+			check.OK, check.Detail = false, "the listener doesn't start from the imported cursor"
+		}
+		checks = append(checks, check)
+	}
+
+	if len(operationIDs) > 0 {
+		check := LegacyCheck{Name: "operations", OK: true}
+		for _, operationID := range operationIDs {
+			payment, err := repository.GetReceivedPaymentByOperationID(operationID)
+			if err != nil {
+				check.OK, check.Detail = false, err.Error()
+				break
+			}
+			if payment == nil {
+				check.OK, check.Detail = false, fmt.Sprintf("operation %d is missing", operationID)
+				break
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// ConvertLegacyConfig converts a config file of a legacy server to the current format. It returns
+// the converted file, moved keys ordered by legacy key and the legacy `cursor_file` value.
+func ConvertLegacyConfig(legacy []byte) (converted []byte, changes []LegacyConfigChange, cursorFile string, err error) {
+	values := map[string]interface{}{}
+	_, err = toml.Decode(string(legacy), &values)
+	if err != nil {
+		return
+	}
+
+	changes = []LegacyConfigChange{}
+	for from, to := range legacyKeys {
+		value, ok := removeKey(values, from)
+		if !ok {
+			continue
+		}
+		changes = append(changes, LegacyConfigChange{From: from, To: to})
+
+		if to == "" {
+			if from == "cursor_file" {
+				cursorFile, _ = value.(string)
+			}
+			continue
+		}
+		if existing, exists := getKey(values, to); exists && !reflect.DeepEqual(existing, value) {
+			err = fmt.Errorf("%s conflicts with %s", from, to)
+			return
+		}
+		setKey(values, to, value)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].From < changes[j].From })
+
+	var buffer bytes.Buffer
+	err = toml.NewEncoder(&buffer).Encode(values)
+	converted = buffer.Bytes()
+	return
+}
+
+// writeConvertedConfig writes the converted config unless path already contains it. A different
+// existing file is overwritten only with force.
+func writeConvertedConfig(path string, converted []byte, force bool) (bool, error) {
+	existing, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil && bytes.Equal(existing, converted):
+		return false, nil
+	case err == nil && !force:
+		return false, fmt.Errorf("%s exists and differs from the converted config, refusing to overwrite it without --force", path)
+	case err != nil && !os.IsNotExist(err):
+		return false, err
+	}
+	return true, ioutil.WriteFile(path, converted, 0600)
+}
+
+// readOperationIDs reads operation IDs from the first column of a CSV file. The first line is
+// skipped when it's a header.
+func readOperationIDs(input io.Reader) ([]int64, error) {
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+
+	operationIDs := []int64{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return operationIDs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		value := strings.TrimSpace(record[0])
+		operationID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || operationID <= 0 {
+			if line == 1 && err != nil {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid operation ID %q", line, value)
+		}
+		operationIDs = append(operationIDs, operationID)
+	}
+}
+
+// getKey returns a value of a dotted key of decoded TOML
+func getKey(values map[string]interface{}, key string) (interface{}, bool) {
+	path := strings.Split(key, ".")
+	for _, name := range path[:len(path)-1] {
+		table, ok := values[name].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		values = table
+	}
+	value, ok := values[path[len(path)-1]]
+	return value, ok
+}
+
+// setKey sets a value of a dotted key of decoded TOML creating missing tables
+func setKey(values map[string]interface{}, key string, value interface{}) {
+	path := strings.Split(key, ".")
+	for _, name := range path[:len(path)-1] {
+		table, ok := values[name].(map[string]interface{})
+		if !ok {
+			table = map[string]interface{}{}
+			values[name] = table
+		}
+		values = table
+	}
+	values[path[len(path)-1]] = value
+}
+
+// removeKey removes a dotted key of decoded TOML and returns its value. Tables left empty are
+// removed too.
+func removeKey(values map[string]interface{}, key string) (interface{}, bool) {
+	path := strings.Split(key, ".")
+	if len(path) == 1 {
+		value, ok := values[key]
+		delete(values, key)
+		return value, ok
+	}
+
+	table, ok := values[path[0]].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := removeKey(table, strings.Join(path[1:], "."))
+	if len(table) == 0 {
+		delete(values, path[0])
+	}
+	return value, ok
+}
@@ -0,0 +1,168 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacyConfig = `port = 8001
+horizon = "https://horizon-testnet.stellar.org"
+network_passphrase = "Test SDF Network ; September 2015"
+base_seed = "SAV75E2NK7Q5JZZLBBBNUPCIAKABN64HNHMDLD62SZWM6EBJ4R7CUNTZ"
+receiving_account_id = "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"
+database_type = "sqlite"
+database_url = "%DIR%/bridge.db"
+cursor_file = "cursor"
+
+[hooks]
+receive = "https://example.com/receive"
+`
+
+func TestConvertLegacyConfig(t *testing.T) {
+	converted, changes, cursorFile, err := ConvertLegacyConfig([]byte(strings.Replace(legacyConfig, "%DIR%", "/var/lib/bridge", 1)))
+	require.NoError(t, err)
+	assert.Equal(t, "cursor", cursorFile)
+	assert.Equal(t, []LegacyConfigChange{
+		{From: "base_seed", To: "accounts.base_seed"},
+		{From: "cursor_file"},
+		{From: "database_type", To: "database.type"},
+		{From: "database_url", To: "database.url"},
+		{From: "hooks.receive", To: "callbacks.receive"},
+		{From: "receiving_account_id", To: "accounts.receiving_account_id"},
+	}, changes)
+	assert.NotContains(t, string(converted), "hooks")
+
+	dir, err := ioutil.TempDir("", "bridge-legacy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bridge.cfg")
+	require.NoError(t, ioutil.WriteFile(path, converted, 0600))
+
+	loaded, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/receive", loaded.Callbacks.Receive)
+	assert.Equal(t, "SAV75E2NK7Q5JZZLBBBNUPCIAKABN64HNHMDLD62SZWM6EBJ4R7CUNTZ", loaded.Accounts.BaseSeed)
+	assert.Equal(t, "/var/lib/bridge/bridge.db", loaded.Database.URL)
+
+	// Converting a current config changes nothing
+	again, changes, _, err := ConvertLegacyConfig(converted)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, string(converted), string(again))
+
+	_, _, _, err = ConvertLegacyConfig([]byte("base_seed = \"SA\"\n[accounts]\nbase_seed = \"SB\"\n"))
+	assert.EqualError(t, err, "base_seed conflicts with accounts.base_seed")
+}
+
+func TestReadOperationIDs(t *testing.T) {
+	ids, err := readOperationIDs(strings.NewReader("operation_id,processed_at\n12884905985,2017-03-01\n\n12884905986\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{12884905985, 12884905986}, ids)
+
+	_, err = readOperationIDs(strings.NewReader("12884905985\nabc\n"))
+	assert.EqualError(t, err, `line 2: invalid operation ID "abc"`)
+}
+
+func TestCommandMigrateLegacy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-legacy")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	legacyPath := filepath.Join(dir, "legacy.cfg")
+	require.NoError(t, ioutil.WriteFile(legacyPath, []byte(strings.Replace(legacyConfig, "%DIR%", dir, 1)), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cursor"), []byte("12884905990\n"), 0600))
+	operationsPath := filepath.Join(dir, "operations.csv")
+	require.NoError(t, ioutil.WriteFile(operationsPath, []byte("operation_id\n12884905985\n12884905986\n"), 0600))
+
+	migration := LegacyMigration{
+		LegacyConfig: legacyPath,
+		ConfigFile:   filepath.Join(dir, "bridge.cfg"),
+		Operations:   operationsPath,
+	}
+	// The config is loaded by `bridge` when there is no legacy config
+	var running config.Config
+	run := func(confirmed bool, migration LegacyMigration) (LegacyMigrationReport, error) {
+		output := &bytes.Buffer{}
+		command := NewCommand(running, output, confirmed)
+		command.now = func() time.Time { return time.Date(2017, 3, 1, 9, 30, 15, 0, time.UTC) }
+		err := command.MigrateLegacy(migration)
+
+		var report LegacyMigrationReport
+		if output.Len() > 0 {
+			require.NoError(t, json.Unmarshal(output.Bytes(), &report))
+		}
+		return report, err
+	}
+
+	// Config changes are only reported without --yes
+	report, err := run(false, migration)
+	assert.Equal(t, ErrNotConfirmed, err)
+	assert.True(t, report.DryRun)
+	assert.Len(t, report.ConfigChanges, 6)
+	_, err = os.Stat(migration.ConfigFile)
+	assert.True(t, os.IsNotExist(err))
+
+	report, err = run(true, migration)
+	require.NoError(t, err)
+	assert.True(t, report.ConfigWritten)
+	assert.Equal(t, "12884905990", report.Cursor)
+	assert.True(t, report.CursorImported)
+	assert.Equal(t, 2, report.OperationsRead)
+	assert.Equal(t, 2, report.OperationsImported)
+	assert.Equal(t, []LegacyCheck{
+		{Name: "config", OK: true},
+		{Name: "cursor", OK: true},
+		{Name: "operations", OK: true},
+	}, report.Checks)
+
+	loaded, err := config.Load(migration.ConfigFile)
+	require.NoError(t, err)
+	driver, err := openDB(loaded)
+	require.NoError(t, err)
+	repository := db.NewRepository(driver)
+	payment, err := repository.GetReceivedPaymentByOperationID(12884905985)
+	require.NoError(t, err)
+	require.NotNil(t, payment)
+	assert.Equal(t, entities.ReceivedPaymentStatusImported, payment.Status)
+
+	// Running again changes nothing
+	report, err = run(true, migration)
+	require.NoError(t, err)
+	assert.False(t, report.ConfigWritten)
+	assert.False(t, report.CursorImported)
+	assert.Equal(t, 0, report.OperationsImported)
+
+	// A different config is not overwritten
+	require.NoError(t, ioutil.WriteFile(legacyPath, []byte(strings.Replace(legacyConfig, "%DIR%", dir, 1)+"api_key = \"0123456789abcdef\"\n"), 0600))
+	_, err = run(true, migration)
+	assert.Contains(t, err.Error(), "refusing to overwrite it without --force")
+
+	// Data of the new server is not mixed with legacy data
+	require.NoError(t, db.NewEntityManager(driver).Persist(&entities.SentTransaction{
+		TransactionID: "c492d87c4642815dfb3c7dcce01af4effd162b031064098a0d786b6e0a00fd74",
+		Status:        entities.SentTransactionStatusSuccess,
+		Source:        "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW",
+		SubmittedAt:   utc.New(time.Now()),
+		EnvelopeXdr:   "AAAA",
+	}))
+	migration.LegacyConfig = ""
+	running = loaded
+	_, err = run(true, migration)
+	assert.Equal(t, ErrNotEmpty, err)
+	migration.Force = true
+	_, err = run(true, migration)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,149 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+)
+
+// newMultiNetworkApp constructs an App of every network of the config. Networks don't share
+// submitters, listeners or databases, only the log sampler is shared. Components of networks are
+// named `<network>/<component>`.
+func newMultiNetworkApp(config config.Config, options Options) (app *App, err error) {
+	trustedProxies, err := server.ParseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return
+	}
+
+	logger := log.StandardLogger()
+	if options.Logger != nil {
+		logger.Out = options.Logger.Out
+		logger.Hooks = options.Logger.Hooks
+		logger.Level = options.Logger.Level
+		logger.Formatter = options.Logger.Formatter
+	}
+
+	logSampler, err := logging.NewSampler(config.LogSampling)
+	if err != nil {
+		return
+	}
+	log.SetFormatter(&logging.Formatter{Formatter: logger.Formatter, Sampler: logSampler})
+
+	app = &App{
+		config:         config,
+		options:        options,
+		trustedProxies: trustedProxies,
+		networks:       map[string]*App{},
+	}
+
+	for _, name := range config.NetworkNames() {
+		networkConfig, _ := config.NetworkConfig(name)
+		// The standard logger is configured once
+		networkOptions := options
+		networkOptions.Logger = nil
+		networkOptions.logSampler = logSampler
+		if name != config.DefaultNetworkName() {
+			networkOptions.Driver = nil
+			networkOptions.Horizon = nil
+		}
+
+		log.WithField("network", name).Print("Creating network")
+		var networkApp *App
+		networkApp, err = newNetworkApp(networkConfig, networkOptions)
+		if err != nil {
+			err = fmt.Errorf("%s network: %s", name, err)
+			return nil, err
+		}
+		networkApp.networkStatus = app.networksStatus
+
+		app.networks[name] = networkApp
+		for _, c := range networkApp.components {
+			app.components = append(app.components, component{name + "/" + c.Name(), c.Start, c.Stop})
+		}
+	}
+	return
+}
+
+// networkRoutes returns a handler passing requests to endpoints of their network
+func (a *App) networkRoutes() http.Handler {
+	routes := map[string]http.Handler{}
+	for name, networkApp := range a.networks {
+		routes[name] = networkApp.routes()
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := a.requestNetwork(r)
+		if !ok {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		handler, ok := routes[name]
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			server.Write(w, bridge.NewNetworkNotConfiguredError(name))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// requestNetwork returns the name of the network of r: `network` param, the network of a network
+// API key or the default network. Params of JSON bodies are read without consuming the body. It
+// returns false when the API key of a network is used with `network` param of another one.
+func (a *App) requestNetwork(r *http.Request) (string, bool) {
+	var name, apiKey string
+	if r.Method == http.MethodPost && bridge.IsJSONRequest(r) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			var params struct {
+				Network string `json:"network"`
+				APIKey  string `json:"apiKey"`
+			}
+			// Invalid bodies are rejected by endpoints of the network
+			json.Unmarshal(body, &params)
+			name, apiKey = params.Network, params.APIKey
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	} else {
+		name, apiKey = r.FormValue(bridge.NetworkParam), r.PostFormValue("apiKey")
+	}
+
+	keyNetwork := a.config.NetworkOfAPIKey(apiKey)
+	switch {
+	case name != "":
+		return name, keyNetwork == "" || keyNetwork == name
+	case keyNetwork != "":
+		return keyNetwork, true
+	default:
+		return a.config.DefaultNetworkName(), true
+	}
+}
+
+// networksStatus implements /status of a server with several networks, the status of every
+// network is returned in `networks`
+func (a *App) networksStatus(w http.ResponseWriter, r *http.Request) {
+	networks := map[string]interface{}{}
+	for name, networkApp := range a.networks {
+		status := networkApp.requestHandler.StatusReport()
+		status["network_passphrase"] = networkApp.config.NetworkPassphrase
+		networks[name] = status
+	}
+
+	err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"default_network": a.config.DefaultNetworkName(),
+		"networks":        networks,
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Error("Error encoding status")
+		server.Write(w, protocols.InternalServerError)
+	}
+}
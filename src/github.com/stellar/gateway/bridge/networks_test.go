@@ -0,0 +1,133 @@
+package bridge
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/go/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppNetworks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-networks")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defaultIssuer := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	testnetIssuer := "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"
+	c := config.Config{
+		Horizon:           "https://horizon.stellar.org",
+		NetworkPassphrase: network.PublicNetworkPassphrase,
+		Accounts:          config.Accounts{IssuingAccountID: defaultIssuer},
+		Database:          config.Database{Type: "sqlite", URL: filepath.Join(dir, "default.db")},
+		Networks: map[string]config.Network{
+			"testnet": {
+				Horizon:           "https://horizon-testnet.stellar.org",
+				NetworkPassphrase: network.TestNetworkPassphrase,
+				APIKey:            "testnet-api-key-0123",
+				Accounts:          config.Accounts{IssuingAccountID: testnetIssuer},
+				Database:          config.Database{Type: "sqlite", URL: filepath.Join(dir, "testnet.db")},
+			},
+		},
+	}
+	// Databases of both networks are migrated
+	applied, err := Migrate(c)
+	require.NoError(t, err)
+	single, err := Migrate(config.Config{Database: c.Database})
+	require.NoError(t, err)
+	assert.Equal(t, 0, single)
+	assert.True(t, applied > 0)
+
+	app, err := NewApp(c, Options{})
+	require.NoError(t, err)
+	handler := app.Handler()
+
+	send := func(request *http.Request) (int, map[string]interface{}) {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		var body map[string]interface{}
+		json.Unmarshal(response.Body.Bytes(), &body)
+		return response.Code, body
+	}
+	payment := func(params url.Values) (int, map[string]interface{}) {
+		params.Set("destination", "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ")
+		params.Set("amount", "1")
+		request := httptest.NewRequest(http.MethodPost, "/payment", strings.NewReader(params.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return send(request)
+	}
+
+	t.Run("status of every network", func(t *testing.T) {
+		code, body := send(httptest.NewRequest(http.MethodGet, "/status", nil))
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "default", body["default_network"])
+		networks := body["networks"].(map[string]interface{})
+		require.Len(t, networks, 2)
+		assert.Equal(t, network.PublicNetworkPassphrase, networks["default"].(map[string]interface{})["network_passphrase"])
+		assert.Equal(t, network.TestNetworkPassphrase, networks["testnet"].(map[string]interface{})["network_passphrase"])
+	})
+
+	t.Run("unknown network", func(t *testing.T) {
+		code, body := payment(url.Values{"network": {"futurenet"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "network_not_configured", body["code"])
+	})
+
+	t.Run("source of another network", func(t *testing.T) {
+		code, body := payment(url.Values{"source": {testnetIssuer}})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "source_other_network", body["code"])
+		assert.Equal(t, map[string]interface{}{"network": "default", "source_network": "testnet"}, body["data"])
+	})
+
+	t.Run("network of API key", func(t *testing.T) {
+		code, body := payment(url.Values{"apiKey": {"testnet-api-key-0123"}, "source": {defaultIssuer}})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "source_other_network", body["code"])
+		assert.Equal(t, "testnet", body["data"].(map[string]interface{})["network"])
+
+		code, _ = payment(url.Values{"apiKey": {"testnet-api-key-0123"}, "network": {"default"}, "source": {defaultIssuer}})
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("network of JSON body", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/payment", strings.NewReader(`{
+			"network": "testnet",
+			"source": "`+defaultIssuer+`",
+			"destination": "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+			"amount": "1"
+		}`))
+		request.Header.Set("Content-Type", "application/json")
+		code, _ := send(request)
+		// The testnet network has its own API key
+		assert.Equal(t, http.StatusForbidden, code)
+
+		request = httptest.NewRequest(http.MethodPost, "/payment", strings.NewReader(`{
+			"apiKey": "testnet-api-key-0123",
+			"network": "testnet",
+			"source": "`+defaultIssuer+`",
+			"destination": "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ",
+			"amount": "1"
+		}`))
+		request.Header.Set("Content-Type", "application/json")
+		code, body := send(request)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, "source_other_network", body["code"])
+	})
+
+	names := []string{}
+	for _, c := range app.Components() {
+		names = append(names, c.Name())
+	}
+	assert.Contains(t, names, "default/leader_elector")
+	assert.Contains(t, names, "testnet/leader_elector")
+}
@@ -0,0 +1,300 @@
+//go:build integration
+// +build integration
+
+package bridge
+
+// End-to-end tests against the public testnet, run with:
+//
+//   go test -tags integration -run TestTestnet -timeout 10m github.com/stellar/gateway/bridge
+//
+// TESTNET_HORIZON_URL and TESTNET_FRIENDBOT_URL change the network (ex. a private network with
+// the testnet passphrase). Tests are skipped when the network is unavailable or reset.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/utc"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testnetPassphrase = "Test SDF Network ; September 2015"
+	// testnetMinLedger is a ledger below which the testnet is considered to be recently reset
+	testnetMinLedger = 1000
+	pollTimeout      = 90 * time.Second
+	pollInterval     = 2 * time.Second
+)
+
+// testnet contains throwaway accounts funded by friendbot
+type testnet struct {
+	t         *testing.T
+	horizon   horizon.Horizon
+	friendbot string
+	submitter submitter.TransactionSubmitter
+	accounts  []*keypair.Full
+}
+
+func testnetURL(env, defaultURL string) string {
+	if value := os.Getenv(env); value != "" {
+		return value
+	}
+	return defaultURL
+}
+
+// newTestnet skips the test when the testnet is unavailable, running a different network or was
+// reset recently
+func newTestnet(t *testing.T) *testnet {
+	n := &testnet{
+		t:         t,
+		horizon:   horizon.New(testnetURL("TESTNET_HORIZON_URL", "https://horizon-testnet.stellar.org")),
+		friendbot: testnetURL("TESTNET_FRIENDBOT_URL", "https://friendbot.stellar.org"),
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(n.horizon.ServerURL)
+	if err != nil {
+		t.Skipf("Testnet Horizon is unavailable: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Skipf("Testnet Horizon responded with %d status code", resp.StatusCode)
+	}
+
+	var root struct {
+		NetworkPassphrase   string `json:"network_passphrase"`
+		HistoryLatestLedger uint32 `json:"history_latest_ledger"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&root))
+	if root.NetworkPassphrase != testnetPassphrase {
+		t.Skipf("Horizon runs %q network, not the testnet", root.NetworkPassphrase)
+	}
+	if root.HistoryLatestLedger < testnetMinLedger {
+		t.Skipf("Testnet was reset recently (latest ledger %d), try again later", root.HistoryLatestLedger)
+	}
+	return n
+}
+
+// poll calls condition every pollInterval until it returns true. The test fails after
+// pollTimeout, or is skipped when accounts created by the test are gone.
+func (n *testnet) poll(what string, condition func() (bool, error)) {
+	deadline := time.Now().Add(pollTimeout)
+	var err error
+	for time.Now().Before(deadline) {
+		var done bool
+		done, err = condition()
+		if done {
+			return
+		}
+		time.Sleep(pollInterval)
+	}
+
+	n.skipIfReset()
+	n.t.Fatalf("Timed out waiting for %s, last error: %v", what, err)
+}
+
+// skipIfReset skips the test when an account created by the test does not exist anymore
+func (n *testnet) skipIfReset() {
+	for _, account := range n.accounts {
+		_, err := n.horizon.LoadAccount(account.Address())
+		if statusErr, ok := err.(*horizon.StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+			n.t.Skipf("Testnet was reset during the test, account %s is gone", account.Address())
+		}
+	}
+}
+
+// fund creates a new account funded by friendbot
+func (n *testnet) fund() *keypair.Full {
+	kp, err := keypair.Random()
+	require.NoError(n.t, err)
+
+	n.poll("friendbot funding "+kp.Address(), func() (bool, error) {
+		resp, err := http.Get(n.friendbot + "?addr=" + url.QueryEscape(kp.Address()))
+		if err != nil {
+			return false, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return false, fmt.Errorf("friendbot responded with %d status code", resp.StatusCode)
+		}
+		return true, nil
+	})
+	n.accounts = append(n.accounts, kp)
+	return kp
+}
+
+// submit submits a transaction with a single operation signed by source
+func (n *testnet) submit(source *keypair.Full, operation interface{}) {
+	response, err := n.submitter.SubmitTransaction(source.Seed(), operation, nil)
+	if err == nil && response.Ledger == nil {
+		err = fmt.Errorf("transaction failed: %+v", response.Extras)
+	}
+	if err != nil {
+		n.skipIfReset()
+		n.t.Fatalf("Error submitting a setup transaction of %s: %s", source.Address(), err)
+	}
+}
+
+// callbackReceiver records receive callbacks
+type callbackReceiver struct {
+	mutex    sync.Mutex
+	payments map[string]url.Values
+}
+
+func (c *callbackReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.payments[r.PostForm.Get("id")] = r.PostForm
+}
+
+// received returns callbacks of payments sent by from
+func (c *callbackReceiver) received(from string) []url.Values {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var payments []url.Values
+	for _, payment := range c.payments {
+		if payment.Get("from") == from {
+			payments = append(payments, payment)
+		}
+	}
+	return payments
+}
+
+func TestTestnetPayments(t *testing.T) {
+	n := newTestnet(t)
+
+	dir, err := ioutil.TempDir("", "bridge-testnet")
+	require.NoError(t, err)
+	receiver := &callbackReceiver{payments: map[string]url.Values{}}
+	callbacks := httptest.NewServer(receiver)
+	// The listener and background jobs of the app are not stopped, they fail harmlessly after
+	// the servers are closed until the test binary exits
+	t.Cleanup(func() {
+		callbacks.Close()
+		os.RemoveAll(dir)
+	})
+
+	issuer := n.fund()
+	source := n.fund()
+	receiving := n.fund()
+	usd := b.CreditAsset("USD", issuer.Address())
+
+	var c config.Config
+	port := 8006
+	c.Port = &port
+	c.Horizon = n.horizon.ServerURL
+	c.NetworkPassphrase = testnetPassphrase
+	c.Database.Type = "sqlite"
+	c.Database.URL = filepath.Join(dir, "bridge.db")
+	c.Accounts.BaseSeed = source.Seed()
+	c.Accounts.ReceivingAccountID = receiving.Address()
+	c.Callbacks.Receive = callbacks.URL
+	c.Assets = []config.Asset{{Code: "XLM"}, {Code: "USD", Issuer: issuer.Address()}}
+	require.NoError(t, c.Validate())
+
+	driver, err := openDB(c)
+	require.NoError(t, err)
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+	entityManager := db.NewEntityManager(driver)
+
+	// Trustlines, USD of the source and an offer for path payments
+	n.submitter = submitter.NewTransactionSubmitter(&n.horizon, entityManager, testnetPassphrase, time.Now)
+	n.submit(source, b.Trust("USD", issuer.Address()))
+	n.submit(receiving, b.Trust("USD", issuer.Address()))
+	n.submit(issuer, b.Payment(b.Destination{source.Address()}, b.CreditAmount{"USD", issuer.Address(), "100"}))
+	n.submit(issuer, b.CreateOffer(b.Rate{Selling: usd, Buying: b.NativeAsset(), Price: "1"}, "50"))
+
+	// The listener starts from the current ledger so payments are not missed while it connects
+	latestLedger, err := n.horizon.LoadLatestLedger()
+	require.NoError(t, err)
+	require.NoError(t, entityManager.Persist(&entities.ListenerCursor{
+		PagingToken: strconv.FormatUint(uint64(latestLedger)<<32, 10),
+		SetAt:       utc.New(time.Now()),
+	}))
+
+	app, err := NewApp(c, Options{Version: "integration"})
+	require.NoError(t, err)
+	bridgeServer := httptest.NewServer(app.routes())
+	t.Cleanup(bridgeServer.Close)
+
+	pay := func(values url.Values) {
+		resp, err := http.PostForm(bridgeServer.URL+"/payment", values)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		if resp.StatusCode != http.StatusOK {
+			n.skipIfReset()
+			t.Fatalf("/payment responded with %d: %s", resp.StatusCode, body)
+		}
+	}
+
+	pay(url.Values{
+		"destination": {receiving.Address()},
+		"amount":      {"10"},
+		"memo_type":   {"text"},
+		"memo":        {"integration"},
+	})
+	pay(url.Values{
+		"destination":  {receiving.Address()},
+		"amount":       {"5"},
+		"asset_code":   {"USD"},
+		"asset_issuer": {issuer.Address()},
+	})
+	pay(url.Values{
+		"destination":  {receiving.Address()},
+		"amount":       {"2"},
+		"asset_code":   {"USD"},
+		"asset_issuer": {issuer.Address()},
+		"send_max":     {"3"},
+	})
+
+	n.poll("receive callbacks", func() (bool, error) {
+		received := len(receiver.received(source.Address()))
+		return received == 3, fmt.Errorf("%d of 3 callbacks received", received)
+	})
+
+	amounts := map[string]string{}
+	for _, payment := range receiver.received(source.Address()) {
+		amounts[payment.Get("asset_code")+" "+payment.Get("amount")] = payment.Get("route")
+	}
+	assert.Equal(t, map[string]string{
+		" 10.0000000":   "integration",
+		"USD 5.0000000": "",
+		"USD 2.0000000": "",
+	}, amounts)
+
+	// Payments are stored after callbacks succeed
+	repository := db.NewRepository(driver)
+	for _, callback := range receiver.received(source.Address()) {
+		operationID, err := strconv.ParseInt(callback.Get("id"), 10, 64)
+		require.NoError(t, err)
+		n.poll("received payment "+callback.Get("id"), func() (bool, error) {
+			payment, err := repository.GetReceivedPaymentByOperationID(operationID)
+			if err != nil || payment == nil {
+				return false, err
+			}
+			return payment.Status == "Success", fmt.Errorf("status %q", payment.Status)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package bridge
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/signatures"
+)
+
+// maxExportLineSize is the maximum length of a line of an envelopes export
+const maxExportLineSize = 1024 * 1024
+
+// VerificationReport is written for every exported envelope with unattributed signatures
+type VerificationReport struct {
+	Hash         string                    `json:"hash"`
+	Error        string                    `json:"error,omitempty"`
+	Unattributed []signatures.Unattributed `json:"unattributed,omitempty"`
+}
+
+// AllowedSigners returns public keys of the config seeds and `accounts.allowed_signers`
+func AllowedSigners(config config.Config) []string {
+	var signers []string
+	for _, seed := range []string{config.Accounts.BaseSeed, config.Accounts.AuthorizingSeed, config.Accounts.RecoverySeed} {
+		if seed != "" {
+			signers = append(signers, seed)
+		}
+	}
+	return append(signers, config.Accounts.AllowedSigners...)
+}
+
+// VerifySignatures reads an export of /admin/export/envelopes from input and writes a report to output
+// for every envelope that cannot be decoded or contains a signature not made by one of allowedSigners.
+// It returns the number of reported envelopes.
+func VerifySignatures(networkPassphrase string, allowedSigners []string, input io.Reader, output io.Writer) (int, error) {
+	verifier, err := signatures.NewVerifier(networkPassphrase, allowedSigners)
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxExportLineSize)
+	encoder := json.NewEncoder(output)
+
+	reported := 0
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var envelope signatures.ExportedEnvelope
+		err = json.Unmarshal(scanner.Bytes(), &envelope)
+		if err != nil {
+			return reported, fmt.Errorf("Cannot decode line %d: %s", line, err)
+		}
+
+		report := VerificationReport{Hash: envelope.Hash}
+		result, err := verifier.Verify(envelope.EnvelopeXDR)
+		if err != nil {
+			report.Error = err.Error()
+		} else if result.Valid() {
+			continue
+		} else {
+			report.Unattributed = result.Unattributed
+		}
+
+		reported++
+		err = encoder.Encode(report)
+		if err != nil {
+			return reported, err
+		}
+	}
+
+	return reported, scanner.Err()
+}
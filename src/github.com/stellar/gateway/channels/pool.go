@@ -0,0 +1,206 @@
+// Package channels leases channel accounts: accounts used only as transaction sources so
+// payments of the same account are submitted concurrently, each with the sequence of its channel
+package channels
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/go/keypair"
+)
+
+// DefaultLeaseWait is how long Lease waits for a free channel when the wait is not configured
+const DefaultLeaseWait = time.Second
+
+// AccountLoader loads accounts of channels to sync their sequence numbers
+type AccountLoader interface {
+	LoadAccount(accountID string) (horizon.AccountResponse, error)
+}
+
+// ExhaustedError is returned by Lease when no channel has been released in time
+type ExhaustedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ExhaustedError) Error() string {
+	return fmt.Sprintf("all channels are leased, retry after %s", e.RetryAfter)
+}
+
+// Pool is a pool of channel accounts. Sequence numbers of channels are tracked locally, a channel
+// is synced with Horizon when it's leased for the first time and after a transaction submitted
+// with it has not been included in a ledger (ex. tx_bad_seq or a lost Horizon response). A
+// disabled pool (zero value) has no channels.
+type Pool struct {
+	loader AccountLoader
+	wait   time.Duration
+	free   chan *channel
+	size   int
+}
+
+type channel struct {
+	seed     string
+	address  string
+	sequence uint64
+	synced   bool
+}
+
+// NewPool creates a pool of channels of seeds, Lease waits for a free channel at most wait
+// (DefaultLeaseWait when 0)
+func NewPool(loader AccountLoader, seeds []string, wait time.Duration) (*Pool, error) {
+	if wait == 0 {
+		wait = DefaultLeaseWait
+	}
+	pool := &Pool{
+		loader: loader,
+		wait:   wait,
+		free:   make(chan *channel, len(seeds)),
+		size:   len(seeds),
+	}
+	for _, seed := range seeds {
+		kp, err := keypair.Parse(seed)
+		if err != nil {
+			return nil, fmt.Errorf("invalid channel seed: %s", err)
+		}
+		if _, ok := kp.(*keypair.Full); !ok {
+			return nil, fmt.Errorf("channel %s is not a seed", kp.Address())
+		}
+		pool.free <- &channel{seed: seed, address: kp.Address()}
+	}
+	return pool, nil
+}
+
+// Enabled returns true when the pool has channels
+func (p *Pool) Enabled() bool {
+	return p != nil && p.size > 0
+}
+
+// Size returns the number of channels
+func (p *Pool) Size() int {
+	if p == nil {
+		return 0
+	}
+	return p.size
+}
+
+// Free returns the number of channels not leased
+func (p *Pool) Free() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.free)
+}
+
+// Lease leases a free channel, syncing its sequence number when needed. It returns
+// *ExhaustedError when all channels stay leased for the wait of the pool and errors of the
+// AccountLoader when the channel cannot be synced.
+func (p *Pool) Lease() (*Lease, error) {
+	var c *channel
+	select {
+	case c = <-p.free:
+	default:
+		timer := time.NewTimer(p.wait)
+		defer timer.Stop()
+		select {
+		case c = <-p.free:
+		case <-timer.C:
+			return nil, &ExhaustedError{RetryAfter: p.wait}
+		}
+	}
+
+	if !c.synced {
+		account, err := p.loader.LoadAccount(c.address)
+		if err != nil {
+			p.free <- c
+			return nil, err
+		}
+		c.sequence, err = strconv.ParseUint(account.SequenceNumber, 10, 64)
+		if err != nil {
+			p.free <- c
+			return nil, fmt.Errorf("invalid sequence number of channel %s: %s", c.address, err)
+		}
+		c.synced = true
+	}
+
+	return &Lease{Sequence: c.sequence + 1, pool: p, channel: c}, nil
+}
+
+// Invalidate makes free channels sync their sequence numbers when they are leased next time (ex.
+// after another replica could use them). Leased channels are synced only when their transactions
+// are not included.
+func (p *Pool) Invalidate() {
+	if !p.Enabled() {
+		return
+	}
+	for i := len(p.free); i > 0; i-- {
+		select {
+		case c := <-p.free:
+			c.synced = false
+			p.free <- c
+		default:
+			return
+		}
+	}
+}
+
+// Lease is a channel leased for a single transaction. It must be released, methods of nil Lease
+// (no channel has been leased) do nothing.
+type Lease struct {
+	// Sequence is the sequence number of the transaction of the lease
+	Sequence uint64
+
+	pool    *Pool
+	channel *channel
+
+	mutex     sync.Mutex
+	submitted bool
+	included  bool
+	released  bool
+}
+
+// Address returns the account ID of the channel
+func (l *Lease) Address() string {
+	return l.channel.address
+}
+
+// Seed returns the seed of the channel, signing the transaction as its source
+func (l *Lease) Seed() string {
+	return l.channel.seed
+}
+
+// Submitted records that the transaction of the lease has been submitted and whether it has been
+// included in a ledger
+func (l *Lease) Submitted(included bool) {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.submitted = true
+	l.included = included
+}
+
+// Release returns the channel to the pool. The sequence number of the lease is used by the
+// channel when the transaction has been included, the channel is synced on its next lease when
+// the transaction has been submitted but not included, it's unchanged otherwise.
+func (l *Lease) Release() {
+	if l == nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.released {
+		return
+	}
+	l.released = true
+
+	switch {
+	case l.included:
+		l.channel.sequence = l.Sequence
+	case l.submitted:
+		l.channel.synced = false
+	}
+	l.pool.free <- l.channel
+}
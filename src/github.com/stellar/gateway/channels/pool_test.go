@@ -0,0 +1,135 @@
+package channels
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	channelSeed    = "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H"
+	channelAddress = "GA3FR7TVTDJAY6TN4MUX7BF4KK6SUHWIYDY7NRNUDTA4OVY3IMY7B6H5"
+)
+
+func TestPoolLease(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", channelAddress).Return(horizon.AccountResponse{AccountID: channelAddress, SequenceNumber: "100"}, nil).Once()
+
+	pool, err := NewPool(mockHorizon, []string{channelSeed}, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, pool.Enabled())
+	assert.Equal(t, 1, pool.Size())
+
+	lease, err := pool.Lease()
+	require.NoError(t, err)
+	assert.Equal(t, channelAddress, lease.Address())
+	assert.Equal(t, channelSeed, lease.Seed())
+	assert.Equal(t, uint64(101), lease.Sequence)
+	assert.Equal(t, 0, pool.Free())
+
+	// All channels are leased
+	_, err = pool.Lease()
+	require.IsType(t, &ExhaustedError{}, err)
+	assert.Equal(t, 10*time.Millisecond, err.(*ExhaustedError).RetryAfter)
+
+	// Included transactions advance the local sequence number, Horizon is not asked again
+	lease.Submitted(true)
+	lease.Release()
+	lease.Release()
+	assert.Equal(t, 1, pool.Free())
+
+	lease, err = pool.Lease()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(102), lease.Sequence)
+
+	// Unsubmitted transactions don't use the sequence number
+	lease.Release()
+	lease, err = pool.Lease()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(102), lease.Sequence)
+	mockHorizon.AssertExpectations(t)
+}
+
+func TestPoolResync(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", channelAddress).Return(horizon.AccountResponse{AccountID: channelAddress, SequenceNumber: "100"}, nil).Once()
+	pool, err := NewPool(mockHorizon, []string{channelSeed}, 0)
+	require.NoError(t, err)
+
+	lease, err := pool.Lease()
+	require.NoError(t, err)
+
+	// ex. tx_bad_seq, the channel is synced on the next lease
+	lease.Submitted(false)
+	lease.Release()
+
+	mockHorizon.On("LoadAccount", channelAddress).Return(horizon.AccountResponse{}, errors.New("horizon is down")).Once()
+	_, err = pool.Lease()
+	assert.EqualError(t, err, "horizon is down")
+	assert.Equal(t, 1, pool.Free())
+
+	mockHorizon.On("LoadAccount", channelAddress).Return(horizon.AccountResponse{AccountID: channelAddress, SequenceNumber: "105"}, nil).Once()
+	lease, err = pool.Lease()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(106), lease.Sequence)
+	mockHorizon.AssertExpectations(t)
+}
+
+func TestPoolInvalidate(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", channelAddress).Return(horizon.AccountResponse{AccountID: channelAddress, SequenceNumber: "100"}, nil).Once()
+	pool, err := NewPool(mockHorizon, []string{channelSeed}, 0)
+	require.NoError(t, err)
+
+	lease, err := pool.Lease()
+	require.NoError(t, err)
+	lease.Submitted(true)
+	lease.Release()
+
+	// ex. another replica became the leader, the channel is synced on the next lease
+	pool.Invalidate()
+	assert.Equal(t, 1, pool.Free())
+	mockHorizon.On("LoadAccount", channelAddress).Return(horizon.AccountResponse{AccountID: channelAddress, SequenceNumber: "110"}, nil).Once()
+	lease, err = pool.Lease()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(111), lease.Sequence)
+	mockHorizon.AssertExpectations(t)
+
+	(&Pool{}).Invalidate()
+}
+
+func TestPoolWaitsForRelease(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", channelAddress).Return(horizon.AccountResponse{AccountID: channelAddress, SequenceNumber: "100"}, nil).Once()
+	pool, err := NewPool(mockHorizon, []string{channelSeed}, time.Minute)
+	require.NoError(t, err)
+
+	lease, err := pool.Lease()
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		lease.Submitted(true)
+		lease.Release()
+	}()
+
+	lease, err = pool.Lease()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(102), lease.Sequence)
+}
+
+func TestDisabledPool(t *testing.T) {
+	var lease *Lease
+	lease.Submitted(true)
+	lease.Release()
+
+	assert.False(t, (&Pool{}).Enabled())
+	assert.False(t, (*Pool)(nil).Enabled())
+
+	_, err := NewPool(nil, []string{channelAddress}, 0)
+	assert.EqualError(t, err, "channel "+channelAddress+" is not a seed")
+}
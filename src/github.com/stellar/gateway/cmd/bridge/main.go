@@ -1,13 +1,17 @@
 package main
 
 import (
+	"fmt"
 	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
 	"runtime"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 	"github.com/stellar/gateway/bridge"
 	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db/schema"
+	"github.com/stellar/gateway/loadtest"
 )
 
 var app *bridge.App
@@ -15,7 +19,24 @@ var rootCmd *cobra.Command
 var migrateFlag bool
 var configFile string
 var versionFlag bool
+var strictSecurityFlag bool
+var securityWaivers []string
 var version = "N/A"
+var backfillAccount string
+var backfillFromLedger uint32
+var verifyInput string
+var verifySigners []string
+var yesFlag bool
+var cursorSet string
+var reprocessForce bool
+var legacyMigration bridge.LegacyMigration
+var loadtestPlan string
+var loadtestTarget string
+var loadtestOutput string
+var reconcileDate string
+var reconcileFormat string
+var snapshotRelease string
+var snapshotOutput string
 
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
@@ -33,35 +54,297 @@ func init() {
 	rootCmd.Flags().BoolVarP(&migrateFlag, "migrate-db", "", false, "migrate DB to the newest schema version")
 	rootCmd.Flags().StringVarP(&configFile, "config", "c", "bridge.cfg", "path to config file")
 	rootCmd.Flags().BoolVarP(&versionFlag, "version", "v", false, "displays bridge server version")
+	rootCmd.Flags().BoolVarP(&strictSecurityFlag, "strict-security", "", false, "refuse to start with an insecure config")
+	rootCmd.Flags().StringSliceVarP(&securityWaivers, "security-waiver", "", nil, "strict security check to waive (can be repeated)")
+
+	backfillCmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "process historical payments received before the listener cursor",
+		Long:  `Processes payments of an account starting at a given ledger through the payment listener. Already processed payments are skipped. Progress is saved so an interrupted backfill continues where it stopped.`,
+		Run:   runBackfill,
+	}
+	backfillCmd.Flags().StringVarP(&configFile, "config", "c", "bridge.cfg", "path to config file")
+	backfillCmd.Flags().StringVarP(&backfillAccount, "account", "", "", "account to backfill (default: accounts.receiving_account_id)")
+	backfillCmd.Flags().Uint32VarP(&backfillFromLedger, "from-ledger", "", 0, "ledger to start the backfill from")
+	rootCmd.AddCommand(backfillCmd)
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify-signatures",
+		Short: "verify signatures of envelopes exported from /admin/export/envelopes",
+		Long:  `Decodes every envelope of an /admin/export/envelopes export and reports signatures not made by allowed signers: public keys of the config seeds, accounts.allowed_signers and --signer flags. Exits with a non-zero status when any envelope is reported.`,
+		Run:   runVerifySignatures,
+	}
+	verifyCmd.Flags().StringVarP(&configFile, "config", "c", "bridge.cfg", "path to config file")
+	verifyCmd.Flags().StringVarP(&verifyInput, "input", "i", "-", "path to the export file (- reads stdin)")
+	verifyCmd.Flags().StringSliceVarP(&verifySigners, "signer", "", nil, "additional allowed signer public key (can be repeated)")
+	rootCmd.AddCommand(verifyCmd)
+
+	// Operational commands work directly with the DB and Horizon when the HTTP API is not available
+	txCmd := &cobra.Command{Use: "tx", Short: "inspect transactions sent by the server"}
+	txCmd.AddCommand(&cobra.Command{
+		Use:   "status <hash>",
+		Short: "show a transaction sent by the server",
+		Run: runCommand(1, func(c *bridge.Command, args []string) error {
+			return c.TxStatus(args[0])
+		}),
+	})
+
+	listenerCmd := &cobra.Command{Use: "listener", Short: "inspect and control the payment listener"}
+	cursorCmd := &cobra.Command{
+		Use:   "cursor",
+		Short: "show or set the paging token the payment listener starts from",
+		Long:  `Shows the paging token the payment listener starts from. --set changes it (a paging token or "now"), the new cursor is used when the listener starts or a standby replica takes over and until a newer payment is processed. Payments that were already processed are never processed again.`,
+		Run: runCommand(0, func(c *bridge.Command, args []string) error {
+			return c.ListenerCursor(cursorSet)
+		}),
+	}
+	cursorCmd.Flags().StringVarP(&cursorSet, "set", "", "", "new cursor (requires --yes)")
+	listenerCmd.AddCommand(cursorCmd)
+
+	reprocessCmd := &cobra.Command{
+		Use:   "reprocess <operation-id>",
+		Short: "reprocess a received payment and send the receive callback again",
+		Run: runCommand(1, func(c *bridge.Command, args []string) error {
+			return c.Reprocess(args[0], reprocessForce)
+		}),
+	}
+	reprocessCmd.Flags().BoolVarP(&reprocessForce, "force", "", false, "reprocess a successful payment")
+
+	accountsCmd := &cobra.Command{Use: "accounts", Short: "inspect configured accounts"}
+	accountsCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "list configured accounts with sequence numbers and balances",
+		Run: runCommand(0, func(c *bridge.Command, args []string) error {
+			return c.AccountsList()
+		}),
+	})
+
+	limitsCmd := &cobra.Command{Use: "limits", Short: "inspect limits enforced by the server"}
+	limitsCmd.AddCommand(&cobra.Command{
+		Use:   "show",
+		Short: "show limits of the config file",
+		Run: runCommand(0, func(c *bridge.Command, args []string) error {
+			return c.LimitsShow()
+		}),
+	})
+
+	migrateLegacyCmd := &cobra.Command{
+		Use:   "migrate-legacy",
+		Short: "import the state of a legacy bridge server",
+		Long:  `Converts a legacy config file (--legacy-config) to the current format written to --config, imports the paging token of a legacy cursor file and IDs of operations processed by the legacy server (CSV, first column) into the database of the config, then verifies the result. Without --yes only config changes are reported. Running it again changes nothing, a database containing other data or a different config file require --force.`,
+		Run:   runMigrateLegacy,
+	}
+	migrateLegacyCmd.Flags().StringVarP(&legacyMigration.LegacyConfig, "legacy-config", "", "", "config file of the legacy server")
+	migrateLegacyCmd.Flags().StringVarP(&legacyMigration.CursorFile, "cursor-file", "", "", "cursor file of the legacy server (default: cursor_file of --legacy-config)")
+	migrateLegacyCmd.Flags().StringVarP(&legacyMigration.Operations, "operations", "", "", "CSV file with IDs of processed operations")
+	migrateLegacyCmd.Flags().BoolVarP(&legacyMigration.Force, "force", "", false, "import into a non-empty database and overwrite a different config file")
+
+	loadtestCmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "send synthetic payments and report latency, errors and saturation",
+		Long:  `Sends /payment requests of a JSON plan (--plan) at its target rate and writes a JSON report with latency percentiles, response codes and the point the server saturated. Payments are sent to a server started with the config, a sandbox Horizon and a temporary database unless --target is the URL of a running server (it submits real transactions).`,
+		Run:   runLoadtest,
+	}
+	loadtestCmd.Flags().StringVarP(&loadtestPlan, "plan", "", "", "path to the plan file")
+	loadtestCmd.Flags().StringVarP(&loadtestTarget, "target", "", "", "URL of a running bridge server (default: a server in this process with a sandbox Horizon)")
+	loadtestCmd.Flags().StringVarP(&loadtestOutput, "output", "o", "-", "path to the report file (- writes stdout)")
+
+	reconcileCmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "compare bridge records of a day with Horizon history",
+		Long:  `Matches payments of configured accounts in ledgers closed during a UTC day (--date) with sent transactions and received payments of the database and writes the report. With --yes the report replaces the stored report of the day and callbacks.reconciliation is called when it has mismatches.`,
+		Run: runCommand(0, func(c *bridge.Command, args []string) error {
+			return c.Reconcile(reconcileDate, reconcileFormat)
+		}),
+	}
+	reconcileCmd.Flags().StringVarP(&reconcileDate, "date", "", "", "UTC day to reconcile (YYYY-MM-DD)")
+	reconcileCmd.Flags().StringVarP(&reconcileFormat, "format", "", "json", "report format (json or csv)")
+
+	schemaCmd := &cobra.Command{Use: "schema", Short: "maintain database schema snapshots"}
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "write the schema snapshot of a release",
+		Long:  `Writes migrations of every database type as of this build to the snapshot file. Run it when a release (--release) is made and commit the file, contract tests migrate databases of the snapshot to check that newer migrations bring them to the schema of a new database and that released migrations are not changed. It doesn't need a config file.`,
+		Run:   runSchemaSnapshot,
+	}
+	snapshotCmd.Flags().StringVarP(&snapshotRelease, "release", "", "", "released version")
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "db/schema/testdata/snapshot.json", "path to the snapshot file")
+	schemaCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(schemaCmd)
+
+	for _, cmd := range []*cobra.Command{txCmd, listenerCmd, reprocessCmd, accountsCmd, limitsCmd, migrateLegacyCmd, loadtestCmd, reconcileCmd} {
+		cmd.PersistentFlags().StringVarP(&configFile, "config", "c", "bridge.cfg", "path to config file")
+		cmd.PersistentFlags().BoolVarP(&yesFlag, "yes", "y", false, "confirm commands changing data or sending callbacks")
+		rootCmd.AddCommand(cmd)
+	}
 }
 
-func run(cmd *cobra.Command, args []string) {
-	viper.SetConfigFile(configFile)
-	viper.SetConfigType("toml")
-	err := viper.ReadInConfig()
+// runCommand runs an operational command taking argsCount args with the config file
+func runCommand(argsCount int, run func(c *bridge.Command, args []string) error) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if len(args) != argsCount {
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		config := loadConfig()
+		err := run(bridge.NewCommand(config, os.Stdout, yesFlag), args)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+}
+
+func runMigrateLegacy(cmd *cobra.Command, args []string) {
+	// The config file is written by the command when a legacy config is converted
+	var c config.Config
+	if legacyMigration.LegacyConfig == "" {
+		c = loadConfig()
+	}
+
+	legacyMigration.ConfigFile = configFile
+	err := bridge.NewCommand(c, os.Stdout, yesFlag).MigrateLegacy(legacyMigration)
 	if err != nil {
-		log.Fatal("Error reading "+configFile+" file: ", err)
+		log.Fatal(err.Error())
 	}
+}
 
-	var config config.Config
-	err = viper.Unmarshal(&config)
+func runLoadtest(cmd *cobra.Command, args []string) {
+	if loadtestPlan == "" {
+		cmd.Usage()
+		os.Exit(1)
+	}
 
-	err = config.Validate()
+	plan, err := loadtest.LoadPlan(loadtestPlan)
 	if err != nil {
 		log.Fatal(err.Error())
-		return
 	}
 
-	if config.LogFormat == "json" {
-		log.SetFormatter(&log.JSONFormatter{})
+	var output io.Writer = os.Stdout
+	if loadtestOutput != "-" {
+		file, err := os.Create(loadtestOutput)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		defer file.Close()
+		output = file
 	}
 
-	app, err = bridge.NewApp(config, migrateFlag, versionFlag, version)
+	err = bridge.NewCommand(loadConfig(), output, yesFlag).Loadtest(plan, loadtestTarget)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+func runSchemaSnapshot(cmd *cobra.Command, args []string) {
+	if snapshotRelease == "" {
+		cmd.Usage()
+		os.Exit(1)
+	}
 
+	snapshot, err := schema.NewSnapshot(snapshotRelease)
 	if err != nil {
 		log.Fatal(err.Error())
+	}
+
+	err = snapshot.Write(snapshotOutput)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	log.Info("Schema snapshot of ", snapshotRelease, " written to ", snapshotOutput)
+}
+
+func runVerifySignatures(cmd *cobra.Command, args []string) {
+	config := loadConfig()
+
+	var input io.Reader = os.Stdin
+	if verifyInput != "-" {
+		file, err := os.Open(verifyInput)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		defer file.Close()
+		input = file
+	}
+
+	signers := append(bridge.AllowedSigners(config), verifySigners...)
+	reported, err := bridge.VerifySignatures(config.NetworkPassphrase, signers, input, os.Stdout)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if reported > 0 {
+		log.Fatalf("%d envelope(s) with unattributed signatures", reported)
+	}
+}
+
+func runBackfill(cmd *cobra.Command, args []string) {
+	config := loadConfig()
+
+	accountID := backfillAccount
+	if accountID == "" {
+		accountID = config.Accounts.ReceivingAccountID
+	}
+	if accountID == "" {
+		log.Fatal("--account or accounts.receiving_account_id is required")
+	}
+
+	err := bridge.RunBackfill(config, accountID, backfillFromLedger)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+func run(cmd *cobra.Command, args []string) {
+	if versionFlag {
+		fmt.Printf("Bridge Server Version: %s \n", version)
+		return
+	}
+
+	options := bridge.Options{ConfigFile: configFile, Version: version}
+	if strictSecurityFlag {
+		policy, err := config.NewSecurityPolicy(securityWaivers)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		options.Security = policy
+	} else if len(securityWaivers) > 0 {
+		log.Fatal("--security-waiver requires --strict-security")
+	}
+
+	config := loadConfig()
+
+	if migrateFlag {
+		migrationsApplied, err := bridge.Migrate(config)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+		log.Info("Applied migrations: ", migrationsApplied)
 		return
 	}
 
+	var err error
+	app, err = bridge.NewApp(config, options)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	err = app.Start()
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
 	app.Serve()
 }
+
+func loadConfig() config.Config {
+	config, err := config.Load(configFile)
+	if err != nil {
+		log.Fatal("Error loading "+configFile+" file: ", err)
+	}
+
+	if config.LogFormat == "json" {
+		log.SetFormatter(&log.JSONFormatter{})
+	}
+
+	return config
+}
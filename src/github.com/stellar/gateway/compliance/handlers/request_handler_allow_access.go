@@ -2,8 +2,8 @@ package handlers
 
 import (
 	log "github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/utc"
 	"net/http"
-	"time"
 
 	"github.com/stellar/gateway/db/entities"
 	"github.com/stellar/gateway/protocols"
@@ -28,7 +28,7 @@ func (rh *RequestHandler) HandlerAllowAccess(c web.C, w http.ResponseWriter, r *
 			FiDomain:    domain,
 			FiPublicKey: publicKey,
 			UserID:      userID,
-			AllowedAt:   time.Now(),
+			AllowedAt:   utc.Now(),
 		}
 		err = rh.EntityManager.Persist(entity)
 	} else {
@@ -36,7 +36,7 @@ func (rh *RequestHandler) HandlerAllowAccess(c web.C, w http.ResponseWriter, r *
 			Name:      name,
 			Domain:    domain,
 			PublicKey: publicKey,
-			AllowedAt: time.Now(),
+			AllowedAt: utc.Now(),
 		}
 		err = rh.EntityManager.Persist(entity)
 	}
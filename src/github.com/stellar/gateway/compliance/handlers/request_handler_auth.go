@@ -10,7 +10,6 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,6 +18,7 @@ import (
 	callback "github.com/stellar/gateway/protocols/compliance"
 	"github.com/stellar/gateway/server"
 	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/utc"
 	baseAmount "github.com/stellar/go/amount"
 	"github.com/stellar/go/protocols/compliance"
 	"github.com/stellar/go/xdr"
@@ -389,7 +389,7 @@ func (rh *RequestHandler) HandlerAuth(c web.C, w http.ResponseWriter, r *http.Re
 			TransactionID:  hex.EncodeToString(transactionHash[:]),
 			Memo:           base64.StdEncoding.EncodeToString(memoBytes[:]),
 			TransactionXdr: authData.Tx,
-			AuthorizedAt:   time.Now(),
+			AuthorizedAt:   utc.Now(),
 			Data:           authreq.DataJSON,
 		}
 		err = rh.EntityManager.Persist(authorizedTransaction)
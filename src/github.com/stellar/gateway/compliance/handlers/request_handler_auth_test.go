@@ -285,7 +285,7 @@ func TestRequestHandlerAuth(t *testing.T) {
 					assert.Equal(t, authorizedTransaction.TransactionID, value.TransactionID)
 					assert.Equal(t, authorizedTransaction.Memo, value.Memo)
 					assert.Equal(t, authorizedTransaction.TransactionXdr, value.TransactionXdr)
-					assert.WithinDuration(t, time.Now(), value.AuthorizedAt, 2*time.Second)
+					assert.WithinDuration(t, time.Now(), value.AuthorizedAt.Time(), 2*time.Second)
 					assert.Equal(t, authorizedTransaction.Data, value.Data)
 				}).Return(nil).Once()
 
@@ -462,7 +462,7 @@ func TestRequestHandlerAuth(t *testing.T) {
 					assert.Equal(t, authorizedTransaction.TransactionID, value.TransactionID)
 					assert.Equal(t, authorizedTransaction.Memo, value.Memo)
 					assert.Equal(t, authorizedTransaction.TransactionXdr, value.TransactionXdr)
-					assert.WithinDuration(t, time.Now(), value.AuthorizedAt, 2*time.Second)
+					assert.WithinDuration(t, time.Now(), value.AuthorizedAt.Time(), 2*time.Second)
 					assert.Equal(t, authorizedTransaction.Data, value.Data)
 				}).Return(nil).Once()
 
@@ -666,7 +666,7 @@ func TestRequestHandlerAuth(t *testing.T) {
 					assert.Equal(t, authorizedTransaction.TransactionID, value.TransactionID)
 					assert.Equal(t, authorizedTransaction.Memo, value.Memo)
 					assert.Equal(t, authorizedTransaction.TransactionXdr, value.TransactionXdr)
-					assert.WithinDuration(t, time.Now(), value.AuthorizedAt, 2*time.Second)
+					assert.WithinDuration(t, time.Now(), value.AuthorizedAt.Time(), 2*time.Second)
 					assert.Equal(t, authorizedTransaction.Data, value.Data)
 				}).Return(nil).Once()
 
@@ -717,7 +717,7 @@ func TestRequestHandlerAuth(t *testing.T) {
 						assert.Equal(t, authorizedTransaction.TransactionID, value.TransactionID)
 						assert.Equal(t, authorizedTransaction.Memo, value.Memo)
 						assert.Equal(t, authorizedTransaction.TransactionXdr, value.TransactionXdr)
-						assert.WithinDuration(t, time.Now(), value.AuthorizedAt, 2*time.Second)
+						assert.WithinDuration(t, time.Now(), value.AuthorizedAt.Time(), 2*time.Second)
 						assert.Equal(t, authorizedTransaction.Data, value.Data)
 					}).Return(nil).Once()
 
@@ -774,7 +774,7 @@ func TestRequestHandlerAuth(t *testing.T) {
 						assert.Equal(t, authorizedTransaction.TransactionID, value.TransactionID)
 						assert.Equal(t, authorizedTransaction.Memo, value.Memo)
 						assert.Equal(t, authorizedTransaction.TransactionXdr, value.TransactionXdr)
-						assert.WithinDuration(t, time.Now(), value.AuthorizedAt, 2*time.Second)
+						assert.WithinDuration(t, time.Now(), value.AuthorizedAt.Time(), 2*time.Second)
 						assert.Equal(t, authorizedTransaction.Data, value.Data)
 					}).Return(nil).Once()
 
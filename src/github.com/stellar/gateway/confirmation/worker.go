@@ -0,0 +1,306 @@
+// Package confirmation posts outcomes of transactions of /payment requests sent with `callback`
+// param. Callbacks are stored in the DB before the transaction is submitted and they are
+// delivered by a Worker running on the leader replica, so a restart does not lose them. Outcomes
+// of submissions without a Horizon response are looked up in Horizon.
+package confirmation
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/leader"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+)
+
+const (
+	// DefaultInterval is the time between lookups of transactions whose outcome is unknown
+	DefaultInterval = 5 * time.Second
+	// DefaultPendingTimeout is the time after which a transaction Horizon has not found is
+	// reported with `not_found` status
+	DefaultPendingTimeout = 10 * time.Minute
+	// batchSize is a number of callbacks loaded at once
+	batchSize = 50
+)
+
+// Event is the event of payment callbacks
+const Event = "payment_confirmation"
+
+// DefaultRetry delivers a callback in 10 attempts from 10 seconds to an hour apart, it's used
+// when `retry.payment_callbacks` is not configured
+var DefaultRetry = retry.Settings{MaxAttempts: 10, BaseBackoff: 10 * time.Second, MaxBackoff: time.Hour, Jitter: 0.2}
+
+// Payload is the JSON body posted to callback URLs
+type Payload struct {
+	Event string `json:"event"`
+	// ID is the `id` param of the payment, empty when it was not sent
+	ID   string `json:"id"`
+	Hash string `json:"hash"`
+	// Status is entities.PaymentCallbackSuccess, entities.PaymentCallbackFailed or
+	// entities.PaymentCallbackNotFound
+	Status string `json:"status"`
+	// Ledger of a successful transaction
+	Ledger *uint64 `json:"ledger"`
+	// ResultCodes of a failed transaction
+	ResultCodes *horizon.ResultCodes `json:"result_codes"`
+}
+
+// Outcome returns the status, the ledger and JSON result codes of a transaction from the response
+// of its submission
+func Outcome(response horizon.SubmitTransactionResponse) (status string, ledger *uint64, resultCodes *string) {
+	if response.Ledger != nil {
+		return entities.PaymentCallbackSuccess, response.Ledger, nil
+	}
+	if codes := bridge.ResultCodesFromHorizonResponse(response); codes != nil {
+		encoded, _ := json.Marshal(codes)
+		value := string(encoded)
+		resultCodes = &value
+	}
+	return entities.PaymentCallbackFailed, nil, resultCodes
+}
+
+// Worker delivers payment callbacks. A callback whose URL does not respond with 200 OK is
+// delivered again after a backoff of Retry until its attempts are exhausted.
+type Worker struct {
+	// Interval is the time between runs, runs are also started by Wake
+	Interval time.Duration
+	// PendingTimeout is the time a transaction is looked up in Horizon before it's reported as not
+	// found
+	PendingTimeout time.Duration
+	// Retry sets attempts and backoffs of deliveries, its calls are not made by Do
+	Retry *retry.Policy
+	// MACKey signs callback bodies like other callbacks
+	MACKey   string
+	Webhooks *webhook.Client
+	// Elector delivers callbacks only on the leader, nil delivers them on every replica
+	Elector *leader.Elector
+
+	horizon       horizon.HorizonInterface
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	log           *logrus.Entry
+	now           func() time.Time
+
+	wake chan struct{}
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewWorker creates a new Worker looking up transactions in horizon
+func NewWorker(
+	horizon horizon.HorizonInterface,
+	repository db.RepositoryInterface,
+	entityManager db.EntityManagerInterface,
+	now func() time.Time,
+) *Worker {
+	return &Worker{
+		Interval:       DefaultInterval,
+		PendingTimeout: DefaultPendingTimeout,
+		Retry:          retry.NewPolicy(retry.PaymentCallbacks, DefaultRetry, time.Sleep),
+		horizon:        horizon,
+		repository:     repository,
+		entityManager:  entityManager,
+		log:            logrus.WithFields(logrus.Fields{"service": "PaymentCallbacks"}),
+		now:            now,
+		wake:           make(chan struct{}, 1),
+		stop:           make(chan struct{}),
+	}
+}
+
+// Run delivers callbacks in the background until Stop is called
+func (w *Worker) Run() {
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+		for {
+			if err := w.process(); err != nil {
+				w.log.WithFields(logrus.Fields{"err": err}).Error("Error sending payment callbacks")
+			}
+			select {
+			case <-ticker.C:
+			case <-w.wake:
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the worker, a callback being delivered is finished
+func (w *Worker) Stop() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+// Wake starts a run after the outcome of a transaction with a callback is known, it does nothing
+// when a run is already pending or the worker is disabled
+func (w *Worker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// process handles due callbacks until none is left, every handled callback is due again later
+// or it's not pending anymore
+func (w *Worker) process() error {
+	for {
+		callbacks, err := w.repository.GetPaymentCallbacks(w.now(), batchSize)
+		if err != nil {
+			return errors.Wrap(err, "Error loading payment callbacks")
+		}
+		if len(callbacks) == 0 {
+			return nil
+		}
+
+		for _, callback := range callbacks {
+			select {
+			case <-w.stop:
+				return nil
+			default:
+			}
+			// Leadership can be handed off during a run
+			if w.Elector != nil && !w.Elector.IsLeader() {
+				return nil
+			}
+
+			err := w.handle(callback)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle looks up the outcome of the transaction of a callback when it's unknown and makes an
+// attempt to deliver the callback when it's known
+func (w *Worker) handle(callback *entities.PaymentCallback) error {
+	logger := w.log.WithFields(logrus.Fields{"hash": callback.TransactionID, "payment_id": callback.PaymentID})
+	now := w.now()
+
+	if callback.TransactionStatus == "" {
+		resolved, err := w.lookup(callback, now)
+		if err != nil {
+			logger.WithFields(logrus.Fields{"err": err}).Warn("Error loading transaction of payment callback")
+		}
+		if !resolved {
+			callback.NextAttemptAt = utc.New(now.Add(w.Interval))
+			return errors.Wrap(w.entityManager.Persist(callback), "Error saving payment callback")
+		}
+	}
+
+	callback.Attempts++
+	err := w.deliver(callback)
+	switch {
+	case err == nil:
+		delivered := utc.New(now)
+		callback.Status = entities.PaymentCallbackStatusDelivered
+		callback.DeliveredAt = &delivered
+		callback.LastError = ""
+		w.Retry.Record(callback.Attempts, false)
+		logger.WithFields(logrus.Fields{"status": callback.TransactionStatus, "attempts": callback.Attempts}).Info("Payment callback delivered")
+	case w.Retry.MaxAttempts() > 0 && callback.Attempts >= w.Retry.MaxAttempts():
+		callback.Status = entities.PaymentCallbackStatusFailed
+		callback.LastError = err.Error()
+		w.Retry.Record(callback.Attempts, true)
+		logger.WithFields(logrus.Fields{logging.CategoryField: logging.CategoryCallbacks, "err": err, "attempts": callback.Attempts}).Error("Payment callback failed")
+	default:
+		callback.LastError = err.Error()
+		callback.NextAttemptAt = utc.New(now.Add(w.backoff(callback.Attempts)))
+		logger.WithFields(logrus.Fields{"err": err, "attempts": callback.Attempts}).Warn("Error sending payment callback, retrying later")
+	}
+	return errors.Wrap(w.entityManager.Persist(callback), "Error saving payment callback")
+}
+
+// lookup resolves the outcome of a transaction submitted without a Horizon response, it returns
+// false while the transaction is not in a ledger and PendingTimeout has not passed
+func (w *Worker) lookup(callback *entities.PaymentCallback, now time.Time) (bool, error) {
+	transaction, err := w.horizon.LoadTransaction(callback.TransactionID)
+	if statusErr, ok := err.(*horizon.StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+		if now.Sub(callback.CreatedAt.Time()) < w.PendingTimeout {
+			return false, nil
+		}
+		callback.Resolve(entities.PaymentCallbackNotFound, nil, nil, utc.New(now))
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	status, ledger, resultCodes := Outcome(transaction.ToSubmitTransactionResponse())
+	callback.Resolve(status, ledger, resultCodes, utc.New(now))
+	return true, nil
+}
+
+// backoff returns the wait after a failed delivery, at least Interval
+func (w *Worker) backoff(attempts int) time.Duration {
+	backoff := w.Retry.Backoff(attempts)
+	if backoff < w.Interval {
+		return w.Interval
+	}
+	return backoff
+}
+
+// deliver posts the payload of a callback to its URL
+func (w *Worker) deliver(callback *entities.PaymentCallback) error {
+	payload := Payload{
+		Event:  Event,
+		ID:     callback.PaymentID,
+		Hash:   callback.TransactionID,
+		Status: callback.TransactionStatus,
+		Ledger: callback.Ledger,
+	}
+	if callback.ResultCodes != nil {
+		payload.ResultCodes = &horizon.ResultCodes{}
+		if err := json.Unmarshal([]byte(*callback.ResultCodes), payload.ResultCodes); err != nil {
+			return errors.Wrap(err, "invalid stored result codes")
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "encoding payload failed")
+	}
+
+	req, err := http.NewRequest("POST", callback.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "configure http request failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if w.MACKey != "" {
+		rawkey, err := strkey.Decode(strkey.VersionByteSeed, w.MACKey)
+		if err != nil {
+			return errors.Wrap(err, "invalid MAC key")
+		}
+		macer := hmac.New(sha256.New, rawkey)
+		macer.Write(body)
+		req.Header.Set("X_PAYLOAD_MAC", base64.StdEncoding.EncodeToString(macer.Sum(nil)))
+	}
+
+	resp, err := w.Webhooks.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "Error sending request to payment callback")
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		responseBody, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("Error response from payment callback: %d %s", resp.StatusCode, responseBody)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package confirmation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stellar/go/strkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-confirmation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+	repository := db.NewRepository(driver)
+	entityManager := db.NewEntityManager(driver)
+
+	macKey := "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J"
+	status := http.StatusOK
+	var payloads []Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		rawkey, err := strkey.Decode(strkey.VersionByteSeed, macKey)
+		require.NoError(t, err)
+		macer := hmac.New(sha256.New, rawkey)
+		macer.Write(body)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(macer.Sum(nil)), r.Header.Get("X_PAYLOAD_MAC"))
+
+		var payload Payload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		payloads = append(payloads, payload)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	webhooks, err := webhook.NewClient(webhook.Settings{})
+	require.NoError(t, err)
+
+	now := time.Date(2018, 1, 2, 10, 0, 0, 0, time.UTC)
+	stored := func(hash, transactionStatus string) {
+		var ledger *uint64
+		if transactionStatus == entities.PaymentCallbackSuccess {
+			value := uint64(1988727)
+			ledger = &value
+		}
+		require.NoError(t, entityManager.Persist(&entities.PaymentCallback{
+			TransactionID:     hash,
+			PaymentID:         "order-" + hash,
+			URL:               server.URL,
+			Status:            entities.PaymentCallbackStatusPending,
+			TransactionStatus: transactionStatus,
+			Ledger:            ledger,
+			CreatedAt:         utc.New(now),
+			NextAttemptAt:     utc.New(now),
+		}))
+	}
+	load := func(hash string) *entities.PaymentCallback {
+		found, err := driver.GetOne(&entities.PaymentCallback{}, "transaction_id = ?", hash)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		return found.(*entities.PaymentCallback)
+	}
+
+	mockHorizon := new(mocks.MockHorizon)
+	worker := NewWorker(mockHorizon, repository, entityManager, func() time.Time { return now })
+	worker.Retry = retry.NewPolicy(retry.PaymentCallbacks, retry.Settings{MaxAttempts: 2, BaseBackoff: time.Minute}, nil)
+	worker.MACKey = macKey
+	worker.Webhooks = webhooks
+
+	t.Run("known outcome is delivered", func(t *testing.T) {
+		stored("6a00", entities.PaymentCallbackSuccess)
+
+		require.NoError(t, worker.process())
+		require.Len(t, payloads, 1)
+		ledger := uint64(1988727)
+		assert.Equal(t, Payload{Event: Event, ID: "order-6a00", Hash: "6a00", Status: "success", Ledger: &ledger}, payloads[0])
+
+		callback := load("6a00")
+		assert.Equal(t, entities.PaymentCallbackStatusDelivered, callback.Status)
+		assert.Equal(t, 1, callback.Attempts)
+		require.NotNil(t, callback.DeliveredAt)
+		assert.Equal(t, now, callback.DeliveredAt.Time())
+		mockHorizon.AssertNotCalled(t, "LoadTransaction", "6a00")
+	})
+
+	t.Run("unknown outcome is looked up in Horizon", func(t *testing.T) {
+		payloads = nil
+		stored("6b00", "")
+
+		notFound := &horizon.StatusError{StatusCode: http.StatusNotFound}
+		mockHorizon.On("LoadTransaction", "6b00").Return(horizon.TransactionResponse{}, notFound).Once()
+		require.NoError(t, worker.process())
+		assert.Empty(t, payloads)
+		callback := load("6b00")
+		assert.Equal(t, entities.PaymentCallbackStatusPending, callback.Status)
+		assert.Equal(t, "", callback.TransactionStatus)
+		assert.Equal(t, 0, callback.Attempts)
+		assert.Equal(t, now.Add(DefaultInterval), callback.NextAttemptAt.Time())
+
+		// The callback is not due until the next lookup
+		require.NoError(t, worker.process())
+		mockHorizon.AssertNumberOfCalls(t, "LoadTransaction", 1)
+
+		now = now.Add(DefaultInterval)
+		failed := false
+		resultXdr := "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB/////gAAAAA="
+		mockHorizon.On("LoadTransaction", "6b00").Return(horizon.TransactionResponse{Hash: "6b00", Ledger: 1988728, ResultXdr: resultXdr, Successful: &failed}, nil).Once()
+		require.NoError(t, worker.process())
+		require.Len(t, payloads, 1)
+		assert.Equal(t, "failed", payloads[0].Status)
+		assert.Nil(t, payloads[0].Ledger)
+		assert.Equal(t, &horizon.ResultCodes{Transaction: "tx_failed", Operations: []string{"op_underfunded"}}, payloads[0].ResultCodes)
+		assert.Equal(t, entities.PaymentCallbackStatusDelivered, load("6b00").Status)
+	})
+
+	t.Run("transaction is not found after the pending timeout", func(t *testing.T) {
+		payloads = nil
+		stored("6c00", "")
+
+		now = now.Add(DefaultPendingTimeout)
+		mockHorizon.On("LoadTransaction", "6c00").Return(horizon.TransactionResponse{}, &horizon.StatusError{StatusCode: http.StatusNotFound}).Once()
+		require.NoError(t, worker.process())
+		require.Len(t, payloads, 1)
+		assert.Equal(t, "not_found", payloads[0].Status)
+		assert.Equal(t, entities.PaymentCallbackNotFound, load("6c00").TransactionStatus)
+	})
+
+	t.Run("failed deliveries are retried until attempts are exhausted", func(t *testing.T) {
+		payloads = nil
+		status = http.StatusInternalServerError
+		stored("6d00", entities.PaymentCallbackSuccess)
+
+		require.NoError(t, worker.process())
+		callback := load("6d00")
+		assert.Equal(t, entities.PaymentCallbackStatusPending, callback.Status)
+		assert.Equal(t, 1, callback.Attempts)
+		assert.Contains(t, callback.LastError, "Error response from payment callback: 500")
+		assert.Equal(t, now.Add(time.Minute), callback.NextAttemptAt.Time())
+
+		now = now.Add(time.Minute)
+		require.NoError(t, worker.process())
+		assert.Len(t, payloads, 2)
+		callback = load("6d00")
+		assert.Equal(t, entities.PaymentCallbackStatusFailed, callback.Status)
+		assert.Equal(t, 2, callback.Attempts)
+		assert.Nil(t, callback.DeliveredAt)
+
+		stats := worker.Retry.Stats()
+		assert.Equal(t, int64(4), stats.Calls)
+		assert.Equal(t, int64(1), stats.Exhausted)
+
+		callbacks, err := repository.GetPaymentCallbacks(now.Add(time.Hour), batchSize)
+		require.NoError(t, err)
+		assert.Empty(t, callbacks)
+	})
+
+	// Disabled workers are zero values
+	(&Worker{}).Wake()
+}
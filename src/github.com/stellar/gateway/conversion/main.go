@@ -0,0 +1,377 @@
+// Package conversion converts received payments of accepted assets into a single target asset
+// with path payments from the receiving account to itself
+package conversion
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/market"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/amount"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// ErrInterrupted is returned for payments with a pending conversion, the path payment could have
+// been applied to a ledger before the bridge was stopped so it's not submitted again
+var ErrInterrupted = errors.New("previous conversion was interrupted, check sent transactions of the receiving account")
+
+// Asset is an asset converted into the target asset
+type Asset struct {
+	protocols.Asset
+	// MinAmount is the smallest received amount that is converted
+	MinAmount xdr.Int64
+}
+
+// Settings contains settings of a Converter
+type Settings struct {
+	// Seed is a seed of the receiving account, it signs conversions
+	Seed string
+	// Target is the asset received payments are converted into
+	Target protocols.Asset
+	// Assets are accepted assets converted into Target
+	Assets []Asset
+	// MaxSlippage is the maximum estimated slippage of a conversion, ex. 0.01 for 1%
+	MaxSlippage *big.Rat
+}
+
+// Validate returns an error when settings are incomplete or inconsistent
+func (s Settings) Validate() error {
+	kp, err := keypair.Parse(s.Seed)
+	if _, ok := kp.(*keypair.Full); err != nil || !ok {
+		return errors.New("seed is invalid")
+	}
+
+	if !s.Target.Validate() {
+		return errors.New("target asset is invalid")
+	}
+
+	if len(s.Assets) == 0 {
+		return errors.New("at least one asset is required")
+	}
+
+	for _, asset := range s.Assets {
+		if !asset.Asset.Validate() {
+			return fmt.Errorf("asset %s is invalid", assetName(asset.Asset))
+		}
+		if asset.Asset == s.Target {
+			return fmt.Errorf("asset %s is the target asset", assetName(asset.Asset))
+		}
+		if asset.MinAmount < 0 {
+			return fmt.Errorf("min_amount of %s cannot be negative", assetName(asset.Asset))
+		}
+	}
+
+	if s.MaxSlippage == nil || s.MaxSlippage.Sign() < 0 {
+		return errors.New("max_slippage is invalid")
+	}
+	return nil
+}
+
+// Converter sells received payments of Settings.Assets for the target asset. Each conversion is
+// stored as entities.Conversion of the received payment, so a payment is converted once even
+// when it's reprocessed, and its transaction is stored as entities.SentTransaction by the
+// submitter.
+type Converter struct {
+	settings      Settings
+	accountID     string
+	horizon       horizon.HorizonInterface
+	submitter     submitter.TransactionSubmitterInterface
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	now           func() time.Time
+	log           *logrus.Entry
+}
+
+// NewConverter creates a Converter, settings must be valid
+func NewConverter(
+	settings Settings,
+	horizon horizon.HorizonInterface,
+	submitter submitter.TransactionSubmitterInterface,
+	repository db.RepositoryInterface,
+	entityManager db.EntityManagerInterface,
+	now func() time.Time,
+) *Converter {
+	return &Converter{
+		settings:      settings,
+		accountID:     keypair.MustParse(settings.Seed).Address(),
+		horizon:       horizon,
+		submitter:     submitter,
+		repository:    repository,
+		entityManager: entityManager,
+		now:           now,
+		log: logrus.WithFields(logrus.Fields{
+			"service":             "Converter",
+			logging.CategoryField: logging.CategoryListener,
+		}),
+	}
+}
+
+// Accepts returns true when payment is in one of the converted assets. It returns false for a
+// nil Converter.
+func (c *Converter) Accepts(payment horizon.PaymentResponse) bool {
+	_, ok := c.asset(payment)
+	return ok
+}
+
+// Convertible returns true when payment is in one of the converted assets and its amount is not
+// below the asset's minimum. It returns false for a nil Converter.
+func (c *Converter) Convertible(payment horizon.PaymentResponse) bool {
+	asset, ok := c.asset(payment)
+	if !ok {
+		return false
+	}
+	received, err := amount.Parse(payment.Amount)
+	return err == nil && received >= asset.MinAmount
+}
+
+// IsConversion returns true for payments sent by the receiving account to itself, they are path
+// payments of conversions
+func (c *Converter) IsConversion(payment horizon.PaymentResponse) bool {
+	return c != nil && payment.From == c.accountID && payment.To == c.accountID
+}
+
+func (c *Converter) asset(payment horizon.PaymentResponse) (Asset, bool) {
+	if c == nil {
+		return Asset{}, false
+	}
+	received := paymentAsset(payment)
+	for _, asset := range c.settings.Assets {
+		if asset.Asset == received {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Convert converts a received payment into the target asset. A successful conversion of the
+// payment is returned without sending it again. The whole received amount is sold with a path
+// payment delivering the amount estimated from the order book, it fails when the market moves
+// against the estimate.
+func (c *Converter) Convert(payment horizon.PaymentResponse) (*entities.Conversion, error) {
+	log := c.log.WithField(logging.RequestIDField, payment.ID)
+
+	conversion, err := c.repository.GetConversionByOperationID(payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	if conversion != nil {
+		switch conversion.Status {
+		case entities.ConversionStatusSuccess:
+			log.WithFields(logrus.Fields{"transaction_hash": conversion.TransactionHash}).Info("Payment already converted")
+			return conversion, nil
+		case entities.ConversionStatusPending:
+			return nil, ErrInterrupted
+		}
+	} else {
+		conversion = &entities.Conversion{OperationID: payment.ID}
+	}
+
+	send := paymentAsset(payment)
+	target := c.settings.Target
+	conversion.Status = entities.ConversionStatusPending
+	conversion.Error = ""
+	conversion.SendAssetCode, conversion.SendAssetIssuer = storedAsset(send)
+	conversion.SendMax = payment.Amount
+	conversion.SendAmount = ""
+	conversion.DestinationAssetCode, conversion.DestinationAssetIssuer = storedAsset(target)
+	conversion.DestinationAmount = ""
+	conversion.EstimatedPrice = ""
+	conversion.TransactionHash = ""
+	conversion.CreatedAt = utc.New(c.now())
+	conversion.ConvertedAt = nil
+
+	err = c.entityManager.Persist(conversion)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.convert(conversion, send, log)
+	if err != nil {
+		log.WithFields(logrus.Fields{"err": err}).Error("Conversion failed")
+		conversion.Status = entities.ConversionStatusFailed
+		conversion.Error = err.Error()
+	} else {
+		log.WithFields(logrus.Fields{
+			"transaction_hash":   conversion.TransactionHash,
+			"destination_amount": conversion.DestinationAmount,
+		}).Info("Payment converted")
+		conversion.Status = entities.ConversionStatusSuccess
+		convertedAt := utc.New(c.now())
+		conversion.ConvertedAt = &convertedAt
+	}
+
+	if persistErr := c.entityManager.Persist(conversion); persistErr != nil {
+		return nil, persistErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return conversion, nil
+}
+
+func (c *Converter) convert(conversion *entities.Conversion, send protocols.Asset, log *logrus.Entry) error {
+	sendAmount, err := amount.Parse(conversion.SendMax)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.horizon.LoadOrderBook(c.settings.Target.ToBaseAsset(), send.ToBaseAsset())
+	if err != nil {
+		return fmt.Errorf("cannot load order book: %s", err)
+	}
+	book, err := market.NewOrderBook(response)
+	if err != nil {
+		return fmt.Errorf("cannot parse order book: %s", err)
+	}
+
+	estimate, err := market.EstimateSale([]market.OrderBook{book}, big.NewRat(int64(sendAmount), amount.One))
+	if err != nil {
+		return fmt.Errorf("cannot estimate conversion: %s", err)
+	}
+	conversion.EstimatedPrice = new(big.Rat).Inv(estimate.Price).FloatString(7)
+
+	if estimate.Slippage().Cmp(c.settings.MaxSlippage) > 0 {
+		return fmt.Errorf("estimated slippage %s exceeds max_slippage %s", estimate.Slippage().FloatString(4), c.settings.MaxSlippage.FloatString(4))
+	}
+
+	// Rounded down so the estimate can be delivered for the received amount
+	destinationAmount := new(big.Int).Quo(
+		new(big.Int).Mul(estimate.DestinationAmount.Num(), big.NewInt(amount.One)),
+		estimate.DestinationAmount.Denom(),
+	)
+	if destinationAmount.Sign() <= 0 {
+		return errors.New("received amount is too small to convert")
+	}
+	conversion.DestinationAmount = amount.String(xdr.Int64(destinationAmount.Int64()))
+
+	log.WithFields(logrus.Fields{
+		"send_max":           conversion.SendMax,
+		"destination_amount": conversion.DestinationAmount,
+		"estimated_price":    conversion.EstimatedPrice,
+	}).Info("Submitting conversion")
+	operation := c.operation(conversion, send)
+	if operation.Err != nil {
+		return fmt.Errorf("cannot build path payment: %s", operation.Err)
+	}
+	submitResponse, err := c.submitter.SubmitTransaction(c.settings.Seed, operation, nil)
+	if err != nil {
+		return fmt.Errorf("cannot submit transaction: %s", err)
+	}
+	conversion.TransactionHash = submitResponse.Hash
+
+	if submitResponse.Ledger == nil {
+		return fmt.Errorf("transaction failed: %s", failureCode(submitResponse))
+	}
+
+	conversion.SendAmount = sendAmountOf(submitResponse)
+	return nil
+}
+
+// operation returns a path payment of a conversion from the receiving account to itself
+func (c *Converter) operation(conversion *entities.Conversion, send protocols.Asset) b.PaymentBuilder {
+	destinationAmount := interface{}(b.NativeAmount{conversion.DestinationAmount})
+	if c.settings.Target.Code != "" {
+		destinationAmount = b.CreditAmount{c.settings.Target.Code, c.settings.Target.Issuer, conversion.DestinationAmount}
+	}
+
+	return b.Payment(
+		b.Destination{c.accountID},
+		destinationAmount,
+		b.PayWithPath{Asset: send.ToBaseAsset(), MaxAmount: conversion.SendMax},
+	)
+}
+
+// failureCode returns a Horizon result code of a failed transaction, ex. `path_payment_over_sendmax`
+func failureCode(response horizon.SubmitTransactionResponse) string {
+	if resultErr := response.TransactionResultError(); resultErr != nil {
+		return resultErr.Name
+	}
+	if response.Extras == nil {
+		return "unknown"
+	}
+
+	var result xdr.TransactionResult
+	err := xdr.SafeUnmarshalBase64(response.Extras.ResultXdr, &result)
+	if err != nil || result.Result.Results == nil || len(*result.Result.Results) == 0 {
+		return "tx_failed"
+	}
+
+	operationResult := (*result.Result.Results)[0]
+	if operationResult.Tr == nil || operationResult.Tr.PathPaymentResult == nil {
+		return "op_" + snakeCase(strings.TrimPrefix(operationResult.Code.String(), "OperationResultCodeOp"))
+	}
+	return "path_payment_" + snakeCase(strings.TrimPrefix(operationResult.Tr.PathPaymentResult.Code.String(), "PathPaymentResultCodePathPayment"))
+}
+
+// snakeCase converts a CamelCase name of an XDR enum value to lower snake case
+func snakeCase(name string) string {
+	var result []rune
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				result = append(result, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		result = append(result, r)
+	}
+	return string(result)
+}
+
+// sendAmountOf returns the amount spent by the path payment of a successful transaction, empty
+// when the result is not available
+func sendAmountOf(response horizon.SubmitTransactionResponse) string {
+	if response.ResultXdr == nil {
+		return ""
+	}
+
+	var result xdr.TransactionResult
+	err := xdr.SafeUnmarshalBase64(*response.ResultXdr, &result)
+	if err != nil || result.Result.Results == nil || len(*result.Result.Results) == 0 {
+		return ""
+	}
+
+	operationResult := (*result.Result.Results)[0]
+	if operationResult.Tr == nil || operationResult.Tr.PathPaymentResult == nil {
+		return ""
+	}
+	return amount.String(operationResult.Tr.PathPaymentResult.SendAmount())
+}
+
+// paymentAsset returns the asset of a payment, XLM has empty code and issuer
+func paymentAsset(payment horizon.PaymentResponse) protocols.Asset {
+	if payment.AssetType == "native" {
+		return protocols.Asset{}
+	}
+	return protocols.Asset{Code: payment.AssetCode, Issuer: payment.AssetIssuer}
+}
+
+// storedAsset returns code and issuer of an asset stored in the DB
+func storedAsset(asset protocols.Asset) (string, string) {
+	if asset.Code == "" {
+		return "XLM", ""
+	}
+	return asset.Code, asset.Issuer
+}
+
+func assetName(asset protocols.Asset) string {
+	code, issuer := storedAsset(asset)
+	if issuer == "" {
+		return code
+	}
+	return code + ":" + issuer
+}
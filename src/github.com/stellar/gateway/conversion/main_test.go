@@ -0,0 +1,285 @@
+package conversion
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/utc"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const usdIssuer = "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"
+
+// transactionResult returns result_xdr of a transaction with a single path payment result
+func transactionResult(t *testing.T, code xdr.PathPaymentResultCode, sent xdr.Int64) string {
+	var value interface{}
+	transactionCode := xdr.TransactionResultCodeTxFailed
+	if code == xdr.PathPaymentResultCodePathPaymentSuccess {
+		var seller xdr.AccountId
+		require.NoError(t, seller.SetAddress(usdIssuer))
+		value = xdr.PathPaymentResultSuccess{
+			Offers: []xdr.ClaimOfferAtom{{SellerId: seller, AssetBought: xdr.Asset{Type: xdr.AssetTypeAssetTypeNative}, AmountBought: sent}},
+			Last:   xdr.SimplePaymentResult{Destination: seller},
+		}
+		transactionCode = xdr.TransactionResultCodeTxSuccess
+	}
+
+	pathPaymentResult, err := xdr.NewPathPaymentResult(code, value)
+	require.NoError(t, err)
+	tr, err := xdr.NewOperationResultTr(xdr.OperationTypePathPayment, pathPaymentResult)
+	require.NoError(t, err)
+	operationResult, err := xdr.NewOperationResult(xdr.OperationResultCodeOpInner, tr)
+	require.NoError(t, err)
+
+	result := xdr.TransactionResult{FeeCharged: 100}
+	result.Result.Code = transactionCode
+	result.Result.Results = &[]xdr.OperationResult{operationResult}
+	encoded, err := xdr.MarshalBase64(result)
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestSettingsValidate(t *testing.T) {
+	random, err := keypair.Random()
+	require.NoError(t, err)
+	seed := random.Seed()
+	usd := protocols.Asset{Code: "USD", Issuer: usdIssuer}
+	valid := Settings{Seed: seed, Target: usd, Assets: []Asset{{}}, MaxSlippage: big.NewRat(1, 100)}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.Seed = keypair.MustParse(seed).Address()
+	assert.EqualError(t, invalid.Validate(), "seed is invalid")
+
+	invalid = valid
+	invalid.Assets = nil
+	assert.EqualError(t, invalid.Validate(), "at least one asset is required")
+
+	invalid = valid
+	invalid.Assets = []Asset{{Asset: usd}}
+	assert.EqualError(t, invalid.Validate(), "asset USD:"+usdIssuer+" is the target asset")
+
+	invalid = valid
+	invalid.Assets = []Asset{{Asset: protocols.Asset{Code: "EUR"}}}
+	assert.EqualError(t, invalid.Validate(), "asset EUR is invalid")
+
+	invalid = valid
+	invalid.MaxSlippage = big.NewRat(-1, 100)
+	assert.EqualError(t, invalid.Validate(), "max_slippage is invalid")
+}
+
+func TestConverter(t *testing.T) {
+	receiving, err := keypair.Random()
+	require.NoError(t, err)
+	usd := protocols.Asset{Code: "USD", Issuer: usdIssuer}
+	settings := Settings{
+		Seed:        receiving.Seed(),
+		Target:      usd,
+		Assets:      []Asset{{MinAmount: 10 * xdr.Int64(1e7)}},
+		MaxSlippage: big.NewRat(5, 100),
+	}
+
+	// 50 XLM buy 10 USD at the best price, more is bought at 5.5
+	book := horizon.OrderBookResponse{Asks: []horizon.OrderBookLevel{
+		{Price: "5.0000000", Amount: "10.0000000"},
+		{Price: "5.5000000", Amount: "100.0000000"},
+	}}
+
+	payment := func(id, amount string) horizon.PaymentResponse {
+		return horizon.PaymentResponse{
+			ID:        id,
+			Type:      "payment",
+			From:      "GBNDNTS5ZMUEBZPVFGQY3KCETCONHUEGQG2KWCYWYSDWFFGMVF2T7WNS",
+			To:        receiving.Address(),
+			AssetType: "native",
+			Amount:    amount,
+		}
+	}
+
+	setup := func() (*Converter, *mocks.MockHorizon, *mocks.MockTransactionSubmitter, *mocks.MockRepository, *mocks.MockEntityManager) {
+		mockHorizon := new(mocks.MockHorizon)
+		mockSubmitter := new(mocks.MockTransactionSubmitter)
+		mockRepository := new(mocks.MockRepository)
+		mockEntityManager := new(mocks.MockEntityManager)
+		converter := NewConverter(settings, mockHorizon, mockSubmitter, mockRepository, mockEntityManager, mocks.Now)
+		return converter, mockHorizon, mockSubmitter, mockRepository, mockEntityManager
+	}
+
+	t.Run("accepted assets", func(t *testing.T) {
+		converter, _, _, _, _ := setup()
+		assert.True(t, converter.Accepts(payment("1", "1.0000000")))
+		assert.False(t, converter.Convertible(payment("1", "9.9999999")))
+		assert.True(t, converter.Convertible(payment("1", "10.0000000")))
+
+		received := payment("1", "10.0000000")
+		received.AssetType, received.AssetCode, received.AssetIssuer = "credit_alphanum4", "USD", usdIssuer
+		assert.False(t, converter.Accepts(received))
+		assert.False(t, converter.Convertible(received))
+
+		conversion := payment("2", "10.0000000")
+		assert.False(t, converter.IsConversion(conversion))
+		conversion.From = receiving.Address()
+		assert.True(t, converter.IsConversion(conversion))
+
+		var disabled *Converter
+		assert.False(t, disabled.Accepts(payment("1", "10.0000000")))
+		assert.False(t, disabled.Convertible(payment("1", "10.0000000")))
+		assert.False(t, disabled.IsConversion(conversion))
+	})
+
+	t.Run("converts payment", func(t *testing.T) {
+		converter, mockHorizon, mockSubmitter, mockRepository, mockEntityManager := setup()
+		mockRepository.On("GetConversionByOperationID", "1").Return(nil, nil).Once()
+		mockHorizon.On("LoadOrderBook", usd.ToBaseAsset(), b.NativeAsset()).Return(book, nil).Once()
+
+		var statuses []string
+		mockEntityManager.On("Persist", mock.AnythingOfType("*entities.Conversion")).Run(func(args mock.Arguments) {
+			statuses = append(statuses, args.Get(0).(*entities.Conversion).Status)
+		}).Return(nil).Twice()
+
+		resultXdr := transactionResult(t, xdr.PathPaymentResultCodePathPaymentSuccess, 50*1e7)
+		ledger := uint64(100)
+		mockSubmitter.On("SubmitTransaction", receiving.Seed(), mock.Anything, nil).Run(func(args mock.Arguments) {
+			operation := args.Get(1).(b.PaymentBuilder)
+			require.True(t, operation.PathPayment)
+			assert.Equal(t, xdr.Int64(50*1e7), operation.PP.SendMax)
+			assert.Equal(t, xdr.Int64(10*1e7), operation.PP.DestAmount)
+			assert.Equal(t, receiving.Address(), operation.PP.Destination.Address())
+			assert.Equal(t, xdr.AssetTypeAssetTypeNative, operation.PP.SendAsset.Type)
+		}).Return(horizon.SubmitTransactionResponse{Hash: "abcd", Ledger: &ledger, ResultXdr: &resultXdr}, nil).Once()
+
+		conversion, err := converter.Convert(payment("1", "50.0000000"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{entities.ConversionStatusPending, entities.ConversionStatusSuccess}, statuses)
+		assert.Equal(t, "1", conversion.OperationID)
+		assert.Equal(t, "XLM", conversion.SendAssetCode)
+		assert.Equal(t, "", conversion.SendAssetIssuer)
+		assert.Equal(t, "50.0000000", conversion.SendMax)
+		assert.Equal(t, "50.0000000", conversion.SendAmount)
+		assert.Equal(t, "USD", conversion.DestinationAssetCode)
+		assert.Equal(t, usdIssuer, conversion.DestinationAssetIssuer)
+		assert.Equal(t, "10.0000000", conversion.DestinationAmount)
+		assert.Equal(t, "0.2000000", conversion.EstimatedPrice)
+		assert.Equal(t, "abcd", conversion.TransactionHash)
+		assert.Equal(t, utc.New(mocks.PredefinedTime), *conversion.ConvertedAt)
+		mockSubmitter.AssertExpectations(t)
+	})
+
+	t.Run("amount is rounded down", func(t *testing.T) {
+		converter, mockHorizon, mockSubmitter, mockRepository, mockEntityManager := setup()
+		mockRepository.On("GetConversionByOperationID", "1").Return(nil, nil).Once()
+		mockHorizon.On("LoadOrderBook", usd.ToBaseAsset(), b.NativeAsset()).Return(book, nil).Once()
+		mockEntityManager.On("Persist", mock.AnythingOfType("*entities.Conversion")).Return(nil).Twice()
+
+		ledger := uint64(100)
+		mockSubmitter.On("SubmitTransaction", receiving.Seed(), mock.Anything, nil).Run(func(args mock.Arguments) {
+			// 10 + 50 / 5.5 = 19.090909...
+			assert.Equal(t, xdr.Int64(190909090), args.Get(1).(b.PaymentBuilder).PP.DestAmount)
+		}).Return(horizon.SubmitTransactionResponse{Hash: "abcd", Ledger: &ledger}, nil).Once()
+
+		conversion, err := converter.Convert(payment("1", "100.0000000"))
+		require.NoError(t, err)
+		assert.Equal(t, "19.0909090", conversion.DestinationAmount)
+		// The send amount is unknown without result_xdr
+		assert.Equal(t, "", conversion.SendAmount)
+	})
+
+	t.Run("excessive slippage", func(t *testing.T) {
+		converter, mockHorizon, mockSubmitter, mockRepository, mockEntityManager := setup()
+		mockRepository.On("GetConversionByOperationID", "1").Return(nil, nil).Once()
+		mockHorizon.On("LoadOrderBook", usd.ToBaseAsset(), b.NativeAsset()).Return(book, nil).Once()
+
+		var stored *entities.Conversion
+		mockEntityManager.On("Persist", mock.AnythingOfType("*entities.Conversion")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*entities.Conversion)
+		}).Return(nil).Twice()
+
+		_, err := converter.Convert(payment("1", "200.0000000"))
+		assert.EqualError(t, err, "estimated slippage 0.0732 exceeds max_slippage 0.0500")
+		assert.Equal(t, entities.ConversionStatusFailed, stored.Status)
+		assert.Equal(t, err.Error(), stored.Error)
+		mockSubmitter.AssertNotCalled(t, "SubmitTransaction", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed transaction", func(t *testing.T) {
+		converter, mockHorizon, mockSubmitter, mockRepository, mockEntityManager := setup()
+		mockRepository.On("GetConversionByOperationID", "1").Return(nil, nil).Once()
+		mockHorizon.On("LoadOrderBook", usd.ToBaseAsset(), b.NativeAsset()).Return(book, nil).Once()
+
+		var stored *entities.Conversion
+		mockEntityManager.On("Persist", mock.AnythingOfType("*entities.Conversion")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*entities.Conversion)
+		}).Return(nil).Twice()
+
+		mockSubmitter.On("SubmitTransaction", receiving.Seed(), mock.Anything, nil).Return(horizon.SubmitTransactionResponse{
+			Hash:   "abcd",
+			Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: transactionResult(t, xdr.PathPaymentResultCodePathPaymentOverSendmax, 0)},
+		}, nil).Once()
+
+		_, err := converter.Convert(payment("1", "50.0000000"))
+		assert.EqualError(t, err, "transaction failed: path_payment_over_sendmax")
+		assert.Equal(t, entities.ConversionStatusFailed, stored.Status)
+		assert.Equal(t, "abcd", stored.TransactionHash)
+	})
+
+	t.Run("submission error", func(t *testing.T) {
+		converter, mockHorizon, mockSubmitter, mockRepository, mockEntityManager := setup()
+		mockRepository.On("GetConversionByOperationID", "1").Return(nil, nil).Once()
+		mockHorizon.On("LoadOrderBook", usd.ToBaseAsset(), b.NativeAsset()).Return(book, nil).Once()
+		mockEntityManager.On("Persist", mock.AnythingOfType("*entities.Conversion")).Return(nil).Twice()
+		mockSubmitter.On("SubmitTransaction", receiving.Seed(), mock.Anything, nil).Return(horizon.SubmitTransactionResponse{}, errors.New("timeout")).Once()
+
+		_, err := converter.Convert(payment("1", "50.0000000"))
+		assert.EqualError(t, err, "cannot submit transaction: timeout")
+	})
+
+	t.Run("converted payment", func(t *testing.T) {
+		converter, mockHorizon, mockSubmitter, mockRepository, _ := setup()
+		existing := &entities.Conversion{OperationID: "1", Status: entities.ConversionStatusSuccess, TransactionHash: "abcd"}
+		mockRepository.On("GetConversionByOperationID", "1").Return(existing, nil).Once()
+
+		conversion, err := converter.Convert(payment("1", "50.0000000"))
+		require.NoError(t, err)
+		assert.Equal(t, existing, conversion)
+		mockHorizon.AssertNotCalled(t, "LoadOrderBook", mock.Anything, mock.Anything)
+		mockSubmitter.AssertNotCalled(t, "SubmitTransaction", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("interrupted conversion", func(t *testing.T) {
+		converter, _, mockSubmitter, mockRepository, _ := setup()
+		existing := &entities.Conversion{OperationID: "1", Status: entities.ConversionStatusPending}
+		mockRepository.On("GetConversionByOperationID", "1").Return(existing, nil).Once()
+
+		_, err := converter.Convert(payment("1", "50.0000000"))
+		assert.Equal(t, ErrInterrupted, err)
+		mockSubmitter.AssertNotCalled(t, "SubmitTransaction", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("failed conversion is attempted again", func(t *testing.T) {
+		converter, mockHorizon, mockSubmitter, mockRepository, mockEntityManager := setup()
+		existing := &entities.Conversion{OperationID: "1", Status: entities.ConversionStatusFailed, Error: "transaction failed: path_payment_over_sendmax"}
+		mockRepository.On("GetConversionByOperationID", "1").Return(existing, nil).Once()
+		mockHorizon.On("LoadOrderBook", usd.ToBaseAsset(), b.NativeAsset()).Return(book, nil).Once()
+		mockEntityManager.On("Persist", existing).Return(nil).Twice()
+
+		ledger := uint64(100)
+		mockSubmitter.On("SubmitTransaction", receiving.Seed(), mock.Anything, nil).Return(horizon.SubmitTransactionResponse{Hash: "ef01", Ledger: &ledger}, nil).Once()
+
+		conversion, err := converter.Convert(payment("1", "50.0000000"))
+		require.NoError(t, err)
+		assert.Equal(t, existing, conversion)
+		assert.Equal(t, entities.ConversionStatusSuccess, conversion.Status)
+		assert.Equal(t, "", conversion.Error)
+		assert.Equal(t, "ef01", conversion.TransactionHash)
+	})
+}
@@ -0,0 +1,130 @@
+package counterparty
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrie(t *testing.T) {
+	trie := NewTrie()
+	assert.True(t, trie.Add("example.com"))
+	assert.False(t, trie.Add("example.com"))
+	assert.True(t, trie.Add("pay.bank.co.uk"))
+	assert.Equal(t, 2, trie.Len())
+
+	assert.True(t, trie.Match("example.com"))
+	assert.True(t, trie.Match("Pay.Example.com."))
+	assert.True(t, trie.Match("eu.pay.bank.co.uk"))
+	assert.False(t, trie.Match("bank.co.uk"))
+	assert.False(t, trie.Match("co.uk"))
+	assert.False(t, trie.Match("notexample.com"))
+	assert.False(t, trie.Match("com"))
+	assert.False(t, trie.Match(""))
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	domain, err := NormalizeDomain(" Example.COM. ")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", domain)
+
+	for _, invalid := range []string{"", "example..com", "-example.com", "exa_mple.com", "*.example.com", strings.Repeat("a", 64) + ".com"} {
+		_, err := NormalizeDomain(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParse(t *testing.T) {
+	domains, err := Parse(strings.NewReader("domain,name\n# comment\nexample.com,Example\n\"bank.co.uk\",\"Bank, Ltd\"\nexample.com\n"), FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, 2, domains.Len())
+	assert.True(t, domains.Match("bank.co.uk"))
+
+	_, err = Parse(strings.NewReader("domain\nexample.com\n\nexa mple.com\n"), FormatCSV)
+	assert.EqualError(t, err, ":4: invalid domain exa mple.com")
+
+	_, err = Parse(strings.NewReader("example.com\n\"bank.co.uk\n"), FormatCSV)
+	require.IsType(t, &LineError{}, err)
+	assert.Equal(t, 2, err.(*LineError).Line)
+
+	domains, err = Parse(strings.NewReader("{\"domain\": \"example.com\", \"name\": \"Example\"}\n\n{\"domain\": \"bank.co.uk\"}\n"), FormatJSONLines)
+	require.NoError(t, err)
+	assert.Equal(t, 2, domains.Len())
+
+	_, err = Parse(strings.NewReader("{\"domain\": \"example.com\"}\n{\"domain\": \"\"}\n"), FormatJSONLines)
+	assert.EqualError(t, err, ":2: domain is empty")
+
+	_, err = Parse(strings.NewReader("{\"domain\": \"example.com\"}\nexample.com\n"), FormatJSONLines)
+	require.IsType(t, &LineError{}, err)
+	assert.Equal(t, 2, err.(*LineError).Line)
+}
+
+func TestLists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "counterparty")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	allowFile := filepath.Join(dir, "allow.csv")
+	denyFile := filepath.Join(dir, "deny.jsonl")
+	var allow strings.Builder
+	allow.WriteString("domain\n")
+	for i := 0; i < 40000; i++ {
+		fmt.Fprintf(&allow, "fi%d.example.com\n", i)
+	}
+	allow.WriteString("bank.co.uk\n")
+	require.NoError(t, ioutil.WriteFile(allowFile, []byte(allow.String()), 0600))
+	require.NoError(t, ioutil.WriteFile(denyFile, []byte("{\"domain\": \"blocked.bank.co.uk\"}\n"), 0600))
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	lists, err := NewLists(Settings{AllowFile: allowFile, DenyFile: denyFile}, func() time.Time { return now })
+	require.NoError(t, err)
+
+	assert.True(t, lists.Allowed("fi39999.example.com"))
+	assert.True(t, lists.Allowed("eu.bank.co.uk"))
+	assert.False(t, lists.Allowed("blocked.bank.co.uk"))
+	assert.False(t, lists.Allowed("example.com"))
+
+	status := lists.Status()
+	require.NotNil(t, status.Allow)
+	assert.Equal(t, 40001, status.Allow.Entries)
+	assert.Len(t, status.Allow.Checksum, 64)
+	require.NotNil(t, status.Deny)
+	assert.Equal(t, 1, status.Deny.Entries)
+
+	t.Run("invalid file keeps running lists", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(denyFile, []byte("{\"domain\": \"blocked.bank.co.uk\"}\n{\"domain\": \"bad domain\"}\n"), 0600))
+		_, err := lists.Reload()
+		assert.EqualError(t, err, denyFile+":2: invalid domain bad domain")
+		assert.Equal(t, status, lists.Status())
+		assert.False(t, lists.Allowed("blocked.bank.co.uk"))
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		require.NoError(t, ioutil.WriteFile(denyFile, []byte("{\"domain\": \"fi1.example.com\"}\n"), 0600))
+		reloaded, err := lists.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, status.Allow.Checksum, reloaded.Allow.Checksum)
+		assert.NotEqual(t, status.Deny.Checksum, reloaded.Deny.Checksum)
+		assert.Equal(t, now, reloaded.Deny.LoadedAt.Time())
+		assert.True(t, lists.Allowed("blocked.bank.co.uk"))
+		assert.False(t, lists.Allowed("fi1.example.com"))
+	})
+
+	t.Run("no lists", func(t *testing.T) {
+		lists, err := NewLists(Settings{}, time.Now)
+		require.NoError(t, err)
+		assert.True(t, lists.Allowed("example.com"))
+		assert.Equal(t, Status{}, lists.Status())
+
+		_, err = NewLists(Settings{AllowFile: filepath.Join(dir, "allow.txt")}, time.Now)
+		assert.Error(t, err)
+	})
+}
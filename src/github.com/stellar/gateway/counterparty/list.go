@@ -0,0 +1,192 @@
+// Package counterparty loads allow and deny lists of counterparty domains from external files
+package counterparty
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stellar/gateway/utc"
+)
+
+// Formats of list files
+const (
+	// FormatCSV is a CSV file with a domain in the first column. A header row starting with
+	// `domain` and lines starting with `#` are skipped, other columns are ignored.
+	FormatCSV = "csv"
+	// FormatJSONLines is a file with a JSON object with `domain` field per line, empty lines are
+	// skipped
+	FormatJSONLines = "jsonl"
+)
+
+// maxJSONLine is a maximum length of a line of a JSON lines file
+const maxJSONLine = 64 * 1024
+
+// List is a loaded list file
+type List struct {
+	domains *Trie
+	status  ListStatus
+}
+
+// ListStatus identifies the version of a loaded list
+type ListStatus struct {
+	Path    string `json:"path"`
+	Entries int    `json:"entries"`
+	// Checksum is a hex encoded SHA-256 of the file
+	Checksum string   `json:"checksum"`
+	LoadedAt utc.Time `json:"loaded_at"`
+}
+
+// LineError is an error of an invalid entry of a list file
+type LineError struct {
+	Path string
+	Line int
+	Err  string
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Err)
+}
+
+// FormatOf returns the format of a list file by its extension: `.csv` or `.jsonl` (`.ndjson`)
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONLines, nil
+	default:
+		return "", fmt.Errorf("%s: unknown list format, use .csv or .jsonl file", path)
+	}
+}
+
+// Load reads and validates a list file. The file is parsed while it's read so only the domains
+// are kept in memory.
+func Load(path string, now time.Time) (*List, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	domains, err := Parse(io.TeeReader(file, hash), format)
+	if lineErr, ok := err.(*LineError); ok {
+		lineErr.Path = path
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &List{
+		domains: domains,
+		status: ListStatus{
+			Path:     path,
+			Entries:  domains.Len(),
+			Checksum: hex.EncodeToString(hash.Sum(nil)),
+			LoadedAt: utc.New(now),
+		},
+	}, nil
+}
+
+// Parse reads domains of a list in a given format. Invalid entries are returned as *LineError,
+// duplicates are ignored.
+func Parse(reader io.Reader, format string) (*Trie, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(reader)
+	case FormatJSONLines:
+		return parseJSONLines(reader)
+	default:
+		return nil, fmt.Errorf("unknown list format %s", format)
+	}
+}
+
+func parseCSV(reader io.Reader) (*Trie, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comment = '#'
+	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = true
+
+	domains := NewTrie()
+	for first := true; ; first = false {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return domains, nil
+		}
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			return nil, &LineError{Line: parseErr.Line, Err: parseErr.Err.Error()}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), "domain") {
+			continue
+		}
+		line, _ := csvReader.FieldPos(0)
+		domain, err := NormalizeDomain(record[0])
+		if err != nil {
+			return nil, &LineError{Line: line, Err: err.Error()}
+		}
+		domains.Add(domain)
+	}
+}
+
+func parseJSONLines(reader io.Reader) (*Trie, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 4096), maxJSONLine)
+
+	domains := NewTrie()
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var entry struct {
+			Domain string `json:"domain"`
+		}
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, &LineError{Line: line, Err: "invalid JSON: " + err.Error()}
+		}
+		domain, err := NormalizeDomain(entry.Domain)
+		if err != nil {
+			return nil, &LineError{Line: line, Err: err.Error()}
+		}
+		domains.Add(domain)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return nil, &LineError{Line: line + 1, Err: "line is too long"}
+		}
+		return nil, err
+	}
+	return domains, nil
+}
+
+// Match returns true when domain or one of its parent domains is in the list
+func (l *List) Match(domain string) bool {
+	return l.domains.Match(domain)
+}
+
+// Status returns the version of the list
+func (l *List) Status() ListStatus {
+	return l.status
+}
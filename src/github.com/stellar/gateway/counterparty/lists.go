@@ -0,0 +1,92 @@
+package counterparty
+
+import (
+	"sync"
+	"time"
+)
+
+// Settings are paths of list files, a list is not used when its path is empty
+type Settings struct {
+	AllowFile string
+	DenyFile  string
+}
+
+// Status is returned by /status and /admin/counterparties/reload endpoints, a list is nil when
+// it's not configured
+type Status struct {
+	Allow *ListStatus `json:"allow,omitempty"`
+	Deny  *ListStatus `json:"deny,omitempty"`
+}
+
+// Lists checks counterparty domains against an allow list and a deny list. Lists are replaced
+// by Reload while domains are checked.
+type Lists struct {
+	settings Settings
+	now      func() time.Time
+
+	mutex sync.RWMutex
+	allow *List
+	deny  *List
+}
+
+// NewLists creates new Lists and loads their files
+func NewLists(settings Settings, now func() time.Time) (*Lists, error) {
+	l := &Lists{settings: settings, now: now}
+	_, err := l.Reload()
+	return l, err
+}
+
+// Reload reads list files again. Running lists are kept when any of the files is invalid.
+func (l *Lists) Reload() (Status, error) {
+	allow, err := l.load(l.settings.AllowFile)
+	if err != nil {
+		return l.Status(), err
+	}
+	deny, err := l.load(l.settings.DenyFile)
+	if err != nil {
+		return l.Status(), err
+	}
+
+	l.mutex.Lock()
+	l.allow = allow
+	l.deny = deny
+	l.mutex.Unlock()
+	return l.Status(), nil
+}
+
+func (l *Lists) load(path string) (*List, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return Load(path, l.now())
+}
+
+// Allowed returns false when domain is in the deny list or when there is an allow list and
+// domain is not in it
+func (l *Lists) Allowed(domain string) bool {
+	l.mutex.RLock()
+	allow, deny := l.allow, l.deny
+	l.mutex.RUnlock()
+
+	if deny != nil && deny.Match(domain) {
+		return false
+	}
+	return allow == nil || allow.Match(domain)
+}
+
+// Status returns versions of the running lists
+func (l *Lists) Status() Status {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	var status Status
+	if l.allow != nil {
+		allow := l.allow.Status()
+		status.Allow = &allow
+	}
+	if l.deny != nil {
+		deny := l.deny.Status()
+		status.Deny = &deny
+	}
+	return status
+}
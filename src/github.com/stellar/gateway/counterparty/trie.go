@@ -0,0 +1,103 @@
+package counterparty
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	maxDomain = 253
+	maxLabel  = 63
+)
+
+// Trie is a set of domains matched by suffix: a domain matches an entry equal to it or to one of
+// its parent domains, so `example.com` matches `example.com` and `pay.example.com`. Labels are
+// stored from the top-level domain down so entries share their parent domains.
+type Trie struct {
+	root node
+	size int
+}
+
+type node struct {
+	children map[string]*node
+	// terminal is set when the path to the node is an entry
+	terminal bool
+}
+
+// NewTrie creates an empty Trie
+func NewTrie() *Trie {
+	return &Trie{}
+}
+
+// Add adds a normalized domain (see NormalizeDomain), false is returned when it's already in the
+// Trie
+func (t *Trie) Add(domain string) bool {
+	current := &t.root
+	labels := strings.Split(domain, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		if current.children == nil {
+			current.children = map[string]*node{}
+		}
+		child, ok := current.children[labels[i]]
+		if !ok {
+			child = &node{}
+			current.children[labels[i]] = child
+		}
+		current = child
+	}
+
+	if current.terminal {
+		return false
+	}
+	current.terminal = true
+	t.size++
+	return true
+}
+
+// Match returns true when domain or one of its parent domains is in the Trie
+func (t *Trie) Match(domain string) bool {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	current := &t.root
+	for end := len(domain); end >= 0; {
+		start := strings.LastIndexByte(domain[:end], '.') + 1
+		child, ok := current.children[domain[start:end]]
+		if !ok {
+			return false
+		}
+		if child.terminal {
+			return true
+		}
+		current = child
+		end = start - 1
+	}
+	return false
+}
+
+// Len returns a number of entries
+func (t *Trie) Len() int {
+	return t.size
+}
+
+// NormalizeDomain returns a lower case domain without a trailing dot or an error when it's not a
+// valid domain name
+func NormalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return "", errors.New("domain is empty")
+	}
+	if len(domain) > maxDomain {
+		return "", errors.New("domain is too long")
+	}
+
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > maxLabel || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", errors.New("invalid domain " + domain)
+		}
+		for _, char := range label {
+			if !(char >= 'a' && char <= 'z' || char >= '0' && char <= '9' || char == '-') {
+				return "", errors.New("invalid domain " + domain)
+			}
+		}
+	}
+	return domain, nil
+}
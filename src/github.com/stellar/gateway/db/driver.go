@@ -1,6 +1,8 @@
 package db
 
 import (
+	"time"
+
 	"github.com/jmoiron/sqlx"
 	"github.com/stellar/gateway/db/entities"
 )
@@ -17,4 +19,21 @@ type Driver interface {
 
 	GetOne(object entities.Entity, where string, params ...interface{}) (entities.Entity, error)
 	GetMany(slice interface{}, where, order, offset, limit *string, params ...interface{}) (err error)
+
+	// AcquireLease acquires (or renews) a LeaderLease for ttl, it returns false when the lease is
+	// held by another holder and has not expired. Expiry is checked using the DB clock.
+	AcquireLease(name, holder string, ttl time.Duration) (bool, error)
+	// TransferLease gives a LeaderLease held by `from` to `to` for ttl, it returns false when the
+	// lease is not held by `from`
+	TransferLease(name, from, to string, ttl time.Duration) (bool, error)
+	// TakeOverLease gives a LeaderLease to holder for ttl regardless of its current holder
+	TakeOverLease(name, holder string, ttl time.Duration) error
+
+	// RequestHandoff replaces the LeaderHandoff of a lease with a new request of holder
+	RequestHandoff(name, holder, version string) error
+	// GetHandoff returns the LeaderHandoff of a lease, nil when there is none
+	GetHandoff(name string) (*entities.LeaderHandoff, error)
+	// SetHandoffStatus updates the status of the LeaderHandoff of holder, requests of other
+	// holders are not changed
+	SetHandoffStatus(name, holder, status string) error
 }
@@ -1,6 +1,25 @@
 // Code generated by go-bindata.
 // sources:
 // migrations_gateway/01_init.sql
+// migrations_gateway/02_daily_volume.sql
+// migrations_gateway/03_backfill.sql
+// migrations_gateway/04_payment_requests.sql
+// migrations_gateway/05_leader_lease.sql
+// migrations_gateway/06_listener_cursor.sql
+// migrations_gateway/07_correlation_id.sql
+// migrations_gateway/08_payment_payload.sql
+// migrations_gateway/09_retired_account.sql
+// migrations_gateway/10_conversion.sql
+// migrations_gateway/11_idempotent_payment.sql
+// migrations_gateway/12_counterparty_stats.sql
+// migrations_gateway/13_reconciliation.sql
+// migrations_gateway/14_event.sql
+// migrations_gateway/15_internal_transfer.sql
+// migrations_gateway/16_leader_handoff.sql
+// migrations_gateway/17_account_generation.sql
+// migrations_gateway/18_received_payment_dispute.sql
+// migrations_gateway/19_queued_payment.sql
+// migrations_gateway/20_payment_callback.sql
 // migrations_compliance/01_init.sql
 // DO NOT EDIT!
 
@@ -69,7 +88,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _migrations_gateway01_initSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x94\x93\x41\x6f\xe2\x30\x10\x85\xef\xf9\x15\x73\x4c\xb4\x8b\xb4\xa0\x05\x55\x42\x1c\x02\x71\xdb\xa8\x21\xd0\xe0\x1c\x38\x25\x26\x99\xa6\x56\x89\x83\x1c\x87\xd2\x7f\x5f\x87\xaa\xa5\x31\x2a\x6d\x8f\xb6\xbf\x79\x9e\x79\x4f\xd3\xeb\xc1\x9f\x92\x17\x92\x29\x84\x78\x67\xcd\x22\xe2\x52\x02\xd4\x9d\x06\x04\xd2\x08\x33\xe4\x7b\xcc\x97\xec\xa5\x44\xa1\x52\xb0\x2d\x80\x94\xe7\x29\x70\xa1\xec\x7e\xdf\x81\x70\x41\x21\x8c\x83\x00\xdc\x98\x2e\x12\x3f\xd4\xf5\x73\x12\xd2\xbf\x2d\x57\xed\x50\xcb\xf2\x4a\x24\x6d\xc5\x9e\xc9\xec\x91\x49\x7b\x30\x1c\x9e\xca\x8e\xdc\x4e\x56\x19\xd6\x35\xe6\x09\xd3\x5f\xe4\xba\x13\xc5\x4b\x34\x18\x56\x70\x51\x24\xaa\x7a\x42\x71\x49\xab\x56\x4c\x35\xf5\x05\x62\x19\xf9\x73\x37\x5a\xc3\x1d\x59\x83\xdd\x8e\xe2\xb4\xb7\x71\xe8\xdf\xc7\xe4\x78\x69\xb4\x6d\x77\xcf\x8e\xe5\x00\x09\x6f\xfc\x90\x4c\x7c\x21\x2a\x6f\x0a\x1e\xb9\x76\xe3\x80\xc2\xec\xd6\x8d\x56\x84\x4e\x1a\xf5\x70\x35\xb6\x0c\x23\x57\xda\x3d\x2a\x99\xa8\x59\xd6\x2a\xfd\xd2\x48\x75\xaa\xec\x58\x39\xfa\xff\xcd\xf4\xfd\x7f\x26\x50\x35\x32\xc3\x13\x30\x1c\x99\x40\xb3\x29\xb9\x52\x17\xb3\xa8\x9b\x2c\x43\xcc\x4d\xe6\xdd\x88\x0f\x6e\x8b\x79\x81\x32\x85\x0d\x2f\xda\x29\x07\xba\x99\x33\x06\xc5\x1e\xb7\xda\xe1\xe4\x90\x6b\x52\xe1\x41\x75\xff\x92\x58\x37\x5b\xf5\xf6\xda\xc9\xd4\x54\x3a\xcf\xf5\xa7\x49\x7d\xde\x00\xaf\x7a\x16\x96\x17\x2d\x96\x5f\x6d\xc0\xb8\xf3\x6a\xc6\x3a\xb6\x5e\x03\x00\x00\xff\xff\x83\xe1\xb3\xac\x4f\x03\x00\x00")
+var _migrations_gateway01_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x93\x41\xcf\x9a\x40\x10\x86\xef\xfc\x8a\x39\x42\x5a\x13\x35\xd5\x34\x31\x1e\x50\xb6\x2d\x29\xa2\xc5\xe5\xe0\x09\x56\x98\xd2\x4d\x65\x97\x2c\x83\xb5\xff\xbe\xc1\xc6\x5a\xd6\xd4\x7e\xdf\x71\x77\x9e\x99\x9d\x79\xdf\x9d\xd1\x08\xde\xd4\xb2\x32\x82\x10\xd2\xc6\x59\x27\xcc\xe7\x0c\xb8\xbf\x8a\x18\xe4\x09\x16\x28\xcf\x58\xee\xc4\xcf\x1a\x15\xe5\xe0\x3a\x00\xb9\x2c\x73\x90\x8a\xdc\xc9\xc4\x83\x78\xcb\x21\x4e\xa3\x08\xfc\x94\x6f\xb3\x30\x5e\x27\x6c\xc3\x62\xfe\xb6\xe7\x74\x83\x46\x90\xd4\x2a\xeb\x33\xce\xc2\x14\xdf\x84\x71\xa7\xb3\xd9\x3d\xed\xca\x35\x46\x17\xd8\xb6\x58\x66\x82\x72\x28\x05\x21\xc9\x1a\x2d\x46\x54\x52\x55\x19\xe9\xef\xa8\x9e\xd5\x6a\x49\x50\xd7\x3e\x21\x76\x49\xb8\xf1\x93\x03\x7c\x66\x07\x70\xfb\x51\xbc\xbe\x87\x34\x0e\xbf\xa4\xec\x7a\x69\xb5\xed\x0e\xcf\x9e\xe3\x01\x8b\x3f\x86\x31\x5b\x86\x4a\xe9\x60\x05\x01\xfb\xe0\xa7\x11\x87\xf5\x27\x3f\xd9\x33\xbe\xec\xe8\xeb\xfb\x85\x63\x09\xb9\x47\x45\xdc\x08\xd5\x8a\xa2\xaf\xf4\x4a\x21\xe9\x9e\x39\x90\x72\xfe\xee\x3f\xd3\x4f\xc6\x36\xa0\x3b\x53\xe0\x1d\x98\xcd\x6d\xa0\x3b\xd6\x92\xe8\xa9\x17\x6d\x57\x14\x88\xa5\xcd\xdc\x84\xf8\xc3\x9d\xb0\xac\xd0\xe4\x70\x94\x55\xff\x5d\xa6\x63\xef\x91\x41\x75\xc6\x93\x6e\x30\xbb\x94\x26\x07\xc2\x0b\x0d\xdf\x32\xd8\x76\x27\xfa\x1d\xbd\x35\x7d\xf5\xd4\xae\xf4\xe8\xeb\x4b\x9d\xfa\x7b\x03\x02\xfd\x43\x39\x41\xb2\xdd\xfd\x6b\x03\x16\x83\xa8\x6d\xeb\xc2\xf9\x35\x00\x83\xe1\xb3\xac\x4f\x03\x00\x00")
 
 func migrations_gateway01_initSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -89,7 +108,387 @@ func migrations_gateway01_initSql() (*asset, error) {
 	return a, nil
 }
 
-var _migrations_compliance01_initSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xac\x94\x4d\x53\xc2\x30\x10\x86\xef\xfd\x15\x7b\x2c\x23\x1c\x70\x84\x71\x86\xe1\x50\x68\xd0\x8e\xa5\x60\x4d\x0f\x9c\xda\x48\x83\x64\xa4\x09\x13\x52\x41\x7f\xbd\x6d\xd5\x7e\xf1\x25\xa3\x37\xd8\x3e\xbb\x7d\xf7\x69\x26\xad\x16\x5c\x45\xec\x45\x12\x45\xc1\x5b\x6b\x43\x17\x19\x18\x01\x36\x06\x36\x82\xc0\x88\xd5\x52\x48\xf6\x41\x43\x2c\x09\xdf\x90\xb9\x62\x82\x07\xa0\x6b\x00\x01\x0b\x03\x60\x5c\xe9\xed\x76\x03\x9c\x09\x06\xc7\xb3\x6d\x30\x3c\x3c\xf1\x2d\x27\x99\x32\x46\x0e\x6e\xa6\x9c\x2a\x3a\xfd\xb4\x67\xbe\x24\x52\xef\xde\x14\x4d\x19\x15\xd1\x48\x04\xf0\x46\xe4\xe1\xc7\xe5\x21\xbb\x50\x06\xa0\xe8\x4e\x55\x11\x92\x67\xf5\x89\x0a\x20\x4c\x16\x52\x2c\xa2\x55\x28\xa9\x92\x03\xcd\x53\xd7\x1a\x1b\xee\x0c\x1e\xd0\x0c\xf4\x74\xb3\x86\xd6\x00\xe4\xdc\x59\x0e\xea\x5b\x9c\x0b\x73\x00\x26\x1a\x19\x9e\x8d\x61\x78\x6f\xb8\x4f\x08\xf7\x63\xb5\xb8\xed\x69\x75\x5f\xab\x95\xd8\xd2\x70\x64\x5d\xe8\x88\x93\x88\x16\xdb\x5f\x77\x3a\xb5\xf5\x43\x11\x11\xc6\x4f\x11\xeb\xf8\x79\xc5\xe6\xfe\x2b\x7d\xff\x36\xdc\xe9\xd6\x08\xf2\x95\xed\xb8\x9c\x7d\x09\x69\xd5\x73\xac\x47\x0f\x65\xc5\x3c\x86\xfe\xf3\x6b\x8f\x28\xc7\xd0\xcb\xff\xfe\x26\xd4\xdb\x50\x79\xa1\xd2\x05\xf3\xcf\x59\x4d\x90\xf3\x62\x13\xe8\xbc\xdb\x38\xc9\x97\x1d\xee\xe3\x73\xfe\x41\x7f\x25\x8a\x9f\xbf\x53\xaf\x65\x6c\x16\x79\x7e\x6d\xbd\x7c\x0b\x98\x62\xcb\x35\xd3\x9d\x4c\x4f\xdf\x02\xbd\x2a\x93\x9f\xfc\x83\xf5\xec\x03\xf6\xb4\xcf\x00\x00\x00\xff\xff\xb0\xd9\x8a\xda\x6d\x04\x00\x00")
+var _migrations_gateway02_daily_volumeSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x52\xc1\x6e\xe2\x30\x14\xbc\xfb\x2b\xde\x8d\x44\x1b\xa4\x05\x89\xd5\x4a\x11\x07\x93\x78\xb7\x51\x83\x43\x5d\xa7\x12\x27\x9c\x26\x86\x5a\x22\x4e\x95\x38\x54\xfc\x7d\x95\x20\x4a\xa0\x01\x7a\xf5\xbc\xf1\x9b\x99\x37\xc3\x21\xfc\xca\xd5\xa6\x4c\x8c\x84\xf8\x1d\xe1\x90\x13\x06\x1c\xcf\x42\x02\x82\xc9\x54\xaa\x9d\xcc\x16\xc9\x3e\x97\xda\x08\x04\x80\x7d\x1f\xbc\x28\x8c\xe7\x14\x44\x52\x55\xd2\xac\xd2\x22\x93\x02\x76\x49\x99\xbe\x25\xa5\x35\x1a\xdb\x40\x23\x0e\x34\x0e\x43\xf0\xc9\x3f\x1c\x87\x1c\x06\x03\xa7\x97\xaa\xaa\xaa\x96\xe5\x89\x3c\xf9\xf3\x33\x72\x5e\xd4\xda\x9c\x68\xe3\xc9\xa4\x97\xe7\x22\xe4\x31\x82\x39\x39\xfa\xf1\x13\xb5\xdd\xbf\x14\xdb\x3a\x97\x02\x2c\x04\x20\x54\x26\x40\x69\x63\x8d\x46\x9d\x1f\x70\xcc\xa3\x55\x40\x3d\x46\xe6\x84\xf2\x46\xba\xc8\x12\xd3\x35\xf9\xfb\x34\xdd\xc2\xf7\x92\xe8\x0c\xdd\xf2\xdc\x8e\x65\xaa\x94\xa9\x51\x85\xbe\xb1\x2f\x3d\x04\xf0\xaa\x36\x8d\xf8\xf1\x25\x5c\xd5\xf9\x75\x70\x2d\x65\x75\x15\x5d\xb0\x60\x8e\xd9\x12\x1e\xc9\x12\xac\x26\x1d\xbb\xd9\x17\xd3\xe0\x29\x26\xed\x63\x9b\xc4\xea\xe0\xb7\xa3\xd4\x6a\xdf\x85\x73\x16\x85\x73\xe1\xd9\xe9\x9a\xb3\x91\x0d\x84\xfe\x0f\x28\x99\x06\x5a\x17\xfe\xec\xeb\x70\xde\x03\x66\xcf\x84\x4f\x6b\xb3\xfe\xeb\x22\xd4\x2d\xa8\x5f\x7c\x68\xe4\xb3\x68\xd1\x77\x51\xf7\x6e\x79\x5b\xe6\x79\x05\x0f\x42\xfb\xb1\xa3\xec\x6f\x68\x5e\xd4\xda\x08\x17\x7d\x0e\x00\x39\x44\x01\x9c\x3c\x03\x00\x00")
+
+func migrations_gateway02_daily_volumeSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway02_daily_volumeSql,
+		"migrations_gateway/02_daily_volume.sql",
+	)
+}
+
+func migrations_gateway02_daily_volumeSql() (*asset, error) {
+	bytes, err := migrations_gateway02_daily_volumeSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/02_daily_volume.sql", size: 828, mode: os.FileMode(420), modTime: time.Unix(1791953484, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway03_backfillSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x91\x51\x4b\xf3\x30\x14\x86\xef\xf3\x2b\xce\x65\xcb\xf7\x0d\x54\x98\x08\x65\x17\x59\x7b\xd4\x62\x96\xce\x98\x5c\xec\xaa\x89\x6d\x36\xc3\xb6\x74\xd4\x74\xb2\x7f\x2f\x2d\xea\x36\x45\xbc\x4b\x0e\xcf\xf3\x72\x78\xcf\x68\x04\xff\xb6\x6e\xd5\x9a\x60\x41\xed\x08\x65\x12\x05\x48\x3a\x65\x08\x5a\xd8\xca\xba\xbd\xad\xe7\xe6\xb0\xb5\x3e\x68\xa0\x59\x06\x69\xc1\xd4\x8c\x83\x7e\x36\xd5\x7a\xe9\x36\x1b\x0d\xc1\xf9\x83\xf3\x21\xba\x8c\x81\x17\x12\xb8\x62\x0c\x32\xbc\xa5\x8a\x49\xb8\x48\x08\x49\x05\x52\x89\x9f\xa1\xd3\x0f\x2f\xed\xda\xd7\xa6\xd5\x10\x11\x00\xed\x6a\x0d\x43\xc4\x69\x06\x55\xb2\x28\x73\x9e\x0a\x9c\x21\x97\xff\x7b\xce\x54\x55\xd3\xf9\x50\xf6\xfc\xde\xb4\xd5\x8b\x69\xa3\xf1\xf5\xd1\x19\xa0\x9d\x59\x39\xbf\x2a\x43\xb3\xb6\xfe\x88\x5d\x8d\xc7\xdf\xb8\x6e\x57\x9b\x60\xeb\xd2\x04\x0d\xfd\x2b\xb8\xad\x3d\x23\xe6\x22\x9f\x51\xb1\x80\x07\x5c\x40\xd4\xef\x18\xf7\x9e\xe2\xf9\xa3\xc2\x61\x78\xb6\x4f\x74\xfa\x8b\x49\x0c\xc8\xef\x72\x8e\x93\xdc\xfb\x26\x9b\x7e\x35\x92\xde\x53\xf1\x84\x72\xd2\x85\xe5\x4d\x42\xc8\x69\xff\x59\xf3\xe6\x49\x26\x8a\xf9\x2f\x5d\x25\x7f\x9c\x67\x50\x7f\xdc\x27\x21\xef\x03\x00\xc7\x72\x0c\xa9\xe4\x01\x00\x00")
+
+func migrations_gateway03_backfillSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway03_backfillSql,
+		"migrations_gateway/03_backfill.sql",
+	)
+}
+
+func migrations_gateway03_backfillSql() (*asset, error) {
+	bytes, err := migrations_gateway03_backfillSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/03_backfill.sql", size: 484, mode: os.FileMode(420), modTime: time.Unix(1461004393, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway04_payment_requestsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\xd2\x41\x6f\xaa\x40\x10\x07\xf0\x3b\x9f\x62\x6e\x42\x9e\x26\x4f\xf3\x34\x2f\x31\x1e\x50\xf6\xbd\x92\x22\x5a\x0a\x07\x4f\xb0\x91\xb1\xdd\x44\x76\xe9\xee\xd0\xd6\x6f\xdf\x40\x1b\x01\x63\xed\x91\xc9\xef\x3f\x4b\x66\x66\x34\x82\x5f\x85\x78\xd2\x9c\x10\x92\xd2\x5a\x45\xcc\x8d\x19\xc4\xee\x32\x60\x90\x6d\xf9\xa9\x40\x49\x11\xbe\x54\x68\x28\x03\xdb\x02\xc8\x44\x9e\x81\x90\x64\x8f\xc7\x0e\x84\x9b\x18\xc2\x24\x08\xc0\x4d\xe2\x4d\xea\x87\xab\x88\xad\x59\x18\x0f\x6b\xa7\x3f\x53\x69\xed\x5f\xb9\xde\x3f\x73\x6d\xcf\xfe\xb4\x99\x06\xe5\x68\x48\x48\x4e\x42\xc9\x56\x4d\x67\x17\x8a\x1b\x83\x94\xee\x55\x8e\x2d\x1a\x4f\xae\x22\x61\x4c\x85\xfa\x56\xaf\x42\x55\x92\x5a\x30\x99\x4e\x2f\x44\x81\x85\x4a\xe9\x54\x76\x1f\xfb\x7d\xc5\xdc\xea\x61\x88\x53\x65\x6e\x34\xd8\x6b\xe4\x84\x79\xca\x29\x83\x9c\x13\x92\x28\xb0\x2f\xf0\xbd\x14\x1a\x4d\x5f\x78\xec\x9f\x9b\x04\x1d\x75\xa8\x8e\x07\x71\x3c\x62\xfe\x83\x53\x25\xea\x66\xcc\xbd\x7d\xf4\x7e\xfc\x1c\x1a\x0c\xea\xc8\x36\xf2\xd7\x6e\xb4\x83\x7b\xb6\x03\xbb\xde\xba\x53\x57\x93\xd0\x7f\x48\x58\x53\xec\x6d\xd8\xee\x7e\x35\xb2\x21\xf5\x2c\x33\xb0\x3b\x33\x1d\x7e\x15\x1d\xcb\x01\x16\xfe\xf7\x43\xb6\xf0\xa5\x54\xde\xf2\xfc\xfc\xea\xce\x8d\x1e\x59\xbc\xa8\xe8\xf0\x77\x6e\x59\xdd\x0b\xf5\xd4\x9b\xb4\xbc\x68\xb3\xfd\xe6\x42\xe7\xd6\xc7\x00\xd4\x04\x50\xb0\xd0\x02\x00\x00")
+
+func migrations_gateway04_payment_requestsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway04_payment_requestsSql,
+		"migrations_gateway/04_payment_requests.sql",
+	)
+}
+
+func migrations_gateway04_payment_requestsSql() (*asset, error) {
+	bytes, err := migrations_gateway04_payment_requestsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/04_payment_requests.sql", size: 720, mode: os.FileMode(420), modTime: time.Unix(1461004393, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway05_leader_leaseSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xcf\xcd\x4a\x03\x31\x14\xc5\xf1\x7d\x9e\xe2\x2c\x67\xd0\x6e\xc4\x8a\x50\xba\x48\x9b\xab\x0e\xc6\xb4\xc4\xcc\xa2\x2b\x73\x71\xae\x76\xc0\xc9\x94\x4c\xfc\x78\x7c\x11\x17\x7e\xd0\xf5\xf9\xc1\xe1\x3f\x9b\xe1\x64\xe8\x9f\x33\x17\x41\x7b\x50\x6b\x4f\x3a\x10\x82\x5e\x59\x42\xb4\xc2\x9d\x64\x2b\x3c\x49\x44\xa5\x80\x98\x78\x90\x88\x37\xce\x8f\x7b\xce\xd5\xc5\x79\x0d\xb7\x09\x70\xad\xb5\xa7\x5f\xf3\x7e\x7c\xe9\x24\xff\x80\xb3\xf9\xfc\x9f\x90\x8f\x43\x9f\x65\x7a\xe0\x12\xd1\x71\x91\xd2\x0f\xf2\x47\x6c\x7d\x73\xa7\xfd\x0e\xb7\xb4\x43\xf5\xfd\x57\xab\x1a\xe4\xae\x1b\x47\xcb\x26\xa5\xd1\xac\x60\xe8\x4a\xb7\x36\x60\x7d\xa3\xfd\x3d\x85\xe5\x6b\x79\xba\x5c\x28\xf5\x3b\xc6\x8c\xef\x49\x19\xbf\xd9\x1e\x8b\x59\xa8\xcf\x01\x00\xa2\x25\x03\x33\xf8\x00\x00\x00")
+
+func migrations_gateway05_leader_leaseSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway05_leader_leaseSql,
+		"migrations_gateway/05_leader_lease.sql",
+	)
+}
+
+func migrations_gateway05_leader_leaseSql() (*asset, error) {
+	bytes, err := migrations_gateway05_leader_leaseSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/05_leader_lease.sql", size: 248, mode: os.FileMode(420), modTime: time.Unix(1791956736, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway06_listener_cursorSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\x8f\xb1\x4e\xf3\x30\x14\x85\x77\x3f\xc5\x1d\x13\xfd\x7f\x87\x22\x55\x42\xaa\x3a\xb8\xc9\x05\x22\x5c\xa7\x32\xce\xd0\xc9\xb6\xe8\x6d\xb0\x50\x9c\xc8\xb9\x05\xf1\xf6\xa8\x5d\x00\x09\xe6\xf3\x9d\xa3\xf3\x2d\x16\xf0\x6f\x88\x7d\x0e\x4c\xd0\x4d\xa2\x32\x28\x2d\x82\x95\x5b\x85\xe0\x55\x9c\x99\x12\xe5\xea\x9c\xe7\x31\x7b\x28\x04\x80\x8f\x47\x0f\x31\x71\xb1\x5c\x96\xa0\x5b\x0b\xba\x53\x0a\x64\x67\x5b\xd7\xe8\xca\xe0\x0e\xb5\xfd\x7f\xe1\xa6\xd0\xc7\xd4\x3b\x1e\x5f\x29\x79\x78\x0b\xf9\xf9\x25\xe4\xe2\x66\xb5\xfa\xaa\x5d\xb9\x70\x62\xca\x6e\x0a\x1f\x03\x25\x76\xbf\xad\x5f\xb1\x99\xd8\x05\xf6\x70\x0c\x4c\x1c\x07\xfa\x91\xee\x4d\xb3\x93\xe6\x00\x8f\x78\x80\xe2\xf2\xb0\x14\x25\xa0\xbe\x6f\x34\x6e\x9a\x94\xc6\x7a\x0b\x35\xde\xc9\x4e\x59\xa8\x1e\xa4\x79\x42\xbb\x39\xf3\xe9\x76\x2d\xc4\x77\xff\x7a\x7c\x4f\xa2\x36\xed\xfe\x0f\xff\xb5\xf8\x1c\x00\x8a\x3b\x3e\xac\x2e\x01\x00\x00")
+
+func migrations_gateway06_listener_cursorSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway06_listener_cursorSql,
+		"migrations_gateway/06_listener_cursor.sql",
+	)
+}
+
+func migrations_gateway06_listener_cursorSql() (*asset, error) {
+	bytes, err := migrations_gateway06_listener_cursorSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/06_listener_cursor.sql", size: 302, mode: os.FileMode(420), modTime: time.Unix(1791958003, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway07_correlation_idSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\xcd\xb1\xaa\xc2\x30\x14\x06\xe0\x3d\x4f\xf1\x6f\xbd\x17\xe9\xa0\x93\xd0\x29\x9a\x3a\x1d\x5b\xa9\xc9\x6c\x0e\x31\x68\x40\x13\x39\x06\x7d\x7d\x71\x73\x11\xe7\x6f\xf8\xda\x16\xb3\x6b\x3a\x09\xd7\x08\x77\x53\x9a\x6c\x3f\xc1\xea\x15\xf5\xf0\xfb\x98\xab\x15\xce\x77\x0e\x35\x95\xec\xa1\x8d\xc1\x7a\x24\xb7\x1d\xe0\x43\x11\x89\x17\x7e\xc3\x21\x1d\x3d\x1e\x2c\xe1\xcc\xf2\x37\x5f\x2c\xff\x31\x8c\x16\x83\x23\x82\xe9\x37\xda\x91\x45\xd3\x74\x4a\x7d\x5e\xa6\x3c\xf3\x8f\xcd\x4c\xe3\xee\x5b\xd7\xa9\xd7\x00\x5b\x4e\x73\xb0\xb9\x00\x00\x00")
+
+func migrations_gateway07_correlation_idSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway07_correlation_idSql,
+		"migrations_gateway/07_correlation_id.sql",
+	)
+}
+
+func migrations_gateway07_correlation_idSql() (*asset, error) {
+	bytes, err := migrations_gateway07_correlation_idSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/07_correlation_id.sql", size: 185, mode: os.FileMode(420), modTime: time.Unix(1791960323, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway08_payment_payloadSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\xce\x41\xae\x82\x30\x10\x80\xe1\x3d\xa7\x98\xfd\x0b\x27\x60\xd5\x67\x71\x35\x82\xc1\x76\xed\x0c\x52\x49\x93\xd2\x36\x75\x8c\x72\x7b\xb7\xc6\x98\x18\x0e\xf0\x7f\xf9\xeb\x1a\xfe\x16\x3f\x17\x16\x07\x36\x57\x0a\x4d\x3b\x80\x51\xff\xd8\x02\x9d\x5c\x14\x53\x38\xde\xf8\x22\x3e\x45\x02\xa5\x35\xec\x7a\xb4\x87\x0e\x28\xf3\x1a\x12\x4f\x04\xe2\x9e\x02\xba\xdd\x2b\x8b\x06\x3a\x8b\xd8\x6c\x50\x8a\x1b\xef\x3e\xc8\xf9\x5a\xd2\x42\x30\xfa\xd9\xc7\x4f\xac\x7a\x5f\xd4\xe9\x11\x7f\xf0\x7a\xe8\x8f\xdf\xfd\x66\x4b\x99\x79\x0d\x89\x27\x6a\xaa\xd7\x00\xa7\x19\xb1\xd7\x23\x01\x00\x00")
+
+func migrations_gateway08_payment_payloadSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway08_payment_payloadSql,
+		"migrations_gateway/08_payment_payload.sql",
+	)
+}
+
+func migrations_gateway08_payment_payloadSql() (*asset, error) {
+	bytes, err := migrations_gateway08_payment_payloadSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/08_payment_payload.sql", size: 291, mode: os.FileMode(420), modTime: time.Unix(1791961839, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway09_retired_accountSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x91\x31\x6f\x83\x30\x10\x85\x77\xff\x8a\xdb\x02\x6a\x19\x52\x95\xa8\x52\x94\xc1\x09\x6e\x8b\x4a\x9c\xc8\x35\x43\x26\xb0\xc0\x05\x0f\xd8\x91\x71\xda\xbf\x5f\x20\x4a\x03\xa9\xda\xf1\x4e\xdf\x7b\xba\x7b\x2f\x08\xe0\xae\x51\x95\x15\x4e\x42\x7a\x44\x1b\x46\x30\x27\xc0\xf1\x3a\x21\x90\x33\xe9\x94\x95\x25\x2e\x0a\x73\xd2\x2e\x07\x0f\x01\xe4\xaa\xcc\x41\x69\xe7\xcd\xe7\x3e\xd0\x1d\x07\x9a\x26\x09\xe0\x94\xef\xb2\x98\x76\xf2\x2d\xa1\xfc\xbe\xe7\xc4\x59\x95\xf5\xfc\xa7\xb0\x45\x2d\xac\x17\x2e\xae\x9a\x01\x6a\xa4\xad\x64\x99\x75\x7e\xe6\x1f\xca\x1c\x65\x77\xa0\x32\x7a\x62\xf6\x10\x86\x37\x9c\xb3\x42\xb7\xa2\x18\xc8\x5a\xb4\xf5\x95\x5d\x3c\x8e\x8e\x8d\xc8\x33\x4e\x13\x0e\xb3\xd9\xa0\xb2\xe7\x2f\x33\xd1\x7d\x58\x76\x39\x38\xd5\xc8\xa9\xaf\x95\x56\x56\xaa\x75\xf2\x17\x76\xb1\xba\xa0\x7b\x16\x6f\x31\x3b\xc0\x1b\x39\x80\xd7\x47\xe5\xf7\xdb\x7e\x9a\xe4\xe1\x8d\x27\x1f\xf9\x40\xe8\x4b\x4c\xc9\x2a\xd6\xda\x44\xeb\x1f\xd7\xcd\x2b\x66\xef\x84\xaf\x4e\xee\xe3\x69\x89\x50\x30\xea\x2a\x32\x5f\x1a\x45\x6c\xb7\xff\xa3\xab\x25\xfa\x06\x05\xa6\x51\x74\xda\x01\x00\x00")
+
+func migrations_gateway09_retired_accountSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway09_retired_accountSql,
+		"migrations_gateway/09_retired_account.sql",
+	)
+}
+
+func migrations_gateway09_retired_accountSql() (*asset, error) {
+	bytes, err := migrations_gateway09_retired_accountSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/09_retired_account.sql", size: 474, mode: os.FileMode(420), modTime: time.Unix(1791963022, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway10_conversionSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\x93\x41\x6f\x82\x30\x14\xc7\xef\x7c\x8a\x77\x1b\x64\x33\x51\x33\xcd\x12\xe3\x01\xa5\xdb\xc8\x10\x1d\x83\x83\x27\x68\xe0\x4d\x39\xd0\x9a\xb6\x38\x3f\xfe\x0a\x8b\x03\x51\x13\xbd\xb5\xcd\xef\xf7\x7f\xaf\xcd\x6b\xaf\x07\x8f\x45\xbe\x11\x54\x21\x44\x3b\x63\x1e\x10\x3b\x24\x10\xda\x33\x8f\x40\x32\xe7\x6c\x8f\x42\xe6\x9c\x25\x60\x1a\x00\x49\x9e\x25\x90\x33\x65\x0e\x06\x16\xf8\xcb\x10\xfc\xc8\xf3\xc0\x8e\xc2\x65\xec\xfa\x5a\x5d\x10\x3f\x7c\xaa\x38\xbe\x43\x9d\xa8\xbd\xb8\x32\xf6\x54\xa4\x5b\x2a\xcc\xe1\x68\xd4\x68\x35\x27\x15\x55\xa5\x6c\x88\x41\xbf\x03\xa0\x10\x5c\xc4\x05\x4a\x49\x37\x98\x80\xc2\x83\xea\x24\x20\xcb\x62\x2a\x25\xaa\x38\xe5\x19\xb6\xa2\x86\xd6\x55\x32\x97\xb2\x44\xd1\xb0\xa3\x71\xeb\x3a\x0e\x79\xb5\x23\x2f\x84\x87\x87\x46\x2b\xe8\xa1\x45\xf7\x2f\x26\x17\xbc\x64\xea\x32\xd5\xcd\xcc\x50\xaa\x9c\xfd\x3d\xd0\x4d\xbd\x9f\x0b\xf7\x5c\xe1\xc4\xbe\xa3\xcd\x4a\x2b\xf4\x60\x64\xf1\x4e\xe4\x29\xde\x26\x29\x41\x99\xa4\x69\x5d\x6c\x4b\xe5\xb6\xb1\xc6\xcf\xd7\xad\x54\x60\x5d\x88\xea\xd6\x32\xbd\xd2\x85\xf1\xf4\x09\xd2\x7a\x16\xcf\x98\x63\xce\x91\x5b\x05\xee\xc2\x0e\xd6\xf0\x41\xd6\x60\x56\xf3\x6a\x55\xa7\x91\xef\x7e\x46\xa4\x3e\xec\xcc\xa6\x79\xba\xb7\x0c\x0b\x88\xff\xe6\xfa\x64\xea\x32\xc6\x9d\xd9\x7f\x81\xf9\xbb\x1d\x7c\x91\x70\x5a\xaa\xef\x97\x89\x61\xf4\x5a\xff\xc6\xe1\x3f\xcc\x70\x82\xe5\xea\xc2\xbf\x99\x18\xbf\xb3\x2b\xdb\xe2\x62\x03\x00\x00")
+
+func migrations_gateway10_conversionSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway10_conversionSql,
+		"migrations_gateway/10_conversion.sql",
+	)
+}
+
+func migrations_gateway10_conversionSql() (*asset, error) {
+	bytes, err := migrations_gateway10_conversionSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/10_conversion.sql", size: 866, mode: os.FileMode(420), modTime: time.Unix(1791964726, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway11_idempotent_paymentSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x85\x91\x4d\x4f\x84\x30\x10\x86\xef\xfd\x15\x73\x84\x28\x87\x4d\x8c\x31\xd9\xec\x81\x5d\xaa\x36\xb2\x05\x91\x1e\xf6\x04\x0d\x54\x21\x91\x16\xdb\xc1\x8f\x7f\x2f\xac\xc9\x7e\xb8\x7e\x9c\xda\x4e\x9f\x79\x67\xe6\x9d\x20\x80\xb3\xae\x7d\xb2\x12\x15\x88\x9e\xac\x32\x1a\xe6\x14\xf2\x70\x19\x53\x28\x59\xad\xba\xde\xa0\xd2\x98\xca\x8f\x6e\x3c\x4a\xf0\x08\x40\xd9\xd6\x25\xb4\x1a\xbd\xd9\xcc\x07\x9e\xe4\xc0\x45\x1c\x43\x28\xf2\xa4\x60\x7c\x54\x58\x53\x9e\x9f\x4f\x5c\xff\x95\x55\x4c\xfc\xab\xb4\x55\x23\xad\x77\x79\xb1\xcf\xd9\x42\x56\xbd\x0c\xca\x61\xd1\x48\xd7\xfc\x81\xa1\x95\xda\xc9\x0a\x5b\xa3\xff\xd3\x73\xbd\xd1\x4e\x15\x0e\x25\x0e\x6e\xdf\x69\x44\xaf\x43\x11\xff\x40\x96\x80\xea\x1d\x4f\xff\x2b\xab\x46\x5b\xea\x42\x8e\x73\xd7\xe3\x0d\xdb\x4e\x1d\xd7\xaa\x4c\xd7\x3f\xab\x13\xe6\xbb\x52\x9a\xb1\x75\x98\x6d\xe0\x8e\x6e\xc0\x9b\xdc\xf3\xa7\xa8\xe0\xec\x5e\xd0\x6d\xf0\xc8\x29\xef\xf0\xe5\x13\x1f\x28\xbf\x61\x9c\x2e\x98\xd6\x26\x5a\xee\xc4\x57\xb7\x61\xf6\x40\xf3\xc5\x80\x8f\x57\x73\x42\x82\x83\x45\x46\xe6\x4d\x93\x28\x4b\xd2\xdf\x17\x39\x27\x9f\x43\x1d\x05\xea\xfa\x01\x00\x00")
+
+func migrations_gateway11_idempotent_paymentSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway11_idempotent_paymentSql,
+		"migrations_gateway/11_idempotent_payment.sql",
+	)
+}
+
+func migrations_gateway11_idempotent_paymentSql() (*asset, error) {
+	bytes, err := migrations_gateway11_idempotent_paymentSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/11_idempotent_payment.sql", size: 506, mode: os.FileMode(420), modTime: time.Unix(1791965059, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway12_counterparty_statsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8d\x92\x41\x6f\x82\x40\x10\x85\xef\xfc\x8a\xb9\x09\xa9\x26\xd5\xa4\x4d\x13\xe2\x01\x61\xdb\x92\x22\x58\x5c\x0e\x9e\x60\x95\xad\x6e\x22\xbb\x84\x5d\xda\xfa\xef\xbb\x60\xad\xd2\x6a\xdb\xdb\x66\xf6\x9b\x97\x79\xf3\x66\x30\x80\xab\x82\xad\x2b\xa2\x28\x24\xa5\xe1\xc6\xc8\xc1\x08\xb0\x33\x09\x10\x64\xae\xa8\xb9\xa2\x55\x49\x2a\xb5\x9b\x2b\xa2\x64\x06\xa6\x01\x90\xb1\x3c\x03\xc6\x95\x39\x1c\x5a\x10\x46\x18\xc2\x24\x08\xc0\x49\x70\x94\xfa\xa1\x56\x98\xa2\x10\xf7\x1b\x2e\xa7\x52\x31\x4e\x14\x13\x3c\x83\x57\x52\xad\x36\xa4\x32\x6f\x6e\x8f\x4d\x2d\x45\xa4\xa4\x2a\x5d\x89\x9c\x1e\xa1\xe1\xe8\x2c\xc4\xa4\xac\x69\x75\x5e\x0b\x3c\x74\xef\x24\x01\x86\x5e\xaf\xed\x28\xc9\xae\xa0\xbc\x11\xd6\x26\x32\x58\xb2\xb5\x1e\xf9\x27\x7d\xdd\xc2\x05\x25\x3c\x25\xc5\x1e\xcd\x45\xbd\xdc\xd2\x4b\xe8\x9e\x4a\x8b\xd1\x3f\xc1\x0d\x93\x4a\xe8\x05\x17\x19\x28\xfa\xae\xba\xb6\x36\xa2\xae\x7e\x27\xea\x32\xd7\xd9\xe4\x29\x69\x06\xd3\x2f\xc5\x0a\xda\x21\x66\xb1\x3f\x75\xe2\x05\x3c\xa1\x05\x98\x4d\x34\x56\x53\x4d\x42\xff\x39\x41\x6d\xf1\x34\x86\xb4\xdd\xa3\x8e\xb1\x93\x4d\xbf\x13\x42\xff\xdb\xb6\x2d\xc3\x02\x14\x3e\xf8\x21\x1a\xfb\x9c\x0b\x6f\xf2\xe5\xd3\x7d\x74\xe2\x39\xc2\xe3\x5a\xbd\xdc\xd9\x86\x13\x60\x14\x1f\x4e\x67\xae\x57\x8f\x2b\xc2\x25\x59\xed\xe3\x77\x3c\x0f\xdc\x28\x48\xa6\xa1\x96\xe7\xa2\x20\x5b\x46\xe5\xa7\xe1\x83\x5e\x63\xc9\x36\x8c\xc1\xc9\x4d\x7a\xe2\x8d\xff\xa1\xec\xc5\xd1\xec\x8c\xb4\x6d\xb4\x1f\x17\x4f\xd9\x36\x3e\x00\x84\xab\x9c\x65\xfc\x02\x00\x00")
+
+func migrations_gateway12_counterparty_statsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway12_counterparty_statsSql,
+		"migrations_gateway/12_counterparty_stats.sql",
+	)
+}
+
+func migrations_gateway12_counterparty_statsSql() (*asset, error) {
+	bytes, err := migrations_gateway12_counterparty_statsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/12_counterparty_stats.sql", size: 764, mode: os.FileMode(420), modTime: time.Unix(1791965316, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway13_reconciliationSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x75\x90\xcd\x6e\xc3\x20\x10\x84\xef\x3c\xc5\x1e\xb1\x5a\x4b\xf5\xad\x52\x94\x03\x89\x69\x8a\xea\xe0\x94\xc2\x21\xa7\x80\x30\x49\x90\x62\x88\x08\xfd\x79\xfc\xda\x6e\xa5\x36\xaa\x7a\x5a\x69\xe6\xdb\xd9\xd5\x94\x25\xdc\xf4\xfe\x90\x4c\x76\xa0\xce\x68\x29\x28\x91\x14\x24\x59\x34\x14\xb4\x70\x36\x06\xeb\x4f\xde\x64\x1f\x83\x06\x8c\x00\xb4\xef\x34\xf8\x90\x71\x55\x15\xc0\x5b\x09\x5c\x35\x0d\x10\x25\xdb\x1d\xe3\xc3\xfa\x9a\x72\x79\x3b\x72\xdd\x10\xa9\xe1\xcd\x24\x7b\x34\x09\x57\x77\x3f\xf4\x64\xf7\xfe\xd2\x9b\x6c\x8f\xee\xf2\x37\x6e\x02\x92\x3b\xc7\x94\x35\x9c\x62\x38\x64\xf7\x91\xaf\x5d\x9b\xdc\x90\xdf\xed\xcc\x40\x8c\x97\xb2\xef\xdd\x15\xb1\x11\x6c\x4d\xc4\x16\x9e\xe8\x16\xf0\xf8\x74\x31\xaa\x8a\xb3\x67\x45\x27\xf1\xfb\x41\xfc\x35\x0b\x54\x00\xe5\x2b\xc6\xe9\x9c\x85\x10\xeb\x05\xd4\xf4\x81\xa8\x46\xc2\xf2\x91\x88\x17\x2a\xe7\xaf\x79\x7f\x3f\x43\xa8\xfc\x55\x58\x1d\xdf\x03\xaa\x45\xbb\xf9\xa7\xb0\x19\xfa\x04\xb6\xeb\x5a\xd4\x5f\x01\x00\x00")
+
+func migrations_gateway13_reconciliationSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway13_reconciliationSql,
+		"migrations_gateway/13_reconciliation.sql",
+	)
+}
+
+func migrations_gateway13_reconciliationSql() (*asset, error) {
+	bytes, err := migrations_gateway13_reconciliationSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/13_reconciliation.sql", size: 351, mode: os.FileMode(420), modTime: time.Unix(1791965705, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway14_eventSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x5d\x90\x31\x6f\xc2\x30\x14\x84\x77\xff\x8a\x37\x26\x6a\x23\x15\x54\xaa\x4a\x88\xc1\x10\xd3\x46\x0d\x0e\x72\x9d\x81\x09\x9b\xc4\x0d\xae\xc0\x8e\x8c\x49\xcb\xbf\xaf\xc3\x42\xc3\xf6\xa4\xfb\x4e\xf7\xee\x92\x04\x1e\x8e\xba\x71\xd2\x2b\x28\x5b\xb4\x60\x04\x73\x02\x1c\xcf\x73\x02\x82\x74\xca\x78\x01\x11\x02\x10\xba\x16\xb0\xd3\x8d\x36\x3e\x1a\x3f\xc5\x40\x0b\x0e\xb4\xcc\x73\xc0\x25\x2f\xb6\x19\x0d\xc6\x15\xa1\xfc\xb1\x47\xfd\xa5\x55\x02\x3a\xe9\xaa\xbd\x74\xd1\xcb\xf3\x8d\xbe\xca\xa7\xf3\xee\x5b\x55\xfe\x46\x8c\x27\x93\x3b\xa4\x53\xee\xa4\xad\x11\xd0\xc7\x8d\x46\x77\x6a\x2b\x2f\x07\x2b\xc3\x3f\x07\x6b\x1a\xaf\x7e\xfd\x50\xae\x9c\x0a\x6d\xea\xad\x0c\x11\x75\xb8\xbc\x3e\xaa\x01\xb1\x66\xd9\x0a\xb3\x0d\x7c\x90\x0d\x44\x7d\xb1\x18\xc5\x40\xe8\x5b\x46\xc9\x2c\x33\xc6\xa6\x73\x48\xc9\x12\x97\x39\x87\xc5\x3b\x66\x9f\x84\xcf\xce\xfe\xeb\x75\x8a\x50\xf2\x6f\xad\xd4\xfe\x18\x94\xb2\x62\x3d\x5c\x6b\x8a\xfe\x00\x00\xb1\x58\x9c\x53\x01\x00\x00")
+
+func migrations_gateway14_eventSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway14_eventSql,
+		"migrations_gateway/14_event.sql",
+	)
+}
+
+func migrations_gateway14_eventSql() (*asset, error) {
+	bytes, err := migrations_gateway14_eventSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/14_event.sql", size: 339, mode: os.FileMode(420), modTime: time.Unix(1791969789, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway15_internal_transferSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x85\xcd\xb1\x0a\x42\x21\x14\x06\xe0\xdd\xa7\xf8\xc7\x22\x84\x9a\xef\x64\x1d\x9b\x4e\x1a\x37\xdd\x95\x90\x10\xea\xdc\xf0\x0a\x97\xde\xbe\xc6\xa0\xa1\xf1\x9b\x3e\xad\xb1\x79\xd4\x5b\xcb\xbd\x20\x3e\x95\xe1\x60\x47\x04\xb3\x67\x8b\x74\x29\xd2\x43\xcb\x32\xe7\x6b\xaf\x93\x24\x18\x22\x1c\x3c\xc7\x93\x43\xaa\xd2\x4b\x93\x7c\x4f\xe8\x55\x5e\x1f\xad\x76\x6b\x38\x1f\xe0\x22\x33\xc8\x1e\x4d\xe4\x80\xed\xa0\x94\xfe\x2a\x68\x5a\xe4\x4f\x42\xa3\x3f\xff\x2e\x83\x7a\x03\xc8\x0f\x32\x8e\xaa\x00\x00\x00")
+
+func migrations_gateway15_internal_transferSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway15_internal_transferSql,
+		"migrations_gateway/15_internal_transfer.sql",
+	)
+}
+
+func migrations_gateway15_internal_transferSql() (*asset, error) {
+	bytes, err := migrations_gateway15_internal_transferSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/15_internal_transfer.sql", size: 170, mode: os.FileMode(420), modTime: time.Unix(1791970239, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway16_leader_handoffSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x8f\xb1\x0e\x82\x30\x18\x84\xf7\x3e\xc5\x3f\x42\x94\x41\x23\xc4\x84\x38\x14\xa9\x42\xac\x40\x6a\x19\xd8\x68\xb4\x08\x89\xb4\xa6\xa0\xbe\xbe\x1a\x07\xd4\x18\xe7\xfb\xee\x72\x9f\xe3\xc0\xa8\x6d\x8e\x46\xf4\x12\xf2\x33\x5a\x32\x82\x39\x01\x8e\x03\x4a\xa0\xa4\x52\x1c\xa4\x89\x84\x3a\xe8\xaa\x2a\xc1\x42\x00\xa5\x12\xad\x2c\xe1\x2a\xcc\xbe\x16\xc6\xf2\x66\x36\x24\x29\x87\x24\xa7\x74\xfc\x8c\x6b\x7d\x7a\x54\x06\x60\xea\xba\x5f\xc4\x55\x9a\xae\xd1\xea\xcf\x46\xd7\x8b\xfe\xd2\x0d\xc0\xc4\xfb\x04\x32\x16\x6f\x31\x2b\x60\x43\x0a\xb0\x5e\x87\x6c\x64\x03\x49\xd6\x71\x42\x16\xb1\x52\x3a\x0c\x20\x24\x2b\x9c\x53\x0e\xcb\x08\xb3\x1d\xe1\x8b\x4b\x5f\xcd\x7d\x84\x9c\x37\xdf\x50\xdf\x14\x0a\x59\x9a\xfd\xf6\xf5\xd1\x1d\xab\xc3\x70\xce\x1d\x01\x00\x00")
+
+func migrations_gateway16_leader_handoffSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway16_leader_handoffSql,
+		"migrations_gateway/16_leader_handoff.sql",
+	)
+}
+
+func migrations_gateway16_leader_handoffSql() (*asset, error) {
+	bytes, err := migrations_gateway16_leader_handoffSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/16_leader_handoff.sql", size: 285, mode: os.FileMode(420), modTime: time.Unix(1791971571, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway17_account_generationSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9d\x94\x51\x4f\xc2\x30\x14\x85\xdf\xf7\x2b\xee\xe3\x16\x21\x91\x07\x8d\x09\xc1\xa4\xb2\x2a\x8b\xa3\xc3\xb2\x45\x79\x5a\xeb\x56\x71\x0f\x74\xd8\x75\x1a\xff\xbd\xed\x54\x18\xe2\x84\xf8\xd8\xde\xef\x9e\xde\x73\xda\xb4\xdf\x87\x93\x55\xb1\x54\x5c\x0b\x48\xd6\xce\x98\x62\x14\x63\x88\xd1\x55\x88\x81\xa1\x2c\x2b\x6b\xa9\x6f\x84\x14\x06\x28\x4a\xc9\xc0\x75\x00\x58\x91\x33\x28\xa4\x76\x07\x03\x0f\x48\x14\x03\x49\xc2\x10\x50\x12\x47\x69\x40\x8c\xc2\x14\x93\xb8\x67\x39\xfe\xd9\x9f\x5a\xfe\x95\xab\xec\x99\x2b\xf7\xec\x7c\xdb\xd3\x40\xcb\x96\xfa\x63\xb1\x34\xba\x5b\x4d\x1f\x5f\xa3\x24\x8c\x61\xd0\x90\x95\x78\xa9\x85\xcc\x44\x37\x77\xda\x70\x2b\xa1\x96\x22\x4f\xb9\x66\x90\x1b\x5f\xba\x58\x89\x0d\xb1\x39\x56\x89\x4c\x09\x53\x3d\xc4\xd5\xeb\x7c\x9f\x6a\x1b\x98\xd1\x60\x8a\xe8\x02\x6e\xf1\x02\x5c\x1b\x8d\x67\x77\x13\x12\xdc\x25\xb8\xd9\xdc\x89\xc1\x6d\xaf\x3c\xc7\x03\x4c\x6e\x02\x82\x47\x81\x94\xa5\x7f\xb5\x39\x7e\x3c\x41\x74\x8e\xe3\x51\xad\x9f\x2e\x86\x0e\x0a\x63\x4c\xbf\x2f\x65\xc6\xdf\x57\x42\x6a\x6a\xc3\xa8\xcc\x50\xc8\xf7\x61\x1c\x85\xc9\x94\x1c\x99\xe5\x0f\xbd\xb1\x9d\x46\xa8\x35\x57\xfa\x7d\xae\xb9\xae\xfe\x21\x69\x1d\xfb\x34\x9a\x41\x40\x7c\xfc\x00\x2c\x37\x93\x15\xb2\xe9\x4a\x79\x55\x09\xcd\x2c\x61\x65\xdb\xb9\xb4\xa9\xed\x31\x5f\x0d\x26\xa9\x56\x9d\xf5\x76\x26\x31\xab\x86\x4a\xb3\x32\x17\xdb\x55\x51\x55\xb5\x50\xcc\x1b\x3a\x4e\xbf\xf5\xae\xfd\xf2\x4d\x3a\x3e\x0e\xb1\x79\xd9\xd7\x34\x9a\xfe\xea\xf9\x7e\x82\x29\xde\xb5\x7b\x79\x4c\x56\x5d\xb6\xf7\x0c\x1d\x4a\xa0\xcb\xf6\x5f\x46\x37\xb9\xff\x72\x5b\x07\x9e\x4d\x77\x5b\x53\xe9\xfc\x01\x86\xce\x07\x71\x02\xc5\xae\x33\x04\x00\x00")
+
+func migrations_gateway17_account_generationSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway17_account_generationSql,
+		"migrations_gateway/17_account_generation.sql",
+	)
+}
+
+func migrations_gateway17_account_generationSql() (*asset, error) {
+	bytes, err := migrations_gateway17_account_generationSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/17_account_generation.sql", size: 1075, mode: os.FileMode(420), modTime: time.Unix(1791972539, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway18_received_payment_disputeSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x85\x8f\xb1\x0e\x82\x30\x14\x45\xf7\x7e\xc5\xdb\xd0\x28\x8b\x09\x13\x53\xb5\x38\x55\x4a\x9a\x76\xb6\x0d\x7d\x68\x13\x41\x52\x2a\xc6\xbf\x17\x63\x62\x1c\x30\xce\xef\x9e\x7b\xcf\x4b\x53\x58\xb5\xfe\x14\x6c\x44\xd0\x3d\xa1\x5c\x15\x12\x14\xdd\xf2\x02\x8c\xc4\x1a\xfd\x88\xae\xb2\x8f\x16\xbb\x68\x80\x32\x06\x3b\xc1\xf5\xa1\x04\xe3\xfc\xd0\xdf\x22\xba\xa3\x9d\x0e\x6e\xc2\xa3\x6f\x11\x58\xb1\xa7\x9a\x2b\x28\x35\xe7\x6b\x02\xf3\x44\xe3\xf1\xe2\x06\x03\xa3\x0d\xf5\xd9\x86\xc5\x26\xcb\x96\x50\x8a\x37\xf5\xa9\x48\x92\x9c\x90\xf4\x4b\x8f\x5d\xef\xdd\x1f\x41\x26\x45\xf5\x73\xef\xe5\x33\x1f\x98\x5e\xc8\xc9\x13\xf5\xf3\x9d\x01\x0a\x01\x00\x00")
+
+func migrations_gateway18_received_payment_disputeSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway18_received_payment_disputeSql,
+		"migrations_gateway/18_received_payment_dispute.sql",
+	)
+}
+
+func migrations_gateway18_received_payment_disputeSql() (*asset, error) {
+	bytes, err := migrations_gateway18_received_payment_disputeSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/18_received_payment_dispute.sql", size: 266, mode: os.FileMode(420), modTime: time.Unix(1791973860, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway19_queued_paymentSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8d\x92\x5d\x6f\x82\x30\x18\x85\xef\xfb\x2b\xde\x3b\x21\xd3\x44\x93\x65\x59\x62\xbc\x40\xe9\x36\x32\x44\x65\xe5\xc2\x2b\x68\xb4\xdb\x48\xa0\x65\xe5\x65\x1f\xff\x7e\x50\x65\x8a\xba\x65\x77\xfd\x78\xce\x69\x4f\xce\x3b\x18\xc0\x55\x9e\xbe\x68\x8e\x02\xa2\x82\xcc\x42\xea\x30\x0a\xcc\x99\xfa\x14\x92\x55\x25\x2a\xb1\x5d\xf2\xaf\x5c\x48\x4c\xc0\x22\x00\x49\xba\x4d\x20\x95\x68\x8d\x46\x36\x04\x0b\x06\x41\xe4\xfb\xe0\x44\x6c\x11\x7b\x41\xad\x9e\xd3\x80\xf5\x1b\xae\xd8\xa9\xe2\x86\x7f\xe7\x7a\xf3\xca\xb5\x75\x73\x7d\xd0\x18\xa8\x44\x8e\x55\x79\x00\x46\xc3\x13\xa0\x76\xc9\x14\xaf\x2d\x50\x7c\x62\xf7\x4a\xab\x4c\xfc\xa1\xdc\x28\xad\x45\xc6\x31\x55\xf2\xd7\x3f\x80\x4b\xef\x9c\xc8\x67\xd0\xeb\x19\x0d\x47\x14\x79\x81\xe5\x85\x84\x2d\x39\x34\x60\xc6\x4b\x8c\x85\xd6\x4a\xff\xcf\x58\x8b\xb2\x50\xb2\x14\x71\x1b\xb8\xf5\x6f\xb9\x43\xaa\x3d\xb9\x4f\x7c\x76\xff\x66\x2a\x89\x79\x5d\xc7\xb6\xee\x0c\xd3\x5c\x74\x73\x9b\xaf\xed\x83\x74\xb1\x33\xaf\x52\x20\x66\xa7\x66\xa7\xd4\x32\xf4\xe6\x4e\xb8\x86\x47\xba\x06\xab\x69\xdf\x6e\x4e\xa3\xc0\x5b\x45\xd4\x1c\x76\x9a\xb6\x8e\x77\x86\x34\x48\x1b\xdb\x6a\x57\x7d\x33\x48\x36\xb1\x81\x06\xf7\x5e\x40\x27\x9e\x94\xca\x9d\xfe\xbc\x3e\x7b\x70\xc2\x27\xca\x26\x15\x3e\xdf\x8e\x09\x19\x1c\x4d\xa9\xab\x3e\x24\x71\xc3\xc5\xf2\xf2\x94\x8e\xc9\x37\x9e\x1c\x73\x5b\xd3\x02\x00\x00")
+
+func migrations_gateway19_queued_paymentSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway19_queued_paymentSql,
+		"migrations_gateway/19_queued_payment.sql",
+	)
+}
+
+func migrations_gateway19_queued_paymentSql() (*asset, error) {
+	bytes, err := migrations_gateway19_queued_paymentSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/19_queued_payment.sql", size: 723, mode: os.FileMode(420), modTime: time.Unix(1791974593, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway20_payment_callbackSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x92\x4d\x6f\x82\x40\x10\x86\xef\xfb\x2b\xe6\x26\xa4\x9a\x68\xd3\x34\x4d\x1a\x0f\x28\xdb\x96\x14\xd1\x50\x38\x78\x62\x57\xd8\x5a\x52\x58\xcc\xee\x60\xdb\x7f\x5f\x50\xf1\x03\x3f\x6e\xb3\x9b\x67\xe6\x7d\xe7\xa3\xd7\x83\xbb\x3c\x5d\x2a\x8e\x02\xc2\x15\x19\xfb\xd4\x0a\x28\x04\xd6\xc8\xa5\xc0\x66\xfc\x2f\x17\x12\xc7\x3c\xcb\x16\x3c\xfe\x66\x60\x10\x00\x96\x26\x0c\x52\x89\xc6\x60\x60\x82\x37\x0d\xc0\x0b\x5d\x17\xac\x30\x98\x46\x8e\x57\xe5\x4f\xa8\x17\x74\x6b\x0e\x15\x97\x9a\xc7\x98\x16\x32\xaa\x73\xd6\x5c\xc5\x5f\x5c\x19\x8f\x0f\x87\xbc\x0d\xb8\xda\xca\x5c\x85\xc0\xa6\x2f\x56\xe8\x06\xd0\xe9\x6c\xf8\x52\x65\x0c\x50\xfc\xe2\x69\x19\x8d\x1c\x4b\x7d\x28\x31\xe8\xb7\x74\x8e\x0d\xdd\x82\xdb\x7a\x99\x48\x96\x42\x31\x58\xa4\xcb\xba\xef\xfb\x0a\x6d\x88\x7d\x6d\x25\x74\x99\x61\x14\x17\x89\xd0\x3b\x73\x67\x0c\x47\x14\xf9\x0a\xf5\x85\xf1\x35\x6c\x7f\x2b\xc8\x35\x46\x42\xa9\x42\x5d\xea\x33\x56\xa2\xda\x56\x12\x71\x64\x90\x54\x11\xa6\xb9\x38\x25\x64\x95\x13\xed\xd4\x6e\x60\x89\xc8\xd2\xb5\x50\xed\x52\x6d\xdf\x33\xdf\x99\x58\xfe\x1c\xde\xe9\x1c\x8c\x7a\xfb\x66\xfd\x5b\xbf\xce\x56\x6c\xb4\x7f\x0e\x68\x33\x70\xa3\x89\xba\xe7\x3e\x4d\x62\x02\xf5\x5e\x1d\x8f\x0e\x1d\x29\x0b\x7b\xb4\xf7\x32\x7e\xb3\xfc\x0f\x1a\x0c\x4b\xfc\x7c\x7a\x26\xa4\x77\x74\xb5\x76\xf1\x23\x89\xed\x4f\x67\xd7\xae\xf6\x99\xfc\x03\x74\xfd\x61\xde\xe5\x02\x00\x00")
+
+func migrations_gateway20_payment_callbackSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway20_payment_callbackSql,
+		"migrations_gateway/20_payment_callback.sql",
+	)
+}
+
+func migrations_gateway20_payment_callbackSql() (*asset, error) {
+	bytes, err := migrations_gateway20_payment_callbackSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/20_payment_callback.sql", size: 741, mode: os.FileMode(420), modTime: time.Unix(1791977579, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_compliance01_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x94\x4d\x6f\xda\x30\x18\xc7\xef\xf9\x14\xcf\x31\x68\x70\x60\x1a\x68\x12\xe2\x10\x88\xd9\xa2\x85\xc0\x32\xfb\xc0\xc9\xf6\x88\x33\xac\x11\x07\x39\x4e\xa1\xfd\xf4\x55\x68\x9b\x37\xde\x8a\xda\x5b\xe4\xfc\xff\xf6\xef\xf9\xc9\x72\xaf\x07\x5f\x12\xf9\x4f\x73\x23\x80\xec\xac\x69\x88\x1c\x8c\x00\x3b\x13\x1f\x01\x73\x72\xb3\x49\xb5\x7c\x12\x11\xd6\x5c\x65\x7c\x6d\x64\xaa\x18\xd8\x16\x00\x93\x11\x03\xa9\x8c\xdd\xef\x77\x20\x58\x60\x08\x88\xef\x83\x43\xf0\x82\x7a\xc1\x34\x44\x73\x14\xe0\x6e\x91\x33\x55\x93\x16\x9d\xf5\x86\x6b\x7b\xf8\xad\x2a\x1d\x53\x89\x48\x52\x06\x0f\x5c\x9f\xff\x5d\xdf\xe4\x10\x69\x06\x46\x1c\x4c\x33\xc2\x4b\x56\xca\x0d\x83\x88\x1b\x61\x64\x22\x9a\xa1\x88\x1b\x7e\xa6\xbc\x0c\xbd\xb9\x13\xae\xe0\x17\x5a\x81\x5d\x4c\xd6\xb1\x3a\x80\x82\x1f\x5e\x80\xc6\x9e\x52\xa9\x3b\x01\x17\xcd\x1c\xe2\x63\x98\xfe\x74\xc2\x3f\x08\x8f\x73\x13\x7f\x1f\x59\x6d\x5f\xdb\x6d\xba\x17\xd1\xcc\xbb\xd3\x91\xe2\x89\xa8\xa6\xff\x3a\x18\xb4\xc6\x8f\xd2\x84\x4b\x75\x2d\xb1\xcb\xff\x6e\xe5\x9a\xfe\x17\x8f\xaf\x86\x07\xc3\x56\x82\xbf\xb0\x5d\x96\x73\x2a\xa1\x58\x25\x81\xf7\x9b\xa0\xa3\x99\x12\xc3\x7e\xfb\x3a\x49\xd4\x31\xec\x3a\xd4\xc7\x84\x92\x4c\xe8\x3b\x95\xc6\x92\xde\xb2\x1a\x4b\x7a\x5b\x6c\x2c\xe9\x6d\xb7\x79\x26\xf4\xf1\x72\x5f\xde\xe7\x13\xf4\x37\x50\x68\x79\xa6\xdd\x62\xec\x56\x3c\xef\xb6\x5e\x7f\x05\xdc\x74\xaf\x2c\x37\x5c\x2c\xaf\xbf\x02\xa3\x66\xa6\xbc\xf9\x67\xd7\x49\x26\x34\x1b\x59\xcf\x03\x00\xb0\xd9\x8a\xda\x6d\x04\x00\x00")
 
 func migrations_compliance01_initSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -161,19 +560,40 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"migrations_gateway/01_init.sql":    migrations_gateway01_initSql,
-	"migrations_compliance/01_init.sql": migrations_compliance01_initSql,
+	"migrations_gateway/01_init.sql":             migrations_gateway01_initSql,
+	"migrations_gateway/02_daily_volume.sql":     migrations_gateway02_daily_volumeSql,
+	"migrations_gateway/03_backfill.sql":         migrations_gateway03_backfillSql,
+	"migrations_gateway/04_payment_requests.sql": migrations_gateway04_payment_requestsSql,
+	"migrations_gateway/05_leader_lease.sql":     migrations_gateway05_leader_leaseSql,
+	"migrations_gateway/06_listener_cursor.sql":  migrations_gateway06_listener_cursorSql,
+	"migrations_gateway/07_correlation_id.sql":   migrations_gateway07_correlation_idSql,
+	"migrations_gateway/08_payment_payload.sql":  migrations_gateway08_payment_payloadSql,
+	"migrations_gateway/09_retired_account.sql":  migrations_gateway09_retired_accountSql,
+	"migrations_gateway/10_conversion.sql": migrations_gateway10_conversionSql,
+	"migrations_gateway/11_idempotent_payment.sql": migrations_gateway11_idempotent_paymentSql,
+	"migrations_gateway/12_counterparty_stats.sql": migrations_gateway12_counterparty_statsSql,
+	"migrations_gateway/13_reconciliation.sql": migrations_gateway13_reconciliationSql,
+	"migrations_gateway/14_event.sql": migrations_gateway14_eventSql,
+	"migrations_gateway/15_internal_transfer.sql": migrations_gateway15_internal_transferSql,
+	"migrations_gateway/16_leader_handoff.sql": migrations_gateway16_leader_handoffSql,
+	"migrations_gateway/17_account_generation.sql": migrations_gateway17_account_generationSql,
+	"migrations_gateway/18_received_payment_dispute.sql": migrations_gateway18_received_payment_disputeSql,
+	"migrations_gateway/19_queued_payment.sql": migrations_gateway19_queued_paymentSql,
+	"migrations_gateway/20_payment_callback.sql": migrations_gateway20_payment_callbackSql,
+	"migrations_compliance/01_init.sql":          migrations_compliance01_initSql,
 }
 
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"}
 // AssetDir("data/img") would return []string{"a.png", "b.png"}
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error
@@ -210,7 +630,26 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"01_init.sql": &bintree{migrations_compliance01_initSql, map[string]*bintree{}},
 	}},
 	"migrations_gateway": &bintree{nil, map[string]*bintree{
-		"01_init.sql": &bintree{migrations_gateway01_initSql, map[string]*bintree{}},
+		"01_init.sql":             &bintree{migrations_gateway01_initSql, map[string]*bintree{}},
+		"02_daily_volume.sql":     &bintree{migrations_gateway02_daily_volumeSql, map[string]*bintree{}},
+		"03_backfill.sql":         &bintree{migrations_gateway03_backfillSql, map[string]*bintree{}},
+		"04_payment_requests.sql": &bintree{migrations_gateway04_payment_requestsSql, map[string]*bintree{}},
+		"05_leader_lease.sql":     &bintree{migrations_gateway05_leader_leaseSql, map[string]*bintree{}},
+		"06_listener_cursor.sql":  &bintree{migrations_gateway06_listener_cursorSql, map[string]*bintree{}},
+		"07_correlation_id.sql":   &bintree{migrations_gateway07_correlation_idSql, map[string]*bintree{}},
+		"08_payment_payload.sql":  &bintree{migrations_gateway08_payment_payloadSql, map[string]*bintree{}},
+		"09_retired_account.sql":  &bintree{migrations_gateway09_retired_accountSql, map[string]*bintree{}},
+		"10_conversion.sql": &bintree{migrations_gateway10_conversionSql, map[string]*bintree{}},
+		"11_idempotent_payment.sql": &bintree{migrations_gateway11_idempotent_paymentSql, map[string]*bintree{}},
+		"12_counterparty_stats.sql": &bintree{migrations_gateway12_counterparty_statsSql, map[string]*bintree{}},
+		"13_reconciliation.sql": &bintree{migrations_gateway13_reconciliationSql, map[string]*bintree{}},
+		"14_event.sql": &bintree{migrations_gateway14_eventSql, map[string]*bintree{}},
+		"15_internal_transfer.sql": &bintree{migrations_gateway15_internal_transferSql, map[string]*bintree{}},
+		"16_leader_handoff.sql": &bintree{migrations_gateway16_leader_handoffSql, map[string]*bintree{}},
+		"17_account_generation.sql": &bintree{migrations_gateway17_account_generationSql, map[string]*bintree{}},
+		"18_received_payment_dispute.sql": &bintree{migrations_gateway18_received_payment_disputeSql, map[string]*bintree{}},
+		"19_queued_payment.sql": &bintree{migrations_gateway19_queued_paymentSql, map[string]*bintree{}},
+		"20_payment_callback.sql": &bintree{migrations_gateway20_payment_callbackSql, map[string]*bintree{}},
 	}},
 }}
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	// To load mysql driver
 	_ "github.com/go-sql-driver/mysql"
@@ -74,6 +75,32 @@ func (d *Driver) Insert(object entities.Entity) (id int64, err error) {
 		result, err = d.database.NamedExec(query, object)
 	case *entities.ReceivedPayment:
 		result, err = d.database.NamedExec(query, object)
+	case *entities.DailyVolume:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.BackfillCursor:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.ListenerCursor:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.RetiredAccount:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.Conversion:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.IdempotentPayment:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.CounterpartyStats:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.Reconciliation:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.Event:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.PaymentRequest:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.AccountGeneration:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.QueuedPayment:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.PaymentCallback:
+		result, err = d.database.NamedExec(query, object)
 	}
 
 	if err != nil {
@@ -132,6 +159,32 @@ func (d *Driver) Update(object entities.Entity) (err error) {
 		_, err = d.database.NamedExec(query, object)
 	case *entities.ReceivedPayment:
 		_, err = d.database.NamedExec(query, object)
+	case *entities.DailyVolume:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.BackfillCursor:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.ListenerCursor:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.RetiredAccount:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Conversion:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.IdempotentPayment:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.CounterpartyStats:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Reconciliation:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Event:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.PaymentRequest:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.AccountGeneration:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.QueuedPayment:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.PaymentCallback:
+		_, err = d.database.NamedExec(query, object)
 	}
 
 	return
@@ -169,6 +222,97 @@ func (d *Driver) GetOne(object entities.Entity, where string, params ...interfac
 	return object, err
 }
 
+// AcquireLease acquires or renews a lease, the lease is not updated when it's held by another
+// holder and has not expired. `holder` is assigned first so `expires_at` is updated only when
+// the lease was acquired.
+func (d *Driver) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	_, err := d.database.Exec(
+		`INSERT INTO LeaderLease (name, holder, expires_at) VALUES (?, ?, NOW() + INTERVAL ? SECOND)
+		ON DUPLICATE KEY UPDATE
+		holder = IF(holder = VALUES(holder) OR expires_at < NOW(), VALUES(holder), holder),
+		expires_at = IF(holder = VALUES(holder), VALUES(expires_at), expires_at);`,
+		name,
+		holder,
+		int64(ttl/time.Second),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	var current string
+	err = d.database.Get(&current, "SELECT holder FROM LeaderLease WHERE name = ?;", name)
+	if err != nil {
+		return false, err
+	}
+	return current == holder, nil
+}
+
+// TransferLease gives a lease held by `from` to `to`, the update is skipped when the lease has
+// another holder
+func (d *Driver) TransferLease(name, from, to string, ttl time.Duration) (bool, error) {
+	result, err := d.database.Exec(
+		"UPDATE LeaderLease SET holder = ?, expires_at = NOW() + INTERVAL ? SECOND WHERE name = ? AND holder = ?;",
+		to,
+		int64(ttl/time.Second),
+		name,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// TakeOverLease gives a lease to holder regardless of its current holder
+func (d *Driver) TakeOverLease(name, holder string, ttl time.Duration) error {
+	_, err := d.database.Exec(
+		`INSERT INTO LeaderLease (name, holder, expires_at) VALUES (?, ?, NOW() + INTERVAL ? SECOND)
+		ON DUPLICATE KEY UPDATE holder = VALUES(holder), expires_at = VALUES(expires_at);`,
+		name,
+		holder,
+		int64(ttl/time.Second),
+	)
+	return err
+}
+
+// RequestHandoff replaces a handoff request of a lease
+func (d *Driver) RequestHandoff(name, holder, version string) error {
+	_, err := d.database.Exec(
+		`INSERT INTO LeaderHandoff (name, holder, version, status) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE holder = VALUES(holder), version = VALUES(version), status = VALUES(status);`,
+		name,
+		holder,
+		version,
+		entities.LeaderHandoffStatusRequested,
+	)
+	return err
+}
+
+// GetHandoff returns a handoff request of a lease, nil when there is none
+func (d *Driver) GetHandoff(name string) (*entities.LeaderHandoff, error) {
+	handoff := &entities.LeaderHandoff{}
+	err := d.database.Get(handoff, "SELECT * FROM LeaderHandoff WHERE name = ?;", name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return handoff, nil
+}
+
+// SetHandoffStatus updates a status of a handoff request of holder
+func (d *Driver) SetHandoffStatus(name, holder, status string) error {
+	_, err := d.database.Exec(
+		"UPDATE LeaderHandoff SET status = ? WHERE name = ? AND holder = ?;",
+		status,
+		name,
+		holder,
+	)
+	return err
+}
+
 // GetMany returns many entities
 func (d *Driver) GetMany(slice interface{}, where, order, offset, limit *string, params ...interface{}) (err error) {
 	_, tableName, err := getTypeData(slice)
@@ -211,6 +355,13 @@ func (d *Driver) GetMany(slice interface{}, where, order, offset, limit *string,
 			tmp[i].SetExists()
 		}
 		slice = &tmp
+	case *[]*entities.DailyVolume:
+		err = d.database.Select(slice, query.String(), params...)
+		tmp := *slice
+		for i := range tmp {
+			tmp[i].SetExists()
+		}
+		slice = &tmp
 	}
 
 	if err != nil && err.Error() == "sql: no rows in result set" {
@@ -237,10 +388,51 @@ func getTypeData(object interface{}) (typeValue reflect.Type, tableName string,
 	case *entities.ReceivedPayment:
 		typeValue = reflect.TypeOf(*object)
 		tableName = "ReceivedPayment"
+	case *entities.DailyVolume:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "DailyVolume"
+	case *entities.BackfillCursor:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "BackfillCursor"
+	case *entities.ListenerCursor:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "ListenerCursor"
+	case *entities.RetiredAccount:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "RetiredAccount"
+	case *entities.Conversion:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Conversion"
+	case *entities.IdempotentPayment:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "IdempotentPayment"
+	case *entities.CounterpartyStats:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "CounterpartyStats"
+	case *entities.Reconciliation:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Reconciliation"
+	case *entities.Event:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Event"
+	case *entities.PaymentRequest:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "PaymentRequest"
+	case *entities.AccountGeneration:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "AccountGeneration"
+	case *entities.QueuedPayment:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "QueuedPayment"
+	case *entities.PaymentCallback:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "PaymentCallback"
 	case *[]*entities.SentTransaction:
 		tableName = "SentTransaction"
 	case *[]*entities.ReceivedPayment:
 		tableName = "ReceivedPayment"
+	case *[]*entities.DailyVolume:
+		tableName = "DailyVolume"
 	default:
 		return typeValue, tableName, fmt.Errorf("Unknown entity type: %T", object)
 	}
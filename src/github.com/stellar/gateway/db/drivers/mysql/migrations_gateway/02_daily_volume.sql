@@ -0,0 +1,25 @@
+-- +migrate Up
+ALTER TABLE `ReceivedPayment`
+  ADD COLUMN `asset_code` varchar(12) NOT NULL DEFAULT '',
+  ADD COLUMN `asset_issuer` varchar(56) NOT NULL DEFAULT '',
+  ADD COLUMN `amount` varchar(255) NOT NULL DEFAULT '';
+
+CREATE TABLE `DailyVolume` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `date` varchar(10) NOT NULL,
+  `asset_code` varchar(12) NOT NULL,
+  `asset_issuer` varchar(56) NOT NULL,
+  `direction` varchar(10) NOT NULL,
+  `count` bigint(20) NOT NULL,
+  `sum` bigint(20) NOT NULL,
+  `fees` bigint(20) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `date_asset_direction` (`date`, `asset_code`, `asset_issuer`, `direction`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `DailyVolume`;
+ALTER TABLE `ReceivedPayment`
+  DROP COLUMN `asset_code`,
+  DROP COLUMN `asset_issuer`,
+  DROP COLUMN `amount`;
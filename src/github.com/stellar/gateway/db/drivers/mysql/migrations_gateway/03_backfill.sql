@@ -0,0 +1,15 @@
+-- +migrate Up
+ALTER TABLE `ReceivedPayment` ADD COLUMN `backfill` tinyint(1) NOT NULL DEFAULT 0;
+
+CREATE TABLE `BackfillCursor` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` varchar(56) NOT NULL,
+  `paging_token` varchar(255) NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `account_id` (`account_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `BackfillCursor`;
+ALTER TABLE `ReceivedPayment` DROP COLUMN `backfill`;
@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE `PaymentRequest` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `request_id` varchar(64) NOT NULL,
+  `destination` varchar(56) NOT NULL,
+  `asset_code` varchar(12) NOT NULL,
+  `asset_issuer` varchar(56) NOT NULL,
+  `amount` varchar(255) NOT NULL,
+  `memo_type` varchar(10) NOT NULL,
+  `memo` varchar(255) NOT NULL,
+  `status` varchar(10) NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime DEFAULT NULL,
+  `fulfilled_at` datetime DEFAULT NULL,
+  `operation_id` varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `request_id` (`request_id`),
+  KEY `memo` (`memo_type`, `memo`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `PaymentRequest`;
@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE `LeaderLease` (
+  `name` varchar(64) NOT NULL,
+  `holder` varchar(255) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `LeaderLease`;
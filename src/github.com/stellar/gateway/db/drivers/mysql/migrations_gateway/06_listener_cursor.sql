@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE `ListenerCursor` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `paging_token` varchar(255) NOT NULL,
+  `after_payment_id` int(11) NOT NULL,
+  `set_at` datetime NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `ListenerCursor`;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE `SentTransaction` ADD COLUMN `correlation_id` varchar(128) NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE `SentTransaction` DROP COLUMN `correlation_id`;
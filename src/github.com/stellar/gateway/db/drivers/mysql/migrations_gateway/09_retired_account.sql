@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE `RetiredAccount` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` varchar(56) NOT NULL,
+  `merged_into` varchar(56) NOT NULL,
+  `operation_id` varchar(255) NOT NULL,
+  `transaction_hash` varchar(64) NOT NULL DEFAULT '',
+  `retired_at` datetime NOT NULL,
+  `reregistered_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `account_id` (`account_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `RetiredAccount`;
@@ -0,0 +1,23 @@
+-- +migrate Up
+CREATE TABLE `Conversion` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `operation_id` varchar(255) NOT NULL,
+  `status` varchar(10) NOT NULL,
+  `error_message` text NOT NULL,
+  `send_asset_code` varchar(12) NOT NULL,
+  `send_asset_issuer` varchar(56) NOT NULL DEFAULT '',
+  `send_max` varchar(50) NOT NULL,
+  `send_amount` varchar(50) NOT NULL DEFAULT '',
+  `destination_asset_code` varchar(12) NOT NULL,
+  `destination_asset_issuer` varchar(56) NOT NULL DEFAULT '',
+  `destination_amount` varchar(50) NOT NULL DEFAULT '',
+  `estimated_price` varchar(50) NOT NULL DEFAULT '',
+  `transaction_hash` varchar(64) NOT NULL DEFAULT '',
+  `created_at` datetime NOT NULL,
+  `converted_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `operation_id` (`operation_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `Conversion`;
@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE `IdempotentPayment` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `payment_id` varchar(64) NOT NULL,
+  `request_hash` varchar(64) NOT NULL,
+  `transaction_id` varchar(64) NOT NULL,
+  `response_status` int(11) DEFAULT NULL,
+  `response` text DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  `completed_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `payment_id` (`payment_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `IdempotentPayment`;
@@ -0,0 +1,20 @@
+-- +migrate Up
+CREATE TABLE `CounterpartyStats` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `destination` varchar(56) NOT NULL,
+  `asset_code` varchar(12) NOT NULL,
+  `asset_issuer` varchar(56) NOT NULL DEFAULT '',
+  `payment_count` bigint NOT NULL DEFAULT 0,
+  `mean_amount` double NOT NULL DEFAULT 0,
+  `amount_m2` double NOT NULL DEFAULT 0,
+  `amount_histogram` text NOT NULL,
+  `hour_histogram` text NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `destination_asset` (`destination`, `asset_code`, `asset_issuer`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+ALTER TABLE `SentTransaction` ADD COLUMN `anomalies` text DEFAULT NULL;
+
+-- +migrate Down
+ALTER TABLE `SentTransaction` DROP COLUMN `anomalies`;
+DROP TABLE `CounterpartyStats`;
@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE `Reconciliation` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `date` varchar(10) NOT NULL,
+  `mismatches` int(11) NOT NULL,
+  `report` longtext NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `date` (`date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `Reconciliation`;
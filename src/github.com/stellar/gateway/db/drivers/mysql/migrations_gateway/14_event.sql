@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE `Event` (
+  `id` bigint(20) NOT NULL AUTO_INCREMENT,
+  `type` varchar(64) NOT NULL,
+  `subject` varchar(255) NOT NULL,
+  `version` int(11) NOT NULL,
+  `payload` longtext NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `Event`;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE `SentTransaction` ADD COLUMN `internal` tinyint(1) NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE `SentTransaction` DROP COLUMN `internal`;
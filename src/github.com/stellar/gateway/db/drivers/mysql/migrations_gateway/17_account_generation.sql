@@ -0,0 +1,24 @@
+-- +migrate Up
+CREATE TABLE `AccountGeneration` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `account_id` varchar(56) NOT NULL,
+  `generation` bigint NOT NULL DEFAULT 1,
+  `sequence` bigint NOT NULL DEFAULT 0,
+  `merged_at` datetime DEFAULT NULL,
+  `recreated_at` datetime DEFAULT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `account_id` (`account_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+ALTER TABLE `PaymentRequest` ADD COLUMN `generation` bigint NOT NULL DEFAULT 1;
+ALTER TABLE `CounterpartyStats` ADD COLUMN `generation` bigint NOT NULL DEFAULT 1,
+  DROP INDEX `destination_asset`,
+  ADD UNIQUE KEY `destination_generation_asset` (`destination`, `generation`, `asset_code`, `asset_issuer`);
+
+-- +migrate Down
+DELETE FROM `CounterpartyStats` WHERE `generation` > 1;
+ALTER TABLE `CounterpartyStats` DROP INDEX `destination_generation_asset`,
+  ADD UNIQUE KEY `destination_asset` (`destination`, `asset_code`, `asset_issuer`),
+  DROP COLUMN `generation`;
+ALTER TABLE `PaymentRequest` DROP COLUMN `generation`;
+DROP TABLE `AccountGeneration`;
@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE `QueuedPayment` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `payment_id` varchar(64) NOT NULL,
+  `status` varchar(10) NOT NULL,
+  `payload` text NOT NULL,
+  `role` varchar(10) NOT NULL,
+  `correlation_id` varchar(64) NOT NULL DEFAULT '',
+  `attempts` int(11) NOT NULL DEFAULT 0,
+  `last_error` varchar(64) NOT NULL DEFAULT '',
+  `response_status` int(11) DEFAULT NULL,
+  `response` text DEFAULT NULL,
+  `queued_at` datetime NOT NULL,
+  `last_attempt_at` datetime DEFAULT NULL,
+  `settled_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `payment_id` (`payment_id`),
+  KEY `status` (`status`, `id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `QueuedPayment`;
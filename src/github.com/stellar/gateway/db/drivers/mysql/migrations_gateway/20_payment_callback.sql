@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE `PaymentCallback` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `transaction_id` varchar(64) NOT NULL,
+  `payment_id` varchar(64) NOT NULL DEFAULT '',
+  `url` text NOT NULL,
+  `status` varchar(10) NOT NULL,
+  `transaction_status` varchar(10) NOT NULL DEFAULT '',
+  `ledger` bigint(20) DEFAULT NULL,
+  `result_codes` text DEFAULT NULL,
+  `attempts` int(11) NOT NULL DEFAULT 0,
+  `last_error` text NOT NULL,
+  `created_at` datetime NOT NULL,
+  `next_attempt_at` datetime NOT NULL,
+  `delivered_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `transaction_id` (`transaction_id`),
+  KEY `status` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +migrate Down
+DROP TABLE `PaymentCallback`;
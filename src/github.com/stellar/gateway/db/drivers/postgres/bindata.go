@@ -1,7 +1,28 @@
 // Code generated by go-bindata.
 // sources:
 // migrations_gateway/01_init.sql
+// migrations_gateway/02_daily_volume.sql
+// migrations_gateway/03_utc_timestamps.sql
+// migrations_gateway/04_backfill.sql
+// migrations_gateway/05_payment_requests.sql
+// migrations_gateway/06_leader_lease.sql
+// migrations_gateway/07_listener_cursor.sql
+// migrations_gateway/08_correlation_id.sql
+// migrations_gateway/09_payment_payload.sql
+// migrations_gateway/10_retired_account.sql
+// migrations_gateway/11_conversion.sql
+// migrations_gateway/12_idempotent_payment.sql
+// migrations_gateway/13_counterparty_stats.sql
+// migrations_gateway/14_reconciliation.sql
+// migrations_gateway/15_event.sql
+// migrations_gateway/16_internal_transfer.sql
+// migrations_gateway/17_leader_handoff.sql
+// migrations_gateway/18_account_generation.sql
+// migrations_gateway/19_received_payment_dispute.sql
+// migrations_gateway/20_queued_payment.sql
+// migrations_gateway/21_payment_callback.sql
 // migrations_compliance/01_init.sql
+// migrations_compliance/02_utc_timestamps.sql
 // DO NOT EDIT!
 
 package postgres
@@ -69,7 +90,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _migrations_gateway01_initSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x7c\x92\x41\x4f\xc3\x30\x0c\x85\xef\xf9\x15\x3e\x6e\x82\x49\x80\xd8\x2e\x3b\x15\x16\xa4\x89\xb2\x8d\xd2\x1e\x76\xaa\xb2\xc4\x2a\x11\x6d\x52\x25\xee\x18\xff\x9e\x0c\x09\xd6\x86\xc1\x31\xfa\x5e\x6c\x3f\x3f\x4f\x26\x70\xd1\xe8\xca\x09\x42\x28\x5a\x76\x9f\xf1\x24\xe7\x90\x27\x77\x29\x87\x0c\x25\xea\x3d\xaa\x8d\xf8\x68\xd0\x10\x8c\x18\x80\x56\xb0\xd3\x95\x47\xa7\x45\x7d\x19\xde\xb6\xc5\xf0\x57\x5b\x53\x06\xb2\x17\x4e\xbe\x0a\x37\xba\x99\x4e\xc7\x50\xac\x96\xcf\x05\x87\xd5\x3a\x87\x55\x91\xa6\x47\x71\xeb\xac\x44\xef\x51\x95\x82\x80\x74\x83\x9e\x44\xd3\x0e\x25\xa2\xd2\xa6\x2a\xc9\xbe\xa1\x19\xd6\xeb\xab\xc2\x3f\xea\xfc\xdf\x7c\x93\x2d\x9f\x92\x6c\x0b\x8f\x7c\x0b\x23\xad\xc6\x6c\x3c\x67\x43\x6f\x2f\xc1\x50\xee\x84\xf1\x42\x1e\xa7\xff\xf6\x76\x32\x46\x27\xd8\xb7\x36\xbb\xed\x75\x82\xdf\xa3\x5c\x5f\x45\x93\xda\xce\x49\xfc\xc1\xd3\x59\x84\xbb\x5d\xa3\x89\xfe\xdb\x88\xef\xa4\x44\x54\xb1\x64\xc1\x1f\x92\x22\x3d\xc9\x6a\x54\x15\xba\x63\x38\x3a\x44\x15\x53\x34\x7b\xac\x43\x56\xe5\x41\x39\x20\x3c\xd0\xa0\x85\x43\xdf\xd5\xf4\xc5\x06\x2b\x8d\xab\x9c\x5d\x6b\xff\x82\x16\xf6\xdd\xb0\x45\xb6\xde\x9c\xbf\xa0\x79\x9f\x45\x09\xcc\xd9\x67\x00\x00\x00\xff\xff\x4d\x61\x55\x6b\x8b\x02\x00\x00")
+var _migrations_gateway01_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x92\xcf\x4f\xfa\x40\x10\xc5\xef\xfb\x57\xcc\x11\xf2\xfd\x92\xa8\x11\x2e\x9c\xaa\xac\x09\xb1\x02\xd6\xf6\xc0\xa9\x59\x76\x27\x75\x62\xbb\xdb\xec\x4e\x11\xff\x7b\x03\x09\xf6\x07\xe8\xf9\xf3\x32\xf3\xde\xbc\x99\x4c\xe0\x5f\x45\x85\x57\x8c\x90\xd5\xe2\x31\x91\x51\x2a\x21\x8d\x1e\x62\x09\x09\x6a\xa4\x3d\x9a\x8d\xfa\xaa\xd0\x32\x8c\x04\x00\x19\xd8\x51\x11\xd0\x93\x2a\xff\x0b\x00\x57\xa3\x57\x4c\xce\xe6\x64\x60\xaf\xbc\x7e\x57\x7e\x74\x37\x9d\x8e\x21\x5b\x2d\x5f\x33\x09\xab\x75\x0a\xab\x2c\x8e\x8f\xe2\xda\x3b\x8d\x21\xa0\xc9\x15\x03\x53\x85\x81\x55\x55\xf7\x25\xaa\x20\x5b\xe4\xec\x3e\xd0\xf6\xe7\x75\x55\x81\x15\x37\xe1\x77\xbe\x49\x96\x2f\x51\xb2\x85\x67\xb9\x85\x11\x99\xb1\x18\xcf\x45\x3f\xdb\x1b\x5a\x4e\xbd\xb2\x41\xe9\xa3\xfb\x73\xb6\x36\x18\xb7\xb0\x1b\x6d\x76\xdf\xd9\x04\x97\x56\x6e\x6f\xfa\x4e\x82\x6b\xbc\xc6\x1f\x3c\x9d\x0d\x70\xb3\xab\x88\xf9\xaf\x8b\x84\x46\x6b\x44\x33\x94\x2c\xe4\x53\x94\xc5\xad\xac\x44\x53\xa0\x3f\x96\x43\x96\x2f\x28\xda\x3d\x96\xae\xc6\xfc\x60\x3c\x30\x1e\xb8\xb7\xc2\x63\x68\x4a\x3e\xb1\xb3\xd1\x53\x85\xc3\x29\x57\xcf\xda\xfd\xa0\x85\xfb\xb4\x62\x91\xac\x37\xd7\x3f\x68\xde\x65\x83\x06\xe6\xe2\x7b\x00\x4d\x61\x55\x6b\x8b\x02\x00\x00")
 
 func migrations_gateway01_initSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -89,7 +110,407 @@ func migrations_gateway01_initSql() (*asset, error) {
 	return a, nil
 }
 
-var _migrations_compliance01_initSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x94\x93\xc1\x6e\xf2\x30\x10\x84\xef\x7e\x8a\x3d\x12\xfd\x70\xf9\x55\xb8\x70\x4a\x8b\x2b\x45\xa5\x81\x46\x89\x54\x4e\xd6\x82\x5d\xb0\x1a\xc7\xc8\x38\x05\xfa\xf4\x75\x4a\x21\x71\x21\xa0\x4a\xb9\xc4\x3b\xbb\x3b\xf3\x25\xee\xf5\xe0\x9f\x92\x4b\x83\x56\x40\xb6\x26\x0f\x09\x0d\x53\x0a\x69\x78\x3f\xa6\x10\x96\x76\xa5\x8d\xfc\x14\x3c\x35\x58\x6c\x70\x61\xa5\x2e\xa0\x43\x00\x24\x87\xb9\x5c\x6e\x84\x91\x98\x77\xdd\xbb\xad\xeb\xcc\xd5\x3e\xd0\x2c\x56\x68\x3a\x83\xbb\x00\xe2\x49\x0a\x71\x36\x1e\x57\x32\x25\x94\x6e\x2d\x36\x67\xec\xb8\x01\x2b\x76\xd6\x13\xe0\xc9\x0e\x43\x0b\x56\x2a\xb1\xb1\xa8\xd6\x9e\x86\xa3\xc5\xf3\x4e\xf7\x4c\x93\xe8\x39\x4c\x66\xf0\x44\x67\xd0\x91\x3c\x20\xc1\x90\xfc\x4a\x9b\xe7\x7a\x2b\xf8\x63\x74\x31\x61\x81\x4a\x9c\xac\xff\xef\xf7\x7d\xef\x5c\x2b\x94\x45\x7b\x7d\x5d\xce\x73\xb9\x60\xef\x62\x0f\xdf\x82\xfe\xc0\xaf\xe3\x61\x77\x7b\xae\x33\xfb\x24\x80\x3a\x40\x16\x47\x2f\x19\x85\x28\x1e\xd1\x57\xc0\x37\xc9\xe6\x7b\xf6\x63\x69\x12\x37\x83\x1d\x0e\x5d\xf4\x2b\x8d\x0d\xaf\x7e\x73\x5d\x68\x63\x97\x39\x5e\x17\xe9\xb9\xc9\xd7\x01\x3a\xc1\x2d\x86\x4e\x72\x0b\x63\xe9\x36\x36\xff\xbf\xb3\x19\x7f\xe7\x1c\xb4\x51\x2e\x2b\x56\x9e\x27\x76\x5c\x5f\x63\x3b\x00\xf1\x54\xdd\xa3\xcb\x6a\x32\x69\x5e\xbf\x91\xde\x16\x64\x94\x4c\xa6\xd7\xae\xdf\xd0\x53\x1c\x3f\xce\xa5\xd3\x6a\xf7\x90\x7c\x05\x00\x00\xff\xff\x02\xc5\x23\x8a\xe0\x03\x00\x00")
+var _migrations_gateway02_daily_volumeSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x5f\x6b\xc2\x30\x14\xc5\xdf\xf3\x29\xee\x9b\x2d\x53\x98\x82\x7b\xf1\xa9\xb3\x19\xc8\x6a\x75\xa5\x1d\xf8\x34\x62\x73\xe7\x2e\x98\x64\x24\xa9\xc3\x6f\x3f\xa2\xf3\x1f\x53\x87\xec\xad\xdc\x3f\xbf\x9e\x73\x72\x3b\x1d\xb8\x53\xb4\xb0\xc2\x23\x54\x9f\x2c\xc9\x4a\x5e\x40\x99\x3c\x66\x1c\x0a\xac\x91\x56\x28\xa7\x62\xad\x50\x7b\x48\xd2\x14\x86\x93\xac\x1a\xe7\x20\x9c\x43\xff\x56\x1b\x89\xb0\x12\xb6\xfe\x10\x36\xea\xf6\x62\xc8\x27\x25\xe4\x55\x96\x41\xca\x9f\x92\x2a\x2b\xa1\xd5\x1a\xdc\x86\x24\xe7\x1a\xb4\x7b\x68\xff\xe1\x9f\x50\x65\x1a\xed\xf7\xb8\x5e\xbf\x7f\x81\xc7\x86\x05\x4f\x4a\xfe\x43\x4c\x05\x2d\xd7\xaf\x66\xd9\x28\x84\x88\x01\x90\x84\x39\x2d\x1c\x5a\x12\xcb\x36\x03\x90\x21\xad\x1d\xb4\x7b\x7f\x60\x86\xe6\x1f\xd9\x1c\x46\xae\x78\x0d\x43\x92\x2c\xd6\x9e\x8c\xbe\xf8\xa7\x7a\x63\x6e\x4e\x0b\xd2\xfe\xa4\xe1\x1a\x75\xae\xfc\x8e\xe8\xce\xd5\xa7\xc5\x68\x9c\x14\x33\x78\xe6\x33\x88\x48\xc6\xa1\x56\xe5\xa3\x97\x8a\x43\x14\xac\xb6\x8f\x3c\xed\xbe\xb7\xe2\xdb\x07\x95\x31\x8b\x07\x8c\x1d\x9f\x53\x6a\xbe\x34\x4b\x8b\xc9\xf4\x77\xaa\xd7\x1f\x70\xb3\x73\x72\x16\x21\xcd\x5b\x77\xb6\x0a\x6f\xd8\x52\xa6\xd1\x7e\xc0\xbe\x07\x00\x0e\xed\x57\x80\x12\x03\x00\x00")
+
+func migrations_gateway02_daily_volumeSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway02_daily_volumeSql,
+		"migrations_gateway/02_daily_volume.sql",
+	)
+}
+
+func migrations_gateway02_daily_volumeSql() (*asset, error) {
+	bytes, err := migrations_gateway02_daily_volumeSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/02_daily_volume.sql", size: 786, mode: os.FileMode(420), modTime: time.Unix(1791953484, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway03_utc_timestampsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xc4\x92\x41\x4f\xdb\x40\x14\x84\xef\xf9\x15\x73\x2a\xa0\xca\xe9\xbd\x55\x0f\x81\x5a\x15\x52\x08\x28\xac\x0f\xe5\x42\xd6\xde\xa9\xb3\x52\xfc\xd6\xda\xf7\x9c\x14\x7e\x7d\xe5\xa4\x20\x72\x69\x2b\xb5\x88\xf3\xce\x7c\x6f\x34\xb3\x45\x81\xf7\x5d\x6c\xb3\x37\xa2\xea\x27\x45\x01\x17\x3b\xaa\xf9\xae\x57\x0c\xca\x00\x4b\xa8\x09\xb5\x94\x19\xe0\x15\x9b\xd4\xf8\x0d\x2c\x76\x44\xfa\x0e\x5b\x13\x75\x8e\xa1\x25\x94\x79\xcb\x3c\x85\x5b\xf3\x01\x3e\x13\x4d\x92\x2d\xb3\x31\x60\xd0\x28\xed\x08\x1f\xe5\x7b\xeb\x63\x92\x67\xff\xe1\x7e\x4c\x02\xa5\x6a\x4c\xf2\x11\xf1\x40\x3e\x20\xb1\xf3\x0a\x49\x86\x3c\x88\x44\x69\x11\x05\x95\xbb\x80\xd2\xb0\x1a\x69\x23\x6c\x35\xe2\x7b\x9f\x7d\x47\x63\x7e\x42\x07\x6f\xbe\xf6\x4a\x54\xcb\x39\x4e\xf9\x63\x8a\xd5\xbb\x27\xc7\xe7\x72\xc8\xa9\xe7\x87\x73\xe6\x4d\x94\xd5\x19\x76\x6b\xca\xf3\x8d\xa2\xf8\x55\x4b\x11\xea\xe9\x64\x36\x77\xe5\x12\x6e\x76\x3e\x2f\xb1\x64\xc3\xb8\x65\xb8\xf1\x0f\x1d\xc5\x70\x78\xbb\xb8\x9e\x57\x57\x0b\xf4\x39\x35\x54\x65\xb8\xf7\x06\xf7\xed\xa6\xdc\x37\xb5\xef\xd3\x1e\x51\xdd\x5e\x2e\xbe\x1e\x6b\x66\x0e\xee\xf2\xaa\xc4\xdd\xf5\xa2\x44\x33\xe4\x4c\xb1\x7b\xa5\x59\x94\xf6\xf4\x64\x1c\xe3\x2e\x09\x4f\xce\x3e\x1d\x65\xb8\xa5\x98\xcb\x5e\xd4\x37\xfb\xe2\x8e\x32\xe8\x50\x77\xd1\xec\xb7\x19\x8e\x34\xaf\x92\xa1\x69\xc8\xf0\x87\x0c\x2f\x34\x7f\x9f\x61\xf2\xf2\xcf\x7e\x49\x3b\xf9\x2f\xeb\xbc\xfd\x36\x6f\xbf\xcc\xbf\xed\xf2\x73\x00\xa2\xe4\xee\x5e\x4b\x04\x00\x00")
+
+func migrations_gateway03_utc_timestampsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway03_utc_timestampsSql,
+		"migrations_gateway/03_utc_timestamps.sql",
+	)
+}
+
+func migrations_gateway03_utc_timestampsSql() (*asset, error) {
+	bytes, err := migrations_gateway03_utc_timestampsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/03_utc_timestamps.sql", size: 1099, mode: os.FileMode(420), modTime: time.Unix(1791953850, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway04_backfillSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\x41\x4f\xc2\x30\x18\x86\xef\xfd\x15\xef\x71\x8b\x72\x31\xc1\xcb\x4e\x85\xd5\x84\x58\x06\x36\xeb\x81\x13\xf9\x58\xcb\x6c\xd8\xda\xa5\x2b\x18\xfd\xf5\x06\x43\x50\x8c\xf1\xfe\x3c\x6f\xbe\xef\x99\x4c\x70\xd7\xbb\x36\x52\xb2\xd0\x03\xe3\xb2\x16\x0a\x35\x9f\x49\x01\x65\x1b\xeb\x4e\xd6\xac\xe9\xbd\xb7\x3e\x81\x97\x25\xe6\x2b\xa9\x97\x15\x76\xd4\x1c\xf6\xae\xeb\xb0\x0b\xa1\xb3\xe4\x51\xad\x6a\x54\x5a\x4a\x94\xe2\x89\x6b\x59\x63\x4f\xdd\x68\x0b\xc6\xe6\x4a\xf0\x5a\x5c\x16\x67\x17\x6d\x7e\x8c\x63\x88\xc8\x18\xe0\x0c\x76\xae\x1d\x6d\x74\xd4\xdd\x33\x80\x9a\x26\x1c\x7d\xda\x3a\x83\x13\xc5\xe6\x95\x62\x36\x7d\xcc\xaf\xfb\x67\x64\xa0\xd6\xf9\x76\x9b\xc2\xc1\xfa\x2b\xf4\x30\x9d\xde\x52\xc7\xc1\x50\xb2\x66\x4b\x09\xc9\xf5\x76\x4c\xd4\x0f\xe9\xe3\x06\x59\xab\xc5\x92\xab\x0d\x9e\xc5\x06\x99\x33\xf9\x59\xd3\xd5\xe2\x45\x0b\x64\xdf\x77\xe4\x2c\x2f\x18\xfb\xd9\xa9\x0c\x6f\x9e\x95\x6a\xb5\xfe\xf3\xad\xe2\xdf\x88\x5f\xda\xaf\x8a\x05\xfb\x1c\x00\x05\xe3\x05\x53\x86\x01\x00\x00")
+
+func migrations_gateway04_backfillSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway04_backfillSql,
+		"migrations_gateway/04_backfill.sql",
+	)
+}
+
+func migrations_gateway04_backfillSql() (*asset, error) {
+	bytes, err := migrations_gateway04_backfillSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/04_backfill.sql", size: 390, mode: os.FileMode(420), modTime: time.Unix(1476130062, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway05_payment_requestsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x92\x41\x4f\xc2\x40\x10\x85\xef\xfb\x2b\xe6\x46\x1b\x21\x51\x22\x5c\x38\x55\xbb\x26\xc4\x5a\xb0\xa1\x89\x9c\x9a\x95\x1d\x70\x92\x6e\x5b\x77\xa7\x2a\xfe\x7a\x53\x08\x85\x06\x48\xbc\x6d\x76\xbe\xf7\x36\xfb\xe6\x0d\x06\x70\x63\x68\x63\x15\x23\xa4\x95\x78\x4c\x64\xb0\x90\xb0\x08\x1e\x22\x09\x73\xb5\x35\x58\x70\x82\x9f\x35\x3a\x06\x4f\x00\x90\x86\x77\xda\x38\xb4\xa4\xf2\xbe\x00\xb0\xfb\x59\x46\x1a\xbe\x94\x5d\x7d\x28\xeb\x8d\xef\x7d\x88\x67\x0b\x88\xd3\x28\x6a\x10\x8d\x8e\xa9\x50\x4c\x65\xd1\x32\xa3\x71\x97\x51\xce\x21\x67\xab\x52\x63\x8b\xdc\x0d\x2f\x21\xe4\x5c\x8d\xf6\xba\x8f\x29\xeb\x82\xdb\xf1\x70\x34\xea\xce\x0d\x9a\x32\xe3\x6d\x75\xf2\xcc\xed\x39\x71\x5d\xef\x58\x71\xed\xae\x8a\x57\x16\x15\xa3\xce\x14\x03\x93\x41\xc7\xca\x54\xfc\xdb\x41\xf0\xa7\x22\x8b\xee\x0c\x49\xa3\x08\x42\xf9\x14\xa4\xd1\x91\x5d\xd7\xf9\x9a\xf2\x1c\xf5\xbf\xe8\xb2\x42\xbb\x4b\xf9\x74\x19\x9d\x1f\xb4\x92\x5e\xaf\xb1\x9f\x27\xd3\x97\x20\x59\xc2\xb3\x5c\x82\x47\xda\x6f\xee\xd2\x78\xfa\x9a\x4a\xf0\x8e\x7b\xf5\x85\x3f\x11\x87\x5e\x4c\xe3\x50\xbe\x41\xb5\xef\x45\x76\x80\x76\x99\xcd\xe2\xb3\xbe\xb4\x69\xf7\xa1\x39\x36\x3e\xa7\x75\x0b\xcb\xef\x42\x84\xc9\x6c\x7e\xb1\x6e\x13\xf1\x37\x00\xd7\xb3\x86\x7e\x9b\x02\x00\x00")
+
+func migrations_gateway05_payment_requestsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway05_payment_requestsSql,
+		"migrations_gateway/05_payment_requests.sql",
+	)
+}
+
+func migrations_gateway05_payment_requestsSql() (*asset, error) {
+	bytes, err := migrations_gateway05_payment_requestsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/05_payment_requests.sql", size: 667, mode: os.FileMode(420), modTime: time.Unix(1476130062, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway06_leader_leaseSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\xce\xc1\xca\x82\x40\x14\xc5\xf1\xfd\x7d\x8a\xb3\x54\xbe\xcf\x4d\x64\x1b\x57\x96\xb3\x88\x26\x95\x41\x17\xae\xe2\x92\x97\x14\x1a\x95\x71\xa8\xe8\xe9\xa3\x4d\x24\xad\xff\x07\xce\x2f\x8a\xf0\x67\xfb\x8b\x63\x2f\xa8\x27\xda\x19\x95\x56\x0a\x55\xba\xd5\x0a\x5a\xb8\x15\xa7\x85\x67\x41\x40\xc0\xc0\x56\x70\x63\x77\xee\xd8\x05\x9b\x75\x88\xbc\xa8\x90\xd7\x5a\xff\x13\xd0\x8d\xd7\x56\xdc\x27\xaf\xe2\x78\xd9\xe5\x31\xf5\x4e\xe6\x13\x7b\xf8\xde\xca\xec\xd9\x4e\xfe\xb9\x98\x94\x66\x7f\x4c\x4d\x83\x83\x6a\x10\xbc\xcf\x42\x0a\x13\xa2\x6f\x62\x36\xde\x07\xca\x4c\x51\xfe\x12\x13\x7a\x0d\x00\x94\x1a\x71\x31\xcc\x00\x00\x00")
+
+func migrations_gateway06_leader_leaseSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway06_leader_leaseSql,
+		"migrations_gateway/06_leader_lease.sql",
+	)
+}
+
+func migrations_gateway06_leader_leaseSql() (*asset, error) {
+	bytes, err := migrations_gateway06_leader_leaseSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/06_leader_lease.sql", size: 204, mode: os.FileMode(420), modTime: time.Unix(1791956736, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway07_listener_cursorSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xce\x31\x4b\x03\x41\x10\xc5\xf1\x7e\x3e\xc5\x2b\xef\xd0\x34\x42\xaa\x54\x67\x72\x85\x78\x26\xe1\xb8\x14\xa9\x96\xd1\x8c\xeb\xa0\xbb\xb7\xcc\x8e\x8a\x7e\x7a\x11\x1b\x03\xa9\xdf\x9f\xc7\x6f\xb1\xc0\x55\xd2\x68\xec\x82\x43\xa1\xf5\xd8\x77\x53\x8f\xa9\xbb\x1d\x7a\x0c\x5a\x5d\xb2\xd8\xfa\xdd\xea\x6c\x68\x08\xd0\x13\x1e\x35\x56\x31\xe5\xb7\x6b\x02\x0a\x47\xcd\x31\xf8\xfc\x2a\x19\x1f\x6c\x4f\x2f\x6c\xcd\xcd\x72\xd9\x62\xbb\x9b\xb0\x3d\x0c\xc3\x6f\xc5\xcf\x2e\x16\x0a\x7f\x25\xc9\x1e\xfe\x3e\x34\xfb\x59\x53\xc5\x03\x3b\x5c\x93\x54\xe7\x54\xfc\xfb\x6c\xde\x8f\x77\x0f\xdd\x78\xc4\x7d\x7f\x44\xa3\xa7\x96\xda\x15\xd1\x7f\xfc\x66\xfe\xcc\xb4\x19\x77\xfb\x8b\xf8\x15\xfd\x0c\x00\x3e\x0f\x3e\x12\xe9\x00\x00\x00")
+
+func migrations_gateway07_listener_cursorSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway07_listener_cursorSql,
+		"migrations_gateway/07_listener_cursor.sql",
+	)
+}
+
+func migrations_gateway07_listener_cursorSql() (*asset, error) {
+	bytes, err := migrations_gateway07_listener_cursorSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/07_listener_cursor.sql", size: 233, mode: os.FileMode(420), modTime: time.Unix(1791958003, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway08_correlation_idSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x08\x4e\xcd\x2b\x09\x29\x4a\xcc\x2b\x4e\x4c\x2e\xc9\xcc\xcf\x53\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xce\x2f\x2a\x4a\xcd\x49\x04\x09\xc7\x67\xa6\x28\x94\x25\x16\x25\x67\x24\x16\x69\x18\x1a\x59\x68\x2a\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x28\xb8\xb8\xba\x39\x86\xfa\x84\x28\xa8\xab\x5b\x73\x71\x21\xdb\xe3\x92\x5f\x9e\x87\xd7\x26\x97\x20\xff\x00\xec\x56\x59\x73\x01\x06\x00\x2b\x28\xc1\xbd\xb1\x00\x00\x00")
+
+func migrations_gateway08_correlation_idSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway08_correlation_idSql,
+		"migrations_gateway/08_correlation_id.sql",
+	)
+}
+
+func migrations_gateway08_correlation_idSql() (*asset, error) {
+	bytes, err := migrations_gateway08_correlation_idSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/08_correlation_id.sql", size: 177, mode: os.FileMode(420), modTime: time.Unix(1791960323, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway09_payment_payloadSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xce\x41\x0e\xc2\x20\x10\x40\xd1\x3d\xa7\x98\xbd\xe9\x09\x58\xa1\xe0\x6a\x6c\x4d\x85\xb5\x99\x5a\x6c\x48\x28\x10\x1c\xa3\xbd\xbd\x5b\x63\x4c\xd3\x03\xfc\x97\xdf\x34\xb0\x9b\xc3\x54\x89\x3d\xb8\x22\x14\x5a\xd3\x83\x55\x7b\x34\x70\xf1\x89\x6d\xa5\xf4\xa0\x1b\x87\x9c\x40\x69\x0d\x87\x0e\xdd\xa9\x85\x42\x4b\xcc\x34\x02\xfb\x37\x83\x36\x47\xe5\xd0\x42\xeb\x10\xe5\x56\xa1\xfa\xe1\x19\x22\x5f\xef\x35\xcf\x30\x84\x29\xa4\x5f\x48\x7c\xaf\xe9\xfc\x4a\xab\xb4\xee\xbb\xf3\x3f\x5b\x6e\xae\x0a\x2d\x31\xd3\x28\xc5\x67\x00\xbf\x36\x93\x48\x13\x01\x00\x00")
+
+func migrations_gateway09_payment_payloadSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway09_payment_payloadSql,
+		"migrations_gateway/09_payment_payload.sql",
+	)
+}
+
+func migrations_gateway09_payment_payloadSql() (*asset, error) {
+	bytes, err := migrations_gateway09_payment_payloadSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/09_payment_payload.sql", size: 275, mode: os.FileMode(420), modTime: time.Unix(1791961839, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway10_retired_accountSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x75\x91\xc1\x6e\x83\x30\x0c\x86\xef\x79\x0a\xdf\x0a\xda\xb8\x4c\xa3\x97\x9e\xb2\x91\x49\x55\x19\x54\x11\x48\xeb\x09\x65\x60\x41\xa4\x92\x20\x27\xdb\xa4\x3d\xfd\xe8\x3a\x06\xd5\xba\xa3\xed\xcf\xbf\xed\xdf\x51\x04\x37\xbd\x6e\x49\x79\x84\x72\x60\x8f\x52\xf0\x42\x40\xc1\x1f\x52\x01\x12\xbd\x26\x6c\x78\x5d\xdb\x37\xe3\x21\x60\x00\xba\x81\x57\xdd\x3a\x24\xad\x8e\xb7\x63\xac\xce\xb5\x6a\xcc\xbf\x2b\xaa\x3b\x45\x41\xbc\x0e\x21\xcb\x0b\xc8\xca\x34\x3d\x21\x3d\x52\x8b\x4d\xa5\x8d\xb7\xff\x32\x76\xc0\x71\x03\x6d\xcd\x52\xe8\x2e\x8e\x2f\x29\x4f\xca\x38\x55\x7f\x73\x9d\x72\xdd\x2f\xb9\xbe\x9f\x41\x48\xc4\x13\x2f\xd3\x02\x56\xab\x53\x0f\x9d\x6f\xa8\x94\x07\xaf\x7b\x74\x5e\xf5\x83\xff\xbc\x90\x25\x24\x6c\xb5\xf3\x78\x85\x9b\xc4\x26\x76\x2f\xb7\xcf\x5c\x1e\x60\x27\x0e\x10\xe8\x26\x64\xe1\x66\xf2\x6c\x9b\x25\xe2\x65\x9e\xf7\x63\xcc\xc2\xa0\x3c\xfb\xe3\xe8\x5c\x1d\x75\x58\xb4\xf8\x45\x62\x3f\x0c\x4b\x64\xbe\xbf\xfa\x8b\x0d\xfb\x02\xaa\x99\xb4\xc5\xb8\x01\x00\x00")
+
+func migrations_gateway10_retired_accountSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway10_retired_accountSql,
+		"migrations_gateway/10_retired_account.sql",
+	)
+}
+
+func migrations_gateway10_retired_accountSql() (*asset, error) {
+	bytes, err := migrations_gateway10_retired_accountSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/10_retired_account.sql", size: 440, mode: os.FileMode(420), modTime: time.Unix(1791963022, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway11_conversionSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8d\x53\xcf\x6f\x82\x30\x14\xbe\xf3\x57\xbc\x9b\x92\xcd\xc4\x99\xe9\xc5\x13\x93\x2e\x31\x63\xe8\x08\x24\x7a\x22\x6f\xf0\xa2\x4d\x46\x4b\xda\xea\xcc\xfe\xfa\x55\x36\x41\xd4\x2d\x1c\x9b\xef\xd7\x6b\xfb\xbd\xc1\x00\xee\x0a\xbe\x51\x68\x08\x92\xd2\x99\x45\xcc\x8b\x19\xc4\xde\x53\xc0\x60\x26\xc5\x9e\x94\xe6\x52\x40\xdf\x01\xe0\x39\xbc\xf3\x8d\x26\xc5\xf1\xe3\xde\x9e\x65\x49\x56\x66\xd1\xd4\x22\x7b\x54\xd9\x16\x55\x7f\x34\x1e\xbb\x10\x2e\x62\x08\x93\x20\x38\xb2\xb4\x41\xb3\xd3\x35\xfe\x30\x6c\xc3\xa4\x94\x54\x69\x41\x5a\xe3\x86\xc0\xd0\xc1\xd4\x30\xf8\xec\xd9\x4b\x82\x18\x7a\xbd\xca\x88\x44\x9e\xa2\xd6\x64\xd2\x4c\xe6\xd4\x38\x8e\x2e\x02\x1b\x1e\xd7\x7a\x47\xaa\x66\x8e\x27\xee\xbf\xe6\x05\x1e\x1a\xee\xf0\x96\x6b\x21\x77\xc2\xdc\xe4\x5c\xf8\xe5\xa4\x0d\x17\x3f\xaf\xd3\x61\xe6\x6b\x7a\xf7\xd1\x5b\xda\xce\x03\x1e\x45\x85\xfd\xf4\x3c\x2d\x15\xcf\xa8\x8b\xc4\x28\x14\x1a\xb3\x2a\x68\x8b\x7a\x5b\x6b\x26\x8f\x7f\x6a\x32\x45\x55\x08\x1a\xb0\x79\x36\x14\x8b\xd2\x7c\xb5\xee\x9e\x55\x2d\xbb\x41\x3a\x39\x9d\x88\xcb\x68\xfe\xea\x45\x6b\x78\x61\x6b\xe8\xf3\xdc\x75\xdc\xe9\xa9\xaf\x49\x38\x7f\x4b\x18\xcc\x43\x9f\xad\x7e\x0d\x8f\xb5\x4d\x5b\x15\x5d\x84\xad\x46\x9f\x63\xd6\xc9\x19\x9c\x6d\x82\x2f\x3f\x85\xe3\x47\x8b\xe5\xd5\x26\x4c\x9d\x6f\x02\x17\xf1\xf2\x32\x03\x00\x00")
+
+func migrations_gateway11_conversionSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway11_conversionSql,
+		"migrations_gateway/11_conversion.sql",
+	)
+}
+
+func migrations_gateway11_conversionSql() (*asset, error) {
+	bytes, err := migrations_gateway11_conversionSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/11_conversion.sql", size: 818, mode: os.FileMode(420), modTime: time.Unix(1791964726, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway12_idempotent_paymentSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x91\x4d\x4f\x84\x30\x10\x86\xef\xfd\x15\x73\x84\x28\x37\xe3\x65\x4f\x28\x35\x21\x22\x20\x59\x12\xf7\x44\x66\x61\x02\x4d\xb6\xa5\xb6\xb3\x7e\xfd\x7a\x6b\x0c\x71\x75\x77\x3d\x35\xed\x3c\x79\x3b\xf3\x4c\x92\xc0\x85\x56\xa3\x43\x26\x68\xad\xb8\x6d\x64\xba\x96\xb0\x4e\x6f\x0a\x09\xf9\x40\xda\xce\x4c\x86\x6b\x7c\xd7\xe1\x80\x48\x00\xa8\x01\xb6\x6a\xf4\xe4\x14\xee\x2e\xc3\xdd\x7e\xd7\xba\xf0\xfe\x82\xae\x9f\xd0\x45\xd7\x57\x31\x94\xd5\x1a\xca\xb6\x28\xbe\x10\x47\xcf\x7b\xf2\xdc\x4d\xe8\xa7\xb3\x10\x3b\x34\x1e\x7b\x56\xb3\xf9\x3f\xcb\xdb\xd9\x78\xea\x3c\x23\xef\x3d\x28\xc3\x34\x92\x83\x4c\xde\xa5\x6d\x71\xcc\x01\xd3\x1b\x1f\x55\x7b\x47\x61\xe2\xa1\x43\x06\x56\x3a\xf4\x86\xda\xf2\xc7\xaf\x8f\xfa\x59\xdb\x1d\x9d\x80\xfe\x66\xd5\x4d\xfe\x90\x36\x1b\xb8\x97\x1b\x88\xd4\x10\x8b\x78\xb5\x78\x6c\xcb\xfc\xb1\x0d\x22\xcb\x4c\x3e\x05\x71\x8b\xce\x6e\x71\x76\xe0\xae\x2a\x4f\xf9\xfe\x01\x42\xa8\x48\x0e\x96\x95\xcd\xaf\x46\x64\x4d\x55\x9f\x5b\xd6\x4a\x7c\x02\xa8\x71\xaf\x82\xdc\x01\x00\x00")
+
+func migrations_gateway12_idempotent_paymentSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway12_idempotent_paymentSql,
+		"migrations_gateway/12_idempotent_payment.sql",
+	)
+}
+
+func migrations_gateway12_idempotent_paymentSql() (*asset, error) {
+	bytes, err := migrations_gateway12_idempotent_paymentSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/12_idempotent_payment.sql", size: 476, mode: os.FileMode(420), modTime: time.Unix(1791965059, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway13_counterparty_statsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\x92\x51\x6b\xc2\x30\x10\x80\xdf\xfb\x2b\xee\x4d\x65\x0a\x9b\xb0\xbd\xf4\xa9\xb3\x19\xc8\x6a\xeb\x6a\x0b\xf3\xa9\x9c\x6d\xd0\x80\x49\x4a\x92\x6e\x73\xbf\x7e\x49\x9d\x52\xb1\x13\xf6\x14\x92\x7c\xb9\xdc\xdd\x77\x93\x09\xdc\x71\xb6\x55\x68\x28\xe4\xb5\x37\x4b\x49\x90\x11\xc8\x82\xe7\x88\xc0\x4c\x36\xc2\x50\x55\xa3\x32\x87\x95\x41\xa3\x61\xe8\x01\xb0\x0a\x36\x6c\xab\xa9\x62\xb8\x1f\xdb\x7d\x45\xb5\x61\x02\x0d\x93\x02\x3e\x50\x95\x3b\x54\xc3\xc7\xa7\x11\xc4\x49\x06\x71\x1e\x45\x8e\x41\xad\xa9\x29\x4a\x59\xd1\x33\xf2\x30\xed\x43\x98\xd6\x0d\x55\xbd\x71\x20\x24\x2f\x41\x1e\x65\x30\x18\x38\xbe\xc6\x03\xa7\xc2\x05\xb5\x49\xba\x8c\x98\x5d\xae\xd8\x7b\x87\x72\x8a\xa2\x40\xde\x82\x95\x6c\x36\x7b\x0a\xb5\xa2\x25\xd3\x2e\xe5\xfe\x27\x47\xba\xe0\xd3\x7f\x3e\xd8\x31\x6d\xa4\xed\x26\x07\x43\xbf\xcc\x45\x81\x3b\xd9\xa8\x5b\xf7\x4d\x5d\x59\x09\x55\x81\x06\x0c\xe3\xb6\xa9\xc8\x6b\xf3\x7d\x81\x2c\xd3\xf9\x22\x48\xd7\xf0\x4a\xd6\x30\x64\xd5\xc8\x1b\xf9\x27\x63\x79\x3c\x7f\xcb\x09\xcc\xe3\x90\xbc\x43\xd9\x11\x57\x68\x67\xae\xe8\x58\x2a\xda\x56\x43\x12\xf7\x09\xee\x70\xe3\x8e\xb6\xf1\x85\x1f\xfb\x6d\x10\x65\x24\xfd\x9d\x93\x95\xf5\x90\x29\x14\x1a\xcb\x76\x0a\x82\x30\x84\x59\x12\xe5\x8b\x18\x50\x48\x8e\x7b\x46\xf5\xb1\xde\x53\xcb\x5c\x41\xbe\xe7\x4d\x3a\xc3\x17\xca\x4f\x71\x33\x6a\x98\x26\xcb\xab\xb0\xbe\xd7\x1e\xff\x31\xaf\xbe\xf7\x03\x6b\x42\xa8\x68\xdf\x02\x00\x00")
+
+func migrations_gateway13_counterparty_statsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway13_counterparty_statsSql,
+		"migrations_gateway/13_counterparty_stats.sql",
+	)
+}
+
+func migrations_gateway13_counterparty_statsSql() (*asset, error) {
+	bytes, err := migrations_gateway13_counterparty_statsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/13_counterparty_stats.sql", size: 735, mode: os.FileMode(420), modTime: time.Unix(1791965316, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway14_reconciliationSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6d\x50\xc1\xaa\xc2\x30\x10\xbc\xe7\x2b\xf6\xd8\xe2\x2b\x3c\xcf\x9e\xea\x6b\x0e\xe5\xd5\x54\x43\x0b\xcf\x93\xc4\x74\xa9\x0b\xa6\x2d\xe9\xa2\xe2\xd7\x9b\x0a\x82\xe5\x79\xdc\x99\xdd\xd9\x99\x49\x12\x58\x38\x6a\xbd\x61\x84\x7a\x10\x3f\x5a\xa6\x95\x84\x2a\x5d\x17\x12\x34\xda\xbe\xb3\x74\x26\xc3\xd4\x77\x10\x09\x00\x6a\xe0\x48\xed\x88\x9e\xcc\xf9\x2b\xcc\xcd\x74\x77\x31\xde\x9e\x8c\x8f\x96\xdf\x31\xa8\xb2\x02\x55\x17\xc5\x44\x3a\x1a\x9d\x61\x7b\xc2\x11\xa8\x63\x6c\xd1\xcf\x68\x8f\x43\xef\x19\x18\x6f\x3c\xc3\xad\xc7\xa0\xda\x1c\x4c\xe0\xc8\xe1\xc8\xc6\x0d\x7c\x9f\xad\x6c\x75\xbe\x49\xf5\x1e\x7e\xe5\x1e\x22\x6a\x62\x11\xaf\x5e\xd6\x6b\x95\xef\x6a\x09\xb9\xca\xe4\x5f\x78\xf1\x9e\xe0\xf0\x74\x5b\xaa\x7f\xc1\x26\x3c\x28\x88\xe4\xad\x8c\xac\xbf\x76\x22\xd3\xe5\xf6\x63\x19\x2b\xf1\x00\x25\x5c\xa2\x6e\x39\x01\x00\x00")
+
+func migrations_gateway14_reconciliationSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway14_reconciliationSql,
+		"migrations_gateway/14_reconciliation.sql",
+	)
+}
+
+func migrations_gateway14_reconciliationSql() (*asset, error) {
+	bytes, err := migrations_gateway14_reconciliationSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/14_reconciliation.sql", size: 313, mode: os.FileMode(420), modTime: time.Unix(1791965705, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway15_eventSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x55\x8f\xb1\x0e\x82\x30\x14\x45\xf7\x7e\xc5\x1b\x21\xca\x62\xc4\x85\x09\xa5\x83\x11\x81\x10\x18\x98\x4c\x81\x17\xac\x81\xd2\x94\x27\x8a\x5f\x2f\x2e\x06\xc6\x9b\x73\x86\x7b\x1c\x07\x36\x9d\x6c\x8c\x20\x84\x5c\xb3\x53\xca\xfd\x8c\x43\xe6\x1f\x43\x0e\x7c\x44\x45\x60\x31\x00\x59\x43\x29\x9b\x01\x8d\x14\xed\x76\xde\x34\x69\x84\x51\x98\xea\x2e\x8c\x75\xd8\xdb\x10\xc5\x19\x44\x79\x18\xfe\xe0\xf0\x2c\x1f\x58\xd1\x9f\xef\x5c\x77\x2d\x8c\x68\x06\xd9\x2b\x90\x8a\xb0\x41\xb3\x62\x5a\x4c\x6d\x2f\x6a\x20\x7c\xd3\x0a\x54\x06\xe7\x8f\xf5\x4d\x10\x90\xec\x70\x20\xd1\x69\xfa\xac\x94\x24\x3d\x5f\xfd\xb4\x80\x0b\x2f\xc0\x92\xb5\xcd\x6c\x8f\x31\x67\x11\x18\xf4\x2f\xc5\x82\x34\x4e\x96\x81\x1e\xfb\x02\xd6\xbe\x88\xb3\x04\x01\x00\x00")
+
+func migrations_gateway15_eventSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway15_eventSql,
+		"migrations_gateway/15_event.sql",
+	)
+}
+
+func migrations_gateway15_eventSql() (*asset, error) {
+	bytes, err := migrations_gateway15_eventSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/15_event.sql", size: 260, mode: os.FileMode(420), modTime: time.Unix(1791969789, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway16_internal_transferSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\xcd\x31\x0e\x02\x21\x10\x05\xd0\x9e\x53\xfc\xde\x70\x82\xad\xd0\xc1\x6a\x04\xb3\xc2\x01\x46\x83\x86\x04\x07\xc3\x92\x78\x7d\x5b\x63\xe1\x05\xde\xb3\x16\xbb\x67\x7d\x0c\x99\x05\xf9\x65\x1c\x27\xbf\x22\xb9\x3d\x7b\x5c\x8a\xce\x34\x44\x37\xb9\xcd\xda\x15\x8e\x08\x87\xc8\xf9\x14\x50\x75\x96\xa1\xd2\x70\xed\xbd\x15\x51\x84\x98\x10\x32\x33\xc8\x1f\x5d\xe6\x84\xbb\xb4\xad\x2c\xc6\xd8\x2f\x9f\xfa\x5b\xff\x0e\xb4\xc6\xf3\x6f\xb1\x98\x0f\x81\x97\x52\x1f\xa3\x00\x00\x00")
+
+func migrations_gateway16_internal_transferSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway16_internal_transferSql,
+		"migrations_gateway/16_internal_transfer.sql",
+	)
+}
+
+func migrations_gateway16_internal_transferSql() (*asset, error) {
+	bytes, err := migrations_gateway16_internal_transferSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/16_internal_transfer.sql", size: 163, mode: os.FileMode(420), modTime: time.Unix(1791970239, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway17_leader_handoffSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x75\xcf\x31\x0f\x82\x30\x14\x04\xe0\xfd\xfd\x8a\x37\xd2\x28\x83\x46\x58\x98\x50\x9a\x68\xac\x40\x1a\x18\x18\x5f\xa4\x08\x89\xb4\xa6\x45\xfc\xfb\xe2\x42\x24\xd1\xf9\xbb\x4b\xee\x7c\x1f\x57\x7d\x77\xb3\x34\x28\x2c\x1f\x70\x90\x3c\x2e\x38\x16\xf1\x5e\x70\x14\x8a\x6a\x65\x8f\xa4\x6b\xd3\x34\xe8\x01\xa2\xa6\x5e\xe1\x48\xf6\xda\x92\xf5\xc2\x1d\xc3\x34\x2b\x30\x2d\x85\x58\x4f\xd8\x9a\xfb\x14\x9f\x79\x1b\x04\x4b\x1f\x95\x75\x9d\xd1\x7f\xfb\x6e\xa0\xe1\xe9\x66\xde\x84\x4b\xce\xe5\xe9\x12\xcb\x0a\xcf\xbc\x42\xef\x33\x84\x01\x8b\x00\xfc\xaf\x03\x89\x79\x69\x48\x64\x96\xff\x3a\x10\xc1\x1b\xb3\x51\x7e\xf6\xec\x00\x00\x00")
+
+func migrations_gateway17_leader_handoffSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway17_leader_handoffSql,
+		"migrations_gateway/17_leader_handoff.sql",
+	)
+}
+
+func migrations_gateway17_leader_handoffSql() (*asset, error) {
+	bytes, err := migrations_gateway17_leader_handoffSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/17_leader_handoff.sql", size: 236, mode: os.FileMode(420), modTime: time.Unix(1791971571, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway18_account_generationSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x9d\x94\xcb\x4e\xc3\x30\x10\x45\xf7\xf9\x8a\x59\xb6\xa2\x95\x60\x01\x9b\x4a\x48\xa1\x71\xa1\x22\x4d\x8a\x49\x04\x5d\x45\x26\x19\x05\x4b\xe4\x81\xed\x80\xca\xd7\x63\x97\x3e\x0c\x4d\x5f\xec\x62\xfb\xce\x9d\xb9\x47\xa3\xf4\xfb\x70\x56\xf0\x5c\x30\x85\x10\xd7\xce\x90\x12\x37\x22\x10\xb9\x37\x3e\x01\x37\x4d\xab\xa6\x54\xb7\x58\xa2\x7e\xe7\x55\x09\x1d\x07\x80\x67\xf0\xc2\x73\x89\x82\xb3\xb7\x9e\x3e\xb3\x1f\x55\xa2\xef\x3f\x98\x48\x5f\x99\xe8\x5c\x5e\x75\x21\x08\x23\x08\x62\xdf\x37\x92\x7c\xe3\xa0\x4b\x79\xa9\xd6\xaf\xe0\x91\x91\x1b\xfb\x11\x5c\x18\x9d\xc4\xf7\x06\xcb\x14\x77\xaa\xce\x8d\xaa\x40\x91\x63\x96\x30\x05\x8a\x17\x28\x15\x2b\x6a\xf5\xb5\x96\xac\x7a\x0a\x4c\x05\xea\x54\x07\x85\x4d\x9d\xb5\xc9\xec\xf9\xa7\x74\x3c\x71\xe9\x0c\xee\xc9\x0c\x3a\x3c\xeb\x3a\xdd\xc1\x8a\x54\x1c\x8c\x1f\x62\x02\xe3\xc0\x23\xcf\x6b\x14\x9b\xbc\x89\x45\x27\x0c\xda\x88\x6e\x04\xda\xd4\xf5\x23\x42\x97\xf4\xa7\x6c\x5e\x60\xa9\xa8\x61\x22\x15\xb8\x9e\x07\xc3\xd0\x8f\x27\xc1\x51\x38\x7f\x7b\x0d\x4d\x0b\x14\x35\x13\x6a\xfe\xa8\x98\x92\xa7\xdb\x79\x34\x9c\x2e\x63\xa6\x96\x5b\x22\x8d\x5d\x92\xe9\x11\x79\xb9\x8c\x2c\x25\xaa\x76\x40\x07\x2a\x6d\x6e\xc6\xc4\x20\xdb\x1e\xbd\x63\x95\xf4\xac\xe9\x7b\xb0\x28\x4a\xd2\x2a\xc3\xd5\x37\x97\xb2\x41\xa1\xc9\x3a\x7d\x6b\xd1\xbd\xea\xb3\x74\x3c\xe2\x13\x3d\xdf\x88\x86\x93\x96\x26\x4f\x77\x84\x12\x1b\xcd\xf5\x69\x10\xfe\x46\xf9\x17\x8f\xa3\x21\xec\x09\xbe\x7f\x0d\x16\x81\xb6\xf6\x60\xef\x22\xee\x2a\x59\xdc\xef\xf8\x71\x0c\x9c\x6f\x5c\x62\xfb\x43\x68\x04\x00\x00")
+
+func migrations_gateway18_account_generationSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway18_account_generationSql,
+		"migrations_gateway/18_account_generation.sql",
+	)
+}
+
+func migrations_gateway18_account_generationSql() (*asset, error) {
+	bytes, err := migrations_gateway18_account_generationSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/18_account_generation.sql", size: 1128, mode: os.FileMode(420), modTime: time.Unix(1791972539, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway19_received_payment_disputeSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\xce\xb1\x0a\xc2\x30\x14\x85\xe1\x3d\x4f\x71\xb7\x2a\xd2\x45\xe8\x94\x29\x9a\x3a\xc5\xa6\x84\x66\x96\x4b\x73\xd5\x80\xa9\xa5\xbd\x56\xf4\xe9\x1d\x04\x71\x51\xe8\x03\x9c\xef\x3f\x79\x0e\xab\x14\x4f\x03\x32\x81\xef\x85\x32\x4d\xe9\xa0\x51\x1b\x53\x82\xa3\x96\xe2\x44\xa1\xc6\x47\xa2\x8e\x41\x69\x0d\x5b\x6b\xfc\xbe\x82\x10\xc7\xfe\xc6\x14\x0e\xc8\xc0\x31\xd1\xc8\x98\x7a\x7e\x82\x2e\x77\xca\x9b\x06\x2a\x6f\x8c\x9c\x8d\x1d\x23\x5d\xc2\x08\x13\x0e\xed\x19\x87\xc5\xba\x28\x96\x50\xd9\xb7\xf6\xa1\xb3\x4c\x0a\x91\x7f\xbd\xd6\xd7\x7b\xf7\x37\xa5\x9d\xad\x7f\xb4\xe4\xfc\x21\xb2\x14\x2f\x15\x53\xdd\xb7\x35\x01\x00\x00")
+
+func migrations_gateway19_received_payment_disputeSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway19_received_payment_disputeSql,
+		"migrations_gateway/19_received_payment_dispute.sql",
+	)
+}
+
+func migrations_gateway19_received_payment_disputeSql() (*asset, error) {
+	bytes, err := migrations_gateway19_received_payment_disputeSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/19_received_payment_dispute.sql", size: 309, mode: os.FileMode(420), modTime: time.Unix(1791973860, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway20_queued_paymentSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8d\x92\x4f\x6f\x82\x40\x10\xc5\xef\xfb\x29\xe6\x26\xa6\x9a\xd8\xa4\xe9\xc5\x13\x2d\xdb\x84\x94\xa2\x12\x48\xea\x89\x6c\x65\x42\x37\x59\x58\xba\x3b\xf6\xdf\xa7\x2f\x2a\xa8\x80\x26\x3d\xee\xbe\xb7\xbf\x9d\x37\x33\xd3\x29\xdc\x14\x32\x37\x82\x10\x92\x8a\x3d\x46\xdc\x8d\x39\xc4\xee\x43\xc0\x61\xb5\xc5\x2d\x66\x4b\xf1\x53\x60\x49\xe0\x30\x00\x99\xc1\x9b\xcc\x2d\x1a\x29\xd4\xa4\x3e\x57\x07\x2d\xad\xef\x3f\x85\xd9\xbc\x0b\xe3\xdc\xdf\x8d\x21\x5c\xc4\x10\x26\x41\xb0\xb3\x58\x12\xb4\xb5\x47\xf9\x76\xd6\x95\x6b\x82\xd2\x22\x03\xc2\x6f\xea\x08\x46\x2b\xbc\xfa\x6a\xa3\x8d\x41\x25\x48\xea\xf2\xda\xdf\xe0\xf1\x27\x37\x09\x62\x18\x8d\x76\x2f\x04\x11\x16\x15\x59\x90\x25\x61\x8e\x66\xe8\x9b\xed\x6c\x4a\x58\x4a\xd1\x18\x6d\xfe\x03\x35\x68\x2b\x5d\x5a\x4c\x9b\x90\x2d\xbb\x75\x1d\xb3\x34\xbe\x43\xca\xbe\xfa\xb1\x6f\x73\x2a\x08\x48\x16\x58\xa3\xea\x3a\x7f\x3b\x71\xf7\x55\x35\x09\xfa\xbe\x3e\xcd\x22\x91\x1a\xe2\xfa\xb6\x65\xe4\xbf\xb8\xd1\x1a\x9e\xf9\x1a\x1c\x99\x8d\xd9\x78\xde\x0e\x3f\x09\xfd\x55\xc2\xc1\x0f\x3d\xfe\xda\x16\xd7\x0e\xfa\x6c\xe0\x8b\xb0\xbf\x20\x27\xf1\x04\xbb\x48\x69\xda\x35\x24\x1c\x84\x09\xec\x09\x6c\x7a\xb6\x9b\x9e\xfe\x2a\x99\x17\x2d\x96\x97\x76\x73\xce\xfe\x00\x65\x83\x7c\x7e\xc7\x02\x00\x00")
+
+func migrations_gateway20_queued_paymentSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway20_queued_paymentSql,
+		"migrations_gateway/20_queued_payment.sql",
+	)
+}
+
+func migrations_gateway20_queued_paymentSql() (*asset, error) {
+	bytes, err := migrations_gateway20_queued_paymentSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/20_queued_payment.sql", size: 711, mode: os.FileMode(420), modTime: time.Unix(1791974593, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway21_payment_callbackSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x85\x92\xcd\x6b\xc2\x40\x10\xc5\xef\xf9\x2b\xe6\x66\xa4\x0a\x16\x4a\x2f\x9e\x52\x93\x82\x34\x8d\x12\x22\xd4\x53\x18\x93\xc1\x2e\xdd\x24\x32\x3b\xda\x8f\xbf\xbe\x9b\xaa\xc1\xc4\x8f\x1e\x97\x79\xf3\x7b\xfb\x98\x37\x1c\xc2\x5d\xa1\xd6\x8c\x42\xb0\xd8\x38\x93\x38\xf0\x92\x00\x12\xef\x29\x0c\x60\x8e\xdf\x05\x95\x32\x41\xad\x57\x98\x7d\x80\xeb\x00\xa8\x1c\x56\x6a\x6d\x88\x15\xea\x81\x7d\x0b\x63\x69\x30\x13\x55\x95\xa9\x9d\xed\x90\xb3\x77\x64\xf7\xf1\xa1\x0f\xd1\x2c\x81\x68\x11\x86\xb5\x6c\xb3\x47\x5d\x93\x80\x1f\x3c\x7b\x8b\x30\x81\x5e\xaf\x56\x6f\x59\x83\xd0\x97\xb4\x10\x46\x50\xb6\xa6\x59\xbf\x1f\xb5\x1d\x4e\x3f\x72\x43\xda\x71\xd2\x94\xaf\x89\xeb\x48\xaa\x94\x66\x76\x64\x32\x99\xad\x96\x34\xab\x72\x32\xfb\x0f\x75\x15\x28\x42\xc5\x46\x0c\xd8\x75\xaa\x49\x67\x3e\xa3\x3f\x1b\x34\x92\x12\x73\xc5\xed\x5c\x9d\xdf\x64\x4c\xf6\x0e\x79\x8a\x02\xa2\x0a\xb2\x31\x2c\xfb\xa7\x15\xb3\xb4\xeb\xe9\xc1\xf5\x96\x2e\x27\xad\x76\xc4\xe7\xb0\x6e\x82\x79\x3c\x7d\xf5\xe2\x25\xbc\x04\x4b\x70\x55\xde\x77\xfa\xe3\x63\x0b\xa6\x91\x1f\xbc\x35\xa7\xcb\x0e\x35\x48\x3b\x27\x9f\x45\xe7\x45\x69\x4b\xfe\x25\x1e\xee\x75\x89\xb4\x1f\x0d\xba\xb9\x2d\xd2\x19\x9e\x54\xd7\xaf\x3e\x4b\xc7\x8f\x67\xf3\xcb\xd5\x1d\x3b\xbf\x8b\x4d\xb9\xfa\xe8\x02\x00\x00")
+
+func migrations_gateway21_payment_callbackSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway21_payment_callbackSql,
+		"migrations_gateway/21_payment_callback.sql",
+	)
+}
+
+func migrations_gateway21_payment_callbackSql() (*asset, error) {
+	bytes, err := migrations_gateway21_payment_callbackSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/21_payment_callback.sql", size: 744, mode: os.FileMode(420), modTime: time.Unix(1791977579, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_compliance01_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x93\xcf\x6e\xb2\x40\x14\xc5\xf7\xf3\x14\x77\x29\xf9\x74\xf3\xa5\xba\x61\x45\x2b\x4d\x48\x2d\x5a\x02\x49\x5d\x4d\xae\xce\xa0\x37\x65\xc0\x0c\x43\xd5\x3e\x7d\x63\xfd\x03\x53\x05\xd3\xed\x9c\x73\xef\x9c\xf3\x83\x19\x0c\xe0\x9f\xa2\x95\x46\x23\x21\xd9\xb0\xa7\xc8\xf7\x62\x1f\x62\xef\x71\xe2\x83\x57\x99\x75\xa1\xe9\x4b\x8a\x58\x63\x5e\xe2\xd2\x50\x91\x43\x8f\x01\x90\x80\x05\xad\x4a\xa9\x09\xb3\x3e\x03\x30\xb5\xce\x49\xc0\x27\xea\xe5\x1a\x75\x6f\xf4\xe0\x40\x38\x8d\x21\x4c\x26\x93\x83\x4d\x49\x55\xb4\x8a\xcd\x1d\x3b\xa1\xc1\xc8\x9d\xb1\x0c\x78\x89\xc3\xd1\x80\x21\x25\x4b\x83\x6a\x63\x79\x04\x1a\xbc\x9e\x64\x00\xb3\x28\x78\xf5\xa2\x39\xbc\xf8\x73\xe8\x91\x70\x98\xe3\xb2\x5f\x6d\xb3\xac\xd8\x4a\xf1\x1c\xdc\x6c\x98\xa3\x92\x97\xe8\xff\x87\x43\x3b\xbb\x28\x14\x52\xde\xae\x6f\xaa\x45\x46\x4b\xfe\x21\xf7\xf0\x63\x18\x8e\x6c\x1d\x8f\x77\xb7\xf7\xba\x8a\xcf\x1c\xa8\x0b\x24\x61\xf0\x96\xf8\x10\x84\x63\xff\x1d\x30\x25\xbe\xd8\xf3\x53\xa4\x69\xd8\x2c\x76\x3c\x74\xdc\xae\xc1\x46\x56\x7b\xb8\x16\xda\xd8\x25\xa5\xd4\x37\xe9\xa5\xc4\xbb\x01\xa6\xc4\xef\x31\x4c\x89\xdf\xc3\x58\x95\x52\x37\xff\xbf\xab\x1d\x7f\xe7\xec\xb4\x51\xae\x0e\xac\xac\x4c\xfc\x7c\x7d\x8d\xed\x08\xc4\x72\xf5\xe1\x64\x3b\x6c\x66\xcd\xe7\x37\x2e\xb6\x39\x1b\x47\xd3\x59\xd7\xf3\x73\x2d\xc7\xf9\xe3\xdc\x3a\x4d\x4a\xa9\x5d\xf6\x3d\x00\x02\xc5\x23\x8a\xe0\x03\x00\x00")
 
 func migrations_compliance01_initSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -109,6 +530,26 @@ func migrations_compliance01_initSql() (*asset, error) {
 	return a, nil
 }
 
+var _migrations_compliance02_utc_timestampsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x92\x41\x6f\xd3\x50\x10\x84\xef\xf9\x15\x73\xa2\xad\x90\xc3\x1d\xc4\x21\x2d\x06\x45\x4a\x53\x54\x9e\x0f\xf4\xd2\x6c\xec\x25\x79\x92\xbd\xcf\xda\x5d\x37\x90\x5f\x8f\x1c\x93\x40\x7a\xe0\x62\x29\x57\x7b\x76\xbe\xd1\xcc\xcb\x32\xbc\x6d\xe2\x46\xc9\x19\x45\x3b\xc9\x32\x84\xd8\xb0\x39\x35\xad\xa1\x33\xae\xe0\x09\x6b\x86\x79\x52\xae\x40\x86\x3a\x95\x54\xc3\x63\xc3\x48\x3f\xe0\x5b\x46\x99\x9a\xb6\x8e\x24\x25\xc3\x58\x5f\x58\xa7\x08\x5b\xfe\x05\xd2\xfe\x9f\xbc\xb0\x3a\x57\xe8\x2c\xca\xa6\x07\xf4\x27\x87\xf3\x7d\x92\x93\xc7\x90\x21\x26\x81\xb1\x59\x4c\xf2\x1e\x71\x70\x1f\x2c\xb1\x23\x83\x24\x87\x76\x22\x51\x36\x88\x82\x22\xdc\xc1\xd8\xb1\xea\xdd\x7a\xb3\x55\x6f\xdf\x92\x52\xc3\xce\x7a\xb4\xae\xc8\x69\x4d\xc6\x28\x1e\x17\xb8\xe6\x9f\x53\xac\xde\x1c\x2f\x3e\xe6\x9d\xa6\x96\xdf\xdd\xb2\xd6\x51\x56\x37\xd8\x6d\x59\x4e\x8c\x2c\xfb\x53\x4d\x56\xad\xa7\x93\xd9\x22\xe4\x8f\x08\xb3\xdb\x45\x8e\x59\xe7\xdb\xa4\x71\xcf\x55\x50\x12\xa3\xf2\x10\x7d\x50\xdc\x3d\x2c\x8a\xfb\x25\xe8\x24\x79\x26\x47\xf8\xfe\x35\x3f\x94\x76\xa8\xd6\xf7\x28\xbe\xcd\x97\x5f\x5e\x89\x66\x01\x61\x7e\x9f\xe3\xe9\x61\x99\xa3\xec\x54\x59\xfc\xd9\xd8\x3d\xca\xe6\xfa\xaa\x1f\xe6\x29\x09\x5f\xdd\x7c\x38\xcf\x52\xd7\x69\xc7\xd5\xe7\xf9\x2b\xfe\xf0\xf9\x7f\xf0\xbf\x8a\x51\xe4\xc2\x58\x2f\xc2\x9e\xfc\xfb\x5a\x3f\xa5\x9d\x9c\xa7\x19\xb7\xc9\x31\xd8\x99\xe4\x32\x8b\x8c\xe9\x64\xdc\x1e\x63\xc8\xbf\x07\x00\x52\x5a\x54\x62\x3b\x04\x00\x00")
+
+func migrations_compliance02_utc_timestampsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_compliance02_utc_timestampsSql,
+		"migrations_compliance/02_utc_timestamps.sql",
+	)
+}
+
+func migrations_compliance02_utc_timestampsSql() (*asset, error) {
+	bytes, err := migrations_compliance02_utc_timestampsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_compliance/02_utc_timestamps.sql", size: 1083, mode: os.FileMode(420), modTime: time.Unix(1791953855, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -161,19 +602,42 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"migrations_gateway/01_init.sql":    migrations_gateway01_initSql,
-	"migrations_compliance/01_init.sql": migrations_compliance01_initSql,
+	"migrations_gateway/01_init.sql":              migrations_gateway01_initSql,
+	"migrations_gateway/02_daily_volume.sql":      migrations_gateway02_daily_volumeSql,
+	"migrations_gateway/03_utc_timestamps.sql":    migrations_gateway03_utc_timestampsSql,
+	"migrations_gateway/04_backfill.sql":          migrations_gateway04_backfillSql,
+	"migrations_gateway/05_payment_requests.sql":  migrations_gateway05_payment_requestsSql,
+	"migrations_gateway/06_leader_lease.sql":      migrations_gateway06_leader_leaseSql,
+	"migrations_gateway/07_listener_cursor.sql":   migrations_gateway07_listener_cursorSql,
+	"migrations_gateway/08_correlation_id.sql":    migrations_gateway08_correlation_idSql,
+	"migrations_gateway/09_payment_payload.sql":   migrations_gateway09_payment_payloadSql,
+	"migrations_gateway/10_retired_account.sql":   migrations_gateway10_retired_accountSql,
+	"migrations_gateway/11_conversion.sql": migrations_gateway11_conversionSql,
+	"migrations_gateway/12_idempotent_payment.sql": migrations_gateway12_idempotent_paymentSql,
+	"migrations_gateway/13_counterparty_stats.sql": migrations_gateway13_counterparty_statsSql,
+	"migrations_gateway/14_reconciliation.sql": migrations_gateway14_reconciliationSql,
+	"migrations_gateway/15_event.sql": migrations_gateway15_eventSql,
+	"migrations_gateway/16_internal_transfer.sql": migrations_gateway16_internal_transferSql,
+	"migrations_gateway/17_leader_handoff.sql": migrations_gateway17_leader_handoffSql,
+	"migrations_gateway/18_account_generation.sql": migrations_gateway18_account_generationSql,
+	"migrations_gateway/19_received_payment_dispute.sql": migrations_gateway19_received_payment_disputeSql,
+	"migrations_gateway/20_queued_payment.sql": migrations_gateway20_queued_paymentSql,
+	"migrations_gateway/21_payment_callback.sql": migrations_gateway21_payment_callbackSql,
+	"migrations_compliance/01_init.sql":           migrations_compliance01_initSql,
+	"migrations_compliance/02_utc_timestamps.sql": migrations_compliance02_utc_timestampsSql,
 }
 
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"}
 // AssetDir("data/img") would return []string{"a.png", "b.png"}
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error
@@ -207,10 +671,31 @@ type bintree struct {
 
 var _bintree = &bintree{nil, map[string]*bintree{
 	"migrations_compliance": &bintree{nil, map[string]*bintree{
-		"01_init.sql": &bintree{migrations_compliance01_initSql, map[string]*bintree{}},
+		"01_init.sql":           &bintree{migrations_compliance01_initSql, map[string]*bintree{}},
+		"02_utc_timestamps.sql": &bintree{migrations_compliance02_utc_timestampsSql, map[string]*bintree{}},
 	}},
 	"migrations_gateway": &bintree{nil, map[string]*bintree{
-		"01_init.sql": &bintree{migrations_gateway01_initSql, map[string]*bintree{}},
+		"01_init.sql":             &bintree{migrations_gateway01_initSql, map[string]*bintree{}},
+		"02_daily_volume.sql":     &bintree{migrations_gateway02_daily_volumeSql, map[string]*bintree{}},
+		"03_utc_timestamps.sql":   &bintree{migrations_gateway03_utc_timestampsSql, map[string]*bintree{}},
+		"04_backfill.sql":         &bintree{migrations_gateway04_backfillSql, map[string]*bintree{}},
+		"05_payment_requests.sql": &bintree{migrations_gateway05_payment_requestsSql, map[string]*bintree{}},
+		"06_leader_lease.sql":     &bintree{migrations_gateway06_leader_leaseSql, map[string]*bintree{}},
+		"07_listener_cursor.sql":  &bintree{migrations_gateway07_listener_cursorSql, map[string]*bintree{}},
+		"08_correlation_id.sql":   &bintree{migrations_gateway08_correlation_idSql, map[string]*bintree{}},
+		"09_payment_payload.sql":  &bintree{migrations_gateway09_payment_payloadSql, map[string]*bintree{}},
+		"10_retired_account.sql":  &bintree{migrations_gateway10_retired_accountSql, map[string]*bintree{}},
+		"11_conversion.sql": &bintree{migrations_gateway11_conversionSql, map[string]*bintree{}},
+		"12_idempotent_payment.sql": &bintree{migrations_gateway12_idempotent_paymentSql, map[string]*bintree{}},
+		"13_counterparty_stats.sql": &bintree{migrations_gateway13_counterparty_statsSql, map[string]*bintree{}},
+		"14_reconciliation.sql": &bintree{migrations_gateway14_reconciliationSql, map[string]*bintree{}},
+		"15_event.sql": &bintree{migrations_gateway15_eventSql, map[string]*bintree{}},
+		"16_internal_transfer.sql": &bintree{migrations_gateway16_internal_transferSql, map[string]*bintree{}},
+		"17_leader_handoff.sql": &bintree{migrations_gateway17_leader_handoffSql, map[string]*bintree{}},
+		"18_account_generation.sql": &bintree{migrations_gateway18_account_generationSql, map[string]*bintree{}},
+		"19_received_payment_dispute.sql": &bintree{migrations_gateway19_received_payment_disputeSql, map[string]*bintree{}},
+		"20_queued_payment.sql": &bintree{migrations_gateway20_queued_paymentSql, map[string]*bintree{}},
+		"21_payment_callback.sql": &bintree{migrations_gateway21_payment_callbackSql, map[string]*bintree{}},
 	}},
 }}
 
@@ -2,9 +2,11 @@ package postgres
 
 import (
 	"bytes"
+	"database/sql"
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	// To load pq driver
@@ -85,6 +87,32 @@ func (d *Driver) Insert(object entities.Entity) (id int64, err error) {
 		err = stmt.Get(&id, object)
 	case *entities.ReceivedPayment:
 		err = stmt.Get(&id, object)
+	case *entities.DailyVolume:
+		err = stmt.Get(&id, object)
+	case *entities.BackfillCursor:
+		err = stmt.Get(&id, object)
+	case *entities.ListenerCursor:
+		err = stmt.Get(&id, object)
+	case *entities.RetiredAccount:
+		err = stmt.Get(&id, object)
+	case *entities.Conversion:
+		err = stmt.Get(&id, object)
+	case *entities.IdempotentPayment:
+		err = stmt.Get(&id, object)
+	case *entities.CounterpartyStats:
+		err = stmt.Get(&id, object)
+	case *entities.Reconciliation:
+		err = stmt.Get(&id, object)
+	case *entities.Event:
+		err = stmt.Get(&id, object)
+	case *entities.PaymentRequest:
+		err = stmt.Get(&id, object)
+	case *entities.AccountGeneration:
+		err = stmt.Get(&id, object)
+	case *entities.QueuedPayment:
+		err = stmt.Get(&id, object)
+	case *entities.PaymentCallback:
+		err = stmt.Get(&id, object)
 	}
 
 	if err != nil {
@@ -141,6 +169,32 @@ func (d *Driver) Update(object entities.Entity) (err error) {
 		_, err = d.database.NamedExec(query, object)
 	case *entities.ReceivedPayment:
 		_, err = d.database.NamedExec(query, object)
+	case *entities.DailyVolume:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.BackfillCursor:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.ListenerCursor:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.RetiredAccount:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Conversion:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.IdempotentPayment:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.CounterpartyStats:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Reconciliation:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Event:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.PaymentRequest:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.AccountGeneration:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.QueuedPayment:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.PaymentCallback:
+		_, err = d.database.NamedExec(query, object)
 	}
 
 	return
@@ -181,6 +235,95 @@ func (d *Driver) GetOne(object entities.Entity, where string, params ...interfac
 	return object, err
 }
 
+// AcquireLease acquires or renews a lease, the lease is not updated when it's held by another
+// holder and has not expired
+func (d *Driver) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	var current string
+	err := d.database.Get(
+		&current,
+		`INSERT INTO LeaderLease (name, holder, expires_at) VALUES ($1, $2, now() + $3 * interval '1 second')
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+		WHERE LeaderLease.holder = EXCLUDED.holder OR LeaderLease.expires_at < now()
+		RETURNING holder;`,
+		name,
+		holder,
+		int64(ttl/time.Second),
+	)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return current == holder, nil
+}
+
+// TransferLease gives a lease held by `from` to `to`, the update is skipped when the lease has
+// another holder
+func (d *Driver) TransferLease(name, from, to string, ttl time.Duration) (bool, error) {
+	result, err := d.database.Exec(
+		"UPDATE LeaderLease SET holder = $1, expires_at = now() + $2 * interval '1 second' WHERE name = $3 AND holder = $4;",
+		to,
+		int64(ttl/time.Second),
+		name,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// TakeOverLease gives a lease to holder regardless of its current holder
+func (d *Driver) TakeOverLease(name, holder string, ttl time.Duration) error {
+	_, err := d.database.Exec(
+		`INSERT INTO LeaderLease (name, holder, expires_at) VALUES ($1, $2, now() + $3 * interval '1 second')
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at;`,
+		name,
+		holder,
+		int64(ttl/time.Second),
+	)
+	return err
+}
+
+// RequestHandoff replaces a handoff request of a lease
+func (d *Driver) RequestHandoff(name, holder, version string) error {
+	_, err := d.database.Exec(
+		`INSERT INTO LeaderHandoff (name, holder, version, status) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, version = EXCLUDED.version, status = EXCLUDED.status;`,
+		name,
+		holder,
+		version,
+		entities.LeaderHandoffStatusRequested,
+	)
+	return err
+}
+
+// GetHandoff returns a handoff request of a lease, nil when there is none
+func (d *Driver) GetHandoff(name string) (*entities.LeaderHandoff, error) {
+	handoff := &entities.LeaderHandoff{}
+	err := d.database.Get(handoff, "SELECT * FROM LeaderHandoff WHERE name = $1;", name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return handoff, nil
+}
+
+// SetHandoffStatus updates a status of a handoff request of holder
+func (d *Driver) SetHandoffStatus(name, holder, status string) error {
+	_, err := d.database.Exec(
+		"UPDATE LeaderHandoff SET status = $1 WHERE name = $2 AND holder = $3;",
+		status,
+		name,
+		holder,
+	)
+	return err
+}
+
 // GetMany returns many entities
 func (d *Driver) GetMany(slice interface{}, where, order, offset, limit *string, params ...interface{}) (err error) {
 	_, tableName, err := getTypeData(slice)
@@ -225,6 +368,13 @@ func (d *Driver) GetMany(slice interface{}, where, order, offset, limit *string,
 			tmp[i].SetExists()
 		}
 		slice = &tmp
+	case *[]*entities.DailyVolume:
+		err = d.database.Select(slice, query.String(), params...)
+		tmp := *slice
+		for i := range tmp {
+			tmp[i].SetExists()
+		}
+		slice = &tmp
 	}
 
 	if err != nil && err.Error() == "sql: no rows in result set" {
@@ -251,10 +401,51 @@ func getTypeData(object interface{}) (typeValue reflect.Type, tableName string,
 	case *entities.ReceivedPayment:
 		typeValue = reflect.TypeOf(*object)
 		tableName = "ReceivedPayment"
+	case *entities.DailyVolume:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "DailyVolume"
+	case *entities.BackfillCursor:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "BackfillCursor"
+	case *entities.ListenerCursor:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "ListenerCursor"
+	case *entities.RetiredAccount:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "RetiredAccount"
+	case *entities.Conversion:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Conversion"
+	case *entities.IdempotentPayment:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "IdempotentPayment"
+	case *entities.CounterpartyStats:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "CounterpartyStats"
+	case *entities.Reconciliation:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Reconciliation"
+	case *entities.Event:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Event"
+	case *entities.PaymentRequest:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "PaymentRequest"
+	case *entities.AccountGeneration:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "AccountGeneration"
+	case *entities.QueuedPayment:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "QueuedPayment"
+	case *entities.PaymentCallback:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "PaymentCallback"
 	case *[]*entities.SentTransaction:
 		tableName = "SentTransaction"
 	case *[]*entities.ReceivedPayment:
 		tableName = "ReceivedPayment"
+	case *[]*entities.DailyVolume:
+		tableName = "DailyVolume"
 	default:
 		return typeValue, tableName, fmt.Errorf("Unknown entity type: %T", object)
 	}
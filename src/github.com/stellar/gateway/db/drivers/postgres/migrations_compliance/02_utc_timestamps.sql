@@ -0,0 +1,12 @@
+-- +migrate Up
+-- Timestamps used to be stored as local time of the compliance server. They are converted using
+-- the time zone of the migration session: if the server was not running in UTC set `timezone`
+-- parameter of the database URL (ex. `&timezone=Europe/Berlin`) when running --migrate-db.
+ALTER TABLE AuthorizedTransaction ALTER COLUMN authorized_at TYPE timestamptz USING authorized_at AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE AllowedFI ALTER COLUMN allowed_at TYPE timestamptz USING allowed_at AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE AllowedUser ALTER COLUMN allowed_at TYPE timestamptz USING allowed_at AT TIME ZONE current_setting('TimeZone');
+
+-- +migrate Down
+ALTER TABLE AuthorizedTransaction ALTER COLUMN authorized_at TYPE timestamp USING authorized_at AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE AllowedFI ALTER COLUMN allowed_at TYPE timestamp USING allowed_at AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE AllowedUser ALTER COLUMN allowed_at TYPE timestamp USING allowed_at AT TIME ZONE current_setting('TimeZone');
@@ -0,0 +1,23 @@
+-- +migrate Up
+ALTER TABLE ReceivedPayment ADD COLUMN asset_code varchar(12) NOT NULL DEFAULT '';
+ALTER TABLE ReceivedPayment ADD COLUMN asset_issuer varchar(56) NOT NULL DEFAULT '';
+ALTER TABLE ReceivedPayment ADD COLUMN amount varchar(255) NOT NULL DEFAULT '';
+
+CREATE TABLE DailyVolume (
+  id bigserial,
+  date varchar(10) NOT NULL,
+  asset_code varchar(12) NOT NULL,
+  asset_issuer varchar(56) NOT NULL,
+  direction varchar(10) NOT NULL,
+  count bigint NOT NULL,
+  sum bigint NOT NULL,
+  fees bigint NOT NULL,
+  PRIMARY KEY (id),
+  UNIQUE (date, asset_code, asset_issuer, direction)
+);
+
+-- +migrate Down
+DROP TABLE DailyVolume;
+ALTER TABLE ReceivedPayment DROP COLUMN asset_code;
+ALTER TABLE ReceivedPayment DROP COLUMN asset_issuer;
+ALTER TABLE ReceivedPayment DROP COLUMN amount;
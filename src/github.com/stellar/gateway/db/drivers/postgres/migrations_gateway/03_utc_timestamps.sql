@@ -0,0 +1,12 @@
+-- +migrate Up
+-- Timestamps used to be stored as local time of the bridge server. They are converted using
+-- the time zone of the migration session: if the server was not running in UTC set `timezone`
+-- parameter of the database URL (ex. `&timezone=Europe/Berlin`) when running --migrate-db.
+ALTER TABLE ReceivedPayment ALTER COLUMN processed_at TYPE timestamptz USING processed_at AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE SentTransaction ALTER COLUMN submitted_at TYPE timestamptz USING submitted_at AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE SentTransaction ALTER COLUMN succeeded_at TYPE timestamptz USING succeeded_at AT TIME ZONE current_setting('TimeZone');
+
+-- +migrate Down
+ALTER TABLE ReceivedPayment ALTER COLUMN processed_at TYPE timestamp USING processed_at AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE SentTransaction ALTER COLUMN submitted_at TYPE timestamp USING submitted_at AT TIME ZONE current_setting('TimeZone');
+ALTER TABLE SentTransaction ALTER COLUMN succeeded_at TYPE timestamp USING succeeded_at AT TIME ZONE current_setting('TimeZone');
@@ -0,0 +1,15 @@
+-- +migrate Up
+ALTER TABLE ReceivedPayment ADD COLUMN backfill boolean NOT NULL DEFAULT false;
+
+CREATE TABLE BackfillCursor (
+  id bigserial,
+  account_id varchar(56) NOT NULL,
+  paging_token varchar(255) NOT NULL,
+  updated_at timestamptz NOT NULL,
+  PRIMARY KEY (id),
+  UNIQUE (account_id)
+);
+
+-- +migrate Down
+DROP TABLE BackfillCursor;
+ALTER TABLE ReceivedPayment DROP COLUMN backfill;
@@ -0,0 +1,23 @@
+-- +migrate Up
+CREATE TABLE PaymentRequest (
+  id bigserial,
+  request_id varchar(64) NOT NULL,
+  destination varchar(56) NOT NULL,
+  asset_code varchar(12) NOT NULL,
+  asset_issuer varchar(56) NOT NULL,
+  amount varchar(255) NOT NULL,
+  memo_type varchar(10) NOT NULL,
+  memo varchar(255) NOT NULL,
+  status varchar(10) NOT NULL,
+  created_at timestamptz NOT NULL,
+  expires_at timestamptz NULL DEFAULT NULL,
+  fulfilled_at timestamptz NULL DEFAULT NULL,
+  operation_id varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (id),
+  UNIQUE (request_id)
+);
+
+CREATE INDEX payment_request_memo ON PaymentRequest (memo_type, memo);
+
+-- +migrate Down
+DROP TABLE PaymentRequest;
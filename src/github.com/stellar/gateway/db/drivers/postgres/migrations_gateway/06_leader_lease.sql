@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE LeaderLease (
+  name varchar(64) NOT NULL,
+  holder varchar(255) NOT NULL,
+  expires_at timestamptz NOT NULL,
+  PRIMARY KEY (name)
+);
+
+-- +migrate Down
+DROP TABLE LeaderLease;
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE ListenerCursor (
+  id bigserial,
+  paging_token varchar(255) NOT NULL,
+  after_payment_id bigint NOT NULL,
+  set_at timestamptz NOT NULL,
+  PRIMARY KEY (id)
+);
+
+-- +migrate Down
+DROP TABLE ListenerCursor;
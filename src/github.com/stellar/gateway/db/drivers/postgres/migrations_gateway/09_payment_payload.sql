@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE SentTransaction ADD COLUMN payload text DEFAULT NULL;
+ALTER TABLE SentTransaction ADD COLUMN rebuilt_from bigint DEFAULT NULL;
+
+-- +migrate Down
+ALTER TABLE SentTransaction DROP COLUMN rebuilt_from;
+ALTER TABLE SentTransaction DROP COLUMN payload;
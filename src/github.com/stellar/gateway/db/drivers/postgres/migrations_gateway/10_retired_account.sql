@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE RetiredAccount (
+  id bigserial,
+  account_id varchar(56) NOT NULL,
+  merged_into varchar(56) NOT NULL,
+  operation_id varchar(255) NOT NULL,
+  transaction_hash varchar(64) NOT NULL DEFAULT '',
+  retired_at timestamptz NOT NULL,
+  reregistered_at timestamptz DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+CREATE INDEX retired_account_account_id ON RetiredAccount (account_id);
+
+-- +migrate Down
+DROP TABLE RetiredAccount;
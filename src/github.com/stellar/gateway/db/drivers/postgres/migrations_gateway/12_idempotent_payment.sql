@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE IdempotentPayment (
+  id bigserial,
+  payment_id varchar(64) NOT NULL,
+  request_hash varchar(64) NOT NULL,
+  transaction_id varchar(64) NOT NULL,
+  response_status integer DEFAULT NULL,
+  response text DEFAULT NULL,
+  created_at timestamptz NOT NULL,
+  completed_at timestamptz DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX idempotent_payment_payment_id ON IdempotentPayment (payment_id);
+
+-- +migrate Down
+DROP TABLE IdempotentPayment;
@@ -0,0 +1,20 @@
+-- +migrate Up
+CREATE TABLE CounterpartyStats (
+  id bigserial,
+  destination varchar(56) NOT NULL,
+  asset_code varchar(12) NOT NULL,
+  asset_issuer varchar(56) NOT NULL DEFAULT '',
+  payment_count bigint NOT NULL DEFAULT 0,
+  mean_amount double precision NOT NULL DEFAULT 0,
+  amount_m2 double precision NOT NULL DEFAULT 0,
+  amount_histogram text NOT NULL,
+  hour_histogram text NOT NULL,
+  updated_at timestamptz NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX counterparty_stats_destination_asset ON CounterpartyStats (destination, asset_code, asset_issuer);
+ALTER TABLE SentTransaction ADD COLUMN anomalies text DEFAULT NULL;
+
+-- +migrate Down
+ALTER TABLE SentTransaction DROP COLUMN anomalies;
+DROP TABLE CounterpartyStats;
@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE Reconciliation (
+  id bigserial,
+  date varchar(10) NOT NULL,
+  mismatches integer NOT NULL,
+  report text NOT NULL,
+  created_at timestamptz NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX reconciliation_date ON Reconciliation (date);
+
+-- +migrate Down
+DROP TABLE Reconciliation;
@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE Event (
+  id bigserial,
+  type varchar(64) NOT NULL,
+  subject varchar(255) NOT NULL,
+  version integer NOT NULL,
+  payload text NOT NULL,
+  created_at timestamptz NOT NULL,
+  PRIMARY KEY (id)
+);
+
+-- +migrate Down
+DROP TABLE Event;
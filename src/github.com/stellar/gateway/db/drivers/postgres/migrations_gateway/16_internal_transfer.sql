@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE SentTransaction ADD COLUMN internal boolean NOT NULL DEFAULT false;
+
+-- +migrate Down
+ALTER TABLE SentTransaction DROP COLUMN internal;
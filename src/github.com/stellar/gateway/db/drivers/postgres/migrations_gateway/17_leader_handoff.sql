@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE LeaderHandoff (
+  name varchar(64) NOT NULL,
+  holder varchar(255) NOT NULL,
+  version varchar(64) NOT NULL,
+  status varchar(16) NOT NULL,
+  PRIMARY KEY (name)
+);
+
+-- +migrate Down
+DROP TABLE LeaderHandoff;
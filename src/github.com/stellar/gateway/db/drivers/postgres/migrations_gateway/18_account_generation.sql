@@ -0,0 +1,24 @@
+-- +migrate Up
+CREATE TABLE AccountGeneration (
+  id bigserial,
+  account_id varchar(56) NOT NULL,
+  generation bigint NOT NULL DEFAULT 1,
+  sequence bigint NOT NULL DEFAULT 0,
+  merged_at timestamptz DEFAULT NULL,
+  recreated_at timestamptz DEFAULT NULL,
+  updated_at timestamptz NOT NULL,
+  PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX account_generation_account_id ON AccountGeneration (account_id);
+ALTER TABLE PaymentRequest ADD COLUMN generation bigint NOT NULL DEFAULT 1;
+ALTER TABLE CounterpartyStats ADD COLUMN generation bigint NOT NULL DEFAULT 1;
+DROP INDEX counterparty_stats_destination_asset;
+CREATE UNIQUE INDEX counterparty_stats_destination_generation_asset ON CounterpartyStats (destination, generation, asset_code, asset_issuer);
+
+-- +migrate Down
+DELETE FROM CounterpartyStats WHERE generation > 1;
+DROP INDEX counterparty_stats_destination_generation_asset;
+CREATE UNIQUE INDEX counterparty_stats_destination_asset ON CounterpartyStats (destination, asset_code, asset_issuer);
+ALTER TABLE CounterpartyStats DROP COLUMN generation;
+ALTER TABLE PaymentRequest DROP COLUMN generation;
+DROP TABLE AccountGeneration;
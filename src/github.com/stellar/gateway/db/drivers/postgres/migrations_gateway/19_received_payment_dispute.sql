@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE ReceivedPayment ADD COLUMN disputed_at timestamptz DEFAULT NULL;
+ALTER TABLE ReceivedPayment ADD COLUMN disputed_fields varchar(255) NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE ReceivedPayment DROP COLUMN disputed_fields;
+ALTER TABLE ReceivedPayment DROP COLUMN disputed_at;
@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE QueuedPayment (
+  id bigserial,
+  payment_id varchar(64) NOT NULL,
+  status varchar(10) NOT NULL,
+  payload text NOT NULL,
+  role varchar(10) NOT NULL,
+  correlation_id varchar(64) NOT NULL DEFAULT '',
+  attempts integer NOT NULL DEFAULT 0,
+  last_error varchar(64) NOT NULL DEFAULT '',
+  response_status integer DEFAULT NULL,
+  response text DEFAULT NULL,
+  queued_at timestamptz NOT NULL,
+  last_attempt_at timestamptz DEFAULT NULL,
+  settled_at timestamptz DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX queued_payment_payment_id ON QueuedPayment (payment_id);
+CREATE INDEX queued_payment_status ON QueuedPayment (status, id);
+
+-- +migrate Down
+DROP TABLE QueuedPayment;
@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE PaymentCallback (
+  id bigserial,
+  transaction_id varchar(64) NOT NULL,
+  payment_id varchar(64) NOT NULL DEFAULT '',
+  url text NOT NULL,
+  status varchar(10) NOT NULL,
+  transaction_status varchar(10) NOT NULL DEFAULT '',
+  ledger bigint DEFAULT NULL,
+  result_codes text DEFAULT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  last_error text NOT NULL DEFAULT '',
+  created_at timestamptz NOT NULL,
+  next_attempt_at timestamptz NOT NULL,
+  delivered_at timestamptz DEFAULT NULL,
+  PRIMARY KEY (id)
+);
+CREATE INDEX payment_callback_transaction_id ON PaymentCallback (transaction_id);
+CREATE INDEX payment_callback_status ON PaymentCallback (status, next_attempt_at);
+
+-- +migrate Down
+DROP TABLE PaymentCallback;
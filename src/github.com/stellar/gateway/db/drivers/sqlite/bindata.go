@@ -0,0 +1,586 @@
+// Code generated by go-bindata.
+// sources:
+// migrations_gateway/01_init.sql
+// migrations_gateway/02_correlation_id.sql
+// migrations_gateway/03_payment_payload.sql
+// migrations_gateway/04_retired_account.sql
+// migrations_gateway/05_conversion.sql
+// migrations_gateway/06_idempotent_payment.sql
+// migrations_gateway/07_counterparty_stats.sql
+// migrations_gateway/08_reconciliation.sql
+// migrations_gateway/09_event.sql
+// migrations_gateway/10_internal_transfer.sql
+// migrations_gateway/11_leader_handoff.sql
+// migrations_gateway/12_account_generation.sql
+// migrations_gateway/13_received_payment_dispute.sql
+// migrations_gateway/14_queued_payment.sql
+// migrations_gateway/15_payment_callback.sql
+// migrations_compliance/01_init.sql
+// DO NOT EDIT!
+
+package sqlite
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes []byte
+	info  os.FileInfo
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+func (fi bindataFileInfo) IsDir() bool {
+	return false
+}
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var _migrations_gateway01_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x56\x41\x6f\xe2\x3c\x10\xbd\xe7\x57\xcc\x8d\x56\x1f\x48\x6d\xf5\xc1\xa5\x27\x5a\xf2\x49\xe8\x4b\x81\xd2\xb0\xda\x9e\x22\x13\x0f\x60\x35\xb1\xb3\xf6\xb8\x5b\xfe\xfd\xca\x81\x40\x42\x12\x16\x56\xda\x5b\xe4\xf7\x1c\xcf\xbc\x79\x7e\x49\xaf\x07\xff\xa4\x62\xad\x19\x21\x2c\x32\xaf\xd7\x83\xb7\xd7\x40\x10\x82\x89\x37\x98\x32\x30\xc4\x34\x19\x60\x04\xb4\x41\x88\xad\xd6\x28\x09\x5e\xb6\x6f\xaf\x01\x30\xc9\x61\xa6\x0c\xad\x35\x9a\x3d\xdf\x7b\x9e\xfb\xc3\xd0\x87\x70\xf8\x14\xf8\x30\xc7\x18\xc5\x27\xf2\x19\xdb\xa6\x6e\xdb\x8d\x07\x20\x38\x08\x49\xb8\x46\x0d\xb3\xf9\xf8\x65\x38\x7f\x87\xff\xfd\x77\x18\x2e\xc2\xe9\x78\xf2\x3c\xf7\x5f\xfc\x49\xd8\xf5\x00\x54\x86\x9a\x91\x50\x32\x12\x1c\x3e\x99\x8e\x37\x4c\xdf\x3c\xf4\xfb\xb7\x30\x99\x86\x30\x59\x04\x01\x2c\x26\xe3\xd7\x85\xef\xc8\x99\x56\x31\x1a\x83\x3c\x62\x04\x9c\x11\x92\x48\xf1\x40\xcc\x19\x6c\x2d\xe4\x3a\x22\xf5\x81\xb2\xf9\x75\x8e\x65\x88\x91\x35\xed\x38\x33\x06\x29\x8a\x15\xc7\x03\xe7\xfe\xe1\x48\x81\x91\xff\xdf\x70\x11\x84\xd0\xe9\x1c\xd9\xc2\x18\x8b\xfa\xc0\xef\x0f\xda\xf9\xa9\xb2\x92\x9a\x4f\x3f\xa1\x2e\x59\xfc\xb1\x12\x49\x02\x4b\xa5\x12\x64\xb2\xce\xbb\xf3\x6e\x1f\xbd\xea\x38\xde\x50\x52\xa8\x99\x34\x2c\x76\xc2\x5e\x35\x0e\x3a\xee\x2b\x0f\x64\xf0\xef\x59\x01\xef\xef\x4e\x60\x65\x75\x8c\x8d\x5a\xe4\xb0\x5d\xa6\x82\xe8\xcc\x18\x8d\x8d\x63\x44\x7e\xc2\x28\x7a\x2e\x58\x09\x72\xd7\xd0\x52\xac\x85\xa4\x1a\x8a\xf2\x13\x13\x95\x61\xf4\xc5\x35\x10\x7e\x51\xe5\x04\x8d\xc6\x26\x94\x63\x45\x99\xb9\xeb\xca\x6f\xa9\x4b\x3b\x62\x22\xd9\x7e\x53\x89\x4d\xf1\x2a\x59\x5d\x0b\xad\x6a\xfd\xc6\x6d\x17\x59\xcc\x91\xb8\xd0\xb8\x9b\x78\xdb\x49\x71\x6e\xbc\xbd\x5e\x65\xc0\xd8\xb4\x69\x79\x85\x68\x9a\xd6\x77\x37\x12\x6e\x5c\x5b\xdd\x7d\x71\xae\xfe\xe2\x79\x57\x68\xf7\x58\xd1\x6d\x5d\xca\xa7\xbd\xb5\x9f\xad\x36\x4a\x5f\xa5\x26\x8b\xf3\x46\xca\x06\xed\x0f\x9a\x03\xe3\xa2\x38\xb0\x19\x67\x6d\x6e\xac\x17\xbe\x4f\xb9\x39\xfe\xb0\x68\xae\x0b\x3b\xbd\xdb\xd3\x76\xb3\x4a\x85\x73\x34\x24\x24\xab\x4c\xb3\x3f\xf8\x3b\xbe\x39\x97\x47\x0e\x4f\x31\x55\x11\x6d\x33\x6c\xf5\x95\x63\x5c\x9c\xb6\x35\x53\x6a\x6c\x55\xdf\xe1\xf8\x95\x09\x8d\xa6\x82\x9f\xde\xf5\x95\x4d\x5c\x4c\x22\x3f\xcb\xba\xe0\x5b\x53\x6c\xe9\x74\xca\x83\x1f\x4f\x46\xfe\x77\xc8\x76\x83\x8f\x8a\x29\xe6\x5d\x4f\x27\x35\x43\x1c\xf4\xea\x82\x7b\xac\x19\x28\x40\xc6\x51\x07\xc8\xcc\x2e\x44\x24\x4b\xb1\xd9\x0f\x25\x33\xb9\x2e\x37\x2a\xe1\xa8\x9b\x2b\x6f\x53\xaa\xdd\xc7\x81\x30\x84\x12\xf5\x1f\x5c\xc0\xcb\xae\x15\x5b\x11\xea\xa8\x50\x4d\xf0\xa6\x24\x71\x0e\x6e\x2d\xb7\xfc\xd3\x32\x52\x3f\xa5\x37\x9a\x4f\x67\xcd\xff\x1c\x8f\x65\xec\xe4\x03\x58\xc1\x4a\x09\x5e\x59\xaf\xc6\x51\x05\xaa\xce\xb7\x02\x95\x46\x59\x5d\xaf\x68\xfb\xe8\xfd\x1a\x00\x05\xd1\x58\xfa\x7f\x09\x00\x00")
+
+func migrations_gateway01_initSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway01_initSql,
+		"migrations_gateway/01_init.sql",
+	)
+}
+
+func migrations_gateway01_initSql() (*asset, error) {
+	bytes, err := migrations_gateway01_initSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/01_init.sql", size: 2431, mode: os.FileMode(420), modTime: time.Unix(1791958044, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway02_correlation_idSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x92\x51\x6f\xdb\x20\x14\x85\xdf\xf9\x15\xf7\x2d\x8d\x46\xa5\xad\x5a\xaa\x49\x7e\xf2\x62\x2a\x45\xc3\xb8\x23\xf8\xa1\x4f\x11\x85\xab\x0c\xc9\x81\x08\x5f\xb7\xfd\xf9\x53\xb6\x64\xb5\xdd\x68\xaf\xf7\x1c\xb8\xdf\x39\x70\x7b\x0b\x9f\x0e\x61\x9f\x2d\x21\xb4\x47\x56\x4a\x23\x34\x98\xf2\xbb\x14\xb0\xc5\x48\x26\xdb\xd8\x5b\x47\x21\x45\x28\xab\x0a\xd6\x8d\x6c\x6b\x05\x2e\xe5\x8c\x9d\x3d\x8d\x77\xc1\xc3\x8b\xcd\xee\x97\xcd\x37\x5f\xee\xbe\x2d\x41\x35\x06\x54\x2b\x25\x54\xe2\xa1\x6c\xa5\x81\xc5\xa2\x60\x6c\xbc\xa7\x4a\xaf\xf1\x34\xd8\xfe\x94\x81\x10\x9c\x8d\x0b\x02\x9f\xd3\x11\x5c\xea\x86\x43\xec\xd9\x5a\x8b\xd2\x88\xeb\x1c\x3b\x9f\x5e\x23\xdc\x30\x80\xe0\x21\x44\xc2\x3d\x66\x78\xd4\x9b\xba\xd4\x4f\xf0\x43\x3c\x41\xd9\x9a\x66\xa3\xd6\x5a\xd4\x42\x19\xce\x00\x68\x74\x78\x44\x7b\xff\xf5\x1d\xf6\x64\xeb\xc9\xd2\xd0\xbf\x87\xf9\x3c\x93\xd3\x90\x1d\xfe\x93\x57\xf7\x33\x79\x78\x3e\x04\x22\xf4\x3b\x4b\xe0\x2d\x21\x85\x03\xce\x1c\xce\x21\xfa\x99\xe3\xd2\xd2\xc5\xd5\xa1\x3f\x05\x7a\x0e\xfb\x10\xe9\x83\x8a\xf1\x05\xbb\x74\xc4\xdd\x9b\xcf\x40\xf8\x46\x93\x0d\x19\xfb\xa1\xa3\x3f\xda\x05\xf3\x6e\xb5\x5a\x4e\x6e\x61\xcb\x82\x6d\xd4\x56\x68\x03\x1b\x65\x9a\xeb\xed\x6e\x85\x14\x6b\x03\xc1\xf3\x59\x79\xfc\xdc\x12\x3f\xd7\xc1\x27\xb9\xf9\x24\x23\x3f\x67\xe1\x13\x6a\x3e\xa6\x7c\xd0\x4d\x3d\x27\x28\x58\xa5\x9b\xc7\xeb\x6f\x5f\xfc\xef\x83\xfe\x45\xd7\x42\x95\xb5\x80\x8f\xc9\x0a\xf6\x7b\x00\x80\x7b\x6b\xf5\xec\x02\x00\x00")
+
+func migrations_gateway02_correlation_idSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway02_correlation_idSql,
+		"migrations_gateway/02_correlation_id.sql",
+	)
+}
+
+func migrations_gateway02_correlation_idSql() (*asset, error) {
+	bytes, err := migrations_gateway02_correlation_idSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/02_correlation_id.sql", size: 748, mode: os.FileMode(420), modTime: time.Unix(1791960323, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway03_payment_payloadSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x92\xcd\x6e\xdb\x30\x10\x84\xef\x7c\x8a\xbd\x39\x46\x19\xa0\x09\xea\xa0\x80\x4f\xaa\xc5\x00\x46\xf5\x93\xd2\xd4\x21\x27\x83\x26\xb7\x2e\x01\x89\x34\xa8\x55\x92\xbe\x7d\xa1\xda\x75\x65\xc6\x28\x7a\xe5\x2c\x87\x33\x1f\xf7\xf6\x16\x3e\x74\x6e\x1f\x35\x21\x34\x07\x96\x15\x4a\x48\x50\xd9\x97\x42\xc0\x06\x3d\xa9\xa8\x7d\xaf\x0d\xb9\xe0\x21\xcb\x73\x58\xd5\x45\x53\x56\x70\xd0\x3f\xdb\xa0\x2d\x10\xbe\x11\xe4\xe2\x31\x6b\x0a\x05\x55\x53\x14\xcb\xff\x75\x88\xb8\x1b\x5c\x4b\xdb\xef\x31\x74\xb0\x73\x7b\xe7\x53\x23\x36\x8d\x96\x87\x57\x3f\x1e\x6c\xbe\x15\x8e\x10\x8c\xf6\x33\x02\x1b\xc3\x01\x4c\x68\x87\xce\xf7\x6c\x25\x45\xa6\xc4\xf5\x87\xb7\x36\xbc\x7a\xb8\x61\x00\xce\x82\xf3\x84\x7b\x8c\xf0\x24\xd7\x65\x26\x9f\xe1\xab\x78\x86\xac\x51\xf5\xba\x5a\x49\x51\x8a\x4a\x71\x06\x40\x93\xcb\xce\xc2\x8b\x8e\xe6\x87\x8e\x37\x0f\x9f\xe6\x50\xd5\xc7\x84\xe3\x58\x4f\x9a\x86\xfe\x2c\xdf\x7d\x4c\xe4\x30\x44\x83\x67\x79\xf1\x90\xc8\xc3\xae\x73\x44\x68\xb7\x9a\xc0\x6a\x42\x72\x1d\x26\x13\xc6\x20\xda\x64\x62\xca\x69\xf4\x69\xd1\x8e\x85\xae\x50\x1c\x55\xf4\x2f\xd8\x86\x03\x6e\xdf\x6c\x3c\x7e\xd8\xf4\x85\x88\xfd\xd0\xd2\x6f\xed\x4f\xcc\xfb\xc5\x62\xfe\xce\xc5\x84\x18\xb1\xd5\x29\x90\xbb\xfb\xcf\x7f\x3b\x9d\x2f\xcd\x66\x6c\xbe\x64\xeb\x6a\x23\xa4\x82\x75\xa5\xea\xeb\x1f\xb2\x11\x85\x58\x29\x70\x96\x27\xbc\xf9\x09\x2c\x3f\x11\xe4\x17\xa8\xf8\x05\x16\x7e\xaa\xcf\x2f\x8a\xf2\x49\x31\x9e\x86\x7f\x94\x75\x99\x26\x5a\xb2\x5c\xd6\x4f\xd7\xd7\xe7\x9f\x4b\x7d\xac\x22\x45\x95\x95\x02\xde\x37\x5d\xb2\x5f\x03\x00\xba\x39\x13\x4f\x62\x03\x00\x00")
+
+func migrations_gateway03_payment_payloadSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway03_payment_payloadSql,
+		"migrations_gateway/03_payment_payload.sql",
+	)
+}
+
+func migrations_gateway03_payment_payloadSql() (*asset, error) {
+	bytes, err := migrations_gateway03_payment_payloadSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/03_payment_payload.sql", size: 866, mode: os.FileMode(420), modTime: time.Unix(1791961839, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway04_retired_accountSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x75\x91\x3d\x4f\xc3\x30\x14\x45\x77\xff\x8a\xb7\xb5\x15\x64\x41\xa4\x4b\x27\x43\x8c\x14\x91\x3a\x95\xe5\x48\x74\x8a\xac\xe4\x29\xf1\x10\xbb\x7a\x31\xf0\xf7\x71\x28\x25\x29\x1f\xb3\xcf\x3d\xf2\xbd\x2f\x49\xe0\x66\xb0\x1d\x99\x80\x50\x9d\xd8\xa3\x12\x5c\x0b\xd0\xfc\xa1\x10\xa0\x30\x58\xc2\x96\x37\x8d\x7f\x75\x01\xd6\x0c\xc0\xb6\x60\x5d\xc0\x0e\x09\x0e\x2a\xdf\x73\x75\x84\x67\x71\x04\x5e\xe9\x32\x97\x31\xbc\x17\x52\xdf\x46\xce\x9c\x33\x75\xe4\xdf\x0c\x35\xbd\xa1\x75\xba\xdd\x80\x2c\x35\xc8\xaa\x28\x26\x64\x40\xea\xb0\xad\xa3\xce\xff\xcb\xf8\x13\xc6\x9f\x59\xef\x96\xa2\xbb\x34\xbd\xa6\x02\x19\x37\x9a\xe6\x93\xeb\xcd\xd8\x7f\x93\xdb\xfb\x19\x84\x4c\x3c\xf1\xaa\xd0\xb0\x5a\x4d\x19\x3a\x77\xab\x4d\x80\x36\x76\x0f\x76\xc0\x2b\x27\x21\x61\x67\xc7\x80\x3f\xa1\x8b\x66\x02\xd9\x66\x77\x59\x2c\x97\x99\x78\x99\xad\x5f\xf5\x17\x33\x94\xf2\xd7\x9e\xf3\x6b\xf4\xb0\x64\x71\x89\xcc\xbf\x3b\x96\xa9\xf2\xf0\xe7\x25\x76\xec\x03\xf6\x2c\xdd\x4c\xb6\x01\x00\x00")
+
+func migrations_gateway04_retired_accountSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway04_retired_accountSql,
+		"migrations_gateway/04_retired_account.sql",
+	)
+}
+
+func migrations_gateway04_retired_accountSql() (*asset, error) {
+	bytes, err := migrations_gateway04_retired_accountSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/04_retired_account.sql", size: 438, mode: os.FileMode(420), modTime: time.Unix(1791963022, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway05_conversionSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8d\x93\x41\x6f\x82\x40\x10\x85\xef\xfc\x8a\xb9\xa9\x69\x49\xac\x29\x5e\x3c\x51\xd9\x26\xa4\xb8\x58\x02\x89\x9e\xc8\x06\x26\xba\x07\x76\xcd\xee\x6a\xfd\xf9\x5d\x68\x41\x51\xdb\x70\xdb\x64\xde\xf7\x66\x66\xf3\xc6\x75\xe1\xa9\xe2\x3b\xc5\x0c\x42\x76\x70\x96\x09\xf1\x53\x02\xa9\xff\x16\x11\x58\x4a\x71\x42\xa5\xb9\x14\x30\x76\x00\x78\x09\x5c\x18\xdc\xa1\x82\x75\x12\xae\xfc\x64\x0b\x1f\x64\x0b\x7e\x96\xc6\x21\xb5\xe0\x8a\xd0\xf4\xd9\xea\xe4\x01\xad\x9d\xa5\x72\x4b\x9c\x98\x2a\xf6\x4c\x8d\x67\x9e\x37\x01\x1a\xa7\x40\xb3\x28\xaa\x55\xda\x30\x73\xd4\x5d\xfd\x65\xda\x2f\xa3\x52\x52\xe5\x15\x6a\xcd\x76\x08\x06\xcf\xa6\x2b\x43\x40\xde\xfd\x2c\x4a\x61\x34\x6a\x8c\x50\x94\x39\xd3\x1a\x4d\x5e\xc8\x12\x2f\x8e\xb3\x9b\x86\x17\x1d\xd7\xfa\x68\xb7\x68\x95\xde\x7c\xf2\xaf\x79\xc5\xce\x17\xed\xf4\x91\x6b\x25\x8f\xc2\x3c\xd4\xdc\xf8\x95\xa8\x0d\x17\x3f\xbf\x33\x60\xe6\x7b\xf9\xf0\xd1\x7b\xec\xe0\x01\x6b\xa8\xb2\x61\x28\xf3\x83\xe2\x05\x0e\x41\x8c\x62\x42\xb3\xa2\x69\xb4\x67\x7a\xdf\x31\xf3\xd7\x3f\x99\x42\x61\xd3\x84\x19\x28\xed\xc3\xf6\xc4\xde\xe2\x45\x13\xbd\x5b\x45\xeb\x51\xab\x9c\xc9\xa2\x4d\x6b\x46\xc3\xcf\x8c\x40\x48\x03\xb2\xf9\x25\xeb\xd0\xe6\xbd\x20\xc6\xb4\x97\xe7\xeb\x9a\x75\x72\xdc\xab\x3b\x08\xe4\x97\x70\x82\x24\x5e\xdf\xdd\xc1\xc2\xf9\x06\x36\xe2\x12\x3d\x30\x03\x00\x00")
+
+func migrations_gateway05_conversionSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway05_conversionSql,
+		"migrations_gateway/05_conversion.sql",
+	)
+}
+
+func migrations_gateway05_conversionSql() (*asset, error) {
+	bytes, err := migrations_gateway05_conversionSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/05_conversion.sql", size: 816, mode: os.FileMode(420), modTime: time.Unix(1791964726, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway06_idempotent_paymentSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x91\x4d\x4f\xc3\x30\x0c\x86\xef\xf9\x15\x3e\x6e\x82\xde\x10\x97\x9d\x02\x0d\x52\x45\x97\x96\xaa\x91\xd8\xa9\x8a\x5a\x6b\xad\x44\x3e\x48\x3c\x3e\xfe\x3d\x41\xa8\x50\x18\xdb\x29\x96\xf2\xf8\xb5\xf5\x38\xcb\xe0\xc2\x4c\xfb\xa0\x09\x41\x79\x76\xdb\x08\xde\x0a\x68\xf9\x4d\x29\xa0\x18\xd0\x78\x47\x68\xa9\xd6\xef\x26\x3d\xb0\x62\x00\xd3\x00\x93\x25\xdc\x63\x80\xba\x29\xb6\xbc\xd9\xc1\xbd\xd8\x01\x57\x6d\x55\xc8\xd4\xbf\x15\xb2\xbd\x4c\x9c\xff\xea\xe9\x12\xff\xa2\x43\x3f\xea\xb0\xba\xbe\x5a\x83\xac\x5a\x90\xaa\x2c\x3f\x91\x80\xcf\x07\x8c\xd4\x8d\x3a\x8e\x27\x21\x0a\xda\x46\xdd\xd3\xe4\xec\xf9\xac\xe8\x9d\x8d\xd8\x45\xd2\x74\x88\xdf\x3b\xe6\xe2\x8e\xab\xf2\x98\x03\xc2\x37\x3a\xfa\xed\x03\x26\x13\x43\xa7\x09\x86\x54\xd0\x64\xf0\xd7\x94\xde\x19\xff\x84\x7f\x89\x65\x0a\x5b\x6f\x66\x8b\x4a\x16\x0f\x2a\x69\x94\xb9\x78\x4c\xda\x66\x99\xdd\x6c\x66\x61\xa8\x92\xff\xd9\xfe\x01\x52\x28\xcb\x16\xa7\xca\xdd\xab\x65\x79\x53\xd5\xa7\x4e\xb5\x61\x1f\x90\xeb\xe9\xe8\xda\x01\x00\x00")
+
+func migrations_gateway06_idempotent_paymentSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway06_idempotent_paymentSql,
+		"migrations_gateway/06_idempotent_payment.sql",
+	)
+}
+
+func migrations_gateway06_idempotent_paymentSql() (*asset, error) {
+	bytes, err := migrations_gateway06_idempotent_paymentSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/06_idempotent_payment.sql", size: 474, mode: os.FileMode(420), modTime: time.Unix(1791965059, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway07_counterparty_statsSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\x53\xcb\x6e\xdb\x30\x10\xbc\xeb\x2b\xf6\xe6\x18\xa5\x81\xc4\xa8\x83\x02\x3e\xa9\x16\x03\x18\x95\xa5\x44\xa6\x80\xe6\x24\xd0\x12\x6b\x13\x10\x45\x81\xa4\xd2\xe4\xef\x4b\xfa\x91\xea\x65\x03\xb9\x09\xda\xd9\xdd\xd9\x99\xe1\x6c\x06\xdf\x04\xdf\x2b\x6a\x18\xa4\xb5\xb7\x4a\xb0\x4f\x30\x10\xff\x67\x88\x61\x25\x9b\xca\x30\x55\x53\x65\x3e\xb6\x86\x1a\x0d\x77\x1e\x00\x2f\x80\xdb\xdf\x7b\xa6\xe0\x39\x59\x6f\xfc\xe4\x15\x7e\xe1\x57\xf0\x53\x12\xaf\x23\xdb\xbf\xc1\x11\x41\x16\x57\x30\x6d\x78\x45\x0d\x97\x15\xbc\x51\x95\x1f\xa8\xba\x5b\x3c\x4e\x21\x8a\x09\x44\x69\x18\x3a\x0c\xd5\x9a\x99\x2c\x97\x05\xfb\x84\x3c\xcc\xc7\x20\x5c\xeb\xc6\x2e\x1c\x9b\x03\x01\x7e\xf2\xd3\x90\xc0\x64\xe2\xf0\x35\xfd\x10\xac\x72\x43\x2d\x79\xd8\xf1\xbd\x25\x3b\xc4\xde\x3b\xa8\x60\xb4\xca\xa8\x38\x02\x0b\xd9\xec\x4a\x06\xb5\x62\x39\xd7\x8e\xf2\x78\xcb\x09\x9d\x89\xf9\x17\x1b\x0e\x5c\x1b\x69\x55\x16\x60\xd8\xbb\xe9\x1c\x78\x90\x8d\xba\x55\x6f\xea\xc2\x9a\x53\x64\xd4\x92\xb4\x1f\x86\x0b\xf6\x59\xf7\xa6\xcb\x8b\x65\x69\xb4\x7e\x49\x31\xac\xa3\x00\xff\x86\xbc\xe5\x5c\xa6\x9d\x75\x59\xcb\x8e\xec\xa8\x29\xc4\xd1\x98\xc3\x2d\x1c\x6a\xf9\x83\x3a\x46\xd8\xb5\x7e\x48\x70\x72\x0e\xca\xd6\x0a\x4e\x14\xad\x34\xcd\x8f\x76\xfb\x41\x00\xab\x38\x4c\x37\x11\xd0\x4a\x0a\x5a\x72\xa6\x4f\x87\x5d\xb4\x71\xe4\x97\x9e\x37\x6b\xa5\x2f\x90\x7f\x2b\x2f\x48\xe2\xe7\x6b\xe9\x5b\x3a\xf8\xf6\x25\xe4\x16\x9c\xd3\x6a\x62\xf5\x50\xb2\xb6\xb7\x96\x8d\xa8\x74\x37\xba\x3d\x46\x59\x61\x87\x7f\x29\xbd\xa6\xd5\x6c\x7b\x2e\xc1\x7b\xfc\xde\x4d\xa7\xd3\xb6\xd1\xff\xc3\x7b\xdf\x2b\x5b\x6f\x73\x76\x35\xfe\xba\xd9\x09\x6e\xae\x99\x7b\x42\xe4\x39\x63\x45\x0f\xd1\x56\xd1\xa1\x4a\x56\xb8\x83\xce\x61\xef\x57\x59\xf5\xc6\x4a\x59\xb3\xec\xbd\x50\xc3\x78\x29\xa6\x9b\xd2\x1c\x6b\x17\x9a\xf3\xc5\x62\x3a\x98\x92\x4b\xa5\x58\x49\xfb\x82\x3c\xcc\x7f\xdc\x7a\x8a\xa5\xa4\xc5\xd0\xf9\xd3\xde\x5d\xc3\xed\xe2\x3f\x4a\x8a\x31\xe6\x2e\xda\xeb\x68\x8b\x13\x62\x33\x4d\xe2\x71\x47\xb7\x38\xc4\x2b\x62\x4d\x45\x3d\xc3\xd0\xd9\x19\x74\xb6\x00\x75\xb4\x46\x1d\x5d\xd1\x59\x3f\xd4\x51\x0a\xb5\x94\x41\xbd\xeb\xd1\xe5\x34\xd4\x3d\xe3\x29\x89\x37\x7d\xa2\xcb\x76\xa6\x07\xb5\x1b\xaf\xe8\x74\x61\x82\x23\x7f\x63\x53\x1d\x0f\x7b\xff\x01\xb1\x1c\xad\xb1\xbb\x05\x00\x00")
+
+func migrations_gateway07_counterparty_statsSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway07_counterparty_statsSql,
+		"migrations_gateway/07_counterparty_stats.sql",
+	)
+}
+
+func migrations_gateway07_counterparty_statsSql() (*asset, error) {
+	bytes, err := migrations_gateway07_counterparty_statsSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/07_counterparty_stats.sql", size: 1467, mode: os.FileMode(420), modTime: time.Unix(1791965316, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway08_reconciliationSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6d\x90\xc1\x0a\xc2\x30\x10\x44\xef\xf9\x8a\x3d\x56\xb4\xa0\x67\x4f\xd5\xe6\x50\xac\xa9\x86\x04\xec\xa9\x84\x74\xa9\x01\xdb\x4a\x5c\xd4\xcf\x37\x15\x2c\x15\xbd\xce\xec\x3e\x66\x26\x8e\x61\xde\xba\xc6\x1b\x42\xd0\x57\xb6\x95\x3c\x51\x1c\x54\xb2\xc9\x39\x48\xb4\x7d\x67\xdd\xc5\x19\x72\x7d\x07\x11\x03\x70\x35\xb8\x8e\xb0\x41\x0f\x07\x99\xed\x13\x59\xc2\x8e\x97\x90\x68\x55\x64\x22\x3c\xef\xb9\x50\x8b\x70\x57\x0f\xbc\xbb\xf1\xf6\x6c\x7c\xb4\x5a\xce\x40\x14\x0a\x84\xce\xf3\xc1\x6c\xdd\xad\x35\x64\xcf\x78\x1b\x61\x53\xdb\xe3\xb5\xf7\x04\x84\x4f\xfa\xd2\xad\xc7\x40\xad\x2b\x43\x6f\x3c\xb9\x16\x47\x9f\xcd\xd6\x9f\xec\x5a\x64\x47\xcd\x21\x13\x29\x3f\x05\xd6\xb4\x42\xf5\x8e\x55\x88\x9f\x66\x83\x1e\x08\x2c\x9e\xac\x91\xf6\x8f\x8e\xa5\xb2\x38\xfc\x5d\x63\xcd\x5e\xd9\xab\xda\x32\x3a\x01\x00\x00")
+
+func migrations_gateway08_reconciliationSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway08_reconciliationSql,
+		"migrations_gateway/08_reconciliation.sql",
+	)
+}
+
+func migrations_gateway08_reconciliationSql() (*asset, error) {
+	bytes, err := migrations_gateway08_reconciliationSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/08_reconciliation.sql", size: 314, mode: os.FileMode(420), modTime: time.Unix(1791965705, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway09_eventSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x55\xcf\xbb\x0e\x82\x40\x10\x05\xd0\x7e\xbf\x62\x4a\x88\xd2\x18\xb1\xa1\x5a\x65\x0b\x22\xaf\x90\xa5\xa0\x32\x2b\x4c\x70\x8d\x3c\xb2\x8c\x28\x7f\x2f\x36\x04\xba\x49\xce\x9d\xcc\x5c\xc7\x81\x5d\xa3\x6b\xa3\x08\x21\xef\xd9\x25\x13\x5c\x0a\x90\xfc\x1c\x0a\x10\x23\xb6\x04\x16\x03\xd0\x15\xe8\x96\xb0\x46\x03\x69\x16\x44\x3c\x2b\xe0\x2a\x0a\xe0\xb9\x4c\x82\x78\xde\x89\x44\x2c\xf7\x73\x8e\xa6\x1e\x61\x54\xa6\x7c\x28\x63\x9d\x8e\x36\xc4\x89\x84\x38\x0f\xc3\x3f\x0e\xef\xfb\x13\x4b\x5a\xfc\xe0\xba\xdb\xc0\x88\x66\xd0\x5d\xbb\x9c\x5a\x5b\xaf\xa6\x57\xa7\x2a\x20\xfc\xd2\x06\x4a\x83\xf3\xef\xd5\x4d\x11\x54\xf3\x40\xba\xc1\xc5\x99\xed\x31\xe6\xac\x1a\xfa\xdd\xa7\x65\x7e\x96\xa4\xeb\x86\x1e\xfb\x01\x63\x2f\xef\x25\x05\x01\x00\x00")
+
+func migrations_gateway09_eventSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway09_eventSql,
+		"migrations_gateway/09_event.sql",
+	)
+}
+
+func migrations_gateway09_eventSql() (*asset, error) {
+	bytes, err := migrations_gateway09_eventSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/09_event.sql", size: 261, mode: os.FileMode(420), modTime: time.Unix(1791969789, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway10_internal_transferSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x53\xc9\x6e\xc2\x30\x14\xbc\xe7\x2b\xde\x8d\xa2\x1a\xa9\x45\xa5\xaa\xc4\x29\x25\x46\x42\xcd\xd2\x06\xe7\xc0\x09\x99\xe4\x95\x5a\x72\x6c\xe4\x38\x5d\xfe\xbe\xa6\x05\x44\x1c\xda\xa3\x3d\xf3\x96\x99\xb1\x47\x23\xb8\xae\xc5\xd6\x70\x8b\x50\xec\x82\x30\x66\x34\x07\x16\x3e\xc6\x14\x96\xa8\x2c\x33\x5c\x35\xbc\xb4\x42\x2b\x08\xa3\x08\x66\x59\x5c\x24\x29\x08\x65\xd1\x28\x2e\x61\xa3\xb5\x44\xae\x20\xcd\x18\xa4\x45\x1c\x43\x44\xe7\x61\x11\x33\xb8\x99\x06\xc1\xe8\xac\x77\xa4\x3f\xd4\xfe\x62\xf9\x12\x0b\x77\x2c\xb9\x1a\x58\xa8\x8c\xde\x41\xa9\x65\x5b\xab\x26\x98\xe5\x34\x64\xf4\xf2\xec\x75\xe5\xca\xe1\x2a\x00\x10\xd5\xcf\xf0\x2d\x1a\x78\xce\x17\x49\x98\xaf\xe0\x89\xae\x20\x2c\x58\xb6\x48\x5d\x8b\x84\xa6\x8c\x38\x9e\x3d\x2b\x76\x35\xef\xdc\x94\x6f\xdc\x5c\xdd\xdf\x0d\x4f\xbb\xee\x69\x8d\xe5\xb6\x6d\x4e\xf0\xed\x8d\x07\xeb\xd6\x94\x78\x82\x27\xf7\x1e\xdc\x6e\x6a\x61\x2d\x56\x6b\xee\xc4\x38\x99\x56\xd4\xe8\x31\xca\x12\xb1\xf2\x18\x47\x93\x8e\x2c\x89\xd5\x5e\xd0\x46\x6c\x9d\xb6\x1e\x8a\xea\x1d\xa5\xde\xe1\xfa\xb3\x32\x60\xf1\xd3\x76\x26\x18\x6c\x5a\x69\x7f\xb0\xe3\x9a\xe3\xc9\x64\xd8\xeb\x52\x6a\x63\x50\x72\xdf\x90\xdb\xf1\xc3\xb0\x9f\xde\x60\xb0\x2f\xd9\xf1\x2f\xa9\x79\xf5\x3b\xd3\xef\x67\x70\xd3\x0a\x37\xf8\xd5\xe8\xfa\xaf\xcd\xb9\xd2\x35\x97\x02\x9b\x7e\x8b\x60\x38\x0d\x16\xe9\x92\xe6\x0c\x16\x29\xcb\x2e\x07\xbe\xa4\x31\x9d\x31\x97\x39\xf1\xf2\x24\x87\xe0\xc8\x21\x21\xd2\x89\x82\x74\x6c\x27\x07\x7b\x49\xc7\x48\x72\x66\x1c\xf1\xcc\x21\x47\xe5\xa4\xa3\x92\x9c\xe9\x99\xe7\x59\xe2\xef\x3c\x0d\xa2\x3c\x7b\xbe\xfc\x80\xa7\xff\xfd\xac\x5f\xb1\x39\x4d\xc3\xc4\xbd\xff\xac\x5f\xfb\x0d\x85\x36\xd1\x99\xa5\x03\x00\x00")
+
+func migrations_gateway10_internal_transferSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway10_internal_transferSql,
+		"migrations_gateway/10_internal_transfer.sql",
+	)
+}
+
+func migrations_gateway10_internal_transferSql() (*asset, error) {
+	bytes, err := migrations_gateway10_internal_transferSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/10_internal_transfer.sql", size: 933, mode: os.FileMode(420), modTime: time.Unix(1791970239, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway11_leader_handoffSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x6d\x8e\xb1\x0e\x82\x30\x14\x45\xf7\xf7\x15\x6f\x84\x28\x83\x46\x58\x98\x50\x9a\x68\xac\x40\x9a\x32\x30\xbe\x40\x11\x12\x69\x4d\x41\xfc\x7d\x61\x01\x07\xe6\x73\xee\xcd\xf1\x3c\xdc\x75\xed\xd3\xd2\xa0\x30\x7f\xc3\x45\xb0\x48\x32\x94\xd1\x99\x33\xe4\x8a\x2a\x65\xaf\xa4\x2b\x53\xd7\xe8\x00\xa2\xa6\x4e\xe1\x48\xb6\x6c\xc8\x3a\xc1\xc9\xc5\x24\x95\x98\xe4\x9c\x63\x26\x6e\x8f\x48\x14\x78\x67\xc5\x7e\x12\x1b\xf3\x9a\xa6\x8b\x7a\xf4\xfd\xd5\x9d\xf9\xa8\x6c\xdf\x1a\xbd\xf9\x35\xf3\x7e\xa0\xe1\xd3\x2f\xf8\x10\xac\x18\xdc\x10\xc0\xfb\xcb\x8e\xcd\x57\x43\x2c\xd2\x6c\x2b\x3b\x84\x1f\x53\xe8\x37\x5e\xe2\x00\x00\x00")
+
+func migrations_gateway11_leader_handoffSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway11_leader_handoffSql,
+		"migrations_gateway/11_leader_handoff.sql",
+	)
+}
+
+func migrations_gateway11_leader_handoffSql() (*asset, error) {
+	bytes, err := migrations_gateway11_leader_handoffSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/11_leader_handoff.sql", size: 226, mode: os.FileMode(420), modTime: time.Unix(1791971571, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway12_account_generationSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\xbd\x55\xc9\x6e\xdb\x30\x10\xbd\xfb\x2b\x78\xb3\x83\xd2\x40\x62\xd4\xb9\x18\x3d\xa8\x16\xd3\x1a\x95\x25\x47\x96\xd0\xe6\x24\x28\x12\x63\x13\xb0\x96\x92\x54\x1b\xff\x7d\x48\x6b\xa3\x36\x2f\x3d\xf4\x26\x60\xde\x8c\xe6\xbd\x99\xc7\x99\x4e\xc1\xa7\x88\xec\xa8\xcf\x31\x70\xd3\xd1\xd2\x46\x9a\x83\x80\xa3\x7d\x35\x10\xd0\x82\x20\xc9\x62\xfe\x0d\xc7\x58\xc4\x49\x12\x83\xc9\x08\x00\x12\x02\x12\x73\xbc\xc3\x14\x6c\xec\xd5\x5a\xb3\x5f\xc0\x0f\xf4\x02\x34\xd7\xb1\x56\xa6\xc8\x5f\x23\xd3\x81\x02\xe7\xe7\xd9\x9e\xc0\xff\xf1\x69\xb0\xf7\xe9\x64\xfe\x78\x07\x4c\xcb\x01\xa6\x6b\x18\x12\xb2\xab\x2b\xbf\x92\x9d\xa8\x5a\x45\x81\x8e\x9e\x34\xd7\x70\xc0\x83\xc4\x31\xfc\x3b\xc3\x71\x80\x07\x51\xf7\x12\x15\x61\xba\xc3\xa1\xe7\x73\x10\x0a\x36\x9c\x44\xb8\x8a\x97\x3f\xa4\x38\xa0\x58\x04\xcf\xa3\xb2\x34\xec\x60\xca\x5f\x8e\xee\x16\xa5\x48\xae\xb9\x7a\x76\x11\x58\x99\x3a\xfa\x55\xb1\xad\x29\x79\x8a\x00\x96\xd9\x27\x66\x0d\x10\x45\x35\xc3\x41\x76\x21\xfc\xc6\x3f\x46\x38\xe6\xb6\xa4\xcd\x38\xd0\x74\x1d\x2c\x2d\xc3\x5d\x9b\x57\x29\xd6\xac\xb5\x94\xbf\xc0\x34\xf5\x29\x3f\x6e\xb9\xcf\xd9\xed\xe5\x74\xdb\xda\x14\x34\x03\xa5\x9a\xc7\x64\x39\x2f\x14\x2d\x92\xb8\xa0\xcc\x18\xe6\xfd\x02\x5d\xc8\x54\x75\x93\x45\xa4\x64\xdd\xd6\x27\x4a\x0a\x54\xba\x87\xe0\x94\xe4\x05\x49\x88\xcb\x6f\xc2\x58\x86\xa9\x50\x76\x34\x55\x76\x5c\x4f\xfe\xc6\x39\xa1\x81\x1d\x5f\x48\xf8\xf6\xd9\x20\x02\x1c\xf8\xf1\x58\xec\x00\x4d\x52\xd1\xfe\x21\x8b\x62\xd6\x34\x48\x73\x4e\x5e\x28\x6a\xdf\x64\x11\x5a\x24\x2a\x16\x79\xfc\x5c\x5b\xa4\x10\x50\x22\x15\xde\x83\x6e\xaa\x25\xa8\x20\x0f\xb3\x3e\x48\xae\xcc\x70\x9d\x48\x2a\x52\x85\x67\xf3\x79\x33\x1e\xe1\x28\xf1\xf8\x31\x55\x7e\x73\xdf\x45\x0c\xe7\xcb\xe1\x67\x6c\x30\xb9\xcf\xa1\x6a\x1c\xbf\xa7\x84\x62\x76\xd6\xc1\x6f\xd9\xe1\x8d\x1c\x0e\x17\x7c\x9e\xa4\xe5\xca\x29\x03\x68\xb4\x5b\xa5\x8c\xc7\xd2\xf8\x2b\x73\x8b\x6c\x47\x2c\xb4\x63\xf5\xce\x7e\x8b\x0c\xb4\x74\xc4\xf8\xa1\x32\x5a\x08\x1a\x4b\x3b\xb4\xa8\xb0\xd0\x1d\xd6\xfa\xe6\x9f\xb0\x10\x0c\x2a\xca\x40\x45\x05\xd8\x60\x0b\x9b\xac\x9e\x6c\x6b\xdd\x6a\x75\xa1\xae\x7f\x3b\x34\xfc\x0a\xe5\x0c\x6d\x64\x6a\x6b\xb1\xfe\x56\x27\xb3\x30\x46\x6e\xf6\x34\x0f\x7a\xa5\x0a\xa7\x85\x10\x8e\x6e\x3d\x6c\x93\x16\xd5\xfa\x6d\x1d\x78\xbb\x6e\xb7\xd8\xff\x32\x8e\xb2\x29\x12\x5f\x0a\x70\x7a\x5c\x2e\x9d\x2d\x5f\xbc\x79\xb9\xe7\xc2\x24\x7b\x3d\x60\x90\x8a\x3b\x45\x98\x6c\xb9\x3f\x25\x47\x7b\xd1\xec\xc6\x84\x3d\x61\x3c\x11\xef\x60\x04\x38\x7e\xe7\x0d\x82\xfb\x24\xa3\xe7\xe2\x97\x6e\xa2\x6a\x8d\x81\xb1\x29\xee\xb8\xd2\x11\x0d\x15\xa1\xaa\x14\xac\x35\x80\x1d\x76\xb0\xc5\x06\xaa\xdd\x9f\x1c\xd1\xbd\x2c\x3f\xbf\x23\x1b\xa9\xf7\xf0\x4b\x75\xf9\x06\x76\xf1\xc2\x99\x6d\xfb\xa5\x27\xff\x1f\xce\xe4\xd5\xb7\xf1\xcc\x3d\xfc\x00\xea\xa5\xb6\x29\xf3\x09\x00\x00")
+
+func migrations_gateway12_account_generationSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway12_account_generationSql,
+		"migrations_gateway/12_account_generation.sql",
+	)
+}
+
+func migrations_gateway12_account_generationSql() (*asset, error) {
+	bytes, err := migrations_gateway12_account_generationSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/12_account_generation.sql", size: 2547, mode: os.FileMode(420), modTime: time.Unix(1791972539, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway13_received_payment_disputeSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\x92\xc1\x4f\xc2\x30\x14\xc6\xef\xfb\x2b\xde\x0d\x89\x25\x51\x12\xbc\x70\x9a\xac\x26\x8b\x5b\x87\xa5\x3b\x70\x5a\xea\x56\xb0\x71\x6b\x97\xb5\xc3\xf8\xdf\xdb\x09\x02\xd3\xa1\xf1\xd8\xf6\xf7\xbe\x7e\xef\x7b\x6f\x32\x81\xeb\x4a\x6e\x1b\x6e\x05\xa4\xb5\xe7\x47\x0c\x53\x60\xfe\x7d\x84\x81\x8a\x5c\xc8\x9d\x28\x96\xfc\xbd\x12\xca\x82\x1f\x04\xb0\x48\xa2\x34\x26\x50\x48\x53\xb7\x56\x14\x19\xb7\x50\xb8\x52\x2b\x2b\x01\x01\x7e\xf0\xd3\x88\x01\x49\xa3\x68\xfe\x6f\xa5\x8d\x14\x65\x61\x60\xc7\x9b\xfc\x85\x37\x57\xd3\xd9\x6c\x0c\x24\xd9\xab\x1d\xa5\x47\xa3\xb9\xe7\x4d\xce\x2c\x07\xfa\x4d\x75\x17\xab\xa7\x48\xba\x63\xce\xd5\xc8\x39\x6a\x74\x0d\xb9\x2e\xdb\x4a\x19\x6f\x41\xb1\xcf\xf0\xb0\x91\xac\x70\xe5\x70\xe5\x01\xc8\x02\xa4\xb2\x62\x2b\x1a\x58\xd2\x30\xf6\xe9\x1a\x1e\xf1\x1a\xfc\x94\x25\x21\x71\x12\x31\x26\x0c\x39\x4e\xd7\xc2\xfd\x2b\xb5\xca\x5c\xc5\xb0\xd7\x94\x84\x4f\x29\xee\xe0\xba\xd1\xb9\x30\xe6\x5b\x4c\x5f\xe0\x27\xc1\xb7\x52\x6d\x33\xab\x5f\x85\x1a\x96\xeb\x28\x63\xb9\x6d\xcd\xe5\x77\xee\xfe\xb0\x59\xae\x0b\x71\x64\x6e\xa7\x83\xe9\x9d\x68\x69\x4c\xeb\x9a\xfd\xe2\x67\x77\x97\xf9\x4a\xb7\x6e\x66\x7f\x0d\xa6\x43\x9f\x79\xfe\xba\x91\x65\x09\xcf\x5a\x97\x82\xab\x9f\xdc\x8d\x37\x9e\x7b\x21\x59\x61\xca\x20\x24\x2c\x19\x9e\xc8\x0a\x47\x78\xc1\xdc\x50\x50\x2f\x70\xd4\x4b\x14\xf5\xd2\x43\x87\x94\xd0\x59\x1a\xa8\xd7\x2b\x3a\x74\x82\x4e\x36\x1f\x68\x12\x7f\x77\x30\xf7\x02\x9a\x2c\x87\xf7\xe5\xd7\xad\xde\x5b\xa7\x98\xf8\xb1\x5b\xb7\xe4\x67\xed\x07\x67\x55\x1b\x40\x6b\x03\x00\x00")
+
+func migrations_gateway13_received_payment_disputeSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway13_received_payment_disputeSql,
+		"migrations_gateway/13_received_payment_dispute.sql",
+	)
+}
+
+func migrations_gateway13_received_payment_disputeSql() (*asset, error) {
+	bytes, err := migrations_gateway13_received_payment_disputeSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/13_received_payment_dispute.sql", size: 875, mode: os.FileMode(420), modTime: time.Unix(1791973860, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway14_queued_paymentSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x8d\x92\x4d\x6b\x83\x40\x10\x86\xef\xfe\x8a\xb9\x25\xa1\x09\xa4\x50\x7a\xc9\xc9\xc6\x2d\x48\xcd\x6a\x44\xa1\x39\xc9\x12\x87\x54\x50\xd7\xee\x8e\xfd\xf8\xf7\xf5\x3b\xd1\x24\xd0\xf3\xfb\xcc\x3b\xf3\xce\xcc\x6a\x05\x0f\x59\x72\x52\x82\x10\xc2\xc2\xd8\xfa\xcc\x0c\x18\x04\xe6\x8b\xc3\x60\x5f\x62\x89\xb1\x27\x7e\x33\xcc\x09\xe6\x06\x40\x12\x43\x92\x13\x9e\x50\x81\xe7\xdb\x3b\xd3\x3f\xc0\x1b\x3b\x80\x19\x06\xae\xcd\xab\xda\x1d\xe3\xc1\xb2\xe2\x8a\xb6\x26\xaa\xf8\x2f\xa1\x8e\x1f\x42\xcd\x9f\x9f\x16\xc0\xdd\x00\x78\xe8\x38\x35\xa2\x49\x50\xa9\x07\xf9\x71\x3d\x96\x2b\x87\x54\x8a\x18\x08\x7f\x68\x24\x28\x99\xe2\xdd\xaa\xa3\x54\x0a\x53\x41\x89\xcc\xef\xf5\x06\x8b\xbd\x9a\xa1\x13\xc0\x6c\x56\x57\x08\x22\xcc\x0a\xd2\x43\xae\x2b\x6e\x5d\x63\xa9\xd0\x14\xa1\x52\x52\xfd\xc7\x54\xa1\x2e\x64\xae\x31\xea\x42\xf6\xde\x3d\x35\x64\xe9\xb8\x36\xe5\x54\xfd\x6c\xd6\x1f\x09\x82\xb8\xba\x0e\x25\x19\x8e\xb2\x36\x23\x75\xe3\x8f\xa0\xa9\x8f\x46\xa2\x74\x62\x74\xc9\x18\x8b\x4d\x7f\xf7\x90\xdb\xfb\x90\x81\xcd\x2d\xf6\xde\xf7\xef\x6f\x79\x71\x53\x97\x4f\x7f\xe3\x2c\x9e\xcd\x6e\xba\x74\x1b\xb9\x76\x68\x85\x25\x34\x0e\xc6\xea\xe2\x2d\x2d\xf9\x9d\x1b\x96\xef\x7a\xb7\xde\x72\x63\xfc\x01\x16\x02\x8f\x94\xc2\x02\x00\x00")
+
+func migrations_gateway14_queued_paymentSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway14_queued_paymentSql,
+		"migrations_gateway/14_queued_payment.sql",
+	)
+}
+
+func migrations_gateway14_queued_paymentSql() (*asset, error) {
+	bytes, err := migrations_gateway14_queued_paymentSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/14_queued_payment.sql", size: 706, mode: os.FileMode(420), modTime: time.Unix(1791974593, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_gateway15_payment_callbackSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x85\x52\x4d\x6b\x83\x40\x10\xbd\xfb\x2b\xe6\x96\x84\x46\x48\xa1\xf4\x92\x93\x8d\x5b\x90\x1a\x15\x51\x68\x4e\xb2\x59\x07\xbb\x74\xd5\xb0\x3b\x49\xdb\x7f\xdf\x4d\x93\x48\x34\x1f\xbd\x2d\xcc\xfb\x98\xb7\xf3\x5c\x17\x1e\x6a\x59\x69\x4e\x08\xf9\xc6\x59\xa4\xcc\xcb\x18\x64\xde\x4b\xc8\x20\xe1\x3f\x35\x36\xb4\xe0\x4a\xad\xb9\xf8\x84\xb1\x03\x20\x4b\x90\x0d\x61\x85\x1a\x92\x34\x58\x7a\xe9\x0a\xde\xd8\x0a\xbc\x3c\x8b\x83\xc8\xb2\x97\x2c\xca\xa6\x16\x47\x9a\x37\x86\x0b\x92\x6d\x53\x58\xce\x8e\x6b\xf1\xc1\xf5\xf8\xf9\x69\x02\x51\x9c\x41\x94\x87\xe1\x1e\xb6\x39\x58\xdc\x82\x80\xcf\x5e\xbd\x3c\xcc\x60\x34\xda\xa3\xb7\x5a\x01\xe1\x37\xf5\x24\x0c\x71\xda\x9a\x8e\xfe\x38\xeb\x3b\x9c\x2f\x72\x07\x3a\x70\x52\x58\xee\x23\xae\x65\x65\xd3\x76\xb3\x93\xa6\x46\xb3\x55\x54\x88\xb6\x44\x73\x58\x68\x88\xe0\x44\x58\x6f\xc8\x74\x9f\x75\xe1\x33\xfb\xb3\xe1\x86\x0a\xd4\xba\xd5\xfd\x5c\x83\x6d\x84\x46\x7b\x9f\xb2\xe0\x04\xa5\x7d\x90\xac\xb1\x97\xb1\xb1\xdc\xe2\x68\x79\x13\x54\xa2\x92\x3b\xd4\x03\x99\xf3\xc5\x9d\xc9\xfc\x54\x80\x20\xf2\xd9\x7b\x77\x1d\x71\x6c\x40\x31\xb8\x6a\x1c\x5d\x76\xa4\x0f\xf9\x57\xf1\x78\x92\x6b\x4a\x87\xd1\x74\x98\xce\x4a\x3a\xee\x59\x6b\xfd\xf6\xab\x71\xfc\x34\x4e\xae\xb7\x76\xee\xfc\x02\xda\xaa\x2b\x1d\xe3\x02\x00\x00")
+
+func migrations_gateway15_payment_callbackSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_gateway15_payment_callbackSql,
+		"migrations_gateway/15_payment_callback.sql",
+	)
+}
+
+func migrations_gateway15_payment_callbackSql() (*asset, error) {
+	bytes, err := migrations_gateway15_payment_callbackSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_gateway/15_payment_callback.sql", size: 739, mode: os.FileMode(420), modTime: time.Unix(1791977579, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _migrations_compliance01_initSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x92\x31\x6f\xf2\x30\x10\x86\x77\xff\x8a\x1b\x41\x1f\x2c\x9f\x0a\x0b\x53\x0a\xae\x14\x15\x02\x8d\xec\x81\x29\xba\xe2\x03\xac\x62\x1b\x39\xa6\xd0\xfe\xfa\x2a\x4d\xa1\xb8\x0a\x91\x58\xe3\xe7\x2e\xef\x73\x7a\xfb\x7d\xf8\x67\xf4\xc6\x63\x20\x90\x7b\x36\xce\x79\x22\x38\x88\xe4\x71\xca\x21\x39\x84\xad\xf3\xfa\x93\x94\xf0\x68\x4b\x5c\x05\xed\x2c\x74\x18\x80\x56\xa0\x6d\xa0\x0d\x79\x58\xe4\xe9\x2c\xc9\x97\xf0\xcc\x97\x90\x48\x31\x4f\xb3\x71\xce\x67\x3c\x13\x3d\x06\x10\x7e\xe7\x0a\xad\x60\xb5\x45\xdf\x19\x3e\x74\x21\x9b\x0b\xc8\xe4\x74\x5a\x31\x86\x8c\x83\x77\xf4\x8d\x8f\xd7\x0b\x4e\xca\x43\xa0\x53\x88\x00\xbc\x64\x2c\x30\x80\xc2\x40\x41\x1b\x8a\x10\x85\x01\xe3\x41\xd6\x1d\xb1\x3f\xaa\xbb\x9d\x3b\x92\x7a\x4a\xef\xd2\xb3\x68\xe8\x12\xfd\xff\x60\x10\x67\x57\xce\xa0\xb6\xcd\xef\x20\xb3\xf4\x45\xf2\x0a\xdb\x1f\x5e\x77\x7a\x55\xbc\xd1\x47\x7d\x9f\xc1\xb0\x11\xc3\x3a\x61\xa3\xe5\x4d\x1f\x59\x92\xbf\xcb\x68\xad\x8b\x76\xa9\xb5\x2e\xda\xbc\x7e\x90\x36\xa7\x0a\x39\x94\xe4\xab\x42\xdc\xdc\xd1\x66\x5b\x2d\xa8\xcf\x07\x9d\xe8\x5f\xbd\xf3\xde\xee\xf7\x41\xae\xab\x3d\x71\x47\xcb\x26\xf9\x7c\xd1\x56\xed\x51\x44\x9c\x1b\xd1\xf4\x55\x96\xe4\x47\xec\x6b\x00\xe8\x0f\x24\xef\x3c\x03\x00\x00")
+
+func migrations_compliance01_initSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_migrations_compliance01_initSql,
+		"migrations_compliance/01_init.sql",
+	)
+}
+
+func migrations_compliance01_initSql() (*asset, error) {
+	bytes, err := migrations_compliance01_initSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "migrations_compliance/01_init.sql", size: 828, mode: os.FileMode(420), modTime: time.Unix(1791958044, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"migrations_gateway/01_init.sql":            migrations_gateway01_initSql,
+	"migrations_gateway/02_correlation_id.sql":  migrations_gateway02_correlation_idSql,
+	"migrations_gateway/03_payment_payload.sql": migrations_gateway03_payment_payloadSql,
+	"migrations_gateway/04_retired_account.sql": migrations_gateway04_retired_accountSql,
+	"migrations_gateway/05_conversion.sql": migrations_gateway05_conversionSql,
+	"migrations_gateway/06_idempotent_payment.sql": migrations_gateway06_idempotent_paymentSql,
+	"migrations_gateway/07_counterparty_stats.sql": migrations_gateway07_counterparty_statsSql,
+	"migrations_gateway/08_reconciliation.sql": migrations_gateway08_reconciliationSql,
+	"migrations_gateway/09_event.sql": migrations_gateway09_eventSql,
+	"migrations_gateway/10_internal_transfer.sql": migrations_gateway10_internal_transferSql,
+	"migrations_gateway/11_leader_handoff.sql": migrations_gateway11_leader_handoffSql,
+	"migrations_gateway/12_account_generation.sql": migrations_gateway12_account_generationSql,
+	"migrations_gateway/13_received_payment_dispute.sql": migrations_gateway13_received_payment_disputeSql,
+	"migrations_gateway/14_queued_payment.sql": migrations_gateway14_queued_paymentSql,
+	"migrations_gateway/15_payment_callback.sql": migrations_gateway15_payment_callbackSql,
+	"migrations_compliance/01_init.sql":         migrations_compliance01_initSql,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"migrations_compliance": &bintree{nil, map[string]*bintree{
+		"01_init.sql": &bintree{migrations_compliance01_initSql, map[string]*bintree{}},
+	}},
+	"migrations_gateway": &bintree{nil, map[string]*bintree{
+		"01_init.sql":            &bintree{migrations_gateway01_initSql, map[string]*bintree{}},
+		"02_correlation_id.sql":  &bintree{migrations_gateway02_correlation_idSql, map[string]*bintree{}},
+		"03_payment_payload.sql": &bintree{migrations_gateway03_payment_payloadSql, map[string]*bintree{}},
+		"04_retired_account.sql": &bintree{migrations_gateway04_retired_accountSql, map[string]*bintree{}},
+		"05_conversion.sql": &bintree{migrations_gateway05_conversionSql, map[string]*bintree{}},
+		"06_idempotent_payment.sql": &bintree{migrations_gateway06_idempotent_paymentSql, map[string]*bintree{}},
+		"07_counterparty_stats.sql": &bintree{migrations_gateway07_counterparty_statsSql, map[string]*bintree{}},
+		"08_reconciliation.sql": &bintree{migrations_gateway08_reconciliationSql, map[string]*bintree{}},
+		"09_event.sql": &bintree{migrations_gateway09_eventSql, map[string]*bintree{}},
+		"10_internal_transfer.sql": &bintree{migrations_gateway10_internal_transferSql, map[string]*bintree{}},
+		"11_leader_handoff.sql": &bintree{migrations_gateway11_leader_handoffSql, map[string]*bintree{}},
+		"12_account_generation.sql": &bintree{migrations_gateway12_account_generationSql, map[string]*bintree{}},
+		"13_received_payment_dispute.sql": &bintree{migrations_gateway13_received_payment_disputeSql, map[string]*bintree{}},
+		"14_queued_payment.sql": &bintree{migrations_gateway14_queued_paymentSql, map[string]*bintree{}},
+		"15_payment_callback.sql": &bintree{migrations_gateway15_payment_callbackSql, map[string]*bintree{}},
+	}},
+}}
+
+// RestoreAsset restores an asset under the given directory
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreAssets restores an asset under the given directory recursively
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(cannonicalName, "/")...)...)
+}
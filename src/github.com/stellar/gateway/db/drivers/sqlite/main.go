@@ -0,0 +1,463 @@
+package sqlite
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	// To load sqlite driver
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stellar/gateway/db/entities"
+)
+
+//go:generate go-bindata -ignore .+\.go$ -pkg sqlite -o bindata.go ./migrations_gateway ./migrations_compliance
+
+// Driver implements Driver interface using SQLite database file. It's meant for development, tests
+// and running `bridge` commands locally, not for servers sharing a DB.
+type Driver struct {
+	database *sqlx.DB
+}
+
+// Init opens a database file, url is a path of the file
+func (d *Driver) Init(url string) (err error) {
+	d.database, err = sqlx.Connect("sqlite3", url)
+	if err != nil {
+		return
+	}
+	// SQLite allows a single writer, concurrent connections fail with "database is locked"
+	d.database.SetMaxOpenConns(1)
+	return
+}
+
+func (d *Driver) DB() *sqlx.DB {
+	return d.database
+}
+
+// MigrateUp migrates DB using migrate files
+func (d *Driver) MigrateUp(component string) (migrationsApplied int, err error) {
+	source := d.getAssetMigrationSource(component)
+	migrationsApplied, err = migrate.Exec(d.database.DB, "sqlite3", source, migrate.Up)
+	return
+}
+
+// Insert inserts the entity to a DB
+func (d *Driver) Insert(object entities.Entity) (id int64, err error) {
+	value, tableName, err := getTypeData(object)
+
+	if err != nil {
+		return 0, err
+	}
+
+	fieldsCount := value.NumField()
+	var fieldNames []string
+	var fieldValues []string
+
+	for i := 0; i < fieldsCount; i++ {
+		field := value.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "" {
+			continue
+		}
+		fieldNames = append(fieldNames, tag)
+		fieldValues = append(fieldValues, ":"+tag)
+	}
+
+	query := "INSERT INTO " + tableName + " (" + strings.Join(fieldNames, ", ") + ") VALUES (" + strings.Join(fieldValues, ", ") + ");"
+
+	var result sql.Result
+	switch object := object.(type) {
+	case *entities.AuthorizedTransaction:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.AllowedFi:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.AllowedUser:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.SentTransaction:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.ReceivedPayment:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.DailyVolume:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.BackfillCursor:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.ListenerCursor:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.RetiredAccount:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.Conversion:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.IdempotentPayment:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.CounterpartyStats:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.Reconciliation:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.Event:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.PaymentRequest:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.AccountGeneration:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.QueuedPayment:
+		result, err = d.database.NamedExec(query, object)
+	case *entities.PaymentCallback:
+		result, err = d.database.NamedExec(query, object)
+	}
+
+	if err != nil {
+		return
+	}
+
+	id, err = result.LastInsertId()
+
+	if id == 0 {
+		// Not autoincrement
+		if object.GetID() == nil {
+			return 0, fmt.Errorf("Not autoincrement but ID nil")
+		}
+		id = *object.GetID()
+	}
+
+	if err == nil {
+		object.SetID(id)
+		object.SetExists()
+	}
+
+	return
+}
+
+// Update updates the entity to a DB
+func (d *Driver) Update(object entities.Entity) (err error) {
+	value, tableName, err := getTypeData(object)
+
+	if err != nil {
+		return err
+	}
+
+	fieldsCount := value.NumField()
+
+	query := "UPDATE " + tableName + " SET "
+	var fields []string
+
+	for i := 0; i < fieldsCount; i++ {
+		field := value.Field(i)
+		if field.Tag.Get("db") == "id" || field.Tag.Get("db") == "" {
+			continue
+		}
+		fields = append(fields, field.Tag.Get("db")+" = :"+field.Tag.Get("db"))
+	}
+
+	query += strings.Join(fields, ", ") + " WHERE id = :id;"
+
+	switch object := object.(type) {
+	case *entities.AuthorizedTransaction:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.AllowedFi:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.AllowedUser:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.SentTransaction:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.ReceivedPayment:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.DailyVolume:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.BackfillCursor:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.ListenerCursor:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.RetiredAccount:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Conversion:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.IdempotentPayment:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.CounterpartyStats:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Reconciliation:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.Event:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.PaymentRequest:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.AccountGeneration:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.QueuedPayment:
+		_, err = d.database.NamedExec(query, object)
+	case *entities.PaymentCallback:
+		_, err = d.database.NamedExec(query, object)
+	}
+
+	return
+}
+
+// Delete delets the entity from a DB
+func (d *Driver) Delete(object entities.Entity) (err error) {
+	_, tableName, err := getTypeData(object)
+
+	if err != nil {
+		return
+	}
+
+	query := "DELETE FROM " + tableName + " WHERE id = :id;"
+	_, err = d.database.NamedExec(query, object)
+
+	return
+}
+
+// GetOne returns a single entity based on a seach conditions
+func (d *Driver) GetOne(object entities.Entity, where string, params ...interface{}) (entities.Entity, error) {
+	_, tableName, err := getTypeData(object)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.database.Get(object, "SELECT * FROM "+tableName+" WHERE "+where+" LIMIT 1;", params...)
+	if err != nil {
+		if err.Error() == "sql: no rows in result set" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	object.SetExists() // Mark this entity as existing
+	return object, err
+}
+
+// AcquireLease acquires or renews a lease. An expired lease or a lease of the same holder is
+// taken over by the update, the lease is then held by the holder in the DB.
+func (d *Driver) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	expiresAt := fmt.Sprintf("+%d seconds", int64(ttl/time.Second))
+
+	_, err := d.database.Exec(
+		"INSERT OR IGNORE INTO LeaderLease (name, holder, expires_at) VALUES (?, ?, datetime('now', ?));",
+		name,
+		holder,
+		expiresAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = d.database.Exec(
+		`UPDATE LeaderLease SET holder = ?, expires_at = datetime('now', ?)
+		WHERE name = ? AND (holder = ? OR expires_at < datetime('now'));`,
+		holder,
+		expiresAt,
+		name,
+		holder,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	var current string
+	err = d.database.Get(&current, "SELECT holder FROM LeaderLease WHERE name = ?;", name)
+	if err != nil {
+		return false, err
+	}
+	return current == holder, nil
+}
+
+// TransferLease gives a lease held by `from` to `to`, the update is skipped when the lease has
+// another holder
+func (d *Driver) TransferLease(name, from, to string, ttl time.Duration) (bool, error) {
+	result, err := d.database.Exec(
+		"UPDATE LeaderLease SET holder = ?, expires_at = datetime('now', ?) WHERE name = ? AND holder = ?;",
+		to,
+		fmt.Sprintf("+%d seconds", int64(ttl/time.Second)),
+		name,
+		from,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows == 1, err
+}
+
+// TakeOverLease gives a lease to holder regardless of its current holder
+func (d *Driver) TakeOverLease(name, holder string, ttl time.Duration) error {
+	_, err := d.database.Exec(
+		"INSERT OR REPLACE INTO LeaderLease (name, holder, expires_at) VALUES (?, ?, datetime('now', ?));",
+		name,
+		holder,
+		fmt.Sprintf("+%d seconds", int64(ttl/time.Second)),
+	)
+	return err
+}
+
+// RequestHandoff replaces a handoff request of a lease
+func (d *Driver) RequestHandoff(name, holder, version string) error {
+	_, err := d.database.Exec(
+		"INSERT OR REPLACE INTO LeaderHandoff (name, holder, version, status) VALUES (?, ?, ?, ?);",
+		name,
+		holder,
+		version,
+		entities.LeaderHandoffStatusRequested,
+	)
+	return err
+}
+
+// GetHandoff returns a handoff request of a lease, nil when there is none
+func (d *Driver) GetHandoff(name string) (*entities.LeaderHandoff, error) {
+	handoff := &entities.LeaderHandoff{}
+	err := d.database.Get(handoff, "SELECT * FROM LeaderHandoff WHERE name = ?;", name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return handoff, nil
+}
+
+// SetHandoffStatus updates a status of a handoff request of holder
+func (d *Driver) SetHandoffStatus(name, holder, status string) error {
+	_, err := d.database.Exec(
+		"UPDATE LeaderHandoff SET status = ? WHERE name = ? AND holder = ?;",
+		status,
+		name,
+		holder,
+	)
+	return err
+}
+
+// GetMany returns many entities
+func (d *Driver) GetMany(slice interface{}, where, order, offset, limit *string, params ...interface{}) (err error) {
+	_, tableName, err := getTypeData(slice)
+	if err != nil {
+		return
+	}
+
+	var query bytes.Buffer
+
+	query.WriteString("SELECT * FROM " + tableName)
+
+	if where != nil {
+		query.WriteString(" WHERE " + *where)
+	}
+
+	if order != nil {
+		query.WriteString(" ORDER BY " + *order)
+	}
+
+	if limit != nil && offset != nil {
+		query.WriteString(fmt.Sprintf(" LIMIT %s, %s", *offset, *limit))
+	} else if limit != nil {
+		query.WriteString(" LIMIT " + *limit)
+	}
+
+	query.WriteString(";")
+
+	switch slice := slice.(type) {
+	case *[]*entities.ReceivedPayment:
+		err = d.database.Select(slice, query.String(), params...)
+		tmp := *slice
+		for i := range tmp {
+			tmp[i].SetExists()
+		}
+		slice = &tmp
+	case *[]*entities.SentTransaction:
+		err = d.database.Select(slice, query.String(), params...)
+		tmp := *slice
+		for i := range tmp {
+			tmp[i].SetExists()
+		}
+		slice = &tmp
+	case *[]*entities.DailyVolume:
+		err = d.database.Select(slice, query.String(), params...)
+		tmp := *slice
+		for i := range tmp {
+			tmp[i].SetExists()
+		}
+		slice = &tmp
+	}
+
+	if err != nil && err.Error() == "sql: no rows in result set" {
+		return nil
+	}
+
+	return
+}
+
+func getTypeData(object interface{}) (typeValue reflect.Type, tableName string, err error) {
+	switch object := object.(type) {
+	case *entities.AuthorizedTransaction:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "AuthorizedTransaction"
+	case *entities.AllowedFi:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "AllowedFI"
+	case *entities.AllowedUser:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "AllowedUser"
+	case *entities.SentTransaction:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "SentTransaction"
+	case *entities.ReceivedPayment:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "ReceivedPayment"
+	case *entities.DailyVolume:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "DailyVolume"
+	case *entities.BackfillCursor:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "BackfillCursor"
+	case *entities.ListenerCursor:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "ListenerCursor"
+	case *entities.RetiredAccount:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "RetiredAccount"
+	case *entities.Conversion:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Conversion"
+	case *entities.IdempotentPayment:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "IdempotentPayment"
+	case *entities.CounterpartyStats:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "CounterpartyStats"
+	case *entities.Reconciliation:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Reconciliation"
+	case *entities.Event:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "Event"
+	case *entities.PaymentRequest:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "PaymentRequest"
+	case *entities.AccountGeneration:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "AccountGeneration"
+	case *entities.QueuedPayment:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "QueuedPayment"
+	case *entities.PaymentCallback:
+		typeValue = reflect.TypeOf(*object)
+		tableName = "PaymentCallback"
+	case *[]*entities.SentTransaction:
+		tableName = "SentTransaction"
+	case *[]*entities.ReceivedPayment:
+		tableName = "ReceivedPayment"
+	case *[]*entities.DailyVolume:
+		tableName = "DailyVolume"
+	default:
+		return typeValue, tableName, fmt.Errorf("Unknown entity type: %T", object)
+	}
+	return
+}
+
+func (d *Driver) getAssetMigrationSource(component string) (source *migrate.AssetMigrationSource) {
+	source = &migrate.AssetMigrationSource{
+		Asset:    Asset,
+		AssetDir: AssetDir,
+		Dir:      "migrations_" + component,
+	}
+	return
+}
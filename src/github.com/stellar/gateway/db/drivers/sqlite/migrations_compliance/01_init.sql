@@ -0,0 +1,32 @@
+-- +migrate Up
+CREATE TABLE AuthorizedTransaction (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  transaction_id char(64) NOT NULL,
+  memo varchar(64) NOT NULL,
+  transaction_xdr text NOT NULL,
+  authorized_at datetime NOT NULL,
+  data text NOT NULL
+);
+
+CREATE TABLE AllowedFI (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  name varchar(255) NOT NULL,
+  domain varchar(255) NOT NULL UNIQUE,
+  public_key char(56) NOT NULL UNIQUE,
+  allowed_at datetime NOT NULL
+);
+
+CREATE TABLE AllowedUser (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  fi_name varchar(255) NOT NULL,
+  fi_domain varchar(255) NOT NULL,
+  fi_public_key char(56) NOT NULL,
+  user_id varchar(255) NOT NULL,
+  allowed_at datetime NOT NULL,
+  UNIQUE (fi_public_key, user_id)
+);
+
+-- +migrate Down
+DROP TABLE AuthorizedTransaction;
+DROP TABLE AllowedFI;
+DROP TABLE AllowedUser;
@@ -0,0 +1,84 @@
+-- +migrate Up
+-- SQLite schema starts at the current MySQL and Postgres schema
+CREATE TABLE ReceivedPayment (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  operation_id varchar(255) NOT NULL UNIQUE,
+  processed_at datetime NOT NULL,
+  paging_token varchar(255) NOT NULL,
+  status varchar(255) NOT NULL,
+  asset_code varchar(12) NOT NULL DEFAULT '',
+  asset_issuer varchar(56) NOT NULL DEFAULT '',
+  amount varchar(255) NOT NULL DEFAULT '',
+  backfill boolean NOT NULL DEFAULT 0
+);
+
+CREATE TABLE SentTransaction (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  transaction_id varchar(64) NOT NULL,
+  status varchar(10) NOT NULL,
+  source varchar(56) NOT NULL,
+  submitted_at datetime NOT NULL,
+  succeeded_at datetime DEFAULT NULL,
+  ledger bigint DEFAULT NULL,
+  envelope_xdr text NOT NULL,
+  result_xdr varchar(255) DEFAULT NULL
+);
+
+CREATE TABLE DailyVolume (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  date varchar(10) NOT NULL,
+  asset_code varchar(12) NOT NULL,
+  asset_issuer varchar(56) NOT NULL,
+  direction varchar(10) NOT NULL,
+  count bigint NOT NULL,
+  sum bigint NOT NULL,
+  fees bigint NOT NULL,
+  UNIQUE (date, asset_code, asset_issuer, direction)
+);
+
+CREATE TABLE BackfillCursor (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  account_id varchar(56) NOT NULL UNIQUE,
+  paging_token varchar(255) NOT NULL,
+  updated_at datetime NOT NULL
+);
+
+CREATE TABLE PaymentRequest (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  request_id varchar(64) NOT NULL UNIQUE,
+  destination varchar(56) NOT NULL,
+  asset_code varchar(12) NOT NULL,
+  asset_issuer varchar(56) NOT NULL,
+  amount varchar(255) NOT NULL,
+  memo_type varchar(10) NOT NULL,
+  memo varchar(255) NOT NULL,
+  status varchar(10) NOT NULL,
+  created_at datetime NOT NULL,
+  expires_at datetime DEFAULT NULL,
+  fulfilled_at datetime DEFAULT NULL,
+  operation_id varchar(255) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX payment_request_memo ON PaymentRequest (memo_type, memo);
+
+CREATE TABLE LeaderLease (
+  name varchar(64) NOT NULL PRIMARY KEY,
+  holder varchar(255) NOT NULL,
+  expires_at datetime NOT NULL
+);
+
+CREATE TABLE ListenerCursor (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  paging_token varchar(255) NOT NULL,
+  after_payment_id bigint NOT NULL,
+  set_at datetime NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE ReceivedPayment;
+DROP TABLE SentTransaction;
+DROP TABLE DailyVolume;
+DROP TABLE BackfillCursor;
+DROP TABLE PaymentRequest;
+DROP TABLE LeaderLease;
+DROP TABLE ListenerCursor;
@@ -0,0 +1,19 @@
+-- +migrate Up
+ALTER TABLE SentTransaction ADD COLUMN correlation_id varchar(128) NOT NULL DEFAULT '';
+
+-- +migrate Down
+-- SQLite can't drop columns
+CREATE TABLE SentTransaction_down (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  transaction_id varchar(64) NOT NULL,
+  status varchar(10) NOT NULL,
+  source varchar(56) NOT NULL,
+  submitted_at datetime NOT NULL,
+  succeeded_at datetime DEFAULT NULL,
+  ledger bigint DEFAULT NULL,
+  envelope_xdr text NOT NULL,
+  result_xdr varchar(255) DEFAULT NULL
+);
+INSERT INTO SentTransaction_down SELECT id, transaction_id, status, source, submitted_at, succeeded_at, ledger, envelope_xdr, result_xdr FROM SentTransaction;
+DROP TABLE SentTransaction;
+ALTER TABLE SentTransaction_down RENAME TO SentTransaction;
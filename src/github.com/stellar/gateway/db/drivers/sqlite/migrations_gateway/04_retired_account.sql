@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE RetiredAccount (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  account_id varchar(56) NOT NULL,
+  merged_into varchar(56) NOT NULL,
+  operation_id varchar(255) NOT NULL,
+  transaction_hash varchar(64) NOT NULL DEFAULT '',
+  retired_at datetime NOT NULL,
+  reregistered_at datetime DEFAULT NULL
+);
+CREATE INDEX retired_account_account_id ON RetiredAccount (account_id);
+
+-- +migrate Down
+DROP TABLE RetiredAccount;
@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE Conversion (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  operation_id varchar(255) NOT NULL,
+  status varchar(10) NOT NULL,
+  error_message text NOT NULL DEFAULT '',
+  send_asset_code varchar(12) NOT NULL,
+  send_asset_issuer varchar(56) NOT NULL DEFAULT '',
+  send_max varchar(50) NOT NULL,
+  send_amount varchar(50) NOT NULL DEFAULT '',
+  destination_asset_code varchar(12) NOT NULL,
+  destination_asset_issuer varchar(56) NOT NULL DEFAULT '',
+  destination_amount varchar(50) NOT NULL DEFAULT '',
+  estimated_price varchar(50) NOT NULL DEFAULT '',
+  transaction_hash varchar(64) NOT NULL DEFAULT '',
+  created_at datetime NOT NULL,
+  converted_at datetime DEFAULT NULL
+);
+CREATE UNIQUE INDEX conversion_operation_id ON Conversion (operation_id);
+
+-- +migrate Down
+DROP TABLE Conversion;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE IdempotentPayment (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  payment_id varchar(64) NOT NULL,
+  request_hash varchar(64) NOT NULL,
+  transaction_id varchar(64) NOT NULL,
+  response_status integer DEFAULT NULL,
+  response text DEFAULT NULL,
+  created_at datetime NOT NULL,
+  completed_at datetime DEFAULT NULL
+);
+CREATE UNIQUE INDEX idempotent_payment_payment_id ON IdempotentPayment (payment_id);
+
+-- +migrate Down
+DROP TABLE IdempotentPayment;
@@ -0,0 +1,36 @@
+-- +migrate Up
+CREATE TABLE CounterpartyStats (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  destination varchar(56) NOT NULL,
+  asset_code varchar(12) NOT NULL,
+  asset_issuer varchar(56) NOT NULL DEFAULT '',
+  payment_count bigint NOT NULL DEFAULT 0,
+  mean_amount double precision NOT NULL DEFAULT 0,
+  amount_m2 double precision NOT NULL DEFAULT 0,
+  amount_histogram text NOT NULL,
+  hour_histogram text NOT NULL,
+  updated_at datetime NOT NULL
+);
+CREATE UNIQUE INDEX counterparty_stats_destination_asset ON CounterpartyStats (destination, asset_code, asset_issuer);
+ALTER TABLE SentTransaction ADD COLUMN anomalies text DEFAULT NULL;
+
+-- +migrate Down
+DROP TABLE CounterpartyStats;
+-- SQLite can't drop columns
+CREATE TABLE SentTransaction_down (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  transaction_id varchar(64) NOT NULL,
+  status varchar(10) NOT NULL,
+  source varchar(56) NOT NULL,
+  submitted_at datetime NOT NULL,
+  succeeded_at datetime DEFAULT NULL,
+  ledger bigint DEFAULT NULL,
+  envelope_xdr text NOT NULL,
+  result_xdr varchar(255) DEFAULT NULL,
+  correlation_id varchar(128) NOT NULL DEFAULT '',
+  payload text DEFAULT NULL,
+  rebuilt_from bigint DEFAULT NULL
+);
+INSERT INTO SentTransaction_down SELECT id, transaction_id, status, source, submitted_at, succeeded_at, ledger, envelope_xdr, result_xdr, correlation_id, payload, rebuilt_from FROM SentTransaction;
+DROP TABLE SentTransaction;
+ALTER TABLE SentTransaction_down RENAME TO SentTransaction;
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE Reconciliation (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  date varchar(10) NOT NULL,
+  mismatches integer NOT NULL,
+  report text NOT NULL,
+  created_at datetime NOT NULL
+);
+CREATE UNIQUE INDEX reconciliation_date ON Reconciliation (date);
+
+-- +migrate Down
+DROP TABLE Reconciliation;
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE Event (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  type varchar(64) NOT NULL,
+  subject varchar(255) NOT NULL,
+  version integer NOT NULL,
+  payload text NOT NULL,
+  created_at datetime NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE Event;
@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE LeaderHandoff (
+  name varchar(64) NOT NULL PRIMARY KEY,
+  holder varchar(255) NOT NULL,
+  version varchar(64) NOT NULL,
+  status varchar(16) NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE LeaderHandoff;
@@ -0,0 +1,54 @@
+-- +migrate Up
+CREATE TABLE AccountGeneration (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  account_id varchar(56) NOT NULL,
+  generation bigint NOT NULL DEFAULT 1,
+  sequence bigint NOT NULL DEFAULT 0,
+  merged_at datetime DEFAULT NULL,
+  recreated_at datetime DEFAULT NULL,
+  updated_at datetime NOT NULL
+);
+CREATE UNIQUE INDEX account_generation_account_id ON AccountGeneration (account_id);
+ALTER TABLE PaymentRequest ADD COLUMN generation bigint NOT NULL DEFAULT 1;
+ALTER TABLE CounterpartyStats ADD COLUMN generation bigint NOT NULL DEFAULT 1;
+DROP INDEX counterparty_stats_destination_asset;
+CREATE UNIQUE INDEX counterparty_stats_destination_generation_asset ON CounterpartyStats (destination, generation, asset_code, asset_issuer);
+
+-- +migrate Down
+DROP TABLE AccountGeneration;
+-- SQLite can't drop columns
+CREATE TABLE PaymentRequest_down (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  request_id varchar(64) NOT NULL UNIQUE,
+  destination varchar(56) NOT NULL,
+  asset_code varchar(12) NOT NULL,
+  asset_issuer varchar(56) NOT NULL,
+  amount varchar(255) NOT NULL,
+  memo_type varchar(10) NOT NULL,
+  memo varchar(255) NOT NULL,
+  status varchar(10) NOT NULL,
+  created_at datetime NOT NULL,
+  expires_at datetime DEFAULT NULL,
+  fulfilled_at datetime DEFAULT NULL,
+  operation_id varchar(255) NOT NULL DEFAULT ''
+);
+INSERT INTO PaymentRequest_down SELECT id, request_id, destination, asset_code, asset_issuer, amount, memo_type, memo, status, created_at, expires_at, fulfilled_at, operation_id FROM PaymentRequest;
+DROP TABLE PaymentRequest;
+ALTER TABLE PaymentRequest_down RENAME TO PaymentRequest;
+CREATE INDEX payment_request_memo ON PaymentRequest (memo_type, memo);
+CREATE TABLE CounterpartyStats_down (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  destination varchar(56) NOT NULL,
+  asset_code varchar(12) NOT NULL,
+  asset_issuer varchar(56) NOT NULL DEFAULT '',
+  payment_count bigint NOT NULL DEFAULT 0,
+  mean_amount double precision NOT NULL DEFAULT 0,
+  amount_m2 double precision NOT NULL DEFAULT 0,
+  amount_histogram text NOT NULL,
+  hour_histogram text NOT NULL,
+  updated_at datetime NOT NULL
+);
+INSERT INTO CounterpartyStats_down SELECT id, destination, asset_code, asset_issuer, payment_count, mean_amount, amount_m2, amount_histogram, hour_histogram, updated_at FROM CounterpartyStats WHERE generation = 1;
+DROP TABLE CounterpartyStats;
+ALTER TABLE CounterpartyStats_down RENAME TO CounterpartyStats;
+CREATE UNIQUE INDEX counterparty_stats_destination_asset ON CounterpartyStats (destination, asset_code, asset_issuer);
@@ -0,0 +1,20 @@
+-- +migrate Up
+ALTER TABLE ReceivedPayment ADD COLUMN disputed_at datetime DEFAULT NULL;
+ALTER TABLE ReceivedPayment ADD COLUMN disputed_fields varchar(255) NOT NULL DEFAULT '';
+
+-- +migrate Down
+-- SQLite can't drop columns
+CREATE TABLE ReceivedPayment_down (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  operation_id varchar(255) NOT NULL UNIQUE,
+  processed_at datetime NOT NULL,
+  paging_token varchar(255) NOT NULL,
+  status varchar(255) NOT NULL,
+  asset_code varchar(12) NOT NULL DEFAULT '',
+  asset_issuer varchar(56) NOT NULL DEFAULT '',
+  amount varchar(255) NOT NULL DEFAULT '',
+  backfill boolean NOT NULL DEFAULT 0
+);
+INSERT INTO ReceivedPayment_down SELECT id, operation_id, processed_at, paging_token, status, asset_code, asset_issuer, amount, backfill FROM ReceivedPayment;
+DROP TABLE ReceivedPayment;
+ALTER TABLE ReceivedPayment_down RENAME TO ReceivedPayment;
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// AccountGeneration numbers lives of an account ID. An account merged away and created again
+// starts with a new sequence number, records of the previous generation (ex. counterparty
+// statistics, payment requests) are not mixed with the new one.
+type AccountGeneration struct {
+	exists    bool
+	ID        *int64 `db:"id" json:"-"`
+	AccountID string `db:"account_id" json:"account_id"`
+	// Generation is 1 for the first observed account, it's incremented when it's recreated
+	Generation int64 `db:"generation" json:"generation"`
+	// Sequence is the highest sequence number observed in this generation
+	Sequence int64 `db:"sequence" json:"sequence"`
+	// MergedAt is set when the account was merged (an observed account_merge, or it's missing in
+	// Horizon), the next time it exists it's a new generation
+	MergedAt    *utc.Time `db:"merged_at" json:"merged_at,omitempty"`
+	RecreatedAt *utc.Time `db:"recreated_at" json:"recreated_at,omitempty"`
+	UpdatedAt   utc.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// GetID returns ID of the entity
+func (e *AccountGeneration) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *AccountGeneration) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *AccountGeneration) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *AccountGeneration) SetExists() {
+	e.exists = true
+}
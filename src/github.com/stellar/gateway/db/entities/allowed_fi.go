@@ -1,17 +1,17 @@
 package entities
 
 import (
-	"time"
+	"github.com/stellar/gateway/utc"
 )
 
 // AllowedFi represents allowed FI
 type AllowedFi struct {
 	exists    bool
-	ID        *int64    `db:"id"`
-	Name      string    `db:"name"`
-	Domain    string    `db:"domain"`
-	PublicKey string    `db:"public_key"`
-	AllowedAt time.Time `db:"allowed_at"`
+	ID        *int64   `db:"id"`
+	Name      string   `db:"name"`
+	Domain    string   `db:"domain"`
+	PublicKey string   `db:"public_key"`
+	AllowedAt utc.Time `db:"allowed_at"`
 }
 
 // GetID returns ID of the entity
@@ -1,18 +1,18 @@
 package entities
 
 import (
-	"time"
+	"github.com/stellar/gateway/utc"
 )
 
 // AllowedUser represents allowed user
 type AllowedUser struct {
 	exists      bool
-	ID          *int64    `db:"id"`
-	FiName      string    `db:"fi_name"`
-	FiDomain    string    `db:"fi_domain"`
-	FiPublicKey string    `db:"fi_public_key"`
-	UserID      string    `db:"user_id"`
-	AllowedAt   time.Time `db:"allowed_at"`
+	ID          *int64   `db:"id"`
+	FiName      string   `db:"fi_name"`
+	FiDomain    string   `db:"fi_domain"`
+	FiPublicKey string   `db:"fi_public_key"`
+	UserID      string   `db:"user_id"`
+	AllowedAt   utc.Time `db:"allowed_at"`
 }
 
 // GetID returns ID of the entity
@@ -1,18 +1,18 @@
 package entities
 
 import (
-	"time"
+	"github.com/stellar/gateway/utc"
 )
 
 // AuthorizedTransaction represents authorized transaction
 type AuthorizedTransaction struct {
 	exists         bool
-	ID             *int64    `db:"id"`
-	TransactionID  string    `db:"transaction_id"`
-	Memo           string    `db:"memo"`
-	TransactionXdr string    `db:"transaction_xdr"`
-	AuthorizedAt   time.Time `db:"authorized_at"`
-	Data           string    `db:"data"`
+	ID             *int64   `db:"id"`
+	TransactionID  string   `db:"transaction_id"`
+	Memo           string   `db:"memo"`
+	TransactionXdr string   `db:"transaction_xdr"`
+	AuthorizedAt   utc.Time `db:"authorized_at"`
+	Data           string   `db:"data"`
 }
 
 // GetID returns ID of the entity
@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// BackfillCursor is a paging token of the last payment processed by a historical backfill of an
+// account. It's separate from the live listener cursor so backfills can be resumed.
+type BackfillCursor struct {
+	exists      bool
+	ID          *int64   `db:"id" json:"id"`
+	AccountID   string   `db:"account_id" json:"account_id"`
+	PagingToken string   `db:"paging_token" json:"paging_token"`
+	UpdatedAt   utc.Time `db:"updated_at" json:"updated_at"`
+}
+
+// GetID returns ID of the entity
+func (e *BackfillCursor) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *BackfillCursor) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *BackfillCursor) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *BackfillCursor) SetExists() {
+	e.exists = true
+}
@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+const (
+	// ConversionStatusPending is a status of a conversion that is being submitted
+	ConversionStatusPending = "pending"
+	// ConversionStatusSuccess is a status of a conversion applied to a ledger
+	ConversionStatusSuccess = "success"
+	// ConversionStatusFailed is a status of a conversion that was not submitted or failed, it's
+	// attempted again when the received payment is reprocessed
+	ConversionStatusFailed = "failed"
+)
+
+// Conversion is a path payment from the receiving account to itself converting a received
+// payment into the auto_conversion target asset. XLM is stored with `XLM` code like in
+// ReceivedPayment.
+type Conversion struct {
+	exists bool
+	ID     *int64 `db:"id" json:"-"`
+	// OperationID is the ID of the converted received payment
+	OperationID string `db:"operation_id" json:"operation_id"`
+	Status      string `db:"status" json:"status"`
+	// Error is a reason of a failed conversion
+	Error           string `db:"error_message" json:"error,omitempty"`
+	SendAssetCode   string `db:"send_asset_code" json:"send_asset_code"`
+	SendAssetIssuer string `db:"send_asset_issuer" json:"send_asset_issuer"`
+	SendMax         string `db:"send_max" json:"send_max"`
+	// SendAmount is the amount spent by the path payment, the rest stays in the receiving account
+	SendAmount             string `db:"send_amount" json:"send_amount,omitempty"`
+	DestinationAssetCode   string `db:"destination_asset_code" json:"destination_asset_code"`
+	DestinationAssetIssuer string `db:"destination_asset_issuer" json:"destination_asset_issuer"`
+	DestinationAmount      string `db:"destination_amount" json:"destination_amount"`
+	// EstimatedPrice is a price of the send asset in the destination asset estimated from order books
+	EstimatedPrice  string    `db:"estimated_price" json:"estimated_price"`
+	TransactionHash string    `db:"transaction_hash" json:"transaction_hash,omitempty"`
+	CreatedAt       utc.Time  `db:"created_at" json:"created_at"`
+	ConvertedAt     *utc.Time `db:"converted_at" json:"converted_at,omitempty"`
+}
+
+// GetID returns ID of the entity
+func (e *Conversion) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *Conversion) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *Conversion) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *Conversion) SetExists() {
+	e.exists = true
+}
@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// CounterpartyStats are rolling statistics of successful payments sent to a single destination
+// account in a single asset, updated incrementally by every payment. XLM is stored with `XLM`
+// code like in DailyVolume. A recreated destination starts new statistics of its generation.
+type CounterpartyStats struct {
+	exists      bool
+	ID          *int64 `db:"id" json:"-"`
+	Destination string `db:"destination" json:"destination"`
+	Generation  int64  `db:"generation" json:"generation"`
+	AssetCode   string `db:"asset_code" json:"asset_code"`
+	AssetIssuer string `db:"asset_issuer" json:"asset_issuer"`
+	Count       int64  `db:"payment_count" json:"count"`
+	// MeanAmount and AmountM2 (sum of squared differences from the mean) are updated with
+	// Welford's algorithm, amounts are in stroops
+	MeanAmount float64 `db:"mean_amount" json:"mean_amount"`
+	AmountM2   float64 `db:"amount_m2" json:"-"`
+	// AmountHistogram is a JSON object of payment counts by amount bucket, see anomaly.Bucket
+	AmountHistogram string `db:"amount_histogram" json:"-"`
+	// HourHistogram is a JSON array of payment counts by UTC hour of day
+	HourHistogram string   `db:"hour_histogram" json:"-"`
+	UpdatedAt     utc.Time `db:"updated_at" json:"updated_at"`
+}
+
+// GetID returns ID of the entity
+func (e *CounterpartyStats) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *CounterpartyStats) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *CounterpartyStats) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *CounterpartyStats) SetExists() {
+	e.exists = true
+}
@@ -0,0 +1,52 @@
+package entities
+
+// DailyVolumeDirection type represents direction of payments aggregated in DailyVolume
+type DailyVolumeDirection string
+
+const (
+	// DailyVolumeDirectionSent is a direction of payments sent by the bridge server
+	DailyVolumeDirectionSent DailyVolumeDirection = "sent"
+	// DailyVolumeDirectionReceived is a direction of payments received by the bridge server
+	DailyVolumeDirectionReceived DailyVolumeDirection = "received"
+	// DailyVolumeDirectionRefund is a direction of sent payments with `return` memo
+	DailyVolumeDirectionRefund DailyVolumeDirection = "refund"
+	// DailyVolumeDirectionInternal is a direction of payments sent to accounts of the bridge server
+	DailyVolumeDirectionInternal DailyVolumeDirection = "internal"
+)
+
+// DailyVolume represents aggregated payments of a single asset and direction in a single day (UTC)
+type DailyVolume struct {
+	exists      bool
+	ID          *int64               `db:"id" json:"id"`
+	Date        string               `db:"date" json:"date"` // YYYY-MM-DD
+	AssetCode   string               `db:"asset_code" json:"asset_code"`
+	AssetIssuer string               `db:"asset_issuer" json:"asset_issuer"`
+	Direction   DailyVolumeDirection `db:"direction" json:"direction"`
+	Count       int64                `db:"count" json:"count"`
+	Sum         int64                `db:"sum" json:"sum"`   // in stroops
+	Fees        int64                `db:"fees" json:"fees"` // in stroops
+}
+
+// GetID returns ID of the entity
+func (e *DailyVolume) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *DailyVolume) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *DailyVolume) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *DailyVolume) SetExists() {
+	e.exists = true
+}
@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// Event is a state transition of a payment or a transaction in the event journal. Events are
+// never updated, their IDs are sequence numbers consumers read the journal after.
+type Event struct {
+	exists bool
+	ID     *int64 `db:"id"`
+	// Type is one of events package types, ex. `transaction_succeeded`
+	Type string `db:"type"`
+	// Subject identifies what changed: a transaction hash, an operation ID of a received payment
+	// or a request ID
+	Subject string `db:"subject"`
+	// Version is the version of Payload of the type
+	Version int `db:"version"`
+	// Payload is a JSON object with details of the transition
+	Payload   string   `db:"payload"`
+	CreatedAt utc.Time `db:"created_at"`
+}
+
+// GetID returns ID of the entity
+func (e *Event) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *Event) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *Event) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *Event) SetExists() {
+	e.exists = true
+}
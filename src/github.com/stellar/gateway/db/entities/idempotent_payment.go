@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// IdempotentPayment is a /payment request sent with a client-supplied `id`. It's stored before
+// its transaction is submitted and completed with the response of the request, so a request
+// repeated with the same id returns the response of the first one.
+type IdempotentPayment struct {
+	exists bool
+	ID     *int64 `db:"id"`
+	// PaymentID is the `id` param of the request
+	PaymentID string `db:"payment_id"`
+	// RequestHash is a hash of other params of the request, see bridge.PaymentRequest.Hash
+	RequestHash string `db:"request_hash"`
+	// TransactionID is the hash of the submitted transaction, its envelope is in SentTransaction
+	TransactionID string `db:"transaction_id"`
+	// ResponseStatus and Response are the HTTP status and body of the response, nil until the
+	// response is known
+	ResponseStatus *int      `db:"response_status"`
+	Response       *string   `db:"response"`
+	CreatedAt      utc.Time  `db:"created_at"`
+	CompletedAt    *utc.Time `db:"completed_at"`
+}
+
+// IsCompleted returns true when the response of the payment is stored
+func (e *IdempotentPayment) IsCompleted() bool {
+	return e.Response != nil
+}
+
+// Complete stores the response of the payment
+func (e *IdempotentPayment) Complete(status int, body []byte) {
+	response := string(body)
+	e.ResponseStatus = &status
+	e.Response = &response
+	now := utc.Now()
+	e.CompletedAt = &now
+}
+
+// GetID returns ID of the entity
+func (e *IdempotentPayment) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *IdempotentPayment) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *IdempotentPayment) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *IdempotentPayment) SetExists() {
+	e.exists = true
+}
@@ -0,0 +1,26 @@
+package entities
+
+// Statuses of a LeaderHandoff
+const (
+	// LeaderHandoffStatusRequested is a handoff the leader has not answered yet
+	LeaderHandoffStatusRequested = "requested"
+	// LeaderHandoffStatusReleased is a handoff of a leader that transferred the lease to the holder
+	// of the request
+	LeaderHandoffStatusReleased = "released"
+	// LeaderHandoffStatusDeclined is a handoff the leader refused, ex. because the holder of the
+	// request runs the same version
+	LeaderHandoffStatusDeclined = "declined"
+	// LeaderHandoffStatusTakenOver is a handoff not answered in time, its holder took the lease
+	// over
+	LeaderHandoffStatusTakenOver = "taken_over"
+)
+
+// LeaderHandoff is a request of a replica (Holder) running Version to take over a leader lease
+// from its current holder. There is a single request per lease, a new request replaces the
+// previous one. Like LeaderLease it's written by the drivers directly, it's not an Entity.
+type LeaderHandoff struct {
+	Name    string `db:"name"`
+	Holder  string `db:"holder"`
+	Version string `db:"version"`
+	Status  string `db:"status"`
+}
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// ListenerCursor is a paging token the payment listener starts from, set by `bridge listener cursor
+// --set`. It's used until the listener processes a payment after AfterPaymentID.
+type ListenerCursor struct {
+	exists      bool
+	ID          *int64 `db:"id" json:"id"`
+	PagingToken string `db:"paging_token" json:"paging_token"`
+	// AfterPaymentID is the ID of the last ReceivedPayment when the cursor was set
+	AfterPaymentID int64    `db:"after_payment_id" json:"after_payment_id"`
+	SetAt          utc.Time `db:"set_at" json:"set_at"`
+}
+
+// GetID returns ID of the entity
+func (e *ListenerCursor) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *ListenerCursor) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *ListenerCursor) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *ListenerCursor) SetExists() {
+	e.exists = true
+}
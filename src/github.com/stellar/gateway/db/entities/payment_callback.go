@@ -0,0 +1,88 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// PaymentCallbackStatus type represents status of a payment callback
+type PaymentCallbackStatus string
+
+const (
+	// PaymentCallbackStatusPending is a status of callbacks waiting for the outcome of the
+	// transaction or for a successful delivery
+	PaymentCallbackStatusPending PaymentCallbackStatus = "pending"
+	// PaymentCallbackStatusDelivered is a status of callbacks whose URL responded with 200 OK
+	PaymentCallbackStatusDelivered PaymentCallbackStatus = "delivered"
+	// PaymentCallbackStatusFailed is a status of callbacks that could not be delivered in the
+	// allowed number of attempts
+	PaymentCallbackStatusFailed PaymentCallbackStatus = "failed"
+)
+
+// Outcomes of transactions reported by payment callbacks
+const (
+	// PaymentCallbackSuccess is reported when the transaction has been applied to a ledger
+	PaymentCallbackSuccess = "success"
+	// PaymentCallbackFailed is reported when the transaction has failed
+	PaymentCallbackFailed = "failed"
+	// PaymentCallbackNotFound is reported when the outcome of a submission was unknown and
+	// Horizon did not find the transaction in time
+	PaymentCallbackNotFound = "not_found"
+)
+
+// PaymentCallback is a `callback` URL of a /payment request. It's stored before the transaction
+// is submitted and the outcome of the transaction is posted to the URL by the confirmation
+// worker, so callbacks of submissions interrupted by a restart are still delivered.
+type PaymentCallback struct {
+	exists bool
+	ID     *int64 `db:"id"`
+	// TransactionID is the hash of the submitted transaction
+	TransactionID string `db:"transaction_id"`
+	// PaymentID is the `id` param of the request, empty when it was not sent
+	PaymentID string                `db:"payment_id"`
+	URL       string                `db:"url"`
+	Status    PaymentCallbackStatus `db:"status"`
+	// TransactionStatus is the outcome of the transaction (PaymentCallbackSuccess,
+	// PaymentCallbackFailed or PaymentCallbackNotFound), empty until it's known
+	TransactionStatus string  `db:"transaction_status"`
+	Ledger            *uint64 `db:"ledger"`
+	// ResultCodes is a JSON horizon.ResultCodes of a failed transaction
+	ResultCodes *string `db:"result_codes"`
+	Attempts    int     `db:"attempts"`
+	// LastError is the error of the last failed delivery
+	LastError     string    `db:"last_error"`
+	CreatedAt     utc.Time  `db:"created_at"`
+	NextAttemptAt utc.Time  `db:"next_attempt_at"`
+	DeliveredAt   *utc.Time `db:"delivered_at"`
+}
+
+// Resolve stores the outcome of the transaction, the callback is delivered from now on
+func (e *PaymentCallback) Resolve(transactionStatus string, ledger *uint64, resultCodes *string, now utc.Time) {
+	e.TransactionStatus = transactionStatus
+	e.Ledger = ledger
+	e.ResultCodes = resultCodes
+	e.NextAttemptAt = now
+}
+
+// GetID returns ID of the entity
+func (e *PaymentCallback) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *PaymentCallback) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *PaymentCallback) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *PaymentCallback) SetExists() {
+	e.exists = true
+}
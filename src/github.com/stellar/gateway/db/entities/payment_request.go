@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// PaymentRequestStatus type represents status of a payment request
+type PaymentRequestStatus string
+
+const (
+	// PaymentRequestStatusOpen is a status of payment requests waiting for a payment
+	PaymentRequestStatusOpen PaymentRequestStatus = "open"
+	// PaymentRequestStatusFulfilled is a status of paid payment requests
+	PaymentRequestStatusFulfilled PaymentRequestStatus = "fulfilled"
+	// PaymentRequestStatusExpired is a status of payment requests not paid before `expires_at`
+	PaymentRequestStatusExpired PaymentRequestStatus = "expired"
+)
+
+// PaymentRequest represents a payment the bridge server expects to receive. It's created by
+// /payment_requests endpoint and matched against received payments by the listener.
+type PaymentRequest struct {
+	exists bool
+	ID     *int64 `db:"id" json:"-"`
+	// RequestID is a random public ID of the request
+	RequestID   string               `db:"request_id" json:"id"`
+	Destination string               `db:"destination" json:"destination"`
+	AssetCode   string               `db:"asset_code" json:"asset_code"`
+	AssetIssuer string               `db:"asset_issuer" json:"asset_issuer"`
+	Amount      string               `db:"amount" json:"amount"`
+	MemoType    string               `db:"memo_type" json:"memo_type"`
+	Memo        string               `db:"memo" json:"memo"`
+	Status      PaymentRequestStatus `db:"status" json:"status"`
+	CreatedAt   utc.Time             `db:"created_at" json:"created_at"`
+	ExpiresAt   *utc.Time            `db:"expires_at" json:"expires_at"`
+	FulfilledAt *utc.Time            `db:"fulfilled_at" json:"fulfilled_at"`
+	// OperationID is an ID of the operation fulfilling the request
+	OperationID string `db:"operation_id" json:"operation_id,omitempty"`
+	// Generation of the destination account when the request was created, payments to a
+	// recreated destination do not fulfill it
+	Generation int64 `db:"generation" json:"-"`
+}
+
+// GetID returns ID of the entity
+func (e *PaymentRequest) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *PaymentRequest) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *PaymentRequest) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *PaymentRequest) SetExists() {
+	e.exists = true
+}
+
+// MarkFulfilled marks request as fulfilled by a given operation
+func (e *PaymentRequest) MarkFulfilled(operationID string, now utc.Time) {
+	e.Status = PaymentRequestStatusFulfilled
+	e.OperationID = operationID
+	e.FulfilledAt = &now
+}
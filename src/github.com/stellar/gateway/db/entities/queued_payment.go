@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// QueuedPaymentStatus type represents status of a queued payment
+type QueuedPaymentStatus string
+
+const (
+	// QueuedPaymentStatusQueued is a status of payments waiting to be sent by the queue worker
+	QueuedPaymentStatusQueued QueuedPaymentStatus = "queued"
+	// QueuedPaymentStatusSuccess is a status of queued payments that have been sent
+	QueuedPaymentStatusSuccess QueuedPaymentStatus = "success"
+	// QueuedPaymentStatusFailed is a status of queued payments that failed with a final error
+	QueuedPaymentStatusFailed QueuedPaymentStatus = "failed"
+)
+
+// QueuedPayment is a /payment request sent with `queue=true`. It's stored when the request is
+// received and sent later by the queue worker, so the transaction is built with the sequence
+// number of the source at the time it's submitted.
+type QueuedPayment struct {
+	exists bool
+	ID     *int64 `db:"id"`
+	// PaymentID is a random public ID returned by /payment
+	PaymentID string              `db:"payment_id"`
+	Status    QueuedPaymentStatus `db:"status"`
+	// Payload is a JSON bridge.PaymentPayload of the request, secrets are not stored
+	Payload string `db:"payload"`
+	// Role is the server.Role of the request
+	Role          string `db:"role"`
+	CorrelationID string `db:"correlation_id"`
+	Attempts      int    `db:"attempts"`
+	// LastError is the error code of the last attempt that left the payment queued
+	LastError string `db:"last_error"`
+	// ResponseStatus and Response are the HTTP status and body of the /payment response, nil
+	// until the payment is settled
+	ResponseStatus *int      `db:"response_status"`
+	Response       *string   `db:"response"`
+	QueuedAt       utc.Time  `db:"queued_at"`
+	LastAttemptAt  *utc.Time `db:"last_attempt_at"`
+	SettledAt      *utc.Time `db:"settled_at"`
+}
+
+// Settle stores the final response of the payment, 2xx responses are successes
+func (e *QueuedPayment) Settle(status int, body []byte, now utc.Time) {
+	response := string(body)
+	e.ResponseStatus = &status
+	e.Response = &response
+	e.SettledAt = &now
+	e.Status = QueuedPaymentStatusFailed
+	if status >= 200 && status < 300 {
+		e.Status = QueuedPaymentStatusSuccess
+	}
+}
+
+// GetID returns ID of the entity
+func (e *QueuedPayment) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *QueuedPayment) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *QueuedPayment) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *QueuedPayment) SetExists() {
+	e.exists = true
+}
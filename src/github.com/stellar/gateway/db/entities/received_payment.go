@@ -1,17 +1,38 @@
 package entities
 
 import (
-	"time"
+	"strings"
+
+	"github.com/stellar/gateway/utc"
 )
 
+// ReceivedPaymentStatusImported is a status of operations processed by a legacy bridge server,
+// imported by `bridge migrate-legacy` so the listener doesn't process them again
+const ReceivedPaymentStatusImported = "Imported"
+
 // ReceivedPayment represents payment received by the gateway server
 type ReceivedPayment struct {
 	exists      bool
-	ID          *int64    `db:"id" json:"id"`
-	OperationID string    `db:"operation_id" json:"operation_id"`
-	ProcessedAt time.Time `db:"processed_at" json:"processed_at"`
-	PagingToken string    `db:"paging_token" json:"paging_token"`
-	Status      string    `db:"status" json:"status"`
+	ID          *int64   `db:"id" json:"id"`
+	OperationID string   `db:"operation_id" json:"operation_id"`
+	ProcessedAt utc.Time `db:"processed_at" json:"processed_at"`
+	PagingToken string   `db:"paging_token" json:"paging_token"`
+	Status      string   `db:"status" json:"status"`
+	AssetCode   string   `db:"asset_code" json:"asset_code"`
+	AssetIssuer string   `db:"asset_issuer" json:"asset_issuer"`
+	Amount      string   `db:"amount" json:"amount"`
+	// Backfill is true for payments ingested by a historical backfill, they are not used as the
+	// cursor of the live listener
+	Backfill bool `db:"backfill" json:"backfill"`
+	// DisputedAt is set when /verify/payment found on-chain data not matching the payment,
+	// DisputedFields are the mismatched fields separated by commas
+	DisputedAt     *utc.Time `db:"disputed_at" json:"disputed_at,omitempty"`
+	DisputedFields string    `db:"disputed_fields" json:"disputed_fields,omitempty"`
+	// Issuer fields are not stored, they are added by admin views from cached stellar.toml
+	// files of asset issuers
+	IssuerName        string `json:"issuer_name,omitempty"`
+	IssuerDomain      string `json:"issuer_domain,omitempty"`
+	AnchorAssetStatus string `json:"anchor_asset_status,omitempty"`
 }
 
 // GetID returns ID of the entity
@@ -37,3 +58,9 @@ func (e *ReceivedPayment) IsNew() bool {
 func (e *ReceivedPayment) SetExists() {
 	e.exists = true
 }
+
+// MarkDisputed marks payment as disputed because of mismatched fields
+func (e *ReceivedPayment) MarkDisputed(fields []string, now utc.Time) {
+	e.DisputedAt = &now
+	e.DisputedFields = strings.Join(fields, ",")
+}
@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// Reconciliation is a reconciliation report of a day, a report generated again for the day
+// replaces it
+type Reconciliation struct {
+	exists bool
+	ID     *int64 `db:"id"`
+	// Date is a UTC date (YYYY-MM-DD)
+	Date string `db:"date"`
+	// Mismatches is a number of report entries that are not matched
+	Mismatches int64 `db:"mismatches"`
+	// Report is a JSON reconciliation.Report
+	Report    string   `db:"report"`
+	CreatedAt utc.Time `db:"created_at"`
+}
+
+// GetID returns ID of the entity
+func (e *Reconciliation) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *Reconciliation) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *Reconciliation) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *Reconciliation) SetExists() {
+	e.exists = true
+}
@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"github.com/stellar/gateway/utc"
+)
+
+// RetiredAccount is a monitored account merged into another account. The listener stops streaming
+// payments of the account until it's registered again by /admin/accounts/{id}/reregister.
+type RetiredAccount struct {
+	exists    bool
+	ID        *int64 `db:"id" json:"-"`
+	AccountID string `db:"account_id" json:"account_id"`
+	// MergedInto is the account remaining funds were sent to
+	MergedInto string `db:"merged_into" json:"merged_into"`
+	// OperationID is the ID of the account_merge operation
+	OperationID     string   `db:"operation_id" json:"operation_id"`
+	TransactionHash string   `db:"transaction_hash" json:"transaction_hash,omitempty"`
+	RetiredAt       utc.Time `db:"retired_at" json:"retired_at"`
+	// ReregisteredAt is set when the recreated account is monitored again
+	ReregisteredAt *utc.Time `db:"reregistered_at" json:"reregistered_at,omitempty"`
+}
+
+// GetID returns ID of the entity
+func (e *RetiredAccount) GetID() *int64 {
+	if e.ID == nil {
+		return nil
+	}
+	newID := *e.ID
+	return &newID
+}
+
+// SetID sets ID of the entity
+func (e *RetiredAccount) SetID(id int64) {
+	e.ID = &id
+}
+
+// IsNew returns true if the entity has not been persisted yet
+func (e *RetiredAccount) IsNew() bool {
+	return !e.exists
+}
+
+// SetExists sets entity as persisted
+func (e *RetiredAccount) SetExists() {
+	e.exists = true
+}
@@ -3,7 +3,8 @@ package entities
 import (
 	"database/sql/driver"
 	"errors"
-	"time"
+
+	"github.com/stellar/gateway/utc"
 )
 
 // SentTransactionStatus type represents sent transaction status
@@ -42,11 +43,23 @@ type SentTransaction struct {
 	TransactionID string                `db:"transaction_id" json:"transaction_id"`
 	Status        SentTransactionStatus `db:"status" json:"status"` // sending/success/failure
 	Source        string                `db:"source" json:"source"`
-	SubmittedAt   time.Time             `db:"submitted_at" json:"submitted_at"`
-	SucceededAt   *time.Time            `db:"succeeded_at" json:"succeeded_at"`
+	SubmittedAt   utc.Time              `db:"submitted_at" json:"submitted_at"`
+	SucceededAt   *utc.Time             `db:"succeeded_at" json:"succeeded_at"`
 	Ledger        *uint64               `db:"ledger" json:"ledger"`
 	EnvelopeXdr   string                `db:"envelope_xdr" json:"envelope_xdr"`
 	ResultXdr     *string               `db:"result_xdr" json:"result_xdr"`
+	// CorrelationID is sent by a client or the ID of the request that sent the transaction
+	CorrelationID string `db:"correlation_id" json:"correlation_id"`
+	// Payload is a JSON bridge.PaymentPayload of the request that sent the payment, nil for
+	// transactions that cannot be rebuilt
+	Payload *string `db:"payload" json:"payload,omitempty"`
+	// RebuiltFrom is the ID of the failed transaction this one has been rebuilt from
+	RebuiltFrom *int64 `db:"rebuilt_from" json:"rebuilt_from,omitempty"`
+	// Anomalies is a JSON anomaly.Report of a payment flagged by anomaly detection
+	Anomalies *string `db:"anomalies" json:"anomalies,omitempty"`
+	// Internal is true for transfers to an account of the bridge config, they are not counted in
+	// volumes of sent payments
+	Internal bool `db:"internal" json:"internal"`
 }
 
 // GetID returns ID of the entity
@@ -73,11 +86,12 @@ func (e *SentTransaction) SetExists() {
 	e.exists = true
 }
 
-// MarkSucceeded marks transaction as succeeded
-func (e *SentTransaction) MarkSucceeded(ledger uint64) {
+// MarkSucceeded marks transaction as succeeded, resultXdr is nil when Horizon did not return it
+func (e *SentTransaction) MarkSucceeded(ledger uint64, resultXdr *string) {
 	e.Status = SentTransactionStatusSuccess
 	e.Ledger = &ledger
-	now := time.Now()
+	e.ResultXdr = resultXdr
+	now := utc.Now()
 	e.SucceededAt = &now
 }
 
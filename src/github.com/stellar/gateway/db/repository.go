@@ -2,9 +2,11 @@ package db
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/pagination"
 	"github.com/stellar/go/support/db"
 )
 
@@ -17,6 +19,32 @@ type RepositoryInterface interface {
 	GetReceivedPaymentByOperationID(operationID int64) (*entities.ReceivedPayment, error)
 	GetReceivedPayments(page, limit int) ([]*entities.ReceivedPayment, error)
 	GetSentTransactions(page, limit int) ([]*entities.SentTransaction, error)
+	GetReceivedPaymentsPage(q pagination.Query) ([]*entities.ReceivedPayment, error)
+	GetDisputedReceivedPaymentsPage(q pagination.Query) ([]*entities.ReceivedPayment, error)
+	GetSentTransactionsPage(q pagination.Query) ([]*entities.SentTransaction, error)
+	GetReceivedPaymentsProcessedBetween(from, to time.Time) ([]*entities.ReceivedPayment, error)
+	GetSentTransactionsSucceededBetween(from, to time.Time) ([]*entities.SentTransaction, error)
+	GetSentTransactionsSubmittedBetween(from, to time.Time, afterID int64, limit int) ([]*entities.SentTransaction, error)
+	GetDailyVolumes(from, to, assetCode, assetIssuer string) ([]*entities.DailyVolume, error)
+	GetBackfillCursor(accountID string) (*entities.BackfillCursor, error)
+	GetPaymentRequestByRequestID(requestID string) (*entities.PaymentRequest, error)
+	GetPaymentRequestsByMemo(memoType, memo string) ([]*entities.PaymentRequest, error)
+	GetPaymentRequestByOperationID(operationID string) (*entities.PaymentRequest, error)
+	GetExpiredPaymentRequests(now time.Time) ([]*entities.PaymentRequest, error)
+	GetListenerCursor() (*entities.ListenerCursor, error)
+	GetLastReceivedPaymentID() (int64, error)
+	GetSentTransactionByHash(hash string) (*entities.SentTransaction, error)
+	GetSentTransactionsRebuiltFrom(id int64) ([]*entities.SentTransaction, error)
+	GetRetiredAccount(accountID string) (*entities.RetiredAccount, error)
+	GetAccountGeneration(accountID string) (*entities.AccountGeneration, error)
+	GetConversionByOperationID(operationID string) (*entities.Conversion, error)
+	GetIdempotentPaymentByPaymentID(paymentID string) (*entities.IdempotentPayment, error)
+	GetQueuedPaymentByPaymentID(paymentID string) (*entities.QueuedPayment, error)
+	GetQueuedPayments(limit int) ([]*entities.QueuedPayment, error)
+	GetPaymentCallbacks(now time.Time, limit int) ([]*entities.PaymentCallback, error)
+	GetCounterpartyStats(destination string) ([]*entities.CounterpartyStats, error)
+	GetReconciliationByDate(date string) (*entities.Reconciliation, error)
+	GetEventsAfter(sequence int64, limit int) ([]*entities.Event, error)
 }
 
 // Repository helps getting data from DB
@@ -36,11 +64,21 @@ func NewRepository(driver Driver) (r Repository) {
 	return
 }
 
-// GetLastCursorValue returns last cursor value from a DB
+// GetLastCursorValue returns last cursor value from a DB: a cursor set by `bridge listener cursor
+// --set` until a payment is processed after it, otherwise the paging token of the last payment
 func (r Repository) GetLastCursorValue() (cursor *string, err error) {
 	receivedPayment, err := r.getLastReceivedPayment()
 	if err != nil {
 		return nil, err
+	}
+
+	listenerCursor, err := r.GetListenerCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	if listenerCursor != nil && (receivedPayment == nil || *receivedPayment.ID <= listenerCursor.AfterPaymentID) {
+		return &listenerCursor.PagingToken, nil
 	} else if receivedPayment == nil {
 		return nil, nil
 	} else {
@@ -48,6 +86,34 @@ func (r Repository) GetLastCursorValue() (cursor *string, err error) {
 	}
 }
 
+// GetListenerCursor returns the last cursor set by `bridge listener cursor --set`
+func (r Repository) GetListenerCursor() (*entities.ListenerCursor, error) {
+	var found entities.ListenerCursor
+
+	err := r.repo.GetRaw(&found, "SELECT * FROM ListenerCursor ORDER BY id DESC LIMIT 1")
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetLastReceivedPaymentID returns ID of the last payment processed by the listener, 0 if there
+// are none
+func (r Repository) GetLastReceivedPaymentID() (int64, error) {
+	receivedPayment, err := r.getLastReceivedPayment()
+	if err != nil || receivedPayment == nil {
+		return 0, err
+	}
+	return *receivedPayment.ID, nil
+}
+
 // GetAuthorizedTransactionByMemo returns authorized transaction searching by memo
 func (r Repository) GetAuthorizedTransactionByMemo(memo string) (*entities.AuthorizedTransaction, error) {
 
@@ -177,12 +243,408 @@ func (r Repository) GetSentTransactions(page, limit int) ([]*entities.SentTransa
 	return transactions, err
 }
 
-// getLastReceivedPayment returns the last received payment
+// GetReceivedPaymentsPage returns received payments of a page, pass them to pagination.NewPage
+func (r Repository) GetReceivedPaymentsPage(q pagination.Query) ([]*entities.ReceivedPayment, error) {
+	payments := []*entities.ReceivedPayment{}
+
+	err := r.selectPage(&payments, "ReceivedPayment", q)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		payment.SetExists()
+	}
+	return payments, nil
+}
+
+// GetDisputedReceivedPaymentsPage returns a page of received payments marked as disputed, pass
+// them to pagination.NewPage
+func (r Repository) GetDisputedReceivedPaymentsPage(q pagination.Query) ([]*entities.ReceivedPayment, error) {
+	payments := []*entities.ReceivedPayment{}
+
+	err := r.selectPageWhere(&payments, "ReceivedPayment", q, "disputed_at IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		payment.SetExists()
+	}
+	return payments, nil
+}
+
+// GetSentTransactionsPage returns sent transactions of a page, pass them to pagination.NewPage
+func (r Repository) GetSentTransactionsPage(q pagination.Query) ([]*entities.SentTransaction, error) {
+	transactions := []*entities.SentTransaction{}
+
+	err := r.selectPage(&transactions, "SentTransaction", q)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transaction := range transactions {
+		transaction.SetExists()
+	}
+	return transactions, nil
+}
+
+func (r Repository) selectPage(dest interface{}, table string, q pagination.Query) error {
+	return r.selectPageWhere(dest, table, q, "")
+}
+
+// selectPageWhere selects a page of records matching condition (without params), all records when
+// it's empty
+func (r Repository) selectPageWhere(dest interface{}, table string, q pagination.Query, condition string) error {
+	where, params, order, limit := q.SQL()
+	if condition != "" && where != "" {
+		where = condition + " AND " + where
+	} else if condition != "" {
+		where = condition
+	}
+
+	query := "SELECT * FROM " + table
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT %d", order, limit)
+
+	err := r.repo.SelectRaw(dest, query, params...)
+	if err != nil && !r.repo.NoRows(err) {
+		return err
+	}
+	return nil
+}
+
+// GetReceivedPaymentsProcessedBetween returns received payments with `processed_at` in [from, to)
+func (r Repository) GetReceivedPaymentsProcessedBetween(from, to time.Time) ([]*entities.ReceivedPayment, error) {
+	payments := []*entities.ReceivedPayment{}
+
+	err := r.repo.SelectRaw(
+		&payments,
+		"SELECT * FROM ReceivedPayment WHERE processed_at >= ? AND processed_at < ? ORDER BY id",
+		from,
+		to,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		payment.SetExists()
+	}
+	return payments, nil
+}
+
+// GetSentTransactionsSucceededBetween returns successful transactions with `succeeded_at` in [from, to)
+func (r Repository) GetSentTransactionsSucceededBetween(from, to time.Time) ([]*entities.SentTransaction, error) {
+	transactions := []*entities.SentTransaction{}
+
+	err := r.repo.SelectRaw(
+		&transactions,
+		"SELECT * FROM SentTransaction WHERE status = ? AND succeeded_at >= ? AND succeeded_at < ? ORDER BY id",
+		entities.SentTransactionStatusSuccess,
+		from,
+		to,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, transaction := range transactions {
+		transaction.SetExists()
+	}
+	return transactions, nil
+}
+
+// GetSentTransactionsSubmittedBetween returns up to limit transactions with `submitted_at` in [from, to)
+// and ID greater than afterID ordered by ID. It's used to page through all transactions in a range.
+func (r Repository) GetSentTransactionsSubmittedBetween(from, to time.Time, afterID int64, limit int) ([]*entities.SentTransaction, error) {
+	transactions := []*entities.SentTransaction{}
+
+	err := r.repo.SelectRaw(
+		&transactions,
+		fmt.Sprintf("SELECT * FROM SentTransaction WHERE submitted_at >= ? AND submitted_at < ? AND id > ? ORDER BY id LIMIT %d", limit),
+		from,
+		to,
+		afterID,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, transaction := range transactions {
+		transaction.SetExists()
+	}
+	return transactions, nil
+}
+
+// GetDailyVolumes returns daily volumes between from and to dates (YYYY-MM-DD, inclusive) ordered by date.
+// Empty assetCode or assetIssuer matches all assets.
+func (r Repository) GetDailyVolumes(from, to, assetCode, assetIssuer string) ([]*entities.DailyVolume, error) {
+	volumes := []*entities.DailyVolume{}
+
+	query := "SELECT * FROM DailyVolume WHERE date >= ? AND date <= ?"
+	params := []interface{}{from, to}
+
+	if assetCode != "" {
+		query += " AND asset_code = ?"
+		params = append(params, assetCode)
+	}
+
+	if assetIssuer != "" {
+		query += " AND asset_issuer = ?"
+		params = append(params, assetIssuer)
+	}
+
+	query += " ORDER BY date, asset_code, asset_issuer, direction"
+
+	err := r.repo.SelectRaw(&volumes, query, params...)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, volume := range volumes {
+		volume.SetExists()
+	}
+	return volumes, nil
+}
+
+// GetBackfillCursor returns backfill cursor of a given account
+func (r Repository) GetBackfillCursor(accountID string) (*entities.BackfillCursor, error) {
+
+	var found entities.BackfillCursor
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM BackfillCursor WHERE account_id = ?",
+		accountID,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetRetiredAccount returns the last retirement of a merged account, nil when the account is not
+// retired or it was reregistered after its last merge
+func (r Repository) GetRetiredAccount(accountID string) (*entities.RetiredAccount, error) {
+
+	var found entities.RetiredAccount
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM RetiredAccount WHERE account_id = ? AND reregistered_at IS NULL ORDER BY id DESC LIMIT 1",
+		accountID,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetAccountGeneration returns the current generation of an account, nil when the account was not
+// observed yet
+func (r Repository) GetAccountGeneration(accountID string) (*entities.AccountGeneration, error) {
+
+	var found entities.AccountGeneration
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM AccountGeneration WHERE account_id = ?",
+		accountID,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetConversionByOperationID returns the conversion of a received payment, nil when the payment was
+// not converted
+func (r Repository) GetConversionByOperationID(operationID string) (*entities.Conversion, error) {
+
+	var found entities.Conversion
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM Conversion WHERE operation_id = ?",
+		operationID,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetSentTransactionByHash returns the last transaction sent with a given hash
+func (r Repository) GetSentTransactionByHash(hash string) (*entities.SentTransaction, error) {
+
+	var found entities.SentTransaction
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM SentTransaction WHERE transaction_id = ? ORDER BY id DESC LIMIT 1",
+		hash,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetSentTransactionsRebuiltFrom returns transactions rebuilt from a transaction with a given ID
+func (r Repository) GetSentTransactionsRebuiltFrom(id int64) ([]*entities.SentTransaction, error) {
+	transactions := []*entities.SentTransaction{}
+
+	err := r.repo.SelectRaw(
+		&transactions,
+		"SELECT * FROM SentTransaction WHERE rebuilt_from = ? ORDER BY id",
+		id,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, transaction := range transactions {
+		transaction.SetExists()
+	}
+	return transactions, nil
+}
+
+// GetPaymentRequestByRequestID returns payment request by its public ID
+func (r Repository) GetPaymentRequestByRequestID(requestID string) (*entities.PaymentRequest, error) {
+
+	var found entities.PaymentRequest
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM PaymentRequest WHERE request_id = ?",
+		requestID,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetPaymentRequestsByMemo returns payment requests (in any status) with a given memo
+func (r Repository) GetPaymentRequestsByMemo(memoType, memo string) ([]*entities.PaymentRequest, error) {
+	requests := []*entities.PaymentRequest{}
+
+	err := r.repo.SelectRaw(
+		&requests,
+		"SELECT * FROM PaymentRequest WHERE memo_type = ? AND memo = ? ORDER BY id",
+		memoType,
+		memo,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, request := range requests {
+		request.SetExists()
+	}
+	return requests, nil
+}
+
+// GetPaymentRequestByOperationID returns payment request fulfilled by an operation
+func (r Repository) GetPaymentRequestByOperationID(operationID string) (*entities.PaymentRequest, error) {
+
+	var found entities.PaymentRequest
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM PaymentRequest WHERE operation_id = ? ORDER BY id LIMIT 1",
+		operationID,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetExpiredPaymentRequests returns open payment requests with `expires_at` before now
+func (r Repository) GetExpiredPaymentRequests(now time.Time) ([]*entities.PaymentRequest, error) {
+	requests := []*entities.PaymentRequest{}
+
+	err := r.repo.SelectRaw(
+		&requests,
+		"SELECT * FROM PaymentRequest WHERE status = ? AND expires_at <= ? ORDER BY id",
+		entities.PaymentRequestStatusOpen,
+		now,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, request := range requests {
+		request.SetExists()
+	}
+	return requests, nil
+}
+
+// CountReceivedPaymentsExcept returns a number of received payments with a status other than status
+func (r Repository) CountReceivedPaymentsExcept(status string) (count int64, err error) {
+	err = r.repo.GetRaw(&count, "SELECT COUNT(*) FROM ReceivedPayment WHERE status != ?", status)
+	return
+}
+
+// getLastReceivedPayment returns the last payment received by the listener
 func (r Repository) getLastReceivedPayment() (*entities.ReceivedPayment, error) {
 	var receivedPayment entities.ReceivedPayment
 	// DO NOT use `processed_at` as payment can be reprocessed. Reprocessing will update `processed_at`
-	// value but not `id`.
-	err := r.repo.GetRaw(&receivedPayment, "SELECT * FROM ReceivedPayment ORDER BY id DESC LIMIT 1")
+	// value but not `id`. Backfilled payments are older than the listener cursor so they are skipped.
+	err := r.repo.GetRaw(&receivedPayment, "SELECT * FROM ReceivedPayment WHERE backfill = ? ORDER BY id DESC LIMIT 1", false)
 
 	if r.repo.NoRows(err) {
 		return nil, nil
@@ -195,3 +657,156 @@ func (r Repository) getLastReceivedPayment() (*entities.ReceivedPayment, error)
 	receivedPayment.SetExists()
 	return &receivedPayment, nil
 }
+
+// GetIdempotentPaymentByPaymentID returns a payment sent with `id` param, nil when no payment was
+// sent with the id
+func (r Repository) GetIdempotentPaymentByPaymentID(paymentID string) (*entities.IdempotentPayment, error) {
+
+	var found entities.IdempotentPayment
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM IdempotentPayment WHERE payment_id = ?",
+		paymentID,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetQueuedPaymentByPaymentID returns a payment queued by /payment, nil when no payment was queued
+// with the ID
+func (r Repository) GetQueuedPaymentByPaymentID(paymentID string) (*entities.QueuedPayment, error) {
+
+	var found entities.QueuedPayment
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM QueuedPayment WHERE payment_id = ?",
+		paymentID,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetQueuedPayments returns at most limit payments waiting in the queue, in the order they were
+// queued
+func (r Repository) GetQueuedPayments(limit int) ([]*entities.QueuedPayment, error) {
+	payments := []*entities.QueuedPayment{}
+
+	err := r.repo.SelectRaw(
+		&payments,
+		fmt.Sprintf("SELECT * FROM QueuedPayment WHERE status = ? ORDER BY id LIMIT %d", limit),
+		entities.QueuedPaymentStatusQueued,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		payment.SetExists()
+	}
+	return payments, nil
+}
+
+// GetPaymentCallbacks returns at most limit pending payment callbacks whose next attempt is due at
+// now, the longest waiting first
+func (r Repository) GetPaymentCallbacks(now time.Time, limit int) ([]*entities.PaymentCallback, error) {
+	callbacks := []*entities.PaymentCallback{}
+
+	err := r.repo.SelectRaw(
+		&callbacks,
+		fmt.Sprintf("SELECT * FROM PaymentCallback WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT %d", limit),
+		entities.PaymentCallbackStatusPending,
+		now,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, callback := range callbacks {
+		callback.SetExists()
+	}
+	return callbacks, nil
+}
+
+// GetCounterpartyStats returns statistics of payments sent to a destination account, one for
+// every asset sent to every generation of it
+func (r Repository) GetCounterpartyStats(destination string) ([]*entities.CounterpartyStats, error) {
+	stats := []*entities.CounterpartyStats{}
+
+	err := r.repo.SelectRaw(
+		&stats,
+		"SELECT * FROM CounterpartyStats WHERE destination = ? ORDER BY id",
+		destination,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, assetStats := range stats {
+		assetStats.SetExists()
+	}
+	return stats, nil
+}
+
+// GetReconciliationByDate returns the reconciliation report of a UTC date (YYYY-MM-DD), nil when
+// the day has not been reconciled
+func (r Repository) GetReconciliationByDate(date string) (*entities.Reconciliation, error) {
+
+	var found entities.Reconciliation
+
+	err := r.repo.GetRaw(
+		&found,
+		"SELECT * FROM Reconciliation WHERE date = ?",
+		date,
+	)
+
+	if r.repo.NoRows(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	found.SetExists()
+	return &found, nil
+}
+
+// GetEventsAfter returns up to limit events of the journal with sequence numbers (IDs) greater than
+// sequence, in order of sequence numbers
+func (r Repository) GetEventsAfter(sequence int64, limit int) ([]*entities.Event, error) {
+	events := []*entities.Event{}
+
+	err := r.repo.SelectRaw(
+		&events,
+		fmt.Sprintf("SELECT * FROM Event WHERE id > ? ORDER BY id LIMIT %d", limit),
+		sequence,
+	)
+	if err != nil && !r.repo.NoRows(err) {
+		return nil, err
+	}
+
+	for _, event := range events {
+		event.SetExists()
+	}
+	return events, nil
+}
@@ -0,0 +1,244 @@
+// Package schema keeps snapshots of database migrations as they were released. Databases of
+// deployed servers were migrated by released migration files, so a snapshot lets tests recreate
+// them and check that migrations added later bring them to the same schema as a new database.
+// Snapshots are written by `bridge schema snapshot` when a release is made.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stellar/gateway/db/drivers/mysql"
+	"github.com/stellar/gateway/db/drivers/postgres"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+)
+
+// Types are database types with migrations, values of `database.type` config param
+var Types = []string{"mysql", "postgres", "sqlite"}
+
+// Components are migrated components, gateway tables are used by the bridge server and
+// compliance tables by the compliance server
+var Components = []string{"gateway", "compliance"}
+
+// migrationsTable keeps applied migrations, it's not a part of the compared schema
+const migrationsTable = "gorp_migrations"
+
+type dialect struct {
+	// name is the sql-migrate dialect
+	name     string
+	asset    func(name string) ([]byte, error)
+	assetDir func(name string) ([]string, error)
+}
+
+var dialects = map[string]dialect{
+	"mysql":    {"mysql", mysql.Asset, mysql.AssetDir},
+	"postgres": {"postgres", postgres.Asset, postgres.AssetDir},
+	"sqlite":   {"sqlite3", sqlite.Asset, sqlite.AssetDir},
+}
+
+// Migration is a migration file
+type Migration struct {
+	ID  string `json:"id"`
+	SQL string `json:"sql"`
+}
+
+// Snapshot contains migration files of a release by database type and component. Database types
+// added after the release have no migrations in it.
+type Snapshot struct {
+	Release    string                            `json:"release"`
+	Migrations map[string]map[string][]Migration `json:"migrations"`
+}
+
+// Migrations returns current migration files of a component of a database type ordered by ID
+func Migrations(databaseType, component string) ([]Migration, error) {
+	d, ok := dialects[databaseType]
+	if !ok {
+		return nil, fmt.Errorf("unknown database type: %s", databaseType)
+	}
+
+	dir := "migrations_" + component
+	names, err := d.assetDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	migrations := []Migration{}
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		sql, err := d.asset(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{ID: name, SQL: string(sql)})
+	}
+	return migrations, nil
+}
+
+// NewSnapshot returns a snapshot of current migration files of every database type
+func NewSnapshot(release string) (Snapshot, error) {
+	snapshot := Snapshot{Release: release, Migrations: map[string]map[string][]Migration{}}
+	for _, databaseType := range Types {
+		snapshot.Migrations[databaseType] = map[string][]Migration{}
+		for _, component := range Components {
+			migrations, err := Migrations(databaseType, component)
+			if err != nil {
+				return Snapshot{}, err
+			}
+			snapshot.Migrations[databaseType][component] = migrations
+		}
+	}
+	return snapshot, nil
+}
+
+// LoadSnapshot reads a snapshot file
+func LoadSnapshot(filename string) (snapshot Snapshot, err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &snapshot)
+	return
+}
+
+// Write writes the snapshot file
+func (s Snapshot) Write(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// Changed returns IDs of migrations of the snapshot whose files have been changed or removed
+// since the release. Databases migrated by the release don't run changed migrations again, schema
+// changes must be added as new migrations.
+func (s Snapshot) Changed(databaseType, component string) ([]string, error) {
+	current, err := Migrations(databaseType, component)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	for _, migration := range current {
+		files[migration.ID] = migration.SQL
+	}
+
+	changed := []string{}
+	for _, migration := range s.Migrations[databaseType][component] {
+		if sql, ok := files[migration.ID]; !ok || sql != migration.SQL {
+			changed = append(changed, migration.ID)
+		}
+	}
+	return changed, nil
+}
+
+// Apply runs migrations of the snapshot on an empty database, like the release did. Applied
+// migrations are recorded the same way so the driver's MigrateUp runs only newer migrations.
+func (s Snapshot) Apply(databaseType, component string, database *sqlx.DB) (int, error) {
+	d, ok := dialects[databaseType]
+	if !ok {
+		return 0, fmt.Errorf("unknown database type: %s", databaseType)
+	}
+
+	source := migrate.MemoryMigrationSource{}
+	for _, migration := range s.Migrations[databaseType][component] {
+		parsed, err := migrate.ParseMigration(migration.ID, bytes.NewReader([]byte(migration.SQL)))
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse %s migration %s: %s", databaseType, migration.ID, err)
+		}
+		source.Migrations = append(source.Migrations, parsed)
+	}
+	return migrate.Exec(database.DB, d.name, source, migrate.Up)
+}
+
+// Schema contains column types of tables by lowercase table and column names
+type Schema map[string]map[string]string
+
+// Describe returns the schema of a database
+func Describe(databaseType string, database *sqlx.DB) (Schema, error) {
+	switch databaseType {
+	case "sqlite":
+		return describeSQLite(database)
+	case "mysql":
+		return describeColumns(database, "SELECT table_name, column_name, column_type FROM information_schema.columns WHERE table_schema = DATABASE()")
+	case "postgres":
+		return describeColumns(database, "SELECT table_name, column_name, data_type FROM information_schema.columns WHERE table_schema = current_schema()")
+	}
+	return nil, fmt.Errorf("unknown database type: %s", databaseType)
+}
+
+// Tables returns names of tables of a schema in order, without the table of applied migrations
+func (s Schema) Tables() []string {
+	tables := []string{}
+	for table := range s {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+func (s Schema) add(table, column, columnType string) {
+	table = strings.ToLower(table)
+	if table == migrationsTable {
+		return
+	}
+	if s[table] == nil {
+		s[table] = map[string]string{}
+	}
+	s[table][strings.ToLower(column)] = strings.ToLower(columnType)
+}
+
+func describeColumns(database *sqlx.DB, query string) (Schema, error) {
+	rows, err := database.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schema := Schema{}
+	for rows.Next() {
+		var table, column, columnType string
+		if err := rows.Scan(&table, &column, &columnType); err != nil {
+			return nil, err
+		}
+		schema.add(table, column, columnType)
+	}
+	return schema, rows.Err()
+}
+
+func describeSQLite(database *sqlx.DB) (Schema, error) {
+	var tables []string
+	err := database.Select(&tables, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return nil, err
+	}
+
+	schema := Schema{}
+	for _, table := range tables {
+		var columns []struct {
+			ID         int64   `db:"cid"`
+			Name       string  `db:"name"`
+			Type       string  `db:"type"`
+			NotNull    bool    `db:"notnull"`
+			Default    *string `db:"dflt_value"`
+			PrimaryKey int64   `db:"pk"`
+		}
+		err = database.Select(&columns, "PRAGMA table_info("+table+")")
+		if err != nil {
+			return nil, err
+		}
+		for _, column := range columns {
+			schema.add(table, column.Name, column.Type)
+		}
+	}
+	return schema, nil
+}
@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotFile is the snapshot of the previous release
+const snapshotFile = "testdata/snapshot.json"
+
+func TestReleasedMigrationsUnchanged(t *testing.T) {
+	snapshot, err := LoadSnapshot(snapshotFile)
+	require.NoError(t, err)
+
+	for _, databaseType := range Types {
+		for _, component := range Components {
+			changed, err := snapshot.Changed(databaseType, component)
+			require.NoError(t, err)
+			assert.Empty(t, changed, "%s %s migrations of %s release have been changed, add new migrations instead", databaseType, component, snapshot.Release)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-schema")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	snapshot, err := NewSnapshot("0.0.11")
+	require.NoError(t, err)
+	for _, databaseType := range Types {
+		for _, component := range Components {
+			assert.NotEmpty(t, snapshot.Migrations[databaseType][component], databaseType+" "+component)
+		}
+	}
+
+	filename := filepath.Join(dir, "snapshot.json")
+	require.NoError(t, snapshot.Write(filename))
+	loaded, err := LoadSnapshot(filename)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, loaded)
+
+	changed, err := loaded.Changed("sqlite", "gateway")
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	migrations := loaded.Migrations["sqlite"]["gateway"]
+	migrations[0].SQL += "\n"
+	changed, err = loaded.Changed("sqlite", "gateway")
+	require.NoError(t, err)
+	assert.Equal(t, []string{migrations[0].ID}, changed)
+}
+
+func TestApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-schema")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	scratch := &sqlite.Driver{}
+	require.NoError(t, scratch.Init(filepath.Join(dir, "scratch.db")))
+	_, err = scratch.MigrateUp("gateway")
+	require.NoError(t, err)
+	expected, err := Describe("sqlite", scratch.DB())
+	require.NoError(t, err)
+	assert.Contains(t, expected.Tables(), "senttransaction")
+	assert.NotContains(t, expected.Tables(), migrationsTable)
+	assert.Equal(t, "text", expected["senttransaction"]["envelope_xdr"])
+
+	// A release without the last migration
+	migrations, err := Migrations("sqlite", "gateway")
+	require.NoError(t, err)
+	snapshot := Snapshot{Release: "0.0.10", Migrations: map[string]map[string][]Migration{
+		"sqlite": {"gateway": migrations[:len(migrations)-1]},
+	}}
+
+	released := &sqlite.Driver{}
+	require.NoError(t, released.Init(filepath.Join(dir, "released.db")))
+	applied, err := snapshot.Apply("sqlite", "gateway", released.DB())
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations)-1, applied)
+
+	applied, err = released.MigrateUp("gateway")
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	actual, err := Describe("sqlite", released.DB())
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
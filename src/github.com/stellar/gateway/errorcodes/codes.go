@@ -0,0 +1,187 @@
+// Code generated by errorcodes/gen. DO NOT EDIT.
+
+package errorcodes
+
+// Error codes returned in `code` of error responses
+const (
+	// AllowTrustBatchRolledBack (400): Other operation in the same transaction failed. Authorization has not been applied.
+	AllowTrustBatchRolledBack = "allow_trust_batch_rolled_back"
+	// AllowTrustCantRevoke (400): Authorizing account has AUTH_REVOCABLE_FLAG set. Can't revoke the trustline.
+	AllowTrustCantRevoke = "allow_trust_cant_revoke"
+	// AllowTrustMalformed (400): Asset name is malformed.
+	AllowTrustMalformed = "allow_trust_malformed"
+	// AllowTrustNoTrustline (400): Trustor does not have a trustline yet.
+	AllowTrustNoTrustline = "allow_trust_no_trustline"
+	// AllowTrustTrustNotRequired (400): Authorizing account does not require allowing trust. Set AUTH_REQUIRED_FLAG on your account to use this feature.
+	AllowTrustTrustNotRequired = "allow_trust_trust_not_required"
+	// AssetCodeNotAllowed (400): Given asset_code not allowed.
+	AssetCodeNotAllowed = "asset_code_not_allowed"
+	// AuthServerNotDefined (400): No AUTH_SERVER defined in stellar.toml file.
+	AuthServerNotDefined = "auth_server_not_defined"
+	// BatchPaymentFailed (400): Transaction of the batch failed. No payment has been sent.
+	BatchPaymentFailed = "batch_payment_failed"
+	// BatchPaymentFederationMemo (400): Federation returned memo fields of a destination but the memo of a batch applies to all payments.
+	BatchPaymentFederationMemo = "batch_payment_federation_memo"
+	// BatchPaymentRolledBack (400): Other payment of the same transaction failed. Payment has not been applied.
+	BatchPaymentRolledBack = "batch_payment_rolled_back"
+	// CannotResolveDestination (400): Cannot resolve federated Stellar address.
+	CannotResolveDestination = "cannot_resolve_destination"
+	// CannotUseMemo (400): Memo given in request but federation returned memo fields.
+	CannotUseMemo = "cannot_use_memo"
+	// ChannelsExhausted (503, retriable): All channel accounts are in use, please try again later.
+	ChannelsExhausted = "channels_exhausted"
+	// CounterpartyNotAllowed (403): Payments to the domain of destination are not allowed.
+	CounterpartyNotAllowed = "counterparty_not_allowed"
+	// CreateAccountAlreadyExists (400): Destination account has been created before the transaction was applied.
+	CreateAccountAlreadyExists = "create_account_already_exists"
+	// CreateAccountInvalidAmount (400): Starting balance of the created account must be a positive number with at most 7 decimal places.
+	CreateAccountInvalidAmount = "create_account_invalid_amount"
+	// CreateAccountLowReserve (400): Starting balance is below the minimum balance of an account.
+	CreateAccountLowReserve = "create_account_low_reserve"
+	// CreateAccountMalformed (400): Create account operation is malformed.
+	CreateAccountMalformed = "create_account_malformed"
+	// CreateAccountUnderfunded (400): Not enough funds to create the destination account.
+	CreateAccountUnderfunded = "create_account_underfunded"
+	// Denied (403): Transaction denied by destination.
+	Denied = "denied"
+	// DependencyUnavailable (503, retriable): Dependency is unavailable, please try again later.
+	DependencyUnavailable = "dependency_unavailable"
+	// HorizonTimeout (504, retriable): Horizon did not respond in time.
+	HorizonTimeout = "horizon_timeout"
+	// InternalServerError (500, retriable): Internal Server Error, please try again.
+	InternalServerError = "internal_server_error"
+	// InvalidFederationMemo (502, retriable): Federation server of the destination returned an invalid memo.
+	InvalidFederationMemo = "invalid_federation_memo"
+	// InvalidFee (400): Fee must be an integer number of stroops, at least 100 per operation of the transaction.
+	InvalidFee = "invalid_fee"
+	// InvalidParameter (400): Invalid parameter.
+	InvalidParameter = "invalid_parameter"
+	// InvalidTimeBounds (400): max_time must be in the future and not before min_time.
+	InvalidTimeBounds = "invalid_time_bounds"
+	// MissingParameter (400): Required parameter is missing.
+	MissingParameter = "missing_parameter"
+	// MultiAssetPaymentFailed (400): At least one asset cannot be sent. No asset has been sent.
+	MultiAssetPaymentFailed = "multi_asset_payment_failed"
+	// MultiAssetPaymentRolledBack (400): Other asset of the same transaction failed. Payment has not been applied.
+	MultiAssetPaymentRolledBack = "multi_asset_payment_rolled_back"
+	// NetworkNotConfigured (400): Network is not configured.
+	NetworkNotConfigured = "network_not_configured"
+	// PaymentAmountBelowReserve (400): Destination account does not exist and amount is below the minimum balance of an account created by the payment.
+	PaymentAmountBelowReserve = "payment_amount_below_reserve"
+	// PaymentAnomalyApprovalRequired (403): Payment deviates from previous payments to the destination. It needs to be sent again by an operator with `approve_anomaly=true`.
+	PaymentAnomalyApprovalRequired = "payment_anomaly_approval_required"
+	// PaymentAnomalyBlocked (403): Payment deviates from previous payments to the destination and has been blocked.
+	PaymentAnomalyBlocked = "payment_anomaly_blocked"
+	// PaymentDestinationDoesNotExist (400): Destination account does not exist and account creation is forbidden.
+	PaymentDestinationDoesNotExist = "payment_destination_does_not_exist"
+	// PaymentDestinationExists (400): Destination account exists, starting_balance can only be set when the payment creates it.
+	PaymentDestinationExists = "payment_destination_exists"
+	// PaymentDuplicateID (409): Payment with the same id has been sent with different params.
+	PaymentDuplicateID = "payment_duplicate_id"
+	// PaymentExcessiveSlippage (400): Estimated price of the path payment exceeds allowed slippage.
+	PaymentExcessiveSlippage = "payment_excessive_slippage"
+	// PaymentInProgress (409): Payment with the same id is being sent by another request, repeat the request to get its response.
+	PaymentInProgress = "payment_in_progress"
+	// PaymentInvalidAmount (400): Amount must be a positive number with at most 7 decimal places, without exponent or group separators.
+	PaymentInvalidAmount = "payment_invalid_amount"
+	// PaymentInvalidIssuer (400): Asset issuer federation address cannot be resolved to an account ID without memo.
+	PaymentInvalidIssuer = "payment_invalid_issuer"
+	// PaymentInvalidMemo (400): Memo is invalid.
+	PaymentInvalidMemo = "payment_invalid_memo"
+	// PaymentInvalidPath (400): Path is invalid.
+	PaymentInvalidPath = "payment_invalid_path"
+	// PaymentInvalidSource (400): Source is not a name of a configured source account.
+	PaymentInvalidSource = "payment_invalid_source"
+	// PaymentLineFull (400): Sending this payment would make a destination go above their limit.
+	PaymentLineFull = "payment_line_full"
+	// PaymentMalformed (400): Operation is malformed.
+	PaymentMalformed = "payment_malformed"
+	// PaymentMalformedAssetCode (400): Asset code must be 1-12 alphanumeric characters.
+	PaymentMalformedAssetCode = "payment_malformed_asset_code"
+	// PaymentMemoPolicyViolation (400): Memo does not meet deposit requirements of the domain of destination.
+	PaymentMemoPolicyViolation = "payment_memo_policy_violation"
+	// PaymentNoDestination (400): Destination account does not exist.
+	PaymentNoDestination = "payment_no_destination"
+	// PaymentNoIssuer (400): Missing issuer on asset.
+	PaymentNoIssuer = "payment_no_issuer"
+	// PaymentNoPathFound (400): No path from the send asset delivers the amount within send_max.
+	PaymentNoPathFound = "payment_no_path_found"
+	// PaymentNoTrust (400): Destination missing a trust line for asset.
+	PaymentNoTrust = "payment_no_trust"
+	// PaymentNotAuthorized (400): Destination not authorized to trust asset. It needs to be allowed first by using /authorize endpoint.
+	PaymentNotAuthorized = "payment_not_authorized"
+	// PaymentNotFound (404): Payment not found or its result has expired.
+	PaymentNotFound = "payment_not_found"
+	// PaymentNothingToSweep (400): Balance of the source account does not exceed its minimum balance and the fee of the transaction.
+	PaymentNothingToSweep = "payment_nothing_to_sweep"
+	// PaymentOfferCrossSelf (400): would cross one of its own offers.
+	PaymentOfferCrossSelf = "payment_offer_cross_self"
+	// PaymentOverSendmax (400): Could not satisfy sendmax.
+	PaymentOverSendmax = "payment_over_sendmax"
+	// PaymentRequestNotFound (404): Payment request not found.
+	PaymentRequestNotFound = "payment_request_not_found"
+	// PaymentSourceNotRegistered (403): Source is not registered with the domain of destination.
+	PaymentSourceNotRegistered = "payment_source_not_registered"
+	// PaymentSourceSeedNotAllowed (403): Source must be a name of a configured source account or a public key, seeds are not accepted.
+	PaymentSourceSeedNotAllowed = "payment_source_seed_not_allowed"
+	// PaymentSrcNoTrust (400): No trustline on source account.
+	PaymentSrcNoTrust = "payment_src_no_trust"
+	// PaymentSrcNotAuthorized (400): Source not authorized to transfer.
+	PaymentSrcNotAuthorized = "payment_src_not_authorized"
+	// PaymentStartingBalanceBelowReserve (400): starting_balance is below the minimum balance of an account.
+	PaymentStartingBalanceBelowReserve = "payment_starting_balance_below_reserve"
+	// PaymentSweepOffers (400): Source account has offers, its available balance cannot be computed.
+	PaymentSweepOffers = "payment_sweep_offers"
+	// PaymentTooFewOffers (400): Not enough offers to satisfy path.
+	PaymentTooFewOffers = "payment_too_few_offers"
+	// PaymentUnderfunded (400): Not enough funds to send this transaction.
+	PaymentUnderfunded = "payment_underfunded"
+	// Pending (202, retriable): Transaction pending. Repeat your request after given time.
+	Pending = "pending"
+	// PreauthOutstanding (409): Recovery account has a signer of an earlier recovery envelope, a new envelope invalidates it. Send replace=true to replace it.
+	PreauthOutstanding = "preauth_outstanding"
+	// RateLimited (429, retriable): Horizon rate limit exceeded, please try again later.
+	RateLimited = "rate_limited"
+	// RebuildAlreadyRebuilt (400): Transaction has already been rebuilt and the rebuilt transaction has not failed.
+	RebuildAlreadyRebuilt = "rebuild_already_rebuilt"
+	// RebuildNotAvailable (400): Transaction has no stored request. Only payments sent by /payment without compliance protocol can be rebuilt.
+	RebuildNotAvailable = "rebuild_not_available"
+	// RebuildNotFailed (400): Only failed transactions can be rebuilt.
+	RebuildNotFailed = "rebuild_not_failed"
+	// RebuildSecretOmitted (400): Payment was sent with a `source` or `signers` secret that is not in the config file, the secret has not been stored. Send the payment again.
+	RebuildSecretOmitted = "rebuild_secret_omitted"
+	// RebuildSourceNotConfigured (400): Seed of the source account is no longer in the config file.
+	RebuildSourceNotConfigured = "rebuild_source_not_configured"
+	// RebuildUnsupportedVersion (400): Stored request has a schema version this server cannot rebuild.
+	RebuildUnsupportedVersion = "rebuild_unsupported_version"
+	// ReceivedPaymentNotFound (404): Received payment not found.
+	ReceivedPaymentNotFound = "received_payment_not_found"
+	// SourceNotExist (400): Source account does not exist.
+	SourceNotExist = "source_not_exist"
+	// SourceOtherNetwork (400): Source account is configured in another network. Send the payment with `network` param of the network of the source.
+	SourceOtherNetwork = "source_other_network"
+	// TransactionBadAuth (400): Invalid network or too few signatures.
+	TransactionBadAuth = "transaction_bad_auth"
+	// TransactionBadAuthExtra (400): Unused signatures attached to transaction.
+	TransactionBadAuthExtra = "transaction_bad_auth_extra"
+	// TransactionBadSeq (400): Bad Sequence. Please, try again.
+	TransactionBadSeq = "transaction_bad_seq"
+	// TransactionInsufficientBalance (400): Transaction fee would bring account below reserve.
+	TransactionInsufficientBalance = "transaction_insufficient_balance"
+	// TransactionInsufficientFee (400): Transaction fee is too small.
+	TransactionInsufficientFee = "transaction_insufficient_fee"
+	// TransactionInternalError (502, retriable): Network failed to process the transaction, please try again.
+	TransactionInternalError = "transaction_internal_error"
+	// TransactionMissingOperation (400): Transaction has no operations.
+	TransactionMissingOperation = "transaction_missing_operation"
+	// TransactionNoAccount (400): Source account not found.
+	TransactionNoAccount = "transaction_no_account"
+	// TransactionNotFound (404): Transaction not found.
+	TransactionNotFound = "transaction_not_found"
+	// TransactionTooEarly (400): Transaction submitted before its min time.
+	TransactionTooEarly = "transaction_too_early"
+	// TransactionTooLate (400): Transaction submitted after its max time.
+	TransactionTooLate = "transaction_too_late"
+	// ValidationFailed (400): Request has more than one invalid parameter, see errors.
+	ValidationFailed = "validation_failed"
+)
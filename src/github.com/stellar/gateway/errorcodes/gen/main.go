@@ -0,0 +1,23 @@
+// Command gen writes constants of registered error codes to errorcodes/codes.go. It's run by
+// `go generate` in the errorcodes directory.
+package main
+
+import (
+	"io/ioutil"
+	"log"
+
+	"github.com/stellar/gateway/errorcodes"
+	// Packages declaring error responses register them when they are imported
+	_ "github.com/stellar/gateway/protocols/bridge"
+	_ "github.com/stellar/gateway/protocols/compliance"
+)
+
+func main() {
+	source, err := errorcodes.Source(errorcodes.Entries())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(errorcodes.SourceFile, source, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package errorcodes is the registry of error codes returned by the servers. Packages declaring
+// error responses register them in their init functions, the registry is listed by `/errors`,
+// validates `error_mapping` codes and generates the exported constants of this package so clients
+// can match error codes without string literals.
+package errorcodes
+
+//go:generate go run ./gen
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Entry is a registered error code
+type Entry struct {
+	Code string `json:"code"`
+	// Status is the HTTP status of responses with the code
+	Status int `json:"status"`
+	// Retriable is true when sending the same request again later can succeed
+	Retriable bool `json:"retriable"`
+	// Description is the message of responses with the code
+	Description string `json:"description"`
+}
+
+var (
+	mutex   sync.RWMutex
+	entries = map[string]Entry{}
+)
+
+// Register adds entries to the registry. It panics when a code is registered again with a
+// different status, retriability or description, so a code means the same thing in every package
+// returning it.
+func Register(registered ...Entry) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, entry := range registered {
+		if existing, ok := entries[entry.Code]; ok && existing != entry {
+			panic(fmt.Sprintf("errorcodes: %s registered twice with different entries", entry.Code))
+		}
+		entries[entry.Code] = entry
+	}
+}
+
+// Lookup returns a registered entry of a code
+func Lookup(code string) (Entry, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	entry, ok := entries[code]
+	return entry, ok
+}
+
+// Entries returns all registered entries sorted by code
+func Entries() []Entry {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result
+}
+
+// IsRetriableStatus returns true for HTTP statuses of errors that are retriable regardless of the
+// code: rate limits and server failures
+func IsRetriableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
@@ -0,0 +1,142 @@
+package errorcodes_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stellar/gateway/errorcodes"
+	_ "github.com/stellar/gateway/protocols/bridge"
+	_ "github.com/stellar/gateway/protocols/compliance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	entry, ok := errorcodes.Lookup("invalid_parameter")
+	require.True(t, ok)
+	assert.Equal(t, errorcodes.Entry{Code: "invalid_parameter", Status: http.StatusBadRequest, Description: "Invalid parameter."}, entry)
+
+	// Codes can be registered again by packages returning the same response
+	errorcodes.Register(entry)
+
+	entry.Status = http.StatusConflict
+	assert.Panics(t, func() { errorcodes.Register(entry) })
+
+	_, ok = errorcodes.Lookup("not_registered")
+	assert.False(t, ok)
+}
+
+func TestEntries(t *testing.T) {
+	entries := errorcodes.Entries()
+	require.NotEmpty(t, entries)
+	for i := 1; i < len(entries); i++ {
+		assert.True(t, entries[i-1].Code < entries[i].Code, "entries are sorted by code")
+	}
+
+	retriable := map[string]bool{}
+	for _, entry := range entries {
+		retriable[entry.Code] = entry.Retriable
+	}
+	assert.True(t, retriable["internal_server_error"])
+	assert.True(t, retriable["rate_limited"])
+	assert.True(t, retriable["pending"])
+	assert.True(t, retriable["transaction_internal_error"])
+	assert.False(t, retriable["transaction_bad_seq"])
+	assert.False(t, retriable["payment_invalid_amount"])
+}
+
+func TestConstantName(t *testing.T) {
+	assert.Equal(t, "PaymentInvalidAmount", errorcodes.ConstantName("payment_invalid_amount"))
+	assert.Equal(t, "PaymentDuplicateID", errorcodes.ConstantName("payment_duplicate_id"))
+	assert.Equal(t, "Pending", errorcodes.ConstantName("pending"))
+}
+
+func TestSourceIsGenerated(t *testing.T) {
+	expected, err := errorcodes.Source(errorcodes.Entries())
+	require.NoError(t, err)
+	actual, err := ioutil.ReadFile(errorcodes.SourceFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual), "%s is outdated, run `go generate` in errorcodes", errorcodes.SourceFile)
+	assert.Equal(t, "payment_invalid_amount", errorcodes.PaymentInvalidAmount)
+}
+
+// TestErrorResponsesAreRegistered checks codes of error responses declared anywhere in the source
+// (literals with a `Code` and transaction errors), so a handler cannot return a code that is not
+// listed by /errors and generated as a constant.
+func TestErrorResponsesAreRegistered(t *testing.T) {
+	codes := map[string]string{}
+	fileSet := token.NewFileSet()
+	err := filepath.Walk("..", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == "vendor" || info.Name() == "testdata") {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fileSet, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.CompositeLit:
+				if !isErrorResponse(node.Type) {
+					return true
+				}
+				for _, element := range node.Elts {
+					field, ok := element.(*ast.KeyValueExpr)
+					if key, isIdent := field.Key.(*ast.Ident); ok && isIdent && key.Name == "Code" {
+						addCode(codes, field.Value, fileSet.Position(field.Pos()).String())
+					}
+				}
+			case *ast.CallExpr:
+				if name, ok := node.Fun.(*ast.Ident); ok && name.Name == "newTransactionError" && len(node.Args) > 1 {
+					addCode(codes, node.Args[1], fileSet.Position(node.Pos()).String())
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, codes)
+
+	for code, position := range codes {
+		_, ok := errorcodes.Lookup(code)
+		assert.True(t, ok, "%s: error code %s is not registered", position, code)
+	}
+}
+
+func isErrorResponse(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name == "ErrorResponse"
+	case *ast.SelectorExpr:
+		return expr.Sel.Name == "ErrorResponse"
+	}
+	return false
+}
+
+// addCode adds a string literal code, codes copied from other responses are skipped
+func addCode(codes map[string]string, value ast.Expr, position string) {
+	literal, ok := value.(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return
+	}
+	code, err := strconv.Unquote(literal.Value)
+	if err == nil {
+		codes[code] = position
+	}
+}
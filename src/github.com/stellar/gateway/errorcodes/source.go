@@ -0,0 +1,50 @@
+package errorcodes
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// SourceFile is the file of generated constants, relative to the package directory
+const SourceFile = "codes.go"
+
+// initialisms are words of codes written in upper case in constant names
+var initialisms = map[string]bool{"id": true, "xdr": true, "url": true}
+
+// ConstantName returns the name of the constant of a code, ex. `PaymentDuplicateID` for
+// `payment_duplicate_id`
+func ConstantName(code string) string {
+	var name strings.Builder
+	for _, word := range strings.Split(code, "_") {
+		if word == "" {
+			continue
+		}
+		if initialisms[word] {
+			name.WriteString(strings.ToUpper(word))
+			continue
+		}
+		name.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return name.String()
+}
+
+// Source returns the formatted source of SourceFile declaring a constant of every entry
+func Source(entries []Entry) ([]byte, error) {
+	var source bytes.Buffer
+	source.WriteString("// Code generated by errorcodes/gen. DO NOT EDIT.\n\n")
+	source.WriteString("package errorcodes\n\n")
+	source.WriteString("// Error codes returned in `code` of error responses\n")
+	source.WriteString("const (\n")
+	for _, entry := range entries {
+		retriable := ""
+		if entry.Retriable {
+			retriable = ", retriable"
+		}
+		fmt.Fprintf(&source, "\t// %s (%d%s): %s\n", ConstantName(entry.Code), entry.Status, retriable, entry.Description)
+		fmt.Fprintf(&source, "\t%s = %q\n", ConstantName(entry.Code), entry.Code)
+	}
+	source.WriteString(")\n")
+	return format.Source(source.Bytes())
+}
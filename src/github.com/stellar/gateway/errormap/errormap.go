@@ -0,0 +1,199 @@
+// Package errormap rewrites error codes of responses to codes expected by downstream systems
+package errormap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/stellar/gateway/protocols"
+)
+
+// Mapping replaces the code, message and HTTP status of error responses with a bridge code,
+// empty fields are not replaced
+type Mapping struct {
+	HTTPStatus      int    `mapstructure:"http_status"`
+	ExternalCode    string `mapstructure:"external_code"`
+	ExternalMessage string `mapstructure:"external_message"`
+}
+
+// BridgeError is the error of a mapped response before it was mapped
+type BridgeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Status  int    `json:"status"`
+}
+
+// MappedResponse is a mapped error response, the bridge error is kept in `bridge_error`
+type MappedResponse struct {
+	*protocols.ErrorResponse
+	BridgeError BridgeError `json:"bridge_error"`
+}
+
+// Marshal marshals MappedResponse
+func (response *MappedResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
+
+// Mapper maps error responses by bridge code. Mappings can be replaced while responses are
+// written.
+type Mapper struct {
+	mutex    sync.RWMutex
+	mappings map[string]Mapping
+	webhooks bool
+}
+
+// NewMapper creates a new Mapper, webhooks enables mapping of errors in webhook payloads
+func NewMapper(mappings map[string]Mapping, webhooks bool) (*Mapper, error) {
+	m := &Mapper{}
+	return m, m.Update(mappings, webhooks)
+}
+
+// Validate returns an error if a mapping has a code that is not a registered bridge code or an
+// invalid HTTP status
+func Validate(mappings map[string]Mapping) error {
+	codes := make([]string, 0, len(mappings))
+	for code := range mappings {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if !protocols.IsRegisteredErrorCode(code) {
+			return fmt.Errorf("error_mapping.codes.%s: unknown bridge error code", code)
+		}
+		status := mappings[code].HTTPStatus
+		if status != 0 && (status < 400 || status > 599) {
+			return fmt.Errorf("error_mapping.codes.%s.http_status must be between 400 and 599", code)
+		}
+	}
+	return nil
+}
+
+// Update validates and replaces mappings, they are not replaced when they are invalid
+func (m *Mapper) Update(mappings map[string]Mapping, webhooks bool) error {
+	err := Validate(mappings)
+	if err != nil {
+		return err
+	}
+
+	copied := make(map[string]Mapping, len(mappings))
+	for code, mapping := range mappings {
+		copied[code] = mapping
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.mappings = copied
+	m.webhooks = webhooks
+	return nil
+}
+
+// Map returns a mapped copy of a response, false when its code is not mapped
+func (m *Mapper) Map(response *protocols.ErrorResponse) (*MappedResponse, bool) {
+	m.mutex.RLock()
+	mapping, ok := m.mappings[response.Code]
+	m.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	mapped := *response
+	if mapping.HTTPStatus != 0 {
+		mapped.Status = mapping.HTTPStatus
+	}
+	if mapping.ExternalCode != "" {
+		mapped.Code = mapping.ExternalCode
+	}
+	if mapping.ExternalMessage != "" {
+		mapped.Message = mapping.ExternalMessage
+	}
+	return &MappedResponse{
+		ErrorResponse: &mapped,
+		BridgeError:   BridgeError{Code: response.Code, Message: response.Message, Status: response.Status},
+	}, true
+}
+
+// WebhookError returns an error to include in a webhook payload: the mapped response when
+// mapping of webhooks is enabled and the code is mapped, the response otherwise
+func (m *Mapper) WebhookError(response *protocols.ErrorResponse) interface{} {
+	m.mutex.RLock()
+	webhooks := m.webhooks
+	m.mutex.RUnlock()
+	if !webhooks {
+		return response
+	}
+	if mapped, ok := m.Map(response); ok {
+		return mapped
+	}
+	return response
+}
+
+// empty returns true when no code is mapped
+func (m *Mapper) empty() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.mappings) == 0
+}
+
+// Middleware maps JSON error responses of handlers. Responses with a non-200 status are buffered
+// and decoded, responses without a mapped `code` are written unchanged.
+func (m *Mapper) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.empty() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &mappingWriter{ResponseWriter: w, mapper: m}
+		next.ServeHTTP(writer, r)
+		writer.flush()
+	})
+}
+
+// mappingWriter buffers responses written after WriteHeader with a non-200 status
+type mappingWriter struct {
+	http.ResponseWriter
+	mapper *Mapper
+	status int
+	// buffer is nil when the response is written through
+	buffer *bytes.Buffer
+}
+
+func (w *mappingWriter) WriteHeader(status int) {
+	if status == http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	w.buffer = &bytes.Buffer{}
+}
+
+func (w *mappingWriter) Write(p []byte) (int, error) {
+	if w.buffer != nil {
+		return w.buffer.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// flush writes the buffered response, mapped when it has a mapped code
+func (w *mappingWriter) flush() {
+	if w.buffer == nil {
+		return
+	}
+
+	status, body := w.status, w.buffer.Bytes()
+	var response protocols.ErrorResponse
+	if json.Unmarshal(body, &response) == nil && response.Code != "" {
+		response.Status = status
+		if mapped, ok := w.mapper.Map(&response); ok {
+			status, body = mapped.Status, mapped.Marshal()
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+	w.ResponseWriter.Write(body)
+}
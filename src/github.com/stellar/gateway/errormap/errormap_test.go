@@ -0,0 +1,117 @@
+package errormap_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/errormap"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/gateway/server"
+	"github.com/stellar/gateway/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// successResponse is a success response with a `code` field
+type successResponse struct{}
+
+func (successResponse) HTTPStatus() int { return http.StatusOK }
+func (successResponse) Marshal() []byte { return []byte(`{"code":"payment_underfunded"}`) }
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, errormap.Validate(map[string]errormap.Mapping{
+		"payment_underfunded": {HTTPStatus: 402, ExternalCode: "1042"},
+		"rate_limited":        {ExternalCode: "1099"},
+	}))
+
+	err := errormap.Validate(map[string]errormap.Mapping{"payment_underfunded": {}, "payment_underfunded_typo": {ExternalCode: "1042"}})
+	assert.EqualError(t, err, "error_mapping.codes.payment_underfunded_typo: unknown bridge error code")
+
+	err = errormap.Validate(map[string]errormap.Mapping{"payment_underfunded": {HTTPStatus: 200}})
+	assert.EqualError(t, err, "error_mapping.codes.payment_underfunded.http_status must be between 400 and 599")
+
+	_, err = errormap.NewMapper(map[string]errormap.Mapping{"unknown": {}}, false)
+	assert.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	mapper, err := errormap.NewMapper(map[string]errormap.Mapping{
+		"payment_underfunded": {HTTPStatus: 402, ExternalCode: "1042", ExternalMessage: "Insufficient funds"},
+		"rate_limited":        {ExternalCode: "1099"},
+	}, false)
+	require.NoError(t, err)
+
+	var response server.Response
+	handler := mapper.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.Write(w, response)
+	}))
+	get := func(r server.Response) *httptest.ResponseRecorder {
+		response = r
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/payment", nil))
+		return recorder
+	}
+
+	t.Run("mapped code", func(t *testing.T) {
+		recorder := get(bridge.PaymentUnderfunded)
+		assert.Equal(t, 402, recorder.Code)
+		assert.Equal(t, test.StringToJSONMap(`{
+		  "code": "1042",
+		  "message": "Insufficient funds",
+		  "bridge_error": {
+		    "code": "payment_underfunded",
+		    "message": "Not enough funds to send this transaction.",
+		    "status": 400
+		  }
+		}`), test.StringToJSONMap(recorder.Body.String()))
+	})
+
+	t.Run("not replaced fields and headers are kept", func(t *testing.T) {
+		recorder := get(protocols.NewRateLimitedError(7 * time.Second))
+		assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		assert.Equal(t, "7", recorder.Header().Get("Retry-After"))
+		body := test.StringToJSONMap(recorder.Body.String())
+		assert.Equal(t, "1099", body["code"])
+		assert.Equal(t, protocols.RateLimitedError.Message, body["message"])
+		assert.Equal(t, map[string]interface{}{"retry_after": float64(7)}, body["data"])
+		assert.Equal(t, "rate_limited", body["bridge_error"].(map[string]interface{})["code"])
+	})
+
+	t.Run("unmapped code is unchanged", func(t *testing.T) {
+		recorder := get(bridge.PaymentNoTrust)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, string(bridge.PaymentNoTrust.Marshal()), recorder.Body.String())
+	})
+
+	t.Run("success response is unchanged", func(t *testing.T) {
+		recorder := get(successResponse{})
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"code":"payment_underfunded"}`, recorder.Body.String())
+	})
+
+	t.Run("mappings are replaced", func(t *testing.T) {
+		require.NoError(t, mapper.Update(map[string]errormap.Mapping{"payment_no_trust": {ExternalCode: "1050"}}, false))
+		assert.Equal(t, "1050", test.StringToJSONMap(get(bridge.PaymentNoTrust).Body.String())["code"])
+		assert.Equal(t, "payment_underfunded", test.StringToJSONMap(get(bridge.PaymentUnderfunded).Body.String())["code"])
+
+		assert.Error(t, mapper.Update(map[string]errormap.Mapping{"unknown": {}}, false))
+		assert.Equal(t, "1050", test.StringToJSONMap(get(bridge.PaymentNoTrust).Body.String())["code"])
+	})
+}
+
+func TestWebhookError(t *testing.T) {
+	mappings := map[string]errormap.Mapping{"payment_underfunded": {ExternalCode: "1042"}}
+	mapper, err := errormap.NewMapper(mappings, false)
+	require.NoError(t, err)
+	assert.Equal(t, bridge.PaymentUnderfunded, mapper.WebhookError(bridge.PaymentUnderfunded))
+
+	require.NoError(t, mapper.Update(mappings, true))
+	mapped, ok := mapper.WebhookError(bridge.PaymentUnderfunded).(*errormap.MappedResponse)
+	require.True(t, ok)
+	assert.Equal(t, "1042", mapped.Code)
+	assert.Equal(t, "payment_underfunded", mapped.BridgeError.Code)
+	assert.Equal(t, bridge.PaymentNoTrust, mapper.WebhookError(bridge.PaymentNoTrust))
+}
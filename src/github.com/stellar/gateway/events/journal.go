@@ -0,0 +1,285 @@
+// Package events journals state transitions of transactions and payments (submitted, confirmed,
+// received, callback delivered...) in the Event table, so audit systems can replay them in order
+// from any sequence number.
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/utc"
+)
+
+// Types of events
+const (
+	// TransactionSubmitting is recorded when a transaction is stored before it's submitted
+	TransactionSubmitting = "transaction_submitting"
+	// TransactionSucceeded is recorded when a transaction is included in a ledger
+	TransactionSucceeded = "transaction_succeeded"
+	// TransactionFailed is recorded when a transaction is rejected by Horizon or fails
+	TransactionFailed = "transaction_failed"
+	// PaymentReceived is recorded when the listener stores a received payment
+	PaymentReceived = "payment_received"
+	// CallbackDelivered is recorded when the receive callback accepted a received payment
+	CallbackDelivered = "callback_delivered"
+	// PaymentProcessed is recorded with the final status of a received or reprocessed payment
+	PaymentProcessed = "payment_processed"
+	// PaymentAnomalyBlocked is recorded when a payment flagged by anomaly detection is rejected
+	PaymentAnomalyBlocked = "payment_anomaly_blocked"
+	// PaymentAnomalyApproved is recorded when an operator approves a flagged payment
+	PaymentAnomalyApproved = "payment_anomaly_approved"
+	// PaymentDisputed is recorded when /verify/payment finds on-chain data of a received payment
+	// not matching the stored payment
+	PaymentDisputed = "payment_disputed"
+	// LeaderHandoffRequested is recorded when a replica asks the leader to hand the lease off
+	LeaderHandoffRequested = "leader_handoff_requested"
+	// LeaderHandoffReleased is recorded when the leader drained singleton components and gave
+	// the lease to the replica that requested it
+	LeaderHandoffReleased = "leader_handoff_released"
+	// LeaderHandoffDeclined is recorded when the leader refuses a handoff
+	LeaderHandoffDeclined = "leader_handoff_declined"
+	// LeaderHandoffTakenOver is recorded when a replica takes the lease over because the leader
+	// did not answer its handoff request in time
+	LeaderHandoffTakenOver = "leader_handoff_taken_over"
+	// AccountRecreated is recorded when an account is observed in a new generation after it was
+	// merged and created again
+	AccountRecreated = "account_recreated"
+)
+
+// PayloadVersion is the version of payloads of all event types. It's increased when a field of a
+// payload is removed or changes its meaning, new fields are added without a new version.
+const PayloadVersion = 1
+
+const (
+	// DefaultBufferSize is the number of events waiting to be written used when
+	// `events.buffer_size` is not configured
+	DefaultBufferSize = 1024
+	// writeAttempts is a number of times an event is written when the DB fails
+	writeAttempts = 3
+	// writeRetryWait is a time between write attempts
+	writeRetryWait = time.Second
+)
+
+// Event is an event of the journal as returned by /admin/events
+type Event struct {
+	// Sequence increases with every event written, consumers read events after the last
+	// sequence they processed
+	Sequence  int64           `json:"sequence"`
+	Type      string          `json:"type"`
+	Subject   string          `json:"subject"`
+	Version   int             `json:"version"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt utc.Time        `json:"created_at"`
+}
+
+// NewEvent converts a stored event
+func NewEvent(stored *entities.Event) Event {
+	event := Event{
+		Type:      stored.Type,
+		Subject:   stored.Subject,
+		Version:   stored.Version,
+		Payload:   json.RawMessage(stored.Payload),
+		CreatedAt: stored.CreatedAt,
+	}
+	if stored.ID != nil {
+		event.Sequence = *stored.ID
+	}
+	return event
+}
+
+// Sink receives every event after it's written, ex. a publisher to a message queue of a service
+// embedding the bridge. Events are published once, consumers missing some of them can read them
+// from /admin/events.
+type Sink interface {
+	Publish(event Event) error
+}
+
+// RecorderInterface helps mocking Journal
+type RecorderInterface interface {
+	Record(eventType, subject string, payload interface{})
+}
+
+// Journal writes recorded events to the DB in the background, so recording doesn't wait for the
+// DB. Events are written in order they are recorded. Buffered events that are not written yet are
+// lost when the process crashes, Stop writes them before it returns. The zero Journal is
+// disabled, it doesn't record events.
+type Journal struct {
+	entityManager db.EntityManagerInterface
+	// Sink receives written events, optional
+	Sink  Sink
+	log   *logrus.Entry
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	// mutex guards queue from being closed by Stop while events are sent to it
+	mutex   sync.RWMutex
+	queue   chan *entities.Event
+	started bool
+	stopped bool
+	done    chan struct{}
+}
+
+// NewJournal creates a new Journal buffering up to bufferSize events, DefaultBufferSize when 0
+func NewJournal(entityManager db.EntityManagerInterface, bufferSize int, now func() time.Time) *Journal {
+	if bufferSize == 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Journal{
+		entityManager: entityManager,
+		log: logrus.WithFields(logrus.Fields{
+			"service": "EventJournal",
+		}),
+		now:   now,
+		sleep: time.Sleep,
+		queue: make(chan *entities.Event, bufferSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// Enabled returns true when the journal records events
+func (j *Journal) Enabled() bool {
+	return j != nil && j.queue != nil
+}
+
+// Record adds an event with a JSON payload to the journal. It returns as soon as the event is
+// buffered, it waits for writes only when the buffer is full.
+func (j *Journal) Record(eventType, subject string, payload interface{}) {
+	if !j.Enabled() {
+		return
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		j.log.WithFields(logrus.Fields{"err": err, "type": eventType, "subject": subject}).Error("Cannot encode event payload")
+		return
+	}
+	event := &entities.Event{
+		Type:      eventType,
+		Subject:   subject,
+		Version:   PayloadVersion,
+		Payload:   string(raw),
+		CreatedAt: utc.New(j.now()),
+	}
+
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	if j.stopped {
+		j.log.WithFields(logrus.Fields{"type": eventType, "subject": subject}).Warn("Event recorded after the journal has stopped")
+		return
+	}
+
+	select {
+	case j.queue <- event:
+	default:
+		j.log.WithFields(logrus.Fields{"buffer_size": cap(j.queue)}).Warn("Event buffer is full, waiting for writes")
+		j.queue <- event
+	}
+}
+
+// Run starts writing recorded events in the background
+func (j *Journal) Run() {
+	if !j.Enabled() {
+		return
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.started || j.stopped {
+		return
+	}
+	j.started = true
+
+	go func() {
+		defer close(j.done)
+		for event := range j.queue {
+			j.write(event)
+		}
+	}()
+}
+
+// Stop stops recording events and returns when buffered events are written. Events recorded
+// after Stop are dropped.
+func (j *Journal) Stop() {
+	if !j.Enabled() {
+		return
+	}
+
+	j.mutex.Lock()
+	started := j.started
+	if !j.stopped {
+		j.stopped = true
+		close(j.queue)
+	}
+	j.mutex.Unlock()
+
+	if started {
+		<-j.done
+	}
+}
+
+// write stores an event and publishes it to the sink. An event that cannot be stored is logged
+// with its payload so it can be recovered from logs.
+func (j *Journal) write(event *entities.Event) {
+	var err error
+	for attempt := 1; attempt <= writeAttempts; attempt++ {
+		err = j.entityManager.Persist(event)
+		if err == nil {
+			break
+		}
+		j.log.WithFields(logrus.Fields{"err": err, "attempt": attempt}).Warn("Error writing event")
+		if attempt < writeAttempts {
+			j.sleep(writeRetryWait)
+		}
+	}
+
+	if err != nil {
+		j.log.WithFields(logrus.Fields{
+			"type":    event.Type,
+			"subject": event.Subject,
+			"payload": event.Payload,
+		}).Error("Event lost, it cannot be written")
+		return
+	}
+
+	if j.Sink == nil {
+		return
+	}
+	published := NewEvent(event)
+	if err := j.Sink.Publish(published); err != nil {
+		j.log.WithFields(logrus.Fields{"err": err, "sequence": published.Sequence}).Error("Error publishing event to sink")
+	}
+}
+
+// transactionEvent is a payload of transaction events
+type transactionEvent struct {
+	Source        string  `json:"source"`
+	CorrelationID string  `json:"correlation_id,omitempty"`
+	Ledger        *uint64 `json:"ledger,omitempty"`
+	ResultXdr     *string `json:"result_xdr,omitempty"`
+}
+
+// RecordTransaction records the current status of a stored sent transaction: TransactionSubmitting,
+// TransactionSucceeded or TransactionFailed. Nothing is recorded when recorder is nil.
+func RecordTransaction(recorder RecorderInterface, sentTransaction *entities.SentTransaction) {
+	if recorder == nil {
+		return
+	}
+
+	eventType := TransactionSubmitting
+	switch sentTransaction.Status {
+	case entities.SentTransactionStatusSuccess:
+		eventType = TransactionSucceeded
+	case entities.SentTransactionStatusFailure:
+		eventType = TransactionFailed
+	}
+	recorder.Record(eventType, sentTransaction.TransactionID, transactionEvent{
+		Source:        sentTransaction.Source,
+		CorrelationID: sentTransaction.CorrelationID,
+		Ledger:        sentTransaction.Ledger,
+		ResultXdr:     sentTransaction.ResultXdr,
+	})
+}
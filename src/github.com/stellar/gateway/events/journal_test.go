@@ -0,0 +1,156 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink collects published events
+type recordingSink struct {
+	mutex  sync.Mutex
+	events []Event
+}
+
+func (s *recordingSink) Publish(event Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestJournal(t *testing.T) {
+	now := time.Date(2017, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	t.Run("events are written in order they are recorded", func(t *testing.T) {
+		entityManager := new(mocks.MockEntityManager)
+		var written []*entities.Event
+		sequence := int64(40)
+		entityManager.On("Persist", mock.AnythingOfType("*entities.Event")).Run(func(args mock.Arguments) {
+			event := args.Get(0).(*entities.Event)
+			sequence++
+			event.SetID(sequence)
+			written = append(written, event)
+		}).Return(nil)
+
+		journal := NewJournal(entityManager, 2, clock)
+		sink := &recordingSink{}
+		journal.Sink = sink
+		journal.Run()
+		for i := 0; i < 5; i++ {
+			journal.Record(TransactionSucceeded, "6a0049b4", map[string]interface{}{"ledger": i})
+		}
+		journal.Stop()
+
+		require.Len(t, written, 5)
+		for i, event := range written {
+			assert.Equal(t, TransactionSucceeded, event.Type)
+			assert.Equal(t, "6a0049b4", event.Subject)
+			assert.Equal(t, PayloadVersion, event.Version)
+			assert.Equal(t, now, event.CreatedAt.Time())
+			var payload map[string]int
+			require.NoError(t, json.Unmarshal([]byte(event.Payload), &payload))
+			assert.Equal(t, i, payload["ledger"])
+		}
+
+		require.Len(t, sink.events, 5)
+		assert.Equal(t, int64(41), sink.events[0].Sequence)
+		assert.Equal(t, int64(45), sink.events[4].Sequence)
+		assert.JSONEq(t, `{"ledger": 4}`, string(sink.events[4].Payload))
+	})
+
+	t.Run("writes are retried", func(t *testing.T) {
+		entityManager := new(mocks.MockEntityManager)
+		entityManager.On("Persist", mock.Anything).Return(errors.New("connection refused")).Once()
+		entityManager.On("Persist", mock.Anything).Return(nil).Once()
+
+		journal := NewJournal(entityManager, 0, clock)
+		var waits []time.Duration
+		journal.sleep = func(d time.Duration) { waits = append(waits, d) }
+		journal.Run()
+		journal.Record(PaymentReceived, "12884905985", struct{}{})
+		journal.Stop()
+
+		entityManager.AssertExpectations(t)
+		assert.Equal(t, []time.Duration{writeRetryWait}, waits)
+	})
+
+	t.Run("events that cannot be written are dropped", func(t *testing.T) {
+		entityManager := new(mocks.MockEntityManager)
+		entityManager.On("Persist", mock.Anything).Return(errors.New("connection refused")).Times(writeAttempts)
+		sink := &recordingSink{}
+
+		journal := NewJournal(entityManager, 0, clock)
+		journal.Sink = sink
+		journal.sleep = func(time.Duration) {}
+		journal.Run()
+		journal.Record(PaymentReceived, "12884905985", struct{}{})
+		journal.Stop()
+
+		entityManager.AssertExpectations(t)
+		assert.Empty(t, sink.events)
+	})
+
+	t.Run("events recorded after stop are dropped", func(t *testing.T) {
+		entityManager := new(mocks.MockEntityManager)
+		journal := NewJournal(entityManager, 0, clock)
+		journal.Run()
+		journal.Stop()
+		journal.Record(PaymentReceived, "12884905985", struct{}{})
+		journal.Stop()
+
+		entityManager.AssertNotCalled(t, "Persist", mock.Anything)
+	})
+
+	t.Run("zero journal is disabled", func(t *testing.T) {
+		var disabled *Journal
+		assert.False(t, disabled.Enabled())
+		disabled.Record(PaymentReceived, "12884905985", struct{}{})
+
+		journal := &Journal{}
+		assert.False(t, journal.Enabled())
+		journal.Run()
+		journal.Record(PaymentReceived, "12884905985", struct{}{})
+		journal.Stop()
+	})
+}
+
+// typeRecorder collects types and payloads of recorded events
+type typeRecorder struct {
+	types    []string
+	payloads []interface{}
+}
+
+func (r *typeRecorder) Record(eventType, subject string, payload interface{}) {
+	r.types = append(r.types, eventType)
+	r.payloads = append(r.payloads, payload)
+}
+
+func TestRecordTransaction(t *testing.T) {
+	recorder := &typeRecorder{}
+	sentTransaction := &entities.SentTransaction{
+		TransactionID: "6a0049b4",
+		Status:        entities.SentTransactionStatusSending,
+		Source:        "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW",
+	}
+	RecordTransaction(recorder, sentTransaction)
+	sentTransaction.MarkSucceeded(1988727, nil)
+	RecordTransaction(recorder, sentTransaction)
+	sentTransaction.MarkFailed("AAAAAAAAAGT////7AAAAAA==")
+	RecordTransaction(recorder, sentTransaction)
+	RecordTransaction(nil, sentTransaction)
+
+	assert.Equal(t, []string{TransactionSubmitting, TransactionSucceeded, TransactionFailed}, recorder.types)
+	raw, err := json.Marshal(recorder.payloads[1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"source": "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW", "ledger": 1988727}`, string(raw))
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/gateway/db/entities"
+)
+
+// Page is a response of /admin/events. Next is the URL of events after the last one of the page,
+// it's the same request when there are no new events.
+type Page struct {
+	Records []Event `json:"records"`
+	Links   struct {
+		Next string `json:"next"`
+	} `json:"links"`
+}
+
+// NewPage creates a page of events read after sequence number after, next link keeps limit
+func NewPage(path string, after int64, limit int, stored []*entities.Event) Page {
+	page := Page{Records: make([]Event, 0, len(stored))}
+	for _, event := range stored {
+		page.Records = append(page.Records, NewEvent(event))
+	}
+	page.Links.Next = fmt.Sprintf("%s?after=%d&limit=%d", path, page.Last(after), limit)
+	return page
+}
+
+// Last returns the sequence number of the last event of the page, after when it's empty
+func (p Page) Last(after int64) int64 {
+	if len(p.Records) == 0 {
+		return after
+	}
+	return p.Records[len(p.Records)-1].Sequence
+}
+
+// OffsetFile stores the sequence number of the last event processed by a consumer of
+// /admin/events, so the consumer continues after it when restarted. A consumer saves the offset
+// after it processed events of a page, events of a page processed again after a crash have the
+// same sequence numbers so they can be deduplicated.
+type OffsetFile struct {
+	Path string
+}
+
+// Load returns the saved sequence number, 0 (all events) when nothing has been saved
+func (f OffsetFile) Load() (int64, error) {
+	raw, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	sequence, err := strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid offset in %s: %s", f.Path, err)
+	}
+	return sequence, nil
+}
+
+// Save stores a sequence number. The file is replaced atomically so a crash doesn't leave a
+// partially written offset.
+func (f OffsetFile) Save(sequence int64) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(strconv.FormatInt(sequence, 10) + "\n")
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
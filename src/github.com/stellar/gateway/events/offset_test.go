@@ -0,0 +1,52 @@
+package events
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPage(t *testing.T) {
+	id := func(id int64) *int64 { return &id }
+	page := NewPage("/admin/events", 40, 2, []*entities.Event{
+		{ID: id(41), Type: PaymentReceived, Subject: "12884905985", Version: 1, Payload: `{"amount":"20"}`},
+		{ID: id(43), Type: PaymentProcessed, Subject: "12884905985", Version: 1, Payload: `{"status":"Success"}`},
+	})
+	require.Len(t, page.Records, 2)
+	assert.Equal(t, int64(43), page.Last(40))
+	assert.Equal(t, "/admin/events?after=43&limit=2", page.Links.Next)
+
+	empty := NewPage("/admin/events", 43, 2, nil)
+	assert.NotNil(t, empty.Records)
+	assert.Equal(t, "/admin/events?after=43&limit=2", empty.Links.Next)
+}
+
+func TestOffsetFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "events")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	offset := OffsetFile{Path: filepath.Join(dir, "offset")}
+	sequence, err := offset.Load()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sequence)
+
+	require.NoError(t, offset.Save(43))
+	require.NoError(t, offset.Save(1988727))
+	sequence, err = offset.Load()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1988727), sequence)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "temporary files are removed")
+
+	require.NoError(t, ioutil.WriteFile(offset.Path, []byte("last"), 0644))
+	_, err = offset.Load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package external
+
+import (
+	"net"
+	"regexp"
+
+	"github.com/stellar/gateway/breaker"
+	"github.com/stellar/go/address"
+	fproto "github.com/stellar/go/protocols/federation"
+	"github.com/stellar/go/support/errors"
+)
+
+// BreakerFederationPrefix prefixes names of breakers of federation domains, ex. `federation:stellar.org`
+const BreakerFederationPrefix = "federation:"
+
+var serverErrorStatus = regexp.MustCompile(`failed with \(5\d\d\) status code`)
+
+// federationBreaker calls the federation client through circuit breakers, a breaker per domain so a
+// single failing federation server does not affect payments to other domains
+type federationBreaker struct {
+	client   FederationClientInterface
+	breakers *breaker.Set
+}
+
+// NewFederationBreaker returns FederationClientInterface that fails fast with *breaker.OpenError
+// when federation or stellar.toml server of a domain is failing
+func NewFederationBreaker(client FederationClientInterface, breakers *breaker.Set) FederationClientInterface {
+	return &federationBreaker{client: client, breakers: breakers}
+}
+
+// isFederationFailure returns true for network errors and server errors, other errors (ex. an
+// unknown name) come from an available server
+func isFederationFailure(err error) bool {
+	if _, ok := errors.Cause(err).(net.Error); ok {
+		return true
+	}
+	return serverErrorStatus.MatchString(err.Error())
+}
+
+func (f *federationBreaker) LookupByAddress(addy string) (response *fproto.NameResponse, err error) {
+	_, domain, err := address.Split(addy)
+	if err != nil {
+		// Invalid addresses are rejected by the client without a request
+		return f.client.LookupByAddress(addy)
+	}
+
+	err = f.breakers.Get(BreakerFederationPrefix+domain).Do(func() error {
+		response, err = f.client.LookupByAddress(addy)
+		return err
+	}, isFederationFailure)
+	return
+}
+
+func (f *federationBreaker) LookupByAccountID(aid string) (response *fproto.IDResponse, err error) {
+	// Domain is known only after loading home_domain of the account
+	return f.client.LookupByAccountID(aid)
+}
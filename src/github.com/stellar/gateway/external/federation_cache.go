@@ -0,0 +1,119 @@
+package external
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	fproto "github.com/stellar/go/protocols/federation"
+)
+
+// DefaultFederationCacheTTL is a time responses of warm federation addresses are cached for
+var DefaultFederationCacheTTL = 5 * time.Minute
+
+// FederationCache caches responses of frequently used (warm) federation addresses, other
+// addresses and account ID lookups are always sent to the client
+type FederationCache struct {
+	client FederationClientInterface
+	ttl    time.Duration
+	now    func() time.Time
+
+	mutex     sync.Mutex
+	addresses map[string]bool
+	entries   map[string]cachedName
+}
+
+type cachedName struct {
+	response  *fproto.NameResponse
+	expiresAt time.Time
+}
+
+// NewFederationCache creates a new FederationCache of client
+func NewFederationCache(client FederationClientInterface, ttl time.Duration, now func() time.Time) *FederationCache {
+	return &FederationCache{
+		client:    client,
+		ttl:       ttl,
+		now:       now,
+		addresses: map[string]bool{},
+		entries:   map[string]cachedName{},
+	}
+}
+
+// SetAddresses replaces warm addresses, cached responses of removed addresses are dropped
+func (c *FederationCache) SetAddresses(addresses []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.addresses = make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		c.addresses[normalizeAddress(address)] = true
+	}
+	for address := range c.entries {
+		if !c.addresses[address] {
+			delete(c.entries, address)
+		}
+	}
+}
+
+// Preload looks up a warm address bypassing the cache and caches the response
+func (c *FederationCache) Preload(addy string) error {
+	_, err := c.lookup(normalizeAddress(addy))
+	return err
+}
+
+// LookupByAddress returns a cached response of a warm address when it has not expired
+func (c *FederationCache) LookupByAddress(addy string) (*fproto.NameResponse, error) {
+	response, _, err := c.lookupByAddress(addy)
+	return response, err
+}
+
+// lookupByAddress is LookupByAddress also returning true when the response is cached
+func (c *FederationCache) lookupByAddress(addy string) (*fproto.NameResponse, bool, error) {
+	address := normalizeAddress(addy)
+
+	c.mutex.Lock()
+	entry, ok := c.entries[address]
+	warm := c.addresses[address]
+	c.mutex.Unlock()
+
+	if !warm {
+		response, err := c.client.LookupByAddress(addy)
+		return response, false, err
+	}
+	if ok && c.now().Before(entry.expiresAt) {
+		response := *entry.response
+		return &response, true, nil
+	}
+	response, err := c.lookup(address)
+	return response, false, err
+}
+
+// LookupByAccountID is not cached
+func (c *FederationCache) LookupByAccountID(aid string) (*fproto.IDResponse, error) {
+	return c.client.LookupByAccountID(aid)
+}
+
+func (c *FederationCache) lookup(address string) (*fproto.NameResponse, error) {
+	response, err := c.client.LookupByAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// The address could be removed by SetAddresses during the lookup
+	if c.addresses[address] {
+		cached := *response
+		c.entries[address] = cachedName{response: &cached, expiresAt: c.now().Add(c.ttl)}
+	}
+	return response, nil
+}
+
+// normalizeAddress lowercases the domain of a federation address, names are case sensitive
+func normalizeAddress(addy string) string {
+	i := strings.LastIndex(addy, "*")
+	if i < 0 {
+		return addy
+	}
+	return addy[:i+1] + strings.ToLower(addy[i+1:])
+}
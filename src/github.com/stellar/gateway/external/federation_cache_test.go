@@ -0,0 +1,57 @@
+package external
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFederationCache(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	client := &stubFederation{}
+	cache := NewFederationCache(client, time.Minute, func() time.Time { return now })
+	cache.SetAddresses([]string{"alice*Stellar.org"})
+
+	require.NoError(t, cache.Preload("alice*stellar.org"))
+	assert.Equal(t, 1, client.calls)
+
+	// Warm addresses are cached until they expire, domains are case insensitive
+	response, err := cache.LookupByAddress("alice*STELLAR.org")
+	require.NoError(t, err)
+	assert.Equal(t, "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", response.AccountID)
+	assert.Equal(t, 1, client.calls)
+
+	now = now.Add(time.Minute)
+	_, err = cache.LookupByAddress("alice*stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, 2, client.calls)
+
+	// Other addresses and account IDs are not cached
+	for i := 0; i < 2; i++ {
+		_, err = cache.LookupByAddress("bob*stellar.org")
+		require.NoError(t, err)
+		_, err = cache.LookupByAccountID("GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 6, client.calls)
+
+	// Failures are not cached
+	client.failures, client.err = 7, errors.New("federation request failed with (503) status code")
+	cache.SetAddresses([]string{"carol*stellar.org"})
+	_, err = cache.LookupByAddress("carol*stellar.org")
+	assert.Equal(t, client.err, err)
+	client.failures = 0
+	_, err = cache.LookupByAddress("carol*stellar.org")
+	require.NoError(t, err)
+	_, err = cache.LookupByAddress("carol*stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, 8, client.calls)
+
+	// Removed addresses are not cached anymore
+	_, err = cache.LookupByAddress("alice*stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, 9, client.calls)
+}
@@ -0,0 +1,360 @@
+package external
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/breaker"
+	"github.com/stellar/go/address"
+	fproto "github.com/stellar/go/protocols/federation"
+	"github.com/stellar/go/support/errors"
+)
+
+// DefaultFederationMetricsDomains is a number of domains with their own metrics when
+// resolver_metrics.max_domains is not set
+const DefaultFederationMetricsDomains = 100
+
+// FederationOtherDomain labels metrics of domains above the cap and of invalid addresses
+const FederationOtherDomain = "other"
+
+// FederationMetricsWindow is the time window metrics are aggregated over, it's divided into
+// minute slots
+const FederationMetricsWindow = time.Hour
+
+const federationMetricsSlot = time.Minute
+
+const federationMetricsSlots = int(FederationMetricsWindow / federationMetricsSlot)
+
+// FederationLatencyBuckets are upper bounds in seconds of buckets of latency histograms
+var FederationLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Classifications of failed lookups
+const (
+	FederationErrorBreakerOpen = "breaker_open"
+	FederationErrorRateLimited = "rate_limited"
+	FederationErrorNetwork     = "network"
+	FederationErrorServer      = "server"
+	FederationErrorNotFound    = "not_found"
+	FederationErrorOther       = "other"
+)
+
+var notFoundStatus = regexp.MustCompile(`failed with \(404\) status code`)
+
+// classifyFederationError returns the classification of an error of a lookup
+func classifyFederationError(err error) string {
+	switch {
+	case isOpenBreaker(err):
+		return FederationErrorBreakerOpen
+	case rateLimitedStatus.MatchString(err.Error()):
+		return FederationErrorRateLimited
+	case isNetworkError(err):
+		return FederationErrorNetwork
+	case serverErrorStatus.MatchString(err.Error()):
+		return FederationErrorServer
+	case notFoundStatus.MatchString(err.Error()):
+		return FederationErrorNotFound
+	default:
+		return FederationErrorOther
+	}
+}
+
+func isOpenBreaker(err error) bool {
+	_, ok := errors.Cause(err).(*breaker.OpenError)
+	return ok
+}
+
+func isNetworkError(err error) bool {
+	_, ok := errors.Cause(err).(net.Error)
+	return ok
+}
+
+// federationCounters count lookups of a domain
+type federationCounters struct {
+	lookups    int64
+	cacheHits  int64
+	errors     map[string]int64
+	latencySum float64
+	// buckets are non-cumulative counts of FederationLatencyBuckets, the last one is +Inf
+	buckets []int64
+}
+
+func (c *federationCounters) observe(latency time.Duration, hit bool, class string) {
+	if c.buckets == nil {
+		c.buckets = make([]int64, len(FederationLatencyBuckets)+1)
+	}
+	c.lookups++
+	if hit {
+		c.cacheHits++
+	}
+	if class != "" {
+		if c.errors == nil {
+			c.errors = map[string]int64{}
+		}
+		c.errors[class]++
+	}
+
+	seconds := latency.Seconds()
+	c.latencySum += seconds
+	i := sort.SearchFloat64s(FederationLatencyBuckets, seconds)
+	c.buckets[i]++
+}
+
+func (c *federationCounters) add(other *federationCounters) {
+	if other.lookups == 0 {
+		return
+	}
+	if c.buckets == nil {
+		c.buckets = make([]int64, len(FederationLatencyBuckets)+1)
+	}
+	c.lookups += other.lookups
+	c.cacheHits += other.cacheHits
+	c.latencySum += other.latencySum
+	for i, count := range other.buckets {
+		c.buckets[i] += count
+	}
+	for class, count := range other.errors {
+		if c.errors == nil {
+			c.errors = map[string]int64{}
+		}
+		c.errors[class] += count
+	}
+}
+
+func (c *federationCounters) stats(domain string) FederationDomainStats {
+	stats := FederationDomainStats{
+		Domain:  domain,
+		Lookups: c.lookups,
+		Errors:  map[string]int64{},
+		Latency: FederationLatencyHistogram{Sum: c.latencySum, Count: c.lookups},
+	}
+	var errorCount int64
+	for class, count := range c.errors {
+		stats.Errors[class] = count
+		errorCount += count
+	}
+	if c.lookups > 0 {
+		stats.ErrorRate = float64(errorCount) / float64(c.lookups)
+		stats.CacheHitRatio = float64(c.cacheHits) / float64(c.lookups)
+		stats.AverageLatency = c.latencySum / float64(c.lookups)
+	}
+
+	var cumulative int64
+	for i, count := range c.buckets {
+		cumulative += count
+		le := "+Inf"
+		if i < len(FederationLatencyBuckets) {
+			le = strconv.FormatFloat(FederationLatencyBuckets[i], 'f', -1, 64)
+		}
+		stats.Latency.Buckets = append(stats.Latency.Buckets, FederationLatencyBucket{LE: le, Count: cumulative})
+	}
+	return stats
+}
+
+// federationDomain contains counters of a domain since start and of minute slots of the window
+type federationDomain struct {
+	total    federationCounters
+	slots    [federationMetricsSlots]federationCounters
+	starts   [federationMetricsSlots]time.Time
+	lastSeen time.Time
+}
+
+func (d *federationDomain) observe(now time.Time, latency time.Duration, hit bool, class string) {
+	d.total.observe(latency, hit, class)
+
+	start := now.Truncate(federationMetricsSlot)
+	i := int(start.Unix()/int64(federationMetricsSlot/time.Second)) % federationMetricsSlots
+	if !d.starts[i].Equal(start) {
+		d.slots[i] = federationCounters{}
+		d.starts[i] = start
+	}
+	d.slots[i].observe(latency, hit, class)
+	d.lastSeen = now
+}
+
+// window returns counters of slots of the window ending at now
+func (d *federationDomain) window(now time.Time) federationCounters {
+	var counters federationCounters
+	from := now.Add(-FederationMetricsWindow)
+	for i := range d.slots {
+		if d.starts[i].After(from) {
+			counters.add(&d.slots[i])
+		}
+	}
+	return counters
+}
+
+// FederationLatencyBucket is a cumulative count of lookups not slower than LE seconds, like `le`
+// buckets of Prometheus histograms
+type FederationLatencyBucket struct {
+	LE    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+// FederationLatencyHistogram is a histogram of latencies of lookups in seconds
+type FederationLatencyHistogram struct {
+	Buckets []FederationLatencyBucket `json:"buckets"`
+	Sum     float64                   `json:"sum"`
+	Count   int64                     `json:"count"`
+}
+
+// FederationDomainStats are metrics of lookups of addresses of a domain. Errors are counts of
+// failed lookups by classification (ex. `network` or `not_found`).
+type FederationDomainStats struct {
+	Domain         string                     `json:"domain"`
+	Lookups        int64                      `json:"lookups"`
+	Errors         map[string]int64           `json:"errors"`
+	ErrorRate      float64                    `json:"error_rate"`
+	CacheHitRatio  float64                    `json:"cache_hit_ratio"`
+	AverageLatency float64                    `json:"average_latency_seconds"`
+	Latency        FederationLatencyHistogram `json:"latency_seconds"`
+}
+
+// cachedFederationClient is implemented by clients reporting responses served from a cache
+type cachedFederationClient interface {
+	lookupByAddress(addy string) (*fproto.NameResponse, bool, error)
+}
+
+// FederationMetrics measures lookups of federation addresses per destination domain: latency,
+// errors by classification and cache hits. Up to maxDomains domains have their own metrics,
+// lookups of other domains are counted in FederationOtherDomain. Domains without lookups in the
+// window are dropped when the cap is reached and their totals are moved to FederationOtherDomain,
+// so many distinct domains don't grow memory and totals of all domains don't change.
+type FederationMetrics struct {
+	client     FederationClientInterface
+	maxDomains int
+	now        func() time.Time
+
+	mutex     sync.Mutex
+	domains   map[string]*federationDomain
+	other     *federationDomain
+	lastSweep time.Time
+}
+
+// NewFederationMetrics returns FederationClientInterface measuring lookups of client, it should
+// wrap the whole client (including cache and retries) to measure resolution seen by payments
+func NewFederationMetrics(client FederationClientInterface, maxDomains int, now func() time.Time) *FederationMetrics {
+	if maxDomains == 0 {
+		maxDomains = DefaultFederationMetricsDomains
+	}
+	return &FederationMetrics{
+		client:     client,
+		maxDomains: maxDomains,
+		now:        now,
+		domains:    map[string]*federationDomain{},
+		other:      &federationDomain{},
+	}
+}
+
+// MaxDomains returns the number of domains with their own metrics
+func (m *FederationMetrics) MaxDomains() int {
+	return m.maxDomains
+}
+
+// LookupByAddress looks up addy with the client and measures the lookup
+func (m *FederationMetrics) LookupByAddress(addy string) (response *fproto.NameResponse, err error) {
+	var domain string
+	if _, d, splitErr := address.Split(addy); splitErr == nil {
+		domain = strings.ToLower(d)
+	}
+
+	hit := false
+	start := m.now()
+	if cached, ok := m.client.(cachedFederationClient); ok {
+		response, hit, err = cached.lookupByAddress(addy)
+	} else {
+		response, err = m.client.LookupByAddress(addy)
+	}
+	end := m.now()
+
+	var class string
+	if err != nil {
+		class = classifyFederationError(err)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.domain(domain, end).observe(end, end.Sub(start), hit, class)
+	return
+}
+
+// LookupByAccountID is not measured, the domain is known only after loading home_domain of the
+// account
+func (m *FederationMetrics) LookupByAccountID(aid string) (*fproto.IDResponse, error) {
+	return m.client.LookupByAccountID(aid)
+}
+
+// domain returns metrics of a domain, creating them when the cap is not reached. The caller must
+// hold the mutex.
+func (m *FederationMetrics) domain(name string, now time.Time) *federationDomain {
+	if name == "" {
+		return m.other
+	}
+	if d, ok := m.domains[name]; ok {
+		return d
+	}
+
+	if len(m.domains) >= m.maxDomains {
+		m.sweep(now)
+		if len(m.domains) >= m.maxDomains {
+			return m.other
+		}
+	}
+	d := &federationDomain{}
+	m.domains[name] = d
+	return d
+}
+
+// sweep drops domains without lookups in the window, at most once per slot so a full cap of
+// active domains is not scanned on every lookup of a new domain
+func (m *FederationMetrics) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < federationMetricsSlot {
+		return
+	}
+	m.lastSweep = now
+
+	for name, d := range m.domains {
+		if now.Sub(d.lastSeen) >= FederationMetricsWindow {
+			m.other.total.add(&d.total)
+			delete(m.domains, name)
+		}
+	}
+}
+
+// Window returns metrics of domains with lookups in the last FederationMetricsWindow ordered by
+// domain, FederationOtherDomain last
+func (m *FederationMetrics) Window() []FederationDomainStats {
+	now := m.now()
+	return m.stats(func(d *federationDomain) federationCounters {
+		return d.window(now)
+	})
+}
+
+// Totals returns metrics of lookups since start ordered by domain, FederationOtherDomain last
+func (m *FederationMetrics) Totals() []FederationDomainStats {
+	return m.stats(func(d *federationDomain) federationCounters {
+		return d.total
+	})
+}
+
+func (m *FederationMetrics) stats(counters func(d *federationDomain) federationCounters) []FederationDomainStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := []FederationDomainStats{}
+	for name, d := range m.domains {
+		if c := counters(d); c.lookups > 0 {
+			stats = append(stats, c.stats(name))
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Domain < stats[j].Domain })
+
+	if c := counters(m.other); c.lookups > 0 {
+		stats = append(stats, c.stats(FederationOtherDomain))
+	}
+	return stats
+}
@@ -0,0 +1,162 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/breaker"
+	fproto "github.com/stellar/go/protocols/federation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timedFederation takes latency of the domain of an address to respond, advancing the clock
+type timedFederation struct {
+	now     *time.Time
+	latency map[string]time.Duration
+	errs    map[string]error
+}
+
+func (f *timedFederation) LookupByAddress(addy string) (*fproto.NameResponse, error) {
+	domain := strings.ToLower(addy[len("alice*"):])
+	*f.now = f.now.Add(f.latency[domain])
+	if err := f.errs[domain]; err != nil {
+		return nil, err
+	}
+	return &fproto.NameResponse{AccountID: "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"}, nil
+}
+
+func (f *timedFederation) LookupByAccountID(aid string) (*fproto.IDResponse, error) {
+	return &fproto.IDResponse{Address: "alice*stellar.org"}, nil
+}
+
+func TestFederationMetrics(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	client := &timedFederation{
+		now:     &now,
+		latency: map[string]time.Duration{"stellar.org": 200 * time.Millisecond, "slow.com": 3 * time.Second},
+		errs:    map[string]error{"slow.com": errors.New("federation request failed with (503) status code")},
+	}
+	metrics := NewFederationMetrics(client, 0, clock)
+	assert.Equal(t, DefaultFederationMetricsDomains, metrics.MaxDomains())
+
+	for i := 0; i < 3; i++ {
+		_, err := metrics.LookupByAddress("alice*Stellar.org")
+		require.NoError(t, err)
+	}
+	_, err := metrics.LookupByAddress("alice*slow.com")
+	assert.Error(t, err)
+
+	window := metrics.Window()
+	require.Len(t, window, 2)
+	slow, stellar := window[0], window[1]
+
+	assert.Equal(t, "slow.com", slow.Domain)
+	assert.Equal(t, int64(1), slow.Lookups)
+	assert.Equal(t, map[string]int64{FederationErrorServer: 1}, slow.Errors)
+	assert.Equal(t, float64(1), slow.ErrorRate)
+	assert.Equal(t, float64(3), slow.AverageLatency)
+
+	// Domains are case insensitive, buckets are cumulative
+	assert.Equal(t, "stellar.org", stellar.Domain)
+	assert.Equal(t, int64(3), stellar.Lookups)
+	assert.Equal(t, float64(0), stellar.ErrorRate)
+	assert.InDelta(t, 0.6, stellar.Latency.Sum, 1e-9)
+	assert.Equal(t, int64(3), stellar.Latency.Count)
+	assert.Equal(t, FederationLatencyBucket{LE: "0.1", Count: 0}, stellar.Latency.Buckets[1])
+	assert.Equal(t, FederationLatencyBucket{LE: "0.25", Count: 3}, stellar.Latency.Buckets[2])
+	assert.Equal(t, FederationLatencyBucket{LE: "+Inf", Count: 3}, stellar.Latency.Buckets[len(FederationLatencyBuckets)])
+
+	// Lookups leave the window after an hour, totals are kept
+	now = now.Add(FederationMetricsWindow)
+	assert.Empty(t, metrics.Window())
+	assert.Len(t, metrics.Totals(), 2)
+
+	// Account ID lookups are not measured, invalid addresses are counted in other
+	_, err = metrics.LookupByAccountID("GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS")
+	require.NoError(t, err)
+	metrics.LookupByAddress("stellar.org")
+	window = metrics.Window()
+	require.Len(t, window, 1)
+	assert.Equal(t, FederationOtherDomain, window[0].Domain)
+}
+
+func TestFederationMetricsCache(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	cache := NewFederationCache(&stubFederation{}, time.Minute, clock)
+	cache.SetAddresses([]string{"alice*stellar.org"})
+	metrics := NewFederationMetrics(cache, 0, clock)
+
+	for _, addy := range []string{"alice*stellar.org", "alice*stellar.org", "alice*stellar.org", "bob*stellar.org"} {
+		_, err := metrics.LookupByAddress(addy)
+		require.NoError(t, err)
+	}
+
+	window := metrics.Window()
+	require.Len(t, window, 1)
+	assert.Equal(t, int64(4), window[0].Lookups)
+	assert.Equal(t, 0.5, window[0].CacheHitRatio)
+}
+
+func TestFederationMetricsDomainsChurn(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	client := &timedFederation{now: &now}
+	metrics := NewFederationMetrics(client, 10, clock)
+
+	// Domains above the cap are counted in other
+	for i := 0; i < 1000; i++ {
+		_, err := metrics.LookupByAddress(fmt.Sprintf("alice*domain%d.com", i))
+		require.NoError(t, err)
+	}
+	window := metrics.Window()
+	require.Len(t, window, 11)
+	assert.Equal(t, FederationOtherDomain, window[10].Domain)
+	assert.Equal(t, int64(990), window[10].Lookups)
+
+	// Domains active in the window keep their slots
+	now = now.Add(30 * time.Minute)
+	_, err := metrics.LookupByAddress("alice*domain0.com")
+	require.NoError(t, err)
+	_, err = metrics.LookupByAddress("alice*new0.com")
+	require.NoError(t, err)
+	assert.Len(t, metrics.domains, 10)
+
+	// Idle domains are dropped for new ones, their totals are moved to other
+	now = now.Add(45 * time.Minute)
+	for i := 0; i < 1000; i++ {
+		_, err = metrics.LookupByAddress(fmt.Sprintf("alice*new%d.com", i))
+		require.NoError(t, err)
+	}
+	assert.Len(t, metrics.domains, 10)
+	assert.Contains(t, metrics.domains, "domain0.com")
+	assert.Contains(t, metrics.domains, "new1.com")
+	assert.NotContains(t, metrics.domains, "domain1.com")
+
+	var lookups int64
+	totals := metrics.Totals()
+	require.Len(t, totals, 11)
+	for _, stats := range totals {
+		lookups += stats.Lookups
+	}
+	assert.Equal(t, int64(2002), lookups)
+}
+
+func TestClassifyFederationError(t *testing.T) {
+	for err, class := range map[error]string{
+		&breaker.OpenError{Dependency: "federation:stellar.org"}:           FederationErrorBreakerOpen,
+		errors.New("http get failed with (429) status code"):               FederationErrorRateLimited,
+		&net.DNSError{Err: "no such host", Name: "federation.stellar.org"}: FederationErrorNetwork,
+		errors.New("http get failed with (502) status code"):               FederationErrorServer,
+		errors.New("http get failed with (404) status code"):               FederationErrorNotFound,
+		errors.New("stellar.toml is missing federation server info"):       FederationErrorOther,
+	} {
+		assert.Equal(t, class, classifyFederationError(err), err.Error())
+	}
+}
@@ -0,0 +1,72 @@
+package external
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/stellar/gateway/retry"
+	fproto "github.com/stellar/go/protocols/federation"
+)
+
+// DefaultResolverRetry makes a single attempt, federation requests are retried only when
+// `retry.resolver` is configured. Rate limited requests are repeated for up to 5 seconds.
+var DefaultResolverRetry = retry.Settings{MaxAttempts: 1, BaseBackoff: time.Second, MaxBackoff: 5 * time.Second, MaxRateLimitWait: 5 * time.Second}
+
+// rateLimitedStatus matches errors of federation (and home domain) requests answered with 429
+var rateLimitedStatus = regexp.MustCompile(`\(429\) status code|Too Many Requests`)
+
+// rateLimitedError marks a rate limited request for the retry policy. The federation client does
+// not return response headers, so the time of the reset is not known.
+type rateLimitedError struct {
+	error
+}
+
+func (e rateLimitedError) RetryIn() time.Duration {
+	return 0
+}
+
+// markRateLimited wraps errors of rate limited requests in rateLimitedError
+func markRateLimited(err error) error {
+	if err != nil && rateLimitedStatus.MatchString(err.Error()) {
+		return rateLimitedError{err}
+	}
+	return err
+}
+
+// unmarkRateLimited returns the error of the client
+func unmarkRateLimited(err error) error {
+	if rateLimited, ok := err.(rateLimitedError); ok {
+		return rateLimited.error
+	}
+	return err
+}
+
+// federationRetry retries federation requests failing with network or server errors
+type federationRetry struct {
+	client FederationClientInterface
+	policy *retry.Policy
+}
+
+// NewFederationRetry returns FederationClientInterface retrying requests of client with policy.
+// A client with circuit breakers should be wrapped so open breakers are not retried.
+func NewFederationRetry(client FederationClientInterface, policy *retry.Policy) FederationClientInterface {
+	return &federationRetry{client: client, policy: policy}
+}
+
+func (f *federationRetry) LookupByAddress(addy string) (response *fproto.NameResponse, err error) {
+	err = f.policy.Do(func(int) error {
+		response, err = f.client.LookupByAddress(addy)
+		return markRateLimited(err)
+	}, isFederationFailure)
+	err = unmarkRateLimited(err)
+	return
+}
+
+func (f *federationRetry) LookupByAccountID(aid string) (response *fproto.IDResponse, err error) {
+	err = f.policy.Do(func(int) error {
+		response, err = f.client.LookupByAccountID(aid)
+		return markRateLimited(err)
+	}, isFederationFailure)
+	err = unmarkRateLimited(err)
+	return
+}
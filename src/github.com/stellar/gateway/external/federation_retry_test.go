@@ -0,0 +1,96 @@
+package external
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/breaker"
+	"github.com/stellar/gateway/retry"
+	fproto "github.com/stellar/go/protocols/federation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubFederation fails a number of requests before responding
+type stubFederation struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (s *stubFederation) LookupByAddress(addy string) (*fproto.NameResponse, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, s.err
+	}
+	return &fproto.NameResponse{AccountID: "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"}, nil
+}
+
+func (s *stubFederation) LookupByAccountID(aid string) (*fproto.IDResponse, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, s.err
+	}
+	return &fproto.IDResponse{Address: "alice*stellar.org"}, nil
+}
+
+func TestFederationRetry(t *testing.T) {
+	serverError := errors.New("federation request failed with (503) status code")
+	var waits []time.Duration
+	sleep := func(d time.Duration) { waits = append(waits, d) }
+
+	// A single attempt by default
+	client := &stubFederation{failures: 1, err: serverError}
+	_, err := NewFederationRetry(client, retry.NewPolicy(retry.Resolver, DefaultResolverRetry, sleep)).LookupByAddress("alice*stellar.org")
+	assert.Equal(t, serverError, err)
+	assert.Equal(t, 1, client.calls)
+	assert.Empty(t, waits)
+
+	settings := DefaultResolverRetry
+	settings.MaxAttempts = 3
+	resolver := NewFederationRetry(client, retry.NewPolicy(retry.Resolver, settings, sleep))
+
+	client.calls = 0
+	response, err := resolver.LookupByAddress("alice*stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", response.AccountID)
+	assert.Equal(t, []time.Duration{time.Second}, waits)
+
+	// Network errors are retried
+	client.calls = 0
+	client.err = &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	_, err = resolver.LookupByAccountID("GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS")
+	require.NoError(t, err)
+	assert.Equal(t, 2, client.calls)
+
+	// Unknown names and open breakers are not
+	for _, clientErr := range []error{errors.New("federation request failed with (404) status code"), &breaker.OpenError{Dependency: "federation:stellar.org"}} {
+		client.calls = 0
+		client.err = clientErr
+		_, err = resolver.LookupByAddress("alice*stellar.org")
+		assert.Equal(t, clientErr, err)
+		assert.Equal(t, 1, client.calls)
+	}
+}
+
+func TestFederationRetryRateLimited(t *testing.T) {
+	rateLimited := errors.New("get federation failed: http get failed with (429) status code")
+	var waits []time.Duration
+	sleep := func(d time.Duration) { waits = append(waits, d) }
+	resolver := NewFederationRetry(&stubFederation{failures: 2, err: rateLimited}, retry.NewPolicy(retry.Resolver, DefaultResolverRetry, sleep))
+
+	// Repeated with backoffs although a single attempt is made by default
+	_, err := resolver.LookupByAddress("alice*stellar.org")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+
+	// The error of the client is returned when the wait would be too long
+	client := &stubFederation{failures: 10, err: rateLimited}
+	waits = nil
+	_, err = NewFederationRetry(client, retry.NewPolicy(retry.Resolver, DefaultResolverRetry, sleep)).LookupByAccountID("GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS")
+	assert.Equal(t, rateLimited, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+	assert.Equal(t, 3, client.calls)
+}
@@ -0,0 +1,225 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/go/clients/stellartoml"
+)
+
+const (
+	// DefaultIssuerInfoTTL is a time stellar.toml files of issuers are cached for
+	DefaultIssuerInfoTTL = 72 * time.Hour
+	// DefaultIssuerInfoWait is a time receive callbacks wait for issuers that are not cached
+	DefaultIssuerInfoWait = time.Second
+	// issuerInfoFailureTTL is a time failed lookups are cached for, so payments of an issuer
+	// without a working stellar.toml don't trigger a lookup each
+	issuerInfoFailureTTL = time.Hour
+	// issuerTomlMaxSize is the maximum size of stellar.toml files of issuers, CURRENCIES make
+	// them larger than stellartoml.StellarTomlMaxSize
+	issuerTomlMaxSize = 100 * 1024
+)
+
+// IssuerInfo identifies the anchor that issued an asset
+type IssuerInfo struct {
+	// Name is ORG_NAME of the stellar.toml
+	Name string `json:"issuer_name,omitempty"`
+	// Domain is the home domain of the issuer account
+	Domain string `json:"issuer_domain,omitempty"`
+	// AnchorAssetStatus is `status` of the asset in CURRENCIES of the stellar.toml, ex. `live`
+	AnchorAssetStatus string `json:"anchor_asset_status,omitempty"`
+}
+
+// HTTPGetter is an http client fetching stellar.toml files of issuers
+type HTTPGetter interface {
+	Get(url string) (*http.Response, error)
+}
+
+// IssuerInfoResolver resolves home domains of issuer accounts and their stellar.toml files.
+// Lookups run in the background and are cached, a failed lookup is reported as no info. The
+// zero value is disabled and never looks up issuers.
+type IssuerInfoResolver struct {
+	horizon horizon.HorizonInterface
+	client  HTTPGetter
+	ttl     time.Duration
+	now     func() time.Time
+	log     *logrus.Entry
+	// UseHTTP fetches stellar.toml files using plain HTTP, used by tests
+	UseHTTP bool
+
+	mutex   sync.Mutex
+	entries map[string]*issuerEntry
+}
+
+// issuerEntry is a lookup of an issuer account, done is closed when it finishes
+type issuerEntry struct {
+	done      chan struct{}
+	domain    string
+	toml      *issuerToml
+	expiresAt time.Time
+	// previous is the expired entry of the issuer, returned until the new lookup finishes
+	previous *issuerEntry
+}
+
+// issuerToml contains fields of a stellar.toml identifying the issuer
+type issuerToml struct {
+	Accounts      []string `toml:"ACCOUNTS"`
+	Documentation struct {
+		OrgName string `toml:"ORG_NAME"`
+	} `toml:"DOCUMENTATION"`
+	Currencies []struct {
+		Code   string `toml:"code"`
+		Issuer string `toml:"issuer"`
+		Status string `toml:"status"`
+	} `toml:"CURRENCIES"`
+}
+
+// NewIssuerInfoResolver creates a new IssuerInfoResolver caching stellar.toml files for ttl
+func NewIssuerInfoResolver(h horizon.HorizonInterface, client HTTPGetter, ttl time.Duration, now func() time.Time) *IssuerInfoResolver {
+	return &IssuerInfoResolver{
+		horizon: h,
+		client:  client,
+		ttl:     ttl,
+		now:     now,
+		log:     logrus.WithField("service", "IssuerInfoResolver"),
+		entries: map[string]*issuerEntry{},
+	}
+}
+
+// Lookup returns info of an asset issuer, waiting up to wait for a lookup that is not cached.
+// ok is false when the resolver is disabled, the lookup failed or it did not finish in time
+// (it continues in the background and its result is cached).
+func (r *IssuerInfoResolver) Lookup(code, issuer string, wait time.Duration) (info IssuerInfo, ok bool) {
+	if r.horizon == nil || issuer == "" {
+		return IssuerInfo{}, false
+	}
+
+	entry := r.entry(issuer)
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-entry.done:
+		case <-timer.C:
+		}
+	}
+
+	select {
+	case <-entry.done:
+	default:
+		if entry.previous == nil {
+			return IssuerInfo{}, false
+		}
+		entry = entry.previous
+	}
+	return entry.info(code, issuer)
+}
+
+// entry returns the entry of an issuer, a lookup is started when it's not cached or expired
+func (r *IssuerInfoResolver) entry(issuer string) *issuerEntry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry, ok := r.entries[issuer]
+	if ok {
+		select {
+		case <-entry.done:
+			if r.now().Before(entry.expiresAt) {
+				return entry
+			}
+		default:
+			return entry
+		}
+	}
+
+	next := &issuerEntry{done: make(chan struct{})}
+	if ok && entry.toml != nil {
+		next.previous = entry
+	}
+	r.entries[issuer] = next
+	go r.resolve(issuer, next)
+	return next
+}
+
+func (r *IssuerInfoResolver) resolve(issuer string, entry *issuerEntry) {
+	domain, toml, err := r.fetch(issuer)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err != nil {
+		r.log.WithFields(logrus.Fields{"issuer": issuer, "err": err}).Warn("Cannot resolve issuer info")
+		entry.expiresAt = r.now().Add(issuerInfoFailureTTL)
+	} else {
+		entry.domain = domain
+		entry.toml = toml
+		entry.expiresAt = r.now().Add(r.ttl)
+	}
+	close(entry.done)
+}
+
+// fetch loads the home domain of an issuer account and its stellar.toml
+func (r *IssuerInfoResolver) fetch(issuer string) (string, *issuerToml, error) {
+	account, err := r.horizon.LoadAccount(issuer)
+	if err != nil {
+		return "", nil, err
+	}
+	if account.HomeDomain == "" {
+		return "", nil, errors.New("issuer account has no home_domain")
+	}
+
+	scheme := "https"
+	if r.UseHTTP {
+		scheme = "http"
+	}
+	resp, err := r.client.Get(fmt.Sprintf("%s://%s%s", scheme, account.HomeDomain, stellartoml.WellKnownPath))
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("stellar.toml request failed with (%d) status code", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, issuerTomlMaxSize))
+	if err != nil {
+		return "", nil, err
+	}
+	var parsed issuerToml
+	_, err = toml.Decode(string(body), &parsed)
+	if err != nil {
+		return "", nil, err
+	}
+	return account.HomeDomain, &parsed, nil
+}
+
+// info returns info of an asset of a finished lookup. Anyone can set a home_domain of an account
+// so the issuer must be listed by the stellar.toml of the domain.
+func (entry *issuerEntry) info(code, issuer string) (IssuerInfo, bool) {
+	if entry.toml == nil {
+		return IssuerInfo{}, false
+	}
+
+	info := IssuerInfo{Name: entry.toml.Documentation.OrgName, Domain: entry.domain}
+	listed := false
+	for _, account := range entry.toml.Accounts {
+		listed = listed || account == issuer
+	}
+	for _, currency := range entry.toml.Currencies {
+		if currency.Issuer != issuer {
+			continue
+		}
+		listed = true
+		if currency.Code == code {
+			info.AnchorAssetStatus = currency.Status
+		}
+	}
+	return info, listed
+}
@@ -0,0 +1,104 @@
+package external
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testIssuer     = "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"
+	unlistedIssuer = "GBL27BKG2JSDU6KQ5YJKCDWTVIU24VTG4PLB63SF4K2DBZS5XZMWRPVU"
+)
+
+func TestIssuerInfoResolver(t *testing.T) {
+	var requests int32
+	toml := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "/.well-known/stellar.toml", r.URL.Path)
+		w.Write([]byte(`
+[DOCUMENTATION]
+ORG_NAME = "Example Anchor"
+
+[[CURRENCIES]]
+code = "USD"
+issuer = "` + testIssuer + `"
+status = "live"
+`))
+	}))
+	defer toml.Close()
+	domain := strings.TrimPrefix(toml.URL, "http://")
+
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	mockHorizon := new(mocks.MockHorizon)
+	resolver := NewIssuerInfoResolver(mockHorizon, http.DefaultClient, time.Hour, func() time.Time { return now })
+	resolver.UseHTTP = true
+
+	mockHorizon.On("LoadAccount", testIssuer).Return(horizon.AccountResponse{AccountID: testIssuer, HomeDomain: domain}, nil).Twice()
+	mockHorizon.On("LoadAccount", unlistedIssuer).Return(horizon.AccountResponse{AccountID: unlistedIssuer, HomeDomain: domain}, nil).Once()
+
+	info, ok := resolver.Lookup("USD", testIssuer, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, IssuerInfo{Name: "Example Anchor", Domain: domain, AnchorAssetStatus: "live"}, info)
+
+	// Other assets of a listed issuer have no status, the stellar.toml is cached
+	info, ok = resolver.Lookup("EUR", testIssuer, 0)
+	assert.True(t, ok)
+	assert.Equal(t, IssuerInfo{Name: "Example Anchor", Domain: domain}, info)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Issuers not listed by the stellar.toml of their home domain are not identified
+	_, ok = resolver.Lookup("USD", unlistedIssuer, time.Second)
+	assert.False(t, ok)
+
+	// Expired entries are returned until the lookup finishes
+	now = now.Add(time.Hour)
+	info, ok = resolver.Lookup("USD", testIssuer, 0)
+	assert.True(t, ok)
+	assert.Equal(t, "Example Anchor", info.Name)
+	_, ok = resolver.Lookup("USD", testIssuer, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	mockHorizon.AssertExpectations(t)
+}
+
+func TestIssuerInfoResolverFailures(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	mockHorizon := new(mocks.MockHorizon)
+	resolver := NewIssuerInfoResolver(mockHorizon, http.DefaultClient, time.Hour, func() time.Time { return now })
+
+	loaded := make(chan struct{})
+	mockHorizon.On("LoadAccount", testIssuer).Return(horizon.AccountResponse{}, errors.New("horizon is down")).
+		Run(func(mock.Arguments) { <-loaded }).Once()
+
+	// The lookup continues in the background
+	_, ok := resolver.Lookup("USD", testIssuer, time.Millisecond)
+	assert.False(t, ok)
+	close(loaded)
+
+	// Failed lookups are cached
+	_, ok = resolver.Lookup("USD", testIssuer, time.Second)
+	assert.False(t, ok)
+	_, ok = resolver.Lookup("USD", testIssuer, time.Second)
+	assert.False(t, ok)
+	mockHorizon.AssertExpectations(t)
+
+	mockHorizon.On("LoadAccount", testIssuer).Return(horizon.AccountResponse{AccountID: testIssuer}, nil).Once()
+	now = now.Add(issuerInfoFailureTTL)
+	_, ok = resolver.Lookup("USD", testIssuer, time.Second)
+	assert.False(t, ok)
+	mockHorizon.AssertExpectations(t)
+
+	// The zero value is disabled
+	_, ok = (&IssuerInfoResolver{}).Lookup("USD", testIssuer, time.Second)
+	assert.False(t, ok)
+}
@@ -0,0 +1,198 @@
+// Package generation detects accounts merged away and created again. A recreated account starts
+// with a new sequence number and no balances, so cached sequence numbers, statistics of payments
+// and expected payments of its previous generation must not be applied to it.
+package generation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/utc"
+)
+
+// RecentWindow is the time a recreated account is reported by Recent
+const RecentWindow = 30 * 24 * time.Hour
+
+// recreatedEvent is a payload of account_recreated events, their subject is the account ID
+type recreatedEvent struct {
+	AccountID  string `json:"account_id"`
+	Generation int64  `json:"generation"`
+	// PreviousSequence is the highest sequence number of the previous generation
+	PreviousSequence int64 `json:"previous_sequence"`
+	Sequence         int64 `json:"sequence"`
+}
+
+// Tracker numbers generations of accounts from their observed sequence numbers. An account is
+// recreated when its sequence number is lower than the highest one observed, or when it exists
+// again after it was merged. Nil and zero Trackers are disabled: every account is in generation 1.
+type Tracker struct {
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	now           func() time.Time
+	log           *logrus.Entry
+	// Events records account_recreated events, optional
+	Events events.RecorderInterface
+	// Recreated hooks are called with the ID of a recreated account, ex. to drop its cached
+	// sequence number
+	Recreated []func(accountID string)
+	// mutex serializes updates of generations, a recreation observed concurrently would be
+	// counted twice
+	mutex sync.Mutex
+}
+
+// NewTracker creates a new Tracker
+func NewTracker(repository db.RepositoryInterface, entityManager db.EntityManagerInterface, now func() time.Time) *Tracker {
+	return &Tracker{
+		repository:    repository,
+		entityManager: entityManager,
+		now:           now,
+		log:           logrus.WithFields(logrus.Fields{"service": "GenerationTracker"}),
+	}
+}
+
+// Enabled returns false for nil and zero Trackers
+func (t *Tracker) Enabled() bool {
+	return t != nil && t.repository != nil
+}
+
+// Observe records the sequence number of an existing account and returns its generation. The first
+// observed account is in generation 1.
+func (t *Tracker) Observe(accountID string, sequence uint64) (int64, error) {
+	return t.record(accountID, int64(sequence), false)
+}
+
+// Created records an observed create_account of an account, a merged account is in its next
+// generation. Its sequence number is recorded by the next Observe.
+func (t *Tracker) Created(accountID string) (int64, error) {
+	return t.record(accountID, 0, true)
+}
+
+func (t *Tracker) record(accountID string, sequence int64, created bool) (int64, error) {
+	if !t.Enabled() {
+		return 1, nil
+	}
+
+	recreated, g, err := t.update(accountID, sequence, created)
+	if err != nil {
+		return 0, err
+	}
+	if recreated {
+		for _, hook := range t.Recreated {
+			hook(accountID)
+		}
+	}
+	return g.Generation, nil
+}
+
+// update returns true when the account was recreated. The sequence number of a created account is
+// not known.
+func (t *Tracker) update(accountID string, sequence int64, created bool) (bool, *entities.AccountGeneration, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	g, err := t.repository.GetAccountGeneration(accountID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	now := utc.New(t.now())
+	if g == nil {
+		g = &entities.AccountGeneration{AccountID: accountID, Generation: 1, Sequence: sequence, UpdatedAt: now}
+		return false, g, t.entityManager.Persist(g)
+	}
+
+	// A create_account of an account that was not merged is a replay of its creation
+	if g.MergedAt == nil && (created || sequence >= g.Sequence) {
+		if created || sequence == g.Sequence {
+			return false, g, nil
+		}
+		g.Sequence = sequence
+		g.UpdatedAt = now
+		return false, g, t.entityManager.Persist(g)
+	}
+
+	event := recreatedEvent{AccountID: accountID, Generation: g.Generation + 1, PreviousSequence: g.Sequence, Sequence: sequence}
+	g.Generation++
+	g.Sequence = sequence
+	g.MergedAt = nil
+	g.RecreatedAt = &now
+	g.UpdatedAt = now
+	err = t.entityManager.Persist(g)
+	if err != nil {
+		return false, nil, err
+	}
+
+	t.log.WithFields(logrus.Fields{
+		"account_id":        accountID,
+		"generation":        g.Generation,
+		"previous_sequence": event.PreviousSequence,
+		"sequence":          sequence,
+	}).Warn("Account was merged and created again")
+	if t.Events != nil {
+		t.Events.Record(events.AccountRecreated, accountID, event)
+	}
+	return true, g, nil
+}
+
+// Merged records that an account was merged (or it's missing in Horizon), the account is in the
+// next generation when it exists again. Accounts that were not observed yet are not recorded.
+func (t *Tracker) Merged(accountID string) error {
+	if !t.Enabled() {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	g, err := t.repository.GetAccountGeneration(accountID)
+	if err != nil || g == nil || g.MergedAt != nil {
+		return err
+	}
+
+	now := utc.New(t.now())
+	g.MergedAt = &now
+	g.UpdatedAt = now
+	return t.entityManager.Persist(g)
+}
+
+// Generation returns the generation payments to an account are counted in. Payments to a merged
+// account create its next generation.
+func (t *Tracker) Generation(accountID string) (int64, error) {
+	if !t.Enabled() {
+		return 1, nil
+	}
+
+	g, err := t.repository.GetAccountGeneration(accountID)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case g == nil:
+		return 1, nil
+	case g.MergedAt != nil:
+		return g.Generation + 1, nil
+	default:
+		return g.Generation, nil
+	}
+}
+
+// Recent returns the generation of an account that was merged or recreated within RecentWindow,
+// nil for other accounts
+func (t *Tracker) Recent(accountID string) (*entities.AccountGeneration, error) {
+	if !t.Enabled() {
+		return nil, nil
+	}
+
+	g, err := t.repository.GetAccountGeneration(accountID)
+	if err != nil || g == nil {
+		return nil, err
+	}
+	if g.MergedAt != nil || (g.RecreatedAt != nil && t.now().Sub(g.RecreatedAt.Time()) < RecentWindow) {
+		return g, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,137 @@
+package generation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorded records types of events
+type recorded []string
+
+func (r *recorded) Record(eventType, subject string, payload interface{}) {
+	*r = append(*r, eventType+" "+subject)
+}
+
+func TestTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-generation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+	repository := db.NewRepository(driver)
+
+	now := time.Date(2018, 3, 1, 10, 15, 0, 0, time.UTC)
+	tracker := NewTracker(repository, db.NewEntityManager(driver), func() time.Time { return now })
+	recordedEvents := &recorded{}
+	tracker.Events = recordedEvents
+	var invalidated []string
+	tracker.Recreated = []func(string){func(accountID string) { invalidated = append(invalidated, accountID) }}
+
+	t.Run("lower sequence number is a new generation", func(t *testing.T) {
+		source := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+		for _, sequence := range []uint64{100, 105, 105} {
+			g, err := tracker.Observe(source, sequence)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), g)
+		}
+		assert.Empty(t, invalidated)
+
+		// Merged without an observed merge, created again in an earlier ledger range
+		g, err := tracker.Observe(source, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), g)
+		assert.Equal(t, []string{source}, invalidated)
+		assert.Equal(t, &recorded{events.AccountRecreated + " " + source}, recordedEvents)
+
+		stored, err := repository.GetAccountGeneration(source)
+		require.NoError(t, err)
+		assert.Equal(t, int64(20), stored.Sequence)
+		assert.Equal(t, now, stored.RecreatedAt.Time())
+	})
+
+	invalidated = nil
+	*recordedEvents = nil
+
+	t.Run("merged account is a new generation when it exists again", func(t *testing.T) {
+		destination := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+		require.NoError(t, tracker.Merged(destination))
+		g, err := tracker.Generation(destination)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), g, "accounts not observed before are not recorded merged")
+
+		_, err = tracker.Observe(destination, 1<<32)
+		require.NoError(t, err)
+		recent, err := tracker.Recent(destination)
+		require.NoError(t, err)
+		assert.Nil(t, recent)
+
+		require.NoError(t, tracker.Merged(destination))
+		g, err = tracker.Generation(destination)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), g, "payments to a merged account create the next generation")
+		recent, err = tracker.Recent(destination)
+		require.NoError(t, err)
+		assert.NotNil(t, recent)
+
+		// Created again with a higher sequence number
+		g, err = tracker.Observe(destination, 2<<32)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), g)
+		g, err = tracker.Generation(destination)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), g)
+		assert.Equal(t, []string{destination}, invalidated)
+		assert.Equal(t, &recorded{events.AccountRecreated + " " + destination}, recordedEvents)
+
+		now = now.Add(RecentWindow)
+		recent, err = tracker.Recent(destination)
+		require.NoError(t, err)
+		assert.Nil(t, recent)
+	})
+
+	invalidated = nil
+
+	t.Run("create_account of a merged account is a new generation", func(t *testing.T) {
+		destination := "GAMVF7G4GJC4A7JMFJWLUAEIBFQD5RT3DCB5DC5TJDEKQBBACQ4JZVEE"
+		_, err := tracker.Observe(destination, 1<<32+3)
+		require.NoError(t, err)
+		// Replayed creation of the first generation
+		g, err := tracker.Created(destination)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), g)
+
+		require.NoError(t, tracker.Merged(destination))
+		g, err = tracker.Created(destination)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), g)
+		assert.Equal(t, []string{destination}, invalidated)
+
+		// Sequence number of the new generation is observed later
+		g, err = tracker.Observe(destination, 3<<32)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), g)
+		assert.Equal(t, []string{destination}, invalidated)
+	})
+
+	t.Run("disabled tracker", func(t *testing.T) {
+		var disabled *Tracker
+		g, err := disabled.Observe("GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ", 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), g)
+		g, err = (&Tracker{}).Generation("GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), g)
+	})
+}
@@ -0,0 +1,172 @@
+// Package handoff hands synchronous payments over to asynchronous processing when they are not
+// finished before the deadline of their request. Results of handed off payments are kept in a
+// Store until they are fetched by ID.
+package handoff
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/inflight"
+)
+
+const (
+	// TimeoutHeader is the number of seconds a client waits for the response
+	TimeoutHeader = "Request-Timeout"
+	// MaxWait is the longest time requests can wait for a payment before it's handed off
+	MaxWait = 60 * time.Second
+	// DefaultSize is a number of finished payments kept by default
+	DefaultSize = 1024
+	// timeoutMargin is subtracted from TimeoutHeader so the response reaches the client in time
+	timeoutMargin = 500 * time.Millisecond
+)
+
+// ParseWait parses a wait in seconds, ex. `max_wait` param
+func ParseWait(value string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0, errors.New("must be a positive number of seconds")
+	}
+	wait := time.Duration(seconds * float64(time.Second))
+	if wait > MaxWait {
+		return 0, errors.New("must be at most " + strconv.Itoa(int(MaxWait/time.Second)) + " seconds")
+	}
+	return wait, nil
+}
+
+// Wait returns how long a request waits for its payment: the shorter of maxWait and TimeoutHeader
+// less a margin for the response to arrive. 0 is returned when neither is set and the request
+// waits until the payment is finished, invalid TimeoutHeader values are ignored.
+func Wait(maxWait time.Duration, header http.Header) time.Duration {
+	wait := maxWait
+	if value := header.Get(TimeoutHeader); value != "" {
+		if timeout, err := ParseWait(value); err == nil {
+			timeout -= timeoutMargin
+			if timeout <= 0 {
+				timeout = time.Millisecond
+			}
+			if wait == 0 || timeout < wait {
+				wait = timeout
+			}
+		}
+	}
+	return wait
+}
+
+// Result is the response of a finished payment
+type Result struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Store keeps payments handed off to asynchronous processing. Payments are kept until they are
+// finished and then until size newer payments are finished.
+type Store struct {
+	mutex    sync.Mutex
+	payments map[string]*Payment
+	// finished are IDs of finished payments, oldest first
+	finished []string
+	size     int
+}
+
+// NewStore creates a new Store keeping size finished payments
+func NewStore(size int) *Store {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Store{payments: map[string]*Payment{}, size: size}
+}
+
+// Start starts a payment processed while its request waits, tracked is its entry in the inflight
+// registry (can be nil). It's added to the store only when it's handed off.
+func (s *Store) Start(tracked *inflight.Payment) (*Payment, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	return &Payment{ID: hex.EncodeToString(id), Tracked: tracked, store: s, done: make(chan struct{})}, nil
+}
+
+// Get returns a handed off payment, false when it's unknown or was dropped
+func (s *Store) Get(id string) (*Payment, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	payment, ok := s.payments[id]
+	return payment, ok
+}
+
+func (s *Store) add(payment *Payment) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.payments[payment.ID] = payment
+}
+
+func (s *Store) finish(payment *Payment) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.finished = append(s.finished, payment.ID)
+	for len(s.finished) > s.size {
+		delete(s.payments, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+}
+
+// Payment is a payment that can be handed off. Finish and HandOff are serialized by its mutex:
+// either the request gets the result or the payment is handed off, never both.
+type Payment struct {
+	// ID is returned to clients of handed off payments
+	ID string
+	// Tracked reports the stage and transaction hash of the payment
+	Tracked   *inflight.Payment
+	store     *Store
+	mutex     sync.Mutex
+	handedOff bool
+	result    *Result
+	done      chan struct{}
+}
+
+// Finish stores the response of the payment
+func (p *Payment) Finish(result Result) {
+	p.mutex.Lock()
+	p.result = &result
+	handedOff := p.handedOff
+	p.mutex.Unlock()
+	close(p.done)
+
+	if handedOff {
+		p.store.finish(p)
+	}
+}
+
+// Done is closed when the payment is finished
+func (p *Payment) Done() <-chan struct{} {
+	return p.done
+}
+
+// HandOff converts the payment to an asynchronous one and adds it to the store. False is returned
+// when the payment has already been finished and its result should be sent instead.
+func (p *Payment) HandOff() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.result != nil {
+		return false
+	}
+	p.handedOff = true
+	// Added while locked so Finish cannot remove it from the store first
+	p.store.add(p)
+	return true
+}
+
+// Result returns the response of the payment, nil until it's finished
+func (p *Payment) Result() *Result {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.result
+}
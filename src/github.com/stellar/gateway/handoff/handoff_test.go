@@ -0,0 +1,107 @@
+package handoff
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWait(t *testing.T) {
+	wait, err := ParseWait("2.5")
+	require.NoError(t, err)
+	assert.Equal(t, 2500*time.Millisecond, wait)
+
+	for _, invalid := range []string{"", "abc", "0", "-1", "61"} {
+		_, err := ParseWait(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestWait(t *testing.T) {
+	tests := []struct {
+		maxWait time.Duration
+		timeout string
+		wait    time.Duration
+	}{
+		{0, "", 0},
+		{5 * time.Second, "", 5 * time.Second},
+		{0, "10", 9500 * time.Millisecond},
+		{5 * time.Second, "10", 5 * time.Second},
+		{20 * time.Second, "10", 9500 * time.Millisecond},
+		{0, "0.2", time.Millisecond},
+		{5 * time.Second, "invalid", 5 * time.Second},
+	}
+	for _, test := range tests {
+		header := http.Header{}
+		if test.timeout != "" {
+			header.Set(TimeoutHeader, test.timeout)
+		}
+		assert.Equal(t, test.wait, Wait(test.maxWait, header), "%s %s", test.maxWait, test.timeout)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(2)
+
+	finished, err := store.Start(nil)
+	require.NoError(t, err)
+	finished.Finish(Result{Status: http.StatusOK, Body: []byte("{}")})
+	assert.False(t, finished.HandOff())
+	_, ok := store.Get(finished.ID)
+	assert.False(t, ok, "payments finished in time are not stored")
+
+	var payments []*Payment
+	for i := 0; i < 3; i++ {
+		payment, err := store.Start(nil)
+		require.NoError(t, err)
+		require.True(t, payment.HandOff())
+		payments = append(payments, payment)
+	}
+	assert.Len(t, payments[0].ID, 32)
+	assert.NotEqual(t, payments[0].ID, payments[1].ID)
+
+	stored, ok := store.Get(payments[0].ID)
+	require.True(t, ok)
+	assert.Nil(t, stored.Result())
+
+	for _, payment := range payments {
+		payment.Finish(Result{Status: http.StatusOK})
+	}
+	_, ok = store.Get(payments[0].ID)
+	assert.False(t, ok, "oldest finished payment is dropped")
+	stored, ok = store.Get(payments[2].ID)
+	require.True(t, ok)
+	assert.Equal(t, &Result{Status: http.StatusOK}, stored.Result())
+}
+
+// The request either gets the result or hands the payment off, the result is never lost
+func TestHandOffRace(t *testing.T) {
+	store := NewStore(DefaultSize)
+	for i := 0; i < 1000; i++ {
+		payment, err := store.Start(nil)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payment.Finish(Result{Status: http.StatusOK})
+		}()
+		handedOff := payment.HandOff()
+		wg.Wait()
+
+		if handedOff {
+			stored, ok := store.Get(payment.ID)
+			require.True(t, ok)
+			require.NotNil(t, stored.Result())
+		} else {
+			require.NotNil(t, payment.Result())
+			_, ok := store.Get(payment.ID)
+			require.False(t, ok)
+		}
+	}
+}
@@ -2,6 +2,66 @@ package horizon
 
 // AccountResponse contains account data returned by Horizon
 type AccountResponse struct {
-	AccountID      string `json:"id"`
-	SequenceNumber string `json:"sequence"`
+	AccountID      string    `json:"id"`
+	SequenceNumber string    `json:"sequence"`
+	Balances       []Balance `json:"balances"`
+	// HomeDomain is a domain of the stellar.toml of the account, empty when not set
+	HomeDomain string `json:"home_domain,omitempty"`
+	// SubentryCount is the number of trustlines, offers, signers and data entries of the account,
+	// each raises its minimum balance by a base reserve
+	SubentryCount int32             `json:"subentry_count"`
+	Signers       []Signer          `json:"signers,omitempty"`
+	Data          map[string]string `json:"data,omitempty"`
+}
+
+// Signer is a signer of an account, Horizon lists the master key of the account too
+type Signer struct {
+	Key string `json:"key"`
+	// PublicKey is the key of signers returned by Horizon before 0.9
+	PublicKey string `json:"public_key,omitempty"`
+	Weight    int32  `json:"weight"`
+	Type      string `json:"type"`
+}
+
+// Balance contains a single balance (trustline) of an account
+type Balance struct {
+	Balance     string `json:"balance"`
+	Limit       string `json:"limit,omitempty"`
+	AssetType   string `json:"asset_type"`
+	AssetCode   string `json:"asset_code,omitempty"`
+	AssetIssuer string `json:"asset_issuer,omitempty"`
+}
+
+// GetBalance returns the balance of a given credit asset. ok is false when
+// account does not trust the asset.
+func (account AccountResponse) GetBalance(assetCode, assetIssuer string) (balance Balance, ok bool) {
+	for _, balance = range account.Balances {
+		if balance.AssetType != "native" && balance.AssetCode == assetCode && balance.AssetIssuer == assetIssuer {
+			return balance, true
+		}
+	}
+	return Balance{}, false
+}
+
+// Trustlines returns the number of trustlines of the account
+func (account AccountResponse) Trustlines() int {
+	trustlines := 0
+	for _, balance := range account.Balances {
+		if balance.AssetType != "native" {
+			trustlines++
+		}
+	}
+	return trustlines
+}
+
+// Offers returns the number of offers of the account: subentries that are not trustlines, signers
+// other than the master key or data entries
+func (account AccountResponse) Offers() int {
+	offers := int(account.SubentryCount) - account.Trustlines() - len(account.Data)
+	for _, signer := range account.Signers {
+		if signer.Key != account.AccountID && signer.PublicKey != account.AccountID {
+			offers--
+		}
+	}
+	return offers
 }
@@ -0,0 +1,150 @@
+package horizon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/stellar/gateway/breaker"
+	"github.com/stellar/go/build"
+)
+
+// Names of breakers of Horizon endpoint classes
+const (
+	BreakerAccounts     = "horizon.accounts"
+	BreakerOperations   = "horizon.operations"
+	BreakerOrderBook    = "horizon.order_book"
+	BreakerPaths        = "horizon.paths"
+	BreakerLedgers      = "horizon.ledgers"
+	BreakerTransactions = "horizon.transactions"
+)
+
+// breakerHorizon calls Horizon through circuit breakers, a breaker per endpoint class
+type breakerHorizon struct {
+	horizon  HorizonInterface
+	breakers *breaker.Set
+}
+
+// NewBreakerHorizon returns HorizonInterface that fails fast with *breaker.OpenError when an
+// endpoint class of h is failing. Streams are not broken, the listener reconnects on its own.
+func NewBreakerHorizon(h HorizonInterface, breakers *breaker.Set) HorizonInterface {
+	return &breakerHorizon{horizon: h, breakers: breakers}
+}
+
+// isHorizonFailure returns true for errors caused by unavailable Horizon: network errors, timeouts
+// and server errors. Client errors (ex. account not found), unexpected responses, rate limiting
+// and requests cancelled by callers are not failures of availability.
+func isHorizonFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	switch err := err.(type) {
+	case *StatusError:
+		return err.StatusCode >= http.StatusInternalServerError
+	case *HorizonSchemaError, *RateLimitedError:
+		return false
+	}
+	return true
+}
+
+func (h *breakerHorizon) LoadAccount(accountID string) (response AccountResponse, err error) {
+	err = h.breakers.Get(BreakerAccounts).Do(func() error {
+		response, err = h.horizon.LoadAccount(accountID)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) LoadAccountCtx(ctx context.Context, accountID string) (response AccountResponse, err error) {
+	err = h.breakers.Get(BreakerAccounts).Do(func() error {
+		response, err = h.horizon.LoadAccountCtx(ctx, accountID)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) LoadMemo(p *PaymentResponse) (err error) {
+	return h.breakers.Get(BreakerOperations).Do(func() error {
+		return h.horizon.LoadMemo(p)
+	}, isHorizonFailure)
+}
+
+func (h *breakerHorizon) LoadOperation(operationID string) (response PaymentResponse, err error) {
+	err = h.breakers.Get(BreakerOperations).Do(func() error {
+		response, err = h.horizon.LoadOperation(operationID)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) LoadOrderBook(selling, buying build.Asset) (response OrderBookResponse, err error) {
+	err = h.breakers.Get(BreakerOrderBook).Do(func() error {
+		response, err = h.horizon.LoadOrderBook(selling, buying)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) LoadPaths(sourceAccount, destinationAccount string, destinationAsset build.Asset, destinationAmount string) (response PathsPage, err error) {
+	err = h.breakers.Get(BreakerPaths).Do(func() error {
+		response, err = h.horizon.LoadPaths(sourceAccount, destinationAccount, destinationAsset, destinationAmount)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) LoadPayments(accountID, cursor string, limit int) (response PaymentsPage, err error) {
+	err = h.breakers.Get(BreakerOperations).Do(func() error {
+		response, err = h.horizon.LoadPayments(accountID, cursor, limit)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) LoadLatestLedger() (ledger uint32, err error) {
+	err = h.breakers.Get(BreakerLedgers).Do(func() error {
+		ledger, err = h.horizon.LoadLatestLedger()
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) LoadLedger(sequence uint32) (response LedgerResponse, err error) {
+	err = h.breakers.Get(BreakerLedgers).Do(func() error {
+		response, err = h.horizon.LoadLedger(sequence)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) LoadTransaction(hash string) (response TransactionResponse, err error) {
+	err = h.breakers.Get(BreakerTransactions).Do(func() error {
+		response, err = h.horizon.LoadTransaction(hash)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) StreamPayments(accountID string, cursor *string, onPaymentHandler PaymentHandler) error {
+	return h.horizon.StreamPayments(accountID, cursor, onPaymentHandler)
+}
+
+func (h *breakerHorizon) SubmitTransaction(txeBase64 string) (response SubmitTransactionResponse, err error) {
+	err = h.breakers.Get(BreakerTransactions).Do(func() error {
+		response, err = h.horizon.SubmitTransaction(txeBase64)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) SubmitTransactionCtx(ctx context.Context, txeBase64 string) (response SubmitTransactionResponse, err error) {
+	err = h.breakers.Get(BreakerTransactions).Do(func() error {
+		response, err = h.horizon.SubmitTransactionCtx(ctx, txeBase64)
+		return err
+	}, isHorizonFailure)
+	return
+}
+
+func (h *breakerHorizon) WithCorrelationID(id string) HorizonInterface {
+	return &breakerHorizon{horizon: h.horizon.WithCorrelationID(id), breakers: h.breakers}
+}
@@ -0,0 +1,61 @@
+package horizon
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/breaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerHorizon(t *testing.T) {
+	status := http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(http.StatusText(status)))
+	}))
+	defer server.Close()
+
+	h := New(server.URL)
+	breakers := breaker.NewSet(breaker.Settings{FailureRate: 0.5, MinRequests: 2, Window: time.Minute, OpenTimeout: time.Minute}, time.Now)
+	bh := NewBreakerHorizon(&h, breakers)
+
+	// Accounts not found do not open the breaker
+	for i := 0; i < 3; i++ {
+		_, err := bh.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+		assert.IsType(t, &StatusError{}, err)
+	}
+	assert.Equal(t, breaker.StateClosed, breakers.Get(BreakerAccounts).Status().State)
+
+	status = http.StatusBadGateway
+	_, err := bh.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+	assert.IsType(t, &StatusError{}, err)
+	_, err = bh.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+	assert.IsType(t, &StatusError{}, err)
+	// 2 of 5 requests failed
+	assert.Equal(t, breaker.StateClosed, breakers.Get(BreakerAccounts).Status().State)
+	bh.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+
+	_, err = bh.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+	openErr, ok := err.(*breaker.OpenError)
+	require.True(t, ok, "expected OpenError, got %v", err)
+	assert.Equal(t, BreakerAccounts, openErr.Dependency)
+
+	// Other endpoint classes are not affected
+	_, err = bh.SubmitTransaction("AAAA")
+	assert.IsType(t, &StatusError{}, err)
+	assert.Equal(t, breaker.StateClosed, breakers.Get(BreakerTransactions).Status().State)
+}
+
+func TestIsHorizonFailure(t *testing.T) {
+	assert.True(t, isHorizonFailure(&TimeoutError{Endpoint: "accounts", Timeout: time.Second}))
+	assert.True(t, isHorizonFailure(&StatusError{StatusCode: http.StatusGatewayTimeout}))
+	// Requests of disconnected clients
+	assert.False(t, isHorizonFailure(fmt.Errorf("Get: %w", context.Canceled)))
+	assert.False(t, isHorizonFailure(&StatusError{StatusCode: http.StatusNotFound}))
+}
@@ -0,0 +1,229 @@
+package horizon
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// DefaultFailureLogSize is a number of failed exchanges kept per endpoint by default
+	DefaultFailureLogSize = 20
+	// maxCapturedBody is a maximum size of a captured response body
+	maxCapturedBody = 4096
+	redacted        = "<redacted>"
+)
+
+var seedPattern = regexp.MustCompile(`S[A-Z2-7]{55}`)
+
+// Failure is a captured failed Horizon exchange. Signatures, seeds and URL credentials are
+// redacted.
+type Failure struct {
+	ID       string           `json:"id"`
+	Endpoint string           `json:"endpoint"`
+	Time     utc.Time         `json:"time"`
+	Request  FailureRequest   `json:"request"`
+	Response *FailureResponse `json:"response,omitempty"`
+	// Error is set when there is no response or the response can't be decoded
+	Error string `json:"error,omitempty"`
+}
+
+// FailureRequest is a request of a captured Failure
+type FailureRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// EnvelopeXdr is a submitted envelope without signatures
+	EnvelopeXdr string `json:"envelope_xdr,omitempty"`
+	// CorrelationID is sent in X-Correlation-ID header
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// FailureResponse is a response of a captured Failure
+type FailureResponse struct {
+	StatusCode int    `json:"status_code"`
+	Body       string `json:"body"`
+	// Truncated is true when Body was longer than the captured size
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// FailureLog keeps the last failed exchanges of every Horizon endpoint
+type FailureLog struct {
+	mutex     sync.Mutex
+	size      int
+	endpoints map[string]*failureRing
+	now       func() time.Time
+}
+
+// failureRing is a ring buffer of failures of a single endpoint
+type failureRing struct {
+	failures []Failure
+	next     int
+}
+
+// NewFailureLog creates a new FailureLog keeping size failures per endpoint
+func NewFailureLog(size int, now func() time.Time) *FailureLog {
+	if size <= 0 {
+		size = DefaultFailureLogSize
+	}
+	return &FailureLog{size: size, endpoints: map[string]*failureRing{}, now: now}
+}
+
+// add records a failure and returns its ID. Request and response are redacted first.
+func (l *FailureLog) add(failure Failure) string {
+	failure.ID = newFailureID()
+	failure.Time = utc.New(l.now())
+	failure.Request.URL = redactURL(failure.Request.URL)
+	if failure.Request.EnvelopeXdr != "" {
+		failure.Request.EnvelopeXdr = redactEnvelope(failure.Request.EnvelopeXdr)
+	}
+	failure.Error = seedPattern.ReplaceAllString(failure.Error, redacted)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	ring, ok := l.endpoints[failure.Endpoint]
+	if !ok {
+		ring = &failureRing{}
+		l.endpoints[failure.Endpoint] = ring
+	}
+	if len(ring.failures) < l.size {
+		ring.failures = append(ring.failures, failure)
+	} else {
+		ring.failures[ring.next] = failure
+	}
+	ring.next = (ring.next + 1) % l.size
+	return failure.ID
+}
+
+// List returns failures of an endpoint, newest first. Failures of all endpoints are returned
+// when endpoint is empty.
+func (l *FailureLog) List(endpoint string) []Failure {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	failures := []Failure{}
+	for name, ring := range l.endpoints {
+		if endpoint != "" && name != endpoint {
+			continue
+		}
+		failures = append(failures, ring.failures...)
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Time.Time().After(failures[j].Time.Time()) })
+	return failures
+}
+
+// Get returns a failure by its ID
+func (l *FailureLog) Get(id string) (Failure, bool) {
+	for _, failure := range l.List("") {
+		if failure.ID == id {
+			return failure, true
+		}
+	}
+	return Failure{}, false
+}
+
+func newFailureID() string {
+	raw := make([]byte, 8)
+	_, err := rand.Read(raw)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(raw)
+}
+
+// newFailureResponse returns a redacted response with a size-bounded body
+func newFailureResponse(statusCode int, body []byte) *FailureResponse {
+	response := &FailureResponse{StatusCode: statusCode, Body: redactBody(body)}
+	if len(response.Body) > maxCapturedBody {
+		response.Body = response.Body[:maxCapturedBody]
+		response.Truncated = true
+	}
+	return response
+}
+
+// redactURL removes credentials of a Horizon URL
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redacted
+	}
+	if u.User != nil {
+		u.User = url.User("redacted")
+	}
+	return seedPattern.ReplaceAllString(u.String(), redacted)
+}
+
+// redactEnvelope removes signatures of a base64 encoded envelope, signature hints are kept
+func redactEnvelope(envelopeXdr string) string {
+	var envelope xdr.TransactionEnvelope
+	err := xdr.SafeUnmarshalBase64(envelopeXdr, &envelope)
+	if err != nil {
+		return redacted
+	}
+	for i := range envelope.Signatures {
+		envelope.Signatures[i].Signature = xdr.Signature{}
+	}
+	redactedEnvelope, err := xdr.MarshalBase64(envelope)
+	if err != nil {
+		return redacted
+	}
+	return redactedEnvelope
+}
+
+// redactBody removes signatures and seeds of a response body. Envelopes in JSON bodies
+// (`envelope_xdr` fields) are kept without signatures.
+func redactBody(body []byte) string {
+	var document interface{}
+	if json.Unmarshal(body, &document) == nil {
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		if encoder.Encode(redactJSON(document)) == nil {
+			body = bytes.TrimSpace(buffer.Bytes())
+		}
+	}
+	return seedPattern.ReplaceAllString(string(body), redacted)
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			switch envelope, isString := field.(string); {
+			case key == "envelope_xdr" && isString:
+				value[key] = redactEnvelope(envelope)
+			case key == "signatures":
+				value[key] = redacted
+			default:
+				value[key] = redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = redactJSON(value[i])
+		}
+	}
+	return value
+}
+
+// FailureID returns an ID of the captured failure that caused err, empty when err is not a
+// Horizon error
+func FailureID(err error) string {
+	switch err := err.(type) {
+	case *StatusError:
+		return err.FailureID
+	case *HorizonSchemaError:
+		return err.FailureID
+	case *RateLimitedError:
+		return err.FailureID
+	}
+	return ""
+}
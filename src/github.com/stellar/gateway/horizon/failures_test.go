@@ -0,0 +1,190 @@
+package horizon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Envelope signed by SDZT3EJZ7FZRYNTLOZ7VH6G5UYBFO2IO3Q5PGONMILPCZU3AL7QNZHTE
+const (
+	testSeed      = "SDZT3EJZ7FZRYNTLOZ7VH6G5UYBFO2IO3Q5PGONMILPCZU3AL7QNZHTE"
+	testEnvelope  = "AAAAAJbmB/pwwloZXCaCr9WR3Fue2lNhHGaDWKVOWO7MPq4QAAAAZAAk2eQAAAABAAAAAAAAAAAAAAABAAAAAAAAAAEAAAAAd2/WGgaQ6CJcXQtGRFodrubQZ9ci5ZPRlxpqNPWV1CAAAAAAAAAAADuaygAAAAAAAAAAAcw+rhAAAABAyFjIMIZOtstCWtZlVBDj1AhTmsk5v1i2GGY4by2b5mgZoXXGgFTB8sfbQav0LzFKCcxY8h+9xPMT2e9xznAfDw=="
+	testSignature = "yFjIMIZOtstCWtZlVBDj1AhTmsk5v1i2GGY4by2b5mgZoXXGgFTB8sfbQav0LzFKCcxY8h+9xPMT2e9xznAfDw=="
+)
+
+func TestFailureLogRing(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	log := NewFailureLog(2, func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	})
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		ids = append(ids, log.add(Failure{Endpoint: "accounts", Request: FailureRequest{URL: fmt.Sprintf("https://horizon/accounts/%d", i)}}))
+	}
+	submitID := log.add(Failure{Endpoint: "submit_transaction"})
+
+	accounts := log.List("accounts")
+	require.Len(t, accounts, 2)
+	assert.Equal(t, ids[2], accounts[0].ID)
+	assert.Equal(t, ids[1], accounts[1].ID)
+	assert.Equal(t, "https://horizon/accounts/2", accounts[0].Request.URL)
+
+	all := log.List("")
+	require.Len(t, all, 3)
+	assert.Equal(t, submitID, all[0].ID)
+
+	_, ok := log.Get(ids[0])
+	assert.False(t, ok, "overwritten failure")
+	failure, ok := log.Get(ids[1])
+	assert.True(t, ok)
+	assert.Equal(t, "accounts", failure.Endpoint)
+	assert.Empty(t, log.List("payments"))
+}
+
+func TestFailureCaptureRedaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/transactions":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{
+  "status": 400,
+  "detail": "Seed %s leaked by a proxy",
+  "extras": {
+    "envelope_xdr": "%s",
+    "result_xdr": "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB////+gAAAAA="
+  }
+}`, testSeed, r.PostFormValue("tx"))
+		case "/transactions/ad71fc31":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, `{"envelope_xdr": "%s", "signatures": ["%s"], "padding": "%s"}`, testEnvelope, testSignature, strings.Repeat("x", maxCapturedBody))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	h := New(strings.Replace(server.URL, "http://", "http://user:password@", 1))
+
+	response, err := h.SubmitTransaction(testEnvelope)
+	require.NoError(t, err)
+	require.NotEmpty(t, response.FailureID)
+
+	_, transactionErr := h.LoadTransaction("ad71fc31")
+	require.Error(t, transactionErr)
+	require.NotEmpty(t, FailureID(transactionErr))
+
+	// Not found transactions are not failures
+	_, err = h.LoadTransaction("unknown")
+	require.Error(t, err)
+	assert.Empty(t, FailureID(err))
+
+	failures := h.Failures.List("")
+	require.Len(t, failures, 2)
+
+	submission, ok := h.Failures.Get(response.FailureID)
+	require.True(t, ok)
+	assert.Equal(t, "submit_transaction", submission.Endpoint)
+	assert.Equal(t, "POST", submission.Request.Method)
+	assert.Equal(t, http.StatusBadRequest, submission.Response.StatusCode)
+	assert.Contains(t, submission.Response.Body, "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB////+gAAAAA=")
+
+	// Envelopes are kept without signatures
+	for _, envelopeXdr := range []string{submission.Request.EnvelopeXdr, redactedEnvelopeOf(t, submission.Response.Body)} {
+		var envelope xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(envelopeXdr, &envelope))
+		require.Len(t, envelope.Signatures, 1)
+		assert.Empty(t, envelope.Signatures[0].Signature)
+		assert.Equal(t, uint64(10372672437354497), uint64(envelope.Tx.SeqNum))
+	}
+
+	transaction, ok := h.Failures.Get(FailureID(transactionErr))
+	require.True(t, ok)
+	assert.Equal(t, "transactions", transaction.Endpoint)
+	assert.True(t, transaction.Response.Truncated)
+	assert.Len(t, transaction.Response.Body, maxCapturedBody)
+
+	// Nothing secret is returned by /admin/debug/horizon_failures
+	encoded, err := json.Marshal(failures)
+	require.NoError(t, err)
+	for _, secret := range []string{testSeed, testSignature, "password"} {
+		assert.NotContains(t, string(encoded), secret)
+	}
+	assert.Contains(t, submission.Request.URL, "//redacted@")
+}
+
+func TestFailureCaptureErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/accounts/GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB":
+			w.Write([]byte(`{"id": "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"}`))
+		default:
+			w.WriteHeader(http.StatusGatewayTimeout)
+		}
+	}))
+	h := New(server.URL)
+
+	_, err := h.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+	require.IsType(t, &HorizonSchemaError{}, err)
+	failure, ok := h.Failures.Get(FailureID(err))
+	require.True(t, ok)
+	assert.Equal(t, "accounts", failure.Endpoint)
+	assert.Equal(t, http.StatusOK, failure.Response.StatusCode)
+	assert.Equal(t, err.Error(), failure.Error)
+
+	_, err = h.SubmitTransaction(testEnvelope)
+	require.IsType(t, &StatusError{}, err)
+	failure, ok = h.Failures.Get(FailureID(err))
+	require.True(t, ok)
+	assert.Equal(t, http.StatusGatewayTimeout, failure.Response.StatusCode)
+	assert.Empty(t, failure.Error)
+
+	// Responses are lost
+	server.Close()
+	_, err = h.LoadLatestLedger()
+	require.Error(t, err)
+	failures := h.Failures.List("ledgers")
+	require.Len(t, failures, 1)
+	assert.Nil(t, failures[0].Response)
+	assert.NotEmpty(t, failures[0].Error)
+}
+
+func redactedEnvelopeOf(t *testing.T, body string) string {
+	var response SubmitTransactionResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &response))
+	require.NotNil(t, response.Extras)
+	return response.Extras.EnvelopeXdr
+}
+
+func TestFailureCaptureCorrelationID(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(CorrelationIDHeader))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	h := New(server.URL)
+
+	_, err := h.WithCorrelationID("order-42").LoadLatestLedger()
+	require.Error(t, err)
+	_, err = h.SubmitTransaction(testEnvelope)
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"order-42", ""}, received)
+	failures := h.Failures.List("ledgers")
+	require.Len(t, failures, 1)
+	assert.Equal(t, "order-42", failures[0].Request.CorrelationID)
+	failures = h.Failures.List("submit_transaction")
+	require.Len(t, failures, 1)
+	assert.Empty(t, failures[0].Request.CorrelationID)
+}
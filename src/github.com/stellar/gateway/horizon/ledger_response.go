@@ -0,0 +1,9 @@
+package horizon
+
+import "github.com/stellar/gateway/utc"
+
+// LedgerResponse contains a closed ledger as returned by Horizon
+type LedgerResponse struct {
+	Sequence uint32   `json:"sequence"`
+	ClosedAt utc.Time `json:"closed_at"`
+}
@@ -0,0 +1,90 @@
+package horizon
+
+import (
+	"context"
+
+	"github.com/stellar/gateway/ratelimit"
+	"github.com/stellar/go/build"
+)
+
+// limitedHorizon submits transactions when a rate limiter allows it, other requests are passed
+// through
+type limitedHorizon struct {
+	horizon  HorizonInterface
+	limiter  *ratelimit.Limiter
+	priority ratelimit.Priority
+}
+
+// NewLimitedHorizon returns HorizonInterface waiting for limiter before every submission, with
+// ratelimit.High priority. Components sharing limiter share its budget of submissions.
+func NewLimitedHorizon(h HorizonInterface, limiter *ratelimit.Limiter) HorizonInterface {
+	return &limitedHorizon{horizon: h, limiter: limiter, priority: ratelimit.High}
+}
+
+// WithSubmissionPriority returns a copy of h submitting with priority, h is returned when it's not
+// created by NewLimitedHorizon
+func WithSubmissionPriority(h HorizonInterface, priority ratelimit.Priority) HorizonInterface {
+	limited, ok := h.(*limitedHorizon)
+	if !ok {
+		return h
+	}
+	return &limitedHorizon{horizon: limited.horizon, limiter: limited.limiter, priority: priority}
+}
+
+func (h *limitedHorizon) LoadAccount(accountID string) (AccountResponse, error) {
+	return h.horizon.LoadAccount(accountID)
+}
+
+func (h *limitedHorizon) LoadAccountCtx(ctx context.Context, accountID string) (AccountResponse, error) {
+	return h.horizon.LoadAccountCtx(ctx, accountID)
+}
+
+func (h *limitedHorizon) LoadMemo(p *PaymentResponse) error {
+	return h.horizon.LoadMemo(p)
+}
+
+func (h *limitedHorizon) LoadOperation(operationID string) (PaymentResponse, error) {
+	return h.horizon.LoadOperation(operationID)
+}
+
+func (h *limitedHorizon) LoadOrderBook(selling, buying build.Asset) (OrderBookResponse, error) {
+	return h.horizon.LoadOrderBook(selling, buying)
+}
+
+func (h *limitedHorizon) LoadPaths(sourceAccount, destinationAccount string, destinationAsset build.Asset, destinationAmount string) (PathsPage, error) {
+	return h.horizon.LoadPaths(sourceAccount, destinationAccount, destinationAsset, destinationAmount)
+}
+
+func (h *limitedHorizon) LoadPayments(accountID, cursor string, limit int) (PaymentsPage, error) {
+	return h.horizon.LoadPayments(accountID, cursor, limit)
+}
+
+func (h *limitedHorizon) LoadLatestLedger() (uint32, error) {
+	return h.horizon.LoadLatestLedger()
+}
+
+func (h *limitedHorizon) LoadLedger(sequence uint32) (LedgerResponse, error) {
+	return h.horizon.LoadLedger(sequence)
+}
+
+func (h *limitedHorizon) LoadTransaction(hash string) (TransactionResponse, error) {
+	return h.horizon.LoadTransaction(hash)
+}
+
+func (h *limitedHorizon) StreamPayments(accountID string, cursor *string, onPaymentHandler PaymentHandler) error {
+	return h.horizon.StreamPayments(accountID, cursor, onPaymentHandler)
+}
+
+func (h *limitedHorizon) SubmitTransaction(txeBase64 string) (SubmitTransactionResponse, error) {
+	h.limiter.Wait(h.priority)
+	return h.horizon.SubmitTransaction(txeBase64)
+}
+
+func (h *limitedHorizon) SubmitTransactionCtx(ctx context.Context, txeBase64 string) (SubmitTransactionResponse, error) {
+	h.limiter.Wait(h.priority)
+	return h.horizon.SubmitTransactionCtx(ctx, txeBase64)
+}
+
+func (h *limitedHorizon) WithCorrelationID(id string) HorizonInterface {
+	return &limitedHorizon{horizon: h.horizon.WithCorrelationID(id), limiter: h.limiter, priority: h.priority}
+}
@@ -0,0 +1,50 @@
+package horizon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stellar/gateway/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitedHorizon(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/transactions") {
+			w.Write([]byte(`{"hash": "6a0049b44e0d0341bd52f131c74383e6ccd2b74b92c829c990994d24bbfcfa7a", "ledger": 1988727}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	h := New(server.URL)
+	limiter := ratelimit.NewLimiter(ratelimit.Settings{Rate: 50})
+	lh := NewLimitedHorizon(&h, limiter)
+
+	// Only submissions take tokens
+	for i := 0; i < 3; i++ {
+		lh.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+	}
+	assert.Equal(t, int64(0), limiter.Status().Priorities["high"].Submitted)
+
+	_, err := lh.SubmitTransaction("AAAA")
+	assert.NoError(t, err)
+	_, err = ratelimitedCopy(lh).SubmitTransaction("AAAA")
+	assert.NoError(t, err)
+
+	status := limiter.Status()
+	assert.Equal(t, int64(1), status.Priorities["high"].Submitted)
+	assert.Equal(t, int64(1), status.Priorities["low"].Submitted)
+	assert.Equal(t, int64(1), status.Priorities["low"].Delayed)
+
+	// Other clients are not changed
+	assert.Equal(t, &h, WithSubmissionPriority(&h, ratelimit.Low))
+}
+
+// ratelimitedCopy returns a copy of h with a correlation ID submitting with low priority
+func ratelimitedCopy(h HorizonInterface) HorizonInterface {
+	return WithSubmissionPriority(h.WithCorrelationID("1b3a6e1c"), ratelimit.Low)
+}
@@ -2,61 +2,133 @@ package horizon
 
 import (
 	"bufio"
+	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/go/build"
 	"github.com/stellar/go/xdr"
 )
 
-// PaymentHandler is a function that is called when a new payment is received
+// PaymentHandler is a function that is called when a new payment is received. Returning
+// ErrStopStreaming stops the stream, other errors make StreamPayments retry the payment.
 type PaymentHandler func(PaymentResponse) error
 
+// ErrStopStreaming is returned by a PaymentHandler to stop StreamPayments, StreamPayments
+// returns it without retrying the payment
+var ErrStopStreaming = errors.New("Streaming stopped by payment handler")
+
+// DefaultHandlerRetry retries payments every 10 seconds until they are handled, it's used when
+// `retry.callbacks` is not configured
+var DefaultHandlerRetry = retry.Settings{BaseBackoff: 10 * time.Second, MaxBackoff: 10 * time.Second}
+
 // HorizonInterface allows mocking Horizon struct object
 type HorizonInterface interface {
 	LoadAccount(accountID string) (response AccountResponse, err error)
+	// LoadAccountCtx is LoadAccount cancelled with ctx
+	LoadAccountCtx(ctx context.Context, accountID string) (response AccountResponse, err error)
 	LoadMemo(p *PaymentResponse) (err error)
 	LoadOperation(operationID string) (response PaymentResponse, err error)
+	LoadOrderBook(selling, buying build.Asset) (response OrderBookResponse, err error)
+	LoadPaths(sourceAccount, destinationAccount string, destinationAsset build.Asset, destinationAmount string) (response PathsPage, err error)
+	LoadPayments(accountID, cursor string, limit int) (response PaymentsPage, err error)
+	LoadLatestLedger() (ledger uint32, err error)
+	LoadLedger(sequence uint32) (response LedgerResponse, err error)
+	LoadTransaction(hash string) (response TransactionResponse, err error)
 	StreamPayments(accountID string, cursor *string, onPaymentHandler PaymentHandler) (err error)
 	SubmitTransaction(txeBase64 string) (response SubmitTransactionResponse, err error)
+	// SubmitTransactionCtx is SubmitTransaction with values of ctx, a sent envelope can be
+	// applied so cancellation of ctx does not abort it
+	SubmitTransactionCtx(ctx context.Context, txeBase64 string) (response SubmitTransactionResponse, err error)
+	// WithCorrelationID returns a client sending X-Correlation-ID header with requests
+	WithCorrelationID(id string) HorizonInterface
 }
 
+// CorrelationIDHeader is a header forwarding an ID tracing a request across services
+const CorrelationIDHeader = "X-Correlation-ID"
+
 // Horizon implements methods to get (or submit) data from Horizon server
 type Horizon struct {
 	ServerURL string
-	log       *logrus.Entry
+	// Failures captures failed exchanges, nothing is captured when nil
+	Failures *FailureLog
+	// HandlerRetry retries payments returning an error from a PaymentHandler, StreamPayments
+	// returns the error when attempts are exhausted
+	HandlerRetry *retry.Policy
+	// RateLimits counts 429 responses, nothing is counted when nil
+	RateLimits *RateLimits
+	// Timeout limits every account load and submission, DefaultTimeout is used when 0
+	Timeout       time.Duration
+	correlationID string
+	log           *logrus.Entry
+}
+
+// DefaultTimeout is Timeout of account loads and submissions when it's not configured
+const DefaultTimeout = 30 * time.Second
+
+// TimeoutError is returned when Horizon does not respond to a request before Timeout. The
+// outcome of a timed out submission is unknown, the transaction can still be applied.
+type TimeoutError struct {
+	Endpoint string
+	Timeout  time.Duration
 }
 
-const submitTimeout = 30 * time.Second
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("Horizon %s request timed out after %s", e.Endpoint, e.Timeout)
+}
+
+// StatusError is returned when Horizon responds with an unexpected status code
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+	// FailureID is an ID of the captured exchange in Horizon.Failures
+	FailureID string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("StatusCode indicates error: %s", e.Body)
+}
 
 // New creates a new Horizon instance
 func New(serverURL string) (horizon Horizon) {
 	horizon.ServerURL = serverURL
+	horizon.Failures = NewFailureLog(DefaultFailureLogSize, time.Now)
+	horizon.RateLimits = NewRateLimits(time.Now)
+	horizon.HandlerRetry = retry.NewPolicy(retry.Callbacks, DefaultHandlerRetry, time.Sleep)
 	horizon.log = logrus.WithFields(logrus.Fields{
-		"service": "Horizon",
+		"service":             "Horizon",
+		logging.CategoryField: logging.CategoryHorizon,
 	})
 	return
 }
 
 // LoadAccount loads a single account from Horizon server
 func (h *Horizon) LoadAccount(accountID string) (response AccountResponse, err error) {
+	return h.LoadAccountCtx(context.Background(), accountID)
+}
+
+// LoadAccountCtx loads a single account from Horizon server, the request is cancelled with ctx
+// and fails with *TimeoutError after Timeout
+func (h *Horizon) LoadAccountCtx(ctx context.Context, accountID string) (response AccountResponse, err error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout())
+	defer cancel()
+
 	h.log.WithFields(logrus.Fields{
 		"accountID": accountID,
 	}).Info("Loading account")
-	resp, err := http.Get(h.ServerURL + "/accounts/" + accountID)
-	if err != nil {
-		return
-	}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	request := FailureRequest{Method: "GET", URL: h.ServerURL + "/accounts/" + accountID}
+	resp, body, err := h.get(ctx, "accounts", request)
 	if err != nil {
 		return
 	}
@@ -65,11 +137,11 @@ func (h *Horizon) LoadAccount(accountID string) (response AccountResponse, err e
 		h.log.WithFields(logrus.Fields{
 			"accountID": accountID,
 		}).Error("Account does not exist")
-		err = fmt.Errorf("StatusCode indicates error: %s", body)
+		err = h.statusError("accounts", request, resp.StatusCode, body)
 		return
 	}
 
-	err = json.Unmarshal(body, &response)
+	err = h.decodeCaptured("accounts", request, "account", body, &response)
 	if err != nil {
 		return
 	}
@@ -85,13 +157,8 @@ func (h *Horizon) LoadOperation(operationID string) (response PaymentResponse, e
 	h.log.WithFields(logrus.Fields{
 		"operationID": operationID,
 	}).Info("Loading operation")
-	resp, err := http.Get(h.ServerURL + "/operations/" + operationID)
-	if err != nil {
-		return
-	}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
+	request := FailureRequest{Method: "GET", URL: h.ServerURL + "/operations/" + operationID}
+	resp, body, err := h.get(context.Background(), "operations", request)
 	if err != nil {
 		return
 	}
@@ -100,11 +167,11 @@ func (h *Horizon) LoadOperation(operationID string) (response PaymentResponse, e
 		h.log.WithFields(logrus.Fields{
 			"operationID": operationID,
 		}).Error("Operation does not exist")
-		err = fmt.Errorf("StatusCode indicates error: %s", body)
+		err = h.statusError("operations", request, resp.StatusCode, body)
 		return
 	}
 
-	err = json.Unmarshal(body, &response)
+	err = h.decodeCaptured("operations", request, "operation", body, &response)
 	if err != nil {
 		return
 	}
@@ -115,14 +182,189 @@ func (h *Horizon) LoadOperation(operationID string) (response PaymentResponse, e
 	return
 }
 
+// LoadOrderBook loads order book summary for a given pair of assets from Horizon server
+func (h *Horizon) LoadOrderBook(selling, buying build.Asset) (response OrderBookResponse, err error) {
+	query := url.Values{}
+	addAssetToQuery(query, "selling_", selling)
+	addAssetToQuery(query, "buying_", buying)
+
+	request := FailureRequest{Method: "GET", URL: h.ServerURL + "/order_book?" + query.Encode()}
+	resp, body, err := h.get(context.Background(), "order_book", request)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode != 200 {
+		h.log.WithFields(logrus.Fields{
+			"query": query.Encode(),
+		}).Error("Cannot load order book")
+		err = h.statusError("order_book", request, resp.StatusCode, body)
+		return
+	}
+
+	err = h.decodeCaptured("order_book", request, "order book", body, &response)
+	return
+}
+
+// LoadPaths loads paths of path payments from assets of a source account delivering an amount
+// of an asset to a destination account
+func (h *Horizon) LoadPaths(sourceAccount, destinationAccount string, destinationAsset build.Asset, destinationAmount string) (response PathsPage, err error) {
+	query := url.Values{}
+	query.Set("source_account", sourceAccount)
+	query.Set("destination_account", destinationAccount)
+	query.Set("destination_amount", destinationAmount)
+	addAssetToQuery(query, "destination_", destinationAsset)
+
+	request := FailureRequest{Method: "GET", URL: h.ServerURL + "/paths?" + query.Encode()}
+	resp, body, err := h.get(context.Background(), "paths", request)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode != 200 {
+		h.log.WithFields(logrus.Fields{
+			"query": query.Encode(),
+		}).Error("Cannot load paths")
+		err = h.statusError("paths", request, resp.StatusCode, body)
+		return
+	}
+
+	err = h.decodeCaptured("paths", request, "paths", body, &response)
+	return
+}
+
+// LoadPayments loads a page of payments of a given account (in ascending order) after a cursor
+func (h *Horizon) LoadPayments(accountID, cursor string, limit int) (response PaymentsPage, err error) {
+	query := url.Values{}
+	query.Set("order", "asc")
+	query.Set("limit", strconv.Itoa(limit))
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	request := FailureRequest{Method: "GET", URL: h.ServerURL + "/accounts/" + accountID + "/payments?" + query.Encode()}
+	resp, body, err := h.get(context.Background(), "payments", request)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode != 200 {
+		h.log.WithFields(logrus.Fields{
+			"accountID": accountID,
+			"cursor":    cursor,
+		}).Error("Cannot load payments")
+		err = h.statusError("payments", request, resp.StatusCode, body)
+		return
+	}
+
+	err = h.decodeCaptured("payments", request, "payments", body, &response)
+	if err != nil {
+		return
+	}
+
+	response.RateLimit = rateLimitFromHeaders(resp.Header)
+	return
+}
+
+// LoadLatestLedger loads sequence of the latest ledger ingested by Horizon server
+func (h *Horizon) LoadLatestLedger() (ledger uint32, err error) {
+	request := FailureRequest{Method: "GET", URL: h.ServerURL + "/"}
+	resp, body, err := h.get(context.Background(), "ledgers", request)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode != 200 {
+		err = h.statusError("ledgers", request, resp.StatusCode, body)
+		return
+	}
+
+	var root rootResponse
+	err = h.decodeCaptured("ledgers", request, "root", body, &root)
+	ledger = root.HistoryLatestLedger
+	return
+}
+
+// LoadLedger loads a closed ledger by its sequence. It returns *StatusError with
+// http.StatusNotFound when the ledger is not in Horizon history.
+func (h *Horizon) LoadLedger(sequence uint32) (response LedgerResponse, err error) {
+	request := FailureRequest{Method: "GET", URL: h.ServerURL + "/ledgers/" + strconv.FormatUint(uint64(sequence), 10)}
+	resp, body, err := h.get(context.Background(), "ledgers", request)
+	if err != nil {
+		return
+	}
+
+	// Ledgers older than Horizon history are expected by lookups of a ledger closed at a time
+	if resp.StatusCode == http.StatusNotFound {
+		err = &StatusError{StatusCode: resp.StatusCode, Body: body}
+		return
+	}
+
+	if resp.StatusCode != 200 {
+		err = h.statusError("ledgers", request, resp.StatusCode, body)
+		return
+	}
+
+	err = h.decodeCaptured("ledgers", request, "ledger", body, &response)
+	return
+}
+
+// LoadTransaction loads a transaction applied to a ledger by its hash. It returns *StatusError
+// with http.StatusNotFound when the transaction is not in a ledger.
+func (h *Horizon) LoadTransaction(hash string) (response TransactionResponse, err error) {
+	request := FailureRequest{Method: "GET", URL: h.ServerURL + "/transactions/" + hash}
+	resp, body, err := h.get(context.Background(), "transactions", request)
+	if err != nil {
+		return
+	}
+
+	// Not found is an expected result, not a failure
+	if resp.StatusCode == http.StatusNotFound {
+		err = &StatusError{StatusCode: resp.StatusCode, Body: body}
+		return
+	}
+
+	if resp.StatusCode != 200 {
+		err = h.statusError("transactions", request, resp.StatusCode, body)
+		return
+	}
+
+	err = h.decodeCaptured("transactions", request, "transaction", body, &response)
+	return
+}
+
+func addAssetToQuery(query url.Values, prefix string, asset build.Asset) {
+	if asset.Native {
+		query.Set(prefix+"asset_type", "native")
+		return
+	}
+
+	if len(asset.Code) <= 4 {
+		query.Set(prefix+"asset_type", "credit_alphanum4")
+	} else {
+		query.Set(prefix+"asset_type", "credit_alphanum12")
+	}
+	query.Set(prefix+"asset_code", asset.Code)
+	query.Set(prefix+"asset_issuer", asset.Issuer)
+}
+
 // LoadMemo loads memo for a transaction in PaymentResponse
 func (h *Horizon) LoadMemo(p *PaymentResponse) (err error) {
-	res, err := http.Get(p.Links.Transaction.Href)
+	request := FailureRequest{Method: "GET", URL: p.Links.Transaction.Href}
+	_, body, err := h.get(context.Background(), "transactions", request)
+	if err != nil {
+		return err
+	}
+
+	var memo transactionMemo
+	err = h.decodeCaptured("transactions", request, "transaction", body, &memo)
 	if err != nil {
 		return err
 	}
-	defer res.Body.Close()
-	return json.NewDecoder(res.Body).Decode(&p.Memo)
+
+	p.Memo.Type = memo.Type
+	p.Memo.Value = memo.Value
+	return nil
 }
 
 // StreamPayments streams incoming payments
@@ -144,6 +386,12 @@ func (h *Horizon) StreamPayments(accountID string, cursor *string, onPaymentHand
 		return err
 	}
 	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return h.rateLimited("payments", FailureRequest{Method: "GET", URL: url}, resp, body)
+	}
+
 	scanner := bufio.NewScanner(resp.Body)
 	scanner.Split(splitSSE)
 
@@ -163,20 +411,20 @@ func (h *Horizon) StreamPayments(accountID string, cursor *string, onPaymentHand
 
 		var payment PaymentResponse
 		data := ev.Data.(string)
-		err = json.Unmarshal([]byte(data), &payment)
+		err = h.decode("payment", []byte(data), &payment)
 		if err != nil {
 			return err
 		}
 
-		for {
-			err = onPaymentHandler(payment)
-			if err != nil {
-				h.log.Error("Error from onPaymentHandler: ", err)
-				h.log.Info("Sleeping...")
-				time.Sleep(10 * time.Second)
-			} else {
-				break
+		err = h.HandlerRetry.Do(func(attempt int) error {
+			handlerErr := onPaymentHandler(payment)
+			if handlerErr != nil && handlerErr != ErrStopStreaming {
+				h.log.Error("Error from onPaymentHandler: ", handlerErr)
 			}
+			return handlerErr
+		}, isHandlerRetryable)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -192,26 +440,83 @@ func (h *Horizon) StreamPayments(accountID string, cursor *string, onPaymentHand
 	return nil
 }
 
+// isHandlerRetryable returns false when a PaymentHandler stops the stream
+func isHandlerRetryable(err error) bool {
+	return err != ErrStopStreaming
+}
+
+// WithCorrelationID returns a copy of h sending X-Correlation-ID header with requests
+func (h *Horizon) WithCorrelationID(id string) HorizonInterface {
+	horizon := *h
+	horizon.correlationID = id
+	if id != "" {
+		horizon.log = h.log.WithField("correlation_id", id)
+	}
+	return &horizon
+}
+
+// newRequest returns a request with X-Correlation-ID header when h has a correlation ID
+func (h *Horizon) newRequest(ctx context.Context, request FailureRequest, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, request.Method, request.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	if h.correlationID != "" {
+		req.Header.Set(CorrelationIDHeader, h.correlationID)
+	}
+	return req, nil
+}
+
 // SubmitTransaction submits a transaction to Stellar network via Horizon server
 func (h *Horizon) SubmitTransaction(txeBase64 string) (response SubmitTransactionResponse, err error) {
+	return h.SubmitTransactionCtx(context.Background(), txeBase64)
+}
+
+// SubmitTransactionCtx submits a transaction to Stellar network via Horizon server. It fails
+// with *TimeoutError after Timeout, cancellation of ctx (ex. a disconnected client) does not
+// abort the submission: the envelope may have been received by Horizon already.
+func (h *Horizon) SubmitTransactionCtx(ctx context.Context, txeBase64 string) (response SubmitTransactionResponse, err error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.timeout())
+	defer cancel()
+
 	v := url.Values{}
 	v.Set("tx", txeBase64)
 
-	client := http.Client{
-		Timeout: submitTimeout,
+	request := FailureRequest{Method: "POST", URL: h.ServerURL + "/transactions", EnvelopeXdr: txeBase64}
+	req, err := h.newRequest(ctx, request, strings.NewReader(v.Encode()))
+	if err != nil {
+		return
 	}
-	resp, err := client.PostForm(h.ServerURL+"/transactions", v)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
+		err = h.timeoutError(ctx, "submit_transaction", err)
+		h.captureFailure("submit_transaction", request, 0, nil, err)
 		return
 	}
 
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		err = h.timeoutError(ctx, "submit_transaction", err)
+		h.captureFailure("submit_transaction", request, resp.StatusCode, nil, err)
 		return
 	}
 
-	err = json.Unmarshal(body, &response)
+	// Rate limited envelopes are rejected before they are processed
+	if resp.StatusCode == http.StatusTooManyRequests {
+		err = h.rateLimited("submit_transaction", request, resp, body)
+		return
+	}
+
+	// Server errors (ex. 504 timeout) do not contain a transaction result
+	if resp.StatusCode >= http.StatusInternalServerError {
+		err = h.statusError("submit_transaction", request, resp.StatusCode, body)
+		return
+	}
+
+	err = h.decodeCaptured("submit_transaction", request, "transaction submission", body, &response)
 	if err != nil {
 		h.log.WithFields(logrus.Fields{
 			"body": string(body),
@@ -219,6 +524,18 @@ func (h *Horizon) SubmitTransaction(txeBase64 string) (response SubmitTransactio
 		return
 	}
 
+	// Success response without ledger would be treated as a failed transaction
+	if resp.StatusCode == http.StatusOK && response.Ledger == nil {
+		err = &HorizonSchemaError{Resource: "transaction submission", Field: "ledger", Err: errMissing}
+		h.log.WithField("body", string(body)).Debug(err.Error())
+		h.captureFailure("submit_transaction", request, resp.StatusCode, body, err)
+		return
+	}
+
+	if response.Ledger == nil {
+		response.FailureID = h.captureFailure("submit_transaction", request, resp.StatusCode, body, nil)
+	}
+
 	if response.Ledger != nil {
 		h.log.WithFields(logrus.Fields{
 			"ledger": response.Ledger,
@@ -233,6 +550,93 @@ func (h *Horizon) SubmitTransaction(txeBase64 string) (response SubmitTransactio
 	return
 }
 
+// get sends a GET request and reads the response, transport errors are captured
+func (h *Horizon) get(ctx context.Context, endpoint string, request FailureRequest) (resp *http.Response, body []byte, err error) {
+	req, err := h.newRequest(ctx, request, nil)
+	if err != nil {
+		return
+	}
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		err = h.timeoutError(ctx, endpoint, err)
+		h.captureFailure(endpoint, request, 0, nil, err)
+		return
+	}
+
+	defer resp.Body.Close()
+	body, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		err = h.timeoutError(ctx, endpoint, err)
+		h.captureFailure(endpoint, request, resp.StatusCode, nil, err)
+		return
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		err = h.rateLimited(endpoint, request, resp, body)
+	}
+	return
+}
+
+// timeout returns Timeout or DefaultTimeout when it's not set
+func (h *Horizon) timeout() time.Duration {
+	if h.Timeout == 0 {
+		return DefaultTimeout
+	}
+	return h.Timeout
+}
+
+// timeoutError returns *TimeoutError when err was caused by the deadline of ctx, err otherwise
+func (h *Horizon) timeoutError(ctx context.Context, endpoint string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{Endpoint: endpoint, Timeout: h.timeout()}
+	}
+	return err
+}
+
+// statusError returns a captured *StatusError
+func (h *Horizon) statusError(endpoint string, request FailureRequest, statusCode int, body []byte) error {
+	err := &StatusError{StatusCode: statusCode, Body: body}
+	h.captureFailure(endpoint, request, statusCode, body, err)
+	return err
+}
+
+// decodeCaptured is decode capturing responses that cannot be decoded
+func (h *Horizon) decodeCaptured(endpoint string, request FailureRequest, resource string, body []byte, response schemaResponse) error {
+	err := h.decode(resource, body, response)
+	if err != nil {
+		h.captureFailure(endpoint, request, http.StatusOK, body, err)
+	}
+	return err
+}
+
+// captureFailure records a failed exchange in h.Failures and returns its ID. The ID is attached
+// to *StatusError and *HorizonSchemaError errors.
+func (h *Horizon) captureFailure(endpoint string, request FailureRequest, statusCode int, body []byte, err error) string {
+	if h.Failures == nil {
+		return ""
+	}
+
+	request.CorrelationID = h.correlationID
+	failure := Failure{Endpoint: endpoint, Request: request}
+	if statusCode != 0 {
+		failure.Response = newFailureResponse(statusCode, body)
+	}
+	// Bodies of status errors are already in the response
+	if _, isStatusError := err.(*StatusError); err != nil && !isStatusError {
+		failure.Error = err.Error()
+	}
+
+	id := h.Failures.add(failure)
+	switch err := err.(type) {
+	case *StatusError:
+		err.FailureID = id
+	case *HorizonSchemaError:
+		err.FailureID = id
+	}
+	return id
+}
+
 func unmarshalTransactionResult(transactionResult string) (txResult xdr.TransactionResult, err error) {
 	reader := strings.NewReader(transactionResult)
 	b64r := base64.NewDecoder(base64.StdEncoding, reader)
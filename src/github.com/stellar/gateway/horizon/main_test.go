@@ -0,0 +1,155 @@
+package horizon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamPaymentsStop(t *testing.T) {
+	operation, err := ioutil.ReadFile("testdata/horizon-2.0.0/operation.json")
+	require.NoError(t, err)
+	var data bytes.Buffer
+	require.NoError(t, json.Compact(&data, operation))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 2; i++ {
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data.String())
+		}
+		// Keep the stream open like Horizon does
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	h := New(server.URL)
+	calls := 0
+	err = h.StreamPayments("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB", nil, func(payment PaymentResponse) error {
+		calls++
+		return ErrStopStreaming
+	})
+	assert.Equal(t, ErrStopStreaming, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStreamPaymentsRetry(t *testing.T) {
+	operation, err := ioutil.ReadFile("testdata/horizon-2.0.0/operation.json")
+	require.NoError(t, err)
+	var data bytes.Buffer
+	require.NoError(t, json.Compact(&data, operation))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", data.String())
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	// Handled payments stop the stream
+	handlerErr := errors.New("receive callback failed")
+	failing := func(failures int, calls *int) PaymentHandler {
+		return func(payment PaymentResponse) error {
+			*calls++
+			if *calls <= failures {
+				return handlerErr
+			}
+			return ErrStopStreaming
+		}
+	}
+
+	// Payments are retried every 10 seconds until they are handled
+	h := New(server.URL)
+	var waits []time.Duration
+	h.HandlerRetry = retry.NewPolicy(retry.Callbacks, DefaultHandlerRetry, func(d time.Duration) { waits = append(waits, d) })
+	calls := 0
+	err = h.StreamPayments("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB", nil, failing(3, &calls))
+	assert.Equal(t, ErrStopStreaming, err)
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, []time.Duration{10 * time.Second, 10 * time.Second, 10 * time.Second}, waits)
+
+	// The error is returned when attempts are exhausted
+	settings := DefaultHandlerRetry
+	settings.MaxAttempts = 2
+	h.HandlerRetry = retry.NewPolicy(retry.Callbacks, settings, func(time.Duration) {})
+	calls = 0
+	err = h.StreamPayments("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB", nil, failing(3, &calls))
+	assert.Equal(t, handlerErr, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, int64(1), h.HandlerRetry.Stats().Exhausted)
+}
+
+func TestHorizonContext(t *testing.T) {
+	submitSuccess, err := ioutil.ReadFile("testdata/horizon-2.0.0/submit_success.json")
+	require.NoError(t, err)
+
+	submissionCancelled := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Horizon responds to submissions once the transaction is in a ledger, "fast" envelopes
+		// are in the next one
+		delay := time.Second
+		if r.PostFormValue("tx") == "fast" {
+			delay = 100 * time.Millisecond
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			if r.URL.Path == "/transactions" {
+				submissionCancelled <- true
+			}
+			return
+		}
+		if r.URL.Path == "/transactions" {
+			w.Write(submitSuccess)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	h := New(server.URL)
+	h.Timeout = 50 * time.Millisecond
+
+	t.Run("account load times out", func(t *testing.T) {
+		_, err := h.LoadAccountCtx(context.Background(), "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+		assert.Equal(t, &TimeoutError{Endpoint: "accounts", Timeout: 50 * time.Millisecond}, err)
+		failures := h.Failures.List("accounts")
+		require.NotEmpty(t, failures)
+		assert.Equal(t, err.Error(), failures[0].Error)
+	})
+
+	t.Run("account load is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := h.LoadAccountCtx(ctx, "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+		assert.True(t, errors.Is(err, context.Canceled), "expected cancelled request, got %v", err)
+	})
+
+	t.Run("submission times out", func(t *testing.T) {
+		_, err := h.SubmitTransactionCtx(context.Background(), "AAAA")
+		assert.Equal(t, &TimeoutError{Endpoint: "submit_transaction", Timeout: 50 * time.Millisecond}, err)
+		assert.True(t, <-submissionCancelled)
+	})
+
+	t.Run("submission is not cancelled", func(t *testing.T) {
+		h.Timeout = time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		response, err := h.SubmitTransactionCtx(ctx, "fast")
+		require.NoError(t, err)
+		assert.NotNil(t, response.Ledger)
+		assert.Empty(t, submissionCancelled)
+	})
+}
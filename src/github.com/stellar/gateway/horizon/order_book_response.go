@@ -0,0 +1,14 @@
+package horizon
+
+// OrderBookResponse contains order book summary returned by Horizon
+type OrderBookResponse struct {
+	Bids []OrderBookLevel `json:"bids"`
+	Asks []OrderBookLevel `json:"asks"`
+}
+
+// OrderBookLevel is a single price level of an order book. Price is an amount of counter
+// (buying) asset for a unit of base (selling) asset, Amount is in base asset for asks.
+type OrderBookLevel struct {
+	Price  string `json:"price"`
+	Amount string `json:"amount"`
+}
@@ -0,0 +1,30 @@
+package horizon
+
+// PathsPage contains payment paths found by Horizon, a path per source asset held by the source
+// account
+type PathsPage struct {
+	Embedded struct {
+		Records []PathResponse `json:"records"`
+	} `json:"_embedded"`
+}
+
+// PathResponse is a path of a path payment. SourceAmount is the amount of the source asset
+// needed to deliver DestinationAmount through assets of Path using current offers.
+type PathResponse struct {
+	SourceAssetType        string      `json:"source_asset_type"`
+	SourceAssetCode        string      `json:"source_asset_code,omitempty"`
+	SourceAssetIssuer      string      `json:"source_asset_issuer,omitempty"`
+	SourceAmount           string      `json:"source_amount"`
+	DestinationAssetType   string      `json:"destination_asset_type"`
+	DestinationAssetCode   string      `json:"destination_asset_code,omitempty"`
+	DestinationAssetIssuer string      `json:"destination_asset_issuer,omitempty"`
+	DestinationAmount      string      `json:"destination_amount"`
+	Path                   []PathAsset `json:"path"`
+}
+
+// PathAsset is an intermediate asset of a path
+type PathAsset struct {
+	AssetType   string `json:"asset_type"`
+	AssetCode   string `json:"asset_code,omitempty"`
+	AssetIssuer string `json:"asset_issuer,omitempty"`
+}
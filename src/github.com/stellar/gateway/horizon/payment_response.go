@@ -5,6 +5,8 @@ type PaymentResponse struct {
 	ID          string `json:"id"`
 	Type        string `json:"type"`
 	PagingToken string `json:"paging_token"`
+	// TransactionHash is not sent by old Horizon versions
+	TransactionHash string `json:"transaction_hash"`
 
 	Links struct {
 		Transaction struct {
@@ -20,6 +22,14 @@ type PaymentResponse struct {
 	AssetIssuer string `json:"asset_issuer"`
 	Amount      string `json:"amount"`
 
+	// account_merge fields: Account is merged into Into
+	Account string `json:"account"`
+	Into    string `json:"into"`
+
+	// create_account fields: Funder created Account
+	Funder          string `json:"funder"`
+	StartingBalance string `json:"starting_balance"`
+
 	// transaction fields
 	Memo struct {
 		Type  string `json:"memo_type"`
@@ -0,0 +1,83 @@
+package horizon
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// PaymentsPage is a single page of payments returned by Horizon
+type PaymentsPage struct {
+	Embedded struct {
+		Records []PaymentResponse `json:"records"`
+	} `json:"_embedded"`
+
+	// RateLimit is read from response headers, nil when Horizon does not send them
+	RateLimit *RateLimit `json:"-"`
+}
+
+// RateLimit contains rate limit state sent by Horizon in X-Ratelimit-* headers
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	// Reset is a time after which Remaining is restored
+	Reset time.Duration
+}
+
+// RateLimitedError is returned when Horizon responds with 429 Too Many Requests
+type RateLimitedError struct {
+	// RetryAfter is read from Retry-After or X-Ratelimit-Reset header, 0 when not sent
+	RetryAfter time.Duration
+	// RateLimit is nil when Horizon does not send X-Ratelimit-* headers
+	RateLimit *RateLimit
+	// FailureID is an ID of the captured exchange in Horizon.Failures
+	FailureID string
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("Horizon rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+// RetryIn implements retry.RateLimited
+func (e *RateLimitedError) RetryIn() time.Duration {
+	return e.RetryAfter
+}
+
+// LedgerFromPagingToken returns a ledger sequence of an operation with a given paging token
+func LedgerFromPagingToken(pagingToken string) (uint32, error) {
+	id, err := strconv.ParseInt(pagingToken, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(id >> 32), nil
+}
+
+// PagingTokenFromLedger returns a paging token pointing to the beginning of a given ledger
+func PagingTokenFromLedger(ledger uint32) string {
+	return strconv.FormatInt(int64(ledger)<<32, 10)
+}
+
+func rateLimitFromHeaders(header http.Header) *RateLimit {
+	limit, err := strconv.Atoi(header.Get("X-Ratelimit-Limit"))
+	if err != nil {
+		return nil
+	}
+	remaining, _ := strconv.Atoi(header.Get("X-Ratelimit-Remaining"))
+	reset, _ := strconv.Atoi(header.Get("X-Ratelimit-Reset"))
+	return &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Duration(reset) * time.Second,
+	}
+}
+
+func retryAfterFromHeaders(header http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if rateLimit := rateLimitFromHeaders(header); rateLimit != nil && rateLimit.Reset > 0 {
+		return rateLimit.Reset
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package horizon
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/utc"
+)
+
+// defaultRateLimitPeriod is a time Horizon is considered rate limiting when a 429 response does
+// not advertise when the limit resets
+const defaultRateLimitPeriod = 10 * time.Second
+
+// RateLimits counts 429 responses of Horizon per endpoint. Rate limiting is self-inflicted, it's
+// reported separately from failures so it's not mistaken for an outage.
+type RateLimits struct {
+	mutex  sync.Mutex
+	now    func() time.Time
+	counts map[string]int64
+	// lastAt is nil until the first 429 response
+	lastAt *utc.Time
+	until  utc.Time
+}
+
+// RateLimitStatus is returned by /status endpoint
+type RateLimitStatus struct {
+	// Limited is true until the reset period of the last 429 response has passed
+	Limited bool      `json:"limited"`
+	LastAt  *utc.Time `json:"last_at,omitempty"`
+	RetryAt *utc.Time `json:"retry_at,omitempty"`
+	// Counts are numbers of 429 responses by endpoint since start
+	Counts map[string]int64 `json:"counts"`
+}
+
+// NewRateLimits creates a new RateLimits
+func NewRateLimits(now func() time.Time) *RateLimits {
+	return &RateLimits{now: now, counts: map[string]int64{}}
+}
+
+func (r *RateLimits) record(endpoint string, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = defaultRateLimitPeriod
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := r.now()
+	lastAt := utc.New(now)
+	r.counts[endpoint]++
+	r.lastAt = &lastAt
+	if until := now.Add(retryAfter); until.After(r.until.Time()) {
+		r.until = utc.New(until)
+	}
+}
+
+// Status returns counts of 429 responses and whether Horizon is rate limiting requests
+func (r *RateLimits) Status() RateLimitStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	status := RateLimitStatus{LastAt: r.lastAt, Counts: make(map[string]int64, len(r.counts))}
+	for endpoint, count := range r.counts {
+		status.Counts[endpoint] = count
+	}
+	if r.now().Before(r.until.Time()) {
+		retryAt := r.until
+		status.Limited = true
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// rateLimited returns a captured *RateLimitedError of a 429 response
+func (h *Horizon) rateLimited(endpoint string, request FailureRequest, resp *http.Response, body []byte) error {
+	err := &RateLimitedError{
+		RetryAfter: retryAfterFromHeaders(resp.Header),
+		RateLimit:  rateLimitFromHeaders(resp.Header),
+	}
+	err.FailureID = h.captureFailure(endpoint, request, resp.StatusCode, body, nil)
+	if h.RateLimits != nil {
+		h.RateLimits.record(endpoint, err.RetryAfter)
+	}
+	h.log.WithField("retry_after", err.RetryAfter).Warn("Horizon rate limit exceeded")
+	return err
+}
@@ -0,0 +1,112 @@
+package horizon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/breaker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rateLimitingServer is a fake Horizon responding with 429 after allowed requests until reset
+type rateLimitingServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	allowed  int
+	requests int
+}
+
+func newRateLimitingServer(t *testing.T, allowed int) *rateLimitingServer {
+	s := &rateLimitingServer{allowed: allowed}
+	fixtures := testdataServer(t, "testdata/horizon-0.17.0")
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		s.requests++
+		remaining := s.allowed - s.requests
+		s.mutex.Unlock()
+
+		w.Header().Set("X-Ratelimit-Limit", strconv.Itoa(allowed))
+		w.Header().Set("X-Ratelimit-Reset", "7")
+		if remaining < 0 {
+			w.Header().Set("X-Ratelimit-Remaining", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"type": "https://stellar.org/horizon-errors/rate_limit_exceeded", "status": 429}`))
+			return
+		}
+		w.Header().Set("X-Ratelimit-Remaining", strconv.Itoa(remaining))
+		fixtures.Config.Handler.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// reset lets allowed requests through again
+func (s *rateLimitingServer) reset() {
+	s.mutex.Lock()
+	s.requests = 0
+	s.mutex.Unlock()
+}
+
+func TestRateLimitedAfterRequests(t *testing.T) {
+	server := newRateLimitingServer(t, 2)
+	defer server.Close()
+
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	h := New(server.URL)
+	h.RateLimits = NewRateLimits(func() time.Time { return now })
+
+	for i := 0; i < 2; i++ {
+		_, err := h.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+		require.NoError(t, err)
+	}
+	assert.False(t, h.RateLimits.Status().Limited)
+
+	_, err := h.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+	rateLimited, ok := err.(*RateLimitedError)
+	require.True(t, ok, "expected RateLimitedError, got %v", err)
+	assert.Equal(t, 7*time.Second, rateLimited.RetryAfter)
+	assert.Equal(t, &RateLimit{Limit: 2, Remaining: 0, Reset: 7 * time.Second}, rateLimited.RateLimit)
+	failure, ok := h.Failures.Get(FailureID(err))
+	require.True(t, ok, "captured failure")
+	require.NotNil(t, failure.Response)
+	assert.Equal(t, http.StatusTooManyRequests, failure.Response.StatusCode)
+
+	_, err = h.SubmitTransaction(testEnvelope)
+	assert.IsType(t, &RateLimitedError{}, err)
+	_, err = h.LoadTransaction("3389e9f0f1a65f19736cacf544c2e825313e8447f569233bb8db39aa607c8889")
+	assert.IsType(t, &RateLimitedError{}, err)
+
+	status := h.RateLimits.Status()
+	assert.True(t, status.Limited)
+	assert.Equal(t, "2017-03-01T09:30:07Z", status.RetryAt.String())
+	assert.Equal(t, map[string]int64{"accounts": 1, "submit_transaction": 1, "transactions": 1}, status.Counts)
+
+	// Limit is reset
+	now = now.Add(8 * time.Second)
+	server.reset()
+	_, err = h.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+	require.NoError(t, err)
+	status = h.RateLimits.Status()
+	assert.False(t, status.Limited)
+	assert.Nil(t, status.RetryAt)
+	assert.Equal(t, "2017-03-01T09:30:00Z", status.LastAt.String())
+}
+
+func TestRateLimitedDoesNotOpenBreaker(t *testing.T) {
+	server := newRateLimitingServer(t, 0)
+	defer server.Close()
+
+	h := New(server.URL)
+	breakers := breaker.NewSet(breaker.Settings{FailureRate: 0.5, MinRequests: 2, Window: time.Minute, OpenTimeout: time.Minute}, time.Now)
+	bh := NewBreakerHorizon(&h, breakers)
+
+	for i := 0; i < 5; i++ {
+		_, err := bh.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+		assert.IsType(t, &RateLimitedError{}, err)
+	}
+	assert.Equal(t, breaker.StateClosed, breakers.Get(BreakerAccounts).Status().State)
+}
@@ -0,0 +1,255 @@
+package horizon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// HorizonSchemaError is returned when a Horizon response cannot be decoded or a field the bridge
+// depends on is missing, usually after a Horizon upgrade changed the response schema. Unknown
+// fields are ignored.
+type HorizonSchemaError struct {
+	// Resource is a decoded resource, ex. `account`
+	Resource string
+	// Field is a JSON path of the missing or invalid field, empty when the body is not valid JSON
+	Field string
+	Err   error
+	// FailureID is an ID of the captured exchange in Horizon.Failures
+	FailureID string
+}
+
+func (e *HorizonSchemaError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("Unexpected Horizon %s response: %s", e.Resource, e.Err)
+	}
+	return fmt.Sprintf("Unexpected Horizon %s response: %s %s", e.Resource, e.Field, e.Err)
+}
+
+var errMissing = errors.New("is missing")
+
+// schemaResponse is implemented by responses validating fields the bridge depends on
+type schemaResponse interface {
+	// validateSchema returns a path of the first missing or invalid field and an error
+	validateSchema() (string, error)
+}
+
+// decodeResponse unmarshals a Horizon response and validates its schema
+func decodeResponse(resource string, body []byte, response schemaResponse) error {
+	err := json.Unmarshal(body, response)
+	if err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			return &HorizonSchemaError{
+				Resource: resource,
+				Field:    typeErr.Field,
+				Err:      fmt.Errorf("has unexpected type %s", typeErr.Value),
+			}
+		}
+		return &HorizonSchemaError{Resource: resource, Err: err}
+	}
+
+	field, err := response.validateSchema()
+	if err != nil {
+		return &HorizonSchemaError{Resource: resource, Field: field, Err: err}
+	}
+	return nil
+}
+
+// decode is decodeResponse logging the raw body of invalid responses
+func (h *Horizon) decode(resource string, body []byte, response schemaResponse) error {
+	err := decodeResponse(resource, body, response)
+	if err != nil {
+		h.log.WithField("body", string(body)).Debug(err.Error())
+	}
+	return err
+}
+
+func (account *AccountResponse) validateSchema() (string, error) {
+	if account.AccountID == "" {
+		return "id", errMissing
+	}
+	if account.SequenceNumber == "" {
+		return "sequence", errMissing
+	}
+	if _, err := strconv.ParseUint(account.SequenceNumber, 10, 64); err != nil {
+		return "sequence", errors.New("is not a number")
+	}
+	if account.Balances == nil {
+		return "balances", errMissing
+	}
+	for i, balance := range account.Balances {
+		if field, err := balance.validateSchema(); err != nil {
+			return fmt.Sprintf("balances[%d].%s", i, field), err
+		}
+	}
+	return "", nil
+}
+
+func (balance Balance) validateSchema() (string, error) {
+	if balance.Balance == "" {
+		return "balance", errMissing
+	}
+	if balance.AssetType == "" {
+		return "asset_type", errMissing
+	}
+	if balance.AssetType == "native" {
+		return "", nil
+	}
+	if balance.AssetCode == "" {
+		return "asset_code", errMissing
+	}
+	if balance.AssetIssuer == "" {
+		return "asset_issuer", errMissing
+	}
+	return "", nil
+}
+
+func (payment *PaymentResponse) validateSchema() (string, error) {
+	if payment.ID == "" {
+		return "id", errMissing
+	}
+	if payment.Type == "" {
+		return "type", errMissing
+	}
+	if payment.PagingToken == "" {
+		return "paging_token", errMissing
+	}
+	if payment.Links.Transaction.Href == "" {
+		return "_links.transaction.href", errMissing
+	}
+
+	// Other operation types are skipped by the listener
+	if payment.Type != "payment" && payment.Type != "path_payment" {
+		return "", nil
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"from", payment.From},
+		{"to", payment.To},
+		{"asset_type", payment.AssetType},
+		{"amount", payment.Amount},
+	} {
+		if field.value == "" {
+			return field.name, errMissing
+		}
+	}
+	if payment.AssetType != "native" && (payment.AssetCode == "" || payment.AssetIssuer == "") {
+		return "asset_code", errMissing
+	}
+	return "", nil
+}
+
+func (page *PaymentsPage) validateSchema() (string, error) {
+	if page.Embedded.Records == nil {
+		return "_embedded.records", errMissing
+	}
+	for i := range page.Embedded.Records {
+		if field, err := page.Embedded.Records[i].validateSchema(); err != nil {
+			return fmt.Sprintf("_embedded.records[%d].%s", i, field), err
+		}
+	}
+	return "", nil
+}
+
+// transactionMemo is a transaction resource, only memo is decoded
+type transactionMemo struct {
+	Type  string `json:"memo_type"`
+	Value string `json:"memo"`
+}
+
+func (memo *transactionMemo) validateSchema() (string, error) {
+	if memo.Type == "" {
+		return "memo_type", errMissing
+	}
+	return "", nil
+}
+
+func (transaction *TransactionResponse) validateSchema() (string, error) {
+	if transaction.Hash == "" {
+		return "hash", errMissing
+	}
+	if transaction.Ledger == 0 {
+		return "ledger", errMissing
+	}
+	if transaction.ResultXdr == "" {
+		return "result_xdr", errMissing
+	}
+	return "", nil
+}
+
+func (ledger *LedgerResponse) validateSchema() (string, error) {
+	if ledger.Sequence == 0 {
+		return "sequence", errMissing
+	}
+	if ledger.ClosedAt.Time().IsZero() {
+		return "closed_at", errMissing
+	}
+	return "", nil
+}
+
+// rootResponse is a Horizon root resource
+type rootResponse struct {
+	HistoryLatestLedger uint32 `json:"history_latest_ledger"`
+}
+
+func (root *rootResponse) validateSchema() (string, error) {
+	if root.HistoryLatestLedger == 0 {
+		return "history_latest_ledger", errMissing
+	}
+	return "", nil
+}
+
+func (orderBook *OrderBookResponse) validateSchema() (string, error) {
+	if orderBook.Bids == nil {
+		return "bids", errMissing
+	}
+	if orderBook.Asks == nil {
+		return "asks", errMissing
+	}
+	for name, levels := range map[string][]OrderBookLevel{"bids": orderBook.Bids, "asks": orderBook.Asks} {
+		for i, level := range levels {
+			if level.Price == "" || level.Amount == "" {
+				return fmt.Sprintf("%s[%d]", name, i), errors.New("is missing price or amount")
+			}
+		}
+	}
+	return "", nil
+}
+
+func (page *PathsPage) validateSchema() (string, error) {
+	if page.Embedded.Records == nil {
+		return "_embedded.records", errMissing
+	}
+	for i, path := range page.Embedded.Records {
+		if path.SourceAssetType == "" {
+			return fmt.Sprintf("_embedded.records[%d].source_asset_type", i), errMissing
+		}
+		if path.SourceAssetType != "native" && (path.SourceAssetCode == "" || path.SourceAssetIssuer == "") {
+			return fmt.Sprintf("_embedded.records[%d].source_asset_code", i), errMissing
+		}
+		if path.SourceAmount == "" {
+			return fmt.Sprintf("_embedded.records[%d].source_amount", i), errMissing
+		}
+		for j, asset := range path.Path {
+			if asset.AssetType == "" {
+				return fmt.Sprintf("_embedded.records[%d].path[%d].asset_type", i, j), errMissing
+			}
+		}
+	}
+	return "", nil
+}
+
+func (response *SubmitTransactionResponse) validateSchema() (string, error) {
+	if response.Ledger != nil && response.Hash == "" {
+		return "hash", errMissing
+	}
+	// Error responses without `extras` (ex. timeouts) are not transaction results
+	if response.Ledger == nil && response.Extras != nil && response.Extras.ResultXdr == "" {
+		return "extras.result_xdr", errMissing
+	}
+	return "", nil
+}
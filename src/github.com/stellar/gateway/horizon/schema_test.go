@@ -0,0 +1,285 @@
+package horizon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/build"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testdataServer serves responses recorded from a single Horizon version
+func testdataServer(t *testing.T, dir string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fixture string
+		status := http.StatusOK
+		switch {
+		case r.URL.Path == "/":
+			fixture = "root"
+		case r.URL.Path == "/order_book":
+			fixture = "order_book"
+		case r.URL.Path == "/paths":
+			fixture = "paths"
+		case strings.HasSuffix(r.URL.Path, "/payments"):
+			fixture = "payments"
+		case strings.HasPrefix(r.URL.Path, "/accounts/"):
+			fixture = "account"
+		case strings.HasPrefix(r.URL.Path, "/ledgers/"):
+			fixture = "ledger"
+		case strings.HasPrefix(r.URL.Path, "/operations/"):
+			fixture = "operation"
+		case strings.HasPrefix(r.URL.Path, "/transactions/"):
+			fixture = "transaction"
+		case r.URL.Path == "/transactions" && r.PostFormValue("tx") == "fail":
+			fixture = "submit_failure"
+			status = http.StatusBadRequest
+		case r.URL.Path == "/transactions":
+			fixture = "submit_success"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadFile(filepath.Join(dir, fixture+".json"))
+		require.NoError(t, err)
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+}
+
+func TestHorizonVersionsContract(t *testing.T) {
+	versions, err := filepath.Glob("testdata/horizon-*")
+	require.NoError(t, err)
+	require.NotEmpty(t, versions)
+
+	for _, dir := range versions {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			server := testdataServer(t, dir)
+			defer server.Close()
+			h := New(server.URL)
+
+			account, err := h.LoadAccount("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB")
+			require.NoError(t, err)
+			assert.Equal(t, "40046149010963", account.SequenceNumber)
+			balance, ok := account.GetBalance("USD", "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR")
+			assert.True(t, ok)
+			assert.Equal(t, "100.0000000", balance.Balance)
+			assert.Equal(t, int32(1), account.SubentryCount)
+			assert.Equal(t, 1, account.Trustlines())
+			assert.Equal(t, 0, account.Offers())
+
+			page, err := h.LoadPayments("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB", "", 10)
+			require.NoError(t, err)
+			require.Len(t, page.Embedded.Records, 3)
+			assert.Equal(t, "38654709762", page.Embedded.Records[1].PagingToken)
+			assert.Equal(t, "create_account", page.Embedded.Records[2].Type)
+
+			payment, err := h.LoadOperation("38654709761")
+			require.NoError(t, err)
+			assert.Equal(t, "20.0000000", payment.Amount)
+
+			// Recorded transaction links point to the public server
+			payment.Links.Transaction.Href = server.URL + "/transactions/ad71fc31"
+			require.NoError(t, h.LoadMemo(&payment))
+			assert.Equal(t, "text", payment.Memo.Type)
+			assert.Equal(t, "invoice 1", payment.Memo.Value)
+
+			transaction, err := h.LoadTransaction("ad71fc31bfae25b0bd14add4cc5306661edf84cdd73f1353d2906363899167e1")
+			require.NoError(t, err)
+			assert.Equal(t, uint64(9), transaction.Ledger)
+			assert.True(t, transaction.IsSuccessful())
+
+			ledger, err := h.LoadLatestLedger()
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1300000), ledger)
+
+			closed, err := h.LoadLedger(9)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(9), closed.Sequence)
+			assert.Equal(t, "2019-03-01T09:30:15Z", closed.ClosedAt.String())
+
+			orderBook, err := h.LoadOrderBook(build.CreditAsset("USD", "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"), build.NativeAsset())
+			require.NoError(t, err)
+			assert.Equal(t, "2.0000000", orderBook.Bids[0].Price)
+
+			paths, err := h.LoadPaths("GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB", "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS", build.CreditAsset("USD", "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"), "10")
+			require.NoError(t, err)
+			require.Len(t, paths.Embedded.Records, 2)
+			assert.Equal(t, "9.5000000", paths.Embedded.Records[1].SourceAmount)
+			assert.Equal(t, []PathAsset{{AssetType: "native"}}, paths.Embedded.Records[1].Path)
+
+			success, err := h.SubmitTransaction("ok")
+			require.NoError(t, err)
+			require.NotNil(t, success.Ledger)
+			assert.Equal(t, uint64(1300001), *success.Ledger)
+
+			failure, err := h.SubmitTransaction("fail")
+			require.NoError(t, err)
+			assert.Nil(t, failure.Ledger)
+			assert.Equal(t, "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB////+gAAAAA=", failure.Extras.ResultXdr)
+		})
+	}
+}
+
+// changedFixture loads a fixture of the newest version and changes it
+func changedFixture(t *testing.T, name string, change func(body map[string]interface{})) []byte {
+	versions, err := filepath.Glob("testdata/horizon-*")
+	require.NoError(t, err)
+
+	raw, err := ioutil.ReadFile(filepath.Join(versions[len(versions)-1], name+".json"))
+	require.NoError(t, err)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &body))
+	change(body)
+
+	raw, err = json.Marshal(body)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestHorizonSchemaError(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixture  string
+		change   func(body map[string]interface{})
+		response func() schemaResponse
+		field    string
+	}{
+		{
+			"renamed sequence",
+			"account",
+			func(body map[string]interface{}) {
+				body["sequence_number"] = body["sequence"]
+				delete(body, "sequence")
+			},
+			func() schemaResponse { return &AccountResponse{} },
+			"sequence",
+		},
+		{
+			"numeric sequence",
+			"account",
+			func(body map[string]interface{}) { body["sequence"] = 40046149010963 },
+			func() schemaResponse { return &AccountResponse{} },
+			"sequence",
+		},
+		{
+			"missing balance",
+			"account",
+			func(body map[string]interface{}) {
+				delete(body["balances"].([]interface{})[0].(map[string]interface{}), "balance")
+			},
+			func() schemaResponse { return &AccountResponse{} },
+			"balances[0].balance",
+		},
+		{
+			"missing balances",
+			"account",
+			func(body map[string]interface{}) { delete(body, "balances") },
+			func() schemaResponse { return &AccountResponse{} },
+			"balances",
+		},
+		{
+			"missing paging token",
+			"payments",
+			func(body map[string]interface{}) {
+				records := body["_embedded"].(map[string]interface{})["records"].([]interface{})
+				delete(records[1].(map[string]interface{}), "paging_token")
+			},
+			func() schemaResponse { return &PaymentsPage{} },
+			"_embedded.records[1].paging_token",
+		},
+		{
+			"missing records",
+			"payments",
+			func(body map[string]interface{}) { delete(body, "_embedded") },
+			func() schemaResponse { return &PaymentsPage{} },
+			"_embedded.records",
+		},
+		{
+			"missing source amount",
+			"paths",
+			func(body map[string]interface{}) {
+				records := body["_embedded"].(map[string]interface{})["records"].([]interface{})
+				delete(records[1].(map[string]interface{}), "source_amount")
+			},
+			func() schemaResponse { return &PathsPage{} },
+			"_embedded.records[1].source_amount",
+		},
+		{
+			"missing amount",
+			"operation",
+			func(body map[string]interface{}) { delete(body, "amount") },
+			func() schemaResponse { return &PaymentResponse{} },
+			"amount",
+		},
+		{
+			"string ledger",
+			"transaction",
+			func(body map[string]interface{}) { body["ledger"] = "9" },
+			func() schemaResponse { return &TransactionResponse{} },
+			"ledger",
+		},
+		{
+			"missing result xdr",
+			"submit_failure",
+			func(body map[string]interface{}) {
+				delete(body["extras"].(map[string]interface{}), "result_xdr")
+			},
+			func() schemaResponse { return &SubmitTransactionResponse{} },
+			"extras.result_xdr",
+		},
+		{
+			"missing latest ledger",
+			"root",
+			func(body map[string]interface{}) { delete(body, "history_latest_ledger") },
+			func() schemaResponse { return &rootResponse{} },
+			"history_latest_ledger",
+		},
+		{
+			"missing memo type",
+			"transaction",
+			func(body map[string]interface{}) { delete(body, "memo_type") },
+			func() schemaResponse { return &transactionMemo{} },
+			"memo_type",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := decodeResponse(test.fixture, changedFixture(t, test.fixture, test.change), test.response())
+			require.Error(t, err)
+			schemaErr, ok := err.(*HorizonSchemaError)
+			require.True(t, ok, "expected HorizonSchemaError, got %T", err)
+			assert.Equal(t, test.field, schemaErr.Field)
+		})
+	}
+
+	t.Run("not JSON", func(t *testing.T) {
+		err := decodeResponse("account", []byte("<html>Bad Gateway</html>"), &AccountResponse{})
+		require.Error(t, err)
+		assert.IsType(t, &HorizonSchemaError{}, err)
+	})
+}
+
+func TestSubmitTransactionSuccessWithoutLedger(t *testing.T) {
+	body := changedFixture(t, "submit_success", func(body map[string]interface{}) {
+		body["ledger_sequence"] = body["ledger"]
+		delete(body, "ledger")
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	h := New(server.URL)
+	_, err := h.SubmitTransaction("ok")
+	require.Error(t, err)
+	assert.Equal(t, "Unexpected Horizon transaction submission response: ledger is missing", err.Error())
+}
@@ -2,6 +2,8 @@ package horizon
 
 import (
 	"encoding/json"
+
+	"github.com/stellar/gateway/txspec"
 )
 
 // SubmitTransactionResponse contains result of submitting transaction to Stellar network
@@ -11,6 +13,25 @@ type SubmitTransactionResponse struct {
 	ResultXdr  *string                          `json:"result_xdr,omitempty"`  // Only success response.
 	Ledger     *uint64                          `json:"ledger"`
 	Extras     *SubmitTransactionResponseExtras `json:"extras,omitempty"`
+
+	// TrustlineCreated is true when /payment with auto_trust prepended change_trust operation
+	TrustlineCreated bool `json:"trustline_created,omitempty"`
+	// Warnings contains conflicts between /payment params and its `uri` and a warning about a
+	// recently recreated destination
+	Warnings []string `json:"warnings,omitempty"`
+	// TimeBounds are time bounds of the /payment transaction set by min_time and max_time
+	TimeBounds *txspec.TimeBounds `json:"time_bounds,omitempty"`
+	// Amount is the amount sent by a /payment with `amount=all`
+	Amount string `json:"amount,omitempty"`
+	// Path is the path found for a /payment path payment sent without path params
+	Path *PathResponse `json:"path,omitempty"`
+	// Attempts is a number of transactions /payment submitted, more than 1 when a transaction
+	// failed with tx_bad_seq and was rebuilt with a new sequence number
+	Attempts int `json:"attempts,omitempty"`
+	// ResultCodes are result codes of the /payment transaction decoded from its result XDR
+	ResultCodes *ResultCodes `json:"result_codes,omitempty"`
+	// FailureID is an ID of a captured failed submission in Horizon.Failures
+	FailureID string `json:"-"`
 }
 
 // HTTPStatus implements protocols.SuccessResponse interface
@@ -24,6 +45,15 @@ func (response *SubmitTransactionResponse) Marshal() []byte {
 	return json
 }
 
+// ResultCodes are codes of a transaction result named like `extras.result_codes` of Horizon, so
+// clients don't have to decode result XDR
+type ResultCodes struct {
+	Transaction string   `json:"transaction,omitempty"`
+	Operations  []string `json:"operations,omitempty"`
+	// ResultXdr is the raw result, set only when it cannot be decoded or contains unknown codes
+	ResultXdr string `json:"result_xdr,omitempty"`
+}
+
 // SubmitTransactionResponseExtras contains extra information returned by Horizon
 type SubmitTransactionResponseExtras struct {
 	EnvelopeXdr string `json:"envelope_xdr"`
@@ -0,0 +1,52 @@
+package horizon
+
+// TransactionResponse contains a transaction applied to a ledger as returned by Horizon
+type TransactionResponse struct {
+	Hash        string `json:"hash"`
+	Ledger      uint64 `json:"ledger"`
+	EnvelopeXdr string `json:"envelope_xdr"`
+	ResultXdr   string `json:"result_xdr"`
+	// Successful is missing in responses of Horizon versions storing only successful transactions
+	Successful *bool `json:"successful"`
+	// InnerTransaction is sent for fee-bump transactions, Hash is the hash of the fee-bump
+	// transaction then
+	InnerTransaction *InnerTransaction `json:"inner_transaction,omitempty"`
+}
+
+// InnerTransaction is a transaction wrapped by a fee-bump transaction
+type InnerTransaction struct {
+	Hash string `json:"hash"`
+}
+
+// InnerHash returns the hash of the wrapped transaction of a fee-bump transaction, the hash of
+// other transactions
+func (response TransactionResponse) InnerHash() string {
+	if response.InnerTransaction != nil && response.InnerTransaction.Hash != "" {
+		return response.InnerTransaction.Hash
+	}
+	return response.Hash
+}
+
+// IsSuccessful returns true if the transaction operations were applied
+func (response TransactionResponse) IsSuccessful() bool {
+	return response.Successful == nil || *response.Successful
+}
+
+// ToSubmitTransactionResponse returns the response Horizon returns when the transaction is
+// submitted: a failed transaction has no ledger and its result is in Extras.
+func (response TransactionResponse) ToSubmitTransactionResponse() SubmitTransactionResponse {
+	if response.IsSuccessful() {
+		return SubmitTransactionResponse{
+			Hash:      response.Hash,
+			Ledger:    &response.Ledger,
+			ResultXdr: &response.ResultXdr,
+		}
+	}
+	return SubmitTransactionResponse{
+		Hash: response.Hash,
+		Extras: &SubmitTransactionResponseExtras{
+			EnvelopeXdr: response.EnvelopeXdr,
+			ResultXdr:   response.ResultXdr,
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package horizon
+
+import (
+	"net/http"
+
+	"github.com/stellar/go/xdr"
+)
+
+// Remediation hints of transaction-level failures
+const (
+	RemediationRetryAfterMinTime      = "retry_after_min_time"
+	RemediationRetryWithNewTimebounds = "retry_with_new_timebounds"
+	RemediationAddOperations          = "add_operations"
+	RemediationRetryWithNewSequence   = "retry_with_new_sequence"
+	RemediationAddSignatures          = "add_signatures"
+	RemediationFundSource             = "fund_source"
+	RemediationCreateSourceAccount    = "create_source_account"
+	RemediationIncreaseFee            = "increase_fee"
+	RemediationRemoveSignatures       = "remove_signatures"
+	RemediationRetry                  = "retry"
+)
+
+// TransactionResultError is a transaction-level failure of a submitted transaction: a result code
+// other than tx_success and tx_failed (failed operations are reported by operation results)
+type TransactionResultError struct {
+	Code xdr.TransactionResultCode
+	// Name is the code returned by Horizon in `result_codes.transaction`
+	Name string
+	// Status is the HTTP status of responses reporting the failure
+	Status int
+	// Remediation is a machine-readable hint how the transaction can be fixed
+	Remediation string
+	// Retriable is true when submitting the same envelope again can succeed
+	Retriable bool
+}
+
+func (e *TransactionResultError) Error() string {
+	return "transaction failed: " + e.Name
+}
+
+// transactionResultErrors contains every transaction-level result code of xdr.TransactionResultCode
+var transactionResultErrors = map[xdr.TransactionResultCode]TransactionResultError{
+	xdr.TransactionResultCodeTxTooEarly:            {Name: "tx_too_early", Status: http.StatusBadRequest, Remediation: RemediationRetryAfterMinTime},
+	xdr.TransactionResultCodeTxTooLate:             {Name: "tx_too_late", Status: http.StatusBadRequest, Remediation: RemediationRetryWithNewTimebounds},
+	xdr.TransactionResultCodeTxMissingOperation:    {Name: "tx_missing_operation", Status: http.StatusBadRequest, Remediation: RemediationAddOperations},
+	xdr.TransactionResultCodeTxBadSeq:              {Name: "tx_bad_seq", Status: http.StatusBadRequest, Remediation: RemediationRetryWithNewSequence},
+	xdr.TransactionResultCodeTxBadAuth:             {Name: "tx_bad_auth", Status: http.StatusBadRequest, Remediation: RemediationAddSignatures},
+	xdr.TransactionResultCodeTxInsufficientBalance: {Name: "tx_insufficient_balance", Status: http.StatusBadRequest, Remediation: RemediationFundSource},
+	xdr.TransactionResultCodeTxNoAccount:           {Name: "tx_no_source_account", Status: http.StatusBadRequest, Remediation: RemediationCreateSourceAccount},
+	xdr.TransactionResultCodeTxInsufficientFee:     {Name: "tx_insufficient_fee", Status: http.StatusBadRequest, Remediation: RemediationIncreaseFee},
+	xdr.TransactionResultCodeTxBadAuthExtra:        {Name: "tx_bad_auth_extra", Status: http.StatusBadRequest, Remediation: RemediationRemoveSignatures},
+	// Internal errors of stellar-core are not caused by the transaction
+	xdr.TransactionResultCodeTxInternalError: {Name: "tx_internal_error", Status: http.StatusBadGateway, Remediation: RemediationRetry, Retriable: true},
+}
+
+// NewTransactionResultError returns the classification of a transaction-level result code, nil
+// for tx_success, tx_failed and unknown codes
+func NewTransactionResultError(code xdr.TransactionResultCode) *TransactionResultError {
+	resultErr, ok := transactionResultErrors[code]
+	if !ok {
+		return nil
+	}
+	resultErr.Code = code
+	return &resultErr
+}
+
+// TransactionResultError returns the transaction-level failure of a submitted transaction, nil
+// when it was applied, failed in operations or its result cannot be decoded
+func (response *SubmitTransactionResponse) TransactionResultError() *TransactionResultError {
+	if response.Ledger != nil || response.Extras == nil {
+		return nil
+	}
+
+	var result xdr.TransactionResult
+	err := xdr.SafeUnmarshalBase64(response.Extras.ResultXdr, &result)
+	if err != nil {
+		return nil
+	}
+	return NewTransactionResultError(result.Result.Code)
+}
@@ -0,0 +1,82 @@
+package horizon
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionResultErrors(t *testing.T) {
+	tests := []struct {
+		code        xdr.TransactionResultCode
+		name        string
+		status      int
+		remediation string
+		retriable   bool
+	}{
+		{xdr.TransactionResultCodeTxTooEarly, "tx_too_early", http.StatusBadRequest, RemediationRetryAfterMinTime, false},
+		{xdr.TransactionResultCodeTxTooLate, "tx_too_late", http.StatusBadRequest, RemediationRetryWithNewTimebounds, false},
+		{xdr.TransactionResultCodeTxMissingOperation, "tx_missing_operation", http.StatusBadRequest, RemediationAddOperations, false},
+		{xdr.TransactionResultCodeTxBadSeq, "tx_bad_seq", http.StatusBadRequest, RemediationRetryWithNewSequence, false},
+		{xdr.TransactionResultCodeTxBadAuth, "tx_bad_auth", http.StatusBadRequest, RemediationAddSignatures, false},
+		{xdr.TransactionResultCodeTxInsufficientBalance, "tx_insufficient_balance", http.StatusBadRequest, RemediationFundSource, false},
+		{xdr.TransactionResultCodeTxNoAccount, "tx_no_source_account", http.StatusBadRequest, RemediationCreateSourceAccount, false},
+		{xdr.TransactionResultCodeTxInsufficientFee, "tx_insufficient_fee", http.StatusBadRequest, RemediationIncreaseFee, false},
+		{xdr.TransactionResultCodeTxBadAuthExtra, "tx_bad_auth_extra", http.StatusBadRequest, RemediationRemoveSignatures, false},
+		{xdr.TransactionResultCodeTxInternalError, "tx_internal_error", http.StatusBadGateway, RemediationRetry, true},
+	}
+
+	tested := map[xdr.TransactionResultCode]bool{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resultErr := NewTransactionResultError(test.code)
+			require.NotNil(t, resultErr)
+			assert.Equal(t, &TransactionResultError{
+				Code:        test.code,
+				Name:        test.name,
+				Status:      test.status,
+				Remediation: test.remediation,
+				Retriable:   test.retriable,
+			}, resultErr)
+			assert.EqualError(t, resultErr, "transaction failed: "+test.name)
+		})
+		tested[test.code] = true
+	}
+
+	assert.Nil(t, NewTransactionResultError(xdr.TransactionResultCodeTxSuccess))
+	assert.Nil(t, NewTransactionResultError(xdr.TransactionResultCodeTxFailed))
+
+	// Fails when xdr gets a result code without a classification
+	var code xdr.TransactionResultCode
+	for value := int32(math.MinInt8); value <= math.MaxInt8; value++ {
+		if !code.ValidEnum(value) {
+			continue
+		}
+		code = xdr.TransactionResultCode(value)
+		if code == xdr.TransactionResultCodeTxSuccess || code == xdr.TransactionResultCodeTxFailed {
+			continue
+		}
+		assert.True(t, tested[code], "%s has no test", code)
+		assert.NotNil(t, NewTransactionResultError(code), "%s has no classification", code)
+	}
+}
+
+func TestSubmitTransactionResponseTransactionResultError(t *testing.T) {
+	ledger := uint64(1)
+	failed := func(resultXdr string) *SubmitTransactionResponse {
+		return &SubmitTransactionResponse{Extras: &SubmitTransactionResponseExtras{ResultXdr: resultXdr}}
+	}
+
+	resultErr := failed("AAAAAAAAAAD////7AAAAAA==").TransactionResultError()
+	require.NotNil(t, resultErr)
+	assert.Equal(t, xdr.TransactionResultCodeTxBadSeq, resultErr.Code)
+
+	// Failed payment operation
+	assert.Nil(t, failed("AAAAAAAAAGT/////AAAAAQAAAAAAAAAB////+gAAAAA=").TransactionResultError())
+	assert.Nil(t, failed("invalid").TransactionResultError())
+	assert.Nil(t, (&SubmitTransactionResponse{Ledger: &ledger}).TransactionResultError())
+}
@@ -0,0 +1,268 @@
+// Package inflight keeps a registry of payments being processed, returned by /admin/inflight
+package inflight
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stellar/gateway/utc"
+)
+
+// Stages of a payment
+const (
+	StageResolving            = "resolving"
+	StageLoadingAccount       = "loading_account"
+	StageAwaitingApproval     = "awaiting_approval"
+	StageSubmitting           = "submitting"
+	StageAwaitingConfirmation = "awaiting_confirmation"
+)
+
+// Kinds of payments
+const (
+	// KindSync is a payment processed while its request waits for the response
+	KindSync = "sync"
+	// KindAsync is a queued payment processed after its request has been answered
+	KindAsync = "async"
+)
+
+const (
+	// DefaultSize is a number of payments tracked at the same time by default
+	DefaultSize = 1024
+	// maxStages is a number of stage transitions kept per payment
+	maxStages = 16
+)
+
+// Registry tracks payments in a fixed number of slots. Every payment locks only its own slot so
+// payments don't contend with each other, payments started when all slots are used are counted
+// but not tracked.
+type Registry struct {
+	slots     []slot
+	next      uint32
+	untracked int64
+	now       func() time.Time
+	// OnDone is called with the final state of every tracked payment when it's done, it must be
+	// set before payments are started
+	OnDone func(PaymentStatus)
+}
+
+type slot struct {
+	// used is set when the slot is taken by Start, before entry is written
+	used  int32
+	mutex sync.Mutex
+	// generation is incremented on every Start so stale Payments can't change a reused slot
+	generation uint64
+	entry      entry
+}
+
+type entry struct {
+	requestID string
+	kind      string
+	source    string
+	hash      string
+	startedAt time.Time
+	stages    []stage
+}
+
+type stage struct {
+	name      string
+	startedAt time.Time
+}
+
+// Payment is a handle of a tracked payment. Methods of nil Payment (a payment that is not
+// tracked) do nothing.
+type Payment struct {
+	slot       *slot
+	generation uint64
+	now        func() time.Time
+	onDone     func(PaymentStatus)
+}
+
+// Snapshot is returned by /admin/inflight endpoint
+type Snapshot struct {
+	// Payments are in-flight payments, oldest first
+	Payments []PaymentStatus `json:"payments"`
+	// Size is a maximum number of tracked payments
+	Size int `json:"size"`
+	// Untracked is a number of payments started when the registry was full since start
+	Untracked int64 `json:"untracked"`
+}
+
+// PaymentStatus is a state of an in-flight payment
+type PaymentStatus struct {
+	RequestID string `json:"request_id,omitempty"`
+	Kind      string `json:"kind"`
+	// Source is the config key of the source seed or the source account ID
+	Source string `json:"source,omitempty"`
+	// Hash is the hash of the transaction, set when it's signed
+	Hash           string        `json:"hash,omitempty"`
+	Stage          string        `json:"stage,omitempty"`
+	StartedAt      utc.Time      `json:"started_at"`
+	ElapsedSeconds float64       `json:"elapsed_seconds"`
+	Stages         []StageStatus `json:"stages"`
+}
+
+// StageStatus is a time spent in a stage, the last stage is still running
+type StageStatus struct {
+	Stage          string  `json:"stage"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+// NewRegistry creates a new Registry tracking up to size payments at the same time
+func NewRegistry(size int, now func() time.Time) *Registry {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Registry{slots: make([]slot, size), now: now}
+}
+
+// Start starts tracking a payment, nil is returned when the registry is full
+func (r *Registry) Start(requestID, kind, source string) *Payment {
+	size := uint32(len(r.slots))
+	first := atomic.AddUint32(&r.next, 1)
+	for i := uint32(0); i < size; i++ {
+		s := &r.slots[(first+i)%size]
+		if !atomic.CompareAndSwapInt32(&s.used, 0, 1) {
+			continue
+		}
+
+		s.mutex.Lock()
+		s.generation++
+		s.entry = entry{requestID: requestID, kind: kind, source: source, startedAt: r.now()}
+		payment := &Payment{slot: s, generation: s.generation, now: r.now, onDone: r.OnDone}
+		s.mutex.Unlock()
+		return payment
+	}
+
+	atomic.AddInt64(&r.untracked, 1)
+	return nil
+}
+
+// SetStage records a transition to a stage, nothing is recorded when the payment is already in it
+func (p *Payment) SetStage(name string) {
+	if p == nil {
+		return
+	}
+
+	p.slot.mutex.Lock()
+	defer p.slot.mutex.Unlock()
+	if p.slot.generation != p.generation {
+		return
+	}
+	stages := p.slot.entry.stages
+	if len(stages) == maxStages || (len(stages) > 0 && stages[len(stages)-1].name == name) {
+		return
+	}
+	p.slot.entry.stages = append(stages, stage{name: name, startedAt: p.now()})
+}
+
+// SetHash records the hash of the signed transaction of the payment
+func (p *Payment) SetHash(hash string) {
+	p.update(func(e *entry) { e.hash = hash })
+}
+
+// SetKind changes the kind of the payment, ex. when its request stopped waiting for it
+func (p *Payment) SetKind(kind string) {
+	p.update(func(e *entry) { e.kind = kind })
+}
+
+func (p *Payment) update(fn func(*entry)) {
+	if p == nil {
+		return
+	}
+
+	p.slot.mutex.Lock()
+	defer p.slot.mutex.Unlock()
+	if p.slot.generation == p.generation {
+		fn(&p.slot.entry)
+	}
+}
+
+// Status returns the state of the payment, false when it's not tracked anymore
+func (p *Payment) Status() (PaymentStatus, bool) {
+	if p == nil {
+		return PaymentStatus{}, false
+	}
+
+	p.slot.mutex.Lock()
+	defer p.slot.mutex.Unlock()
+	if p.slot.generation != p.generation {
+		return PaymentStatus{}, false
+	}
+	return p.slot.entry.status(p.now()), true
+}
+
+// Done stops tracking the payment and releases its slot
+func (p *Payment) Done() {
+	if p == nil {
+		return
+	}
+
+	p.slot.mutex.Lock()
+	if p.slot.generation != p.generation {
+		p.slot.mutex.Unlock()
+		return
+	}
+	var status PaymentStatus
+	if p.onDone != nil {
+		status = p.slot.entry.status(p.now())
+	}
+	// Generation is changed so the slot is not released again by a second Done
+	p.slot.generation++
+	p.slot.entry = entry{}
+	p.slot.mutex.Unlock()
+	atomic.StoreInt32(&p.slot.used, 0)
+
+	if p.onDone != nil {
+		p.onDone(status)
+	}
+}
+
+// Snapshot returns payments being processed
+func (r *Registry) Snapshot() Snapshot {
+	now := r.now()
+	payments := []PaymentStatus{}
+	for i := range r.slots {
+		s := &r.slots[i]
+		if atomic.LoadInt32(&s.used) == 0 {
+			continue
+		}
+
+		s.mutex.Lock()
+		entry := s.entry
+		entry.stages = append([]stage(nil), s.entry.stages...)
+		s.mutex.Unlock()
+		// Slot has been taken but the payment is not written yet
+		if entry.startedAt.IsZero() {
+			continue
+		}
+		payments = append(payments, entry.status(now))
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].StartedAt.Time().Before(payments[j].StartedAt.Time())
+	})
+	return Snapshot{Payments: payments, Size: len(r.slots), Untracked: atomic.LoadInt64(&r.untracked)}
+}
+
+func (e entry) status(now time.Time) PaymentStatus {
+	status := PaymentStatus{
+		RequestID:      e.requestID,
+		Kind:           e.kind,
+		Source:         e.source,
+		Hash:           e.hash,
+		StartedAt:      utc.New(e.startedAt),
+		ElapsedSeconds: now.Sub(e.startedAt).Seconds(),
+		Stages:         make([]StageStatus, len(e.stages)),
+	}
+	for i, stage := range e.stages {
+		end := now
+		if i+1 < len(e.stages) {
+			end = e.stages[i+1].startedAt
+		}
+		status.Stages[i] = StageStatus{Stage: stage.name, ElapsedSeconds: end.Sub(stage.startedAt).Seconds()}
+		status.Stage = stage.name
+	}
+	return status
+}
@@ -0,0 +1,124 @@
+package inflight
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	registry := NewRegistry(2, func() time.Time { return now })
+
+	first := registry.Start("req-1", KindSync, "base_seed")
+	first.SetStage(StageResolving)
+	now = now.Add(2 * time.Second)
+	first.SetStage(StageLoadingAccount)
+	first.SetStage(StageLoadingAccount)
+	now = now.Add(time.Second)
+	second := registry.Start("req-2", KindSync, "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW")
+	now = now.Add(time.Second)
+
+	snapshot := registry.Snapshot()
+	require.Len(t, snapshot.Payments, 2)
+	assert.Equal(t, 2, snapshot.Size)
+	payment := snapshot.Payments[0]
+	assert.Equal(t, "req-1", payment.RequestID)
+	assert.Equal(t, "base_seed", payment.Source)
+	assert.Equal(t, StageLoadingAccount, payment.Stage)
+	assert.Equal(t, float64(4), payment.ElapsedSeconds)
+	assert.Equal(t, []StageStatus{
+		{Stage: StageResolving, ElapsedSeconds: 2},
+		{Stage: StageLoadingAccount, ElapsedSeconds: 2},
+	}, payment.Stages)
+	assert.Equal(t, "req-2", snapshot.Payments[1].RequestID)
+	assert.Empty(t, snapshot.Payments[1].Stage)
+
+	t.Run("status", func(t *testing.T) {
+		second.SetHash("ab12")
+		second.SetKind(KindAsync)
+		status, ok := second.Status()
+		require.True(t, ok)
+		assert.Equal(t, "ab12", status.Hash)
+		assert.Equal(t, KindAsync, status.Kind)
+		assert.Equal(t, float64(1), status.ElapsedSeconds)
+	})
+
+	t.Run("full registry", func(t *testing.T) {
+		untracked := registry.Start("req-3", KindSync, "")
+		assert.Nil(t, untracked)
+		untracked.SetStage(StageSubmitting)
+		untracked.SetHash("ab12")
+		_, ok := untracked.Status()
+		assert.False(t, ok)
+		untracked.Done()
+		assert.Equal(t, int64(1), registry.Snapshot().Untracked)
+	})
+
+	t.Run("stale payment doesn't change a reused slot", func(t *testing.T) {
+		first.Done()
+		third := registry.Start("req-3", KindAsync, "")
+		require.NotNil(t, third)
+
+		first.SetStage(StageSubmitting)
+		first.SetKind(KindSync)
+		_, ok := first.Status()
+		assert.False(t, ok)
+		first.Done()
+		snapshot := registry.Snapshot()
+		require.Len(t, snapshot.Payments, 2)
+		assert.Equal(t, "req-2", snapshot.Payments[0].RequestID)
+		assert.Equal(t, "req-3", snapshot.Payments[1].RequestID)
+		assert.Empty(t, snapshot.Payments[1].Stages)
+		assert.Equal(t, KindAsync, snapshot.Payments[1].Kind)
+
+		second.Done()
+		third.Done()
+		assert.Empty(t, registry.Snapshot().Payments)
+	})
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	registry := NewRegistry(8, time.Now)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				payment := registry.Start("req", KindSync, "")
+				payment.SetStage(StageResolving)
+				registry.Snapshot()
+				payment.SetStage(StageSubmitting)
+				payment.Done()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, registry.Snapshot().Payments)
+}
+
+func TestRegistryOnDone(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	registry := NewRegistry(1, func() time.Time { return now })
+	var done []PaymentStatus
+	registry.OnDone = func(status PaymentStatus) { done = append(done, status) }
+
+	payment := registry.Start("req-1", KindSync, "base_seed")
+	payment.SetStage(StageSubmitting)
+	now = now.Add(3 * time.Second)
+	payment.Done()
+	payment.Done()
+	registry.Start("req-2", KindSync, "").Done()
+
+	require.Len(t, done, 2)
+	assert.Equal(t, "req-1", done[0].RequestID)
+	assert.Equal(t, float64(3), done[0].ElapsedSeconds)
+	assert.Equal(t, []StageStatus{{Stage: StageSubmitting, ElapsedSeconds: 3}}, done[0].Stages)
+	assert.Equal(t, "req-2", done[1].RequestID)
+}
@@ -0,0 +1,243 @@
+// Package jws signs response bodies with detached JSON Web Signatures (RFC 7515, EdDSA of RFC
+// 8037) so clients can prove a response was sent by the bridge server
+package jws
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+)
+
+const (
+	// AcceptHeader is sent by clients requesting a signed response, ex. `Accept-Signature: jws`
+	AcceptHeader = "Accept-Signature"
+	// AcceptValue is a value of AcceptHeader requesting a detached JWS
+	AcceptValue = "jws"
+	// SignatureHeader contains a detached compact JWS of the response body:
+	// `<protected header>..<signature>`
+	SignatureHeader = "X-JWS-Signature"
+	// Algorithm is the JWS algorithm of signatures
+	Algorithm = "EdDSA"
+	// KeysPath is the path of the endpoint serving verification keys
+	KeysPath = "/.well-known/jwks.json"
+)
+
+var encoding = base64.RawURLEncoding
+
+// header is a protected JWS header
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// JWK is a public key in JWK Set returned by KeysPath endpoint
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKSet is returned by KeysPath endpoint
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Addresses returns public keys of Ed25519 JWKs as account IDs by their key IDs, a map accepted by
+// Verify and Transport
+func (set JWKSet) Addresses() (map[string]string, error) {
+	addresses := make(map[string]string, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.KeyType != "OKP" || key.Curve != "Ed25519" {
+			continue
+		}
+		raw, err := encoding.DecodeString(key.X)
+		if err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("key %s is not a valid Ed25519 key", key.KeyID)
+		}
+		address, err := strkey.Encode(strkey.VersionByteAccountID, raw)
+		if err != nil {
+			return nil, err
+		}
+		addresses[key.KeyID] = address
+	}
+	return addresses, nil
+}
+
+// Signer signs responses with the current key. Previous keys are only served for verification of
+// responses signed before a rotation.
+type Signer struct {
+	key  *keypair.Full
+	id   string
+	keys JWKSet
+}
+
+// NewSigner creates a new Signer signing with seed identified by keyID. previous are public keys
+// (account IDs) of rotated keys by their key IDs.
+func NewSigner(seed, keyID string, previous map[string]string) (*Signer, error) {
+	if keyID == "" {
+		return nil, errors.New("key ID is required")
+	}
+	parsed, err := keypair.Parse(seed)
+	if err != nil {
+		return nil, errors.New("invalid signing seed")
+	}
+	full, ok := parsed.(*keypair.Full)
+	if !ok {
+		return nil, errors.New("invalid signing seed")
+	}
+
+	signer := &Signer{key: full, id: keyID}
+	signer.keys.Keys = append(signer.keys.Keys, newJWK(keyID, full.Address()))
+
+	ids := make([]string, 0, len(previous))
+	for id := range previous {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if id == keyID {
+			return nil, fmt.Errorf("previous key %s has the ID of the signing key", id)
+		}
+		if _, err := strkey.Decode(strkey.VersionByteAccountID, previous[id]); err != nil {
+			return nil, fmt.Errorf("previous key %s is not a valid public key", id)
+		}
+		signer.keys.Keys = append(signer.keys.Keys, newJWK(id, previous[id]))
+	}
+	return signer, nil
+}
+
+// newJWK returns a JWK of a valid Stellar account ID
+func newJWK(keyID, address string) JWK {
+	raw := strkey.MustDecode(strkey.VersionByteAccountID, address)
+	return JWK{
+		KeyType:   "OKP",
+		Curve:     "Ed25519",
+		X:         encoding.EncodeToString(raw),
+		KeyID:     keyID,
+		Use:       "sig",
+		Algorithm: Algorithm,
+	}
+}
+
+// Sign returns a detached compact JWS of payload
+func (s *Signer) Sign(payload []byte) (string, error) {
+	protected, err := json.Marshal(header{Algorithm: Algorithm, KeyID: s.id})
+	if err != nil {
+		return "", err
+	}
+	encodedHeader := encoding.EncodeToString(protected)
+	signature, err := s.key.Sign([]byte(encodedHeader + "." + encoding.EncodeToString(payload)))
+	if err != nil {
+		return "", err
+	}
+	return encodedHeader + ".." + encoding.EncodeToString(signature), nil
+}
+
+// Keys returns keys verifying signatures, the signing key first
+func (s *Signer) Keys() JWKSet {
+	return s.keys
+}
+
+// KeysHandler implements KeysPath endpoint
+func (s *Signer) KeysHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	json.NewEncoder(w).Encode(s.keys)
+}
+
+// Middleware signs responses of requests with `Accept-Signature: jws`, other responses are
+// written unchanged without buffering
+func (s *Signer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", AcceptHeader)
+		if !Accepts(r.Header) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writer := &signingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(writer, r)
+
+		signature, err := s.Sign(writer.body.Bytes())
+		if err == nil {
+			w.Header().Set(SignatureHeader, signature)
+		}
+		w.WriteHeader(writer.status)
+		w.Write(writer.body.Bytes())
+	})
+}
+
+// Accepts returns true when request headers ask for a signed response
+func Accepts(h http.Header) bool {
+	for _, value := range strings.Split(h.Get(AcceptHeader), ",") {
+		if strings.EqualFold(strings.TrimSpace(value), AcceptValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// signingWriter buffers a response until it's signed
+type signingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *signingWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *signingWriter) Write(p []byte) (int, error) {
+	return w.body.Write(p)
+}
+
+// Verify verifies a detached compact JWS of payload with keys by their IDs and returns the ID of
+// the signing key
+func Verify(payload []byte, signature string, keys map[string]string) (string, error) {
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return "", errors.New("signature is not a detached compact JWS")
+	}
+
+	rawHeader, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return "", errors.New("invalid JWS header encoding")
+	}
+	var protected header
+	err = json.Unmarshal(rawHeader, &protected)
+	if err != nil {
+		return "", errors.New("invalid JWS header")
+	}
+	if protected.Algorithm != Algorithm {
+		return "", fmt.Errorf("unsupported JWS algorithm %s", protected.Algorithm)
+	}
+	address, ok := keys[protected.KeyID]
+	if !ok {
+		return "", fmt.Errorf("unknown key ID %s", protected.KeyID)
+	}
+
+	rawSignature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("invalid JWS signature encoding")
+	}
+	key, err := keypair.Parse(address)
+	if err != nil {
+		return "", fmt.Errorf("key %s is not a valid public key", protected.KeyID)
+	}
+	err = key.Verify([]byte(parts[0]+"."+encoding.EncodeToString(payload)), rawSignature)
+	if err != nil {
+		return "", errors.New("invalid signature")
+	}
+	return protected.KeyID, nil
+}
@@ -0,0 +1,161 @@
+package jws
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	seed         = "SCI5S4BQFJ4GMNIWMT5MHFYSCCQJ7CNEGVTAEHUA67G363XPAORV6IUX"
+	previousSeed = "SAJUWB773ZLKLBWQ455NBPABCELGUT77VTAGOCU32FB5XAKPWKDN26TA"
+)
+
+func newTestSigner(t *testing.T) (*Signer, map[string]string) {
+	previous := map[string]string{"2025-01": keypair.MustParse(previousSeed).Address()}
+	signer, err := NewSigner(seed, "2026-01", previous)
+	require.NoError(t, err)
+
+	keys, err := signer.Keys().Addresses()
+	require.NoError(t, err)
+	return signer, keys
+}
+
+func TestSigner(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	assert.Equal(t, map[string]string{
+		"2026-01": keypair.MustParse(seed).Address(),
+		"2025-01": keypair.MustParse(previousSeed).Address(),
+	}, keys)
+
+	payload := []byte(`{"hash":"ab12"}`)
+	signature, err := signer.Sign(payload)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(strings.Split(signature, ".")))
+	assert.True(t, strings.HasPrefix(signature, "eyJhbGciOiJFZERTQSIsImtpZCI6IjIwMjYtMDEifQ.."))
+
+	kid, err := Verify(payload, signature, keys)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01", kid)
+
+	_, err = Verify([]byte(`{"hash":"ab13"}`), signature, keys)
+	assert.EqualError(t, err, "invalid signature")
+
+	_, err = Verify(payload, signature, map[string]string{"2025-01": keys["2025-01"]})
+	assert.EqualError(t, err, "unknown key ID 2026-01")
+
+	// Responses signed before a rotation are verified with the previous key
+	previous, err := NewSigner(previousSeed, "2025-01", nil)
+	require.NoError(t, err)
+	signature, err = previous.Sign(payload)
+	require.NoError(t, err)
+	kid, err = Verify(payload, signature, keys)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01", kid)
+
+	_, err = Verify(payload, "abc.def.ghi", keys)
+	assert.EqualError(t, err, "signature is not a detached compact JWS")
+
+	_, err = NewSigner(seed, "", nil)
+	assert.EqualError(t, err, "key ID is required")
+	_, err = NewSigner(keypair.MustParse(seed).Address(), "2026-01", nil)
+	assert.EqualError(t, err, "invalid signing seed")
+	_, err = NewSigner(seed, "2026-01", map[string]string{"2026-01": keys["2025-01"]})
+	assert.EqualError(t, err, "previous key 2026-01 has the ID of the signing key")
+	_, err = NewSigner(seed, "2026-01", map[string]string{"2025-01": previousSeed})
+	assert.EqualError(t, err, "previous key 2025-01 is not a valid public key")
+}
+
+func TestMiddleware(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	handler := signer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"invalid_parameter"}`))
+	}))
+
+	t.Run("unsigned", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/payment", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"code":"invalid_parameter"}`, w.Body.String())
+		assert.Empty(t, w.Header().Get(SignatureHeader))
+		assert.Equal(t, AcceptHeader, w.Header().Get("Vary"))
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/payment", nil)
+		r.Header.Set(AcceptHeader, "gzip, JWS")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, `{"code":"invalid_parameter"}`, w.Body.String())
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		kid, err := Verify(w.Body.Bytes(), w.Header().Get(SignatureHeader), keys)
+		require.NoError(t, err)
+		assert.Equal(t, "2026-01", kid)
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		signer.KeysHandler(w, httptest.NewRequest("GET", KeysPath, nil))
+		var set JWKSet
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+		require.Len(t, set.Keys, 2)
+		assert.Equal(t, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         set.Keys[0].X,
+			KeyID:     "2026-01",
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		}, set.Keys[0])
+		assert.Equal(t, "2025-01", set.Keys[1].KeyID)
+	})
+}
+
+func TestTransport(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	body := `{"hash":"ab12"}`
+	server := httptest.NewServer(signer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Keys: keys, Required: true}}
+	response, err := client.Get(server.URL)
+	require.NoError(t, err)
+	received, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(received))
+
+	tampered := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, _ := signer.Sign([]byte(body))
+		w.Header().Set(SignatureHeader, signature)
+		w.Write([]byte(`{"hash":"ab13"}`))
+	}))
+	defer tampered.Close()
+
+	_, err = client.Get(tampered.URL)
+	assert.Error(t, err)
+
+	unsigned := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer unsigned.Close()
+
+	_, err = client.Get(unsigned.URL)
+	assert.Error(t, err)
+
+	response, err = (&http.Client{Transport: &Transport{Keys: keys}}).Get(unsigned.URL)
+	require.NoError(t, err)
+	response.Body.Close()
+}
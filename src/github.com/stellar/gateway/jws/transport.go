@@ -0,0 +1,64 @@
+package jws
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Transport is a client http.RoundTripper requesting signed responses from the bridge server and
+// verifying signatures of responses containing them
+type Transport struct {
+	// Base sends requests, http.DefaultTransport when nil
+	Base http.RoundTripper
+	// Keys are public keys (account IDs) verifying signatures by their key IDs
+	Keys map[string]string
+	// Required rejects responses without a signature
+	Required bool
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// RoundTrip must not modify the request
+	signedRequest := new(http.Request)
+	*signedRequest = *request
+	signedRequest.Header = make(http.Header, len(request.Header)+1)
+	for name, values := range request.Header {
+		signedRequest.Header[name] = values
+	}
+	signedRequest.Header.Set(AcceptHeader, AcceptValue)
+
+	response, err := base.RoundTrip(signedRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	signature := response.Header.Get(SignatureHeader)
+	if signature == "" {
+		if t.Required {
+			response.Body.Close()
+			return nil, fmt.Errorf("response of %s has no signature", request.URL)
+		}
+		return response, nil
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = Verify(body, signature, t.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("response of %s: %s", request.URL, err)
+	}
+
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return response, nil
+}
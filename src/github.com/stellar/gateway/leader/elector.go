@@ -0,0 +1,213 @@
+// Package leader elects a single replica running singleton components (ex. the payment listener)
+// using a lease kept in the DB
+package leader
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/utc"
+)
+
+// Roles of a replica
+const (
+	// RoleLeader replica runs singleton components
+	RoleLeader = "leader"
+	// RoleStandby replica only handles HTTP requests and takes over when the leader's lease expires
+	RoleStandby = "standby"
+)
+
+// Store keeps leases. A lease is held by a single holder until it expires, expiry is checked
+// using the store's clock.
+type Store interface {
+	// AcquireLease acquires a lease or renews it when it's already held by holder. It returns
+	// false when the lease is held by another holder and has not expired.
+	AcquireLease(name, holder string, ttl time.Duration) (bool, error)
+}
+
+// Status is returned by /status endpoint
+type Status struct {
+	Enabled bool   `json:"enabled"`
+	Replica string `json:"replica"`
+	Role    string `json:"role"`
+	// LeaderUntil is a time the replica steps down unless the lease is renewed before
+	LeaderUntil *utc.Time `json:"leader_until,omitempty"`
+	// LastError is an error of the last renewal
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Elector renews a lease every ttl/3. The replica considers itself the leader only until
+// a time of the start of the last successful renewal plus 4/5 of ttl, which is before the lease
+// expires in the store. When the store is unreachable the leader steps down before a standby
+// replica can acquire the expired lease so two replicas are never leaders at the same time.
+// A standby replica takes over within 4/3 of ttl after the leader stops renewing.
+type Elector struct {
+	store  Store
+	name   string
+	holder string
+	ttl    time.Duration
+	now    func() time.Time
+	log    *logrus.Entry
+
+	// Version of the replica, the leader hands the lease off only to replicas of other versions
+	Version string
+	// HandoffTimeout is how long a replica starting as a standby waits for the leader to hand the
+	// lease off before it takes the lease over. Handoffs are not requested when it's 0 or the
+	// store is not a HandoffStore.
+	HandoffTimeout time.Duration
+	// Hooks are called when the replica steps down for a handoff and when it becomes the leader,
+	// they must be set before Run
+	Hooks Hooks
+	// Events journals handoffs, optional
+	Events events.RecorderInterface
+
+	handoffStore HandoffStore
+	stop         chan struct{}
+	once         sync.Once
+	mutex        sync.Mutex
+	leaderUntil  time.Time
+	lastError    error
+	// handoffDeadline is a time the requested handoff is taken over, zero when this replica
+	// does not wait for a handoff
+	handoffDeadline time.Time
+	// notBefore delays leadership after a takeover until the previous leader has stepped down
+	notBefore time.Time
+}
+
+// NewElector creates a new Elector of a lease name held as holder. Elector with nil store is
+// always the leader, it's used when leader election is disabled.
+func NewElector(store Store, name, holder string, ttl time.Duration, now func() time.Time) *Elector {
+	handoffStore, _ := store.(HandoffStore)
+	return &Elector{
+		store:        store,
+		name:         name,
+		holder:       holder,
+		ttl:          ttl,
+		now:          now,
+		stop:         make(chan struct{}),
+		handoffStore: handoffStore,
+		log: logrus.WithFields(logrus.Fields{
+			"service": "LeaderElector",
+			"lease":   name,
+			"replica": holder,
+		}),
+	}
+}
+
+// Run renews the lease in the background
+func (e *Elector) Run() {
+	if e.store == nil {
+		return
+	}
+
+	e.Renew()
+	e.requestHandoff()
+	go func() {
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.Renew()
+				e.handoff()
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops renewing the lease, the replica steps down before the lease expires and another
+// replica takes over
+func (e *Elector) Stop() {
+	e.once.Do(func() { close(e.stop) })
+}
+
+// Renew acquires or renews the lease once. Errors do not end leadership acquired before, it
+// ends before the lease expires in the store. Acquired hooks are called before the replica
+// becomes the leader.
+func (e *Elector) Renew() {
+	start := e.now()
+	acquired, err := e.store.AcquireLease(e.name, e.holder, e.ttl)
+
+	e.mutex.Lock()
+	wasLeader := start.Before(e.leaderUntil)
+	leaderUntil := e.leaderUntil
+	if err == nil {
+		leaderUntil = time.Time{}
+		// The previous leader of a takeover can still consider itself the leader
+		if acquired && !start.Before(e.notBefore) {
+			leaderUntil = start.Add(e.ttl - e.ttl/5)
+		}
+	}
+	becomesLeader := !wasLeader && e.now().Before(leaderUntil)
+	e.mutex.Unlock()
+
+	if becomesLeader {
+		for _, acquiredHook := range e.Hooks.Acquired {
+			acquiredHook()
+		}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.lastError = err
+	e.leaderUntil = leaderUntil
+	if err != nil {
+		e.log.WithFields(logrus.Fields{"err": err}).Error("Error renewing leader lease")
+	}
+
+	isLeader := e.now().Before(e.leaderUntil)
+	if isLeader && !wasLeader {
+		e.log.Info("Became the leader")
+	} else if !isLeader && wasLeader {
+		e.log.Warn("Stepped down, no longer the leader")
+	}
+}
+
+// IsLeader returns true when the replica should run singleton components
+func (e *Elector) IsLeader() bool {
+	if e.store == nil {
+		return true
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.now().Before(e.leaderUntil)
+}
+
+// WaitLeader blocks until the replica is the leader
+func (e *Elector) WaitLeader() {
+	for !e.IsLeader() {
+		time.Sleep(time.Second)
+	}
+}
+
+// Status returns the current role of the replica
+func (e *Elector) Status() Status {
+	status := Status{
+		Enabled: e.store != nil,
+		Replica: e.holder,
+		Role:    RoleStandby,
+	}
+	if e.IsLeader() {
+		status.Role = RoleLeader
+	}
+	if e.store == nil {
+		return status
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if status.Role == RoleLeader {
+		leaderUntil := utc.New(e.leaderUntil)
+		status.LeaderUntil = &leaderUntil
+	}
+	if e.lastError != nil {
+		status.LastError = e.lastError.Error()
+	}
+	return status
+}
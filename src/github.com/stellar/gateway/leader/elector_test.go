@@ -0,0 +1,168 @@
+package leader
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type lease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// memoryStore keeps leases like the DB drivers do, unreachable holders get an error
+type memoryStore struct {
+	now         *time.Time
+	leases      map[string]lease
+	unreachable map[string]bool
+	// delay is added to the clock before a lease is written, like a slow query
+	delay time.Duration
+}
+
+func newMemoryStore(now *time.Time) *memoryStore {
+	return &memoryStore{now: now, leases: make(map[string]lease), unreachable: make(map[string]bool)}
+}
+
+func (s *memoryStore) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	if s.unreachable[holder] {
+		return false, errors.New("dial tcp: i/o timeout")
+	}
+
+	*s.now = s.now.Add(s.delay)
+	current, ok := s.leases[name]
+	if ok && current.holder != holder && !current.expiresAt.Before(*s.now) {
+		return false, nil
+	}
+	s.leases[name] = lease{holder: holder, expiresAt: s.now.Add(ttl)}
+	return true, nil
+}
+
+func TestElector(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := newMemoryStore(&now)
+	ttl := 30 * time.Second
+
+	a := NewElector(store, "listener", "replica-a", ttl, clock)
+	b := NewElector(store, "listener", "replica-b", ttl, clock)
+
+	a.Renew()
+	b.Renew()
+	assert.True(t, a.IsLeader())
+	assert.False(t, b.IsLeader())
+	assert.Equal(t, RoleLeader, a.Status().Role)
+	assert.Equal(t, Status{Enabled: true, Replica: "replica-b", Role: RoleStandby}, b.Status())
+
+	// Renewals keep the leader
+	for i := 0; i < 5; i++ {
+		now = now.Add(ttl / 3)
+		a.Renew()
+		b.Renew()
+		assert.True(t, a.IsLeader())
+		assert.False(t, b.IsLeader())
+	}
+}
+
+func TestElectorStoreUnreachable(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := newMemoryStore(&now)
+	ttl := 30 * time.Second
+
+	a := NewElector(store, "listener", "replica-a", ttl, clock)
+	b := NewElector(store, "listener", "replica-b", ttl, clock)
+	a.Renew()
+	b.Renew()
+	require.True(t, a.IsLeader())
+
+	// The leader can't reach the store, a standby replica still can
+	store.unreachable["replica-a"] = true
+	renewAt := now.Add(ttl / 3)
+	var tookOverAfter time.Duration
+	start := now
+	for elapsed := time.Duration(0); elapsed <= 2*ttl; elapsed += time.Second {
+		now = start.Add(elapsed)
+		if !now.Before(renewAt) {
+			a.Renew()
+			b.Renew()
+			renewAt = renewAt.Add(ttl / 3)
+		}
+
+		require.False(t, a.IsLeader() && b.IsLeader(), "two leaders after %s", elapsed)
+		if b.IsLeader() && tookOverAfter == 0 {
+			tookOverAfter = elapsed
+		}
+	}
+
+	assert.False(t, a.IsLeader())
+	assert.Equal(t, "dial tcp: i/o timeout", a.Status().LastError)
+	require.True(t, b.IsLeader())
+	assert.True(t, tookOverAfter <= ttl+ttl/3, "took over after %s", tookOverAfter)
+
+	// Old leader is a standby when the store is reachable again
+	store.unreachable["replica-a"] = false
+	a.Renew()
+	assert.False(t, a.IsLeader())
+	assert.Equal(t, Status{Enabled: true, Replica: "replica-a", Role: RoleStandby}, a.Status())
+}
+
+func TestElectorSlowRenewal(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	store := newMemoryStore(&now)
+	ttl := 30 * time.Second
+
+	a := NewElector(store, "listener", "replica-a", ttl, func() time.Time { return now })
+	store.delay = 10 * time.Second
+	a.Renew()
+
+	// Leadership is counted from the start of the renewal, not from its end
+	require.True(t, a.IsLeader())
+	assert.Equal(t, now.Add(-10*time.Second).Add(24*time.Second).Unix(), a.Status().LeaderUntil.Unix())
+	now = now.Add(14 * time.Second)
+	assert.False(t, a.IsLeader())
+}
+
+func TestElectorDisabled(t *testing.T) {
+	e := NewElector(nil, "listener", "replica-a", 0, time.Now)
+	e.Run()
+	assert.True(t, e.IsLeader())
+	assert.Equal(t, Status{Replica: "replica-a", Role: RoleLeader}, e.Status())
+}
+
+// countingStore counts renewals of a lease that is always acquired
+type countingStore struct {
+	mutex    sync.Mutex
+	renewals int
+}
+
+func (s *countingStore) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.renewals++
+	return true, nil
+}
+
+func (s *countingStore) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.renewals
+}
+
+func TestElectorStop(t *testing.T) {
+	store := &countingStore{}
+	e := NewElector(store, "listener", "replica-a", 300*time.Millisecond, time.Now)
+	e.Run()
+	assert.Equal(t, 1, store.count())
+
+	e.Stop()
+	e.Stop()
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, 1, store.count())
+	// Leader steps down when the last renewal ends
+	assert.False(t, e.IsLeader())
+}
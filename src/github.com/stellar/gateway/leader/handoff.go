@@ -0,0 +1,191 @@
+package leader
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
+)
+
+// HandoffStore is a Store handing leases off between replicas of different versions during
+// deploys, so the old and the new version never run singleton components at the same time.
+//
+// A replica starting as a standby requests a handoff. The leader answers at its next renewal: it
+// declines requests of replicas running its own version, otherwise it steps down, waits for Drain
+// hooks and transfers the lease to the requesting replica. A replica that is not answered within
+// its HandoffTimeout (ex. the leader runs a version without handoffs) takes the lease over and
+// becomes the leader one ttl later, when the previous leader has stepped down.
+type HandoffStore interface {
+	Store
+	// TransferLease gives a lease held by `from` to `to`, it returns false when the lease is not
+	// held by `from`
+	TransferLease(name, from, to string, ttl time.Duration) (bool, error)
+	// TakeOverLease gives a lease to holder regardless of its current holder
+	TakeOverLease(name, holder string, ttl time.Duration) error
+	// RequestHandoff replaces the handoff request of a lease
+	RequestHandoff(name, holder, version string) error
+	// GetHandoff returns the handoff request of a lease, nil when there is none
+	GetHandoff(name string) (*entities.LeaderHandoff, error)
+	// SetHandoffStatus updates the status of a handoff request of holder
+	SetHandoffStatus(name, holder, status string) error
+}
+
+// Hooks are called around changes of the leader
+type Hooks struct {
+	// Drain hooks are called when the leader has stepped down for a handoff, before the lease is
+	// transferred. They return when singleton components finished their current work (ex. a
+	// payment being processed).
+	Drain []func()
+	// Acquired hooks are called before the replica becomes the leader, ex. to drop sequence
+	// numbers cached while another replica was the leader
+	Acquired []func()
+}
+
+// handoffEvent is a payload of handoff events
+type handoffEvent struct {
+	From    string `json:"from,omitempty"`
+	To      string `json:"to"`
+	Version string `json:"version"`
+}
+
+// handoffsEnabled returns true when the replica requests and answers handoffs
+func (e *Elector) handoffsEnabled() bool {
+	return e.handoffStore != nil && e.HandoffTimeout > 0
+}
+
+// requestHandoff requests a handoff when the replica starts as a standby
+func (e *Elector) requestHandoff() {
+	if !e.handoffsEnabled() || e.IsLeader() {
+		return
+	}
+
+	err := e.handoffStore.RequestHandoff(e.name, e.holder, e.Version)
+	if err != nil {
+		e.log.WithFields(logrus.Fields{"err": err}).Error("Error requesting leader handoff")
+		return
+	}
+
+	e.mutex.Lock()
+	e.handoffDeadline = e.now().Add(e.HandoffTimeout)
+	e.mutex.Unlock()
+	e.log.WithFields(logrus.Fields{"version": e.Version}).Info("Requested leader handoff")
+	e.record(events.LeaderHandoffRequested, handoffEvent{To: e.holder, Version: e.Version})
+}
+
+// handoff waits for the answer of a requested handoff, or answers handoff requests of other
+// replicas on the leader. It's called after every renewal.
+func (e *Elector) handoff() {
+	if !e.handoffsEnabled() {
+		return
+	}
+
+	e.mutex.Lock()
+	deadline := e.handoffDeadline
+	e.mutex.Unlock()
+
+	if !deadline.IsZero() {
+		e.awaitHandoff(deadline)
+	} else if e.IsLeader() {
+		e.answerHandoff()
+	}
+}
+
+// awaitHandoff checks the request of this replica, the lease is taken over after deadline
+func (e *Elector) awaitHandoff(deadline time.Time) {
+	if e.IsLeader() {
+		e.log.Info("Lease handed off by the previous leader")
+		e.endHandoff(time.Time{})
+		return
+	}
+
+	request, err := e.handoffStore.GetHandoff(e.name)
+	if err != nil {
+		e.log.WithFields(logrus.Fields{"err": err}).Error("Error loading leader handoff")
+		return
+	}
+	if request == nil || request.Holder != e.holder || request.Status == entities.LeaderHandoffStatusDeclined {
+		e.log.Info("Leader handoff declined or replaced, staying a standby")
+		e.endHandoff(time.Time{})
+		return
+	}
+	// Released lease is acquired by the next renewal
+	if request.Status != entities.LeaderHandoffStatusRequested || e.now().Before(deadline) {
+		return
+	}
+
+	err = e.handoffStore.TakeOverLease(e.name, e.holder, e.ttl)
+	if err != nil {
+		e.log.WithFields(logrus.Fields{"err": err}).Error("Error taking leader lease over")
+		return
+	}
+	e.setHandoffStatus(e.holder, entities.LeaderHandoffStatusTakenOver)
+	e.endHandoff(e.now().Add(e.ttl))
+	e.log.Warn("Leader did not answer the handoff in time, took the lease over")
+	e.record(events.LeaderHandoffTakenOver, handoffEvent{To: e.holder, Version: e.Version})
+}
+
+// endHandoff stops waiting for a handoff, leadership starts at notBefore at the earliest
+func (e *Elector) endHandoff(notBefore time.Time) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.handoffDeadline = time.Time{}
+	e.notBefore = notBefore
+}
+
+// answerHandoff declines or releases a requested handoff of another replica
+func (e *Elector) answerHandoff() {
+	request, err := e.handoffStore.GetHandoff(e.name)
+	if err != nil {
+		e.log.WithFields(logrus.Fields{"err": err}).Error("Error loading leader handoff")
+		return
+	}
+	if request == nil || request.Status != entities.LeaderHandoffStatusRequested || request.Holder == e.holder {
+		return
+	}
+
+	log := e.log.WithFields(logrus.Fields{"to": request.Holder, "version": request.Version})
+	event := handoffEvent{From: e.holder, To: request.Holder, Version: request.Version}
+	if request.Version == e.Version {
+		e.setHandoffStatus(request.Holder, entities.LeaderHandoffStatusDeclined)
+		log.Info("Declined leader handoff to a replica of the same version")
+		e.record(events.LeaderHandoffDeclined, event)
+		return
+	}
+
+	e.mutex.Lock()
+	e.leaderUntil = time.Time{}
+	e.mutex.Unlock()
+	log.Info("Stepped down for leader handoff, draining")
+	for _, drain := range e.Hooks.Drain {
+		drain()
+	}
+
+	// The lease is acquired again by the next renewal when it's not transferred, the request is
+	// answered again then
+	transferred, err := e.handoffStore.TransferLease(e.name, e.holder, request.Holder, e.ttl)
+	if err != nil {
+		log.WithFields(logrus.Fields{"err": err}).Error("Error transferring leader lease")
+		return
+	}
+	if !transferred {
+		log.Warn("Leader lease was taken over before it was handed off")
+		return
+	}
+	e.setHandoffStatus(request.Holder, entities.LeaderHandoffStatusReleased)
+	log.Info("Handed leader lease off")
+	e.record(events.LeaderHandoffReleased, event)
+}
+
+func (e *Elector) setHandoffStatus(holder, status string) {
+	err := e.handoffStore.SetHandoffStatus(e.name, holder, status)
+	if err != nil {
+		e.log.WithFields(logrus.Fields{"err": err, "status": status}).Error("Error updating leader handoff")
+	}
+}
+
+func (e *Elector) record(eventType string, payload handoffEvent) {
+	if e.Events != nil {
+		e.Events.Record(eventType, e.name, payload)
+	}
+}
@@ -0,0 +1,176 @@
+package leader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handoffStore keeps handoff requests of a memoryStore
+type handoffStore struct {
+	*memoryStore
+	handoffs map[string]entities.LeaderHandoff
+}
+
+func newHandoffStore(now *time.Time) *handoffStore {
+	return &handoffStore{memoryStore: newMemoryStore(now), handoffs: make(map[string]entities.LeaderHandoff)}
+}
+
+func (s *handoffStore) TransferLease(name, from, to string, ttl time.Duration) (bool, error) {
+	if s.leases[name].holder != from {
+		return false, nil
+	}
+	s.leases[name] = lease{holder: to, expiresAt: s.now.Add(ttl)}
+	return true, nil
+}
+
+func (s *handoffStore) TakeOverLease(name, holder string, ttl time.Duration) error {
+	s.leases[name] = lease{holder: holder, expiresAt: s.now.Add(ttl)}
+	return nil
+}
+
+func (s *handoffStore) RequestHandoff(name, holder, version string) error {
+	s.handoffs[name] = entities.LeaderHandoff{Name: name, Holder: holder, Version: version, Status: entities.LeaderHandoffStatusRequested}
+	return nil
+}
+
+func (s *handoffStore) GetHandoff(name string) (*entities.LeaderHandoff, error) {
+	handoff, ok := s.handoffs[name]
+	if !ok {
+		return nil, nil
+	}
+	return &handoff, nil
+}
+
+func (s *handoffStore) SetHandoffStatus(name, holder, status string) error {
+	if handoff := s.handoffs[name]; handoff.Holder == holder {
+		handoff.Status = status
+		s.handoffs[name] = handoff
+	}
+	return nil
+}
+
+// eventTypes records types of events
+type eventTypes []string
+
+func (r *eventTypes) Record(eventType, subject string, payload interface{}) {
+	*r = append(*r, eventType)
+}
+
+func TestElectorHandoff(t *testing.T) {
+	ttl := 30 * time.Second
+	setup := func(oldVersion, newVersion string) (*time.Time, *Elector, *Elector, *eventTypes, *handoffStore) {
+		now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+		clock := func() time.Time { return now }
+		store := newHandoffStore(&now)
+		recorded := &eventTypes{}
+
+		old := NewElector(store, "listener", "replica-a", ttl, clock)
+		old.Version = oldVersion
+		old.HandoffTimeout = time.Minute
+		old.Events = recorded
+		old.Renew()
+		require.True(t, old.IsLeader())
+
+		started := NewElector(store, "listener", "replica-b", ttl, clock)
+		started.Version = newVersion
+		started.HandoffTimeout = time.Minute
+		started.Events = recorded
+		started.Renew()
+		started.requestHandoff()
+		require.False(t, started.IsLeader())
+		return &now, old, started, recorded, store
+	}
+
+	t.Run("leader of other version drains and hands the lease off", func(t *testing.T) {
+		now, old, started, recorded, store := setup("0.0.11", "0.0.12")
+		var drained, acquired bool
+		old.Hooks.Drain = []func(){func() {
+			drained = true
+			assert.False(t, old.IsLeader(), "leader steps down before draining")
+			assert.False(t, started.IsLeader())
+		}}
+		started.Hooks.Acquired = []func(){func() {
+			acquired = true
+			assert.False(t, started.IsLeader(), "hooks are called before leadership")
+		}}
+
+		*now = now.Add(ttl / 3)
+		old.Renew()
+		old.handoff()
+		assert.True(t, drained)
+		assert.False(t, old.IsLeader())
+		assert.Equal(t, entities.LeaderHandoffStatusReleased, store.handoffs["listener"].Status)
+
+		started.Renew()
+		started.handoff()
+		assert.True(t, acquired)
+		assert.True(t, started.IsLeader())
+		old.Renew()
+		assert.False(t, old.IsLeader())
+		assert.Equal(t, &eventTypes{events.LeaderHandoffRequested, events.LeaderHandoffReleased}, recorded)
+	})
+
+	t.Run("handoff to the same version is declined", func(t *testing.T) {
+		now, old, started, recorded, store := setup("0.0.11", "0.0.11")
+		old.Hooks.Drain = []func(){func() { t.Error("leader drained") }}
+
+		old.handoff()
+		assert.True(t, old.IsLeader())
+		assert.Equal(t, entities.LeaderHandoffStatusDeclined, store.handoffs["listener"].Status)
+
+		// Declined replica does not take the lease over
+		started.handoff()
+		*now = now.Add(2 * time.Minute)
+		old.Renew()
+		started.Renew()
+		started.handoff()
+		assert.True(t, old.IsLeader())
+		assert.False(t, started.IsLeader())
+		assert.Equal(t, &eventTypes{events.LeaderHandoffRequested, events.LeaderHandoffDeclined}, recorded)
+	})
+
+	t.Run("unanswered handoff is taken over", func(t *testing.T) {
+		now, old, started, recorded, store := setup("0.0.11", "0.0.12")
+		// Leader of a version without handoffs
+		old.HandoffTimeout = 0
+
+		start := *now
+		var tookOverAt time.Time
+		for elapsed := time.Duration(0); elapsed <= 3*time.Minute; elapsed += ttl / 3 {
+			*now = start.Add(elapsed)
+			old.Renew()
+			old.handoff()
+			started.Renew()
+			started.handoff()
+
+			for step := time.Duration(0); step < ttl/3; step += time.Second {
+				*now = start.Add(elapsed + step)
+				require.False(t, old.IsLeader() && started.IsLeader(), "two leaders after %s", elapsed+step)
+			}
+			if started.IsLeader() && tookOverAt.IsZero() {
+				tookOverAt = start.Add(elapsed)
+			}
+		}
+
+		assert.False(t, old.IsLeader())
+		require.True(t, started.IsLeader())
+		assert.True(t, tookOverAt.Sub(start) >= time.Minute+ttl, "took over after %s", tookOverAt.Sub(start))
+		assert.Equal(t, entities.LeaderHandoffStatusTakenOver, store.handoffs["listener"].Status)
+		assert.Equal(t, &eventTypes{events.LeaderHandoffRequested, events.LeaderHandoffTakenOver}, recorded)
+	})
+
+	t.Run("handoff is not requested by the leader", func(t *testing.T) {
+		now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+		store := newHandoffStore(&now)
+		e := NewElector(store, "listener", "replica-a", ttl, func() time.Time { return now })
+		e.HandoffTimeout = time.Minute
+		e.Renew()
+		e.requestHandoff()
+		assert.Empty(t, store.handoffs)
+	})
+}
@@ -0,0 +1,190 @@
+package listener
+
+import (
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/conversion"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/protocols"
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentListenerConversion(t *testing.T) {
+	receiving, err := keypair.Random()
+	require.NoError(t, err)
+	usd := protocols.Asset{Code: "USD", Issuer: "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"}
+
+	config := &config.Config{
+		Assets:    []config.Asset{{Code: usd.Code, Issuer: usd.Issuer}},
+		Accounts:  config.Accounts{ReceivingAccountID: receiving.Address()},
+		Callbacks: config.Callbacks{Receive: "http://receive_callback"},
+	}
+
+	// XLM is converted into USD at 5 XLM per USD
+	book := horizon.OrderBookResponse{Asks: []horizon.OrderBookLevel{{Price: "5.0000000", Amount: "1000.0000000"}}}
+
+	type setup struct {
+		listener      PaymentListener
+		horizon       *mocks.MockHorizon
+		submitter     *mocks.MockTransactionSubmitter
+		repository    *mocks.MockRepository
+		entityManager *mocks.MockEntityManager
+		client        *mocks.MockHTTPClient
+	}
+	newSetup := func() setup {
+		s := setup{
+			horizon:       new(mocks.MockHorizon),
+			submitter:     new(mocks.MockTransactionSubmitter),
+			repository:    new(mocks.MockRepository),
+			entityManager: new(mocks.MockEntityManager),
+			client:        new(mocks.MockHTTPClient),
+		}
+		volumes := new(mocks.MockVolumeAggregator)
+		volumes.On("Touch", mock.AnythingOfType("time.Time")).Return()
+
+		s.listener, err = NewPaymentListener(config, s.entityManager, s.horizon, s.repository, volumes, s.client, mocks.Now)
+		require.NoError(t, err)
+		s.listener.Converter = conversion.NewConverter(conversion.Settings{
+			Seed:        receiving.Seed(),
+			Target:      usd,
+			Assets:      []conversion.Asset{{MinAmount: xdr.Int64(10 * 1e7)}},
+			MaxSlippage: new(big.Rat),
+		}, s.horizon, s.submitter, s.repository, s.entityManager, mocks.Now)
+		return s
+	}
+
+	payment := func(amount string) horizon.PaymentResponse {
+		return horizon.PaymentResponse{
+			ID:          "1",
+			Type:        "payment",
+			PagingToken: "2",
+			From:        "GBL27BKG2JSDU6KQ5YJKCDWTVIU24VTG4PLB63SF4K2DBZS5XZMWRPVU",
+			To:          receiving.Address(),
+			AssetType:   "native",
+			Amount:      amount,
+		}
+	}
+
+	t.Run("sends a single callback of a converted payment", func(t *testing.T) {
+		s := newSetup()
+		operation := payment("50.0000000")
+
+		s.repository.On("GetReceivedPaymentByOperationID", int64(1)).Return(nil, nil).Once()
+		s.repository.On("GetConversionByOperationID", "1").Return(nil, nil).Once()
+		s.horizon.On("LoadMemo", &operation).Return(nil).Once()
+		s.horizon.On("LoadOrderBook", usd.ToBaseAsset(), b.NativeAsset()).Return(book, nil).Once()
+		s.entityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
+			Run(ensurePaymentStatus(t, operation, "Processing...")).Return(nil).Once()
+		s.entityManager.On("Persist", mock.AnythingOfType("*entities.Conversion")).Return(nil).Twice()
+		s.entityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
+			Run(ensurePaymentStatus(t, operation, "Success")).Return(nil).Once()
+
+		ledger := uint64(100)
+		s.submitter.On("SubmitTransaction", receiving.Seed(), mock.Anything, nil).
+			Return(horizon.SubmitTransactionResponse{Hash: "abcd", Ledger: &ledger}, nil).Once()
+
+		s.client.On("Do", mock.AnythingOfType("*http.Request")).Return(net.BuildHTTPResponse(200, "ok"), nil).Run(func(args mock.Arguments) {
+			req := args.Get(0).(*http.Request)
+			assert.Equal(t, "50.0000000", req.PostFormValue("amount"))
+			assert.Equal(t, "", req.PostFormValue("asset_code"))
+			assert.Equal(t, "10.0000000", req.PostFormValue("converted_amount"))
+			assert.Equal(t, "USD", req.PostFormValue("converted_asset_code"))
+			assert.Equal(t, usd.Issuer, req.PostFormValue("converted_asset_issuer"))
+			assert.Equal(t, "abcd", req.PostFormValue("conversion_transaction_hash"))
+		}).Once()
+
+		require.NoError(t, s.listener.onPayment(operation))
+		s.entityManager.AssertExpectations(t)
+		s.client.AssertExpectations(t)
+	})
+
+	t.Run("flags a failed conversion", func(t *testing.T) {
+		s := newSetup()
+		// 2000 XLM buy 400 USD at most
+		operation := payment("2000.0000001")
+
+		s.repository.On("GetReceivedPaymentByOperationID", int64(1)).Return(nil, nil).Once()
+		s.repository.On("GetConversionByOperationID", "1").Return(nil, nil).Once()
+		s.horizon.On("LoadMemo", &operation).Return(nil).Once()
+		s.horizon.On("LoadOrderBook", usd.ToBaseAsset(), b.NativeAsset()).Return(horizon.OrderBookResponse{
+			Asks: []horizon.OrderBookLevel{{Price: "5.0000000", Amount: "400.0000000"}},
+		}, nil).Once()
+		s.entityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
+			Run(ensurePaymentStatus(t, operation, "Processing...")).Return(nil).Once()
+		s.entityManager.On("Persist", mock.AnythingOfType("*entities.Conversion")).Return(nil).Twice()
+		s.entityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
+			Run(ensurePaymentStatus(t, operation, "Conversion failed: cannot estimate conversion: not enough offers to fill the amount")).Return(nil).Once()
+
+		require.NoError(t, s.listener.onPayment(operation))
+		s.entityManager.AssertExpectations(t)
+		s.client.AssertNotCalled(t, "Do", mock.Anything)
+		s.submitter.AssertNotCalled(t, "SubmitTransaction", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("reprocessing does not convert a payment again", func(t *testing.T) {
+		s := newSetup()
+		operation := payment("50.0000000")
+
+		s.repository.On("GetReceivedPaymentByOperationID", int64(1)).
+			Return(&entities.ReceivedPayment{OperationID: "1", Status: "Error response from receive callback"}, nil).Once()
+		s.repository.On("GetConversionByOperationID", "1").Return(&entities.Conversion{
+			OperationID:            "1",
+			Status:                 entities.ConversionStatusSuccess,
+			DestinationAssetCode:   usd.Code,
+			DestinationAssetIssuer: usd.Issuer,
+			DestinationAmount:      "10.0000000",
+			TransactionHash:        "abcd",
+		}, nil).Once()
+		s.horizon.On("LoadMemo", &operation).Return(nil).Once()
+		s.entityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).Return(nil).Twice()
+		s.client.On("Do", mock.AnythingOfType("*http.Request")).Return(net.BuildHTTPResponse(200, "ok"), nil).Run(func(args mock.Arguments) {
+			req := args.Get(0).(*http.Request)
+			assert.Equal(t, "10.0000000", req.PostFormValue("converted_amount"))
+			assert.Equal(t, "abcd", req.PostFormValue("conversion_transaction_hash"))
+		}).Once()
+
+		require.NoError(t, s.listener.ReprocessPayment(operation, false))
+		s.client.AssertExpectations(t)
+		s.horizon.AssertNotCalled(t, "LoadOrderBook", mock.Anything, mock.Anything)
+		s.submitter.AssertNotCalled(t, "SubmitTransaction", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("skips payments that are not converted", func(t *testing.T) {
+		own := payment("10.0000000")
+		own.Type = "path_payment"
+		own.From = receiving.Address()
+		own.AssetType, own.AssetCode, own.AssetIssuer = "credit_alphanum4", usd.Code, usd.Issuer
+
+		tests := []struct {
+			operation horizon.PaymentResponse
+			status    string
+		}{
+			{own, "Conversion"},
+			{payment("9.9999999"), "Amount below auto_conversion minimum"},
+		}
+
+		for _, test := range tests {
+			s := newSetup()
+			s.repository.On("GetReceivedPaymentByOperationID", int64(1)).Return(nil, nil).Once()
+			s.entityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
+				Run(ensurePaymentStatus(t, test.operation, "Processing...")).Return(nil).Once()
+			s.entityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
+				Run(ensurePaymentStatus(t, test.operation, test.status)).Return(nil).Once()
+
+			require.NoError(t, s.listener.onPayment(test.operation))
+			s.entityManager.AssertExpectations(t)
+			s.client.AssertNotCalled(t, "Do", mock.Anything)
+		}
+	})
+}
@@ -9,21 +9,33 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"encoding/base64"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/conversion"
 	"github.com/stellar/gateway/db"
 	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
+	"github.com/stellar/gateway/external"
+	"github.com/stellar/gateway/generation"
 	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/leader"
+	"github.com/stellar/gateway/logging"
 	callback "github.com/stellar/gateway/protocols/compliance"
+	"github.com/stellar/gateway/stats"
+	"github.com/stellar/gateway/utc"
 	"github.com/stellar/go/protocols/compliance"
 	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/support/errors"
 )
 
+// streamReconnectWait is a time between reconnections of the payments stream
+const streamReconnectWait = 10 * time.Second
+
 // PaymentListener is listening for a new payments received by ReceivingAccount
 type PaymentListener struct {
 	client        HTTP
@@ -32,7 +44,28 @@ type PaymentListener struct {
 	horizon       horizon.HorizonInterface
 	log           *logrus.Entry
 	repository    db.RepositoryInterface
+	volumes       stats.VolumeAggregatorInterface
 	now           func() time.Time
+	// reregistered wakes up Listen waiting for a merged account
+	reregistered chan struct{}
+	stop         chan struct{}
+	stopOnce     *sync.Once
+	// processing is locked while a streamed payment is processed
+	processing *sync.Mutex
+	// Elector makes Listen stream payments and expire payment requests only on the leader
+	// replica, the listener always runs when nil
+	Elector *leader.Elector
+	// IssuerInfo adds issuer_name, issuer_domain and anchor_asset_status of the asset to receive
+	// callbacks, they are not sent when nil or disabled
+	IssuerInfo *external.IssuerInfoResolver
+	// Converter converts received payments of auto_conversion assets before receive callbacks
+	// are sent, assets are not converted when nil
+	Converter *conversion.Converter
+	// Events journals received payments and their processing, optional
+	Events events.RecorderInterface
+	// Generations numbers lives of merged and recreated accounts, payments do not fulfill payment
+	// requests of a previous generation of the receiving account. Disabled when nil.
+	Generations *generation.Tracker
 }
 
 // HTTP represents an http client that a payment listener can use to make HTTP
@@ -41,26 +74,30 @@ type HTTP interface {
 	Do(req *http.Request) (resp *http.Response, err error)
 }
 
-const callbackTimeout = 60 * time.Second
-
 // NewPaymentListener creates a new PaymentListener
 func NewPaymentListener(
 	config *config.Config,
 	entityManager db.EntityManagerInterface,
 	horizon horizon.HorizonInterface,
 	repository db.RepositoryInterface,
+	volumes stats.VolumeAggregatorInterface,
+	client HTTP,
 	now func() time.Time,
 ) (pl PaymentListener, err error) {
-	pl.client = &http.Client{
-		Timeout: callbackTimeout,
-	}
+	pl.client = client
 	pl.config = config
 	pl.entityManager = entityManager
 	pl.horizon = horizon
 	pl.repository = repository
+	pl.volumes = volumes
 	pl.now = now
+	pl.reregistered = make(chan struct{}, 1)
+	pl.stop = make(chan struct{})
+	pl.stopOnce = &sync.Once{}
+	pl.processing = &sync.Mutex{}
 	pl.log = logrus.WithFields(logrus.Fields{
-		"service": "PaymentListener",
+		"service":             "PaymentListener",
+		logging.CategoryField: logging.CategoryListener,
 	})
 	return
 }
@@ -69,13 +106,38 @@ func NewPaymentListener(
 func (pl *PaymentListener) Listen() (err error) {
 	accountID := pl.config.Accounts.ReceivingAccountID
 
-	_, err = pl.horizon.LoadAccount(accountID)
+	// Merged account does not exist until it's created again and reregistered
+	retired, err := pl.repository.GetRetiredAccount(accountID)
 	if err != nil {
 		return
 	}
+	if retired == nil {
+		_, err = pl.horizon.LoadAccount(accountID)
+		if err != nil {
+			return
+		}
+	}
+
+	go pl.expirePaymentRequests()
 
 	go func() {
 		for {
+			if !pl.isLeader() {
+				pl.log.Info("Standby replica, waiting for leadership")
+				pl.Elector.WaitLeader()
+			}
+
+			err := pl.waitReregistered(accountID)
+			if err != nil {
+				pl.log.WithFields(logrus.Fields{"error": err}).Error("Could not load retired account from the DB")
+				return
+			}
+			if pl.stopped() {
+				pl.log.Info("Stopped listening for new payments")
+				return
+			}
+
+			// Cursor is loaded again after a takeover, the previous leader could advance it
 			cursor, err := pl.repository.GetLastCursorValue()
 			if err != nil {
 				pl.log.WithFields(logrus.Fields{"error": err}).Error("Could not load last cursor from the DB")
@@ -101,10 +163,27 @@ func (pl *PaymentListener) Listen() (err error) {
 				cursor,
 				pl.onPayment,
 			)
+			if err == horizon.ErrStopStreaming {
+				// The account was merged when the replica is still the leader
+				if !pl.isLeader() {
+					pl.log.Warn("Stopped listening for new payments, no longer the leader")
+				}
+				continue
+			}
+			if rateLimited, ok := err.(*horizon.RateLimitedError); ok {
+				// Counted by Horizon.RateLimits, reconnecting earlier would be rejected
+				wait := streamReconnectWait
+				if rateLimited.RetryAfter > wait {
+					wait = rateLimited.RetryAfter
+				}
+				pl.log.WithFields(logrus.Fields{"wait": wait}).Warn("Streaming rate limited by Horizon")
+				pl.sleep(wait)
+				continue
+			}
 			if err != nil {
 				pl.log.Error("Error while streaming: ", err)
 				pl.log.Info("Sleeping...")
-				time.Sleep(10 * time.Second)
+				pl.sleep(streamReconnectWait)
 			}
 		}
 	}()
@@ -112,33 +191,68 @@ func (pl *PaymentListener) Listen() (err error) {
 	return
 }
 
+// Stop stops streaming payments and expiring payment requests. The stream is closed when the
+// next payment is received or Horizon disconnects, payments received after Stop are not
+// processed and are streamed again from the saved cursor when the listener starts next time.
+func (pl *PaymentListener) Stop() {
+	if pl.stopOnce != nil {
+		pl.stopOnce.Do(func() { close(pl.stop) })
+	}
+}
+
+// stopped returns true after Stop, never for a listener not created by NewPaymentListener
+func (pl *PaymentListener) stopped() bool {
+	select {
+	case <-pl.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep waits for d or until the listener is stopped
+func (pl *PaymentListener) sleep(d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-pl.stop:
+	}
+}
+
 func (pl *PaymentListener) ReprocessPayment(payment horizon.PaymentResponse, force bool) error {
-	pl.log.WithFields(logrus.Fields{"id": payment.ID}).Info("Reprocessing a payment")
+	log := pl.paymentLog(payment)
+	log.WithFields(logrus.Fields{"id": payment.ID}).Info("Reprocessing a payment")
 
 	id, err := strconv.ParseInt(payment.ID, 10, 64)
 	if err != nil {
-		pl.log.WithFields(logrus.Fields{"err": err}).Error("Error converting ID to int64")
+		log.WithFields(logrus.Fields{"err": err}).Error("Error converting ID to int64")
 		return err
 	}
 
 	existingPayment, err := pl.repository.GetReceivedPaymentByOperationID(id)
 	if err != nil {
-		pl.log.WithFields(logrus.Fields{"err": err}).Error("Error checking if receive payment exists")
+		log.WithFields(logrus.Fields{"err": err}).Error("Error checking if receive payment exists")
 		return err
 	}
 
 	if existingPayment == nil {
-		pl.log.WithFields(logrus.Fields{"id": payment.ID}).Info("Payment has not been processed yet")
+		log.WithFields(logrus.Fields{"id": payment.ID}).Info("Payment has not been processed yet")
 		return errors.New("Payment has not been processed yet")
 	}
 
 	if existingPayment.Status == "Success" && !force {
-		pl.log.WithFields(logrus.Fields{"id": payment.ID}).Info("Trying to reprocess successful transaction without force")
+		log.WithFields(logrus.Fields{"id": payment.ID}).Info("Trying to reprocess successful transaction without force")
 		return errors.New("Trying to reprocess successful transaction without force")
 	}
 
+	if existingPayment.Status == entities.ReceivedPaymentStatusImported && !force {
+		log.WithFields(logrus.Fields{"id": payment.ID}).Info("Trying to reprocess imported payment without force")
+		return errors.New("Payment was processed by the legacy server, reprocessing requires force")
+	}
+
+	previouslyProcessedAt := existingPayment.ProcessedAt
 	existingPayment.Status = "Reprocessing..."
-	existingPayment.ProcessedAt = pl.now()
+	existingPayment.ProcessedAt = utc.New(pl.now())
+	setPaymentAsset(existingPayment, payment)
 
 	err = pl.entityManager.Persist(existingPayment)
 	if err != nil {
@@ -148,70 +262,190 @@ func (pl *PaymentListener) ReprocessPayment(payment horizon.PaymentResponse, for
 	err = pl.process(payment)
 
 	if err != nil {
-		pl.log.WithFields(logrus.Fields{"err": err}).Error("Payment reprocessed with errors")
+		log.WithFields(logrus.Fields{"err": err}).Error("Payment reprocessed with errors")
 		existingPayment.Status = err.Error()
 	} else {
-		pl.log.Info("Payment successfully reprocessed")
+		log.Info("Payment successfully reprocessed")
 		existingPayment.Status = "Success"
 	}
 
-	return pl.entityManager.Persist(existingPayment)
+	err = pl.entityManager.Persist(existingPayment)
+	if err != nil {
+		return err
+	}
+	pl.recordEvent(events.PaymentProcessed, payment.ID, processedEvent{Status: existingPayment.Status, Reprocessed: true})
+
+	// Payment could have been counted in a different day before
+	pl.touchVolumes(previouslyProcessedAt)
+	pl.touchVolumes(existingPayment.ProcessedAt)
+	return nil
+}
+
+// BackfillPayment processes a historical payment loaded by a backfill the same way as payments
+// received by Listen. Payments that were already received are skipped so callbacks are not sent
+// twice. Backfilled payments don't move the listener cursor.
+func (pl *PaymentListener) BackfillPayment(payment horizon.PaymentResponse) error {
+	return pl.receive(payment, true)
+}
+
+// Drain returns when the streamed payment being processed, if any, is stored with its final
+// status. Payments streamed after the replica stepped down are not processed, the next leader
+// streams them again from the cursor in the DB.
+func (pl *PaymentListener) Drain() {
+	if pl.processing != nil {
+		pl.processing.Lock()
+		pl.processing.Unlock()
+	}
+}
+
+func (pl *PaymentListener) onPayment(payment horizon.PaymentResponse) error {
+	if pl.processing != nil {
+		pl.processing.Lock()
+		defer pl.processing.Unlock()
+	}
+	if pl.stopped() {
+		return horizon.ErrStopStreaming
+	}
+	if !pl.isLeader() {
+		// New leader streams the payment again from the cursor saved in the DB
+		return horizon.ErrStopStreaming
+	}
+	if pl.isMerged(payment) {
+		return pl.retire(payment)
+	}
+	if payment.Type == "create_account" {
+		pl.created(payment)
+	}
+	return pl.receive(payment, false)
 }
 
-func (pl *PaymentListener) onPayment(payment horizon.PaymentResponse) (err error) {
-	pl.log.WithFields(logrus.Fields{"id": payment.ID}).Info("New received payment")
+func (pl *PaymentListener) receive(payment horizon.PaymentResponse, backfill bool) (err error) {
+	log := pl.paymentLog(payment)
+	log.WithFields(logrus.Fields{"id": payment.ID, "backfill": backfill}).Info("New received payment")
 
 	id, err := strconv.ParseInt(payment.ID, 10, 64)
 	if err != nil {
-		pl.log.WithFields(logrus.Fields{"err": err}).Error("Error converting ID to int64")
+		log.WithFields(logrus.Fields{"err": err}).Error("Error converting ID to int64")
 		return err
 	}
 
 	existingPayment, err := pl.repository.GetReceivedPaymentByOperationID(id)
 	if err != nil {
-		pl.log.WithFields(logrus.Fields{"err": err}).Error("Error checking if receive payment exists")
+		log.WithFields(logrus.Fields{"err": err}).Error("Error checking if receive payment exists")
 		return err
 	}
 
 	if existingPayment != nil {
-		pl.log.WithFields(logrus.Fields{"id": payment.ID}).Info("Payment already exists")
+		log.WithFields(logrus.Fields{"id": payment.ID}).Info("Payment already exists")
 		return
 	}
 
 	dbPayment := &entities.ReceivedPayment{
 		OperationID: payment.ID,
-		ProcessedAt: pl.now(),
+		ProcessedAt: utc.New(pl.now()),
 		PagingToken: payment.PagingToken,
 		Status:      "Processing...",
+		Backfill:    backfill,
 	}
+	setPaymentAsset(dbPayment, payment)
 
 	err = pl.entityManager.Persist(dbPayment)
 	if err != nil {
 		return
 	}
+	pl.recordEvent(events.PaymentReceived, payment.ID, receivedEvent{
+		AssetCode:   dbPayment.AssetCode,
+		AssetIssuer: dbPayment.AssetIssuer,
+		Amount:      dbPayment.Amount,
+		Backfill:    backfill,
+	})
 
 	process, status := pl.shouldProcessPayment(payment)
 	if !process {
 		dbPayment.Status = status
-		pl.log.Info(status)
+		log.Info(status)
 	} else {
 		err = pl.process(payment)
 
 		if err != nil {
-			pl.log.WithFields(logrus.Fields{"err": err}).Error("Payment processed with errors")
+			log.WithFields(logrus.Fields{"err": err}).Error("Payment processed with errors")
 			dbPayment.Status = err.Error()
 		} else {
-			pl.log.Info("Payment successfully processed")
+			log.Info("Payment successfully processed")
 			dbPayment.Status = "Success"
 		}
 	}
 
-	return pl.entityManager.Persist(dbPayment)
+	err = pl.entityManager.Persist(dbPayment)
+	if err != nil {
+		return
+	}
+	pl.recordEvent(events.PaymentProcessed, payment.ID, processedEvent{Status: dbPayment.Status})
+
+	pl.touchVolumes(dbPayment.ProcessedAt)
+	return
+}
+
+// receivedEvent is a payload of PaymentReceived events
+type receivedEvent struct {
+	AssetCode   string `json:"asset_code"`
+	AssetIssuer string `json:"asset_issuer,omitempty"`
+	Amount      string `json:"amount"`
+	Backfill    bool   `json:"backfill"`
+}
+
+// processedEvent is a payload of PaymentProcessed events
+type processedEvent struct {
+	Status      string `json:"status"`
+	Reprocessed bool   `json:"reprocessed,omitempty"`
+}
+
+// recordEvent records an event of a received payment when the journal is set
+func (pl *PaymentListener) recordEvent(eventType, paymentID string, payload interface{}) {
+	if pl.Events != nil {
+		pl.Events.Record(eventType, paymentID, payload)
+	}
+}
+
+func (pl *PaymentListener) isLeader() bool {
+	return pl.Elector == nil || pl.Elector.IsLeader()
+}
+
+// paymentLog returns a logger of a single payment. Payment ID is used as a request ID so all logs
+// of a payment are sampled together.
+func (pl *PaymentListener) paymentLog(payment horizon.PaymentResponse) *logrus.Entry {
+	return pl.log.WithField(logging.RequestIDField, payment.ID)
+}
+
+func (pl *PaymentListener) touchVolumes(processedAt utc.Time) {
+	if pl.volumes != nil {
+		pl.volumes.Touch(processedAt.Time())
+	}
+}
+
+// setPaymentAsset stores asset and amount of a payment in ReceivedPayment so daily volumes can be
+// computed from the DB.
+func setPaymentAsset(dbPayment *entities.ReceivedPayment, payment horizon.PaymentResponse) {
+	if payment.Type != "payment" && payment.Type != "path_payment" {
+		return
+	}
+
+	dbPayment.AssetCode = payment.AssetCode
+	dbPayment.AssetIssuer = payment.AssetIssuer
+	if payment.AssetType == "native" {
+		dbPayment.AssetCode = "XLM"
+		dbPayment.AssetIssuer = ""
+	}
+	dbPayment.Amount = payment.Amount
 }
 
 // shouldProcessPayment returns false and text status if payment should not be processed
 // (ex. asset is different than allowed assets).
 func (pl *PaymentListener) shouldProcessPayment(payment horizon.PaymentResponse) (bool, string) {
+	if pl.isMerged(payment) {
+		return false, "Account merged"
+	}
+
 	if payment.Type != "payment" && payment.Type != "path_payment" {
 		return false, "Not a payment operation"
 	}
@@ -220,7 +454,15 @@ func (pl *PaymentListener) shouldProcessPayment(payment horizon.PaymentResponse)
 		return false, "Operation sent not received"
 	}
 
-	if !pl.isAssetAllowed(payment.AssetType, payment.AssetCode, payment.AssetIssuer) {
+	if pl.Converter.IsConversion(payment) {
+		return false, "Conversion"
+	}
+
+	if pl.Converter.Accepts(payment) && !pl.Converter.Convertible(payment) {
+		return false, "Amount below auto_conversion minimum"
+	}
+
+	if !pl.Converter.Accepts(payment) && !pl.isAssetAllowed(payment.AssetType, payment.AssetCode, payment.AssetIssuer) {
 		return false, "Asset not allowed"
 	}
 
@@ -228,12 +470,15 @@ func (pl *PaymentListener) shouldProcessPayment(payment horizon.PaymentResponse)
 }
 
 func (pl *PaymentListener) process(payment horizon.PaymentResponse) error {
+	log := pl.paymentLog(payment)
+	callbackLog := log.WithField(logging.CategoryField, logging.CategoryCallbacks)
+
 	err := pl.horizon.LoadMemo(&payment)
 	if err != nil {
 		return errors.Wrap(err, "Unable to load transaction memo")
 	}
 
-	pl.log.WithFields(logrus.Fields{"memo": payment.Memo.Value, "type": payment.Memo.Type}).Info("Loaded memo")
+	log.WithFields(logrus.Fields{"memo": payment.Memo.Value, "type": payment.Memo.Type}).Info("Loaded memo")
 
 	var receiveResponse callback.ReceiveResponse
 	var route string
@@ -243,7 +488,7 @@ func (pl *PaymentListener) process(payment horizon.PaymentResponse) error {
 		complianceRequestURL := pl.config.Compliance + "/receive"
 		complianceRequestBody := url.Values{"memo": {string(payment.Memo.Value)}}
 
-		pl.log.WithFields(logrus.Fields{"url": complianceRequestURL, "body": complianceRequestBody}).Info("Sending request to compliance server")
+		callbackLog.WithFields(logrus.Fields{"url": complianceRequestURL, "body": complianceRequestBody}).Info("Sending request to compliance server")
 		resp, err := pl.postForm(complianceRequestURL, complianceRequestBody)
 		if err != nil {
 			return errors.Wrap(err, "Error sending request to compliance server")
@@ -256,7 +501,7 @@ func (pl *PaymentListener) process(payment horizon.PaymentResponse) error {
 		}
 
 		if resp.StatusCode != 200 {
-			pl.log.WithFields(logrus.Fields{
+			callbackLog.WithFields(logrus.Fields{
 				"status": resp.StatusCode,
 				"body":   string(body),
 			}).Error("Error response from compliance server")
@@ -285,20 +530,35 @@ func (pl *PaymentListener) process(payment horizon.PaymentResponse) error {
 		route = payment.Memo.Value
 	}
 
-	resp, err := pl.postForm(
-		pl.config.Callbacks.Receive,
-		url.Values{
-			"id":           {payment.ID},
-			"from":         {payment.From},
-			"route":        {route},
-			"amount":       {payment.Amount},
-			"asset_code":   {payment.AssetCode},
-			"asset_issuer": {payment.AssetIssuer},
-			"memo_type":    {payment.Memo.Type},
-			"memo":         {payment.Memo.Value},
-			"data":         {receiveResponse.Data},
-		},
-	)
+	correlationID, err := pl.correlationID(payment)
+	if err != nil {
+		return errors.Wrap(err, "Error loading sent transaction of the payment")
+	}
+
+	values := url.Values{
+		"id":             {payment.ID},
+		"from":           {payment.From},
+		"route":          {route},
+		"amount":         {payment.Amount},
+		"asset_code":     {payment.AssetCode},
+		"asset_issuer":   {payment.AssetIssuer},
+		"memo_type":      {payment.Memo.Type},
+		"memo":           {payment.Memo.Value},
+		"data":           {receiveResponse.Data},
+		"correlation_id": {correlationID},
+	}
+	pl.addIssuerInfo(values, payment)
+
+	// Failed conversions are not retried, the payment is reprocessed after manual handling
+	if pl.Converter.Convertible(payment) {
+		converted, err := pl.Converter.Convert(payment)
+		if err != nil {
+			return errors.Wrap(err, "Conversion failed")
+		}
+		addConversion(values, converted)
+	}
+
+	resp, err := pl.postForm(pl.config.Callbacks.Receive, values)
 	if err != nil {
 		return errors.Wrap(err, "Error sending request to receive callback")
 	}
@@ -310,14 +570,71 @@ func (pl *PaymentListener) process(payment horizon.PaymentResponse) error {
 			return errors.Wrap(err, "Error reading receive callback response")
 		}
 
-		pl.log.WithFields(logrus.Fields{
+		callbackLog.WithFields(logrus.Fields{
 			"status": resp.StatusCode,
 			"body":   string(body),
 		}).Error("Error response from receive callback")
 		return errors.New("Error response from receive callback")
 	}
+	pl.recordEvent(events.CallbackDelivered, payment.ID, struct{}{})
 
-	return nil
+	return pl.fulfillPaymentRequest(payment)
+}
+
+// addIssuerInfo adds info of the issuer of a received asset to receive callback values, the
+// fields are omitted when the issuer is not resolved within issuer_info.wait_seconds
+func (pl *PaymentListener) addIssuerInfo(values url.Values, payment horizon.PaymentResponse) {
+	if pl.IssuerInfo == nil || payment.AssetType == "native" {
+		return
+	}
+
+	wait := external.DefaultIssuerInfoWait
+	if pl.config.IssuerInfo.WaitSeconds != 0 {
+		wait = time.Duration(pl.config.IssuerInfo.WaitSeconds * float64(time.Second))
+	}
+	info, ok := pl.IssuerInfo.Lookup(payment.AssetCode, payment.AssetIssuer, wait)
+	if !ok {
+		return
+	}
+
+	for name, value := range map[string]string{
+		"issuer_name":         info.Name,
+		"issuer_domain":       info.Domain,
+		"anchor_asset_status": info.AnchorAssetStatus,
+	} {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+}
+
+// addConversion adds the leg of a conversion to receive callback values, the received asset and
+// amount are sent in the fields of the received payment
+func addConversion(values url.Values, converted *entities.Conversion) {
+	// XLM has empty code like in asset_code of the received payment
+	code := converted.DestinationAssetCode
+	if code == "XLM" && converted.DestinationAssetIssuer == "" {
+		code = ""
+	}
+	values.Set("converted_amount", converted.DestinationAmount)
+	values.Set("converted_asset_code", code)
+	values.Set("converted_asset_issuer", converted.DestinationAssetIssuer)
+	values.Set("conversion_send_amount", converted.SendAmount)
+	values.Set("conversion_transaction_hash", converted.TransactionHash)
+}
+
+// correlationID returns the correlation ID of a payment sent by this server (ex. from the base
+// account), empty for payments sent by others
+func (pl *PaymentListener) correlationID(payment horizon.PaymentResponse) (string, error) {
+	if payment.TransactionHash == "" {
+		return "", nil
+	}
+
+	transaction, err := pl.repository.GetSentTransactionByHash(payment.TransactionHash)
+	if err != nil || transaction == nil {
+		return "", err
+	}
+	return transaction.CorrelationID, nil
 }
 
 func (pl *PaymentListener) isAssetAllowed(asset_type string, code string, issuer string) bool {
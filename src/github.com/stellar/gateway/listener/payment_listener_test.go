@@ -16,10 +16,13 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/stellar/gateway/bridge/config"
 	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
 	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/leader"
 	"github.com/stellar/gateway/mocks"
 	"github.com/stellar/gateway/net"
 	callback "github.com/stellar/gateway/protocols/compliance"
+	"github.com/stellar/gateway/utc"
 	"github.com/stellar/go/protocols/compliance"
 	"github.com/stellar/go/strkey"
 	"github.com/stretchr/testify/assert"
@@ -31,17 +34,39 @@ func ensurePaymentStatus(t *testing.T, operation horizon.PaymentResponse, status
 	return func(args mock.Arguments) {
 		payment := args.Get(0).(*entities.ReceivedPayment)
 		assert.Equal(t, operation.ID, payment.OperationID)
-		assert.Equal(t, mocks.PredefinedTime, payment.ProcessedAt)
+		assert.Equal(t, utc.New(mocks.PredefinedTime), payment.ProcessedAt)
 		assert.Equal(t, operation.PagingToken, payment.PagingToken)
 		assert.Equal(t, status, payment.Status)
 	}
 }
 
+// leaseHeldElsewhere is a lease store of a standby replica
+type leaseHeldElsewhere struct{}
+
+func (leaseHeldElsewhere) AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+// eventRecorder collects events recorded by the listener
+type eventRecorder struct {
+	types    []string
+	subjects []string
+	payloads []interface{}
+}
+
+func (r *eventRecorder) Record(eventType, subject string, payload interface{}) {
+	r.types = append(r.types, eventType)
+	r.subjects = append(r.subjects, subject)
+	r.payloads = append(r.payloads, payload)
+}
+
 func TestPaymentListener(t *testing.T) {
 	mockEntityManager := new(mocks.MockEntityManager)
 	mockHorizon := new(mocks.MockHorizon)
 	mockRepository := new(mocks.MockRepository)
 	mockHTTPClient := new(mocks.MockHTTPClient)
+	mockVolumeAggregator := new(mocks.MockVolumeAggregator)
+	mockVolumeAggregator.On("Touch", mock.AnythingOfType("time.Time")).Return()
 
 	config := &config.Config{
 		Assets: []config.Asset{
@@ -62,12 +87,12 @@ func TestPaymentListener(t *testing.T) {
 		mockEntityManager,
 		mockHorizon,
 		mockRepository,
+		mockVolumeAggregator,
+		mockHTTPClient,
 		mocks.Now,
 	)
 	require.NoError(t, err)
 
-	paymentListener.client = mockHTTPClient
-
 	Convey("PaymentListener", t, func() {
 		operation := horizon.PaymentResponse{
 			ID:          "1",
@@ -92,6 +117,51 @@ func TestPaymentListener(t *testing.T) {
 			})
 		})
 
+		Convey("When replica is not the leader", func() {
+			operation.Type = "payment"
+			paymentListener.Elector = leader.NewElector(leaseHeldElsewhere{}, "listener", "replica-b", 30*time.Second, time.Now)
+			paymentListener.Elector.Renew()
+			defer func() { paymentListener.Elector = nil }()
+
+			Convey("it should stop streaming without processing the payment", func() {
+				calls := len(mockRepository.Calls)
+				err := paymentListener.onPayment(operation)
+				assert.Equal(t, horizon.ErrStopStreaming, err)
+				assert.Len(t, mockRepository.Calls, calls)
+			})
+		})
+
+		Convey("When backfilled operation exists", func() {
+			operation.Type = "payment"
+			mockRepository.On("GetReceivedPaymentByOperationID", int64(1)).Return(&entities.ReceivedPayment{}, nil).Once()
+
+			Convey("it should not process it again", func() {
+				err := paymentListener.BackfillPayment(operation)
+				assert.Nil(t, err)
+				mockEntityManager.AssertExpectations(t)
+				mockHTTPClient.AssertNotCalled(t, "Do", mock.Anything)
+			})
+		})
+
+		Convey("When operation is backfilled", func() {
+			operation.Type = "create_account"
+
+			mockEntityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
+				Run(func(args mock.Arguments) {
+					payment := args.Get(0).(*entities.ReceivedPayment)
+					assert.True(t, payment.Backfill)
+				}).Return(nil).Twice()
+
+			mockRepository.On("GetReceivedPaymentByOperationID", int64(1)).Return(nil, nil).Once()
+
+			Convey("it should mark the payment as backfilled", func() {
+				err := paymentListener.BackfillPayment(operation)
+				assert.Nil(t, err)
+				mockEntityManager.AssertExpectations(t)
+				mockRepository.AssertExpectations(t)
+			})
+		})
+
 		Convey("When operation is not a payment", func() {
 			operation.Type = "create_account"
 
@@ -308,15 +378,25 @@ func TestPaymentListener(t *testing.T) {
 			operation.AssetIssuer = "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"
 			operation.Memo.Type = "text"
 			operation.Memo.Value = "testing"
+			operation.TransactionHash = "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050"
 
 			mockRepository.On("GetReceivedPaymentByOperationID", int64(1)).Return(nil, nil).Once()
+			mockRepository.On("GetPaymentRequestsByMemo", "text", "testing").Return([]*entities.PaymentRequest{}, nil).Once()
+			mockRepository.On("GetSentTransactionByHash", operation.TransactionHash).
+				Return(&entities.SentTransaction{CorrelationID: "order-42"}, nil).Once()
 			mockHorizon.On("LoadMemo", &operation).Return(nil).Once()
 
 			mockEntityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
 				Run(ensurePaymentStatus(t, operation, "Processing...")).Return(nil).Once()
 
 			mockEntityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).
-				Run(ensurePaymentStatus(t, operation, "Success")).Return(nil).Once()
+				Run(func(args mock.Arguments) {
+					ensurePaymentStatus(t, operation, "Success")(args)
+					payment := args.Get(0).(*entities.ReceivedPayment)
+					assert.Equal(t, "USD", payment.AssetCode)
+					assert.Equal(t, "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR", payment.AssetIssuer)
+					assert.Equal(t, "100", payment.Amount)
+				}).Return(nil).Once()
 
 			mockHTTPClient.On(
 				"Do",
@@ -335,14 +415,25 @@ func TestPaymentListener(t *testing.T) {
 				assert.Equal(t, operation.AssetIssuer, req.PostFormValue("asset_issuer"))
 				assert.Equal(t, operation.Memo.Type, req.PostFormValue("memo_type"))
 				assert.Equal(t, operation.Memo.Value, req.PostFormValue("memo"))
+				assert.Equal(t, "order-42", req.PostFormValue("correlation_id"))
 			}).Once()
 
 			Convey("it should save the status", func() {
+				recorder := &eventRecorder{}
+				paymentListener.Events = recorder
+				defer func() { paymentListener.Events = nil }()
+
 				err := paymentListener.onPayment(operation)
 				assert.Nil(t, err)
 				mockHorizon.AssertExpectations(t)
 				mockEntityManager.AssertExpectations(t)
 				mockRepository.AssertExpectations(t)
+				mockVolumeAggregator.AssertCalled(t, "Touch", utc.New(mocks.PredefinedTime).Time())
+
+				assert.Equal(t, []string{events.PaymentReceived, events.CallbackDelivered, events.PaymentProcessed}, recorder.types)
+				assert.Equal(t, []string{"1", "1", "1"}, recorder.subjects)
+				assert.Equal(t, receivedEvent{AssetCode: "USD", AssetIssuer: operation.AssetIssuer, Amount: "100"}, recorder.payloads[0])
+				assert.Equal(t, processedEvent{Status: "Success"}, recorder.payloads[2])
 			})
 		})
 
@@ -465,7 +556,7 @@ func TestPaymentListener(t *testing.T) {
 				existingPayment := entities.ReceivedPayment{
 					ID:          &id,
 					OperationID: operation.ID,
-					ProcessedAt: mocks.PredefinedTime,
+					ProcessedAt: utc.New(mocks.PredefinedTime),
 					PagingToken: operation.PagingToken,
 					Status:      "Failed",
 				}
@@ -505,6 +596,20 @@ func TestPaymentListener(t *testing.T) {
 				mockHorizon.AssertExpectations(t)
 				mockEntityManager.AssertExpectations(t)
 			})
+
+			Convey("it should not reprocess a payment imported from a legacy server without force", func() {
+				operation := horizon.PaymentResponse{ID: "1", Type: "payment"}
+				existingPayment := entities.ReceivedPayment{
+					OperationID: operation.ID,
+					Status:      entities.ReceivedPaymentStatusImported,
+				}
+				existingPayment.SetExists()
+				mockRepository.On("GetReceivedPaymentByOperationID", int64(1)).Return(&existingPayment, nil).Once()
+
+				err := paymentListener.ReprocessPayment(operation, false)
+				assert.EqualError(t, err, "Payment was processed by the legacy server, reprocessing requires force")
+				mockEntityManager.AssertExpectations(t)
+			})
 		})
 	})
 }
@@ -534,7 +639,7 @@ func TestPostForm_MACKey(t *testing.T) {
 	defer srv.Close()
 
 	cfg := &config.Config{}
-	pl, err := NewPaymentListener(cfg, nil, nil, nil, nil)
+	pl, err := NewPaymentListener(cfg, nil, nil, nil, nil, http.DefaultClient, nil)
 	require.NoError(t, err)
 
 	// no mac if the key is not set
@@ -554,3 +659,23 @@ func TestPostForm_MACKey(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid MAC key")
 	}
 }
+
+func TestPaymentListenerStop(t *testing.T) {
+	mockRepository := new(mocks.MockRepository)
+	config := &config.Config{
+		Accounts: config.Accounts{ReceivingAccountID: "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"},
+	}
+	paymentListener, err := NewPaymentListener(config, nil, nil, mockRepository, nil, nil, mocks.Now)
+	require.NoError(t, err)
+
+	paymentListener.Stop()
+	paymentListener.Stop()
+
+	// Payments streamed after Stop are not processed
+	err = paymentListener.onPayment(horizon.PaymentResponse{ID: "1", Type: "payment"})
+	assert.Equal(t, horizon.ErrStopStreaming, err)
+	mockRepository.AssertExpectations(t)
+
+	// Listener not created by NewPaymentListener can be stopped
+	(&PaymentListener{}).Stop()
+}
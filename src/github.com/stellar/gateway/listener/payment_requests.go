@@ -0,0 +1,197 @@
+package listener
+
+import (
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/support/errors"
+)
+
+const (
+	// PaymentRequestFulfilledEvent is sent to `callbacks.payment_request` when a request is paid
+	PaymentRequestFulfilledEvent = "payment_request_fulfilled"
+	// PaymentRequestExpiredEvent is sent to `callbacks.payment_request` when a request expires unpaid
+	PaymentRequestExpiredEvent = "payment_request_expired"
+)
+
+const paymentRequestsExpiryInterval = time.Minute
+
+// fulfillPaymentRequest marks a payment request matching the payment as fulfilled and sends
+// a callback. Payments are matched by memo, the callback is sent again when a payment is
+// reprocessed. Requests created before the receiving account was recreated are not fulfilled.
+func (pl *PaymentListener) fulfillPaymentRequest(payment horizon.PaymentResponse) error {
+	if payment.Memo.Type != "text" && payment.Memo.Type != "id" {
+		return nil
+	}
+
+	requests, err := pl.repository.GetPaymentRequestsByMemo(payment.Memo.Type, payment.Memo.Value)
+	if err != nil {
+		return errors.Wrap(err, "Error loading payment requests")
+	}
+	accountGeneration, err := pl.Generations.Generation(payment.To)
+	if err != nil {
+		return errors.Wrap(err, "Error loading account generation")
+	}
+
+	now := utc.New(pl.now())
+	for _, request := range requests {
+		if request.Generation != accountGeneration || !MatchesPaymentRequest(request, payment, now) {
+			continue
+		}
+
+		if request.Status == entities.PaymentRequestStatusOpen {
+			request.MarkFulfilled(payment.ID, now)
+			err = pl.entityManager.Persist(request)
+			if err != nil {
+				return errors.Wrap(err, "Error saving payment request")
+			}
+		}
+
+		pl.paymentLog(payment).WithFields(logrus.Fields{"payment_request": request.RequestID}).Info("Payment request fulfilled")
+		return pl.sendPaymentRequestCallback(request, PaymentRequestFulfilledEvent)
+	}
+
+	return nil
+}
+
+// MatchesPaymentRequest returns true if the payment pays a payment request: destination, asset,
+// amount and memo must be equal and the request must be open and not expired (or already fulfilled
+// by this payment).
+func MatchesPaymentRequest(request *entities.PaymentRequest, payment horizon.PaymentResponse, now utc.Time) bool {
+	switch request.Status {
+	case entities.PaymentRequestStatusOpen:
+		if request.ExpiresAt != nil && request.ExpiresAt.Unix() <= now.Unix() {
+			return false
+		}
+	case entities.PaymentRequestStatusFulfilled:
+		if request.OperationID != payment.ID {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if payment.To != request.Destination ||
+		payment.Memo.Type != request.MemoType ||
+		payment.Memo.Value != request.Memo {
+		return false
+	}
+
+	if request.AssetCode == "" {
+		if payment.AssetType != "native" {
+			return false
+		}
+	} else if payment.AssetType == "native" ||
+		payment.AssetCode != request.AssetCode ||
+		payment.AssetIssuer != request.AssetIssuer {
+		return false
+	}
+
+	requested, err := amount.Parse(request.Amount)
+	if err != nil {
+		return false
+	}
+	paid, err := amount.Parse(payment.Amount)
+	if err != nil {
+		return false
+	}
+	return requested == paid
+}
+
+// ExpirePaymentRequests marks open payment requests past `expires_at` as expired and sends
+// a callback for each of them. A request stays open when its callback fails so it's retried.
+func (pl *PaymentListener) ExpirePaymentRequests() error {
+	requests, err := pl.repository.GetExpiredPaymentRequests(pl.now())
+	if err != nil {
+		return errors.Wrap(err, "Error loading expired payment requests")
+	}
+
+	for _, request := range requests {
+		err = pl.sendPaymentRequestCallback(request, PaymentRequestExpiredEvent)
+		if err != nil {
+			return err
+		}
+
+		request.Status = entities.PaymentRequestStatusExpired
+		err = pl.entityManager.Persist(request)
+		if err != nil {
+			return errors.Wrap(err, "Error saving payment request")
+		}
+
+		pl.log.WithFields(logrus.Fields{"payment_request": request.RequestID}).Info("Payment request expired")
+	}
+
+	return nil
+}
+
+func (pl *PaymentListener) expirePaymentRequests() {
+	ticker := time.NewTicker(paymentRequestsExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-pl.stop:
+			return
+		}
+		if !pl.isLeader() {
+			continue
+		}
+
+		err := pl.ExpirePaymentRequests()
+		if err != nil {
+			pl.log.WithFields(logrus.Fields{"err": err}).Error("Error expiring payment requests")
+		}
+	}
+}
+
+func (pl *PaymentListener) sendPaymentRequestCallback(request *entities.PaymentRequest, event string) error {
+	if pl.config.Callbacks.PaymentRequest == "" {
+		return nil
+	}
+
+	log := pl.log.WithFields(logrus.Fields{
+		logging.CategoryField: logging.CategoryCallbacks,
+		"payment_request":     request.RequestID,
+		"event":               event,
+	})
+
+	resp, err := pl.postForm(
+		pl.config.Callbacks.PaymentRequest,
+		url.Values{
+			"event":        {event},
+			"id":           {request.RequestID},
+			"operation_id": {request.OperationID},
+			"amount":       {request.Amount},
+			"asset_code":   {request.AssetCode},
+			"asset_issuer": {request.AssetIssuer},
+			"memo_type":    {request.MemoType},
+			"memo":         {request.Memo},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error sending request to payment_request callback")
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "Error reading payment_request callback response")
+		}
+
+		log.WithFields(logrus.Fields{
+			"status": resp.StatusCode,
+			"body":   string(body),
+		}).Error("Error response from payment_request callback")
+		return errors.New("Error response from payment_request callback")
+	}
+
+	return nil
+}
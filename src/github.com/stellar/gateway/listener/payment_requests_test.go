@@ -0,0 +1,206 @@
+package listener
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/generation"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testReceivingAccount = "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB"
+	testIssuer           = "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"
+)
+
+func testPaymentRequest() *entities.PaymentRequest {
+	return &entities.PaymentRequest{
+		RequestID:   "abc",
+		Destination: testReceivingAccount,
+		Generation:  1,
+		AssetCode:   "USD",
+		AssetIssuer: testIssuer,
+		Amount:      "10",
+		MemoType:    "id",
+		Memo:        "123",
+		Status:      entities.PaymentRequestStatusOpen,
+	}
+}
+
+func testRequestPayment() horizon.PaymentResponse {
+	payment := horizon.PaymentResponse{
+		ID:          "1",
+		Type:        "payment",
+		To:          testReceivingAccount,
+		AssetType:   "credit_alphanum4",
+		AssetCode:   "USD",
+		AssetIssuer: testIssuer,
+		Amount:      "10.0000000",
+	}
+	payment.Memo.Type = "id"
+	payment.Memo.Value = "123"
+	return payment
+}
+
+func TestMatchesPaymentRequest(t *testing.T) {
+	now := utc.Unix(1500000000)
+	past := utc.Unix(1400000000)
+	future := utc.Unix(1600000000)
+
+	tests := []struct {
+		name    string
+		request func(r *entities.PaymentRequest)
+		payment func(p *horizon.PaymentResponse)
+		matches bool
+	}{
+		{"equal", nil, nil, true},
+		{"not expired", func(r *entities.PaymentRequest) { r.ExpiresAt = &future }, nil, true},
+		{"expired", func(r *entities.PaymentRequest) { r.ExpiresAt = &past }, nil, false},
+		{"other amount", nil, func(p *horizon.PaymentResponse) { p.Amount = "9.9999999" }, false},
+		{"other memo", nil, func(p *horizon.PaymentResponse) { p.Memo.Value = "124" }, false},
+		{"other memo type", nil, func(p *horizon.PaymentResponse) { p.Memo.Type = "text" }, false},
+		{"other destination", nil, func(p *horizon.PaymentResponse) { p.To = testIssuer }, false},
+		{"other issuer", nil, func(p *horizon.PaymentResponse) { p.AssetIssuer = testReceivingAccount }, false},
+		{"native payment", nil, func(p *horizon.PaymentResponse) { p.AssetType, p.AssetCode, p.AssetIssuer = "native", "", "" }, false},
+		{
+			"native request",
+			func(r *entities.PaymentRequest) { r.AssetCode, r.AssetIssuer = "", "" },
+			func(p *horizon.PaymentResponse) { p.AssetType, p.AssetCode, p.AssetIssuer = "native", "", "" },
+			true,
+		},
+		{"fulfilled by other payment", func(r *entities.PaymentRequest) { r.MarkFulfilled("2", past) }, nil, false},
+		{"fulfilled by this payment", func(r *entities.PaymentRequest) { r.MarkFulfilled("1", past) }, nil, true},
+		{"expired status", func(r *entities.PaymentRequest) { r.Status = entities.PaymentRequestStatusExpired }, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := testPaymentRequest()
+			payment := testRequestPayment()
+			if test.request != nil {
+				test.request(request)
+			}
+			if test.payment != nil {
+				test.payment(&payment)
+			}
+			assert.Equal(t, test.matches, MatchesPaymentRequest(request, payment, now))
+		})
+	}
+}
+
+func newPaymentRequestsListener(t *testing.T) (*PaymentListener, *mocks.MockRepository, *mocks.MockEntityManager, *mocks.MockHTTPClient) {
+	mockRepository := new(mocks.MockRepository)
+	mockEntityManager := new(mocks.MockEntityManager)
+	mockHTTPClient := new(mocks.MockHTTPClient)
+
+	cfg := &config.Config{
+		Callbacks: config.Callbacks{PaymentRequest: "http://payment_request_callback"},
+	}
+	pl, err := NewPaymentListener(cfg, mockEntityManager, nil, mockRepository, nil, mockHTTPClient, func() time.Time { return time.Unix(1500000000, 0) })
+	require.NoError(t, err)
+	return &pl, mockRepository, mockEntityManager, mockHTTPClient
+}
+
+func expectPaymentRequestCallback(t *testing.T, client *mocks.MockHTTPClient, event string, status int) {
+	client.On(
+		"Do",
+		mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "http://payment_request_callback"
+		}),
+	).Run(func(args mock.Arguments) {
+		req := args.Get(0).(*http.Request)
+		assert.Equal(t, event, req.PostFormValue("event"))
+		assert.Equal(t, "abc", req.PostFormValue("id"))
+	}).Return(net.BuildHTTPResponse(status, "ok"), nil).Once()
+}
+
+func TestFulfillPaymentRequest(t *testing.T) {
+	pl, mockRepository, mockEntityManager, mockHTTPClient := newPaymentRequestsListener(t)
+
+	request := testPaymentRequest()
+	other := testPaymentRequest()
+	other.Amount = "20"
+	mockRepository.On("GetPaymentRequestsByMemo", "id", "123").Return([]*entities.PaymentRequest{other, request}, nil)
+
+	mockEntityManager.On("Persist", request).Run(func(args mock.Arguments) {
+		assert.Equal(t, entities.PaymentRequestStatusFulfilled, request.Status)
+		assert.Equal(t, "1", request.OperationID)
+		assert.Equal(t, utc.Unix(1500000000), *request.FulfilledAt)
+	}).Return(nil).Once()
+	expectPaymentRequestCallback(t, mockHTTPClient, PaymentRequestFulfilledEvent, 200)
+
+	err := pl.fulfillPaymentRequest(testRequestPayment())
+	assert.NoError(t, err)
+	assert.Equal(t, entities.PaymentRequestStatusOpen, other.Status)
+
+	// Reprocessing sends the callback again without changing the request
+	expectPaymentRequestCallback(t, mockHTTPClient, PaymentRequestFulfilledEvent, 200)
+	err = pl.fulfillPaymentRequest(testRequestPayment())
+	assert.NoError(t, err)
+
+	mockEntityManager.AssertExpectations(t)
+	mockHTTPClient.AssertExpectations(t)
+}
+
+func TestFulfillPaymentRequestOfRecreatedAccount(t *testing.T) {
+	pl, mockRepository, mockEntityManager, mockHTTPClient := newPaymentRequestsListener(t)
+	pl.Generations = generation.NewTracker(mockRepository, mockEntityManager, pl.now)
+
+	// Created before the receiving account was merged and created again
+	previous := testPaymentRequest()
+	request := testPaymentRequest()
+	request.Generation = 2
+	mockRepository.On("GetPaymentRequestsByMemo", "id", "123").Return([]*entities.PaymentRequest{previous, request}, nil)
+	mockRepository.On("GetAccountGeneration", testReceivingAccount).Return(&entities.AccountGeneration{AccountID: testReceivingAccount, Generation: 2}, nil)
+
+	mockEntityManager.On("Persist", request).Return(nil).Once()
+	expectPaymentRequestCallback(t, mockHTTPClient, PaymentRequestFulfilledEvent, 200)
+
+	err := pl.fulfillPaymentRequest(testRequestPayment())
+	assert.NoError(t, err)
+	assert.Equal(t, entities.PaymentRequestStatusOpen, previous.Status)
+	assert.Equal(t, entities.PaymentRequestStatusFulfilled, request.Status)
+
+	mockEntityManager.AssertExpectations(t)
+	mockHTTPClient.AssertExpectations(t)
+}
+
+func TestExpirePaymentRequests(t *testing.T) {
+	pl, mockRepository, mockEntityManager, mockHTTPClient := newPaymentRequestsListener(t)
+
+	request := testPaymentRequest()
+	mockRepository.On("GetExpiredPaymentRequests", time.Unix(1500000000, 0)).Return([]*entities.PaymentRequest{request}, nil)
+
+	// Failed callback leaves the request open
+	expectPaymentRequestCallback(t, mockHTTPClient, PaymentRequestExpiredEvent, 500)
+	err := pl.ExpirePaymentRequests()
+	assert.Error(t, err)
+	assert.Equal(t, entities.PaymentRequestStatusOpen, request.Status)
+
+	expectPaymentRequestCallback(t, mockHTTPClient, PaymentRequestExpiredEvent, 200)
+	mockEntityManager.On("Persist", request).Return(nil).Once()
+	err = pl.ExpirePaymentRequests()
+	assert.NoError(t, err)
+	assert.Equal(t, entities.PaymentRequestStatusExpired, request.Status)
+
+	mockEntityManager.AssertExpectations(t)
+	mockHTTPClient.AssertExpectations(t)
+}
+
+func TestExpirePaymentRequestsError(t *testing.T) {
+	pl, mockRepository, _, _ := newPaymentRequestsListener(t)
+	mockRepository.On("GetExpiredPaymentRequests", mock.Anything).Return(nil, errors.New("DB error"))
+
+	err := pl.ExpirePaymentRequests()
+	assert.Error(t, err)
+}
@@ -0,0 +1,253 @@
+package listener
+
+import (
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/support/errors"
+)
+
+const (
+	// AccountMergedEvent is sent to `callbacks.admin` when the receiving account is merged
+	AccountMergedEvent = "account_merged"
+	// AccountReregisteredEvent is sent to `callbacks.admin` when a merged account is monitored again
+	AccountReregisteredEvent = "account_reregistered"
+)
+
+const (
+	// ListenerStatusActive is a status of a listener streaming payments
+	ListenerStatusActive = "active"
+	// ListenerStatusRetired is a status of a listener waiting for its merged account to be reregistered
+	ListenerStatusRetired = "retired"
+	// ListenerStatusStopped is a status of a listener that was not started
+	ListenerStatusStopped = "stopped"
+)
+
+// retiredAccountCheckInterval is a time between checks of a retired account, it can be
+// reregistered on a different replica
+const retiredAccountCheckInterval = time.Minute
+
+var (
+	// ErrListenerStopped is returned by Reregister when the listener was not started
+	ErrListenerStopped = errors.New("Payment listener is not running")
+	// ErrAccountNotRetired is returned by Reregister when the account was not merged
+	ErrAccountNotRetired = errors.New("Account is not retired")
+)
+
+// Status contains the state of the listener returned by /status
+type Status struct {
+	AccountID string                   `json:"account_id,omitempty"`
+	Status    string                   `json:"status"`
+	Retired   *entities.RetiredAccount `json:"retired,omitempty"`
+}
+
+// Status returns the state of the listener. Retired accounts are not streamed so monitoring should
+// not expect new payments of them.
+func (pl *PaymentListener) Status() (Status, error) {
+	if pl == nil || pl.repository == nil {
+		return Status{Status: ListenerStatusStopped}, nil
+	}
+
+	accountID := pl.config.Accounts.ReceivingAccountID
+	retired, err := pl.repository.GetRetiredAccount(accountID)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{AccountID: accountID, Status: ListenerStatusActive}
+	if retired != nil {
+		status.Status = ListenerStatusRetired
+		status.Retired = retired
+	}
+	return status, nil
+}
+
+// Reregister resumes streaming payments of a merged account after it was created again. The
+// account must exist in Horizon, payments received before the merge are kept.
+func (pl *PaymentListener) Reregister(accountID string) (*entities.RetiredAccount, error) {
+	if pl == nil || pl.repository == nil {
+		return nil, ErrListenerStopped
+	}
+
+	retired, err := pl.repository.GetRetiredAccount(accountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error loading retired account")
+	}
+	if retired == nil {
+		return nil, ErrAccountNotRetired
+	}
+
+	account, err := pl.horizon.LoadAccount(accountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Account was not created again")
+	}
+	// Records the new generation
+	pl.observe(accountID, account.SequenceNumber)
+
+	now := utc.New(pl.now())
+	retired.ReregisteredAt = &now
+	err = pl.entityManager.Persist(retired)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error saving retired account")
+	}
+
+	pl.log.WithFields(logrus.Fields{"account_id": accountID}).Warn("Merged account reregistered")
+
+	// Listen can be waiting on this replica, others notice it at the next check
+	select {
+	case pl.reregistered <- struct{}{}:
+	default:
+	}
+
+	err = pl.sendAdminCallback(AccountReregisteredEvent, retired)
+	if err != nil {
+		pl.log.WithFields(logrus.Fields{"err": err}).Error("Error sending account_reregistered callback")
+	}
+	return retired, nil
+}
+
+// isMerged returns true if the payment is a merge of the receiving account
+func (pl *PaymentListener) isMerged(payment horizon.PaymentResponse) bool {
+	return payment.Type == "account_merge" && payment.Account == pl.config.Accounts.ReceivingAccountID
+}
+
+// retire marks the receiving account merged by the payment as retired and stops the stream. The
+// retirement is saved before the payment so it's not lost when the listener is restarted.
+func (pl *PaymentListener) retire(payment horizon.PaymentResponse) error {
+	log := pl.paymentLog(payment).WithFields(logrus.Fields{"account_id": payment.Account, "merged_into": payment.Into})
+
+	retired, err := pl.repository.GetRetiredAccount(payment.Account)
+	if err != nil {
+		return errors.Wrap(err, "Error loading retired account")
+	}
+
+	if retired == nil || retired.OperationID != payment.ID {
+		retired = &entities.RetiredAccount{
+			AccountID:       payment.Account,
+			MergedInto:      payment.Into,
+			OperationID:     payment.ID,
+			TransactionHash: payment.TransactionHash,
+			RetiredAt:       utc.New(pl.now()),
+		}
+		err = pl.entityManager.Persist(retired)
+		if err != nil {
+			return errors.Wrap(err, "Error saving retired account")
+		}
+		log.Warn("Receiving account merged, stopped listening for new payments")
+
+		err = pl.Generations.Merged(payment.Account)
+		if err != nil {
+			log.WithFields(logrus.Fields{"err": err}).Error("Error saving merged account generation")
+		}
+
+		err = pl.sendAdminCallback(AccountMergedEvent, retired)
+		if err != nil {
+			log.WithFields(logrus.Fields{"err": err}).Error("Error sending account_merged callback")
+		}
+	}
+
+	err = pl.receive(payment, false)
+	if err != nil {
+		return err
+	}
+	return horizon.ErrStopStreaming
+}
+
+// observe records the sequence number of the loaded account in Generations, errors are logged
+func (pl *PaymentListener) observe(accountID, sequenceNumber string) {
+	if !pl.Generations.Enabled() {
+		return
+	}
+	sequence, err := strconv.ParseUint(sequenceNumber, 10, 64)
+	if err == nil {
+		_, err = pl.Generations.Observe(accountID, sequence)
+	}
+	if err != nil {
+		pl.log.WithFields(logrus.Fields{"err": err, "account_id": accountID}).Error("Error observing account generation")
+	}
+}
+
+// created records a streamed create_account in Generations, a merged account created again is in
+// its next generation
+func (pl *PaymentListener) created(payment horizon.PaymentResponse) {
+	_, err := pl.Generations.Created(payment.Account)
+	if err != nil {
+		pl.paymentLog(payment).WithFields(logrus.Fields{"err": err, "account_id": payment.Account}).Error("Error observing account generation")
+	}
+}
+
+// waitReregistered blocks while the account is retired
+func (pl *PaymentListener) waitReregistered(accountID string) error {
+	for waiting := false; ; waiting = true {
+		retired, err := pl.repository.GetRetiredAccount(accountID)
+		if err != nil {
+			return err
+		}
+		if retired == nil {
+			return nil
+		}
+
+		if !waiting {
+			pl.log.WithFields(logrus.Fields{
+				"account_id":  accountID,
+				"merged_into": retired.MergedInto,
+			}).Warn("Receiving account is merged, waiting for /admin/accounts/{id}/reregister")
+		}
+
+		select {
+		case <-pl.reregistered:
+		case <-time.After(retiredAccountCheckInterval):
+		case <-pl.stop:
+			return nil
+		}
+	}
+}
+
+func (pl *PaymentListener) sendAdminCallback(event string, retired *entities.RetiredAccount) error {
+	if pl.config.Callbacks.Admin == "" {
+		return nil
+	}
+
+	log := pl.log.WithFields(logrus.Fields{
+		logging.CategoryField: logging.CategoryCallbacks,
+		"account_id":          retired.AccountID,
+		"event":               event,
+	})
+
+	resp, err := pl.postForm(
+		pl.config.Callbacks.Admin,
+		url.Values{
+			"event":            {event},
+			"account_id":       {retired.AccountID},
+			"merged_into":      {retired.MergedInto},
+			"operation_id":     {retired.OperationID},
+			"transaction_hash": {retired.TransactionHash},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "Error sending request to admin callback")
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return errors.Wrap(err, "Error reading admin callback response")
+		}
+
+		log.WithFields(logrus.Fields{
+			"status": resp.StatusCode,
+			"body":   string(body),
+		}).Error("Error response from admin callback")
+		return errors.New("Error response from admin callback")
+	}
+
+	return nil
+}
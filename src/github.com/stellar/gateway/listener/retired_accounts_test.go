@@ -0,0 +1,219 @@
+package listener
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/bridge/config"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/generation"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/net"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testMergedInto = "GBIHSMPXC2KJ3NJVHEYTG3KCHYEUQRT45X6AWYWXMAXZOAX4F5LFZYYQ"
+
+func newRetiredAccountsListener(t *testing.T) (*PaymentListener, *mocks.MockHorizon, *mocks.MockRepository, *mocks.MockEntityManager, *mocks.MockHTTPClient) {
+	mockHorizon := new(mocks.MockHorizon)
+	mockRepository := new(mocks.MockRepository)
+	mockEntityManager := new(mocks.MockEntityManager)
+	mockHTTPClient := new(mocks.MockHTTPClient)
+	mockVolumeAggregator := new(mocks.MockVolumeAggregator)
+	mockVolumeAggregator.On("Touch", mock.AnythingOfType("time.Time")).Return()
+
+	cfg := &config.Config{
+		Accounts:  config.Accounts{ReceivingAccountID: testReceivingAccount},
+		Callbacks: config.Callbacks{Receive: "http://receive_callback", Admin: "http://admin_callback"},
+	}
+	pl, err := NewPaymentListener(cfg, mockEntityManager, mockHorizon, mockRepository, mockVolumeAggregator, mockHTTPClient, func() time.Time { return time.Unix(1500000000, 0) })
+	require.NoError(t, err)
+	return &pl, mockHorizon, mockRepository, mockEntityManager, mockHTTPClient
+}
+
+func testMerge() horizon.PaymentResponse {
+	return horizon.PaymentResponse{
+		ID:              "12",
+		Type:            "account_merge",
+		PagingToken:     "12",
+		TransactionHash: "5cd9a1fe1b0b1e1c3b4e6e1f5c1e9d6b2a7f0e3d4c5b6a798877665544332211",
+		Account:         testReceivingAccount,
+		Into:            testMergedInto,
+	}
+}
+
+func expectAdminCallback(t *testing.T, client *mocks.MockHTTPClient, event string, status int) {
+	client.On(
+		"Do",
+		mock.MatchedBy(func(req *http.Request) bool {
+			return req.URL.String() == "http://admin_callback"
+		}),
+	).Run(func(args mock.Arguments) {
+		req := args.Get(0).(*http.Request)
+		assert.Equal(t, event, req.PostFormValue("event"))
+		assert.Equal(t, testReceivingAccount, req.PostFormValue("account_id"))
+		assert.Equal(t, testMergedInto, req.PostFormValue("merged_into"))
+		assert.Equal(t, "12", req.PostFormValue("operation_id"))
+	}).Return(net.BuildHTTPResponse(status, "ok"), nil).Once()
+}
+
+func TestRetireMergedAccount(t *testing.T) {
+	pl, _, mockRepository, mockEntityManager, mockHTTPClient := newRetiredAccountsListener(t)
+	merge := testMerge()
+
+	mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(nil, nil).Once()
+	mockEntityManager.On("Persist", mock.AnythingOfType("*entities.RetiredAccount")).Run(func(args mock.Arguments) {
+		retired := args.Get(0).(*entities.RetiredAccount)
+		assert.Equal(t, testMergedInto, retired.MergedInto)
+		assert.Equal(t, merge.TransactionHash, retired.TransactionHash)
+		assert.Equal(t, utc.Unix(1500000000), retired.RetiredAt)
+		assert.Nil(t, retired.ReregisteredAt)
+	}).Return(nil).Once()
+	// Failed callback does not prevent the retirement
+	expectAdminCallback(t, mockHTTPClient, AccountMergedEvent, 500)
+
+	mockRepository.On("GetReceivedPaymentByOperationID", int64(12)).Return(nil, nil).Once()
+	mockEntityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).Return(nil).Once()
+	mockEntityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).Run(func(args mock.Arguments) {
+		assert.Equal(t, "Account merged", args.Get(0).(*entities.ReceivedPayment).Status)
+	}).Return(nil).Once()
+
+	err := pl.onPayment(merge)
+	assert.Equal(t, horizon.ErrStopStreaming, err)
+
+	// Streamed again before the cursor moved, the account is not retired twice
+	retired := &entities.RetiredAccount{AccountID: testReceivingAccount, MergedInto: testMergedInto, OperationID: "12"}
+	mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(retired, nil).Once()
+	mockRepository.On("GetReceivedPaymentByOperationID", int64(12)).Return(&entities.ReceivedPayment{}, nil).Once()
+
+	err = pl.onPayment(merge)
+	assert.Equal(t, horizon.ErrStopStreaming, err)
+
+	mockEntityManager.AssertExpectations(t)
+	mockHTTPClient.AssertExpectations(t)
+	mockRepository.AssertExpectations(t)
+}
+
+func TestMergeIntoReceivingAccount(t *testing.T) {
+	pl, _, mockRepository, mockEntityManager, mockHTTPClient := newRetiredAccountsListener(t)
+	merge := testMerge()
+	merge.Account, merge.Into = testMergedInto, testReceivingAccount
+
+	mockRepository.On("GetReceivedPaymentByOperationID", int64(12)).Return(nil, nil).Once()
+	mockEntityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).Return(nil).Once()
+	mockEntityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).Run(func(args mock.Arguments) {
+		assert.Equal(t, "Not a payment operation", args.Get(0).(*entities.ReceivedPayment).Status)
+	}).Return(nil).Once()
+
+	err := pl.onPayment(merge)
+	assert.NoError(t, err)
+	mockEntityManager.AssertExpectations(t)
+	mockHTTPClient.AssertNotCalled(t, "Do", mock.Anything)
+}
+
+func TestReregister(t *testing.T) {
+	pl, mockHorizon, mockRepository, mockEntityManager, mockHTTPClient := newRetiredAccountsListener(t)
+	retired := &entities.RetiredAccount{AccountID: testReceivingAccount, MergedInto: testMergedInto, OperationID: "12"}
+
+	t.Run("not retired", func(t *testing.T) {
+		mockRepository.On("GetRetiredAccount", testMergedInto).Return(nil, nil).Once()
+		_, err := pl.Reregister(testMergedInto)
+		assert.Equal(t, ErrAccountNotRetired, err)
+	})
+
+	t.Run("not created again", func(t *testing.T) {
+		mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(retired, nil).Once()
+		mockHorizon.On("LoadAccount", testReceivingAccount).Return(horizon.AccountResponse{}, errors.New("Not found")).Once()
+		_, err := pl.Reregister(testReceivingAccount)
+		assert.EqualError(t, err, "Account was not created again: Not found")
+		assert.Nil(t, retired.ReregisteredAt)
+	})
+
+	t.Run("reregistered", func(t *testing.T) {
+		status, err := (*PaymentListener)(nil).Status()
+		require.NoError(t, err)
+		assert.Equal(t, ListenerStatusStopped, status.Status)
+
+		mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(retired, nil).Once()
+		status, err = pl.Status()
+		require.NoError(t, err)
+		assert.Equal(t, Status{AccountID: testReceivingAccount, Status: ListenerStatusRetired, Retired: retired}, status)
+
+		mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(retired, nil).Once()
+		mockHorizon.On("LoadAccount", testReceivingAccount).Return(horizon.AccountResponse{AccountID: testReceivingAccount}, nil).Once()
+		mockEntityManager.On("Persist", retired).Return(nil).Once()
+		expectAdminCallback(t, mockHTTPClient, AccountReregisteredEvent, 200)
+
+		_, err = pl.Reregister(testReceivingAccount)
+		require.NoError(t, err)
+		assert.Equal(t, utc.Unix(1500000000), *retired.ReregisteredAt)
+
+		// Listen waiting for the account wakes up and streams again, the replica checking it last
+		// still saw it retired
+		mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(retired, nil).Once()
+		mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(nil, nil).Once()
+		done := make(chan error)
+		go func() { done <- pl.waitReregistered(testReceivingAccount) }()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("waitReregistered did not return")
+		}
+
+		mockEntityManager.AssertExpectations(t)
+		mockHTTPClient.AssertExpectations(t)
+	})
+}
+
+func TestRecreatedReceivingAccountGeneration(t *testing.T) {
+	pl, mockHorizon, mockRepository, mockEntityManager, mockHTTPClient := newRetiredAccountsListener(t)
+	pl.Generations = generation.NewTracker(mockRepository, mockEntityManager, pl.now)
+	observed := &entities.AccountGeneration{AccountID: testReceivingAccount, Generation: 1, Sequence: 100 << 32}
+	observed.SetExists()
+	mockRepository.On("GetAccountGeneration", testReceivingAccount).Return(observed, nil)
+
+	// Merge
+	mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(nil, nil).Once()
+	mockEntityManager.On("Persist", mock.AnythingOfType("*entities.RetiredAccount")).Return(nil).Once()
+	expectAdminCallback(t, mockHTTPClient, AccountMergedEvent, 200)
+	mockEntityManager.On("Persist", observed).Run(func(args mock.Arguments) {
+		assert.Equal(t, utc.Unix(1500000000), *observed.MergedAt)
+	}).Return(nil).Once()
+	mockRepository.On("GetReceivedPaymentByOperationID", int64(12)).Return(nil, nil).Once()
+	mockEntityManager.On("Persist", mock.AnythingOfType("*entities.ReceivedPayment")).Return(nil).Twice()
+
+	err := pl.onPayment(testMerge())
+	assert.Equal(t, horizon.ErrStopStreaming, err)
+	accountGeneration, err := pl.Generations.Generation(testReceivingAccount)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), accountGeneration, "payment requests created while merged are paid to the next generation")
+
+	// Created again and reregistered
+	retired := &entities.RetiredAccount{AccountID: testReceivingAccount, MergedInto: testMergedInto, OperationID: "12"}
+	mockRepository.On("GetRetiredAccount", testReceivingAccount).Return(retired, nil).Once()
+	mockHorizon.On("LoadAccount", testReceivingAccount).Return(horizon.AccountResponse{AccountID: testReceivingAccount, SequenceNumber: "515396075520"}, nil).Once()
+	mockEntityManager.On("Persist", observed).Run(func(args mock.Arguments) {
+		assert.Equal(t, int64(2), observed.Generation)
+		assert.Equal(t, int64(515396075520), observed.Sequence)
+		assert.Nil(t, observed.MergedAt)
+		assert.NotNil(t, observed.RecreatedAt)
+	}).Return(nil).Once()
+	mockEntityManager.On("Persist", retired).Return(nil).Once()
+	expectAdminCallback(t, mockHTTPClient, AccountReregisteredEvent, 200)
+
+	_, err = pl.Reregister(testReceivingAccount)
+	require.NoError(t, err)
+	accountGeneration, err = pl.Generations.Generation(testReceivingAccount)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), accountGeneration)
+
+	mockEntityManager.AssertExpectations(t)
+	mockHTTPClient.AssertExpectations(t)
+}
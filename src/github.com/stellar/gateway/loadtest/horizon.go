@@ -0,0 +1,440 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// DefaultBalance is a balance of XLM and trustlines of accounts not in the state
+	DefaultBalance = "100000000.0000000"
+	// defaultLimit is a limit of trustlines of accounts not in the state
+	defaultLimit = "922337203685.4775807"
+	// firstLedger is the latest ledger of a started sandbox Horizon
+	firstLedger = 1000
+)
+
+// failure is a result code of HorizonSettings.Failures
+type failure struct {
+	// code is the transaction result code
+	code xdr.TransactionResultCode
+	// operation returns the failed result of the last operation of tx_failed transactions, false
+	// when the operation cannot fail with the code (the transaction is applied)
+	operation func(xdr.Operation) (xdr.OperationResult, bool)
+}
+
+// failureResults are result codes that can be injected, by name used by Horizon in `result_codes`
+var failureResults = map[string]failure{
+	"tx_bad_seq":              {code: xdr.TransactionResultCodeTxBadSeq},
+	"tx_bad_auth":             {code: xdr.TransactionResultCodeTxBadAuth},
+	"tx_insufficient_balance": {code: xdr.TransactionResultCodeTxInsufficientBalance},
+	"tx_insufficient_fee":     {code: xdr.TransactionResultCodeTxInsufficientFee},
+	"tx_too_late":             {code: xdr.TransactionResultCodeTxTooLate},
+	"tx_internal_error":       {code: xdr.TransactionResultCodeTxInternalError},
+	"op_underfunded": {code: xdr.TransactionResultCodeTxFailed, operation: operationFailure(
+		xdr.PaymentResultCodePaymentUnderfunded,
+		xdr.PathPaymentResultCodePathPaymentUnderfunded,
+		xdr.CreateAccountResultCodeCreateAccountUnderfunded,
+	)},
+	"op_no_trust": {code: xdr.TransactionResultCodeTxFailed, operation: operationFailure(
+		xdr.PaymentResultCodePaymentNoTrust,
+		xdr.PathPaymentResultCodePathPaymentNoTrust,
+		xdr.CreateAccountResultCodeCreateAccountSuccess,
+	)},
+	"op_line_full": {code: xdr.TransactionResultCodeTxFailed, operation: operationFailure(
+		xdr.PaymentResultCodePaymentLineFull,
+		xdr.PathPaymentResultCodePathPaymentLineFull,
+		xdr.CreateAccountResultCodeCreateAccountSuccess,
+	)},
+	"op_no_destination": {code: xdr.TransactionResultCodeTxFailed, operation: operationFailure(
+		xdr.PaymentResultCodePaymentNoDestination,
+		xdr.PathPaymentResultCodePathPaymentNoDestination,
+		xdr.CreateAccountResultCodeCreateAccountSuccess,
+	)},
+}
+
+// operationFailure returns a failure of payment operations, create_account fails only when
+// createAccount is not a success code
+func operationFailure(
+	payment xdr.PaymentResultCode,
+	pathPayment xdr.PathPaymentResultCode,
+	createAccount xdr.CreateAccountResultCode,
+) func(xdr.Operation) (xdr.OperationResult, bool) {
+	return func(op xdr.Operation) (xdr.OperationResult, bool) {
+		tr := xdr.OperationResultTr{Type: op.Body.Type}
+		switch op.Body.Type {
+		case xdr.OperationTypePayment:
+			tr.PaymentResult = &xdr.PaymentResult{Code: payment}
+		case xdr.OperationTypePathPayment:
+			tr.PathPaymentResult = &xdr.PathPaymentResult{Code: pathPayment}
+		case xdr.OperationTypeCreateAccount:
+			if createAccount == xdr.CreateAccountResultCodeCreateAccountSuccess {
+				return xdr.OperationResult{}, false
+			}
+			tr.CreateAccountResult = &xdr.CreateAccountResult{Code: createAccount}
+		default:
+			return xdr.OperationResult{}, false
+		}
+		return xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &tr}, true
+	}
+}
+
+// FakeHorizon is a sandbox Horizon server handling requests sent by payments. Submitted
+// transactions are applied to sequence numbers and created accounts only, balances never change.
+// Operations payments don't send (other than change_trust, allow_trust, set_options and
+// manage_data) fail with op_bad_auth.
+type FakeHorizon struct {
+	settings          HorizonSettings
+	networkPassphrase string
+	balances          []horizon.Balance
+
+	mutex        sync.Mutex
+	random       *rand.Rand
+	accounts     map[string]*fakeAccount
+	transactions map[string]horizon.TransactionResponse
+	ledger       uint64
+	sleep        func(time.Duration)
+}
+
+type fakeAccount struct {
+	sequence uint64
+	balances []horizon.Balance
+}
+
+// NewFakeHorizon creates a new FakeHorizon. Accounts added by AddAccount and created by
+// transactions have XLM and trustlines of assets with DefaultBalance.
+func NewFakeHorizon(settings HorizonSettings, networkPassphrase string, assets []horizon.Balance, seed int64) *FakeHorizon {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	balances := []horizon.Balance{{Balance: DefaultBalance, AssetType: "native"}}
+	for _, asset := range assets {
+		asset.Balance = DefaultBalance
+		asset.Limit = defaultLimit
+		balances = append(balances, asset)
+	}
+
+	h := &FakeHorizon{
+		settings:          settings,
+		networkPassphrase: networkPassphrase,
+		balances:          balances,
+		random:            rand.New(rand.NewSource(seed)),
+		accounts:          map[string]*fakeAccount{},
+		transactions:      map[string]horizon.TransactionResponse{},
+		ledger:            firstLedger,
+		sleep:             time.Sleep,
+	}
+
+	if settings.State != nil {
+		for id, account := range settings.State.Accounts {
+			sequence, _ := strconv.ParseUint(account.Sequence, 10, 64)
+			h.accounts[id] = &fakeAccount{sequence: sequence, balances: account.Balances}
+		}
+	}
+	return h
+}
+
+// AddAccount adds an account with default balances unless it's already in the state
+func (h *FakeHorizon) AddAccount(accountID string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if _, ok := h.accounts[accountID]; !ok {
+		h.accounts[accountID] = &fakeAccount{sequence: firstLedger << 32, balances: h.balances}
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *FakeHorizon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/transactions":
+		h.sleep(time.Duration(h.settings.SubmitLatencyMS) * time.Millisecond)
+		h.submitTransaction(w, r)
+		return
+	case r.Method != "GET":
+		writeProblem(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.sleep(time.Duration(h.settings.LatencyMS) * time.Millisecond)
+	switch {
+	case r.URL.Path == "/":
+		h.mutex.Lock()
+		ledger := h.ledger
+		h.mutex.Unlock()
+		writeJSON(w, http.StatusOK, map[string]uint64{"history_latest_ledger": ledger})
+	case strings.HasPrefix(r.URL.Path, "/accounts/") && !strings.Contains(r.URL.Path[len("/accounts/"):], "/"):
+		h.loadAccount(w, r.URL.Path[len("/accounts/"):])
+	case r.URL.Path == "/order_book":
+		h.loadOrderBook(w, r)
+	case strings.HasPrefix(r.URL.Path, "/transactions/"):
+		h.loadTransaction(w, r.URL.Path[len("/transactions/"):])
+	default:
+		writeProblem(w, http.StatusNotFound, "Resource missing")
+	}
+}
+
+func (h *FakeHorizon) loadAccount(w http.ResponseWriter, accountID string) {
+	h.mutex.Lock()
+	account, ok := h.accounts[accountID]
+	var response horizon.AccountResponse
+	if ok {
+		response = horizon.AccountResponse{
+			AccountID:      accountID,
+			SequenceNumber: strconv.FormatUint(account.sequence, 10),
+			Balances:       account.balances,
+		}
+	}
+	h.mutex.Unlock()
+
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "Resource missing")
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *FakeHorizon) loadOrderBook(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	response := horizon.OrderBookResponse{Bids: []horizon.OrderBookLevel{}, Asks: []horizon.OrderBookLevel{}}
+	if h.settings.State != nil {
+		for _, book := range h.settings.State.OrderBooks {
+			if book.Selling.Code == query.Get("selling_asset_code") &&
+				book.Selling.Issuer == query.Get("selling_asset_issuer") &&
+				book.Buying.Code == query.Get("buying_asset_code") &&
+				book.Buying.Issuer == query.Get("buying_asset_issuer") {
+				response = book.OrderBookResponse
+				break
+			}
+		}
+	}
+	if response.Bids == nil {
+		response.Bids = []horizon.OrderBookLevel{}
+	}
+	if response.Asks == nil {
+		response.Asks = []horizon.OrderBookLevel{}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *FakeHorizon) loadTransaction(w http.ResponseWriter, hash string) {
+	h.mutex.Lock()
+	transaction, ok := h.transactions[hash]
+	h.mutex.Unlock()
+
+	if !ok {
+		writeProblem(w, http.StatusNotFound, "Resource missing")
+		return
+	}
+	writeJSON(w, http.StatusOK, transaction)
+}
+
+func (h *FakeHorizon) submitTransaction(w http.ResponseWriter, r *http.Request) {
+	envelopeXdr := r.PostFormValue("tx")
+	var envelope xdr.TransactionEnvelope
+	err := xdr.SafeUnmarshalBase64(envelopeXdr, &envelope)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "Transaction malformed")
+		return
+	}
+	hashBytes, err := network.HashTransaction(&envelope.Tx, h.networkPassphrase)
+	if err != nil {
+		writeProblem(w, http.StatusBadRequest, "Transaction malformed")
+		return
+	}
+	hash := fmt.Sprintf("%x", hashBytes)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	result, codes := h.apply(&envelope.Tx)
+	resultXdr, err := xdr.MarshalBase64(result)
+	if err != nil {
+		writeProblem(w, http.StatusInternalServerError, "Internal error")
+		return
+	}
+
+	if result.Result.Code != xdr.TransactionResultCodeTxSuccess {
+		response := map[string]interface{}{
+			"type":   "https://stellar.org/horizon-errors/transaction_failed",
+			"title":  "Transaction Failed",
+			"status": http.StatusBadRequest,
+			"extras": map[string]interface{}{
+				"envelope_xdr": envelopeXdr,
+				"result_xdr":   resultXdr,
+				"result_codes": codes,
+			},
+		}
+		if result.Result.Code == xdr.TransactionResultCodeTxFailed {
+			successful := false
+			h.ledger++
+			h.transactions[hash] = horizon.TransactionResponse{Hash: hash, Ledger: h.ledger, EnvelopeXdr: envelopeXdr, ResultXdr: resultXdr, Successful: &successful}
+		}
+		writeJSON(w, http.StatusBadRequest, response)
+		return
+	}
+
+	successful := true
+	h.ledger++
+	h.transactions[hash] = horizon.TransactionResponse{Hash: hash, Ledger: h.ledger, EnvelopeXdr: envelopeXdr, ResultXdr: resultXdr, Successful: &successful}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"hash":         hash,
+		"ledger":       h.ledger,
+		"envelope_xdr": envelopeXdr,
+		"result_xdr":   resultXdr,
+	})
+}
+
+// apply returns the result of a transaction with its `result_codes` and applies successful and
+// tx_failed transactions, h.mutex must be locked
+func (h *FakeHorizon) apply(tx *xdr.Transaction) (xdr.TransactionResult, map[string]interface{}) {
+	result := xdr.TransactionResult{FeeCharged: xdr.Int64(tx.Fee)}
+
+	source, ok := h.accounts[tx.SourceAccount.Address()]
+	if !ok {
+		result.Result.Code = xdr.TransactionResultCodeTxNoAccount
+		return result, transactionCodes(result.Result.Code, nil)
+	}
+	// Transactions with a sequence lower than an applied one were sent out of order
+	if uint64(tx.SeqNum) <= source.sequence {
+		result.Result.Code = xdr.TransactionResultCodeTxBadSeq
+		return result, transactionCodes(result.Result.Code, nil)
+	}
+
+	results := make([]xdr.OperationResult, len(tx.Operations))
+	names := make([]string, len(tx.Operations))
+	result.Result.Code = xdr.TransactionResultCodeTxSuccess
+	for i, op := range tx.Operations {
+		results[i], names[i] = h.operationResult(op)
+		if names[i] != "op_success" {
+			result.Result.Code = xdr.TransactionResultCodeTxFailed
+		}
+	}
+
+	if name, failure, ok := h.injectedFailure(); ok {
+		if failure.operation == nil {
+			result.Result.Code = failure.code
+			return result, transactionCodes(result.Result.Code, nil)
+		}
+		last := len(results) - 1
+		if operationResult, fails := failure.operation(tx.Operations[last]); fails {
+			results[last], names[last] = operationResult, name
+			result.Result.Code = xdr.TransactionResultCodeTxFailed
+		}
+	}
+	result.Result.Results = &results
+
+	// Sequence numbers are consumed by failed transactions too
+	source.sequence = uint64(tx.SeqNum)
+	if result.Result.Code != xdr.TransactionResultCodeTxSuccess {
+		return result, transactionCodes(result.Result.Code, names)
+	}
+	for _, op := range tx.Operations {
+		if op.Body.Type == xdr.OperationTypeCreateAccount {
+			destination := op.Body.CreateAccountOp.Destination.Address()
+			h.accounts[destination] = &fakeAccount{sequence: h.ledger << 32, balances: h.balances}
+		}
+	}
+	return result, nil
+}
+
+// injectedFailure draws a failure of HorizonSettings.Failures, codes are drawn in alphabetical
+// order so seeded runs are repeatable
+func (h *FakeHorizon) injectedFailure() (string, failure, bool) {
+	codes := make([]string, 0, len(h.settings.Failures))
+	for code := range h.settings.Failures {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if h.random.Float64() < h.settings.Failures[code] {
+			return code, failureResults[code], true
+		}
+	}
+	return "", failure{}, false
+}
+
+// operationResult returns the result of an operation and its code name
+func (h *FakeHorizon) operationResult(op xdr.Operation) (xdr.OperationResult, string) {
+	name := "op_success"
+	tr := xdr.OperationResultTr{Type: op.Body.Type}
+	switch op.Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		tr.CreateAccountResult = &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountSuccess}
+		if _, exists := h.accounts[op.Body.CreateAccountOp.Destination.Address()]; exists {
+			tr.CreateAccountResult.Code = xdr.CreateAccountResultCodeCreateAccountAlreadyExist
+			name = "op_already_exists"
+		}
+	case xdr.OperationTypePayment:
+		tr.PaymentResult = &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentSuccess}
+		if _, exists := h.accounts[op.Body.PaymentOp.Destination.Address()]; !exists {
+			tr.PaymentResult.Code = xdr.PaymentResultCodePaymentNoDestination
+			name = "op_no_destination"
+		}
+	case xdr.OperationTypePathPayment:
+		pathPayment := op.Body.PathPaymentOp
+		tr.PathPaymentResult = &xdr.PathPaymentResult{Code: xdr.PathPaymentResultCodePathPaymentNoDestination}
+		if _, exists := h.accounts[pathPayment.Destination.Address()]; !exists {
+			name = "op_no_destination"
+			break
+		}
+		// All of send_max is spent in a single offer
+		tr.PathPaymentResult.Code = xdr.PathPaymentResultCodePathPaymentSuccess
+		tr.PathPaymentResult.Success = &xdr.PathPaymentResultSuccess{
+			Offers: []xdr.ClaimOfferAtom{{
+				SellerId:     pathPayment.Destination,
+				AssetSold:    pathPayment.DestAsset,
+				AmountSold:   pathPayment.DestAmount,
+				AssetBought:  pathPayment.SendAsset,
+				AmountBought: pathPayment.SendMax,
+			}},
+			Last: xdr.SimplePaymentResult{Destination: pathPayment.Destination, Asset: pathPayment.DestAsset, Amount: pathPayment.DestAmount},
+		}
+	case xdr.OperationTypeChangeTrust:
+		tr.ChangeTrustResult = &xdr.ChangeTrustResult{Code: xdr.ChangeTrustResultCodeChangeTrustSuccess}
+	case xdr.OperationTypeAllowTrust:
+		tr.AllowTrustResult = &xdr.AllowTrustResult{Code: xdr.AllowTrustResultCodeAllowTrustSuccess}
+	case xdr.OperationTypeSetOptions:
+		tr.SetOptionsResult = &xdr.SetOptionsResult{Code: xdr.SetOptionsResultCodeSetOptionsSuccess}
+	case xdr.OperationTypeManageData:
+		tr.ManageDataResult = &xdr.ManageDataResult{Code: xdr.ManageDataResultCodeManageDataSuccess}
+	default:
+		return xdr.OperationResult{Code: xdr.OperationResultCodeOpBadAuth}, "op_bad_auth"
+	}
+	return xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &tr}, name
+}
+
+// transactionCodes returns `result_codes` of a failed transaction
+func transactionCodes(code xdr.TransactionResultCode, operations []string) map[string]interface{} {
+	name := "tx_failed"
+	if resultErr := horizon.NewTransactionResultError(code); resultErr != nil {
+		name = resultErr.Name
+	}
+	codes := map[string]interface{}{"transaction": name}
+	if operations != nil {
+		codes["operations"] = operations
+	}
+	return codes
+}
+
+func writeJSON(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeProblem(w http.ResponseWriter, status int, title string) {
+	writeJSON(w, status, map[string]interface{}{"title": title, "status": status})
+}
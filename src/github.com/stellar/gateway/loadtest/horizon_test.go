@@ -0,0 +1,113 @@
+package loadtest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols/bridge"
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSeed = "SDZT3EJZ7FZRYNTLOZ7VH6G5UYBFO2IO3Q5PGONMILPCZU3AL7QNZHTE"
+
+func newTestHorizon(t *testing.T, settings HorizonSettings) (*FakeHorizon, horizon.Horizon) {
+	fake := NewFakeHorizon(settings, build.TestNetwork.Passphrase, []horizon.Balance{
+		{AssetType: "credit_alphanum4", AssetCode: "USD", AssetIssuer: testDestination},
+	}, 1)
+	fake.sleep = func(d time.Duration) {}
+	fake.AddAccount(keypair.MustParse(testSeed).Address())
+	fake.AddAccount(testDestination)
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, horizon.New(server.URL)
+}
+
+// submit submits a transaction of the source account with the next sequence number
+func submit(t *testing.T, h horizon.Horizon, muts ...build.TransactionMutator) horizon.SubmitTransactionResponse {
+	account, err := h.LoadAccount(keypair.MustParse(testSeed).Address())
+	require.NoError(t, err)
+	sequence, err := strconv.ParseUint(account.SequenceNumber, 10, 64)
+	require.NoError(t, err)
+
+	muts = append([]build.TransactionMutator{
+		build.SourceAccount{AddressOrSeed: testSeed},
+		build.Sequence{Sequence: sequence + 1},
+		build.TestNetwork,
+	}, muts...)
+	envelope := build.Transaction(muts...).Sign(testSeed)
+	require.NoError(t, envelope.Err)
+	txeB64, err := envelope.Base64()
+	require.NoError(t, err)
+
+	response, err := h.SubmitTransaction(txeB64)
+	require.NoError(t, err)
+	return response
+}
+
+func TestFakeHorizonAccounts(t *testing.T) {
+	_, h := newTestHorizon(t, HorizonSettings{})
+
+	account, err := h.LoadAccount(testDestination)
+	require.NoError(t, err)
+	balance, ok := account.GetBalance("USD", testDestination)
+	require.True(t, ok)
+	assert.Equal(t, DefaultBalance, balance.Balance)
+
+	_, err = h.LoadAccount("GBIHSMPXC2KJ3NJVHEYTG3KCHYEUQRT45X6AWYWXMAXZOAX4F5LFZYYQ")
+	require.IsType(t, &horizon.StatusError{}, err)
+	assert.Equal(t, http.StatusNotFound, err.(*horizon.StatusError).StatusCode)
+
+	orderBook, err := h.LoadOrderBook(build.NativeAsset(), build.CreditAsset("USD", testDestination))
+	require.NoError(t, err)
+	assert.Empty(t, orderBook.Bids)
+}
+
+func TestFakeHorizonSubmit(t *testing.T) {
+	_, h := newTestHorizon(t, HorizonSettings{})
+	created := "GBIHSMPXC2KJ3NJVHEYTG3KCHYEUQRT45X6AWYWXMAXZOAX4F5LFZYYQ"
+
+	response := submit(t, h, build.CreateAccount(build.Destination{AddressOrSeed: created}, build.NativeAmount{Amount: "20"}))
+	require.NotNil(t, response.Ledger)
+	assert.Nil(t, bridge.ErrorFromHorizonResponse(response))
+	_, err := h.LoadAccount(created)
+	require.NoError(t, err)
+
+	transaction, err := h.LoadTransaction(response.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, *response.Ledger, transaction.Ledger)
+
+	response = submit(t, h, build.Payment(build.Destination{AddressOrSeed: created}, build.NativeAmount{Amount: "1"}))
+	require.NotNil(t, response.Ledger)
+
+	// Account created again
+	response = submit(t, h, build.CreateAccount(build.Destination{AddressOrSeed: created}, build.NativeAmount{Amount: "20"}))
+	require.Nil(t, response.Ledger)
+	var result xdr.TransactionResult
+	require.NoError(t, xdr.SafeUnmarshalBase64(response.Extras.ResultXdr, &result))
+	assert.Equal(t, xdr.TransactionResultCodeTxFailed, result.Result.Code)
+	assert.Equal(t, xdr.CreateAccountResultCodeCreateAccountAlreadyExist, (*result.Result.Results)[0].Tr.CreateAccountResult.Code)
+}
+
+func TestFakeHorizonFailures(t *testing.T) {
+	_, h := newTestHorizon(t, HorizonSettings{Failures: map[string]float64{"op_no_trust": 1}})
+	payment := build.Payment(build.Destination{AddressOrSeed: testDestination}, build.CreditAmount{Code: "USD", Issuer: testDestination, Amount: "1"})
+
+	response := submit(t, h, payment)
+	assert.Nil(t, response.Ledger)
+	assert.Equal(t, bridge.PaymentNoTrust.Code, bridge.ErrorFromHorizonResponse(response).Code)
+	assert.Nil(t, response.TransactionResultError())
+
+	_, h = newTestHorizon(t, HorizonSettings{Failures: map[string]float64{"tx_bad_seq": 1}})
+	response = submit(t, h, payment)
+	require.NotNil(t, response.TransactionResultError())
+	assert.Equal(t, "tx_bad_seq", response.TransactionResultError().Name)
+}
@@ -0,0 +1,146 @@
+// Package loadtest drives /payment of the bridge server with synthetic load and reports latency,
+// errors and the rate at which the server saturates. Payments are sent to a sandbox Horizon by
+// default so no real network is touched.
+package loadtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/simulation"
+)
+
+const (
+	// DefaultConcurrency is a number of requests sent at the same time by default
+	DefaultConcurrency = 64
+	// DefaultDurationSeconds is a length of a run by default, ramp-up included
+	DefaultDurationSeconds = 60
+)
+
+// Plan describes a load test run, it's read from a JSON file by `bridge loadtest --plan`
+type Plan struct {
+	// RPS is the target rate of /payment requests per second
+	RPS float64 `json:"rps"`
+	// RampUpSeconds is a time the rate grows linearly from 0 to RPS
+	RampUpSeconds float64 `json:"ramp_up_seconds"`
+	// DurationSeconds is a length of the run, ramp-up included
+	DurationSeconds float64 `json:"duration_seconds"`
+	// Concurrency is a maximum number of requests waiting for a response, requests due when all
+	// of them are waiting are dropped
+	Concurrency int `json:"concurrency"`
+	// Seed makes the choice of payments and injected failures repeatable when not 0
+	Seed int64 `json:"seed"`
+	// Destinations is a pool of destination account IDs used in turn
+	Destinations []string `json:"destinations"`
+	// Mix are payment types sent in proportion to their weights
+	Mix []Payment `json:"mix"`
+	// Horizon configures the sandbox Horizon, ignored with `--target`
+	Horizon HorizonSettings `json:"horizon"`
+	// UseConfigDatabase runs the in-process server with the database of the config instead of
+	// a temporary SQLite database
+	UseConfigDatabase bool `json:"use_config_database"`
+}
+
+// Payment is a type of payments of the mix
+type Payment struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+	// Params are /payment params, `destination` is taken from the pool when not set
+	Params map[string]string `json:"params"`
+	// NewDestination sends every payment to a new account, creating it
+	NewDestination bool `json:"new_destination"`
+}
+
+// HorizonSettings configures the sandbox Horizon
+type HorizonSettings struct {
+	// LatencyMS is a delay of account and order book requests
+	LatencyMS int `json:"latency_ms"`
+	// SubmitLatencyMS is a delay of transaction submissions (ledger close)
+	SubmitLatencyMS int `json:"submit_latency_ms"`
+	// Failures are rates (0 to 1) of submissions failing with a result code, ex. `tx_bad_seq` or
+	// `op_underfunded`
+	Failures map[string]float64 `json:"failures"`
+	// State contains accounts and order books, the source accounts of the config, destinations
+	// of the pool and accounts created by payments exist with default balances
+	State *simulation.State `json:"state"`
+}
+
+// LoadPlan reads a plan from a JSON file and validates it
+func LoadPlan(path string) (plan Plan, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, &plan)
+	if err != nil {
+		err = fmt.Errorf("Cannot decode plan: %s", err)
+		return
+	}
+
+	err = plan.Validate()
+	return
+}
+
+// Validate validates the plan and sets defaults
+func (p *Plan) Validate() error {
+	if p.RPS <= 0 {
+		return errors.New("rps must be positive")
+	}
+	if p.DurationSeconds == 0 {
+		p.DurationSeconds = DefaultDurationSeconds
+	}
+	if p.RampUpSeconds < 0 || p.RampUpSeconds > p.DurationSeconds {
+		return errors.New("ramp_up_seconds must be between 0 and duration_seconds")
+	}
+	if p.Concurrency == 0 {
+		p.Concurrency = DefaultConcurrency
+	}
+	if p.Concurrency < 0 {
+		return errors.New("concurrency must be positive")
+	}
+
+	if len(p.Mix) == 0 {
+		return errors.New("mix is empty")
+	}
+	for i, payment := range p.Mix {
+		if payment.Name == "" {
+			return fmt.Errorf("mix[%d].name is missing", i)
+		}
+		if payment.Weight <= 0 {
+			return fmt.Errorf("mix %s weight must be positive", payment.Name)
+		}
+		_, hasDestination := payment.Params["destination"]
+		if !hasDestination && !payment.NewDestination && len(p.Destinations) == 0 {
+			return fmt.Errorf("mix %s has no destination and destinations are empty", payment.Name)
+		}
+	}
+
+	for _, destination := range p.Destinations {
+		if !protocols.IsValidAccountID(destination) {
+			return fmt.Errorf("destination %s is not an account ID", destination)
+		}
+	}
+
+	for code, rate := range p.Horizon.Failures {
+		if _, ok := failureResults[code]; !ok {
+			return fmt.Errorf("horizon.failures: unknown result code %s", code)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("horizon.failures: rate of %s must be between 0 and 1", code)
+		}
+	}
+	return nil
+}
+
+// due returns a number of requests that should have been sent since the start, the rate grows
+// linearly during the ramp-up
+func (p *Plan) due(seconds float64) float64 {
+	if seconds < p.RampUpSeconds {
+		return p.RPS * seconds * seconds / (2 * p.RampUpSeconds)
+	}
+	return p.RPS*p.RampUpSeconds/2 + p.RPS*(seconds-p.RampUpSeconds)
+}
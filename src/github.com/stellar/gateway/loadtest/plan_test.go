@@ -0,0 +1,53 @@
+package loadtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDestination = "GBKGH7QZVCZ2ZA5OUGZSTHFNXTBHL3MPCKSCBJUAQODGPMWP7OMMRKDW"
+
+func TestPlanValidate(t *testing.T) {
+	plan := Plan{
+		RPS:          10,
+		Destinations: []string{testDestination},
+		Mix:          []Payment{{Name: "xlm", Weight: 1, Params: map[string]string{"amount": "1"}}},
+	}
+	require.NoError(t, plan.Validate())
+	assert.Equal(t, float64(DefaultDurationSeconds), plan.DurationSeconds)
+	assert.Equal(t, DefaultConcurrency, plan.Concurrency)
+
+	for _, test := range []struct {
+		name   string
+		change func(*Plan)
+		err    string
+	}{
+		{"rps", func(p *Plan) { p.RPS = 0 }, "rps must be positive"},
+		{"ramp-up", func(p *Plan) { p.RampUpSeconds = 120 }, "ramp_up_seconds must be between 0 and duration_seconds"},
+		{"empty mix", func(p *Plan) { p.Mix = nil }, "mix is empty"},
+		{"weight", func(p *Plan) { p.Mix[0].Weight = 0 }, "mix xlm weight must be positive"},
+		{"no destination", func(p *Plan) { p.Destinations = nil }, "mix xlm has no destination and destinations are empty"},
+		{"federated destination", func(p *Plan) { p.Destinations = []string{"bob*example.com"} }, "destination bob*example.com is not an account ID"},
+		{"unknown code", func(p *Plan) { p.Horizon.Failures = map[string]float64{"tx_unknown": 0.1} }, "horizon.failures: unknown result code tx_unknown"},
+		{"rate", func(p *Plan) { p.Horizon.Failures = map[string]float64{"tx_bad_seq": 2} }, "horizon.failures: rate of tx_bad_seq must be between 0 and 1"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			changed := plan
+			changed.Mix = append([]Payment(nil), plan.Mix...)
+			test.change(&changed)
+			assert.EqualError(t, changed.Validate(), test.err)
+		})
+	}
+}
+
+func TestPlanRamp(t *testing.T) {
+	plan := Plan{RPS: 100, RampUpSeconds: 10, DurationSeconds: 30}
+	assert.Equal(t, float64(125), plan.due(5))
+	assert.Equal(t, float64(500), plan.due(10))
+	assert.Equal(t, float64(2500), plan.due(30))
+
+	plan.RampUpSeconds = 0
+	assert.Equal(t, float64(150), plan.due(1.5))
+}
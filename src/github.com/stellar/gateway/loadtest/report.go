@@ -0,0 +1,149 @@
+package loadtest
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/stellar/gateway/utc"
+)
+
+// Saturation reasons
+const (
+	// SaturationWorkersBusy is reported when requests were dropped because `concurrency` requests
+	// were waiting for responses
+	SaturationWorkersBusy = "workers_busy"
+	// SaturationInflightFull is reported when the inflight registry of the server was full
+	SaturationInflightFull = "inflight_full"
+)
+
+// Codes of responses other than error responses
+const (
+	CodeSuccess      = "success"
+	CodeHandedOff    = "handed_off"
+	CodeRequestError = "request_error"
+)
+
+// Report is a result of a run
+type Report struct {
+	Target          string   `json:"target"`
+	StartedAt       utc.Time `json:"started_at"`
+	DurationSeconds float64  `json:"duration_seconds"`
+	// Requests is a number of requests sent
+	Requests int64 `json:"requests"`
+	// Dropped is a number of requests not sent because all workers were busy
+	Dropped int64 `json:"dropped"`
+	// Throughput is a number of successful (and handed off) payments per second
+	Throughput float64 `json:"throughput"`
+	// Latency is a latency of all responses
+	Latency Latency `json:"latency"`
+	// ByMix are latencies of responses by payment types of the mix
+	ByMix map[string]Latency `json:"by_mix"`
+	// ByStage are times payments spent in inflight stages, ex. `submitting`. It's empty when
+	// the server is not in the same process.
+	ByStage map[string]Latency `json:"by_stage"`
+	// Codes are numbers of responses by `code` of the error response, `success` or `handed_off`
+	Codes map[string]int64 `json:"codes"`
+	// Inflight is nil when /admin/inflight cannot be loaded
+	Inflight *InflightReport `json:"inflight"`
+	Timeline []Second        `json:"timeline"`
+	// Saturation is the first second the server could not keep up, nil when it did
+	Saturation *Saturation `json:"saturation"`
+}
+
+// Latency contains percentiles of latencies in milliseconds
+type Latency struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// InflightReport contains maximums of the inflight registry of the server
+type InflightReport struct {
+	// Max is the largest number of payments processed at the same time
+	Max int `json:"max"`
+	// Size is a number of payments the registry tracks
+	Size int `json:"size"`
+	// Untracked is a number of payments started when the registry was full during the run
+	Untracked int64 `json:"untracked"`
+}
+
+// Second contains requests of a single second of the run, responses are counted in the second
+// they were received
+type Second struct {
+	Second    int     `json:"second"`
+	TargetRPS float64 `json:"target_rps"`
+	Sent      int     `json:"sent"`
+	Dropped   int     `json:"dropped"`
+	Completed int     `json:"completed"`
+	Errors    int     `json:"errors"`
+	P99       float64 `json:"p99_ms"`
+	// Busy is the largest number of requests waiting for responses
+	Busy int `json:"busy"`
+	// Inflight is the largest number of payments processed by the server
+	Inflight  int   `json:"inflight"`
+	Untracked int64 `json:"untracked"`
+}
+
+// Saturation is the point the server could not keep up with the target rate
+type Saturation struct {
+	AtSeconds int     `json:"at_seconds"`
+	RPS       float64 `json:"rps"`
+	// Throughput is a number of successful payments in the second
+	Throughput float64 `json:"throughput"`
+	Reason     string  `json:"reason"`
+}
+
+// newLatency returns percentiles of latencies
+func newLatency(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return Latency{
+		Count: len(sorted),
+		P50:   milliseconds(percentile(sorted, 0.5)),
+		P90:   milliseconds(percentile(sorted, 0.9)),
+		P99:   milliseconds(percentile(sorted, 0.99)),
+		Max:   milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
+
+// saturation returns the first second requests were dropped or the inflight registry was full
+func saturation(timeline []Second) *Saturation {
+	for _, second := range timeline {
+		reason := ""
+		switch {
+		case second.Dropped > 0:
+			reason = SaturationWorkersBusy
+		case second.Untracked > 0:
+			reason = SaturationInflightFull
+		default:
+			continue
+		}
+		return &Saturation{
+			AtSeconds:  second.Second,
+			RPS:        second.TargetRPS,
+			Throughput: float64(second.Completed - second.Errors),
+			Reason:     reason,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/inflight"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/keypair"
+)
+
+const (
+	// dispatchInterval is a time between checks of requests due
+	dispatchInterval = 10 * time.Millisecond
+	// sampleInterval is a time between samples of busy workers and in-flight payments
+	sampleInterval = 100 * time.Millisecond
+)
+
+// Runner sends payments of a plan to a target
+type Runner struct {
+	plan       Plan
+	target     Target
+	targetName string
+	now        func() time.Time
+
+	mutex       sync.Mutex
+	random      *rand.Rand
+	destination int
+	started     time.Time
+	requests    int64
+	dropped     int64
+	timeline    []Second
+	latencies   []time.Duration
+	secondLats  map[int][]time.Duration
+	byMix       map[string][]time.Duration
+	byStage     map[string][]time.Duration
+	codes       map[string]int64
+	inflight    *InflightReport
+	untracked   int64
+	totalWeight float64
+}
+
+// NewRunner creates a new Runner, targetName is reported as Report.Target
+func NewRunner(plan Plan, target Target, targetName string) *Runner {
+	seed := plan.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	r := &Runner{
+		plan:       plan,
+		target:     target,
+		targetName: targetName,
+		now:        time.Now,
+		random:     rand.New(rand.NewSource(seed)),
+		secondLats: map[int][]time.Duration{},
+		byMix:      map[string][]time.Duration{},
+		byStage:    map[string][]time.Duration{},
+		codes:      map[string]int64{},
+	}
+	for _, payment := range plan.Mix {
+		r.totalWeight += payment.Weight
+	}
+	return r
+}
+
+// ObservePayment records times of stages of a payment, it's set as inflight.Registry.OnDone of
+// servers in the same process
+func (r *Runner) ObservePayment(status inflight.PaymentStatus) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, stage := range status.Stages {
+		r.byStage[stage.Stage] = append(r.byStage[stage.Stage], time.Duration(stage.ElapsedSeconds*float64(time.Second)))
+	}
+}
+
+// Run sends payments until the end of the plan and waits for their responses
+func (r *Runner) Run() Report {
+	workers := make(chan struct{}, r.plan.Concurrency)
+	var wg sync.WaitGroup
+
+	r.mutex.Lock()
+	r.started = r.now()
+	r.mutex.Unlock()
+
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go r.sample(workers, stop, sampled)
+
+	ticker := time.NewTicker(dispatchInterval)
+	sent := 0
+	for done := false; !done; {
+		elapsed := r.now().Sub(r.started).Seconds()
+		if elapsed >= r.plan.DurationSeconds {
+			// Requests due in the last interval are sent too
+			elapsed = r.plan.DurationSeconds
+			done = true
+		}
+
+		for due := int(r.plan.due(elapsed)); sent < due; sent++ {
+			name, params := r.nextPayment()
+			select {
+			case workers <- struct{}{}:
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					r.send(name, params)
+					<-workers
+				}()
+			default:
+				r.record(func(second *Second) { second.Dropped++ })
+				r.mutex.Lock()
+				r.dropped++
+				r.mutex.Unlock()
+			}
+		}
+		if !done {
+			<-ticker.C
+		}
+	}
+	ticker.Stop()
+
+	wg.Wait()
+	close(stop)
+	<-sampled
+	return r.report()
+}
+
+// nextPayment returns a payment type of the mix and /payment params
+func (r *Runner) nextPayment() (string, url.Values) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	payment := r.plan.Mix[len(r.plan.Mix)-1]
+	pick := r.random.Float64() * r.totalWeight
+	for _, candidate := range r.plan.Mix {
+		if pick < candidate.Weight {
+			payment = candidate
+			break
+		}
+		pick -= candidate.Weight
+	}
+
+	params := url.Values{}
+	for name, value := range payment.Params {
+		params.Set(name, value)
+	}
+	if params.Get("destination") == "" {
+		if payment.NewDestination {
+			params.Set("destination", r.newAccountID())
+		} else {
+			params.Set("destination", r.plan.Destinations[r.destination%len(r.plan.Destinations)])
+			r.destination++
+		}
+	}
+	return payment.Name, params
+}
+
+// newAccountID returns an ID of a new account, r.mutex must be locked
+func (r *Runner) newAccountID() string {
+	var seed [32]byte
+	r.random.Read(seed[:])
+	kp, _ := keypair.FromRawSeed(seed)
+	return kp.Address()
+}
+
+func (r *Runner) send(name string, params url.Values) {
+	r.record(func(second *Second) { second.Sent++ })
+	started := r.now()
+	status, body, err := r.target.Pay(params)
+	latency := r.now().Sub(started)
+
+	code := responseCode(status, body, err)
+	failed := code != CodeSuccess && code != CodeHandedOff
+
+	r.mutex.Lock()
+	r.requests++
+	r.latencies = append(r.latencies, latency)
+	r.byMix[name] = append(r.byMix[name], latency)
+	r.codes[code]++
+	second := r.second()
+	r.secondLats[second] = append(r.secondLats[second], latency)
+	r.timeline[second].Completed++
+	if failed {
+		r.timeline[second].Errors++
+	}
+	r.mutex.Unlock()
+}
+
+// responseCode returns `code` of an error response, a response that is not JSON is reported by
+// its HTTP status
+func responseCode(status int, body []byte, err error) string {
+	if err != nil {
+		return CodeRequestError
+	}
+	switch status {
+	case http.StatusOK:
+		return CodeSuccess
+	case http.StatusAccepted:
+		return CodeHandedOff
+	}
+
+	var response struct {
+		Code string `json:"code"`
+	}
+	if json.Unmarshal(body, &response) == nil && response.Code != "" {
+		return response.Code
+	}
+	return "http_" + strconv.Itoa(status)
+}
+
+// sample records busy workers and in-flight payments until stop is closed
+func (r *Runner) sample(workers chan struct{}, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		busy := len(workers)
+		snapshot, ok := r.target.Inflight()
+
+		r.mutex.Lock()
+		second := r.second()
+		if busy > r.timeline[second].Busy {
+			r.timeline[second].Busy = busy
+		}
+		if ok {
+			if r.inflight == nil {
+				r.inflight = &InflightReport{Size: snapshot.Size}
+				r.untracked = snapshot.Untracked
+			}
+			if len(snapshot.Payments) > r.inflight.Max {
+				r.inflight.Max = len(snapshot.Payments)
+			}
+			if len(snapshot.Payments) > r.timeline[second].Inflight {
+				r.timeline[second].Inflight = len(snapshot.Payments)
+			}
+			r.timeline[second].Untracked += snapshot.Untracked - r.untracked
+			r.inflight.Untracked += snapshot.Untracked - r.untracked
+			r.untracked = snapshot.Untracked
+		}
+		r.mutex.Unlock()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// record updates the current second
+func (r *Runner) record(fn func(*Second)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	second := r.second()
+	fn(&r.timeline[second])
+}
+
+// second returns the index of the current second in the timeline, r.mutex must be locked
+func (r *Runner) second() int {
+	second := int(r.now().Sub(r.started) / time.Second)
+	for len(r.timeline) <= second {
+		i := len(r.timeline)
+		r.timeline = append(r.timeline, Second{
+			Second:    i,
+			TargetRPS: r.plan.due(float64(i+1)) - r.plan.due(float64(i)),
+		})
+	}
+	return second
+}
+
+func (r *Runner) report() Report {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	duration := r.now().Sub(r.started).Seconds()
+	report := Report{
+		Target:          r.targetName,
+		StartedAt:       utc.New(r.started),
+		DurationSeconds: duration,
+		Requests:        r.requests,
+		Dropped:         r.dropped,
+		Throughput:      float64(r.codes[CodeSuccess]+r.codes[CodeHandedOff]) / duration,
+		Latency:         newLatency(r.latencies),
+		ByMix:           map[string]Latency{},
+		ByStage:         map[string]Latency{},
+		Codes:           r.codes,
+		Inflight:        r.inflight,
+		Timeline:        r.timeline,
+	}
+	for name, latencies := range r.byMix {
+		report.ByMix[name] = newLatency(latencies)
+	}
+	for name, latencies := range r.byStage {
+		report.ByStage[name] = newLatency(latencies)
+	}
+	for i := range report.Timeline {
+		report.Timeline[i].P99 = newLatency(r.secondLats[i]).P99
+		// Responses of the last requests can arrive after the end of the run
+		if float64(i) >= r.plan.DurationSeconds {
+			report.Timeline[i].TargetRPS = 0
+		}
+	}
+	report.Saturation = saturation(report.Timeline)
+	return report
+}
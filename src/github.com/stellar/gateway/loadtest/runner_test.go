@@ -0,0 +1,123 @@
+package loadtest
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/inflight"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubTarget struct {
+	mutex    sync.Mutex
+	delay    time.Duration
+	requests []url.Values
+}
+
+func (t *stubTarget) Pay(params url.Values) (int, []byte, error) {
+	t.mutex.Lock()
+	t.requests = append(t.requests, params)
+	t.mutex.Unlock()
+	time.Sleep(t.delay)
+
+	switch params.Get("amount") {
+	case "1":
+		return 200, []byte(`{"hash": "ab12"}`), nil
+	case "2":
+		return 400, []byte(`{"code": "payment_underfunded"}`), nil
+	case "3":
+		return 502, []byte(`Bad Gateway`), nil
+	}
+	return 0, nil, errors.New("connection refused")
+}
+
+func (t *stubTarget) Inflight() (inflight.Snapshot, bool) {
+	return inflight.Snapshot{}, false
+}
+
+func TestRunner(t *testing.T) {
+	plan := Plan{
+		RPS:             100,
+		DurationSeconds: 0.5,
+		Concurrency:     100,
+		Seed:            1,
+		Destinations:    []string{testDestination, "GBIHSMPXC2KJ3NJVHEYTG3KCHYEUQRT45X6AWYWXMAXZOAX4F5LFZYYQ"},
+		Mix: []Payment{
+			{Name: "ok", Weight: 1, Params: map[string]string{"amount": "1"}},
+			{Name: "underfunded", Weight: 1, Params: map[string]string{"amount": "2"}},
+			{Name: "gateway", Weight: 1, Params: map[string]string{"amount": "3"}},
+			{Name: "refused", Weight: 1, Params: map[string]string{"amount": "4"}, NewDestination: true},
+		},
+	}
+	require.NoError(t, plan.Validate())
+	target := &stubTarget{delay: time.Millisecond}
+	runner := NewRunner(plan, target, "stub")
+	runner.ObservePayment(inflight.PaymentStatus{Stages: []inflight.StageStatus{{Stage: inflight.StageSubmitting, ElapsedSeconds: 0.25}}})
+
+	report := runner.Run()
+	assert.Equal(t, "stub", report.Target)
+	assert.Equal(t, int64(50), report.Requests)
+	assert.Equal(t, int64(0), report.Dropped)
+	assert.Equal(t, 50, report.Latency.Count)
+	assert.Nil(t, report.Inflight)
+	assert.Nil(t, report.Saturation)
+	assert.Equal(t, Latency{Count: 1, P50: 250, P90: 250, P99: 250, Max: 250}, report.ByStage[inflight.StageSubmitting])
+
+	counts := map[string]int{}
+	for name, latency := range report.ByMix {
+		counts[name] = latency.Count
+	}
+	assert.Equal(t, map[string]int64{
+		CodeSuccess:           int64(counts["ok"]),
+		"payment_underfunded": int64(counts["underfunded"]),
+		"http_502":            int64(counts["gateway"]),
+		CodeRequestError:      int64(counts["refused"]),
+	}, report.Codes)
+
+	destinations := map[string]int{}
+	for _, params := range target.requests {
+		destinations[params.Get("destination")]++
+	}
+	pool := destinations[testDestination] + destinations["GBIHSMPXC2KJ3NJVHEYTG3KCHYEUQRT45X6AWYWXMAXZOAX4F5LFZYYQ"]
+	assert.Equal(t, 50-counts["refused"], pool)
+	assert.Len(t, destinations, 2+counts["refused"])
+}
+
+func TestRunnerSaturation(t *testing.T) {
+	plan := Plan{
+		RPS:             50,
+		DurationSeconds: 1,
+		Concurrency:     2,
+		Destinations:    []string{testDestination},
+		Mix:             []Payment{{Name: "ok", Weight: 1, Params: map[string]string{"amount": "1"}}},
+	}
+	require.NoError(t, plan.Validate())
+
+	report := NewRunner(plan, &stubTarget{delay: 200 * time.Millisecond}, "stub").Run()
+	assert.Equal(t, int64(50), report.Requests+report.Dropped)
+	assert.True(t, report.Dropped > 0)
+	require.NotNil(t, report.Saturation)
+	assert.Equal(t, SaturationWorkersBusy, report.Saturation.Reason)
+	assert.Equal(t, 0, report.Saturation.AtSeconds)
+	assert.Equal(t, float64(50), report.Saturation.RPS)
+	assert.Equal(t, 2, report.Timeline[0].Busy)
+}
+
+func TestLatency(t *testing.T) {
+	latencies := []time.Duration{}
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, Latency{Count: 100, P50: 50, P90: 90, P99: 99, Max: 100}, newLatency(latencies))
+	assert.Equal(t, Latency{}, newLatency(nil))
+
+	assert.Nil(t, saturation([]Second{{Second: 0, Completed: 10}}))
+	assert.Equal(t, &Saturation{AtSeconds: 1, RPS: 20, Throughput: 8, Reason: SaturationInflightFull}, saturation([]Second{
+		{Second: 0, TargetRPS: 10, Completed: 10},
+		{Second: 1, TargetRPS: 20, Completed: 10, Errors: 2, Untracked: 3},
+	}))
+}
@@ -0,0 +1,106 @@
+package loadtest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/inflight"
+)
+
+// requestTimeout is a timeout of requests sent to a running bridge server
+const requestTimeout = 2 * time.Minute
+
+// Target is a bridge server receiving payments
+type Target interface {
+	// Pay sends a /payment request and returns the status and body of the response
+	Pay(params url.Values) (status int, body []byte, err error)
+	// Inflight returns payments being processed, false when they can't be loaded
+	Inflight() (inflight.Snapshot, bool)
+}
+
+// HandlerTarget sends requests to a handler in the same process
+type HandlerTarget struct {
+	Handler  http.Handler
+	Registry *inflight.Registry
+}
+
+// Pay implements Target
+func (t *HandlerTarget) Pay(params url.Values) (int, []byte, error) {
+	request := httptest.NewRequest("POST", "/payment", strings.NewReader(params.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	t.Handler.ServeHTTP(recorder, request)
+	return recorder.Code, recorder.Body.Bytes(), nil
+}
+
+// Inflight implements Target
+func (t *HandlerTarget) Inflight() (inflight.Snapshot, bool) {
+	if t.Registry == nil {
+		return inflight.Snapshot{}, false
+	}
+	return t.Registry.Snapshot(), true
+}
+
+// HTTPTarget sends requests to a running bridge server
+type HTTPTarget struct {
+	url    string
+	apiKey string
+	client *http.Client
+
+	mutex sync.Mutex
+	// noInflight is set when /admin/inflight failed, it's not requested again
+	noInflight bool
+}
+
+// NewHTTPTarget creates a new HTTPTarget. /admin/inflight does not accept API keys (GET) so
+// in-flight payments are not reported when apiKey is set.
+func NewHTTPTarget(serverURL, apiKey string) *HTTPTarget {
+	return &HTTPTarget{
+		url:    strings.TrimSuffix(serverURL, "/"),
+		apiKey: apiKey,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Pay implements Target
+func (t *HTTPTarget) Pay(params url.Values) (int, []byte, error) {
+	if t.apiKey != "" {
+		params.Set("apiKey", t.apiKey)
+	}
+	resp, err := t.client.PostForm(t.url+"/payment", params)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, body, err
+}
+
+// Inflight implements Target
+func (t *HTTPTarget) Inflight() (inflight.Snapshot, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.noInflight || t.apiKey != "" {
+		return inflight.Snapshot{}, false
+	}
+
+	var snapshot inflight.Snapshot
+	resp, err := t.client.Get(t.url + "/admin/inflight")
+	if err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&snapshot)
+			if err == nil {
+				return snapshot, true
+			}
+		}
+	}
+	t.noInflight = true
+	return inflight.Snapshot{}, false
+}
@@ -0,0 +1,19 @@
+package logging
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// Formatter wraps a logrus.Formatter and drops entries rejected by Sampler
+type Formatter struct {
+	Formatter logrus.Formatter
+	Sampler   *Sampler
+}
+
+// Format implements logrus.Formatter. Dropped entries are formatted as empty bytes.
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !f.Sampler.Sample(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
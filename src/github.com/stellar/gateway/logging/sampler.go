@@ -0,0 +1,157 @@
+// Package logging implements sampling of info and debug logs on high-volume payment paths
+package logging
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/utc"
+)
+
+const (
+	// CategoryField is a name of the log field containing a sampling category of the entry.
+	// Entries without it are never sampled.
+	CategoryField = "category"
+	// RequestIDField is a name of the log field used to sample all entries of a request together
+	RequestIDField = "request_id"
+)
+
+const (
+	// CategoryHandler contains logs of HTTP request handlers
+	CategoryHandler = "handler"
+	// CategoryHorizon contains logs of requests sent to Horizon
+	CategoryHorizon = "horizon"
+	// CategoryListener contains logs of the payment listener
+	CategoryListener = "listener"
+	// CategoryCallbacks contains logs of callbacks sent to the receive and compliance servers
+	CategoryCallbacks = "callbacks"
+)
+
+// Categories is a list of all sampling categories
+var Categories = []string{CategoryHandler, CategoryHorizon, CategoryListener, CategoryCallbacks}
+
+// SamplerStatus is returned by /admin/log-sampling endpoint
+type SamplerStatus struct {
+	Rates map[string]float64 `json:"rates"`
+	// BurstUntil is set when full logging is temporarily restored
+	BurstUntil *utc.Time `json:"burst_until"`
+}
+
+// Sampler decides which log entries are emitted. Entries with level Warn and above are always
+// emitted. Other entries are emitted with a probability equal to the rate of their category. The
+// decision is made on RequestIDField when the entry has it so a request is logged fully or not at all.
+type Sampler struct {
+	mutex      sync.RWMutex
+	rates      map[string]float64
+	burstUntil utc.Time
+
+	now    func() time.Time
+	random func() float64
+}
+
+// NewSampler creates a new Sampler. Categories missing in rates are logged fully.
+func NewSampler(rates map[string]float64) (*Sampler, error) {
+	s := &Sampler{
+		rates:  make(map[string]float64),
+		now:    time.Now,
+		random: rand.Float64,
+	}
+	for _, category := range Categories {
+		s.rates[category] = 1
+	}
+	for category, rate := range rates {
+		err := s.SetRate(category, rate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// SetRate sets a sample rate (0 to 1) of a category
+func (s *Sampler) SetRate(category string, rate float64) error {
+	if !isCategory(category) {
+		return fmt.Errorf("unknown log category: %s", category)
+	}
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("sample rate of %s must be between 0 and 1", category)
+	}
+
+	s.mutex.Lock()
+	s.rates[category] = rate
+	s.mutex.Unlock()
+	return nil
+}
+
+// Burst restores full logging for a given duration. Zero duration ends the burst.
+func (s *Sampler) Burst(d time.Duration) {
+	s.mutex.Lock()
+	s.burstUntil = utc.New(s.now().Add(d))
+	s.mutex.Unlock()
+}
+
+// Status returns current rates and burst mode
+func (s *Sampler) Status() SamplerStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	status := SamplerStatus{Rates: make(map[string]float64)}
+	for category, rate := range s.rates {
+		status.Rates[category] = rate
+	}
+	if s.now().Before(s.burstUntil.Time()) {
+		burstUntil := s.burstUntil
+		status.BurstUntil = &burstUntil
+	}
+	return status
+}
+
+// Sample returns true if the entry should be emitted
+func (s *Sampler) Sample(entry *logrus.Entry) bool {
+	if entry.Level <= logrus.WarnLevel {
+		return true
+	}
+
+	category, ok := entry.Data[CategoryField].(string)
+	if !ok {
+		return true
+	}
+
+	s.mutex.RLock()
+	rate, ok := s.rates[category]
+	burst := s.now().Before(s.burstUntil.Time())
+	s.mutex.RUnlock()
+
+	if !ok || burst || rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	if requestID, ok := entry.Data[RequestIDField]; ok {
+		return requestFraction(fmt.Sprint(requestID)) < rate
+	}
+	return s.random() < rate
+}
+
+// requestFraction maps a request ID to [0, 1). Since the value does not depend on a category, a
+// request sampled in a category is also sampled in every category with a higher rate.
+func requestFraction(requestID string) float64 {
+	hash := fnv.New32a()
+	hash.Write([]byte(requestID))
+	return float64(hash.Sum32()) / (1 << 32)
+}
+
+func isCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
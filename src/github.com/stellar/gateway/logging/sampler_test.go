@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func entry(level logrus.Level, fields logrus.Fields) *logrus.Entry {
+	e := logrus.WithFields(fields)
+	e.Level = level
+	return e
+}
+
+func TestSampler(t *testing.T) {
+	Convey("Sampler", t, func() {
+		sampler, err := NewSampler(map[string]float64{
+			CategoryHandler: 0.25,
+			CategoryHorizon: 0,
+		})
+		require.NoError(t, err)
+
+		now := time.Unix(1500000000, 0)
+		sampler.now = func() time.Time { return now }
+
+		Convey("validates rates", func() {
+			_, err := NewSampler(map[string]float64{"unknown": 0.5})
+			assert.Error(t, err)
+			_, err = NewSampler(map[string]float64{CategoryListener: 1.5})
+			assert.Error(t, err)
+			assert.Error(t, sampler.SetRate(CategoryListener, -0.1))
+		})
+
+		Convey("always emits warnings and errors", func() {
+			for _, level := range []logrus.Level{logrus.WarnLevel, logrus.ErrorLevel} {
+				assert.True(t, sampler.Sample(entry(level, logrus.Fields{CategoryField: CategoryHorizon})))
+			}
+			assert.False(t, sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryHorizon})))
+		})
+
+		Convey("always emits entries without category", func() {
+			assert.True(t, sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{"service": "Horizon"})))
+		})
+
+		Convey("emits categories missing in rates", func() {
+			assert.True(t, sampler.Sample(entry(logrus.DebugLevel, logrus.Fields{CategoryField: CategoryListener})))
+		})
+
+		Convey("keeps all entries of a request together", func() {
+			sampled := 0
+			for i := 0; i < 10000; i++ {
+				id := fmt.Sprintf("request-%d", i)
+				first := sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryHandler, RequestIDField: id}))
+				for j := 0; j < 3; j++ {
+					again := sampler.Sample(entry(logrus.DebugLevel, logrus.Fields{CategoryField: CategoryHandler, RequestIDField: id, "line": j}))
+					require.Equal(t, first, again, id)
+				}
+				if first {
+					sampled++
+				}
+			}
+			assert.InDelta(t, 2500, sampled, 250)
+		})
+
+		Convey("request sampled with a lower rate is sampled with a higher rate", func() {
+			require.NoError(t, sampler.SetRate(CategoryCallbacks, 0.5))
+			for i := 0; i < 10000; i++ {
+				id := fmt.Sprintf("request-%d", i)
+				if sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryHandler, RequestIDField: id})) {
+					require.True(t, sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryCallbacks, RequestIDField: id})), id)
+				}
+			}
+		})
+
+		Convey("samples entries without request ID randomly", func() {
+			sampler.random = func() float64 { return 0.2 }
+			assert.True(t, sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryHandler})))
+			sampler.random = func() float64 { return 0.3 }
+			assert.False(t, sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryHandler})))
+		})
+
+		Convey("burst restores full logging", func() {
+			sampler.Burst(10 * time.Minute)
+			assert.True(t, sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryHorizon})))
+
+			status := sampler.Status()
+			require.NotNil(t, status.BurstUntil)
+			assert.Equal(t, "2017-07-14T02:50:00Z", status.BurstUntil.String())
+			assert.Equal(t, 0.25, status.Rates[CategoryHandler])
+
+			now = now.Add(10 * time.Minute)
+			assert.False(t, sampler.Sample(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryHorizon})))
+			assert.Nil(t, sampler.Status().BurstUntil)
+		})
+	})
+}
+
+func TestFormatter(t *testing.T) {
+	sampler, err := NewSampler(map[string]float64{CategoryHorizon: 0})
+	require.NoError(t, err)
+	formatter := &Formatter{Formatter: &logrus.JSONFormatter{}, Sampler: sampler}
+
+	data, err := formatter.Format(entry(logrus.InfoLevel, logrus.Fields{CategoryField: CategoryHorizon}))
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+
+	data, err = formatter.Format(entry(logrus.ErrorLevel, logrus.Fields{CategoryField: CategoryHorizon}))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"category":"horizon"`)
+}
@@ -0,0 +1,188 @@
+// Package market contains helpers estimating execution price of path payments using order books
+package market
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/stellar/gateway/horizon"
+)
+
+// ErrInsufficientLiquidity is returned when order books do not contain enough offers to fill the amount
+var ErrInsufficientLiquidity = errors.New("not enough offers to fill the amount")
+
+// Level is a single price level of an order book. Price is an amount of counter asset paid for a
+// unit of base asset and Amount is an amount of base asset available at this price.
+type Level struct {
+	Price  *big.Rat
+	Amount *big.Rat
+}
+
+// OrderBook contains offers selling base asset for counter asset, best price first.
+type OrderBook struct {
+	Asks []Level
+}
+
+// NewOrderBook creates OrderBook from asks of Horizon order book summary
+func NewOrderBook(response horizon.OrderBookResponse) (book OrderBook, err error) {
+	for _, ask := range response.Asks {
+		price, ok := new(big.Rat).SetString(ask.Price)
+		if !ok {
+			err = fmt.Errorf("invalid price: %s", ask.Price)
+			return
+		}
+
+		amount, ok := new(big.Rat).SetString(ask.Amount)
+		if !ok {
+			err = fmt.Errorf("invalid amount: %s", ask.Amount)
+			return
+		}
+
+		book.Asks = append(book.Asks, Level{Price: price, Amount: amount})
+	}
+	return
+}
+
+// BuyCost returns an amount of counter asset needed to buy a given amount of base asset
+func (book OrderBook) BuyCost(amount *big.Rat) (*big.Rat, error) {
+	cost := new(big.Rat)
+	remaining := new(big.Rat).Set(amount)
+
+	for _, level := range book.Asks {
+		if remaining.Sign() <= 0 {
+			break
+		}
+
+		fill := level.Amount
+		if fill.Cmp(remaining) > 0 {
+			fill = remaining
+		}
+
+		cost.Add(cost, new(big.Rat).Mul(fill, level.Price))
+		remaining = new(big.Rat).Sub(remaining, fill)
+	}
+
+	if remaining.Sign() > 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	return cost, nil
+}
+
+// SellProceeds returns an amount of base asset bought with a given amount of counter asset
+func (book OrderBook) SellProceeds(amount *big.Rat) (*big.Rat, error) {
+	proceeds := new(big.Rat)
+	remaining := new(big.Rat).Set(amount)
+
+	for _, level := range book.Asks {
+		if remaining.Sign() <= 0 {
+			break
+		}
+
+		cost := new(big.Rat).Mul(level.Amount, level.Price)
+		if cost.Cmp(remaining) > 0 {
+			proceeds.Add(proceeds, new(big.Rat).Quo(remaining, level.Price))
+			remaining = new(big.Rat)
+			break
+		}
+
+		proceeds.Add(proceeds, level.Amount)
+		remaining = new(big.Rat).Sub(remaining, cost)
+	}
+
+	if remaining.Sign() > 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	return proceeds, nil
+}
+
+// BestPrice returns a price of the first level or nil when order book is empty
+func (book OrderBook) BestPrice() *big.Rat {
+	if len(book.Asks) == 0 {
+		return nil
+	}
+	return book.Asks[0].Price
+}
+
+// Estimate contains estimated execution of a path payment
+type Estimate struct {
+	// SendAmount is an estimated amount of send asset needed
+	SendAmount *big.Rat
+	// DestinationAmount is an estimated amount of destination asset received
+	DestinationAmount *big.Rat
+	// Price is an estimated price: SendAmount / destination amount
+	Price *big.Rat
+	// BestPrice is a price of the path at the top of order books
+	BestPrice *big.Rat
+}
+
+// Slippage returns Price relative to BestPrice, ex. 0.03 means 3% worse than the best price
+func (estimate Estimate) Slippage() *big.Rat {
+	slippage := new(big.Rat).Quo(estimate.Price, estimate.BestPrice)
+	return slippage.Sub(slippage, big.NewRat(1, 1))
+}
+
+// EstimatePath estimates execution of a path payment delivering destinationAmount. books must be
+// ordered from send asset to destination asset: books[i] is an order book selling the i+1 asset of
+// the path for the i asset (path includes send and destination assets).
+func EstimatePath(books []OrderBook, destinationAmount *big.Rat) (estimate Estimate, err error) {
+	if len(books) == 0 || destinationAmount.Sign() <= 0 {
+		err = errors.New("at least one order book and positive amount required")
+		return
+	}
+
+	amount := destinationAmount
+	bestPrice := big.NewRat(1, 1)
+	for i := len(books) - 1; i >= 0; i-- {
+		best := books[i].BestPrice()
+		if best == nil {
+			err = ErrInsufficientLiquidity
+			return
+		}
+		bestPrice.Mul(bestPrice, best)
+
+		amount, err = books[i].BuyCost(amount)
+		if err != nil {
+			return
+		}
+	}
+
+	estimate.SendAmount = amount
+	estimate.DestinationAmount = destinationAmount
+	estimate.Price = new(big.Rat).Quo(amount, destinationAmount)
+	estimate.BestPrice = bestPrice
+	return
+}
+
+// EstimateSale estimates execution of a path payment selling sendAmount of send asset for as much
+// destination asset as order books allow. books are ordered like in EstimatePath.
+func EstimateSale(books []OrderBook, sendAmount *big.Rat) (estimate Estimate, err error) {
+	if len(books) == 0 || sendAmount.Sign() <= 0 {
+		err = errors.New("at least one order book and positive amount required")
+		return
+	}
+
+	amount := sendAmount
+	bestPrice := big.NewRat(1, 1)
+	for _, book := range books {
+		best := book.BestPrice()
+		if best == nil {
+			err = ErrInsufficientLiquidity
+			return
+		}
+		bestPrice.Mul(bestPrice, best)
+
+		amount, err = book.SellProceeds(amount)
+		if err != nil {
+			return
+		}
+	}
+
+	estimate.SendAmount = sendAmount
+	estimate.DestinationAmount = amount
+	estimate.Price = new(big.Rat).Quo(sendAmount, amount)
+	estimate.BestPrice = bestPrice
+	return
+}
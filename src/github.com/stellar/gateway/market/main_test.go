@@ -0,0 +1,125 @@
+package market
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadOrderBook(t *testing.T, name string) OrderBook {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+
+	var response horizon.OrderBookResponse
+	require.NoError(t, json.Unmarshal(data, &response))
+
+	book, err := NewOrderBook(response)
+	require.NoError(t, err)
+	return book
+}
+
+func rat(s string) *big.Rat {
+	r, _ := new(big.Rat).SetString(s)
+	return r
+}
+
+func TestMarket(t *testing.T) {
+	deep := loadOrderBook(t, "usd_xlm_deep.json")
+	thin := loadOrderBook(t, "usd_xlm_thin.json")
+	eurUsd := loadOrderBook(t, "eur_usd.json")
+
+	Convey("OrderBook.BuyCost", t, func() {
+		Convey("fills within the first level", func() {
+			cost, err := thin.BuyCost(rat("50"))
+			assert.NoError(t, err)
+			assert.Equal(t, rat("250"), cost)
+		})
+
+		Convey("walks the book", func() {
+			cost, err := thin.BuyCost(rat("300"))
+			assert.NoError(t, err)
+			assert.Equal(t, rat("1650"), cost)
+		})
+
+		Convey("returns error when book is too thin", func() {
+			_, err := thin.BuyCost(rat("1000.0000001"))
+			assert.Equal(t, ErrInsufficientLiquidity, err)
+		})
+	})
+
+	Convey("OrderBook.SellProceeds", t, func() {
+		Convey("fills within the first level", func() {
+			proceeds, err := thin.SellProceeds(rat("250"))
+			assert.NoError(t, err)
+			assert.Equal(t, rat("50"), proceeds)
+		})
+
+		Convey("walks the book", func() {
+			proceeds, err := thin.SellProceeds(rat("1650"))
+			assert.NoError(t, err)
+			assert.Equal(t, rat("300"), proceeds)
+		})
+
+		Convey("returns error when book is too thin", func() {
+			_, err := thin.SellProceeds(rat("5850.0000001"))
+			assert.Equal(t, ErrInsufficientLiquidity, err)
+		})
+	})
+
+	Convey("EstimatePath", t, func() {
+		Convey("single order book", func() {
+			estimate, err := EstimatePath([]OrderBook{thin}, rat("300"))
+			assert.NoError(t, err)
+			assert.Equal(t, rat("1650"), estimate.SendAmount)
+			assert.Equal(t, rat("300"), estimate.DestinationAmount)
+			assert.Equal(t, rat("5.5"), estimate.Price)
+			assert.Equal(t, rat("5"), estimate.BestPrice)
+			assert.Equal(t, rat("0.1"), estimate.Slippage())
+		})
+
+		Convey("multiple order books", func() {
+			// XLM -> USD -> EUR
+			estimate, err := EstimatePath([]OrderBook{deep, eurUsd}, rat("1500"))
+			assert.NoError(t, err)
+			// 1000*1.2 + 500*1.25 = 1825 USD, 1825*5 = 9125 XLM
+			assert.Equal(t, rat("9125"), estimate.SendAmount)
+			assert.Equal(t, rat("6"), estimate.BestPrice)
+			assert.Equal(t, new(big.Rat).SetFrac64(5, 360), estimate.Slippage())
+		})
+
+		Convey("empty order book", func() {
+			_, err := EstimatePath([]OrderBook{deep, {}}, rat("1"))
+			assert.Equal(t, ErrInsufficientLiquidity, err)
+		})
+	})
+	Convey("EstimateSale", t, func() {
+		Convey("single order book", func() {
+			estimate, err := EstimateSale([]OrderBook{thin}, rat("1650"))
+			assert.NoError(t, err)
+			assert.Equal(t, rat("1650"), estimate.SendAmount)
+			assert.Equal(t, rat("300"), estimate.DestinationAmount)
+			assert.Equal(t, rat("5.5"), estimate.Price)
+			assert.Equal(t, rat("0.1"), estimate.Slippage())
+		})
+
+		Convey("multiple order books", func() {
+			// XLM -> USD -> EUR, reverse of the EstimatePath example
+			estimate, err := EstimateSale([]OrderBook{deep, eurUsd}, rat("9125"))
+			assert.NoError(t, err)
+			assert.Equal(t, rat("1500"), estimate.DestinationAmount)
+			assert.Equal(t, rat("6"), estimate.BestPrice)
+			assert.Equal(t, new(big.Rat).SetFrac64(5, 360), estimate.Slippage())
+		})
+
+		Convey("empty order book", func() {
+			_, err := EstimateSale([]OrderBook{deep, {}}, rat("1"))
+			assert.Equal(t, ErrInsufficientLiquidity, err)
+		})
+	})
+}
@@ -1,12 +1,15 @@
 package mocks
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/stellar/gateway/db/entities"
 	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/pagination"
+	"github.com/stellar/go/build"
 	"github.com/stellar/go/clients/stellartoml"
 	fproto "github.com/stellar/go/protocols/federation"
 	"github.com/stellar/go/xdr"
@@ -87,12 +90,53 @@ func (m *MockHorizon) LoadAccount(accountID string) (response horizon.AccountRes
 	return a.Get(0).(horizon.AccountResponse), a.Error(1)
 }
 
+// LoadAccountCtx is a mocking a method, calls are recorded as LoadAccount calls
+func (m *MockHorizon) LoadAccountCtx(ctx context.Context, accountID string) (response horizon.AccountResponse, err error) {
+	return m.LoadAccount(accountID)
+}
+
 // LoadOperation is a mocking a method
 func (m *MockHorizon) LoadOperation(operationID string) (response horizon.PaymentResponse, err error) {
 	a := m.Called(operationID)
 	return a.Get(0).(horizon.PaymentResponse), a.Error(1)
 }
 
+// LoadOrderBook is a mocking a method
+func (m *MockHorizon) LoadOrderBook(selling, buying build.Asset) (response horizon.OrderBookResponse, err error) {
+	a := m.Called(selling, buying)
+	return a.Get(0).(horizon.OrderBookResponse), a.Error(1)
+}
+
+// LoadPaths is a mocking a method
+func (m *MockHorizon) LoadPaths(sourceAccount, destinationAccount string, destinationAsset build.Asset, destinationAmount string) (response horizon.PathsPage, err error) {
+	a := m.Called(sourceAccount, destinationAccount, destinationAsset, destinationAmount)
+	return a.Get(0).(horizon.PathsPage), a.Error(1)
+}
+
+// LoadPayments is a mocking a method
+func (m *MockHorizon) LoadPayments(accountID, cursor string, limit int) (response horizon.PaymentsPage, err error) {
+	a := m.Called(accountID, cursor, limit)
+	return a.Get(0).(horizon.PaymentsPage), a.Error(1)
+}
+
+// LoadLatestLedger is a mocking a method
+func (m *MockHorizon) LoadLatestLedger() (ledger uint32, err error) {
+	a := m.Called()
+	return a.Get(0).(uint32), a.Error(1)
+}
+
+// LoadLedger is a mocking a method
+func (m *MockHorizon) LoadLedger(sequence uint32) (response horizon.LedgerResponse, err error) {
+	a := m.Called(sequence)
+	return a.Get(0).(horizon.LedgerResponse), a.Error(1)
+}
+
+// LoadTransaction is a mocking a method
+func (m *MockHorizon) LoadTransaction(hash string) (response horizon.TransactionResponse, err error) {
+	a := m.Called(hash)
+	return a.Get(0).(horizon.TransactionResponse), a.Error(1)
+}
+
 // LoadMemo is a mocking a method
 func (m *MockHorizon) LoadMemo(p *horizon.PaymentResponse) (err error) {
 	a := m.Called(p)
@@ -111,6 +155,16 @@ func (m *MockHorizon) SubmitTransaction(txeBase64 string) (response horizon.Subm
 	return a.Get(0).(horizon.SubmitTransactionResponse), a.Error(1)
 }
 
+// SubmitTransactionCtx is a mocking a method, calls are recorded as SubmitTransaction calls
+func (m *MockHorizon) SubmitTransactionCtx(ctx context.Context, txeBase64 string) (response horizon.SubmitTransactionResponse, err error) {
+	return m.SubmitTransaction(txeBase64)
+}
+
+// WithCorrelationID is a mocking a method, it returns the mock itself
+func (m *MockHorizon) WithCorrelationID(id string) horizon.HorizonInterface {
+	return m
+}
+
 // MockRepository ...
 type MockRepository struct {
 	mock.Mock
@@ -174,6 +228,228 @@ func (m *MockRepository) GetSentTransactions(page, limit int) ([]*entities.SentT
 	return a.Get(0).([]*entities.SentTransaction), a.Error(1)
 }
 
+// GetReceivedPaymentsPage is a mocking a method
+func (m *MockRepository) GetReceivedPaymentsPage(q pagination.Query) ([]*entities.ReceivedPayment, error) {
+	a := m.Called(q)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.ReceivedPayment), a.Error(1)
+}
+
+// GetDisputedReceivedPaymentsPage is a mocking a method
+func (m *MockRepository) GetDisputedReceivedPaymentsPage(q pagination.Query) ([]*entities.ReceivedPayment, error) {
+	a := m.Called(q)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.ReceivedPayment), a.Error(1)
+}
+
+// GetSentTransactionsPage is a mocking a method
+func (m *MockRepository) GetSentTransactionsPage(q pagination.Query) ([]*entities.SentTransaction, error) {
+	a := m.Called(q)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.SentTransaction), a.Error(1)
+}
+
+// GetReceivedPaymentsProcessedBetween is a mocking a method
+func (m *MockRepository) GetReceivedPaymentsProcessedBetween(from, to time.Time) ([]*entities.ReceivedPayment, error) {
+	a := m.Called(from, to)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.ReceivedPayment), a.Error(1)
+}
+
+// GetSentTransactionsSucceededBetween is a mocking a method
+func (m *MockRepository) GetSentTransactionsSucceededBetween(from, to time.Time) ([]*entities.SentTransaction, error) {
+	a := m.Called(from, to)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.SentTransaction), a.Error(1)
+}
+
+// GetSentTransactionsSubmittedBetween is a mocking a method
+func (m *MockRepository) GetSentTransactionsSubmittedBetween(from, to time.Time, afterID int64, limit int) ([]*entities.SentTransaction, error) {
+	a := m.Called(from, to, afterID, limit)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.SentTransaction), a.Error(1)
+}
+
+// GetDailyVolumes is a mocking a method
+func (m *MockRepository) GetDailyVolumes(from, to, assetCode, assetIssuer string) ([]*entities.DailyVolume, error) {
+	a := m.Called(from, to, assetCode, assetIssuer)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.DailyVolume), a.Error(1)
+}
+
+// GetBackfillCursor is a mocking a method
+func (m *MockRepository) GetBackfillCursor(accountID string) (*entities.BackfillCursor, error) {
+	a := m.Called(accountID)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.BackfillCursor), a.Error(1)
+}
+
+// GetRetiredAccount is a mocking a method
+func (m *MockRepository) GetRetiredAccount(accountID string) (*entities.RetiredAccount, error) {
+	a := m.Called(accountID)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.RetiredAccount), a.Error(1)
+}
+
+// GetAccountGeneration is a mocking a method
+func (m *MockRepository) GetAccountGeneration(accountID string) (*entities.AccountGeneration, error) {
+	a := m.Called(accountID)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.AccountGeneration), a.Error(1)
+}
+
+// GetConversionByOperationID is a mocking a method
+func (m *MockRepository) GetConversionByOperationID(operationID string) (*entities.Conversion, error) {
+	a := m.Called(operationID)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.Conversion), a.Error(1)
+}
+
+// GetIdempotentPaymentByPaymentID is a mocking a method
+func (m *MockRepository) GetIdempotentPaymentByPaymentID(paymentID string) (*entities.IdempotentPayment, error) {
+	a := m.Called(paymentID)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.IdempotentPayment), a.Error(1)
+}
+
+// GetQueuedPaymentByPaymentID is a mocking a method
+func (m *MockRepository) GetQueuedPaymentByPaymentID(paymentID string) (*entities.QueuedPayment, error) {
+	a := m.Called(paymentID)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.QueuedPayment), a.Error(1)
+}
+
+// GetQueuedPayments is a mocking a method
+func (m *MockRepository) GetQueuedPayments(limit int) ([]*entities.QueuedPayment, error) {
+	a := m.Called(limit)
+	return a.Get(0).([]*entities.QueuedPayment), a.Error(1)
+}
+
+// GetPaymentCallbacks is a mocking a method
+func (m *MockRepository) GetPaymentCallbacks(now time.Time, limit int) ([]*entities.PaymentCallback, error) {
+	a := m.Called(now, limit)
+	return a.Get(0).([]*entities.PaymentCallback), a.Error(1)
+}
+
+// GetReconciliationByDate is a mocking a method
+func (m *MockRepository) GetReconciliationByDate(date string) (*entities.Reconciliation, error) {
+	a := m.Called(date)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.Reconciliation), a.Error(1)
+}
+
+// GetEventsAfter is a mocking a method
+func (m *MockRepository) GetEventsAfter(sequence int64, limit int) ([]*entities.Event, error) {
+	a := m.Called(sequence, limit)
+	return a.Get(0).([]*entities.Event), a.Error(1)
+}
+
+// GetCounterpartyStats is a mocking a method
+func (m *MockRepository) GetCounterpartyStats(destination string) ([]*entities.CounterpartyStats, error) {
+	a := m.Called(destination)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.CounterpartyStats), a.Error(1)
+}
+
+// GetListenerCursor is a mocking a method
+func (m *MockRepository) GetListenerCursor() (*entities.ListenerCursor, error) {
+	a := m.Called()
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.ListenerCursor), a.Error(1)
+}
+
+// GetLastReceivedPaymentID is a mocking a method
+func (m *MockRepository) GetLastReceivedPaymentID() (int64, error) {
+	a := m.Called()
+	return a.Get(0).(int64), a.Error(1)
+}
+
+// GetSentTransactionByHash is a mocking a method
+func (m *MockRepository) GetSentTransactionByHash(hash string) (*entities.SentTransaction, error) {
+	a := m.Called(hash)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.SentTransaction), a.Error(1)
+}
+
+// GetSentTransactionsRebuiltFrom is a mocking a method
+func (m *MockRepository) GetSentTransactionsRebuiltFrom(id int64) ([]*entities.SentTransaction, error) {
+	a := m.Called(id)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.SentTransaction), a.Error(1)
+}
+
+// GetPaymentRequestByOperationID is a mocking a method
+func (m *MockRepository) GetPaymentRequestByOperationID(operationID string) (*entities.PaymentRequest, error) {
+	a := m.Called(operationID)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.PaymentRequest), a.Error(1)
+}
+
+// GetPaymentRequestByRequestID is a mocking a method
+func (m *MockRepository) GetPaymentRequestByRequestID(requestID string) (*entities.PaymentRequest, error) {
+	a := m.Called(requestID)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).(*entities.PaymentRequest), a.Error(1)
+}
+
+// GetPaymentRequestsByMemo is a mocking a method
+func (m *MockRepository) GetPaymentRequestsByMemo(memoType, memo string) ([]*entities.PaymentRequest, error) {
+	a := m.Called(memoType, memo)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.PaymentRequest), a.Error(1)
+}
+
+// GetExpiredPaymentRequests is a mocking a method
+func (m *MockRepository) GetExpiredPaymentRequests(now time.Time) ([]*entities.PaymentRequest, error) {
+	a := m.Called(now)
+	if a.Get(0) == nil {
+		return nil, a.Error(1)
+	}
+	return a.Get(0).([]*entities.PaymentRequest), a.Error(1)
+}
+
 // MockSignerVerifier ...
 type MockSignerVerifier struct {
 	mock.Mock
@@ -226,6 +502,16 @@ func (ts *MockTransactionSubmitter) SignAndSubmitRawTransaction(seed string, tx
 	return a.Get(0).(horizon.SubmitTransactionResponse), a.Error(1)
 }
 
+// MockVolumeAggregator ...
+type MockVolumeAggregator struct {
+	mock.Mock
+}
+
+// Touch is a mocking a method
+func (m *MockVolumeAggregator) Touch(t time.Time) {
+	m.Called(t)
+}
+
 // PredefinedTime is a time.Time object that will be returned by Now() function
 var PredefinedTime time.Time
 
@@ -38,7 +38,7 @@ func GetResponse(testServer *httptest.Server, values url.Values) (int, []byte) {
 }
 
 // JSONGetResponse is used in tests
-func JSONGetResponse(testServer *httptest.Server, data map[string]interface{}) (int, []byte) {
+func JSONGetResponse(testServer *httptest.Server, data interface{}) (int, []byte) {
 	j, err := json.Marshal(data)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,202 @@
+// Package pagination implements cursor based pagination of admin list endpoints. Lists are ordered
+// by descending ID (newest first), IDs are unique so the order is stable and records inserted or
+// deleted while paging do not move other records between pages.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/gateway/protocols"
+)
+
+const (
+	// DefaultLimit is a page size used when `limit` param is not set
+	DefaultLimit = 10
+	// MaxLimit is the maximum page size
+	MaxLimit = 200
+)
+
+// Directions of cursors
+const (
+	// DirectionNext pages to older records
+	DirectionNext = "next"
+	// DirectionPrev pages to newer records
+	DirectionPrev = "prev"
+)
+
+// ErrInvalidCursor is returned when a cursor can't be decoded
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+// Cursor is a position in a list: records after (DirectionNext) or before (DirectionPrev) the
+// record with a given ID
+type Cursor struct {
+	ID        int64
+	Direction string
+}
+
+// Encode returns an opaque representation of the cursor used in `cursor` param
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.Direction + ":" + strconv.FormatInt(c.ID, 10)))
+}
+
+// DecodeCursor decodes a cursor returned by Cursor.Encode
+func DecodeCursor(value string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || (parts[0] != DirectionNext && parts[0] != DirectionPrev) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{ID: id, Direction: parts[0]}, nil
+}
+
+// Query selects a single page, the first one when Cursor is nil
+type Query struct {
+	Cursor *Cursor
+	Limit  int
+}
+
+// QueryFromRequest reads `cursor` and `limit` params
+func QueryFromRequest(r *http.Request) (Query, *protocols.ErrorResponse) {
+	values := r.URL.Query()
+	query := Query{Limit: DefaultLimit}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return query, protocols.NewInvalidParameterError("limit", value, fmt.Sprintf("limit must be between 1 and %d.", MaxLimit))
+		}
+		query.Limit = limit
+	}
+
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := DecodeCursor(value)
+		if err != nil {
+			return query, protocols.NewInvalidParameterError("cursor", value, "cursor must be a value of next or prev link.")
+		}
+		query.Cursor = &cursor
+	}
+
+	return query, nil
+}
+
+// SQL returns a condition (with params) and an order selecting records of the page, empty
+// condition selects all records. limit is one more than Query.Limit so NewPage knows if there
+// are more records.
+func (q Query) SQL() (where string, params []interface{}, order string, limit int) {
+	limit = q.Limit + 1
+	switch {
+	case q.Cursor == nil:
+		return "", nil, "id DESC", limit
+	case q.Cursor.Direction == DirectionPrev:
+		return "id > ?", []interface{}{q.Cursor.ID}, "id ASC", limit
+	default:
+		return "id < ?", []interface{}{q.Cursor.ID}, "id DESC", limit
+	}
+}
+
+// Page is a response envelope of list endpoints
+type Page struct {
+	Records interface{} `json:"records"`
+	Links   Links       `json:"links"`
+}
+
+// Links contain URLs of the next (older) and previous (newer) pages. Next is empty on the last
+// page, Prev on the first page.
+type Links struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+type record interface {
+	GetID() *int64
+}
+
+// NewPage builds a page of records selected using Query.SQL in the selected order. records must
+// be a slice of entities, it's trimmed to Query.Limit and ordered by descending ID. path is
+// a path of the endpoint used in links.
+func NewPage(path string, q Query, records interface{}) (Page, error) {
+	value := reflect.ValueOf(records)
+	if value.Kind() != reflect.Slice {
+		return Page{}, errors.New("records must be a slice")
+	}
+
+	more := value.Len() > q.Limit
+	if more {
+		value = value.Slice(0, q.Limit)
+	}
+
+	prev := q.Cursor != nil && q.Cursor.Direction == DirectionPrev
+	if prev {
+		swap := reflect.Swapper(value.Interface())
+		for i, j := 0, value.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	page := Page{Records: value.Interface()}
+	if value.Len() == 0 {
+		// Empty page after or before a cursor links back to the records around it
+		if q.Cursor != nil {
+			reverse := Cursor{ID: q.Cursor.ID, Direction: DirectionNext}
+			if !prev {
+				reverse.Direction = DirectionPrev
+			}
+			link := linkTo(path, reverse, q.Limit)
+			if prev {
+				page.Links.Next = link
+			} else {
+				page.Links.Prev = link
+			}
+		}
+		return page, nil
+	}
+
+	first, err := idOf(value.Index(0))
+	if err != nil {
+		return Page{}, err
+	}
+	last, err := idOf(value.Index(value.Len() - 1))
+	if err != nil {
+		return Page{}, err
+	}
+
+	if more || prev {
+		page.Links.Next = linkTo(path, Cursor{ID: last, Direction: DirectionNext}, q.Limit)
+	}
+	if (more && prev) || (!prev && q.Cursor != nil) {
+		page.Links.Prev = linkTo(path, Cursor{ID: first, Direction: DirectionPrev}, q.Limit)
+	}
+	return page, nil
+}
+
+func idOf(value reflect.Value) (int64, error) {
+	r, ok := value.Interface().(record)
+	if !ok || r.GetID() == nil {
+		return 0, errors.New("records must have IDs")
+	}
+	return *r.GetID(), nil
+}
+
+func linkTo(path string, cursor Cursor, limit int) string {
+	values := url.Values{
+		"cursor": {cursor.Encode()},
+		"limit":  {strconv.Itoa(limit)},
+	}
+	return path + "?" + values.Encode()
+}
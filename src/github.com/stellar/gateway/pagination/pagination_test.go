@@ -0,0 +1,193 @@
+package pagination
+
+import (
+	"math/rand"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRecord struct {
+	ID *int64
+}
+
+func (r *testRecord) GetID() *int64 {
+	return r.ID
+}
+
+// testTable executes Query.SQL like the DB does
+type testTable struct {
+	ids    []int64
+	nextID int64
+}
+
+func (table *testTable) insert(n int) {
+	for i := 0; i < n; i++ {
+		table.nextID++
+		table.ids = append(table.ids, table.nextID)
+	}
+}
+
+func (table *testTable) delete(random *rand.Rand) int64 {
+	i := random.Intn(len(table.ids))
+	id := table.ids[i]
+	table.ids = append(table.ids[:i], table.ids[i+1:]...)
+	return id
+}
+
+func (table *testTable) selectPage(t *testing.T, q Query) []*testRecord {
+	where, params, order, limit := q.SQL()
+
+	ids := append([]int64{}, table.ids...)
+	if order == "id DESC" {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+	} else {
+		require.Equal(t, "id ASC", order)
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+
+	records := []*testRecord{}
+	for _, id := range ids {
+		id := id
+		switch where {
+		case "":
+		case "id < ?":
+			if id >= params[0].(int64) {
+				continue
+			}
+		case "id > ?":
+			if id <= params[0].(int64) {
+				continue
+			}
+		default:
+			t.Fatalf("unexpected condition %s", where)
+		}
+		if len(records) < limit {
+			records = append(records, &testRecord{ID: &id})
+		}
+	}
+	return records
+}
+
+func (table *testTable) load(t *testing.T, link string) Page {
+	query, errorResponse := QueryFromRequest(httptest.NewRequest("GET", link, nil))
+	require.Nil(t, errorResponse)
+	page, err := NewPage("/admin/list", query, table.selectPage(t, query))
+	require.NoError(t, err)
+	return page
+}
+
+// TestPagingMutatingList pages through a list while records are inserted and deleted. Every
+// record existing before paging started and not deleted must be seen exactly once.
+func TestPagingMutatingList(t *testing.T) {
+	check := func(seed int64, size, limit uint8, backwards bool) bool {
+		random := rand.New(rand.NewSource(seed))
+		table := &testTable{}
+		table.insert(int(size))
+		pageSize := int(limit%20) + 1
+
+		expected := map[int64]bool{}
+		for _, id := range table.ids {
+			expected[id] = true
+		}
+
+		link := "/admin/list?limit=" + strconv.Itoa(pageSize)
+		page := table.load(t, link)
+		if backwards {
+			// Go to the last page and follow prev links
+			for page.Links.Next != "" {
+				page = table.load(t, page.Links.Next)
+			}
+		}
+
+		seen := map[int64]bool{}
+		for pages := 0; ; pages++ {
+			// At most 2*size records are inserted
+			require.True(t, pages <= 3*int(size)+1, "too many pages")
+
+			records := page.Records.([]*testRecord)
+			require.True(t, len(records) <= pageSize)
+			for i, record := range records {
+				require.False(t, seen[*record.ID], "duplicate record %d", *record.ID)
+				seen[*record.ID] = true
+				if i > 0 {
+					require.True(t, *records[i-1].ID > *record.ID, "page not ordered")
+				}
+			}
+
+			// Records are inserted at the top and deleted anywhere between pages. Mutations stop
+			// after size pages so paging backwards reaches the top.
+			if pages < int(size) {
+				table.insert(random.Intn(3))
+				if len(table.ids) > 0 && random.Intn(2) == 0 {
+					delete(expected, table.delete(random))
+				}
+			}
+
+			link := page.Links.Next
+			if backwards {
+				link = page.Links.Prev
+			}
+			if link == "" {
+				break
+			}
+			page = table.load(t, link)
+		}
+
+		for id := range expected {
+			require.True(t, seen[id], "skipped record %d", id)
+		}
+		return true
+	}
+
+	require.NoError(t, quick.Check(check, &quick.Config{MaxCount: 500}))
+}
+
+func TestNewPageLinks(t *testing.T) {
+	table := &testTable{}
+	table.insert(5)
+
+	page := table.load(t, "/admin/list?limit=2")
+	assert.Len(t, page.Records, 2)
+	assert.Empty(t, page.Links.Prev)
+	require.NotEmpty(t, page.Links.Next)
+
+	second := table.load(t, page.Links.Next)
+	assert.Equal(t, int64(3), *second.Records.([]*testRecord)[0].ID)
+	assert.Equal(t, page, table.load(t, second.Links.Prev))
+
+	last := table.load(t, second.Links.Next)
+	assert.Len(t, last.Records, 1)
+	assert.Empty(t, last.Links.Next)
+	assert.NotEmpty(t, last.Links.Prev)
+
+	// Empty page links back to the cursor
+	empty := table.load(t, "/admin/list?limit=2&cursor="+Cursor{ID: 1, Direction: DirectionNext}.Encode())
+	assert.Len(t, empty.Records, 0)
+	assert.Empty(t, empty.Links.Next)
+	assert.Equal(t, second.Records, table.load(t, empty.Links.Prev).Records)
+}
+
+func TestQueryFromRequest(t *testing.T) {
+	query, errorResponse := QueryFromRequest(httptest.NewRequest("GET", "/admin/list", nil))
+	require.Nil(t, errorResponse)
+	assert.Equal(t, Query{Limit: DefaultLimit}, query)
+
+	_, errorResponse = QueryFromRequest(httptest.NewRequest("GET", "/admin/list?limit=201", nil))
+	require.NotNil(t, errorResponse)
+	assert.Equal(t, "limit", errorResponse.Data["name"])
+
+	_, errorResponse = QueryFromRequest(httptest.NewRequest("GET", "/admin/list?cursor=bmV4dDph", nil))
+	require.NotNil(t, errorResponse)
+	assert.Equal(t, "cursor", errorResponse.Data["name"])
+
+	cursor := Cursor{ID: 42, Direction: DirectionPrev}
+	decoded, err := DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
@@ -1,6 +1,8 @@
 package bridge
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 
@@ -17,6 +19,8 @@ var (
 	AllowTrustTrustNotRequired = &protocols.ErrorResponse{Code: "allow_trust_trust_not_required", Message: "Authorizing account does not require allowing trust. Set AUTH_REQUIRED_FLAG on your account to use this feature.", Status: http.StatusBadRequest}
 	// AllowTrustCantRevoke is an error response
 	AllowTrustCantRevoke = &protocols.ErrorResponse{Code: "allow_trust_cant_revoke", Message: "Authorizing account has AUTH_REVOCABLE_FLAG set. Can't revoke the trustline.", Status: http.StatusBadRequest}
+	// AllowTrustBatchRolledBack is an error response
+	AllowTrustBatchRolledBack = &protocols.ErrorResponse{Code: "allow_trust_batch_rolled_back", Message: "Other operation in the same transaction failed. Authorization has not been applied.", Status: http.StatusBadRequest}
 )
 
 // AuthorizeRequest represents request made to /authorize endpoint of bridge server
@@ -48,18 +52,104 @@ func (request *AuthorizeRequest) Validate(allowedAssets []config.Asset, issuingA
 		return protocols.NewInvalidParameterError("account_id", request.AccountID, "Account ID must start with `G`.")
 	}
 
-	// Is asset allowed?
-	allowed := false
+	if !isAllowedAsset(allowedAssets, request.AssetCode, issuingAccountID) {
+		return protocols.NewInvalidParameterError("asset_code", request.AssetCode, "Asset code not allowed.")
+	}
+
+	return nil
+}
+
+func isAllowedAsset(allowedAssets []config.Asset, assetCode, issuingAccountID string) bool {
 	for _, asset := range allowedAssets {
-		if asset.Code == request.AssetCode && asset.Issuer == issuingAccountID {
-			allowed = true
-			break
+		if asset.Code == assetCode && asset.Issuer == issuingAccountID {
+			return true
 		}
 	}
+	return false
+}
 
-	if !allowed {
-		return protocols.NewInvalidParameterError("asset_code", request.AssetCode, "Asset code not allowed.")
+// AuthorizeBatchMaxOperations is the maximum number of operations packed into a single transaction
+const AuthorizeBatchMaxOperations = 100
+
+// AuthorizeBatchEntryStatus is the status of a single /authorize/batch entry
+type AuthorizeBatchEntryStatus string
+
+const (
+	// AuthorizeBatchEntryStatusSuccess means the trustline has been (de)authorized
+	AuthorizeBatchEntryStatusSuccess AuthorizeBatchEntryStatus = "success"
+	// AuthorizeBatchEntryStatusFailed means the operation or its transaction failed
+	AuthorizeBatchEntryStatusFailed AuthorizeBatchEntryStatus = "failed"
+	// AuthorizeBatchEntryStatusSkipped means the account has no trustline yet
+	AuthorizeBatchEntryStatusSkipped AuthorizeBatchEntryStatus = "skipped"
+)
+
+// AuthorizeBatchEntry is a single trustline to (de)authorize. Authorize defaults to true.
+type AuthorizeBatchEntry struct {
+	Account   string `json:"account"`
+	AssetCode string `json:"asset_code"`
+	Authorize *bool  `json:"authorize,omitempty"`
+}
+
+// ShouldAuthorize returns false only when entry explicitly revokes authorization
+func (entry AuthorizeBatchEntry) ShouldAuthorize() bool {
+	return entry.Authorize == nil || *entry.Authorize
+}
+
+// AuthorizeBatchRequest represents request made to /authorize/batch endpoint of bridge server.
+// It is sent as a JSON array of entries.
+type AuthorizeBatchRequest struct {
+	Entries []AuthorizeBatchEntry
+}
+
+// UnmarshalJSON decodes a JSON array of entries
+func (request *AuthorizeBatchRequest) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &request.Entries)
+}
+
+// Validate validates if request fields are valid. Useful when checking if a request is correct.
+func (request *AuthorizeBatchRequest) Validate(allowedAssets []config.Asset, issuingAccountID string) error {
+	if len(request.Entries) == 0 {
+		return protocols.NewInvalidParameterError("", "", "Request must contain at least one entry.")
+	}
+
+	for i, entry := range request.Entries {
+		if entry.Account == "" {
+			return protocols.NewMissingParameter(fmt.Sprintf("[%d].account", i))
+		}
+
+		if entry.AssetCode == "" {
+			return protocols.NewMissingParameter(fmt.Sprintf("[%d].asset_code", i))
+		}
+
+		if !protocols.IsValidAccountID(entry.Account) {
+			return protocols.NewInvalidParameterError(fmt.Sprintf("[%d].account", i), entry.Account, "Account ID must start with `G`.")
+		}
+
+		if !isAllowedAsset(allowedAssets, entry.AssetCode, issuingAccountID) {
+			return protocols.NewInvalidParameterError(fmt.Sprintf("[%d].asset_code", i), entry.AssetCode, "Asset code not allowed.")
+		}
 	}
 
 	return nil
 }
+
+// AuthorizeBatchEntryResult contains the result of a single /authorize/batch entry
+type AuthorizeBatchEntryResult struct {
+	AuthorizeBatchEntry
+	Status AuthorizeBatchEntryStatus `json:"status"`
+	Hash   string                    `json:"hash,omitempty"`
+	Ledger *uint64                   `json:"ledger,omitempty"`
+	Error  *protocols.ErrorResponse  `json:"error,omitempty"`
+}
+
+// AuthorizeBatchResponse represents response returned by /authorize/batch endpoint of bridge server
+type AuthorizeBatchResponse struct {
+	protocols.SuccessResponse
+	Results []AuthorizeBatchEntryResult `json:"results"`
+}
+
+// Marshal marshals AuthorizeBatchResponse
+func (response *AuthorizeBatchResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
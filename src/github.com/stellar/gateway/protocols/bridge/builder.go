@@ -44,24 +44,30 @@ type BuilderRequest struct {
 	Signers        []string
 }
 
-// Process parses operations and creates OperationBody object for each operation
+// Process parses operations and creates OperationBody object for each operation. Amounts in
+// stroops are converted to decimal amounts.
 func (r BuilderRequest) Process() error {
 	var err error
 	for i, operation := range r.Operations {
 		var operationBody OperationBody
+		var stroopsErrs protocols.ValidationErrors
 
 		switch operation.Type {
 		case OperationTypeCreateAccount:
 			var createAccount CreateAccountOperationBody
 			err = json.Unmarshal(operation.RawBody, &createAccount)
+			stroopsErrs.Add(protocols.ConvertStroopsParam("starting_balance", &createAccount.StartingBalance, "starting_balance_stroops", &createAccount.StartingBalanceStroops))
 			operationBody = createAccount
 		case OperationTypePayment:
 			var payment PaymentOperationBody
 			err = json.Unmarshal(operation.RawBody, &payment)
+			stroopsErrs.Add(protocols.ConvertStroopsParam("amount", &payment.Amount, "amount_stroops", &payment.AmountStroops))
 			operationBody = payment
 		case OperationTypePathPayment:
 			var pathPayment PathPaymentOperationBody
 			err = json.Unmarshal(operation.RawBody, &pathPayment)
+			stroopsErrs.Add(protocols.ConvertStroopsParam("send_max", &pathPayment.SendMax, "send_max_stroops", &pathPayment.SendMaxStroops))
+			stroopsErrs.Add(protocols.ConvertStroopsParam("destination_amount", &pathPayment.DestinationAmount, "destination_amount_stroops", &pathPayment.DestinationAmountStroops))
 			operationBody = pathPayment
 		case OperationTypeManageOffer:
 			var manageOffer ManageOfferOperationBody
@@ -103,6 +109,9 @@ func (r BuilderRequest) Process() error {
 		if err != nil {
 			return protocols.NewInvalidParameterError("operations["+strconv.Itoa(i)+"][body]", "", "Operation is invalid.", map[string]interface{}{"err": err})
 		}
+		if len(stroopsErrs) > 0 {
+			return stroopsErrs[0]
+		}
 
 		r.Operations[i].Body = operationBody
 	}
@@ -10,6 +10,8 @@ type CreateAccountOperationBody struct {
 	Source          *string
 	Destination     string
 	StartingBalance string `json:"starting_balance"`
+	// StartingBalanceStroops is converted to StartingBalance by BuilderRequest.Process
+	StartingBalanceStroops string `json:"starting_balance_stroops"`
 }
 
 // ToTransactionMutator returns go-stellar-base TransactionMutator
@@ -32,8 +34,8 @@ func (op CreateAccountOperationBody) Validate() error {
 		return protocols.NewInvalidParameterError("destination", op.Destination, "Destination must be a public key (starting with `G`)")
 	}
 
-	if !protocols.IsValidAmount(op.StartingBalance) {
-		return protocols.NewInvalidParameterError("starting_balance", op.StartingBalance, "Not a valid amount.")
+	if !protocols.IsValidPositiveAmount(op.StartingBalance) {
+		return NewPaymentInvalidAmountError("starting_balance", op.StartingBalance)
 	}
 
 	if op.Source != nil && !protocols.IsValidAccountID(*op.Source) {
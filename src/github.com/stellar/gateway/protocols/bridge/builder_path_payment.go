@@ -11,12 +11,14 @@ import (
 type PathPaymentOperationBody struct {
 	Source *string
 
-	SendMax   string          `json:"send_max"`
-	SendAsset protocols.Asset `json:"send_asset"`
+	SendMax        string          `json:"send_max"`
+	SendMaxStroops string          `json:"send_max_stroops"`
+	SendAsset      protocols.Asset `json:"send_asset"`
 
-	Destination       string
-	DestinationAmount string          `json:"destination_amount"`
-	DestinationAsset  protocols.Asset `json:"destination_asset"`
+	Destination              string
+	DestinationAmount        string          `json:"destination_amount"`
+	DestinationAmountStroops string          `json:"destination_amount_stroops"`
+	DestinationAsset         protocols.Asset `json:"destination_asset"`
 
 	Path []protocols.Asset
 }
@@ -66,12 +68,12 @@ func (op PathPaymentOperationBody) Validate() error {
 		return protocols.NewInvalidParameterError("destination", op.Destination, "Destination must be a public key (starting with `G`).")
 	}
 
-	if !protocols.IsValidAmount(op.SendMax) {
-		return protocols.NewInvalidParameterError("send_max", op.SendMax, "Not a valid amount.")
+	if !protocols.IsValidPositiveAmount(op.SendMax) {
+		return NewPaymentInvalidAmountError("send_max", op.SendMax)
 	}
 
-	if !protocols.IsValidAmount(op.DestinationAmount) {
-		return protocols.NewInvalidParameterError("destination_amount", op.DestinationAmount, "Not a valid amount.")
+	if !protocols.IsValidPositiveAmount(op.DestinationAmount) {
+		return NewPaymentInvalidAmountError("destination_amount", op.DestinationAmount)
 	}
 
 	if !op.SendAsset.Validate() {
@@ -86,9 +88,13 @@ func (op PathPaymentOperationBody) Validate() error {
 		return protocols.NewInvalidParameterError("source", *op.Source, "Source must be a public key (starting with `G`).")
 	}
 
+	if len(op.Path) > protocols.MaxPathLength {
+		return NewPaymentInvalidPathError("path", "", pathLengthMoreInfo)
+	}
+
 	for i, asset := range op.Path {
-		if !asset.Validate() {
-			return protocols.NewInvalidParameterError("path["+strconv.Itoa(i)+"]", asset.String(), "Invalid asset.")
+		if moreInfo := invalidPathAsset(i, asset); moreInfo != "" {
+			return NewPaymentInvalidPathError("path["+strconv.Itoa(i)+"]", asset.String(), moreInfo)
 		}
 	}
 
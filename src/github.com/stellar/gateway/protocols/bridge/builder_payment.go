@@ -10,7 +10,9 @@ type PaymentOperationBody struct {
 	Source      *string
 	Destination string
 	Amount      string
-	Asset       protocols.Asset
+	// AmountStroops is converted to Amount by BuilderRequest.Process
+	AmountStroops string `json:"amount_stroops"`
+	Asset         protocols.Asset
 }
 
 // ToTransactionMutator returns go-stellar-base TransactionMutator
@@ -44,8 +46,8 @@ func (op PaymentOperationBody) Validate() error {
 		return protocols.NewInvalidParameterError("destination", op.Destination, "Destination must be a public key (starting with `G`).")
 	}
 
-	if !protocols.IsValidAmount(op.Amount) {
-		return protocols.NewInvalidParameterError("amount", op.Amount, "Invalid amount.")
+	if !protocols.IsValidPositiveAmount(op.Amount) {
+		return NewPaymentInvalidAmountError("amount", op.Amount)
 	}
 
 	if !op.Asset.Validate() {
@@ -2,7 +2,6 @@ package bridge
 
 import (
 	"encoding/base64"
-	"net/http"
 	"strings"
 
 	"github.com/stellar/gateway/horizon"
@@ -11,20 +10,73 @@ import (
 )
 
 var (
+	// TransactionTooEarly is an error response
+	TransactionTooEarly = newTransactionError(xdr.TransactionResultCodeTxTooEarly, "transaction_too_early", "Transaction submitted before its min time.")
+	// TransactionTooLate is an error response
+	TransactionTooLate = newTransactionError(xdr.TransactionResultCodeTxTooLate, "transaction_too_late", "Transaction submitted after its max time.")
+	// TransactionMissingOperation is an error response
+	TransactionMissingOperation = newTransactionError(xdr.TransactionResultCodeTxMissingOperation, "transaction_missing_operation", "Transaction has no operations.")
 	// TransactionBadSequence is an error response
-	TransactionBadSequence = &protocols.ErrorResponse{Code: "transaction_bad_seq", Message: "Bad Sequence. Please, try again.", Status: http.StatusBadRequest}
+	TransactionBadSequence = newTransactionError(xdr.TransactionResultCodeTxBadSeq, "transaction_bad_seq", "Bad Sequence. Please, try again.")
 	// TransactionBadAuth is an error response
-	TransactionBadAuth = &protocols.ErrorResponse{Code: "transaction_bad_auth", Message: "Invalid network or too few signatures.", Status: http.StatusBadRequest}
+	TransactionBadAuth = newTransactionError(xdr.TransactionResultCodeTxBadAuth, "transaction_bad_auth", "Invalid network or too few signatures.")
 	// TransactionInsufficientBalance is an error response
-	TransactionInsufficientBalance = &protocols.ErrorResponse{Code: "transaction_insufficient_balance", Message: "Transaction fee would bring account below reserve.", Status: http.StatusBadRequest}
+	TransactionInsufficientBalance = newTransactionError(xdr.TransactionResultCodeTxInsufficientBalance, "transaction_insufficient_balance", "Transaction fee would bring account below reserve.")
 	// TransactionNoAccount is an error response
-	TransactionNoAccount = &protocols.ErrorResponse{Code: "transaction_no_account", Message: "Source account not found.", Status: http.StatusBadRequest}
+	TransactionNoAccount = newTransactionError(xdr.TransactionResultCodeTxNoAccount, "transaction_no_account", "Source account not found.")
 	// TransactionInsufficientFee is an error response
-	TransactionInsufficientFee = &protocols.ErrorResponse{Code: "transaction_insufficient_fee", Message: "Transaction fee is too small.", Status: http.StatusBadRequest}
+	TransactionInsufficientFee = newTransactionError(xdr.TransactionResultCodeTxInsufficientFee, "transaction_insufficient_fee", "Transaction fee is too small.")
 	// TransactionBadAuthExtra is an error response
-	TransactionBadAuthExtra = &protocols.ErrorResponse{Code: "transaction_bad_auth_extra", Message: "Unused signatures attached to transaction.", Status: http.StatusBadRequest}
+	TransactionBadAuthExtra = newTransactionError(xdr.TransactionResultCodeTxBadAuthExtra, "transaction_bad_auth_extra", "Unused signatures attached to transaction.")
+	// TransactionInternalError is an error response
+	TransactionInternalError = newTransactionError(xdr.TransactionResultCodeTxInternalError, "transaction_internal_error", "Network failed to process the transaction, please try again.")
 )
 
+// transactionErrors are error responses of transaction-level result codes
+var transactionErrors = map[xdr.TransactionResultCode]*protocols.ErrorResponse{
+	xdr.TransactionResultCodeTxTooEarly:            TransactionTooEarly,
+	xdr.TransactionResultCodeTxTooLate:             TransactionTooLate,
+	xdr.TransactionResultCodeTxMissingOperation:    TransactionMissingOperation,
+	xdr.TransactionResultCodeTxBadSeq:              TransactionBadSequence,
+	xdr.TransactionResultCodeTxBadAuth:             TransactionBadAuth,
+	xdr.TransactionResultCodeTxInsufficientBalance: TransactionInsufficientBalance,
+	xdr.TransactionResultCodeTxNoAccount:           TransactionNoAccount,
+	xdr.TransactionResultCodeTxInsufficientFee:     TransactionInsufficientFee,
+	xdr.TransactionResultCodeTxBadAuthExtra:        TransactionBadAuthExtra,
+	xdr.TransactionResultCodeTxInternalError:       TransactionInternalError,
+}
+
+// newTransactionError creates an error response of a transaction-level result code with HTTP
+// status and remediation hint of its horizon.TransactionResultError
+func newTransactionError(resultCode xdr.TransactionResultCode, code, message string) *protocols.ErrorResponse {
+	resultErr := horizon.NewTransactionResultError(resultCode)
+	return &protocols.ErrorResponse{Code: code, Message: message, Status: resultErr.Status, Remediation: resultErr.Remediation, Retriable: resultErr.Retriable}
+}
+
+func init() {
+	protocols.RegisterErrors(
+		TransactionTooEarly, TransactionTooLate, TransactionMissingOperation, TransactionBadSequence,
+		TransactionBadAuth, TransactionInsufficientBalance, TransactionNoAccount, TransactionInsufficientFee,
+		TransactionBadAuthExtra, TransactionInternalError,
+		PaymentCannotResolveDestination, PaymentCannotUseMemo, PaymentSourceNotExist, PaymentAssetCodeNotAllowed,
+		PaymentExcessiveSlippage, PaymentNoPathFound, PaymentCounterpartyNotAllowed, PaymentSourceNotRegistered, PaymentMemoPolicyViolation, PaymentNotFound, PaymentDuplicateID, PaymentInProgress, PaymentInvalidAmount, PaymentInvalidIssuer, PaymentStartingBalanceBelowReserve, PaymentAmountBelowReserve, PaymentSweepOffers, PaymentNothingToSweep, PaymentInvalidSource, PaymentSourceSeedNotAllowed, PaymentDestinationExists, PaymentDestinationDoesNotExist, PaymentInvalidFee, PaymentInvalidTimeBounds, PaymentInvalidPath, PaymentMalformedAssetCode, PaymentInvalidMemo, PaymentInvalidFederationMemo,
+		PaymentChannelsExhausted,
+		PaymentAnomalyBlocked, PaymentAnomalyApprovalRequired, PaymentPending, PaymentDenied,
+		PaymentMalformed, PaymentUnderfunded, PaymentSrcNoTrust, PaymentSrcNotAuthorized, PaymentNoDestination,
+		PaymentNoTrust, PaymentNotAuthorized, PaymentLineFull, PaymentNoIssuer, PaymentTooFewOffers,
+		PaymentOfferCrossSelf, PaymentOverSendmax,
+		CreateAccountMalformed, CreateAccountInvalidAmount, CreateAccountUnderfunded, CreateAccountLowReserve, CreateAccountAlreadyExists,
+		PaymentMultiAssetFailed, PaymentMultiAssetRolledBack,
+		PaymentBatchFailed, PaymentBatchRolledBack, PaymentBatchFederationMemo,
+		NetworkNotConfigured, PaymentSourceOtherNetwork,
+		PaymentRequestNotFound, ReceivedPaymentNotFound,
+		AllowTrustMalformed, AllowTrustNoTrustline, AllowTrustTrustNotRequired, AllowTrustCantRevoke, AllowTrustBatchRolledBack,
+		RebuildNotFailed, RebuildAlreadyRebuilt, RebuildNotAvailable, RebuildUnsupportedVersion, RebuildSecretOmitted,
+		RebuildSourceNotConfigured,
+		PreauthOutstanding,
+	)
+}
+
 // ErrorFromHorizonResponse checks if horizon.SubmitTransactionResponse is an error response and creates ErrorResponse for it
 func ErrorFromHorizonResponse(response horizon.SubmitTransactionResponse) *protocols.ErrorResponse {
 	if response.Ledger == nil && response.Extras != nil {
@@ -45,93 +97,28 @@ func ErrorFromHorizonResponse(response horizon.SubmitTransactionResponse) *proto
 			if len(operationsResultsSlice) > 0 {
 				operationsResult = &operationsResultsSlice[0]
 			}
+			// Report the first failed operation (ex. payment after change_trust added by auto_trust)
+			for i := range operationsResultsSlice {
+				if errorFromOperationResult(operationsResultsSlice[i]) != nil {
+					operationsResult = &operationsResultsSlice[i]
+					break
+				}
+			}
 		}
 
 		if transactionResult != xdr.TransactionResultCodeTxSuccess &&
 			transactionResult != xdr.TransactionResultCodeTxFailed {
-			switch transactionResult {
-			case xdr.TransactionResultCodeTxBadSeq:
-				return TransactionBadSequence
-			case xdr.TransactionResultCodeTxBadAuth:
-				return TransactionBadAuth
-			case xdr.TransactionResultCodeTxInsufficientBalance:
-				return TransactionInsufficientBalance
-			case xdr.TransactionResultCodeTxNoAccount:
-				return TransactionNoAccount
-			case xdr.TransactionResultCodeTxInsufficientFee:
-				return TransactionInsufficientFee
-			case xdr.TransactionResultCodeTxBadAuthExtra:
-				return TransactionBadAuthExtra
-			default:
+			errorResponse, ok := transactionErrors[transactionResult]
+			if !ok {
 				return protocols.InternalServerError
 			}
+			return errorResponse
 		} else if operationsResult != nil {
-			if operationsResult.Tr.AllowTrustResult != nil {
-				switch operationsResult.Tr.AllowTrustResult.Code {
-				case xdr.AllowTrustResultCodeAllowTrustMalformed:
-					return AllowTrustMalformed
-				case xdr.AllowTrustResultCodeAllowTrustNoTrustLine:
-					return AllowTrustNoTrustline
-				case xdr.AllowTrustResultCodeAllowTrustTrustNotRequired:
-					return AllowTrustTrustNotRequired
-				case xdr.AllowTrustResultCodeAllowTrustCantRevoke:
-					return AllowTrustCantRevoke
-				default:
-					return protocols.InternalServerError
-				}
-			} else if operationsResult.Tr.PaymentResult != nil {
-				switch operationsResult.Tr.PaymentResult.Code {
-				case xdr.PaymentResultCodePaymentMalformed:
-					return PaymentMalformed
-				case xdr.PaymentResultCodePaymentUnderfunded:
-					return PaymentUnderfunded
-				case xdr.PaymentResultCodePaymentSrcNoTrust:
-					return PaymentSrcNoTrust
-				case xdr.PaymentResultCodePaymentSrcNotAuthorized:
-					return PaymentSrcNotAuthorized
-				case xdr.PaymentResultCodePaymentNoDestination:
-					return PaymentNoDestination
-				case xdr.PaymentResultCodePaymentNoTrust:
-					return PaymentNoTrust
-				case xdr.PaymentResultCodePaymentNotAuthorized:
-					return PaymentNotAuthorized
-				case xdr.PaymentResultCodePaymentLineFull:
-					return PaymentLineFull
-				case xdr.PaymentResultCodePaymentNoIssuer:
-					return PaymentNoIssuer
-				default:
-					return protocols.InternalServerError
-				}
-			} else if operationsResult.Tr.PathPaymentResult != nil {
-				switch operationsResult.Tr.PathPaymentResult.Code {
-				case xdr.PathPaymentResultCodePathPaymentMalformed:
-					return PaymentMalformed
-				case xdr.PathPaymentResultCodePathPaymentUnderfunded:
-					return PaymentUnderfunded
-				case xdr.PathPaymentResultCodePathPaymentSrcNoTrust:
-					return PaymentSrcNoTrust
-				case xdr.PathPaymentResultCodePathPaymentSrcNotAuthorized:
-					return PaymentSrcNotAuthorized
-				case xdr.PathPaymentResultCodePathPaymentNoDestination:
-					return PaymentNoDestination
-				case xdr.PathPaymentResultCodePathPaymentNoTrust:
-					return PaymentNoTrust
-				case xdr.PathPaymentResultCodePathPaymentNotAuthorized:
-					return PaymentNotAuthorized
-				case xdr.PathPaymentResultCodePathPaymentLineFull:
-					return PaymentLineFull
-				case xdr.PathPaymentResultCodePathPaymentNoIssuer:
-					return PaymentNoIssuer
-				case xdr.PathPaymentResultCodePathPaymentTooFewOffers:
-					return PaymentTooFewOffers
-				case xdr.PathPaymentResultCodePathPaymentOfferCrossSelf:
-					return PaymentOfferCrossSelf
-				case xdr.PathPaymentResultCodePathPaymentOverSendmax:
-					return PaymentOverSendmax
-				default:
-					return protocols.InternalServerError
-				}
+			errorResponse := errorFromOperationResult(*operationsResult)
+			if errorResponse == nil {
+				return protocols.InternalServerError
 			}
+			return errorResponse
 		} else {
 			return protocols.InternalServerError
 		}
@@ -140,6 +127,129 @@ func ErrorFromHorizonResponse(response horizon.SubmitTransactionResponse) *proto
 	return nil
 }
 
+// OperationErrorsFromHorizonResponse returns an ErrorResponse for every operation in a failed
+// transaction. Elements for operations that succeeded are nil. It returns nil when the
+// transaction was successful or failed for a reason unrelated to its operations.
+func OperationErrorsFromHorizonResponse(response horizon.SubmitTransactionResponse) []*protocols.ErrorResponse {
+	if response.Ledger != nil || response.Extras == nil {
+		return nil
+	}
+
+	txResult, err := unmarshalTransactionResult(response.Extras.ResultXdr)
+	if err != nil || txResult.Result.Code != xdr.TransactionResultCodeTxFailed || txResult.Result.Results == nil {
+		return nil
+	}
+
+	operationsResults := *txResult.Result.Results
+	errors := make([]*protocols.ErrorResponse, len(operationsResults))
+	for i, operationResult := range operationsResults {
+		errors[i] = errorFromOperationResult(operationResult)
+	}
+	return errors
+}
+
+// errorFromOperationResult returns nil when operation was successful
+func errorFromOperationResult(operationsResult xdr.OperationResult) *protocols.ErrorResponse {
+	if operationsResult.Tr == nil {
+		return protocols.InternalServerError
+	}
+
+	if operationsResult.Tr.AllowTrustResult != nil {
+		switch operationsResult.Tr.AllowTrustResult.Code {
+		case xdr.AllowTrustResultCodeAllowTrustSuccess:
+			return nil
+		case xdr.AllowTrustResultCodeAllowTrustMalformed:
+			return AllowTrustMalformed
+		case xdr.AllowTrustResultCodeAllowTrustNoTrustLine:
+			return AllowTrustNoTrustline
+		case xdr.AllowTrustResultCodeAllowTrustTrustNotRequired:
+			return AllowTrustTrustNotRequired
+		case xdr.AllowTrustResultCodeAllowTrustCantRevoke:
+			return AllowTrustCantRevoke
+		default:
+			return protocols.InternalServerError
+		}
+	} else if operationsResult.Tr.PaymentResult != nil {
+		switch operationsResult.Tr.PaymentResult.Code {
+		case xdr.PaymentResultCodePaymentSuccess:
+			return nil
+		case xdr.PaymentResultCodePaymentMalformed:
+			return PaymentMalformed
+		case xdr.PaymentResultCodePaymentUnderfunded:
+			return PaymentUnderfunded
+		case xdr.PaymentResultCodePaymentSrcNoTrust:
+			return PaymentSrcNoTrust
+		case xdr.PaymentResultCodePaymentSrcNotAuthorized:
+			return PaymentSrcNotAuthorized
+		case xdr.PaymentResultCodePaymentNoDestination:
+			return PaymentNoDestination
+		case xdr.PaymentResultCodePaymentNoTrust:
+			return PaymentNoTrust
+		case xdr.PaymentResultCodePaymentNotAuthorized:
+			return PaymentNotAuthorized
+		case xdr.PaymentResultCodePaymentLineFull:
+			return PaymentLineFull
+		case xdr.PaymentResultCodePaymentNoIssuer:
+			return PaymentNoIssuer
+		default:
+			return protocols.InternalServerError
+		}
+	} else if operationsResult.Tr.CreateAccountResult != nil {
+		switch operationsResult.Tr.CreateAccountResult.Code {
+		case xdr.CreateAccountResultCodeCreateAccountSuccess:
+			return nil
+		case xdr.CreateAccountResultCodeCreateAccountMalformed:
+			return CreateAccountMalformed
+		case xdr.CreateAccountResultCodeCreateAccountUnderfunded:
+			return CreateAccountUnderfunded
+		case xdr.CreateAccountResultCodeCreateAccountLowReserve:
+			return CreateAccountLowReserve
+		case xdr.CreateAccountResultCodeCreateAccountAlreadyExist:
+			return CreateAccountAlreadyExists
+		default:
+			return protocols.InternalServerError
+		}
+	} else if operationsResult.Tr.ChangeTrustResult != nil {
+		if operationsResult.Tr.ChangeTrustResult.Code != xdr.ChangeTrustResultCodeChangeTrustSuccess {
+			return protocols.InternalServerError
+		}
+		return nil
+	} else if operationsResult.Tr.PathPaymentResult != nil {
+		switch operationsResult.Tr.PathPaymentResult.Code {
+		case xdr.PathPaymentResultCodePathPaymentSuccess:
+			return nil
+		case xdr.PathPaymentResultCodePathPaymentMalformed:
+			return PaymentMalformed
+		case xdr.PathPaymentResultCodePathPaymentUnderfunded:
+			return PaymentUnderfunded
+		case xdr.PathPaymentResultCodePathPaymentSrcNoTrust:
+			return PaymentSrcNoTrust
+		case xdr.PathPaymentResultCodePathPaymentSrcNotAuthorized:
+			return PaymentSrcNotAuthorized
+		case xdr.PathPaymentResultCodePathPaymentNoDestination:
+			return PaymentNoDestination
+		case xdr.PathPaymentResultCodePathPaymentNoTrust:
+			return PaymentNoTrust
+		case xdr.PathPaymentResultCodePathPaymentNotAuthorized:
+			return PaymentNotAuthorized
+		case xdr.PathPaymentResultCodePathPaymentLineFull:
+			return PaymentLineFull
+		case xdr.PathPaymentResultCodePathPaymentNoIssuer:
+			return PaymentNoIssuer
+		case xdr.PathPaymentResultCodePathPaymentTooFewOffers:
+			return PaymentTooFewOffers
+		case xdr.PathPaymentResultCodePathPaymentOfferCrossSelf:
+			return PaymentOfferCrossSelf
+		case xdr.PathPaymentResultCodePathPaymentOverSendmax:
+			return PaymentOverSendmax
+		default:
+			return protocols.InternalServerError
+		}
+	}
+
+	return nil
+}
+
 func unmarshalTransactionResult(transactionResult string) (txResult xdr.TransactionResult, err error) {
 	reader := strings.NewReader(transactionResult)
 	b64r := base64.NewDecoder(base64.StdEncoding, reader)
@@ -0,0 +1,65 @@
+package bridge
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorFromHorizonResponseTransactionResults(t *testing.T) {
+	var code xdr.TransactionResultCode
+	for value := int32(math.MinInt8); value <= math.MaxInt8; value++ {
+		if !code.ValidEnum(value) {
+			continue
+		}
+		code = xdr.TransactionResultCode(value)
+		resultErr := horizon.NewTransactionResultError(code)
+		if resultErr == nil {
+			continue
+		}
+
+		t.Run(resultErr.Name, func(t *testing.T) {
+			resultXdr, err := xdr.MarshalBase64(xdr.TransactionResult{
+				FeeCharged: 100,
+				Result:     xdr.TransactionResultResult{Code: code},
+			})
+			require.NoError(t, err)
+
+			errorResponse := ErrorFromHorizonResponse(horizon.SubmitTransactionResponse{
+				Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: resultXdr},
+			})
+			require.NotNil(t, errorResponse)
+			assert.NotEqual(t, protocols.InternalServerError, errorResponse, "%s has no error response", resultErr.Name)
+			assert.Equal(t, resultErr.Status, errorResponse.Status)
+			assert.Equal(t, resultErr.Remediation, errorResponse.Remediation)
+			assert.True(t, protocols.IsRegisteredErrorCode(errorResponse.Code))
+		})
+	}
+}
+
+func TestErrorFromHorizonResponseCreateAccountResults(t *testing.T) {
+	failed := func(code xdr.CreateAccountResultCode) horizon.SubmitTransactionResponse {
+		resultXdr, err := xdr.MarshalBase64(xdr.TransactionResult{
+			FeeCharged: 100,
+			Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxFailed, Results: &[]xdr.OperationResult{{
+				Code: xdr.OperationResultCodeOpInner,
+				Tr: &xdr.OperationResultTr{
+					Type:                xdr.OperationTypeCreateAccount,
+					CreateAccountResult: &xdr.CreateAccountResult{Code: code},
+				},
+			}}},
+		})
+		require.NoError(t, err)
+		return horizon.SubmitTransactionResponse{Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: resultXdr}}
+	}
+
+	assert.Equal(t, CreateAccountMalformed, ErrorFromHorizonResponse(failed(xdr.CreateAccountResultCodeCreateAccountMalformed)))
+	assert.Equal(t, CreateAccountUnderfunded, ErrorFromHorizonResponse(failed(xdr.CreateAccountResultCodeCreateAccountUnderfunded)))
+	assert.Equal(t, CreateAccountLowReserve, ErrorFromHorizonResponse(failed(xdr.CreateAccountResultCodeCreateAccountLowReserve)))
+	assert.Equal(t, CreateAccountAlreadyExists, ErrorFromHorizonResponse(failed(xdr.CreateAccountResultCodeCreateAccountAlreadyExist)))
+}
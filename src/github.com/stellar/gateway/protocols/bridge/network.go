@@ -0,0 +1,40 @@
+package bridge
+
+import (
+	"net/http"
+
+	"github.com/stellar/gateway/protocols"
+)
+
+// NetworkParam selects the network of a request on servers with `networks` config
+const NetworkParam = "network"
+
+var (
+	// NetworkNotConfigured is an error response
+	NetworkNotConfigured = &protocols.ErrorResponse{Code: "network_not_configured", Message: "Network is not configured.", Status: http.StatusBadRequest}
+	// PaymentSourceOtherNetwork is an error response
+	PaymentSourceOtherNetwork = &protocols.ErrorResponse{Code: "source_other_network", Message: "Source account is configured in another network. Send the payment with `network` param of the network of the source.", Status: http.StatusBadRequest}
+)
+
+// NewNetworkNotConfiguredError creates and returns a new NetworkNotConfigured error
+func NewNetworkNotConfiguredError(network string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  NetworkNotConfigured.Status,
+		Code:    NetworkNotConfigured.Code,
+		Message: NetworkNotConfigured.Message,
+		Data:    map[string]interface{}{"network": network},
+	}
+}
+
+// NewPaymentSourceOtherNetworkError creates and returns a new PaymentSourceOtherNetwork error of
+// a source configured in sourceNetwork sent to network
+func NewPaymentSourceOtherNetworkError(sourceNetwork, network string) *protocols.ErrorResponse {
+	data := map[string]interface{}{"network": network, "source_network": sourceNetwork}
+	return &protocols.ErrorResponse{
+		Status:  PaymentSourceOtherNetwork.Status,
+		Code:    PaymentSourceOtherNetwork.Code,
+		Message: PaymentSourceOtherNetwork.Message,
+		Data:    data,
+		LogData: data,
+	}
+}
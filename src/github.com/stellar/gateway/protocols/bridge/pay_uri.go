@@ -0,0 +1,176 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+)
+
+const (
+	payURIPrefix = "web+stellar:pay?"
+	// payURISignatureParam is the last param of signed URIs
+	payURISignatureParam  = "&signature="
+	payURISignaturePrefix = "stellar.sep.7 - URI Scheme"
+)
+
+// ParsedPayURI contains fields of a SEP-7 `web+stellar:pay` URI. Memo fields use /payment
+// memo types (`text`, `id` or hex encoded `hash`).
+type ParsedPayURI struct {
+	Destination       string
+	Amount            string
+	AssetCode         string
+	AssetIssuer       string
+	MemoType          string
+	Memo              string
+	Callback          string
+	NetworkPassphrase string
+	OriginDomain      string
+	Signature         string
+	// signedPart is the URI without the signature param
+	signedPart string
+}
+
+// ParsePayURI parses a SEP-7 `web+stellar:pay` URI
+func ParsePayURI(uri string) (*ParsedPayURI, error) {
+	if !strings.HasPrefix(uri, payURIPrefix) {
+		return nil, errors.New("URI must start with `web+stellar:pay?`")
+	}
+
+	query, err := url.ParseQuery(strings.TrimPrefix(uri, payURIPrefix))
+	if err != nil {
+		return nil, errors.New("URI query is invalid")
+	}
+
+	parsed := &ParsedPayURI{
+		Destination:       query.Get("destination"),
+		Amount:            query.Get("amount"),
+		AssetCode:         query.Get("asset_code"),
+		AssetIssuer:       query.Get("asset_issuer"),
+		Callback:          query.Get("callback"),
+		NetworkPassphrase: query.Get("network_passphrase"),
+		OriginDomain:      query.Get("origin_domain"),
+		Signature:         query.Get("signature"),
+		signedPart:        uri,
+	}
+
+	if parsed.Destination == "" {
+		return nil, errors.New("URI destination is missing")
+	}
+
+	if parsed.Signature != "" {
+		index := strings.LastIndex(uri, payURISignatureParam)
+		if index == -1 {
+			return nil, errors.New("signature must be the last URI param")
+		}
+		parsed.signedPart = uri[:index]
+	}
+
+	memo := query.Get("memo")
+	switch memoType := query.Get("memo_type"); memoType {
+	case "":
+		if memo != "" {
+			// MEMO_TEXT is the default memo type
+			parsed.MemoType, parsed.Memo = "text", memo
+		}
+	case "MEMO_TEXT":
+		parsed.MemoType, parsed.Memo = "text", memo
+	case "MEMO_ID":
+		parsed.MemoType, parsed.Memo = "id", memo
+	case "MEMO_HASH":
+		// SEP-7 hash memos are base64 encoded
+		hash, err := base64.StdEncoding.DecodeString(memo)
+		if err != nil || len(hash) != 32 {
+			return nil, errors.New("URI memo must be a base64 encoded 32 bytes hash")
+		}
+		parsed.MemoType, parsed.Memo = "hash", hex.EncodeToString(hash)
+	default:
+		return nil, fmt.Errorf("URI memo_type %s is not supported", memoType)
+	}
+
+	return parsed, nil
+}
+
+// IsSigned returns true if the URI has origin_domain and signature params
+func (uri *ParsedPayURI) IsSigned() bool {
+	return uri.OriginDomain != "" || uri.Signature != ""
+}
+
+// VerifySignature checks the URI signature with the URI_REQUEST_SIGNING_KEY of origin_domain
+func (uri *ParsedPayURI) VerifySignature(signingKey string) error {
+	if uri.OriginDomain == "" || uri.Signature == "" {
+		return errors.New("signed URI must contain origin_domain and signature")
+	}
+
+	kp, err := keypair.Parse(signingKey)
+	if err != nil {
+		return errors.New("URI_REQUEST_SIGNING_KEY of origin_domain is invalid")
+	}
+
+	// `+` of signatures that were not percent-encoded is decoded as a space
+	signature, err := base64.StdEncoding.DecodeString(strings.Replace(uri.Signature, " ", "+", -1))
+	if err != nil {
+		return errors.New("URI signature is not base64 encoded")
+	}
+
+	err = kp.Verify(PayURISignaturePayload(uri.signedPart), signature)
+	if err != nil {
+		return errors.New("URI signature is invalid")
+	}
+	return nil
+}
+
+// PayURISignaturePayload returns the payload signed by origin_domain: 35 zero bytes, 4, the SEP-7
+// prefix and the URI without the signature param
+func PayURISignaturePayload(unsignedURI string) []byte {
+	var payload bytes.Buffer
+	payload.Write(make([]byte, 35))
+	payload.WriteByte(4)
+	payload.WriteString(payURISignaturePrefix)
+	payload.WriteString(unsignedURI)
+	return payload.Bytes()
+}
+
+// MergePayURI sets empty fields of the request to the URI values. Explicit request values win and
+// a warning is returned for every conflicting field. Asset and memo are merged as pairs.
+func (request *PaymentRequest) MergePayURI(uri *ParsedPayURI) (warnings []string) {
+	merge := func(name string, value *string, uriValue string) {
+		if *value == "" {
+			*value = uriValue
+		} else if uriValue != "" && *value != uriValue {
+			warnings = append(warnings, fmt.Sprintf("%s from the request was used instead of the uri value %s", name, uriValue))
+		}
+	}
+
+	merge("destination", &request.Destination, uri.Destination)
+	if request.AmountStroops == "" {
+		merge("amount", &request.Amount, uri.Amount)
+	} else if uri.Amount != "" {
+		// amount_stroops is converted when the request is validated
+		stroops, _ := strconv.ParseInt(request.AmountStroops, 10, 64)
+		if uriStroops, err := amount.Parse(uri.Amount); err != nil || int64(uriStroops) != stroops {
+			warnings = append(warnings, fmt.Sprintf("amount_stroops from the request was used instead of the uri value %s", uri.Amount))
+		}
+	}
+
+	if request.AssetCode == "" && request.AssetIssuer == "" {
+		request.AssetCode, request.AssetIssuer = uri.AssetCode, uri.AssetIssuer
+	} else if uri.AssetCode != "" && (request.AssetCode != uri.AssetCode || request.AssetIssuer != uri.AssetIssuer) {
+		warnings = append(warnings, fmt.Sprintf("asset from the request was used instead of the uri asset %s:%s", uri.AssetCode, uri.AssetIssuer))
+	}
+
+	if request.MemoType == "" && request.Memo == "" {
+		request.MemoType, request.Memo = uri.MemoType, uri.Memo
+	} else if uri.Memo != "" && (request.MemoType != uri.MemoType || request.Memo != uri.Memo) {
+		warnings = append(warnings, fmt.Sprintf("memo from the request was used instead of the uri %s memo %s", uri.MemoType, uri.Memo))
+	}
+
+	return warnings
+}
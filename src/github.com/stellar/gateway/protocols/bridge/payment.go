@@ -1,12 +1,26 @@
 package bridge
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
 	"net/http"
 	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/stellar/gateway/anomaly"
+	"github.com/stellar/gateway/handoff"
 	"github.com/stellar/gateway/protocols"
 	callback "github.com/stellar/gateway/protocols/compliance"
+	"github.com/stellar/gateway/txspec"
+	"github.com/stellar/go/address"
 	"github.com/stellar/go/keypair"
 )
 
@@ -21,11 +35,65 @@ var (
 	PaymentSourceNotExist = &protocols.ErrorResponse{Code: "source_not_exist", Message: "Source account does not exist.", Status: http.StatusBadRequest}
 	// PaymentAssetCodeNotAllowed is an error response
 	PaymentAssetCodeNotAllowed = &protocols.ErrorResponse{Code: "asset_code_not_allowed", Message: "Given asset_code not allowed.", Status: http.StatusBadRequest}
+	// PaymentExcessiveSlippage is an error response
+	PaymentExcessiveSlippage = &protocols.ErrorResponse{Code: "payment_excessive_slippage", Message: "Estimated price of the path payment exceeds allowed slippage.", Status: http.StatusBadRequest}
+	// PaymentNoPathFound is an error response
+	PaymentNoPathFound = &protocols.ErrorResponse{Code: "payment_no_path_found", Message: "No path from the send asset delivers the amount within send_max.", Status: http.StatusBadRequest}
+	// PaymentCounterpartyNotAllowed is an error response
+	PaymentCounterpartyNotAllowed = &protocols.ErrorResponse{Code: "counterparty_not_allowed", Message: "Payments to the domain of destination are not allowed.", Status: http.StatusForbidden}
+	// PaymentSourceNotRegistered is an error response
+	PaymentSourceNotRegistered = &protocols.ErrorResponse{Code: "payment_source_not_registered", Message: "Source is not registered with the domain of destination.", Status: http.StatusForbidden}
+	// PaymentMemoPolicyViolation is an error response
+	PaymentMemoPolicyViolation = &protocols.ErrorResponse{Code: "payment_memo_policy_violation", Message: "Memo does not meet deposit requirements of the domain of destination.", Status: http.StatusBadRequest}
+	// PaymentNotFound is an error response
+	PaymentNotFound = &protocols.ErrorResponse{Code: "payment_not_found", Message: "Payment not found or its result has expired.", Status: http.StatusNotFound}
+	// PaymentAnomalyBlocked is an error response
+	PaymentAnomalyBlocked = &protocols.ErrorResponse{Code: "payment_anomaly_blocked", Message: "Payment deviates from previous payments to the destination and has been blocked.", Status: http.StatusForbidden}
+	// PaymentAnomalyApprovalRequired is an error response
+	PaymentAnomalyApprovalRequired = &protocols.ErrorResponse{Code: "payment_anomaly_approval_required", Message: "Payment deviates from previous payments to the destination. It needs to be sent again by an operator with `approve_anomaly=true`.", Status: http.StatusForbidden}
+	// PaymentDuplicateID is an error response
+	PaymentDuplicateID = &protocols.ErrorResponse{Code: "payment_duplicate_id", Message: "Payment with the same id has been sent with different params.", Status: http.StatusConflict}
+	// PaymentInProgress is an error response
+	PaymentInProgress = &protocols.ErrorResponse{Code: "payment_in_progress", Message: "Payment with the same id is being sent by another request, repeat the request to get its response.", Status: http.StatusConflict}
+	// PaymentInvalidAmount is an error response
+	PaymentInvalidAmount = &protocols.ErrorResponse{Code: "payment_invalid_amount", Message: "Amount must be a positive number with at most 7 decimal places, without exponent or group separators.", Status: http.StatusBadRequest}
+	// PaymentInvalidIssuer is an error response
+	PaymentInvalidIssuer = &protocols.ErrorResponse{Code: "payment_invalid_issuer", Message: "Asset issuer federation address cannot be resolved to an account ID without memo.", Status: http.StatusBadRequest}
+	// PaymentStartingBalanceBelowReserve is an error response
+	PaymentStartingBalanceBelowReserve = &protocols.ErrorResponse{Code: "payment_starting_balance_below_reserve", Message: "starting_balance is below the minimum balance of an account.", Status: http.StatusBadRequest}
+	// PaymentAmountBelowReserve is an error response
+	PaymentAmountBelowReserve = &protocols.ErrorResponse{Code: "payment_amount_below_reserve", Message: "Destination account does not exist and amount is below the minimum balance of an account created by the payment.", Status: http.StatusBadRequest}
+	// PaymentSweepOffers is an error response
+	PaymentSweepOffers = &protocols.ErrorResponse{Code: "payment_sweep_offers", Message: "Source account has offers, its available balance cannot be computed.", Status: http.StatusBadRequest}
+	// PaymentNothingToSweep is an error response
+	PaymentNothingToSweep = &protocols.ErrorResponse{Code: "payment_nothing_to_sweep", Message: "Balance of the source account does not exceed its minimum balance and the fee of the transaction.", Status: http.StatusBadRequest}
+	// PaymentInvalidSource is an error response
+	PaymentInvalidSource = &protocols.ErrorResponse{Code: "payment_invalid_source", Message: "Source is not a name of a configured source account.", Status: http.StatusBadRequest}
+	// PaymentSourceSeedNotAllowed is an error response
+	PaymentSourceSeedNotAllowed = &protocols.ErrorResponse{Code: "payment_source_seed_not_allowed", Message: "Source must be a name of a configured source account or a public key, seeds are not accepted.", Status: http.StatusForbidden}
+	// PaymentDestinationExists is an error response
+	PaymentDestinationExists = &protocols.ErrorResponse{Code: "payment_destination_exists", Message: "Destination account exists, starting_balance can only be set when the payment creates it.", Status: http.StatusBadRequest}
+	// PaymentDestinationDoesNotExist is an error response
+	PaymentDestinationDoesNotExist = &protocols.ErrorResponse{Code: "payment_destination_does_not_exist", Message: "Destination account does not exist and account creation is forbidden.", Status: http.StatusBadRequest}
+	// PaymentInvalidFee is an error response
+	PaymentInvalidFee = &protocols.ErrorResponse{Code: "invalid_fee", Message: "Fee must be an integer number of stroops, at least 100 per operation of the transaction.", Status: http.StatusBadRequest}
+	// PaymentInvalidTimeBounds is an error response
+	PaymentInvalidTimeBounds = &protocols.ErrorResponse{Code: "invalid_time_bounds", Message: "max_time must be in the future and not before min_time.", Status: http.StatusBadRequest}
+	// PaymentInvalidPath is an error response
+	PaymentInvalidPath = &protocols.ErrorResponse{Code: "payment_invalid_path", Message: "Path is invalid.", Status: http.StatusBadRequest}
+	// PaymentMalformedAssetCode is an error response
+	PaymentMalformedAssetCode = &protocols.ErrorResponse{Code: "payment_malformed_asset_code", Message: "Asset code must be 1-12 alphanumeric characters.", Status: http.StatusBadRequest}
+	// PaymentInvalidMemo is an error response
+	PaymentInvalidMemo = &protocols.ErrorResponse{Code: "payment_invalid_memo", Message: "Memo is invalid.", Status: http.StatusBadRequest}
+	// PaymentInvalidFederationMemo is an error response
+	PaymentInvalidFederationMemo = &protocols.ErrorResponse{Code: "invalid_federation_memo", Message: "Federation server of the destination returned an invalid memo.", Status: http.StatusBadGateway}
+	// PaymentChannelsExhausted is an error response
+	PaymentChannelsExhausted = &protocols.ErrorResponse{Code: "channels_exhausted", Message: "All channel accounts are in use, please try again later.", Status: http.StatusServiceUnavailable}
 
 	// compliance
 
 	// PaymentPending is an error response
-	PaymentPending = &protocols.ErrorResponse{Code: "pending", Message: "Transaction pending. Repeat your request after given time.", Status: http.StatusAccepted}
+	PaymentPending = &protocols.ErrorResponse{Code: "pending", Message: "Transaction pending. Repeat your request after given time.", Status: http.StatusAccepted, Retriable: true}
 	// PaymentDenied is an error response
 	PaymentDenied = &protocols.ErrorResponse{Code: "denied", Message: "Transaction denied by destination.", Status: http.StatusForbidden}
 
@@ -55,8 +123,117 @@ var (
 	PaymentOfferCrossSelf = &protocols.ErrorResponse{Code: "payment_offer_cross_self", Message: "would cross one of its own offers.", Status: http.StatusBadRequest}
 	// PaymentOverSendmax is an error response
 	PaymentOverSendmax = &protocols.ErrorResponse{Code: "payment_over_sendmax", Message: "Could not satisfy sendmax.", Status: http.StatusBadRequest}
+
+	// create_account op errors, returned when the destination does not exist and the payment
+	// creates it
+
+	// CreateAccountMalformed is an error response
+	CreateAccountMalformed = &protocols.ErrorResponse{Code: "create_account_malformed", Message: "Create account operation is malformed.", Status: http.StatusBadRequest}
+	// CreateAccountInvalidAmount is an error response
+	CreateAccountInvalidAmount = &protocols.ErrorResponse{Code: "create_account_invalid_amount", Message: "Starting balance of the created account must be a positive number with at most 7 decimal places.", Status: http.StatusBadRequest}
+	// CreateAccountUnderfunded is an error response
+	CreateAccountUnderfunded = &protocols.ErrorResponse{Code: "create_account_underfunded", Message: "Not enough funds to create the destination account.", Status: http.StatusBadRequest}
+	// CreateAccountLowReserve is an error response
+	CreateAccountLowReserve = &protocols.ErrorResponse{Code: "create_account_low_reserve", Message: "Starting balance is below the minimum balance of an account.", Status: http.StatusBadRequest}
+	// CreateAccountAlreadyExists is an error response
+	CreateAccountAlreadyExists = &protocols.ErrorResponse{Code: "create_account_already_exists", Message: "Destination account has been created before the transaction was applied.", Status: http.StatusBadRequest}
 )
 
+// PaymentHandoffStatusPending is the status of a handed off payment that is not finished
+const PaymentHandoffStatusPending = "pending"
+
+// PaymentHandoffStatusQueued is the status of a payment sent with `queue=true` that has not been
+// sent yet
+const PaymentHandoffStatusQueued = "queued"
+
+// PaymentHandoffResponse is returned by /payment when the payment was not finished within
+// max_wait, and by /payment/{id} until it's finished
+type PaymentHandoffResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// Stage of the payment, see inflight package
+	Stage string `json:"stage,omitempty"`
+	// Hash of the transaction, set when it's signed
+	Hash string `json:"hash,omitempty"`
+	// Attempts and LastError of a queued payment, LastError is the error code of the last attempt
+	Attempts  int    `json:"attempts,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// HTTPStatus implements server.Response
+func (response PaymentHandoffResponse) HTTPStatus() int {
+	return http.StatusAccepted
+}
+
+// Marshal implements server.Response
+func (response PaymentHandoffResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
+
+// PaymentStatusUnsigned is the status of payments returned unsigned
+const PaymentStatusUnsigned = "unsigned"
+
+// UnsignedPaymentResponse is returned by /payment when `source` is a public key. The transaction
+// is built as usual but it's not signed or submitted, it's submitted by the client.
+type UnsignedPaymentResponse struct {
+	protocols.SuccessResponse
+	Status string `json:"status"`
+	// Hash of the transaction, signatures don't change it
+	Hash string `json:"hash"`
+	// EnvelopeXdr is a base64 encoded envelope without signatures
+	EnvelopeXdr string `json:"envelope_xdr"`
+	// TrustlineCreated is true when auto_trust prepended change_trust operation
+	TrustlineCreated bool     `json:"trustline_created,omitempty"`
+	Warnings         []string `json:"warnings,omitempty"`
+	// TimeBounds of the transaction set by min_time and max_time
+	TimeBounds *txspec.TimeBounds `json:"time_bounds,omitempty"`
+}
+
+// Marshal implements server.Response
+func (response *UnsignedPaymentResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
+
+// AmountAll is the `amount` param of XLM payments sending the available balance of the source:
+// its XLM balance minus its minimum balance and the fee of the transaction
+const AmountAll = "all"
+
+// PaymentStatusDryRun is the status of payments sent with `dry_run=true`
+const PaymentStatusDryRun = "dry_run"
+
+// DryRunPaymentResponse is returned by /payment when `dry_run=true` is sent. The transaction is
+// built and signed as usual but it's not submitted, so its sequence number is not used: the
+// envelope is stale once another transaction of its source is submitted.
+type DryRunPaymentResponse struct {
+	protocols.SuccessResponse
+	Status string `json:"status"`
+	Hash   string `json:"hash"`
+	// EnvelopeXdr is a base64 encoded envelope, without signatures for public key sources
+	EnvelopeXdr string `json:"envelope_xdr"`
+	// Fee of the transaction in stroops
+	Fee      uint32 `json:"fee"`
+	Sequence string `json:"sequence"`
+	// DestinationAccountID is the account resolved from `destination` (ex. a Stellar address),
+	// empty for batch payments
+	DestinationAccountID string `json:"destination_account_id,omitempty"`
+	// MemoType and Memo of the transaction, hash and return memos are base64 encoded
+	MemoType string `json:"memo_type,omitempty"`
+	Memo     string `json:"memo,omitempty"`
+	// TrustlineCreated is true when auto_trust prepended change_trust operation
+	TrustlineCreated bool     `json:"trustline_created,omitempty"`
+	Warnings         []string `json:"warnings,omitempty"`
+	// TimeBounds of the transaction set by min_time and max_time
+	TimeBounds *txspec.TimeBounds `json:"time_bounds,omitempty"`
+}
+
+// Marshal implements server.Response
+func (response *DryRunPaymentResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
+
 // PaymentRequest represents request made to /payment endpoint of the bridge server
 type PaymentRequest struct {
 	// Source account secret
@@ -69,14 +246,21 @@ type PaymentRequest struct {
 	MemoType string `name:"memo_type"`
 	// Memo value
 	Memo string `name:"memo"`
-	// Amount destination should receive
+	// Amount destination should receive, AmountAll sends the available XLM balance of the source
 	Amount string `name:"amount" required:""`
+	// Amount in stroops, replaces Amount when it's validated
+	AmountStroops string `name:"amount_stroops"`
 	// Code of the asset destination should receive
 	AssetCode string `name:"asset_code"`
 	// Issuer of the asset destination should receive
 	AssetIssuer string `name:"asset_issuer"`
+	// Starting balance of destination when it doesn't exist and is created by the XLM payment,
+	// amount when empty
+	StartingBalance string `name:"starting_balance"`
 	// Only for path_payment
 	SendMax string `name:"send_max"`
+	// Only for path_payment, send max in stroops
+	SendMaxStroops string `name:"send_max_stroops"`
 	// Only for path_payment
 	SendAssetCode string `name:"send_asset_code"`
 	// Only for path_payment
@@ -87,18 +271,76 @@ type PaymentRequest struct {
 	UseCompliance bool `name:"use_compliance"`
 	// Extra memo. If set, UseCompliance value will be ignored and it will use compliance.
 	ExtraMemo string `name:"extra_memo"`
+	// Skips order book check of large path payments. Operator role only.
+	SkipSlippageCheck bool `name:"skip_slippage_check"`
+	// Prepends change_trust operation when source does not trust the asset it sends
+	AutoTrust bool `name:"auto_trust"`
+	// Skips the check of the trustline of destination before a credit asset is sent, ex. when
+	// the trustline is created at the same time
+	SkipTrustCheck bool `name:"skip_trust_check"`
+	// Fails XLM payments to a non-existent destination instead of creating it
+	ForbidAccountCreation bool `name:"forbid_account_creation"`
+	// SEP-7 `web+stellar:pay` URI, explicit params override its values
+	URI string `name:"uri"`
+	// Empty, `multi_asset` (PaymentTypeMultiAsset) or `batch` (PaymentTypeBatch)
+	Type string `name:"type"`
+	// Only for multi_asset: assets[n][asset_code] assets[n][asset_issuer] assets[n][amount]
+	Assets []PaymentAsset
+	// Only for batch: payments[n][destination] payments[n][amount] payments[n][asset_code]
+	// payments[n][asset_issuer]
+	Payments []BatchPayment
+	// Seeds signing the transaction with the source seed (or instead of a source public key):
+	// signers[] or comma-separated signers
+	Signers []string
+	// Seconds to wait for the payment before it's handed off to asynchronous processing
+	MaxWait string `name:"max_wait"`
+	// Client-supplied ID making the request idempotent: a request repeated with the same ID
+	// returns the response of the first one instead of sending another payment
+	ID string `name:"id"`
+	// Stores the payment and sends it in the background, in order with other queued payments.
+	// Payments are retried while Horizon is unreachable.
+	Queue bool `name:"queue"`
+	// Returns 202 Accepted with an ID once the transaction is signed and stored, it's submitted in
+	// the background and its response is returned by /payment/{id}
+	Async bool `name:"async"`
+	// Builds and signs the transaction without submitting it, the envelope is returned
+	DryRun bool `name:"dry_run"`
+	// Sends a payment flagged by anomaly detection with `approve` policy. Operator role only.
+	ApproveAnomaly bool `name:"approve_anomaly"`
+	// Fee of the transaction in stroops, base_fee per operation when empty
+	Fee string `name:"fee"`
+	// MinTime and MaxTime are unix or RFC3339 timestamps between which the transaction can be
+	// included in a ledger, MaxTime 0 or empty means no limit
+	MinTime string `name:"min_time"`
+	MaxTime string `name:"max_time"`
+	// URL the outcome of the submitted transaction is posted to, in addition to the response
+	Callback string `name:"callback"`
 
 	protocols.FormRequest
 }
 
 // FromRequest will populate request fields using http.Request.
 func (request *PaymentRequest) FromRequest(r *http.Request) error {
-	return request.FormRequest.FromRequest(r, request)
+	err := request.FormRequest.FromRequest(r, request)
+	if err != nil {
+		return err
+	}
+	request.Assets = paymentAssetsFromForm(r.PostForm)
+	request.Payments = batchPaymentsFromForm(r.PostForm)
+	request.Signers = signersFromForm(r.PostForm)
+	if value, ok := r.PostForm["path"]; ok && len(request.Path) == 0 {
+		// Invalid values are reported by Validate
+		request.Path, _ = pathFromJSON(value[0])
+	}
+	return nil
 }
 
-// ToValues will create url.Values from request.
+// ToValues will create url.Values from request. Signers are secrets, they are not included.
 func (request *PaymentRequest) ToValues() url.Values {
-	return request.FormRequest.ToValues(request)
+	values := request.FormRequest.ToValues(request)
+	paymentAssetsToValues(values, request.Assets)
+	batchPaymentsToValues(values, request.Payments)
+	return values
 }
 
 // ToComplianceSendRequest transforms PaymentRequest to callback.SendRequest
@@ -120,67 +362,593 @@ func (request *PaymentRequest) ToComplianceSendRequest() callback.SendRequest {
 	}
 }
 
+// MaxPaymentIDLength is the maximum length of `id` param
+const MaxPaymentIDLength = 64
+
+// Hash returns a hex encoded SHA-256 hash of params of a validated request identifying the
+// payment it sends. `id`, `max_wait` and `fee` are not hashed, the source seed is hashed as its
+// address.
+func (request *PaymentRequest) Hash() string {
+	params := request.ToValues()
+	params.Del("id")
+	params.Del("max_wait")
+	params.Del("fee")
+	if request.Source != "" {
+		sourceKeypair, _ := keypair.Parse(request.Source)
+		params.Set("source", sourceKeypair.Address())
+	}
+	hash := sha256.Sum256([]byte(params.Encode()))
+	return hex.EncodeToString(hash[:])
+}
+
+// PaymentParams are params of /payment requests that are not PaymentRequest fields: the API key,
+// the correlation ID, the network and the account state of /simulate
+var PaymentParams = []string{"apiKey", "correlation_id", NetworkParam, "state"}
+
+// indexedPaymentParam matches path[n], assets[n] and payments[n] params
+var indexedPaymentParam = regexp.MustCompile(`^(path\[\d+\]\[asset_(code|issuer)\]|assets\[\d+\]\[(asset_code|asset_issuer|amount|amount_stroops)\]|payments\[\d+\]\[(destination|amount|amount_stroops|asset_code|asset_issuer)\])$`)
+
 // Validate validates if request fields are valid. Useful when checking if a request is correct.
+// Independent checks are run together and their failures are returned in a single
+// protocols.ValidationFailedError, checks depending on other params run only when they are valid.
 func (request *PaymentRequest) Validate() error {
-	err := request.FormRequest.CheckRequired(request)
-	if err != nil {
-		return err
+	if request.Type != "" && request.Type != PaymentTypeMultiAsset && request.Type != PaymentTypeBatch {
+		return protocols.NewInvalidParameterError("type", request.Type, "Type must be empty, `multi_asset` or `batch`.")
 	}
 
-	if request.Source != "" {
-		_, err = keypair.Parse(request.Source)
+	var errs protocols.ValidationErrors
+	if request.Type == "" {
+		errs.Add(protocols.ConvertStroopsParam("amount", &request.Amount, "amount_stroops", &request.AmountStroops))
+		errs.Add(protocols.ConvertStroopsParam("send_max", &request.SendMax, "send_max_stroops", &request.SendMaxStroops))
+	}
+
+	switch {
+	case request.Type == PaymentTypeBatch:
+		// Destinations and amounts are sent in payments[n] params
+	case request.Type == PaymentTypeMultiAsset:
+		// Amount is sent in assets[n][amount]
+		if request.Destination == "" {
+			errs.Add(protocols.NewMissingParameter("destination"))
+		}
+	case request.URI == "":
+		missing, err := request.FormRequest.MissingRequired(request)
 		if err != nil {
-			return protocols.NewInvalidParameterError("source", request.Source, "Source must be a public key (starting with `G`).")
+			return err
+		}
+		for _, missingErr := range missing {
+			// Invalid amount_stroops is reported instead
+			if request.AmountStroops == "" || missingErr.Data["name"] != "amount" {
+				errs.Add(missingErr)
+			}
+		}
+	default:
+		// Required params can be set by the URI (merged by MergePayURI)
+		if request.Destination == "" {
+			errs.Add(protocols.NewMissingParameter("destination"))
+		}
+		if request.Amount == "" && request.AmountStroops == "" {
+			errs.Add(protocols.NewMissingParameter("amount"))
+		}
+	}
+
+	errs = append(errs, request.validateUnknownParams()...)
+
+	if request.Destination != "" && !isValidDestination(request.Destination) {
+		errs.Add(protocols.NewInvalidParameterError("destination", request.Destination, "Destination must be a public key (starting with `G`) or a Stellar address."))
+	}
+
+	if request.MaxWait != "" {
+		if _, waitErr := handoff.ParseWait(request.MaxWait); waitErr != nil {
+			errs.Add(protocols.NewInvalidParameterError("max_wait", request.MaxWait, "max_wait "+waitErr.Error()+"."))
+		}
+	}
+
+	if request.Fee != "" {
+		// The minimum fee of transactions with more operations is checked when they are built
+		if fee, err := strconv.ParseUint(request.Fee, 10, 32); err != nil || fee < txspec.BaseFee {
+			errs.Add(NewPaymentInvalidFeeError(request.Fee, txspec.BaseFee))
+		}
+		// Transactions of compliance payments are built by the compliance server
+		if request.ExtraMemo != "" || request.UseCompliance {
+			errs.Add(protocols.NewInvalidParameterError("fee", request.Fee, "Fee cannot be set in compliance payments."))
 		}
 	}
 
+	if request.MinTime != "" || request.MaxTime != "" {
+		errs.Add(request.validateTimeBounds(time.Now()))
+		if request.ExtraMemo != "" || request.UseCompliance {
+			errs.Add(protocols.NewInvalidParameterError("max_time", request.MaxTime, "Time bounds cannot be set in compliance payments."))
+		}
+	}
+
+	if request.Queue {
+		// Queued payments are sent once and their result is polled with the returned ID
+		switch {
+		case request.MaxWait != "":
+			errs.Add(protocols.NewInvalidParameterError("queue", "true", "Queued payments cannot set max_wait."))
+		case request.ID != "":
+			errs.Add(protocols.NewInvalidParameterError("queue", "true", "Queued payments cannot set id, they are sent once."))
+		case request.ExtraMemo != "" || request.UseCompliance:
+			errs.Add(protocols.NewInvalidParameterError("queue", "true", "Compliance payments cannot be queued."))
+		}
+	}
+
+	if request.Async {
+		// Async payments are answered before they are submitted, they are sent once under the
+		// returned ID
+		switch {
+		case request.Queue:
+			errs.Add(protocols.NewInvalidParameterError("async", "true", "Async payments cannot be queued."))
+		case request.MaxWait != "":
+			errs.Add(protocols.NewInvalidParameterError("async", "true", "Async payments cannot set max_wait."))
+		case request.ID != "":
+			errs.Add(protocols.NewInvalidParameterError("async", "true", "Async payments cannot set id, they are sent once."))
+		case request.ExtraMemo != "" || request.UseCompliance:
+			errs.Add(protocols.NewInvalidParameterError("async", "true", "Compliance payments cannot be async."))
+		}
+	}
+
+	if request.DryRun {
+		// Dry runs are not submitted, nothing is stored or sent
+		switch {
+		case request.Queue:
+			errs.Add(protocols.NewInvalidParameterError("dry_run", "true", "Dry runs cannot be queued."))
+		case request.Async:
+			errs.Add(protocols.NewInvalidParameterError("dry_run", "true", "Dry runs cannot be async."))
+		case request.ID != "":
+			errs.Add(protocols.NewInvalidParameterError("dry_run", "true", "Dry runs cannot set id, they are not sent."))
+		case request.Callback != "":
+			errs.Add(protocols.NewInvalidParameterError("dry_run", "true", "Dry runs cannot set callback, they are not submitted."))
+		case request.ExtraMemo != "" || request.UseCompliance:
+			errs.Add(protocols.NewInvalidParameterError("dry_run", "true", "Compliance payments cannot be dry runs."))
+		}
+	}
+
+	if request.Callback != "" {
+		request.validateCallback(&errs)
+	}
+
+	if len(request.ID) > MaxPaymentIDLength {
+		errs.Add(protocols.NewInvalidParameterError("id", request.ID, fmt.Sprintf("Id must be at most %d characters.", MaxPaymentIDLength)))
+	}
+
+	if request.Source != "" {
+		if _, err := keypair.Parse(request.Source); err != nil {
+			errs.Add(protocols.NewInvalidParameterError("source", request.Source, "Source must be a public key (starting with `G`)."))
+		}
+	}
+
+	if len(request.Signers) > 0 {
+		request.validateSigners(&errs)
+	}
+
+	request.validatePath(&errs)
+
 	// Memo
 	if request.MemoType == "" && request.Memo != "" {
-		return protocols.NewMissingParameter("memo_type")
+		errs.Add(protocols.NewMissingParameter("memo_type"))
 	}
 
 	if request.MemoType != "" && request.Memo == "" {
-		return protocols.NewMissingParameter("memo")
+		errs.Add(protocols.NewMissingParameter("memo"))
 	}
 
-	// Destination Asset
-	if request.AssetCode == "" && request.AssetIssuer != "" {
-		return protocols.NewMissingParameter("asset_code")
+	if request.Type == PaymentTypeMultiAsset {
+		request.validateMultiAsset(&errs)
+		return errs.Err()
 	}
 
-	if request.AssetCode != "" && request.AssetIssuer == "" {
-		return protocols.NewMissingParameter("asset_issuer")
+	if request.Type == PaymentTypeBatch {
+		request.validateBatch(&errs)
+		return errs.Err()
 	}
 
-	if request.AssetIssuer != "" {
-		if !protocols.IsValidAccountID(request.AssetIssuer) {
-			return protocols.NewInvalidParameterError("asset_issuer", request.AssetIssuer, "Asset issuer must be a public key (starting with `G`).")
-		}
+	if request.Amount == AmountAll {
+		request.validateSweep(&errs)
+	} else if request.Amount != "" && !protocols.IsValidPositiveAmount(request.Amount) {
+		errs.Add(NewPaymentInvalidAmountError("amount", request.Amount))
+	}
+
+	if request.SendMax != "" && !protocols.IsValidPositiveAmount(request.SendMax) {
+		errs.Add(NewPaymentInvalidAmountError("send_max", request.SendMax))
+	}
+
+	if request.StartingBalance != "" {
+		request.validateStartingBalance(&errs)
 	}
 
+	// Destination Asset
+	errs.Add(validateAssetParams("asset_code", request.AssetCode, "asset_issuer", request.AssetIssuer, "Asset issuer"))
+
 	// Send Asset
-	if request.SendAssetCode == "" && request.SendAssetIssuer != "" {
-		return protocols.NewMissingParameter("send_asset_code")
+	errs.Add(validateAssetParams("send_asset_code", request.SendAssetCode, "send_asset_issuer", request.SendAssetIssuer, "Send asset issuer"))
+
+	return errs.Err()
+}
+
+// validateStartingBalance adds failed checks of starting_balance to errs. Accounts are created
+// only by XLM payments, the minimum balance is checked when the destination is loaded.
+func (request *PaymentRequest) validateStartingBalance(errs *protocols.ValidationErrors) {
+	if !protocols.IsValidPositiveAmount(request.StartingBalance) {
+		errs.Add(NewPaymentInvalidAmountError("starting_balance", request.StartingBalance))
+	}
+	switch {
+	case request.AssetCode != "":
+		errs.Add(protocols.NewInvalidParameterError("starting_balance", request.StartingBalance, "starting_balance can only be set in XLM payments."))
+	case request.SendMax != "":
+		errs.Add(protocols.NewInvalidParameterError("starting_balance", request.StartingBalance, "starting_balance cannot be set in path payments."))
+	case request.ExtraMemo != "" || request.UseCompliance:
+		errs.Add(protocols.NewInvalidParameterError("starting_balance", request.StartingBalance, "starting_balance cannot be set in compliance payments."))
+	case request.ForbidAccountCreation:
+		errs.Add(protocols.NewInvalidParameterError("starting_balance", request.StartingBalance, "starting_balance cannot be set when forbid_account_creation is set."))
 	}
+}
 
-	if request.SendAssetCode != "" && request.SendAssetIssuer == "" {
-		return protocols.NewMissingParameter("send_asset_issuer")
+// validateSweep adds failed checks of `amount=all` to errs. The amount is computed from the XLM
+// balance of the source when the payment operation is created.
+func (request *PaymentRequest) validateSweep(errs *protocols.ValidationErrors) {
+	switch {
+	case request.AssetCode != "" || request.AssetIssuer != "":
+		errs.Add(protocols.NewInvalidParameterError("amount", request.Amount, "amount=all can only be set in XLM payments."))
+	case request.SendMax != "":
+		errs.Add(protocols.NewInvalidParameterError("amount", request.Amount, "amount=all cannot be set in path payments."))
+	case request.StartingBalance != "":
+		errs.Add(protocols.NewInvalidParameterError("amount", request.Amount, "amount=all cannot be set with starting_balance."))
+	case request.ExtraMemo != "" || request.UseCompliance:
+		errs.Add(protocols.NewInvalidParameterError("amount", request.Amount, "amount=all cannot be set in compliance payments."))
 	}
+}
 
-	if request.SendAssetIssuer != "" {
-		if !protocols.IsValidAccountID(request.SendAssetIssuer) {
-			return protocols.NewInvalidParameterError("send_asset_issuer", request.SendAssetIssuer, "Send asset issuer must be a public key (starting with `G`).")
-		}
+// validateTimeBounds validates min_time and max_time params at now, only the first failure is
+// returned
+func (request *PaymentRequest) validateTimeBounds(now time.Time) *protocols.ErrorResponse {
+	minTime, err := parseTimestamp(request.MinTime)
+	if request.MinTime != "" && err != nil {
+		return protocols.NewInvalidParameterError("min_time", request.MinTime, "min_time must be a unix or RFC3339 timestamp.")
+	}
+	maxTime, err := parseTimestamp(request.MaxTime)
+	if request.MaxTime != "" && err != nil {
+		return protocols.NewInvalidParameterError("max_time", request.MaxTime, "max_time must be a unix or RFC3339 timestamp.")
 	}
 
+	if maxTime == 0 {
+		return nil
+	}
+	if maxTime <= uint64(now.Unix()) {
+		return NewPaymentInvalidTimeBoundsError("max_time", request.MaxTime, now)
+	}
+	if maxTime < minTime {
+		return NewPaymentInvalidTimeBoundsError("min_time", request.MinTime, now)
+	}
 	return nil
 }
 
+// TimeBounds returns time bounds of the transaction of the request, nil when min_time and
+// max_time are not sent. It must be called after Validate.
+func (request *PaymentRequest) TimeBounds() *txspec.TimeBounds {
+	if request.MinTime == "" && request.MaxTime == "" {
+		return nil
+	}
+	minTime, _ := parseTimestamp(request.MinTime)
+	maxTime, _ := parseTimestamp(request.MaxTime)
+	return &txspec.TimeBounds{MinTime: minTime, MaxTime: maxTime}
+}
+
+// MaxPaymentCallbackLength is the maximum length of `callback` param
+const MaxPaymentCallbackLength = 1024
+
+// validateCallback adds a failed check of `callback` param to errs. Callbacks are stored by
+// submitPayment, transactions of compliance payments are submitted by the compliance server.
+func (request *PaymentRequest) validateCallback(errs *protocols.ValidationErrors) {
+	u, err := url.Parse(request.Callback)
+	switch {
+	case len(request.Callback) > MaxPaymentCallbackLength:
+		errs.Add(protocols.NewInvalidParameterError("callback", request.Callback, fmt.Sprintf("Callback must be at most %d characters.", MaxPaymentCallbackLength)))
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		errs.Add(protocols.NewInvalidParameterError("callback", request.Callback, "Callback must be an absolute http or https URL."))
+	case request.ExtraMemo != "" || request.UseCompliance:
+		errs.Add(protocols.NewInvalidParameterError("callback", request.Callback, "Callback cannot be set in compliance payments."))
+	}
+}
+
+// validateAssetParams validates a pair of asset params, the issuer is checked only when both are set
+func validateAssetParams(codeName, code, issuerName, issuer, issuerLabel string) *protocols.ErrorResponse {
+	if code == "" && issuer != "" {
+		return protocols.NewMissingParameter(codeName)
+	}
+
+	if code != "" && issuer == "" {
+		return protocols.NewMissingParameter(issuerName)
+	}
+
+	// Federation addresses are resolved to account IDs by the handler
+	if issuer != "" && !isValidDestination(issuer) {
+		return protocols.NewInvalidParameterError(issuerName, issuer, issuerLabel+" must be a public key (starting with `G`) or a federation address.")
+	}
+	return nil
+}
+
+// validateUnknownParams returns an error for every param of the request body that is not known
+// by /payment, typos of optional params would be ignored otherwise
+func (request *PaymentRequest) validateUnknownParams() (errs protocols.ValidationErrors) {
+	if request.HTTPRequest == nil {
+		return nil
+	}
+
+	known := map[string]bool{}
+	for _, name := range PaymentParams {
+		known[name] = true
+	}
+	known["signers"] = true
+	known["signers[]"] = true
+	typ := reflect.TypeOf(*request)
+	for i := 0; i < typ.NumField(); i++ {
+		if name := typ.Field(i).Tag.Get("name"); name != "" {
+			known[name] = true
+		}
+	}
+
+	unknown := []string{}
+	for name := range request.HTTPRequest.PostForm {
+		if !known[name] && !indexedPaymentParam.MatchString(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs.Add(protocols.NewInvalidParameterError(name, request.HTTPRequest.PostForm.Get(name), "Unknown parameter."))
+	}
+	return errs
+}
+
+// isValidDestination returns true if destination is an account ID or a federated address, the
+// account ID returned by federation is checked when the address is resolved
+func isValidDestination(destination string) bool {
+	if _, _, err := address.Split(destination); err == nil {
+		return true
+	}
+	return protocols.IsValidAccountID(destination)
+}
+
 func validateStellarAddress(address string) bool {
 	tokens := strings.Split(address, "*")
 	return len(tokens) == 2
 }
 
+// NewPaymentAnomalyError creates a PaymentAnomalyBlocked or PaymentAnomalyApprovalRequired error
+// with the anomaly report
+func NewPaymentAnomalyError(errorResponse *protocols.ErrorResponse, report *anomaly.Report) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  errorResponse.Status,
+		Code:    errorResponse.Code,
+		Message: errorResponse.Message,
+		Data:    map[string]interface{}{"anomalies": report},
+	}
+}
+
+// NewPaymentInvalidFeeError creates a new PaymentInvalidFee error with the minimum fee of the
+// transaction
+func NewPaymentInvalidFeeError(fee string, minFee uint32) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentInvalidFee.Status,
+		Code:    PaymentInvalidFee.Code,
+		Message: PaymentInvalidFee.Message,
+		Data:    map[string]interface{}{"name": "fee", "min_fee": minFee},
+		LogData: map[string]interface{}{"fee": fee, "min_fee": minFee},
+	}
+}
+
+// NewPaymentInvalidAmountError creates a new PaymentInvalidAmount error of the amount param name
+func NewPaymentInvalidAmountError(name, value string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentInvalidAmount.Status,
+		Code:    PaymentInvalidAmount.Code,
+		Message: PaymentInvalidAmount.Message,
+		Data:    map[string]interface{}{"name": name},
+		LogData: map[string]interface{}{"name": name, "value": value},
+	}
+}
+
+// NewCreateAccountInvalidAmountError creates a new CreateAccountInvalidAmount error of the param
+// name of the starting balance
+func NewCreateAccountInvalidAmountError(name, value string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  CreateAccountInvalidAmount.Status,
+		Code:    CreateAccountInvalidAmount.Code,
+		Message: CreateAccountInvalidAmount.Message,
+		Data:    map[string]interface{}{"name": name},
+		LogData: map[string]interface{}{"name": name, "value": value},
+	}
+}
+
+// NewPaymentMalformedAssetCodeError creates a new PaymentMalformedAssetCode error of the asset code
+// param name
+func NewPaymentMalformedAssetCodeError(name, value string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentMalformedAssetCode.Status,
+		Code:    PaymentMalformedAssetCode.Code,
+		Message: PaymentMalformedAssetCode.Message,
+		Data:    map[string]interface{}{"name": name},
+		LogData: map[string]interface{}{"name": name, "value": value},
+	}
+}
+
+// NewPaymentInvalidMemoError creates a new PaymentInvalidMemo error of the memo param
+func NewPaymentInvalidMemoError(value, moreInfo string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:   PaymentInvalidMemo.Status,
+		Code:     PaymentInvalidMemo.Code,
+		Message:  PaymentInvalidMemo.Message,
+		MoreInfo: moreInfo,
+		Data:     map[string]interface{}{"name": "memo"},
+		LogData:  map[string]interface{}{"name": "memo", "value": value},
+	}
+}
+
+// NewPaymentInvalidFederationMemoError creates a new PaymentInvalidFederationMemo error of the memo
+// returned by the federation server of destination
+func NewPaymentInvalidFederationMemoError(destination, memoType, memo, moreInfo string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:   PaymentInvalidFederationMemo.Status,
+		Code:     PaymentInvalidFederationMemo.Code,
+		Message:  PaymentInvalidFederationMemo.Message,
+		MoreInfo: moreInfo,
+		Data:     map[string]interface{}{"destination": destination, "memo_type": memoType},
+		LogData:  map[string]interface{}{"destination": destination, "memo_type": memoType, "memo": memo},
+	}
+}
+
+// NewPaymentInvalidIssuerError creates a new PaymentInvalidIssuer error of the issuer param name
+// sent as a federation address
+func NewPaymentInvalidIssuerError(name, value string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentInvalidIssuer.Status,
+		Code:    PaymentInvalidIssuer.Code,
+		Message: PaymentInvalidIssuer.Message,
+		Data:    map[string]interface{}{"name": name, "value": value},
+		LogData: map[string]interface{}{"name": name, "value": value},
+	}
+}
+
+// NewPaymentStartingBalanceBelowReserveError creates a new PaymentStartingBalanceBelowReserve
+// error with the minimum balance of an account
+func NewPaymentStartingBalanceBelowReserveError(startingBalance, minBalance string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentStartingBalanceBelowReserve.Status,
+		Code:    PaymentStartingBalanceBelowReserve.Code,
+		Message: PaymentStartingBalanceBelowReserve.Message,
+		Data:    map[string]interface{}{"starting_balance": startingBalance, "min_balance": minBalance},
+	}
+}
+
+// NewPaymentDestinationDoesNotExistError creates a new PaymentDestinationDoesNotExist error of a
+// destination account ID
+func NewPaymentDestinationDoesNotExistError(destination string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentDestinationDoesNotExist.Status,
+		Code:    PaymentDestinationDoesNotExist.Code,
+		Message: PaymentDestinationDoesNotExist.Message,
+		Data:    map[string]interface{}{"destination": destination},
+		LogData: map[string]interface{}{"destination": destination},
+	}
+}
+
+// NewPaymentInvalidSourceError creates a new PaymentInvalidSource error of an unknown source name
+func NewPaymentInvalidSourceError(name string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentInvalidSource.Status,
+		Code:    PaymentInvalidSource.Code,
+		Message: PaymentInvalidSource.Message,
+		Data:    map[string]interface{}{"source": name},
+		LogData: map[string]interface{}{"source": name},
+	}
+}
+
+// NewPaymentAmountBelowReserveError creates a new PaymentAmountBelowReserve error with the
+// minimum balance of an account
+func NewPaymentAmountBelowReserveError(amount, minBalance string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentAmountBelowReserve.Status,
+		Code:    PaymentAmountBelowReserve.Code,
+		Message: PaymentAmountBelowReserve.Message,
+		Data:    map[string]interface{}{"amount": amount, "min_balance": minBalance},
+	}
+}
+
+// NewPaymentSweepOffersError creates a new PaymentSweepOffers error with the number of offers of
+// the source account
+func NewPaymentSweepOffersError(offers int) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentSweepOffers.Status,
+		Code:    PaymentSweepOffers.Code,
+		Message: PaymentSweepOffers.Message,
+		Data:    map[string]interface{}{"offers": offers},
+	}
+}
+
+// NewPaymentNothingToSweepError creates a new PaymentNothingToSweep error with the XLM balance,
+// the minimum balance of the source account and the fee paid by it
+func NewPaymentNothingToSweepError(balance, minBalance, fee string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentNothingToSweep.Status,
+		Code:    PaymentNothingToSweep.Code,
+		Message: PaymentNothingToSweep.Message,
+		Data:    map[string]interface{}{"balance": balance, "min_balance": minBalance, "fee": fee},
+	}
+}
+
+// NewPaymentNoPathFoundError creates a new PaymentNoPathFound error with the source amount of the
+// cheapest path, which is above send_max
+func NewPaymentNoPathFoundError(sourceAmount string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentNoPathFound.Status,
+		Code:    PaymentNoPathFound.Code,
+		Message: PaymentNoPathFound.Message,
+		Data:    map[string]interface{}{"source_amount": sourceAmount},
+	}
+}
+
+// NewPaymentDepositRequirementError creates a new PaymentSourceNotRegistered or
+// PaymentMemoPolicyViolation error naming the violated rule of deposit requirements of domain
+// (ex. `memo_type`)
+func NewPaymentDepositRequirementError(errorResponse *protocols.ErrorResponse, domain, rule string) *protocols.ErrorResponse {
+	data := map[string]interface{}{"domain": domain, "rule": rule}
+	return &protocols.ErrorResponse{
+		Status:  errorResponse.Status,
+		Code:    errorResponse.Code,
+		Message: errorResponse.Message,
+		Data:    data,
+		LogData: data,
+	}
+}
+
+// NewPaymentChannelsExhaustedError creates a new PaymentChannelsExhausted error with Retry-After
+// header
+func NewPaymentChannelsExhaustedError(retryAfter time.Duration) *protocols.ErrorResponse {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	return &protocols.ErrorResponse{
+		Status:  PaymentChannelsExhausted.Status,
+		Code:    PaymentChannelsExhausted.Code,
+		Message: PaymentChannelsExhausted.Message,
+		Data:    map[string]interface{}{"retry_after": seconds},
+		Header:  http.Header{"Retry-After": []string{strconv.FormatInt(seconds, 10)}},
+	}
+}
+
+// NewPaymentInvalidTimeBoundsError creates a new PaymentInvalidTimeBounds error of a param, `now`
+// in data is the unix timestamp max_time has been checked against
+func NewPaymentInvalidTimeBoundsError(name, value string, now time.Time) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentInvalidTimeBounds.Status,
+		Code:    PaymentInvalidTimeBounds.Code,
+		Message: PaymentInvalidTimeBounds.Message,
+		Data:    map[string]interface{}{"name": name, "now": now.Unix()},
+		LogData: map[string]interface{}{name: value, "now": now.Unix()},
+	}
+}
+
+// NewPaymentNoTrustError creates a new PaymentNoTrust error of a destination found to have no
+// trustline of an asset before the payment is sent
+func NewPaymentNoTrustError(assetCode, assetIssuer string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentNoTrust.Status,
+		Code:    PaymentNoTrust.Code,
+		Message: PaymentNoTrust.Message,
+		Data:    map[string]interface{}{"asset_code": assetCode, "asset_issuer": assetIssuer},
+	}
+}
+
+// NewPaymentLineFullError creates a new PaymentLineFull error of a trustline of destination with
+// `available` amount left below its limit
+func NewPaymentLineFullError(assetCode, assetIssuer, available string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentLineFull.Status,
+		Code:    PaymentLineFull.Code,
+		Message: PaymentLineFull.Message,
+		Data:    map[string]interface{}{"asset_code": assetCode, "asset_issuer": assetIssuer, "available": available},
+	}
+}
+
 // NewPaymentPendingError creates a new PaymentPending error
 func NewPaymentPendingError(seconds int) *protocols.ErrorResponse {
 	return &protocols.ErrorResponse{
@@ -190,3 +958,16 @@ func NewPaymentPendingError(seconds int) *protocols.ErrorResponse {
 		Data:    map[string]interface{}{"pending": seconds},
 	}
 }
+
+// NewPaymentExcessiveSlippageError creates a new PaymentExcessiveSlippage error
+func NewPaymentExcessiveSlippageError(estimatedPrice, limitPrice string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentExcessiveSlippage.Status,
+		Code:    PaymentExcessiveSlippage.Code,
+		Message: PaymentExcessiveSlippage.Message,
+		Data: map[string]interface{}{
+			"estimated_price": estimatedPrice,
+			"limit_price":     limitPrice,
+		},
+	}
+}
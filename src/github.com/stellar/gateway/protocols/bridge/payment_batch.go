@@ -0,0 +1,240 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/txspec"
+)
+
+// PaymentTypeBatch is `type` of payments sending payments to several destinations in one
+// transaction, so with one sequence number, one fee and one Horizon round trip
+const PaymentTypeBatch = "batch"
+
+// BatchPaymentMaxPayments is the maximum number of payments of a batch, the protocol limit of
+// operations in a transaction
+const BatchPaymentMaxPayments = 100
+
+const (
+	batchDestinationField = "payments[%d][destination]"
+	batchAmountField      = "payments[%d][amount]"
+	batchStroopsField     = "payments[%d][amount_stroops]"
+	batchCodeField        = "payments[%d][asset_code]"
+	batchIssuerField      = "payments[%d][asset_issuer]"
+)
+
+var (
+	// PaymentBatchFailed is an error response
+	PaymentBatchFailed = &protocols.ErrorResponse{Code: "batch_payment_failed", Message: "Transaction of the batch failed. No payment has been sent.", Status: http.StatusBadRequest}
+	// PaymentBatchRolledBack is an error response
+	PaymentBatchRolledBack = &protocols.ErrorResponse{Code: "batch_payment_rolled_back", Message: "Other payment of the same transaction failed. Payment has not been applied.", Status: http.StatusBadRequest}
+	// PaymentBatchFederationMemo is an error response
+	PaymentBatchFederationMemo = &protocols.ErrorResponse{Code: "batch_payment_federation_memo", Message: "Federation returned memo fields of a destination but the memo of a batch applies to all payments.", Status: http.StatusBadRequest}
+)
+
+// NewPaymentBatchFailedError creates and returns a new PaymentBatchFailed error with results of
+// all payments
+func NewPaymentBatchFailedError(results []BatchPaymentResult) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentBatchFailed.Status,
+		Code:    PaymentBatchFailed.Code,
+		Message: PaymentBatchFailed.Message,
+		Data:    map[string]interface{}{"results": results},
+	}
+}
+
+// NewBatchPaymentError returns a copy of errorResponse of the payment at index of a batch with
+// the index in its data
+func NewBatchPaymentError(errorResponse *protocols.ErrorResponse, index int) *protocols.ErrorResponse {
+	result := *errorResponse
+	result.Data = map[string]interface{}{"index": index}
+	for key, value := range errorResponse.Data {
+		result.Data[key] = value
+	}
+	result.LogData = map[string]interface{}{"index": index}
+	for key, value := range errorResponse.LogData {
+		result.LogData[key] = value
+	}
+	return &result
+}
+
+// BatchPayment is a single payment of a batch. Code and Issuer are empty for native asset.
+type BatchPayment struct {
+	// Destination is an account ID or a Stellar address
+	Destination string `json:"destination"`
+	Amount      string `json:"amount"`
+	// AmountStroops replaces Amount when the batch is validated
+	AmountStroops string `json:"amount_stroops,omitempty"`
+	Code          string `json:"asset_code,omitempty"`
+	Issuer        string `json:"asset_issuer,omitempty"`
+}
+
+// BatchPaymentResult contains the result of a single payment of a batch
+type BatchPaymentResult struct {
+	BatchPayment
+	// AccountID is the resolved destination
+	AccountID string `json:"account_id"`
+	// Operation is `payment`, or `create_account` for XLM sent to an account that does not exist
+	Operation string `json:"operation"`
+	// ResultCode is the code of the operation result of a submitted transaction, ex. `op_success`
+	// or `op_no_trust`
+	ResultCode string                   `json:"result_code,omitempty"`
+	Error      *protocols.ErrorResponse `json:"error,omitempty"`
+}
+
+// BatchPaymentResponse represents response returned by /payment endpoint for batch payments
+type BatchPaymentResponse struct {
+	protocols.SuccessResponse
+	Hash        string               `json:"hash"`
+	Ledger      *uint64              `json:"ledger"`
+	Results     []BatchPaymentResult `json:"results"`
+	TimeBounds  *txspec.TimeBounds   `json:"time_bounds,omitempty"`
+	ResultCodes *horizon.ResultCodes `json:"result_codes,omitempty"`
+}
+
+// Marshal marshals BatchPaymentResponse
+func (response *BatchPaymentResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
+
+// batchPaymentsFromForm reads `payments[n]` params, one more than allowed is read so too many
+// payments can be reported
+func batchPaymentsFromForm(form url.Values) (payments []BatchPayment) {
+	for i := 0; i <= BatchPaymentMaxPayments; i++ {
+		fields := []string{
+			fmt.Sprintf(batchDestinationField, i),
+			fmt.Sprintf(batchAmountField, i),
+			fmt.Sprintf(batchCodeField, i),
+			fmt.Sprintf(batchIssuerField, i),
+			fmt.Sprintf(batchStroopsField, i),
+		}
+
+		exists := false
+		for _, field := range fields {
+			_, ok := form[field]
+			exists = exists || ok
+		}
+		if !exists {
+			break
+		}
+
+		payments = append(payments, BatchPayment{
+			Destination:   form.Get(fields[0]),
+			Amount:        form.Get(fields[1]),
+			Code:          form.Get(fields[2]),
+			Issuer:        form.Get(fields[3]),
+			AmountStroops: form.Get(fields[4]),
+		})
+	}
+	return
+}
+
+// batchPaymentsToValues sets `payments[n]` params of payments
+func batchPaymentsToValues(values url.Values, payments []BatchPayment) {
+	for i, payment := range payments {
+		values.Set(fmt.Sprintf(batchDestinationField, i), payment.Destination)
+		values.Set(fmt.Sprintf(batchAmountField, i), payment.Amount)
+		if payment.AmountStroops != "" {
+			values.Set(fmt.Sprintf(batchStroopsField, i), payment.AmountStroops)
+		}
+		if payment.Code != "" {
+			values.Set(fmt.Sprintf(batchCodeField, i), payment.Code)
+			values.Set(fmt.Sprintf(batchIssuerField, i), payment.Issuer)
+		}
+	}
+}
+
+// validateBatch adds failed checks of batch payment params to errs. Source and memo are
+// validated like in other payments, errors of payments contain their index.
+func (request *PaymentRequest) validateBatch(errs *protocols.ValidationErrors) {
+	singlePaymentParams := []struct{ name, value string }{
+		{"destination", request.Destination},
+		{"amount", request.Amount},
+		{"amount_stroops", request.AmountStroops},
+		{"starting_balance", request.StartingBalance},
+		{"asset_code", request.AssetCode},
+		{"asset_issuer", request.AssetIssuer},
+		{"send_max", request.SendMax},
+		{"send_max_stroops", request.SendMaxStroops},
+		{"send_asset_code", request.SendAssetCode},
+		{"send_asset_issuer", request.SendAssetIssuer},
+		{"extra_memo", request.ExtraMemo},
+		{"uri", request.URI},
+	}
+	for _, param := range singlePaymentParams {
+		if param.value != "" {
+			errs.Add(protocols.NewInvalidParameterError(param.name, param.value, "Cannot be used with type=batch, use payments[n] params."))
+		}
+	}
+	if len(request.Path) > 0 {
+		errs.Add(protocols.NewInvalidParameterError("path[0][asset_code]", request.Path[0].Code, "Cannot be used with type=batch."))
+	}
+	if len(request.Assets) > 0 {
+		errs.Add(protocols.NewInvalidParameterError(fmt.Sprintf(assetAmountField, 0), request.Assets[0].Amount, "Cannot be used with type=batch."))
+	}
+	if request.UseCompliance {
+		errs.Add(protocols.NewInvalidParameterError("use_compliance", "true", "Compliance protocol cannot be used with type=batch."))
+	}
+	if request.AutoTrust {
+		errs.Add(protocols.NewInvalidParameterError("auto_trust", "true", "Cannot be used with type=batch."))
+	}
+
+	if len(request.Payments) == 0 {
+		errs.Add(protocols.NewMissingParameter(fmt.Sprintf(batchDestinationField, 0)))
+		return
+	}
+	if len(request.Payments) > BatchPaymentMaxPayments {
+		errs.Add(NewBatchPaymentError(protocols.NewInvalidParameterError(
+			fmt.Sprintf(batchDestinationField, BatchPaymentMaxPayments), "",
+			fmt.Sprintf("At most %d payments can be sent in a single batch.", BatchPaymentMaxPayments),
+		), BatchPaymentMaxPayments))
+		return
+	}
+
+	for i := range request.Payments {
+		payment := &request.Payments[i]
+		if err := protocols.ConvertStroopsParam(fmt.Sprintf(batchAmountField, i), &payment.Amount, fmt.Sprintf(batchStroopsField, i), &payment.AmountStroops); err != nil {
+			errs.Add(NewBatchPaymentError(err, i))
+			continue
+		}
+		if err := validateBatchPayment(i, *payment); err != nil {
+			errs.Add(NewBatchPaymentError(err, i))
+		}
+	}
+}
+
+// validateBatchPayment validates payments[i] params, only the first failure is returned
+func validateBatchPayment(i int, payment BatchPayment) *protocols.ErrorResponse {
+	if payment.Destination == "" {
+		return protocols.NewMissingParameter(fmt.Sprintf(batchDestinationField, i))
+	}
+	if !isValidDestination(payment.Destination) {
+		return protocols.NewInvalidParameterError(fmt.Sprintf(batchDestinationField, i), payment.Destination, "Destination must be a public key (starting with `G`) or a Stellar address.")
+	}
+
+	if payment.Amount == "" {
+		return protocols.NewMissingParameter(fmt.Sprintf(batchAmountField, i))
+	}
+	if !protocols.IsValidPositiveAmount(payment.Amount) {
+		return protocols.NewInvalidParameterError(fmt.Sprintf(batchAmountField, i), payment.Amount, "Amount must be a positive number with at most 7 decimal places.")
+	}
+
+	if payment.Code == "" && payment.Issuer != "" {
+		return protocols.NewMissingParameter(fmt.Sprintf(batchCodeField, i))
+	}
+	if payment.Code != "" && payment.Issuer == "" {
+		return protocols.NewMissingParameter(fmt.Sprintf(batchIssuerField, i))
+	}
+	if payment.Code != "" && !protocols.IsValidAssetCode(payment.Code) {
+		return NewPaymentMalformedAssetCodeError(fmt.Sprintf(batchCodeField, i), payment.Code)
+	}
+	if payment.Issuer != "" && !protocols.IsValidAccountID(payment.Issuer) {
+		return protocols.NewInvalidParameterError(fmt.Sprintf(batchIssuerField, i), payment.Issuer, "Asset issuer must be a public key (starting with `G`).")
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/stellar/gateway/protocols"
+)
+
+// PaymentJSONRequest is a body of /payment requests sent with `Content-Type: application/json`.
+// Params are named like form params and have the same values, booleans are JSON booleans.
+type PaymentJSONRequest struct {
+	APIKey          string `json:"apiKey,omitempty"`
+	CorrelationID   string `json:"correlation_id,omitempty"`
+	Network         string `json:"network,omitempty"`
+	Source          string `json:"source,omitempty"`
+	Sender          string `json:"sender,omitempty"`
+	Destination     string `json:"destination,omitempty"`
+	MemoType        string `json:"memo_type,omitempty"`
+	Memo            string `json:"memo,omitempty"`
+	Amount          string `json:"amount,omitempty"`
+	AmountStroops   string `json:"amount_stroops,omitempty"`
+	StartingBalance string `json:"starting_balance,omitempty"`
+	AssetCode       string `json:"asset_code,omitempty"`
+	AssetIssuer     string `json:"asset_issuer,omitempty"`
+	SendMax         string `json:"send_max,omitempty"`
+	SendMaxStroops  string `json:"send_max_stroops,omitempty"`
+	SendAssetCode   string `json:"send_asset_code,omitempty"`
+	SendAssetIssuer string `json:"send_asset_issuer,omitempty"`
+	// Path of a path payment, `{}` is XLM
+	Path                  []protocols.Asset `json:"path,omitempty"`
+	UseCompliance         bool              `json:"use_compliance,omitempty"`
+	ExtraMemo             string            `json:"extra_memo,omitempty"`
+	SkipSlippageCheck     bool              `json:"skip_slippage_check,omitempty"`
+	AutoTrust             bool              `json:"auto_trust,omitempty"`
+	SkipTrustCheck        bool              `json:"skip_trust_check,omitempty"`
+	ForbidAccountCreation bool              `json:"forbid_account_creation,omitempty"`
+	URI                   string            `json:"uri,omitempty"`
+	Type                  string            `json:"type,omitempty"`
+	// Assets of a multi_asset payment
+	Assets []PaymentAsset `json:"assets,omitempty"`
+	// Payments of a batch payment
+	Payments []BatchPayment `json:"payments,omitempty"`
+	// Seeds of additional signers
+	Signers        []string `json:"signers,omitempty"`
+	MaxWait        string   `json:"max_wait,omitempty"`
+	ID             string   `json:"id,omitempty"`
+	Queue          bool     `json:"queue,omitempty"`
+	Async          bool     `json:"async,omitempty"`
+	DryRun         bool     `json:"dry_run,omitempty"`
+	ApproveAnomaly bool     `json:"approve_anomaly,omitempty"`
+	Fee            string   `json:"fee,omitempty"`
+	Callback       string   `json:"callback,omitempty"`
+}
+
+// IsJSONRequest returns true when r has a JSON body
+func IsJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// ToValues returns form params of the request, a path element without code and issuer is XLM
+func (request PaymentJSONRequest) ToValues() url.Values {
+	form := PaymentRequest{
+		Source:                request.Source,
+		Sender:                request.Sender,
+		Destination:           request.Destination,
+		MemoType:              request.MemoType,
+		Memo:                  request.Memo,
+		Amount:                request.Amount,
+		AmountStroops:         request.AmountStroops,
+		StartingBalance:       request.StartingBalance,
+		AssetCode:             request.AssetCode,
+		AssetIssuer:           request.AssetIssuer,
+		SendMax:               request.SendMax,
+		SendMaxStroops:        request.SendMaxStroops,
+		SendAssetCode:         request.SendAssetCode,
+		SendAssetIssuer:       request.SendAssetIssuer,
+		Path:                  request.Path,
+		UseCompliance:         request.UseCompliance,
+		ExtraMemo:             request.ExtraMemo,
+		SkipSlippageCheck:     request.SkipSlippageCheck,
+		AutoTrust:             request.AutoTrust,
+		SkipTrustCheck:        request.SkipTrustCheck,
+		ForbidAccountCreation: request.ForbidAccountCreation,
+		URI:                   request.URI,
+		Type:                  request.Type,
+		Assets:                request.Assets,
+		Payments:              request.Payments,
+		MaxWait:               request.MaxWait,
+		Queue:                 request.Queue,
+		Async:                 request.Async,
+		DryRun:                request.DryRun,
+		Fee:                   request.Fee,
+		Callback:              request.Callback,
+	}
+	values := form.ToValues()
+	// Params that are not sent are missing like in form requests
+	for name, value := range map[string]bool{
+		"use_compliance":          request.UseCompliance,
+		"skip_slippage_check":     request.SkipSlippageCheck,
+		"auto_trust":              request.AutoTrust,
+		"skip_trust_check":        request.SkipTrustCheck,
+		"forbid_account_creation": request.ForbidAccountCreation,
+		"approve_anomaly":         request.ApproveAnomaly,
+		"queue":                   request.Queue,
+		"async":                   request.Async,
+		"dry_run":                 request.DryRun,
+	} {
+		if !value {
+			values.Del(name)
+		}
+	}
+	if len(request.Signers) > 0 {
+		values["signers[]"] = request.Signers
+	}
+	if request.APIKey != "" {
+		values.Set("apiKey", request.APIKey)
+	}
+	if request.CorrelationID != "" {
+		values.Set("correlation_id", request.CorrelationID)
+	}
+	if request.Network != "" {
+		values.Set(NetworkParam, request.Network)
+	}
+	return values
+}
+
+// ParsePaymentJSON replaces form params of r with params of its JSON body, so a JSON request is
+// validated and processed like a form request with the same params. Fields that are not params
+// are kept as params and rejected by PaymentRequest.Validate like unknown form params.
+func ParsePaymentJSON(r *http.Request) error {
+	var body bytes.Buffer
+	_, err := body.ReadFrom(r.Body)
+	if err != nil {
+		return protocols.NewInvalidParameterError("", "", "Cannot read request body.")
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(body.Bytes(), &fields)
+	if err != nil || fields == nil {
+		return protocols.NewInvalidParameterError("", "", "Request body is not a valid JSON object.")
+	}
+
+	var request PaymentJSONRequest
+	err = json.Unmarshal(body.Bytes(), &request)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return protocols.NewInvalidParameterError(typeErr.Field, "", fmt.Sprintf("Value must be a JSON %s.", jsonTypeName(typeErr.Type)))
+	}
+	if err != nil {
+		return protocols.NewInvalidParameterError("", "", "Request body is not a valid JSON object.")
+	}
+
+	values := request.ToValues()
+	known := jsonFieldNames(reflect.TypeOf(request))
+	for name, value := range fields {
+		if known[name] {
+			continue
+		}
+		var text string
+		if json.Unmarshal(value, &text) != nil {
+			text = string(value)
+		}
+		values.Set(name, text)
+	}
+
+	r.PostForm = values
+	// Query params are kept like in form requests
+	r.Form = url.Values{}
+	for name, value := range r.URL.Query() {
+		r.Form[name] = value
+	}
+	for name, value := range values {
+		r.Form[name] = value
+	}
+	return nil
+}
+
+// jsonFieldNames returns JSON names of fields of a struct type
+func jsonFieldNames(typ reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		names[name] = true
+	}
+	return names
+}
+
+// jsonTypeName returns a name of a JSON type values of typ are decoded from
+func jsonTypeName(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice:
+		return "array"
+	case reflect.Struct:
+		return "object"
+	default:
+		return "string"
+	}
+}
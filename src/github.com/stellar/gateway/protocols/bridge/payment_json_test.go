@@ -0,0 +1,150 @@
+package bridge
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stellar/gateway/protocols"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePaymentJSON(t *testing.T) {
+	body := `{
+  "apiKey": "api-key-api-key-1",
+  "source": "SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42",
+  "destination": "alice*stellar.org",
+  "amount": "20",
+  "asset_code": "USD",
+  "asset_issuer": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET",
+  "send_max": "25",
+  "send_asset_code": "EUR",
+  "send_asset_issuer": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET",
+  "path": [{}, {"code": "BTC", "issuer": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"}],
+  "auto_trust": true,
+  "use_compliance": false,
+  "fee": "300",
+  "memo_typ": "text"
+}`
+	r, err := http.NewRequest("POST", "/payment?correlation_id=order-42", strings.NewReader(body))
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", "application/json")
+	require.True(t, IsJSONRequest(r))
+	require.NoError(t, ParsePaymentJSON(r))
+
+	assert.Equal(t, url.Values{
+		"apiKey":                {"api-key-api-key-1"},
+		"source":                {"SDRAS7XIQNX25UDCCX725R4EYGBFYGJE4HJ2A3DFCWJIHMRSMS7CXX42"},
+		"destination":           {"alice*stellar.org"},
+		"amount":                {"20"},
+		"asset_code":            {"USD"},
+		"asset_issuer":          {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+		"send_max":              {"25"},
+		"send_asset_code":       {"EUR"},
+		"send_asset_issuer":     {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+		"path[0][asset_code]":   {""},
+		"path[0][asset_issuer]": {""},
+		"path[1][asset_code]":   {"BTC"},
+		"path[1][asset_issuer]": {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+		"auto_trust":            {"true"},
+		"fee":                   {"300"},
+		"memo_typ":              {"text"},
+	}, r.PostForm)
+	assert.Equal(t, "order-42", r.FormValue("correlation_id"))
+
+	// The request is read like a form request
+	request := &PaymentRequest{}
+	require.NoError(t, request.FromRequest(r))
+	assert.Equal(t, []protocols.Asset{{}, {Code: "BTC", Issuer: "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"}}, request.Path)
+	assert.True(t, request.AutoTrust)
+	assert.False(t, request.UseCompliance)
+	err = request.Validate()
+	require.Error(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "memo_typ"}, err.(*protocols.ErrorResponse).Data)
+}
+
+func TestParsePaymentJSONMultiAsset(t *testing.T) {
+	body := `{"type": "multi_asset", "destination": "alice*stellar.org", "assets": [{"amount": "1"}, {"asset_code": "USD", "asset_issuer": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET", "amount": "2"}]}`
+	r, err := http.NewRequest("POST", "/payment", strings.NewReader(body))
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", "application/json")
+	require.NoError(t, ParsePaymentJSON(r))
+
+	request := &PaymentRequest{}
+	require.NoError(t, request.FromRequest(r))
+	assert.Equal(t, []PaymentAsset{
+		{Amount: "1"},
+		{Code: "USD", Issuer: "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET", Amount: "2"},
+	}, request.Assets)
+}
+
+func TestParsePaymentJSONBatchStroops(t *testing.T) {
+	body := `{"type": "batch", "payments": [{"destination": "alice*stellar.org", "amount_stroops": "1"}, {"destination": "bob*stellar.org", "amount_stroops": "9223372036854775807"}]}`
+	r, err := http.NewRequest("POST", "/payment", strings.NewReader(body))
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", "application/json")
+	require.NoError(t, ParsePaymentJSON(r))
+
+	request := &PaymentRequest{}
+	require.NoError(t, request.FromRequest(r))
+	require.NoError(t, request.Validate())
+	assert.Equal(t, []BatchPayment{
+		{Destination: "alice*stellar.org", Amount: "0.0000001"},
+		{Destination: "bob*stellar.org", Amount: "922337203685.4775807"},
+	}, request.Payments)
+
+	request.Payments[1].Amount = "1"
+	request.Payments[1].AmountStroops = "1"
+	err = request.Validate()
+	require.Error(t, err)
+	assert.Equal(t, "payments[1][amount_stroops]", err.(*protocols.ErrorResponse).Data["name"])
+}
+
+func TestParsePaymentJSONSigners(t *testing.T) {
+	body := `{"source": "GATKP6ZQM5CSLECPMTAC5226PE367QALCPM6AFHTSULPPZMT62OOPMQB", "destination": "alice*stellar.org", "amount": "1", "signers": ["SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J", "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H"]}`
+	r, err := http.NewRequest("POST", "/payment", strings.NewReader(body))
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", "application/json")
+	require.NoError(t, ParsePaymentJSON(r))
+
+	request := &PaymentRequest{}
+	require.NoError(t, request.FromRequest(r))
+	require.NoError(t, request.Validate())
+	assert.Equal(t, []string{"SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J", "SDOTALIMPAM2IV65IOZA7KZL7XWZI5BODFXTRVLIHLQZQCKK57PH5F3H"}, request.Signers)
+	// Secrets are not stored with the payment
+	assert.Empty(t, request.ToValues()["signers[]"])
+	assert.True(t, NewPaymentPayload(request, "").SignersOmitted)
+
+	request.Queue = true
+	err = request.Validate()
+	require.Error(t, err)
+	assert.Equal(t, "signers", err.(*protocols.ErrorResponse).Data["name"])
+}
+
+func TestParsePaymentJSONInvalid(t *testing.T) {
+	for body, name := range map[string]string{
+		`{"amount": 20}`:            "amount",
+		`{"path": {"code": "USD"}}`: "path",
+		`{"auto_trust": "true"}`:    "auto_trust",
+		`{"fee": 300}`:              "fee",
+		`not json`:                  "",
+		`null`:                      "",
+		`["amount"]`:                "",
+	} {
+		r, err := http.NewRequest("POST", "/payment", strings.NewReader(body))
+		require.NoError(t, err)
+		err = ParsePaymentJSON(r)
+		if assert.Error(t, err, body) {
+			errorResponse := err.(*protocols.ErrorResponse)
+			assert.Equal(t, protocols.InvalidParameterError.Code, errorResponse.Code, body)
+			assert.Equal(t, name, errorResponse.LogData["name"], body)
+		}
+	}
+
+	r, err := http.NewRequest("POST", "/payment", strings.NewReader("amount=20"))
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	assert.False(t, IsJSONRequest(r))
+}
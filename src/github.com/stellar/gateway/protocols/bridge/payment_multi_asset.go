@@ -0,0 +1,220 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/txspec"
+)
+
+// PaymentTypeMultiAsset is `type` of payments sending several assets to a single destination in
+// one transaction, so either all assets are sent or none
+const PaymentTypeMultiAsset = "multi_asset"
+
+// MultiAssetPaymentMaxAssets is the maximum number of assets of a multi-asset payment, every
+// asset is sent by a separate operation
+const MultiAssetPaymentMaxAssets = 100
+
+const (
+	assetCodeField    = "assets[%d][asset_code]"
+	assetIssuerField  = "assets[%d][asset_issuer]"
+	assetAmountField  = "assets[%d][amount]"
+	assetStroopsField = "assets[%d][amount_stroops]"
+)
+
+var (
+	// PaymentMultiAssetFailed is an error response
+	PaymentMultiAssetFailed = &protocols.ErrorResponse{Code: "multi_asset_payment_failed", Message: "At least one asset cannot be sent. No asset has been sent.", Status: http.StatusBadRequest}
+	// PaymentMultiAssetRolledBack is an error response
+	PaymentMultiAssetRolledBack = &protocols.ErrorResponse{Code: "multi_asset_payment_rolled_back", Message: "Other asset of the same transaction failed. Payment has not been applied.", Status: http.StatusBadRequest}
+)
+
+// NewPaymentMultiAssetFailedError creates and returns a new PaymentMultiAssetFailed error with
+// results of all assets
+func NewPaymentMultiAssetFailedError(results []PaymentAssetResult) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:  PaymentMultiAssetFailed.Status,
+		Code:    PaymentMultiAssetFailed.Code,
+		Message: PaymentMultiAssetFailed.Message,
+		Data:    map[string]interface{}{"results": results},
+	}
+}
+
+// PaymentAsset is a single asset of a multi-asset payment. Code and Issuer are empty for native
+// asset.
+type PaymentAsset struct {
+	Code   string `json:"asset_code,omitempty"`
+	Issuer string `json:"asset_issuer,omitempty"`
+	Amount string `json:"amount"`
+	// AmountStroops replaces Amount when the payment is validated
+	AmountStroops string `json:"amount_stroops,omitempty"`
+}
+
+// PaymentAssetStatus is the status of a single asset of a multi-asset payment
+type PaymentAssetStatus string
+
+const (
+	// PaymentAssetStatusSuccess means the asset has been sent
+	PaymentAssetStatusSuccess PaymentAssetStatus = "success"
+	// PaymentAssetStatusFailed means the asset cannot be sent or its transaction failed
+	PaymentAssetStatusFailed PaymentAssetStatus = "failed"
+	// PaymentAssetStatusNotSubmitted means the asset can be sent but other asset cannot
+	PaymentAssetStatusNotSubmitted PaymentAssetStatus = "not_submitted"
+)
+
+// PaymentAssetResult contains the result of a single asset of a multi-asset payment
+type PaymentAssetResult struct {
+	PaymentAsset
+	Status PaymentAssetStatus       `json:"status"`
+	Error  *protocols.ErrorResponse `json:"error,omitempty"`
+}
+
+// MultiAssetPaymentResponse represents response returned by /payment endpoint for multi-asset
+// payments
+type MultiAssetPaymentResponse struct {
+	protocols.SuccessResponse
+	Hash        string               `json:"hash"`
+	Ledger      *uint64              `json:"ledger"`
+	Results     []PaymentAssetResult `json:"results"`
+	TimeBounds  *txspec.TimeBounds   `json:"time_bounds,omitempty"`
+	ResultCodes *horizon.ResultCodes `json:"result_codes,omitempty"`
+}
+
+// Marshal marshals MultiAssetPaymentResponse
+func (response *MultiAssetPaymentResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
+
+// paymentAssetsFromForm reads `assets[n]` params, one more than allowed is read so too many
+// assets can be reported
+func paymentAssetsFromForm(form url.Values) (assets []PaymentAsset) {
+	for i := 0; i <= MultiAssetPaymentMaxAssets; i++ {
+		codeField := fmt.Sprintf(assetCodeField, i)
+		issuerField := fmt.Sprintf(assetIssuerField, i)
+		amountField := fmt.Sprintf(assetAmountField, i)
+		stroopsField := fmt.Sprintf(assetStroopsField, i)
+
+		_, codeExists := form[codeField]
+		_, issuerExists := form[issuerField]
+		_, amountExists := form[amountField]
+		_, stroopsExists := form[stroopsField]
+		if !codeExists && !issuerExists && !amountExists && !stroopsExists {
+			break
+		}
+
+		assets = append(assets, PaymentAsset{
+			Code:          form.Get(codeField),
+			Issuer:        form.Get(issuerField),
+			Amount:        form.Get(amountField),
+			AmountStroops: form.Get(stroopsField),
+		})
+	}
+	return
+}
+
+// paymentAssetsToValues sets `assets[n]` params of assets
+func paymentAssetsToValues(values url.Values, assets []PaymentAsset) {
+	for i, asset := range assets {
+		if asset.Code != "" {
+			values.Set(fmt.Sprintf(assetCodeField, i), asset.Code)
+			values.Set(fmt.Sprintf(assetIssuerField, i), asset.Issuer)
+		}
+		values.Set(fmt.Sprintf(assetAmountField, i), asset.Amount)
+		if asset.AmountStroops != "" {
+			values.Set(fmt.Sprintf(assetStroopsField, i), asset.AmountStroops)
+		}
+	}
+}
+
+// validateMultiAsset adds failed checks of multi-asset payment params to errs. Source and memo
+// are validated like in other payments.
+func (request *PaymentRequest) validateMultiAsset(errs *protocols.ValidationErrors) {
+	singleAssetParams := []struct{ name, value string }{
+		{"amount", request.Amount},
+		{"amount_stroops", request.AmountStroops},
+		{"starting_balance", request.StartingBalance},
+		{"asset_code", request.AssetCode},
+		{"asset_issuer", request.AssetIssuer},
+		{"send_max", request.SendMax},
+		{"send_max_stroops", request.SendMaxStroops},
+		{"send_asset_code", request.SendAssetCode},
+		{"send_asset_issuer", request.SendAssetIssuer},
+		{"extra_memo", request.ExtraMemo},
+		{"uri", request.URI},
+	}
+	for _, param := range singleAssetParams {
+		if param.value != "" {
+			errs.Add(protocols.NewInvalidParameterError(param.name, param.value, "Cannot be used with type=multi_asset, use assets[n] params."))
+		}
+	}
+	if len(request.Path) > 0 {
+		errs.Add(protocols.NewInvalidParameterError("path[0][asset_code]", request.Path[0].Code, "Cannot be used with type=multi_asset."))
+	}
+	if request.UseCompliance {
+		errs.Add(protocols.NewInvalidParameterError("use_compliance", "true", "Compliance protocol cannot be used with type=multi_asset."))
+	}
+	if request.AutoTrust {
+		errs.Add(protocols.NewInvalidParameterError("auto_trust", "true", "Cannot be used with type=multi_asset."))
+	}
+
+	if len(request.Assets) == 0 {
+		errs.Add(protocols.NewMissingParameter(fmt.Sprintf(assetAmountField, 0)))
+		return
+	}
+	if len(request.Assets) > MultiAssetPaymentMaxAssets {
+		errs.Add(protocols.NewInvalidParameterError(
+			fmt.Sprintf(assetAmountField, MultiAssetPaymentMaxAssets), "",
+			fmt.Sprintf("At most %d assets can be sent in a single payment.", MultiAssetPaymentMaxAssets),
+		))
+		return
+	}
+
+	sent := map[protocols.Asset]bool{}
+	for i := range request.Assets {
+		asset := &request.Assets[i]
+		if err := protocols.ConvertStroopsParam(fmt.Sprintf(assetAmountField, i), &asset.Amount, fmt.Sprintf(assetStroopsField, i), &asset.AmountStroops); err != nil {
+			errs.Add(err)
+			continue
+		}
+		if err := validateAsset(i, *asset); err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		key := protocols.Asset{Code: asset.Code, Issuer: asset.Issuer}
+		if sent[key] {
+			errs.Add(protocols.NewInvalidParameterError(fmt.Sprintf(assetCodeField, i), asset.Code, "Every asset can be sent once in a single payment."))
+		}
+		sent[key] = true
+	}
+}
+
+// validateAsset validates assets[i] params, checks of an asset depend on each other so only the
+// first failure is returned
+func validateAsset(i int, asset PaymentAsset) *protocols.ErrorResponse {
+	if asset.Amount == "" {
+		return protocols.NewMissingParameter(fmt.Sprintf(assetAmountField, i))
+	}
+	if !protocols.IsValidPositiveAmount(asset.Amount) {
+		return protocols.NewInvalidParameterError(fmt.Sprintf(assetAmountField, i), asset.Amount, "Amount must be a positive number with at most 7 decimal places.")
+	}
+
+	if asset.Code == "" && asset.Issuer != "" {
+		return protocols.NewMissingParameter(fmt.Sprintf(assetCodeField, i))
+	}
+	if asset.Code != "" && asset.Issuer == "" {
+		return protocols.NewMissingParameter(fmt.Sprintf(assetIssuerField, i))
+	}
+	if asset.Code != "" && !protocols.IsValidAssetCode(asset.Code) {
+		return NewPaymentMalformedAssetCodeError(fmt.Sprintf(assetCodeField, i), asset.Code)
+	}
+	if asset.Issuer != "" && !protocols.IsValidAccountID(asset.Issuer) {
+		return protocols.NewInvalidParameterError(fmt.Sprintf(assetIssuerField, i), asset.Issuer, "Asset issuer must be a public key (starting with `G`).")
+	}
+	return nil
+}
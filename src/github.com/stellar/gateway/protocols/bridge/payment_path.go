@@ -0,0 +1,133 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/stellar/gateway/protocols"
+)
+
+// indexedPathParam matches path[n][asset_code] and path[n][asset_issuer] params
+var indexedPathParam = regexp.MustCompile(`^path\[(\d+)\]\[asset_(code|issuer)\]$`)
+
+const pathCodeParam = "path[%d][asset_code]"
+
+// pathAsset is an element of `path` JSON param, an empty object is XLM
+type pathAsset struct {
+	AssetCode   string `json:"asset_code"`
+	AssetIssuer string `json:"asset_issuer"`
+}
+
+// pathFromJSON parses `path` param sent as a JSON array instead of path[n] params
+func pathFromJSON(value string) ([]protocols.Asset, error) {
+	var elements []pathAsset
+	err := json.Unmarshal([]byte(value), &elements)
+	if err != nil || elements == nil {
+		return nil, fmt.Errorf("path must be a JSON array of objects with asset_code and asset_issuer")
+	}
+
+	path := make([]protocols.Asset, len(elements))
+	for i, element := range elements {
+		path[i] = protocols.Asset{Code: element.AssetCode, Issuer: element.AssetIssuer}
+	}
+	return path, nil
+}
+
+// validatePath adds failed checks of the path to errs. path[n] params are read until the first
+// missing index, so an index sent after a missing one is a gap instead of being ignored.
+func (request *PaymentRequest) validatePath(errs *protocols.ValidationErrors) {
+	if request.HTTPRequest != nil {
+		form := request.HTTPRequest.PostForm
+		indexes := pathIndexes(form)
+		if value, ok := form["path"]; ok {
+			if len(indexes) > 0 {
+				errs.Add(NewPaymentInvalidPathError("path", value[0], "path cannot be sent with path[n] params."))
+				return
+			}
+			if _, err := pathFromJSON(value[0]); err != nil {
+				errs.Add(NewPaymentInvalidPathError("path", value[0], err.Error()+"."))
+				return
+			}
+		}
+		if len(indexes) > 0 && indexes[len(indexes)-1] >= protocols.MaxPathLength {
+			name := fmt.Sprintf(pathCodeParam, protocols.MaxPathLength)
+			errs.Add(NewPaymentInvalidPathError(name, form.Get(name), pathLengthMoreInfo))
+			return
+		}
+		for i, index := range indexes {
+			name := fmt.Sprintf(pathCodeParam, index)
+			if index != i {
+				errs.Add(NewPaymentInvalidPathError(name, form.Get(name), fmt.Sprintf("path[%d] is sent but path[%d] is missing.", index, i)))
+				return
+			}
+			if _, ok := form[name]; !ok {
+				errs.Add(NewPaymentInvalidPathError(name, "", fmt.Sprintf("%s is missing, it's empty for XLM.", name)))
+				return
+			}
+		}
+	}
+
+	if len(request.Path) > protocols.MaxPathLength {
+		errs.Add(NewPaymentInvalidPathError("path", "", pathLengthMoreInfo))
+		return
+	}
+	for i, asset := range request.Path {
+		if moreInfo := invalidPathAsset(i, asset); moreInfo != "" {
+			errs.Add(NewPaymentInvalidPathError(fmt.Sprintf(pathCodeParam, i), asset.Code, moreInfo))
+		}
+	}
+}
+
+var pathLengthMoreInfo = fmt.Sprintf("A path can have at most %d assets.", protocols.MaxPathLength)
+
+// invalidPathAsset returns why the ith asset of a path is invalid or an empty string when it's valid
+func invalidPathAsset(i int, asset protocols.Asset) string {
+	switch {
+	case asset.Code == "" && asset.Issuer == "":
+		return ""
+	case asset.Issuer == "":
+		return fmt.Sprintf("path[%d][asset_issuer] is missing, both code and issuer are empty for XLM.", i)
+	case asset.Code == "":
+		return fmt.Sprintf("path[%d][asset_code] is missing, both code and issuer are empty for XLM.", i)
+	case !protocols.IsValidAssetCode(asset.Code):
+		return fmt.Sprintf("path[%d][asset_code] is not a valid asset code.", i)
+	case !protocols.IsValidAccountID(asset.Issuer):
+		return fmt.Sprintf("path[%d][asset_issuer] must be a public key (starting with `G`).", i)
+	}
+	return ""
+}
+
+// pathIndexes returns sorted indexes of path[n] params
+func pathIndexes(form map[string][]string) []int {
+	found := map[int]bool{}
+	for name := range form {
+		if match := indexedPathParam.FindStringSubmatch(name); match != nil {
+			index, err := strconv.Atoi(match[1])
+			if err == nil {
+				found[index] = true
+			}
+		}
+	}
+
+	indexes := make([]int, 0, len(found))
+	for index := range found {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// NewPaymentInvalidPathError creates a new PaymentInvalidPath error of a path param
+func NewPaymentInvalidPathError(name, value, moreInfo string) *protocols.ErrorResponse {
+	return &protocols.ErrorResponse{
+		Status:   PaymentInvalidPath.Status,
+		Code:     PaymentInvalidPath.Code,
+		Message:  PaymentInvalidPath.Message,
+		MoreInfo: moreInfo,
+		Data:     map[string]interface{}{"name": name},
+		LogData:  map[string]interface{}{"name": name, "value": value},
+	}
+}
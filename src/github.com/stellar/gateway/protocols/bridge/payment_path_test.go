@@ -0,0 +1,116 @@
+package bridge
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/go/build"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentRequestPath(t *testing.T) {
+	parse := func(path url.Values) (*PaymentRequest, error) {
+		params := url.Values{
+			"destination":       {"GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"},
+			"amount":            {"20"},
+			"send_max":          {"25"},
+			"send_asset_code":   {"EUR"},
+			"send_asset_issuer": {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+		}
+		for name, value := range path {
+			params[name] = value
+		}
+		r, err := http.NewRequest("POST", "/payment", strings.NewReader(params.Encode()))
+		require.NoError(t, err)
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		request := &PaymentRequest{}
+		require.NoError(t, request.FromRequest(r))
+		return request, request.Validate()
+	}
+	invalidPath := func(err error) map[string]interface{} {
+		require.Error(t, err)
+		errorResponse := err.(*protocols.ErrorResponse)
+		assert.Equal(t, PaymentInvalidPath.Code, errorResponse.Code)
+		return errorResponse.Data
+	}
+
+	indexed, err := parse(url.Values{
+		"path[0][asset_code]":   {""},
+		"path[0][asset_issuer]": {""},
+		"path[1][asset_code]":   {"BTC"},
+		"path[1][asset_issuer]": {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+	})
+	require.NoError(t, err)
+	jsonPath, err := parse(url.Values{
+		"path": {`[{}, {"asset_code": "BTC", "asset_issuer": "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"}]`},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []protocols.Asset{{}, {Code: "BTC", Issuer: "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"}}, jsonPath.Path)
+	assert.Equal(t, indexed.Path, jsonPath.Path)
+	assert.Equal(t, build.NativeAsset(), jsonPath.Path[0].ToBaseAsset())
+	// Both are stored and hashed as path[n] params
+	assert.Equal(t, indexed.ToValues(), jsonPath.ToValues())
+	assert.Equal(t, indexed.Hash(), jsonPath.Hash())
+
+	t.Run("gap in indexed params", func(t *testing.T) {
+		_, err := parse(url.Values{
+			"path[0][asset_code]":   {""},
+			"path[0][asset_issuer]": {""},
+			"path[2][asset_code]":   {"BTC"},
+			"path[2][asset_issuer]": {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"},
+		})
+		assert.Equal(t, map[string]interface{}{"name": "path[2][asset_code]"}, invalidPath(err))
+		assert.Equal(t, "path[2] is sent but path[1] is missing.", err.(*protocols.ErrorResponse).MoreInfo)
+
+		_, err = parse(url.Values{"path[0][asset_issuer]": {"GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"}})
+		assert.Equal(t, map[string]interface{}{"name": "path[0][asset_code]"}, invalidPath(err))
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := parse(url.Values{"path": {`{"asset_code": "BTC"}`}})
+		assert.Equal(t, map[string]interface{}{"name": "path"}, invalidPath(err))
+
+		_, err = parse(url.Values{"path": {`[{}]`}, "path[0][asset_code]": {""}})
+		assert.Equal(t, map[string]interface{}{"name": "path"}, invalidPath(err))
+
+		_, err = parse(url.Values{"path": {`[{"asset_code": "BTC"}]`}})
+		assert.Equal(t, map[string]interface{}{"name": "path[0][asset_code]"}, invalidPath(err))
+	})
+
+	t.Run("invalid assets", func(t *testing.T) {
+		_, err := parse(url.Values{"path": {`[{"asset_code": "BTC"}]`}})
+		invalidPath(err)
+		assert.Equal(t, "path[0][asset_issuer] is missing, both code and issuer are empty for XLM.", err.(*protocols.ErrorResponse).MoreInfo)
+
+		_, err = parse(url.Values{"path": {`[{}, {"asset_code": "BTC", "asset_issuer": "GBABZMS7"}]`}})
+		assert.Equal(t, map[string]interface{}{"name": "path[1][asset_code]"}, invalidPath(err))
+		assert.Equal(t, "path[1][asset_issuer] must be a public key (starting with `G`).", err.(*protocols.ErrorResponse).MoreInfo)
+	})
+
+	t.Run("too many assets", func(t *testing.T) {
+		_, err := parse(url.Values{"path": {`[{}, {}, {}, {}, {}, {}]`}})
+		assert.Equal(t, map[string]interface{}{"name": "path"}, invalidPath(err))
+		assert.Equal(t, "A path can have at most 5 assets.", err.(*protocols.ErrorResponse).MoreInfo)
+
+		params := url.Values{}
+		for i := 0; i <= protocols.MaxPathLength; i++ {
+			params.Set(fmt.Sprintf(pathCodeParam, i), "")
+			params.Set(fmt.Sprintf("path[%d][asset_issuer]", i), "")
+		}
+		_, err = parse(params)
+		assert.Equal(t, map[string]interface{}{"name": "path[5][asset_code]"}, invalidPath(err))
+
+		params.Del("path[5][asset_code]")
+		params.Del("path[5][asset_issuer]")
+		request, err := parse(params)
+		require.NoError(t, err)
+		assert.Len(t, request.Path, protocols.MaxPathLength)
+	})
+}
@@ -0,0 +1,72 @@
+package bridge
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/stellar/gateway/protocols"
+)
+
+// PaymentPayloadVersion is the schema version of new PaymentPayloads. Payloads of other versions
+// are not rebuilt.
+const PaymentPayloadVersion = 1
+
+var (
+	// RebuildNotFailed is an error response
+	RebuildNotFailed = &protocols.ErrorResponse{Code: "rebuild_not_failed", Message: "Only failed transactions can be rebuilt.", Status: http.StatusBadRequest}
+	// RebuildAlreadyRebuilt is an error response
+	RebuildAlreadyRebuilt = &protocols.ErrorResponse{Code: "rebuild_already_rebuilt", Message: "Transaction has already been rebuilt and the rebuilt transaction has not failed.", Status: http.StatusBadRequest}
+	// RebuildNotAvailable is an error response
+	RebuildNotAvailable = &protocols.ErrorResponse{Code: "rebuild_not_available", Message: "Transaction has no stored request. Only payments sent by /payment without compliance protocol can be rebuilt.", Status: http.StatusBadRequest}
+	// RebuildUnsupportedVersion is an error response
+	RebuildUnsupportedVersion = &protocols.ErrorResponse{Code: "rebuild_unsupported_version", Message: "Stored request has a schema version this server cannot rebuild.", Status: http.StatusBadRequest}
+	// RebuildSecretOmitted is an error response
+	RebuildSecretOmitted = &protocols.ErrorResponse{Code: "rebuild_secret_omitted", Message: "Payment was sent with a `source` or `signers` secret that is not in the config file, the secret has not been stored. Send the payment again.", Status: http.StatusBadRequest}
+	// RebuildSourceNotConfigured is an error response
+	RebuildSourceNotConfigured = &protocols.ErrorResponse{Code: "rebuild_source_not_configured", Message: "Seed of the source account is no longer in the config file.", Status: http.StatusBadRequest}
+)
+
+// PaymentPayload is a validated PaymentRequest stored with its sent transaction so the payment
+// can be built and sent again. Secrets are never stored: the source is a reference to a seed in
+// the config file or it's omitted.
+type PaymentPayload struct {
+	Version int `json:"version"`
+	// SourceAlias is the config key of the source seed (ex. `base_seed`)
+	SourceAlias string `json:"source_alias,omitempty"`
+	// SourceOmitted is true when the source seed is not in the config file
+	SourceOmitted bool `json:"source_omitted,omitempty"`
+	// SignersOmitted is true when the payment was signed by `signers`
+	SignersOmitted bool `json:"signers_omitted,omitempty"`
+	// Params are request params without `source` and `uri`, params of the URI are merged
+	Params url.Values `json:"params"`
+}
+
+// NewPaymentPayload creates a PaymentPayload of a validated request. sourceAlias is empty when
+// the source seed is not in the config file.
+func NewPaymentPayload(request *PaymentRequest, sourceAlias string) PaymentPayload {
+	params := request.ToValues()
+	params.Del("source")
+	params.Del("uri")
+	// Rebuilds and queued payments are not handed off and send a new payment
+	params.Del("max_wait")
+	params.Del("id")
+	params.Del("queue")
+	params.Del("async")
+	return PaymentPayload{
+		Version:        PaymentPayloadVersion,
+		SourceAlias:    sourceAlias,
+		SourceOmitted:  sourceAlias == "",
+		SignersOmitted: len(request.Signers) > 0,
+		Params:         params,
+	}
+}
+
+// ToValues returns params of /payment request signed by a source seed
+func (payload PaymentPayload) ToValues(source string) url.Values {
+	values := url.Values{}
+	for name, value := range payload.Params {
+		values[name] = value
+	}
+	values.Set("source", source)
+	return values
+}
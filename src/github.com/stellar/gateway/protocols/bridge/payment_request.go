@@ -0,0 +1,150 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/protocols"
+)
+
+// PaymentRequestCreateRequest represents request made to /payment_requests endpoint of bridge server
+type PaymentRequestCreateRequest struct {
+	Amount string `name:"amount" required:""`
+	// AmountStroops can be sent instead of Amount, ex. `10000000` for 1 unit
+	AmountStroops string `name:"amount_stroops"`
+	AssetCode     string `name:"asset_code"`
+	AssetIssuer   string `name:"asset_issuer"`
+	// MemoType is `text` or `id`, a random `id` memo is generated when memo is empty
+	MemoType string `name:"memo_type"`
+	Memo     string `name:"memo"`
+	// ExpiresAt is a unix or RFC3339 timestamp after which the request can't be fulfilled
+	ExpiresAt string `name:"expires_at"`
+
+	protocols.FormRequest
+}
+
+// FromRequest will populate request fields using http.Request.
+func (request *PaymentRequestCreateRequest) FromRequest(r *http.Request) error {
+	return request.FormRequest.FromRequest(r, request)
+}
+
+// ToValues will create url.Values from request.
+func (request *PaymentRequestCreateRequest) ToValues() url.Values {
+	return request.FormRequest.ToValues(request)
+}
+
+// ExpiresAtValue returns ExpiresAt as unix timestamp, 0 if not set. It must be called after Validate.
+func (request *PaymentRequestCreateRequest) ExpiresAtValue() uint64 {
+	expiresAt, _ := parseTimestamp(request.ExpiresAt)
+	return expiresAt
+}
+
+// Validate validates if request fields are valid. Useful when checking if a request is correct.
+func (request *PaymentRequestCreateRequest) Validate() error {
+	if err := protocols.ConvertStroopsParam("amount", &request.Amount, "amount_stroops", &request.AmountStroops); err != nil {
+		return err
+	}
+
+	err := request.FormRequest.CheckRequired(request)
+	if err != nil {
+		return err
+	}
+
+	if !protocols.IsValidPositiveAmount(request.Amount) {
+		return NewPaymentInvalidAmountError("amount", request.Amount)
+	}
+
+	asset := protocols.Asset{Code: request.AssetCode, Issuer: request.AssetIssuer}
+	if !asset.Validate() {
+		return protocols.NewInvalidParameterError("asset_code", request.AssetCode, "Asset is invalid.")
+	}
+
+	switch request.MemoType {
+	case "":
+		if request.Memo != "" {
+			return protocols.NewMissingParameter("memo_type")
+		}
+	case "text":
+		if len(request.Memo) > 28 {
+			return protocols.NewInvalidParameterError("memo", request.Memo, "Memo text must be at most 28 bytes long.")
+		}
+	case "id":
+		_, err = strconv.ParseUint(request.Memo, 10, 64)
+		if err != nil {
+			return protocols.NewInvalidParameterError("memo", request.Memo, "Memo ID must be a number.")
+		}
+	default:
+		// Hash memos are used by the compliance protocol
+		return protocols.NewInvalidParameterError("memo_type", request.MemoType, "Memo type must be `text` or `id`.")
+	}
+
+	if request.MemoType != "" && request.Memo == "" {
+		return protocols.NewMissingParameter("memo")
+	}
+
+	if request.ExpiresAt != "" {
+		_, err = parseTimestamp(request.ExpiresAt)
+		if err != nil {
+			return protocols.NewInvalidParameterError("expires_at", request.ExpiresAt, "expires_at must be a unix or RFC3339 timestamp.")
+		}
+	}
+
+	return nil
+}
+
+// PaymentRequestResponse represents response returned by /payment_requests endpoints
+type PaymentRequestResponse struct {
+	protocols.SuccessResponse
+	*entities.PaymentRequest
+	// URI is a SEP-7 `web+stellar:pay` URI wallets can pay the request with
+	URI string `json:"uri"`
+	// QRPayload is a text to encode in a QR code, wallets scanning it open the URI
+	QRPayload string `json:"qr_payload"`
+}
+
+// NewPaymentRequestResponse creates PaymentRequestResponse of a given payment request
+func NewPaymentRequestResponse(paymentRequest *entities.PaymentRequest) *PaymentRequestResponse {
+	uri := PayURI(paymentRequest)
+	return &PaymentRequestResponse{
+		PaymentRequest: paymentRequest,
+		URI:            uri,
+		QRPayload:      uri,
+	}
+}
+
+// Marshal marshals PaymentRequestResponse
+func (response *PaymentRequestResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
+
+// PaymentRequestNotFound is an error response
+var PaymentRequestNotFound = &protocols.ErrorResponse{Code: "payment_request_not_found", Message: "Payment request not found.", Status: http.StatusNotFound}
+
+// sep7MemoTypes maps memo types to SEP-7 `memo_type` values
+var sep7MemoTypes = map[string]string{
+	"text": "MEMO_TEXT",
+	"id":   "MEMO_ID",
+}
+
+// PayURI returns a SEP-7 `web+stellar:pay` URI of a payment request
+func PayURI(paymentRequest *entities.PaymentRequest) string {
+	query := url.Values{}
+	query.Set("destination", paymentRequest.Destination)
+	query.Set("amount", paymentRequest.Amount)
+	if paymentRequest.AssetCode != "" && paymentRequest.AssetIssuer != "" {
+		query.Set("asset_code", paymentRequest.AssetCode)
+		query.Set("asset_issuer", paymentRequest.AssetIssuer)
+	}
+	if paymentRequest.Memo != "" {
+		query.Set("memo", paymentRequest.Memo)
+		query.Set("memo_type", sep7MemoTypes[paymentRequest.MemoType])
+	}
+
+	// SEP-7 requires percent-encoding, url.Values encodes spaces as `+` (and `+` as `%2B`)
+	return "web+stellar:pay?" + strings.Replace(query.Encode(), "+", "%20", -1)
+}
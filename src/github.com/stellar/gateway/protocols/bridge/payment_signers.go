@@ -0,0 +1,73 @@
+package bridge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/go/keypair"
+)
+
+// MaxPaymentSigners is the maximum number of `signers` of a payment, envelopes have at most 20
+// signatures and one is left for the source seed
+const MaxPaymentSigners = 19
+
+// signersFromForm reads repeated `signers[]` params and comma-separated `signers` params
+func signersFromForm(form url.Values) []string {
+	var signers []string
+	signers = append(signers, form["signers[]"]...)
+	for _, value := range form["signers"] {
+		for _, signer := range strings.Split(value, ",") {
+			if signer = strings.TrimSpace(signer); signer != "" {
+				signers = append(signers, signer)
+			}
+		}
+	}
+	return signers
+}
+
+// ValidateSigners validates `signers` of a request whose source was set after Validate (ex. to
+// the base seed), so signers duplicating the source are rejected
+func (request *PaymentRequest) ValidateSigners() error {
+	var errs protocols.ValidationErrors
+	request.validateSigners(&errs)
+	return errs.Err()
+}
+
+// validateSigners adds failed checks of `signers` to errs. Signers are secrets so their values
+// are not returned in errors, a signer is reported by its position.
+func (request *PaymentRequest) validateSigners(errs *protocols.ValidationErrors) {
+	if len(request.Signers) > MaxPaymentSigners {
+		errs.Add(protocols.NewInvalidParameterError("signers", "", fmt.Sprintf("At most %d signers can be sent.", MaxPaymentSigners)))
+		return
+	}
+	if request.ExtraMemo != "" || request.UseCompliance {
+		errs.Add(protocols.NewInvalidParameterError("signers", "", "Signers cannot be set in compliance payments."))
+		return
+	}
+	if request.Queue {
+		errs.Add(protocols.NewInvalidParameterError("signers", "", "Payments with signers cannot be queued, secrets are not stored."))
+		return
+	}
+
+	signed := map[string]bool{}
+	if sourceKeypair, err := keypair.Parse(request.Source); err == nil {
+		if _, ok := sourceKeypair.(*keypair.Full); ok {
+			signed[sourceKeypair.Address()] = true
+		}
+	}
+	for i, signer := range request.Signers {
+		signerKeypair, err := keypair.Parse(signer)
+		if _, ok := signerKeypair.(*keypair.Full); err != nil || !ok {
+			errs.Add(protocols.NewInvalidParameterError("signers", "", fmt.Sprintf("Signer %d must be a secret seed (starting with `S`).", i+1)))
+			continue
+		}
+		// A signature of a duplicate takes a slot of the envelope without adding weight
+		if signed[signerKeypair.Address()] {
+			errs.Add(protocols.NewInvalidParameterError("signers", "", fmt.Sprintf("Signer %d is a duplicate of the source or another signer.", i+1)))
+			continue
+		}
+		signed[signerKeypair.Address()] = true
+	}
+}
@@ -0,0 +1,178 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/xdr"
+)
+
+// PreauthSignerWeight is the weight of pre-authorized transaction signers added by /preauth
+const PreauthSignerWeight = 255
+
+// PreauthOutstanding is an error response
+var PreauthOutstanding = &protocols.ErrorResponse{Code: "preauth_outstanding", Message: "Recovery account has a signer of an earlier recovery envelope, a new envelope invalidates it. Send replace=true to replace it.", Status: http.StatusConflict}
+
+// PreauthRequest represents request made to /preauth endpoint of bridge server
+type PreauthRequest struct {
+	Source      string `name:"source"`
+	Destination string `name:"destination" required:""`
+	Amount      string `name:"amount" required:""`
+	// AmountStroops is the amount in stroops, it replaces Amount when the request is validated
+	AmountStroops string `name:"amount_stroops"`
+	AssetCode     string `name:"asset_code"`
+	AssetIssuer   string `name:"asset_issuer"`
+	// MaxTime is a unix or RFC3339 timestamp after which recovery transaction is no longer valid, 0 means no limit
+	MaxTime string `name:"max_time"`
+	// Replace builds a new recovery envelope when the recovery account has a pre-authorized
+	// transaction signer of an earlier one, the earlier envelope becomes invalid
+	Replace bool `name:"replace"`
+
+	protocols.FormRequest
+}
+
+// FromRequest will populate request fields using http.Request.
+func (request *PreauthRequest) FromRequest(r *http.Request) error {
+	return request.FormRequest.FromRequest(r, request)
+}
+
+// ToValues will create url.Values from request.
+func (request *PreauthRequest) ToValues() url.Values {
+	return request.FormRequest.ToValues(request)
+}
+
+// ToPaymentRequest returns PaymentRequest that can be used to build recovery payment operation
+func (request *PreauthRequest) ToPaymentRequest() *PaymentRequest {
+	return &PaymentRequest{
+		Source:      request.Source,
+		Destination: request.Destination,
+		Amount:      request.Amount,
+		AssetCode:   request.AssetCode,
+		AssetIssuer: request.AssetIssuer,
+	}
+}
+
+// MaxTimeValue returns MaxTime as unix timestamp. It must be called after Validate.
+func (request *PreauthRequest) MaxTimeValue() uint64 {
+	maxTime, _ := parseTimestamp(request.MaxTime)
+	return maxTime
+}
+
+// parseTimestamp parses unix timestamp or RFC3339 timestamp with any offset
+func parseTimestamp(value string) (uint64, error) {
+	if unix, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return unix, nil
+	}
+
+	t, err := utc.Parse(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if t.Unix() < 0 {
+		return 0, errors.New("timestamp must be after 1970-01-01T00:00:00Z")
+	}
+	return uint64(t.Unix()), nil
+}
+
+// Validate validates if request fields are valid. Useful when checking if a request is correct.
+func (request *PreauthRequest) Validate() error {
+	if err := protocols.ConvertStroopsParam("amount", &request.Amount, "amount_stroops", &request.AmountStroops); err != nil {
+		return err
+	}
+
+	err := request.FormRequest.CheckRequired(request)
+	if err != nil {
+		return err
+	}
+
+	if request.Source != "" && !protocols.IsValidSecret(request.Source) {
+		return protocols.NewInvalidParameterError("source", request.Source, "Source parameter must start with `S`.")
+	}
+
+	// Federation addresses are not accepted, recovery destination must be known upfront
+	if !protocols.IsValidAccountID(request.Destination) {
+		return protocols.NewInvalidParameterError("destination", request.Destination, "Destination public key must start with `G`.")
+	}
+
+	if !protocols.IsValidPositiveAmount(request.Amount) {
+		return NewPaymentInvalidAmountError("amount", request.Amount)
+	}
+
+	asset := protocols.Asset{Code: request.AssetCode, Issuer: request.AssetIssuer}
+	if !asset.Validate() {
+		return protocols.NewInvalidParameterError("asset_code", request.AssetCode, "Asset is invalid.")
+	}
+
+	if request.MaxTime != "" {
+		_, err = parseTimestamp(request.MaxTime)
+		if err != nil {
+			return protocols.NewInvalidParameterError("max_time", request.MaxTime, "max_time must be a unix or RFC3339 timestamp.")
+		}
+	}
+
+	return nil
+}
+
+// PreauthResponse represents response returned by /preauth endpoint of bridge server
+type PreauthResponse struct {
+	protocols.SuccessResponse
+	// Hash is a hex encoded hash of the recovery transaction
+	Hash string `json:"hash"`
+	// Signer is a pre-authorized transaction signer (`T...`) added to the accounts
+	Signer string `json:"signer"`
+	// SequenceNumber reserved for the recovery transaction
+	SequenceNumber string `json:"sequence_number"`
+	// TransactionEnvelope is an unsigned recovery transaction envelope
+	TransactionEnvelope string `json:"transaction_envelope"`
+}
+
+// Marshal marshals PreauthResponse
+func (response *PreauthResponse) Marshal() []byte {
+	json, _ := json.MarshalIndent(response, "", "  ")
+	return json
+}
+
+// PreauthSubmitRequest represents request made to /preauth/submit endpoint of bridge server
+type PreauthSubmitRequest struct {
+	TransactionEnvelope string `name:"transaction_envelope" required:""`
+
+	protocols.FormRequest
+}
+
+// FromRequest will populate request fields using http.Request.
+func (request *PreauthSubmitRequest) FromRequest(r *http.Request) error {
+	return request.FormRequest.FromRequest(r, request)
+}
+
+// ToValues will create url.Values from request.
+func (request *PreauthSubmitRequest) ToValues() url.Values {
+	return request.FormRequest.ToValues(request)
+}
+
+// Validate validates if request fields are valid. Useful when checking if a request is correct.
+func (request *PreauthSubmitRequest) Validate() error {
+	err := request.FormRequest.CheckRequired(request)
+	if err != nil {
+		return err
+	}
+
+	var envelope xdr.TransactionEnvelope
+	err = xdr.SafeUnmarshalBase64(request.TransactionEnvelope, &envelope)
+	if err != nil {
+		return protocols.NewInvalidParameterError("transaction_envelope", request.TransactionEnvelope, "Cannot decode transaction envelope.")
+	}
+
+	// Pre-authorized transaction signer is removed once the transaction is applied
+	// and extra signatures would fail with tx_bad_auth_extra.
+	if len(envelope.Signatures) != 0 {
+		return protocols.NewInvalidParameterError("transaction_envelope", request.TransactionEnvelope, "Recovery transaction envelope must not be signed.")
+	}
+
+	return nil
+}
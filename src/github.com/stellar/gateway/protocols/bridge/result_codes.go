@@ -0,0 +1,161 @@
+package bridge
+
+import (
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/go/xdr"
+)
+
+// unknownResultCode is the code of operation results not known by the bridge server
+const unknownResultCode = "op_unknown"
+
+// Operation result codes are named like in `result_codes` of Horizon
+var (
+	paymentResultCodes = map[xdr.PaymentResultCode]string{
+		xdr.PaymentResultCodePaymentSuccess:          "op_success",
+		xdr.PaymentResultCodePaymentMalformed:        "op_malformed",
+		xdr.PaymentResultCodePaymentUnderfunded:      "op_underfunded",
+		xdr.PaymentResultCodePaymentSrcNoTrust:       "op_src_no_trust",
+		xdr.PaymentResultCodePaymentSrcNotAuthorized: "op_src_not_authorized",
+		xdr.PaymentResultCodePaymentNoDestination:    "op_no_destination",
+		xdr.PaymentResultCodePaymentNoTrust:          "op_no_trust",
+		xdr.PaymentResultCodePaymentNotAuthorized:    "op_not_authorized",
+		xdr.PaymentResultCodePaymentLineFull:         "op_line_full",
+		xdr.PaymentResultCodePaymentNoIssuer:         "op_no_issuer",
+	}
+	pathPaymentResultCodes = map[xdr.PathPaymentResultCode]string{
+		xdr.PathPaymentResultCodePathPaymentSuccess:          "op_success",
+		xdr.PathPaymentResultCodePathPaymentMalformed:        "op_malformed",
+		xdr.PathPaymentResultCodePathPaymentUnderfunded:      "op_underfunded",
+		xdr.PathPaymentResultCodePathPaymentSrcNoTrust:       "op_src_no_trust",
+		xdr.PathPaymentResultCodePathPaymentSrcNotAuthorized: "op_src_not_authorized",
+		xdr.PathPaymentResultCodePathPaymentNoDestination:    "op_no_destination",
+		xdr.PathPaymentResultCodePathPaymentNoTrust:          "op_no_trust",
+		xdr.PathPaymentResultCodePathPaymentNotAuthorized:    "op_not_authorized",
+		xdr.PathPaymentResultCodePathPaymentLineFull:         "op_line_full",
+		xdr.PathPaymentResultCodePathPaymentNoIssuer:         "op_no_issuer",
+		xdr.PathPaymentResultCodePathPaymentTooFewOffers:     "op_too_few_offers",
+		xdr.PathPaymentResultCodePathPaymentOfferCrossSelf:   "op_cross_self",
+		xdr.PathPaymentResultCodePathPaymentOverSendmax:      "op_over_source_max",
+	}
+	createAccountResultCodes = map[xdr.CreateAccountResultCode]string{
+		xdr.CreateAccountResultCodeCreateAccountSuccess:      "op_success",
+		xdr.CreateAccountResultCodeCreateAccountMalformed:    "op_malformed",
+		xdr.CreateAccountResultCodeCreateAccountUnderfunded:  "op_underfunded",
+		xdr.CreateAccountResultCodeCreateAccountLowReserve:   "op_low_reserve",
+		xdr.CreateAccountResultCodeCreateAccountAlreadyExist: "op_already_exists",
+	}
+	changeTrustResultCodes = map[xdr.ChangeTrustResultCode]string{
+		xdr.ChangeTrustResultCodeChangeTrustSuccess:        "op_success",
+		xdr.ChangeTrustResultCodeChangeTrustMalformed:      "op_malformed",
+		xdr.ChangeTrustResultCodeChangeTrustNoIssuer:       "op_no_issuer",
+		xdr.ChangeTrustResultCodeChangeTrustInvalidLimit:   "op_invalid_limit",
+		xdr.ChangeTrustResultCodeChangeTrustLowReserve:     "op_low_reserve",
+		xdr.ChangeTrustResultCodeChangeTrustSelfNotAllowed: "op_self_not_allowed",
+	}
+)
+
+// OperationResultCodes returns codes of operation results (ex. `op_success` or `op_no_trust`) of
+// a submitted transaction decoded from its result XDR. Results of operation types other than
+// payment, path_payment, create_account and change_trust are `op_unknown`. It returns nil when the
+// response has no operation results (ex. `tx_bad_seq`).
+func OperationResultCodes(response horizon.SubmitTransactionResponse) []string {
+	resultXdr := responseResultXdr(response)
+	if resultXdr == "" {
+		return nil
+	}
+
+	txResult, err := unmarshalTransactionResult(resultXdr)
+	if err != nil {
+		return nil
+	}
+	return operationResultCodes(txResult)
+}
+
+// ResultCodesFromHorizonResponse returns the transaction-level code and codes of operations of a
+// submitted transaction. A result that cannot be decoded or has codes unknown to the bridge server
+// is returned in `result_xdr` of the codes. It returns nil when the response has no result XDR.
+func ResultCodesFromHorizonResponse(response horizon.SubmitTransactionResponse) *horizon.ResultCodes {
+	resultXdr := responseResultXdr(response)
+	if resultXdr == "" {
+		return nil
+	}
+
+	txResult, err := unmarshalTransactionResult(resultXdr)
+	if err != nil {
+		return &horizon.ResultCodes{ResultXdr: resultXdr}
+	}
+
+	codes := &horizon.ResultCodes{Operations: operationResultCodes(txResult)}
+	switch txResult.Result.Code {
+	case xdr.TransactionResultCodeTxSuccess:
+		codes.Transaction = "tx_success"
+	case xdr.TransactionResultCodeTxFailed:
+		codes.Transaction = "tx_failed"
+	default:
+		if resultErr := horizon.NewTransactionResultError(txResult.Result.Code); resultErr != nil {
+			codes.Transaction = resultErr.Name
+		}
+	}
+
+	known := codes.Transaction != ""
+	for _, code := range codes.Operations {
+		known = known && code != unknownResultCode
+	}
+	if !known {
+		codes.ResultXdr = resultXdr
+	}
+	return codes
+}
+
+// responseResultXdr returns result XDR of a successful or failed transaction
+func responseResultXdr(response horizon.SubmitTransactionResponse) string {
+	if response.ResultXdr != nil {
+		return *response.ResultXdr
+	} else if response.Extras != nil {
+		return response.Extras.ResultXdr
+	}
+	return ""
+}
+
+func operationResultCodes(txResult xdr.TransactionResult) []string {
+	if txResult.Result.Results == nil {
+		return nil
+	}
+
+	results := *txResult.Result.Results
+	codes := make([]string, len(results))
+	for i, result := range results {
+		codes[i] = operationResultCode(result)
+	}
+	return codes
+}
+
+func operationResultCode(result xdr.OperationResult) string {
+	switch result.Code {
+	case xdr.OperationResultCodeOpBadAuth:
+		return "op_bad_auth"
+	case xdr.OperationResultCodeOpNoAccount:
+		return "op_no_source_account"
+	}
+
+	if result.Tr == nil {
+		return unknownResultCode
+	}
+
+	var code string
+	var ok bool
+	switch {
+	case result.Tr.PaymentResult != nil:
+		code, ok = paymentResultCodes[result.Tr.PaymentResult.Code]
+	case result.Tr.PathPaymentResult != nil:
+		code, ok = pathPaymentResultCodes[result.Tr.PathPaymentResult.Code]
+	case result.Tr.CreateAccountResult != nil:
+		code, ok = createAccountResultCodes[result.Tr.CreateAccountResult.Code]
+	case result.Tr.ChangeTrustResult != nil:
+		code, ok = changeTrustResultCodes[result.Tr.ChangeTrustResult.Code]
+	}
+	if !ok {
+		return unknownResultCode
+	}
+	return code
+}
@@ -0,0 +1,53 @@
+package bridge
+
+import (
+	"testing"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultCodesFromHorizonResponse(t *testing.T) {
+	failed := func(results ...xdr.OperationResult) string {
+		resultXdr, err := xdr.MarshalBase64(xdr.TransactionResult{
+			FeeCharged: 200,
+			Result:     xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxFailed, Results: &results},
+		})
+		require.NoError(t, err)
+		return resultXdr
+	}
+	createAccount := xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+		Type:                xdr.OperationTypeCreateAccount,
+		CreateAccountResult: &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountSuccess},
+	}}
+	payment := xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+		Type:          xdr.OperationTypePayment,
+		PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentUnderfunded},
+	}}
+	manageData := xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+		Type:             xdr.OperationTypeManageData,
+		ManageDataResult: &xdr.ManageDataResult{Code: xdr.ManageDataResultCodeManageDataNotSupportedYet},
+	}}
+
+	resultXdr := failed(createAccount, payment)
+	codes := ResultCodesFromHorizonResponse(horizon.SubmitTransactionResponse{
+		Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: resultXdr},
+	})
+	assert.Equal(t, &horizon.ResultCodes{Transaction: "tx_failed", Operations: []string{"op_success", "op_underfunded"}}, codes)
+
+	// Unknown codes are returned with the raw result
+	resultXdr = failed(manageData)
+	codes = ResultCodesFromHorizonResponse(horizon.SubmitTransactionResponse{
+		Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: resultXdr},
+	})
+	assert.Equal(t, &horizon.ResultCodes{Transaction: "tx_failed", Operations: []string{"op_unknown"}, ResultXdr: resultXdr}, codes)
+
+	codes = ResultCodesFromHorizonResponse(horizon.SubmitTransactionResponse{
+		Extras: &horizon.SubmitTransactionResponseExtras{ResultXdr: "AAAA!"},
+	})
+	assert.Equal(t, &horizon.ResultCodes{ResultXdr: "AAAA!"}, codes)
+
+	assert.Nil(t, ResultCodesFromHorizonResponse(horizon.SubmitTransactionResponse{}))
+}
@@ -0,0 +1,72 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/gateway/utc"
+)
+
+// Sources of expected values of verified fields
+const (
+	// VerificationSourceReceivedPayment fields are compared with the stored received payment
+	VerificationSourceReceivedPayment = "received_payment"
+	// VerificationSourcePaymentRequest fields are compared with the payment request fulfilled by
+	// the payment
+	VerificationSourcePaymentRequest = "payment_request"
+)
+
+// VerifiedField is a field of on-chain data compared with the value expected by the database
+type VerifiedField struct {
+	Field string `json:"field"`
+	// Source is VerificationSourceReceivedPayment or VerificationSourcePaymentRequest
+	Source   string `json:"source"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	Matched  bool   `json:"matched"`
+}
+
+// PaymentVerification is a verdict of /verify/payment
+type PaymentVerification struct {
+	OperationID     string `json:"operation_id"`
+	TransactionHash string `json:"transaction_hash"`
+	// PaymentRequest is the ID of the payment request fulfilled by the payment, if any
+	PaymentRequest string          `json:"payment_request,omitempty"`
+	Verified       bool            `json:"verified"`
+	Fields         []VerifiedField `json:"fields"`
+	// Disputed is true when the payment is marked as disputed, by this or a previous verification
+	Disputed   bool     `json:"disputed"`
+	VerifiedAt utc.Time `json:"verified_at"`
+}
+
+// Mismatched returns names of fields that didn't match, each name once
+func (verification *PaymentVerification) Mismatched() []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, field := range verification.Fields {
+		if !field.Matched && !seen[field.Field] {
+			seen[field.Field] = true
+			names = append(names, field.Field)
+		}
+	}
+	return names
+}
+
+// PaymentVerificationResponse represents response returned by /verify/payment endpoint. Signature
+// is a detached compact JWS of the exact bytes of Verdict.
+type PaymentVerificationResponse struct {
+	protocols.SuccessResponse
+	Verdict   json.RawMessage `json:"verdict"`
+	Signature string          `json:"signature"`
+}
+
+// Marshal marshals PaymentVerificationResponse. The response is not indented so Verdict is
+// written exactly as it was signed.
+func (response *PaymentVerificationResponse) Marshal() []byte {
+	json, _ := json.Marshal(response)
+	return json
+}
+
+// ReceivedPaymentNotFound is an error response
+var ReceivedPaymentNotFound = &protocols.ErrorResponse{Code: "received_payment_not_found", Message: "Received payment not found.", Status: http.StatusNotFound}
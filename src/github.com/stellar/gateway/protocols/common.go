@@ -49,6 +49,9 @@ type FormRequest struct {
 	HTTPRequest *http.Request
 }
 
+// MaxPathLength is the maximum number of intermediate assets of a path payment
+const MaxPathLength = 5
+
 const (
 	pathCodeField   = "path[%d][asset_code]"
 	pathIssuerField = "path[%d][asset_issuer]"
@@ -68,7 +71,7 @@ func (request *FormRequest) FromRequest(r *http.Request, destination interface{}
 		case "path":
 			var path []Asset
 
-			for i := 0; i < 5; i++ {
+			for i := 0; i < MaxPathLength; i++ {
 				codeFieldName := fmt.Sprintf(pathCodeField, i)
 				issuerFieldName := fmt.Sprintf(pathIssuerField, i)
 
@@ -114,13 +117,26 @@ func (request *FormRequest) FromRequest(r *http.Request, destination interface{}
 
 // CheckRequired checks whether all fields marked as required have value
 func (request *FormRequest) CheckRequired(destination interface{}) error {
+	missing, err := request.MissingRequired(destination)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return missing[0]
+	}
+	return nil
+}
+
+// MissingRequired returns errors of all fields marked as required without value, fields set
+// after parsing the form (ex. amounts converted from stroops) are not missing
+func (request *FormRequest) MissingRequired(destination interface{}) (missing ValidationErrors, err error) {
 	rvalue := reflect.ValueOf(destination).Elem()
 	typ := rvalue.Type()
 	for i := 0; i < rvalue.NumField(); i++ {
 		required, _, err := structtag.Extract("required", string(typ.Field(i).Tag))
 
 		if err != nil {
-			return NewInternalServerError(
+			return nil, NewInternalServerError(
 				"Error extracting tag using structtag",
 				map[string]interface{}{"error": err},
 			)
@@ -128,12 +144,13 @@ func (request *FormRequest) CheckRequired(destination interface{}) error {
 
 		if required {
 			name := typ.Field(i).Tag.Get("name")
-			if request.HTTPRequest.PostFormValue(name) == "" {
-				return NewMissingParameter(name)
+			field := rvalue.Field(i)
+			if request.HTTPRequest.PostFormValue(name) == "" && (field.Kind() != reflect.String || field.String() == "") {
+				missing.Add(NewMissingParameter(name))
 			}
 		}
 	}
-	return nil
+	return missing, nil
 }
 
 // ToValues transforms request object to url.Values
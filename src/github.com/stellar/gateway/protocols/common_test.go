@@ -45,3 +45,21 @@ func TestProtocols(t *testing.T) {
 		})
 	})
 }
+
+func TestValidationErrors(t *testing.T) {
+	var errs protocols.ValidationErrors
+	errs.Add(nil)
+	assert.Nil(t, errs.Err())
+
+	errs.Add(protocols.NewMissingParameter("destination"))
+	assert.Equal(t, errs[0], errs.Err())
+
+	errs.Add(protocols.NewInvalidParameterError("amount", "1.x", "Amount must be a number."))
+	err := errs.Err().(*protocols.ErrorResponse)
+	assert.Equal(t, "validation_failed", err.Code)
+	assert.Equal(t, http.StatusBadRequest, err.Status)
+	assert.Equal(t, []protocols.FieldError{
+		{Field: "destination", Code: "missing_parameter", Message: "Required parameter is missing."},
+		{Field: "amount", Code: "invalid_parameter", Message: "Amount must be a number."},
+	}, err.Errors)
+}
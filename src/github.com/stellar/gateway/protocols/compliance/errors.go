@@ -19,3 +19,7 @@ var (
 	// AuthServerNotDefined is an error response
 	AuthServerNotDefined = &protocols.ErrorResponse{Code: "auth_server_not_defined", Message: "No AUTH_SERVER defined in stellar.toml file.", Status: http.StatusBadRequest}
 )
+
+func init() {
+	protocols.RegisterErrors(TransactionNotFoundError, CannotResolveDestination, AuthServerNotDefined)
+}
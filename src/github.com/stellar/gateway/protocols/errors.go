@@ -2,7 +2,12 @@ package protocols
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stellar/gateway/errorcodes"
 )
 
 var (
@@ -12,8 +17,40 @@ var (
 	InvalidParameterError = &ErrorResponse{Code: "invalid_parameter", Message: "Invalid parameter.", Status: http.StatusBadRequest}
 	// MissingParameterError is an error response
 	MissingParameterError = &ErrorResponse{Code: "missing_parameter", Message: "Required parameter is missing.", Status: http.StatusBadRequest}
+	// DependencyUnavailableError is an error response
+	DependencyUnavailableError = &ErrorResponse{Code: "dependency_unavailable", Message: "Dependency is unavailable, please try again later.", Status: http.StatusServiceUnavailable}
+	// RateLimitedError is an error response
+	RateLimitedError = &ErrorResponse{Code: "rate_limited", Message: "Horizon rate limit exceeded, please try again later.", Status: http.StatusTooManyRequests}
+	// HorizonTimeoutError is an error response
+	HorizonTimeoutError = &ErrorResponse{Code: "horizon_timeout", Message: "Horizon did not respond in time.", Status: http.StatusGatewayTimeout}
+	// ValidationFailedError is an error response
+	ValidationFailedError = &ErrorResponse{Code: "validation_failed", Message: "Request has more than one invalid parameter, see errors.", Status: http.StatusBadRequest}
 )
 
+func init() {
+	RegisterErrors(InternalServerError, InvalidParameterError, MissingParameterError, DependencyUnavailableError, RateLimitedError, HorizonTimeoutError, ValidationFailedError)
+}
+
+// RegisterErrors registers error responses returned by servers in errorcodes. It's called by init
+// functions of packages declaring error responses. Responses with Retriable set, rate limits and
+// server failures are registered as retriable.
+func RegisterErrors(responses ...*ErrorResponse) {
+	for _, response := range responses {
+		errorcodes.Register(errorcodes.Entry{
+			Code:        response.Code,
+			Status:      response.Status,
+			Retriable:   response.Retriable || errorcodes.IsRetriableStatus(response.Status),
+			Description: response.Message,
+		})
+	}
+}
+
+// IsRegisteredErrorCode returns true if an error response with a given code has been registered
+func IsRegisteredErrorCode(code string) bool {
+	_, ok := errorcodes.Lookup(code)
+	return ok
+}
+
 // NewInternalServerError creates and returns a new InternalServerError
 func NewInternalServerError(logMessage string, logData map[string]interface{}) *ErrorResponse {
 	return &ErrorResponse{
@@ -61,6 +98,101 @@ func NewMissingParameter(name string) *ErrorResponse {
 	}
 }
 
+// NewDependencyUnavailableError creates and returns a new DependencyUnavailableError naming the
+// unavailable dependency (ex. `horizon.transactions`)
+func NewDependencyUnavailableError(dependency string) *ErrorResponse {
+	data := map[string]interface{}{"dependency": dependency}
+	return &ErrorResponse{
+		Status:  DependencyUnavailableError.Status,
+		Code:    DependencyUnavailableError.Code,
+		Message: DependencyUnavailableError.Message,
+		Data:    data,
+		LogData: data,
+	}
+}
+
+// NewHorizonTimeoutError creates and returns a new HorizonTimeoutError of a Horizon endpoint (ex.
+// `submit_transaction`). The outcome of a timed out submission is unknown.
+func NewHorizonTimeoutError(endpoint string) *ErrorResponse {
+	data := map[string]interface{}{"endpoint": endpoint}
+	response := &ErrorResponse{
+		Status:  HorizonTimeoutError.Status,
+		Code:    HorizonTimeoutError.Code,
+		Message: HorizonTimeoutError.Message,
+		Data:    data,
+		LogData: data,
+	}
+	if endpoint == "submit_transaction" {
+		response.MoreInfo = "Outcome of the submission is unknown, the transaction can still be applied. Look it up by hash before sending it again."
+	}
+	return response
+}
+
+// NewRateLimitedError creates and returns a new RateLimitedError with Retry-After header, the
+// header is not sent when retryAfter is 0 (not advertised by Horizon)
+func NewRateLimitedError(retryAfter time.Duration) *ErrorResponse {
+	response := &ErrorResponse{
+		Status:  RateLimitedError.Status,
+		Code:    RateLimitedError.Code,
+		Message: RateLimitedError.Message,
+	}
+	if retryAfter > 0 {
+		seconds := int64(math.Ceil(retryAfter.Seconds()))
+		response.Data = map[string]interface{}{"retry_after": seconds}
+		response.Header = http.Header{"Retry-After": []string{strconv.FormatInt(seconds, 10)}}
+	}
+	return response
+}
+
+// FieldError is a failed validation check of a single request param, returned in `errors` of
+// ValidationFailedError responses
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors accumulates failures of independent validation checks of a request so they
+// are reported together
+type ValidationErrors []*ErrorResponse
+
+// Add adds a failed check, nil errors are skipped
+func (errs *ValidationErrors) Add(err *ErrorResponse) {
+	if err != nil {
+		*errs = append(*errs, err)
+	}
+}
+
+// Err returns nil when no check failed and the error itself when a single check failed, so
+// responses of requests with one problem are unchanged. Otherwise ValidationFailedError with
+// every failure in `errors` is returned.
+func (errs ValidationErrors) Err() error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	fields := make([]FieldError, len(errs))
+	for i, err := range errs {
+		fields[i] = FieldError{Code: err.Code, Message: err.Message}
+		if name, ok := err.Data["name"].(string); ok {
+			fields[i].Field = name
+		}
+		if err.MoreInfo != "" {
+			fields[i].Message = err.MoreInfo
+		}
+	}
+	return &ErrorResponse{
+		Status:  ValidationFailedError.Status,
+		Code:    ValidationFailedError.Code,
+		Message: ValidationFailedError.Message,
+		Errors:  fields,
+		LogData: map[string]interface{}{"errors": fields},
+	}
+}
+
 // ErrorResponse represents error response and implements server.Response and error interfaces
 type ErrorResponse struct {
 	// HTTP status code
@@ -71,12 +203,21 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 	// Additional information returned to API consumer
 	MoreInfo string `json:"more_info,omitempty"`
+	// Machine-readable hint how the request can be fixed (ex. `fund_source`)
+	Remediation string `json:"remediation,omitempty"`
+	// Retriable is true when sending the same request again later can succeed, it's registered
+	// in errorcodes
+	Retriable bool `json:"-"`
 	// Error data that will be returned to API consumer
 	Data map[string]interface{} `json:"data,omitempty"`
+	// Errors are failed checks of ValidationFailedError responses
+	Errors []FieldError `json:"errors,omitempty"`
 	// Error message that will be logged.
 	LogMessage string `json:"-"`
 	// Error data that will be logged.
 	LogData map[string]interface{} `json:"-"`
+	// HTTP headers of the response (ex. Retry-After)
+	Header http.Header `json:"-"`
 }
 
 // Error returns Message or LogMessage if set
@@ -92,6 +233,11 @@ func (error *ErrorResponse) HTTPStatus() int {
 	return error.Status
 }
 
+// HTTPHeader returns ErrorResponse.Header
+func (error *ErrorResponse) HTTPHeader() http.Header {
+	return error.Header
+}
+
 // Marshal marshals ErrorResponse
 func (error *ErrorResponse) Marshal() []byte {
 	json, _ := json.MarshalIndent(error, "", "  ")
@@ -1,10 +1,29 @@
 package protocols
 
 import (
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+
 	"github.com/stellar/go/amount"
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
 )
 
+// stroopsAmount matches integers without sign and leading zeros
+var stroopsAmount = regexp.MustCompile(`^[1-9][0-9]*$`)
+
+// MaxAmount is the largest amount of an asset, the maximum int64 in stroops
+const MaxAmount = "922337203685.4775807"
+
+// assetCode matches codes of credit_alphanum4 and credit_alphanum12 assets
+var assetCode = regexp.MustCompile(`^[a-zA-Z0-9]{1,12}$`)
+
+// decimalAmount matches plain decimal numbers with at most 7 decimal places, without sign,
+// exponent or group separators
+var decimalAmount = regexp.MustCompile(`^[0-9]+(\.[0-9]{1,7})?$`)
+
 // IsValidAccountID returns true if account ID is valid
 func IsValidAccountID(accountID string) bool {
 	_, err := keypair.Parse(accountID)
@@ -33,19 +52,97 @@ func IsValidSecret(secret string) bool {
 	return true
 }
 
-// IsValidAssetCode returns true if asset code is valid
+// IsValidAssetCode returns true if asset code is valid: 1-4 (alphanum4) or 5-12 (alphanum12)
+// alphanumeric characters
 func IsValidAssetCode(code string) bool {
-	if len(code) < 1 || len(code) > 12 {
-		return false
-	}
-	return true
+	return assetCode.MatchString(code)
 }
 
-// IsValidAmount returns true if amount is valid
+// IsValidAmount returns true if amount is a plain decimal number with at most 7 decimal places like
+// IsValidPositiveAmount, zero included (ex. the limit of a removed trustline)
 func IsValidAmount(a string) bool {
+	if !decimalAmount.MatchString(a) {
+		return false
+	}
 	_, err := amount.Parse(a)
+	return err == nil
+}
+
+// IsValidPositiveAmount returns true if amount is a positive plain decimal number (ex. `10.5`) with
+// at most 7 decimal places, see ParsePositiveAmount
+func IsValidPositiveAmount(a string) bool {
+	_, ok := ParsePositiveAmount(a)
+	return ok
+}
+
+// ParsePositiveAmount returns the value in stroops of a positive plain decimal amount (ex. `10.5`)
+// with at most 7 decimal places, up to MaxAmount. Unlike amount.Parse it returns false for signs,
+// exponents (`1e3`), hex (`0x10`), fractions (`1/2`), group or decimal comma separators (`1,000`,
+// `10,50`) and surrounding spaces, which amount.Parse could accept or misread.
+func ParsePositiveAmount(a string) (xdr.Int64, bool) {
+	if !decimalAmount.MatchString(a) {
+		return 0, false
+	}
+	// amount.Parse fails when the value overflows int64, so its result is at most MaxAmount
+	value, err := amount.Parse(a)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// AmountFromStroops returns the decimal amount (ex. `1.0000000`) of an amount in stroops (ex.
+// `10000000`). It returns false when stroops is not a positive int64 integer.
+func AmountFromStroops(stroops string) (string, bool) {
+	if !stroopsAmount.MatchString(stroops) {
+		return "", false
+	}
+	value, err := strconv.ParseInt(stroops, 10, 64)
 	if err != nil {
-		return false
+		return "", false
 	}
-	return true
+	return amount.String(xdr.Int64(value)), true
+}
+
+// ConvertStroopsParam replaces an amount param in stroops (ex. `amount_stroops`) with its decimal
+// counterpart (ex. `amount`), so the amount is validated and used like a decimal one. It returns an
+// error when both params are sent or the amount in stroops is invalid.
+func ConvertStroopsParam(name string, value *string, stroopsName string, stroops *string) *ErrorResponse {
+	if *stroops == "" {
+		return nil
+	}
+	if *value != "" {
+		return NewInvalidParameterError(stroopsName, *stroops, "Cannot be sent together with "+name+".")
+	}
+
+	converted, ok := AmountFromStroops(*stroops)
+	if !ok {
+		return NewInvalidParameterError(stroopsName, *stroops, "Amount in stroops must be a positive integer of at most 9223372036854775807.")
+	}
+	*value, *stroops = converted, ""
+	return nil
+}
+
+// IsValidTransactionHash returns true if hash is a hex encoded transaction hash
+func IsValidTransactionHash(hash string) bool {
+	decoded, err := hex.DecodeString(hash)
+	return err == nil && len(decoded) == 32
+}
+
+// DecodeHash decodes a 32 bytes hash encoded as hex or as standard base64 with or without padding.
+// Values which are valid hex are always decoded as hex, so a hex value of a wrong length is not
+// retried as base64.
+func DecodeHash(value string) (hash [32]byte, ok bool) {
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		decoded, err = base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			decoded, err = base64.RawStdEncoding.DecodeString(value)
+		}
+	}
+	if err != nil || len(decoded) != len(hash) {
+		return hash, false
+	}
+	copy(hash[:], decoded)
+	return hash, true
 }
@@ -0,0 +1,132 @@
+package protocols
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmountFromStroops(t *testing.T) {
+	for stroops, expected := range map[string]string{
+		"1":                   "0.0000001",
+		"10000000":            "1.0000000",
+		"9223372036854775807": "922337203685.4775807",
+	} {
+		amount, ok := AmountFromStroops(stroops)
+		assert.True(t, ok, stroops)
+		assert.Equal(t, expected, amount, stroops)
+	}
+
+	for _, stroops := range []string{"", "0", "-1", "+1", "01", "1.5", "1e7", " 1", "9223372036854775808", "18446744073709551616"} {
+		_, ok := AmountFromStroops(stroops)
+		assert.False(t, ok, stroops)
+	}
+}
+
+func TestIsValidAssetCode(t *testing.T) {
+	for _, valid := range []string{"X", "USD", "EURT", "ABCDE", "usd", "123456789012"} {
+		assert.True(t, IsValidAssetCode(valid), valid)
+	}
+
+	for _, invalid := range []string{"", "1234567890123", "US-D", "US D", "USD\x00", "ÉUR"} {
+		assert.False(t, IsValidAssetCode(invalid), invalid)
+	}
+}
+
+func TestIsValidPositiveAmount(t *testing.T) {
+	for _, valid := range []string{"1", "10.5", "0.0000001", "007", "922337203685.4775807"} {
+		assert.True(t, IsValidPositiveAmount(valid), valid)
+	}
+
+	for _, invalid := range []string{"", "0", "0.0000000", "-1", "+1", "1e3", "10,5", "1,000", "1.", ".5", "1.12345678", " 1", "922337203685.4775808", "test"} {
+		assert.False(t, IsValidPositiveAmount(invalid), invalid)
+	}
+}
+
+func TestParsePositiveAmount(t *testing.T) {
+	value, ok := ParsePositiveAmount("10.5")
+	assert.True(t, ok)
+	assert.Equal(t, xdr.Int64(105000000), value)
+
+	value, ok = ParsePositiveAmount(MaxAmount)
+	assert.True(t, ok)
+	assert.Equal(t, xdr.Int64(math.MaxInt64), value)
+
+	// Values amount.Parse would accept or read differently
+	for _, invalid := range []string{"10,50", "1e3", "0x10", "1/2", " 5 ", "5\n", "-0.5", "99999999999999"} {
+		_, ok := ParsePositiveAmount(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestIsValidAmount(t *testing.T) {
+	for _, valid := range []string{"0", "0.0000000", "10.5", MaxAmount} {
+		assert.True(t, IsValidAmount(valid), valid)
+	}
+
+	for _, invalid := range []string{"", "-1", "1e3", "0x10", "1/2", "10,5", " 1"} {
+		assert.False(t, IsValidAmount(invalid), invalid)
+	}
+}
+
+func TestConvertStroopsParam(t *testing.T) {
+	value, stroops := "", "9223372036854775807"
+	assert.Nil(t, ConvertStroopsParam("amount", &value, "amount_stroops", &stroops))
+	assert.Equal(t, "922337203685.4775807", value)
+	assert.Equal(t, "", stroops)
+
+	// Decimal amounts are kept
+	value, stroops = "20", ""
+	assert.Nil(t, ConvertStroopsParam("amount", &value, "amount_stroops", &stroops))
+	assert.Equal(t, "20", value)
+
+	value, stroops = "20", "200000000"
+	err := ConvertStroopsParam("amount", &value, "amount_stroops", &stroops)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "amount_stroops", err.Data["name"])
+		assert.Equal(t, "20", value)
+	}
+
+	value, stroops = "", "0"
+	err = ConvertStroopsParam("send_max", &value, "send_max_stroops", &stroops)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "send_max_stroops", err.Data["name"])
+		assert.Equal(t, "", value)
+	}
+}
+
+func TestDecodeHash(t *testing.T) {
+	expected := [32]byte{}
+	for i := range expected {
+		expected[i] = byte(i)
+	}
+
+	for _, value := range []string{
+		// Also valid base64 of 48 bytes
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+		"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=",
+		"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8",
+	} {
+		hash, ok := DecodeHash(value)
+		assert.True(t, ok, value)
+		assert.Equal(t, expected, hash, value)
+	}
+
+	for _, value := range []string{
+		"",
+		// 31 bytes
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e",
+		"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHg==",
+		// 33 bytes
+		"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8g",
+		// Valid hex of 24 bytes is not decoded as base64 of 36 bytes
+		"000102030405060708090a0b0c0d0e0f1011121314151617",
+		"AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=!",
+	} {
+		_, ok := DecodeHash(value)
+		assert.False(t, ok, value)
+	}
+}
@@ -0,0 +1,245 @@
+// Package queue sends payments queued by /payment with `queue=true`. Queued payments are stored
+// in the DB and sent in the order they were queued by a Worker running on the leader replica.
+// Payments that cannot be sent because Horizon is unreachable stay queued and are retried.
+package queue
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/leader"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+)
+
+const (
+	// DefaultInterval is the time between attempts while queued payments cannot be sent
+	DefaultInterval = 10 * time.Second
+	// batchSize is a number of queued payments loaded at once
+	batchSize = 50
+)
+
+// SettledEvent is the event of callbacks sent when a queued payment is settled
+const SettledEvent = "queued_payment_settled"
+
+// Result is the response of an attempt to send a queued payment
+type Result struct {
+	Status int
+	Body   []byte
+}
+
+// Retryable returns true when the payment has not been sent because the bridge or a dependency
+// is unavailable (5xx responses) or rate limited, the payment stays queued
+func (r Result) Retryable() bool {
+	return r.Status == http.StatusTooManyRequests || r.Status >= http.StatusInternalServerError
+}
+
+// code returns the error code of a response body, empty when it's not an error response
+func (r Result) code() string {
+	var body struct {
+		Code string `json:"code"`
+	}
+	json.Unmarshal(r.Body, &body)
+	return body.Code
+}
+
+// Sender sends a queued payment and returns the response
+type Sender func(payment *entities.QueuedPayment) Result
+
+// Worker sends queued payments in order. A payment with a retryable response stops the run, the
+// following payments wait until it's sent so they are sent in order. Sequence numbers are
+// assigned by Sender when a payment is sent, a failed payment does not leave a gap.
+type Worker struct {
+	// Interval is the time between runs, runs are also started by Wake
+	Interval time.Duration
+	// MaxAttempts settles a payment with its last retryable response after a number of attempts,
+	// payments are retried until they are sent when it's 0
+	MaxAttempts int
+	// Callback is called when a payment is settled
+	Callback string
+	// MACKey signs callback bodies like other callbacks
+	MACKey   string
+	Webhooks *webhook.Client
+	// Elector sends payments only on the leader, nil sends them on every replica
+	Elector *leader.Elector
+
+	send          Sender
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	log           *logrus.Entry
+	now           func() time.Time
+
+	wake chan struct{}
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewWorker creates a new Worker sending payments with send
+func NewWorker(
+	send Sender,
+	repository db.RepositoryInterface,
+	entityManager db.EntityManagerInterface,
+	now func() time.Time,
+) *Worker {
+	return &Worker{
+		Interval:      DefaultInterval,
+		send:          send,
+		repository:    repository,
+		entityManager: entityManager,
+		log:           logrus.WithFields(logrus.Fields{"service": "PaymentQueue"}),
+		now:           now,
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Run sends queued payments in the background until Stop is called
+func (w *Worker) Run() {
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+		for {
+			if err := w.process(); err != nil {
+				w.log.WithFields(logrus.Fields{"err": err}).Error("Error sending queued payments")
+			}
+			select {
+			case <-ticker.C:
+			case <-w.wake:
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the worker, a payment being sent is finished
+func (w *Worker) Stop() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+// Wake starts a run after a payment has been queued, it does nothing when a run is already
+// pending or the worker is disabled
+func (w *Worker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// process sends queued payments until the queue is empty or a payment cannot be sent
+func (w *Worker) process() error {
+	for {
+		payments, err := w.repository.GetQueuedPayments(batchSize)
+		if err != nil {
+			return errors.Wrap(err, "Error loading queued payments")
+		}
+		if len(payments) == 0 {
+			return nil
+		}
+
+		for _, payment := range payments {
+			select {
+			case <-w.stop:
+				return nil
+			default:
+			}
+			// Leadership can be handed off during a run
+			if w.Elector != nil && !w.Elector.IsLeader() {
+				return nil
+			}
+
+			sent, err := w.sendPayment(payment)
+			if err != nil || !sent {
+				return err
+			}
+		}
+	}
+}
+
+// sendPayment makes an attempt to send a payment, it returns false when the payment stays queued
+func (w *Worker) sendPayment(payment *entities.QueuedPayment) (bool, error) {
+	logger := w.log.WithFields(logrus.Fields{"payment_id": payment.PaymentID})
+	result := w.send(payment)
+	now := utc.New(w.now())
+	payment.Attempts++
+	payment.LastAttemptAt = &now
+
+	if result.Retryable() && (w.MaxAttempts == 0 || payment.Attempts < w.MaxAttempts) {
+		payment.LastError = result.code()
+		logger.WithFields(logrus.Fields{"status": result.Status, "code": payment.LastError, "attempts": payment.Attempts}).Warn("Queued payment cannot be sent, retrying later")
+		return false, errors.Wrap(w.entityManager.Persist(payment), "Error saving queued payment")
+	}
+
+	payment.Settle(result.Status, result.Body, now)
+	err := w.entityManager.Persist(payment)
+	if err != nil {
+		return false, errors.Wrap(err, "Error saving queued payment")
+	}
+	logger.WithFields(logrus.Fields{"status": payment.Status, "response_status": result.Status, "attempts": payment.Attempts}).Info("Queued payment settled")
+
+	// The payment is settled anyway, failed callbacks are not retried
+	err = w.sendCallback(payment)
+	if err != nil {
+		logger.WithFields(logrus.Fields{logging.CategoryField: logging.CategoryCallbacks, "err": err}).Error("Error sending queued payment callback")
+	}
+	return true, nil
+}
+
+// sendCallback posts a queued_payment_settled event to Callback
+func (w *Worker) sendCallback(payment *entities.QueuedPayment) error {
+	if w.Callback == "" {
+		return nil
+	}
+
+	body := url.Values{
+		"event":           {SettledEvent},
+		"id":              {payment.PaymentID},
+		"status":          {string(payment.Status)},
+		"attempts":        {strconv.Itoa(payment.Attempts)},
+		"response_status": {strconv.Itoa(*payment.ResponseStatus)},
+		"response":        {*payment.Response},
+	}.Encode()
+
+	req, err := http.NewRequest("POST", w.Callback, strings.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "configure http request failed")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if w.MACKey != "" {
+		rawkey, err := strkey.Decode(strkey.VersionByteSeed, w.MACKey)
+		if err != nil {
+			return errors.Wrap(err, "invalid MAC key")
+		}
+		macer := hmac.New(sha256.New, rawkey)
+		macer.Write([]byte(body))
+		req.Header.Set("X_PAYLOAD_MAC", base64.StdEncoding.EncodeToString(macer.Sum(nil)))
+	}
+
+	resp, err := w.Webhooks.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "Error sending request to queue callback")
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		responseBody, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("Error response from queue callback: %d %s", resp.StatusCode, responseBody)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package queue
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+	repository := db.NewRepository(driver)
+	entityManager := db.NewEntityManager(driver)
+
+	queued := func(id string) {
+		require.NoError(t, entityManager.Persist(&entities.QueuedPayment{
+			PaymentID: id,
+			Status:    entities.QueuedPaymentStatusQueued,
+			Payload:   "{}",
+			QueuedAt:  utc.Now(),
+		}))
+	}
+	load := func(id string) *entities.QueuedPayment {
+		payment, err := repository.GetQueuedPaymentByPaymentID(id)
+		require.NoError(t, err)
+		require.NotNil(t, payment)
+		return payment
+	}
+
+	var callbacks []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		callbacks = append(callbacks, r.PostForm.Get("id")+":"+r.PostForm.Get("status"))
+		assert.NotEmpty(t, r.Header.Get("X_PAYLOAD_MAC"))
+	}))
+	defer server.Close()
+	webhooks, err := webhook.NewClient(webhook.Settings{})
+	require.NoError(t, err)
+
+	responses := map[string][]Result{}
+	var sent []string
+	worker := NewWorker(func(payment *entities.QueuedPayment) Result {
+		sent = append(sent, payment.PaymentID)
+		result := responses[payment.PaymentID][0]
+		responses[payment.PaymentID] = responses[payment.PaymentID][1:]
+		return result
+	}, repository, entityManager, time.Now)
+	worker.Callback = server.URL
+	worker.MACKey = "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J"
+	worker.Webhooks = webhooks
+
+	queued("first")
+	queued("second")
+	queued("third")
+	unavailable := Result{http.StatusServiceUnavailable, []byte(`{"code":"dependency_unavailable"}`)}
+	responses["first"] = []Result{unavailable, {http.StatusOK, []byte(`{"hash":"6a00"}`)}}
+	responses["second"] = []Result{{http.StatusBadRequest, []byte(`{"code":"payment_underfunded"}`)}}
+	responses["third"] = []Result{unavailable, unavailable}
+
+	t.Run("unavailable payment blocks the queue", func(t *testing.T) {
+		require.NoError(t, worker.process())
+		assert.Equal(t, []string{"first"}, sent)
+		payment := load("first")
+		assert.Equal(t, entities.QueuedPaymentStatusQueued, payment.Status)
+		assert.Equal(t, 1, payment.Attempts)
+		assert.Equal(t, "dependency_unavailable", payment.LastError)
+		assert.NotNil(t, payment.LastAttemptAt)
+		assert.Empty(t, callbacks)
+	})
+
+	t.Run("payments are settled in order", func(t *testing.T) {
+		sent = nil
+		worker.MaxAttempts = 2
+		require.NoError(t, worker.process())
+		assert.Equal(t, []string{"first", "second", "third"}, sent)
+
+		payment := load("first")
+		assert.Equal(t, entities.QueuedPaymentStatusSuccess, payment.Status)
+		assert.Equal(t, 2, payment.Attempts)
+		assert.Equal(t, http.StatusOK, *payment.ResponseStatus)
+		assert.Equal(t, `{"hash":"6a00"}`, *payment.Response)
+		assert.NotNil(t, payment.SettledAt)
+		assert.Equal(t, entities.QueuedPaymentStatusFailed, load("second").Status)
+		assert.Equal(t, entities.QueuedPaymentStatusQueued, load("third").Status)
+		assert.Equal(t, []string{"first:success", "second:failed"}, callbacks)
+	})
+
+	t.Run("payment fails after max attempts", func(t *testing.T) {
+		sent = nil
+		require.NoError(t, worker.process())
+		assert.Equal(t, []string{"third"}, sent)
+		payment := load("third")
+		assert.Equal(t, entities.QueuedPaymentStatusFailed, payment.Status)
+		assert.Equal(t, http.StatusServiceUnavailable, *payment.ResponseStatus)
+
+		payments, err := repository.GetQueuedPayments(batchSize)
+		require.NoError(t, err)
+		assert.Empty(t, payments)
+	})
+
+	// Disabled workers are zero values
+	(&Worker{}).Wake()
+}
@@ -0,0 +1,222 @@
+// Package ratelimit smooths transaction submissions to Horizon with a token bucket. Submissions
+// waiting for a token are served by priority, so bursts of background jobs don't delay payments
+// whose requests are waiting for the response.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/stellar/gateway/utc"
+)
+
+// Priority of a submission, lower values take tokens first
+type Priority int
+
+// Priorities of submissions
+const (
+	// High priority submissions are sent by requests waiting for their response
+	High Priority = iota
+	// Low priority submissions are sent by background jobs (ex. auto conversion)
+	Low
+
+	priorities = 2
+)
+
+var priorityNames = [priorities]string{"high", "low"}
+
+func (p Priority) String() string {
+	return priorityNames[p]
+}
+
+// Settings configure a Limiter
+type Settings struct {
+	// Rate is a number of submissions per second, the limiter is disabled when it's 0
+	Rate float64
+	// Burst is a number of submissions that can be sent at once after a quiet period, 1 when
+	// it's not set
+	Burst int
+}
+
+// Status is returned by /status and /admin/submission-rate endpoints
+type Status struct {
+	Enabled bool    `json:"enabled"`
+	Rate    float64 `json:"rate,omitempty"`
+	Burst   int     `json:"burst,omitempty"`
+	// Tokens is a number of submissions that can be sent now without waiting
+	Tokens float64 `json:"tokens"`
+	// Saturated is true while submissions are waiting for tokens
+	Saturated bool `json:"saturated"`
+	// SaturatedAt is the last time a submission had to wait, nil until then
+	SaturatedAt *utc.Time `json:"saturated_at,omitempty"`
+	// Priorities contain numbers of waiting and sent submissions by priority
+	Priorities map[string]PriorityStatus `json:"priorities"`
+}
+
+// PriorityStatus contains numbers of submissions of a priority since start
+type PriorityStatus struct {
+	Waiting   int   `json:"waiting"`
+	Submitted int64 `json:"submitted"`
+	// Delayed is a number of submissions that waited for a token
+	Delayed        int64   `json:"delayed"`
+	WaitSeconds    float64 `json:"wait_seconds"`
+	MaxWaitSeconds float64 `json:"max_wait_seconds"`
+}
+
+// Limiter is a token bucket refilled at Settings.Rate up to Settings.Burst tokens, every
+// submission takes a token. Nil and zero Limiters are disabled and never wait.
+type Limiter struct {
+	settings Settings
+
+	mutex     sync.Mutex
+	tokens    float64
+	updatedAt time.Time
+	// queues are submissions waiting for a token by priority, oldest first
+	queues [priorities][]*waiter
+	// timer dispatches tokens to waiting submissions, nil when nothing is waiting
+	timer       *time.Timer
+	stats       [priorities]PriorityStatus
+	saturatedAt *utc.Time
+}
+
+type waiter struct {
+	ready chan struct{}
+	since time.Time
+}
+
+// NewLimiter creates a new Limiter with a full bucket. Waits are timed by the system clock.
+func NewLimiter(settings Settings) *Limiter {
+	if settings.Burst <= 0 {
+		settings.Burst = 1
+	}
+	return &Limiter{settings: settings, tokens: float64(settings.Burst), updatedAt: time.Now()}
+}
+
+// Enabled returns false for nil and zero Limiters
+func (l *Limiter) Enabled() bool {
+	return l != nil && l.settings.Rate > 0
+}
+
+// Wait blocks until a submission of priority can be sent and returns the time it waited. A
+// token is taken right away only when no submission of the same or a higher priority is waiting.
+func (l *Limiter) Wait(priority Priority) time.Duration {
+	if !l.Enabled() {
+		return 0
+	}
+
+	l.mutex.Lock()
+	now := time.Now()
+	l.refill(now)
+	if l.tokens >= 1 && l.waitingAhead(priority) == 0 {
+		l.tokens--
+		l.stats[priority].Submitted++
+		l.mutex.Unlock()
+		return 0
+	}
+
+	w := &waiter{ready: make(chan struct{}), since: now}
+	l.queues[priority] = append(l.queues[priority], w)
+	saturatedAt := utc.New(now)
+	l.saturatedAt = &saturatedAt
+	l.schedule()
+	l.mutex.Unlock()
+
+	<-w.ready
+	return time.Since(now)
+}
+
+// Delay estimates how long a submission of priority sent now would wait for a token
+func (l *Limiter) Delay(priority Priority) time.Duration {
+	if !l.Enabled() {
+		return 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.refill(time.Now())
+	missing := float64(l.waitingAhead(priority)+1) - l.tokens
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / l.settings.Rate * float64(time.Second))
+}
+
+// Status returns the state of the bucket and numbers of submissions by priority
+func (l *Limiter) Status() Status {
+	if !l.Enabled() {
+		return Status{Priorities: map[string]PriorityStatus{}}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.refill(time.Now())
+	status := Status{
+		Enabled:     true,
+		Rate:        l.settings.Rate,
+		Burst:       l.settings.Burst,
+		Tokens:      math.Floor(l.tokens*100) / 100,
+		SaturatedAt: l.saturatedAt,
+		Priorities:  map[string]PriorityStatus{},
+	}
+	for p, stats := range l.stats {
+		stats.Waiting = len(l.queues[p])
+		status.Saturated = status.Saturated || stats.Waiting > 0
+		status.Priorities[Priority(p).String()] = stats
+	}
+	return status
+}
+
+// refill adds tokens accumulated since the last update, l.mutex must be locked
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.updatedAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	l.tokens = math.Min(float64(l.settings.Burst), l.tokens+elapsed*l.settings.Rate)
+	l.updatedAt = now
+}
+
+// waitingAhead returns a number of waiting submissions of priority or a higher one
+func (l *Limiter) waitingAhead(priority Priority) int {
+	waiting := 0
+	for p := High; p <= priority; p++ {
+		waiting += len(l.queues[p])
+	}
+	return waiting
+}
+
+// schedule starts the dispatch timer for the next token, l.mutex must be locked
+func (l *Limiter) schedule() {
+	if l.timer != nil || l.waitingAhead(Low) == 0 {
+		return
+	}
+	wait := time.Duration((1 - l.tokens) / l.settings.Rate * float64(time.Second))
+	l.timer = time.AfterFunc(wait, l.dispatch)
+}
+
+// dispatch hands available tokens to waiting submissions, highest priority first
+func (l *Limiter) dispatch() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.timer = nil
+
+	now := time.Now()
+	l.refill(now)
+	for p := range l.queues {
+		for l.tokens >= 1 && len(l.queues[p]) > 0 {
+			w := l.queues[p][0]
+			l.queues[p] = l.queues[p][1:]
+			l.tokens--
+
+			waited := now.Sub(w.since).Seconds()
+			stats := &l.stats[p]
+			stats.Submitted++
+			stats.Delayed++
+			stats.WaitSeconds += waited
+			stats.MaxWaitSeconds = math.Max(stats.MaxWaitSeconds, waited)
+			close(w.ready)
+		}
+	}
+	l.schedule()
+}
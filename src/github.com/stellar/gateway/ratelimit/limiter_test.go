@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitFor waits until l has a number of waiting submissions
+func waitFor(t *testing.T, l *Limiter, waiting int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		total := 0
+		for _, stats := range l.Status().Priorities {
+			total += stats.Waiting
+		}
+		if total == waiting {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d waiting submissions", waiting)
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("burst is sent without waiting", func(t *testing.T) {
+		l := NewLimiter(Settings{Rate: 20, Burst: 3})
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, time.Duration(0), l.Wait(Low))
+		}
+		assert.False(t, l.Status().Saturated)
+		assert.True(t, l.Delay(High) > 0)
+
+		waited := l.Wait(High)
+		assert.True(t, waited > 0, "waited %s", waited)
+		status := l.Status()
+		assert.Equal(t, int64(1), status.Priorities["high"].Delayed)
+		assert.Equal(t, int64(3), status.Priorities["low"].Submitted)
+		assert.NotNil(t, status.SaturatedAt)
+	})
+
+	t.Run("high priority takes tokens first", func(t *testing.T) {
+		l := NewLimiter(Settings{Rate: 10})
+		l.Wait(Low)
+
+		var mutex sync.Mutex
+		var order []Priority
+		var wg sync.WaitGroup
+		send := func(p Priority) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l.Wait(p)
+				mutex.Lock()
+				order = append(order, p)
+				mutex.Unlock()
+			}()
+		}
+		send(Low)
+		waitFor(t, l, 1)
+		send(Low)
+		waitFor(t, l, 2)
+		send(High)
+		waitFor(t, l, 3)
+
+		status := l.Status()
+		assert.True(t, status.Saturated)
+		assert.Equal(t, 1, status.Priorities["high"].Waiting)
+		assert.Equal(t, 2, status.Priorities["low"].Waiting)
+		// The high priority submission is ahead of both low priority ones
+		require.True(t, l.Delay(High) < l.Delay(Low))
+
+		wg.Wait()
+		assert.Equal(t, []Priority{High, Low, Low}, order)
+		assert.False(t, l.Status().Saturated)
+	})
+
+	t.Run("disabled limiter", func(t *testing.T) {
+		var l *Limiter
+		assert.Equal(t, time.Duration(0), l.Wait(Low))
+		assert.False(t, l.Status().Enabled)
+		assert.Equal(t, time.Duration(0), NewLimiter(Settings{}).Delay(High))
+	})
+}
@@ -0,0 +1,812 @@
+package reconciliation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/leader"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/stats"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/gateway/webhook"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// DefaultPageLimit is a number of payments loaded from Horizon in a single request
+	DefaultPageLimit = 200
+	// checkInterval is a time between checks whether the previous day has been reconciled
+	checkInterval = 10 * time.Minute
+	// recordsMargin widens the window bridge records of a day are loaded from, records are
+	// stored a moment after their ledger is closed
+	recordsMargin = time.Hour
+	// rateLimitedWait is used when Horizon responds with 429 without Retry-After
+	rateLimitedWait = 10 * time.Second
+)
+
+// MismatchEvent is the event of callbacks sent when a stored report has mismatches
+const MismatchEvent = "reconciliation_mismatch"
+
+var (
+	// ErrDayNotOver is returned when a day that has not ended is reconciled
+	ErrDayNotOver = errors.New("Only days that have ended can be reconciled")
+	// ErrNotIngested is returned when the last ledger of the day is not in Horizon history yet
+	ErrNotIngested = errors.New("Horizon history does not include the end of the day yet")
+)
+
+// Reconciler generates reconciliation reports of days. Payments of Accounts (and of source
+// accounts of payment operations sent by the bridge during the day) in ledgers closed during the
+// day are matched with SentTransaction and ReceivedPayment records: operations of sent
+// transactions by the transaction hash and operation index, unwrapping fee-bump transactions, and
+// received payments by the operation ID. Transaction source accounts (ex. channel accounts) are
+// never compared. When Hour is set, Run reconciles the previous day once a day.
+type Reconciler struct {
+	// Accounts are configured accounts whose payments are compared
+	Accounts []string
+	// Hour is the UTC hour the previous day is reconciled at by Run
+	Hour      int
+	PageLimit int
+	// Callback is called when a stored report has mismatches
+	Callback string
+	// MACKey signs callback bodies like other callbacks
+	MACKey   string
+	Webhooks *webhook.Client
+	// Elector runs scheduled reconciliations only on the leader, nil runs them on every replica
+	Elector *leader.Elector
+
+	horizon       horizon.HorizonInterface
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	log           *logrus.Entry
+	now           func() time.Time
+	sleep         func(time.Duration)
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewReconciler creates a new Reconciler
+func NewReconciler(
+	accounts []string,
+	horizon horizon.HorizonInterface,
+	repository db.RepositoryInterface,
+	entityManager db.EntityManagerInterface,
+	now func() time.Time,
+) *Reconciler {
+	return &Reconciler{
+		Accounts:      accounts,
+		PageLimit:     DefaultPageLimit,
+		horizon:       horizon,
+		repository:    repository,
+		entityManager: entityManager,
+		log:           logrus.WithFields(logrus.Fields{"service": "Reconciler"}),
+		now:           now,
+		sleep:         time.Sleep,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Run reconciles the previous day in the background after Hour, when it has not been reconciled
+// yet. Failed reconciliations are retried every 10 minutes.
+func (r *Reconciler) Run() {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			r.runScheduled()
+			select {
+			case <-ticker.C:
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops scheduled reconciliations, a reconciliation in progress is finished
+func (r *Reconciler) Stop() {
+	r.once.Do(func() { close(r.stop) })
+}
+
+func (r *Reconciler) runScheduled() {
+	if r.Elector != nil && !r.Elector.IsLeader() {
+		return
+	}
+
+	now := r.now().UTC()
+	if now.Hour() < r.Hour {
+		return
+	}
+
+	date := stats.Date(now.AddDate(0, 0, -1))
+	log := r.log.WithField("date", date)
+	existing, err := r.repository.GetReconciliationByDate(date)
+	if err != nil {
+		log.WithFields(logrus.Fields{"err": err}).Error("Error loading reconciliation")
+		return
+	}
+	if existing != nil {
+		return
+	}
+
+	report, err := r.Reconcile(date)
+	if err == ErrNotIngested {
+		log.Warn(err.Error())
+		return
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{"err": err}).Error("Error reconciling day")
+		return
+	}
+
+	err = r.Store(report)
+	if err != nil {
+		log.WithFields(logrus.Fields{"err": err}).Error("Error storing reconciliation")
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"matched":           report.Totals.Matched,
+		"missing_in_bridge": report.Totals.MissingInBridge,
+		"missing_on_chain":  report.Totals.MissingOnChain,
+		"amount_mismatch":   report.Totals.AmountMismatch,
+	}).Info("Day reconciled")
+}
+
+// Store stores a report replacing the previous report of the day and calls Callback when it has
+// mismatches
+func (r *Reconciler) Store(report *Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	reconciliation, err := r.repository.GetReconciliationByDate(report.Date)
+	if err != nil {
+		return err
+	}
+	if reconciliation == nil {
+		reconciliation = &entities.Reconciliation{Date: report.Date}
+	}
+	reconciliation.Mismatches = report.Totals.Mismatches()
+	reconciliation.Report = string(body)
+	reconciliation.CreatedAt = report.GeneratedAt
+
+	err = r.entityManager.Persist(reconciliation)
+	if err != nil {
+		return err
+	}
+
+	if reconciliation.Mismatches > 0 {
+		err = r.sendCallback(report)
+		if err != nil {
+			return errors.Wrap(err, "Report stored, error sending reconciliation callback")
+		}
+	}
+	return nil
+}
+
+// Reconcile generates a report of a UTC date (YYYY-MM-DD) that has ended
+func (r *Reconciler) Reconcile(date string) (*Report, error) {
+	from, err := time.Parse(stats.DateFormat, date)
+	if err != nil {
+		return nil, errors.New("Date must be YYYY-MM-DD")
+	}
+	to := from.Add(24 * time.Hour)
+	if r.now().Before(to) {
+		return nil, ErrDayNotOver
+	}
+
+	var latest uint32
+	err = r.retryRateLimited(func() (err error) {
+		latest, err = r.horizon.LoadLatestLedger()
+		return
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error loading latest ledger")
+	}
+
+	fromLedger, err := r.firstLedgerClosedAt(from, latest)
+	if err != nil {
+		return nil, err
+	}
+	// The first ledger of the next day
+	toLedger, err := r.firstLedgerClosedAt(to, latest)
+	if err != nil {
+		return nil, err
+	}
+	if toLedger > latest {
+		return nil, ErrNotIngested
+	}
+
+	d := &day{
+		reconciler:   r,
+		fromLedger:   fromLedger,
+		toLedger:     toLedger,
+		sent:         map[string]*sentRecord{},
+		received:     map[string]*entities.ReceivedPayment{},
+		seenOps:      map[string]bool{},
+		seenSent:     map[sentOperation]bool{},
+		seenReceived: map[int64]bool{},
+		innerHashes:  map[string]string{},
+		senders:      map[string]bool{},
+	}
+	err = d.loadRecords(from.Add(-recordsMargin), to.Add(recordsMargin))
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := map[string]bool{}
+	for _, account := range r.Accounts {
+		accounts[account] = true
+	}
+	for sender := range d.senders {
+		accounts[sender] = true
+	}
+
+	report := &Report{
+		Date:       date,
+		From:       utc.New(from),
+		To:         utc.New(to),
+		FromLedger: fromLedger,
+		ToLedger:   toLedger - 1,
+		Accounts:   []string{},
+	}
+	for account := range accounts {
+		report.Accounts = append(report.Accounts, account)
+	}
+	sort.Strings(report.Accounts)
+
+	for _, account := range report.Accounts {
+		err = d.matchPayments(account)
+		if err != nil {
+			return nil, err
+		}
+	}
+	d.addMissingOnChain()
+
+	sort.SliceStable(d.entries, func(i, j int) bool {
+		a, b := d.entries[i], d.entries[j]
+		if a.Ledger != b.Ledger {
+			return a.Ledger < b.Ledger
+		}
+		if a.TransactionHash != b.TransactionHash {
+			return a.TransactionHash < b.TransactionHash
+		}
+		if a.OperationIndex != b.OperationIndex {
+			return a.OperationIndex < b.OperationIndex
+		}
+		return a.OperationID < b.OperationID
+	})
+
+	report.Entries = d.entries
+	if report.Entries == nil {
+		report.Entries = []Entry{}
+	}
+	for _, entry := range report.Entries {
+		report.Totals.add(entry.Status)
+	}
+	report.GeneratedAt = utc.New(r.now())
+	return report, nil
+}
+
+// firstLedgerClosedAt returns the first ledger closed at or after t, latest+1 when the latest
+// ledger was closed before t. Ledgers missing in Horizon history are older than the history.
+func (r *Reconciler) firstLedgerClosedAt(t time.Time, latest uint32) (uint32, error) {
+	low, high := uint32(1), latest+1
+	for low < high {
+		middle := low + (high-low)/2
+
+		var ledger horizon.LedgerResponse
+		err := r.retryRateLimited(func() (err error) {
+			ledger, err = r.horizon.LoadLedger(middle)
+			return
+		})
+		if statusErr, ok := err.(*horizon.StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+			low = middle + 1
+			continue
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "Error loading ledger")
+		}
+
+		if ledger.ClosedAt.Time().Before(t) {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+	return low, nil
+}
+
+// retryRateLimited calls a Horizon request again until it's not rate limited
+func (r *Reconciler) retryRateLimited(call func() error) error {
+	for {
+		err := call()
+		rateLimited, ok := err.(*horizon.RateLimitedError)
+		if !ok {
+			return err
+		}
+
+		wait := rateLimited.RetryAfter
+		if wait <= 0 {
+			wait = rateLimitedWait
+		}
+		r.log.WithFields(logrus.Fields{"wait": wait}).Warn("Horizon rate limit exceeded, waiting")
+		r.sleep(wait)
+	}
+}
+
+// sentRecord is a sent transaction with its decoded envelope
+type sentRecord struct {
+	transaction *entities.SentTransaction
+	envelope    xdr.TransactionEnvelope
+}
+
+// sentOperation identifies an operation of a sent transaction
+type sentOperation struct {
+	hash  string
+	index int
+}
+
+// day is the state of a single Reconcile call
+type day struct {
+	reconciler           *Reconciler
+	fromLedger, toLedger uint32
+
+	// sent and received are records of ledgers of the day by transaction hash and operation ID
+	sent     map[string]*sentRecord
+	received map[string]*entities.ReceivedPayment
+
+	seenOps      map[string]bool
+	seenSent     map[sentOperation]bool
+	seenReceived map[int64]bool
+	// innerHashes are hashes of transactions wrapped by fee-bump transactions
+	innerHashes map[string]string
+	// senders are source accounts of payment operations of sent transactions
+	senders map[string]bool
+
+	entries []Entry
+}
+
+func (d *day) inDay(ledger uint32) bool {
+	return ledger >= d.fromLedger && ledger < d.toLedger
+}
+
+// loadRecords loads records stored in [from, to) of ledgers of the day
+func (d *day) loadRecords(from, to time.Time) error {
+	transactions, err := d.reconciler.repository.GetSentTransactionsSucceededBetween(from, to)
+	if err != nil {
+		return errors.Wrap(err, "Error loading sent transactions")
+	}
+	for _, transaction := range transactions {
+		if transaction.Ledger == nil || !d.inDay(uint32(*transaction.Ledger)) {
+			continue
+		}
+		record, err := newSentRecord(transaction)
+		if err != nil {
+			return err
+		}
+		d.sent[transaction.TransactionID] = record
+
+		for i := range record.envelope.Tx.Operations {
+			if expected, ok := record.payment(i); ok {
+				d.senders[expected.from] = true
+			}
+		}
+	}
+
+	payments, err := d.reconciler.repository.GetReceivedPaymentsProcessedBetween(from, to)
+	if err != nil {
+		return errors.Wrap(err, "Error loading received payments")
+	}
+	for _, payment := range payments {
+		ledger, err := horizon.LedgerFromPagingToken(payment.OperationID)
+		if err != nil || !d.inDay(ledger) {
+			continue
+		}
+		d.received[payment.OperationID] = payment
+	}
+	return nil
+}
+
+// matchPayments pages through payments of an account in ledgers of the day
+func (d *day) matchPayments(account string) error {
+	r := d.reconciler
+	cursor := horizon.PagingTokenFromLedger(d.fromLedger)
+	for {
+		var page horizon.PaymentsPage
+		err := r.retryRateLimited(func() (err error) {
+			page, err = r.horizon.LoadPayments(account, cursor, r.PageLimit)
+			return
+		})
+		if err != nil {
+			return errors.Wrap(err, "Error loading payments of "+account)
+		}
+
+		for _, payment := range page.Embedded.Records {
+			ledger, err := horizon.LedgerFromPagingToken(payment.PagingToken)
+			if err != nil {
+				return errors.Wrap(err, "Invalid paging token of operation "+payment.ID)
+			}
+			if ledger >= d.toLedger {
+				return nil
+			}
+			cursor = payment.PagingToken
+
+			if d.seenOps[payment.ID] {
+				continue
+			}
+			d.seenOps[payment.ID] = true
+
+			err = d.match(payment, ledger)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(page.Embedded.Records) < r.PageLimit {
+			return nil
+		}
+	}
+}
+
+// match adds an entry of an operation on chain
+func (d *day) match(payment horizon.PaymentResponse, ledger uint32) error {
+	r := d.reconciler
+	id, err := strconv.ParseInt(payment.ID, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "Invalid operation ID "+payment.ID)
+	}
+
+	hash := payment.TransactionHash
+	if hash == "" {
+		// Old Horizon versions send only the transaction link
+		hash = payment.Links.Transaction.Href[strings.LastIndex(payment.Links.Transaction.Href, "/")+1:]
+	}
+
+	entry := Entry{
+		OperationID:     payment.ID,
+		TransactionHash: hash,
+		// The lowest 12 bits of operation IDs are the operation order starting at 1
+		OperationIndex: int(id&0xfff) - 1,
+		Ledger:         ledger,
+		Type:           payment.Type,
+	}
+	entry.From, entry.To = participants(payment)
+	entry.AssetCode, entry.AssetIssuer, entry.Amount = chainAsset(payment)
+
+	sent := d.sent[hash]
+	if sent == nil && d.senders[entry.From] {
+		inner, err := d.innerHash(hash)
+		if err != nil {
+			return err
+		}
+		if inner != hash {
+			entry.FeeBumpHash = hash
+			entry.TransactionHash = inner
+			sent = d.sent[inner]
+		}
+	}
+	if sent == nil {
+		sent, err = d.loadSent(entry.TransactionHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	received := d.received[payment.ID]
+	if received == nil {
+		received, err = r.repository.GetReceivedPaymentByOperationID(id)
+		if err != nil {
+			return errors.Wrap(err, "Error loading received payment")
+		}
+	}
+	if received != nil {
+		d.seenReceived[*received.ID] = true
+	}
+
+	switch {
+	case sent != nil:
+		d.seenSent[sentOperation{entry.TransactionHash, entry.OperationIndex}] = true
+		entry.Kind = KindSent
+		entry.RecordID = sent.transaction.GetID()
+		entry.BridgeStatus = string(sent.transaction.Status)
+		entry.Status = StatusAmountMismatch
+		if expected, ok := sent.payment(entry.OperationIndex); ok {
+			entry.BridgeAsset = expected.asset()
+			if expected.hasAmount {
+				entry.BridgeAmount = amount.String(expected.amount)
+			}
+			if entry.Type == "account_merge" || (entry.BridgeAsset == assetName(entry.AssetCode, entry.AssetIssuer) && sameAmount(entry.Amount, expected.amount)) {
+				entry.Status = StatusMatched
+			}
+		}
+	case received != nil:
+		entry.Kind = KindReceived
+		entry.RecordID = received.GetID()
+		entry.BridgeStatus = received.Status
+		entry.BridgeAmount = received.Amount
+		entry.Status = StatusMatched
+		// Payments received before assets and amounts were stored are not compared
+		if received.AssetCode != "" {
+			entry.BridgeAsset = assetName(received.AssetCode, received.AssetIssuer)
+			bridgeAmount, err := amount.Parse(received.Amount)
+			if entry.BridgeAsset != assetName(entry.AssetCode, entry.AssetIssuer) || err != nil || !sameAmount(entry.Amount, bridgeAmount) {
+				entry.Status = StatusAmountMismatch
+			}
+		}
+	default:
+		entry.Status = StatusMissingInBridge
+	}
+
+	d.entries = append(d.entries, entry)
+	return nil
+}
+
+// innerHash returns the hash of a transaction wrapped by a fee-bump transaction, the hash of
+// other transactions
+func (d *day) innerHash(hash string) (string, error) {
+	if inner, ok := d.innerHashes[hash]; ok {
+		return inner, nil
+	}
+
+	r := d.reconciler
+	var transaction horizon.TransactionResponse
+	err := r.retryRateLimited(func() (err error) {
+		transaction, err = r.horizon.LoadTransaction(hash)
+		return
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Error loading transaction "+hash)
+	}
+
+	d.innerHashes[hash] = transaction.InnerHash()
+	return d.innerHashes[hash], nil
+}
+
+// loadSent loads a transaction stored outside of the records window, ex. a transaction resumed by
+// an idempotent payment
+func (d *day) loadSent(hash string) (*sentRecord, error) {
+	transaction, err := d.reconciler.repository.GetSentTransactionByHash(hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error loading sent transaction")
+	}
+	if transaction == nil {
+		return nil, nil
+	}
+	return newSentRecord(transaction)
+}
+
+// addMissingOnChain adds entries of records of the day not matched with operations on chain
+func (d *day) addMissingOnChain() {
+	for hash, record := range d.sent {
+		for i := range record.envelope.Tx.Operations {
+			expected, ok := record.payment(i)
+			if !ok || d.seenSent[sentOperation{hash, i}] {
+				continue
+			}
+			entry := Entry{
+				Status:          StatusMissingOnChain,
+				Kind:            KindSent,
+				TransactionHash: hash,
+				OperationIndex:  i,
+				Ledger:          uint32(*record.transaction.Ledger),
+				Type:            expected.operationType,
+				From:            expected.from,
+				To:              expected.to,
+				BridgeAsset:     expected.asset(),
+				BridgeStatus:    string(record.transaction.Status),
+				RecordID:        record.transaction.GetID(),
+			}
+			if expected.hasAmount {
+				entry.BridgeAmount = amount.String(expected.amount)
+			}
+			d.entries = append(d.entries, entry)
+		}
+	}
+
+	for operationID, payment := range d.received {
+		if d.seenReceived[*payment.ID] {
+			continue
+		}
+		ledger, _ := horizon.LedgerFromPagingToken(operationID)
+		entry := Entry{
+			Status:       StatusMissingOnChain,
+			Kind:         KindReceived,
+			OperationID:  operationID,
+			Ledger:       ledger,
+			BridgeAmount: payment.Amount,
+			BridgeStatus: payment.Status,
+			RecordID:     payment.GetID(),
+		}
+		if payment.AssetCode != "" {
+			entry.BridgeAsset = assetName(payment.AssetCode, payment.AssetIssuer)
+		}
+		d.entries = append(d.entries, entry)
+	}
+}
+
+func newSentRecord(transaction *entities.SentTransaction) (*sentRecord, error) {
+	record := &sentRecord{transaction: transaction}
+	err := xdr.SafeUnmarshalBase64(transaction.EnvelopeXdr, &record.envelope)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid envelope of sent transaction "+transaction.TransactionID)
+	}
+	return record, nil
+}
+
+// expectedPayment is a payment operation of a sent transaction
+type expectedPayment struct {
+	operationType string
+	from, to      string
+	code, issuer  string
+	amount        xdr.Int64
+	// hasAmount is false for account merges
+	hasAmount bool
+}
+
+func (e expectedPayment) asset() string {
+	if e.code == "" {
+		return ""
+	}
+	return assetName(e.code, e.issuer)
+}
+
+// payment returns the payment operation at index, false when it's not a payment
+func (record *sentRecord) payment(index int) (expectedPayment, bool) {
+	operations := record.envelope.Tx.Operations
+	if index < 0 || index >= len(operations) {
+		return expectedPayment{}, false
+	}
+
+	operation := operations[index]
+	expected := expectedPayment{from: record.envelope.Tx.SourceAccount.Address(), hasAmount: true}
+	if operation.SourceAccount != nil {
+		expected.from = operation.SourceAccount.Address()
+	}
+
+	var asset xdr.Asset
+	switch operation.Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		op := operation.Body.CreateAccountOp
+		expected.operationType = "create_account"
+		expected.to = op.Destination.Address()
+		expected.code = "XLM"
+		expected.amount = op.StartingBalance
+		return expected, true
+	case xdr.OperationTypePayment:
+		op := operation.Body.PaymentOp
+		expected.operationType = "payment"
+		expected.to = op.Destination.Address()
+		asset = op.Asset
+		expected.amount = op.Amount
+	case xdr.OperationTypePathPayment:
+		op := operation.Body.PathPaymentOp
+		expected.operationType = "path_payment"
+		expected.to = op.Destination.Address()
+		asset = op.DestAsset
+		expected.amount = op.DestAmount
+	case xdr.OperationTypeAccountMerge:
+		expected.operationType = "account_merge"
+		expected.to = operation.Body.Destination.Address()
+		expected.hasAmount = false
+		return expected, true
+	default:
+		return expectedPayment{}, false
+	}
+
+	var assetType xdr.AssetType
+	err := asset.Extract(&assetType, &expected.code, &expected.issuer)
+	if err != nil {
+		return expectedPayment{}, false
+	}
+	if assetType == xdr.AssetTypeAssetTypeNative {
+		expected.code = "XLM"
+	}
+	return expected, true
+}
+
+// participants returns the sender and the receiver of an operation
+func participants(payment horizon.PaymentResponse) (from, to string) {
+	switch payment.Type {
+	case "create_account":
+		return payment.Funder, payment.Account
+	case "account_merge":
+		return payment.Account, payment.Into
+	default:
+		return payment.From, payment.To
+	}
+}
+
+// chainAsset returns the asset and the amount delivered by an operation, account merges have no
+// asset and amount
+func chainAsset(payment horizon.PaymentResponse) (code, issuer, value string) {
+	switch payment.Type {
+	case "create_account":
+		return "XLM", "", payment.StartingBalance
+	case "account_merge":
+		return "", "", ""
+	}
+	if payment.AssetType == "native" {
+		return "XLM", "", payment.Amount
+	}
+	return payment.AssetCode, payment.AssetIssuer, payment.Amount
+}
+
+func sameAmount(value string, expected xdr.Int64) bool {
+	parsed, err := amount.Parse(value)
+	return err == nil && parsed == expected
+}
+
+func assetName(code, issuer string) string {
+	if issuer == "" {
+		return code
+	}
+	return code + ":" + issuer
+}
+
+// sendCallback posts a reconciliation_mismatch event to Callback
+func (r *Reconciler) sendCallback(report *Report) error {
+	if r.Callback == "" {
+		return nil
+	}
+
+	body := url.Values{
+		"event":             {MismatchEvent},
+		"date":              {report.Date},
+		"matched":           {strconv.FormatInt(report.Totals.Matched, 10)},
+		"missing_in_bridge": {strconv.FormatInt(report.Totals.MissingInBridge, 10)},
+		"missing_on_chain":  {strconv.FormatInt(report.Totals.MissingOnChain, 10)},
+		"amount_mismatch":   {strconv.FormatInt(report.Totals.AmountMismatch, 10)},
+	}.Encode()
+
+	req, err := http.NewRequest("POST", r.Callback, strings.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "configure http request failed")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if r.MACKey != "" {
+		rawkey, err := strkey.Decode(strkey.VersionByteSeed, r.MACKey)
+		if err != nil {
+			return errors.Wrap(err, "invalid MAC key")
+		}
+		macer := hmac.New(sha256.New, rawkey)
+		macer.Write([]byte(body))
+		req.Header.Set("X_PAYLOAD_MAC", base64.StdEncoding.EncodeToString(macer.Sum(nil)))
+	}
+
+	resp, err := r.Webhooks.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "Error sending request to reconciliation callback")
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		responseBody, _ := ioutil.ReadAll(resp.Body)
+		r.log.WithFields(logrus.Fields{
+			logging.CategoryField: logging.CategoryCallbacks,
+			"status":              resp.StatusCode,
+			"body":                string(responseBody),
+		}).Error("Error response from reconciliation callback")
+		return errors.New("Error response from reconciliation callback")
+	}
+	return nil
+}
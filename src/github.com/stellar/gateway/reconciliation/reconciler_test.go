@@ -0,0 +1,289 @@
+package reconciliation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/drivers/sqlite"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dayStart is the start of the reconciled day, ledger n is closed at dayStart + (n-4) hours
+var dayStart = time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func ledgerClosedAt(sequence uint32) time.Time {
+	return dayStart.Add(time.Duration(int(sequence)-4) * time.Hour)
+}
+
+func operationID(ledger uint32, index int) string {
+	return strconv.FormatInt(int64(ledger)<<32|1<<12|int64(index+1), 10)
+}
+
+func randomAddress(t *testing.T) string {
+	kp, err := keypair.Random()
+	require.NoError(t, err)
+	return kp.Address()
+}
+
+func paymentOperation(t *testing.T, source, destination, code, issuer string, units int64) xdr.Operation {
+	var to, issuerID xdr.AccountId
+	require.NoError(t, to.SetAddress(destination))
+
+	var asset xdr.Asset
+	if code == "XLM" {
+		require.NoError(t, asset.SetNative())
+	} else {
+		require.NoError(t, issuerID.SetAddress(issuer))
+		require.NoError(t, asset.SetCredit(code, issuerID))
+	}
+
+	body, err := xdr.NewOperationBody(xdr.OperationTypePayment, xdr.PaymentOp{Destination: to, Asset: asset, Amount: xdr.Int64(units * 1e7)})
+	require.NoError(t, err)
+	operation := xdr.Operation{Body: body}
+	if source != "" {
+		var sourceID xdr.AccountId
+		require.NoError(t, sourceID.SetAddress(source))
+		operation.SourceAccount = &sourceID
+	}
+	return operation
+}
+
+func envelope(t *testing.T, source string, operations ...xdr.Operation) string {
+	var envelope xdr.TransactionEnvelope
+	require.NoError(t, envelope.Tx.SourceAccount.SetAddress(source))
+	envelope.Tx.Operations = operations
+	value, err := xdr.MarshalBase64(envelope)
+	require.NoError(t, err)
+	return value
+}
+
+func TestReconcile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bridge-reconciliation")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	driver := &sqlite.Driver{}
+	require.NoError(t, driver.Init(filepath.Join(dir, "bridge.db")))
+	_, err = driver.MigrateUp("gateway")
+	require.NoError(t, err)
+	repository := db.NewRepository(driver)
+	entityManager := db.NewEntityManager(driver)
+
+	base := randomAddress(t)
+	receiving := randomAddress(t)
+	channel := randomAddress(t)
+	destination := randomAddress(t)
+	issuer := randomAddress(t)
+
+	sent := func(hash string, ledger uint64, envelopeXdr string) {
+		succeededAt := utc.New(ledgerClosedAt(uint32(ledger)).Add(time.Second))
+		require.NoError(t, entityManager.Persist(&entities.SentTransaction{
+			TransactionID: hash,
+			Status:        entities.SentTransactionStatusSuccess,
+			Source:        base,
+			SubmittedAt:   succeededAt,
+			SucceededAt:   &succeededAt,
+			Ledger:        &ledger,
+			EnvelopeXdr:   envelopeXdr,
+		}))
+	}
+	received := func(ledger uint32, amount string) {
+		id := operationID(ledger, 0)
+		require.NoError(t, entityManager.Persist(&entities.ReceivedPayment{
+			OperationID: id,
+			PagingToken: id,
+			ProcessedAt: utc.New(ledgerClosedAt(ledger).Add(time.Second)),
+			Status:      "Success",
+			AssetCode:   "USD",
+			AssetIssuer: issuer,
+			Amount:      amount,
+		}))
+	}
+	payment := func(ledger uint32, hash, from, to, value string) horizon.PaymentResponse {
+		payment := horizon.PaymentResponse{
+			ID:              operationID(ledger, 0),
+			Type:            "payment",
+			PagingToken:     operationID(ledger, 0),
+			TransactionHash: hash,
+			From:            from,
+			To:              to,
+			AssetType:       "credit_alphanum4",
+			AssetCode:       "USD",
+			AssetIssuer:     issuer,
+			Amount:          value,
+		}
+		return payment
+	}
+
+	// Previous day
+	sent("before", 2, envelope(t, base, paymentOperation(t, "", destination, "USD", issuer, 1)))
+	// Matched
+	sent("matched", 5, envelope(t, base, paymentOperation(t, "", destination, "USD", issuer, 10)))
+	// Sent by a channel account and wrapped by a fee-bump transaction
+	sent("channel", 6, envelope(t, channel, paymentOperation(t, base, destination, "XLM", "", 5)))
+	// Amount mismatch
+	sent("mismatch", 7, envelope(t, base, paymentOperation(t, "", destination, "USD", issuer, 3)))
+	// Missing on chain
+	sent("missing", 8, envelope(t, base, paymentOperation(t, "", destination, "USD", issuer, 7)))
+	received(9, "20.0000000")
+	received(11, "1.0000000")
+
+	feeBumped := payment(6, "feebump", base, destination, "5.0000000")
+	feeBumped.AssetType = "native"
+	feeBumped.AssetCode = ""
+	feeBumped.AssetIssuer = ""
+
+	basePayments := horizon.PaymentsPage{}
+	basePayments.Embedded.Records = []horizon.PaymentResponse{
+		payment(5, "matched", base, destination, "10.0000000"),
+		feeBumped,
+		payment(7, "mismatch", base, destination, "4.0000000"),
+		// Next day
+		payment(28, "after", base, destination, "1.0000000"),
+	}
+	receivingPayments := horizon.PaymentsPage{}
+	receivingPayments.Embedded.Records = []horizon.PaymentResponse{
+		payment(9, "received", destination, receiving, "20.0000000"),
+		payment(10, "unknown", destination, receiving, "2.0000000"),
+	}
+
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadLatestLedger").Return(uint32(30), nil)
+	// Ledger 1 is older than Horizon history
+	mockHorizon.On("LoadLedger", uint32(1)).Return(horizon.LedgerResponse{}, &horizon.StatusError{StatusCode: http.StatusNotFound})
+	for sequence := uint32(2); sequence <= 30; sequence++ {
+		mockHorizon.On("LoadLedger", sequence).Return(horizon.LedgerResponse{Sequence: sequence, ClosedAt: utc.New(ledgerClosedAt(sequence))}, nil)
+	}
+	mockHorizon.On("LoadPayments", base, horizon.PagingTokenFromLedger(4), DefaultPageLimit).Return(basePayments, nil)
+	mockHorizon.On("LoadPayments", receiving, horizon.PagingTokenFromLedger(4), DefaultPageLimit).Return(receivingPayments, nil)
+	mockHorizon.On("LoadTransaction", "feebump").Return(horizon.TransactionResponse{
+		Hash:             "feebump",
+		InnerTransaction: &horizon.InnerTransaction{Hash: "channel"},
+	}, nil)
+
+	now := func() time.Time { return dayStart.Add(36 * time.Hour) }
+	reconciler := NewReconciler([]string{base, receiving}, mockHorizon, repository, entityManager, now)
+
+	t.Run("day that has not ended is rejected", func(t *testing.T) {
+		_, err := reconciler.Reconcile("2019-03-02")
+		assert.Equal(t, ErrDayNotOver, err)
+	})
+
+	report, err := reconciler.Reconcile("2019-03-01")
+	require.NoError(t, err)
+
+	t.Run("ledgers of the day are found", func(t *testing.T) {
+		assert.Equal(t, uint32(4), report.FromLedger)
+		assert.Equal(t, uint32(27), report.ToLedger)
+		assert.Equal(t, Totals{Matched: 3, MissingInBridge: 1, MissingOnChain: 2, AmountMismatch: 1}, report.Totals)
+		require.Len(t, report.Entries, 7)
+	})
+
+	entries := map[uint32]Entry{}
+	for _, entry := range report.Entries {
+		entries[entry.Ledger] = entry
+	}
+
+	t.Run("sent payment is matched", func(t *testing.T) {
+		entry := entries[5]
+		assert.Equal(t, StatusMatched, entry.Status)
+		assert.Equal(t, KindSent, entry.Kind)
+		assert.Equal(t, "USD:"+issuer, entry.BridgeAsset)
+		assert.Equal(t, "10.0000000", entry.BridgeAmount)
+		assert.NotNil(t, entry.RecordID)
+	})
+
+	t.Run("fee-bump transaction of a channel account is matched", func(t *testing.T) {
+		entry := entries[6]
+		assert.Equal(t, StatusMatched, entry.Status)
+		assert.Equal(t, "channel", entry.TransactionHash)
+		assert.Equal(t, "feebump", entry.FeeBumpHash)
+		assert.Equal(t, "XLM", entry.BridgeAsset)
+	})
+
+	t.Run("different amount is reported", func(t *testing.T) {
+		entry := entries[7]
+		assert.Equal(t, StatusAmountMismatch, entry.Status)
+		assert.Equal(t, "4.0000000", entry.Amount)
+		assert.Equal(t, "3.0000000", entry.BridgeAmount)
+	})
+
+	t.Run("sent payment missing on chain is reported", func(t *testing.T) {
+		entry := entries[8]
+		assert.Equal(t, StatusMissingOnChain, entry.Status)
+		assert.Equal(t, KindSent, entry.Kind)
+		assert.Equal(t, "missing", entry.TransactionHash)
+		assert.Equal(t, destination, entry.To)
+		assert.Equal(t, "7.0000000", entry.BridgeAmount)
+	})
+
+	t.Run("received payment is matched", func(t *testing.T) {
+		entry := entries[9]
+		assert.Equal(t, StatusMatched, entry.Status)
+		assert.Equal(t, KindReceived, entry.Kind)
+		assert.Equal(t, "Success", entry.BridgeStatus)
+	})
+
+	t.Run("payment missing in bridge is reported", func(t *testing.T) {
+		entry := entries[10]
+		assert.Equal(t, StatusMissingInBridge, entry.Status)
+		assert.Equal(t, "", entry.Kind)
+		assert.Nil(t, entry.RecordID)
+	})
+
+	t.Run("received payment missing on chain is reported", func(t *testing.T) {
+		entry := entries[11]
+		assert.Equal(t, StatusMissingOnChain, entry.Status)
+		assert.Equal(t, KindReceived, entry.Kind)
+		assert.Equal(t, operationID(11, 0), entry.OperationID)
+	})
+
+	t.Run("report is stored", func(t *testing.T) {
+		require.NoError(t, reconciler.Store(report))
+		require.NoError(t, reconciler.Store(report))
+
+		stored, err := repository.GetReconciliationByDate("2019-03-01")
+		require.NoError(t, err)
+		require.NotNil(t, stored)
+		assert.Equal(t, int64(4), stored.Mismatches)
+		assert.Equal(t, int64(1), *stored.ID)
+	})
+
+	t.Run("report is written as CSV", func(t *testing.T) {
+		var buffer bytes.Buffer
+		require.NoError(t, report.WriteCSV(&buffer))
+		rows, err := csv.NewReader(&buffer).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 8)
+		assert.Equal(t, csvHeader, rows[0])
+		assert.Equal(t, []string{StatusMatched, KindSent, operationID(5, 0), "matched", "", "0", "5"}, rows[1][:7])
+	})
+}
+
+func TestReconcileNotIngested(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadLatestLedger").Return(uint32(20), nil)
+	for sequence := uint32(1); sequence <= 20; sequence++ {
+		mockHorizon.On("LoadLedger", sequence).Return(horizon.LedgerResponse{Sequence: sequence, ClosedAt: utc.New(ledgerClosedAt(sequence))}, nil)
+	}
+
+	now := func() time.Time { return dayStart.Add(48 * time.Hour) }
+	reconciler := NewReconciler(nil, mockHorizon, nil, nil, now)
+	_, err := reconciler.Reconcile("2019-03-01")
+	assert.Equal(t, ErrNotIngested, err)
+}
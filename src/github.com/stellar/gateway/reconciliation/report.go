@@ -0,0 +1,137 @@
+// Package reconciliation compares payments recorded by the bridge server (sent transactions and
+// received payments) with Horizon history of configured accounts and reports differences of a day
+package reconciliation
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/stellar/gateway/utc"
+)
+
+const (
+	// StatusMatched is an operation on chain recorded by the bridge with the same asset and amount
+	StatusMatched = "matched"
+	// StatusMissingInBridge is an operation on chain not recorded by the bridge
+	StatusMissingInBridge = "missing_in_bridge"
+	// StatusMissingOnChain is a payment recorded by the bridge not found on chain
+	StatusMissingOnChain = "missing_on_chain"
+	// StatusAmountMismatch is an operation on chain recorded by the bridge with a different asset
+	// or amount
+	StatusAmountMismatch = "amount_mismatch"
+)
+
+const (
+	// KindSent is an operation of a transaction in SentTransaction table
+	KindSent = "sent"
+	// KindReceived is an operation in ReceivedPayment table
+	KindReceived = "received"
+)
+
+// Entry is a single payment operation of a report
+type Entry struct {
+	Status string `json:"status"`
+	// Kind is empty for missing_in_bridge entries
+	Kind string `json:"kind,omitempty"`
+	// OperationID is empty for operations of sent transactions missing on chain
+	OperationID     string `json:"operation_id,omitempty"`
+	TransactionHash string `json:"transaction_hash,omitempty"`
+	// FeeBumpHash is the hash of a fee-bump transaction wrapping the transaction
+	FeeBumpHash    string `json:"fee_bump_hash,omitempty"`
+	OperationIndex int    `json:"operation_index"`
+	Ledger         uint32 `json:"ledger"`
+	Type           string `json:"type,omitempty"`
+	From           string `json:"from,omitempty"`
+	To             string `json:"to,omitempty"`
+	// AssetCode is `XLM` for XLM
+	AssetCode   string `json:"asset_code,omitempty"`
+	AssetIssuer string `json:"asset_issuer,omitempty"`
+	// Amount is the amount on chain, empty for missing_on_chain entries
+	Amount string `json:"amount,omitempty"`
+	// BridgeAmount and BridgeAsset (`code:issuer` or `XLM`) are recorded by the bridge, empty for
+	// missing_in_bridge entries
+	BridgeAmount string `json:"bridge_amount,omitempty"`
+	BridgeAsset  string `json:"bridge_asset,omitempty"`
+	// BridgeStatus is the status of the bridge record, ex. `success` or `Operation sent not
+	// received`
+	BridgeStatus string `json:"bridge_status,omitempty"`
+	// RecordID is the ID of the SentTransaction or ReceivedPayment
+	RecordID *int64 `json:"record_id,omitempty"`
+}
+
+// Totals contain numbers of entries by status
+type Totals struct {
+	Matched         int64 `json:"matched"`
+	MissingInBridge int64 `json:"missing_in_bridge"`
+	MissingOnChain  int64 `json:"missing_on_chain"`
+	AmountMismatch  int64 `json:"amount_mismatch"`
+}
+
+// Mismatches returns the number of entries that are not matched
+func (t Totals) Mismatches() int64 {
+	return t.MissingInBridge + t.MissingOnChain + t.AmountMismatch
+}
+
+func (t *Totals) add(status string) {
+	switch status {
+	case StatusMatched:
+		t.Matched++
+	case StatusMissingInBridge:
+		t.MissingInBridge++
+	case StatusMissingOnChain:
+		t.MissingOnChain++
+	case StatusAmountMismatch:
+		t.AmountMismatch++
+	}
+}
+
+// Report is a reconciliation report of a UTC day
+type Report struct {
+	Date string   `json:"date"`
+	From utc.Time `json:"from"`
+	To   utc.Time `json:"to"`
+	// FromLedger and ToLedger are the first and the last ledger closed during the day
+	FromLedger uint32 `json:"from_ledger"`
+	ToLedger   uint32 `json:"to_ledger"`
+	// Accounts are accounts whose Horizon payments were compared
+	Accounts    []string `json:"accounts"`
+	Totals      Totals   `json:"totals"`
+	Entries     []Entry  `json:"entries"`
+	GeneratedAt utc.Time `json:"generated_at"`
+}
+
+// csvHeader is the first row of a CSV report, a column per Entry field
+var csvHeader = []string{
+	"status", "kind", "operation_id", "transaction_hash", "fee_bump_hash", "operation_index", "ledger",
+	"type", "from", "to", "asset_code", "asset_issuer", "amount", "bridge_amount", "bridge_asset",
+	"bridge_status", "record_id",
+}
+
+// WriteCSV writes entries of the report as CSV with a header row
+func (report *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range report.Entries {
+		recordID := ""
+		if entry.RecordID != nil {
+			recordID = strconv.FormatInt(*entry.RecordID, 10)
+		}
+		err = writer.Write([]string{
+			entry.Status, entry.Kind, entry.OperationID, entry.TransactionHash, entry.FeeBumpHash,
+			strconv.Itoa(entry.OperationIndex), strconv.FormatUint(uint64(entry.Ledger), 10),
+			entry.Type, entry.From, entry.To, entry.AssetCode, entry.AssetIssuer, entry.Amount,
+			entry.BridgeAmount, entry.BridgeAsset, entry.BridgeStatus, recordID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
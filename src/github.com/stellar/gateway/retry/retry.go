@@ -0,0 +1,285 @@
+// Package retry implements retry policies shared by components calling external services
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Names of components with retry policies, keys of `retry` config group
+const (
+	// Submitter resubmits transactions when Horizon responses are lost
+	Submitter = "submitter"
+	// Callbacks retries handling of received payments (receive and compliance callbacks)
+	Callbacks = "callbacks"
+	// Resolver retries federation requests failing with network or server errors
+	Resolver = "resolver"
+	// BadSequence rebuilds /payment transactions failing with tx_bad_seq with a new sequence number
+	BadSequence = "bad_seq"
+	// PaymentCallbacks redelivers `callback` URLs of /payment responding with other status than 200
+	PaymentCallbacks = "payment_callbacks"
+)
+
+// Components are names of all components with retry policies
+var Components = []string{Submitter, Callbacks, Resolver, BadSequence, PaymentCallbacks}
+
+// maxCountedAttempts is a number of attempts above which calls are counted in a single
+// histogram bucket
+const maxCountedAttempts = 10
+
+// RateLimited is implemented by errors of rate limited calls (ex. *horizon.RateLimitedError).
+// These calls are waited for instead of counted as failed attempts.
+type RateLimited interface {
+	// RetryIn returns a time after which the limit is reset, 0 when it's unknown
+	RetryIn() time.Duration
+}
+
+// Settings of a retry policy
+type Settings struct {
+	// MaxAttempts is a number of calls including the first one, 0 retries until success or a
+	// non-retryable error
+	MaxAttempts int
+	// BaseBackoff is a wait after the first attempt, it doubles after every next attempt
+	BaseBackoff time.Duration
+	// MaxBackoff caps the wait, BaseBackoff when it's smaller
+	MaxBackoff time.Duration
+	// Jitter (0 to 1) is a fraction of the wait that is randomized, ex. 0.2 waits between 80% and
+	// 100% of the backoff
+	Jitter float64
+	// MaxRateLimitWait is a total wait for rate limits of a call, rate limited errors are
+	// returned when it would be exceeded
+	MaxRateLimitWait time.Duration
+}
+
+// merge returns s with non-zero fields of overrides
+func (s Settings) merge(overrides Settings) Settings {
+	if overrides.MaxAttempts != 0 {
+		s.MaxAttempts = overrides.MaxAttempts
+	}
+	if overrides.BaseBackoff != 0 {
+		s.BaseBackoff = overrides.BaseBackoff
+	}
+	if overrides.MaxBackoff != 0 {
+		s.MaxBackoff = overrides.MaxBackoff
+	}
+	if overrides.Jitter != 0 {
+		s.Jitter = overrides.Jitter
+	}
+	if overrides.MaxRateLimitWait != 0 {
+		s.MaxRateLimitWait = overrides.MaxRateLimitWait
+	}
+	return s
+}
+
+// Stats is returned by /admin/retry-policies endpoint
+type Stats struct {
+	Name        string `json:"name"`
+	MaxAttempts int    `json:"max_attempts"`
+	// Calls is a number of finished calls of Policy.Do
+	Calls int64 `json:"calls"`
+	// Exhausted is a number of calls that failed with a retryable error after MaxAttempts
+	Exhausted int64 `json:"exhausted"`
+	// Attempts is a histogram of attempts made by calls, calls with more than 10 attempts are
+	// counted in `more` bucket
+	Attempts map[string]int64 `json:"attempts"`
+	// RateLimited is a number of waits for rate limits, they are not counted as attempts
+	RateLimited int64 `json:"rate_limited"`
+}
+
+// Policy retries calls failing with retryable errors with exponential backoff
+type Policy struct {
+	name     string
+	settings Settings
+	sleep    func(time.Duration)
+	random   func() float64
+	log      *logrus.Entry
+
+	mutex       sync.Mutex
+	calls       int64
+	exhausted   int64
+	rateLimited int64
+	attempts    map[string]int64
+}
+
+// NewPolicy creates a new Policy. sleep waits between attempts, time.Sleep outside of tests.
+func NewPolicy(name string, settings Settings, sleep func(time.Duration)) *Policy {
+	return &Policy{
+		name:     name,
+		settings: settings,
+		sleep:    sleep,
+		random:   rand.Float64,
+		log:      logrus.WithFields(logrus.Fields{"service": "Retry", "policy": name}),
+		attempts: make(map[string]int64),
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error that is not retryable or MaxAttempts are
+// made. fn gets a number of the attempt starting with 1. The error of the last attempt is
+// returned. Rate limited calls are repeated after the limit is reset as long as MaxRateLimitWait
+// is not exceeded, they keep the number of the attempt.
+func (p *Policy) Do(fn func(attempt int) error, retryable func(error) bool) error {
+	var err error
+	attempt := 1
+	exhausted := false
+	limited := 0
+	var waited time.Duration
+	for ; ; attempt++ {
+		err = fn(attempt)
+		if rateLimited, ok := err.(RateLimited); ok {
+			wait := rateLimited.RetryIn()
+			if wait <= 0 {
+				wait = p.Backoff(limited + 1)
+			}
+			if waited+wait > p.settings.MaxRateLimitWait {
+				break
+			}
+
+			limited++
+			waited += wait
+			p.countRateLimited()
+			p.log.WithFields(logrus.Fields{"attempt": attempt, "wait": wait}).Info("Waiting for rate limit")
+			p.sleep(wait)
+			attempt--
+			continue
+		}
+		if err == nil || !retryable(err) {
+			break
+		}
+		if p.settings.MaxAttempts > 0 && attempt >= p.settings.MaxAttempts {
+			exhausted = true
+			break
+		}
+
+		backoff := p.Backoff(attempt)
+		p.log.WithFields(logrus.Fields{"attempt": attempt, "backoff": backoff, "err": err}).Info("Retrying")
+		p.sleep(backoff)
+	}
+
+	p.record(attempt, exhausted)
+	return err
+}
+
+// Backoff returns a wait after an attempt
+func (p *Policy) Backoff(attempt int) time.Duration {
+	maxBackoff := p.settings.MaxBackoff
+	if maxBackoff < p.settings.BaseBackoff {
+		maxBackoff = p.settings.BaseBackoff
+	}
+
+	backoff := float64(p.settings.BaseBackoff) * math.Pow(2, float64(attempt-1))
+	if backoff > float64(maxBackoff) {
+		backoff = float64(maxBackoff)
+	}
+	if p.settings.Jitter > 0 {
+		backoff -= backoff * p.settings.Jitter * p.random()
+	}
+	return time.Duration(backoff)
+}
+
+// MaxAttempts returns a number of attempts of a call including the first one, 0 is unlimited
+func (p *Policy) MaxAttempts() int {
+	return p.settings.MaxAttempts
+}
+
+// Record counts a finished call whose attempts were made outside of Do, ex. by a worker storing
+// the time of the next attempt. exhausted is true when the last attempt failed after MaxAttempts.
+func (p *Policy) Record(attempts int, exhausted bool) {
+	p.record(attempts, exhausted)
+}
+
+func (p *Policy) countRateLimited() {
+	p.mutex.Lock()
+	p.rateLimited++
+	p.mutex.Unlock()
+}
+
+func (p *Policy) record(attempts int, exhausted bool) {
+	bucket := "more"
+	if attempts <= maxCountedAttempts {
+		bucket = strconv.Itoa(attempts)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.calls++
+	p.attempts[bucket]++
+	if exhausted {
+		p.exhausted++
+		p.log.WithFields(logrus.Fields{"attempts": attempts}).Warn("Retry attempts exhausted")
+	}
+}
+
+// Stats returns metrics of the policy
+func (p *Policy) Stats() Stats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := Stats{
+		Name:        p.name,
+		MaxAttempts: p.settings.MaxAttempts,
+		Calls:       p.calls,
+		Exhausted:   p.exhausted,
+		Attempts:    make(map[string]int64, len(p.attempts)),
+		RateLimited: p.rateLimited,
+	}
+	for bucket, count := range p.attempts {
+		stats.Attempts[bucket] = count
+	}
+	return stats
+}
+
+// Set contains policies of components, settings configured in `retry` config group override
+// defaults of components
+type Set struct {
+	overrides map[string]Settings
+	sleep     func(time.Duration)
+
+	mutex    sync.Mutex
+	policies map[string]*Policy
+}
+
+// NewSet creates a new Set
+func NewSet(overrides map[string]Settings, sleep func(time.Duration)) *Set {
+	return &Set{
+		overrides: overrides,
+		sleep:     sleep,
+		policies:  make(map[string]*Policy),
+	}
+}
+
+// Get returns a policy of a component. defaults are used for settings not configured for the
+// component when the policy is created.
+func (s *Set) Get(name string, defaults Settings) *Policy {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	p, ok := s.policies[name]
+	if !ok {
+		p = NewPolicy(name, defaults.merge(s.overrides[name]), s.sleep)
+		s.policies[name] = p
+	}
+	return p
+}
+
+// Stats returns metrics of all policies ordered by name
+func (s *Set) Stats() []Stats {
+	s.mutex.Lock()
+	policies := make([]*Policy, 0, len(s.policies))
+	for _, p := range s.policies {
+		policies = append(policies, p)
+	}
+	s.mutex.Unlock()
+
+	stats := make([]Stats, 0, len(policies))
+	for _, p := range policies {
+		stats = append(stats, p.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
@@ -0,0 +1,148 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errRetryable = errors.New("retryable")
+
+func isRetryable(err error) bool {
+	return err == errRetryable
+}
+
+// failing returns fn failing failures times with err
+func failing(failures int, err error, calls *int) func(int) error {
+	return func(attempt int) error {
+		*calls++
+		if attempt != *calls {
+			panic("attempts are not numbered")
+		}
+		if *calls <= failures {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestPolicyDo(t *testing.T) {
+	var waits []time.Duration
+	p := NewPolicy("test", Settings{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: 3 * time.Second}, func(d time.Duration) {
+		waits = append(waits, d)
+	})
+
+	calls := 0
+	assert.NoError(t, p.Do(failing(2, errRetryable, &calls), isRetryable))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+
+	// Exhausted
+	calls, waits = 0, nil
+	assert.Equal(t, errRetryable, p.Do(failing(5, errRetryable, &calls), isRetryable))
+	assert.Equal(t, 3, calls)
+	assert.Len(t, waits, 2)
+
+	// Not retryable
+	calls, waits = 0, nil
+	other := errors.New("other")
+	assert.Equal(t, other, p.Do(failing(5, other, &calls), isRetryable))
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, waits)
+
+	stats := p.Stats()
+	assert.Equal(t, int64(3), stats.Calls)
+	assert.Equal(t, int64(1), stats.Exhausted)
+	assert.Equal(t, map[string]int64{"1": 1, "3": 2}, stats.Attempts)
+}
+
+func TestPolicyUnlimited(t *testing.T) {
+	waits := 0
+	p := NewPolicy("test", Settings{BaseBackoff: 10 * time.Second, MaxBackoff: 10 * time.Second}, func(d time.Duration) {
+		assert.Equal(t, 10*time.Second, d)
+		waits++
+	})
+
+	calls := 0
+	assert.NoError(t, p.Do(failing(20, errRetryable, &calls), isRetryable))
+	assert.Equal(t, 21, calls)
+	assert.Equal(t, 20, waits)
+	assert.Equal(t, map[string]int64{"more": 1}, p.Stats().Attempts)
+	assert.Zero(t, p.Stats().Exhausted)
+}
+
+type rateLimitedError time.Duration
+
+func (e rateLimitedError) Error() string          { return "rate limited" }
+func (e rateLimitedError) RetryIn() time.Duration { return time.Duration(e) }
+
+func TestPolicyRateLimited(t *testing.T) {
+	var waits []time.Duration
+	p := NewPolicy("test", Settings{MaxAttempts: 2, BaseBackoff: time.Second, MaxRateLimitWait: 10 * time.Second}, func(d time.Duration) {
+		waits = append(waits, d)
+	})
+
+	// Rate limited calls keep the number of the attempt
+	var attempts []int
+	errs := []error{rateLimitedError(3 * time.Second), rateLimitedError(0), errRetryable, nil}
+	assert.NoError(t, p.Do(func(attempt int) error {
+		attempts = append(attempts, attempt)
+		err := errs[0]
+		errs = errs[1:]
+		return err
+	}, isRetryable))
+	assert.Equal(t, []int{1, 1, 1, 2}, attempts)
+	assert.Equal(t, []time.Duration{3 * time.Second, time.Second, time.Second}, waits)
+
+	// Reset after MaxRateLimitWait
+	waits = nil
+	limited := rateLimitedError(8 * time.Second)
+	calls := 0
+	assert.Equal(t, limited, p.Do(func(int) error {
+		calls++
+		return limited
+	}, isRetryable))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []time.Duration{8 * time.Second}, waits)
+
+	stats := p.Stats()
+	assert.Equal(t, int64(3), stats.RateLimited)
+	assert.Equal(t, map[string]int64{"1": 1, "2": 1}, stats.Attempts)
+	assert.Zero(t, stats.Exhausted)
+}
+
+func TestPolicyBackoff(t *testing.T) {
+	p := NewPolicy("test", Settings{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}, nil)
+	var backoffs []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		backoffs = append(backoffs, p.Backoff(attempt))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, backoffs)
+
+	// MaxBackoff below BaseBackoff
+	p = NewPolicy("test", Settings{BaseBackoff: 2 * time.Second}, nil)
+	assert.Equal(t, 2*time.Second, p.Backoff(3))
+
+	p = NewPolicy("test", Settings{BaseBackoff: 10 * time.Second, Jitter: 0.2}, nil)
+	p.random = func() float64 { return 1 }
+	assert.Equal(t, 8*time.Second, p.Backoff(1))
+	p.random = func() float64 { return 0 }
+	assert.Equal(t, 10*time.Second, p.Backoff(1))
+}
+
+func TestSet(t *testing.T) {
+	s := NewSet(map[string]Settings{Submitter: {MaxAttempts: 5}}, nil)
+	defaults := Settings{MaxAttempts: 3, BaseBackoff: 2 * time.Second}
+
+	submitter := s.Get(Submitter, defaults)
+	assert.Equal(t, Settings{MaxAttempts: 5, BaseBackoff: 2 * time.Second}, submitter.settings)
+	assert.Equal(t, submitter, s.Get(Submitter, Settings{}))
+	assert.Equal(t, defaults, s.Get(Callbacks, defaults).settings)
+
+	stats := s.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, Callbacks, stats[0].Name)
+	assert.Equal(t, 5, stats[1].MaxAttempts)
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ForwardedForHeader is a header containing addresses of a client and proxies forwarding its
+// request, each proxy appends the address it received the request from
+const ForwardedForHeader = "X-Forwarded-For"
+
+// TrustedProxies are networks of proxies (load balancers) trusted to report client addresses in
+// X-Forwarded-For headers and PROXY protocol headers
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDRs (ex. `10.0.0.0/8`, `fd00::/8`) and IP addresses of trusted
+// proxies
+func ParseTrustedProxies(values []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := parseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address: %s", value)
+			}
+			bits := 8 * len(ip)
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %s", value)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Contains returns true if ip is an address of a trusted proxy
+func (proxies TrustedProxies) Contains(ip net.IP) bool {
+	for _, network := range proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type clientIPContextKey struct{}
+
+// WithClientIP returns a shallow copy of r with a given client IP attached
+func WithClientIP(r *http.Request, ip string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), clientIPContextKey{}, ip))
+}
+
+// ClientIP returns the client IP attached to the request by ClientIPMiddleware, the address of
+// the connection when the middleware is not used
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey{}).(string); ok {
+		return ip
+	}
+	if ip := remoteIP(r); ip != nil {
+		return ip.String()
+	}
+	return r.RemoteAddr
+}
+
+// ClientIPMiddleware attaches the IP of the client to every request. X-Forwarded-For headers are
+// used only when the connection is from a trusted proxy: the client is the last address not
+// belonging to a trusted proxy, so addresses prepended by the client are ignored.
+func ClientIPMiddleware(trusted TrustedProxies) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, WithClientIP(r, clientIP(r, trusted)))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func clientIP(r *http.Request, trusted TrustedProxies) string {
+	ip := remoteIP(r)
+	if ip == nil {
+		return r.RemoteAddr
+	}
+	if !trusted.Contains(ip) {
+		return ip.String()
+	}
+
+	forwarded := []string{}
+	for _, header := range r.Header[http.CanonicalHeaderKey(ForwardedForHeader)] {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := parseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			// Addresses before an invalid one cannot be trusted
+			break
+		}
+		ip = hop
+		if !trusted.Contains(ip) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+// remoteIP returns the IP of the connection of a request
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return parseIP(host)
+}
+
+// parseIP parses an IP address with or without a port, IPv4-mapped IPv6 addresses are returned
+// as IPv4 addresses
+func parseIP(value string) net.IP {
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	// Zones of link-local addresses are not part of the address
+	if i := strings.LastIndex(value, "%"); i >= 0 {
+		value = value[:i]
+	}
+
+	ip := net.ParseIP(value)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "fd00::/8", "192.0.2.1", "2001:db8::1"})
+	require.NoError(t, err)
+	assert.True(t, proxies.Contains(parseIP("10.1.2.3")))
+	assert.True(t, proxies.Contains(parseIP("::ffff:10.1.2.3")))
+	assert.True(t, proxies.Contains(parseIP("fd12::1")))
+	assert.True(t, proxies.Contains(parseIP("192.0.2.1")))
+	assert.False(t, proxies.Contains(parseIP("192.0.2.2")))
+	assert.True(t, proxies.Contains(parseIP("2001:db8::1")))
+	assert.False(t, proxies.Contains(parseIP("2001:db8::2")))
+
+	for _, value := range []string{"10.0.0.0/33", "proxy.example.com", ""} {
+		_, err = ParseTrustedProxies([]string{value})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "fd00::/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		clientIP   string
+	}{
+		{"direct IPv4 client", "198.51.100.7:41000", nil, "198.51.100.7"},
+		{"direct IPv6 client", "[2001:db8::7]:41000", nil, "2001:db8::7"},
+		{"IPv4-mapped client", "[::ffff:198.51.100.7]:41000", nil, "198.51.100.7"},
+		{"untrusted client spoofing header", "198.51.100.7:41000", []string{"203.0.113.9"}, "198.51.100.7"},
+		{"trusted proxy", "10.0.0.2:41000", []string{"203.0.113.9"}, "203.0.113.9"},
+		{"trusted IPv6 proxy", "[fd00::2]:41000", []string{"2001:db8::9"}, "2001:db8::9"},
+		{"client prepending addresses", "10.0.0.2:41000", []string{"192.0.2.66, 203.0.113.9"}, "203.0.113.9"},
+		{"chain of trusted proxies", "10.0.0.2:41000", []string{"203.0.113.9, 10.0.0.3", "fd00::4"}, "203.0.113.9"},
+		{"client prepending trusted address", "10.0.0.2:41000", []string{"10.9.9.9, 203.0.113.9"}, "203.0.113.9"},
+		{"only trusted addresses", "10.0.0.2:41000", []string{"10.0.0.3"}, "10.0.0.3"},
+		{"invalid address", "10.0.0.2:41000", []string{"203.0.113.9, <script>"}, "10.0.0.2"},
+		{"address with port", "10.0.0.2:41000", []string{"[2001:db8::9]:443"}, "2001:db8::9"},
+		{"trusted proxy without header", "10.0.0.2:41000", nil, "10.0.0.2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clientIP string
+			handler := ClientIPMiddleware(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				clientIP = ClientIP(r)
+			}))
+
+			request := httptest.NewRequest("GET", "/status", nil)
+			request.RemoteAddr = test.remoteAddr
+			for _, header := range test.forwarded {
+				request.Header.Add(ForwardedForHeader, header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+			assert.Equal(t, test.clientIP, clientIP)
+		})
+	}
+
+	// The connection address is used without the middleware
+	request := httptest.NewRequest("GET", "/status", nil)
+	request.RemoteAddr = "[2001:db8::7]:41000"
+	request.Header.Set(ForwardedForHeader, "203.0.113.9")
+	assert.Equal(t, "2001:db8::7", ClientIP(request))
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+)
+
+const (
+	// CorrelationIDParam is a param containing an ID tracing a request across services
+	CorrelationIDParam = "correlation_id"
+	// CorrelationIDHeader is used when CorrelationIDParam is not sent
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// maxCorrelationIDLength is a maximum length of a correlation ID
+const maxCorrelationIDLength = 128
+
+var validCorrelationID = regexp.MustCompile(`^[\x20-\x7e]+$`)
+
+// ErrInvalidCorrelationID is returned by CorrelationID for IDs that are too long or contain
+// characters other than printable ASCII
+var ErrInvalidCorrelationID = errors.New("correlation_id must be printable ASCII of at most 128 characters")
+
+// CorrelationID returns `correlation_id` param or X-Correlation-ID header of r, the ID of the
+// request when neither is sent. An invalid ID is returned with ErrInvalidCorrelationID.
+func CorrelationID(r *http.Request) (string, error) {
+	id := r.FormValue(CorrelationIDParam)
+	if id == "" {
+		id = r.Header.Get(CorrelationIDHeader)
+	}
+	if id == "" {
+		return RequestID(r), nil
+	}
+
+	if len(id) > maxCorrelationIDLength || !validCorrelationID.MatchString(id) {
+		return id, ErrInvalidCorrelationID
+	}
+	return id, nil
+}
@@ -10,8 +10,20 @@ type Response interface {
 	Marshal() []byte
 }
 
+// HeaderResponse is a Response with HTTP headers
+type HeaderResponse interface {
+	HTTPHeader() http.Header
+}
+
 // Write writes a response to the given http.ResponseWriter
 func Write(w http.ResponseWriter, response Response) {
+	if headerResponse, ok := response.(HeaderResponse); ok {
+		for name, values := range headerResponse.HTTPHeader() {
+			for _, value := range values {
+				w.Header().Add(name, value)
+			}
+		}
+	}
 	if response.HTTPStatus() != 200 {
 		w.WriteHeader(response.HTTPStatus())
 	}
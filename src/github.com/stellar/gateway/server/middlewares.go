@@ -50,11 +50,32 @@ func HeadersMiddleware() func(next http.Handler) http.Handler {
 }
 
 // APIKeyMiddleware checks for apiKey in a request and writes http.StatusForbidden if it's incorrect.
-func APIKeyMiddleware(apiKey string) func(next http.Handler) http.Handler {
+// Requests made with operatorAPIKey are allowed and get RoleOperator role. When apiKey is empty
+// requests without a key are allowed.
+func APIKeyMiddleware(apiKey, operatorAPIKey string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			k := r.PostFormValue("apiKey")
-			if k != apiKey {
+			if operatorAPIKey != "" && k == operatorAPIKey {
+				next.ServeHTTP(w, WithRole(r, RoleOperator))
+				return
+			}
+			if apiKey != "" && k != apiKey {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, WithRole(r, RoleClient))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// OperatorOnlyMiddleware writes http.StatusForbidden for requests to paths starting with pathPrefix
+// (ex. `/admin`) without RoleOperator role. It must run after APIKeyMiddleware.
+func OperatorOnlyMiddleware(pathPrefix string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.URL.Path, pathPrefix) && RequestRole(r) != RoleOperator {
 				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
@@ -63,3 +84,20 @@ func APIKeyMiddleware(apiKey string) func(next http.Handler) http.Handler {
 		return http.HandlerFunc(fn)
 	}
 }
+
+// RequestIDMiddleware attaches an ID to every request and sends it back in X-Request-ID header.
+// Valid X-Request-ID sent by a client is reused, otherwise a random ID is generated.
+func RequestIDMiddleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID.MatchString(id) {
+				id = newRequestID()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTP(w, WithRequestID(r, id))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
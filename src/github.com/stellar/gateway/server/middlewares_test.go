@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperatorOnlyMiddleware(t *testing.T) {
+	handler := APIKeyMiddleware("client-key", "operator-key")(OperatorOnlyMiddleware("/admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	tests := []struct {
+		path   string
+		apiKey string
+		status int
+	}{
+		{"/admin/reload", "operator-key", http.StatusOK},
+		{"/admin/reload", "client-key", http.StatusForbidden},
+		{"/admin/disputes", "", http.StatusForbidden},
+		{"/payment", "client-key", http.StatusOK},
+		{"/payment", "operator-key", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader("apiKey="+test.apiKey))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, test.status, w.Code, test.path+" with "+test.apiKey)
+	}
+}
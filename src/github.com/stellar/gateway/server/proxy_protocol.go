@@ -0,0 +1,267 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ProxyHeaderTimeout is a time a trusted proxy has to send the PROXY protocol header
+var ProxyHeaderTimeout = 5 * time.Second
+
+const (
+	// proxyV1MaxLength is the maximum length of a v1 header including CRLF
+	proxyV1MaxLength = 107
+	// proxyV2HeaderLength is the length of a v2 header without addresses
+	proxyV2HeaderLength = 16
+)
+
+// proxyV2Signature starts v2 headers
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// ErrNoProxyHeader is returned when a connection from a trusted proxy does not start with a
+// PROXY protocol header
+var ErrNoProxyHeader = errors.New("connection does not start with PROXY protocol header")
+
+// proxyListener parses PROXY protocol headers of connections from trusted proxies
+type proxyListener struct {
+	net.Listener
+	trusted TrustedProxies
+	log     *logrus.Entry
+
+	once      sync.Once
+	closeOnce sync.Once
+	accepted  chan net.Conn
+	// done is closed when the accept loop stopped with acceptErr
+	done      chan struct{}
+	acceptErr error
+	closed    chan struct{}
+}
+
+// NewProxyProtocolListener returns a listener parsing PROXY protocol (v1 and v2) headers of
+// connections from trusted proxies, RemoteAddr of a connection returns the client address sent
+// by the proxy. Connections from trusted proxies without a valid header are closed and
+// connections from other addresses are not parsed, so clients cannot spoof their addresses.
+// Headers are parsed concurrently, a slow connection does not block others.
+func NewProxyProtocolListener(l net.Listener, trusted TrustedProxies) net.Listener {
+	return &proxyListener{
+		Listener: l,
+		trusted:  trusted,
+		log:      logrus.WithField("service", "ProxyProtocol"),
+		accepted: make(chan net.Conn),
+		done:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+// Accept returns the next connection with a parsed header
+func (l *proxyListener) Accept() (net.Conn, error) {
+	l.once.Do(func() { go l.acceptLoop() })
+	select {
+	case conn := <-l.accepted:
+		return conn, nil
+	case <-l.done:
+		return nil, l.acceptErr
+	}
+}
+
+// Close closes the listener, connections with headers not parsed yet are closed
+func (l *proxyListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
+
+func (l *proxyListener) acceptLoop() {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(5 * time.Millisecond)
+				continue
+			}
+			l.acceptErr = err
+			close(l.done)
+			return
+		}
+		if tc, ok := conn.(*net.TCPConn); ok {
+			tc.SetKeepAlive(true)
+			tc.SetKeepAlivePeriod(3 * time.Minute)
+		}
+		go l.handshake(conn)
+	}
+}
+
+func (l *proxyListener) handshake(conn net.Conn) {
+	if !l.trusted.Contains(remoteIPOf(conn.RemoteAddr())) {
+		l.deliver(conn)
+		return
+	}
+
+	conn.SetReadDeadline(time.Now().Add(ProxyHeaderTimeout))
+	reader := bufio.NewReaderSize(conn, 256)
+	source, err := ReadProxyHeader(reader)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		l.log.WithFields(logrus.Fields{"remote_addr": conn.RemoteAddr().String(), "err": err}).Warn("Invalid PROXY protocol header")
+		conn.Close()
+		return
+	}
+	l.deliver(&proxyConn{Conn: conn, reader: reader, remoteAddr: source})
+}
+
+func (l *proxyListener) deliver(conn net.Conn) {
+	select {
+	case l.accepted <- conn:
+	case <-l.closed:
+		conn.Close()
+	}
+}
+
+// proxyConn is a connection with a client address from the PROXY protocol header
+type proxyConn struct {
+	net.Conn
+	reader     *bufio.Reader
+	remoteAddr net.Addr
+}
+
+// Read reads data after the header, including data buffered while the header was parsed
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address, the address of the proxy for health checks of the proxy
+func (c *proxyConn) RemoteAddr() net.Addr {
+	if c.remoteAddr == nil {
+		return c.Conn.RemoteAddr()
+	}
+	return c.remoteAddr
+}
+
+// ReadProxyHeader reads a PROXY protocol v1 or v2 header and returns the source address. The
+// address is nil for connections of the proxy itself (v1 `UNKNOWN` and v2 `LOCAL` headers).
+func ReadProxyHeader(reader *bufio.Reader) (net.Addr, error) {
+	start, err := reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	switch start[0] {
+	case 'P':
+		return readProxyV1(reader)
+	case proxyV2Signature[0]:
+		return readProxyV2(reader)
+	}
+	return nil, ErrNoProxyHeader
+}
+
+// readProxyV1 reads a header like `PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n`
+func readProxyV1(reader *bufio.Reader) (net.Addr, error) {
+	line := make([]byte, 0, proxyV1MaxLength)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+		if len(line) == proxyV1MaxLength {
+			return nil, errors.New("v1 header is too long")
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("v1 header does not end with CRLF")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if fields[0] != "PROXY" || len(fields) < 2 {
+		return nil, ErrNoProxyHeader
+	}
+	if fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errors.New("invalid v1 header")
+	}
+
+	ip := net.ParseIP(fields[2])
+	if ip == nil || strings.Contains(fields[2], ":") != (fields[1] == "TCP6") {
+		return nil, errors.New("invalid v1 source address")
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil || (len(fields[4]) > 1 && fields[4][0] == '0') {
+		return nil, errors.New("invalid v1 source port")
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2 reads a binary header, TLVs after the addresses are skipped
+func readProxyV2(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, proxyV2HeaderLength)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:12], proxyV2Signature) {
+		return nil, ErrNoProxyHeader
+	}
+	if header[12]>>4 != 2 {
+		return nil, errors.New("unsupported v2 version")
+	}
+
+	length := int(binary.BigEndian.Uint16(header[14:16]))
+	payload := make([]byte, length)
+	_, err = io.ReadFull(reader, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch header[12] & 0x0F {
+	case 0x0:
+		// LOCAL
+		return nil, nil
+	case 0x1:
+		// PROXY
+	default:
+		return nil, errors.New("unsupported v2 command")
+	}
+
+	var ipLength int
+	switch header[13] >> 4 {
+	case 0x1:
+		ipLength = net.IPv4len
+	case 0x2:
+		ipLength = net.IPv6len
+	default:
+		// AF_UNSPEC and AF_UNIX have no IP address
+		return nil, nil
+	}
+	if length < 2*ipLength+4 {
+		return nil, errors.New("v2 addresses are too short")
+	}
+
+	ip := make(net.IP, ipLength)
+	copy(ip, payload[:ipLength])
+	port := binary.BigEndian.Uint16(payload[2*ipLength : 2*ipLength+2])
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// remoteIPOf returns the IP of an address, nil when it's not an IP address
+func remoteIPOf(addr net.Addr) net.IP {
+	if addr == nil {
+		return nil
+	}
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return parseIP(tcp.IP.String())
+	}
+	return parseIP(addr.String())
+}
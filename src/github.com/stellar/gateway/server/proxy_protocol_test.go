@@ -0,0 +1,187 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proxyV2Header returns a v2 PROXY header of a TCP connection from src
+func proxyV2Header(command byte, src, dst *net.TCPAddr) []byte {
+	family := byte(0x11)
+	srcIP, dstIP := src.IP.To4(), dst.IP.To4()
+	if srcIP == nil {
+		family = 0x21
+		srcIP, dstIP = src.IP.To16(), dst.IP.To16()
+	}
+
+	addresses := append(append([]byte{}, srcIP...), dstIP...)
+	ports := make([]byte, 4)
+	binary.BigEndian.PutUint16(ports[0:2], uint16(src.Port))
+	binary.BigEndian.PutUint16(ports[2:4], uint16(dst.Port))
+	// A TLV (PP2_TYPE_ALPN) is skipped
+	payload := append(append(addresses, ports...), 0x01, 0x00, 0x02, 'h', '2')
+
+	header := append([]byte{}, proxyV2Signature...)
+	header = append(header, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:16], uint16(len(payload)))
+	return append(header, payload...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	client := &net.TCPAddr{IP: net.ParseIP("2001:db8::7"), Port: 41000}
+	proxy := &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 443}
+
+	tests := []struct {
+		name   string
+		header string
+		source string
+		err    bool
+	}{
+		{"v1 TCP4", "PROXY TCP4 198.51.100.7 192.0.2.1 41000 443\r\n", "198.51.100.7:41000", false},
+		{"v1 TCP6", "PROXY TCP6 2001:db8::7 2001:db8::1 41000 443\r\n", "[2001:db8::7]:41000", false},
+		{"v1 UNKNOWN", "PROXY UNKNOWN\r\n", "", false},
+		{"v2 TCP6", string(proxyV2Header(0x1, client, proxy)), "[2001:db8::7]:41000", false},
+		{"v2 TCP4", string(proxyV2Header(0x1, &net.TCPAddr{IP: net.ParseIP("198.51.100.7"), Port: 41000}, &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 443})), "198.51.100.7:41000", false},
+		{"v2 LOCAL", string(proxyV2Header(0x0, client, proxy)), "", false},
+		{"HTTP request", "GET / HTTP/1.1\r\n", "", true},
+		{"v1 without CRLF", "PROXY TCP4 198.51.100.7 192.0.2.1 41000 443\n", "", true},
+		{"v1 IPv6 in TCP4", "PROXY TCP4 2001:db8::7 2001:db8::1 41000 443\r\n", "", true},
+		{"v1 invalid address", "PROXY TCP4 198.51.100.300 192.0.2.1 41000 443\r\n", "", true},
+		{"v1 invalid port", "PROXY TCP4 198.51.100.7 192.0.2.1 70000 443\r\n", "", true},
+		{"v1 too long", "PROXY TCP6 " + strings.Repeat("a", 120) + "\r\n", "", true},
+		{"v2 invalid signature", "\r\n\r\n\x00\r\nQUIZ\n\x21\x11\x00\x00", "", true},
+		{"v2 truncated", string(proxyV2Header(0x1, client, proxy)[:20]), "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(test.header + "GET / HTTP/1.1\r\n"))
+			source, err := ReadProxyHeader(reader)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if test.source == "" {
+				assert.Nil(t, source)
+			} else {
+				assert.Equal(t, test.source, source.String())
+			}
+
+			// Data after the header is not consumed
+			rest, _ := ioutil.ReadAll(reader)
+			assert.Equal(t, "GET / HTTP/1.1\r\n", string(rest))
+		})
+	}
+}
+
+// listenProxyProtocol starts an HTTP server behind a PROXY protocol listener returning client IPs
+func listenProxyProtocol(t *testing.T, network, address string, trusted []string) net.Listener {
+	proxies, err := ParseTrustedProxies(trusted)
+	require.NoError(t, err)
+
+	inner, err := net.Listen(network, address)
+	if err != nil {
+		t.Skipf("cannot listen on %s: %s", address, err)
+	}
+	listener := NewProxyProtocolListener(inner, proxies)
+	go http.Serve(listener, ClientIPMiddleware(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ClientIP(r)))
+	})))
+	return listener
+}
+
+// requestClientIP sends a request after a prefix and returns the body of the response, empty
+// when the connection was closed or the request was rejected
+func requestClientIP(t *testing.T, address, prefix string, headers ...string) string {
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	request := prefix + "GET /status HTTP/1.1\r\nHost: bridge\r\nConnection: close\r\n"
+	for _, header := range headers {
+		request += header + "\r\n"
+	}
+	_, err = io.WriteString(conn, request+"\r\n")
+	require.NoError(t, err)
+
+	response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return ""
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return ""
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	listener := listenProxyProtocol(t, "tcp4", "127.0.0.1:0", []string{"127.0.0.0/8"})
+	defer listener.Close()
+	address := listener.Addr().String()
+
+	// A slow proxy does not block other connections
+	slow, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer slow.Close()
+	io.WriteString(slow, "PROXY TCP4")
+
+	assert.Equal(t, "198.51.100.7", requestClientIP(t, address, "PROXY TCP4 198.51.100.7 127.0.0.1 41000 443\r\n"))
+	assert.Equal(t, "2001:db8::7", requestClientIP(t, address, "PROXY TCP6 2001:db8::7 2001:db8::1 41000 443\r\n"))
+	v2 := proxyV2Header(0x1, &net.TCPAddr{IP: net.ParseIP("2001:db8::8"), Port: 41000}, &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 443})
+	assert.Equal(t, "2001:db8::8", requestClientIP(t, address, string(v2)))
+	// Health checks of the proxy itself
+	assert.Equal(t, "127.0.0.1", requestClientIP(t, address, "PROXY UNKNOWN\r\n"))
+
+	// Connections from trusted proxies must start with a header
+	assert.Equal(t, "", requestClientIP(t, address, ""))
+	assert.Equal(t, "", requestClientIP(t, address, "PROXY TCP4 198.51.100.300 127.0.0.1 41000 443\r\n"))
+
+	// X-Forwarded-For of a client behind the proxy is ignored, the client is not a trusted proxy
+	assert.Equal(t, "198.51.100.7", requestClientIP(t, address, "PROXY TCP4 198.51.100.7 127.0.0.1 41000 443\r\n", "X-Forwarded-For: 203.0.113.9"))
+}
+
+func TestProxyProtocolListenerUntrusted(t *testing.T) {
+	listener := listenProxyProtocol(t, "tcp4", "127.0.0.1:0", []string{"10.0.0.0/8"})
+	defer listener.Close()
+	address := listener.Addr().String()
+
+	assert.Equal(t, "127.0.0.1", requestClientIP(t, address, ""))
+	assert.Equal(t, "127.0.0.1", requestClientIP(t, address, "", "X-Forwarded-For: 203.0.113.9"))
+	// Headers of untrusted connections are not parsed, they are invalid HTTP requests
+	assert.Equal(t, "", requestClientIP(t, address, "PROXY TCP4 198.51.100.7 127.0.0.1 41000 443\r\n"))
+}
+
+func TestProxyProtocolListenerIPv6(t *testing.T) {
+	listener := listenProxyProtocol(t, "tcp6", "[::1]:0", []string{"::1"})
+	defer listener.Close()
+	address := listener.Addr().String()
+
+	assert.Equal(t, "2001:db8::7", requestClientIP(t, address, "PROXY TCP6 2001:db8::7 ::1 41000 443\r\n"))
+	assert.Equal(t, "::1", requestClientIP(t, address, string(proxyV2Header(0x0, &net.TCPAddr{IP: net.ParseIP("::1")}, &net.TCPAddr{IP: net.ParseIP("::1")}))))
+}
+
+func TestProxyProtocolListenerClose(t *testing.T) {
+	listener := listenProxyProtocol(t, "tcp4", "127.0.0.1:0", []string{"127.0.0.0/8"})
+	require.NoError(t, listener.Close())
+
+	_, err := listener.Accept()
+	assert.Error(t, err)
+	// Accept keeps returning the error
+	_, err = listener.Accept()
+	assert.Error(t, err)
+}
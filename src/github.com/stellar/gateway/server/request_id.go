@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// RequestIDHeader is a header containing ID of a request
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[a-zA-Z0-9._:-]{1,64}$`)
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a shallow copy of r with a given request ID attached
+func WithRequestID(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestIDContextKey{}, id))
+}
+
+// RequestID returns an ID attached to the request by RequestIDMiddleware
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	raw := make([]byte, 16)
+	_, err := rand.Read(raw)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(raw)
+}
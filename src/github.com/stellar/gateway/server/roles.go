@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+	"net/http"
+)
+
+// Role is a role of the API consumer determined by the API key used
+type Role string
+
+const (
+	// RoleClient is a role of requests made with `api_key` (or without a key when it's not set)
+	RoleClient Role = "client"
+	// RoleOperator is a role of requests made with `operator_api_key`
+	RoleOperator Role = "operator"
+)
+
+type roleContextKey struct{}
+
+// WithRole returns a shallow copy of r with a given role attached
+func WithRole(r *http.Request, role Role) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), roleContextKey{}, role))
+}
+
+// RequestRole returns a role attached to the request by APIKeyMiddleware
+func RequestRole(r *http.Request) Role {
+	role, ok := r.Context().Value(roleContextKey{}).(Role)
+	if !ok {
+		return RoleClient
+	}
+	return role
+}
@@ -0,0 +1,160 @@
+// Package signatures verifies that transaction envelopes were signed only by allowed keys. It's used
+// by `bridge verify-signatures` to check envelopes exported from /admin/export/envelopes.
+package signatures
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/stellar/go/hash"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+)
+
+// Envelope types (first 4 bytes of an envelope). Legacy envelopes start with the source account
+// key type which is equal to envelopeTypeTxV0.
+const (
+	envelopeTypeTxV0    uint32 = 0
+	envelopeTypeTx      uint32 = 2
+	envelopeTypeFeeBump uint32 = 5
+)
+
+const (
+	// PayloadTransaction is a transaction signed by its source and operation sources
+	PayloadTransaction = "transaction"
+	// PayloadFeeBump is a fee bump transaction signed by the fee source
+	PayloadFeeBump = "fee_bump"
+)
+
+// SignedPayload is a transaction hash with signatures of it. Fee bump envelopes contain two
+// payloads: the fee bump transaction and the inner transaction.
+type SignedPayload struct {
+	Kind       string
+	Hash       [32]byte
+	Signatures []xdr.DecoratedSignature
+}
+
+// signatures is decoded after the (fee bump) transaction
+type signatures struct {
+	Signatures []xdr.DecoratedSignature `xdrmaxsize:"20"`
+}
+
+// DecodeEnvelope decodes a base64 envelope and returns its signed payloads. Legacy (v0), v1 and fee
+// bump envelopes are supported as long as the transactions use ed25519 (not muxed) accounts and
+// time bounds preconditions.
+func DecodeEnvelope(envelopeXDR, networkPassphrase string) ([]SignedPayload, error) {
+	raw, err := base64.StdEncoding.DecodeString(envelopeXDR)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) < 4 {
+		return nil, errors.New("envelope is too short")
+	}
+
+	switch envelopeType := binary.BigEndian.Uint32(raw); envelopeType {
+	case envelopeTypeTxV0:
+		payload, err := decodeTransactionEnvelope(raw, networkPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []SignedPayload{payload}, nil
+	case envelopeTypeTx:
+		payload, err := decodeTransactionEnvelope(raw[4:], networkPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []SignedPayload{payload}, nil
+	case envelopeTypeFeeBump:
+		return decodeFeeBumpEnvelope(raw[4:], networkPassphrase)
+	default:
+		return nil, fmt.Errorf("unsupported envelope type %d", envelopeType)
+	}
+}
+
+// decodeTransactionEnvelope decodes a transaction and its signatures. The XDR of v1 transactions
+// with ed25519 source and time bounds is equal to the XDR of legacy transactions.
+func decodeTransactionEnvelope(raw []byte, networkPassphrase string) (SignedPayload, error) {
+	var envelope xdr.TransactionEnvelope
+	err := xdr.SafeUnmarshal(raw, &envelope)
+	if err != nil {
+		return SignedPayload{}, fmt.Errorf("cannot decode transaction envelope: %s", err)
+	}
+
+	transactionHash, err := network.HashTransaction(&envelope.Tx, networkPassphrase)
+	if err != nil {
+		return SignedPayload{}, err
+	}
+
+	return SignedPayload{
+		Kind:       PayloadTransaction,
+		Hash:       transactionHash,
+		Signatures: envelope.Signatures,
+	}, nil
+}
+
+// decodeFeeBumpEnvelope decodes FeeBumpTransactionEnvelope without the envelope type:
+//
+//	struct FeeBumpTransaction {
+//	    MuxedAccount feeSource;
+//	    int64 fee;
+//	    union switch (EnvelopeType type) { case ENVELOPE_TYPE_TX: TransactionV1Envelope v1; } innerTx;
+//	    union switch (int v) { case 0: void; } ext;
+//	};
+//	DecoratedSignature signatures<20>;
+func decodeFeeBumpEnvelope(raw []byte, networkPassphrase string) ([]SignedPayload, error) {
+	reader := bytes.NewReader(raw)
+
+	var feeSource xdr.AccountId
+	var fee xdr.Int64
+	var innerType, ext xdr.Uint32
+	var inner xdr.TransactionEnvelope
+	for _, field := range []interface{}{&feeSource, &fee, &innerType, &inner, &ext} {
+		_, err := xdr.Unmarshal(reader, field)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode fee bump transaction: %s", err)
+		}
+	}
+
+	if uint32(innerType) != envelopeTypeTx {
+		return nil, fmt.Errorf("unsupported inner transaction type %d", innerType)
+	}
+	if ext != 0 {
+		return nil, fmt.Errorf("unsupported fee bump transaction ext %d", ext)
+	}
+
+	transactionLength := len(raw) - reader.Len()
+
+	var outer signatures
+	err := xdr.SafeUnmarshal(raw[transactionLength:], &outer)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode fee bump signatures: %s", err)
+	}
+
+	networkID := network.ID(networkPassphrase)
+	var payload bytes.Buffer
+	payload.Write(networkID[:])
+	binary.Write(&payload, binary.BigEndian, envelopeTypeFeeBump)
+	payload.Write(raw[:transactionLength])
+
+	innerHash, err := network.HashTransaction(&inner.Tx, networkPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return []SignedPayload{
+		{
+			Kind:       PayloadFeeBump,
+			Hash:       hash.Hash(payload.Bytes()),
+			Signatures: outer.Signatures,
+		},
+		{
+			Kind:       PayloadTransaction,
+			Hash:       innerHash,
+			Signatures: inner.Signatures,
+		},
+	}, nil
+}
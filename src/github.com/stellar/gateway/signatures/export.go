@@ -0,0 +1,29 @@
+package signatures
+
+import (
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/utc"
+)
+
+// ExportedEnvelope is a single line of /admin/export/envelopes response
+type ExportedEnvelope struct {
+	Hash        string   `json:"hash"`
+	EnvelopeXDR string   `json:"envelope_xdr"`
+	SubmittedAt utc.Time `json:"submitted_at"`
+	// Result is a status of the transaction: sending, success or failure
+	Result    entities.SentTransactionStatus `json:"result"`
+	Ledger    *uint64                        `json:"ledger,omitempty"`
+	ResultXDR *string                        `json:"result_xdr,omitempty"`
+}
+
+// NewExportedEnvelope creates ExportedEnvelope from a sent transaction
+func NewExportedEnvelope(transaction *entities.SentTransaction) ExportedEnvelope {
+	return ExportedEnvelope{
+		Hash:        transaction.TransactionID,
+		EnvelopeXDR: transaction.EnvelopeXdr,
+		SubmittedAt: transaction.SubmittedAt,
+		Result:      transaction.Status,
+		Ledger:      transaction.Ledger,
+		ResultXDR:   transaction.ResultXdr,
+	}
+}
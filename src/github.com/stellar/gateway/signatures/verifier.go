@@ -0,0 +1,106 @@
+package signatures
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// Unattributed is a signature that was not made by any of the allowed signers
+type Unattributed struct {
+	// Payload is PayloadTransaction or PayloadFeeBump
+	Payload string `json:"payload"`
+	// Index of the signature in the payload signatures
+	Index int `json:"index"`
+	// Hint is a hex encoded signature hint (last 4 bytes of the signer public key)
+	Hint   string `json:"hint"`
+	Reason string `json:"reason"`
+}
+
+// Result is a result of verifying a single envelope
+type Result struct {
+	// Signers contains allowed signers that signed the envelope
+	Signers      []string       `json:"signers"`
+	Unattributed []Unattributed `json:"unattributed"`
+}
+
+// Valid returns true if all signatures were made by allowed signers
+func (r Result) Valid() bool {
+	return len(r.Unattributed) == 0
+}
+
+// Verifier checks envelopes against a set of allowed signers
+type Verifier struct {
+	networkPassphrase string
+	signers           []keypair.KP
+}
+
+// NewVerifier creates a Verifier, allowed signers are account IDs (`G...`) or seeds (`S...`)
+func NewVerifier(networkPassphrase string, allowedSigners []string) (*Verifier, error) {
+	verifier := &Verifier{networkPassphrase: networkPassphrase}
+	for _, signer := range allowedSigners {
+		kp, err := keypair.Parse(signer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed signer %s", signer)
+		}
+		// Seeds are not kept
+		public, err := keypair.Parse(kp.Address())
+		if err != nil {
+			return nil, err
+		}
+		verifier.signers = append(verifier.signers, public)
+	}
+	return verifier, nil
+}
+
+// Verify decodes an envelope and attributes every signature to an allowed signer. A signature is
+// attributed when its hint matches the signer and it verifies against the payload hash.
+func (v *Verifier) Verify(envelopeXDR string) (Result, error) {
+	payloads, err := DecodeEnvelope(envelopeXDR, v.networkPassphrase)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Signers: []string{}, Unattributed: []Unattributed{}}
+	seen := make(map[string]bool)
+
+	for _, payload := range payloads {
+		for i, signature := range payload.Signatures {
+			signer, reason := v.attribute(payload.Hash, signature)
+			if signer == "" {
+				result.Unattributed = append(result.Unattributed, Unattributed{
+					Payload: payload.Kind,
+					Index:   i,
+					Hint:    hex.EncodeToString(signature.Hint[:]),
+					Reason:  reason,
+				})
+				continue
+			}
+
+			if !seen[signer] {
+				seen[signer] = true
+				result.Signers = append(result.Signers, signer)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// attribute returns the allowed signer of a signature or a reason why there's none
+func (v *Verifier) attribute(payloadHash [32]byte, signature xdr.DecoratedSignature) (string, string) {
+	reason := "no allowed signer with this hint"
+	for _, signer := range v.signers {
+		if signer.Hint() != [4]byte(signature.Hint) {
+			continue
+		}
+
+		if signer.Verify(payloadHash[:], signature.Signature) == nil {
+			return signer.Address(), ""
+		}
+		reason = "signature does not verify for allowed signers with this hint"
+	}
+	return "", reason
+}
@@ -0,0 +1,209 @@
+package signatures
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stellar/gateway/submitter"
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/hash"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sourceSeed    = "SABLR5HOI2IUOYB27TR4TO7HWDJIGSRJTT4UUTXXZOFVVPGQKJ5ME43J"
+	signerSeed    = "SABY7FRMMJWPBTKQQ2ZN43AUJQ3Z2ZAK36VYSG2SPE2ABNQXA66H5E5G"
+	feeSourceSeed = "SARMR3N465GTEHQLR3TSHDD7FHFC2I22ECFLYCHAZDEJWBVED66RW7FQ"
+	strangerSeed  = "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"
+)
+
+func testTransaction(t *testing.T) *xdr.Transaction {
+	source := keypair.MustParse(sourceSeed)
+	txBuilder := build.Transaction(
+		build.SourceAccount{source.Address()},
+		build.Sequence{1},
+		build.TestNetwork,
+		build.Payment(
+			build.Destination{"GAMVF7G4GJC4A7JMFJWLUAEIBFQD5RT3DCB5DC5TJDEKQBBACQ4JZVEE"},
+			build.NativeAmount{"20"},
+		),
+	)
+	require.NoError(t, txBuilder.Err)
+	return txBuilder.TX
+}
+
+// v1Envelope converts a legacy envelope to ENVELOPE_TYPE_TX envelope
+func v1Envelope(t *testing.T, legacy string) string {
+	raw, err := base64.StdEncoding.DecodeString(legacy)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(append([]byte{0, 0, 0, 2}, raw...))
+}
+
+// feeBumpEnvelope wraps a legacy envelope in a fee bump envelope signed by seeds
+func feeBumpEnvelope(t *testing.T, legacy string, seeds ...string) string {
+	raw, err := base64.StdEncoding.DecodeString(legacy)
+	require.NoError(t, err)
+
+	var feeSource xdr.AccountId
+	require.NoError(t, feeSource.SetAddress(keypair.MustParse(feeSourceSeed).Address()))
+
+	var transaction bytes.Buffer
+	_, err = xdr.Marshal(&transaction, feeSource)
+	require.NoError(t, err)
+	_, err = xdr.Marshal(&transaction, xdr.Int64(400))
+	require.NoError(t, err)
+	_, err = xdr.Marshal(&transaction, xdr.Uint32(envelopeTypeTx))
+	require.NoError(t, err)
+	transaction.Write(raw)
+	_, err = xdr.Marshal(&transaction, xdr.Uint32(0))
+	require.NoError(t, err)
+
+	networkID := network.ID(network.TestNetworkPassphrase)
+	var payload bytes.Buffer
+	payload.Write(networkID[:])
+	payload.Write([]byte{0, 0, 0, 5})
+	payload.Write(transaction.Bytes())
+	payloadHash := hash.Hash(payload.Bytes())
+
+	var outer signatures
+	for _, seed := range seeds {
+		signature, err := keypair.MustParse(seed).SignDecorated(payloadHash[:])
+		require.NoError(t, err)
+		outer.Signatures = append(outer.Signatures, signature)
+	}
+
+	var envelope bytes.Buffer
+	envelope.Write([]byte{0, 0, 0, 5})
+	envelope.Write(transaction.Bytes())
+	_, err = xdr.Marshal(&envelope, outer)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(envelope.Bytes())
+}
+
+func address(seed string) string {
+	return keypair.MustParse(seed).Address()
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	tx := testTransaction(t)
+	txHash, err := submitter.TransactionHash(tx, network.TestNetworkPassphrase)
+	require.NoError(t, err)
+
+	legacy, err := submitter.SignEnvelope(tx, network.TestNetworkPassphrase, sourceSeed, signerSeed)
+	require.NoError(t, err)
+
+	t.Run("legacy", func(t *testing.T) {
+		payloads, err := DecodeEnvelope(legacy, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		require.Len(t, payloads, 1)
+		assert.Equal(t, PayloadTransaction, payloads[0].Kind)
+		assert.Equal(t, txHash, payloads[0].Hash)
+		assert.Len(t, payloads[0].Signatures, 2)
+	})
+
+	t.Run("v1", func(t *testing.T) {
+		payloads, err := DecodeEnvelope(v1Envelope(t, legacy), network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		require.Len(t, payloads, 1)
+		assert.Equal(t, txHash, payloads[0].Hash)
+		assert.Len(t, payloads[0].Signatures, 2)
+	})
+
+	t.Run("fee bump", func(t *testing.T) {
+		payloads, err := DecodeEnvelope(feeBumpEnvelope(t, legacy, feeSourceSeed), network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		require.Len(t, payloads, 2)
+		assert.Equal(t, PayloadFeeBump, payloads[0].Kind)
+		assert.Len(t, payloads[0].Signatures, 1)
+		assert.Equal(t, PayloadTransaction, payloads[1].Kind)
+		assert.Equal(t, txHash, payloads[1].Hash)
+		assert.Len(t, payloads[1].Signatures, 2)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := DecodeEnvelope("AAAA", network.TestNetworkPassphrase)
+		assert.Error(t, err)
+
+		_, err = DecodeEnvelope("AAAAAQ==", network.TestNetworkPassphrase)
+		assert.EqualError(t, err, "unsupported envelope type 1")
+
+		_, err = DecodeEnvelope("not base64", network.TestNetworkPassphrase)
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifier(t *testing.T) {
+	tx := testTransaction(t)
+	legacy, err := submitter.SignEnvelope(tx, network.TestNetworkPassphrase, sourceSeed, signerSeed)
+	require.NoError(t, err)
+
+	verifier, err := NewVerifier(network.TestNetworkPassphrase, []string{
+		sourceSeed,
+		address(signerSeed),
+		address(feeSourceSeed),
+	})
+	require.NoError(t, err)
+
+	t.Run("multiple signatures", func(t *testing.T) {
+		result, err := verifier.Verify(legacy)
+		require.NoError(t, err)
+		assert.True(t, result.Valid())
+		assert.Equal(t, []string{address(sourceSeed), address(signerSeed)}, result.Signers)
+	})
+
+	t.Run("fee bump", func(t *testing.T) {
+		result, err := verifier.Verify(feeBumpEnvelope(t, legacy, feeSourceSeed))
+		require.NoError(t, err)
+		assert.True(t, result.Valid())
+		assert.Equal(t, []string{address(feeSourceSeed), address(sourceSeed), address(signerSeed)}, result.Signers)
+	})
+
+	t.Run("fee bump signed by stranger", func(t *testing.T) {
+		result, err := verifier.Verify(feeBumpEnvelope(t, legacy, strangerSeed))
+		require.NoError(t, err)
+		assert.False(t, result.Valid())
+		require.Len(t, result.Unattributed, 1)
+		assert.Equal(t, PayloadFeeBump, result.Unattributed[0].Payload)
+		assert.Equal(t, 0, result.Unattributed[0].Index)
+		assert.Equal(t, "no allowed signer with this hint", result.Unattributed[0].Reason)
+	})
+
+	t.Run("signature by stranger", func(t *testing.T) {
+		envelope, err := submitter.SignEnvelope(tx, network.TestNetworkPassphrase, sourceSeed, strangerSeed)
+		require.NoError(t, err)
+
+		result, err := verifier.Verify(envelope)
+		require.NoError(t, err)
+		assert.False(t, result.Valid())
+		assert.Equal(t, []string{address(sourceSeed)}, result.Signers)
+
+		stranger := keypair.MustParse(strangerSeed).Hint()
+		assert.Equal(t, []Unattributed{{
+			Payload: PayloadTransaction,
+			Index:   1,
+			Hint:    hex.EncodeToString(stranger[:]),
+			Reason:  "no allowed signer with this hint",
+		}}, result.Unattributed)
+	})
+
+	t.Run("signature for other network", func(t *testing.T) {
+		envelope, err := submitter.SignEnvelope(tx, network.PublicNetworkPassphrase, sourceSeed)
+		require.NoError(t, err)
+
+		result, err := verifier.Verify(envelope)
+		require.NoError(t, err)
+		require.Len(t, result.Unattributed, 1)
+		assert.Equal(t, "signature does not verify for allowed signers with this hint", result.Unattributed[0].Reason)
+	})
+
+	t.Run("invalid signer", func(t *testing.T) {
+		_, err := NewVerifier(network.TestNetworkPassphrase, []string{"GABC"})
+		assert.EqualError(t, err, "invalid allowed signer GABC")
+	})
+}
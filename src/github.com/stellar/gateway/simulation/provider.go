@@ -0,0 +1,318 @@
+// Package simulation provides account state to /simulate payments and records transactions
+// instead of submitting them
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/stellar/gateway/external"
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/market"
+	"github.com/stellar/gateway/protocols"
+	"github.com/stellar/go/build"
+	"github.com/stellar/go/network"
+	fproto "github.com/stellar/go/protocols/federation"
+	"github.com/stellar/go/xdr"
+)
+
+// Results of checks
+const (
+	ResultOK       = "ok"
+	ResultNotFound = "not_found"
+	ResultError    = "error"
+)
+
+// ErrNotSimulated is returned by requests a payment never makes (ex. streaming)
+var ErrNotSimulated = errors.New("Not available in simulation")
+
+// State is an inline account state fixture of /simulate
+type State struct {
+	// Accounts by account ID, accounts not listed do not exist
+	Accounts map[string]Account `json:"accounts"`
+	// Federation responses by Stellar address, addresses not listed can't be resolved
+	Federation map[string]fproto.NameResponse `json:"federation"`
+	// OrderBooks used by path payment slippage checks
+	OrderBooks []OrderBook `json:"order_books"`
+}
+
+// Account is a state of a single account. Balances with `asset_code` are trustlines.
+type Account struct {
+	Sequence string            `json:"sequence"`
+	Balances []horizon.Balance `json:"balances"`
+}
+
+// OrderBook is an order book of selling/buying assets, empty asset code means XLM
+type OrderBook struct {
+	Selling protocols.Asset `json:"selling"`
+	Buying  protocols.Asset `json:"buying"`
+	horizon.OrderBookResponse
+}
+
+// Check is an outcome of a single check made by a simulated payment
+type Check struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject,omitempty"`
+	Result  string `json:"result"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// Provider implements horizon.HorizonInterface and external.FederationClientInterface for a single
+// simulated payment. State is read from the fixture or, when there is none, from Horizon and
+// federation servers. Transactions are never submitted, their envelopes are recorded without
+// signatures.
+type Provider struct {
+	state             *State
+	horizon           horizon.HorizonInterface
+	federation        external.FederationClientInterface
+	networkPassphrase string
+
+	// Checks contains outcomes of checks in order they were made
+	Checks []Check
+	// EnvelopeXdr is the transaction that would be submitted, empty if none
+	EnvelopeXdr string
+}
+
+// NewProvider creates a new Provider. h and federation are used only when state is nil.
+func NewProvider(state *State, h horizon.HorizonInterface, federation external.FederationClientInterface, networkPassphrase string) *Provider {
+	return &Provider{
+		state:             state,
+		horizon:           h,
+		federation:        federation,
+		networkPassphrase: networkPassphrase,
+		Checks:            []Check{},
+	}
+}
+
+func (p *Provider) check(name, subject string, err error) {
+	check := Check{Name: name, Subject: subject, Result: ResultOK}
+	if statusErr, ok := err.(*horizon.StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+		check.Result = ResultNotFound
+	} else if err != nil {
+		check.Result = ResultError
+		check.Detail = err.Error()
+	}
+	p.Checks = append(p.Checks, check)
+}
+
+// LoadAccount returns an account of the state
+func (p *Provider) LoadAccount(accountID string) (response horizon.AccountResponse, err error) {
+	defer func() { p.check("load_account", accountID, err) }()
+
+	if p.state == nil {
+		return p.horizon.LoadAccount(accountID)
+	}
+
+	account, ok := p.state.Accounts[accountID]
+	if !ok {
+		err = &horizon.StatusError{StatusCode: http.StatusNotFound, Body: []byte("Account not in simulation state")}
+		return
+	}
+	response = horizon.AccountResponse{
+		AccountID:      accountID,
+		SequenceNumber: account.Sequence,
+		Balances:       account.Balances,
+	}
+	return
+}
+
+// LoadOrderBook returns an order book of the state
+func (p *Provider) LoadOrderBook(selling, buying build.Asset) (response horizon.OrderBookResponse, err error) {
+	subject := assetString(selling) + "/" + assetString(buying)
+	defer func() { p.check("order_book", subject, err) }()
+
+	if p.state == nil {
+		return p.horizon.LoadOrderBook(selling, buying)
+	}
+
+	for _, book := range p.state.OrderBooks {
+		if sameAsset(book.Selling, selling) && sameAsset(book.Buying, buying) {
+			return book.OrderBookResponse, nil
+		}
+	}
+	// Missing order books are empty
+	return
+}
+
+// LoadPaths returns direct paths from assets of the source account with an order book of the
+// state selling the destination asset. Multi-hop paths are not searched in the state.
+func (p *Provider) LoadPaths(sourceAccount, destinationAccount string, destinationAsset build.Asset, destinationAmount string) (response horizon.PathsPage, err error) {
+	defer func() { p.check("find_paths", sourceAccount, err) }()
+
+	if p.state == nil {
+		return p.horizon.LoadPaths(sourceAccount, destinationAccount, destinationAsset, destinationAmount)
+	}
+
+	response.Embedded.Records = []horizon.PathResponse{}
+	account, ok := p.state.Accounts[sourceAccount]
+	if !ok {
+		err = &horizon.StatusError{StatusCode: http.StatusNotFound, Body: []byte("Account not in simulation state")}
+		return
+	}
+	amount, ok := new(big.Rat).SetString(destinationAmount)
+	if !ok {
+		err = fmt.Errorf("invalid destination amount %s", destinationAmount)
+		return
+	}
+
+	for _, balance := range account.Balances {
+		source := protocols.Asset{Code: balance.AssetCode, Issuer: balance.AssetIssuer}
+		for _, book := range p.state.OrderBooks {
+			if !sameAsset(book.Selling, destinationAsset) || !sameAsset(book.Buying, source.ToBaseAsset()) {
+				continue
+			}
+			orderBook, bookErr := market.NewOrderBook(book.OrderBookResponse)
+			if bookErr != nil {
+				err = bookErr
+				return
+			}
+			estimate, estimateErr := market.EstimatePath([]market.OrderBook{orderBook}, amount)
+			if estimateErr != nil {
+				// Order books without enough offers have no path
+				continue
+			}
+			response.Embedded.Records = append(response.Embedded.Records, horizon.PathResponse{
+				SourceAssetType:        balance.AssetType,
+				SourceAssetCode:        balance.AssetCode,
+				SourceAssetIssuer:      balance.AssetIssuer,
+				SourceAmount:           estimate.SendAmount.FloatString(7),
+				DestinationAssetType:   assetType(destinationAsset),
+				DestinationAssetCode:   destinationAsset.Code,
+				DestinationAssetIssuer: destinationAsset.Issuer,
+				DestinationAmount:      destinationAmount,
+				Path:                   []horizon.PathAsset{},
+			})
+		}
+	}
+	return
+}
+
+// LoadAccountCtx is LoadAccount, ctx cancels requests of the read-only Horizon
+func (p *Provider) LoadAccountCtx(ctx context.Context, accountID string) (response horizon.AccountResponse, err error) {
+	if p.state != nil {
+		return p.LoadAccount(accountID)
+	}
+
+	defer func() { p.check("load_account", accountID, err) }()
+	return p.horizon.LoadAccountCtx(ctx, accountID)
+}
+
+// SubmitTransactionCtx is SubmitTransaction, nothing is submitted
+func (p *Provider) SubmitTransactionCtx(ctx context.Context, txeBase64 string) (horizon.SubmitTransactionResponse, error) {
+	return p.SubmitTransaction(txeBase64)
+}
+
+// SubmitTransaction records the envelope without signatures instead of submitting it
+func (p *Provider) SubmitTransaction(txeBase64 string) (response horizon.SubmitTransactionResponse, err error) {
+	defer func() { p.check("build_transaction", "", err) }()
+
+	var envelope xdr.TransactionEnvelope
+	err = xdr.SafeUnmarshalBase64(txeBase64, &envelope)
+	if err != nil {
+		return
+	}
+
+	hash, err := network.HashTransaction(&envelope.Tx, p.networkPassphrase)
+	if err != nil {
+		return
+	}
+
+	envelope.Signatures = nil
+	p.EnvelopeXdr, err = xdr.MarshalBase64(envelope)
+	if err != nil {
+		return
+	}
+
+	response.Hash = fmt.Sprintf("%x", hash)
+	return
+}
+
+// LookupByAddress resolves an address using the state
+func (p *Provider) LookupByAddress(addy string) (response *fproto.NameResponse, err error) {
+	defer func() { p.check("resolve_destination", addy, err) }()
+
+	if p.state == nil {
+		return p.federation.LookupByAddress(addy)
+	}
+
+	found, ok := p.state.Federation[addy]
+	if !ok {
+		err = errors.New("Address not in simulation state")
+		return
+	}
+	return &found, nil
+}
+
+// LookupByAccountID is not used by payments
+func (p *Provider) LookupByAccountID(aid string) (*fproto.IDResponse, error) {
+	return nil, ErrNotSimulated
+}
+
+// LoadMemo is not used by payments
+func (p *Provider) LoadMemo(payment *horizon.PaymentResponse) error {
+	return ErrNotSimulated
+}
+
+// LoadOperation is not used by payments
+func (p *Provider) LoadOperation(operationID string) (horizon.PaymentResponse, error) {
+	return horizon.PaymentResponse{}, ErrNotSimulated
+}
+
+// LoadPayments is not used by payments
+func (p *Provider) LoadPayments(accountID, cursor string, limit int) (horizon.PaymentsPage, error) {
+	return horizon.PaymentsPage{}, ErrNotSimulated
+}
+
+// LoadLatestLedger is not used by payments
+func (p *Provider) LoadLatestLedger() (uint32, error) {
+	return 0, ErrNotSimulated
+}
+
+// LoadLedger is not used by payments
+func (p *Provider) LoadLedger(sequence uint32) (horizon.LedgerResponse, error) {
+	return horizon.LedgerResponse{}, ErrNotSimulated
+}
+
+// LoadTransaction is not used by simulated payments, they are never resubmitted
+func (p *Provider) LoadTransaction(hash string) (horizon.TransactionResponse, error) {
+	return horizon.TransactionResponse{}, ErrNotSimulated
+}
+
+// StreamPayments is not used by payments
+func (p *Provider) StreamPayments(accountID string, cursor *string, onPaymentHandler horizon.PaymentHandler) error {
+	return ErrNotSimulated
+}
+
+// WithCorrelationID returns the provider, Horizon requests of a simulation are sent by the
+// Horizon client the provider was created with
+func (p *Provider) WithCorrelationID(id string) horizon.HorizonInterface {
+	return p
+}
+
+func sameAsset(a protocols.Asset, b build.Asset) bool {
+	if b.Native {
+		return a.Code == "" && a.Issuer == ""
+	}
+	return a.Code == b.Code && a.Issuer == b.Issuer
+}
+
+// assetType returns the Horizon asset_type of asset
+func assetType(asset build.Asset) string {
+	switch {
+	case asset.Native:
+		return "native"
+	case len(asset.Code) <= 4:
+		return "credit_alphanum4"
+	}
+	return "credit_alphanum12"
+}
+
+func assetString(asset build.Asset) string {
+	if asset.Native {
+		return "XLM"
+	}
+	return asset.Code + ":" + asset.Issuer
+}
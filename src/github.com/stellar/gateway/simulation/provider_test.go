@@ -0,0 +1,58 @@
+package simulation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/go/build"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderOrderBooks(t *testing.T) {
+	var state State
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "order_books": [{
+    "selling": {"code": "USD", "issuer": "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"},
+    "buying": {"code": ""},
+    "bids": [{"price": "2.0000000", "amount": "100.0000000"}],
+    "asks": [{"price": "2.1000000", "amount": "50.0000000"}]
+  }]
+}`), &state))
+
+	p := NewProvider(&state, nil, nil, "Test SDF Network ; September 2015")
+	usd := build.CreditAsset("USD", "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR")
+
+	book, err := p.LoadOrderBook(usd, build.NativeAsset())
+	require.NoError(t, err)
+	assert.Equal(t, []horizon.OrderBookLevel{{Price: "2.1000000", Amount: "50.0000000"}}, book.Asks)
+
+	// Order books not in the state are empty
+	book, err = p.LoadOrderBook(build.NativeAsset(), usd)
+	require.NoError(t, err)
+	assert.Empty(t, book.Asks)
+
+	assert.Equal(t, []Check{
+		{Name: "order_book", Subject: "USD:GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR/XLM", Result: ResultOK},
+		{Name: "order_book", Subject: "XLM/USD:GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR", Result: ResultOK},
+	}, p.Checks)
+}
+
+func TestProviderWithoutState(t *testing.T) {
+	mockHorizon := new(mocks.MockHorizon)
+	mockHorizon.On("LoadAccount", "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS").
+		Return(horizon.AccountResponse{SequenceNumber: "100"}, nil).Once()
+
+	p := NewProvider(nil, mockHorizon, nil, "Test SDF Network ; September 2015")
+	account, err := p.LoadAccount("GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS")
+	require.NoError(t, err)
+	assert.Equal(t, "100", account.SequenceNumber)
+	mockHorizon.AssertExpectations(t)
+
+	// Transactions are never submitted
+	_, err = p.SubmitTransaction("AAAA")
+	assert.Error(t, err)
+	assert.Equal(t, ResultError, p.Checks[1].Result)
+}
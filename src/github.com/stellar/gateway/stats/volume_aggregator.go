@@ -0,0 +1,322 @@
+// Package stats aggregates payments sent and received by the bridge server into daily volumes
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/db"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+// DateFormat is a format of DailyVolume dates
+const DateFormat = "2006-01-02"
+
+// Date returns a DailyVolume date (UTC) t belongs to
+func Date(t time.Time) string {
+	return t.UTC().Format(DateFormat)
+}
+
+// VolumeAggregatorInterface helps mocking VolumeAggregator
+type VolumeAggregatorInterface interface {
+	Touch(t time.Time)
+}
+
+// VolumeAggregator keeps DailyVolume table in sync with ReceivedPayment and SentTransaction tables.
+// Daily volumes are never incremented: every touched day is recomputed from the raw tables so
+// reprocessed payments are not counted twice.
+type VolumeAggregator struct {
+	repository    db.RepositoryInterface
+	entityManager db.EntityManagerInterface
+	log           *logrus.Entry
+
+	mutex sync.Mutex
+	dirty map[string]bool
+	wake  chan struct{}
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// NewVolumeAggregator creates a new VolumeAggregator
+func NewVolumeAggregator(repository db.RepositoryInterface, entityManager db.EntityManagerInterface) *VolumeAggregator {
+	return &VolumeAggregator{
+		repository:    repository,
+		entityManager: entityManager,
+		log: logrus.WithFields(logrus.Fields{
+			"service": "VolumeAggregator",
+		}),
+		dirty: make(map[string]bool),
+		wake:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+	}
+}
+
+// Touch schedules recomputation of the day t belongs to. It should be called every time a payment
+// is finalized (or reprocessed). It never blocks.
+func (a *VolumeAggregator) Touch(t time.Time) {
+	a.mutex.Lock()
+	a.dirty[Date(t)] = true
+	a.mutex.Unlock()
+
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run starts recomputing touched days in the background
+func (a *VolumeAggregator) Run() {
+	go func() {
+		for {
+			select {
+			case <-a.wake:
+			case <-a.stop:
+				return
+			}
+
+			a.mutex.Lock()
+			dates := make([]string, 0, len(a.dirty))
+			for date := range a.dirty {
+				dates = append(dates, date)
+			}
+			a.dirty = make(map[string]bool)
+			a.mutex.Unlock()
+
+			sort.Strings(dates)
+			for _, date := range dates {
+				err := a.Recompute(date)
+				if err != nil {
+					a.log.WithFields(logrus.Fields{"err": err, "date": date}).Error("Error recomputing daily volumes")
+					// Will be retried with the next Touch
+					a.mutex.Lock()
+					a.dirty[date] = true
+					a.mutex.Unlock()
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops recomputing touched days, a recomputation in progress is finished. Days touched
+// after Stop are not recomputed.
+func (a *VolumeAggregator) Stop() {
+	a.once.Do(func() { close(a.stop) })
+}
+
+// Recompute recomputes volumes of a given date (YYYY-MM-DD) from the raw tables
+func (a *VolumeAggregator) Recompute(date string) error {
+	from, err := time.Parse(DateFormat, date)
+	if err != nil {
+		return err
+	}
+	to := from.Add(24 * time.Hour)
+
+	received, err := a.repository.GetReceivedPaymentsProcessedBetween(from, to)
+	if err != nil {
+		return err
+	}
+
+	sent, err := a.repository.GetSentTransactionsSucceededBetween(from, to)
+	if err != nil {
+		return err
+	}
+
+	volumes, err := AggregateVolumes(date, received, sent)
+	if err != nil {
+		return err
+	}
+
+	existing, err := a.repository.GetDailyVolumes(date, date, "", "")
+	if err != nil {
+		return err
+	}
+
+	stale := make(map[volumeKey]*entities.DailyVolume)
+	for _, volume := range existing {
+		stale[keyOf(volume)] = volume
+	}
+
+	for _, volume := range volumes {
+		key := keyOf(volume)
+		if current, ok := stale[key]; ok {
+			delete(stale, key)
+			if current.Count == volume.Count && current.Sum == volume.Sum && current.Fees == volume.Fees {
+				continue
+			}
+			current.Count = volume.Count
+			current.Sum = volume.Sum
+			current.Fees = volume.Fees
+			volume = current
+		}
+
+		err = a.entityManager.Persist(volume)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, volume := range stale {
+		err = a.entityManager.Delete(volume)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type volumeKey struct {
+	assetCode   string
+	assetIssuer string
+	direction   entities.DailyVolumeDirection
+}
+
+func keyOf(volume *entities.DailyVolume) volumeKey {
+	return volumeKey{volume.AssetCode, volume.AssetIssuer, volume.Direction}
+}
+
+// AggregateVolumes aggregates payments into daily volumes of a given date. Only payments processed
+// with `Success` status and successful transactions are counted. Payment, path payment and create
+// account operations are counted as payments; fee charged for a transaction is added to the volume
+// of its first payment. Transactions succeeded before results were stored have no fee. Transactions with `return` memo are counted as refunds, internal transfers have
+// their own direction.
+func AggregateVolumes(
+	date string,
+	received []*entities.ReceivedPayment,
+	sent []*entities.SentTransaction,
+) ([]*entities.DailyVolume, error) {
+	volumes := make(map[volumeKey]*entities.DailyVolume)
+	add := func(key volumeKey, amount, fee int64) {
+		volume, ok := volumes[key]
+		if !ok {
+			volume = &entities.DailyVolume{
+				Date:        date,
+				AssetCode:   key.assetCode,
+				AssetIssuer: key.assetIssuer,
+				Direction:   key.direction,
+			}
+			volumes[key] = volume
+		}
+		volume.Count++
+		volume.Sum += amount
+		volume.Fees += fee
+	}
+
+	for _, payment := range received {
+		// Payments received before amounts were stored have empty Amount
+		if payment.Status != "Success" || payment.Amount == "" {
+			continue
+		}
+
+		value, err := amount.Parse(payment.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount of received payment %s: %s", payment.OperationID, err)
+		}
+
+		add(volumeKey{payment.AssetCode, payment.AssetIssuer, entities.DailyVolumeDirectionReceived}, int64(value), 0)
+	}
+
+	for _, transaction := range sent {
+		if transaction.Status != entities.SentTransactionStatusSuccess {
+			continue
+		}
+
+		var envelope xdr.TransactionEnvelope
+		err := xdr.SafeUnmarshalBase64(transaction.EnvelopeXdr, &envelope)
+		if err != nil {
+			return nil, fmt.Errorf("invalid envelope of sent transaction %s: %s", transaction.TransactionID, err)
+		}
+
+		direction := entities.DailyVolumeDirectionSent
+		if transaction.Internal {
+			direction = entities.DailyVolumeDirectionInternal
+		} else if envelope.Tx.Memo.Type == xdr.MemoTypeMemoReturn {
+			direction = entities.DailyVolumeDirectionRefund
+		}
+
+		fee, err := feeCharged(transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, operation := range envelope.Tx.Operations {
+			asset, value, ok := paymentOf(operation)
+			if !ok {
+				continue
+			}
+
+			code, issuer, err := assetCodeAndIssuer(asset)
+			if err != nil {
+				return nil, fmt.Errorf("invalid asset in sent transaction %s: %s", transaction.TransactionID, err)
+			}
+
+			add(volumeKey{code, issuer, direction}, int64(value), fee)
+			fee = 0
+		}
+	}
+
+	result := make([]*entities.DailyVolume, 0, len(volumes))
+	for _, volume := range volumes {
+		result = append(result, volume)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := keyOf(result[i]), keyOf(result[j])
+		if a.assetCode != b.assetCode {
+			return a.assetCode < b.assetCode
+		}
+		if a.assetIssuer != b.assetIssuer {
+			return a.assetIssuer < b.assetIssuer
+		}
+		return a.direction < b.direction
+	})
+	return result, nil
+}
+
+// feeCharged returns the fee charged for a sent transaction from its result, the fee of the
+// envelope is only the maximum fee the transaction offered
+func feeCharged(transaction *entities.SentTransaction) (int64, error) {
+	if transaction.ResultXdr == nil {
+		return 0, nil
+	}
+
+	var result xdr.TransactionResult
+	err := xdr.SafeUnmarshalBase64(*transaction.ResultXdr, &result)
+	if err != nil {
+		return 0, fmt.Errorf("invalid result of sent transaction %s: %s", transaction.TransactionID, err)
+	}
+	return int64(result.FeeCharged), nil
+}
+
+// paymentOf returns asset and amount delivered by the operation or false if it's not a payment
+func paymentOf(operation xdr.Operation) (xdr.Asset, xdr.Int64, bool) {
+	switch operation.Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		native, _ := xdr.NewAsset(xdr.AssetTypeAssetTypeNative, nil)
+		return native, operation.Body.CreateAccountOp.StartingBalance, true
+	case xdr.OperationTypePayment:
+		return operation.Body.PaymentOp.Asset, operation.Body.PaymentOp.Amount, true
+	case xdr.OperationTypePathPayment:
+		return operation.Body.PathPaymentOp.DestAsset, operation.Body.PathPaymentOp.DestAmount, true
+	default:
+		return xdr.Asset{}, 0, false
+	}
+}
+
+// assetCodeAndIssuer returns asset code and issuer, native asset is `XLM` with empty issuer
+func assetCodeAndIssuer(asset xdr.Asset) (code, issuer string, err error) {
+	var assetType xdr.AssetType
+	err = asset.Extract(&assetType, &code, &issuer)
+	if err != nil {
+		return
+	}
+
+	if assetType == xdr.AssetTypeAssetTypeNative {
+		code = "XLM"
+	}
+	return
+}
@@ -0,0 +1,456 @@
+package stats
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/quick"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/utc"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSource      = "GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ"
+	testDestination = "GBABZMS7MEDWKWSHOMUKAWGIOE5UA4XLVPUHRHVMUW2DUVEZXLH5OIET"
+	testIssuer      = "GD4I7AFSLZGTDL34TQLWJOM2NHLIIOEKD5RHHZUW54HERBLSIRKUOXRR"
+)
+
+var testAssets = [][2]string{
+	{"XLM", ""},
+	{"EURT", testIssuer},
+	{"USD", testIssuer},
+}
+
+// testDays are days raw payments are generated in
+var testDays = []time.Time{
+	time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC),
+	time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC),
+	time.Date(2017, 3, 3, 0, 0, 0, 0, time.UTC),
+}
+
+type totals struct {
+	count, sum, fees int64
+}
+
+// rawPayments contains generated raw table rows and totals expected in the aggregates
+// computed independently from AggregateVolumes.
+type rawPayments struct {
+	received []*entities.ReceivedPayment
+	sent     []*entities.SentTransaction
+	expected map[string]map[volumeKey]totals // date => totals
+}
+
+func (raw *rawPayments) expect(date string, key volumeKey, sum, fees int64) {
+	if raw.expected[date] == nil {
+		raw.expected[date] = make(map[volumeKey]totals)
+	}
+	t := raw.expected[date][key]
+	t.count++
+	t.sum += sum
+	t.fees += fees
+	raw.expected[date][key] = t
+}
+
+func randomTime(r *rand.Rand) time.Time {
+	day := testDays[r.Intn(len(testDays))]
+	return day.Add(time.Duration(r.Int63n(int64(24 * time.Hour))))
+}
+
+func randomAsset(r *rand.Rand) (xdr.Asset, volumeKey) {
+	pick := testAssets[r.Intn(len(testAssets))]
+	var asset xdr.Asset
+	if pick[1] == "" {
+		asset.SetNative()
+	} else {
+		var issuer xdr.AccountId
+		issuer.SetAddress(pick[1])
+		asset.SetCredit(pick[0], issuer)
+	}
+	return asset, volumeKey{assetCode: pick[0], assetIssuer: pick[1]}
+}
+
+func randomReceivedPayment(r *rand.Rand, raw *rawPayments, id int) {
+	_, key := randomAsset(r)
+	value := r.Int63n(1000000*amount.One) + 1
+	payment := &entities.ReceivedPayment{
+		OperationID: strconv.Itoa(id),
+		ProcessedAt: utc.New(randomTime(r)),
+		AssetCode:   key.assetCode,
+		AssetIssuer: key.assetIssuer,
+		Amount:      amount.String(xdr.Int64(value)),
+	}
+
+	switch r.Intn(4) {
+	case 0:
+		payment.Status = "Error response from receive callback"
+	case 1:
+		// Payment processed before amounts were stored
+		payment.Status = "Success"
+		payment.Amount = ""
+	default:
+		payment.Status = "Success"
+		key.direction = entities.DailyVolumeDirectionReceived
+		raw.expect(Date(payment.ProcessedAt.Time()), key, value, 0)
+	}
+
+	raw.received = append(raw.received, payment)
+}
+
+func randomSentTransaction(r *rand.Rand, raw *rawPayments) {
+	var source, destination xdr.AccountId
+	source.SetAddress(testSource)
+	destination.SetAddress(testDestination)
+
+	tx := xdr.Transaction{SourceAccount: source, SeqNum: xdr.SequenceNumber(r.Int63())}
+
+	direction := entities.DailyVolumeDirectionSent
+	switch r.Intn(3) {
+	case 0:
+		tx.Memo, _ = xdr.NewMemo(xdr.MemoTypeMemoNone, nil)
+	case 1:
+		tx.Memo, _ = xdr.NewMemo(xdr.MemoTypeMemoText, "text")
+	case 2:
+		tx.Memo, _ = xdr.NewMemo(xdr.MemoTypeMemoReturn, xdr.Hash{1, 2, 3})
+		direction = entities.DailyVolumeDirectionRefund
+	}
+	// Internal transfers are not counted as sent or refunded
+	internal := r.Intn(5) == 0
+	if internal {
+		direction = entities.DailyVolumeDirectionInternal
+	}
+
+	type payment struct {
+		key   volumeKey
+		value int64
+	}
+	var payments []payment
+
+	for i := r.Intn(3) + 1; i > 0; i-- {
+		asset, key := randomAsset(r)
+		value := r.Int63n(1000000*amount.One) + 1
+		key.direction = direction
+
+		var body xdr.OperationBody
+		switch r.Intn(4) {
+		case 0:
+			body, _ = xdr.NewOperationBody(xdr.OperationTypePayment, xdr.PaymentOp{
+				Destination: destination,
+				Asset:       asset,
+				Amount:      xdr.Int64(value),
+			})
+		case 1:
+			var native xdr.Asset
+			native.SetNative()
+			body, _ = xdr.NewOperationBody(xdr.OperationTypePathPayment, xdr.PathPaymentOp{
+				SendAsset:   native,
+				SendMax:     xdr.Int64(value * 10),
+				Destination: destination,
+				DestAsset:   asset,
+				DestAmount:  xdr.Int64(value),
+			})
+		case 2:
+			body, _ = xdr.NewOperationBody(xdr.OperationTypeCreateAccount, xdr.CreateAccountOp{
+				Destination:     destination,
+				StartingBalance: xdr.Int64(value),
+			})
+			key.assetCode = "XLM"
+			key.assetIssuer = ""
+		case 3:
+			// Not a payment
+			body, _ = xdr.NewOperationBody(xdr.OperationTypeInflation, nil)
+			value = 0
+		}
+
+		tx.Operations = append(tx.Operations, xdr.Operation{Body: body})
+		if value != 0 {
+			payments = append(payments, payment{key, value})
+		}
+	}
+	tx.Fee = xdr.Uint32(100 * len(tx.Operations))
+
+	envelope, err := xdr.MarshalBase64(xdr.TransactionEnvelope{Tx: tx})
+	if err != nil {
+		panic(err)
+	}
+
+	transaction := &entities.SentTransaction{
+		TransactionID: strconv.Itoa(len(raw.sent)),
+		Status:        entities.SentTransactionStatusSuccess,
+		EnvelopeXdr:   envelope,
+		Internal:      internal,
+	}
+
+	if r.Intn(4) == 0 {
+		transaction.MarkFailed("AAAAAAAAAAD////7AAAAAA==")
+	} else {
+		// The network charges only the base fee of operations, never more than the envelope fee
+		fee := int64(r.Intn(int(tx.Fee) + 1))
+		transaction.MarkSucceeded(1, transactionResult(fee))
+		succeededAt := utc.New(randomTime(r))
+		transaction.SucceededAt = &succeededAt
+
+		for _, p := range payments {
+			raw.expect(Date(succeededAt.Time()), p.key, p.value, fee)
+			fee = 0
+		}
+	}
+
+	raw.sent = append(raw.sent, transaction)
+}
+
+// transactionResult returns a result XDR of a successful transaction charged fee
+func transactionResult(fee int64) *string {
+	result, err := xdr.NewTransactionResultResult(xdr.TransactionResultCodeTxSuccess, []xdr.OperationResult{})
+	if err != nil {
+		panic(err)
+	}
+	resultXdr, err := xdr.MarshalBase64(xdr.TransactionResult{FeeCharged: xdr.Int64(fee), Result: result})
+	if err != nil {
+		panic(err)
+	}
+	return &resultXdr
+}
+
+// Generate implements quick.Generator
+func (rawPayments) Generate(r *rand.Rand, size int) reflect.Value {
+	raw := rawPayments{expected: make(map[string]map[volumeKey]totals)}
+	for i := r.Intn(size + 1); i > 0; i-- {
+		randomReceivedPayment(r, &raw, len(raw.received))
+	}
+	for i := r.Intn(size + 1); i > 0; i-- {
+		randomSentTransaction(r, &raw)
+	}
+	return reflect.ValueOf(raw)
+}
+
+func (raw rawPayments) receivedOn(date string) (result []*entities.ReceivedPayment) {
+	for _, payment := range raw.received {
+		if Date(payment.ProcessedAt.Time()) == date {
+			result = append(result, payment)
+		}
+	}
+	return
+}
+
+func (raw rawPayments) sentOn(date string) (result []*entities.SentTransaction) {
+	for _, transaction := range raw.sent {
+		if transaction.Status == entities.SentTransactionStatusSuccess && Date(transaction.SucceededAt.Time()) == date {
+			result = append(result, transaction)
+		}
+	}
+	return
+}
+
+func totalsOf(volumes []*entities.DailyVolume) map[string]map[volumeKey]totals {
+	result := make(map[string]map[volumeKey]totals)
+	for _, volume := range volumes {
+		if result[volume.Date] == nil {
+			result[volume.Date] = make(map[volumeKey]totals)
+		}
+		result[volume.Date][keyOf(volume)] = totals{volume.Count, volume.Sum, volume.Fees}
+	}
+	return result
+}
+
+// memoryStore is an in-memory DB implementing repository and entity manager methods used by
+// VolumeAggregator
+type memoryStore struct {
+	mocks.MockRepository
+	raw     *rawPayments
+	volumes map[int64]entities.DailyVolume
+	nextID  int64
+}
+
+func (s *memoryStore) GetReceivedPaymentsProcessedBetween(from, to time.Time) ([]*entities.ReceivedPayment, error) {
+	return s.raw.receivedOn(Date(from)), nil
+}
+
+func (s *memoryStore) GetSentTransactionsSucceededBetween(from, to time.Time) ([]*entities.SentTransaction, error) {
+	return s.raw.sentOn(Date(from)), nil
+}
+
+func (s *memoryStore) GetDailyVolumes(from, to, assetCode, assetIssuer string) ([]*entities.DailyVolume, error) {
+	var result []*entities.DailyVolume
+	for _, volume := range s.volumes {
+		if volume.Date >= from && volume.Date <= to {
+			v := volume
+			v.SetExists()
+			result = append(result, &v)
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryStore) Persist(object entities.Entity) error {
+	volume := object.(*entities.DailyVolume)
+	if volume.IsNew() {
+		s.nextID++
+		volume.SetID(s.nextID)
+		volume.SetExists()
+	}
+	s.volumes[*volume.ID] = *volume
+	return nil
+}
+
+func (s *memoryStore) Delete(object entities.Entity) error {
+	delete(s.volumes, *object.GetID())
+	return nil
+}
+
+func (s *memoryStore) all() (result []*entities.DailyVolume) {
+	for id := range s.volumes {
+		volume := s.volumes[id]
+		result = append(result, &volume)
+	}
+	return
+}
+
+func quickConfig(seed int64) *quick.Config {
+	return &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(seed))}
+}
+
+func TestAggregateVolumes(t *testing.T) {
+	Convey("AggregateVolumes", t, func() {
+		Convey("aggregates are equal to raw sums", func() {
+			property := func(raw rawPayments) bool {
+				var volumes []*entities.DailyVolume
+				for _, day := range testDays {
+					date := Date(day)
+					dayVolumes, err := AggregateVolumes(date, raw.receivedOn(date), raw.sentOn(date))
+					require.NoError(t, err)
+					volumes = append(volumes, dayVolumes...)
+				}
+				return assert.Equal(t, raw.expected, totalsOf(volumes))
+			}
+			assert.NoError(t, quick.Check(property, quickConfig(1)))
+		})
+
+		Convey("ignores unsuccessful transactions passed", func() {
+			raw := rawPayments{expected: make(map[string]map[volumeKey]totals)}
+			randomSentTransaction(rand.New(rand.NewSource(1)), &raw)
+			raw.sent[0].MarkFailed("AAAAAAAAAAD////7AAAAAA==")
+
+			volumes, err := AggregateVolumes("2017-03-01", nil, raw.sent)
+			assert.NoError(t, err)
+			assert.Empty(t, volumes)
+		})
+
+		Convey("counts fee charged instead of the envelope fee", func() {
+			raw := rawPayments{expected: make(map[string]map[volumeKey]totals)}
+			randomSentTransaction(rand.New(rand.NewSource(1)), &raw)
+			raw.sent[0].MarkSucceeded(1, transactionResult(7))
+
+			volumes, err := AggregateVolumes("2017-03-01", nil, raw.sent)
+			assert.NoError(t, err)
+			var fees int64
+			for _, volume := range volumes {
+				fees += volume.Fees
+			}
+			assert.Equal(t, int64(7), fees)
+
+			Convey("and no fee when the result is not stored", func() {
+				raw.sent[0].MarkSucceeded(1, nil)
+
+				volumes, err := AggregateVolumes("2017-03-01", nil, raw.sent)
+				assert.NoError(t, err)
+				for _, volume := range volumes {
+					assert.Equal(t, int64(0), volume.Fees)
+				}
+			})
+
+			Convey("and returns error for invalid result", func() {
+				invalid := "invalid"
+				raw.sent[0].MarkSucceeded(1, &invalid)
+
+				_, err := AggregateVolumes("2017-03-01", nil, raw.sent)
+				assert.Error(t, err)
+			})
+		})
+
+		Convey("returns error for invalid amount", func() {
+			_, err := AggregateVolumes("2017-03-01", []*entities.ReceivedPayment{
+				{Status: "Success", AssetCode: "XLM", Amount: "ten"},
+			}, nil)
+			assert.Error(t, err)
+		})
+	})
+}
+
+func TestVolumeAggregatorRecompute(t *testing.T) {
+	Convey("VolumeAggregator.Recompute", t, func() {
+		Convey("keeps aggregates consistent when payments are reprocessed", func() {
+			property := func(raw rawPayments, seed int64) bool {
+				store := &memoryStore{raw: &raw, volumes: make(map[int64]entities.DailyVolume)}
+				aggregator := NewVolumeAggregator(store, store)
+
+				for _, day := range testDays {
+					require.NoError(t, aggregator.Recompute(Date(day)))
+				}
+				if !assert.Equal(t, raw.expected, totalsOf(store.all())) {
+					return false
+				}
+
+				// Reprocess some of the payments: status changes and processed_at moves
+				r := rand.New(rand.NewSource(seed))
+				touched := make(map[string]bool)
+				for _, payment := range raw.received {
+					if r.Intn(2) == 0 {
+						continue
+					}
+					touched[Date(payment.ProcessedAt.Time())] = true
+					payment.ProcessedAt = utc.New(randomTime(r))
+					touched[Date(payment.ProcessedAt.Time())] = true
+					if r.Intn(2) == 0 {
+						payment.Status = "Success"
+					} else {
+						payment.Status = "Error response from receive callback"
+					}
+				}
+
+				for date := range touched {
+					require.NoError(t, aggregator.Recompute(date))
+				}
+
+				var expected []*entities.DailyVolume
+				for _, day := range testDays {
+					date := Date(day)
+					volumes, err := AggregateVolumes(date, raw.receivedOn(date), raw.sentOn(date))
+					require.NoError(t, err)
+					expected = append(expected, volumes...)
+				}
+				return assert.Equal(t, totalsOf(expected), totalsOf(store.all()))
+			}
+			assert.NoError(t, quick.Check(property, quickConfig(2)))
+		})
+	})
+}
+
+func TestNewVolumeReport(t *testing.T) {
+	Convey("NewVolumeReport", t, func() {
+		volumes := []*entities.DailyVolume{
+			{Date: "2017-03-01", AssetCode: "EURT", AssetIssuer: testIssuer, Direction: entities.DailyVolumeDirectionSent, Count: 2, Sum: 30 * amount.One, Fees: 200},
+			{Date: "2017-03-01", AssetCode: "EURT", AssetIssuer: testIssuer, Direction: entities.DailyVolumeDirectionReceived, Count: 1, Sum: 5 * amount.One},
+			{Date: "2017-03-02", AssetCode: "EURT", AssetIssuer: testIssuer, Direction: entities.DailyVolumeDirectionSent, Count: 1, Sum: 12 * amount.One, Fees: 100},
+		}
+
+		report := NewVolumeReport("2017-03-01", "2017-03-02", volumes)
+
+		So(report.Volumes, ShouldHaveLength, 3)
+		assert.Equal(t, VolumeTotals{Count: 1, Sum: "12.0000000", Fees: "0.0000100"}, report.Volumes[2].VolumeTotals)
+		assert.Equal(t, VolumeTotals{Count: 3, Sum: "42.0000000", Fees: "0.0000300"}, report.Volumes[2].Running)
+
+		assert.Equal(t, []VolumeReportTotal{
+			{AssetCode: "EURT", AssetIssuer: testIssuer, Direction: entities.DailyVolumeDirectionSent, VolumeTotals: VolumeTotals{Count: 3, Sum: "42.0000000", Fees: "0.0000300"}},
+			{AssetCode: "EURT", AssetIssuer: testIssuer, Direction: entities.DailyVolumeDirectionReceived, VolumeTotals: VolumeTotals{Count: 1, Sum: "5.0000000", Fees: "0.0000000"}},
+		}, report.Totals)
+	})
+}
@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/xdr"
+)
+
+// VolumeTotals contains a number of payments, their sum and fees paid
+type VolumeTotals struct {
+	Count int64  `json:"count"`
+	Sum   string `json:"sum"`
+	Fees  string `json:"fees"`
+}
+
+// VolumeReportEntry is a single day of VolumeReport series. Running contains totals of the asset and
+// direction from the beginning of the report up to (and including) this day.
+type VolumeReportEntry struct {
+	Date        string                        `json:"date"`
+	AssetCode   string                        `json:"asset_code"`
+	AssetIssuer string                        `json:"asset_issuer"`
+	Direction   entities.DailyVolumeDirection `json:"direction"`
+	VolumeTotals
+	Running VolumeTotals `json:"running"`
+}
+
+// VolumeReportTotal contains totals of a single asset and direction in VolumeReport
+type VolumeReportTotal struct {
+	AssetCode   string                        `json:"asset_code"`
+	AssetIssuer string                        `json:"asset_issuer"`
+	Direction   entities.DailyVolumeDirection `json:"direction"`
+	VolumeTotals
+}
+
+// VolumeReport is returned by /admin/stats/volumes endpoint
+type VolumeReport struct {
+	From    string              `json:"from"`
+	To      string              `json:"to"`
+	Volumes []VolumeReportEntry `json:"volumes"`
+	Totals  []VolumeReportTotal `json:"totals"`
+}
+
+type runningTotals struct {
+	count, sum, fees int64
+}
+
+func (t runningTotals) toVolumeTotals() VolumeTotals {
+	return VolumeTotals{
+		Count: t.count,
+		Sum:   amount.String(xdr.Int64(t.sum)),
+		Fees:  amount.String(xdr.Int64(t.fees)),
+	}
+}
+
+// NewVolumeReport creates a VolumeReport from daily volumes ordered by date
+func NewVolumeReport(from, to string, volumes []*entities.DailyVolume) VolumeReport {
+	report := VolumeReport{
+		From:    from,
+		To:      to,
+		Volumes: []VolumeReportEntry{},
+		Totals:  []VolumeReportTotal{},
+	}
+
+	running := make(map[volumeKey]*runningTotals)
+	var keys []volumeKey
+
+	for _, volume := range volumes {
+		key := keyOf(volume)
+		totals, ok := running[key]
+		if !ok {
+			totals = &runningTotals{}
+			running[key] = totals
+			keys = append(keys, key)
+		}
+		totals.count += volume.Count
+		totals.sum += volume.Sum
+		totals.fees += volume.Fees
+
+		report.Volumes = append(report.Volumes, VolumeReportEntry{
+			Date:         volume.Date,
+			AssetCode:    volume.AssetCode,
+			AssetIssuer:  volume.AssetIssuer,
+			Direction:    volume.Direction,
+			VolumeTotals: runningTotals{volume.Count, volume.Sum, volume.Fees}.toVolumeTotals(),
+			Running:      totals.toVolumeTotals(),
+		})
+	}
+
+	for _, key := range keys {
+		report.Totals = append(report.Totals, VolumeReportTotal{
+			AssetCode:    key.assetCode,
+			AssetIssuer:  key.assetIssuer,
+			Direction:    key.direction,
+			VolumeTotals: running[key].toVolumeTotals(),
+		})
+	}
+
+	return report
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"sync"
 	"time"
@@ -12,25 +14,57 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stellar/gateway/db"
 	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/events"
 	"github.com/stellar/gateway/horizon"
+	"github.com/stellar/gateway/logging"
+	"github.com/stellar/gateway/ratelimit"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/gateway/stats"
+	"github.com/stellar/gateway/utc"
 	"github.com/stellar/go/build"
 	"github.com/stellar/go/hash"
 	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/xdr"
 )
 
+const (
+	// submitAttempts is a number of times an envelope is submitted when Horizon responses are lost
+	submitAttempts = 3
+	// resubmitWait is a time between resubmissions of an envelope
+	resubmitWait = 2 * time.Second
+	// rateLimitWait is a longest wait for a reset of Horizon rate limit, payments are handled
+	// synchronously so longer limits are returned to clients
+	rateLimitWait = 10 * time.Second
+)
+
+// DefaultRetry is a policy of resubmissions used when `retry.submitter` is not configured
+var DefaultRetry = retry.Settings{MaxAttempts: submitAttempts, BaseBackoff: resubmitWait, MaxBackoff: resubmitWait, MaxRateLimitWait: rateLimitWait}
+
 // TransactionSubmitterInterface helps mocking TransactionSubmitter
 type TransactionSubmitterInterface interface {
 	SubmitTransaction(seed string, operation, memo interface{}) (response horizon.SubmitTransactionResponse, err error)
 	SignAndSubmitRawTransaction(seed string, tx *xdr.Transaction) (response horizon.SubmitTransactionResponse, err error)
 }
 
+// AccountObserver is notified about sequence numbers of loaded accounts, implemented by
+// generation.Tracker
+type AccountObserver interface {
+	Observe(accountID string, sequence uint64) (int64, error)
+}
+
 // TransactionSubmitter submits transactions to Stellar Network
 type TransactionSubmitter struct {
 	Horizon       horizon.HorizonInterface
 	Accounts      map[string]*Account // seed => *Account
+	accountsMutex *sync.Mutex         // guards Accounts, shared with copies
 	EntityManager db.EntityManagerInterface
+	Volumes       stats.VolumeAggregatorInterface // notified about successful transactions, optional
+	Events        events.RecorderInterface        // notified about status changes of transactions, optional
+	Generations   AccountObserver                 // notified about sequence numbers of loaded accounts, optional
 	Network       build.Network
+	// Retry resubmits envelopes when Horizon responses are lost
+	Retry         *retry.Policy
+	correlationID string
 	log           *logrus.Entry
 	now           func() time.Time
 }
@@ -53,14 +87,34 @@ func NewTransactionSubmitter(
 	ts.Horizon = horizon
 	ts.EntityManager = entityManager
 	ts.Accounts = make(map[string]*Account)
+	ts.accountsMutex = &sync.Mutex{}
 	ts.Network = build.Network{networkPassphrase}
 	ts.log = logrus.WithFields(logrus.Fields{
-		"service": "TransactionSubmitter",
+		"service":             "TransactionSubmitter",
+		logging.CategoryField: logging.CategoryHorizon,
 	})
 	ts.now = now
+	ts.Retry = retry.NewPolicy(retry.Submitter, DefaultRetry, time.Sleep)
 	return
 }
 
+// WithCorrelationID returns a copy of ts sharing its accounts. Transactions sent by the copy are
+// stored with the correlation ID and Horizon requests carry it in X-Correlation-ID header.
+func (ts *TransactionSubmitter) WithCorrelationID(id string) TransactionSubmitterInterface {
+	submitter := *ts
+	submitter.correlationID = id
+	submitter.Horizon = ts.Horizon.WithCorrelationID(id)
+	return &submitter
+}
+
+// WithSubmissionPriority returns a copy of ts sharing its accounts. Transactions sent by the copy
+// are submitted with priority when its Horizon is rate limited (see horizon.NewLimitedHorizon).
+func (ts *TransactionSubmitter) WithSubmissionPriority(priority ratelimit.Priority) *TransactionSubmitter {
+	submitter := *ts
+	submitter.Horizon = horizon.WithSubmissionPriority(ts.Horizon, priority)
+	return &submitter
+}
+
 // LoadAccount loads currect state of Stellar account
 func (ts *TransactionSubmitter) LoadAccount(seed string) (account *Account, err error) {
 	account = &Account{}
@@ -86,16 +140,59 @@ func (ts *TransactionSubmitter) InitAccount(seed string) (err error) {
 	return
 }
 
-// GetAccount returns an account by a given seed
+// GetAccount returns an account by a given seed. Accounts that fail to load are loaded again by
+// the next call.
 func (ts *TransactionSubmitter) GetAccount(seed string) (account *Account, err error) {
+	ts.accountsMutex.Lock()
 	account, exist := ts.Accounts[seed]
 	if !exist {
 		account, err = ts.LoadAccount(seed)
-		ts.Accounts[seed] = account
+		if err == nil {
+			ts.Accounts[seed] = account
+		}
+	}
+	ts.accountsMutex.Unlock()
+
+	// Observers can invalidate the account
+	if !exist && err == nil {
+		ts.observe(account.Keypair.Address(), account.SequenceNumber)
 	}
 	return
 }
 
+// observe notifies Generations about a loaded sequence number, errors are logged
+func (ts *TransactionSubmitter) observe(accountID string, sequence uint64) {
+	if ts.Generations == nil {
+		return
+	}
+	if _, err := ts.Generations.Observe(accountID, sequence); err != nil {
+		ts.log.WithFields(logrus.Fields{"err": err, "account_id": accountID}).Error("Error observing account generation")
+	}
+}
+
+// InvalidateAccounts drops loaded accounts, so their sequence numbers are loaded from Horizon again
+// by the next transactions (ex. after another replica sent transactions of the accounts).
+// Transactions being signed keep the accounts they got.
+func (ts *TransactionSubmitter) InvalidateAccounts() {
+	ts.accountsMutex.Lock()
+	defer ts.accountsMutex.Unlock()
+	for seed := range ts.Accounts {
+		delete(ts.Accounts, seed)
+	}
+}
+
+// InvalidateAccount drops loaded accounts of an address (ex. it was merged and created again with
+// a new sequence number), they are loaded again by the next transaction
+func (ts *TransactionSubmitter) InvalidateAccount(accountID string) {
+	ts.accountsMutex.Lock()
+	defer ts.accountsMutex.Unlock()
+	for seed, account := range ts.Accounts {
+		if account.Keypair.Address() == accountID {
+			delete(ts.Accounts, seed)
+		}
+	}
+}
+
 // SignAndSubmitRawTransaction will:
 // - update sequence number of the transaction to the current one,
 // - sign it,
@@ -111,26 +208,9 @@ func (ts *TransactionSubmitter) SignAndSubmitRawTransaction(seed string, tx *xdr
 	tx.SeqNum = xdr.SequenceNumber(account.SequenceNumber)
 	account.Mutex.Unlock()
 
-	hash, err := TransactionHash(tx, ts.Network.Passphrase)
-	if err != nil {
-		ts.log.Print("Error calculating transaction hash")
-		return
-	}
-
-	sig, err := account.Keypair.SignDecorated(hash[:])
+	txeB64, err := SignEnvelope(tx, ts.Network.Passphrase, account.Seed)
 	if err != nil {
-		ts.log.Print("Error signing a transaction")
-		return
-	}
-
-	envelopeXdr := xdr.TransactionEnvelope{
-		Tx:         *tx,
-		Signatures: []xdr.DecoratedSignature{sig},
-	}
-
-	txeB64, err := xdr.MarshalBase64(envelopeXdr)
-	if err != nil {
-		ts.log.Print("Cannot encode transaction envelope")
+		ts.log.Print("Error signing a transaction: ", err)
 		return
 	}
 
@@ -144,22 +224,28 @@ func (ts *TransactionSubmitter) SignAndSubmitRawTransaction(seed string, tx *xdr
 		TransactionID: hex.EncodeToString(transactionHashBytes[:]),
 		Status:        entities.SentTransactionStatusSending,
 		Source:        account.Keypair.Address(),
-		SubmittedAt:   ts.now(),
+		SubmittedAt:   utc.New(ts.now()),
 		EnvelopeXdr:   txeB64,
+		CorrelationID: ts.correlationID,
 	}
 	err = ts.EntityManager.Persist(sentTransaction)
 	if err != nil {
 		return
 	}
+	events.RecordTransaction(ts.Events, sentTransaction)
 
-	response, err = ts.Horizon.SubmitTransaction(txeB64)
+	response, err = ts.submit(sentTransaction.TransactionID, txeB64)
+	// Horizon responses of failed transactions don't have the hash
+	if response.Hash == "" {
+		response.Hash = sentTransaction.TransactionID
+	}
 	if err != nil {
 		ts.log.Error("Error submitting transaction ", err)
 		return
 	}
 
 	if response.Ledger != nil {
-		sentTransaction.MarkSucceeded(*response.Ledger)
+		sentTransaction.MarkSucceeded(*response.Ledger, response.ResultXdr)
 	} else {
 		var result string
 		if response.Extras != nil {
@@ -173,22 +259,103 @@ func (ts *TransactionSubmitter) SignAndSubmitRawTransaction(seed string, tx *xdr
 	if err != nil {
 		return
 	}
+	events.RecordTransaction(ts.Events, sentTransaction)
+
+	if ts.Volumes != nil && sentTransaction.SucceededAt != nil {
+		ts.Volumes.Touch(sentTransaction.SucceededAt.Time())
+	}
 
 	// Sync sequence number
-	if response.Extras != nil && response.Extras.ResultXdr == "AAAAAAAAAAD////7AAAAAA==" {
+	if resultErr := response.TransactionResultError(); resultErr != nil && resultErr.Remediation == horizon.RemediationRetryWithNewSequence {
 		account.Mutex.Lock()
 		ts.log.Print("Syncing sequence number for ", account.Keypair.Address())
 		accountResponse, err2 := ts.Horizon.LoadAccount(account.Keypair.Address())
+		synced := err2 == nil
 		if err2 != nil {
 			ts.log.Error("Error updating sequence number ", err)
 		} else {
 			account.SequenceNumber, _ = strconv.ParseUint(accountResponse.SequenceNumber, 10, 64)
 		}
+		sequence := account.SequenceNumber
 		account.Mutex.Unlock()
+
+		if synced {
+			ts.observe(account.Keypair.Address(), sequence)
+		}
 	}
 	return
 }
 
+// submit submits an envelope and resubmits it when a response is lost (network errors, Horizon
+// timeouts) or the transaction failed with a retriable result code (see
+// horizon.TransactionResultError). The first attempt may have been applied to a ledger when its
+// response was lost and resubmissions of an applied envelope fail (ex. tx_bad_seq), so the
+// transaction is loaded from Horizon before a failure of a resubmission is returned.
+func (ts *TransactionSubmitter) submit(hash, txeB64 string) (response horizon.SubmitTransactionResponse, err error) {
+	attempts := 0
+	err = ts.Retry.Do(func(attempt int) error {
+		attempts = attempt
+		var submitErr error
+		response, submitErr = ts.Horizon.SubmitTransaction(txeB64)
+		if submitErr != nil && isResponseLost(submitErr) {
+			ts.log.WithFields(logrus.Fields{"hash": hash, "attempt": attempt, "err": submitErr}).
+				Warn("Transaction submission response lost")
+		}
+		if submitErr != nil {
+			return submitErr
+		}
+		if resultErr := response.TransactionResultError(); resultErr != nil && resultErr.Retriable {
+			ts.log.WithFields(logrus.Fields{"hash": hash, "attempt": attempt, "result": resultErr.Name}).
+				Warn("Transaction failed with retriable result")
+			return resultErr
+		}
+		return nil
+	}, isRetriable)
+
+	// The last failed response is returned when retriable results are exhausted
+	if _, ok := err.(*horizon.TransactionResultError); ok {
+		err = nil
+	}
+
+	if attempts == 1 || (err == nil && response.Ledger != nil) {
+		return
+	}
+
+	transaction, loadErr := ts.Horizon.LoadTransaction(hash)
+	if loadErr != nil {
+		// Not found: no attempt was applied
+		if statusErr, ok := loadErr.(*horizon.StatusError); !ok || statusErr.StatusCode != http.StatusNotFound {
+			ts.log.WithFields(logrus.Fields{"hash": hash, "err": loadErr}).Error("Error loading resubmitted transaction")
+		}
+		return
+	}
+
+	ts.log.WithFields(logrus.Fields{"hash": hash, "ledger": transaction.Ledger}).
+		Info("Resubmitted transaction was already applied")
+	err = nil
+	response = transaction.ToSubmitTransactionResponse()
+	return
+}
+
+// isRetriable returns true if an envelope should be submitted again
+func isRetriable(err error) bool {
+	if resultErr, ok := err.(*horizon.TransactionResultError); ok {
+		return resultErr.Retriable
+	}
+	return isResponseLost(err)
+}
+
+// isResponseLost returns true if a transaction may have been submitted but its result is unknown
+func isResponseLost(err error) bool {
+	switch err := err.(type) {
+	case *horizon.StatusError:
+		return err.StatusCode >= http.StatusInternalServerError
+	case *url.Error:
+		return true
+	}
+	return false
+}
+
 // SubmitTransaction builds and submits transaction to Stellar network
 func (ts *TransactionSubmitter) SubmitTransaction(seed string, operation, memo interface{}) (response horizon.SubmitTransactionResponse, err error) {
 	account, err := ts.GetAccount(seed)
@@ -274,3 +441,29 @@ func TransactionHash(tx *xdr.Transaction, networkPassphrase string) ([32]byte, e
 
 	return hash.Hash(txBytes.Bytes()), nil
 }
+
+// SignEnvelope signs a transaction for a given network and returns base64 encoded envelope. All
+// transactions built by the servers are signed here so, given a transaction with a fixed sequence
+// number, the envelope is deterministic (ed25519 signatures do not depend on randomness).
+func SignEnvelope(tx *xdr.Transaction, networkPassphrase string, seeds ...string) (string, error) {
+	hash, err := TransactionHash(tx, networkPassphrase)
+	if err != nil {
+		return "", err
+	}
+
+	envelope := xdr.TransactionEnvelope{Tx: *tx}
+	for _, seed := range seeds {
+		signer, err := keypair.Parse(seed)
+		if err != nil {
+			return "", err
+		}
+
+		signature, err := signer.SignDecorated(hash[:])
+		if err != nil {
+			return "", err
+		}
+		envelope.Signatures = append(envelope.Signatures, signature)
+	}
+
+	return xdr.MarshalBase64(envelope)
+}
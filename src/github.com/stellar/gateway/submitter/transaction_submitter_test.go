@@ -2,6 +2,11 @@ package submitter
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
 	"time"
 
@@ -9,11 +14,28 @@ import (
 	"github.com/stellar/gateway/db/entities"
 	"github.com/stellar/gateway/horizon"
 	"github.com/stellar/gateway/mocks"
+	"github.com/stellar/gateway/retry"
+	"github.com/stellar/gateway/utc"
 	b "github.com/stellar/go/build"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+// recreations invalidates accounts observed with a lower sequence number, like generation.Tracker
+type recreations struct {
+	submitter *TransactionSubmitter
+	sequences map[string]uint64
+}
+
+func (r *recreations) Observe(accountID string, sequence uint64) (int64, error) {
+	previous, ok := r.sequences[accountID]
+	r.sequences[accountID] = sequence
+	if ok && sequence < previous {
+		r.submitter.InvalidateAccount(accountID)
+	}
+	return 1, nil
+}
+
 func TestTransactionSubmitter(t *testing.T) {
 	mockHorizon := new(mocks.MockHorizon)
 	mockEntityManager := new(mocks.MockEntityManager)
@@ -69,6 +91,53 @@ func TestTransactionSubmitter(t *testing.T) {
 				assert.Equal(t, account.SequenceNumber, uint64(10372672437354496))
 				mockHorizon.AssertExpectations(t)
 			})
+
+			Convey("Loads invalidated accounts again", func() {
+				mockHorizon.On("LoadAccount", accountID).Return(
+					horizon.AccountResponse{AccountID: accountID, SequenceNumber: "10372672437354496"},
+					nil,
+				).Once()
+				assert.Nil(t, transactionSubmitter.InitAccount(seed))
+
+				transactionSubmitter.InvalidateAccounts()
+				mockHorizon.On("LoadAccount", accountID).Return(
+					horizon.AccountResponse{AccountID: accountID, SequenceNumber: "10372672437354500"},
+					nil,
+				).Once()
+				account, err := transactionSubmitter.GetAccount(seed)
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(10372672437354500), account.SequenceNumber)
+				mockHorizon.AssertExpectations(t)
+			})
+
+			Convey("Loads recreated accounts again", func() {
+				observer := &recreations{submitter: &transactionSubmitter, sequences: map[string]uint64{}}
+				transactionSubmitter.Generations = observer
+				otherSeed := "SBKKWO3ZVDDEHDJILGHPHCJCFD2GNUAYIUDMRAS326HLUEQ7ZFXWIGQK"
+				mockHorizon.On("LoadAccount", "GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ").Return(
+					horizon.AccountResponse{SequenceNumber: "5"},
+					nil,
+				).Once()
+				assert.Nil(t, transactionSubmitter.InitAccount(otherSeed))
+				mockHorizon.On("LoadAccount", accountID).Return(
+					horizon.AccountResponse{AccountID: accountID, SequenceNumber: "10372672437354496"},
+					nil,
+				).Once()
+				assert.Nil(t, transactionSubmitter.InitAccount(seed))
+
+				// Recreated account observed by a payment loading it from Horizon, the cached
+				// account is loaded again with the new sequence number
+				observer.Observe(accountID, 4294967296)
+				mockHorizon.On("LoadAccount", accountID).Return(
+					horizon.AccountResponse{AccountID: accountID, SequenceNumber: "4294967296"},
+					nil,
+				).Once()
+				account, err := transactionSubmitter.GetAccount(seed)
+				assert.Nil(t, err)
+				assert.Equal(t, uint64(4294967296), account.SequenceNumber)
+				assert.Contains(t, transactionSubmitter.Accounts, otherSeed)
+				mockHorizon.AssertExpectations(t)
+			})
 		})
 
 		Convey("SubmitTransaction", func() {
@@ -111,7 +180,7 @@ func TestTransactionSubmitter(t *testing.T) {
 						assert.Equal(t, "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050", transaction.TransactionID)
 						assert.Equal(t, "sending", string(transaction.Status))
 						assert.Equal(t, "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", transaction.Source)
-						assert.Equal(t, mocks.PredefinedTime, transaction.SubmittedAt)
+						assert.Equal(t, utc.New(mocks.PredefinedTime), transaction.SubmittedAt)
 						assert.Equal(t, txB64, transaction.EnvelopeXdr)
 					})
 
@@ -124,7 +193,7 @@ func TestTransactionSubmitter(t *testing.T) {
 						assert.Equal(t, "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050", transaction.TransactionID)
 						assert.Equal(t, "failure", string(transaction.Status))
 						assert.Equal(t, "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", transaction.Source)
-						assert.Equal(t, mocks.PredefinedTime, transaction.SubmittedAt)
+						assert.Equal(t, utc.New(mocks.PredefinedTime), transaction.SubmittedAt)
 						assert.Equal(t, txB64, transaction.EnvelopeXdr)
 					})
 
@@ -176,7 +245,7 @@ func TestTransactionSubmitter(t *testing.T) {
 						assert.Equal(t, "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050", transaction.TransactionID)
 						assert.Equal(t, "sending", string(transaction.Status))
 						assert.Equal(t, "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", transaction.Source)
-						assert.Equal(t, mocks.PredefinedTime, transaction.SubmittedAt)
+						assert.Equal(t, utc.New(mocks.PredefinedTime), transaction.SubmittedAt)
 						assert.Equal(t, txB64, transaction.EnvelopeXdr)
 					})
 
@@ -189,7 +258,7 @@ func TestTransactionSubmitter(t *testing.T) {
 						assert.Equal(t, "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050", transaction.TransactionID)
 						assert.Equal(t, "failure", string(transaction.Status))
 						assert.Equal(t, "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", transaction.Source)
-						assert.Equal(t, mocks.PredefinedTime, transaction.SubmittedAt)
+						assert.Equal(t, utc.New(mocks.PredefinedTime), transaction.SubmittedAt)
 						assert.Equal(t, txB64, transaction.EnvelopeXdr)
 					})
 
@@ -254,7 +323,7 @@ func TestTransactionSubmitter(t *testing.T) {
 						assert.Equal(t, "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050", transaction.TransactionID)
 						assert.Equal(t, "sending", string(transaction.Status))
 						assert.Equal(t, "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", transaction.Source)
-						assert.Equal(t, mocks.PredefinedTime, transaction.SubmittedAt)
+						assert.Equal(t, utc.New(mocks.PredefinedTime), transaction.SubmittedAt)
 						assert.Equal(t, txB64, transaction.EnvelopeXdr)
 					})
 
@@ -267,7 +336,7 @@ func TestTransactionSubmitter(t *testing.T) {
 						assert.Equal(t, "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050", transaction.TransactionID)
 						assert.Equal(t, "success", string(transaction.Status))
 						assert.Equal(t, "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", transaction.Source)
-						assert.Equal(t, mocks.PredefinedTime, transaction.SubmittedAt)
+						assert.Equal(t, utc.New(mocks.PredefinedTime), transaction.SubmittedAt)
 						assert.Equal(t, txB64, transaction.EnvelopeXdr)
 					})
 
@@ -283,6 +352,235 @@ func TestTransactionSubmitter(t *testing.T) {
 					assert.Equal(t, uint64(10372672437354497), transactionSubmitter.Accounts[seed].SequenceNumber)
 					mockHorizon.AssertExpectations(t)
 				})
+
+				Convey("Stores the correlation ID with the transaction", func() {
+					transactionSubmitter := NewTransactionSubmitter(
+						mockHorizon,
+						mockEntityManager,
+						"Test SDF Network ; September 2015",
+						mocks.Now,
+					)
+
+					mockHorizon.On("LoadAccount", accountID).Return(
+						horizon.AccountResponse{
+							AccountID:      accountID,
+							SequenceNumber: "10372672437354496",
+						},
+						nil,
+					).Once()
+
+					err := transactionSubmitter.InitAccount(seed)
+					assert.Nil(t, err)
+
+					mockEntityManager.On(
+						"Persist",
+						mock.AnythingOfType("*entities.SentTransaction"),
+					).Return(nil).Twice().Run(func(args mock.Arguments) {
+						transaction := args.Get(0).(*entities.SentTransaction)
+						assert.Equal(t, "order-42", transaction.CorrelationID)
+					})
+
+					ledger := uint64(1486276)
+					mockHorizon.On("SubmitTransaction", mock.AnythingOfType("string")).Return(
+						horizon.SubmitTransactionResponse{Ledger: &ledger},
+						nil,
+					).Once()
+
+					_, err = transactionSubmitter.WithCorrelationID("order-42").SubmitTransaction(seed, operation, nil)
+					assert.Nil(t, err)
+					// Accounts are shared with the copy
+					assert.Equal(t, uint64(10372672437354497), transactionSubmitter.Accounts[seed].SequenceNumber)
+					mockHorizon.AssertExpectations(t)
+				})
+			})
+
+			Convey("Resubmits transaction when a response is lost", func() {
+				operation := b.Payment(
+					b.Destination{"GB3W7VQ2A2IOQIS4LUFUMRC2DWXONUDH24ROLE6RS4NGUNHVSXKCABOM"},
+					b.NativeAmount{"100"},
+				)
+
+				transactionSubmitter := NewTransactionSubmitter(
+					mockHorizon,
+					mockEntityManager,
+					"Test SDF Network ; September 2015",
+					mocks.Now,
+				)
+				var waits []time.Duration
+				transactionSubmitter.Retry = retry.NewPolicy(retry.Submitter, DefaultRetry, func(d time.Duration) { waits = append(waits, d) })
+
+				mockHorizon.On(
+					"LoadAccount",
+					accountID,
+				).Return(
+					horizon.AccountResponse{
+						AccountID:      accountID,
+						SequenceNumber: "10372672437354496",
+					},
+					nil,
+				).Once()
+
+				err := transactionSubmitter.InitAccount(seed)
+				assert.Nil(t, err)
+
+				txB64 := "AAAAAJbmB/pwwloZXCaCr9WR3Fue2lNhHGaDWKVOWO7MPq4QAAAAZAAk2eQAAAABAAAAAAAAAAAAAAABAAAAAAAAAAEAAAAAd2/WGgaQ6CJcXQtGRFodrubQZ9ci5ZPRlxpqNPWV1CAAAAAAAAAAADuaygAAAAAAAAAAAcw+rhAAAABAyFjIMIZOtstCWtZlVBDj1AhTmsk5v1i2GGY4by2b5mgZoXXGgFTB8sfbQav0LzFKCcxY8h+9xPMT2e9xznAfDw=="
+				hash := "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050"
+				timeout := &horizon.StatusError{StatusCode: http.StatusGatewayTimeout, Body: []byte("Timeout")}
+				badSeq := horizon.SubmitTransactionResponse{
+					Extras: &horizon.SubmitTransactionResponseExtras{
+						ResultXdr: "AAAAAAAAAAD////7AAAAAA==", // tx_bad_seq
+					},
+				}
+				successful := true
+				applied := horizon.TransactionResponse{
+					Hash:       hash,
+					Ledger:     1486276,
+					ResultXdr:  "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAABAAAAAAAAAAA=",
+					Successful: &successful,
+				}
+
+				var statuses []string
+				mockEntityManager.On(
+					"Persist",
+					mock.AnythingOfType("*entities.SentTransaction"),
+				).Return(nil).Twice().Run(func(args mock.Arguments) {
+					transaction := args.Get(0).(*entities.SentTransaction)
+					assert.Equal(t, hash, transaction.TransactionID)
+					statuses = append(statuses, string(transaction.Status))
+				})
+
+				Convey("First attempt was applied, resubmission fails with tx_bad_seq", func() {
+					mockHorizon.On("SubmitTransaction", txB64).Return(horizon.SubmitTransactionResponse{}, timeout).Once()
+					mockHorizon.On("SubmitTransaction", txB64).Return(badSeq, nil).Once()
+					mockHorizon.On("LoadTransaction", hash).Return(applied, nil).Once()
+
+					response, err := transactionSubmitter.SubmitTransaction(seed, operation, nil)
+					assert.Nil(t, err)
+					assert.Equal(t, uint64(1486276), *response.Ledger)
+					assert.Equal(t, applied.ResultXdr, *response.ResultXdr)
+					assert.Nil(t, response.Extras)
+					assert.Equal(t, []string{"sending", "success"}, statuses)
+					assert.Equal(t, []time.Duration{resubmitWait}, waits)
+					// Sequence number is not synced
+					assert.Equal(t, uint64(10372672437354497), transactionSubmitter.Accounts[seed].SequenceNumber)
+					mockHorizon.AssertExpectations(t)
+				})
+
+				Convey("Responses of all attempts are lost", func() {
+					lost := &url.Error{Op: "Post", URL: "https://horizon/transactions", Err: errors.New("connection reset by peer")}
+					mockHorizon.On("SubmitTransaction", txB64).Return(horizon.SubmitTransactionResponse{}, lost).Times(submitAttempts)
+					mockHorizon.On("LoadTransaction", hash).Return(applied, nil).Once()
+
+					response, err := transactionSubmitter.SubmitTransaction(seed, operation, nil)
+					assert.Nil(t, err)
+					assert.Equal(t, uint64(1486276), *response.Ledger)
+					assert.Equal(t, []string{"sending", "success"}, statuses)
+					assert.Len(t, waits, submitAttempts-1)
+					stats := transactionSubmitter.Retry.Stats()
+					assert.Equal(t, int64(1), stats.Exhausted)
+					assert.Equal(t, map[string]int64{"3": 1}, stats.Attempts)
+					mockHorizon.AssertExpectations(t)
+				})
+
+				Convey("First attempt was applied and failed", func() {
+					failed := false
+					applied.Successful = &failed
+					applied.ResultXdr = "AAAAAAAAAGT/////AAAAAQAAAAAAAAAB////+wAAAAA=" // no_destination
+
+					mockHorizon.On("SubmitTransaction", txB64).Return(horizon.SubmitTransactionResponse{}, timeout).Once()
+					mockHorizon.On("SubmitTransaction", txB64).Return(badSeq, nil).Once()
+					mockHorizon.On("LoadTransaction", hash).Return(applied, nil).Once()
+
+					response, err := transactionSubmitter.SubmitTransaction(seed, operation, nil)
+					assert.Nil(t, err)
+					assert.Nil(t, response.Ledger)
+					assert.Equal(t, applied.ResultXdr, response.Extras.ResultXdr)
+					assert.Equal(t, []string{"sending", "failure"}, statuses)
+					mockHorizon.AssertExpectations(t)
+				})
+
+				Convey("No attempt was applied", func() {
+					mockHorizon.On("SubmitTransaction", txB64).Return(horizon.SubmitTransactionResponse{}, timeout).Once()
+					mockHorizon.On("SubmitTransaction", txB64).Return(badSeq, nil).Once()
+					mockHorizon.On("LoadTransaction", hash).Return(
+						horizon.TransactionResponse{},
+						&horizon.StatusError{StatusCode: http.StatusNotFound},
+					).Once()
+
+					// Updating sequence number
+					mockHorizon.On(
+						"LoadAccount",
+						accountID,
+					).Return(
+						horizon.AccountResponse{
+							AccountID:      accountID,
+							SequenceNumber: "100",
+						},
+						nil,
+					).Once()
+
+					response, err := transactionSubmitter.SubmitTransaction(seed, operation, nil)
+					assert.Nil(t, err)
+					assert.Nil(t, response.Ledger)
+					assert.Equal(t, []string{"sending", "failure"}, statuses)
+					assert.Equal(t, uint64(100), transactionSubmitter.Accounts[seed].SequenceNumber)
+					mockHorizon.AssertExpectations(t)
+				})
+
+				Convey("Client errors are not resubmitted", func() {
+					mockHorizon.On("SubmitTransaction", txB64).Return(
+						horizon.SubmitTransactionResponse{},
+						&horizon.HorizonSchemaError{Resource: "transaction submission", Field: "ledger"},
+					).Once()
+
+					_, err := transactionSubmitter.SubmitTransaction(seed, operation, nil)
+					assert.NotNil(t, err)
+					assert.Empty(t, waits)
+					assert.Equal(t, []string{"sending"}, statuses)
+					mockHorizon.AssertExpectations(t)
+
+					// The second Persist is not called, remove it so it's not used by other tests
+					mockEntityManager.ExpectedCalls = nil
+				})
+
+				Convey("Retriable results are resubmitted", func() {
+					internalError := horizon.SubmitTransactionResponse{
+						Extras: &horizon.SubmitTransactionResponseExtras{
+							ResultXdr: "AAAAAAAAAGT////1AAAAAA==", // tx_internal_error
+						},
+					}
+					ledger := uint64(1486276)
+					mockHorizon.On("SubmitTransaction", txB64).Return(internalError, nil).Once()
+					mockHorizon.On("SubmitTransaction", txB64).Return(horizon.SubmitTransactionResponse{Hash: hash, Ledger: &ledger}, nil).Once()
+
+					response, err := transactionSubmitter.SubmitTransaction(seed, operation, nil)
+					assert.Nil(t, err)
+					assert.Equal(t, ledger, *response.Ledger)
+					assert.Equal(t, []string{"sending", "success"}, statuses)
+					assert.Equal(t, []time.Duration{resubmitWait}, waits)
+					mockHorizon.AssertExpectations(t)
+				})
+
+				Convey("Last failed response is returned when retriable results are exhausted", func() {
+					internalError := horizon.SubmitTransactionResponse{
+						Extras: &horizon.SubmitTransactionResponseExtras{
+							ResultXdr: "AAAAAAAAAGT////1AAAAAA==", // tx_internal_error
+						},
+					}
+					mockHorizon.On("SubmitTransaction", txB64).Return(internalError, nil).Times(submitAttempts)
+					mockHorizon.On("LoadTransaction", hash).Return(
+						horizon.TransactionResponse{},
+						&horizon.StatusError{StatusCode: http.StatusNotFound},
+					).Once()
+
+					response, err := transactionSubmitter.SubmitTransaction(seed, operation, nil)
+					assert.Nil(t, err)
+					assert.Nil(t, response.Ledger)
+					assert.Equal(t, internalError.Extras.ResultXdr, response.Extras.ResultXdr)
+					assert.Equal(t, []string{"sending", "failure"}, statuses)
+					assert.Equal(t, int64(1), transactionSubmitter.Retry.Stats().Exhausted)
+					mockHorizon.AssertExpectations(t)
+				})
 			})
 
 			Convey("Submits transaction with a memo", func() {
@@ -326,7 +624,7 @@ func TestTransactionSubmitter(t *testing.T) {
 						assert.Equal(t, "60cb3c020b0c97352cbabdf68a822b04baea61927b0f1ac31260a9f8d0150316", transaction.TransactionID)
 						assert.Equal(t, "sending", string(transaction.Status))
 						assert.Equal(t, "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", transaction.Source)
-						assert.Equal(t, mocks.PredefinedTime, transaction.SubmittedAt)
+						assert.Equal(t, utc.New(mocks.PredefinedTime), transaction.SubmittedAt)
 						assert.Equal(t, txB64, transaction.EnvelopeXdr)
 					})
 
@@ -339,7 +637,7 @@ func TestTransactionSubmitter(t *testing.T) {
 						assert.Equal(t, "60cb3c020b0c97352cbabdf68a822b04baea61927b0f1ac31260a9f8d0150316", transaction.TransactionID)
 						assert.Equal(t, "success", string(transaction.Status))
 						assert.Equal(t, "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", transaction.Source)
-						assert.Equal(t, mocks.PredefinedTime, transaction.SubmittedAt)
+						assert.Equal(t, utc.New(mocks.PredefinedTime), transaction.SubmittedAt)
 						assert.Equal(t, txB64, transaction.EnvelopeXdr)
 					})
 
@@ -359,3 +657,66 @@ func TestTransactionSubmitter(t *testing.T) {
 		})
 	})
 }
+
+// rateLimitingHorizon is a fake Horizon responding with 429 to limited requests after allowed
+// ones, other requests succeed
+func rateLimitingHorizon(allowed, limited int, retryAfter time.Duration) *httptest.Server {
+	requests := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > allowed && requests <= allowed+limited {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.URL.Path == "/transactions" {
+			fmt.Fprint(w, `{"hash": "4f885999be6ea7891052a53e496bcfb5c5a1a5bfb31923f649b028fdc74dd050", "ledger": 1486276, "envelope_xdr": "AAAA", "result_xdr": "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAABAAAAAAAAAAA="}`)
+			return
+		}
+		fmt.Fprint(w, `{"id": "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", "account_id": "GCLOMB72ODBFUGK4E2BK7VMR3RNZ5WSTMEOGNA2YUVHFR3WMH2XBAB6H", "sequence": "10372672437354496", "balances": [{"balance": "100.0000000", "asset_type": "native"}]}`)
+	}))
+}
+
+func TestTransactionSubmitterRateLimited(t *testing.T) {
+	seed := "SDZT3EJZ7FZRYNTLOZ7VH6G5UYBFO2IO3Q5PGONMILPCZU3AL7QNZHTE"
+	operation := b.Payment(
+		b.Destination{"GB3W7VQ2A2IOQIS4LUFUMRC2DWXONUDH24ROLE6RS4NGUNHVSXKCABOM"},
+		b.NativeAmount{"100"},
+	)
+	mockEntityManager := new(mocks.MockEntityManager)
+	mockEntityManager.On("Persist", mock.AnythingOfType("*entities.SentTransaction")).Return(nil)
+
+	submitter := func(server *httptest.Server, waits *[]time.Duration) TransactionSubmitter {
+		h := horizon.New(server.URL)
+		ts := NewTransactionSubmitter(&h, mockEntityManager, "Test SDF Network ; September 2015", mocks.Now)
+		ts.Retry = retry.NewPolicy(retry.Submitter, DefaultRetry, func(d time.Duration) { *waits = append(*waits, d) })
+		return ts
+	}
+
+	// Submission waits for resets of the limit without using resubmission attempts
+	server := rateLimitingHorizon(1, 2, 3*time.Second)
+	defer server.Close()
+	var waits []time.Duration
+	ts := submitter(server, &waits)
+
+	response, err := ts.SubmitTransaction(seed, operation, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(1486276), *response.Ledger)
+	}
+	assert.Equal(t, []time.Duration{3 * time.Second, 3 * time.Second}, waits)
+	stats := ts.Retry.Stats()
+	assert.Equal(t, int64(2), stats.RateLimited)
+	assert.Equal(t, map[string]int64{"1": 1}, stats.Attempts)
+
+	// Resets longer than rateLimitWait are returned
+	longServer := rateLimitingHorizon(1, 1, time.Minute)
+	defer longServer.Close()
+	waits = nil
+	ts = submitter(longServer, &waits)
+
+	_, err = ts.SubmitTransaction(seed, operation, nil)
+	if assert.IsType(t, &horizon.RateLimitedError{}, err) {
+		assert.Equal(t, time.Minute, err.(*horizon.RateLimitedError).RetryAfter)
+	}
+	assert.Empty(t, waits)
+}
@@ -0,0 +1,161 @@
+package txspec
+
+import (
+	"errors"
+
+	b "github.com/stellar/go/build"
+	"github.com/stellar/go/xdr"
+)
+
+// BuildBackend encodes transactions with mutators of github.com/stellar/go/build
+type BuildBackend struct{}
+
+// Build encodes spec to a transaction
+func (BuildBackend) Build(spec TxSpec) (*xdr.Transaction, error) {
+	mutators := []b.TransactionMutator{
+		b.SourceAccount{spec.Source},
+		b.Sequence{spec.Sequence},
+	}
+
+	for i, operation := range spec.Operations {
+		builder, err := buildOperation(operation)
+		if err == nil {
+			err = builderError(builder.Err())
+		}
+		if err != nil {
+			return nil, operationError(i, operation, err)
+		}
+		mutators = append(mutators, builder)
+	}
+
+	if spec.Memo != nil {
+		memo, err := buildMemo(*spec.Memo)
+		if err != nil {
+			return nil, err
+		}
+		mutators = append(mutators, memo)
+	}
+
+	fee, err := spec.fee()
+	if err != nil {
+		return nil, err
+	}
+	mutators = append(mutators, feeMutator(fee), timeBoundsMutator{spec.TimeBounds})
+
+	tx := b.Transaction(mutators...)
+	if tx.Err != nil {
+		return nil, tx.Err
+	}
+	return tx.TX, nil
+}
+
+// builderError returns errors of the build package caused by invalid asset codes and amounts as
+// ErrInvalidAssetCode and ErrInvalidAmount, the package has no error values to compare with
+func builderError(err error) error {
+	if err != nil && err.Error() == ErrInvalidAssetCode.Error() {
+		return ErrInvalidAssetCode
+	}
+	return amountError(err)
+}
+
+// operationBuilder is an operation builder of the build package. Builders collect errors of their
+// mutators, they are checked before the operation is added to the transaction so Build can tell
+// which operation is invalid.
+type operationBuilder interface {
+	b.TransactionMutator
+	Err() error
+}
+
+type paymentBuilder struct{ b.PaymentBuilder }
+
+func (builder paymentBuilder) Err() error { return builder.PaymentBuilder.Err }
+
+type createAccountBuilder struct{ b.CreateAccountBuilder }
+
+func (builder createAccountBuilder) Err() error { return builder.CreateAccountBuilder.Err }
+
+type changeTrustBuilder struct{ b.ChangeTrustBuilder }
+
+func (builder changeTrustBuilder) Err() error { return builder.ChangeTrustBuilder.Err }
+
+// feeMutator sets the fee of a transaction. Defaults, applied after all mutators, keeps fees that
+// are already set.
+type feeMutator uint32
+
+// MutateTransaction for feeMutator sets the fee of the transaction
+func (m feeMutator) MutateTransaction(o *b.TransactionBuilder) error {
+	o.TX.Fee = xdr.Uint32(m)
+	return nil
+}
+
+// timeBoundsMutator sets time bounds of a transaction, the build package has no mutator for them
+type timeBoundsMutator struct {
+	bounds *TimeBounds
+}
+
+// MutateTransaction for timeBoundsMutator sets time bounds of the transaction
+func (m timeBoundsMutator) MutateTransaction(o *b.TransactionBuilder) error {
+	o.TX.TimeBounds = m.bounds.encode()
+	return nil
+}
+
+func buildOperation(operation OperationSpec) (operationBuilder, error) {
+	var mutators []interface{}
+	if operation.Type == ChangeTrust {
+		if operation.Amount != "" {
+			mutators = append(mutators, b.Limit(operation.Amount))
+		}
+	} else {
+		mutators = append(mutators, b.Destination{operation.Destination})
+		if operation.Asset.Native() {
+			mutators = append(mutators, b.NativeAmount{operation.Amount})
+		} else {
+			mutators = append(mutators, b.CreditAmount{operation.Asset.Code, operation.Asset.Issuer, operation.Amount})
+		}
+	}
+
+	if operation.SendMax != "" {
+		payWith := b.PayWith(buildAsset(operation.SendAsset), operation.SendMax)
+		for _, asset := range operation.Path {
+			payWith = payWith.Through(buildAsset(asset))
+		}
+		mutators = append(mutators, payWith)
+	}
+
+	if operation.Source != "" {
+		mutators = append(mutators, b.SourceAccount{operation.Source})
+	}
+
+	switch operation.Type {
+	case Payment:
+		return paymentBuilder{b.Payment(mutators...)}, nil
+	case CreateAccount:
+		return createAccountBuilder{b.CreateAccount(mutators...)}, nil
+	case ChangeTrust:
+		return changeTrustBuilder{b.Trust(operation.Asset.Code, operation.Asset.Issuer, mutators...)}, nil
+	default:
+		return nil, errors.New("unsupported operation type: " + string(operation.Type))
+	}
+}
+
+func buildAsset(asset Asset) b.Asset {
+	if asset.Native() {
+		return b.NativeAsset()
+	}
+	return b.CreditAsset(asset.Code, asset.Issuer)
+}
+
+func buildMemo(memo Memo) (b.TransactionMutator, error) {
+	switch memo.Type {
+	case xdr.MemoTypeMemoId:
+		return b.MemoID{memo.ID}, nil
+	case xdr.MemoTypeMemoText:
+		return b.MemoText{memo.Text}, nil
+	case xdr.MemoTypeMemoHash:
+		return b.MemoHash{memo.Hash}, nil
+	case xdr.MemoTypeMemoReturn:
+		return b.MemoReturn{memo.Hash}, nil
+	default:
+		return nil, errors.New("unsupported memo type: " + memo.Type.String())
+	}
+}
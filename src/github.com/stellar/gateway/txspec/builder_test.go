@@ -0,0 +1,165 @@
+package txspec
+
+import (
+	"testing"
+
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendsBuildSameTransactions(t *testing.T) {
+	source := "GAHA6GRCLCCN7XE2NEEUDSIVOFBOQ6GLSYXVLYCJXJKLPMDR5XB5XZZJ"
+	destination := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+	usd := Asset{Code: "USD", Issuer: issuer}
+
+	tests := []struct {
+		name       string
+		operations []OperationSpec
+		memo       *Memo
+		fee        uint32
+		timeBounds *TimeBounds
+		err        error
+	}{
+		{
+			name:       "payment",
+			operations: []OperationSpec{{Type: Payment, Destination: destination, Asset: usd, Amount: "20.5"}},
+			memo:       &Memo{Type: xdr.MemoTypeMemoId, ID: 123},
+		},
+		{
+			name: "path payment of a 12 characters asset",
+			operations: []OperationSpec{{
+				Type:        Payment,
+				Destination: destination,
+				Asset:       Asset{Code: "LONGASSET123", Issuer: issuer},
+				Amount:      "1",
+				SendAsset:   usd,
+				SendMax:     "2",
+				Path:        []Asset{{}, {Code: "EUR", Issuer: issuer}},
+			}},
+			memo: &Memo{Type: xdr.MemoTypeMemoReturn, Hash: xdr.Hash{1, 2, 3}},
+		},
+		{
+			name: "trustline and create account with operation source",
+			operations: []OperationSpec{
+				{Type: ChangeTrust, Asset: usd},
+				{Type: ChangeTrust, Asset: usd, Amount: "100"},
+				{Type: CreateAccount, Source: issuer, Destination: destination, Amount: "1"},
+			},
+			memo: &Memo{Type: xdr.MemoTypeMemoText, Text: "text"},
+		},
+		{
+			name: "fee",
+			operations: []OperationSpec{
+				{Type: CreateAccount, Destination: destination, Amount: "1"},
+				{Type: Payment, Destination: destination, Asset: usd, Amount: "1"},
+			},
+			fee: 250,
+		},
+		{
+			name:       "time bounds",
+			operations: []OperationSpec{{Type: Payment, Destination: destination, Asset: usd, Amount: "1"}},
+			timeBounds: &TimeBounds{MinTime: 1488360615, MaxTime: 1488364215},
+		},
+		{
+			name:       "time bounds without max time",
+			operations: []OperationSpec{{Type: Payment, Destination: destination, Asset: usd, Amount: "1"}},
+			timeBounds: &TimeBounds{MinTime: 1488360615},
+		},
+		{
+			name: "fee lower than the minimum fee",
+			operations: []OperationSpec{
+				{Type: CreateAccount, Destination: destination, Amount: "1"},
+				{Type: Payment, Destination: destination, Asset: usd, Amount: "1"},
+			},
+			fee: 150,
+			err: ErrInvalidFee,
+		},
+		{
+			name:       "invalid asset code",
+			operations: []OperationSpec{{Type: Payment, Destination: destination, Asset: Asset{Code: "TOOLONGASSET1", Issuer: issuer}, Amount: "1"}},
+			err:        &OperationError{Index: 0, Type: Payment, Err: ErrInvalidAssetCode},
+		},
+		{
+			name:       "asset issuer without code",
+			operations: []OperationSpec{{Type: Payment, Destination: destination, Asset: Asset{Issuer: issuer}, Amount: "1"}},
+			err:        &OperationError{Index: 0, Type: Payment, Err: ErrInvalidAssetCode},
+		},
+		{
+			name:       "invalid amount",
+			operations: []OperationSpec{{Type: Payment, Destination: destination, Asset: usd, Amount: "test"}},
+			err:        &OperationError{Index: 0, Type: Payment, Err: ErrInvalidAmount},
+		},
+		{
+			name: "invalid starting balance of the second operation",
+			operations: []OperationSpec{
+				{Type: Payment, Destination: destination, Asset: usd, Amount: "1"},
+				{Type: CreateAccount, Destination: destination, Amount: "test"},
+			},
+			err: &OperationError{Index: 1, Type: CreateAccount, Err: ErrInvalidAmount},
+		},
+		{
+			name:       "invalid send max",
+			operations: []OperationSpec{{Type: Payment, Destination: destination, Asset: usd, Amount: "1", SendMax: "test"}},
+			err:        &OperationError{Index: 0, Type: Payment, Err: ErrInvalidAmount},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec := TxSpec{Source: source, Sequence: 101, Memo: test.memo, Operations: test.operations, Fee: test.fee, TimeBounds: test.timeBounds}
+
+			expected, expectedErr := Backend(BackendBuild).Build(spec)
+			tx, err := Backend(BackendXDR).Build(spec)
+			assert.Equal(t, test.err, expectedErr)
+			assert.Equal(t, test.err, err)
+			if test.err != nil {
+				return
+			}
+
+			expectedXDR, err := xdr.MarshalBase64(expected)
+			require.NoError(t, err)
+			txXDR, err := xdr.MarshalBase64(tx)
+			require.NoError(t, err)
+			assert.Equal(t, expectedXDR, txXDR)
+			if test.fee != 0 {
+				assert.Equal(t, xdr.Uint32(test.fee), tx.Fee)
+			}
+			if test.timeBounds != nil {
+				assert.Equal(t, &xdr.TimeBounds{MinTime: xdr.Uint64(test.timeBounds.MinTime), MaxTime: xdr.Uint64(test.timeBounds.MaxTime)}, tx.TimeBounds)
+			}
+		})
+	}
+}
+
+func TestBackendsRejectInvalidSpecs(t *testing.T) {
+	destination := "GAPCT362RATBUJ37RN2MOKQIZLHSJMO33MMCSRUXTTHIGVDYWOFG5HDS"
+	issuer := "GCF3WVYTHF75PEG6622G5G6KU26GOSDQPDHSCJ3DQD7VONH4EYVDOGKJ"
+
+	specs := map[string]TxSpec{
+		"invalid source": {Source: "invalid"},
+		"path payment creating account": {Source: destination, Operations: []OperationSpec{
+			{Type: CreateAccount, Destination: issuer, Amount: "1", SendMax: "1"},
+		}},
+		"account created with credit asset": {Source: destination, Operations: []OperationSpec{
+			{Type: CreateAccount, Destination: issuer, Asset: Asset{Code: "USD", Issuer: issuer}, Amount: "1"},
+		}},
+		"text memo too long": {Source: destination, Memo: &Memo{Type: xdr.MemoTypeMemoText, Text: "012345678901234567890123456789"}},
+	}
+
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			_, err := Backend(BackendBuild).Build(spec)
+			assert.Error(t, err)
+			_, err = Backend(BackendXDR).Build(spec)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBackend(t *testing.T) {
+	assert.Equal(t, BuildBackend{}, Backend(""))
+	assert.Equal(t, XDRBackend{}, Backend(BackendXDR))
+	assert.Nil(t, Backend("txnbuild"))
+}
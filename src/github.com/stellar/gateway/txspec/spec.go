@@ -0,0 +1,186 @@
+// Package txspec describes transactions built by the bridge server independently of the library
+// encoding them. Handlers describe a transaction as a TxSpec and a Builder backend encodes it to
+// XDR, so the backend can be replaced (ex. when migrating to a newer SDK) without changing
+// handlers. Every backend must encode a spec to the same bytes.
+package txspec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go/xdr"
+)
+
+// OperationType is a type of operation that can be described by a spec
+type OperationType string
+
+const (
+	// Payment sends Amount of Asset to Destination, it is a path payment paying at most SendMax
+	// of SendAsset when SendMax is set
+	Payment OperationType = "payment"
+	// CreateAccount creates Destination with a starting balance of Amount
+	CreateAccount OperationType = "create_account"
+	// ChangeTrust creates or updates a trustline of Asset with a limit of Amount
+	ChangeTrust OperationType = "change_trust"
+)
+
+const (
+	// BackendBuild encodes transactions with github.com/stellar/go/build
+	BackendBuild = "build"
+	// BackendXDR encodes transactions to XDR structures directly, without a builder library
+	BackendXDR = "xdr"
+)
+
+// MemoTextMaxLength is the maximum length of text memos in bytes
+const MemoTextMaxLength = 28
+
+// BaseFee is the minimum fee of an operation in stroops
+const BaseFee = 100
+
+// BaseReserve is the base reserve of the public and test networks in stroops, the minimum
+// balance of an account is 2 base reserves
+const BaseReserve = 5000000
+
+var (
+	// ErrInvalidAssetCode is returned when an asset code is empty or longer than 12 characters
+	ErrInvalidAssetCode = errors.New("Asset code length is invalid")
+	// ErrInvalidAmount is returned when an amount is not a number
+	ErrInvalidAmount = errors.New("cannot parse amount")
+	// ErrInvalidFee is returned when the fee of a spec is lower than BaseFee per operation
+	ErrInvalidFee = errors.New("fee is lower than the minimum fee of the transaction")
+)
+
+// OperationError is returned by builders when an operation of a spec cannot be encoded. Err is the
+// cause (ex. ErrInvalidAmount), Index and Type tell which operation of the spec failed.
+type OperationError struct {
+	Index int
+	Type  OperationType
+	Err   error
+}
+
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %s", e.Index, e.Type, e.Err)
+}
+
+// Unwrap returns the cause of the error
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// Asset is a Stellar asset, XLM when both Code and Issuer are empty
+type Asset struct {
+	Code   string
+	Issuer string
+}
+
+// Native returns true for XLM
+func (a Asset) Native() bool {
+	return a == Asset{}
+}
+
+// OperationSpec describes a single operation of a transaction
+type OperationSpec struct {
+	Type OperationType
+	// Source is the source account of the operation, transaction source when empty
+	Source      string
+	Destination string
+	// Asset is the asset received by the destination or the asset of a trustline
+	Asset Asset
+	// Amount is the amount received by the destination, the starting balance of a created account
+	// or the limit of a trustline (maximum limit when empty)
+	Amount string
+	// SendAsset, SendMax and Path are set for path payments only
+	SendAsset Asset
+	SendMax   string
+	Path      []Asset
+}
+
+// Memo is a transaction memo, ID, Text or Hash is used depending on Type
+type Memo struct {
+	Type xdr.MemoType
+	ID   uint64
+	Text string
+	// Hash is the value of hash and return memos
+	Hash xdr.Hash
+}
+
+// TimeBounds are unix timestamps between which a transaction can be included in a ledger,
+// MaxTime 0 means no upper limit
+type TimeBounds struct {
+	MinTime uint64 `json:"min_time"`
+	MaxTime uint64 `json:"max_time"`
+}
+
+// encode returns XDR time bounds, nil when bounds is nil
+func (bounds *TimeBounds) encode() *xdr.TimeBounds {
+	if bounds == nil {
+		return nil
+	}
+	return &xdr.TimeBounds{MinTime: xdr.Uint64(bounds.MinTime), MaxTime: xdr.Uint64(bounds.MaxTime)}
+}
+
+// TxSpec describes a transaction
+type TxSpec struct {
+	Source string
+	// Sequence is the sequence number of the transaction (next sequence number of Source)
+	Sequence   uint64
+	Memo       *Memo
+	Operations []OperationSpec
+	// Fee is the fee of the whole transaction in stroops, BaseFee per operation when 0
+	Fee uint32
+	// TimeBounds of the transaction, it's valid at any time when nil
+	TimeBounds *TimeBounds
+}
+
+// MinFee returns the minimum fee of the transaction of spec
+func (spec TxSpec) MinFee() uint32 {
+	return uint32(BaseFee * len(spec.Operations))
+}
+
+// fee returns the fee of the transaction of spec or ErrInvalidFee when it is too low
+func (spec TxSpec) fee() (uint32, error) {
+	if spec.Fee == 0 {
+		return spec.MinFee(), nil
+	}
+	if spec.Fee < spec.MinFee() {
+		return 0, ErrInvalidFee
+	}
+	return spec.Fee, nil
+}
+
+// Builder encodes transaction specs. The fee of transactions is Fee of the spec, BaseFee stroops
+// per operation when it is not set.
+type Builder interface {
+	Build(spec TxSpec) (*xdr.Transaction, error)
+}
+
+// Backend returns the builder of a backend, the build backend when name is empty and nil when the
+// backend is unknown
+func Backend(name string) Builder {
+	switch name {
+	case "", BackendBuild:
+		return BuildBackend{}
+	case BackendXDR:
+		return XDRBackend{}
+	default:
+		return nil
+	}
+}
+
+// amountError returns ErrInvalidAmount for errors of amount.Parse caused by values that are not
+// numbers and err otherwise
+func amountError(err error) error {
+	if err != nil && strings.HasPrefix(err.Error(), ErrInvalidAmount.Error()) {
+		return ErrInvalidAmount
+	}
+	return err
+}
+
+// operationError wraps err of the index operation of a spec in OperationError, nil when err is nil
+func operationError(index int, operation OperationSpec, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &OperationError{Index: index, Type: operation.Type, Err: err}
+}
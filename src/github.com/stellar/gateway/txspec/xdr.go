@@ -0,0 +1,222 @@
+package txspec
+
+import (
+	"errors"
+	"math"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// XDRBackend encodes transactions to XDR structures directly, the way txnbuild of newer SDKs
+// does. It validates specs in the same order as BuildBackend so both return the same errors.
+type XDRBackend struct{}
+
+// Build encodes spec to a transaction
+func (XDRBackend) Build(spec TxSpec) (*xdr.Transaction, error) {
+	tx := &xdr.Transaction{SeqNum: xdr.SequenceNumber(spec.Sequence)}
+	err := setAccountID(spec.Source, &tx.SourceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range spec.Operations {
+		xdrOperation, err := encodeOperation(operation)
+		if err != nil {
+			return nil, operationError(i, operation, err)
+		}
+		tx.Operations = append(tx.Operations, xdrOperation)
+	}
+
+	if spec.Memo != nil {
+		tx.Memo, err = encodeMemo(*spec.Memo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fee, err := spec.fee()
+	if err != nil {
+		return nil, err
+	}
+	tx.Fee = xdr.Uint32(fee)
+	tx.TimeBounds = spec.TimeBounds.encode()
+	return tx, nil
+}
+
+func encodeOperation(operation OperationSpec) (xdrOperation xdr.Operation, err error) {
+	var body interface{}
+	var operationType xdr.OperationType
+
+	switch operation.Type {
+	case Payment:
+		body, operationType, err = encodePayment(operation)
+	case CreateAccount:
+		operationType = xdr.OperationTypeCreateAccount
+		body, err = encodeCreateAccount(operation)
+	case ChangeTrust:
+		operationType = xdr.OperationTypeChangeTrust
+		body, err = encodeChangeTrust(operation)
+	default:
+		err = errors.New("unsupported operation type: " + string(operation.Type))
+	}
+	if err != nil {
+		return
+	}
+
+	if operation.Source != "" {
+		xdrOperation.SourceAccount = &xdr.AccountId{}
+		err = setAccountID(operation.Source, xdrOperation.SourceAccount)
+		if err != nil {
+			return
+		}
+	}
+
+	xdrOperation.Body, err = xdr.NewOperationBody(operationType, body)
+	return
+}
+
+func encodePayment(operation OperationSpec) (interface{}, xdr.OperationType, error) {
+	var destination xdr.AccountId
+	err := setAccountID(operation.Destination, &destination)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	destAmount, err := parseAmount(operation.Amount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	destAsset, err := encodeAsset(operation.Asset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if operation.SendMax == "" {
+		return xdr.PaymentOp{Destination: destination, Asset: destAsset, Amount: destAmount}, xdr.OperationTypePayment, nil
+	}
+
+	op := xdr.PathPaymentOp{Destination: destination, DestAsset: destAsset, DestAmount: destAmount}
+	op.SendMax, err = parseAmount(operation.SendMax)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, asset := range operation.Path {
+		xdrAsset, err := encodeAsset(asset)
+		if err != nil {
+			return nil, 0, err
+		}
+		op.Path = append(op.Path, xdrAsset)
+	}
+
+	op.SendAsset, err = encodeAsset(operation.SendAsset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return op, xdr.OperationTypePathPayment, nil
+}
+
+func encodeCreateAccount(operation OperationSpec) (op xdr.CreateAccountOp, err error) {
+	err = setAccountID(operation.Destination, &op.Destination)
+	if err != nil {
+		return
+	}
+
+	if !operation.Asset.Native() {
+		err = errors.New("create_account operation can only fund accounts with XLM")
+		return
+	}
+
+	op.StartingBalance, err = parseAmount(operation.Amount)
+	if err != nil {
+		return
+	}
+
+	if operation.SendMax != "" {
+		err = errors.New("create_account operation cannot be a path payment")
+	}
+	return
+}
+
+func encodeChangeTrust(operation OperationSpec) (op xdr.ChangeTrustOp, err error) {
+	// XLM is rejected as a credit asset with an empty code
+	op.Line, err = encodeCreditAsset(operation.Asset)
+	if err != nil {
+		return
+	}
+
+	op.Limit = xdr.Int64(math.MaxInt64)
+	if operation.Amount != "" {
+		op.Limit, err = parseAmount(operation.Amount)
+		if err != nil {
+			return
+		}
+	}
+
+	if operation.SendMax != "" {
+		err = errors.New("change_trust operation cannot be a path payment")
+	}
+	return
+}
+
+func encodeAsset(asset Asset) (xdr.Asset, error) {
+	if asset.Native() {
+		return xdr.NewAsset(xdr.AssetTypeAssetTypeNative, nil)
+	}
+	return encodeCreditAsset(asset)
+}
+
+func encodeCreditAsset(asset Asset) (xdr.Asset, error) {
+	var issuer xdr.AccountId
+	err := setAccountID(asset.Issuer, &issuer)
+	if err != nil {
+		return xdr.Asset{}, err
+	}
+
+	length := len(asset.Code)
+	switch {
+	case length >= 1 && length <= 4:
+		var code [4]byte
+		copy(code[:], asset.Code)
+		return xdr.NewAsset(xdr.AssetTypeAssetTypeCreditAlphanum4, xdr.AssetAlphaNum4{AssetCode: code, Issuer: issuer})
+	case length >= 5 && length <= 12:
+		var code [12]byte
+		copy(code[:], asset.Code)
+		return xdr.NewAsset(xdr.AssetTypeAssetTypeCreditAlphanum12, xdr.AssetAlphaNum12{AssetCode: code, Issuer: issuer})
+	default:
+		return xdr.Asset{}, ErrInvalidAssetCode
+	}
+}
+
+func encodeMemo(memo Memo) (xdr.Memo, error) {
+	switch memo.Type {
+	case xdr.MemoTypeMemoId:
+		return xdr.NewMemo(xdr.MemoTypeMemoId, xdr.Uint64(memo.ID))
+	case xdr.MemoTypeMemoText:
+		if len(memo.Text) > MemoTextMaxLength {
+			return xdr.Memo{}, errors.New("Memo too long; over 28 bytes")
+		}
+		return xdr.NewMemo(xdr.MemoTypeMemoText, memo.Text)
+	case xdr.MemoTypeMemoHash, xdr.MemoTypeMemoReturn:
+		return xdr.NewMemo(memo.Type, memo.Hash)
+	default:
+		return xdr.Memo{}, errors.New("unsupported memo type: " + memo.Type.String())
+	}
+}
+
+func parseAmount(value string) (xdr.Int64, error) {
+	result, err := amount.Parse(value)
+	return result, amountError(err)
+}
+
+// setAccountID sets aid to the account of an address or a seed
+func setAccountID(addressOrSeed string, aid *xdr.AccountId) error {
+	kp, err := keypair.Parse(addressOrSeed)
+	if err != nil {
+		return err
+	}
+	return aid.SetAddress(kp.Address())
+}
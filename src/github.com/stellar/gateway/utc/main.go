@@ -0,0 +1,125 @@
+// Package utc contains a timestamp type used in all data emitted by the servers
+package utc
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Format is the only format timestamps are emitted in: RFC3339 in UTC with second precision
+const Format = "2006-01-02T15:04:05Z"
+
+// legacyFormats are formats of timestamps stored as strings (ex. MySQL without parseTime=true).
+// Values without offset are UTC.
+var legacyFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Time is a point in time stored as seconds since Unix epoch, independent of a location of the
+// server. It's encoded in JSON as `Format` and stored in DB as UTC.
+type Time struct {
+	unix int64
+}
+
+// New creates Time from time.Time in any location
+func New(t time.Time) Time {
+	return Time{t.Unix()}
+}
+
+// Now returns current Time
+func Now() Time {
+	return New(time.Now())
+}
+
+// Unix creates Time from seconds since Unix epoch
+func Unix(sec int64) Time {
+	return Time{sec}
+}
+
+// Parse parses RFC3339 timestamp with any offset and normalizes it to UTC
+func Parse(value string) (Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return Time{}, fmt.Errorf("timestamp must be RFC3339, ex. %s", Format)
+	}
+	return New(t), nil
+}
+
+// Time returns time.Time in UTC
+func (t Time) Time() time.Time {
+	return time.Unix(t.unix, 0).UTC()
+}
+
+// Unix returns seconds since Unix epoch
+func (t Time) Unix() int64 {
+	return t.unix
+}
+
+// String returns t in `Format`
+func (t Time) String() string {
+	return t.Time().Format(Format)
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	*t, err = Parse(value)
+	return err
+}
+
+// Scan implements database/sql.Scanner interface
+func (t *Time) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case time.Time:
+		*t = New(src)
+		return nil
+	case int64:
+		*t = Unix(src)
+		return nil
+	case []byte:
+		return t.scanString(string(src))
+	case string:
+		return t.scanString(src)
+	default:
+		return fmt.Errorf("cannot convert %T to utc.Time", src)
+	}
+}
+
+func (t *Time) scanString(src string) error {
+	if sec, err := strconv.ParseInt(src, 10, 64); err == nil {
+		*t = Unix(sec)
+		return nil
+	}
+
+	for _, format := range legacyFormats {
+		parsed, err := time.ParseInLocation(format, src, time.UTC)
+		if err == nil {
+			*t = New(parsed)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot parse %s as utc.Time", src)
+}
+
+// Value implements driver.Valuer
+func (t Time) Value() (driver.Value, error) {
+	return t.Time(), nil
+}
+
+var _ driver.Valuer = Time{}
@@ -0,0 +1,68 @@
+package utc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTime(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	local := time.Date(2017, 3, 1, 10, 30, 15, 500, cet)
+
+	Convey("Time", t, func() {
+		Convey("is encoded as RFC3339 UTC regardless of location", func() {
+			data, err := json.Marshal(New(local))
+			assert.NoError(t, err)
+			assert.Equal(t, `"2017-03-01T09:30:15Z"`, string(data))
+		})
+
+		Convey("Parse normalizes offsets", func() {
+			for _, value := range []string{"2017-03-01T09:30:15Z", "2017-03-01T10:30:15+01:00", "2017-03-01T04:30:15-05:00"} {
+				parsed, err := Parse(value)
+				assert.NoError(t, err)
+				assert.Equal(t, New(local), parsed)
+			}
+
+			_, err := Parse("2017-03-01 09:30:15")
+			assert.Error(t, err)
+		})
+
+		Convey("UnmarshalJSON accepts any offset", func() {
+			var parsed Time
+			err := json.Unmarshal([]byte(`"2017-03-01T10:30:15+01:00"`), &parsed)
+			assert.NoError(t, err)
+			assert.Equal(t, New(local), parsed)
+		})
+
+		Convey("Scan", func() {
+			expected := New(local)
+			for _, src := range []interface{}{
+				local,
+				int64(1488360615),
+				[]byte("2017-03-01 09:30:15"),
+				"2017-03-01 10:30:15.123+01:00",
+				"2017-03-01T10:30:15+01:00",
+				"1488360615",
+			} {
+				var scanned Time
+				assert.NoError(t, scanned.Scan(src), "%v", src)
+				assert.Equal(t, expected, scanned, "%v", src)
+			}
+
+			var scanned Time
+			assert.Error(t, scanned.Scan("yesterday"))
+			assert.Error(t, scanned.Scan(1.5))
+		})
+
+		Convey("Value is UTC", func() {
+			value, err := New(local).Value()
+			assert.NoError(t, err)
+			assert.Equal(t, time.UTC, value.(time.Time).Location())
+			assert.Equal(t, local.Unix(), value.(time.Time).Unix())
+		})
+	})
+}
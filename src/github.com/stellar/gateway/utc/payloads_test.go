@@ -0,0 +1,108 @@
+package utc_test
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stellar/gateway/db/entities"
+	"github.com/stellar/gateway/utc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// payloadPackages contain structs encoded in requests, responses, callbacks and the DB. New
+// packages with payload structs must be added here.
+var payloadPackages = []string{
+	"../backfill",
+	"../signatures",
+	"../bridge/handlers",
+	"../compliance/handlers",
+	"../db/entities",
+	"../horizon",
+	"../listener",
+	"../logging",
+	"../protocols",
+	"../protocols/bridge",
+	"../protocols/compliance",
+	"../stats",
+}
+
+// isTimeType returns true if expr is time.Time, *time.Time, []time.Time etc.
+func isTimeType(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return isTimeType(expr.X)
+	case *ast.ArrayType:
+		return isTimeType(expr.Elt)
+	case *ast.MapType:
+		return isTimeType(expr.Key) || isTimeType(expr.Value)
+	case *ast.SelectorExpr:
+		pkg, ok := expr.X.(*ast.Ident)
+		return ok && pkg.Name == "time" && expr.Sel.Name == "Time"
+	}
+	return false
+}
+
+func TestPayloadsUseUTCTime(t *testing.T) {
+	fset := token.NewFileSet()
+	for _, dir := range payloadPackages {
+		packages, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+			return !strings.HasSuffix(info.Name(), "_test.go")
+		}, 0)
+		require.NoError(t, err)
+
+		for _, pkg := range packages {
+			for filename, file := range pkg.Files {
+				ast.Inspect(file, func(node ast.Node) bool {
+					structType, ok := node.(*ast.StructType)
+					if !ok {
+						return true
+					}
+
+					for _, field := range structType.Fields.List {
+						if isTimeType(field.Type) {
+							position := fset.Position(field.Pos())
+							t.Errorf(
+								"%s:%d: payload struct field uses time.Time, use utc.Time instead",
+								filepath.ToSlash(filename),
+								position.Line,
+							)
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+}
+
+func TestEntitiesEncodeUTCTimestamps(t *testing.T) {
+	timestamp := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`)
+	now := utc.New(time.Now().In(time.FixedZone("CET", 3600)))
+
+	var ledger uint64 = 1
+	for _, payload := range []interface{}{
+		entities.ReceivedPayment{ProcessedAt: now},
+		entities.SentTransaction{SubmittedAt: now, SucceededAt: &now, Ledger: &ledger},
+	} {
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &fields))
+
+		for name, value := range fields {
+			if strings.HasSuffix(name, "_at") {
+				assert.Regexp(t, timestamp, value, "%T.%s", payload, name)
+			}
+		}
+	}
+}
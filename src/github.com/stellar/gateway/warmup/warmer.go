@@ -0,0 +1,242 @@
+// Package warmup prepares caches and connections used by the first payments after a start
+package warmup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/gateway/utc"
+)
+
+// States of a warm-up
+const (
+	StateDisabled = "disabled"
+	StatePending  = "pending"
+	StateRunning  = "running"
+	StateDone     = "done"
+)
+
+// Steps of a warm-up, keys of Status.Failures
+const (
+	StepFederation = "federation"
+	StepSequences  = "sequences"
+	StepHorizon    = "horizon"
+)
+
+// FederationPreloader caches responses of warm federation addresses
+type FederationPreloader interface {
+	SetAddresses(addresses []string)
+	Preload(address string) error
+}
+
+// AccountLoader loads sequence numbers of source accounts, implemented by submitter.TransactionSubmitter
+type AccountLoader interface {
+	InitAccount(seed string) error
+}
+
+// HorizonClient is used to open connections to Horizon
+type HorizonClient interface {
+	LoadLatestLedger() (uint32, error)
+}
+
+// Settings of a Warmer
+type Settings struct {
+	// FederationAddresses are resolved in advance and kept in the cache
+	FederationAddresses []string
+	// Seeds are source accounts whose sequence numbers are loaded
+	Seeds []string
+	// HorizonConnections is a number of concurrent requests opening keep-alive connections
+	HorizonConnections int
+}
+
+// Status is returned by /status endpoint
+type Status struct {
+	State      string    `json:"state"`
+	StartedAt  *utc.Time `json:"started_at,omitempty"`
+	FinishedAt *utc.Time `json:"finished_at,omitempty"`
+	// Failures is a number of failed warm-up requests by step since start, including warm-ups
+	// of addresses added by /admin/reload
+	Failures map[string]int64 `json:"failures"`
+}
+
+// Warmer runs a warm-up in the background. Failures are logged and counted, they never block
+// handling of requests: cold caches are filled by requests as without warm-up.
+type Warmer struct {
+	federation FederationPreloader
+	accounts   AccountLoader
+	horizon    HorizonClient
+	now        func() time.Time
+	log        *logrus.Entry
+
+	mutex      sync.Mutex
+	settings   Settings
+	state      string
+	startedAt  time.Time
+	finishedAt time.Time
+	failures   map[string]int64
+}
+
+// NewWarmer creates a new Warmer. Warmer with nil federation, accounts and horizon is disabled.
+func NewWarmer(federation FederationPreloader, accounts AccountLoader, horizon HorizonClient, settings Settings, now func() time.Time) *Warmer {
+	w := &Warmer{
+		federation: federation,
+		accounts:   accounts,
+		horizon:    horizon,
+		now:        now,
+		log:        logrus.WithFields(logrus.Fields{"service": "Warmer"}),
+		settings:   settings,
+		state:      StatePending,
+		failures:   map[string]int64{StepFederation: 0, StepSequences: 0, StepHorizon: 0},
+	}
+	if federation == nil && accounts == nil && horizon == nil {
+		w.state = StateDisabled
+	}
+	if federation != nil {
+		federation.SetAddresses(settings.FederationAddresses)
+	}
+	return w
+}
+
+// Run starts the warm-up in the background
+func (w *Warmer) Run() {
+	if w.state == StateDisabled {
+		return
+	}
+	go w.Warm()
+}
+
+// Warm runs the warm-up and returns when it's finished
+func (w *Warmer) Warm() {
+	w.mutex.Lock()
+	w.state = StateRunning
+	w.startedAt = w.now()
+	settings := w.settings
+	w.mutex.Unlock()
+
+	w.log.Info("Warm-up started")
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		w.warmFederation(settings.FederationAddresses)
+	}()
+	go func() {
+		defer wg.Done()
+		w.warmSequences(settings.Seeds)
+	}()
+	go func() {
+		defer wg.Done()
+		w.warmHorizon(settings.HorizonConnections)
+	}()
+	wg.Wait()
+
+	w.mutex.Lock()
+	w.state = StateDone
+	w.finishedAt = w.now()
+	failures := w.failures[StepFederation] + w.failures[StepSequences] + w.failures[StepHorizon]
+	w.mutex.Unlock()
+
+	w.log.WithFields(logrus.Fields{"failures": failures}).Info("Warm-up finished")
+}
+
+// SetFederationAddresses replaces warm federation addresses (ex. by /admin/reload) and resolves
+// the added ones in the background
+func (w *Warmer) SetFederationAddresses(addresses []string) {
+	if w.federation == nil {
+		return
+	}
+
+	w.mutex.Lock()
+	known := make(map[string]bool, len(w.settings.FederationAddresses))
+	for _, address := range w.settings.FederationAddresses {
+		known[address] = true
+	}
+	w.settings.FederationAddresses = addresses
+	w.mutex.Unlock()
+
+	w.federation.SetAddresses(addresses)
+
+	added := []string{}
+	for _, address := range addresses {
+		if !known[address] {
+			added = append(added, address)
+		}
+	}
+	go w.warmFederation(added)
+}
+
+// Status returns the state of the warm-up
+func (w *Warmer) Status() Status {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	status := Status{State: w.state, Failures: make(map[string]int64, len(w.failures))}
+	for step, count := range w.failures {
+		status.Failures[step] = count
+	}
+	if !w.startedAt.IsZero() {
+		startedAt := utc.New(w.startedAt)
+		status.StartedAt = &startedAt
+	}
+	if !w.finishedAt.IsZero() {
+		finishedAt := utc.New(w.finishedAt)
+		status.FinishedAt = &finishedAt
+	}
+	return status
+}
+
+func (w *Warmer) warmFederation(addresses []string) {
+	if w.federation == nil {
+		return
+	}
+	for _, address := range addresses {
+		err := w.federation.Preload(address)
+		if err != nil {
+			w.fail(StepFederation, logrus.Fields{"address": address, "err": err})
+		}
+	}
+}
+
+func (w *Warmer) warmSequences(seeds []string) {
+	if w.accounts == nil {
+		return
+	}
+	for _, seed := range seeds {
+		// Errors of the submitter contain account IDs, never seeds
+		err := w.accounts.InitAccount(seed)
+		if err != nil {
+			w.fail(StepSequences, logrus.Fields{"err": err})
+		}
+	}
+}
+
+// warmHorizon sends concurrent requests so the HTTP client keeps that many idle connections
+func (w *Warmer) warmHorizon(connections int) {
+	if w.horizon == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < connections; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := w.horizon.LoadLatestLedger()
+			if err != nil {
+				w.fail(StepHorizon, logrus.Fields{"err": err})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Warmer) fail(step string, fields logrus.Fields) {
+	w.mutex.Lock()
+	w.failures[step]++
+	w.mutex.Unlock()
+
+	fields["step"] = step
+	w.log.WithFields(fields).Warn("Warm-up request failed")
+}
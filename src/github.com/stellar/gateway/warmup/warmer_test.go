@@ -0,0 +1,99 @@
+package warmup
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubWarmed records warmed items, items in fail fail
+type stubWarmed struct {
+	mutex     sync.Mutex
+	fail      map[string]bool
+	addresses []string
+	warmed    []string
+}
+
+func (s *stubWarmed) SetAddresses(addresses []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.addresses = addresses
+}
+
+func (s *stubWarmed) Preload(address string) error {
+	return s.warm(address)
+}
+
+func (s *stubWarmed) InitAccount(seed string) error {
+	return s.warm(seed)
+}
+
+func (s *stubWarmed) LoadLatestLedger() (uint32, error) {
+	return 1, s.warm("ledger")
+}
+
+func (s *stubWarmed) warm(item string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.warmed = append(s.warmed, item)
+	if s.fail[item] {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func (s *stubWarmed) items() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	items := append([]string{}, s.warmed...)
+	sort.Strings(items)
+	return items
+}
+
+func TestWarmer(t *testing.T) {
+	now := time.Date(2017, 3, 1, 9, 30, 0, 0, time.UTC)
+	stub := &stubWarmed{fail: map[string]bool{"bob*stellar.org": true, "ledger": true}}
+	settings := Settings{
+		FederationAddresses: []string{"alice*stellar.org", "bob*stellar.org"},
+		Seeds:               []string{"SBASE", "SAUTHORIZING"},
+		HorizonConnections:  2,
+	}
+	warmer := NewWarmer(stub, stub, stub, settings, func() time.Time { return now })
+
+	assert.Equal(t, settings.FederationAddresses, stub.addresses)
+	assert.Equal(t, StatePending, warmer.Status().State)
+
+	warmer.Warm()
+	assert.Equal(t, []string{"SAUTHORIZING", "SBASE", "alice*stellar.org", "bob*stellar.org", "ledger", "ledger"}, stub.items())
+
+	status := warmer.Status()
+	assert.Equal(t, StateDone, status.State)
+	assert.Equal(t, "2017-03-01T09:30:00Z", status.FinishedAt.String())
+	assert.Equal(t, map[string]int64{StepFederation: 1, StepSequences: 0, StepHorizon: 2}, status.Failures)
+}
+
+func TestWarmerSetFederationAddresses(t *testing.T) {
+	stub := &stubWarmed{}
+	warmer := NewWarmer(stub, nil, nil, Settings{FederationAddresses: []string{"alice*stellar.org"}}, time.Now)
+	warmer.Warm()
+
+	warmer.SetFederationAddresses([]string{"alice*stellar.org", "bob*stellar.org"})
+	assert.Equal(t, []string{"alice*stellar.org", "bob*stellar.org"}, stub.addresses)
+
+	// Only added addresses are resolved
+	for deadline := time.Now().Add(time.Second); len(stub.items()) < 2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []string{"alice*stellar.org", "bob*stellar.org"}, stub.items())
+}
+
+func TestWarmerDisabled(t *testing.T) {
+	warmer := NewWarmer(nil, nil, nil, Settings{}, time.Now)
+	warmer.Run()
+	warmer.SetFederationAddresses([]string{"alice*stellar.org"})
+	assert.Equal(t, StateDisabled, warmer.Status().State)
+}
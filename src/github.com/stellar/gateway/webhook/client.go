@@ -0,0 +1,219 @@
+// Package webhook sends callbacks. Callback hosts are checked against an allowlist and each
+// callback destination can use its own TLS options (custom CA bundle, disabled verification).
+package webhook
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/support/errors"
+)
+
+// Timeout of a single callback request
+const Timeout = 60 * time.Second
+
+// ErrHostNotAllowed is returned when a callback URL host does not match the allowlist
+var ErrHostNotAllowed = errors.New("Callback host is not allowed")
+
+// TLSOptions are TLS options of a single callback destination
+type TLSOptions struct {
+	// CABundle is a path of PEM encoded certificates trusted in addition to system roots
+	CABundle string `mapstructure:"ca_bundle"`
+	// InsecureSkipVerify disables certificate verification, use only with internal hosts
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+}
+
+// Settings of a Client
+type Settings struct {
+	// Destinations are callback URLs by destination name (ex. `receive`)
+	Destinations map[string]string
+	// AllowedHosts are host patterns callback URLs must match, all hosts are allowed when empty.
+	// See MatchHost.
+	AllowedHosts []string
+	// TLS are options by destination name, used for all requests to the destination host
+	TLS map[string]TLSOptions
+}
+
+// Client sends callback requests. Settings can be changed while it's used.
+type Client struct {
+	mutex        sync.RWMutex
+	allowedHosts []string
+	// clients by host of destinations with TLS options
+	clients  map[string]*http.Client
+	fallback *http.Client
+	insecure []string
+}
+
+// NewClient creates a new Client
+func NewClient(settings Settings) (*Client, error) {
+	client := &Client{}
+	client.fallback = &http.Client{Timeout: Timeout, CheckRedirect: client.checkRedirect}
+	err := client.Update(settings)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Update replaces settings of the client. CA bundles are read again. Settings are not changed
+// when it returns an error.
+func (c *Client) Update(settings Settings) error {
+	clients := map[string]*http.Client{}
+	insecure := []string{}
+
+	for name, options := range settings.TLS {
+		rawURL, ok := settings.Destinations[name]
+		if !ok || rawURL == "" {
+			return fmt.Errorf("TLS options of unknown callback destination %s", name)
+		}
+
+		destination, err := url.Parse(rawURL)
+		if err != nil {
+			return errors.Wrap(err, "Cannot parse callback URL of "+name)
+		}
+		if _, exists := clients[destination.Host]; exists {
+			return fmt.Errorf("Callback destination %s shares host %s with another destination with TLS options", name, destination.Host)
+		}
+
+		tlsConfig, err := options.tlsConfig()
+		if err != nil {
+			return errors.Wrap(err, "Invalid TLS options of "+name)
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		clients[destination.Host] = &http.Client{
+			Timeout:       Timeout,
+			Transport:     transport,
+			CheckRedirect: c.checkRedirect,
+		}
+
+		if options.InsecureSkipVerify {
+			insecure = append(insecure, name)
+			logrus.WithFields(logrus.Fields{"destination": name, "host": destination.Host}).
+				Warn("TLS certificate verification of callbacks is DISABLED, callback requests can be intercepted")
+		}
+	}
+	sort.Strings(insecure)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.allowedHosts = settings.AllowedHosts
+	c.clients = clients
+	c.insecure = insecure
+	return nil
+}
+
+// Do sends a callback request if its host is allowed
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	c.mutex.RLock()
+	allowed := HostAllowed(c.allowedHosts, req.URL)
+	client, ok := c.clients[req.URL.Host]
+	c.mutex.RUnlock()
+
+	if !allowed {
+		return nil, errors.Wrap(ErrHostNotAllowed, req.URL.Host)
+	}
+	if !ok {
+		client = c.fallback
+	}
+	return client.Do(req)
+}
+
+// checkRedirect enforces the allowlist on redirects so an allowed host can't redirect callbacks
+// elsewhere
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if !HostAllowed(c.allowedHosts, req.URL) {
+		return errors.Wrap(ErrHostNotAllowed, req.URL.Host)
+	}
+	return nil
+}
+
+// InsecureDestinations returns names of destinations without TLS certificate verification
+func (c *Client) InsecureDestinations() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.insecure
+}
+
+func (o TLSOptions) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.CABundle == "" {
+		return config, nil
+	}
+
+	pem, err := ioutil.ReadFile(o.CABundle)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("No certificates in %s", o.CABundle)
+	}
+	config.RootCAs = pool
+	return config, nil
+}
+
+// HostAllowed returns true if the host of u matches one of patterns or patterns are empty
+func HostAllowed(patterns []string, u *url.URL) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if MatchHost(pattern, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchHost returns true if the host of u matches pattern. Patterns are host names (case
+// insensitive) or IPs optionally followed by a port, `*.` prefix matches all subdomains (not the
+// domain itself). Patterns without a port match any port.
+// Ex. `callbacks.example.com`, `*.internal.example.com:8443`, `10.0.0.5`.
+func MatchHost(pattern string, u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	pattern = strings.ToLower(pattern)
+
+	if patternHost, port, err := net.SplitHostPort(pattern); err == nil {
+		if port != urlPort(u) {
+			return false
+		}
+		pattern = patternHost
+	}
+
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return host == strings.Trim(pattern, "[]")
+}
+
+func urlPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
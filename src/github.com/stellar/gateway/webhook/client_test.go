@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/go/support/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern string
+		url     string
+		match   bool
+	}{
+		{"callbacks.example.com", "https://callbacks.example.com/receive", true},
+		{"callbacks.example.com", "https://CALLBACKS.example.com:8443/receive", true},
+		{"callbacks.example.com", "https://callbacks.example.com.evil.com/receive", false},
+		{"*.example.com", "https://a.b.example.com/receive", true},
+		{"*.example.com", "https://example.com/receive", false},
+		{"*.example.com", "https://evilexample.com/receive", false},
+		{"callbacks.example.com:8443", "https://callbacks.example.com:8443/receive", true},
+		{"callbacks.example.com:8443", "https://callbacks.example.com/receive", false},
+		{"callbacks.example.com:443", "https://callbacks.example.com/receive", true},
+		{"10.0.0.5", "http://10.0.0.5:8000/receive", true},
+		{"[::1]:8000", "http://[::1]:8000/receive", true},
+		{"::1", "http://[::1]/receive", true},
+	}
+
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		require.NoError(t, err)
+		assert.Equal(t, test.match, MatchHost(test.pattern, u), "%s %s", test.pattern, test.url)
+	}
+
+	u, _ := url.Parse("http://anything.com")
+	assert.True(t, HostAllowed(nil, u))
+	assert.False(t, HostAllowed([]string{"example.com"}, u))
+}
+
+func TestClientAllowlist(t *testing.T) {
+	var elsewhereRequests int
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		elsewhereRequests++
+	}))
+	defer elsewhere.Close()
+
+	allowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, elsewhere.URL, http.StatusFound)
+		}
+	}))
+	defer allowed.Close()
+
+	allowedURL, _ := url.Parse(allowed.URL)
+	client, err := NewClient(Settings{AllowedHosts: []string{allowedURL.Host}})
+	require.NoError(t, err)
+
+	do := func(rawURL string) error {
+		req, err := http.NewRequest("POST", rawURL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	assert.NoError(t, do(allowed.URL))
+	assert.Equal(t, ErrHostNotAllowed, errors.Cause(do(elsewhere.URL)))
+
+	err = do(allowed.URL + "/redirect")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrHostNotAllowed.Error())
+	assert.Equal(t, 0, elsewhereRequests)
+
+	// Allowlist can be changed
+	require.NoError(t, client.Update(Settings{}))
+	assert.NoError(t, do(elsewhere.URL))
+	assert.Equal(t, 1, elsewhereRequests)
+}
+
+func TestClientTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "webhook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bundle := filepath.Join(dir, "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(bundle, certificate, 0600))
+
+	do := func(settings Settings) error {
+		client, err := NewClient(settings)
+		require.NoError(t, err)
+		req, err := http.NewRequest("POST", server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	destinations := map[string]string{"receive": server.URL, "error": ""}
+
+	// Self-signed certificate
+	assert.Error(t, do(Settings{Destinations: destinations}))
+
+	assert.NoError(t, do(Settings{
+		Destinations: destinations,
+		TLS:          map[string]TLSOptions{"receive": {CABundle: bundle}},
+	}))
+
+	client, err := NewClient(Settings{
+		Destinations: destinations,
+		TLS:          map[string]TLSOptions{"receive": {InsecureSkipVerify: true}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"receive"}, client.InsecureDestinations())
+
+	_, err = NewClient(Settings{
+		Destinations: destinations,
+		TLS:          map[string]TLSOptions{"error": {InsecureSkipVerify: true}},
+	})
+	assert.Error(t, err, "TLS options of a destination that is not configured")
+
+	_, err = NewClient(Settings{
+		Destinations: destinations,
+		TLS:          map[string]TLSOptions{"receive": {CABundle: filepath.Join(dir, "missing.pem")}},
+	})
+	assert.Error(t, err)
+}